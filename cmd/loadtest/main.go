@@ -0,0 +1,95 @@
+// Command loadtest fires concurrent GET /api/v1/schedule requests at a running
+// server and reports latency percentiles. It exits non-zero if the p95 latency
+// exceeds the target, so it can gate a deployment or a CI job.
+//
+//	go run ./cmd/loadtest -url http://localhost:8080 -c 20 -d 30s -p95 50ms
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+func main() {
+	baseURL := flag.String("url", "http://localhost:8080", "base URL of the server")
+	concurrency := flag.Int("c", 10, "number of concurrent clients")
+	duration := flag.Duration("d", 10*time.Second, "how long to run")
+	years := flag.Int("years", 5, "cycle through the months of this many past years")
+	target := flag.Duration("p95", 50*time.Millisecond, "fail if the 95th percentile latency exceeds this")
+	gzip := flag.Bool("gzip", true, "request gzip-compressed responses")
+	flag.Parse()
+
+	var urls []string
+	now := time.Now()
+	for y := now.Year() - *years + 1; y <= now.Year(); y++ {
+		for m := 1; m <= 12; m++ {
+			urls = append(urls, fmt.Sprintf("%s/api/v1/schedule/%d/%d", *baseURL, y, m))
+		}
+	}
+
+	client := &http.Client{
+		Timeout:   10 * time.Second,
+		Transport: &http.Transport{MaxIdleConnsPerHost: *concurrency, DisableCompression: !*gzip},
+	}
+
+	var (
+		mu        sync.Mutex
+		latencies []time.Duration
+		failures  atomic.Int64
+		wg        sync.WaitGroup
+	)
+	deadline := time.Now().Add(*duration)
+
+	log.Printf("Load testing %s with %d clients for %s", *baseURL, *concurrency, *duration)
+	for worker := 0; worker < *concurrency; worker++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			var local []time.Duration
+			for i := worker; time.Now().Before(deadline); i++ {
+				start := time.Now()
+				resp, err := client.Get(urls[i%len(urls)])
+				if err != nil {
+					failures.Add(1)
+					continue
+				}
+				io.Copy(io.Discard, resp.Body)
+				resp.Body.Close()
+				if resp.StatusCode != http.StatusOK {
+					failures.Add(1)
+					continue
+				}
+				local = append(local, time.Since(start))
+			}
+			mu.Lock()
+			latencies = append(latencies, local...)
+			mu.Unlock()
+		}(worker)
+	}
+	wg.Wait()
+
+	if len(latencies) == 0 {
+		log.Fatalf("No successful requests (%d failures)", failures.Load())
+	}
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	percentile := func(p int) time.Duration { return latencies[(len(latencies)-1)*p/100] }
+
+	fmt.Printf("requests: %d ok, %d failed (%.0f req/s)\n",
+		len(latencies), failures.Load(), float64(len(latencies))/duration.Seconds())
+	fmt.Printf("latency:  p50=%s p95=%s p99=%s max=%s\n",
+		percentile(50), percentile(95), percentile(99), latencies[len(latencies)-1])
+
+	if p95 := percentile(95); p95 > *target {
+		fmt.Printf("FAIL: p95 %s exceeds target %s\n", p95, *target)
+		os.Exit(1)
+	}
+	fmt.Printf("OK: p95 within target %s\n", *target)
+}
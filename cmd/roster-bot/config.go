@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"github.com/korjavin/dutyassistant/internal/service/config"
+	"github.com/korjavin/dutyassistant/internal/store"
+)
+
+// runConfigCommand runs the export-config and import-config commands, which
+// copy the roster's setup from one database to another through a YAML file.
+// It reports whether args was one of them.
+func runConfigCommand(ctx context.Context, args []string, dbPath string) bool {
+	if len(args) == 0 || (args[0] != "export-config" && args[0] != "import-config") {
+		return false
+	}
+	if len(args) > 2 {
+		log.Fatalf("Usage: roster-bot %s [file]", args[0])
+	}
+	s, err := openStore(ctx, dbPath, false)
+	if err != nil {
+		log.Fatalf("Failed to initialize database: %v", err)
+	}
+	if args[0] == "export-config" {
+		err = exportConfig(ctx, s, args[1:])
+	} else {
+		err = importConfig(ctx, s, args[1:])
+	}
+	if err != nil {
+		log.Fatalf("%s failed: %v", args[0], err)
+	}
+	return true
+}
+
+// exportConfig writes the configuration to the file in args, or to stdout.
+func exportConfig(ctx context.Context, s store.Store, args []string) error {
+	cfg, err := config.New(s).Export(ctx)
+	if err != nil {
+		return err
+	}
+	if len(args) == 0 {
+		return config.Write(os.Stdout, cfg)
+	}
+	f, err := os.Create(args[0])
+	if err != nil {
+		return err
+	}
+	if err := config.Write(f, cfg); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	log.Printf("Exported %d users to %s", len(cfg.Users), args[0])
+	return nil
+}
+
+// importConfig imports the configuration in the file in args, or on stdin.
+func importConfig(ctx context.Context, s store.Store, args []string) error {
+	var r io.Reader = os.Stdin
+	if len(args) > 0 {
+		f, err := os.Open(args[0])
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+	cfg, err := config.Parse(r)
+	if err != nil {
+		return err
+	}
+	summary, err := config.New(s).Import(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Created %d users, updated %d, added %d note templates, %d checklist items and %d blackout rules\n",
+		summary.UsersCreated, summary.UsersUpdated, summary.Notes, summary.ChecklistItems, summary.Blackouts)
+	return nil
+}
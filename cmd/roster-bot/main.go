@@ -2,28 +2,61 @@ package main
 
 import (
 	"context"
+	"errors"
+	"flag"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
 	"github.com/robfig/cron/v3"
 
+	"github.com/korjavin/dutyassistant/internal/events"
 	httpserver "github.com/korjavin/dutyassistant/internal/http"
+	"github.com/korjavin/dutyassistant/internal/i18n"
+	"github.com/korjavin/dutyassistant/internal/ical"
+	"github.com/korjavin/dutyassistant/internal/notification"
 	"github.com/korjavin/dutyassistant/internal/scheduler"
+	"github.com/korjavin/dutyassistant/internal/service/badge"
+	"github.com/korjavin/dutyassistant/internal/service/cleanup"
+	"github.com/korjavin/dutyassistant/internal/service/diag"
+	"github.com/korjavin/dutyassistant/internal/service/dutyjobs"
+	"github.com/korjavin/dutyassistant/internal/service/ledger"
+	"github.com/korjavin/dutyassistant/internal/service/maintenance"
+	"github.com/korjavin/dutyassistant/internal/service/reward"
+	"github.com/korjavin/dutyassistant/internal/service/settings"
+	"github.com/korjavin/dutyassistant/internal/store"
+	"github.com/korjavin/dutyassistant/internal/store/memory"
 	"github.com/korjavin/dutyassistant/internal/store/sqlite"
 	"github.com/korjavin/dutyassistant/internal/telegram"
 	"github.com/korjavin/dutyassistant/internal/telegram/handlers"
 )
 
+// version and commit identify the build in logs and /debug. The Docker build
+// sets commit with -ldflags "-X main.commit=..."; otherwise it is taken from
+// the VCS information Go embeds.
+var (
+	version = "v0.1.0"
+	commit  = ""
+)
+
 func main() {
-	log.Println("Roster Bot starting...")
+	ephemeral := flag.Bool("ephemeral", false, "use an in-memory store; all data is lost on exit (for demos)")
+	flag.Parse()
 
 	// Get configuration from environment
 	dbPath := getEnv("DATABASE_PATH", "/app/data/roster.db")
+	if runConfigCommand(context.Background(), flag.Args(), dbPath) || runStatsCommand(context.Background(), flag.Args(), dbPath) ||
+		runMigrateCommand(context.Background(), flag.Args(), dbPath) {
+		return
+	}
+
+	log.Println("Roster Bot starting...")
 	telegramToken := getEnv("TELEGRAM_APITOKEN", "")
 	if telegramToken == "" {
 		log.Fatal("TELEGRAM_APITOKEN environment variable is required")
@@ -34,9 +67,8 @@ func main() {
 	dishGroupID := parseInt64(dishGroupIDStr, 0)
 
 	// Initialize database
-	log.Println("Initializing database at", dbPath)
 	ctx := context.Background()
-	store, err := sqlite.New(ctx, dbPath)
+	store, err := openStore(ctx, dbPath, *ephemeral)
 	if err != nil {
 		log.Fatalf("Failed to initialize database: %v", err)
 	}
@@ -44,6 +76,80 @@ func main() {
 	// Initialize scheduler
 	log.Println("Initializing scheduler...")
 	sched := scheduler.NewScheduler(store)
+	if value := getEnv("ASSIGNMENT_TIME", ""); value != "" {
+		cutoff, err := scheduler.ParseCutoff(value)
+		if err != nil {
+			log.Fatalf("Invalid ASSIGNMENT_TIME: %v", err)
+		}
+		if cutoff >= 20*time.Hour {
+			log.Fatalf("Invalid ASSIGNMENT_TIME %s: the duty must be assigned before the 20:00 reminders", value)
+		}
+		sched.Cutoff = cutoff
+	}
+	if value := getEnv("DAY_ROLLOVER_HOUR", ""); value != "" {
+		hour, err := strconv.Atoi(value)
+		if err != nil || hour < 0 || time.Duration(hour)*time.Hour >= sched.Cutoff {
+			log.Fatalf("Invalid DAY_ROLLOVER_HOUR %q: expected an hour before ASSIGNMENT_TIME", value)
+		}
+		sched.DayRollover = time.Duration(hour) * time.Hour
+	}
+	if value := getEnv("ASSIGN_AHEAD_DAYS", ""); value != "" {
+		days, err := strconv.Atoi(value)
+		if err != nil || days < 0 {
+			log.Fatalf("Invalid ASSIGN_AHEAD_DAYS %q: expected a number of days", value)
+		}
+		sched.Horizon = days
+	}
+	if value := getEnv("RETURN_GRACE_DAYS", ""); value != "" {
+		days, err := strconv.Atoi(value)
+		if err != nil || days < 0 {
+			log.Fatalf("Invalid RETURN_GRACE_DAYS %q: expected a number of days", value)
+		}
+		sched.ReturnGrace = days
+	}
+	if name := getEnv("SHADOW_STRATEGY", ""); name != "" {
+		shadow, err := scheduler.ParseStrategy(name)
+		if err != nil {
+			log.Fatalf("Invalid SHADOW_STRATEGY: %v", err)
+		}
+		sched.Shadow = shadow
+		log.Printf("Shadow strategy %s is compared against round-robin assignments", shadow.Name())
+	}
+	if value := getEnv("WEEKEND_ROTATION", ""); value != "" {
+		if sched.WeekendRotation, err = strconv.ParseBool(value); err != nil {
+			log.Fatalf("Invalid WEEKEND_ROTATION %q: expected true or false", value)
+		}
+	}
+	if value := getEnv("SEASONS", ""); value != "" {
+		seasons, err := scheduler.ParseSeasons(value)
+		if err != nil {
+			log.Fatalf("Invalid SEASONS: %v", err)
+		}
+		for _, season := range seasons {
+			if season.Cutoff != 0 && (season.Cutoff >= 20*time.Hour || season.Cutoff <= sched.DayRollover) {
+				log.Fatalf("Invalid SEASONS: the time of season %q must be after DAY_ROLLOVER_HOUR and before the 20:00 reminders", season.Name)
+			}
+			log.Printf("Season %s", &season)
+		}
+		sched.Seasons = seasons
+		// Users may still have to register, so this only warns
+		if err := sched.CheckSeasons(ctx); err != nil {
+			log.Printf("WARNING: %v", err)
+		}
+	}
+	if value := getEnv("SCHEDULE_CONSTRAINTS", ""); value != "" {
+		constraints, err := scheduler.ParseConstraints(value)
+		if err != nil {
+			log.Fatalf("Invalid SCHEDULE_CONSTRAINTS: %v", err)
+		}
+		sched.Constraints = constraints
+		// Users may still have to register, so this only warns
+		if err := sched.CheckConstraints(ctx); err != nil {
+			log.Printf("WARNING: The schedule constraints can't always be met, they are relaxed on days they leave nobody to pick: %v", err)
+		} else {
+			log.Printf("Enforcing %d schedule constraint(s)", len(constraints))
+		}
+	}
 
 	// Initialize Telegram handlers
 	log.Println("Initializing Telegram handlers...")
@@ -55,6 +161,30 @@ func main() {
 		telegramHandlers = handlers.New(store, sched)
 	}
 
+	// The group chat and admins are kept in the database so /settings can
+	// change them without a restart; the environment only seeds them
+	botSettings := settings.New(store)
+	if err := botSettings.Seed(ctx, dishGroupID, []int64{adminID}); err != nil {
+		log.Fatalf("Failed to seed settings: %v", err)
+	}
+	telegramHandlers.Settings = botSettings
+
+	// Initialize iCal importer for family calendar availability
+	calendarImporter := ical.NewImporter(store, sched, strings.Split(getEnv("ICAL_KEYWORDS", "vacation,trip"), ","))
+	telegramHandlers.Calendars = calendarImporter
+	// Login links point at the web app, so /login needs to know where it is
+	if dnsName := getEnv("DNS_NAME", ""); dnsName != "" {
+		telegramHandlers.WebURL = "https://" + dnsName
+	}
+	telegramHandlers.DayRollover = sched.DayRollover
+	locale := i18n.Default
+	if value := getEnv("LOCALE", ""); value != "" {
+		if locale, err = i18n.Parse(value); err != nil {
+			log.Fatalf("Invalid LOCALE: %v", err)
+		}
+	}
+	telegramHandlers.Locale = locale
+
 	// Initialize and start Telegram bot
 	log.Println("Initializing Telegram bot...")
 	bot, err := telegram.NewBot(telegramToken, telegramHandlers, dishGroupID, adminID)
@@ -62,11 +192,33 @@ func main() {
 		log.Fatalf("Failed to initialize Telegram bot: %v", err)
 	}
 	log.Printf("Access control configured: GroupID=%d, OwnerID=%d", dishGroupID, adminID)
+	telegramHandlers.BotUsername = bot.Username()
+	// Menus are cleaned up once used or abandoned, see /cleanup
+	menuCleanup := cleanup.New(store, bot)
+	if value := getEnv("MENU_CLEANUP_MINUTES", ""); value != "" {
+		minutes, err := strconv.Atoi(value)
+		if err != nil || minutes < 0 {
+			log.Fatalf("Invalid MENU_CLEANUP_MINUTES %q: expected a number of minutes", value)
+		}
+		menuCleanup.Delay = time.Duration(minutes) * time.Minute
+	}
+	telegramHandlers.Cleanup = menuCleanup
 
-	// Start bot in background
-	botCtx, botCancel := context.WithCancel(ctx)
-	defer botCancel()
-	go bot.Start(botCtx)
+	// Fines of payout mode are paid with invoices through a payment provider,
+	// or else in Telegram Stars
+	telegramHandlers.Payments.ProviderToken = getEnv("PAYMENT_PROVIDER_TOKEN", "")
+	if value := getEnv("STARS_PER_UNIT", ""); value != "" {
+		stars, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || stars <= 0 {
+			log.Fatalf("Invalid STARS_PER_UNIT %q: expected a positive number of Stars", value)
+		}
+		telegramHandlers.Payments.StarsPerUnit = stars
+	}
+
+	// Menus and confirmations opened before a restart go on where they were
+	if err := telegramHandlers.RestoreConversations(ctx); err != nil {
+		log.Printf("Failed to restore conversations: %v", err)
+	}
 
 	// Initialize cron scheduler for scheduled jobs (all times in Europe/Berlin)
 	log.Println("Initializing cron scheduler...")
@@ -76,76 +228,364 @@ func main() {
 	}
 	c := cron.New(cron.WithLocation(berlinLoc))
 
-	// Daily at 11:00 AM Berlin - Assign today's duty
-	_, err = c.AddFunc("0 11 * * *", func() {
-		log.Println("[CRON] Running daily duty assignment (11:00 AM Berlin)")
-		duty, err := sched.AssignTodaysDuty(context.Background())
-		if err != nil {
-			log.Printf("[CRON] Error assigning today's duty: %v", err)
-		} else if duty != nil {
-			log.Printf("[CRON] Successfully assigned duty to user %d", duty.UserID)
-
-			// Send notification to assigned user (DM)
-			if duty.User != nil {
-				dmMsg := fmt.Sprintf("🍽️ You've been assigned duty for today (%s)!\n\nAssignment type: %s",
-					duty.DutyDate.Format("2006-01-02"),
-					duty.AssignmentType)
-				if err := bot.SendMessage(duty.User.TelegramUserID, dmMsg); err != nil {
-					log.Printf("[CRON] Failed to send DM to user %d: %v", duty.User.TelegramUserID, err)
-				} else {
-					log.Printf("[CRON] Sent DM notification to user %d", duty.User.TelegramUserID)
-				}
-			}
+	// Notifications honor each user's /notifications preferences
+	notifier := notification.NewNotifier(store, bot, dishGroupID, berlinLoc)
+	notifier.Locale = locale
+	notifier.Settings = botSettings
+	telegramHandlers.Notifier = notifier
+	// The notifier announces schedule changes, whichever interface made them
+	bus := events.NewBus()
+	bus.Subscribe(notifier.HandleEvent)
+	// and tells users who asked with /subscribe about changes to their days
+	bus.Subscribe(notifier.NotifySubscribers)
+	// Rendered /schedule calendars are stale after any change
+	bus.Subscribe(telegramHandlers.InvalidateCalendars)
+	// Completed duties may earn badges, which the notifier announces in turn
+	bus.Subscribe(badge.New(store, bus).HandleEvent)
+	// and every change makes a new version of its month for /history
+	bus.Subscribe(telegramHandlers.History.HandleEvent)
+	// Completed duties earn points in the chore-reward apps of REWARD_WEBHOOKS
+	if path := getEnv("REWARD_WEBHOOKS", ""); path != "" {
+		integrations, err := reward.Load(path)
+		if err != nil {
+			log.Fatalf("Invalid REWARD_WEBHOOKS: %v", err)
+		}
+		bus.Subscribe(reward.New(store, integrations).HandleEvent)
+		log.Printf("Posting completed duties to %d reward app(s)", len(integrations))
+	}
+	sched.Events = bus
+	if err := notifier.RestoreSnoozes(ctx); err != nil {
+		log.Printf("Failed to restore snoozed reminders: %v", err)
+	}
+	if err := notifier.SendPendingMessages(ctx); err != nil {
+		log.Printf("Failed to send messages left over from the last shutdown: %v", err)
+	}
 
-			// Send notification to group chat
-			if dishGroupID != 0 {
-				groupMsg := fmt.Sprintf("🍽️ Duty Assignment for %s\n\n@%s is on duty today!\n\nType: %s",
-					duty.DutyDate.Format("January 2, 2006"),
-					duty.User.FirstName,
-					duty.AssignmentType)
-				if err := bot.SendMessage(dishGroupID, groupMsg); err != nil {
-					log.Printf("[CRON] Failed to send group notification: %v", err)
-				} else {
-					log.Printf("[CRON] Sent group notification to chat %d", dishGroupID)
-				}
-			}
+	// Cron jobs go through the diagnostics service so /debug can show their last result
+	diagnostics := diag.New(store, c)
+	diagnostics.Version, diagnostics.Commit = version, commit
+	diagnostics.BackupDir = getEnv("BACKUP_DIR", "")
+	diagnostics.APIErrors = bot
+	if !*ephemeral {
+		diagnostics.DBPath = dbPath
+	}
+	telegramHandlers.Diag = diagnostics
+
+	// Hourly from 11:00 to 20:00 Berlin - Reminders for users who picked a time after
+	// today's assignment. Reminder times start at 11:00.
+	err = diagnostics.AddJob("0 11-20 * * *", "reminders", func() error {
+		// Until the hour of today's assignment, which sends that hour's reminders itself
+		now := time.Now().In(berlinLoc)
+		if now.Hour() <= int(sched.CutoffOn(scheduler.Today(now, 0)).Hours()) {
+			return nil
 		}
+		err := notifier.SendDailyReminders(context.Background())
+		if err != nil {
+			log.Printf("[CRON] Error sending daily reminders: %v", err)
+		}
+		return err
 	})
 	if err != nil {
-		log.Fatalf("Failed to schedule daily assignment job: %v", err)
+		log.Fatalf("Failed to schedule daily reminders job: %v", err)
 	}
 
-	// Daily at 21:00 PM Berlin - Mark duty as completed
-	_, err = c.AddFunc("0 21 * * *", func() {
-		log.Println("[CRON] Running daily duty completion (21:00 PM Berlin)")
-		err := sched.CompleteTodaysDuty(context.Background())
+	// Daily at 00:00 Berlin - Announce the season that starts today, if any
+	if len(sched.Seasons) > 0 {
+		err = diagnostics.AddJob("0 0 * * *", "season switch", func() error {
+			err := sched.AnnounceSeason(context.Background())
+			if err != nil {
+				log.Printf("[CRON] Error announcing the season: %v", err)
+			}
+			return err
+		})
 		if err != nil {
+			log.Fatalf("Failed to schedule season switch job: %v", err)
+		}
+	}
+
+	// Daily at ASSIGNMENT_TIME (11:00 AM by default) Berlin - Assign today's duty, and at
+	// 21:00 Berlin - Mark it as completed. /settings time moves them; seasons may assign
+	// at other times, each gets a job that only runs on its days.
+	dutyJobs := dutyjobs.New(diagnostics, botSettings, sched, berlinLoc, func() error {
+		return assignTodaysDuty(sched, notifier, botSettings)
+	}, func() error {
+		log.Println("[CRON] Running daily duty completion")
+		err := sched.CompleteTodaysDuty(context.Background())
+		if errors.Is(err, scheduler.ErrUnassignedDay) {
+			log.Println("[CRON] Nobody was on duty today, telling the admins")
+			if err = tellAdmins(context.Background(), botSettings, notifier.ReportUnassignedDay); err != nil {
+				log.Printf("[CRON] %v", err)
+			}
+		} else if err != nil {
 			log.Printf("[CRON] Error completing today's duty: %v", err)
 		} else {
 			log.Printf("[CRON] Successfully marked today's duty as completed")
 		}
+		// Duties are only missed once their day passed, so fines follow the completion
+		if fines, syncErr := telegramHandlers.Ledger.Sync(context.Background()); syncErr != nil {
+			log.Printf("[CRON] Error charging missed duties: %v", syncErr)
+			err = errors.Join(err, syncErr)
+		} else if len(fines) > 0 {
+			log.Printf("[CRON] Added %d ledger entries for missed duties", len(fines))
+			if payout, payoutErr := botSettings.Payout(context.Background()); payoutErr != nil {
+				log.Printf("[CRON] Error telling users about their fines: %v", payoutErr)
+			} else if notifyErr := notifier.NotifyFines(context.Background(), fines, payout.Currency, telegramHandlers.Payments.On()); notifyErr != nil {
+				log.Printf("[CRON] Error telling users about their fines: %v", notifyErr)
+			}
+		}
+		return err
+	})
+	if err := dutyJobs.Refresh(ctx); err != nil {
+		log.Fatalf("Failed to schedule the daily assignment and completion jobs: %v", err)
+	}
+	telegramHandlers.DutyJobs = dutyJobs
+
+	// Start bot in background, once updates find everything they use above and
+	// their changes reach the bus
+	botCtx, botCancel := context.WithCancel(ctx)
+	defer botCancel()
+	go bot.Start(botCtx)
+
+	// Every 15 minutes, and right now - Announce today's duty if the group wasn't told,
+	// e.g. because the bot restarted after the assignment or Telegram was down
+	if err := announceMissed(sched, notifier, berlinLoc); err != nil {
+		log.Printf("Failed to announce today's duty: %v", err)
+	}
+	err = diagnostics.AddJob("*/15 * * * *", "announcement retry", func() error {
+		err := announceMissed(sched, notifier, berlinLoc)
+		if err != nil {
+			log.Printf("[CRON] Error announcing today's duty: %v", err)
+		}
+		return err
+	})
+	if err != nil {
+		log.Fatalf("Failed to schedule announcement retry job: %v", err)
+	}
+
+	// 1st of the month at 10:00 Berlin - Settle last month of payout mode in the group
+	err = diagnostics.AddJob("0 10 1 * *", "monthly settlement", func() error {
+		ctx := context.Background()
+		if _, err := telegramHandlers.Ledger.Sync(ctx); err != nil {
+			log.Printf("[CRON] Error charging missed duties: %v", err)
+			return err
+		}
+		statement, err := telegramHandlers.Ledger.Statement(ctx, time.Now().In(berlinLoc).AddDate(0, 0, -1))
+		if errors.Is(err, ledger.ErrOff) {
+			return nil
+		} else if err != nil {
+			log.Printf("[CRON] Error settling last month: %v", err)
+			return err
+		}
+		if err := notifier.AnnounceStatement(ctx, statement); err != nil {
+			log.Printf("[CRON] Error announcing the settlement: %v", err)
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		log.Fatalf("Failed to schedule monthly settlement job: %v", err)
+	}
+
+	// Sunday at 18:00 Berlin - Ask the group who volunteers for next week, if turned on
+	// with /settings poll
+	err = diagnostics.AddJob("0 18 * * 0", "weekly poll", func() error {
+		err := notifier.SendWeeklyPoll(context.Background())
+		if err != nil {
+			log.Printf("[CRON] Error sending the poll for volunteers: %v", err)
+		}
+		return err
 	})
 	if err != nil {
-		log.Fatalf("Failed to schedule daily completion job: %v", err)
+		log.Fatalf("Failed to schedule weekly poll job: %v", err)
+	}
+
+	// Every minute - Hand the duties nobody covered in time with /cover to the
+	// fallback person of /settings cover
+	err = diagnostics.AddJob("* * * * *", "cover fallback", func() error {
+		ctx := context.Background()
+		expired, err := telegramHandlers.Covers.Expire(ctx)
+		for _, e := range expired {
+			if err := notifier.AnnounceCoverExpired(ctx, e); err != nil {
+				log.Printf("[CRON] Error announcing the fallback of %s: %v", e.Date.Format("2006-01-02"), err)
+			}
+		}
+		if err != nil {
+			log.Printf("[CRON] Error handing over uncovered duties: %v", err)
+		}
+		return err
+	})
+	if err != nil {
+		log.Fatalf("Failed to schedule cover fallback job: %v", err)
 	}
 
 	// Sunday at 21:10 PM Berlin - Send weekly stats
-	_, err = c.AddFunc("10 21 * * 0", func() {
+	err = diagnostics.AddJob("10 21 * * 0", "weekly stats", func() error {
 		log.Println("[CRON] Running weekly stats (Sunday 21:10 PM Berlin)")
-		// TODO: Implement weekly stats gathering and sending to DISH_GROUP
-		log.Printf("[CRON] Weekly stats job executed")
+		err := notifier.SendWeeklyStats(context.Background())
+		if err != nil {
+			log.Printf("[CRON] Error sending weekly stats: %v", err)
+		} else {
+			log.Printf("[CRON] Weekly stats job executed")
+		}
+		return err
 	})
 	if err != nil {
 		log.Fatalf("Failed to schedule weekly stats job: %v", err)
 	}
 
+	// Daily at 03:00 Berlin - Import bin collection days, if a waste calendar is configured
+	if wasteURL := os.Getenv("WASTE_CALENDAR_URL"); wasteURL != "" {
+		wasteImporter := ical.NewWasteImporter(store, wasteURL)
+		syncWaste := func() error {
+			count, err := wasteImporter.Sync(context.Background())
+			if err != nil {
+				log.Printf("[CRON] Error syncing waste calendar: %v", err)
+				return err
+			}
+			log.Printf("[CRON] Imported %d waste collection(s)", count)
+			return nil
+		}
+		syncWaste()
+		if err := diagnostics.AddJob("0 3 * * *", "waste calendar sync", syncWaste); err != nil {
+			log.Fatalf("Failed to schedule waste calendar sync job: %v", err)
+		}
+	}
+
+	// Every 6 hours - Import vacation periods from linked iCal calendars
+	err = diagnostics.AddJob("0 */6 * * *", "calendar sync", func() error {
+		log.Println("[CRON] Running iCal calendar sync")
+		err := calendarImporter.SyncAll(context.Background())
+		if err != nil {
+			log.Printf("[CRON] Error syncing calendars: %v", err)
+		}
+		return err
+	})
+	if err != nil {
+		log.Fatalf("Failed to schedule calendar sync job: %v", err)
+	}
+
+	// Every 5 minutes - Delete finished menus and take the buttons off abandoned ones
+	err = diagnostics.AddJob("*/5 * * * *", "message cleanup", func() error {
+		result, err := menuCleanup.Run(context.Background(), false)
+		if err != nil {
+			log.Printf("[CRON] Error cleaning up menus: %v", err)
+		} else if result.Deleted > 0 || result.Collapsed > 0 {
+			log.Printf("[CRON] Deleted %d finished menus, collapsed %d", result.Deleted, result.Collapsed)
+		}
+		return err
+	})
+	if err != nil {
+		log.Fatalf("Failed to schedule message cleanup job: %v", err)
+	}
+
+	// Daily at 03:30 Berlin - Forget the menus and confirmations that expired
+	err = diagnostics.AddJob("30 3 * * *", "conversation cleanup", func() error {
+		err := store.DeleteExpiredConversationStates(context.Background(), time.Now())
+		if err != nil {
+			log.Printf("[CRON] Error deleting expired conversations: %v", err)
+		}
+		return err
+	})
+	if err != nil {
+		log.Fatalf("Failed to schedule conversation cleanup job: %v", err)
+	}
+
+	// Sundays at 04:00 Berlin - Drop old logs, compact the database and tell
+	// the admins how its size develops
+	if !*ephemeral {
+		dbMaintenance := maintenance.New(store)
+		if value := getEnv("RETENTION_DAYS", ""); value != "" {
+			days, err := strconv.Atoi(value)
+			if err != nil || days < 1 {
+				log.Fatalf("Invalid RETENTION_DAYS %q: expected a number of days", value)
+			}
+			dbMaintenance.Retention = time.Duration(days) * 24 * time.Hour
+		}
+		err = diagnostics.AddJob("0 4 * * 0", "database maintenance", func() error {
+			ctx := context.Background()
+			runs, err := dbMaintenance.Run(ctx)
+			if err != nil {
+				log.Printf("[CRON] Error maintaining the database: %v", err)
+				return err
+			}
+			log.Printf("[CRON] Maintained the database: %d old log rows removed, %d bytes now", runs[0].Deleted, runs[0].SizeAfter)
+			if err := tellAdmins(ctx, botSettings, func(id int64) error { return notifier.ReportMaintenance(id, runs) }); err != nil {
+				log.Printf("[CRON] %v", err)
+			}
+			return nil
+		})
+		if err != nil {
+			log.Fatalf("Failed to schedule database maintenance job: %v", err)
+		}
+	}
+
+	// Daily at 00:05 Berlin - Give held days that weren't confirmed back to the daily assignment
+	err = diagnostics.AddJob("5 0 * * *", "hold release", func() error {
+		released, err := sched.ReleaseExpiredHolds(context.Background())
+		if err != nil {
+			log.Printf("[CRON] Error releasing expired holds: %v", err)
+		}
+		for _, d := range released {
+			log.Printf("[CRON] Released unconfirmed duty on %s", d.DutyDate.Format("2006-01-02"))
+		}
+		return err
+	})
+	if err != nil {
+		log.Fatalf("Failed to schedule hold release job: %v", err)
+	}
+
+	// Hourly at :05 - Version the schedules changed without an event, like
+	// skipped days or replans after queue changes
+	err = diagnostics.AddJob("5 * * * *", "schedule snapshots", func() error {
+		err := telegramHandlers.History.Sync(context.Background())
+		if err != nil {
+			log.Printf("[CRON] Error taking schedule snapshots: %v", err)
+		}
+		return err
+	})
+	if err != nil {
+		log.Fatalf("Failed to schedule schedule snapshots job: %v", err)
+	}
+
+	// Daily at 00:10 Berlin, after the hold release - Plan the next days ahead
+	if sched.Horizon > 0 {
+		err = diagnostics.AddJob("10 0 * * *", "plan ahead", func() error {
+			err := sched.PlanAhead(context.Background())
+			if err != nil {
+				log.Printf("[CRON] Error planning ahead: %v", err)
+			}
+			return err
+		})
+		if err != nil {
+			log.Fatalf("Failed to schedule plan ahead job: %v", err)
+		}
+		log.Printf("Planning duties %d days ahead", sched.Horizon)
+		if err := sched.PlanAhead(ctx); err != nil {
+			log.Printf("Error planning ahead: %v", err)
+		}
+	}
+
+	// The first versions of the months, or the changes made while the bot was down
+	if err := telegramHandlers.History.Sync(ctx); err != nil {
+		log.Printf("Error taking schedule snapshots: %v", err)
+	}
+
 	// Start cron scheduler
 	c.Start()
-	log.Println("Cron scheduler started with 3 jobs")
+	log.Printf("Cron scheduler started with %d jobs", len(c.Entries()))
 
 	// Initialize HTTP server with Gin
 	log.Println("Initializing HTTP server on :8080...")
-	router := httpserver.NewServer(store, telegramToken)
+	minimalPII := false
+	if value := getEnv("MINIMAL_PII", ""); value != "" {
+		if minimalPII, err = strconv.ParseBool(value); err != nil {
+			log.Fatalf("Invalid MINIMAL_PII %q: expected true or false", value)
+		}
+	}
+	if minimalPII {
+		log.Println("Minimal PII mode: the public schedule is anonymized and Telegram IDs are left out of API responses")
+	}
+	router := httpserver.NewServer(store, telegramHandlers.Users, telegramHandlers.Duties, telegramHandlers.Sessions, telegramToken, bot.Username(), getEnv("API_TOKEN", ""), minimalPII)
 
 	// Create HTTP server for graceful shutdown
 	srv := &http.Server{
@@ -161,7 +601,7 @@ func main() {
 		}
 	}()
 
-	log.Println("Roster Bot v0.1.0 initialized successfully")
+	log.Printf("Roster Bot %s initialized successfully", version)
 	log.Println("Press Ctrl+C to shut down")
 
 	// Wait for interrupt signal
@@ -184,12 +624,90 @@ func main() {
 		log.Printf("HTTP server shutdown error: %v", err)
 	}
 
-	// Stop Telegram bot
+	// Stop taking updates and let the handlers already running finish, so
+	// nobody's command is cut off halfway
+	log.Println("Draining Telegram updates...")
+	drainCtx, drainCancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer drainCancel()
+	if err := bot.Shutdown(drainCtx); err != nil {
+		log.Printf("Telegram bot shutdown error: %v", err)
+	}
 	botCancel()
 
+	// Announce schedule changes still waiting in the digest; whatever can't be
+	// sent now is sent on the next start
+	if err := notifier.Close(); err != nil {
+		log.Printf("Failed to send pending notifications: %v", err)
+	}
+
 	log.Println("Roster Bot stopped")
 }
 
+// tellAdmins calls tell with the Telegram user ID of each admin in the
+// settings. Without admins nobody is told, and the day stays as it is.
+func tellAdmins(ctx context.Context, botSettings *settings.Service, tell func(telegramUserID int64) error) error {
+	admins, err := botSettings.AdminIDs(ctx)
+	if err != nil {
+		return err
+	}
+	if len(admins) == 0 {
+		log.Println("[CRON] No admins are configured, nobody is told")
+	}
+	var errs []error
+	for _, id := range admins {
+		errs = append(errs, tell(id))
+	}
+	return errors.Join(errs...)
+}
+
+// openStore opens the SQLite database at dbPath, or an in-memory store in ephemeral mode.
+func openStore(ctx context.Context, dbPath string, ephemeral bool) (store.Store, error) {
+	if ephemeral {
+		log.Println("Running in ephemeral mode: using in-memory store, data will not be persisted")
+		return memory.New(), nil
+	}
+	log.Println("Initializing database at", dbPath)
+	return sqlite.New(ctx, dbPath)
+}
+
+// assignTodaysDuty runs the daily assignment and sends the reminders of its
+// hour. If nobody is available, the admins are asked what happens with the day.
+func assignTodaysDuty(sched *scheduler.Scheduler, notifier *notification.Notifier, botSettings *settings.Service) error {
+	log.Println("[CRON] Running daily duty assignment")
+	ctx := context.Background()
+	duty, err := sched.AssignTodaysDuty(ctx, false)
+	if errors.Is(err, scheduler.ErrNoAvailableUsers) {
+		log.Println("[CRON] Nobody is available for today's duty, asking the admins")
+		if err = tellAdmins(ctx, botSettings, notifier.RequestTakeover); err != nil {
+			log.Printf("[CRON] %v", err)
+		}
+	} else if err != nil {
+		log.Printf("[CRON] Error assigning today's duty: %v", err)
+	} else if duty != nil {
+		log.Printf("[CRON] Successfully assigned duty to user %d", duty.UserID)
+	} else {
+		log.Println("[CRON] Today is marked as a skip day, no duty assigned")
+	}
+
+	// Reminders for users who want them at this hour
+	reminderErr := notifier.SendDailyReminders(ctx)
+	if reminderErr != nil {
+		log.Printf("[CRON] Error sending daily reminders: %v", reminderErr)
+	}
+	return errors.Join(err, reminderErr)
+}
+
+// announceMissed announces today's duty to the group if it wasn't, once it's
+// past the time of the daily assignment.
+func announceMissed(sched *scheduler.Scheduler, notifier *notification.Notifier, loc *time.Location) error {
+	now := time.Now().In(loc)
+	sinceMidnight := time.Duration(now.Hour())*time.Hour + time.Duration(now.Minute())*time.Minute
+	if sinceMidnight < sched.CutoffOn(scheduler.Today(now, 0)) {
+		return nil
+	}
+	return notifier.AnnounceMissed(context.Background())
+}
+
 func getEnv(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
 		return value
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/korjavin/dutyassistant/internal/service/transfer"
+	"github.com/korjavin/dutyassistant/internal/store"
+	"github.com/korjavin/dutyassistant/internal/store/sqlite"
+)
+
+// backends opens the store of a backend by its name, with a DSN like the
+// path of the SQLite database. A backend like postgres is added here once
+// there is a store for it.
+var backends = map[string]func(ctx context.Context, dsn string) (store.Store, error){
+	"sqlite": func(ctx context.Context, dsn string) (store.Store, error) { return sqlite.New(ctx, dsn) },
+}
+
+// runMigrateCommand runs the migrate-data command, which copies the roster
+// from one store backend into an empty one and checks the copy against the
+// source. It reports whether args was it.
+func runMigrateCommand(ctx context.Context, args []string, dbPath string) bool {
+	if len(args) == 0 || args[0] != "migrate-data" {
+		return false
+	}
+	usage := fmt.Sprintf("Usage: roster-bot %s --from <backend> --to <backend> [--from-dsn <dsn>] --to-dsn <dsn>", args[0])
+	flags := flag.NewFlagSet(args[0], flag.ContinueOnError)
+	fromName := flags.String("from", "sqlite", "backend to copy from")
+	toName := flags.String("to", "", "backend to copy to")
+	fromDSN := flags.String("from-dsn", dbPath, "database to copy from, DATABASE_PATH by default")
+	toDSN := flags.String("to-dsn", "", "database to copy to, which must be empty")
+	if err := flags.Parse(args[1:]); err != nil || flags.NArg() > 0 || *toName == "" || *toDSN == "" {
+		log.Fatal(usage)
+	}
+
+	from := openBackend(ctx, *fromName, *fromDSN)
+	to := openBackend(ctx, *toName, *toDSN)
+	log.Printf("Copying the roster from %s %s to %s %s", *fromName, *fromDSN, *toName, *toDSN)
+	if err := transfer.Copy(ctx, from, to); err != nil {
+		log.Fatalf("%s failed: %v", args[0], err)
+	}
+	tables, err := transfer.Verify(ctx, from, to)
+	for _, t := range tables {
+		fmt.Fprintf(os.Stdout, "%-17s %5d rows  sha256:%s\n", t.Name, t.Rows, t.Checksum)
+	}
+	if err != nil {
+		log.Fatalf("%s copied the roster, but checking it failed: %v", args[0], err)
+	}
+	log.Println("Copied the roster, the checksums of both stores match")
+	return true
+}
+
+// openBackend opens the store of the backend name, or exits if there is no
+// such backend.
+func openBackend(ctx context.Context, name, dsn string) store.Store {
+	open, ok := backends[name]
+	if !ok {
+		names := make([]string, 0, len(backends))
+		for n := range backends {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		log.Fatalf("Unknown store backend %q, this build has: %s", name, strings.Join(names, ", "))
+	}
+	s, err := open(ctx, dsn)
+	if err != nil {
+		log.Fatalf("Failed to open the %s store: %v", name, err)
+	}
+	return s
+}
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"context"
+	"log"
+)
+
+// runStatsCommand runs the rebuild-stats command, which counts the users' kept
+// stats again from their duties should they ever be off. It reports whether
+// args was it.
+func runStatsCommand(ctx context.Context, args []string, dbPath string) bool {
+	if len(args) == 0 || args[0] != "rebuild-stats" {
+		return false
+	}
+	if len(args) > 1 {
+		log.Fatalf("Usage: roster-bot %s", args[0])
+	}
+	s, err := openStore(ctx, dbPath, false)
+	if err != nil {
+		log.Fatalf("Failed to initialize database: %v", err)
+	}
+	if err := s.RebuildUserStats(ctx); err != nil {
+		log.Fatalf("%s failed: %v", args[0], err)
+	}
+	log.Println("Rebuilt the user stats")
+	return true
+}
@@ -0,0 +1,132 @@
+// Package events is an in-process bus for domain events. The scheduler
+// publishes what happened to the schedule, and everything that reacts to it,
+// such as the group announcements, subscribes instead of being called inline.
+package events
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/korjavin/dutyassistant/internal/store"
+)
+
+// Event is something that happened to the schedule.
+type Event interface {
+	// Name identifies the kind of event, e.g. in logs.
+	Name() string
+}
+
+// DutyAssigned is published when a day gets a duty, by the daily assignment
+// or by hand.
+type DutyAssigned struct {
+	Duty *store.Duty // User may be nil if the publisher didn't load it
+}
+
+// DutyCompleted is published when the duty of a day is marked as done.
+type DutyCompleted struct {
+	Date   time.Time
+	UserID int64
+}
+
+// DutyReassigned is published when an existing duty is handed over to
+// someone else.
+type DutyReassigned struct {
+	Duty           *store.Duty // User may be nil if the publisher didn't load it
+	PreviousUserID int64
+}
+
+// DutyReleased is published when a held duty wasn't confirmed in time and its
+// day was given back to the daily assignment.
+type DutyReleased struct {
+	Duty *store.Duty // The removed duty, with its user
+}
+
+// DutyRemoved is published when the duty of a day is removed, by hand or
+// because the day is skipped or blacked out.
+type DutyRemoved struct {
+	Duty *store.Duty      // The removed duty, with its user
+	Skip store.SkipReason // Why the day is skipped, empty if the duty was just removed
+}
+
+// DutyBackfilled is published when an admin recorded who actually did the
+// duty on a past day.
+type DutyBackfilled struct {
+	Duty *store.Duty
+}
+
+// UserWentOffDuty is published when a user's off-duty period is set.
+type UserWentOffDuty struct {
+	UserID     int64
+	Start, End time.Time
+}
+
+// MonthPublished is published when an admin published the plan of a month.
+// Its duties are announced with it instead of one by one.
+type MonthPublished struct {
+	Month  time.Time     // First day of the month
+	Duties []*store.Duty // The newly published duties, by date
+}
+
+// BadgeAwarded is published when a user earns a badge.
+type BadgeAwarded struct {
+	Badge *store.Badge
+}
+
+// SeasonStarted is published on the first day of a season, or of the rest of
+// the year after one, when Season is empty.
+type SeasonStarted struct {
+	Season          string
+	Previous        string        // The season that ended, empty for none
+	AssignmentTime  time.Duration // Berlin time of day, as the time since midnight
+	WeekendRotation bool
+	Users           []*store.User // The users on the roster, nil for everyone
+}
+
+func (DutyAssigned) Name() string    { return "duty_assigned" }
+func (DutyCompleted) Name() string   { return "duty_completed" }
+func (DutyReassigned) Name() string  { return "duty_reassigned" }
+func (DutyReleased) Name() string    { return "duty_released" }
+func (DutyRemoved) Name() string     { return "duty_removed" }
+func (DutyBackfilled) Name() string  { return "duty_backfilled" }
+func (UserWentOffDuty) Name() string { return "user_went_off_duty" }
+func (BadgeAwarded) Name() string    { return "badge_awarded" }
+func (MonthPublished) Name() string  { return "month_published" }
+func (SeasonStarted) Name() string   { return "season_started" }
+
+// Handler reacts to an event. Handlers are called synchronously in the order
+// they subscribed, so they should hand off slow work.
+type Handler func(ctx context.Context, e Event)
+
+// Bus delivers published events to its subscribers. A nil *Bus is valid and
+// drops every event.
+type Bus struct {
+	mu       sync.RWMutex
+	handlers []Handler
+}
+
+// NewBus creates a bus without subscribers.
+func NewBus() *Bus {
+	return &Bus{}
+}
+
+// Subscribe registers h for all events published from now on.
+func (b *Bus) Subscribe(h Handler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.handlers = append(b.handlers, h)
+}
+
+// Publish delivers e to every subscriber.
+func (b *Bus) Publish(ctx context.Context, e Event) {
+	if b == nil {
+		return
+	}
+	b.mu.RLock()
+	handlers := b.handlers
+	b.mu.RUnlock()
+
+	for _, h := range handlers {
+		h(ctx, e)
+	}
+}
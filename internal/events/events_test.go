@@ -0,0 +1,25 @@
+package events
+
+import (
+	"context"
+	"testing"
+)
+
+func TestBus_DeliversToAllSubscribersInOrder(t *testing.T) {
+	bus := NewBus()
+	var got []string
+	bus.Subscribe(func(_ context.Context, e Event) { got = append(got, "first:"+e.Name()) })
+	bus.Subscribe(func(_ context.Context, e Event) { got = append(got, "second:"+e.Name()) })
+
+	bus.Publish(context.Background(), DutyCompleted{})
+
+	want := []string{"first:duty_completed", "second:duty_completed"}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+}
+
+func TestBus_NilDropsEvents(t *testing.T) {
+	var bus *Bus
+	bus.Publish(context.Background(), DutyCompleted{}) // must not panic
+}
@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/korjavin/dutyassistant/internal/store"
+)
+
+// Ask handles the GET /api/v1/ask?q=... endpoint.
+// It answers simple questions such as "who is on duty tomorrow" with a short
+// sentence that a smart-speaker routine can read out loud.
+func Ask(s store.DutyStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		berlinLoc, _ := time.LoadLocation("Europe/Berlin")
+		now := time.Now().In(berlinLoc)
+		today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+
+		date, phrase := parseAskDate(c.Query("q"), today)
+
+		duty, err := s.GetDutyByDate(c.Request.Context(), date)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve duty"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"date":   date.Format("2006-01-02"),
+			"answer": askAnswer(duty, phrase, date.Before(today)),
+		})
+	}
+}
+
+// askAnswer builds the spoken sentence for a duty lookup.
+func askAnswer(duty *store.Duty, phrase string, past bool) string {
+	if duty == nil || duty.User == nil {
+		if past {
+			return fmt.Sprintf("Nobody was on duty %s.", phrase)
+		}
+		return fmt.Sprintf("Nobody is assigned to duty %s yet.", phrase)
+	}
+	if past {
+		return fmt.Sprintf("%s was on duty %s.", duty.User.GroupName(), phrase)
+	}
+	return fmt.Sprintf("%s is on duty %s.", duty.User.GroupName(), phrase)
+}
+
+// parseAskDate extracts the day a question refers to. It understands
+// "today", "tomorrow", "yesterday", weekday names (the next such day,
+// including today) and ISO dates. Anything else defaults to today.
+func parseAskDate(q string, today time.Time) (time.Time, string) {
+	words := strings.FieldsFunc(strings.ToLower(q), func(r rune) bool {
+		return r == ' ' || r == '+' || r == '?' || r == ',' || r == '.'
+	})
+
+	for _, w := range words {
+		switch w {
+		case "today", "tonight":
+			return today, "today"
+		case "tomorrow":
+			return today.AddDate(0, 0, 1), "tomorrow"
+		case "yesterday":
+			return today.AddDate(0, 0, -1), "yesterday"
+		}
+
+		if d, err := time.Parse("2006-01-02", w); err == nil {
+			return d, "on " + d.Format("Monday, January 2")
+		}
+
+		for wd := time.Sunday; wd <= time.Saturday; wd++ {
+			if w == strings.ToLower(wd.String()) {
+				offset := (int(wd) - int(today.Weekday()) + 7) % 7
+				return today.AddDate(0, 0, offset), "on " + wd.String()
+			}
+		}
+	}
+	return today, "today"
+}
@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/korjavin/dutyassistant/internal/store"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseAskDate(t *testing.T) {
+	// Wednesday
+	today := time.Date(2025, 7, 16, 0, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		q          string
+		wantDate   time.Time
+		wantPhrase string
+	}{
+		{"who is on duty", today, "today"},
+		{"who+is+on+duty+tomorrow", today.AddDate(0, 0, 1), "tomorrow"},
+		{"Who was on duty yesterday?", today.AddDate(0, 0, -1), "yesterday"},
+		{"who is on duty on friday", today.AddDate(0, 0, 2), "on Friday"},
+		{"who is on duty wednesday", today, "on Wednesday"},
+		{"who is on duty monday", today.AddDate(0, 0, 5), "on Monday"},
+		{"duty 2025-08-01", time.Date(2025, 8, 1, 0, 0, 0, 0, time.UTC), "on Friday, August 1"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.q, func(t *testing.T) {
+			date, phrase := parseAskDate(tc.q, today)
+			assert.Equal(t, tc.wantDate, date)
+			assert.Equal(t, tc.wantPhrase, phrase)
+		})
+	}
+}
+
+func TestAskAnswer(t *testing.T) {
+	duty := &store.Duty{User: &store.User{FirstName: "Alice"}}
+
+	assert.Equal(t, "Alice is on duty tomorrow.", askAnswer(duty, "tomorrow", false))
+	assert.Equal(t, "Alice was on duty yesterday.", askAnswer(duty, "yesterday", true))
+	assert.Equal(t, "Nobody is assigned to duty today yet.", askAnswer(nil, "today", false))
+
+	// Voice assistants speak in the room, so they name users as the group does
+	duty.User.Privacy = store.PrivacyInitials
+	assert.Equal(t, "A. is on duty tomorrow.", askAnswer(duty, "tomorrow", false))
+}
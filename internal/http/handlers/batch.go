@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/korjavin/dutyassistant/internal/scheduler"
+	"github.com/korjavin/dutyassistant/internal/service/duty"
+)
+
+// maxBatchOperations keeps a batch within a month of changes or so.
+const maxBatchOperations = 100
+
+// batchResult reports the outcome of one operation of a batch.
+type batchResult struct {
+	Index  int    `json:"index"`
+	Status string `json:"status"` // ok, or failed
+	Error  string `json:"error,omitempty"`
+	UserID int64  `json:"user_id,omitempty"` // Who is on duty after the operation
+}
+
+// AdminBatchDuties handles the POST /api/v1/duties/batch endpoint. It applies
+// a list of create, modify and delete operations in order, all of them or
+// none, e.g. for editing a whole month in the web calendar before saving.
+// Every operation is reported; if one failed, nothing was changed and the
+// response has the status of the first failure.
+func AdminBatchDuties(duties *duty.Service) gin.HandlerFunc {
+	type operation struct {
+		Op     string `json:"op" binding:"required"`   // create, modify or delete
+		Date   string `json:"date" binding:"required"` // YYYY-MM-DD
+		UserID int64  `json:"user_id"`                 // create and modify
+		Mode   string `json:"mode"`                    // modify: keep (default) or refund
+	}
+	type request struct {
+		Operations []operation `json:"operations" binding:"required,dive"`
+	}
+
+	return func(c *gin.Context) {
+		var req request
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if len(req.Operations) > maxBatchOperations {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("At most %d operations per batch", maxBatchOperations)})
+			return
+		}
+
+		ops := make([]duty.BatchOp, len(req.Operations))
+		for i, o := range req.Operations {
+			op, err := parseBatchOp(o.Op, o.Date, o.UserID, o.Mode)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Operation %d: %v", i, err)})
+				return
+			}
+			ops[i] = op
+		}
+
+		results, err := duties.Batch(c.Request.Context(), ops)
+		if err != nil && !errors.Is(err, duty.ErrBatchFailed) {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to apply the batch"})
+			return
+		}
+
+		status := http.StatusOK
+		resp := make([]batchResult, len(results))
+		for i, r := range results {
+			resp[i] = batchResult{Index: i, Status: "ok"}
+			if r.Duty != nil {
+				resp[i].UserID = r.Duty.UserID
+			}
+			if r.Err == nil {
+				continue
+			}
+			resp[i].Status = "failed"
+			resp[i].Error = "Failed to apply the operation"
+			if code := dutyErrorStatus(r.Err); code != http.StatusInternalServerError {
+				resp[i].Error = r.Err.Error()
+			}
+			if status == http.StatusOK {
+				status = dutyErrorStatus(r.Err)
+			}
+		}
+		c.JSON(status, gin.H{"applied": err == nil, "results": resp})
+	}
+}
+
+// parseBatchOp checks an operation of a batch request.
+func parseBatchOp(action, date string, userID int64, mode string) (duty.BatchOp, error) {
+	op := duty.BatchOp{Action: duty.BatchAction(action), UserID: userID}
+	var err error
+	if op.Date, err = time.Parse("2006-01-02", date); err != nil {
+		return op, errors.New("invalid date format, expected YYYY-MM-DD")
+	}
+	switch op.Action {
+	case duty.BatchCreate, duty.BatchModify:
+		if userID == 0 {
+			return op, fmt.Errorf("%s needs a user_id", action)
+		}
+	case duty.BatchDelete:
+	default:
+		return op, fmt.Errorf("unknown op %q, expected create, modify or delete", action)
+	}
+	if op.Action == duty.BatchModify {
+		if op.Mode, err = scheduler.ParseChangeMode(mode); err != nil {
+			return op, err
+		}
+	}
+	return op, nil
+}
@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/korjavin/dutyassistant/internal/http/middleware"
+	"github.com/korjavin/dutyassistant/internal/service/config"
+)
+
+// AdminExportConfig handles the GET /api/v1/config endpoint.
+// It returns the roster's setup as a YAML file, see package config. The file
+// is made of Telegram IDs, so it is refused in minimal PII mode.
+func AdminExportConfig(configs *config.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if middleware.IsMinimalPII(c.Request.Context()) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Exporting the configuration is disabled in minimal PII mode"})
+			return
+		}
+		cfg, err := configs.Export(c.Request.Context())
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to export the configuration"})
+			return
+		}
+		var file bytes.Buffer
+		if err := config.Write(&file, cfg); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to export the configuration"})
+			return
+		}
+		c.Header("Content-Disposition", `attachment; filename="roster.yaml"`)
+		c.Data(http.StatusOK, "application/yaml", file.Bytes())
+	}
+}
+
+// AdminImportConfig handles the PUT /api/v1/config endpoint.
+// The body is a YAML file as exported by GET /api/v1/config. It responds
+// with what the import changed.
+func AdminImportConfig(configs *config.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		cfg, err := config.Parse(c.Request.Body)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		summary, err := configs.Import(c.Request.Context(), cfg)
+		switch {
+		case errors.Is(err, config.ErrInvalid), errors.Is(err, config.ErrVersion):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		case err != nil:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to import the configuration"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"users_created":   summary.UsersCreated,
+			"users_updated":   summary.UsersUpdated,
+			"notes":           summary.Notes,
+			"checklist_items": summary.ChecklistItems,
+			"blackouts":       summary.Blackouts,
+		})
+	}
+}
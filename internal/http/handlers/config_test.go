@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/korjavin/dutyassistant/internal/service/config"
+	"github.com/korjavin/dutyassistant/internal/store/memory"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAdminConfig(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	configs := config.New(memory.New())
+	router.GET("/api/v1/config", AdminExportConfig(configs))
+	router.PUT("/api/v1/config", AdminImportConfig(configs))
+
+	put := func(body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/api/v1/config", strings.NewReader(body)))
+		return w
+	}
+
+	w := put("version: 1\nusers:\n  - telegram_id: 7\n    name: Ann\n    pool: sometimes\n")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = put("version: 1\nusers:\n  - telegram_id: 7\n    name: Ann\n    pool: all\n    active: true\n")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"users_created": 1, "users_updated": 0, "notes": 0, "checklist_items": 0, "blackouts": 0}`, w.Body.String())
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/config", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/yaml", w.Header().Get("Content-Type"))
+	assert.Contains(t, w.Body.String(), "name: Ann")
+}
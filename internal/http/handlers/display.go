@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/korjavin/dutyassistant/internal/http/middleware"
+	"github.com/korjavin/dutyassistant/internal/service/settings"
+	"github.com/korjavin/dutyassistant/internal/store"
+)
+
+// GetDisplay handles the GET /api/v1/display endpoint. It tells the web app
+// how to draw the calendar, as set with /display: the way the signed-in user
+// set it in their private chat with the bot, else the group chat's.
+func GetDisplay(s store.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+		var chats []int64
+		// A private chat has the ID of its user
+		if u, ok := ctx.Value(middleware.UserKey).(*store.User); ok && u != nil {
+			chats = append(chats, u.TelegramUserID)
+		}
+		group, err := settings.New(s).GroupChatID(ctx)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get the display"})
+			return
+		}
+		if group != 0 {
+			chats = append(chats, group)
+		}
+
+		var display store.ChatDisplay
+		for _, chatID := range chats {
+			d, err := s.GetChatDisplay(ctx, chatID)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get the display"})
+				return
+			}
+			if d != nil {
+				display = *d
+				break
+			}
+		}
+		c.JSON(http.StatusOK, gin.H{"week_numbers": display.WeekNumbers, "adjacent_days": display.AdjacentDays})
+	}
+}
@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/korjavin/dutyassistant/internal/http/middleware"
+	"github.com/korjavin/dutyassistant/internal/service/settings"
+	"github.com/korjavin/dutyassistant/internal/store"
+	"github.com/korjavin/dutyassistant/internal/store/memory"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetDisplay(t *testing.T) {
+	ctx := context.Background()
+	s := memory.New()
+	settings.New(s).SetGroupChatID(ctx, -100)
+	s.SetChatDisplay(ctx, -100, store.ChatDisplay{WeekNumbers: true})
+	s.SetChatDisplay(ctx, 2, store.ChatDisplay{AdjacentDays: true})
+
+	get := func(viewer *store.User) string {
+		gin.SetMode(gin.TestMode)
+		router := gin.New()
+		router.GET("/display", func(c *gin.Context) {
+			if viewer != nil {
+				c.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), middleware.UserKey, viewer))
+			}
+		}, GetDisplay(s))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/display", nil))
+		assert.Equal(t, http.StatusOK, w.Code)
+		return w.Body.String()
+	}
+
+	// Anonymous viewers and users who set nothing privately see the group's
+	assert.JSONEq(t, `{"week_numbers": true, "adjacent_days": false}`, get(nil))
+	assert.JSONEq(t, `{"week_numbers": true, "adjacent_days": false}`, get(&store.User{ID: 1, TelegramUserID: 1}))
+	assert.JSONEq(t, `{"week_numbers": false, "adjacent_days": true}`, get(&store.User{ID: 2, TelegramUserID: 2}))
+}
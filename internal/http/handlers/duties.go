@@ -1,17 +1,48 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/korjavin/dutyassistant/internal/http/middleware"
+	"github.com/korjavin/dutyassistant/internal/scheduler"
+	"github.com/korjavin/dutyassistant/internal/service/duty"
+	"github.com/korjavin/dutyassistant/internal/service/user"
 	"github.com/korjavin/dutyassistant/internal/store"
 )
 
+// dutyErrorStatus maps an error from the duty service to an HTTP status code.
+func dutyErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, user.ErrNotFound), errors.Is(err, scheduler.ErrNoDuty):
+		return http.StatusNotFound
+	case errors.Is(err, scheduler.ErrDutyTaken), errors.Is(err, scheduler.ErrDaySkipped), errors.Is(err, scheduler.ErrNoAvailableUsers),
+		errors.Is(err, duty.ErrInactiveUser), errors.Is(err, duty.ErrPendingUser), errors.Is(err, duty.ErrOffDuty):
+		return http.StatusConflict
+	case errors.Is(err, scheduler.ErrPastDate), errors.Is(err, scheduler.ErrNotPastDate), errors.Is(err, scheduler.ErrFutureDate), errors.Is(err, scheduler.ErrInvalidHold),
+		errors.Is(err, duty.ErrDateTooOld):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// respondDutyError writes err as a JSON error. Internal errors are not
+// shown to the client.
+func respondDutyError(c *gin.Context, err error, fallback string) {
+	status := dutyErrorStatus(err)
+	if status == http.StatusInternalServerError {
+		c.JSON(status, gin.H{"error": fallback})
+		return
+	}
+	c.JSON(status, gin.H{"error": err.Error()})
+}
+
 // VolunteerForDuty handles the POST /api/v1/duties/volunteer endpoint.
-// It allows an authenticated user to volunteer for duty on a specific date.
-func VolunteerForDuty(s store.Store) gin.HandlerFunc {
+// It allows an authenticated user to volunteer for duty on a free date.
+func VolunteerForDuty(duties *duty.Service) gin.HandlerFunc {
 	type request struct {
 		Date string `json:"date" binding:"required"` // YYYY-MM-DD
 	}
@@ -31,28 +62,14 @@ func VolunteerForDuty(s store.Store) gin.HandlerFunc {
 		}
 
 		// Retrieve the authenticated user from the context.
-		user, ok := c.Request.Context().Value(middleware.UserKey).(*store.User)
-		if !ok || user == nil {
+		u, ok := c.Request.Context().Value(middleware.UserKey).(*store.User)
+		if !ok || u == nil {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication failed"})
 			return
 		}
 
-		// Create the new duty record.
-		// The business logic for checking conflicts (e.g., against admin assignments)
-		// should ideally be handled within the store or a dedicated service layer.
-		// For this implementation, we assume a simple create/update.
-		newDuty := &store.Duty{
-			UserID:         user.ID,
-			DutyDate:       dutyDate,
-			AssignmentType: store.AssignmentTypeVoluntary,
-			CreatedAt:      time.Now().UTC(),
-		}
-
-		// Here, we might check if a duty already exists and update it, or just create.
-		// A simple approach is to try deleting any existing duty for that date first.
-		_ = s.DeleteDuty(c.Request.Context(), dutyDate)
-		if err := s.CreateDuty(c.Request.Context(), newDuty); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to assign volunteer duty"})
+		if _, err := duties.Volunteer(c.Request.Context(), dutyDate, u); err != nil {
+			respondDutyError(c, err, "Failed to assign volunteer duty")
 			return
 		}
 
@@ -61,11 +78,14 @@ func VolunteerForDuty(s store.Store) gin.HandlerFunc {
 }
 
 // AdminAssignDuty handles the POST /api/v1/duties endpoint.
-// It allows an administrator to assign any user to duty on a specific date.
-func AdminAssignDuty(s store.Store) gin.HandlerFunc {
+// It allows an administrator to assign any user to duty on a free date. With
+// hold_until, the date is given back to the daily assignment unless the duty
+// is confirmed by the end of that day.
+func AdminAssignDuty(duties *duty.Service) gin.HandlerFunc {
 	type request struct {
-		UserID int64  `json:"user_id" binding:"required"`
-		Date   string `json:"date" binding:"required"` // YYYY-MM-DD
+		UserID    int64  `json:"user_id" binding:"required"`
+		Date      string `json:"date" binding:"required"` // YYYY-MM-DD
+		HoldUntil string `json:"hold_until"`              // YYYY-MM-DD, optional
 	}
 
 	return func(c *gin.Context) {
@@ -81,17 +101,19 @@ func AdminAssignDuty(s store.Store) gin.HandlerFunc {
 			return
 		}
 
-		newDuty := &store.Duty{
-			UserID:         req.UserID,
-			DutyDate:       dutyDate,
-			AssignmentType: store.AssignmentTypeAdmin,
-			CreatedAt:      time.Now().UTC(),
+		if req.HoldUntil == "" {
+			_, err = duties.Assign(c.Request.Context(), dutyDate, req.UserID, store.AssignmentTypeAdmin)
+		} else {
+			var until time.Time
+			until, err = time.Parse("2006-01-02", req.HoldUntil)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid hold_until format, expected YYYY-MM-DD"})
+				return
+			}
+			_, err = duties.AssignHeld(c.Request.Context(), dutyDate, req.UserID, until)
 		}
-
-		// Admin assignment overwrites any existing assignment.
-		_ = s.DeleteDuty(c.Request.Context(), dutyDate)
-		if err := s.CreateDuty(c.Request.Context(), newDuty); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to assign duty"})
+		if err != nil {
+			respondDutyError(c, err, "Failed to assign duty")
 			return
 		}
 
@@ -99,16 +121,48 @@ func AdminAssignDuty(s store.Store) gin.HandlerFunc {
 	}
 }
 
+// AdminAssignToday handles the POST /api/v1/duties/today/assign endpoint.
+// It runs today's daily assignment right away, even before the cutoff, and
+// returns the duty. If today is already assigned, that duty is returned as it
+// is; if it is a skip day, the response is 204 No Content.
+func AdminAssignToday(duties *duty.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		assigned, err := duties.AssignToday(c.Request.Context())
+		if err != nil {
+			respondDutyError(c, err, "Failed to assign today's duty")
+			return
+		}
+		if assigned == nil {
+			c.Status(http.StatusNoContent)
+			return
+		}
+
+		resp := weekDay{
+			Date:      assigned.DutyDate.Format("2006-01-02"),
+			Weekday:   assigned.DutyDate.Weekday().String(),
+			UserID:    assigned.UserID,
+			Completed: assigned.CompletedAt != nil,
+		}
+		if assigned.User != nil {
+			resp.UserName = assigned.User.FirstName
+		}
+		c.JSON(http.StatusOK, resp)
+	}
+}
+
 // AdminModifyDuty handles the PUT /api/v1/duties/:date endpoint.
 // It allows an administrator to change the user assigned to a duty on a specific date.
-func AdminModifyDuty(s store.Store) gin.HandlerFunc {
+// An optional mode of "refund" moves the queue day the duty used up from the
+// previous user to the new one.
+func AdminModifyDuty(duties *duty.Service) gin.HandlerFunc {
 	type request struct {
-		UserID int64 `json:"user_id" binding:"required"`
+		UserID int64  `json:"user_id" binding:"required"`
+		Mode   string `json:"mode"` // keep (default) or refund
 	}
 
 	return func(c *gin.Context) {
-		date := c.Param("date")
-		if _, err := time.Parse("2006-01-02", date); err != nil {
+		dutyDate, err := time.Parse("2006-01-02", c.Param("date"))
+		if err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date format in URL, expected YYYY-MM-DD"})
 			return
 		}
@@ -119,27 +173,33 @@ func AdminModifyDuty(s store.Store) gin.HandlerFunc {
 			return
 		}
 
-		// Parse the date
-		dutyDate, err := time.Parse("2006-01-02", date)
+		mode, err := scheduler.ParseChangeMode(req.Mode)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date format"})
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
 		}
 
-		// Fetch the existing duty to update it.
-		existingDuty, err := s.GetDutyByDate(c.Request.Context(), dutyDate)
-		if err != nil || existingDuty == nil {
-			c.JSON(http.StatusNotFound, gin.H{"error": "No duty found for the specified date"})
+		if _, err := duties.Reassign(c.Request.Context(), dutyDate, req.UserID, mode); err != nil {
+			respondDutyError(c, err, "Failed to modify duty")
 			return
 		}
 
-		// Update the user ID.
-		existingDuty.UserID = req.UserID
-		// The assignment type is kept or could be updated to 'admin' if desired.
-		// existingDuty.AssignmentType = "admin"
+		c.Status(http.StatusOK)
+	}
+}
+
+// AdminConfirmDuty handles the POST /api/v1/duties/:date/confirm endpoint.
+// It confirms a held duty so it is no longer given back when the hold ends.
+func AdminConfirmDuty(duties *duty.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		dutyDate, err := time.Parse("2006-01-02", c.Param("date"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date format in URL, expected YYYY-MM-DD"})
+			return
+		}
 
-		if err := s.UpdateDuty(c.Request.Context(), existingDuty); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to modify duty"})
+		if _, err := duties.Confirm(c.Request.Context(), dutyDate); err != nil {
+			respondDutyError(c, err, "Failed to confirm duty")
 			return
 		}
 
@@ -149,22 +209,83 @@ func AdminModifyDuty(s store.Store) gin.HandlerFunc {
 
 // AdminDeleteDuty handles the DELETE /api/v1/duties/:date endpoint.
 // It allows an administrator to delete a duty assignment for a specific date.
-func AdminDeleteDuty(s store.Store) gin.HandlerFunc {
+func AdminDeleteDuty(duties *duty.Service) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		date := c.Param("date")
-		dutyDate, err := time.Parse("2006-01-02", date)
+		dutyDate, err := time.Parse("2006-01-02", c.Param("date"))
 		if err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date format in URL, expected YYYY-MM-DD"})
 			return
 		}
 
-		if err := s.DeleteDuty(c.Request.Context(), dutyDate); err != nil {
-			// This could fail if the duty doesn't exist, which might not be an error.
-			// Depending on requirements, you might return 204 regardless.
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete duty"})
+		if err := duties.Remove(c.Request.Context(), dutyDate); err != nil {
+			respondDutyError(c, err, "Failed to delete duty")
 			return
 		}
 
 		c.Status(http.StatusNoContent)
 	}
-}
\ No newline at end of file
+}
+
+// AdminBackfillDuty handles the PUT /api/v1/duties/:date/actual endpoint.
+// It allows an administrator to record who actually did the duty on a past date.
+// The duty counts towards stats but is marked as backfilled.
+func AdminBackfillDuty(duties *duty.Service) gin.HandlerFunc {
+	type request struct {
+		UserID int64 `json:"user_id" binding:"required"`
+	}
+
+	return func(c *gin.Context) {
+		dutyDate, err := time.Parse("2006-01-02", c.Param("date"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date format in URL, expected YYYY-MM-DD"})
+			return
+		}
+
+		var req request
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		d, err := duties.Backfill(c.Request.Context(), dutyDate, req.UserID)
+		if err != nil {
+			respondDutyError(c, err, "Failed to backfill duty")
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"date":          d.DutyDate.Format("2006-01-02"),
+			"user_id":       d.UserID,
+			"backfilled_at": d.BackfilledAt,
+		})
+	}
+}
+
+// AdminSetDutyCompletion handles the POST and DELETE /api/v1/duties/:date/complete
+// endpoints. They let an administrator mark the duty of today or a past day as
+// done, or take that back, instead of relying on the 21:00 job. The
+// administrator is recorded as who changed it.
+func AdminSetDutyCompletion(duties *duty.Service, completed bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		dutyDate, err := time.Parse("2006-01-02", c.Param("date"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date format in URL, expected YYYY-MM-DD"})
+			return
+		}
+
+		actor, _ := c.Request.Context().Value(middleware.UserKey).(*store.User)
+		d, err := duties.SetCompletion(c.Request.Context(), dutyDate, completed, actor)
+		if err != nil {
+			respondDutyError(c, err, "Failed to update duty completion")
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"date":          d.DutyDate.Format("2006-01-02"),
+			"user_id":       d.UserID,
+			"status":        d.Status,
+			"completed_at":  d.CompletedAt,
+			"completion_by": d.CompletionBy,
+		})
+	}
+}
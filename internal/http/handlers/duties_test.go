@@ -0,0 +1,221 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/korjavin/dutyassistant/internal/http/middleware"
+	"github.com/korjavin/dutyassistant/internal/scheduler"
+	"github.com/korjavin/dutyassistant/internal/service/duty"
+	"github.com/korjavin/dutyassistant/internal/service/user"
+	"github.com/korjavin/dutyassistant/internal/store"
+	"github.com/korjavin/dutyassistant/internal/store/memory"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAdminBackfillDuty(t *testing.T) {
+	ctx := context.Background()
+	s := memory.New()
+	alice := &store.User{TelegramUserID: 1, FirstName: "Alice", IsActive: true}
+	s.CreateUser(ctx, alice)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.PUT("/duties/:date/actual", AdminBackfillDuty(duty.New(scheduler.NewScheduler(s), user.New(s), s)))
+
+	put := func(date, body string) int {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/duties/"+date+"/actual", strings.NewReader(body)))
+		return w.Code
+	}
+
+	yesterday := time.Now().UTC().AddDate(0, 0, -1)
+	yesterday = time.Date(yesterday.Year(), yesterday.Month(), yesterday.Day(), 0, 0, 0, 0, time.UTC)
+	day := yesterday.Format("2006-01-02")
+
+	assert.Equal(t, http.StatusBadRequest, put("not-a-date", `{"user_id": 1}`))
+	assert.Equal(t, http.StatusBadRequest, put(time.Now().UTC().Format("2006-01-02"), `{"user_id": 1}`), "today is not in the past")
+	assert.Equal(t, http.StatusNotFound, put(day, `{"user_id": 42}`))
+
+	assert.Equal(t, http.StatusOK, put(day, `{"user_id": 1}`))
+	duty, err := s.GetDutyByDate(ctx, yesterday)
+	if assert.NoError(t, err) && assert.NotNil(t, duty) {
+		assert.Equal(t, alice.ID, duty.UserID)
+		assert.NotNil(t, duty.CompletedAt)
+		assert.NotNil(t, duty.BackfilledAt)
+	}
+}
+
+func TestAdminSetDutyCompletion(t *testing.T) {
+	ctx := context.Background()
+	s := memory.New()
+	alice := &store.User{TelegramUserID: 1, FirstName: "Alice", IsActive: true}
+	admin := &store.User{TelegramUserID: 2, FirstName: "Admin", IsActive: true, IsAdmin: true}
+	s.CreateUser(ctx, alice)
+	s.CreateUser(ctx, admin)
+
+	duties := duty.New(scheduler.NewScheduler(s), user.New(s), s)
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), middleware.UserKey, admin))
+	})
+	router.POST("/duties/:date/complete", AdminSetDutyCompletion(duties, true))
+	router.DELETE("/duties/:date/complete", AdminSetDutyCompletion(duties, false))
+
+	send := func(method, date string) int {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(method, "/duties/"+date+"/complete", nil))
+		return w.Code
+	}
+
+	now := time.Now().UTC()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	day := today.Format("2006-01-02")
+
+	assert.Equal(t, http.StatusBadRequest, send(http.MethodPost, "not-a-date"))
+	assert.Equal(t, http.StatusBadRequest, send(http.MethodPost, today.AddDate(0, 0, 1).Format("2006-01-02")), "tomorrow is in the future")
+	assert.Equal(t, http.StatusNotFound, send(http.MethodPost, day), "nobody is on duty")
+
+	s.CreateDuty(ctx, &store.Duty{UserID: alice.ID, DutyDate: today, AssignmentType: store.AssignmentTypeRoundRobin, CreatedAt: now})
+	assert.Equal(t, http.StatusOK, send(http.MethodPost, day))
+	d, err := s.GetDutyByDate(ctx, today)
+	if assert.NoError(t, err) && assert.NotNil(t, d) {
+		assert.NotNil(t, d.CompletedAt)
+		assert.Equal(t, admin.ID, d.CompletionBy)
+	}
+
+	assert.Equal(t, http.StatusOK, send(http.MethodDelete, day))
+	d, err = s.GetDutyByDate(ctx, today)
+	if assert.NoError(t, err) && assert.NotNil(t, d) {
+		assert.Nil(t, d.CompletedAt)
+		assert.Equal(t, store.DutyStatusAnnounced, d.Status)
+	}
+}
+
+func TestAdminAssignDuty_Hold(t *testing.T) {
+	ctx := context.Background()
+	s := memory.New()
+	alice := &store.User{TelegramUserID: 1, FirstName: "Alice", IsActive: true}
+	s.CreateUser(ctx, alice)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	duties := duty.New(scheduler.NewScheduler(s), user.New(s), s)
+	router.POST("/duties", AdminAssignDuty(duties))
+	router.POST("/duties/:date/confirm", AdminConfirmDuty(duties))
+
+	post := func(path, body string) int {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, path, strings.NewReader(body)))
+		return w.Code
+	}
+
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	day := today.AddDate(0, 0, 3)
+	date, until := day.Format("2006-01-02"), today.AddDate(0, 0, 1).Format("2006-01-02")
+
+	assert.Equal(t, http.StatusBadRequest, post("/duties", `{"user_id": 1, "date": "`+date+`", "hold_until": "friday"}`))
+	assert.Equal(t, http.StatusBadRequest, post("/duties", `{"user_id": 1, "date": "`+date+`", "hold_until": "`+date+`"}`), "a hold must end before the duty")
+	assert.Equal(t, http.StatusNotFound, post("/duties/"+date+"/confirm", ""))
+
+	assert.Equal(t, http.StatusCreated, post("/duties", `{"user_id": 1, "date": "`+date+`", "hold_until": "`+until+`"}`))
+	held, _ := s.GetDutyByDate(ctx, day)
+	if assert.NotNil(t, held) && assert.NotNil(t, held.HoldUntil) {
+		assert.Equal(t, until, held.HoldUntil.Format("2006-01-02"))
+	}
+
+	assert.Equal(t, http.StatusOK, post("/duties/"+date+"/confirm", ""))
+	confirmed, _ := s.GetDutyByDate(ctx, day)
+	if assert.NotNil(t, confirmed) {
+		assert.Nil(t, confirmed.HoldUntil)
+	}
+}
+
+func TestAdminAssignToday(t *testing.T) {
+	ctx := context.Background()
+	s := memory.New()
+	alice := &store.User{TelegramUserID: 1, FirstName: "Alice", IsActive: true}
+	s.CreateUser(ctx, alice)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	sched := scheduler.NewScheduler(s)
+	sched.Cutoff = 24 * time.Hour // never reached, only a forced assignment runs
+	duties := duty.New(sched, user.New(s), s)
+	router.POST("/duties/today/assign", AdminAssignToday(duties))
+	router.POST("/duties/:date/confirm", AdminConfirmDuty(duties))
+
+	post := func() *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/duties/today/assign", nil))
+		return w
+	}
+
+	w := post()
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"user_name":"Alice"`)
+	assert.Equal(t, http.StatusOK, post().Code, "an assigned day is returned as it is")
+
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	sched.SkipDay(ctx, today, store.SkipReasonHoliday)
+	assert.Equal(t, http.StatusNoContent, post().Code)
+
+	sched.UnskipDay(ctx, today)
+	s.UpdateUser(ctx, &store.User{ID: alice.ID, TelegramUserID: 1, FirstName: "Alice", IsActive: false})
+	assert.Equal(t, http.StatusConflict, post().Code, "nobody is available")
+}
+
+func TestAdminBatchDuties(t *testing.T) {
+	ctx := context.Background()
+	s := memory.New()
+	alice := &store.User{TelegramUserID: 1, FirstName: "Alice", IsActive: true}
+	bob := &store.User{TelegramUserID: 2, FirstName: "Bob", IsActive: true}
+	s.CreateUser(ctx, alice)
+	s.CreateUser(ctx, bob)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/duties/batch", AdminBatchDuties(duty.New(scheduler.NewScheduler(s), user.New(s), s)))
+	post := func(body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/duties/batch", strings.NewReader(body)))
+		return w
+	}
+
+	now := time.Now()
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC).AddDate(0, 0, 1)
+	date := day.Format("2006-01-02")
+
+	w := post(`{"operations": [
+		{"op": "create", "date": "` + date + `", "user_id": 1},
+		{"op": "modify", "date": "` + date + `", "user_id": 2, "mode": "refund"}
+	]}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"applied":true`)
+	assert.Contains(t, w.Body.String(), `{"index":1,"status":"ok","user_id":2}`)
+
+	// Deleting works, but the day after can't be modified: nothing changes
+	w = post(`{"operations": [
+		{"op": "delete", "date": "` + date + `"},
+		{"op": "modify", "date": "` + day.AddDate(0, 0, 1).Format("2006-01-02") + `", "user_id": 1}
+	]}`)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Contains(t, w.Body.String(), `"applied":false`)
+	assert.Contains(t, w.Body.String(), `{"index":0,"status":"ok"}`)
+	assert.Contains(t, w.Body.String(), `"index":1,"status":"failed"`)
+	d, _ := s.GetDutyByDate(ctx, day)
+	if assert.NotNil(t, d, "the delete is rolled back") {
+		assert.Equal(t, bob.ID, d.UserID)
+	}
+
+	assert.Equal(t, http.StatusBadRequest, post(`{"operations": [{"op": "move", "date": "`+date+`"}]}`).Code)
+	assert.Equal(t, http.StatusBadRequest, post(`{"operations": [{"op": "create", "date": "`+date+`"}]}`).Code)
+}
@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/korjavin/dutyassistant/internal/http/middleware"
+	"github.com/korjavin/dutyassistant/internal/store"
+)
+
+// apiCandidate is a user the daily assignment considered.
+type apiCandidate struct {
+	UserID             int64   `json:"user_id"`
+	Name               string  `json:"name"`
+	Eligible           bool    `json:"eligible"`
+	Excluded           string  `json:"excluded,omitempty"`
+	VolunteerQueueDays int     `json:"volunteer_queue_days"`
+	AdminQueueDays     int     `json:"admin_queue_days"`
+	RecentDuties       int     `json:"recent_duties"`
+	LastDuty           *string `json:"last_duty,omitempty"`
+}
+
+// GetAssignmentExplanation handles the GET /api/v1/assignments/:date/explain
+// endpoint. It returns why the daily assignment picked who it did on the
+// date, as recorded when it ran: everyone it considered with their queues
+// and the duties counted for fairness, why those who couldn't be picked were
+// left out, and how the winner was chosen among the rest. changed is set if
+// the duty was handed to someone else since. Days assigned by hand have no
+// explanation. In minimal PII mode names are "***".
+func GetAssignmentExplanation(s store.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+		date, err := time.Parse("2006-01-02", c.Param("date"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date format in URL, expected YYYY-MM-DD"})
+			return
+		}
+
+		e, err := s.GetAssignmentExplanation(ctx, date)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve the explanation"})
+			return
+		}
+		if e == nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "No assignment was explained for this date"})
+			return
+		}
+		duty, err := s.GetDutyByDate(ctx, date)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve the duty"})
+			return
+		}
+		users, err := s.ListAllUsers(ctx)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve users"})
+			return
+		}
+		names := make(map[int64]string, len(users))
+		for _, u := range users {
+			names[u.ID] = u.FirstName
+		}
+		name := func(id int64) string {
+			if middleware.IsMinimalPII(ctx) {
+				return "***"
+			}
+			return names[id]
+		}
+
+		candidates := make([]apiCandidate, 0, len(e.Candidates))
+		for _, cand := range e.Candidates {
+			item := apiCandidate{
+				UserID:             cand.UserID,
+				Name:               name(cand.UserID),
+				Eligible:           cand.Excluded == "",
+				Excluded:           cand.Excluded,
+				VolunteerQueueDays: cand.VolunteerQueueDays,
+				AdminQueueDays:     cand.AdminQueueDays,
+				RecentDuties:       cand.RecentDuties,
+			}
+			if cand.LastDuty != nil {
+				last := cand.LastDuty.Format("2006-01-02")
+				item.LastDuty = &last
+			}
+			candidates = append(candidates, item)
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"date":            e.Date.Format("2006-01-02"),
+			"user_id":         e.UserID,
+			"name":            name(e.UserID),
+			"assignment_type": e.AssignmentType,
+			"strategy":        e.Strategy,
+			"reason":          e.Reason,
+			"assigned_at":     e.CreatedAt.UTC().Format(time.RFC3339),
+			"changed":         duty == nil || duty.UserID != e.UserID,
+			"candidates":      candidates,
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/korjavin/dutyassistant/internal/http/middleware"
+	"github.com/korjavin/dutyassistant/internal/store"
+	"github.com/korjavin/dutyassistant/internal/store/memory"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetAssignmentExplanation(t *testing.T) {
+	ctx := context.Background()
+	s := memory.New()
+	alice := &store.User{TelegramUserID: 1, FirstName: "Alice", IsActive: true}
+	bob := &store.User{TelegramUserID: 2, FirstName: "Bob", IsActive: true}
+	s.CreateUser(ctx, alice)
+	s.CreateUser(ctx, bob)
+	day := time.Date(2025, 11, 5, 0, 0, 0, 0, time.UTC)
+	last := day.AddDate(0, 0, -1)
+	s.CreateDuty(ctx, &store.Duty{UserID: alice.ID, DutyDate: day, AssignmentType: store.AssignmentTypeRoundRobin})
+	s.SaveAssignmentExplanation(ctx, &store.AssignmentExplanation{
+		Date: day, UserID: alice.ID, AssignmentType: store.AssignmentTypeRoundRobin, Strategy: "window14",
+		Reason: "Had the fewest duties in the last 14 days on duty (0) of 1 available members.", CreatedAt: day.Add(11 * time.Hour),
+		Candidates: []store.AssignmentCandidate{
+			{UserID: alice.ID},
+			{UserID: bob.ID, Excluded: "off_duty", RecentDuties: 1, LastDuty: &last},
+		},
+	})
+
+	gin.SetMode(gin.TestMode)
+	get := func(url string, minimalPII bool) (int, map[string]any) {
+		router := gin.New()
+		if minimalPII {
+			router.Use(middleware.MinimalPII())
+		}
+		router.GET("/assignments/:date/explain", GetAssignmentExplanation(s))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, url, nil))
+		var body map[string]any
+		json.Unmarshal(w.Body.Bytes(), &body)
+		return w.Code, body
+	}
+
+	code, body := get("/assignments/2025-11-05/explain", false)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "Alice", body["name"])
+	assert.Equal(t, "round_robin", body["assignment_type"])
+	assert.Equal(t, "window14", body["strategy"])
+	assert.Contains(t, body["reason"], "fewest duties")
+	assert.Equal(t, false, body["changed"])
+	candidates := body["candidates"].([]any)
+	if assert.Len(t, candidates, 2) {
+		assert.Equal(t, true, candidates[0].(map[string]any)["eligible"])
+		b := candidates[1].(map[string]any)
+		assert.Equal(t, "Bob", b["name"])
+		assert.Equal(t, false, b["eligible"])
+		assert.Equal(t, "off_duty", b["excluded"])
+		assert.Equal(t, "2025-11-04", b["last_duty"])
+	}
+
+	// Handed to Bob since
+	s.UpdateDuty(ctx, &store.Duty{ID: 1, UserID: bob.ID, DutyDate: day, AssignmentType: store.AssignmentTypeAdmin})
+	_, body = get("/assignments/2025-11-05/explain", false)
+	assert.Equal(t, true, body["changed"])
+
+	_, body = get("/assignments/2025-11-05/explain", true)
+	assert.Equal(t, "***", body["name"])
+
+	code, _ = get("/assignments/2025-11-06/explain", false)
+	assert.Equal(t, http.StatusNotFound, code)
+	code, _ = get("/assignments/tomorrow/explain", false)
+	assert.Equal(t, http.StatusBadRequest, code)
+}
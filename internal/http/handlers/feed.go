@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/korjavin/dutyassistant/internal/store"
+)
+
+// feedEntryLimit is the number of schedule changes included in the feed.
+const feedEntryLimit = 50
+
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	ID      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated string      `xml:"updated"`
+	Link    atomLink    `xml:"link"`
+	Author  atomAuthor  `xml:"author"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+type atomEntry struct {
+	ID      string `xml:"id"`
+	Title   string `xml:"title"`
+	Updated string `xml:"updated"`
+	Summary string `xml:"summary"`
+}
+
+// GetFeed handles the GET /api/v1/feed.atom endpoint.
+// It renders recent duty assignments and changes as an Atom feed.
+func GetFeed(s store.DutyStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		changes, err := s.GetRecentDutyChanges(c.Request.Context(), feedEntryLimit)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve schedule changes"})
+			return
+		}
+
+		scheme := "https"
+		if c.Request.TLS == nil && c.GetHeader("X-Forwarded-Proto") == "" {
+			scheme = "http"
+		}
+		baseURL := fmt.Sprintf("%s://%s", scheme, c.Request.Host)
+
+		feed := atomFeed{
+			ID:      baseURL + "/api/v1/feed.atom",
+			Title:   "Duty roster changes",
+			Updated: time.Now().UTC().Format(time.RFC3339),
+			Link:    atomLink{Href: baseURL + "/"},
+			Author:  atomAuthor{Name: "Duty Assistant"},
+			Entries: make([]atomEntry, 0, len(changes)),
+		}
+		if len(changes) > 0 {
+			feed.Updated = changes[0].ChangedAt.UTC().Format(time.RFC3339)
+		}
+
+		for _, change := range changes {
+			feed.Entries = append(feed.Entries, atomEntry{
+				ID:      fmt.Sprintf("tag:%s,%s:duty-change-%d", c.Request.Host, change.ChangedAt.Format("2006-01-02"), change.ID),
+				Title:   feedEntryTitle(change),
+				Updated: change.ChangedAt.UTC().Format(time.RFC3339),
+				Summary: fmt.Sprintf("%s (%s assignment)", feedEntryTitle(change), change.AssignmentType),
+			})
+		}
+
+		out, err := xml.MarshalIndent(feed, "", "  ")
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to render feed"})
+			return
+		}
+		c.Data(http.StatusOK, "application/atom+xml; charset=utf-8", append([]byte(xml.Header), out...))
+	}
+}
+
+// feedEntryTitle describes a single schedule change in one line.
+func feedEntryTitle(change *store.DutyChange) string {
+	day := change.DutyDate.Format("Mon, Jan 2")
+	switch change.Action {
+	case store.DutyChangeReassigned:
+		return fmt.Sprintf("%s: duty reassigned to %s", day, change.UserName)
+	case store.DutyChangeRemoved:
+		return fmt.Sprintf("%s: duty of %s removed", day, change.UserName)
+	case store.DutyChangeBackfilled:
+		return fmt.Sprintf("%s: %s did the duty (recorded afterwards)", day, change.UserName)
+	default:
+		return fmt.Sprintf("%s: %s is on duty", day, change.UserName)
+	}
+}
@@ -0,0 +1,280 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/korjavin/dutyassistant/internal/store"
+)
+
+// Grafana JSON datasource metric names.
+const (
+	grafanaMetricDutyCount       = "duty_count"
+	grafanaMetricDutyCountByType = "duty_count_by_type"
+	grafanaMetricCompletionRate  = "completion_rate"
+)
+
+// grafanaMaxRange caps the time range a single query may scan.
+const grafanaMaxRange = 5 * 365 * 24 * time.Hour
+
+// grafanaAnnotationChangeLimit bounds the change log entries scanned for annotations.
+const grafanaAnnotationChangeLimit = 1000
+
+var grafanaMetrics = []string{grafanaMetricDutyCount, grafanaMetricDutyCountByType, grafanaMetricCompletionRate}
+
+type grafanaRange struct {
+	From time.Time `json:"from"`
+	To   time.Time `json:"to"`
+}
+
+type grafanaQueryRequest struct {
+	Range      grafanaRange `json:"range"`
+	IntervalMs int64        `json:"intervalMs"`
+	Targets    []struct {
+		Target string `json:"target"`
+	} `json:"targets"`
+}
+
+type grafanaSeries struct {
+	Target     string       `json:"target"`
+	Datapoints [][2]float64 `json:"datapoints"`
+}
+
+type grafanaAnnotationRequest struct {
+	Range grafanaRange `json:"range"`
+}
+
+type grafanaAnnotation struct {
+	Time  int64    `json:"time"`
+	Title string   `json:"title"`
+	Text  string   `json:"text"`
+	Tags  []string `json:"tags"`
+}
+
+// GrafanaHealth handles GET /api/v1/grafana, used by the datasource's "Save & test".
+func GrafanaHealth() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"status": "ok"})
+	}
+}
+
+// GrafanaSearch handles POST /api/v1/grafana/search (and /metrics for newer
+// plugin versions). It lists the metrics that can be queried.
+func GrafanaSearch() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.FullPath() == "/api/v1/grafana/metrics" {
+			metrics := make([]gin.H, 0, len(grafanaMetrics))
+			for _, m := range grafanaMetrics {
+				metrics = append(metrics, gin.H{"label": m, "value": m})
+			}
+			c.JSON(http.StatusOK, metrics)
+			return
+		}
+		c.JSON(http.StatusOK, grafanaMetrics)
+	}
+}
+
+// GrafanaQuery handles POST /api/v1/grafana/query.
+// It returns time series for duty counts and completion rates.
+func GrafanaQuery(s store.DutyStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req grafanaQueryRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid query"})
+			return
+		}
+		if err := validateGrafanaRange(req.Range); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		duties, err := dutiesInRange(c.Request.Context(), s, req.Range.From, req.Range.To)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve duties"})
+			return
+		}
+
+		interval := time.Duration(req.IntervalMs) * time.Millisecond
+		if interval < 24*time.Hour {
+			interval = 24 * time.Hour
+		}
+		b := newGrafanaBucketer(req.Range.From, interval)
+
+		berlinLoc, _ := time.LoadLocation("Europe/Berlin")
+		now := time.Now().In(berlinLoc)
+		today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+
+		response := make([]grafanaSeries, 0)
+		for _, t := range req.Targets {
+			switch t.Target {
+			case grafanaMetricDutyCount:
+				response = append(response, b.countBy(duties, func(d *store.Duty) string {
+					if d.User == nil {
+						return fmt.Sprintf("user %d", d.UserID)
+					}
+					return d.User.GroupName()
+				})...)
+			case grafanaMetricDutyCountByType:
+				response = append(response, b.countBy(duties, func(d *store.Duty) string {
+					return string(d.AssignmentType)
+				})...)
+			case grafanaMetricCompletionRate:
+				response = append(response, b.completionRate(duties, today))
+			}
+		}
+
+		c.JSON(http.StatusOK, response)
+	}
+}
+
+// GrafanaAnnotations handles POST /api/v1/grafana/annotations.
+// It marks reassigned and removed duties on the dashboard timeline.
+func GrafanaAnnotations(s store.DutyStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req grafanaAnnotationRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid annotation query"})
+			return
+		}
+		if err := validateGrafanaRange(req.Range); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		changes, err := s.GetRecentDutyChanges(c.Request.Context(), grafanaAnnotationChangeLimit)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve schedule changes"})
+			return
+		}
+
+		annotations := make([]grafanaAnnotation, 0)
+		for _, change := range changes {
+			if change.Action == store.DutyChangeAssigned {
+				continue
+			}
+			if change.DutyDate.Before(req.Range.From) || change.DutyDate.After(req.Range.To) {
+				continue
+			}
+			annotations = append(annotations, grafanaAnnotation{
+				Time:  change.DutyDate.UnixMilli(),
+				Title: feedEntryTitle(change),
+				Text:  fmt.Sprintf("Changed at %s", change.ChangedAt.Format(time.RFC3339)),
+				Tags:  []string{string(change.Action), string(change.AssignmentType)},
+			})
+		}
+
+		c.JSON(http.StatusOK, annotations)
+	}
+}
+
+// validateGrafanaRange rejects empty, inverted or oversized ranges.
+func validateGrafanaRange(r grafanaRange) error {
+	if r.From.IsZero() || r.To.IsZero() || !r.To.After(r.From) {
+		return fmt.Errorf("range.from and range.to are required and must be ordered")
+	}
+	if r.To.Sub(r.From) > grafanaMaxRange {
+		return fmt.Errorf("range must not exceed 5 years")
+	}
+	return nil
+}
+
+// dutiesInRange collects duties between from and to (inclusive) month by month.
+func dutiesInRange(ctx context.Context, s store.DutyStore, from, to time.Time) ([]*store.Duty, error) {
+	var result []*store.Duty
+	month := time.Date(from.Year(), from.Month(), 1, 0, 0, 0, 0, time.UTC)
+	for !month.After(to) {
+		duties, err := s.GetDutiesByMonth(ctx, month.Year(), month.Month())
+		if err != nil {
+			return nil, err
+		}
+		for _, d := range duties {
+			if !d.DutyDate.Before(from.Truncate(24*time.Hour)) && !d.DutyDate.After(to) {
+				result = append(result, d)
+			}
+		}
+		month = month.AddDate(0, 1, 0)
+	}
+	return result, nil
+}
+
+// grafanaBucketer groups duties into fixed-width time buckets starting at from.
+type grafanaBucketer struct {
+	from     time.Time
+	interval time.Duration
+}
+
+func newGrafanaBucketer(from time.Time, interval time.Duration) grafanaBucketer {
+	return grafanaBucketer{from: from.UTC().Truncate(24 * time.Hour), interval: interval}
+}
+
+// bucket returns the start of the bucket the given date falls into, in milliseconds.
+func (b grafanaBucketer) bucket(date time.Time) int64 {
+	n := date.Sub(b.from) / b.interval
+	return b.from.Add(n * b.interval).UnixMilli()
+}
+
+// countBy returns one series per key with the number of duties in each bucket.
+func (b grafanaBucketer) countBy(duties []*store.Duty, key func(*store.Duty) string) []grafanaSeries {
+	counts := make(map[string]map[int64]int)
+	for _, d := range duties {
+		k := key(d)
+		if counts[k] == nil {
+			counts[k] = make(map[int64]int)
+		}
+		counts[k][b.bucket(d.DutyDate)]++
+	}
+
+	keys := make([]string, 0, len(counts))
+	for k := range counts {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	series := make([]grafanaSeries, 0, len(keys))
+	for _, k := range keys {
+		series = append(series, grafanaSeries{Target: k, Datapoints: sortedDatapoints(counts[k])})
+	}
+	return series
+}
+
+// completionRate returns the percentage of past duties in each bucket that
+// were marked completed. Duties from today onwards are not yet due.
+func (b grafanaBucketer) completionRate(duties []*store.Duty, today time.Time) grafanaSeries {
+	due := make(map[int64]int)
+	done := make(map[int64]int)
+	for _, d := range duties {
+		if !d.DutyDate.Before(today) {
+			continue
+		}
+		ts := b.bucket(d.DutyDate)
+		due[ts]++
+		if d.CompletedAt != nil {
+			done[ts]++
+		}
+	}
+
+	rates := make(map[int64]float64, len(due))
+	for ts, n := range due {
+		rates[ts] = float64(done[ts]) * 100 / float64(n)
+	}
+	return grafanaSeries{Target: grafanaMetricCompletionRate, Datapoints: sortedDatapoints(rates)}
+}
+
+// sortedDatapoints converts a bucket map into Grafana [value, timestamp] pairs ordered by time.
+func sortedDatapoints[V int | float64](values map[int64]V) [][2]float64 {
+	timestamps := make([]int64, 0, len(values))
+	for ts := range values {
+		timestamps = append(timestamps, ts)
+	}
+	sort.Slice(timestamps, func(i, j int) bool { return timestamps[i] < timestamps[j] })
+
+	points := make([][2]float64, 0, len(timestamps))
+	for _, ts := range timestamps {
+		points = append(points, [2]float64{float64(values[ts]), float64(ts)})
+	}
+	return points
+}
@@ -12,19 +12,24 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/korjavin/dutyassistant/internal/http/middleware"
+	"github.com/korjavin/dutyassistant/internal/scheduler"
+	schedulermocks "github.com/korjavin/dutyassistant/internal/scheduler/mocks"
+	"github.com/korjavin/dutyassistant/internal/service/duty"
+	"github.com/korjavin/dutyassistant/internal/service/user"
 	"github.com/korjavin/dutyassistant/internal/store"
 	"github.com/korjavin/dutyassistant/internal/store/mocks"
 	"github.com/stretchr/testify/assert"
-	"github.com/stretchr/testify/mock"
+	"go.uber.org/mock/gomock"
 )
 
-// setupTestServer initializes a new Gin test server with a mock store.
-// It does NOT include authentication middleware, allowing handlers to be
-// tested in isolation. Tests are responsible for injecting user context
+// setupTestServer initializes a new Gin test server with a mock store and
+// scheduler. It does NOT include authentication middleware, allowing handlers
+// to be tested in isolation. Tests are responsible for injecting user context
 // as needed.
-func setupTestServer(mockStore *mocks.MockStore) *gin.Engine {
+func setupTestServer(mockStore *mocks.MockStore, mockScheduler *schedulermocks.MockSchedulerInterface) *gin.Engine {
 	gin.SetMode(gin.TestMode)
 	router := gin.New()
+	duties := duty.New(mockScheduler, user.New(mockStore), mockStore)
 
 	api := router.Group("/api/v1")
 	{
@@ -34,10 +39,10 @@ func setupTestServer(mockStore *mocks.MockStore) *gin.Engine {
 
 		// Endpoints that require authentication context.
 		// The real auth middleware is omitted for unit testing.
-		api.POST("/duties/volunteer", VolunteerForDuty(mockStore))
-		api.POST("/duties", AdminAssignDuty(mockStore))
-		api.PUT("/duties/:date", AdminModifyDuty(mockStore))
-		api.DELETE("/duties/:date", AdminDeleteDuty(mockStore))
+		api.POST("/duties/volunteer", VolunteerForDuty(duties))
+		api.POST("/duties", AdminAssignDuty(duties))
+		api.PUT("/duties/:date", AdminModifyDuty(duties))
+		api.DELETE("/duties/:date", AdminDeleteDuty(duties))
 	}
 
 	return router
@@ -45,8 +50,8 @@ func setupTestServer(mockStore *mocks.MockStore) *gin.Engine {
 
 // TestGetSchedule tests the GetSchedule handler.
 func TestGetSchedule(t *testing.T) {
-	mockStore := new(mocks.MockStore)
-	router := setupTestServer(mockStore)
+	mockStore := mocks.NewMockStore(gomock.NewController(t))
+	router := setupTestServer(mockStore, nil)
 
 	t.Run("success", func(t *testing.T) {
 		year, month := 2023, 10
@@ -55,17 +60,19 @@ func TestGetSchedule(t *testing.T) {
 			{ID: 1, UserID: 101, DutyDate: dutyDate},
 		}
 
-		mockStore.On("GetDutiesByMonth", mock.Anything, year, time.Month(month)).Return(expectedDuties, nil).Once()
+		mockStore.EXPECT().GetDutiesByMonth(gomock.Any(), year, time.Month(month)).Return(expectedDuties, nil)
+		mockStore.EXPECT().GetSkipDaysByMonth(gomock.Any(), year, time.Month(month)).Return(nil, nil)
 
 		w := httptest.NewRecorder()
 		req, _ := http.NewRequest("GET", "/api/v1/schedule/2023/10", nil)
 		router.ServeHTTP(w, req)
 
 		assert.Equal(t, http.StatusOK, w.Code)
-		var duties []*store.Duty
-		json.Unmarshal(w.Body.Bytes(), &duties)
-		assert.Equal(t, expectedDuties, duties)
-		mockStore.AssertExpectations(t)
+		var body struct {
+			Duties []scheduleDuty `json:"duties"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &body)
+		assert.Equal(t, []scheduleDuty{{ID: 1, Date: "2023-10-25T00:00:00Z", UserID: 101}}, body.Duties)
 	})
 
 	t.Run("invalid year", func(t *testing.T) {
@@ -76,53 +83,113 @@ func TestGetSchedule(t *testing.T) {
 	})
 
 	t.Run("db error", func(t *testing.T) {
-		mockStore.On("GetDutiesByMonth", mock.Anything, 2023, time.Month(11)).Return(nil, errors.New("db error")).Once()
+		mockStore.EXPECT().GetDutiesByMonth(gomock.Any(), 2023, time.Month(11)).Return(nil, errors.New("db error"))
 
 		w := httptest.NewRecorder()
 		req, _ := http.NewRequest("GET", "/api/v1/schedule/2023/11", nil)
 		router.ServeHTTP(w, req)
 
 		assert.Equal(t, http.StatusInternalServerError, w.Code)
-		mockStore.AssertExpectations(t)
 	})
 }
 
 // TestGetUsers tests the GetUsers handler.
 func TestGetUsers(t *testing.T) {
-	mockStore := new(mocks.MockStore)
-	router := setupTestServer(mockStore)
+	mockStore := mocks.NewMockStore(gomock.NewController(t))
+	router := setupTestServer(mockStore, nil)
 
 	t.Run("success", func(t *testing.T) {
 		expectedUsers := []*store.User{
 			{ID: 1, FirstName: "Alice"},
 			{ID: 2, FirstName: "Bob"},
 		}
-		mockStore.On("ListAllUsers", mock.Anything).Return(expectedUsers, nil).Once()
+		mockStore.EXPECT().ListAllUsers(gomock.Any()).Return(expectedUsers, nil)
 
 		w := httptest.NewRecorder()
 		req, _ := http.NewRequest("GET", "/api/v1/users", nil)
+		// Only authenticated users get the user list.
+		viewer := &store.User{ID: 1, TelegramUserID: 123, IsActive: true}
+		req = req.WithContext(context.WithValue(req.Context(), middleware.UserKey, viewer))
 		router.ServeHTTP(w, req)
 
 		assert.Equal(t, http.StatusOK, w.Code)
 		var users []*store.User
 		json.Unmarshal(w.Body.Bytes(), &users)
 		assert.Equal(t, expectedUsers, users)
-		mockStore.AssertExpectations(t)
+	})
+}
+
+func TestGetUsers_MinimalPII(t *testing.T) {
+	mockStore := mocks.NewMockStore(gomock.NewController(t))
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/api/v1/users", middleware.MinimalPII(), GetUsers(mockStore))
+	mockStore.EXPECT().ListAllUsers(gomock.Any()).Return([]*store.User{{ID: 1, TelegramUserID: 123, FirstName: "Alice"}}, nil)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/api/v1/users", nil)
+	viewer := &store.User{ID: 1, TelegramUserID: 123, IsActive: true}
+	router.ServeHTTP(w, req.WithContext(context.WithValue(req.Context(), middleware.UserKey, viewer)))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var users []map[string]any
+	json.Unmarshal(w.Body.Bytes(), &users)
+	if assert.Len(t, users, 1) {
+		assert.Equal(t, "Alice", users[0]["FirstName"])
+		assert.NotContains(t, users[0], "TelegramUserID")
+	}
+}
+
+// TestAdminMergeUsers tests the AdminMergeUsers handler.
+func TestAdminMergeUsers(t *testing.T) {
+	mockStore := mocks.NewMockStore(gomock.NewController(t))
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/api/v1/users/merge", AdminMergeUsers(user.New(mockStore)))
+
+	alice, alias := &store.User{ID: 1, FirstName: "Alice"}, &store.User{ID: 2, FirstName: "Alice"}
+	mockStore.EXPECT().ListAllUsers(gomock.Any()).Return([]*store.User{alice, alias}, nil).AnyTimes()
+	post := func(body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/v1/users/merge", bytes.NewBufferString(body)))
+		return w
+	}
+
+	t.Run("success", func(t *testing.T) {
+		mockStore.EXPECT().MergeUsers(gomock.Any(), alias.ID, alice.ID, gomock.Any()).
+			Return(&store.UserMerge{ID: 1, FromUserID: alias.ID, ToUserID: alice.ID, DutiesMoved: 2}, nil)
+
+		w := post(`{"from_user_id": 2, "to_user_id": 1}`)
+		assert.Equal(t, http.StatusOK, w.Code)
+		var merge store.UserMerge
+		json.Unmarshal(w.Body.Bytes(), &merge)
+		assert.Equal(t, 2, merge.DutiesMoved)
+	})
+
+	t.Run("unknown user", func(t *testing.T) {
+		assert.Equal(t, http.StatusNotFound, post(`{"from_user_id": 3, "to_user_id": 1}`).Code)
+	})
+
+	t.Run("same user", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, post(`{"from_user_id": 1, "to_user_id": 1}`).Code)
 	})
 }
 
 // TestVolunteerForDuty tests the VolunteerForDuty handler.
 func TestVolunteerForDuty(t *testing.T) {
-	mockStore := new(mocks.MockStore)
-	router := setupTestServer(mockStore)
+	ctrl := gomock.NewController(t)
+	mockStore := mocks.NewMockStore(ctrl)
+	mockScheduler := schedulermocks.NewMockSchedulerInterface(ctrl)
+	router := setupTestServer(mockStore, mockScheduler)
 
 	t.Run("success", func(t *testing.T) {
 		user := &store.User{ID: 1, TelegramUserID: 123, IsActive: true}
 		dateStr := time.Now().Format("2006-01-02")
 		dutyDate, _ := time.Parse("2006-01-02", dateStr)
 
-		mockStore.On("DeleteDuty", mock.Anything, dutyDate).Return(nil).Once()
-		mockStore.On("CreateDuty", mock.Anything, mock.AnythingOfType("*store.Duty")).Return(nil).Once()
+		mockStore.EXPECT().IsUserOffDuty(gomock.Any(), user.ID, dutyDate).Return(false, nil)
+		mockScheduler.EXPECT().AssignDutyTo(gomock.Any(), dutyDate, user.ID, store.AssignmentTypeVoluntary).
+			Return(&store.Duty{UserID: user.ID, DutyDate: dutyDate}, nil)
 
 		body, _ := json.Marshal(gin.H{"date": dateStr})
 		w := httptest.NewRecorder()
@@ -136,22 +203,45 @@ func TestVolunteerForDuty(t *testing.T) {
 		router.ServeHTTP(w, req)
 
 		assert.Equal(t, http.StatusCreated, w.Code)
-		mockStore.AssertExpectations(t)
+	})
+
+	t.Run("day already taken", func(t *testing.T) {
+		user := &store.User{ID: 1, TelegramUserID: 123, IsActive: true}
+		dateStr := "2099-01-02"
+		dutyDate, _ := time.Parse("2006-01-02", dateStr)
+
+		mockStore.EXPECT().IsUserOffDuty(gomock.Any(), user.ID, dutyDate).Return(false, nil)
+		mockScheduler.EXPECT().AssignDutyTo(gomock.Any(), dutyDate, user.ID, store.AssignmentTypeVoluntary).
+			Return(nil, scheduler.ErrDutyTaken)
+
+		body, _ := json.Marshal(gin.H{"date": dateStr})
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/api/v1/duties/volunteer", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		req = req.WithContext(context.WithValue(req.Context(), middleware.UserKey, user))
+
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusConflict, w.Code)
 	})
 }
 
 // TestAdminAssignDuty tests the AdminAssignDuty handler.
 func TestAdminAssignDuty(t *testing.T) {
-	mockStore := new(mocks.MockStore)
-	router := setupTestServer(mockStore)
+	ctrl := gomock.NewController(t)
+	mockStore := mocks.NewMockStore(ctrl)
+	mockScheduler := schedulermocks.NewMockSchedulerInterface(ctrl)
+	router := setupTestServer(mockStore, mockScheduler)
 
 	t.Run("success", func(t *testing.T) {
 		adminUser := &store.User{ID: 1, TelegramUserID: 123, IsActive: true, IsAdmin: true}
 		dateStr := "2023-11-11"
 		dutyDate, _ := time.Parse("2006-01-02", dateStr)
 
-		mockStore.On("DeleteDuty", mock.Anything, dutyDate).Return(nil).Once()
-		mockStore.On("CreateDuty", mock.Anything, mock.AnythingOfType("*store.Duty")).Return(nil).Once()
+		mockStore.EXPECT().ListAllUsers(gomock.Any()).Return([]*store.User{{ID: 101, FirstName: "Bob", IsActive: true}}, nil)
+		mockStore.EXPECT().IsUserOffDuty(gomock.Any(), int64(101), dutyDate).Return(false, nil)
+		mockScheduler.EXPECT().AssignDutyTo(gomock.Any(), dutyDate, int64(101), store.AssignmentTypeAdmin).
+			Return(&store.Duty{UserID: 101, DutyDate: dutyDate}, nil)
 
 		body, _ := json.Marshal(gin.H{"user_id": 101, "date": dateStr})
 		w := httptest.NewRecorder()
@@ -165,23 +255,44 @@ func TestAdminAssignDuty(t *testing.T) {
 		router.ServeHTTP(w, req)
 
 		assert.Equal(t, http.StatusCreated, w.Code)
-		mockStore.AssertExpectations(t)
+	})
+
+	t.Run("user off duty", func(t *testing.T) {
+		adminUser := &store.User{ID: 1, TelegramUserID: 123, IsActive: true, IsAdmin: true}
+		dateStr := "2023-11-11"
+		dutyDate, _ := time.Parse("2006-01-02", dateStr)
+
+		mockStore.EXPECT().ListAllUsers(gomock.Any()).Return([]*store.User{{ID: 101, FirstName: "Bob", IsActive: true}}, nil)
+		mockStore.EXPECT().IsUserOffDuty(gomock.Any(), int64(101), dutyDate).Return(true, nil)
+
+		body, _ := json.Marshal(gin.H{"user_id": 101, "date": dateStr})
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/api/v1/duties", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		req = req.WithContext(context.WithValue(req.Context(), middleware.UserKey, adminUser))
+
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusConflict, w.Code)
 	})
 }
 
 // TestAdminModifyDuty tests the AdminModifyDuty handler.
 func TestAdminModifyDuty(t *testing.T) {
-	mockStore := new(mocks.MockStore)
-	router := setupTestServer(mockStore)
+	ctrl := gomock.NewController(t)
+	mockStore := mocks.NewMockStore(ctrl)
+	mockScheduler := schedulermocks.NewMockSchedulerInterface(ctrl)
+	router := setupTestServer(mockStore, mockScheduler)
 
 	t.Run("success", func(t *testing.T) {
 		adminUser := &store.User{ID: 1, TelegramUserID: 123, IsActive: true, IsAdmin: true}
 		dateStr := "2023-11-12"
 		dutyDate, _ := time.Parse("2006-01-02", dateStr)
-		existingDuty := &store.Duty{ID: 1, UserID: 101, DutyDate: dutyDate}
 
-		mockStore.On("GetDutyByDate", mock.Anything, dutyDate).Return(existingDuty, nil).Once()
-		mockStore.On("UpdateDuty", mock.Anything, mock.AnythingOfType("*store.Duty")).Return(nil).Once()
+		mockStore.EXPECT().ListAllUsers(gomock.Any()).Return([]*store.User{{ID: 102, FirstName: "Carol", IsActive: true}}, nil)
+		mockStore.EXPECT().IsUserOffDuty(gomock.Any(), int64(102), dutyDate).Return(false, nil)
+		mockScheduler.EXPECT().ChangeDutyUser(gomock.Any(), dutyDate, int64(102), scheduler.ChangeModeKeep).
+			Return(&store.Duty{ID: 1, UserID: 102, DutyDate: dutyDate}, nil)
 
 		body, _ := json.Marshal(gin.H{"user_id": 102})
 		w := httptest.NewRecorder()
@@ -195,21 +306,22 @@ func TestAdminModifyDuty(t *testing.T) {
 		router.ServeHTTP(w, req)
 
 		assert.Equal(t, http.StatusOK, w.Code)
-		mockStore.AssertExpectations(t)
 	})
 }
 
 // TestAdminDeleteDuty tests the AdminDeleteDuty handler.
 func TestAdminDeleteDuty(t *testing.T) {
-	mockStore := new(mocks.MockStore)
-	router := setupTestServer(mockStore)
+	ctrl := gomock.NewController(t)
+	mockStore := mocks.NewMockStore(ctrl)
+	mockScheduler := schedulermocks.NewMockSchedulerInterface(ctrl)
+	router := setupTestServer(mockStore, mockScheduler)
 
 	t.Run("success", func(t *testing.T) {
 		adminUser := &store.User{ID: 1, TelegramUserID: 123, IsActive: true, IsAdmin: true}
 		dateStr := "2023-11-13"
 		dutyDate, _ := time.Parse("2006-01-02", dateStr)
 
-		mockStore.On("DeleteDuty", mock.Anything, dutyDate).Return(nil).Once()
+		mockScheduler.EXPECT().RemoveDuty(gomock.Any(), dutyDate).Return(nil)
 
 		w := httptest.NewRecorder()
 		req, _ := http.NewRequest("DELETE", "/api/v1/duties/"+dateStr, nil)
@@ -221,6 +333,21 @@ func TestAdminDeleteDuty(t *testing.T) {
 		router.ServeHTTP(w, req)
 
 		assert.Equal(t, http.StatusNoContent, w.Code)
-		mockStore.AssertExpectations(t)
 	})
-}
\ No newline at end of file
+
+	t.Run("no duty", func(t *testing.T) {
+		adminUser := &store.User{ID: 1, TelegramUserID: 123, IsActive: true, IsAdmin: true}
+		dateStr := "2023-11-14"
+		dutyDate, _ := time.Parse("2006-01-02", dateStr)
+
+		mockScheduler.EXPECT().RemoveDuty(gomock.Any(), dutyDate).Return(scheduler.ErrNoDuty)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("DELETE", "/api/v1/duties/"+dateStr, nil)
+		req = req.WithContext(context.WithValue(req.Context(), middleware.UserKey, adminUser))
+
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
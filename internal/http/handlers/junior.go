@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/korjavin/dutyassistant/internal/http/middleware"
+	"github.com/korjavin/dutyassistant/internal/service/week"
+	"github.com/korjavin/dutyassistant/internal/store"
+)
+
+// juniorDay is one day of the GetJuniorWeek response. It only tells whether
+// the day is the viewer's, not who else is on duty.
+type juniorDay struct {
+	Date    string   `json:"date"`
+	Weekday string   `json:"weekday"`
+	Today   bool     `json:"today,omitempty"`
+	Mine    bool     `json:"mine,omitempty"`
+	Done    bool     `json:"done,omitempty"`
+	NoDuty  bool     `json:"no_duty,omitempty"` // Skipped by an admin, nobody is on duty
+	Bins    []string `json:"bins,omitempty"`
+}
+
+// GetJuniorWeek handles the GET /api/v1/schedule/junior endpoint behind the
+// kid-friendly web view. It returns the current week with the authenticated
+// user's own days marked and nothing about anyone else.
+func GetJuniorWeek(s store.DutyStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, ok := c.Request.Context().Value(middleware.UserKey).(*store.User)
+		if !ok || user == nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+			return
+		}
+
+		now := time.Now()
+		w, err := week.Load(c.Request.Context(), s, now)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve schedule"})
+			return
+		}
+
+		today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+		days := make([]juniorDay, 0, len(w.Days))
+		for _, day := range w.Days {
+			item := juniorDay{
+				Date:    day.Date.Format(time.RFC3339),
+				Weekday: day.Date.Format("Monday"),
+				Today:   day.Date.Equal(today),
+				NoDuty:  day.Skip != nil,
+				Bins:    day.Bins,
+			}
+			if day.Duty != nil && day.Duty.UserID == user.ID {
+				item.Mine = true
+				item.Done = day.Duty.CompletedAt != nil
+			}
+			days = append(days, item)
+		}
+		c.JSON(http.StatusOK, gin.H{"name": user.FirstName, "days": days})
+	}
+}
@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/korjavin/dutyassistant/internal/http/middleware"
+	"github.com/korjavin/dutyassistant/internal/service/login"
+	"github.com/korjavin/dutyassistant/internal/service/user"
+	"github.com/korjavin/dutyassistant/internal/store"
+)
+
+const (
+	loginFailedMessage       = "This login link is invalid or has expired. Send /login to the bot in a private chat for a new one."
+	widgetLoginFailedMessage = "Telegram couldn't confirm who you are, please log in again."
+	widgetNotMemberMessage   = "You aren't on the duty roster yet. Send /start to the bot first."
+)
+
+// Login handles the GET /login endpoint the bot's login links point to. It
+// redeems the code, keeps the session in a cookie and redirects to the
+// calendar.
+func Login(sessions *login.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token, expires, err := sessions.Redeem(c.Request.Context(), c.Query("code"))
+		if errors.Is(err, login.ErrInvalidCode) {
+			c.String(http.StatusUnauthorized, loginFailedMessage)
+			return
+		} else if err != nil {
+			log.Printf("[WEB_AUTH] Failed to redeem login code: %v", err)
+			c.String(http.StatusInternalServerError, "Failed to log in, please try again.")
+			return
+		}
+		setSessionCookie(c, token, int(time.Until(expires).Seconds()))
+		c.Redirect(http.StatusSeeOther, "/")
+	}
+}
+
+// LoginWidget handles the GET /login/telegram endpoint the Telegram Login
+// Widget redirects to. It checks the widget's data is signed with the bot's
+// token, starts a session for the user like Login and redirects to the
+// calendar.
+func LoginWidget(users *user.Service, sessions *login.Service, botToken string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		telegramID, err := login.VerifyWidget(c.Request.URL.Query(), botToken, time.Now())
+		if err != nil {
+			c.String(http.StatusUnauthorized, widgetLoginFailedMessage)
+			return
+		}
+		u, err := users.ByTelegramID(c.Request.Context(), telegramID)
+		if errors.Is(err, user.ErrNotFound) || (err == nil && !u.IsActive) {
+			c.String(http.StatusForbidden, widgetNotMemberMessage)
+			return
+		} else if err != nil {
+			log.Printf("[WEB_AUTH] Failed to look up widget user %d: %v", telegramID, err)
+			c.String(http.StatusInternalServerError, "Failed to log in, please try again.")
+			return
+		}
+		token, expires, err := sessions.Start(c.Request.Context(), u.ID)
+		if err != nil {
+			log.Printf("[WEB_AUTH] Failed to start a session for user %d: %v", u.ID, err)
+			c.String(http.StatusInternalServerError, "Failed to log in, please try again.")
+			return
+		}
+		setSessionCookie(c, token, int(time.Until(expires).Seconds()))
+		c.Redirect(http.StatusSeeOther, "/")
+	}
+}
+
+// GetSession handles the GET /api/v1/session endpoint. It tells the web app
+// who is signed in, or which bot the Telegram Login Widget signs in with.
+func GetSession(loginBot string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		u, ok := c.Request.Context().Value(middleware.UserKey).(*store.User)
+		if !ok || u == nil {
+			c.JSON(http.StatusOK, gin.H{"signed_in": false, "login_bot": loginBot})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"signed_in": true, "user_id": u.ID, "first_name": u.FirstName})
+	}
+}
+
+// Logout handles the POST /api/v1/logout endpoint. It ends the browser's
+// session, if it has one.
+func Logout(sessions *login.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if token, err := c.Cookie(middleware.SessionCookie); err == nil && token != "" {
+			if err := sessions.Logout(c.Request.Context(), token); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to log out"})
+				return
+			}
+		}
+		setSessionCookie(c, "", -1)
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// setSessionCookie sets the session cookie, or deletes it for a negative maxAge.
+func setSessionCookie(c *gin.Context, token string, maxAge int) {
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(middleware.SessionCookie, token, maxAge, "/", "", true, true)
+}
@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/korjavin/dutyassistant/internal/http/middleware"
+	"github.com/korjavin/dutyassistant/internal/service/login"
+	"github.com/korjavin/dutyassistant/internal/service/user"
+	"github.com/korjavin/dutyassistant/internal/store"
+	"github.com/korjavin/dutyassistant/internal/store/memory"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogin(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ctx := context.Background()
+	s := memory.New()
+	alice := &store.User{TelegramUserID: 1, FirstName: "Alice", IsActive: true}
+	s.CreateUser(ctx, alice)
+	sessions := login.New(s)
+	users := user.New(s)
+
+	router := gin.New()
+	router.GET("/login", Login(sessions))
+	router.POST("/api/v1/logout", Logout(sessions))
+	router.GET("/api/v1/users", middleware.OptionalAuth(users, sessions, ""), GetUsers(s))
+	router.GET("/api/v1/schedule/junior", middleware.Authenticate(users, sessions, ""), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/login?code=wrong", nil))
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Contains(t, w.Body.String(), "/login")
+
+	code, _ := sessions.IssueCode(ctx, alice.ID)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/login?code="+code, nil))
+	assert.Equal(t, http.StatusSeeOther, w.Code)
+	assert.Equal(t, "/", w.Header().Get("Location"))
+	cookies := w.Result().Cookies()
+	if assert.Len(t, cookies, 1) {
+		assert.Equal(t, middleware.SessionCookie, cookies[0].Name)
+		assert.True(t, cookies[0].HttpOnly)
+		assert.True(t, cookies[0].Secure)
+	}
+	session := cookies[0]
+
+	// The cookie signs the browser in like Telegram's initData would
+	w = httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/api/v1/schedule/junior", nil)
+	req.AddCookie(session)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = httptest.NewRecorder()
+	req = httptest.NewRequest("GET", "/api/v1/users", nil)
+	req.AddCookie(session)
+	router.ServeHTTP(w, req)
+	assert.Contains(t, w.Body.String(), "Alice")
+
+	w = httptest.NewRecorder()
+	req = httptest.NewRequest("POST", "/api/v1/logout", nil)
+	req.AddCookie(session)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+
+	w = httptest.NewRecorder()
+	req = httptest.NewRequest("GET", "/api/v1/schedule/junior", nil)
+	req.AddCookie(session)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestLoginWidget(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ctx := context.Background()
+	s := memory.New()
+	alice := &store.User{TelegramUserID: 456, FirstName: "Alice", IsActive: true}
+	s.CreateUser(ctx, alice)
+	sessions := login.New(s)
+	users := user.New(s)
+
+	router := gin.New()
+	router.GET("/login/telegram", LoginWidget(users, sessions, "token"))
+	router.GET("/api/v1/session", middleware.OptionalAuth(users, sessions, "token"), GetSession("duty_bot"))
+
+	// The data the widget appends to its auth URL, signed like Telegram does
+	widget := func(telegramID int64, botToken string) string {
+		data := url.Values{
+			"id":         {strconv.FormatInt(telegramID, 10)},
+			"first_name": {"Alice"},
+			"auth_date":  {strconv.FormatInt(time.Now().Unix(), 10)},
+		}
+		var fields []string
+		for key := range data {
+			fields = append(fields, key+"="+data.Get(key))
+		}
+		sort.Strings(fields)
+		secret := sha256.Sum256([]byte(botToken))
+		mac := hmac.New(sha256.New, secret[:])
+		mac.Write([]byte(strings.Join(fields, "\n")))
+		data.Set("hash", hex.EncodeToString(mac.Sum(nil)))
+		return "/login/telegram?" + data.Encode()
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/session", nil))
+	assert.JSONEq(t, `{"signed_in": false, "login_bot": "duty_bot"}`, w.Body.String())
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", widget(456, "other"), nil))
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", widget(789, "token"), nil))
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", widget(456, "token"), nil))
+	assert.Equal(t, http.StatusSeeOther, w.Code)
+	cookies := w.Result().Cookies()
+	if !assert.Len(t, cookies, 1) {
+		return
+	}
+
+	w = httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/api/v1/session", nil)
+	req.AddCookie(cookies[0])
+	router.ServeHTTP(w, req)
+	assert.JSONEq(t, `{"signed_in": true, "user_id": `+strconv.FormatInt(alice.ID, 10)+`, "first_name": "Alice"}`, w.Body.String())
+}
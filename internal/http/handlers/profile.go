@@ -0,0 +1,175 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/korjavin/dutyassistant/internal/http/middleware"
+	"github.com/korjavin/dutyassistant/internal/service/user"
+	"github.com/korjavin/dutyassistant/internal/store"
+)
+
+const (
+	// profileHistoryDays and profileUpcomingDays are the default range of
+	// GetUserDuties around today.
+	profileHistoryDays  = 90
+	profileUpcomingDays = 60
+	// profileMaxDays is the longest range GetUserDuties returns.
+	profileMaxDays = 366
+)
+
+// profileStats is the GetUserStats response.
+type profileStats struct {
+	TotalDuties      int     `json:"total_duties"`
+	DutiesThisMonth  int     `json:"duties_this_month"`
+	NextDutyDate     string  `json:"next_duty_date,omitempty"`
+	Completed        int     `json:"completed"`
+	Missed           int     `json:"missed"`
+	Volunteered      int     `json:"volunteered"`
+	CurrentStreak    int     `json:"current_streak"`
+	CompletionRate   float64 `json:"completion_rate"`
+	TasksDone        int     `json:"tasks_done"`
+	TaskPoints       int     `json:"task_points"`
+	HouseholdAverage float64 `json:"household_average"`
+}
+
+// profileDuty is a duty of the GetUserDuties response.
+type profileDuty struct {
+	Date           string `json:"date"`
+	AssignmentType string `json:"assignment_type"`
+	Status         string `json:"status"`
+	Completed      bool   `json:"completed"`
+	Retroactive    bool   `json:"retroactive,omitempty"`
+}
+
+// GetUserDuties handles the GET /api/v1/users/:id/duties?from=&to= endpoint
+// behind the web app's profile view. It returns a user's duties, or the
+// viewer's own for the ID "me", between two dates, YYYY-MM-DD and both
+// included, by default from 90 days ago to 60 days ahead. Juniors only get
+// their own.
+func GetUserDuties(users *user.Service, s store.DutyStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := profileUserID(c, "duties")
+		if !ok {
+			return
+		}
+
+		var err error
+		now := time.Now()
+		today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+		from, to := today.AddDate(0, 0, -profileHistoryDays), today.AddDate(0, 0, profileUpcomingDays)
+		for param, value := range map[string]*time.Time{"from": &from, "to": &to} {
+			raw := c.Query(param)
+			if raw == "" {
+				continue
+			}
+			if *value, err = time.Parse("2006-01-02", raw); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + param + " date, expected YYYY-MM-DD"})
+				return
+			}
+		}
+		if to.Before(from) || to.Sub(from) >= profileMaxDays*24*time.Hour {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "The range must end after it starts and span at most a year"})
+			return
+		}
+
+		u, err := users.ByID(c.Request.Context(), id)
+		if errors.Is(err, user.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		} else if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve user"})
+			return
+		}
+		duties, err := s.ListDuties(c.Request.Context(), store.DutyFilter{UserID: id, From: from, To: to.AddDate(0, 0, 1)})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve duties"})
+			return
+		}
+
+		items := make([]profileDuty, 0, len(duties))
+		for _, d := range duties {
+			items = append(items, profileDuty{
+				Date:           d.DutyDate.Format("2006-01-02"),
+				AssignmentType: string(d.AssignmentType),
+				Status:         string(d.Status),
+				Completed:      d.CompletedAt != nil,
+				Retroactive:    d.BackfilledAt != nil,
+			})
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"user":   gin.H{"id": u.ID, "name": u.FirstName, "emoji": u.Emoji},
+			"from":   from.Format("2006-01-02"),
+			"to":     to.Format("2006-01-02"),
+			"duties": items,
+		})
+	}
+}
+
+// GetUserStats handles the GET /api/v1/users/:id/stats endpoint behind the web
+// app's profile view. It returns a user's stats, or the viewer's own for the ID
+// "me". Juniors only get their own.
+func GetUserStats(users *user.Service, s store.UserStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := profileUserID(c, "stats")
+		if !ok {
+			return
+		}
+		u, err := users.ByID(c.Request.Context(), id)
+		if errors.Is(err, user.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		} else if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve user"})
+			return
+		}
+		stats, err := s.GetUserStats(c.Request.Context(), id)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve stats"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"user": gin.H{"id": u.ID, "name": u.FirstName, "emoji": u.Emoji},
+			"stats": profileStats{
+				TotalDuties:      stats.TotalDuties,
+				DutiesThisMonth:  stats.DutiesThisMonth,
+				NextDutyDate:     stats.NextDutyDate,
+				Completed:        stats.Completed,
+				Missed:           stats.Missed,
+				Volunteered:      stats.Volunteered,
+				CurrentStreak:    stats.CurrentStreak,
+				CompletionRate:   stats.CompletionRate(),
+				TasksDone:        stats.TasksDone,
+				TaskPoints:       stats.TaskPoints,
+				HouseholdAverage: stats.HouseholdAverage,
+			},
+		})
+	}
+}
+
+// profileUserID returns the ID of the user the profile request is about: the
+// :id parameter, or the viewer's own for "me". Unless it's ok, it has already
+// responded, to juniors asking about someone else with a refusal to show what.
+func profileUserID(c *gin.Context, what string) (int64, bool) {
+	viewer, ok := c.Request.Context().Value(middleware.UserKey).(*store.User)
+	if !ok || viewer == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return 0, false
+	}
+	id := viewer.ID
+	if raw := c.Param("id"); raw != "me" {
+		var err error
+		if id, err = strconv.ParseInt(raw, 10, 64); err != nil || id <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+			return 0, false
+		}
+	}
+	if viewer.IsJunior && !viewer.IsAdmin && viewer.ID != id {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You can only see your own " + what})
+		return 0, false
+	}
+	return id, true
+}
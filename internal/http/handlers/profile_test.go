@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/korjavin/dutyassistant/internal/http/middleware"
+	"github.com/korjavin/dutyassistant/internal/service/user"
+	"github.com/korjavin/dutyassistant/internal/store"
+	"github.com/korjavin/dutyassistant/internal/store/memory"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetUserDuties(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ctx := context.Background()
+	s := memory.New()
+	alice := &store.User{TelegramUserID: 1, FirstName: "Alice", IsActive: true}
+	kid := &store.User{TelegramUserID: 2, FirstName: "Kid", IsActive: true, IsJunior: true}
+	s.CreateUser(ctx, alice)
+	s.CreateUser(ctx, kid)
+	for day, userID := range map[int]int64{1: alice.ID, 2: kid.ID, 3: alice.ID, 20: alice.ID} {
+		s.CreateDuty(ctx, &store.Duty{UserID: userID, DutyDate: time.Date(2025, 7, day, 0, 0, 0, 0, time.UTC), AssignmentType: store.AssignmentTypeRoundRobin})
+	}
+	s.CompleteDuty(ctx, time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC))
+
+	newRouter := func(viewer *store.User) *gin.Engine {
+		router := gin.New()
+		router.GET("/api/v1/users/:id/duties", func(c *gin.Context) {
+			if viewer != nil {
+				c.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), middleware.UserKey, viewer))
+			}
+		}, GetUserDuties(user.New(s), s))
+		return router
+	}
+	get := func(router *gin.Engine, url string) (int, map[string]any) {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", url, nil))
+		var body map[string]any
+		json.Unmarshal(w.Body.Bytes(), &body)
+		return w.Code, body
+	}
+
+	aliceURL := "/api/v1/users/" + strconv.FormatInt(alice.ID, 10) + "/duties"
+	code, body := get(newRouter(alice), aliceURL+"?from=2025-07-01&to=2025-07-03")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, []any{
+		map[string]any{"date": "2025-07-01", "assignment_type": "round_robin", "status": "completed", "completed": true},
+		map[string]any{"date": "2025-07-03", "assignment_type": "round_robin", "status": "announced", "completed": false},
+	}, body["duties"])
+	assert.Equal(t, "Alice", body["user"].(map[string]any)["name"])
+
+	code, body = get(newRouter(kid), "/api/v1/users/me/duties?from=2025-07-01&to=2025-07-31")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Len(t, body["duties"], 1)
+
+	code, _ = get(newRouter(kid), aliceURL)
+	assert.Equal(t, http.StatusForbidden, code, "juniors only see their own duties")
+	code, _ = get(newRouter(nil), aliceURL)
+	assert.Equal(t, http.StatusUnauthorized, code)
+	code, _ = get(newRouter(alice), aliceURL+"?from=2025-07-03&to=2025-07-01")
+	assert.Equal(t, http.StatusBadRequest, code)
+	code, _ = get(newRouter(alice), aliceURL+"?from=2024-01-01&to=2025-07-01")
+	assert.Equal(t, http.StatusBadRequest, code)
+	code, _ = get(newRouter(alice), "/api/v1/users/999/duties")
+	assert.Equal(t, http.StatusNotFound, code)
+}
+
+func TestGetUserStats(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ctx := context.Background()
+	s := memory.New()
+	alice := &store.User{TelegramUserID: 1, FirstName: "Alice", IsActive: true}
+	kid := &store.User{TelegramUserID: 2, FirstName: "Kid", IsActive: true, IsJunior: true}
+	s.CreateUser(ctx, alice)
+	s.CreateUser(ctx, kid)
+	s.CreateDuty(ctx, &store.Duty{UserID: alice.ID, DutyDate: time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC), AssignmentType: store.AssignmentTypeVoluntary})
+	s.CreateDuty(ctx, &store.Duty{UserID: alice.ID, DutyDate: time.Date(2025, 7, 2, 0, 0, 0, 0, time.UTC), AssignmentType: store.AssignmentTypeRoundRobin})
+	s.CompleteDuty(ctx, time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC))
+
+	newRouter := func(viewer *store.User) *gin.Engine {
+		router := gin.New()
+		router.GET("/api/v1/users/:id/stats", func(c *gin.Context) {
+			if viewer != nil {
+				c.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), middleware.UserKey, viewer))
+			}
+		}, GetUserStats(user.New(s), s))
+		return router
+	}
+	get := func(router *gin.Engine, url string) (int, map[string]any) {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", url, nil))
+		var body map[string]any
+		json.Unmarshal(w.Body.Bytes(), &body)
+		return w.Code, body
+	}
+
+	aliceURL := "/api/v1/users/" + strconv.FormatInt(alice.ID, 10) + "/stats"
+	code, body := get(newRouter(alice), aliceURL)
+	assert.Equal(t, http.StatusOK, code)
+	stats := body["stats"].(map[string]any)
+	assert.Equal(t, float64(2), stats["total_duties"])
+	assert.Equal(t, float64(1), stats["completed"])
+	assert.Equal(t, float64(1), stats["volunteered"])
+	assert.Equal(t, "Alice", body["user"].(map[string]any)["name"])
+
+	code, body = get(newRouter(kid), "/api/v1/users/me/stats")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, float64(0), body["stats"].(map[string]any)["total_duties"])
+
+	code, body = get(newRouter(kid), aliceURL)
+	assert.Equal(t, http.StatusForbidden, code, "juniors only see their own stats")
+	assert.Equal(t, "You can only see your own stats", body["error"])
+	code, _ = get(newRouter(nil), aliceURL)
+	assert.Equal(t, http.StatusUnauthorized, code)
+	code, _ = get(newRouter(alice), "/api/v1/users/999/stats")
+	assert.Equal(t, http.StatusNotFound, code)
+}
@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/korjavin/dutyassistant/internal/service/queue"
+)
+
+// apiQueueWait sums up how the days of a queue waited, in hours.
+type apiQueueWait struct {
+	Added            int     `json:"added"`
+	Consumed         int     `json:"consumed"`
+	Untracked        int     `json:"untracked"`
+	Pending          int     `json:"pending"`
+	AverageWaitHours float64 `json:"average_wait_hours"`
+	MaxWaitHours     float64 `json:"max_wait_hours"`
+	OldestPending    *string `json:"oldest_pending,omitempty"`
+}
+
+func newAPIQueueWait(w queue.Wait) apiQueueWait {
+	item := apiQueueWait{
+		Added:            w.Added,
+		Consumed:         w.Consumed,
+		Untracked:        w.Untracked,
+		Pending:          w.Pending,
+		AverageWaitHours: hours(w.Average),
+		MaxWaitHours:     hours(w.Max),
+	}
+	if w.Oldest != nil {
+		oldest := w.Oldest.UTC().Format(time.RFC3339)
+		item.OldestPending = &oldest
+	}
+	return item
+}
+
+// apiConsumption is a queued day a duty used up.
+type apiConsumption struct {
+	Queue     string  `json:"queue"`
+	AddedAt   *string `json:"added_at"` // null if added before changes were recorded
+	At        string  `json:"consumed_at"`
+	WaitHours float64 `json:"wait_hours"`
+}
+
+// apiUserQueues are the metrics of a user's queues.
+type apiUserQueues struct {
+	UserID    int64            `json:"user_id"`
+	Name      string           `json:"name"`
+	Volunteer apiQueueWait     `json:"volunteer"`
+	Admin     apiQueueWait     `json:"admin"`
+	History   []apiConsumption `json:"history"`
+}
+
+// hours returns d in hours, rounded to a tenth.
+func hours(d time.Duration) float64 {
+	return math.Round(d.Hours()*10) / 10
+}
+
+// AdminGetQueueMetrics handles the GET /api/v1/queues/metrics endpoint. It
+// returns how long the days of the volunteer and admin queues waited before
+// a duty used them up, overall and per user, with each user's history of
+// days used up; with ?user_id= those of one user only.
+func AdminGetQueueMetrics(queues *queue.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var userID int64
+		if raw := c.Query("user_id"); raw != "" {
+			var err error
+			userID, err = strconv.ParseInt(raw, 10, 64)
+			if err != nil || userID <= 0 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user_id"})
+				return
+			}
+		}
+
+		m, err := queues.Metrics(c.Request.Context(), userID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve queue metrics"})
+			return
+		}
+
+		users := make([]apiUserQueues, 0, len(m.Users))
+		for _, um := range m.Users {
+			item := apiUserQueues{
+				UserID:    um.User.ID,
+				Name:      um.User.FirstName,
+				Volunteer: newAPIQueueWait(um.Volunteer),
+				Admin:     newAPIQueueWait(um.Admin),
+				History:   make([]apiConsumption, 0, len(um.History)),
+			}
+			for _, h := range um.History {
+				consumption := apiConsumption{Queue: string(h.Queue), At: h.At.UTC().Format(time.RFC3339), WaitHours: hours(h.Wait)}
+				if h.AddedAt != nil {
+					added := h.AddedAt.UTC().Format(time.RFC3339)
+					consumption.AddedAt = &added
+				}
+				item.History = append(item.History, consumption)
+			}
+			users = append(users, item)
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"volunteer": newAPIQueueWait(m.Volunteer),
+			"admin":     newAPIQueueWait(m.Admin),
+			"users":     users,
+		})
+	}
+}
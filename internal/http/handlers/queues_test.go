@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/korjavin/dutyassistant/internal/service/queue"
+	"github.com/korjavin/dutyassistant/internal/store"
+	"github.com/korjavin/dutyassistant/internal/store/memory"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAdminGetQueueMetrics(t *testing.T) {
+	ctx := context.Background()
+	s := memory.New()
+	alice := &store.User{TelegramUserID: 1, FirstName: "Alice", IsActive: true}
+	bob := &store.User{TelegramUserID: 2, FirstName: "Bob", IsActive: true}
+	s.CreateUser(ctx, alice)
+	s.CreateUser(ctx, bob)
+	s.AddToVolunteerQueue(ctx, alice.ID, 2)
+	s.DecrementVolunteerQueue(ctx, alice.ID)
+	s.AddToAdminQueue(ctx, bob.ID, 1)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/queues/metrics", AdminGetQueueMetrics(queue.New(s)))
+
+	get := func(url string) (int, map[string]any) {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, url, nil))
+		var body map[string]any
+		json.Unmarshal(w.Body.Bytes(), &body)
+		return w.Code, body
+	}
+
+	code, body := get("/queues/metrics")
+	assert.Equal(t, http.StatusOK, code)
+	volunteer := body["volunteer"].(map[string]any)
+	assert.Equal(t, float64(2), volunteer["added"])
+	assert.Equal(t, float64(1), volunteer["consumed"])
+	assert.Equal(t, float64(1), volunteer["pending"])
+	assert.NotNil(t, volunteer["oldest_pending"])
+	assert.Equal(t, float64(1), body["admin"].(map[string]any)["pending"])
+
+	users := body["users"].([]any)
+	if assert.Len(t, users, 2) {
+		a := users[0].(map[string]any)
+		assert.Equal(t, "Alice", a["name"])
+		history := a["history"].([]any)
+		if assert.Len(t, history, 1) {
+			assert.Equal(t, "voluntary", history[0].(map[string]any)["queue"])
+			assert.NotNil(t, history[0].(map[string]any)["added_at"])
+		}
+	}
+
+	code, body = get("/queues/metrics?user_id=2")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Len(t, body["users"], 1)
+	assert.Equal(t, float64(0), body["volunteer"].(map[string]any)["added"])
+
+	code, _ = get("/queues/metrics?user_id=bob")
+	assert.Equal(t, http.StatusBadRequest, code)
+}
@@ -1,18 +1,90 @@
 package handlers
 
 import (
+	"fmt"
 	"net/http"
+	"slices"
 	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/korjavin/dutyassistant/internal/http/middleware"
+	"github.com/korjavin/dutyassistant/internal/i18n"
+	"github.com/korjavin/dutyassistant/internal/notification"
+	"github.com/korjavin/dutyassistant/internal/service/week"
 	"github.com/korjavin/dutyassistant/internal/store"
 )
 
+// scheduleDuty is a duty in the frontend-friendly format returned by GetSchedule.
+// User details live once in the response's users map instead of on every duty.
+// Fields left out via ?fields= are zeroed and omitted.
+type scheduleDuty struct {
+	ID             int64  `json:"id,omitempty"`
+	Date           string `json:"date,omitempty"`
+	UserID         int64  `json:"user_id,omitempty"`
+	AssignmentType string `json:"assignment_type,omitempty"`
+	Status         string `json:"status,omitempty"`
+	Retroactive    bool   `json:"retroactive,omitempty"` // Backfilled by an admin after the fact
+	Highlighted    bool   `json:"highlighted,omitempty"` // Belongs to the user picked with ?user_id=
+}
+
+// scheduleUser is a user referenced by the duties in a schedule response.
+type scheduleUser struct {
+	Name               string `json:"name"`
+	Emoji              string `json:"emoji,omitempty"`
+	VolunteerQueueDays int    `json:"volunteer_queue_days"`
+	AdminQueueDays     int    `json:"admin_queue_days"`
+}
+
+// scheduleSkipDay is a day an admin deliberately left without duty.
+type scheduleSkipDay struct {
+	Date   string `json:"date"`
+	Reason string `json:"reason"`
+}
+
+// scheduleFields are the duty fields a client can select with ?fields=.
+var scheduleFields = []string{"id", "date", "user_id", "assignment_type", "status", "retroactive"}
+
+// parseScheduleFields parses a comma-separated ?fields= value. An empty value selects everything.
+func parseScheduleFields(raw string) (map[string]bool, error) {
+	selected := make(map[string]bool)
+	if strings.TrimSpace(raw) == "" {
+		for _, f := range scheduleFields {
+			selected[f] = true
+		}
+		return selected, nil
+	}
+	for _, f := range strings.Split(raw, ",") {
+		f = strings.TrimSpace(f)
+		if !slices.Contains(scheduleFields, f) {
+			return nil, fmt.Errorf("unknown field %q, expected one of %s", f, strings.Join(scheduleFields, ", "))
+		}
+		selected[f] = true
+	}
+	return selected, nil
+}
+
+// scheduleBuffers reuses response slices between requests. The schedule is
+// the most requested endpoint and a month never has more than 31 duties.
+var scheduleBuffers = sync.Pool{
+	New: func() any {
+		buf := make([]scheduleDuty, 0, 31)
+		return &buf
+	},
+}
+
 // GetSchedule handles the GET /api/v1/schedule/:year/:month endpoint.
-// It retrieves the duty schedule for a given month and year.
-func GetSchedule(s store.Store) gin.HandlerFunc {
+// It retrieves the duty schedule for a given month and year. The optional
+// ?fields=date,user_id query limits the duty fields returned, which keeps
+// payloads small for always-on displays; users are only included when
+// user_id is selected. Skip days are always included. With ?user_id=, that
+// user's duties are marked as highlighted so clients can dim the others.
+// ?format=text returns the schedule as plain text instead, one line per day,
+// for screen readers and e-ink displays. Names are hidden from unauthorized
+// viewers, and from everyone in minimal PII mode.
+func GetSchedule(s store.DutyStore) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		year, err := strconv.Atoi(c.Param("year"))
 		if err != nil {
@@ -31,61 +103,186 @@ func GetSchedule(s store.Store) gin.HandlerFunc {
 			return
 		}
 
+		format := c.DefaultQuery("format", "json")
+		if format != "json" && format != "text" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid format, expected json or text"})
+			return
+		}
+
+		fields, err := parseScheduleFields(c.Query("fields"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		var highlightUserID int64
+		if raw := c.Query("user_id"); raw != "" {
+			highlightUserID, err = strconv.ParseInt(raw, 10, 64)
+			if err != nil || highlightUserID <= 0 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user_id"})
+				return
+			}
+		}
+
 		duties, err := s.GetDutiesByMonth(c.Request.Context(), year, time.Month(month))
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve schedule"})
 			return
 		}
 
+		skipDays, err := s.GetSkipDaysByMonth(c.Request.Context(), year, time.Month(month))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve schedule"})
+			return
+		}
+		skips := make([]scheduleSkipDay, 0, len(skipDays))
+		for _, skip := range skipDays {
+			skips = append(skips, scheduleSkipDay{Date: skip.Date.Format(time.RFC3339), Reason: string(skip.Reason)})
+		}
+
 		// Check if user is authenticated
 		user, authenticated := c.Request.Context().Value(middleware.UserKey).(*store.User)
-		// Allow admins or active users
-		isAuthorized := authenticated && user != nil && (user.IsActive || user.IsAdmin)
+		// Allow admins or active users, unless the board is public
+		isAuthorized := authenticated && user != nil && (user.IsActive || user.IsAdmin) &&
+			!middleware.IsMinimalPII(c.Request.Context())
 
-		// Transform to frontend-friendly format
-		type dutyResponse struct {
-			ID                 int64  `json:"id"`
-			Date               string `json:"date"`
-			UserID             int64  `json:"user_id"`
-			UserName           string `json:"user_name"`
-			AssignmentType     string `json:"assignment_type"`
-			VolunteerQueueDays int    `json:"volunteer_queue_days"`
-			AdminQueueDays     int    `json:"admin_queue_days"`
+		if format == "text" {
+			now := time.Now()
+			today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+			first := time.Date(year, time.Month(month), 1, 0, 0, 0, 0, time.UTC)
+			// Like the rest of the web app, the text is in English
+			names := notification.NamesHidden
+			if isAuthorized {
+				names = notification.NamesFull
+			}
+			c.String(http.StatusOK, notification.FormatScheduleText(i18n.English, first, duties, skipDays, today, names))
+			return
 		}
 
-		response := make([]dutyResponse, 0, len(duties))
+		// Transform to frontend-friendly format
+		buf := scheduleBuffers.Get().(*[]scheduleDuty)
+		defer func() {
+			*buf = (*buf)[:0]
+			scheduleBuffers.Put(buf)
+		}()
+
+		response := *buf
+		users := make(map[int64]scheduleUser)
 		for _, duty := range duties {
-			userName := ""
-			volunteerQueue := 0
-			adminQueue := 0
-
-			// Only include user details if authorized
-			if isAuthorized && duty.User != nil {
-				userName = duty.User.FirstName
-				volunteerQueue = duty.User.VolunteerQueueDays
-				adminQueue = duty.User.AdminQueueDays
-			} else if duty.User != nil {
-				userName = "***" // Anonymous placeholder
+			item := scheduleDuty{}
+			if fields["id"] {
+				item.ID = duty.ID
+			}
+			if fields["date"] {
+				item.Date = duty.DutyDate.Format(time.RFC3339)
+			}
+			if fields["user_id"] {
+				item.UserID = duty.UserID
+			}
+			if fields["assignment_type"] {
+				item.AssignmentType = string(duty.AssignmentType)
 			}
+			if fields["status"] {
+				item.Status = string(duty.Status)
+			}
+			if fields["retroactive"] {
+				item.Retroactive = duty.BackfilledAt != nil
+			}
+			item.Highlighted = highlightUserID != 0 && duty.UserID == highlightUserID
+			response = append(response, item)
 
-			response = append(response, dutyResponse{
-				ID:                 duty.ID,
-				Date:               duty.DutyDate.Format(time.RFC3339),
-				UserID:             duty.UserID,
-				UserName:           userName,
-				AssignmentType:     string(duty.AssignmentType),
-				VolunteerQueueDays: volunteerQueue,
-				AdminQueueDays:     adminQueue,
-			})
+			if !fields["user_id"] || duty.User == nil {
+				continue
+			}
+			// Only include user details if authorized, and juniors only see
+			// their own queues
+			if isAuthorized && user.IsJunior && !user.IsAdmin && duty.UserID != user.ID {
+				users[duty.UserID] = scheduleUser{Name: duty.User.FirstName, Emoji: duty.User.Emoji}
+			} else if isAuthorized {
+				users[duty.UserID] = scheduleUser{
+					Name:               duty.User.FirstName,
+					Emoji:              duty.User.Emoji,
+					VolunteerQueueDays: duty.User.VolunteerQueueDays,
+					AdminQueueDays:     duty.User.AdminQueueDays,
+				}
+			} else {
+				users[duty.UserID] = scheduleUser{Name: "***"} // Anonymous placeholder
+			}
 		}
 
-		c.JSON(http.StatusOK, gin.H{"duties": response})
+		*buf = response
+		body := gin.H{"duties": response, "skip_days": skips}
+		if fields["user_id"] {
+			body["users"] = users
+		}
+		if highlightUserID != 0 {
+			body["highlight_user_id"] = highlightUserID
+		}
+		c.JSON(http.StatusOK, body)
+	}
+}
+
+// weekDay is one day of the GetWeek response.
+type weekDay struct {
+	Date       string   `json:"date"`
+	Weekday    string   `json:"weekday"`
+	UserID     int64    `json:"user_id,omitempty"`
+	UserName   string   `json:"user_name,omitempty"`
+	Completed  bool     `json:"completed,omitempty"`
+	Status     string   `json:"status,omitempty"`
+	SkipReason string   `json:"skip_reason,omitempty"`
+	Bins       []string `json:"bins,omitempty"`
+}
+
+// GetWeek handles the GET /api/v1/schedule/week endpoint. It returns who is on
+// duty each day of the current week, Monday to Sunday, along with the same
+// text summary the bot's /week command sends. Names are hidden from
+// unauthorized viewers and in minimal PII mode, as in GetSchedule.
+func GetWeek(s store.DutyStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		now := time.Now()
+		w, err := week.Load(c.Request.Context(), s, now)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve schedule"})
+			return
+		}
+
+		user, authenticated := c.Request.Context().Value(middleware.UserKey).(*store.User)
+		isAuthorized := authenticated && user != nil && (user.IsActive || user.IsAdmin) &&
+			!middleware.IsMinimalPII(c.Request.Context())
+
+		days := make([]weekDay, 0, len(w.Days))
+		for _, day := range w.Days {
+			item := weekDay{Date: day.Date.Format(time.RFC3339), Weekday: day.Date.Format("Mon"), Bins: day.Bins}
+			if day.Duty != nil {
+				item.UserID = day.Duty.UserID
+				item.Completed = day.Duty.CompletedAt != nil
+				item.Status = string(day.Duty.Status)
+				if day.Duty.User != nil {
+					item.UserName = "***" // Anonymous placeholder
+					if isAuthorized {
+						item.UserName = day.Duty.User.FirstName
+					}
+				}
+			} else if day.Skip != nil {
+				item.SkipReason = string(day.Skip.Reason)
+			}
+			days = append(days, item)
+		}
+
+		text := ""
+		if isAuthorized {
+			today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+			// Like the rest of the web app, the text is in English
+			text = notification.FormatWeek(i18n.English, w, today, notification.NamesFull)
+		}
+		c.JSON(http.StatusOK, gin.H{"start": w.Start.Format(time.RFC3339), "days": days, "text": text})
 	}
 }
 
 // GetPrognosis handles the GET /api/v1/prognosis/:year/:month endpoint.
 // It returns an empty prognosis for now (feature not yet implemented).
-func GetPrognosis(s store.Store) gin.HandlerFunc {
+func GetPrognosis(s store.DutyStore) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		_, err := strconv.Atoi(c.Param("year"))
 		if err != nil {
@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/korjavin/dutyassistant/internal/http/middleware"
+	"github.com/korjavin/dutyassistant/internal/store"
+	"github.com/korjavin/dutyassistant/internal/store/sqlite"
+)
+
+// newScheduleBenchRouter serves GET /schedule from an on-disk database holding
+// five years of daily duties, behind the same gzip middleware as production.
+func newScheduleBenchRouter(b *testing.B) *gin.Engine {
+	b.Helper()
+	ctx := context.Background()
+	s, err := sqlite.New(ctx, filepath.Join(b.TempDir(), "bench.db"))
+	if err != nil {
+		b.Fatalf("Failed to create database: %v", err)
+	}
+
+	var ids []int64
+	for i := 0; i < 6; i++ {
+		u := &store.User{TelegramUserID: int64(i + 1), FirstName: fmt.Sprintf("User %d", i), IsActive: true}
+		if err := s.CreateUser(ctx, u); err != nil {
+			b.Fatalf("CreateUser failed: %v", err)
+		}
+		ids = append(ids, u.ID)
+	}
+	end := time.Date(2025, 12, 31, 0, 0, 0, 0, time.UTC)
+	for i, date := 0, end.AddDate(-5, 0, 1); !date.After(end); i, date = i+1, date.AddDate(0, 0, 1) {
+		duty := &store.Duty{UserID: ids[i%len(ids)], DutyDate: date, AssignmentType: store.AssignmentTypeRoundRobin, CreatedAt: date}
+		if err := s.CreateDuty(ctx, duty); err != nil {
+			b.Fatalf("CreateDuty failed: %v", err)
+		}
+	}
+
+	gin.SetMode(gin.ReleaseMode)
+	router := gin.New()
+	router.Use(middleware.Gzip())
+	router.GET("/api/v1/schedule/:year/:month", GetSchedule(s))
+	return router
+}
+
+func scheduleBenchRequest(i int, gzip bool) *http.Request {
+	req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/api/v1/schedule/%d/%d", 2021+i%5, 1+i%12), nil)
+	if gzip {
+		req.Header.Set("Accept-Encoding", "gzip")
+	}
+	return req
+}
+
+func BenchmarkGetSchedule(b *testing.B) {
+	router := newScheduleBenchRouter(b)
+
+	for _, gzip := range []bool{false, true} {
+		b.Run(fmt.Sprintf("gzip=%v", gzip), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				w := httptest.NewRecorder()
+				router.ServeHTTP(w, scheduleBenchRequest(i, gzip))
+				if w.Code != http.StatusOK {
+					b.Fatalf("Unexpected status %d", w.Code)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkGetScheduleParallel simulates concurrent clients and reports the
+// 95th percentile latency alongside the usual per-operation numbers.
+func BenchmarkGetScheduleParallel(b *testing.B) {
+	router := newScheduleBenchRouter(b)
+
+	var mu sync.Mutex
+	var latencies []time.Duration
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		var local []time.Duration
+		for i := 0; pb.Next(); i++ {
+			start := time.Now()
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, scheduleBenchRequest(i, true))
+			local = append(local, time.Since(start))
+			if w.Code != http.StatusOK {
+				b.Errorf("Unexpected status %d", w.Code)
+				return
+			}
+		}
+		mu.Lock()
+		latencies = append(latencies, local...)
+		mu.Unlock()
+	})
+	b.StopTimer()
+
+	if len(latencies) > 0 {
+		sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+		b.ReportMetric(float64(latencies[len(latencies)*95/100].Microseconds()), "p95-µs")
+	}
+}
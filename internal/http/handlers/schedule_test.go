@@ -0,0 +1,286 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/korjavin/dutyassistant/internal/http/middleware"
+	"github.com/korjavin/dutyassistant/internal/service/week"
+	"github.com/korjavin/dutyassistant/internal/store"
+	"github.com/korjavin/dutyassistant/internal/store/memory"
+	"github.com/stretchr/testify/assert"
+)
+
+// newScheduleRouter serves GET /schedule from a store holding two October duties
+// by the same user and one skip day, after the given middleware.
+func newScheduleRouter(t *testing.T, viewer *store.User, mw ...gin.HandlerFunc) *gin.Engine {
+	t.Helper()
+	ctx := context.Background()
+	s := memory.New()
+	alice := &store.User{TelegramUserID: 1, FirstName: "Alice", IsActive: true, VolunteerQueueDays: 2}
+	s.CreateUser(ctx, alice)
+	for _, day := range []int{24, 25} {
+		s.CreateDuty(ctx, &store.Duty{
+			UserID:         alice.ID,
+			DutyDate:       time.Date(2025, 10, day, 0, 0, 0, 0, time.UTC),
+			AssignmentType: store.AssignmentTypeVoluntary,
+			CreatedAt:      time.Now(),
+		})
+	}
+	s.SetSkipDay(ctx, &store.SkipDay{Date: time.Date(2025, 10, 31, 0, 0, 0, 0, time.UTC), Reason: store.SkipReasonEatingOut, CreatedAt: time.Now()})
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(mw...)
+	router.GET("/schedule/:year/:month", func(c *gin.Context) {
+		if viewer != nil {
+			c.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), middleware.UserKey, viewer))
+		}
+	}, GetSchedule(s))
+	return router
+}
+
+type scheduleBody struct {
+	Duties   []map[string]any          `json:"duties"`
+	Users    map[string]map[string]any `json:"users"`
+	SkipDays []map[string]any          `json:"skip_days"`
+}
+
+func getScheduleBody(t *testing.T, router *gin.Engine, url string) (int, scheduleBody) {
+	t.Helper()
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, url, nil))
+	var body scheduleBody
+	json.Unmarshal(w.Body.Bytes(), &body)
+	return w.Code, body
+}
+
+func TestGetSchedule_UsersReferencedOnce(t *testing.T) {
+	router := newScheduleRouter(t, &store.User{ID: 99, IsActive: true})
+
+	code, body := getScheduleBody(t, router, "/schedule/2025/10")
+	assert.Equal(t, http.StatusOK, code)
+	if !assert.Len(t, body.Duties, 2) {
+		return
+	}
+	assert.Equal(t, "2025-10-24T00:00:00Z", body.Duties[0]["date"])
+	assert.Equal(t, "voluntary", body.Duties[0]["assignment_type"])
+	assert.NotContains(t, body.Duties[0], "user_name")
+
+	userID := body.Duties[0]["user_id"]
+	assert.Equal(t, userID, body.Duties[1]["user_id"])
+	assert.Len(t, body.Users, 1)
+	for _, u := range body.Users {
+		assert.Equal(t, "Alice", u["name"])
+		assert.EqualValues(t, 2, u["volunteer_queue_days"])
+	}
+}
+
+func TestGetSchedule_AnonymousViewer(t *testing.T) {
+	router := newScheduleRouter(t, nil)
+
+	_, body := getScheduleBody(t, router, "/schedule/2025/10")
+	for _, u := range body.Users {
+		assert.Equal(t, "***", u["name"])
+		assert.EqualValues(t, 0, u["volunteer_queue_days"])
+	}
+}
+
+func TestGetSchedule_MinimalPII(t *testing.T) {
+	router := newScheduleRouter(t, &store.User{ID: 99, IsActive: true, IsAdmin: true}, middleware.MinimalPII())
+
+	_, body := getScheduleBody(t, router, "/schedule/2025/10")
+	if assert.Len(t, body.Users, 1) {
+		for _, u := range body.Users {
+			assert.Equal(t, "***", u["name"], "names are hidden even from admins")
+			assert.EqualValues(t, 0, u["volunteer_queue_days"])
+		}
+	}
+}
+
+func TestGetSchedule_JuniorViewer(t *testing.T) {
+	router := newScheduleRouter(t, &store.User{ID: 99, IsActive: true, IsJunior: true})
+
+	_, body := getScheduleBody(t, router, "/schedule/2025/10")
+	for _, u := range body.Users {
+		assert.Equal(t, "Alice", u["name"])
+		assert.EqualValues(t, 0, u["volunteer_queue_days"], "juniors don't see other people's queues")
+	}
+}
+
+func TestGetSchedule_Fields(t *testing.T) {
+	router := newScheduleRouter(t, &store.User{ID: 99, IsActive: true})
+
+	code, body := getScheduleBody(t, router, "/schedule/2025/10?fields=date")
+	assert.Equal(t, http.StatusOK, code)
+	if assert.Len(t, body.Duties, 2) {
+		assert.Equal(t, map[string]any{"date": "2025-10-24T00:00:00Z"}, body.Duties[0])
+	}
+	assert.Nil(t, body.Users, "users are only sent when user_id is selected")
+
+	_, body = getScheduleBody(t, router, "/schedule/2025/10?fields=date,user_id")
+	assert.Len(t, body.Duties[0], 2)
+	assert.Len(t, body.Users, 1)
+
+	code, _ = getScheduleBody(t, router, "/schedule/2025/10?fields=date,password")
+	assert.Equal(t, http.StatusBadRequest, code)
+}
+
+func TestGetSchedule_SkipDays(t *testing.T) {
+	router := newScheduleRouter(t, nil)
+
+	// Skip days are listed even when only some duty fields are selected
+	code, body := getScheduleBody(t, router, "/schedule/2025/10?fields=date")
+	assert.Equal(t, http.StatusOK, code)
+	if assert.Len(t, body.SkipDays, 1) {
+		assert.Equal(t, "2025-10-31T00:00:00Z", body.SkipDays[0]["date"])
+		assert.Equal(t, "eating_out", body.SkipDays[0]["reason"])
+	}
+
+	_, body = getScheduleBody(t, router, "/schedule/2025/11")
+	assert.NotNil(t, body.SkipDays, "an empty month still has a skip_days list")
+	assert.Empty(t, body.SkipDays)
+}
+
+func TestGetSchedule_HighlightUser(t *testing.T) {
+	router := newScheduleRouter(t, nil)
+
+	// Alice is the only user in the store, so she has ID 1
+	code, body := getScheduleBody(t, router, "/schedule/2025/10?user_id=1")
+	assert.Equal(t, http.StatusOK, code)
+	if assert.Len(t, body.Duties, 2, "other users' duties are kept so they can be dimmed") {
+		assert.Equal(t, true, body.Duties[0]["highlighted"])
+		assert.Equal(t, true, body.Duties[1]["highlighted"])
+	}
+
+	_, body = getScheduleBody(t, router, "/schedule/2025/10?user_id=2")
+	for _, d := range body.Duties {
+		assert.NotContains(t, d, "highlighted")
+	}
+
+	for _, raw := range []string{"abc", "0", "-1"} {
+		code, _ = getScheduleBody(t, router, "/schedule/2025/10?user_id="+raw)
+		assert.Equal(t, http.StatusBadRequest, code, "user_id=%s", raw)
+	}
+}
+
+func TestGetSchedule_Text(t *testing.T) {
+	get := func(router *gin.Engine, url string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, url, nil))
+		return w
+	}
+
+	w := get(newScheduleRouter(t, &store.User{ID: 99, IsActive: true}), "/schedule/2025/10?format=text")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/plain; charset=utf-8", w.Header().Get("Content-Type"))
+	text := w.Body.String()
+	assert.True(t, strings.HasPrefix(text, "Duty schedule for October 2025\n\nWednesday, October 1: nobody assigned\n"), text)
+	assert.Contains(t, text, "\nFriday, October 24: Alice, missed\n")
+	assert.True(t, strings.HasSuffix(text, "\nFriday, October 31: no duty, eating out"), text)
+
+	w = get(newScheduleRouter(t, nil), "/schedule/2025/10?format=text")
+	assert.Contains(t, w.Body.String(), "\nFriday, October 24: someone, missed\n")
+	assert.NotContains(t, w.Body.String(), "Alice")
+
+	w = get(newScheduleRouter(t, nil), "/schedule/2025/10?format=csv")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestGetWeek(t *testing.T) {
+	ctx := context.Background()
+	s := memory.New()
+	alice := &store.User{TelegramUserID: 1, FirstName: "Alice", IsActive: true}
+	s.CreateUser(ctx, alice)
+	monday := week.Start(time.Now())
+	s.CreateDuty(ctx, &store.Duty{UserID: alice.ID, DutyDate: monday, AssignmentType: store.AssignmentTypeRoundRobin})
+	s.CompleteDuty(ctx, monday)
+	s.SetSkipDay(ctx, &store.SkipDay{Date: monday.AddDate(0, 0, 1), Reason: store.SkipReasonAway})
+
+	gin.SetMode(gin.TestMode)
+	for _, tc := range []struct {
+		name     string
+		viewer   *store.User
+		wantName string
+	}{
+		{"member", &store.User{ID: 99, IsActive: true}, "Alice"},
+		{"anonymous", nil, "***"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			router := gin.New()
+			router.GET("/schedule/week", func(c *gin.Context) {
+				if tc.viewer != nil {
+					c.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), middleware.UserKey, tc.viewer))
+				}
+			}, GetWeek(s))
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/schedule/week", nil))
+			assert.Equal(t, http.StatusOK, w.Code)
+
+			var body struct {
+				Start string           `json:"start"`
+				Days  []map[string]any `json:"days"`
+				Text  string           `json:"text"`
+			}
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+			assert.Equal(t, monday.Format(time.RFC3339), body.Start)
+			if !assert.Len(t, body.Days, 7) {
+				return
+			}
+			assert.Equal(t, "Mon", body.Days[0]["weekday"])
+			assert.Equal(t, tc.wantName, body.Days[0]["user_name"])
+			assert.Equal(t, true, body.Days[0]["completed"])
+			assert.Equal(t, "away", body.Days[1]["skip_reason"])
+			assert.NotContains(t, body.Days[2], "user_id")
+			if tc.viewer != nil {
+				assert.Contains(t, body.Text, "Mon: Alice ✅")
+			} else {
+				assert.Empty(t, body.Text)
+			}
+		})
+	}
+}
+
+func TestGetJuniorWeek(t *testing.T) {
+	ctx := context.Background()
+	s := memory.New()
+	alice := &store.User{TelegramUserID: 1, FirstName: "Alice", IsActive: true, IsJunior: true}
+	bob := &store.User{TelegramUserID: 2, FirstName: "Bob", IsActive: true}
+	s.CreateUser(ctx, alice)
+	s.CreateUser(ctx, bob)
+	monday := week.Start(time.Now())
+	s.CreateDuty(ctx, &store.Duty{UserID: alice.ID, DutyDate: monday, AssignmentType: store.AssignmentTypeRoundRobin})
+	s.CompleteDuty(ctx, monday)
+	s.CreateDuty(ctx, &store.Duty{UserID: bob.ID, DutyDate: monday.AddDate(0, 0, 1), AssignmentType: store.AssignmentTypeRoundRobin})
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/schedule/junior", func(c *gin.Context) {
+		c.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), middleware.UserKey, alice))
+	}, GetJuniorWeek(s))
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/schedule/junior", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NotContains(t, w.Body.String(), "Bob")
+
+	var body struct {
+		Name string           `json:"name"`
+		Days []map[string]any `json:"days"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, "Alice", body.Name)
+	if assert.Len(t, body.Days, 7) {
+		assert.Equal(t, "Monday", body.Days[0]["weekday"])
+		assert.Equal(t, true, body.Days[0]["mine"])
+		assert.Equal(t, true, body.Days[0]["done"])
+		assert.NotContains(t, body.Days[1], "mine", "someone else's day looks like a free one")
+	}
+}
@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/korjavin/dutyassistant/internal/service/task"
+	"github.com/korjavin/dutyassistant/internal/store"
+)
+
+// apiTask is a task of the GetTasks response.
+type apiTask struct {
+	ID        int64   `json:"id"`
+	Title     string  `json:"title"`
+	Weight    int     `json:"weight"`
+	DueDate   string  `json:"due_date,omitempty"`
+	ClaimedBy *int64  `json:"claimed_by,omitempty"`
+	DoneAt    *string `json:"done_at,omitempty"`
+}
+
+func newAPITask(t *store.Task) apiTask {
+	item := apiTask{ID: t.ID, Title: t.Title, Weight: t.Weight, ClaimedBy: t.ClaimedBy}
+	if t.DueDate != nil {
+		item.DueDate = t.DueDate.Format("2006-01-02")
+	}
+	if t.DoneAt != nil {
+		done := t.DoneAt.UTC().Format(time.RFC3339)
+		item.DoneAt = &done
+	}
+	return item
+}
+
+// GetTasks handles the GET /api/v1/tasks endpoint. It returns the one-off
+// tasks outside the rotation, oldest first; with ?open=true only those that
+// aren't done, the ones due first first.
+func GetTasks(tasks *task.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		list := tasks.List
+		if c.Query("open") == "true" {
+			list = tasks.Open
+		}
+		found, err := list(c.Request.Context())
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve tasks"})
+			return
+		}
+
+		items := make([]apiTask, 0, len(found))
+		for _, t := range found {
+			items = append(items, newAPITask(t))
+		}
+		c.JSON(http.StatusOK, items)
+	}
+}
+
+// AdminCreateTask handles the POST /api/v1/tasks endpoint. It adds a task
+// members can claim, counting weight duty days (1 if not given) and due by
+// due_date if one is given. It isn't announced in the group, unlike with
+// /tasks add.
+func AdminCreateTask(tasks *task.Service) gin.HandlerFunc {
+	type request struct {
+		Title   string `json:"title" binding:"required"`
+		Weight  int    `json:"weight"`
+		DueDate string `json:"due_date"` // YYYY-MM-DD, optional
+	}
+
+	return func(c *gin.Context) {
+		var req request
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if strings.TrimSpace(req.Title) == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "The title is empty"})
+			return
+		}
+		if req.Weight == 0 {
+			req.Weight = task.DefaultWeight
+		}
+		var due *time.Time
+		if req.DueDate != "" {
+			date, err := time.Parse("2006-01-02", req.DueDate)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid due_date format, expected YYYY-MM-DD"})
+				return
+			}
+			due = &date
+		}
+
+		t, err := tasks.Add(c.Request.Context(), req.Title, req.Weight, due)
+		if errors.Is(err, task.ErrInvalidWeight) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		} else if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create task"})
+			return
+		}
+		c.JSON(http.StatusCreated, newAPITask(t))
+	}
+}
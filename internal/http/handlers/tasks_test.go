@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/korjavin/dutyassistant/internal/service/task"
+	"github.com/korjavin/dutyassistant/internal/store"
+	"github.com/korjavin/dutyassistant/internal/store/memory"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTasks(t *testing.T) {
+	ctx := context.Background()
+	s := memory.New()
+	alice := &store.User{TelegramUserID: 1, FirstName: "Alice", IsActive: true}
+	s.CreateUser(ctx, alice)
+	tasks := task.New(s)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/tasks", GetTasks(tasks))
+	router.POST("/tasks", AdminCreateTask(tasks))
+
+	post := func(body string) int {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/tasks", strings.NewReader(body)))
+		return w.Code
+	}
+	get := func(url string) []map[string]any {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, url, nil))
+		assert.Equal(t, http.StatusOK, w.Code)
+		var body []map[string]any
+		json.Unmarshal(w.Body.Bytes(), &body)
+		return body
+	}
+
+	assert.Equal(t, []map[string]any{}, get("/tasks"))
+
+	assert.Equal(t, http.StatusBadRequest, post(`{"title": " "}`))
+	assert.Equal(t, http.StatusBadRequest, post(`{"title": "Clean the garage", "weight": 11}`))
+	assert.Equal(t, http.StatusBadRequest, post(`{"title": "Clean the garage", "due_date": "Saturday"}`))
+	assert.Equal(t, http.StatusCreated, post(`{"title": "Wash the windows"}`))
+	assert.Equal(t, http.StatusCreated, post(`{"title": "Clean the garage", "weight": 3, "due_date": "2025-11-08"}`))
+
+	if _, err := tasks.Complete(ctx, 1, alice.ID); err != nil {
+		t.Fatal(err)
+	}
+	all := get("/tasks")
+	if assert.Len(t, all, 2) {
+		assert.Equal(t, "Wash the windows", all[0]["title"])
+		assert.Equal(t, float64(1), all[0]["weight"])
+		assert.Equal(t, float64(alice.ID), all[0]["claimed_by"])
+		assert.NotNil(t, all[0]["done_at"])
+	}
+	assert.Equal(t, []map[string]any{
+		{"id": float64(2), "title": "Clean the garage", "weight": float64(3), "due_date": "2025-11-08"},
+	}, get("/tasks?open=true"))
+}
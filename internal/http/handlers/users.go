@@ -1,16 +1,18 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 	"github.com/korjavin/dutyassistant/internal/http/middleware"
+	"github.com/korjavin/dutyassistant/internal/service/user"
 	"github.com/korjavin/dutyassistant/internal/store"
 )
 
 // GetUsers handles the GET /api/v1/users endpoint.
 // Returns empty list for unauthenticated users.
-func GetUsers(s store.Store) gin.HandlerFunc {
+func GetUsers(s store.UserStore) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Check if user is authenticated
 		user, authenticated := c.Request.Context().Value(middleware.UserKey).(*store.User)
@@ -23,6 +25,12 @@ func GetUsers(s store.Store) gin.HandlerFunc {
 			return
 		}
 
+		// Juniors don't get to see the others' stats
+		if user.IsJunior && !user.IsAdmin {
+			c.JSON(http.StatusOK, publicUsers(c, []*store.User{user}))
+			return
+		}
+
 		users, err := s.ListAllUsers(c.Request.Context())
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve users"})
@@ -34,6 +42,68 @@ func GetUsers(s store.Store) gin.HandlerFunc {
 			users = []*store.User{}
 		}
 
-		c.JSON(http.StatusOK, users)
+		c.JSON(http.StatusOK, publicUsers(c, users))
+	}
+}
+
+// AdminMergeUsers handles the POST /api/v1/users/merge endpoint.
+// It folds the user from_user_id into to_user_id: duties, queue days and
+// stats move over, from_user_id is deleted and the merge is recorded.
+func AdminMergeUsers(users *user.Service) gin.HandlerFunc {
+	type request struct {
+		FromUserID int64 `json:"from_user_id" binding:"required"`
+		ToUserID   int64 `json:"to_user_id" binding:"required"`
+	}
+	return func(c *gin.Context) {
+		var req request
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		merge, err := users.Merge(c.Request.Context(), req.FromUserID, req.ToUserID)
+		switch {
+		case errors.Is(err, user.ErrNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		case errors.Is(err, user.ErrSameUser):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		case err != nil:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to merge users"})
+			return
+		}
+
+		if middleware.IsMinimalPII(c.Request.Context()) {
+			c.JSON(http.StatusOK, mergeWithoutTelegramID{UserMerge: merge})
+			return
+		}
+		c.JSON(http.StatusOK, merge)
+	}
+}
+
+// userWithoutTelegramID is a user without the Telegram ID, which the nil
+// field of the same name hides.
+type userWithoutTelegramID struct {
+	*store.User
+	TelegramUserID *int64 `json:",omitempty"`
+}
+
+// mergeWithoutTelegramID is a user merge without the merged user's Telegram ID.
+type mergeWithoutTelegramID struct {
+	*store.UserMerge
+	FromTelegramUserID *int64 `json:",omitempty"`
+}
+
+// publicUsers returns users for a response, without their Telegram IDs in
+// minimal PII mode.
+func publicUsers(c *gin.Context, users []*store.User) any {
+	if !middleware.IsMinimalPII(c.Request.Context()) {
+		return users
+	}
+	stripped := make([]userWithoutTelegramID, len(users))
+	for i, u := range users {
+		stripped[i] = userWithoutTelegramID{User: u}
 	}
-}
\ No newline at end of file
+	return stripped
+}
@@ -0,0 +1,170 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/korjavin/dutyassistant/internal/http/middleware"
+	"github.com/korjavin/dutyassistant/internal/service/history"
+	"github.com/korjavin/dutyassistant/internal/store"
+)
+
+// apiScheduleDay is a day of a version of the schedule, or null in a diff if
+// it had no duty.
+type apiScheduleDay struct {
+	Date           string `json:"date"`
+	UserID         int64  `json:"user_id,omitempty"`
+	Name           string `json:"name,omitempty"`
+	AssignmentType string `json:"assignment_type,omitempty"`
+	Status         string `json:"status,omitempty"`
+	SkipReason     string `json:"skip_reason,omitempty"`
+}
+
+// apiScheduleChange is a day that differs between two versions.
+type apiScheduleChange struct {
+	Date   string          `json:"date"`
+	Before *apiScheduleDay `json:"before"`
+	After  *apiScheduleDay `json:"after"`
+}
+
+// AdminGetScheduleVersions handles the GET /api/v1/schedule/:year/:month/versions
+// endpoint. It lists the versions of the month's schedule, each with the
+// number of days it changed. With ?version=N it returns that version's days
+// instead, with ?at= those of the version current at a time (RFC3339) or at
+// the end of a day (YYYY-MM-DD, UTC), and with ?from=N&to=M the days that
+// changed between two versions. In minimal PII mode names are "***".
+func AdminGetScheduleVersions(versions *history.Service, s store.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+		year, err := strconv.Atoi(c.Param("year"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid year format"})
+			return
+		}
+		m, err := strconv.Atoi(c.Param("month"))
+		if err != nil || m < 1 || m > 12 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Month must be between 1 and 12"})
+			return
+		}
+		month := time.Date(year, time.Month(m), 1, 0, 0, 0, 0, time.UTC)
+
+		users, err := s.ListAllUsers(ctx)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve users"})
+			return
+		}
+		names := make(map[int64]string, len(users))
+		for _, u := range users {
+			names[u.ID] = u.FirstName
+		}
+		day := func(d *store.ScheduleDay) *apiScheduleDay {
+			if d == nil {
+				return nil
+			}
+			item := &apiScheduleDay{Date: d.Date.Format("2006-01-02"), SkipReason: string(d.SkipReason)}
+			if d.SkipReason == "" {
+				item.UserID, item.Name = d.UserID, names[d.UserID]
+				item.AssignmentType, item.Status = string(d.AssignmentType), string(d.Status)
+				if middleware.IsMinimalPII(ctx) {
+					item.Name = "***"
+				}
+			}
+			return item
+		}
+		// version returns the version a query parameter refers to, or
+		// responds with why there is none
+		version := func(param string) (*store.ScheduleVersion, bool) {
+			n, err := strconv.Atoi(c.Query(param))
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + param + ", expected a version number"})
+				return nil, false
+			}
+			v, err := versions.Version(ctx, month, n)
+			return v, respondVersion(c, err)
+		}
+
+		var v *store.ScheduleVersion
+		switch {
+		case c.Query("from") != "" || c.Query("to") != "":
+			from, ok := version("from")
+			if !ok {
+				return
+			}
+			to, ok := version("to")
+			if !ok {
+				return
+			}
+			changes := make([]apiScheduleChange, 0)
+			for _, change := range history.Diff(from.Days, to.Days) {
+				changes = append(changes, apiScheduleChange{
+					Date: change.Date.Format("2006-01-02"), Before: day(change.Before), After: day(change.After),
+				})
+			}
+			c.JSON(http.StatusOK, gin.H{"from": from.Version, "to": to.Version, "changes": changes})
+			return
+		case c.Query("version") != "":
+			var ok bool
+			if v, ok = version("version"); !ok {
+				return
+			}
+		case c.Query("at") != "":
+			raw := c.Query("at")
+			at, err := time.Parse(time.RFC3339, raw)
+			if err != nil {
+				date, err := time.Parse("2006-01-02", raw)
+				if err != nil {
+					c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid at, expected RFC3339 or YYYY-MM-DD"})
+					return
+				}
+				at = date.AddDate(0, 0, 1).Add(-time.Second)
+			}
+			v, err = versions.AsOf(ctx, month, at)
+			if !respondVersion(c, err) {
+				return
+			}
+		default:
+			all, err := versions.Versions(ctx, month)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve the versions"})
+				return
+			}
+			list := make([]gin.H, 0, len(all))
+			for i, v := range all {
+				changes := len(v.Days)
+				if i > 0 {
+					changes = len(history.Diff(all[i-1].Days, v.Days))
+				}
+				list = append(list, gin.H{"version": v.Version, "created_at": v.CreatedAt.UTC().Format(time.RFC3339), "days": len(v.Days), "changes": changes})
+			}
+			c.JSON(http.StatusOK, gin.H{"month": month.Format("2006-01"), "versions": list})
+			return
+		}
+
+		days := make([]*apiScheduleDay, 0, len(v.Days))
+		for i := range v.Days {
+			days = append(days, day(&v.Days[i]))
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"month":      month.Format("2006-01"),
+			"version":    v.Version,
+			"created_at": v.CreatedAt.UTC().Format(time.RFC3339),
+			"days":       days,
+		})
+	}
+}
+
+// respondVersion responds with the error of looking up a version, if any,
+// and reports whether there was none.
+func respondVersion(c *gin.Context, err error) bool {
+	if errors.Is(err, history.ErrNoVersion) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return false
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve the version"})
+		return false
+	}
+	return true
+}
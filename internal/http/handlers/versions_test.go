@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/korjavin/dutyassistant/internal/http/middleware"
+	"github.com/korjavin/dutyassistant/internal/service/history"
+	"github.com/korjavin/dutyassistant/internal/store"
+	"github.com/korjavin/dutyassistant/internal/store/memory"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAdminGetScheduleVersions(t *testing.T) {
+	ctx := context.Background()
+	s := memory.New()
+	alice := &store.User{TelegramUserID: 1, FirstName: "Alice", IsActive: true}
+	bob := &store.User{TelegramUserID: 2, FirstName: "Bob", IsActive: true}
+	s.CreateUser(ctx, alice)
+	s.CreateUser(ctx, bob)
+	month := time.Date(2025, 11, 1, 0, 0, 0, 0, time.UTC)
+	day := func(d int) time.Time { return time.Date(2025, 11, d, 0, 0, 0, 0, time.UTC) }
+	s.CreateScheduleVersion(ctx, &store.ScheduleVersion{Month: month, CreatedAt: time.Date(2025, 11, 2, 12, 0, 0, 0, time.UTC), Days: []store.ScheduleDay{
+		{Date: day(5), UserID: alice.ID, AssignmentType: store.AssignmentTypeRoundRobin, Status: store.DutyStatusAnnounced},
+	}})
+	s.CreateScheduleVersion(ctx, &store.ScheduleVersion{Month: month, CreatedAt: time.Date(2025, 11, 3, 12, 0, 0, 0, time.UTC), Days: []store.ScheduleDay{
+		{Date: day(5), UserID: bob.ID, AssignmentType: store.AssignmentTypeAdmin, Status: store.DutyStatusAnnounced},
+		{Date: day(6), SkipReason: store.SkipReasonHoliday},
+	}})
+
+	gin.SetMode(gin.TestMode)
+	get := func(url string, minimalPII bool) (int, map[string]any) {
+		router := gin.New()
+		if minimalPII {
+			router.Use(middleware.MinimalPII())
+		}
+		router.GET("/schedule/:year/:month/versions", AdminGetScheduleVersions(history.New(s), s))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, url, nil))
+		var body map[string]any
+		json.Unmarshal(w.Body.Bytes(), &body)
+		return w.Code, body
+	}
+
+	code, body := get("/schedule/2025/11/versions", false)
+	assert.Equal(t, http.StatusOK, code)
+	versions := body["versions"].([]any)
+	if assert.Len(t, versions, 2) {
+		assert.Equal(t, map[string]any{"version": 1.0, "created_at": "2025-11-02T12:00:00Z", "days": 1.0, "changes": 1.0}, versions[0])
+		assert.Equal(t, 2.0, versions[1].(map[string]any)["changes"])
+	}
+
+	code, body = get("/schedule/2025/11/versions?at=2025-11-02", false)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, 1.0, body["version"])
+	days := body["days"].([]any)
+	if assert.Len(t, days, 1) {
+		assert.Equal(t, "Alice", days[0].(map[string]any)["name"])
+	}
+
+	code, body = get("/schedule/2025/11/versions?from=1&to=2", false)
+	assert.Equal(t, http.StatusOK, code)
+	changes := body["changes"].([]any)
+	if assert.Len(t, changes, 2) {
+		c := changes[0].(map[string]any)
+		assert.Equal(t, "Alice", c["before"].(map[string]any)["name"])
+		assert.Equal(t, "Bob", c["after"].(map[string]any)["name"])
+		c = changes[1].(map[string]any)
+		assert.Nil(t, c["before"])
+		assert.Equal(t, "holiday", c["after"].(map[string]any)["skip_reason"])
+	}
+
+	_, body = get("/schedule/2025/11/versions?version=2", true)
+	assert.Equal(t, "***", body["days"].([]any)[0].(map[string]any)["name"])
+
+	code, _ = get("/schedule/2025/11/versions?version=3", false)
+	assert.Equal(t, http.StatusNotFound, code)
+	code, _ = get("/schedule/2025/11/versions?at=2025-11-01", false)
+	assert.Equal(t, http.StatusNotFound, code)
+	code, _ = get("/schedule/2025/11/versions?from=1", false)
+	assert.Equal(t, http.StatusBadRequest, code)
+	code, _ = get("/schedule/2025/13/versions", false)
+	assert.Equal(t, http.StatusBadRequest, code)
+}
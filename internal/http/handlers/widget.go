@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/korjavin/dutyassistant/internal/http/middleware"
+	"github.com/korjavin/dutyassistant/internal/store"
+)
+
+// widgetCacheTTL is how long GetWidget serves the same answer, and how long
+// clients and proxies may cache it.
+const widgetCacheTTL = time.Minute
+
+// widget is the GET /api/v1/widget response.
+type widget struct {
+	Date       string `json:"date"`
+	Name       string `json:"name,omitempty"`
+	Status     string `json:"status,omitempty"`
+	Completed  bool   `json:"completed"`
+	SkipReason string `json:"skip_reason,omitempty"`
+}
+
+// widgetCache keeps the last widget answer for widgetCacheTTL.
+type widgetCache struct {
+	mu      sync.Mutex
+	widget  widget
+	expires time.Time
+}
+
+// GetWidget handles the GET /api/v1/widget endpoint for family homepages and
+// e-ink displays. It needs no authentication and only tells today's assignee
+// by first name and whether the duty is done, or why the day is skipped.
+// Answers are cached for a minute; in minimal PII mode the name is "***".
+func GetWidget(s store.DutyStore) gin.HandlerFunc {
+	cache := &widgetCache{}
+	return func(c *gin.Context) {
+		w, err := cache.get(c.Request.Context(), s, time.Now())
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve today's duty"})
+			return
+		}
+		if w.Name != "" && middleware.IsMinimalPII(c.Request.Context()) {
+			w.Name = "***"
+		}
+		c.Header("Cache-Control", "public, max-age=60")
+		c.JSON(http.StatusOK, w)
+	}
+}
+
+// get returns the cached widget, loading it again once it expired.
+func (wc *widgetCache) get(ctx context.Context, s store.DutyStore, now time.Time) (widget, error) {
+	wc.mu.Lock()
+	defer wc.mu.Unlock()
+	if now.Before(wc.expires) {
+		return wc.widget, nil
+	}
+
+	berlinLoc, _ := time.LoadLocation("Europe/Berlin")
+	local := now.In(berlinLoc)
+	today := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, time.UTC)
+	w := widget{Date: today.Format("2006-01-02")}
+	duty, err := s.GetDutyByDate(ctx, today)
+	if err != nil {
+		return widget{}, err
+	}
+	if duty != nil {
+		w.Status = string(duty.Status)
+		w.Completed = duty.CompletedAt != nil
+		if duty.User != nil {
+			w.Name = duty.User.GroupName()
+		}
+	} else {
+		skip, err := s.GetSkipDay(ctx, today)
+		if err != nil {
+			return widget{}, err
+		}
+		if skip != nil {
+			w.SkipReason = string(skip.Reason)
+		}
+	}
+	wc.widget, wc.expires = w, now.Add(widgetCacheTTL)
+	return w, nil
+}
@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/korjavin/dutyassistant/internal/http/middleware"
+	"github.com/korjavin/dutyassistant/internal/store"
+	"github.com/korjavin/dutyassistant/internal/store/memory"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetWidget(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ctx := context.Background()
+	s := memory.New()
+	alice := &store.User{TelegramUserID: 1, FirstName: "Alice", IsActive: true}
+	s.CreateUser(ctx, alice)
+	berlinLoc, _ := time.LoadLocation("Europe/Berlin")
+	now := time.Now().In(berlinLoc)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	s.CreateDuty(ctx, &store.Duty{UserID: alice.ID, DutyDate: today, AssignmentType: store.AssignmentTypeRoundRobin, Status: store.DutyStatusAnnounced})
+
+	router := gin.New()
+	router.GET("/api/v1/widget", GetWidget(s))
+	private := gin.New()
+	private.GET("/api/v1/widget", middleware.MinimalPII(), GetWidget(s))
+	get := func(router *gin.Engine) widget {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/widget", nil))
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "public, max-age=60", w.Header().Get("Cache-Control"))
+		var body widget
+		json.Unmarshal(w.Body.Bytes(), &body)
+		return body
+	}
+
+	assert.Equal(t, widget{Date: today.Format("2006-01-02"), Name: "Alice", Status: "announced"}, get(router))
+	assert.Equal(t, "***", get(private).Name)
+
+	// Answers are cached, so the completion only shows a minute later
+	s.CompleteDuty(ctx, today)
+	assert.False(t, get(router).Completed)
+}
@@ -8,6 +8,8 @@ import (
 
 	"github.com/gin-gonic/gin"
 	initdata "github.com/telegram-mini-apps/init-data-golang"
+	"github.com/korjavin/dutyassistant/internal/service/login"
+	"github.com/korjavin/dutyassistant/internal/service/user"
 	"github.com/korjavin/dutyassistant/internal/store"
 )
 
@@ -23,16 +25,28 @@ const (
 // Authenticate is a Gin middleware that handles user authentication based on
 // Telegram Web App initData. It validates the data, fetches the corresponding
 // user from the application's database, and attaches the user object to the
-// request context.
+// request context. Requests without an Authorization header may instead carry
+// the session cookie of a browser signed in with a login code from the bot.
 //
 // This middleware should be applied to all endpoints that require user
 // authentication. If authentication fails for any reason, it aborts the
 // request with a 401 Unauthorized or 403 Forbidden status.
-func Authenticate(s store.Store, botToken string) gin.HandlerFunc {
+func Authenticate(users *user.Service, sessions *login.Service, botToken string) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Authorization header is required"})
+			u := sessionUser(c, users, sessions)
+			if u == nil {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Authorization header is required"})
+				return
+			}
+			if !u.IsActive {
+				c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "User is inactive"})
+				return
+			}
+			ctx := context.WithValue(c.Request.Context(), UserKey, u)
+			c.Request = c.Request.WithContext(ctx)
+			c.Next()
 			return
 		}
 
@@ -60,7 +74,7 @@ func Authenticate(s store.Store, botToken string) gin.HandlerFunc {
 		}
 
 		// Fetch the user from our application's database using their Telegram ID.
-		user, err := s.GetUserByTelegramID(c.Request.Context(), data.User.ID)
+		u, err := users.ByTelegramID(c.Request.Context(), data.User.ID)
 		if err != nil {
 			// This can happen if the user is not registered in our system or if there's a database error.
 			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "User not found or database error"})
@@ -68,13 +82,13 @@ func Authenticate(s store.Store, botToken string) gin.HandlerFunc {
 		}
 
 		// Ensure the user is marked as active in the system.
-		if !user.IsActive {
+		if !u.IsActive {
 			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "User is inactive"})
 			return
 		}
 
 		// Store the user object in the request context for use by subsequent handlers.
-		ctx := context.WithValue(c.Request.Context(), UserKey, user)
+		ctx := context.WithValue(c.Request.Context(), UserKey, u)
 		c.Request = c.Request.WithContext(ctx)
 
 		c.Next()
@@ -105,11 +119,18 @@ func AdminRequired() gin.HandlerFunc {
 // OptionalAuth is a middleware that attempts authentication but doesn't require it.
 // If authentication succeeds, the user is added to context. If it fails, the request continues without a user.
 // This allows handlers to provide different responses based on authentication status.
-func OptionalAuth(s store.Store, botToken string) gin.HandlerFunc {
+// Like Authenticate, it falls back to the session cookie without an Authorization header.
+func OptionalAuth(users *user.Service, sessions *login.Service, botToken string) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
-			log.Println("[WEB_AUTH] No Authorization header present")
+			if u := sessionUser(c, users, sessions); u != nil {
+				log.Printf("[WEB_AUTH] User authenticated by session: ID=%d, Name=%s, IsActive=%v", u.ID, u.FirstName, u.IsActive)
+				ctx := context.WithValue(c.Request.Context(), UserKey, u)
+				c.Request = c.Request.WithContext(ctx)
+			} else {
+				log.Println("[WEB_AUTH] No Authorization header present")
+			}
 			c.Next()
 			return
 		}
@@ -141,17 +162,17 @@ func OptionalAuth(s store.Store, botToken string) gin.HandlerFunc {
 
 		log.Printf("[WEB_AUTH] Parsed successfully, user ID: %d", data.User.ID)
 
-		user, err := s.GetUserByTelegramID(c.Request.Context(), data.User.ID)
-		if err != nil || user == nil {
-			log.Printf("[WEB_AUTH] User lookup failed: err=%v, found=%v", err, user != nil)
+		u, err := users.ByTelegramID(c.Request.Context(), data.User.ID)
+		if err != nil {
+			log.Printf("[WEB_AUTH] User lookup failed: %v", err)
 			c.Next()
 			return
 		}
 
-		log.Printf("[WEB_AUTH] User authenticated: ID=%d, Name=%s, IsActive=%v", user.ID, user.FirstName, user.IsActive)
+		log.Printf("[WEB_AUTH] User authenticated: ID=%d, Name=%s, IsActive=%v", u.ID, u.FirstName, u.IsActive)
 
 		// Store user in context if found
-		ctx := context.WithValue(c.Request.Context(), UserKey, user)
+		ctx := context.WithValue(c.Request.Context(), UserKey, u)
 		c.Request = c.Request.WithContext(ctx)
 
 		c.Next()
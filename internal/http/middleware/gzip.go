@@ -0,0 +1,72 @@
+package middleware
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// gzipWriters reuses compressors between requests; allocating a gzip.Writer
+// costs far more than compressing a month of duties.
+var gzipWriters = sync.Pool{
+	New: func() any {
+		w, _ := gzip.NewWriterLevel(io.Discard, gzip.DefaultCompression)
+		return w
+	},
+}
+
+// gzipResponseWriter sends everything written by the handler through a gzip.Writer.
+type gzipResponseWriter struct {
+	gin.ResponseWriter
+	writer  *gzip.Writer
+	written bool
+}
+
+func (g *gzipResponseWriter) WriteHeader(code int) {
+	g.Header().Del("Content-Length")
+	g.ResponseWriter.WriteHeader(code)
+}
+
+func (g *gzipResponseWriter) Write(data []byte) (int, error) {
+	g.Header().Del("Content-Length")
+	g.written = true
+	return g.writer.Write(data)
+}
+
+func (g *gzipResponseWriter) WriteString(s string) (int, error) {
+	return g.Write([]byte(s))
+}
+
+// Gzip compresses responses for clients that send "Accept-Encoding: gzip".
+func Gzip() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !strings.Contains(c.GetHeader("Accept-Encoding"), "gzip") || c.Request.Method == http.MethodHead {
+			c.Next()
+			return
+		}
+
+		gz := gzipWriters.Get().(*gzip.Writer)
+		gz.Reset(c.Writer)
+		defer gzipWriters.Put(gz)
+
+		c.Header("Content-Encoding", "gzip")
+		c.Header("Vary", "Accept-Encoding")
+		writer := &gzipResponseWriter{ResponseWriter: c.Writer, writer: gz}
+		c.Writer = writer
+		defer func() {
+			if !writer.written {
+				// Nothing was written, so don't send an empty gzip stream.
+				c.Header("Content-Encoding", "")
+				gz.Reset(io.Discard)
+				return
+			}
+			gz.Close()
+		}()
+
+		c.Next()
+	}
+}
@@ -0,0 +1,66 @@
+package middleware
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func newGzipRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(Gzip())
+	router.GET("/data", func(c *gin.Context) {
+		c.String(http.StatusOK, strings.Repeat("duty ", 100))
+	})
+	router.GET("/empty", func(c *gin.Context) {
+		c.Status(http.StatusNoContent)
+	})
+	return router
+}
+
+func TestGzip(t *testing.T) {
+	router := newGzipRouter()
+
+	t.Run("compresses when accepted", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/data", nil)
+		req.Header.Set("Accept-Encoding", "gzip, deflate")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, "gzip", w.Header().Get("Content-Encoding"))
+		assert.Equal(t, "Accept-Encoding", w.Header().Get("Vary"))
+		zr, err := gzip.NewReader(w.Body)
+		if !assert.NoError(t, err) {
+			return
+		}
+		body, _ := io.ReadAll(zr)
+		assert.Equal(t, strings.Repeat("duty ", 100), string(body))
+	})
+
+	t.Run("passes through otherwise", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/data", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Empty(t, w.Header().Get("Content-Encoding"))
+		assert.Equal(t, strings.Repeat("duty ", 100), w.Body.String())
+	})
+
+	t.Run("leaves empty responses alone", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/empty", nil)
+		req.Header.Set("Accept-Encoding", "gzip")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNoContent, w.Code)
+		assert.Empty(t, w.Header().Get("Content-Encoding"))
+		assert.Zero(t, w.Body.Len())
+	})
+}
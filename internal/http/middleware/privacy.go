@@ -0,0 +1,28 @@
+package middleware
+
+import (
+	"context"
+
+	"github.com/gin-gonic/gin"
+)
+
+// minimalPIIKey marks requests served in minimal PII mode.
+const minimalPIIKey contextKey = "minimal_pii"
+
+// MinimalPII marks every request it handles as served in minimal PII mode,
+// for deployments that expose the read-only board to the internet. Handlers
+// then anonymize the public schedule for every viewer and leave Telegram IDs
+// out of their responses, see IsMinimalPII.
+func MinimalPII() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := context.WithValue(c.Request.Context(), minimalPIIKey, true)
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
+
+// IsMinimalPII reports whether the request is served in minimal PII mode.
+func IsMinimalPII(ctx context.Context) bool {
+	minimal, _ := ctx.Value(minimalPIIKey).(bool)
+	return minimal
+}
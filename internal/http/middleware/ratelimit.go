@@ -0,0 +1,70 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RateLimit is a middleware for unauthenticated endpoints that allows each
+// client IP at most limit requests per window. Further requests are refused
+// with 429 Too Many Requests and a Retry-After header until the window ends.
+func RateLimit(limit int, window time.Duration) gin.HandlerFunc {
+	l := &rateLimiter{limit: limit, window: window, now: time.Now, clients: make(map[string]*rateWindow)}
+	return func(c *gin.Context) {
+		if wait, ok := l.allow(c.ClientIP()); !ok {
+			c.Header("Retry-After", strconv.Itoa(int(wait.Seconds())+1))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": "Too many requests"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// rateLimiter counts requests per client in fixed windows.
+type rateLimiter struct {
+	limit  int
+	window time.Duration
+	now    func() time.Time
+
+	mu      sync.Mutex
+	clients map[string]*rateWindow
+	pruned  time.Time
+}
+
+// rateWindow is one client's requests in the current window.
+type rateWindow struct {
+	start time.Time
+	count int
+}
+
+// allow counts a request of client and reports whether it is allowed, or
+// else how long until the client's window ends.
+func (l *rateLimiter) allow(client string) (time.Duration, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+	// Forget clients whose windows ended, so the map doesn't grow forever
+	if now.Sub(l.pruned) >= l.window {
+		for ip, w := range l.clients {
+			if now.Sub(w.start) >= l.window {
+				delete(l.clients, ip)
+			}
+		}
+		l.pruned = now
+	}
+
+	w := l.clients[client]
+	if w == nil || now.Sub(w.start) >= l.window {
+		w = &rateWindow{start: now}
+		l.clients[client] = w
+	}
+	if w.count >= l.limit {
+		return w.start.Add(l.window).Sub(now), false
+	}
+	w.count++
+	return 0, true
+}
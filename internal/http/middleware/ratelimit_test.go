@@ -0,0 +1,54 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/widget", RateLimit(2, time.Minute), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	get := func(ip string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/widget", nil)
+		req.RemoteAddr = ip + ":1234"
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	assert.Equal(t, http.StatusOK, get("10.0.0.1").Code)
+	assert.Equal(t, http.StatusOK, get("10.0.0.1").Code)
+	w := get("10.0.0.1")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.NotEmpty(t, w.Header().Get("Retry-After"))
+	// Other clients have their own limit
+	assert.Equal(t, http.StatusOK, get("10.0.0.2").Code)
+}
+
+func TestRateLimiter_Window(t *testing.T) {
+	now := time.Date(2025, 11, 3, 8, 0, 0, 0, time.UTC)
+	l := &rateLimiter{limit: 1, window: time.Minute, now: func() time.Time { return now }, clients: make(map[string]*rateWindow)}
+
+	if _, ok := l.allow("a"); !ok {
+		t.Fatal("Expected the first request to be allowed")
+	}
+	now = now.Add(20 * time.Second)
+	if wait, ok := l.allow("a"); ok || wait != 40*time.Second {
+		t.Errorf("Expected to wait 40s, got %v, %v", wait, ok)
+	}
+	now = now.Add(40 * time.Second)
+	if _, ok := l.allow("a"); !ok {
+		t.Error("Expected a request in the next window to be allowed")
+	}
+	if len(l.clients) != 1 {
+		t.Errorf("Expected one client tracked, got %d", len(l.clients))
+	}
+}
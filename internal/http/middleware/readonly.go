@@ -0,0 +1,31 @@
+package middleware
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/korjavin/dutyassistant/internal/service/settings"
+)
+
+// ReadOnly is a middleware for endpoints that change data. While the bot is
+// in read-only mode for maintenance, see /settings readonly, it refuses
+// every request but GET and HEAD with 503 Service Unavailable; reads pass.
+func ReadOnly(botSettings *settings.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead {
+			c.Next()
+			return
+		}
+		on, err := botSettings.ReadOnly(c.Request.Context())
+		if err != nil {
+			// Let the change through, it most likely fails on its own
+			log.Printf("[HTTP] Failed to check read-only mode: %v", err)
+		}
+		if on {
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"error": "Maintenance in progress, changes are paused until it's over"})
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,34 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/korjavin/dutyassistant/internal/service/settings"
+	"github.com/korjavin/dutyassistant/internal/store/memory"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadOnly(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	botSettings := settings.New(memory.New())
+	router := gin.New()
+	router.Use(ReadOnly(botSettings))
+	router.GET("/duties", func(c *gin.Context) { c.Status(http.StatusOK) })
+	router.POST("/duties", func(c *gin.Context) { c.Status(http.StatusCreated) })
+	request := func(method string) int {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(method, "/duties", nil))
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusCreated, request(http.MethodPost))
+	if err := botSettings.SetReadOnly(context.Background(), true); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, http.StatusServiceUnavailable, request(http.MethodPost))
+	assert.Equal(t, http.StatusOK, request(http.MethodGet), "Reads still work")
+}
@@ -0,0 +1,30 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/korjavin/dutyassistant/internal/service/login"
+	"github.com/korjavin/dutyassistant/internal/service/user"
+	"github.com/korjavin/dutyassistant/internal/store"
+)
+
+// SessionCookie is the cookie that keeps the session token of a browser
+// signed in with a login code from the bot.
+const SessionCookie = "session"
+
+// sessionUser returns the user signed in with the request's session cookie,
+// or nil if there is no valid session.
+func sessionUser(c *gin.Context, users *user.Service, sessions *login.Service) *store.User {
+	token, err := c.Cookie(SessionCookie)
+	if err != nil || token == "" || sessions == nil {
+		return nil
+	}
+	userID, err := sessions.User(c.Request.Context(), token)
+	if err != nil {
+		return nil
+	}
+	u, err := users.ByID(c.Request.Context(), userID)
+	if err != nil {
+		return nil
+	}
+	return u
+}
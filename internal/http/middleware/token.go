@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// APITokenRequired is a middleware for machine clients (smart speakers, feed
+// readers, dashboards) that cannot perform the Telegram Web App handshake.
+// The token may be sent as "Authorization: Bearer <token>" or as a "token"
+// query parameter. If no token is configured, the endpoints are disabled.
+func APITokenRequired(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if token == "" {
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"error": "API token is not configured"})
+			return
+		}
+
+		provided := c.Query("token")
+		if authHeader := c.GetHeader("Authorization"); authHeader != "" {
+			parts := strings.SplitN(authHeader, " ", 2)
+			if len(parts) == 2 && strings.ToLower(parts[0]) == "bearer" {
+				provided = parts[1]
+			}
+		}
+
+		if provided == "" || subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid or missing API token"})
+			return
+		}
+
+		c.Next()
+	}
+}
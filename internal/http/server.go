@@ -1,15 +1,31 @@
 package http
 
 import (
+	"time"
+
 	"github.com/gin-gonic/gin"
 	"github.com/korjavin/dutyassistant/internal/http/handlers"
 	"github.com/korjavin/dutyassistant/internal/http/middleware"
+	"github.com/korjavin/dutyassistant/internal/service/config"
+	"github.com/korjavin/dutyassistant/internal/service/duty"
+	"github.com/korjavin/dutyassistant/internal/service/history"
+	"github.com/korjavin/dutyassistant/internal/service/login"
+	"github.com/korjavin/dutyassistant/internal/service/queue"
+	"github.com/korjavin/dutyassistant/internal/service/settings"
+	"github.com/korjavin/dutyassistant/internal/service/task"
+	"github.com/korjavin/dutyassistant/internal/service/user"
 	"github.com/korjavin/dutyassistant/internal/store"
 )
 
 // NewServer creates and configures a new Gin HTTP server.
 // It sets up the router, registers middleware, and defines all API routes.
-func NewServer(s store.Store, botToken string) *gin.Engine {
+// Users and duties are changed through the same services the bot uses.
+// sessions signs in browsers outside Telegram with login codes from the bot
+// or with the Telegram Login Widget of loginBot, the bot's username.
+// apiToken protects the machine-facing endpoints; if empty they are disabled.
+// minimalPII anonymizes the public schedule for everyone and leaves Telegram
+// IDs out of all responses, for boards exposed to the internet.
+func NewServer(s store.Store, users *user.Service, duties *duty.Service, sessions *login.Service, botToken, loginBot, apiToken string, minimalPII bool) *gin.Engine {
 	// Set Gin to release mode for production.
 	gin.SetMode(gin.ReleaseMode)
 
@@ -25,34 +41,86 @@ func NewServer(s store.Store, botToken string) *gin.Engine {
 	router.Static("/vendor", "./web/vendor")
 	router.StaticFile("/", "./web/index.html")
 	router.StaticFile("/index.html", "./web/index.html")
+	router.StaticFile("/junior", "./web/junior.html")
+	router.StaticFile("/profile", "./web/profile.html")
+
+	// Login links the bot sends start a session for browsers outside Telegram.
+	router.GET("/login", handlers.Login(sessions))
+	router.GET("/login/telegram", handlers.LoginWidget(users, sessions, botToken))
 
 	// Create an instance of the authentication middleware.
-	authMiddleware := middleware.Authenticate(s, botToken)
-	optionalAuthMiddleware := middleware.OptionalAuth(s, botToken)
+	authMiddleware := middleware.Authenticate(users, sessions, botToken)
+	optionalAuthMiddleware := middleware.OptionalAuth(users, sessions, botToken)
 	adminRequiredMiddleware := middleware.AdminRequired()
+	apiTokenMiddleware := middleware.APITokenRequired(apiToken)
+	// Changes of users and admins are refused in read-only mode
+	readOnlyMiddleware := middleware.ReadOnly(settings.New(s))
 
-	// Group all API routes under /api/v1.
+	// Group all API routes under /api/v1. JSON responses are compressed for
+	// clients that accept it.
 	api := router.Group("/api/v1")
+	api.Use(middleware.Gzip())
+	if minimalPII {
+		api.Use(middleware.MinimalPII())
+	}
 	{
 		// Public endpoints with optional auth (return limited data if not authenticated).
+		api.GET("/schedule/week", optionalAuthMiddleware, handlers.GetWeek(s))
 		api.GET("/schedule/:year/:month", optionalAuthMiddleware, handlers.GetSchedule(s))
 		api.GET("/prognosis/:year/:month", handlers.GetPrognosis(s))
 		api.GET("/users", optionalAuthMiddleware, handlers.GetUsers(s))
+		// Embeddable and unauthenticated, so cached and rate limited
+		api.GET("/widget", middleware.RateLimit(30, time.Minute), handlers.GetWidget(s))
+		api.GET("/session", optionalAuthMiddleware, handlers.GetSession(loginBot))
+		api.GET("/display", optionalAuthMiddleware, handlers.GetDisplay(s))
+		api.POST("/logout", handlers.Logout(sessions))
+
+		// Endpoints for machine clients, protected by the API token.
+		machine := api.Group("/")
+		machine.Use(apiTokenMiddleware)
+		{
+			machine.GET("/ask", handlers.Ask(s))
+			machine.GET("/feed.atom", handlers.GetFeed(s))
+
+			// Grafana JSON datasource plugin
+			machine.GET("/grafana", handlers.GrafanaHealth())
+			machine.POST("/grafana/search", handlers.GrafanaSearch())
+			machine.POST("/grafana/metrics", handlers.GrafanaSearch())
+			machine.POST("/grafana/query", handlers.GrafanaQuery(s))
+			machine.POST("/grafana/annotations", handlers.GrafanaAnnotations(s))
+		}
 
 		// Endpoints requiring user authentication (via Telegram Web App).
 		authenticated := api.Group("/")
-		authenticated.Use(authMiddleware)
+		authenticated.Use(authMiddleware, readOnlyMiddleware)
 		{
-			authenticated.POST("/duties/volunteer", handlers.VolunteerForDuty(s))
+			authenticated.POST("/duties/volunteer", handlers.VolunteerForDuty(duties))
+			authenticated.GET("/schedule/junior", handlers.GetJuniorWeek(s))
+			authenticated.GET("/users/:id/duties", handlers.GetUserDuties(users, s))
+			authenticated.GET("/users/:id/stats", handlers.GetUserStats(users, s))
+			authenticated.GET("/tasks", handlers.GetTasks(task.New(s)))
+			authenticated.GET("/assignments/:date/explain", handlers.GetAssignmentExplanation(s))
 		}
 
 		// Endpoints requiring administrator privileges.
 		admin := api.Group("/")
-		admin.Use(authMiddleware, adminRequiredMiddleware)
+		admin.Use(authMiddleware, adminRequiredMiddleware, readOnlyMiddleware)
 		{
-			admin.POST("/duties", handlers.AdminAssignDuty(s))
-			admin.PUT("/duties/:date", handlers.AdminModifyDuty(s))
-			admin.DELETE("/duties/:date", handlers.AdminDeleteDuty(s))
+			admin.POST("/duties", handlers.AdminAssignDuty(duties))
+			admin.POST("/duties/batch", handlers.AdminBatchDuties(duties))
+			admin.PUT("/duties/:date", handlers.AdminModifyDuty(duties))
+			admin.DELETE("/duties/:date", handlers.AdminDeleteDuty(duties))
+			admin.PUT("/duties/:date/actual", handlers.AdminBackfillDuty(duties))
+			admin.POST("/duties/:date/confirm", handlers.AdminConfirmDuty(duties))
+			admin.POST("/duties/:date/complete", handlers.AdminSetDutyCompletion(duties, true))
+			admin.DELETE("/duties/:date/complete", handlers.AdminSetDutyCompletion(duties, false))
+			admin.POST("/duties/today/assign", handlers.AdminAssignToday(duties))
+			admin.POST("/users/merge", handlers.AdminMergeUsers(users))
+			admin.GET("/config", handlers.AdminExportConfig(config.New(s)))
+			admin.PUT("/config", handlers.AdminImportConfig(config.New(s)))
+			admin.POST("/tasks", handlers.AdminCreateTask(task.New(s)))
+			admin.GET("/queues/metrics", handlers.AdminGetQueueMetrics(queue.New(s)))
+			admin.GET("/schedule/:year/:month/versions", handlers.AdminGetScheduleVersions(history.New(s), s))
 		}
 	}
 
@@ -0,0 +1,188 @@
+// Package i18n formats dates in the language of a chat.
+package i18n
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+// Locale is a language the bot formats dates in, as its ISO 639-1 code.
+type Locale string
+
+const (
+	English Locale = "en"
+	// AmericanEnglish is English with weeks starting on Sunday.
+	AmericanEnglish Locale = "en-US"
+	German          Locale = "de"
+)
+
+// Default is the locale of chats that didn't pick one, unless LOCALE
+// configures another.
+const Default = English
+
+// Locales are the supported locales.
+var Locales = []Locale{English, AmericanEnglish, German}
+
+// names are the weekday and month names of a locale. Weekdays start on
+// Sunday, as time.Weekday does.
+type names struct {
+	// firstWeekday is the day weeks start on in calendars.
+	firstWeekday time.Weekday
+	// weekAbbr heads the column of week numbers in calendars.
+	weekAbbr      string
+	weekdays      [7]string
+	weekdayAbbrs  [7]string
+	weekdayShorts [7]string
+	months        [12]string
+	monthAbbrs    [12]string
+	// layouts maps English layouts to the locale's word order, e.g.
+	// "January 2, 2006" to "2. January 2006". Layouts missing here keep the
+	// English order.
+	layouts map[string]string
+}
+
+var english = names{
+	firstWeekday:  time.Monday,
+	weekAbbr:      "Wk",
+	weekdays:      [7]string{"Sunday", "Monday", "Tuesday", "Wednesday", "Thursday", "Friday", "Saturday"},
+	weekdayAbbrs:  [7]string{"Sun", "Mon", "Tue", "Wed", "Thu", "Fri", "Sat"},
+	weekdayShorts: [7]string{"Su", "Mo", "Tu", "We", "Th", "Fr", "Sa"},
+	months:        [12]string{"January", "February", "March", "April", "May", "June", "July", "August", "September", "October", "November", "December"},
+	monthAbbrs:    [12]string{"Jan", "Feb", "Mar", "Apr", "May", "Jun", "Jul", "Aug", "Sep", "Oct", "Nov", "Dec"},
+}
+
+var localeNames = map[Locale]names{
+	English:         english,
+	AmericanEnglish: withFirstWeekday(english, time.Sunday),
+	German: {
+		firstWeekday:  time.Monday,
+		weekAbbr:      "KW",
+		weekdays:      [7]string{"Sonntag", "Montag", "Dienstag", "Mittwoch", "Donnerstag", "Freitag", "Samstag"},
+		weekdayAbbrs:  [7]string{"So", "Mo", "Di", "Mi", "Do", "Fr", "Sa"},
+		weekdayShorts: [7]string{"So", "Mo", "Di", "Mi", "Do", "Fr", "Sa"},
+		months:        [12]string{"Januar", "Februar", "März", "April", "Mai", "Juni", "Juli", "August", "September", "Oktober", "November", "Dezember"},
+		monthAbbrs:    [12]string{"Jan", "Feb", "Mär", "Apr", "Mai", "Jun", "Jul", "Aug", "Sep", "Okt", "Nov", "Dez"},
+		layouts: map[string]string{
+			"Monday, January 2":       "Monday, 2. January",
+			"Monday, 02 January 2006": "Monday, 02. January 2006",
+			"January 2, 2006":         "2. January 2006",
+			"Mon, Jan 2":              "Mon, 2. Jan",
+			"Jan 2":                   "2. Jan",
+		},
+	},
+}
+
+// withFirstWeekday returns n with weeks starting on day.
+func withFirstWeekday(n names, day time.Weekday) names {
+	n.firstWeekday = day
+	return n
+}
+
+// Parse returns the locale of a language code such as "de" or "de-AT", as
+// Telegram reports a user's language. The region picks a locale of its own
+// if there is one, like "en-US", and is ignored otherwise. It fails for
+// unsupported languages.
+func Parse(code string) (Locale, error) {
+	language, region, _ := strings.Cut(strings.ReplaceAll(strings.ToLower(strings.TrimSpace(code)), "_", "-"), "-")
+	if regional := Locale(language + "-" + strings.ToUpper(region)); region != "" {
+		if _, ok := localeNames[regional]; ok {
+			return regional, nil
+		}
+	}
+	if _, ok := localeNames[Locale(language)]; !ok {
+		return "", fmt.Errorf("unsupported language %q, expected one of %s", code, Codes())
+	}
+	return Locale(language), nil
+}
+
+// Codes returns the supported locales as a comma-separated string.
+func Codes() string {
+	codes := make([]string, len(Locales))
+	for i, l := range Locales {
+		codes[i] = string(l)
+	}
+	return strings.Join(codes, ", ")
+}
+
+// names returns the names of the locale, falling back to English for
+// unsupported ones.
+func (l Locale) names() names {
+	if n, ok := localeNames[l]; ok {
+		return n
+	}
+	return localeNames[English]
+}
+
+// WeekdayShort returns the two-letter name of a weekday, e.g. "Mo", as
+// calendar headers show it.
+func (l Locale) WeekdayShort(d time.Weekday) string { return l.names().weekdayShorts[d] }
+
+// FirstWeekday returns the day weeks start on in the locale's calendars,
+// Sunday for en-US and Monday for the others.
+func (l Locale) FirstWeekday() time.Weekday { return l.names().firstWeekday }
+
+// WeekAbbr returns the abbreviation of "week" that heads the column of week
+// numbers in calendars, e.g. "Wk" or "KW".
+func (l Locale) WeekAbbr() string { return l.names().weekAbbr }
+
+// Format formats t like time.Time.Format, with the weekday and month names of
+// the locale and, for the layouts the bot uses, its word order.
+func (l Locale) Format(t time.Time, layout string) string {
+	n := l.names()
+	if localized, ok := n.layouts[layout]; ok {
+		layout = localized
+	}
+	// The names are layout elements of their own, so the rest can be
+	// formatted piecewise
+	var b strings.Builder
+	for rest := layout; rest != ""; {
+		name, element := "", ""
+		switch {
+		case strings.HasPrefix(rest, "Monday"):
+			name, element = n.weekdays[t.Weekday()], "Monday"
+		case strings.HasPrefix(rest, "January"):
+			name, element = n.months[t.Month()-1], "January"
+		case strings.HasPrefix(rest, "Mon"):
+			name, element = n.weekdayAbbrs[t.Weekday()], "Mon"
+		case strings.HasPrefix(rest, "Jan"):
+			name, element = n.monthAbbrs[t.Month()-1], "Jan"
+		}
+		if element != "" {
+			b.WriteString(name)
+			rest = rest[len(element):]
+			continue
+		}
+		next := len(rest)
+		for _, element := range []string{"Mon", "Jan"} {
+			if i := strings.Index(rest, element); i > 0 && i < next {
+				next = i
+			}
+		}
+		b.WriteString(t.Format(rest[:next]))
+		rest = rest[next:]
+	}
+	return b.String()
+}
+
+// Store is where the locales chats picked are kept.
+type Store interface {
+	GetChatLocale(ctx context.Context, chatID int64) (string, error)
+}
+
+// ForChat returns the locale a chat picked, or fallback if it didn't pick a
+// supported one. Dates in the fallback beat no message, so errors are only
+// logged.
+func ForChat(ctx context.Context, s Store, chatID int64, fallback Locale) Locale {
+	code, err := s.GetChatLocale(ctx, chatID)
+	if err != nil {
+		log.Printf("[I18N] Failed to get the locale of chat %d: %v", chatID, err)
+		return fallback
+	}
+	if l, err := Parse(code); err == nil {
+		return l
+	}
+	return fallback
+}
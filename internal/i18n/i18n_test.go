@@ -0,0 +1,70 @@
+package i18n
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	for code, want := range map[string]Locale{"en": English, "de": German, "DE": German, "de-AT": German, "en_GB": English, " de ": German, "en-US": AmericanEnglish, "en_us": AmericanEnglish} {
+		if got, err := Parse(code); err != nil || got != want {
+			t.Errorf("Parse(%q) = %q, %v, want %q", code, got, err, want)
+		}
+	}
+	for _, code := range []string{"", "fr", "deutsch"} {
+		if _, err := Parse(code); err == nil {
+			t.Errorf("Parse(%q) should fail", code)
+		}
+	}
+}
+
+func TestLocale_Format(t *testing.T) {
+	day := time.Date(2025, 3, 3, 0, 0, 0, 0, time.UTC) // A Monday
+	tests := []struct {
+		locale Locale
+		layout string
+		want   string
+	}{
+		{English, "Monday, January 2", "Monday, March 3"},
+		{English, "Mon, Jan 2", "Mon, Mar 3"},
+		{English, "2006-01-02", "2025-03-03"},
+		{German, "Monday, January 2", "Montag, 3. März"},
+		{German, "Monday, 02 January 2006", "Montag, 03. März 2025"},
+		{German, "January 2, 2006", "3. März 2025"},
+		{German, "Mon, Jan 2", "Mo, 3. Mär"},
+		{German, "Jan 2006", "Mär 2025"},
+		{German, "January 2006", "März 2025"},
+		{German, "Mon", "Mo"},
+		{German, "2006-01-02 Monday", "2025-03-03 Montag"},
+		{Locale("xx"), "Monday, January 2", "Monday, March 3"},
+	}
+	for _, tt := range tests {
+		if got := tt.locale.Format(day, tt.layout); got != tt.want {
+			t.Errorf("%s: Format(%q) = %q, want %q", tt.locale, tt.layout, got, tt.want)
+		}
+	}
+}
+
+func TestLocale_WeekdayShort(t *testing.T) {
+	if got := German.WeekdayShort(time.Sunday); got != "So" {
+		t.Errorf("WeekdayShort(Sunday) = %q, want So", got)
+	}
+	if got := English.WeekdayShort(time.Tuesday); got != "Tu" {
+		t.Errorf("WeekdayShort(Tuesday) = %q, want Tu", got)
+	}
+}
+
+func TestLocale_FirstWeekday(t *testing.T) {
+	for l, want := range map[Locale]time.Weekday{English: time.Monday, AmericanEnglish: time.Sunday, German: time.Monday, Locale("xx"): time.Monday} {
+		if got := l.FirstWeekday(); got != want {
+			t.Errorf("%s: FirstWeekday() = %s, want %s", l, got, want)
+		}
+	}
+	if got := German.WeekAbbr(); got != "KW" {
+		t.Errorf("de: WeekAbbr() = %q, want KW", got)
+	}
+	// Only the first day differs from English
+	if got := AmericanEnglish.Format(time.Date(2025, 3, 3, 0, 0, 0, 0, time.UTC), "Monday, January 2"); got != "Monday, March 3" {
+		t.Errorf("en-US: Format = %q, want Monday, March 3", got)
+	}
+}
@@ -0,0 +1,204 @@
+package ical
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/korjavin/dutyassistant/internal/store"
+)
+
+// DefaultKeywords are the summary keywords that mark an all-day event as an absence.
+var DefaultKeywords = []string{"vacation", "trip"}
+
+// maxCalendarSize caps how much of a calendar is read, so a broken or hostile
+// server can't make the bot buffer an endless body.
+const maxCalendarSize = 10 << 20
+
+// Scheduler stores the off-duty periods imported from a user's calendar. The
+// scheduler implements it, so new absences are announced and planned around.
+type Scheduler interface {
+	ReplaceCalendarPeriods(ctx context.Context, userID int64, periods []*store.OffDutyPeriod) error
+}
+
+// Importer periodically turns linked family calendars into off-duty periods.
+type Importer struct {
+	store    store.AvailabilityStore
+	sched    Scheduler
+	client   *http.Client
+	keywords []string
+	// now is a function that returns the current time. It's used for testing.
+	now func() time.Time
+}
+
+// NewImporter creates a new Importer that reads calendar links from s and
+// hands their periods to sched. If keywords is empty, DefaultKeywords is used.
+func NewImporter(s store.AvailabilityStore, sched Scheduler, keywords []string) *Importer {
+	if len(keywords) == 0 {
+		keywords = DefaultKeywords
+	}
+	lower := make([]string, 0, len(keywords))
+	for _, k := range keywords {
+		if k = strings.ToLower(strings.TrimSpace(k)); k != "" {
+			lower = append(lower, k)
+		}
+	}
+	return &Importer{
+		store:    s,
+		sched:    sched,
+		client:   publicClient(),
+		keywords: lower,
+		now:      time.Now,
+	}
+}
+
+// SyncAll refreshes the off-duty periods of every user with a linked calendar.
+// A failing calendar does not stop the others; its error is stored on the link.
+func (im *Importer) SyncAll(ctx context.Context) error {
+	links, err := im.store.ListCalendarLinks(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list calendar links: %w", err)
+	}
+
+	for _, link := range links {
+		if _, err := im.Sync(ctx, link); err != nil {
+			log.Printf("[ICAL] Sync failed for user %d: %v", link.UserID, err)
+		}
+	}
+	return nil
+}
+
+// Sync fetches a single calendar and replaces the user's imported off-duty
+// periods with the matching events. It returns the number of periods imported.
+func (im *Importer) Sync(ctx context.Context, link *store.CalendarLink) (int, error) {
+	count, err := im.importPeriods(ctx, link)
+	if err != nil {
+		link.LastError = err.Error()
+	} else {
+		now := im.now().UTC()
+		link.LastSyncedAt = &now
+		link.LastError = ""
+	}
+
+	if setErr := im.store.SetCalendarLink(ctx, link); setErr != nil {
+		log.Printf("[ICAL] Failed to record sync status for user %d: %v", link.UserID, setErr)
+	}
+	return count, err
+}
+
+// importPeriods fetches the linked calendar and stores its matching events.
+func (im *Importer) importPeriods(ctx context.Context, link *store.CalendarLink) (int, error) {
+	events, err := fetch(ctx, im.client, link.URL)
+	if err != nil {
+		return 0, err
+	}
+	periods := im.periodsFromEvents(events)
+	if err := im.sched.ReplaceCalendarPeriods(ctx, link.UserID, periods); err != nil {
+		return 0, fmt.Errorf("failed to store off-duty periods: %w", err)
+	}
+	return len(periods), nil
+}
+
+// publicClient returns an HTTP client that only connects to public addresses.
+// Calendar URLs are sent by members over chat, so they must not reach the bot's
+// own host or the network it runs in.
+func publicClient() *http.Client {
+	dialer := &net.Dialer{Timeout: 10 * time.Second, Control: refusePrivateAddress}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{Timeout: 30 * time.Second, Transport: transport}
+}
+
+// refusePrivateAddress is a net.Dialer Control hook that rejects loopback,
+// private, link-local, unspecified and multicast addresses. It runs after name
+// resolution, so a public name pointing at a private address is refused too.
+func refusePrivateAddress(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsMulticast() {
+		return fmt.Errorf("refusing to connect to non-public address %s", host)
+	}
+	return nil
+}
+
+// fetch downloads and parses the calendar at rawURL.
+func fetch(ctx context.Context, client *http.Client, rawURL string) ([]Event, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid calendar URL: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("unsupported calendar URL scheme %q", u.Scheme)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("invalid calendar URL: %w", err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch calendar: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("calendar server returned status %d", resp.StatusCode)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxCalendarSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read calendar: %w", err)
+	}
+	if len(data) > maxCalendarSize {
+		return nil, errors.New("calendar is larger than 10 MiB")
+	}
+	return Parse(bytes.NewReader(data))
+}
+
+// periodsFromEvents keeps all-day events whose summary matches a keyword and
+// that have not ended yet, converting them into off-duty periods.
+func (im *Importer) periodsFromEvents(events []Event) []*store.OffDutyPeriod {
+	now := im.now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+
+	var periods []*store.OffDutyPeriod
+	for _, e := range events {
+		if !e.AllDay || !im.matches(e.Summary) {
+			continue
+		}
+		end := e.LastDay()
+		if end.Before(today) {
+			continue
+		}
+		periods = append(periods, &store.OffDutyPeriod{
+			StartDate:  e.Start,
+			EndDate:    end,
+			ExternalID: e.UID,
+			Summary:    e.Summary,
+		})
+	}
+	return periods
+}
+
+// matches reports whether summary contains any of the configured keywords.
+func (im *Importer) matches(summary string) bool {
+	summary = strings.ToLower(summary)
+	for _, k := range im.keywords {
+		if strings.Contains(summary, k) {
+			return true
+		}
+	}
+	return false
+}
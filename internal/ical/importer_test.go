@@ -0,0 +1,136 @@
+package ical
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/korjavin/dutyassistant/internal/events"
+	"github.com/korjavin/dutyassistant/internal/scheduler"
+	"github.com/korjavin/dutyassistant/internal/store"
+	"github.com/korjavin/dutyassistant/internal/store/sqlite"
+)
+
+func TestImporter_Sync(t *testing.T) {
+	ctx := context.Background()
+	s, err := sqlite.New(ctx, ":memory:?_pragma=foreign_keys(1)")
+	if err != nil {
+		t.Fatalf("Failed to create test database: %v", err)
+	}
+
+	user := &store.User{TelegramUserID: 1, FirstName: "Alice", IsActive: true}
+	assert.NoError(t, s.CreateUser(ctx, user))
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(sampleCalendar))
+	}))
+	defer srv.Close()
+
+	sched := scheduler.NewScheduler(s)
+	sched.Events = events.NewBus()
+	var wentOffDuty []events.UserWentOffDuty
+	sched.Events.Subscribe(func(_ context.Context, e events.Event) {
+		if e, ok := e.(events.UserWentOffDuty); ok {
+			wentOffDuty = append(wentOffDuty, e)
+		}
+	})
+	im := NewImporter(s, sched, nil)
+	im.client = srv.Client()
+	im.now = func() time.Time { return date(2025, time.July, 1) }
+
+	link := &store.CalendarLink{UserID: user.ID, URL: srv.URL}
+	assert.NoError(t, s.SetCalendarLink(ctx, link))
+
+	count, err := im.Sync(ctx, link)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, count, "trip and vacation should be imported, dentist skipped")
+	assert.Len(t, wentOffDuty, 2, "new periods are announced so the schedule is replanned")
+
+	// Syncing the same calendar again announces nothing
+	_, err = im.Sync(ctx, link)
+	assert.NoError(t, err)
+	assert.Len(t, wentOffDuty, 2)
+
+	offDuty, err := s.IsUserOffDuty(ctx, user.ID, date(2025, time.July, 16))
+	assert.NoError(t, err)
+	assert.True(t, offDuty)
+
+	offDuty, err = s.IsUserOffDuty(ctx, user.ID, date(2025, time.July, 21))
+	assert.NoError(t, err)
+	assert.False(t, offDuty, "DTEND is exclusive")
+
+	stored, err := s.GetCalendarLink(ctx, user.ID)
+	assert.NoError(t, err)
+	if !assert.NotNil(t, stored) {
+		return
+	}
+	assert.NotNil(t, stored.LastSyncedAt)
+	assert.Empty(t, stored.LastError)
+
+	// Unlinking removes the imported periods
+	assert.NoError(t, s.DeleteCalendarLink(ctx, user.ID))
+	periods, err := s.ListOffDutyPeriods(ctx, user.ID)
+	assert.NoError(t, err)
+	assert.Empty(t, periods)
+}
+
+func TestImporter_SyncRecordsError(t *testing.T) {
+	ctx := context.Background()
+	s, err := sqlite.New(ctx, ":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create test database: %v", err)
+	}
+
+	user := &store.User{TelegramUserID: 1, FirstName: "Bob", IsActive: true}
+	assert.NoError(t, s.CreateUser(ctx, user))
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "gone", http.StatusNotFound)
+	}))
+	defer srv.Close()
+
+	im := NewImporter(s, scheduler.NewScheduler(s), nil)
+	im.client = srv.Client()
+	link := &store.CalendarLink{UserID: user.ID, URL: srv.URL}
+	_, err = im.Sync(ctx, link)
+	assert.Error(t, err)
+
+	stored, err := s.GetCalendarLink(ctx, user.ID)
+	if !assert.NoError(t, err) || !assert.NotNil(t, stored) {
+		return
+	}
+	assert.Contains(t, stored.LastError, "404")
+	assert.Nil(t, stored.LastSyncedAt)
+}
+
+func TestFetch_Refused(t *testing.T) {
+	ctx := context.Background()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(sampleCalendar))
+	}))
+	defer srv.Close()
+
+	_, err := fetch(ctx, publicClient(), srv.URL)
+	assert.ErrorContains(t, err, "non-public address", "loopback must not be reachable from member URLs")
+
+	_, err = fetch(ctx, srv.Client(), "ftp://example.com/calendar.ics")
+	assert.ErrorContains(t, err, "unsupported calendar URL scheme")
+
+	_, err = fetch(ctx, srv.Client(), "file:///etc/passwd")
+	assert.ErrorContains(t, err, "unsupported calendar URL scheme")
+}
+
+func TestFetch_TooLarge(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(strings.Repeat("X", maxCalendarSize+1)))
+	}))
+	defer srv.Close()
+
+	_, err := fetch(context.Background(), srv.Client(), srv.URL)
+	assert.ErrorContains(t, err, "larger than")
+}
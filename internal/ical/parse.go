@@ -0,0 +1,145 @@
+// Package ical implements the small subset of RFC 5545 needed to import
+// availability from family calendars: all-day VEVENTs with a summary.
+package ical
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Event is a single VEVENT parsed from an iCal feed.
+type Event struct {
+	UID     string
+	Summary string
+	Start   time.Time
+	End     time.Time // Exclusive, as in DTEND
+	AllDay  bool
+}
+
+// LastDay returns the inclusive last day covered by an all-day event.
+func (e Event) LastDay() time.Time {
+	if !e.End.After(e.Start) {
+		return e.Start
+	}
+	return e.End.AddDate(0, 0, -1)
+}
+
+// Parse reads an iCal stream and returns all events it contains.
+// Events with a DTSTART that cannot be parsed are skipped.
+func Parse(r io.Reader) ([]Event, error) {
+	lines, err := unfold(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var events []Event
+	var current *Event
+	for _, line := range lines {
+		name, params, value := splitLine(line)
+		switch {
+		case name == "BEGIN" && value == "VEVENT":
+			current = &Event{}
+		case name == "END" && value == "VEVENT":
+			if current != nil && !current.Start.IsZero() {
+				if current.End.IsZero() {
+					current.End = current.Start
+					if current.AllDay {
+						current.End = current.Start.AddDate(0, 0, 1)
+					}
+				}
+				events = append(events, *current)
+			}
+			current = nil
+		case current == nil:
+			continue
+		case name == "UID":
+			current.UID = value
+		case name == "SUMMARY":
+			current.Summary = unescapeText(value)
+		case name == "DTSTART":
+			t, allDay, err := parseDate(params, value)
+			if err != nil {
+				continue
+			}
+			current.Start, current.AllDay = t, allDay
+		case name == "DTEND":
+			t, _, err := parseDate(params, value)
+			if err != nil {
+				continue
+			}
+			current.End = t
+		}
+	}
+	return events, nil
+}
+
+// unfold joins continuation lines (lines starting with a space or tab) onto
+// the previous line, as required by RFC 5545 section 3.1.
+func unfold(r io.Reader) ([]string, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	var lines []string
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("could not read calendar: %w", err)
+	}
+	return lines, nil
+}
+
+// splitLine splits "NAME;PARAM=X;PARAM2=Y:value" into its parts.
+func splitLine(line string) (string, map[string]string, string) {
+	colon := strings.Index(line, ":")
+	if colon < 0 {
+		return strings.ToUpper(line), nil, ""
+	}
+	head, value := line[:colon], line[colon+1:]
+	parts := strings.Split(head, ";")
+	params := make(map[string]string, len(parts)-1)
+	for _, p := range parts[1:] {
+		if eq := strings.Index(p, "="); eq > 0 {
+			params[strings.ToUpper(p[:eq])] = p[eq+1:]
+		}
+	}
+	return strings.ToUpper(parts[0]), params, value
+}
+
+// parseDate parses DTSTART/DTEND values in DATE or DATE-TIME form. Date-times
+// are truncated to their calendar date since only whole days matter here.
+func parseDate(params map[string]string, value string) (time.Time, bool, error) {
+	if params["VALUE"] == "DATE" || len(value) == 8 {
+		t, err := time.Parse("20060102", value)
+		return t, true, err
+	}
+
+	layout := "20060102T150405"
+	loc := time.UTC
+	if strings.HasSuffix(value, "Z") {
+		value = strings.TrimSuffix(value, "Z")
+	} else if tzid := params["TZID"]; tzid != "" {
+		if l, err := time.LoadLocation(tzid); err == nil {
+			loc = l
+		}
+	}
+	t, err := time.ParseInLocation(layout, value, loc)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC), false, nil
+}
+
+// unescapeText reverses the TEXT escaping defined in RFC 5545 section 3.3.11.
+func unescapeText(s string) string {
+	r := strings.NewReplacer(`\n`, " ", `\N`, " ", `\,`, ",", `\;`, ";", `\\`, `\`)
+	return r.Replace(s)
+}
@@ -0,0 +1,66 @@
+package ical
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const sampleCalendar = "BEGIN:VCALENDAR\r\n" +
+	"VERSION:2.0\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:trip-1\r\n" +
+	"SUMMARY:Family trip\\, Italy\r\n" +
+	"DTSTART;VALUE=DATE:20250714\r\n" +
+	"DTEND;VALUE=DATE:20250721\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:dentist-1\r\n" +
+	"SUMMARY:Dentist\r\n" +
+	"DTSTART;TZID=Europe/Berlin:20250702T093000\r\n" +
+	"DTEND;TZID=Europe/Berlin:20250702T100000\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:vacation-1\r\n" +
+	"SUMMARY:Summer vac\r\n" +
+	" ation\r\n" +
+	"DTSTART;VALUE=DATE:20250801\r\n" +
+	"END:VEVENT\r\n" +
+	"END:VCALENDAR\r\n"
+
+func date(y int, m time.Month, d int) time.Time {
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+func TestParse(t *testing.T) {
+	events, err := Parse(strings.NewReader(sampleCalendar))
+	assert.NoError(t, err)
+	if !assert.Len(t, events, 3) {
+		return
+	}
+
+	trip := events[0]
+	assert.Equal(t, "trip-1", trip.UID)
+	assert.Equal(t, "Family trip, Italy", trip.Summary)
+	assert.True(t, trip.AllDay)
+	assert.Equal(t, date(2025, time.July, 14), trip.Start)
+	assert.Equal(t, date(2025, time.July, 20), trip.LastDay())
+
+	dentist := events[1]
+	assert.False(t, dentist.AllDay)
+	assert.Equal(t, date(2025, time.July, 2), dentist.Start)
+
+	// Folded summary and missing DTEND default to a single day
+	vacation := events[2]
+	assert.Equal(t, "Summer vacation", vacation.Summary)
+	assert.Equal(t, date(2025, time.August, 1), vacation.LastDay())
+}
+
+func TestParse_SkipsEventsWithoutStart(t *testing.T) {
+	input := "BEGIN:VCALENDAR\nBEGIN:VEVENT\nSUMMARY:Broken\nDTSTART:garbage\nEND:VEVENT\nEND:VCALENDAR\n"
+	events, err := Parse(strings.NewReader(input))
+	assert.NoError(t, err)
+	assert.Empty(t, events)
+}
@@ -0,0 +1,57 @@
+package ical
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/korjavin/dutyassistant/internal/store"
+)
+
+// WasteImporter imports the municipal waste-collection calendar, so duty
+// reminders can say which bins to take out. Each event is a collection day
+// and its summary names the bin, e.g. "Paper" or "Bio".
+type WasteImporter struct {
+	store  store.DutyStore
+	client *http.Client
+	url    string
+	// now is a function that returns the current time. It's used for testing.
+	now func() time.Time
+}
+
+// NewWasteImporter creates a WasteImporter for the calendar at url.
+func NewWasteImporter(s store.DutyStore, url string) *WasteImporter {
+	return &WasteImporter{
+		store:  s,
+		client: &http.Client{Timeout: 30 * time.Second},
+		url:    url,
+		now:    time.Now,
+	}
+}
+
+// Sync fetches the calendar and replaces the stored collection days with the
+// ones that haven't passed yet. It returns the number of collections stored.
+// A failed fetch leaves the stored schedule alone.
+func (wi *WasteImporter) Sync(ctx context.Context) (int, error) {
+	events, err := fetch(ctx, wi.client, wi.url)
+	if err != nil {
+		return 0, err
+	}
+
+	now := wi.now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	var collections []*store.WasteCollection
+	for _, e := range events {
+		bin := strings.TrimSpace(e.Summary)
+		if bin == "" || e.Start.Before(today) {
+			continue
+		}
+		collections = append(collections, &store.WasteCollection{Date: e.Start, Bin: bin})
+	}
+	if err := wi.store.ReplaceWasteCollections(ctx, collections); err != nil {
+		return 0, fmt.Errorf("failed to store waste collections: %w", err)
+	}
+	return len(collections), nil
+}
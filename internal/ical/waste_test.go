@@ -0,0 +1,76 @@
+package ical
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/korjavin/dutyassistant/internal/store"
+	"github.com/korjavin/dutyassistant/internal/store/memory"
+)
+
+const wasteCalendar = `BEGIN:VCALENDAR
+VERSION:2.0
+BEGIN:VEVENT
+UID:w1
+SUMMARY:Paper
+DTSTART;VALUE=DATE:20250624
+END:VEVENT
+BEGIN:VEVENT
+UID:w2
+SUMMARY: Bio
+DTSTART;VALUE=DATE:20250701
+END:VEVENT
+BEGIN:VEVENT
+UID:w3
+SUMMARY:Residual waste
+DTSTART;VALUE=DATE:20250708
+END:VEVENT
+END:VCALENDAR
+`
+
+func TestWasteImporter_Sync(t *testing.T) {
+	ctx := context.Background()
+	s := memory.New()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(wasteCalendar))
+	}))
+	defer srv.Close()
+
+	wi := NewWasteImporter(s, srv.URL)
+	wi.now = func() time.Time { return date(2025, time.July, 1) }
+
+	count, err := wi.Sync(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, count, "past collections are dropped")
+
+	collections, err := s.GetWasteCollections(ctx, date(2025, time.June, 1), date(2025, time.August, 1))
+	assert.NoError(t, err)
+	assert.Equal(t, []*store.WasteCollection{
+		{Date: date(2025, time.July, 1), Bin: "Bio"},
+		{Date: date(2025, time.July, 8), Bin: "Residual waste"},
+	}, collections)
+}
+
+func TestWasteImporter_SyncKeepsScheduleOnError(t *testing.T) {
+	ctx := context.Background()
+	s := memory.New()
+	kept := &store.WasteCollection{Date: date(2025, time.July, 1), Bin: "Paper"}
+	assert.NoError(t, s.ReplaceWasteCollections(ctx, []*store.WasteCollection{kept}))
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "down", http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	_, err := NewWasteImporter(s, srv.URL).Sync(ctx)
+	assert.ErrorContains(t, err, "503")
+
+	collections, _ := s.GetWasteCollections(ctx, date(2025, time.July, 1), date(2025, time.July, 2))
+	assert.Equal(t, []*store.WasteCollection{kept}, collections)
+}
@@ -0,0 +1,97 @@
+package notification
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/korjavin/dutyassistant/internal/i18n"
+	"github.com/korjavin/dutyassistant/internal/service/settings"
+	"github.com/korjavin/dutyassistant/internal/store"
+)
+
+const (
+	// upcomingDays is how many days after the duty the upcoming section lists.
+	upcomingDays = 3
+	// fairnessDays is how many days back the fairness section counts duties.
+	fairnessDays = 30
+)
+
+// announcementSections build the optional sections of the daily announcement
+// of the duty on date, by the setting that turns them on. A section that has
+// nothing to say returns "".
+var announcementSections = map[string]func(n *Notifier, ctx context.Context, l i18n.Locale, date time.Time) (string, error){
+	settings.SectionUpcoming: (*Notifier).upcomingSection,
+	settings.SectionQueues:   (*Notifier).queuesSection,
+	settings.SectionFairness: (*Notifier).fairnessSection,
+}
+
+// composeAnnouncement assembles the daily announcement of the duty: who is on
+// duty, followed by the sections turned on with /settings announce. A section
+// that fails is left out, the announcement goes out anyway.
+func (n *Notifier) composeAnnouncement(ctx context.Context, l i18n.Locale, duty *store.Duty) string {
+	parts := []string{FormatAssignmentAnnouncement(l, duty)}
+	if n.Settings == nil {
+		return parts[0]
+	}
+	sections, err := n.Settings.AnnouncementSections(ctx)
+	if err != nil {
+		log.Printf("[NOTIFY] Failed to get the sections of the announcement: %v", err)
+	}
+	for _, name := range sections {
+		text, err := announcementSections[name](n, ctx, l, duty.DutyDate)
+		if err != nil {
+			log.Printf("[NOTIFY] Leaving out the %s section of the announcement: %v", name, err)
+			continue
+		}
+		if text != "" {
+			parts = append(parts, text)
+		}
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// upcomingSection lists who is on duty the days after date.
+func (n *Notifier) upcomingSection(ctx context.Context, l i18n.Locale, date time.Time) (string, error) {
+	from := date.AddDate(0, 0, 1)
+	duties, err := n.store.ListDuties(ctx, store.DutyFilter{From: from, To: from.AddDate(0, 0, upcomingDays)})
+	if err != nil {
+		return "", fmt.Errorf("failed to list the upcoming duties: %w", err)
+	}
+	return FormatUpcoming(l, from, upcomingDays, duties), nil
+}
+
+// queuesSection lists the days users have in the volunteer and admin queues.
+func (n *Notifier) queuesSection(ctx context.Context, _ i18n.Locale, _ time.Time) (string, error) {
+	users, err := n.rotation(ctx)
+	if err != nil {
+		return "", err
+	}
+	return FormatQueues(users), nil
+}
+
+// fairnessSection shows a bar of how many duties each user did in the
+// fairnessDays up to date.
+func (n *Notifier) fairnessSection(ctx context.Context, _ i18n.Locale, date time.Time) (string, error) {
+	users, err := n.rotation(ctx)
+	if err != nil {
+		return "", err
+	}
+	duties, err := n.store.GetCompletedDutiesInRange(ctx, date.AddDate(0, 0, -fairnessDays), date)
+	if err != nil {
+		return "", fmt.Errorf("failed to get the completed duties: %w", err)
+	}
+	return FormatFairness(users, duties, fairnessDays), nil
+}
+
+// rotation returns the users in the rotation: active and approved.
+func (n *Notifier) rotation(ctx context.Context) ([]*store.User, error) {
+	users, err := n.store.ListActiveUsers(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the active users: %w", err)
+	}
+	return slices.DeleteFunc(users, func(u *store.User) bool { return u.IsPending }), nil
+}
@@ -0,0 +1,126 @@
+package notification
+
+import (
+	"log"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultDigestWindow is how long a Digest waits for further events
+	// before sending what it has buffered.
+	DefaultDigestWindow = 30 * time.Second
+	// digestMaxDelayFactor caps how long a steady stream of events can keep
+	// postponing a digest, as a multiple of the window.
+	digestMaxDelayFactor = 5
+)
+
+// Digest batches events that arrive in quick succession into a single
+// summarized message. Every Add restarts the quiet window; once it passes
+// without new events, or the maximum delay since the first buffered event is
+// reached, all buffered events are sent together.
+type Digest struct {
+	bot    Sender
+	chatID func() int64
+	window time.Duration
+	format func(events []string) string
+
+	mu      sync.Mutex
+	pending []string
+	first   time.Time
+	timer   *time.Timer
+
+	// sendMu keeps digests in order when a timer fires during Flush.
+	sendMu sync.Mutex
+	// now is a function that returns the current time. It's used for testing.
+	now func() time.Time
+}
+
+// NewDigest creates a Digest that sends to chatID, summarizing buffered events with format.
+func NewDigest(bot Sender, chatID int64, window time.Duration, format func(events []string) string) *Digest {
+	return &Digest{
+		bot:    bot,
+		chatID: func() int64 { return chatID },
+		window: window,
+		format: format,
+		now:    time.Now,
+	}
+}
+
+// Add buffers an event and (re)starts the quiet window.
+func (d *Digest) Add(event string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := d.now()
+	if len(d.pending) == 0 {
+		d.first = now
+	}
+	d.pending = append(d.pending, event)
+
+	wait := d.window
+	if deadline := d.first.Add(d.window * digestMaxDelayFactor); now.Add(wait).After(deadline) {
+		wait = deadline.Sub(now)
+	}
+	if d.timer != nil {
+		d.timer.Stop()
+	}
+	d.timer = time.AfterFunc(wait, func() {
+		if err := d.Flush(); err != nil {
+			log.Printf("[NOTIFY] Failed to send digest: %v", err)
+		}
+	})
+}
+
+// Flush sends all buffered events immediately. It is a no-op if nothing is
+// buffered. If sending fails the events stay buffered for the next attempt.
+func (d *Digest) Flush() error {
+	d.sendMu.Lock()
+	defer d.sendMu.Unlock()
+
+	d.mu.Lock()
+	events := d.pending
+	d.pending = nil
+	if d.timer != nil {
+		d.timer.Stop()
+		d.timer = nil
+	}
+	d.mu.Unlock()
+
+	if len(events) == 0 {
+		return nil
+	}
+	chatID := d.chatID()
+	if chatID == 0 {
+		log.Printf("[NOTIFY] No chat to send the digest to, dropping %d event(s)", len(events))
+		return nil
+	}
+	if err := d.bot.SendMessage(chatID, d.format(events)); err != nil {
+		d.mu.Lock()
+		if len(d.pending) == 0 {
+			d.first = d.now()
+		}
+		d.pending = append(events, d.pending...)
+		d.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// Drain removes all buffered events without sending them and returns the
+// message they would have been sent as, or "" if nothing is buffered.
+func (d *Digest) Drain() string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	events := d.pending
+	d.pending = nil
+	if d.timer != nil {
+		d.timer.Stop()
+		d.timer = nil
+	}
+	if len(events) == 0 {
+		return ""
+	}
+	return d.format(events)
+}
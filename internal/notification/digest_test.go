@@ -0,0 +1,111 @@
+package notification
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/korjavin/dutyassistant/internal/i18n"
+	"github.com/korjavin/dutyassistant/internal/store"
+	"github.com/stretchr/testify/assert"
+)
+
+// chanSender delivers sent messages on a channel so tests can wait for timers.
+type chanSender chan string
+
+func (c chanSender) SendMessage(chatID int64, text string) error {
+	c <- text
+	return nil
+}
+
+func (c chanSender) SendMessageWithButtons(chatID int64, text string, buttons []Button) error {
+	return c.SendMessage(chatID, text)
+}
+
+func TestDigest_BatchesEventsWithinWindow(t *testing.T) {
+	sender := make(chanSender, 4)
+	digest := NewDigest(sender, testGroupID, 50*time.Millisecond, FormatDutyChanges)
+
+	for _, name := range []string{"Alice", "Bob", "Carol", "Dave"} {
+		digest.Add(name)
+	}
+
+	select {
+	case text := <-sender:
+		assert.Contains(t, text, "4 duty changes")
+		assert.Contains(t, text, "• Alice\n• Bob\n• Carol\n• Dave")
+	case <-time.After(time.Second):
+		t.Fatal("digest was never sent")
+	}
+
+	select {
+	case text := <-sender:
+		t.Fatalf("expected a single message, got another: %q", text)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestDigest_MaxDelay(t *testing.T) {
+	sender := make(chanSender, 1)
+	digest := NewDigest(sender, testGroupID, time.Hour, FormatDutyChanges)
+	start := time.Now()
+	digest.now = func() time.Time { return start }
+	digest.Add("first")
+
+	// An event arriving just before the deadline must not postpone the digest by a full window.
+	digest.now = func() time.Time { return start.Add(5*time.Hour - 20*time.Millisecond) }
+	digest.Add("second")
+
+	select {
+	case text := <-sender:
+		assert.Contains(t, text, "2 duty changes")
+	case <-time.After(time.Second):
+		t.Fatal("digest was postponed past its maximum delay")
+	}
+}
+
+func TestDigest_Flush(t *testing.T) {
+	sender := &fakeSender{}
+	digest := NewDigest(sender, testGroupID, time.Hour, FormatDutyChanges)
+
+	assert.NoError(t, digest.Flush(), "flushing an empty digest is a no-op")
+	assert.Empty(t, sender.sent)
+
+	digest.Add(FormatDutyChange(i18n.English, time.Date(2025, 10, 27, 0, 0, 0, 0, time.UTC), "Bob"))
+	assert.NoError(t, digest.Flush())
+	if assert.Len(t, sender.sent, 1) {
+		assert.Equal(t, "🔄 Duty change\n\nMon, Oct 27: @Bob is now on duty", sender.sent[0].text)
+	}
+
+	assert.NoError(t, digest.Flush())
+	assert.Len(t, sender.sent, 1, "events are only sent once")
+}
+
+func TestDigest_KeepsEventsWhenSendFails(t *testing.T) {
+	sender := &fakeSender{err: errors.New("telegram is down")}
+	digest := NewDigest(sender, testGroupID, time.Hour, FormatDutyChanges)
+
+	digest.Add(FormatDutyChange(i18n.English, time.Date(2025, 10, 27, 0, 0, 0, 0, time.UTC), "Bob"))
+	assert.Error(t, digest.Flush())
+
+	assert.Equal(t, "🔄 Duty change\n\nMon, Oct 27: @Bob is now on duty", digest.Drain())
+	assert.Equal(t, "", digest.Drain(), "drained events are gone")
+}
+
+func TestAnnounceChange(t *testing.T) {
+	notifier, _, sender, alice, bob := setupNotifierTest(t, 11)
+	monday := time.Date(2025, 10, 27, 0, 0, 0, 0, time.UTC)
+
+	for i, user := range []*store.User{bob, alice, bob} {
+		notifier.AnnounceChange(monday.AddDate(0, 0, i), user)
+	}
+	assert.Empty(t, sender.sent, "changes wait for the digest window")
+
+	assert.NoError(t, notifier.Close())
+	if assert.Len(t, sender.to(testGroupID), 1) {
+		assert.Equal(t, "🔄 3 duty changes\n\n"+
+			"• Mon, Oct 27: @Bob is now on duty\n"+
+			"• Tue, Oct 28: @Alice is now on duty\n"+
+			"• Wed, Oct 29: @Bob is now on duty", sender.to(testGroupID)[0])
+	}
+}
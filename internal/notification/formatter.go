@@ -2,8 +2,15 @@ package notification
 
 import (
 	"fmt"
+	"slices"
+	"sort"
 	"strings"
+	"time"
 
+	"github.com/korjavin/dutyassistant/internal/events"
+	"github.com/korjavin/dutyassistant/internal/i18n"
+	"github.com/korjavin/dutyassistant/internal/service/ledger"
+	"github.com/korjavin/dutyassistant/internal/service/week"
 	"github.com/korjavin/dutyassistant/internal/store"
 )
 
@@ -14,34 +21,164 @@ const (
 
 // FormatDutyAssignedMessage formats the notification message for a pre-existing duty.
 // It reminds the group who is on duty for the upcoming day.
-func FormatDutyAssignedMessage(duty *store.Duty) string {
+func FormatDutyAssignedMessage(l i18n.Locale, duty *store.Duty) string {
 	if duty == nil || duty.User == nil {
 		return "Error: Could not format duty message, essential data is missing."
 	}
-	dateStr := duty.DutyDate.Format(dutyDateFormat)
+	dateStr := l.Format(duty.DutyDate, dutyDateFormat)
 	// Using MarkdownV2 for formatting. Note the escaped period at the end.
 	return fmt.Sprintf(
 		"🔔 *Duty Reminder* 🔔\n\nTomorrow, *%s*, the duty is assigned to *%s*\\.",
 		escapeMarkdown(dateStr),
-		escapeMarkdown(duty.User.FirstName),
+		escapeMarkdown(duty.User.GroupName()),
 	)
 }
 
 // FormatDutyAutoAssignedMessage formats the notification message for a duty that
 // was just automatically assigned by the round-robin scheduler.
-func FormatDutyAutoAssignedMessage(duty *store.Duty) string {
+func FormatDutyAutoAssignedMessage(l i18n.Locale, duty *store.Duty) string {
 	if duty == nil || duty.User == nil {
 		return "Error: Could not format auto-assignment message, essential data is missing."
 	}
-	dateStr := duty.DutyDate.Format(dutyDateFormat)
+	dateStr := l.Format(duty.DutyDate, dutyDateFormat)
 	// Using MarkdownV2 for formatting. Note the escaped characters in the static text.
 	return fmt.Sprintf(
 		"📢 *Automatic Duty Assignment* 📢\n\nNo duty was scheduled for tomorrow\\. The round\\-robin scheduler has assigned the duty for *%s* to *%s*\\.",
 		escapeMarkdown(dateStr),
-		escapeMarkdown(duty.User.FirstName),
+		escapeMarkdown(duty.User.GroupName()),
 	)
 }
 
+// FormatAssignmentAnnouncement formats the announcement of who is on duty
+// today to the group, the first section of the daily announcement.
+func FormatAssignmentAnnouncement(l i18n.Locale, duty *store.Duty) string {
+	date := l.Format(duty.DutyDate, "January 2, 2006")
+	if duty.AssignmentType == store.AssignmentTypeExternal {
+		return fmt.Sprintf("🍽️ Duty Assignment for %s\n\nNobody is available today, %s arranged external help.", date, mention(duty.User))
+	}
+	return fmt.Sprintf("🍽️ Duty Assignment for %s\n\n%s is on duty today!\n\nType: %s", date, mention(duty.User), duty.AssignmentType)
+}
+
+// FormatUpcoming formats who is on duty on the days days from from on, for
+// the announcement. Duties planned ahead are marked, as they may still change.
+func FormatUpcoming(l i18n.Locale, from time.Time, days int, duties []*store.Duty) string {
+	byDate := make(map[string]*store.Duty, len(duties))
+	for _, duty := range duties {
+		byDate[duty.DutyDate.Format("2006-01-02")] = duty
+	}
+	var b strings.Builder
+	b.WriteString("📅 Next days:")
+	for i := 0; i < days; i++ {
+		day := from.AddDate(0, 0, i)
+		fmt.Fprintf(&b, "\n• %s: ", l.Format(day, "Mon, Jan 2"))
+		duty := byDate[day.Format("2006-01-02")]
+		switch {
+		case duty == nil || duty.User == nil:
+			b.WriteString("not assigned yet")
+		case duty.Status == store.DutyStatusProvisional:
+			b.WriteString(duty.User.GroupLabel() + " (planned)")
+		default:
+			b.WriteString(duty.User.GroupLabel())
+		}
+	}
+	return b.String()
+}
+
+// FormatQueues formats the volunteer and admin queues of the users, for the
+// announcement, or returns "" if they are all empty.
+func FormatQueues(users []*store.User) string {
+	var b strings.Builder
+	for _, u := range users {
+		var queued []string
+		if u.VolunteerQueueDays > 0 {
+			queued = append(queued, fmt.Sprintf("%d volunteered", u.VolunteerQueueDays))
+		}
+		if u.AdminQueueDays > 0 {
+			queued = append(queued, fmt.Sprintf("%d assigned by an admin", u.AdminQueueDays))
+		}
+		if len(queued) > 0 {
+			fmt.Fprintf(&b, "\n• %s: %s", u.GroupLabel(), strings.Join(queued, ", "))
+		}
+	}
+	if b.Len() == 0 {
+		return ""
+	}
+	return "🙋 Queued days:" + b.String()
+}
+
+// fairnessBarWidth is how many blocks the bar of the busiest user has.
+const fairnessBarWidth = 5
+
+// FormatFairness formats a bar per user of how many of the duties they did,
+// the busiest first, for the announcement. It returns "" if nobody did any.
+func FormatFairness(users []*store.User, duties []*store.Duty, days int) string {
+	counts := make(map[int64]int)
+	busiest := 0
+	for _, duty := range duties {
+		counts[duty.UserID]++
+		busiest = max(busiest, counts[duty.UserID])
+	}
+	if busiest == 0 {
+		return ""
+	}
+	users = slices.Clone(users)
+	sort.SliceStable(users, func(i, j int) bool { return counts[users[i].ID] > counts[users[j].ID] })
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "⚖️ Duties in the last %d days:", days)
+	for _, u := range users {
+		count := counts[u.ID]
+		filled := (count*fairnessBarWidth + busiest/2) / busiest
+		if count > 0 {
+			filled = max(filled, 1)
+		}
+		fmt.Fprintf(&b, "\n%s %s%s %d", u.GroupLabel(),
+			strings.Repeat("▰", filled), strings.Repeat("▱", fairnessBarWidth-filled), count)
+	}
+	return b.String()
+}
+
+// mention is how announcements name a user: @name, after their emoji if they
+// picked one with /me. The name is the one for the group, see
+// store.User.GroupName.
+func mention(u *store.User) string {
+	return NamesPublic.mention(u)
+}
+
+// Names is how a message names users, depending on who can see it.
+type Names int
+
+const (
+	// NamesHidden leaves users unnamed, for views anyone can see.
+	NamesHidden Names = iota
+	// NamesPublic names users as group messages do, with the privacy they
+	// chose with /me privacy.
+	NamesPublic
+	// NamesFull names users by their name, for private chats and signed-in
+	// views.
+	NamesFull
+)
+
+// mention is mention with the name of u as n gives it.
+func (n Names) mention(u *store.User) string {
+	if u.Emoji == "" {
+		return "@" + n.of(u, "someone")
+	}
+	return u.Emoji + " @" + n.of(u, "someone")
+}
+
+// of returns the name of u, or placeholder for NamesHidden.
+func (n Names) of(u *store.User, placeholder string) string {
+	switch n {
+	case NamesFull:
+		return u.FirstName
+	case NamesPublic:
+		return u.GroupName()
+	default:
+		return placeholder
+	}
+}
+
 // escapeMarkdown escapes characters for Telegram's MarkdownV2 parser.
 // See: https://core.telegram.org/bots/api#markdownv2-style
 func escapeMarkdown(s string) string {
@@ -50,4 +187,505 @@ func escapeMarkdown(s string) string {
 		s = strings.ReplaceAll(s, char, "\\"+char)
 	}
 	return s
-}
\ No newline at end of file
+}
+
+// FormatPersonalReminder formats the private reminder for the user on duty
+// today, followed by the notes for the day.
+func FormatPersonalReminder(duty *store.Duty, notes []string) string {
+	return fmt.Sprintf("🍽️ You're on duty today (%s)!\n\nAssignment type: %s",
+		duty.DutyDate.Format("2006-01-02"), duty.AssignmentType) + formatNotes(notes)
+}
+
+// FormatDailyReminder formats the private reminder of who is on duty today,
+// followed by the notes for the day.
+func FormatDailyReminder(l i18n.Locale, duty *store.Duty, notes []string) string {
+	return fmt.Sprintf("🍽️ %s is on duty today (%s).", duty.User.Label(), l.Format(duty.DutyDate, "Monday, January 2")) +
+		formatNotes(notes)
+}
+
+// formatNotes formats the notes of a duty as lines to append to a reminder.
+func formatNotes(notes []string) string {
+	if len(notes) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("\n")
+	for _, note := range notes {
+		b.WriteString("\n📝 " + note)
+	}
+	return b.String()
+}
+
+// FormatWeeklyStats formats the weekly report of completed duties between from and to, inclusive.
+// Only users with at least one completed duty are listed, busiest first.
+func FormatWeeklyStats(l i18n.Locale, from, to time.Time, duties []*store.Duty, names Names) string {
+	// Counted by user and name, as two users may have the same initials
+	type count struct {
+		userID int64
+		name   string
+		days   int
+	}
+	var counts []*count
+	byUser := make(map[count]*count)
+	for _, duty := range duties {
+		key := count{userID: duty.UserID, name: "Unknown"}
+		if duty.User != nil {
+			key.name = names.of(duty.User, "someone")
+		}
+		c := byUser[key]
+		if c == nil {
+			c = &key
+			byUser[key] = c
+			counts = append(counts, c)
+		}
+		c.days++
+	}
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].days != counts[j].days {
+			return counts[i].days > counts[j].days
+		}
+		return counts[i].name < counts[j].name
+	})
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "📊 Weekly Duty Report (%s - %s)\n\n", l.Format(from, "Jan 2"), l.Format(to, "Jan 2"))
+	if len(counts) == 0 {
+		b.WriteString("No duties were completed this week.")
+		return b.String()
+	}
+	b.WriteString("🏆 Duty Days This Week:\n")
+	for _, c := range counts {
+		days := "days"
+		if c.days == 1 {
+			days = "day"
+		}
+		fmt.Fprintf(&b, "• @%s: %d %s\n", c.name, c.days, days)
+	}
+	fmt.Fprintf(&b, "\nTotal: %d duty days completed", len(duties))
+	return b.String()
+}
+
+// FormatWeeklyTasks formats the part of the weekly report listing the one-off
+// tasks done in the week. Users missing from users are shown as unknown.
+func FormatWeeklyTasks(tasks []*store.Task, users map[int64]*store.User, names Names) string {
+	var b strings.Builder
+	b.WriteString("🧰 Extra Tasks Done:")
+	for _, t := range tasks {
+		name := "unknown"
+		if t.ClaimedBy != nil && users[*t.ClaimedBy] != nil {
+			name = names.mention(users[*t.ClaimedBy])
+		}
+		fmt.Fprintf(&b, "\n• %s: %s (%s)", name, t.Title, FormatTaskWeight(t.Weight))
+	}
+	return b.String()
+}
+
+// FormatTaskWeight describes what a one-off task counts for, e.g. "counts as
+// 3 duty days".
+func FormatTaskWeight(weight int) string {
+	if weight == 1 {
+		return "counts as 1 duty day"
+	}
+	return fmt.Sprintf("counts as %d duty days", weight)
+}
+
+// PollWeek returns the Monday of the week of date, the first day the Sunday
+// poll for volunteers asks about.
+func PollWeek(date time.Time) time.Time {
+	return date.AddDate(0, 0, -(int(date.Weekday())+6)%7)
+}
+
+// pollFree reports whether a day of the poll for volunteers can still be
+// taken: nobody is on duty or it is only planned ahead.
+func pollFree(duty *store.Duty) bool {
+	return duty == nil || duty.Status == store.DutyStatusProvisional
+}
+
+// FormatWeeklyPoll formats the poll for volunteers for the week from the
+// Monday from: who took which day so far and which are free.
+func FormatWeeklyPoll(l i18n.Locale, from time.Time, duties []*store.Duty) string {
+	byDate := make(map[string]*store.Duty, len(duties))
+	for _, duty := range duties {
+		byDate[duty.DutyDate.Format("2006-01-02")] = duty
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "🙋 Any volunteers for next week, %s - %s? Tap a day to take it, the rotation fills the rest.\n",
+		l.Format(from, "Jan 2"), l.Format(from.AddDate(0, 0, 6), "Jan 2"))
+	for i := 0; i < 7; i++ {
+		day := from.AddDate(0, 0, i)
+		fmt.Fprintf(&b, "\n• %s: ", l.Format(day, "Mon, Jan 2"))
+		duty := byDate[day.Format("2006-01-02")]
+		switch {
+		case duty == nil || duty.User == nil:
+			b.WriteString("free")
+		case duty.Status == store.DutyStatusProvisional:
+			b.WriteString("free, " + duty.User.GroupLabel() + " is planned")
+		case duty.AssignmentType == store.AssignmentTypeVoluntary:
+			b.WriteString(duty.User.GroupLabel() + " 🙋")
+		default:
+			b.WriteString(duty.User.GroupLabel())
+		}
+	}
+	return b.String()
+}
+
+// PollButtons returns the buttons of the poll for volunteers for the week from
+// the Monday from: one for each day that is still free.
+func PollButtons(l i18n.Locale, from time.Time, duties []*store.Duty) []Button {
+	byDate := make(map[string]*store.Duty, len(duties))
+	for _, duty := range duties {
+		byDate[duty.DutyDate.Format("2006-01-02")] = duty
+	}
+	var buttons []Button
+	for i := 0; i < 7; i++ {
+		day := from.AddDate(0, 0, i)
+		date := day.Format("2006-01-02")
+		if !pollFree(byDate[date]) {
+			continue
+		}
+		buttons = append(buttons, Button{
+			Text: fmt.Sprintf("%s %d", l.WeekdayShort(day.Weekday()), day.Day()),
+			Data: fmt.Sprintf("%s:%s", VolunteerPollAction, date),
+		})
+	}
+	return buttons
+}
+
+// FormatCoverRequest asks the group who covers the duty on date for u, who is
+// on it. fallback takes over if nobody does within wait, or u keeps the duty
+// if it is nil.
+func FormatCoverRequest(l i18n.Locale, date time.Time, u, fallback *store.User, wait time.Duration) string {
+	text := fmt.Sprintf("🆘 Who can cover for %s on %s? Tap the button to take the duty over.\n\n", u.GroupLabel(), l.Format(date, "Mon, Jan 2"))
+	if fallback == nil {
+		return text + fmt.Sprintf("If nobody does within %s, %s stays on duty.", formatWait(wait), u.GroupLabel())
+	}
+	return text + fmt.Sprintf("If nobody does within %s, %s takes over and %s owes a day.", formatWait(wait), fallback.GroupLabel(), u.GroupLabel())
+}
+
+// FormatCoverAsked tells a user privately that u asks for cover of their
+// duty on date.
+func FormatCoverAsked(l i18n.Locale, date time.Time, u *store.User) string {
+	return fmt.Sprintf("🆘 %s asks who can cover their duty on %s. Tap 🙋 I'll cover under the question to take it over.", u.GroupLabel(), l.Format(date, "Mon, Jan 2"))
+}
+
+// FormatCoverClaimed thanks the user who covers the duty on date for u.
+func FormatCoverClaimed(l i18n.Locale, date time.Time, u, by *store.User) string {
+	return fmt.Sprintf("🙌 %s covers for %s on %s, thanks!", by.GroupLabel(), u.GroupLabel(), l.Format(date, "Mon, Jan 2"))
+}
+
+// FormatCoverExpired tells that nobody covered the duty on date for u in
+// time, so fallback took over, or u stays on duty if it is nil.
+func FormatCoverExpired(l i18n.Locale, date time.Time, u, fallback *store.User) string {
+	text := fmt.Sprintf("⏰ Nobody could cover for %s on %s", u.GroupLabel(), l.Format(date, "Mon, Jan 2"))
+	if fallback == nil {
+		return text + fmt.Sprintf(", so %s stays on duty.", u.GroupLabel())
+	}
+	return text + fmt.Sprintf(", so %s takes over. %s owes a day and is up again soon.", fallback.GroupLabel(), u.GroupLabel())
+}
+
+// formatWait formats how long the group has to cover a duty, e.g. "30
+// minutes" or "2 hours".
+func formatWait(wait time.Duration) string {
+	minutes := int(wait.Round(time.Minute) / time.Minute)
+	switch {
+	case minutes == 60:
+		return "an hour"
+	case minutes > 60 && minutes%60 == 0:
+		return fmt.Sprintf("%d hours", minutes/60)
+	case minutes == 1:
+		return "a minute"
+	}
+	return fmt.Sprintf("%d minutes", minutes)
+}
+
+// FormatTask formats a one-off task with what it counts for, when it is due
+// and who, if anyone, is doing it. claimer is the user who claimed it.
+func FormatTask(l i18n.Locale, t *store.Task, claimer *store.User) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "🧰 %s\nIt %s", t.Title, FormatTaskWeight(t.Weight))
+	if t.DueDate != nil {
+		fmt.Fprintf(&b, ", due %s", l.Format(*t.DueDate, "Mon, Jan 2"))
+	}
+	b.WriteString(".\n\n")
+	name := "Someone"
+	if claimer != nil {
+		name = mention(claimer)
+	}
+	switch {
+	case t.DoneAt != nil:
+		fmt.Fprintf(&b, "✅ Done by %s, thanks!", name)
+	case t.ClaimedBy != nil:
+		fmt.Fprintf(&b, "🙋 %s is on it.", name)
+	default:
+		b.WriteString("Up for grabs, who does it?")
+	}
+	return b.String()
+}
+
+// statusEmoji marks each duty status in overviews.
+var statusEmoji = map[store.DutyStatus]string{
+	store.DutyStatusProvisional:  "🗓",
+	store.DutyStatusAnnounced:    "⏳",
+	store.DutyStatusAcknowledged: "👍",
+	store.DutyStatusCompleted:    "✅",
+	store.DutyStatusMissed:       "❌",
+}
+
+// statusWords describe each duty status in the plain-text schedule.
+var statusWords = map[store.DutyStatus]string{
+	store.DutyStatusProvisional:  "planned",
+	store.DutyStatusAnnounced:    "to do",
+	store.DutyStatusAcknowledged: "acknowledged",
+	store.DutyStatusCompleted:    "done",
+	store.DutyStatusMissed:       "missed",
+}
+
+// StatusEmoji returns the marker of a duty as seen on today: its status, but
+// a duty still open after its day counts as missed even before it is marked so.
+func StatusEmoji(duty *store.Duty, today time.Time) string {
+	return statusEmoji[statusOn(duty, today)]
+}
+
+// statusOn returns the status of a duty as seen on today, see StatusEmoji.
+func statusOn(duty *store.Duty, today time.Time) store.DutyStatus {
+	status := store.InitialStatus(duty)
+	if duty.CompletedAt == nil && duty.DutyDate.Before(today) && status != store.DutyStatusProvisional {
+		status = store.DutyStatusMissed
+	}
+	return status
+}
+
+// FormatScheduleText formats the schedule of month as plain text, one line
+// per day with the user on duty and the status in words, for screen readers
+// and e-ink displays. With NamesHidden, the users on duty are left out.
+func FormatScheduleText(l i18n.Locale, month time.Time, duties []*store.Duty, skipDays []*store.SkipDay, today time.Time, names Names) string {
+	byDate := make(map[string]*store.Duty, len(duties))
+	for _, duty := range duties {
+		byDate[duty.DutyDate.Format("2006-01-02")] = duty
+	}
+	skips := make(map[string]*store.SkipDay, len(skipDays))
+	for _, skip := range skipDays {
+		skips[skip.Date.Format("2006-01-02")] = skip
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Duty schedule for %s\n", l.Format(month, "January 2006"))
+	first := time.Date(month.Year(), month.Month(), 1, 0, 0, 0, 0, time.UTC)
+	for day := first; day.Month() == first.Month(); day = day.AddDate(0, 0, 1) {
+		fmt.Fprintf(&b, "\n%s: ", l.Format(day, "Monday, January 2"))
+		key := day.Format("2006-01-02")
+		switch duty, skip := byDate[key], skips[key]; {
+		case duty != nil:
+			name := "someone"
+			if names != NamesHidden {
+				name = "unknown"
+				if duty.User != nil {
+					name = names.of(duty.User, "someone")
+				}
+			}
+			fmt.Fprintf(&b, "%s, %s", name, statusWords[statusOn(duty, today)])
+		case skip != nil:
+			fmt.Fprintf(&b, "no duty, %s", strings.ReplaceAll(string(skip.Reason), "_", " "))
+		default:
+			b.WriteString("nobody assigned")
+		}
+	}
+	return b.String()
+}
+
+// FormatWeek formats the compact seven-line overview of a week. Duties are
+// marked with their status relative to today: planned (🗓), still to do (⏳),
+// acknowledged (👍), done (✅) or missed (❌). Days with a waste collection
+// list its bins.
+func FormatWeek(l i18n.Locale, w *week.Week, today time.Time, names Names) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "📅 Week of %s\n", l.Format(w.Start, "Jan 2"))
+	for _, day := range w.Days {
+		fmt.Fprintf(&b, "\n%s: ", l.Format(day.Date, "Mon"))
+		switch {
+		case day.Duty != nil:
+			name := "Unknown"
+			if day.Duty.User != nil {
+				name = names.of(day.Duty.User, "Someone")
+			}
+			fmt.Fprintf(&b, "%s %s", name, StatusEmoji(day.Duty, today))
+		case day.Skip != nil:
+			fmt.Fprintf(&b, "🚫 %s", strings.ReplaceAll(string(day.Skip.Reason), "_", " "))
+		default:
+			b.WriteString("—")
+		}
+		if len(day.Bins) > 0 {
+			b.WriteString(" 🗑️ " + strings.Join(day.Bins, ", "))
+		}
+	}
+	return b.String()
+}
+
+// FormatBadgeAwarded formats the group message congratulating a user on a badge.
+func FormatBadgeAwarded(user *store.User, title string) string {
+	return fmt.Sprintf("🏅 %s earned a badge: %s!", mention(user), title)
+}
+
+// FormatStatement formats the group message settling a month of payout
+// mode: what each user was charged and paid, and what they owe now.
+func FormatStatement(l i18n.Locale, st *ledger.Statement) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "💰 Settlement for %s\n\n", l.Format(st.Month, "January 2006"))
+	if len(st.Lines) == 0 {
+		b.WriteString("No duties were missed and nobody owes anything. 🎉")
+		return b.String()
+	}
+	for _, line := range st.Lines {
+		fmt.Fprintf(&b, "• %s: owes %s", mention(line.User), ledger.FormatAmount(line.Balance, st.Currency))
+		var details []string
+		if line.Missed > 0 {
+			duties := "duties"
+			if line.Missed == 1 {
+				duties = "duty"
+			}
+			details = append(details, fmt.Sprintf("%d missed %s, %s", line.Missed, duties, ledger.FormatAmount(line.Charged, st.Currency)))
+		}
+		if line.Paid > 0 {
+			details = append(details, "paid "+ledger.FormatAmount(line.Paid, st.Currency))
+		}
+		if len(details) > 0 {
+			fmt.Fprintf(&b, " (%s)", strings.Join(details, "; "))
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString("\nAdmins record payments with /balance paid <user>.")
+	return b.String()
+}
+
+// FormatFine formats the private message to a user whose duty on date was
+// missed in payout mode, with its fine and what they owe now.
+func FormatFine(l i18n.Locale, date time.Time, fine, balance int64, currency string) string {
+	return fmt.Sprintf("💸 Your duty on %s was missed, which costs %s. You owe %s now.",
+		l.Format(date, "Monday, January 2"), ledger.FormatAmount(fine, currency), ledger.FormatAmount(balance, currency))
+}
+
+// FormatMonthPublished formats the group message with the published plan of
+// a month, a line per duty. Users missing from users are shown as unknown.
+func FormatMonthPublished(l i18n.Locale, month time.Time, duties []*store.Duty, users map[int64]*store.User) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "📣 The plan for %s is published:\n", l.Format(month, "January 2006"))
+	for _, d := range duties {
+		name := "unknown"
+		if u := users[d.UserID]; u != nil {
+			name = mention(u)
+		}
+		fmt.Fprintf(&b, "\n%s: %s", l.Format(d.DutyDate, "Mon, Jan 2"), name)
+	}
+	b.WriteString("\n\nAsk an admin if a day doesn't work for you.")
+	return b.String()
+}
+
+// FormatSeasonStarted formats the group message announcing that a season,
+// or the rest of the year, starts today and what changes with it.
+func FormatSeasonStarted(e events.SeasonStarted) string {
+	var b strings.Builder
+	switch {
+	case e.Season == "":
+		fmt.Fprintf(&b, "🗓 The %s season is over, the regular schedule is back.", e.Previous)
+	case e.Previous == "":
+		fmt.Fprintf(&b, "🗓 The %s season starts today.", e.Season)
+	default:
+		fmt.Fprintf(&b, "🗓 The %s season is over, the %s season starts today.", e.Previous, e.Season)
+	}
+	fmt.Fprintf(&b, "\n\nThe duty is assigned at %02d:%02d", int(e.AssignmentTime.Hours()), int(e.AssignmentTime.Minutes())%60)
+	if e.WeekendRotation {
+		b.WriteString(" and weekends have a rotation of their own")
+	}
+	if e.Users == nil {
+		b.WriteString(". Everyone is on the roster.")
+		return b.String()
+	}
+	names := make([]string, len(e.Users))
+	for i, u := range e.Users {
+		names[i] = mention(u)
+	}
+	fmt.Fprintf(&b, ". On the roster: %s.", strings.Join(names, ", "))
+	return b.String()
+}
+
+// FormatUnassignedDay formats the message telling the admin that nobody was
+// on duty on a day that wasn't skipped, found by the 21:00 completion.
+func FormatUnassignedDay(l i18n.Locale, date time.Time) string {
+	return fmt.Sprintf("⚠️ Nobody was on duty on %s, although the day isn't skipped. "+
+		"Use /backfill if someone did the dishes anyway, or /skip for days like this.",
+		l.Format(date, "Monday, January 2"))
+}
+
+// FormatMaintenance formats the report of the weekly database maintenance
+// for the admin: how big the database is now, what the run freed, and the
+// size after each of the latest runs, runs[0] being this one.
+func FormatMaintenance(runs []*store.MaintenanceRun) string {
+	run := runs[0]
+	var b strings.Builder
+	b.WriteString("🧹 Weekly database maintenance\n\n")
+	fmt.Fprintf(&b, "Size: %s", FormatBytes(run.SizeAfter))
+	if freed := run.SizeBefore - run.SizeAfter; freed > 0 {
+		fmt.Fprintf(&b, " (%s freed)", FormatBytes(freed))
+	}
+	fmt.Fprintf(&b, "\nOld log rows removed: %d", run.Deleted)
+	if len(runs) > 1 {
+		sizes := make([]string, len(runs))
+		for i, r := range runs {
+			sizes[len(runs)-1-i] = FormatBytes(r.SizeAfter)
+		}
+		fmt.Fprintf(&b, "\nLast %d weeks: %s", len(runs), strings.Join(sizes, " → "))
+	}
+	return b.String()
+}
+
+// FormatBytes formats a size in bytes with a binary unit, e.g. "1.5 MiB".
+func FormatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+// FormatApprovalRequest formats the message asking the admins to approve or
+// reject a pending user.
+func FormatApprovalRequest(user *store.User) string {
+	return fmt.Sprintf("🙋 %s wants to join the roster and waits for your approval. "+
+		"Until then they can look around but aren't put on duty.", user.FirstName)
+}
+
+// FormatTakeoverRequest formats the message asking the admin to decide about a
+// day nobody is available for.
+func FormatTakeoverRequest(l i18n.Locale, date time.Time) string {
+	return fmt.Sprintf("⚠️ Nobody is available for duty on %s: everyone is inactive or off-duty.\n\nWhat should happen today?",
+		l.Format(date, "Monday, January 2"))
+}
+
+// FormatDutyChange formats a single schedule change as one line of a digest.
+func FormatDutyChange(l i18n.Locale, date time.Time, userName string) string {
+	return fmt.Sprintf("%s: @%s is now on duty", l.Format(date, "Mon, Jan 2"), userName)
+}
+
+// FormatDutyReleased formats the schedule change of a held duty that wasn't
+// confirmed in time.
+func FormatDutyReleased(l i18n.Locale, date time.Time, userName string) string {
+	return fmt.Sprintf("%s: @%s's hold expired, the day is free again", l.Format(date, "Mon, Jan 2"), userName)
+}
+
+// FormatDutyChanges summarizes schedule changes batched by a Digest into one group message.
+func FormatDutyChanges(changes []string) string {
+	if len(changes) == 1 {
+		return "🔄 Duty change\n\n" + changes[0]
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "🔄 %d duty changes\n\n", len(changes))
+	for _, change := range changes {
+		fmt.Fprintf(&b, "• %s\n", change)
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
@@ -1,9 +1,14 @@
 package notification
 
 import (
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/korjavin/dutyassistant/internal/events"
+	"github.com/korjavin/dutyassistant/internal/i18n"
+	"github.com/korjavin/dutyassistant/internal/service/ledger"
+	"github.com/korjavin/dutyassistant/internal/service/week"
 	"github.com/korjavin/dutyassistant/internal/store"
 	"github.com/stretchr/testify/assert"
 )
@@ -17,7 +22,7 @@ func TestFormatDutyAssignedMessage(t *testing.T) {
 	}
 
 	expected := "🔔 *Duty Reminder* 🔔\n\nTomorrow, *Friday, 27 October 2023*, the duty is assigned to *John*\\."
-	actual := FormatDutyAssignedMessage(duty)
+	actual := FormatDutyAssignedMessage(i18n.English, duty)
 
 	assert.Equal(t, expected, actual)
 }
@@ -31,17 +36,17 @@ func TestFormatDutyAutoAssignedMessage(t *testing.T) {
 	}
 
 	expected := "📢 *Automatic Duty Assignment* 📢\n\nNo duty was scheduled for tomorrow\\. The round\\-robin scheduler has assigned the duty for *Saturday, 28 October 2023* to *Jane*\\."
-	actual := FormatDutyAutoAssignedMessage(duty)
+	actual := FormatDutyAutoAssignedMessage(i18n.English, duty)
 
 	assert.Equal(t, expected, actual)
 }
 
 func TestFormatDutyMessage_NilDuty(t *testing.T) {
 	expected := "Error: Could not format duty message, essential data is missing."
-	actual := FormatDutyAssignedMessage(nil)
+	actual := FormatDutyAssignedMessage(i18n.English, nil)
 	assert.Equal(t, expected, actual)
 
-	actualAuto := FormatDutyAutoAssignedMessage(nil)
+	actualAuto := FormatDutyAutoAssignedMessage(i18n.English, nil)
 	assert.Equal(t, "Error: Could not format auto-assignment message, essential data is missing.", actualAuto)
 }
 
@@ -51,10 +56,10 @@ func TestFormatDutyMessage_NilUser(t *testing.T) {
 		User:     nil, // Nil user
 	}
 	expected := "Error: Could not format duty message, essential data is missing."
-	actual := FormatDutyAssignedMessage(duty)
+	actual := FormatDutyAssignedMessage(i18n.English, duty)
 	assert.Equal(t, expected, actual)
 
-	actualAuto := FormatDutyAutoAssignedMessage(duty)
+	actualAuto := FormatDutyAutoAssignedMessage(i18n.English, duty)
 	assert.Equal(t, "Error: Could not format auto-assignment message, essential data is missing.", actualAuto)
 }
 
@@ -76,4 +81,166 @@ func TestEscapeMarkdown(t *testing.T) {
 			assert.Equal(t, tt.expected, escapeMarkdown(tt.input))
 		})
 	}
-}
\ No newline at end of file
+}
+func TestFormatWeeklyStats(t *testing.T) {
+	from := time.Date(2025, 10, 20, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 0, 6)
+	alice := &store.User{FirstName: "Alice"}
+	bob := &store.User{FirstName: "Bob"}
+	duties := []*store.Duty{{User: bob}, {User: alice}, {User: bob}}
+
+	expected := "📊 Weekly Duty Report (Oct 20 - Oct 26)\n\n" +
+		"🏆 Duty Days This Week:\n• @Bob: 2 days\n• @Alice: 1 day\n\n" +
+		"Total: 3 duty days completed"
+	assert.Equal(t, expected, FormatWeeklyStats(i18n.English, from, to, duties, NamesFull))
+
+	assert.Equal(t, "📊 Weekly Duty Report (Oct 20 - Oct 26)\n\nNo duties were completed this week.", FormatWeeklyStats(i18n.English, from, to, nil, NamesFull))
+}
+
+func TestFormatWeeklyStats_Privacy(t *testing.T) {
+	from := time.Date(2025, 10, 20, 0, 0, 0, 0, time.UTC)
+	alice := &store.User{ID: 1, FirstName: "Alice", Privacy: store.PrivacyInitials}
+	anna := &store.User{ID: 2, FirstName: "Anna", Privacy: store.PrivacyInitials}
+	bob := &store.User{ID: 3, FirstName: "Bob", Privacy: store.PrivacyAlias, Alias: "Captain"}
+	duties := []*store.Duty{{UserID: 1, User: alice}, {UserID: 2, User: anna}, {UserID: 3, User: bob}, {UserID: 1, User: alice}}
+
+	group := FormatWeeklyStats(i18n.English, from, from.AddDate(0, 0, 6), duties, NamesPublic)
+	assert.Contains(t, group, "• @A.: 2 days\n• @A.: 1 day\n• @Captain: 1 day\n", "the same initials are counted apart")
+	assert.NotContains(t, group, "Alice")
+	assert.Contains(t, FormatWeeklyStats(i18n.English, from, from.AddDate(0, 0, 6), duties, NamesFull), "• @Alice: 2 days\n")
+}
+
+func TestFormatWeek(t *testing.T) {
+	monday := time.Date(2025, 10, 20, 0, 0, 0, 0, time.UTC)
+	done := monday.Add(20 * time.Hour)
+	w := &week.Week{Start: monday}
+	for i := range w.Days {
+		w.Days[i].Date = monday.AddDate(0, 0, i)
+	}
+	w.Days[0].Duty = &store.Duty{DutyDate: w.Days[0].Date, User: &store.User{FirstName: "Alice"}, CompletedAt: &done}
+	w.Days[1].Duty = &store.Duty{DutyDate: w.Days[1].Date, User: &store.User{FirstName: "Bob"}}
+	w.Days[2].Duty = &store.Duty{DutyDate: w.Days[2].Date, User: &store.User{FirstName: "Alice"}}
+	w.Days[3].Skip = &store.SkipDay{Reason: store.SkipReasonEatingOut}
+	w.Days[4].Duty = &store.Duty{DutyDate: w.Days[4].Date, User: &store.User{FirstName: "Bob"}, Status: store.DutyStatusAcknowledged}
+	w.Days[5].Duty = &store.Duty{DutyDate: w.Days[5].Date, User: &store.User{FirstName: "Alice"}, Status: store.DutyStatusProvisional}
+	w.Days[2].Bins = []string{"Paper", "Bio"}
+
+	expected := "📅 Week of Oct 20\n\n" +
+		"Mon: Alice ✅\nTue: Bob ❌\nWed: Alice ⏳ 🗑️ Paper, Bio\nThu: 🚫 eating out\nFri: Bob 👍\nSat: Alice 🗓\nSun: —"
+	assert.Equal(t, expected, FormatWeek(i18n.English, w, monday.AddDate(0, 0, 2), NamesFull))
+
+	german := FormatWeek(i18n.German, w, monday.AddDate(0, 0, 2), NamesFull)
+	assert.Contains(t, german, "📅 Week of 20. Okt\n\nMo: Alice ✅\nDi: Bob ❌")
+	assert.Contains(t, german, "So: —")
+}
+
+func TestFormatDutyChange_German(t *testing.T) {
+	date := time.Date(2025, 3, 3, 0, 0, 0, 0, time.UTC)
+	assert.Equal(t, "Mo, 3. Mär: @Bob is now on duty", FormatDutyChange(i18n.German, date, "Bob"))
+	assert.Equal(t, "📅 Schedule change: you are now on duty on Montag, 3. März.", FormatDutyGained(i18n.German, date))
+}
+
+func TestFormatSeasonStarted(t *testing.T) {
+	bob := &store.User{FirstName: "Bob", Emoji: "🦊"}
+	assert.Equal(t, "🗓 The summer season starts today.\n\nThe duty is assigned at 10:00. On the roster: 🦊 @Bob.",
+		FormatSeasonStarted(events.SeasonStarted{Season: "summer", AssignmentTime: 10 * time.Hour, Users: []*store.User{bob}}))
+	assert.Equal(t, "🗓 The summer season is over, the regular schedule is back.\n\nThe duty is assigned at 11:30 and weekends have a rotation of their own. Everyone is on the roster.",
+		FormatSeasonStarted(events.SeasonStarted{Previous: "summer", AssignmentTime: 11*time.Hour + 30*time.Minute, WeekendRotation: true}))
+}
+
+func TestFormatStatement(t *testing.T) {
+	st := &ledger.Statement{Month: time.Date(2025, 11, 1, 0, 0, 0, 0, time.UTC), Currency: "EUR", Lines: []ledger.Line{
+		{User: &store.User{FirstName: "Alice"}, Missed: 2, Charged: 1000, Paid: 500, Balance: 500},
+		{User: &store.User{FirstName: "Bob"}, Paid: 300},
+	}}
+	assert.Equal(t, "💰 Settlement for November 2025\n\n"+
+		"• @Alice: owes 5.00 EUR (2 missed duties, 10.00 EUR; paid 5.00 EUR)\n"+
+		"• @Bob: owes 0.00 EUR (paid 3.00 EUR)\n"+
+		"\nAdmins record payments with /balance paid <user>.", FormatStatement(i18n.English, st))
+
+	st.Lines = nil
+	assert.Contains(t, FormatStatement(i18n.German, st), "Settlement for November 2025\n\nNo duties were missed")
+}
+
+func TestFormatTask(t *testing.T) {
+	due := time.Date(2025, 11, 8, 0, 0, 0, 0, time.UTC)
+	alice := &store.User{ID: 1, FirstName: "Alice", Emoji: "🦊"}
+	task := &store.Task{ID: 3, Title: "Clean the garage", Weight: 3, DueDate: &due}
+	assert.Equal(t, "🧰 Clean the garage\nIt counts as 3 duty days, due Sat, Nov 8.\n\nUp for grabs, who does it?", FormatTask(i18n.English, task, nil))
+
+	task.ClaimedBy = &alice.ID
+	assert.Contains(t, FormatTask(i18n.German, task, alice), "due Sa, 8. Nov.\n\n🙋 🦊 @Alice is on it.")
+	task.DoneAt = &due
+	assert.Contains(t, FormatTask(i18n.English, task, alice), "✅ Done by 🦊 @Alice, thanks!")
+
+	task.Weight = 1
+	assert.Equal(t, "🧰 Extra Tasks Done:\n• 🦊 @Alice: Clean the garage (counts as 1 duty day)",
+		FormatWeeklyTasks([]*store.Task{task}, map[int64]*store.User{alice.ID: alice}, NamesFull))
+
+	alice.Privacy, alice.Alias = store.PrivacyAlias, "Fox"
+	assert.Contains(t, FormatTask(i18n.English, task, alice), "✅ Done by 🦊 @Fox, thanks!")
+	assert.Contains(t, FormatWeeklyTasks([]*store.Task{task}, map[int64]*store.User{alice.ID: alice}, NamesFull), "🦊 @Alice:")
+}
+
+func TestFormatScheduleText(t *testing.T) {
+	month := time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)
+	done := month.Add(20 * time.Hour)
+	duties := []*store.Duty{
+		{DutyDate: month, User: &store.User{FirstName: "Alice"}, CompletedAt: &done},
+		{DutyDate: month.AddDate(0, 0, 1), User: &store.User{FirstName: "Bob"}},
+		{DutyDate: month.AddDate(0, 0, 2), User: &store.User{FirstName: "Alice"}},
+		{DutyDate: month.AddDate(0, 0, 4), User: &store.User{FirstName: "Bob"}, Status: store.DutyStatusProvisional},
+	}
+	skips := []*store.SkipDay{{Date: month.AddDate(0, 0, 3), Reason: store.SkipReasonEatingOut}}
+	today := month.AddDate(0, 0, 2)
+
+	text := FormatScheduleText(i18n.English, month, duties, skips, today, NamesFull)
+	assert.True(t, strings.HasPrefix(text, "Duty schedule for February 2025\n\n"+
+		"Saturday, February 1: Alice, done\n"+
+		"Sunday, February 2: Bob, missed\n"+
+		"Monday, February 3: Alice, to do\n"+
+		"Tuesday, February 4: no duty, eating out\n"+
+		"Wednesday, February 5: Bob, planned\n"+
+		"Thursday, February 6: nobody assigned\n"), text)
+	assert.True(t, strings.HasSuffix(text, "\nFriday, February 28: nobody assigned"), text)
+	assert.Equal(t, 2+28, strings.Count(text, "\n")+1, "a line per day")
+
+	anonymous := FormatScheduleText(i18n.English, month, duties, skips, today, NamesHidden)
+	assert.Contains(t, anonymous, "Saturday, February 1: someone, done\n")
+	assert.NotContains(t, anonymous, "Alice")
+}
+
+func TestFormatFairness(t *testing.T) {
+	alice := &store.User{ID: 1, FirstName: "Alice"}
+	bob := &store.User{ID: 2, FirstName: "Bob", Emoji: "🦊"}
+	carol := &store.User{ID: 3, FirstName: "Carol"}
+	var duties []*store.Duty
+	for i := 0; i < 10; i++ {
+		duties = append(duties, &store.Duty{UserID: alice.ID})
+	}
+	duties = append(duties, &store.Duty{UserID: bob.ID}, &store.Duty{UserID: bob.ID}, &store.Duty{UserID: bob.ID}, &store.Duty{UserID: carol.ID})
+
+	assert.Equal(t, "⚖️ Duties in the last 30 days:\nAlice ▰▰▰▰▰ 10\n🦊 Bob ▰▰▱▱▱ 3\nCarol ▰▱▱▱▱ 1",
+		FormatFairness([]*store.User{carol, bob, alice}, duties, 30))
+	assert.Empty(t, FormatFairness([]*store.User{alice}, nil, 30), "nothing to show without duties")
+	assert.Empty(t, FormatQueues([]*store.User{alice, bob}), "nothing to show without queued days")
+}
+
+func TestFormatMaintenance(t *testing.T) {
+	runs := []*store.MaintenanceRun{
+		{SizeBefore: 3 << 20, SizeAfter: 2 << 20, Deleted: 42},
+		{SizeBefore: 1536 << 10, SizeAfter: 1536 << 10},
+	}
+	msg := FormatMaintenance(runs)
+	assert.Contains(t, msg, "Size: 2.0 MiB (1.0 MiB freed)")
+	assert.Contains(t, msg, "Old log rows removed: 42")
+	assert.Contains(t, msg, "Last 2 weeks: 1.5 MiB → 2.0 MiB")
+
+	// The first run has no trend, and a database that didn't shrink frees nothing
+	msg = FormatMaintenance(runs[1:2])
+	assert.NotContains(t, msg, "freed")
+	assert.NotContains(t, msg, "weeks")
+
+	assert.Equal(t, "512 B", FormatBytes(512))
+	assert.Equal(t, "1.5 KiB", FormatBytes(1536))
+}
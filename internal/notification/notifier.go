@@ -2,119 +2,948 @@ package notification
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log"
+	"sync"
 	"time"
 
-	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/korjavin/dutyassistant/internal/events"
+	"github.com/korjavin/dutyassistant/internal/i18n"
+	"github.com/korjavin/dutyassistant/internal/service/badge"
+	"github.com/korjavin/dutyassistant/internal/service/cover"
+	"github.com/korjavin/dutyassistant/internal/service/ledger"
+	"github.com/korjavin/dutyassistant/internal/service/note"
+	"github.com/korjavin/dutyassistant/internal/service/settings"
+	"github.com/korjavin/dutyassistant/internal/service/week"
 	"github.com/korjavin/dutyassistant/internal/store"
-	"github.com/robfig/cron/v3"
 )
 
-// Scheduler defines the interface for duty assignment operations.
-type Scheduler interface {
-	AssignDutyRoundRobin(ctx context.Context, date time.Time) (*store.Duty, error)
+// Kind identifies a category of private notification a user can opt out of.
+type Kind string
+
+const (
+	KindGroupReminder Kind = "group_reminder"
+	KindPersonalDM    Kind = "personal_dm"
+	KindWeeklyStats   Kind = "weekly_stats"
+	KindSwapRequests  Kind = "swap_requests"
+)
+
+// Kinds lists all notification kinds in the order they are shown to users.
+var Kinds = []Kind{KindGroupReminder, KindPersonalDM, KindWeeklyStats, KindSwapRequests}
+
+// SnoozeAction is the callback action of the snooze buttons on personal
+// reminders. Its single argument is the delay in hours.
+const SnoozeAction = "snooze"
+
+// AcknowledgeAction is the callback action of the button on personal
+// reminders that acknowledges the duty. Its single argument is the date.
+const AcknowledgeAction = "ack"
+
+// SnoozeHours are the delays offered on personal reminders.
+var SnoozeHours = []int{1, 3}
+
+// Callback actions of the buttons sent to the admin when nobody is available
+// for today's duty. Their single argument is the date.
+const (
+	TakeoverAssignAction   = "takeover_assign"   // assign anyway to a chosen person
+	TakeoverSkipAction     = "takeover_skip"     // leave the day without duty
+	TakeoverExternalAction = "takeover_external" // the day is covered by outside help
+)
+
+// Callback actions of the buttons under the announcement of today's duty, for
+// an admin to correct the daily assignment right there. Their single argument
+// is the date.
+const (
+	ReassignTodayAction = "today_reassign" // pick someone else
+	SkipTodayAction     = "today_skip"     // leave the day without duty
+)
+
+// Callback actions of the buttons sent to the admins when someone waits for
+// approval to join the rotation. Their single argument is the user's ID.
+const (
+	ApproveUserAction = "approve_user" // add them to the rotation
+	RejectUserAction  = "reject_user"  // delete their registration
+)
+
+// Callback actions of the buttons of one-off tasks. Their first argument is
+// the task's ID.
+const (
+	ClaimTaskAction    = "task_claim"   // take the task on
+	CompleteTaskAction = "task_done"    // mark it done
+	ReleaseTaskAction  = "task_release" // give it back for someone else to take
+)
+
+// VolunteerPollAction is the callback action of the day buttons of the Sunday
+// poll for volunteers. Its single argument is the date.
+const VolunteerPollAction = "poll_volunteer"
+
+// CoverAction is the callback action of the button under a /cover request
+// that takes the duty over. Its single argument is the date.
+const CoverAction = "cover_claim"
+
+// PayFineAction is the callback action of the button that asks for an
+// invoice of a balance of payout mode. Its single argument is the user's ID.
+const PayFineAction = "pay_fine"
+
+// ErrNotOnDuty is returned when a user snoozes a reminder for a duty that is
+// no longer theirs.
+var ErrNotOnDuty = errors.New("user is not on duty today")
+
+// Button is an inline button attached to a message.
+type Button struct {
+	Text string
+	Data string // Callback data sent back when the button is pressed
+}
+
+// Sender sends a plain-text message to a Telegram chat.
+type Sender interface {
+	SendMessage(chatID int64, text string) error
+	// SendMessageWithButtons sends a message with a row of inline buttons.
+	SendMessageWithButtons(chatID int64, text string, buttons []Button) error
 }
 
-// TelegramBot defines the interface for sending Telegram messages.
-type TelegramBot interface {
-	Send(c tgbotapi.Chattable) (tgbotapi.Message, error)
+// Store is the part of store.Store the notifier reads and writes.
+type Store interface {
+	store.UserStore
+	store.DutyStore
+	store.NotificationStore
 }
 
-// Notifier manages scheduled duty notifications.
+// Notifier delivers duty notifications to the group chat and, according to
+// each user's preferences, to users privately.
 type Notifier struct {
-	store     store.Store
-	scheduler Scheduler
-	bot       TelegramBot
-	cron      *cron.Cron
-	location  *time.Location
-	chatID    int64
-	cronSpec  string
+	store    Store
+	bot      Sender
+	groupID  int64
+	location *time.Location
+	// Locale formats the dates of chats that didn't pick a locale with /language.
+	Locale i18n.Locale
+	// Settings is optional. If set, the group chat is read from it on every
+	// message, so /settings changes it without a restart; groupID is only the
+	// fallback when it can't be read.
+	Settings *settings.Service
+	// changes batches schedule changes into one group message.
+	changes *Digest
+	// notes finds the notes reminders carry.
+	notes *note.Service
+
+	// announceMu makes sure today's duty is announced once, when the
+	// assignment and AnnounceMissed run at the same time
+	announceMu sync.Mutex
+
+	mu sync.Mutex
+	// snoozes holds the timers of pending snoozed reminders, keyed by snooze ID.
+	snoozes map[int64]*time.Timer
 	// now is a function that returns the current time. It's used for testing.
 	now func() time.Time
 }
 
-// NewNotifier creates and new Notifier.
-func NewNotifier(s store.Store, sched Scheduler, bot TelegramBot, chatID int64, cronSpec string, loc *time.Location) *Notifier {
-	return &Notifier{
-		store:     s,
-		scheduler: sched,
-		bot:       bot,
-		location:  loc,
-		chatID:    chatID,
-		cronSpec:  cronSpec,
-		now:       time.Now, // Use real time by default
+// NewNotifier creates a new Notifier. groupID may be 0 if there is no group chat.
+func NewNotifier(s Store, bot Sender, groupID int64, loc *time.Location) *Notifier {
+	n := &Notifier{
+		store:    s,
+		bot:      bot,
+		groupID:  groupID,
+		location: loc,
+		Locale:   i18n.Default,
+		changes:  NewDigest(bot, groupID, DefaultDigestWindow, FormatDutyChanges),
+		notes:    note.New(s),
+		snoozes:  make(map[int64]*time.Timer),
+		now:      time.Now, // Use real time by default
+	}
+	// Digests go to the group chat of when they are sent
+	n.changes.chatID = func() int64 { return n.groupChat(context.Background()) }
+	return n
+}
+
+// Preferences returns a user's notification preferences, or the defaults if
+// they never changed them.
+func Preferences(ctx context.Context, s store.NotificationStore, userID int64) (*store.NotificationPreferences, error) {
+	prefs, err := s.GetNotificationPreferences(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if prefs == nil {
+		prefs = store.DefaultNotificationPreferences(userID)
+	}
+	return prefs, nil
+}
+
+// Wants reports whether the preferences allow notifications of the given kind.
+func Wants(prefs *store.NotificationPreferences, kind Kind) bool {
+	switch kind {
+	case KindGroupReminder:
+		return prefs.GroupReminder
+	case KindPersonalDM:
+		return prefs.PersonalDM
+	case KindWeeklyStats:
+		return prefs.WeeklyStats
+	case KindSwapRequests:
+		return prefs.SwapRequests
+	}
+	return false
+}
+
+// SetWants enables or disables notifications of the given kind.
+func SetWants(prefs *store.NotificationPreferences, kind Kind, on bool) {
+	switch kind {
+	case KindGroupReminder:
+		prefs.GroupReminder = on
+	case KindPersonalDM:
+		prefs.PersonalDM = on
+	case KindWeeklyStats:
+		prefs.WeeklyStats = on
+	case KindSwapRequests:
+		prefs.SwapRequests = on
+	}
+}
+
+// Notify sends text to the user privately if they opted into this kind of
+// notification. It reports whether the message was sent.
+func (n *Notifier) Notify(ctx context.Context, user *store.User, kind Kind, text string) (bool, error) {
+	prefs, err := Preferences(ctx, n.store, user.ID)
+	if err != nil {
+		return false, fmt.Errorf("failed to load notification preferences: %w", err)
+	}
+	if !Wants(prefs, kind) {
+		return false, nil
+	}
+	if err := n.bot.SendMessage(user.TelegramUserID, text); err != nil {
+		return false, fmt.Errorf("failed to send %s notification to user %d: %w", kind, user.TelegramUserID, err)
+	}
+	return true, nil
+}
+
+// locale returns the locale to format dates in for a chat.
+func (n *Notifier) locale(ctx context.Context, chatID int64) i18n.Locale {
+	return i18n.ForChat(ctx, n.store, chatID, n.Locale)
+}
+
+// groupChat returns the ID of the group chat, or 0 if there is none.
+func (n *Notifier) groupChat(ctx context.Context) int64 {
+	if n.Settings == nil {
+		return n.groupID
+	}
+	id, err := n.Settings.GroupChatID(ctx)
+	if err != nil {
+		log.Printf("[NOTIFY] Failed to get the group chat, using %d: %v", n.groupID, err)
+		return n.groupID
 	}
+	return id
 }
 
-// Start initializes and starts the cron scheduler.
-func (n *Notifier) Start() {
-	log.Printf("Starting notifier with schedule '%s' in %s timezone", n.cronSpec, n.location)
+// today returns the current date in the notifier's timezone, as stored in the database.
+func (n *Notifier) today() time.Time {
+	now := n.now().In(n.location)
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+}
 
-	n.cron = cron.New(cron.WithLocation(n.location))
-	_, err := n.cron.AddFunc(n.cronSpec, n.checkAndNotify)
+// AnnounceAssignment posts today's freshly assigned duty to the group chat,
+// with the sections of the announcement turned on in the settings.
+func (n *Notifier) AnnounceAssignment(ctx context.Context, duty *store.Duty) error {
+	groupID := n.groupChat(ctx)
+	if groupID == 0 {
+		return nil
+	}
+	duty, err := n.withUser(ctx, duty)
 	if err != nil {
-		log.Fatalf("Failed to add cron job: %v", err)
+		return err
+	}
+
+	text := n.composeAnnouncement(ctx, n.locale(ctx, groupID), duty)
+	if err := n.bot.SendMessageWithButtons(groupID, text, CorrectionButtons(duty.DutyDate)); err != nil {
+		return fmt.Errorf("failed to send group notification: %w", err)
+	}
+	log.Printf("[NOTIFY] Sent group notification to chat %d", groupID)
+	if err := n.store.MarkDutyAnnounced(ctx, duty.DutyDate, n.now()); err != nil {
+		return fmt.Errorf("failed to record the announcement of %s: %w", duty.DutyDate.Format("2006-01-02"), err)
 	}
-	n.cron.Start()
+	return nil
 }
 
-// Stop gracefully stops the cron scheduler.
-func (n *Notifier) Stop() {
-	log.Println("Stopping notifier...")
-	if n.cron != nil {
-		ctx := n.cron.Stop()
-		<-ctx.Done()
+// CorrectionButtons are the buttons under the announcement of the duty on
+// date, for an admin to correct the assignment if it looks wrong.
+func CorrectionButtons(date time.Time) []Button {
+	d := date.Format("2006-01-02")
+	return []Button{
+		{Text: "✏️ Looks wrong? Reassign", Data: ReassignTodayAction + ":" + d},
+		{Text: "⏭ Skip today", Data: SkipTodayAction + ":" + d},
 	}
-	log.Println("Notifier stopped.")
 }
 
-// checkAndNotify is the core function executed by the cron job.
-// It checks for tomorrow's duty, assigns one if needed, and sends a notification.
-func (n *Notifier) checkAndNotify() {
-	ctx := context.Background()
-	log.Println("Cron job triggered: checking for tomorrow's duty.")
+// AnnounceMissed announces today's duty to the group if it wasn't yet, e.g.
+// because the bot restarted between the assignment and its announcement, or
+// Telegram couldn't be reached. Duties planned ahead or done already are left
+// alone. Call it once it's past the time of the daily assignment.
+func (n *Notifier) AnnounceMissed(ctx context.Context) error {
+	announced, err := n.announceOnce(ctx, n.today())
+	if announced {
+		log.Printf("[NOTIFY] Today's duty wasn't announced yet, announced it now")
+	}
+	return err
+}
 
-	// Determine tomorrow's date in the service's configured timezone.
-	nowInLocation := n.now().In(n.location)
-	tomorrow := nowInLocation.Add(24 * time.Hour)
+// announceOnce announces the duty on date unless it was announced already,
+// and reports whether it did.
+func (n *Notifier) announceOnce(ctx context.Context, date time.Time) (bool, error) {
+	n.announceMu.Lock()
+	defer n.announceMu.Unlock()
 
-	var messageText string
-	var dutyAssigned bool
+	if n.groupChat(ctx) == 0 {
+		return false, nil
+	}
+	duty, err := n.store.GetDutyByDate(ctx, date)
+	if err != nil {
+		return false, fmt.Errorf("failed to get the duty on %s: %w", date.Format("2006-01-02"), err)
+	}
+	if duty == nil || duty.AnnouncedAt != nil ||
+		(duty.Status != store.DutyStatusAnnounced && duty.Status != store.DutyStatusAcknowledged) {
+		return false, nil
+	}
+	return true, n.AnnounceAssignment(ctx, duty)
+}
 
-	// 1. Check if a duty is already assigned for tomorrow.
-	duty, err := n.store.GetDutyByDate(ctx, tomorrow)
+// AnnounceBadge congratulates a user on a new badge in the group chat.
+func (n *Notifier) AnnounceBadge(ctx context.Context, b *store.Badge) error {
+	groupID := n.groupChat(ctx)
+	if groupID == 0 {
+		return nil
+	}
+	user, err := n.userByID(ctx, b.UserID)
 	if err != nil {
-		// We expect an error if no duty is found. Here we assume any error means "not found".
-		// A more robust implementation would check for specific store.ErrNotFound.
-		log.Printf("No duty found for %s. Attempting to assign one.", tomorrow.Format("2006-01-02"))
+		return err
+	}
+	if err := n.bot.SendMessage(groupID, FormatBadgeAwarded(user, badge.Title(b))); err != nil {
+		return fmt.Errorf("failed to announce badge: %w", err)
 	}
+	return nil
+}
 
-	if duty != nil {
-		// Duty already exists, format a reminder message.
-		messageText = FormatDutyAssignedMessage(duty)
-		dutyAssigned = true
-	} else {
-		// 2. If no duty, trigger round-robin assignment.
-		newDuty, assignErr := n.scheduler.AssignDutyRoundRobin(ctx, tomorrow)
-		if assignErr != nil {
-			log.Printf("ERROR: Failed to auto-assign duty for %s: %v", tomorrow.Format("2006-01-02"), assignErr)
-			// Optionally, send an error notification to an admin. For now, we just log.
+// AnnounceStatement posts the settlement of a month of payout mode to the
+// group chat.
+func (n *Notifier) AnnounceStatement(ctx context.Context, st *ledger.Statement) error {
+	groupID := n.groupChat(ctx)
+	if groupID == 0 {
+		return nil
+	}
+	if err := n.bot.SendMessage(groupID, FormatStatement(n.locale(ctx, groupID), st)); err != nil {
+		return fmt.Errorf("failed to announce settlement: %w", err)
+	}
+	return nil
+}
+
+// PayButton is the button that asks for an invoice of the balance of the
+// user with the internal ID userID.
+func PayButton(userID int64) Button {
+	return Button{Text: "💳 Pay now", Data: fmt.Sprintf("%s:%d", PayFineAction, userID)}
+}
+
+// NotifyFines tells the users fined by entries privately what their missed
+// duty costs and what they owe now, if they get personal messages. With
+// payable, the message has a button to pay the balance in Telegram.
+func (n *Notifier) NotifyFines(ctx context.Context, entries []*store.LedgerEntry, currency string, payable bool) error {
+	users, err := n.usersByID(ctx)
+	if err != nil {
+		return err
+	}
+	ledgerEntries, err := n.store.ListLedgerEntries(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list ledger entries: %w", err)
+	}
+	balances := make(map[int64]int64)
+	for _, e := range ledgerEntries {
+		balances[e.UserID] += e.Amount
+	}
+
+	var errs []error
+	for _, e := range entries {
+		user := users[e.UserID]
+		if e.Kind != store.LedgerFine || e.DutyDate == nil || user == nil {
+			continue
+		}
+		prefs, err := Preferences(ctx, n.store, user.ID)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to load notification preferences: %w", err))
+			continue
+		}
+		if !Wants(prefs, KindPersonalDM) {
+			continue
+		}
+		text := FormatFine(n.locale(ctx, user.TelegramUserID), *e.DutyDate, e.Amount, balances[user.ID], currency)
+		if payable && balances[user.ID] > 0 {
+			err = n.bot.SendMessageWithButtons(user.TelegramUserID, text, []Button{PayButton(user.ID)})
+		} else {
+			err = n.bot.SendMessage(user.TelegramUserID, text)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to tell user %d about their fine: %w", user.TelegramUserID, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// AnnounceTask posts a new one-off task to the group chat, with a button for
+// anyone to claim it.
+func (n *Notifier) AnnounceTask(ctx context.Context, t *store.Task) error {
+	groupID := n.groupChat(ctx)
+	if groupID == 0 {
+		return nil
+	}
+	buttons := []Button{{Text: "🙋 I'll do it", Data: fmt.Sprintf("%s:%d", ClaimTaskAction, t.ID)}}
+	if err := n.bot.SendMessageWithButtons(groupID, FormatTask(n.locale(ctx, groupID), t, nil), buttons); err != nil {
+		return fmt.Errorf("failed to announce task %d: %w", t.ID, err)
+	}
+	return nil
+}
+
+// CoverButton is the button under a cover request that takes the duty on date
+// over.
+func CoverButton(date time.Time) Button {
+	return Button{Text: "🙋 I'll cover", Data: fmt.Sprintf("%s:%s", CoverAction, date.Format("2006-01-02"))}
+}
+
+// AskForCover posts a cover request to the group chat, with a button for
+// anyone to take the duty over, and tells those who could take it over
+// privately if they want swap requests. It reports false if there is no
+// group chat.
+func (n *Notifier) AskForCover(ctx context.Context, ask *cover.Ask) (bool, error) {
+	n.sendCoverRequests(ctx, ask)
+	groupID := n.groupChat(ctx)
+	if groupID == 0 {
+		return false, nil
+	}
+	text := FormatCoverRequest(n.locale(ctx, groupID), ask.Request.DutyDate, ask.User, ask.Fallback, ask.Request.Deadline.Sub(ask.Request.CreatedAt))
+	if err := n.bot.SendMessageWithButtons(groupID, text, []Button{CoverButton(ask.Request.DutyDate)}); err != nil {
+		return false, fmt.Errorf("failed to ask for cover of %s: %w", ask.Request.DutyDate.Format("2006-01-02"), err)
+	}
+	return true, nil
+}
+
+// sendCoverRequests tells the active users other than whoever asked about a
+// cover request. Errors are only logged.
+func (n *Notifier) sendCoverRequests(ctx context.Context, ask *cover.Ask) {
+	users, err := n.store.ListActiveUsers(ctx)
+	if err != nil {
+		log.Printf("[NOTIFY] Failed to list users for the cover request of %s: %v", ask.Request.DutyDate.Format("2006-01-02"), err)
+		return
+	}
+	for _, u := range users {
+		if u.ID == ask.User.ID {
+			continue
+		}
+		text := FormatCoverAsked(n.locale(ctx, u.TelegramUserID), ask.Request.DutyDate, ask.User)
+		if _, err := n.Notify(ctx, u, KindSwapRequests, text); err != nil {
+			log.Printf("[NOTIFY] %v", err)
+		}
+	}
+}
+
+// AnnounceCoverExpired tells the group that nobody covered a duty in time and
+// who is on duty now.
+func (n *Notifier) AnnounceCoverExpired(ctx context.Context, e cover.Expired) error {
+	groupID := n.groupChat(ctx)
+	if groupID == 0 {
+		return nil
+	}
+	if err := n.bot.SendMessage(groupID, FormatCoverExpired(n.locale(ctx, groupID), e.Date, e.User, e.Fallback)); err != nil {
+		return fmt.Errorf("failed to announce the fallback of %s: %w", e.Date.Format("2006-01-02"), err)
+	}
+	return nil
+}
+
+// AnnounceMonth posts the published plan of a month to the group chat, in
+// place of announcing its duties one by one.
+func (n *Notifier) AnnounceMonth(ctx context.Context, month time.Time, duties []*store.Duty) error {
+	groupID := n.groupChat(ctx)
+	if groupID == 0 {
+		return nil
+	}
+	byID, err := n.usersByID(ctx)
+	if err != nil {
+		return err
+	}
+	if err := n.bot.SendMessage(groupID, FormatMonthPublished(n.locale(ctx, groupID), month, duties, byID)); err != nil {
+		return fmt.Errorf("failed to announce the plan of %s: %w", month.Format("2006-01"), err)
+	}
+	return nil
+}
+
+// AnnounceChange queues a schedule change for the group chat. Changes made
+// within a short window of each other are posted as a single summary.
+func (n *Notifier) AnnounceChange(date time.Time, user *store.User) {
+	ctx := context.Background()
+	groupID := n.groupChat(ctx)
+	if groupID == 0 {
+		return
+	}
+	n.changes.Add(FormatDutyChange(n.locale(ctx, groupID), date, user.GroupName()))
+}
+
+// HandleEvent announces changes to the schedule to the group chat. It is
+// subscribed to the scheduler's event bus: today's duty is announced right
+// away, changes to other days are batched by AnnounceChange.
+func (n *Notifier) HandleEvent(ctx context.Context, e events.Event) {
+	var duty *store.Duty
+	switch e := e.(type) {
+	case events.DutyAssigned:
+		if e.Duty.DutyDate.Equal(n.today()) {
+			if _, err := n.announceOnce(ctx, e.Duty.DutyDate); err != nil {
+				log.Printf("[NOTIFY] %v", err)
+			}
 			return
 		}
-		// Format an auto-assignment message.
-		messageText = FormatDutyAutoAssignedMessage(newDuty)
-		dutyAssigned = true
+		duty = e.Duty
+	case events.DutyReassigned:
+		duty = e.Duty
+	case events.DutyReleased:
+		// The duty is gone from the store, the scheduler passes its user along
+		if groupID := n.groupChat(ctx); groupID != 0 && e.Duty.User != nil {
+			n.changes.Add(FormatDutyReleased(n.locale(ctx, groupID), e.Duty.DutyDate, e.Duty.User.GroupName()))
+		}
+		return
+	case events.MonthPublished:
+		if err := n.AnnounceMonth(ctx, e.Month, e.Duties); err != nil {
+			log.Printf("[NOTIFY] %v", err)
+		}
+		return
+	case events.BadgeAwarded:
+		if err := n.AnnounceBadge(ctx, e.Badge); err != nil {
+			log.Printf("[NOTIFY] %v", err)
+		}
+		return
+	case events.SeasonStarted:
+		if groupID := n.groupChat(ctx); groupID != 0 {
+			if err := n.bot.SendMessage(groupID, FormatSeasonStarted(e)); err != nil {
+				log.Printf("[NOTIFY] failed to announce the season: %v", err)
+			}
+		}
+		return
+	default:
+		return
 	}
 
-	// 3. Send the notification if a duty is confirmed.
-	if dutyAssigned {
-		msg := tgbotapi.NewMessage(n.chatID, messageText)
-		msg.ParseMode = tgbotapi.ModeMarkdownV2
+	duty, err := n.withUser(ctx, duty)
+	if err != nil {
+		log.Printf("[NOTIFY] %v", err)
+		return
+	}
+	n.AnnounceChange(duty.DutyDate, duty.User)
+}
 
-		if _, err := n.bot.Send(msg); err != nil {
-			log.Printf("ERROR: Failed to send Telegram notification to chat ID %d: %v", n.chatID, err)
-		} else {
-			log.Printf("Successfully sent notification for duty on %s.", tomorrow.Format("2006-01-02"))
+// withUser returns the duty with its user joined. The scheduler returns
+// duties without it.
+func (n *Notifier) withUser(ctx context.Context, duty *store.Duty) (*store.Duty, error) {
+	if duty.User != nil {
+		return duty, nil
+	}
+	stored, err := n.store.GetDutyByDate(ctx, duty.DutyDate)
+	if err != nil || stored == nil || stored.User == nil {
+		return nil, fmt.Errorf("failed to load user for duty on %s: %v", duty.DutyDate.Format("2006-01-02"), err)
+	}
+	return stored, nil
+}
+
+// Close sends any schedule changes that are still waiting to be announced and
+// stops the snooze timers. Snoozed reminders are persisted and resumed by
+// RestoreSnoozes on the next start. Changes that can't be sent are persisted
+// as well and sent by SendPendingMessages on the next start.
+func (n *Notifier) Close() error {
+	n.mu.Lock()
+	for id, timer := range n.snoozes {
+		timer.Stop()
+		delete(n.snoozes, id)
+	}
+	n.mu.Unlock()
+
+	err := n.changes.Flush()
+	if err == nil {
+		return nil
+	}
+	msg := &store.PendingMessage{ChatID: n.groupChat(context.Background()), Text: n.changes.Drain(), CreatedAt: n.now().UTC()}
+	if saveErr := n.store.CreatePendingMessage(context.Background(), msg); saveErr != nil {
+		return fmt.Errorf("failed to send pending changes (%v) and to keep them for later: %w", err, saveErr)
+	}
+	log.Printf("[NOTIFY] Failed to send pending changes, keeping them for the next start: %v", err)
+	return nil
+}
+
+// SendPendingMessages sends the messages that could not be sent before the
+// last shutdown. Messages that fail again are kept for the next start.
+func (n *Notifier) SendPendingMessages(ctx context.Context) error {
+	msgs, err := n.store.ListPendingMessages(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list pending messages: %w", err)
+	}
+	for _, msg := range msgs {
+		if err := n.bot.SendMessage(msg.ChatID, msg.Text); err != nil {
+			return fmt.Errorf("failed to send pending message %d: %w", msg.ID, err)
+		}
+		if err := n.store.DeletePendingMessage(ctx, msg.ID); err != nil {
+			return fmt.Errorf("failed to delete pending message %d: %w", msg.ID, err)
+		}
+	}
+	return nil
+}
+
+// reminderButtons returns the buttons of a personal reminder: "On it" unless
+// the duty is acknowledged already, and "Remind me in ...".
+func reminderButtons(duty *store.Duty) []Button {
+	buttons := make([]Button, 0, len(SnoozeHours)+1)
+	if duty.Status == store.DutyStatusAnnounced {
+		buttons = append(buttons, Button{
+			Text: "👍 On it",
+			Data: fmt.Sprintf("%s:%s", AcknowledgeAction, duty.DutyDate.Format("2006-01-02")),
+		})
+	}
+	for _, hours := range SnoozeHours {
+		buttons = append(buttons, Button{
+			Text: fmt.Sprintf("⏰ Remind me in %dh", hours),
+			Data: fmt.Sprintf("%s:%d", SnoozeAction, hours),
+		})
+	}
+	return buttons
+}
+
+// sendPersonalReminder sends the on-duty reminder with buttons to acknowledge or snooze it.
+func (n *Notifier) sendPersonalReminder(ctx context.Context, user *store.User, duty *store.Duty) error {
+	text := FormatPersonalReminder(duty, n.dutyNotes(ctx, duty))
+	if err := n.bot.SendMessageWithButtons(user.TelegramUserID, text, reminderButtons(duty)); err != nil {
+		return fmt.Errorf("failed to send %s notification to user %d: %w", KindPersonalDM, user.TelegramUserID, err)
+	}
+	return nil
+}
+
+// Snooze postpones today's personal reminder of a user by the given number of
+// hours. It returns when the reminder will be sent again, in the notifier's timezone.
+func (n *Notifier) Snooze(ctx context.Context, user *store.User, hours int) (time.Time, error) {
+	today := n.today()
+	duty, err := n.store.GetDutyByDate(ctx, today)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to get today's duty: %w", err)
+	}
+	if duty == nil || duty.UserID != user.ID || duty.CompletedAt != nil {
+		return time.Time{}, ErrNotOnDuty
+	}
+
+	snooze := &store.ReminderSnooze{
+		UserID:   user.ID,
+		DutyDate: today,
+		RemindAt: n.now().Add(time.Duration(hours) * time.Hour).Truncate(time.Second),
+	}
+	if err := n.store.CreateReminderSnooze(ctx, snooze); err != nil {
+		return time.Time{}, fmt.Errorf("failed to save snoozed reminder: %w", err)
+	}
+	n.scheduleSnooze(snooze)
+	return snooze.RemindAt.In(n.location), nil
+}
+
+// RestoreSnoozes schedules the snoozed reminders persisted before a restart.
+// Reminders that came due while the bot was down are sent right away.
+func (n *Notifier) RestoreSnoozes(ctx context.Context) error {
+	snoozes, err := n.store.ListReminderSnoozes(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list snoozed reminders: %w", err)
+	}
+	for _, snooze := range snoozes {
+		n.scheduleSnooze(snooze)
+	}
+	return nil
+}
+
+// scheduleSnooze starts the in-process timer of a snoozed reminder.
+func (n *Notifier) scheduleSnooze(snooze *store.ReminderSnooze) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	delay := snooze.RemindAt.Sub(n.now())
+	if delay < 0 {
+		delay = 0
+	}
+	n.snoozes[snooze.ID] = time.AfterFunc(delay, func() {
+		if err := n.deliverSnooze(context.Background(), snooze); err != nil {
+			log.Printf("[NOTIFY] %v", err)
+		}
+	})
+}
+
+// deliverSnooze re-sends a snoozed reminder, unless the duty was completed or
+// handed to someone else in the meantime, and forgets the snooze.
+func (n *Notifier) deliverSnooze(ctx context.Context, snooze *store.ReminderSnooze) error {
+	n.mu.Lock()
+	delete(n.snoozes, snooze.ID)
+	n.mu.Unlock()
+
+	if err := n.store.DeleteReminderSnooze(ctx, snooze.ID); err != nil {
+		return fmt.Errorf("failed to delete snoozed reminder %d: %w", snooze.ID, err)
+	}
+	duty, err := n.store.GetDutyByDate(ctx, snooze.DutyDate)
+	if err != nil {
+		return fmt.Errorf("failed to get duty for snoozed reminder %d: %w", snooze.ID, err)
+	}
+	if duty == nil || duty.User == nil || duty.UserID != snooze.UserID || duty.CompletedAt != nil {
+		return nil
+	}
+	return n.sendPersonalReminder(ctx, duty.User, duty)
+}
+
+// dutyNotes returns the notes of a duty. A reminder without its notes beats
+// no reminder, so errors are only logged.
+func (n *Notifier) dutyNotes(ctx context.Context, duty *store.Duty) []string {
+	notes, err := n.notes.ForDuty(ctx, duty)
+	if err != nil {
+		log.Printf("[NOTIFY] Failed to get the notes of the duty on %s: %v", duty.DutyDate.Format("2006-01-02"), err)
+	}
+	return notes
+}
+
+// RequestTakeover asks the admin what to do with today's duty when nobody is
+// available: assign it anyway, skip the day or mark it as external help.
+func (n *Notifier) RequestTakeover(adminChatID int64) error {
+	date := n.today().Format("2006-01-02")
+	buttons := []Button{
+		{Text: "👤 Assign anyway", Data: TakeoverAssignAction + ":" + date},
+		{Text: "⏭ Skip day", Data: TakeoverSkipAction + ":" + date},
+		{Text: "🤝 External help", Data: TakeoverExternalAction + ":" + date},
+	}
+	if err := n.bot.SendMessageWithButtons(adminChatID, FormatTakeoverRequest(n.locale(context.Background(), adminChatID), n.today()), buttons); err != nil {
+		return fmt.Errorf("failed to ask admin %d to take over: %w", adminChatID, err)
+	}
+	return nil
+}
+
+// RequestApproval asks the admin to approve or reject a pending user, before
+// they are put on duty.
+func (n *Notifier) RequestApproval(adminChatID int64, user *store.User) error {
+	buttons := []Button{
+		{Text: "✅ Approve", Data: fmt.Sprintf("%s:%d", ApproveUserAction, user.ID)},
+		{Text: "❌ Reject", Data: fmt.Sprintf("%s:%d", RejectUserAction, user.ID)},
+	}
+	if err := n.bot.SendMessageWithButtons(adminChatID, FormatApprovalRequest(user), buttons); err != nil {
+		return fmt.Errorf("failed to ask admin %d to approve user %d: %w", adminChatID, user.ID, err)
+	}
+	return nil
+}
+
+// ReportUnassignedDay tells the admin that nobody was on duty today although
+// the day wasn't skipped.
+func (n *Notifier) ReportUnassignedDay(adminChatID int64) error {
+	if err := n.bot.SendMessage(adminChatID, FormatUnassignedDay(n.locale(context.Background(), adminChatID), n.today())); err != nil {
+		return fmt.Errorf("failed to tell admin %d about the unassigned day: %w", adminChatID, err)
+	}
+	return nil
+}
+
+// ReportMaintenance tells the admin how the weekly database maintenance went,
+// given the latest runs, newest first.
+func (n *Notifier) ReportMaintenance(adminChatID int64, runs []*store.MaintenanceRun) error {
+	if err := n.bot.SendMessage(adminChatID, FormatMaintenance(runs)); err != nil {
+		return fmt.Errorf("failed to tell admin %d about the database maintenance: %w", adminChatID, err)
+	}
+	return nil
+}
+
+// SendDailyReminders sends today's private reminders to every active user
+// whose reminder hour is the current hour, in their own timezone if they set
+// one (see reminderHour). The person on duty gets a personal reminder;
+// everyone else who asked for it gets the daily "who is on duty" one.
+func (n *Notifier) SendDailyReminders(ctx context.Context) error {
+	duty, err := n.store.GetDutyByDate(ctx, n.today())
+	if err != nil {
+		return fmt.Errorf("failed to get today's duty: %w", err)
+	}
+	if duty == nil || duty.User == nil {
+		return nil
+	}
+
+	users, err := n.store.ListActiveUsers(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list users: %w", err)
+	}
+
+	notes := n.dutyNotes(ctx, duty)
+	hour := n.now().In(n.location).Hour()
+	for _, user := range users {
+		prefs, err := Preferences(ctx, n.store, user.ID)
+		if err != nil {
+			log.Printf("[NOTIFY] Failed to load preferences for user %d: %v", user.ID, err)
+			continue
+		}
+		if n.reminderHour(user, prefs) != hour {
+			continue
+		}
+
+		if user.ID == duty.UserID {
+			if prefs.PersonalDM {
+				if err := n.sendPersonalReminder(ctx, user, duty); err != nil {
+					log.Printf("[NOTIFY] %v", err)
+				}
+			}
+			continue
+		}
+		if _, err := n.Notify(ctx, user, KindGroupReminder, FormatDailyReminder(n.locale(ctx, user.TelegramUserID), duty, notes)); err != nil {
+			log.Printf("[NOTIFY] %v", err)
+		}
+	}
+	return nil
+}
+
+// reminderHour returns the hour of today, in the notifier's timezone, the
+// user's daily reminder is due. For users abroad, who set a timezone with
+// /me, it is their reminder hour there, but still within the reminder hours
+// of the household's day: a reminder due before them comes at the first, one
+// due after them at the last.
+func (n *Notifier) reminderHour(user *store.User, prefs *store.NotificationPreferences) int {
+	if user.Timezone == "" {
+		return prefs.ReminderHour
+	}
+	loc, err := time.LoadLocation(user.Timezone)
+	if err != nil {
+		log.Printf("[NOTIFY] Unknown timezone %q of user %d, using the household's: %v", user.Timezone, user.ID, err)
+		return prefs.ReminderHour
+	}
+	today := n.today()
+	due := time.Date(today.Year(), today.Month(), today.Day(), prefs.ReminderHour, 0, 0, 0, loc).In(n.location)
+	switch dueDay := time.Date(due.Year(), due.Month(), due.Day(), 0, 0, 0, 0, time.UTC); {
+	case dueDay.Before(today):
+		return store.EarliestReminderHour
+	case dueDay.After(today):
+		return store.LatestReminderHour
+	}
+	return min(max(due.Hour(), store.EarliestReminderHour), store.LatestReminderHour)
+}
+
+// SendWeeklyStats posts the report for the past seven days, followed by the
+// overview of the current week, to the group chat and to every active user who
+// opted into weekly stats.
+func (n *Notifier) SendWeeklyStats(ctx context.Context) error {
+	today := n.today()
+	end := today.AddDate(0, 0, 1)
+	start := end.AddDate(0, 0, -7)
+	duties, err := n.store.GetCompletedDutiesInRange(ctx, start, end)
+	if err != nil {
+		return fmt.Errorf("failed to get completed duties: %w", err)
+	}
+	w, err := week.Load(ctx, n.store, today)
+	if err != nil {
+		log.Printf("[NOTIFY] Failed to load the week for weekly stats: %v", err)
+	}
+	// Tasks are a bonus to the report, which is sent without them
+	tasks, err := n.tasksDone(ctx, start, end)
+	if err != nil {
+		log.Printf("[NOTIFY] Failed to get the tasks done for weekly stats: %v", err)
+	}
+	var users map[int64]*store.User
+	if len(tasks) > 0 {
+		if users, err = n.usersByID(ctx); err != nil {
+			log.Printf("[NOTIFY] Failed to get the users for weekly stats: %v", err)
+		}
+	}
+	// The report is the same for every chat but for the language of its dates
+	// and how it names users: by name in private chats, as they chose in the
+	// group
+	type variant struct {
+		locale i18n.Locale
+		names  Names
+	}
+	reports := make(map[variant]string)
+	report := func(chatID int64, names Names) string {
+		v := variant{n.locale(ctx, chatID), names}
+		if text, ok := reports[v]; ok {
+			return text
+		}
+		text := FormatWeeklyStats(v.locale, start, end.AddDate(0, 0, -1), duties, names)
+		if len(tasks) > 0 {
+			text += "\n\n" + FormatWeeklyTasks(tasks, users, names)
+		}
+		if w != nil {
+			text += "\n\n" + FormatWeek(v.locale, w, today, names)
 		}
+		reports[v] = text
+		return text
 	}
-}
\ No newline at end of file
+
+	if groupID := n.groupChat(ctx); groupID != 0 {
+		if err := n.bot.SendMessage(groupID, report(groupID, NamesPublic)); err != nil {
+			log.Printf("[NOTIFY] Failed to send weekly stats to group: %v", err)
+		}
+	}
+
+	active, err := n.store.ListActiveUsers(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list users: %w", err)
+	}
+	for _, user := range active {
+		if _, err := n.Notify(ctx, user, KindWeeklyStats, report(user.TelegramUserID, NamesFull)); err != nil {
+			log.Printf("[NOTIFY] %v", err)
+		}
+	}
+	return nil
+}
+
+// SendWeeklyPoll asks the group who volunteers for which day of the next week,
+// with a button for each day nobody took yet, if the poll is turned on with
+// /settings poll. The days taken by then are left to the daily assignment.
+func (n *Notifier) SendWeeklyPoll(ctx context.Context) error {
+	if n.Settings == nil {
+		return nil
+	}
+	if on, err := n.Settings.WeeklyPoll(ctx); err != nil || !on {
+		return err
+	}
+	groupID := n.groupChat(ctx)
+	if groupID == 0 {
+		return nil
+	}
+
+	today := n.today()
+	from := PollWeek(today.AddDate(0, 0, 7))
+	duties, err := n.store.ListDuties(ctx, store.DutyFilter{From: from, To: from.AddDate(0, 0, 7)})
+	if err != nil {
+		return fmt.Errorf("failed to list next week's duties: %w", err)
+	}
+	l := n.locale(ctx, groupID)
+	buttons := PollButtons(l, from, duties)
+	if len(buttons) == 0 {
+		log.Printf("[NOTIFY] Every day of the week from %s is taken, no poll for volunteers", from.Format("2006-01-02"))
+		return nil
+	}
+	if err := n.bot.SendMessageWithButtons(groupID, FormatWeeklyPoll(l, from, duties), buttons); err != nil {
+		return fmt.Errorf("failed to send the poll for volunteers: %w", err)
+	}
+	return nil
+}
+
+// tasksDone returns the one-off tasks done from start up to, but not
+// including, end, dates in the notifier's timezone.
+func (n *Notifier) tasksDone(ctx context.Context, start, end time.Time) ([]*store.Task, error) {
+	tasks, err := n.store.ListTasks(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tasks: %w", err)
+	}
+	var done []*store.Task
+	for _, t := range tasks {
+		if t.DoneAt == nil {
+			continue
+		}
+		local := t.DoneAt.In(n.location)
+		day := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, time.UTC)
+		if !day.Before(start) && day.Before(end) {
+			done = append(done, t)
+		}
+	}
+	return done, nil
+}
+
+// usersByID returns all users keyed by their ID.
+func (n *Notifier) usersByID(ctx context.Context) (map[int64]*store.User, error) {
+	users, err := n.store.ListAllUsers(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list users: %w", err)
+	}
+	byID := make(map[int64]*store.User, len(users))
+	for _, u := range users {
+		byID[u.ID] = u
+	}
+	return byID, nil
+}
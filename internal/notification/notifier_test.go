@@ -3,173 +3,681 @@ package notification
 import (
 	"context"
 	"errors"
+	"fmt"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
-	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/korjavin/dutyassistant/internal/events"
+	"github.com/korjavin/dutyassistant/internal/i18n"
+	"github.com/korjavin/dutyassistant/internal/service/cover"
+	"github.com/korjavin/dutyassistant/internal/service/settings"
 	"github.com/korjavin/dutyassistant/internal/store"
+	"github.com/korjavin/dutyassistant/internal/store/memory"
 	"github.com/stretchr/testify/assert"
-	"github.com/stretchr/testify/mock"
 )
 
-// MockStore is a mock implementation of the store.Store interface.
-type MockStore struct {
-	mock.Mock
-}
-
-func (m *MockStore) GetDutyByDate(ctx context.Context, date time.Time) (*store.Duty, error) {
-	args := m.Called(ctx, date)
-	duty, _ := args.Get(0).(*store.Duty)
-	return duty, args.Error(1)
-}
-
-// Implement other store.Store methods as needed for tests, returning nil or zero values.
-func (m *MockStore) GetUserByTelegramID(ctx context.Context, id int64) (*store.User, error) { return nil, nil }
-func (m *MockStore) GetUserByName(ctx context.Context, name string) (*store.User, error)    { return nil, nil }
-func (m *MockStore) ListActiveUsers(ctx context.Context) ([]*store.User, error)              { return nil, nil }
-func (m *MockStore) ListAllUsers(ctx context.Context) ([]*store.User, error)                 { return nil, nil }
-func (m *MockStore) CreateUser(ctx context.Context, user *store.User) error                  { return nil }
-func (m *MockStore) UpdateUser(ctx context.Context, user *store.User) error                  { return nil }
-func (m *MockStore) GetUserStats(ctx context.Context, userID int64) (*store.UserStats, error) { return nil, nil }
-func (m *MockStore) CreateDuty(ctx context.Context, duty *store.Duty) error                  { return nil }
-func (m *MockStore) UpdateDuty(ctx context.Context, duty *store.Duty) error                  { return nil }
-func (m *MockStore) DeleteDuty(ctx context.Context, date time.Time) error                    { return nil }
-func (m *MockStore) GetDutiesByMonth(ctx context.Context, year int, month time.Month) ([]*store.Duty, error) {
-	return nil, nil
-}
-func (m *MockStore) GetNextRoundRobinUser(ctx context.Context) (*store.User, error) { return nil, nil }
-func (m *MockStore) IncrementAssignmentCount(ctx context.Context, userID int64, lastAssigned time.Time) error {
-	return nil
+const testGroupID = -100
+
+// sentMessage is a message recorded by fakeSender.
+type sentMessage struct {
+	chatID  int64
+	text    string
+	buttons []Button
+}
+
+// fakeSender records messages instead of sending them to Telegram.
+// It is safe for use by snooze timers.
+type fakeSender struct {
+	mu   sync.Mutex
+	sent []sentMessage
+	err  error
 }
 
-// MockScheduler is a mock implementation of the Scheduler interface.
-type MockScheduler struct {
-	mock.Mock
+func (f *fakeSender) SendMessage(chatID int64, text string) error {
+	return f.SendMessageWithButtons(chatID, text, nil)
 }
 
-func (m *MockScheduler) AssignDutyRoundRobin(ctx context.Context, date time.Time) (*store.Duty, error) {
-	args := m.Called(ctx, date)
-	duty, _ := args.Get(0).(*store.Duty)
-	return duty, args.Error(1)
+func (f *fakeSender) SendMessageWithButtons(chatID int64, text string, buttons []Button) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != nil {
+		return f.err
+	}
+	f.sent = append(f.sent, sentMessage{chatID, text, buttons})
+	return nil
 }
 
-// MockTelegramBot is a mock implementation of the TelegramBot interface.
-type MockTelegramBot struct {
-	mock.Mock
+func (f *fakeSender) messages(chatID int64) []sentMessage {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var msgs []sentMessage
+	for _, m := range f.sent {
+		if m.chatID == chatID {
+			msgs = append(msgs, m)
+		}
+	}
+	return msgs
 }
 
-func (m *MockTelegramBot) Send(c tgbotapi.Chattable) (tgbotapi.Message, error) {
-	args := m.Called(c)
-	return args.Get(0).(tgbotapi.Message), args.Error(1)
+func (f *fakeSender) to(chatID int64) []string {
+	var texts []string
+	for _, m := range f.messages(chatID) {
+		texts = append(texts, m.text)
+	}
+	return texts
 }
 
-// setupNotifierTest creates a Notifier with mocked dependencies for testing.
-func setupNotifierTest(t *testing.T) (*Notifier, *MockStore, *MockScheduler, *MockTelegramBot) {
+// setupNotifierTest creates a Notifier backed by an in-memory store with
+// Alice on duty today and Bob as a second active user.
+func setupNotifierTest(t *testing.T, hour int) (*Notifier, *memory.Store, *fakeSender, *store.User, *store.User) {
+	t.Helper()
 	loc, err := time.LoadLocation("Europe/Berlin")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	ctx := context.Background()
+	s := memory.New()
+	alice := &store.User{TelegramUserID: 1, FirstName: "Alice", IsActive: true}
+	bob := &store.User{TelegramUserID: 2, FirstName: "Bob", IsActive: true}
+	s.CreateUser(ctx, alice)
+	s.CreateUser(ctx, bob)
+
+	// Sunday, 26 October 2025
+	now := time.Date(2025, 10, 26, hour, 0, 0, 0, loc)
+	today := time.Date(2025, 10, 26, 0, 0, 0, 0, time.UTC)
+	s.CreateDuty(ctx, &store.Duty{UserID: alice.ID, DutyDate: today, AssignmentType: store.AssignmentTypeRoundRobin, CreatedAt: now})
+
+	sender := &fakeSender{}
+	notifier := NewNotifier(s, sender, testGroupID, loc)
+	notifier.now = func() time.Time { return now }
+	return notifier, s, sender, alice, bob
+}
+
+func TestNotify_HonorsPreferences(t *testing.T) {
+	notifier, s, sender, alice, _ := setupNotifierTest(t, 11)
+	ctx := context.Background()
+
+	// Defaults: personal DMs on, daily reminders off
+	sent, err := notifier.Notify(ctx, alice, KindPersonalDM, "hi")
 	assert.NoError(t, err)
+	assert.True(t, sent)
+	sent, _ = notifier.Notify(ctx, alice, KindGroupReminder, "hi")
+	assert.False(t, sent)
+
+	prefs := store.DefaultNotificationPreferences(alice.ID)
+	prefs.PersonalDM = false
+	s.SetNotificationPreferences(ctx, prefs)
+	sent, _ = notifier.Notify(ctx, alice, KindPersonalDM, "hi")
+	assert.False(t, sent)
+	assert.Len(t, sender.sent, 1)
+
+	sender.err = errors.New("telegram down")
+	_, err = notifier.Notify(ctx, alice, KindSwapRequests, "hi")
+	assert.Error(t, err)
+}
+
+func TestSendDailyReminders(t *testing.T) {
+	t.Run("default hour", func(t *testing.T) {
+		notifier, _, sender, alice, bob := setupNotifierTest(t, 11)
+
+		assert.NoError(t, notifier.SendDailyReminders(context.Background()))
+		if assert.Len(t, sender.to(alice.TelegramUserID), 1) {
+			assert.Contains(t, sender.to(alice.TelegramUserID)[0], "You're on duty today")
+			buttons := sender.messages(alice.TelegramUserID)[0].buttons
+			if assert.Len(t, buttons, len(SnoozeHours)+1) {
+				assert.True(t, strings.HasPrefix(buttons[0].Data, AcknowledgeAction+":"), "the duty can be acknowledged")
+			}
+		}
+		assert.Empty(t, sender.to(bob.TelegramUserID), "daily reminders are opt-in")
+	})
+
+	t.Run("custom hour", func(t *testing.T) {
+		notifier, s, sender, alice, bob := setupNotifierTest(t, 18)
+		ctx := context.Background()
+
+		prefs := store.DefaultNotificationPreferences(bob.ID)
+		prefs.GroupReminder = true
+		prefs.ReminderHour = 18
+		s.SetNotificationPreferences(ctx, prefs)
+
+		assert.NoError(t, notifier.SendDailyReminders(ctx))
+		assert.Empty(t, sender.to(alice.TelegramUserID), "Alice's reminder hour is 11")
+		if assert.Len(t, sender.to(bob.TelegramUserID), 1) {
+			assert.Contains(t, sender.to(bob.TelegramUserID)[0], "Alice is on duty today")
+		}
+	})
+
+	t.Run("notes", func(t *testing.T) {
+		notifier, s, sender, alice, bob := setupNotifierTest(t, 11)
+		ctx := context.Background()
+
+		date := time.Date(2025, 10, 26, 0, 0, 0, 0, time.UTC)
+		duty, _ := s.GetDutyByDate(ctx, date)
+		duty.Note = "Guests for dinner"
+		s.UpdateDuty(ctx, duty)
+		if err := s.CreateDuty(ctx, &store.Duty{UserID: bob.ID, DutyDate: date.AddDate(0, 0, -1), AssignmentType: store.AssignmentTypeRoundRobin,
+			CreatedAt: date, HandoffNote: "Dishwasher tabs almost out"}); err != nil {
+			t.Fatal(err)
+		}
+		s.CreateNoteTemplate(ctx, &store.NoteTemplate{Rule: "sun", Text: "Bins are brown this week", CreatedAt: date})
+		s.CreateNoteTemplate(ctx, &store.NoteTemplate{Rule: "mon", Text: "Bins are blue this week", CreatedAt: date})
+
+		assert.NoError(t, notifier.SendDailyReminders(ctx))
+		if assert.Len(t, sender.to(alice.TelegramUserID), 1) {
+			reminder := sender.to(alice.TelegramUserID)[0]
+			assert.Contains(t, reminder, "\n\n📝 📨 Handoff from Bob: Dishwasher tabs almost out\n📝 Guests for dinner\n📝 Bins are brown this week")
+			assert.NotContains(t, reminder, "blue")
+		}
+	})
+
+	t.Run("timezone", func(t *testing.T) {
+		ctx := context.Background()
+		for _, tc := range []struct {
+			timezone string
+			hour     int // Berlin hour of the reminder at 11:00 there
+		}{
+			{"America/New_York", 16},
+			{"Europe/Berlin", 11},
+			{"Asia/Tokyo", 11},         // 03:00 in Berlin, before the first reminders
+			{"Pacific/Kiritimati", 11}, // Still the day before in Berlin
+		} {
+			for _, hour := range []int{11, 16} {
+				notifier, s, sender, alice, _ := setupNotifierTest(t, hour)
+				alice.Timezone = tc.timezone
+				s.UpdateUser(ctx, alice)
+
+				assert.NoError(t, notifier.SendDailyReminders(ctx))
+				assert.Equal(t, hour == tc.hour, len(sender.to(alice.TelegramUserID)) == 1, "%s at %d:00", tc.timezone, hour)
+			}
+		}
+	})
+}
+
+func TestAnnounceAssignment(t *testing.T) {
+	notifier, s, sender, _, _ := setupNotifierTest(t, 11)
+	ctx := context.Background()
+	duty, _ := s.GetDutyByDate(ctx, time.Date(2025, 10, 26, 0, 0, 0, 0, time.UTC))
+	duty.User = nil // as returned by the scheduler
+
+	assert.NoError(t, notifier.AnnounceAssignment(ctx, duty))
+	if assert.Len(t, sender.to(testGroupID), 1) {
+		assert.Contains(t, sender.to(testGroupID)[0], "@Alice is on duty today")
+		// Admins can correct the assignment from the announcement
+		assert.Equal(t, []Button{
+			{Text: "✏️ Looks wrong? Reassign", Data: "today_reassign:2025-10-26"},
+			{Text: "⏭ Skip today", Data: "today_skip:2025-10-26"},
+		}, sender.messages(testGroupID)[0].buttons)
+	}
+
+	// A personal emoji goes in front of the name
+	alice, _ := s.GetUserByTelegramID(ctx, 1)
+	alice.Emoji = "🦊"
+	s.UpdateUser(ctx, alice)
+	assert.NoError(t, notifier.AnnounceAssignment(ctx, duty))
+	if assert.Len(t, sender.to(testGroupID), 2) {
+		assert.Contains(t, sender.to(testGroupID)[1], "🦊 @Alice is on duty today")
+	}
+}
+
+func TestAnnounceAssignment_Sections(t *testing.T) {
+	notifier, s, sender, alice, bob := setupNotifierTest(t, 11)
+	ctx := context.Background()
+	notifier.Settings = settings.New(s)
+	assert.NoError(t, notifier.Settings.Seed(ctx, testGroupID, nil))
+	today := time.Date(2025, 10, 26, 0, 0, 0, 0, time.UTC)
+	completedAt := today.AddDate(0, 0, -2)
+	s.CreateDuty(ctx, &store.Duty{UserID: bob.ID, DutyDate: today.AddDate(0, 0, -2), AssignmentType: store.AssignmentTypeRoundRobin, CompletedAt: &completedAt})
+	s.CreateDuty(ctx, &store.Duty{UserID: bob.ID, DutyDate: today.AddDate(0, 0, 1), AssignmentType: store.AssignmentTypeVoluntary})
+	s.CreateDuty(ctx, &store.Duty{UserID: alice.ID, DutyDate: today.AddDate(0, 0, 3), AssignmentType: store.AssignmentTypeRoundRobin, Status: store.DutyStatusProvisional})
+	bob.VolunteerQueueDays = 2
+	s.UpdateUser(ctx, bob)
+	duty, _ := s.GetDutyByDate(ctx, today)
+
+	// Without sections it's just who is on duty
+	assert.NoError(t, notifier.AnnounceAssignment(ctx, duty))
+	assert.Equal(t, "🍽️ Duty Assignment for October 26, 2025\n\n@Alice is on duty today!\n\nType: round_robin", sender.to(testGroupID)[0])
+
+	for _, section := range []string{settings.SectionFairness, settings.SectionQueues, settings.SectionUpcoming} {
+		assert.NoError(t, notifier.Settings.SetAnnouncementSection(ctx, section, true))
+	}
+	assert.NoError(t, notifier.AnnounceAssignment(ctx, duty))
+	assert.Equal(t, "🍽️ Duty Assignment for October 26, 2025\n\n@Alice is on duty today!\n\nType: round_robin\n\n"+
+		"📅 Next days:\n• Mon, Oct 27: Bob\n• Tue, Oct 28: not assigned yet\n• Wed, Oct 29: Alice (planned)\n\n"+
+		"🙋 Queued days:\n• Bob: 2 volunteered\n\n"+
+		"⚖️ Duties in the last 30 days:\nBob ▰▰▰▰▰ 1\nAlice ▱▱▱▱▱ 0", sender.to(testGroupID)[1])
+}
 
-	mockStore := new(MockStore)
-	mockScheduler := new(MockScheduler)
-	mockBot := new(MockTelegramBot)
+func TestAnnounceMissed(t *testing.T) {
+	notifier, s, sender, alice, _ := setupNotifierTest(t, 11)
+	ctx := context.Background()
+	today := time.Date(2025, 10, 26, 0, 0, 0, 0, time.UTC)
 
-	notifier := NewNotifier(mockStore, mockScheduler, mockBot, 12345, "0 16 * * *", loc)
+	// Telegram is down when the duty is assigned
+	sender.err = errors.New("telegram down")
+	assert.Error(t, notifier.AnnounceMissed(ctx))
+	duty, _ := s.GetDutyByDate(ctx, today)
+	assert.Nil(t, duty.AnnouncedAt)
 
-	// Set a fixed time for predictable testing.
-	// This is a Thursday, so "tomorrow" will be a Friday.
-	fixedTime := time.Date(2023, 10, 26, 15, 0, 0, 0, loc)
-	notifier.now = func() time.Time {
-		return fixedTime
+	sender.err = nil
+	assert.NoError(t, notifier.AnnounceMissed(ctx))
+	if assert.Len(t, sender.to(testGroupID), 1) {
+		assert.Contains(t, sender.to(testGroupID)[0], "@Alice is on duty today")
+	}
+	duty, _ = s.GetDutyByDate(ctx, today)
+	if assert.NotNil(t, duty.AnnouncedAt) {
+		assert.True(t, duty.AnnouncedAt.Equal(notifier.now()))
 	}
+	assert.NoError(t, notifier.AnnounceMissed(ctx))
+	assert.Len(t, sender.to(testGroupID), 1, "an announced duty isn't announced again")
 
-	return notifier, mockStore, mockScheduler, mockBot
+	// A duty only planned ahead isn't announced
+	s.DeleteDuty(ctx, today)
+	s.CreateDuty(ctx, &store.Duty{UserID: alice.ID, DutyDate: today, AssignmentType: store.AssignmentTypeRoundRobin, Status: store.DutyStatusProvisional})
+	assert.NoError(t, notifier.AnnounceMissed(ctx))
+	assert.Len(t, sender.to(testGroupID), 1)
 }
 
-func TestCheckAndNotify_DutyAlreadyExists(t *testing.T) {
-	notifier, mockStore, _, mockBot := setupNotifierTest(t)
-	tomorrow := notifier.now().In(notifier.location).Add(24 * time.Hour)
+func TestAnnounce_GroupChatSetting(t *testing.T) {
+	notifier, s, sender, _, bob := setupNotifierTest(t, 21)
+	ctx := context.Background()
+	notifier.Settings = settings.New(s)
+	assert.NoError(t, notifier.Settings.Seed(ctx, testGroupID, nil))
 
-	// Arrange
-	existingDuty := &store.Duty{
-		DutyDate: tomorrow,
-		User:     &store.User{FirstName: "Alex"},
+	// The group is looked up when sending, so changing it needs no restart
+	assert.NoError(t, notifier.Settings.SetGroupChatID(ctx, -200))
+	notifier.AnnounceChange(time.Date(2025, 10, 27, 0, 0, 0, 0, time.UTC), bob)
+	assert.NoError(t, notifier.SendWeeklyStats(ctx))
+	assert.NoError(t, notifier.Close())
+	assert.Empty(t, sender.to(testGroupID))
+	if assert.Len(t, sender.to(-200), 2) {
+		assert.Contains(t, sender.to(-200)[0], "Weekly Duty Report")
+		assert.Contains(t, sender.to(-200)[1], "@Bob is now on duty")
 	}
-	mockStore.On("GetDutyByDate", mock.Anything, mock.Anything).Return(existingDuty, nil)
-	mockBot.On("Send", mock.Anything).Return(tgbotapi.Message{}, nil)
 
-	// Act
-	notifier.checkAndNotify()
+	// Without a group nothing is announced
+	assert.NoError(t, notifier.Settings.SetGroupChatID(ctx, 0))
+	assert.NoError(t, notifier.SendWeeklyStats(ctx))
+	assert.Len(t, sender.to(-200), 2)
+}
+
+func TestHandleEvent(t *testing.T) {
+	notifier, s, sender, _, bob := setupNotifierTest(t, 11)
+	ctx := context.Background()
+	today, _ := s.GetDutyByDate(ctx, time.Date(2025, 10, 26, 0, 0, 0, 0, time.UTC))
+	today.User = nil // as published by the scheduler
+	monday := &store.Duty{UserID: bob.ID, DutyDate: time.Date(2025, 10, 27, 0, 0, 0, 0, time.UTC)}
+	s.CreateDuty(ctx, monday)
 
-	// Assert
-	mockStore.AssertCalled(t, "GetDutyByDate", mock.Anything, mock.Anything)
-	mockBot.AssertCalled(t, "Send", mock.Anything)
-	// Check that the sent message is the "reminder" one.
-	sentMessage := mockBot.Calls[0].Arguments.Get(0).(tgbotapi.MessageConfig)
-	assert.Contains(t, sentMessage.Text, "Duty Reminder")
-	assert.Contains(t, sentMessage.Text, "Alex")
+	notifier.HandleEvent(ctx, events.DutyAssigned{Duty: today})
+	if assert.Len(t, sender.to(testGroupID), 1, "today's duty is announced right away") {
+		assert.Contains(t, sender.to(testGroupID)[0], "@Alice is on duty today")
+	}
+
+	notifier.HandleEvent(ctx, events.DutyReassigned{Duty: monday})
+	notifier.HandleEvent(ctx, events.DutyCompleted{Date: today.DutyDate})
+	released := &store.Duty{UserID: bob.ID, User: bob, DutyDate: time.Date(2025, 10, 28, 0, 0, 0, 0, time.UTC)}
+	notifier.HandleEvent(ctx, events.DutyReleased{Duty: released})
+	assert.NoError(t, notifier.Close())
+	if assert.Len(t, sender.to(testGroupID), 2) {
+		assert.Contains(t, sender.to(testGroupID)[1], "@Bob is now on duty")
+		assert.Contains(t, sender.to(testGroupID)[1], "Tue, Oct 28: @Bob's hold expired, the day is free again")
+	}
 }
 
-func TestCheckAndNotify_AutoAssignSuccess(t *testing.T) {
-	notifier, mockStore, mockScheduler, mockBot := setupNotifierTest(t)
-	tomorrow := notifier.now().In(notifier.location).Add(24 * time.Hour)
+func TestNotifySubscribers(t *testing.T) {
+	notifier, s, sender, alice, bob := setupNotifierTest(t, 11)
+	ctx := context.Background()
+	s.CreateChangeSubscription(ctx, &store.ChangeSubscription{UserID: alice.ID, CreatedAt: notifier.now()})
+	today, _ := s.GetDutyByDate(ctx, time.Date(2025, 10, 26, 0, 0, 0, 0, time.UTC))
+	monday := &store.Duty{UserID: alice.ID, DutyDate: time.Date(2025, 10, 27, 0, 0, 0, 0, time.UTC)}
+	s.CreateDuty(ctx, monday)
+
+	notifier.NotifySubscribers(ctx, events.DutyAssigned{Duty: today})
+	assert.Empty(t, sender.to(alice.TelegramUserID), "today's assignment is covered by the reminder")
 
-	// Arrange
-	// No duty exists
-	mockStore.On("GetDutyByDate", mock.Anything, mock.Anything).Return(nil, errors.New("not found"))
-	// Scheduler assigns a new duty
-	assignedDuty := &store.Duty{
-		DutyDate: tomorrow,
-		User:     &store.User{FirstName: "Casey"},
+	notifier.NotifySubscribers(ctx, events.DutyAssigned{Duty: monday})
+	if assert.Len(t, sender.to(alice.TelegramUserID), 1) {
+		assert.Contains(t, sender.to(alice.TelegramUserID)[0], "you are now on duty on Monday, October 27")
 	}
-	mockScheduler.On("AssignDutyRoundRobin", mock.Anything, mock.Anything).Return(assignedDuty, nil)
-	mockBot.On("Send", mock.Anything).Return(tgbotapi.Message{}, nil)
 
-	// Act
-	notifier.checkAndNotify()
+	// Bob takes Monday over: only Alice is subscribed
+	s.UpdateDuty(ctx, &store.Duty{ID: monday.ID, UserID: bob.ID, DutyDate: monday.DutyDate})
+	notifier.NotifySubscribers(ctx, events.DutyReassigned{Duty: &store.Duty{UserID: bob.ID, DutyDate: monday.DutyDate}, PreviousUserID: alice.ID})
+	if assert.Len(t, sender.to(alice.TelegramUserID), 2) {
+		assert.Contains(t, sender.to(alice.TelegramUserID)[1], "your duty on Monday, October 27 moved to @Bob")
+	}
+	assert.Empty(t, sender.to(bob.TelegramUserID))
+
+	released := &store.Duty{UserID: alice.ID, User: alice, DutyDate: time.Date(2025, 10, 28, 0, 0, 0, 0, time.UTC)}
+	notifier.NotifySubscribers(ctx, events.DutyReleased{Duty: released})
+	if assert.Len(t, sender.to(alice.TelegramUserID), 3) {
+		assert.Contains(t, sender.to(alice.TelegramUserID)[2], "your hold on Tuesday, October 28 expired")
+	}
+
+	removed := &store.Duty{UserID: alice.ID, User: alice, DutyDate: time.Date(2025, 10, 29, 0, 0, 0, 0, time.UTC)}
+	notifier.NotifySubscribers(ctx, events.DutyRemoved{Duty: removed})
+	notifier.NotifySubscribers(ctx, events.DutyRemoved{Duty: released, Skip: store.SkipReasonHoliday})
+	planned := &store.Duty{UserID: alice.ID, User: alice, DutyDate: removed.DutyDate.AddDate(0, 0, 1), Status: store.DutyStatusProvisional}
+	notifier.NotifySubscribers(ctx, events.DutyRemoved{Duty: planned, Skip: store.SkipReasonBlackout})
+	if assert.Len(t, sender.to(alice.TelegramUserID), 5, "planned days were never announced to her") {
+		assert.Contains(t, sender.to(alice.TelegramUserID)[3], "your duty on Wednesday, October 29 was removed")
+		assert.Contains(t, sender.to(alice.TelegramUserID)[4], "Tuesday, October 28 is skipped, you are no longer on duty")
+	}
 
-	// Assert
-	mockStore.AssertCalled(t, "GetDutyByDate", mock.Anything, mock.Anything)
-	mockScheduler.AssertCalled(t, "AssignDutyRoundRobin", mock.Anything, mock.Anything)
-	mockBot.AssertCalled(t, "Send", mock.Anything)
-	// Check that the sent message is the "auto-assignment" one.
-	sentMessage := mockBot.Calls[0].Arguments.Get(0).(tgbotapi.MessageConfig)
-	assert.Contains(t, sentMessage.Text, "Automatic Duty Assignment")
-	assert.Contains(t, sentMessage.Text, "Casey")
+	s.DeleteChangeSubscription(ctx, alice.ID)
+	notifier.NotifySubscribers(ctx, events.DutyAssigned{Duty: monday})
+	assert.Len(t, sender.to(alice.TelegramUserID), 5, "unsubscribed users get nothing")
+	assert.Empty(t, sender.to(testGroupID), "nothing goes to the group")
 }
 
-func TestCheckAndNotify_AutoAssignFails(t *testing.T) {
-	notifier, mockStore, mockScheduler, mockBot := setupNotifierTest(t)
+func TestSendWeeklyStats(t *testing.T) {
+	notifier, s, sender, alice, bob := setupNotifierTest(t, 21)
+	ctx := context.Background()
+	s.CompleteDuty(ctx, time.Date(2025, 10, 26, 0, 0, 0, 0, time.UTC))
+
+	prefs := store.DefaultNotificationPreferences(bob.ID)
+	prefs.WeeklyStats = false
+	s.SetNotificationPreferences(ctx, prefs)
 
-	// Arrange
-	mockStore.On("GetDutyByDate", mock.Anything, mock.Anything).Return(nil, errors.New("not found"))
-	mockScheduler.On("AssignDutyRoundRobin", mock.Anything, mock.Anything).Return(nil, errors.New("scheduler failed"))
+	assert.NoError(t, notifier.SendWeeklyStats(ctx))
+	if assert.Len(t, sender.to(testGroupID), 1) {
+		assert.Contains(t, sender.to(testGroupID)[0], "Weekly Duty Report (Oct 20 - Oct 26)")
+		assert.Contains(t, sender.to(testGroupID)[0], "@Alice: 1 day")
+		assert.Contains(t, sender.to(testGroupID)[0], "📅 Week of Oct 20")
+		assert.Contains(t, sender.to(testGroupID)[0], "Sun: Alice ✅")
+	}
+	assert.Len(t, sender.to(alice.TelegramUserID), 1)
+	assert.Empty(t, sender.to(bob.TelegramUserID))
+}
 
-	// Act
-	notifier.checkAndNotify()
+func TestSendWeeklyStats_Locales(t *testing.T) {
+	notifier, s, sender, alice, _ := setupNotifierTest(t, 21)
+	ctx := context.Background()
+	notifier.Locale = i18n.German
+	s.SetChatLocale(ctx, alice.TelegramUserID, "en")
 
-	// Assert
-	mockStore.AssertCalled(t, "GetDutyByDate", mock.Anything, mock.Anything)
-	mockScheduler.AssertCalled(t, "AssignDutyRoundRobin", mock.Anything, mock.Anything)
-	// The bot should NOT be called if assignment fails.
-	mockBot.AssertNotCalled(t, "Send", mock.Anything)
+	assert.NoError(t, notifier.SendWeeklyStats(ctx))
+	if assert.Len(t, sender.to(testGroupID), 1) {
+		assert.Contains(t, sender.to(testGroupID)[0], "Weekly Duty Report (20. Okt - 26. Okt)")
+		assert.Contains(t, sender.to(testGroupID)[0], "So: Alice")
+	}
+	if assert.Len(t, sender.to(alice.TelegramUserID), 1) {
+		assert.Contains(t, sender.to(alice.TelegramUserID)[0], "Weekly Duty Report (Oct 20 - Oct 26)")
+	}
 }
 
-func TestCheckAndNotify_SendFails(t *testing.T) {
-	notifier, mockStore, _, mockBot := setupNotifierTest(t)
-	tomorrow := notifier.now().In(notifier.location).Add(24 * time.Hour)
+func TestSnooze(t *testing.T) {
+	notifier, s, sender, alice, bob := setupNotifierTest(t, 11)
+	ctx := context.Background()
+	defer notifier.Close()
 
-	// Arrange
-	existingDuty := &store.Duty{
-		DutyDate: tomorrow,
-		User:     &store.User{FirstName: "Alex"},
+	_, err := notifier.Snooze(ctx, bob, 1)
+	assert.ErrorIs(t, err, ErrNotOnDuty)
+
+	remindAt, err := notifier.Snooze(ctx, alice, 3)
+	assert.NoError(t, err)
+	assert.Equal(t, "14:00", remindAt.Format("15:04"))
+
+	snoozes, _ := s.ListReminderSnoozes(ctx)
+	if !assert.Len(t, snoozes, 1) {
+		return
 	}
-	mockStore.On("GetDutyByDate", mock.Anything, mock.Anything).Return(existingDuty, nil)
-	// Simulate a failure in the Telegram API.
-	mockBot.On("Send", mock.Anything).Return(tgbotapi.Message{}, errors.New("telegram network error"))
+	assert.Equal(t, alice.ID, snoozes[0].UserID)
+	assert.True(t, remindAt.Equal(snoozes[0].RemindAt))
 
-	// Act
-	notifier.checkAndNotify()
+	// Fire the reminder without waiting three hours
+	assert.NoError(t, notifier.deliverSnooze(ctx, snoozes[0]))
+	msgs := sender.messages(alice.TelegramUserID)
+	if assert.Len(t, msgs, 1) {
+		assert.Contains(t, msgs[0].text, "You're on duty today")
+		assert.Len(t, msgs[0].buttons, len(SnoozeHours)+1, "the reminder can be snoozed again")
+	}
+	snoozes, _ = s.ListReminderSnoozes(ctx)
+	assert.Empty(t, snoozes, "delivered snoozes are forgotten")
+}
 
-	// Assert
-	mockBot.AssertCalled(t, "Send", mock.Anything)
-	// We can't assert on logs directly without a more complex setup, but we expect the error to be logged.
-}
\ No newline at end of file
+func TestRestoreSnoozes(t *testing.T) {
+	notifier, s, sender, alice, bob := setupNotifierTest(t, 14)
+	ctx := context.Background()
+	defer notifier.Close()
+
+	today := time.Date(2025, 10, 26, 0, 0, 0, 0, time.UTC)
+	dueWhileDown := notifier.now().Add(-time.Hour)
+	s.CreateReminderSnooze(ctx, &store.ReminderSnooze{UserID: alice.ID, DutyDate: today, RemindAt: dueWhileDown})
+	// Bob snoozed before the duty was handed to Alice
+	s.CreateReminderSnooze(ctx, &store.ReminderSnooze{UserID: bob.ID, DutyDate: today, RemindAt: dueWhileDown})
+
+	assert.NoError(t, notifier.RestoreSnoozes(ctx))
+	assert.Eventually(t, func() bool {
+		snoozes, _ := s.ListReminderSnoozes(ctx)
+		return len(snoozes) == 0
+	}, time.Second, 10*time.Millisecond, "overdue snoozes are delivered on restore")
+	assert.Eventually(t, func() bool {
+		return len(sender.to(alice.TelegramUserID)) == 1
+	}, time.Second, 10*time.Millisecond)
+	assert.Empty(t, sender.to(bob.TelegramUserID), "reminders for duties that moved on are dropped")
+}
+
+func TestSendWeeklyPoll(t *testing.T) {
+	notifier, s, sender, alice, bob := setupNotifierTest(t, 18)
+	ctx := context.Background()
+	notifier.Settings = settings.New(s)
+	assert.NoError(t, notifier.Settings.Seed(ctx, testGroupID, nil))
+	today := time.Date(2025, 10, 26, 0, 0, 0, 0, time.UTC)
+	s.CreateDuty(ctx, &store.Duty{UserID: bob.ID, DutyDate: today.AddDate(0, 0, 2), AssignmentType: store.AssignmentTypeVoluntary})
+	s.CreateDuty(ctx, &store.Duty{UserID: alice.ID, DutyDate: today.AddDate(0, 0, 3), AssignmentType: store.AssignmentTypeRoundRobin, Status: store.DutyStatusProvisional})
+	s.CreateDuty(ctx, &store.Duty{UserID: alice.ID, DutyDate: today.AddDate(0, 0, 6), AssignmentType: store.AssignmentTypeAdmin})
+
+	// Off unless turned on
+	assert.NoError(t, notifier.SendWeeklyPoll(ctx))
+	assert.Empty(t, sender.to(testGroupID))
+
+	assert.NoError(t, notifier.Settings.SetWeeklyPoll(ctx, true))
+	assert.NoError(t, notifier.SendWeeklyPoll(ctx))
+	msgs := sender.messages(testGroupID)
+	if !assert.Len(t, msgs, 1) {
+		return
+	}
+	assert.Equal(t, "🙋 Any volunteers for next week, Oct 27 - Nov 2? Tap a day to take it, the rotation fills the rest.\n\n"+
+		"• Mon, Oct 27: free\n• Tue, Oct 28: Bob 🙋\n• Wed, Oct 29: free, Alice is planned\n• Thu, Oct 30: free\n"+
+		"• Fri, Oct 31: free\n• Sat, Nov 1: Alice\n• Sun, Nov 2: free", msgs[0].text)
+	var labels, data []string
+	for _, b := range msgs[0].buttons {
+		labels, data = append(labels, b.Text), append(data, b.Data)
+	}
+	assert.Equal(t, []string{"Mo 27", "We 29", "Th 30", "Fr 31", "Su 2"}, labels)
+	assert.Equal(t, "poll_volunteer:2025-10-27", data[0])
+}
+
+func TestRequestTakeover(t *testing.T) {
+	notifier, _, sender, _, _ := setupNotifierTest(t, 11)
+	const adminChatID = 42
+
+	assert.NoError(t, notifier.RequestTakeover(adminChatID))
+	msgs := sender.messages(adminChatID)
+	if !assert.Len(t, msgs, 1) {
+		return
+	}
+	assert.Contains(t, msgs[0].text, "Nobody is available for duty on Sunday, October 26")
+	var data []string
+	for _, b := range msgs[0].buttons {
+		data = append(data, b.Data)
+	}
+	assert.Equal(t, []string{"takeover_assign:2025-10-26", "takeover_skip:2025-10-26", "takeover_external:2025-10-26"}, data)
+}
+
+func TestRequestApproval(t *testing.T) {
+	notifier, _, sender, _, bob := setupNotifierTest(t, 11)
+	const adminChatID = 42
+
+	assert.NoError(t, notifier.RequestApproval(adminChatID, bob))
+	if msgs := sender.messages(adminChatID); assert.Len(t, msgs, 1) && assert.Len(t, msgs[0].buttons, 2) {
+		assert.Contains(t, msgs[0].text, "Bob wants to join the roster")
+		assert.Equal(t, fmt.Sprintf("approve_user:%d", bob.ID), msgs[0].buttons[0].Data)
+		assert.Equal(t, fmt.Sprintf("reject_user:%d", bob.ID), msgs[0].buttons[1].Data)
+	}
+}
+
+func TestHandleEvent_BadgeAwarded(t *testing.T) {
+	notifier, _, sender, alice, _ := setupNotifierTest(t, 21)
+
+	notifier.HandleEvent(context.Background(), events.BadgeAwarded{Badge: &store.Badge{UserID: alice.ID, Kind: store.BadgeTenDuties}})
+	msgs := sender.messages(testGroupID)
+	if assert.Len(t, msgs, 1) {
+		assert.Equal(t, "🏅 @Alice earned a badge: 🔟 Ten duties!", msgs[0].text)
+	}
+}
+
+func TestHandleEvent_MonthPublished(t *testing.T) {
+	notifier, _, sender, alice, bob := setupNotifierTest(t, 21)
+
+	notifier.HandleEvent(context.Background(), events.MonthPublished{
+		Month: time.Date(2025, 11, 1, 0, 0, 0, 0, time.UTC),
+		Duties: []*store.Duty{
+			{UserID: alice.ID, DutyDate: time.Date(2025, 11, 1, 0, 0, 0, 0, time.UTC)},
+			{UserID: bob.ID, DutyDate: time.Date(2025, 11, 2, 0, 0, 0, 0, time.UTC)},
+		},
+	})
+	// Published days aren't announced one by one as well
+	notifier.Close()
+	msgs := sender.messages(testGroupID)
+	if assert.Len(t, msgs, 1) {
+		assert.Contains(t, msgs[0].text, "📣 The plan for November 2025 is published:\n\nSat, Nov 1: @Alice\nSun, Nov 2: @Bob")
+	}
+}
+
+func TestReportUnassignedDay(t *testing.T) {
+	notifier, _, sender, _, _ := setupNotifierTest(t, 21)
+	const adminChatID = 42
+
+	assert.NoError(t, notifier.ReportUnassignedDay(adminChatID))
+	msgs := sender.messages(adminChatID)
+	if assert.Len(t, msgs, 1) {
+		assert.Contains(t, msgs[0].text, "Nobody was on duty on Sunday, October 26")
+	}
+}
+
+func TestAnnounceAssignment_External(t *testing.T) {
+	notifier, s, sender, alice, _ := setupNotifierTest(t, 11)
+	ctx := context.Background()
+	day := time.Date(2025, 10, 27, 0, 0, 0, 0, time.UTC)
+	s.CreateDuty(ctx, &store.Duty{UserID: alice.ID, DutyDate: day, AssignmentType: store.AssignmentTypeExternal, CreatedAt: notifier.now()})
+	duty, _ := s.GetDutyByDate(ctx, day)
+
+	assert.NoError(t, notifier.AnnounceAssignment(ctx, duty))
+	if assert.Len(t, sender.to(testGroupID), 1) {
+		assert.Contains(t, sender.to(testGroupID)[0], "@Alice arranged external help")
+	}
+}
+
+func TestClose_KeepsUnsentChanges(t *testing.T) {
+	notifier, s, sender, _, bob := setupNotifierTest(t, 21)
+	ctx := context.Background()
+
+	notifier.AnnounceChange(time.Date(2025, 10, 27, 0, 0, 0, 0, time.UTC), bob)
+	sender.err = errors.New("telegram is down")
+	assert.NoError(t, notifier.Close())
+
+	pending, err := s.ListPendingMessages(ctx)
+	if assert.NoError(t, err) && assert.Len(t, pending, 1) {
+		assert.Equal(t, int64(testGroupID), pending[0].ChatID)
+		assert.Contains(t, pending[0].Text, "@Bob is now on duty")
+	}
+
+	// Next start
+	sender.err = nil
+	assert.NoError(t, notifier.SendPendingMessages(ctx))
+	if assert.Len(t, sender.to(testGroupID), 1) {
+		assert.Contains(t, sender.to(testGroupID)[0], "@Bob is now on duty")
+	}
+	pending, _ = s.ListPendingMessages(ctx)
+	assert.Empty(t, pending)
+}
+
+func TestAskForCover(t *testing.T) {
+	notifier, _, sender, alice, bob := setupNotifierTest(t, 9)
+	ctx := context.Background()
+	today := time.Date(2025, 10, 26, 0, 0, 0, 0, time.UTC)
+	created := time.Date(2025, 10, 26, 9, 0, 0, 0, time.UTC)
+	ask := &cover.Ask{
+		Request:  &store.CoverRequest{DutyDate: today, UserID: alice.ID, Deadline: created.Add(30 * time.Minute), CreatedAt: created},
+		User:     alice,
+		Fallback: bob,
+	}
+
+	sent, err := notifier.AskForCover(ctx, ask)
+	assert.NoError(t, err)
+	assert.True(t, sent)
+	msgs := sender.messages(testGroupID)
+	if assert.Len(t, msgs, 1) {
+		assert.Equal(t, "🆘 Who can cover for Alice on Sun, Oct 26? Tap the button to take the duty over.\n\n"+
+			"If nobody does within 30 minutes, Bob takes over and Alice owes a day.", msgs[0].text)
+		if assert.Len(t, msgs[0].buttons, 1) {
+			assert.Equal(t, "cover_claim:2025-10-26", msgs[0].buttons[0].Data)
+		}
+	}
+
+	assert.Equal(t, []string{"🆘 Alice asks who can cover their duty on Sun, Oct 26. Tap 🙋 I'll cover under the question to take it over."},
+		sender.to(bob.TelegramUserID), "Bob could take the duty over")
+	assert.Empty(t, sender.to(alice.TelegramUserID), "Alice asked herself")
+
+	assert.NoError(t, notifier.AnnounceCoverExpired(ctx, cover.Expired{Date: today, User: alice}))
+	msgs = sender.messages(testGroupID)
+	if assert.Len(t, msgs, 2) {
+		assert.Equal(t, "⏰ Nobody could cover for Alice on Sun, Oct 26, so Alice stays on duty.", msgs[1].text)
+	}
+}
+
+func TestAskForCover_SwapRequestsOff(t *testing.T) {
+	notifier, s, sender, alice, bob := setupNotifierTest(t, 9)
+	ctx := context.Background()
+	today := time.Date(2025, 10, 26, 0, 0, 0, 0, time.UTC)
+	created := time.Date(2025, 10, 26, 9, 0, 0, 0, time.UTC)
+	prefs := store.DefaultNotificationPreferences(bob.ID)
+	prefs.SwapRequests = false
+	assert.NoError(t, s.SetNotificationPreferences(ctx, prefs))
+
+	sent, err := notifier.AskForCover(ctx, &cover.Ask{
+		Request: &store.CoverRequest{DutyDate: today, UserID: alice.ID, Deadline: created.Add(30 * time.Minute), CreatedAt: created},
+		User:    alice,
+	})
+	assert.NoError(t, err)
+	assert.True(t, sent)
+	assert.Len(t, sender.messages(testGroupID), 1, "the group is asked anyway")
+	assert.Empty(t, sender.to(bob.TelegramUserID))
+}
+
+func TestNotifyFines(t *testing.T) {
+	notifier, s, sender, alice, bob := setupNotifierTest(t, 21)
+	ctx := context.Background()
+	missed := time.Date(2025, 10, 25, 0, 0, 0, 0, time.UTC)
+	var fines []*store.LedgerEntry
+	for _, u := range []*store.User{alice, bob} {
+		e := &store.LedgerEntry{UserID: u.ID, Kind: store.LedgerFine, Amount: 450, DutyDate: &missed, CreatedAt: missed}
+		assert.NoError(t, s.AddLedgerEntry(ctx, e))
+		fines = append(fines, e)
+	}
+	// Bob doesn't get personal messages
+	prefs := store.DefaultNotificationPreferences(bob.ID)
+	prefs.PersonalDM = false
+	assert.NoError(t, s.SetNotificationPreferences(ctx, prefs))
+
+	assert.NoError(t, notifier.NotifyFines(ctx, fines, "EUR", true))
+	msgs := sender.messages(alice.TelegramUserID)
+	if assert.Len(t, msgs, 1) {
+		assert.Equal(t, "💸 Your duty on Saturday, October 25 was missed, which costs 4.50 EUR. You owe 4.50 EUR now.", msgs[0].text)
+		if assert.Len(t, msgs[0].buttons, 1) {
+			assert.Equal(t, fmt.Sprintf("pay_fine:%d", alice.ID), msgs[0].buttons[0].Data)
+		}
+	}
+	assert.Empty(t, sender.messages(bob.TelegramUserID))
+
+	// Without invoices there is nothing to press
+	assert.NoError(t, notifier.NotifyFines(ctx, fines[:1], "EUR", false))
+	if msgs := sender.messages(alice.TelegramUserID); assert.Len(t, msgs, 2) {
+		assert.Empty(t, msgs[1].buttons)
+	}
+}
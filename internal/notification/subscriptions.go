@@ -0,0 +1,111 @@
+package notification
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/korjavin/dutyassistant/internal/events"
+	"github.com/korjavin/dutyassistant/internal/i18n"
+	"github.com/korjavin/dutyassistant/internal/store"
+)
+
+// NotifySubscribers privately tells users who subscribed with /subscribe
+// about changes to their own duties. It is subscribed to the scheduler's event
+// bus next to HandleEvent. Today's daily assignment isn't a change, the
+// personal reminder already covers it.
+func (n *Notifier) NotifySubscribers(ctx context.Context, e events.Event) {
+	switch e := e.(type) {
+	case events.DutyAssigned:
+		if e.Duty.DutyDate.Equal(n.today()) {
+			return
+		}
+		n.notifySubscriber(ctx, e.Duty.UserID, func(l i18n.Locale) string { return FormatDutyGained(l, e.Duty.DutyDate) })
+	case events.DutyReassigned:
+		if e.PreviousUserID == e.Duty.UserID {
+			return
+		}
+		n.notifySubscriber(ctx, e.Duty.UserID, func(l i18n.Locale) string { return FormatDutyGained(l, e.Duty.DutyDate) })
+		if e.PreviousUserID != 0 {
+			duty, err := n.withUser(ctx, e.Duty)
+			if err != nil {
+				log.Printf("[NOTIFY] %v", err)
+				return
+			}
+			n.notifySubscriber(ctx, e.PreviousUserID, func(l i18n.Locale) string {
+				return FormatDutyLost(l, duty.DutyDate, duty.User.FirstName)
+			})
+		}
+	case events.DutyReleased:
+		n.notifySubscriber(ctx, e.Duty.UserID, func(l i18n.Locale) string { return FormatHoldReleased(l, e.Duty.DutyDate) })
+	case events.DutyRemoved:
+		// Nobody was told about planned days either
+		if e.Duty.Status == store.DutyStatusProvisional {
+			return
+		}
+		n.notifySubscriber(ctx, e.Duty.UserID, func(l i18n.Locale) string { return FormatDutyRemoved(l, e.Duty.DutyDate, e.Skip != "") })
+	}
+}
+
+// notifySubscriber sends the message format returns in the user's locale to
+// the user with the given ID if they are subscribed to changes of their duties.
+func (n *Notifier) notifySubscriber(ctx context.Context, userID int64, format func(i18n.Locale) string) {
+	sub, err := n.store.GetChangeSubscription(ctx, userID)
+	if err != nil {
+		log.Printf("[NOTIFY] Failed to load change subscription of user %d: %v", userID, err)
+		return
+	}
+	if sub == nil {
+		return
+	}
+	user, err := n.userByID(ctx, userID)
+	if err != nil {
+		log.Printf("[NOTIFY] %v", err)
+		return
+	}
+	if err := n.bot.SendMessage(user.TelegramUserID, format(n.locale(ctx, user.TelegramUserID))); err != nil {
+		log.Printf("[NOTIFY] Failed to send change notification to user %d: %v", user.TelegramUserID, err)
+	}
+}
+
+// userByID finds a user by their store ID.
+func (n *Notifier) userByID(ctx context.Context, id int64) (*store.User, error) {
+	users, err := n.store.ListAllUsers(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list users: %w", err)
+	}
+	for _, u := range users {
+		if u.ID == id {
+			return u, nil
+		}
+	}
+	return nil, fmt.Errorf("user %d not found", id)
+}
+
+// FormatDutyGained formats the private message telling a subscriber they are
+// now on duty on a day.
+func FormatDutyGained(l i18n.Locale, date time.Time) string {
+	return fmt.Sprintf("📅 Schedule change: you are now on duty on %s.", l.Format(date, "Monday, January 2"))
+}
+
+// FormatDutyLost formats the private message telling a subscriber their duty
+// on a day went to someone else.
+func FormatDutyLost(l i18n.Locale, date time.Time, userName string) string {
+	return fmt.Sprintf("🔄 Schedule change: your duty on %s moved to @%s.", l.Format(date, "Monday, January 2"), userName)
+}
+
+// FormatDutyRemoved formats the private message telling a subscriber their
+// duty on a day was removed, or dropped because the day is skipped.
+func FormatDutyRemoved(l i18n.Locale, date time.Time, skipped bool) string {
+	if skipped {
+		return fmt.Sprintf("🚫 Schedule change: %s is skipped, you are no longer on duty that day.", l.Format(date, "Monday, January 2"))
+	}
+	return fmt.Sprintf("❌ Schedule change: your duty on %s was removed.", l.Format(date, "Monday, January 2"))
+}
+
+// FormatHoldReleased formats the private message telling a subscriber their
+// held duty wasn't confirmed in time.
+func FormatHoldReleased(l i18n.Locale, date time.Time) string {
+	return fmt.Sprintf("🔓 Schedule change: your hold on %s expired, the day is free again.", l.Format(date, "Monday, January 2"))
+}
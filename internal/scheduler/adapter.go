@@ -4,9 +4,12 @@ import (
 	"context"
 	"time"
 
+	"github.com/korjavin/dutyassistant/internal/events"
 	"github.com/korjavin/dutyassistant/internal/store"
 )
 
+//go:generate go run go.uber.org/mock/mockgen -destination=mocks/scheduler.go -package=mocks . SchedulerInterface
+
 // SchedulerInterface defines the interface for the core business logic of assigning duties.
 // This is used by the Telegram bot handlers.
 type SchedulerInterface interface {
@@ -16,14 +19,69 @@ type SchedulerInterface interface {
 	// VolunteerForDuty adds days to a user's volunteer queue.
 	VolunteerForDuty(ctx context.Context, user *store.User, days int) error
 
-	// AutoAssignDuty automatically assigns today's duty (runs at 11AM).
+	// AutoAssignDuty runs today's daily assignment right away, even before the
+	// cutoff. Admins and the API trigger it; the date is ignored.
 	AutoAssignDuty(ctx context.Context, date time.Time) (*store.Duty, error)
 
-	// ChangeDutyUser changes the assigned user for today or a future duty.
-	ChangeDutyUser(ctx context.Context, date time.Time, newUserID int64) (*store.Duty, error)
+	// AssignDutyTo assigns a free day to a specific user, bypassing the queues.
+	AssignDutyTo(ctx context.Context, date time.Time, userID int64, assignType store.AssignmentType) (*store.Duty, error)
+
+	// AssignHeldDuty assigns a free day to a user until the end of until,
+	// unless the assignment is confirmed by then.
+	AssignHeldDuty(ctx context.Context, date time.Time, userID int64, until time.Time) (*store.Duty, error)
+
+	// HoldDuty puts a duty on hold until the end of until, or confirms it if until is nil.
+	HoldDuty(ctx context.Context, date time.Time, until *time.Time) (*store.Duty, error)
+
+	// ChangeDutyUser changes the assigned user for today or a future duty,
+	// adjusting their queues according to mode.
+	ChangeDutyUser(ctx context.Context, date time.Time, newUserID int64, mode ChangeMode) (*store.Duty, error)
+
+	// RemoveDuty removes today's or a future duty.
+	RemoveDuty(ctx context.Context, date time.Time) error
+
+	// DraftMonth plans the current or next month provisionally for review.
+	DraftMonth(ctx context.Context, month time.Time) ([]*store.Duty, error)
+
+	// PublishMonth assigns the drafted duties of a month for real.
+	PublishMonth(ctx context.Context, month time.Time) ([]*store.Duty, error)
+
+	// Forecast predicts the duties of the next days without changing anything.
+	Forecast(ctx context.Context, days int) ([]*store.Duty, error)
+
+	// SetDutyCompletion marks the duty of today or a past day as done, or
+	// takes that back, on behalf of the admin with the given user ID.
+	SetDutyCompletion(ctx context.Context, date time.Time, completed bool, actorID int64) (*store.Duty, error)
+
+	// BackfillDuty records who actually did the duty on a past day.
+	BackfillDuty(ctx context.Context, date time.Time, userID int64) (*store.Duty, error)
+
+	// AcknowledgeDuty records that the user on duty on date confirmed it.
+	AcknowledgeDuty(ctx context.Context, date time.Time, userID int64) (*store.Duty, error)
+
+	// SkipDay marks a date as "no duty", removing a duty already assigned for it.
+	SkipDay(ctx context.Context, date time.Time, reason store.SkipReason) error
+
+	// UnskipDay removes the "no duty" mark from a date.
+	UnskipDay(ctx context.Context, date time.Time) error
+
+	// AddBlackout stores a blackout rule for the whole household, removing
+	// the duties it covers from today on, and returns those assigned for real.
+	AddBlackout(ctx context.Context, rule, description string) (*store.BlackoutRule, []*store.Duty, error)
+
+	// RemoveBlackout removes a blackout rule.
+	RemoveBlackout(ctx context.Context, id int64) error
 
 	// SetOffDuty sets a user's off-duty period.
 	SetOffDuty(ctx context.Context, userID int64, start, end time.Time) error
+
+	// AddOffDutyPeriods adds imported off-duty periods to a user's, returning
+	// those the user didn't have yet.
+	AddOffDutyPeriods(ctx context.Context, userID int64, periods []*store.OffDutyPeriod) ([]*store.OffDutyPeriod, error)
+
+	// RunInTx calls fn with a scheduler whose changes are all made, or none
+	// of them if fn returns an error. Events are published once they're made.
+	RunInTx(ctx context.Context, fn func(tx SchedulerInterface) error) error
 }
 
 // Verify that Scheduler implements SchedulerInterface
@@ -39,7 +97,34 @@ func (s *Scheduler) VolunteerForDuty(ctx context.Context, user *store.User, days
 	return s.AddToVolunteerQueue(ctx, user.ID, days)
 }
 
-// AutoAssignDuty implements the SchedulerInterface by assigning today's duty.
+// AutoAssignDuty implements the SchedulerInterface by forcing today's assignment.
 func (s *Scheduler) AutoAssignDuty(ctx context.Context, date time.Time) (*store.Duty, error) {
-	return s.AssignTodaysDuty(ctx)
+	return s.AssignTodaysDuty(ctx, true)
+}
+
+// RunInTx implements the SchedulerInterface by running fn on a copy of the
+// scheduler backed by a store transaction. The events the copy publishes are
+// held back until the transaction is committed, and dropped if it isn't.
+func (s *Scheduler) RunInTx(ctx context.Context, fn func(tx SchedulerInterface) error) error {
+	return s.inTx(ctx, func(tx *Scheduler) error { return fn(tx) })
+}
+
+// inTx is RunInTx for the scheduler's own methods, which need the copy as it is.
+func (s *Scheduler) inTx(ctx context.Context, fn func(tx *Scheduler) error) error {
+	var pending []events.Event
+	held := events.NewBus()
+	held.Subscribe(func(_ context.Context, e events.Event) { pending = append(pending, e) })
+
+	err := s.store.RunInTx(ctx, func(tx store.Store) error {
+		inTx := *s
+		inTx.store, inTx.Events = tx, held
+		return fn(&inTx)
+	})
+	if err != nil {
+		return err
+	}
+	for _, e := range pending {
+		s.Events.Publish(ctx, e)
+	}
+	return nil
 }
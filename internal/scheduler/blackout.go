@@ -0,0 +1,206 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/korjavin/dutyassistant/internal/store"
+)
+
+// ErrNoBlackout is returned when removing a blackout rule that doesn't exist.
+var ErrNoBlackout = errors.New("there is no such blackout rule")
+
+// Blackout is a rule for days nobody in the household is on duty, every
+// year. Blackouts are stored as store.BlackoutRule, see ParseBlackout.
+type Blackout struct {
+	// From and Until are the first and last day of the blackout, both
+	// included, unless it is relative to Easter. A blackout with Until
+	// before From spans New Year.
+	From, Until monthDay
+	// Easter is set for a day relative to Easter Sunday, Offset days after
+	// it, so holidays like Good Friday (easter-2) move with it.
+	Easter bool
+	Offset int
+}
+
+// ParseBlackout parses a blackout rule, one of
+//
+//	<MM-DD>            - a day every year, e.g. 12-24
+//	<MM-DD>..<MM-DD>   - the days from one to the other every year, both
+//	                     included, e.g. 12-24..12-26
+//	easter[+N|-N]      - Easter Sunday, or N days after or before it, e.g.
+//	                     easter-2 for Good Friday
+func ParseBlackout(value string) (Blackout, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	if offset, ok := strings.CutPrefix(value, "easter"); ok {
+		if offset == "" {
+			return Blackout{Easter: true}, nil
+		}
+		n, err := strconv.Atoi(offset)
+		if err != nil || (offset[0] != '+' && offset[0] != '-') || n < -366 || n > 366 {
+			return Blackout{}, fmt.Errorf("invalid offset %q, expected easter+N or easter-N", offset)
+		}
+		return Blackout{Easter: true, Offset: n}, nil
+	}
+	from, until, ok := strings.Cut(value, "..")
+	if !ok {
+		until = from
+	}
+	var b Blackout
+	var err error
+	if b.From, err = parseMonthDay(from); err != nil {
+		return Blackout{}, fmt.Errorf("invalid blackout %q, expected MM-DD, MM-DD..MM-DD or easter[+N|-N]", value)
+	}
+	if b.Until, err = parseMonthDay(until); err != nil {
+		return Blackout{}, fmt.Errorf("invalid blackout %q, expected MM-DD, MM-DD..MM-DD or easter[+N|-N]", value)
+	}
+	return b, nil
+}
+
+// Matches reports whether the blackout covers day.
+func (b Blackout) Matches(day time.Time) bool {
+	if b.Easter {
+		date := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, time.UTC)
+		// The offset may reach into the year before or after
+		for _, year := range []int{day.Year() - 1, day.Year(), day.Year() + 1} {
+			if easterSunday(year).AddDate(0, 0, b.Offset).Equal(date) {
+				return true
+			}
+		}
+		return false
+	}
+	d := monthDayOf(day)
+	if b.From <= b.Until {
+		return d >= b.From && d <= b.Until
+	}
+	return d >= b.From || d <= b.Until
+}
+
+// String is the blackout as ParseBlackout reads it.
+func (b Blackout) String() string {
+	switch {
+	case b.Easter && b.Offset == 0:
+		return "easter"
+	case b.Easter:
+		return fmt.Sprintf("easter%+d", b.Offset)
+	case b.From == b.Until:
+		return b.From.String()
+	default:
+		return b.From.String() + ".." + b.Until.String()
+	}
+}
+
+// easterSunday returns the date of Easter Sunday in the Gregorian calendar,
+// by the anonymous Gregorian algorithm.
+func easterSunday(year int) time.Time {
+	a := year % 19
+	b, c := year/100, year%100
+	d, e := b/4, b%4
+	f := (b + 8) / 25
+	g := (b - f + 1) / 3
+	h := (19*a + b - d - g + 15) % 30
+	i, k := c/4, c%4
+	l := (32 + 2*e + 2*i - h - k) % 7
+	m := (a + 11*h + 22*l) / 451
+	month := (h + l - 7*m + 114) / 31
+	day := (h+l-7*m+114)%31 + 1
+	return time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+}
+
+// skipDay returns why day deliberately has no duty: the skip day an admin
+// set, or a blackout rule matching it, with the reason SkipReasonBlackout.
+// It returns nil on other days.
+func (s *Scheduler) skipDay(ctx context.Context, day time.Time) (*store.SkipDay, error) {
+	skip, err := s.store.GetSkipDay(ctx, day)
+	if err != nil || skip != nil {
+		return skip, err
+	}
+	rule, err := s.blackout(ctx, day)
+	if err != nil || rule == nil {
+		return nil, err
+	}
+	return &store.SkipDay{Date: day, Reason: store.SkipReasonBlackout, CreatedAt: rule.CreatedAt}, nil
+}
+
+// blackout returns the first blackout rule matching day, or nil if none does.
+func (s *Scheduler) blackout(ctx context.Context, day time.Time) (*store.BlackoutRule, error) {
+	rules, err := s.store.ListBlackoutRules(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list blackout rules: %w", err)
+	}
+	for _, r := range rules {
+		b, err := ParseBlackout(r.Rule)
+		if err != nil {
+			log.Printf("[SCHEDULER] Ignoring blackout rule #%d: %v", r.ID, err)
+			continue
+		}
+		if b.Matches(day) {
+			return r, nil
+		}
+	}
+	return nil, nil
+}
+
+// AddBlackout stores a blackout rule, see ParseBlackout, and removes the
+// duties from today on that it covers, unless they are completed. It returns
+// the duties it removed that were assigned for real.
+func (s *Scheduler) AddBlackout(ctx context.Context, rule, description string) (*store.BlackoutRule, []*store.Duty, error) {
+	b, err := ParseBlackout(rule)
+	if err != nil {
+		return nil, nil, err
+	}
+	r := &store.BlackoutRule{Rule: b.String(), Description: description, CreatedAt: s.now().UTC()}
+	var removed []*store.Duty
+	err = s.inTx(ctx, func(tx *Scheduler) error {
+		duties, err := tx.store.ListDuties(ctx, store.DutyFilter{From: tx.today()})
+		if err != nil {
+			return fmt.Errorf("failed to list duties: %w", err)
+		}
+		if err := tx.store.CreateBlackoutRule(ctx, r); err != nil {
+			return err
+		}
+		for _, d := range duties {
+			if d.CompletedAt != nil || !b.Matches(d.DutyDate) {
+				continue
+			}
+			if err := tx.removeDuty(ctx, d, store.SkipReasonBlackout); err != nil {
+				return fmt.Errorf("failed to remove the duty on %s: %w", d.DutyDate.Format("2006-01-02"), err)
+			}
+			if d.Status != store.DutyStatusProvisional {
+				removed = append(removed, d)
+			}
+		}
+		tx.replan(ctx)
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return r, removed, nil
+}
+
+// RemoveBlackout removes a blackout rule. Its days are left to the daily
+// assignment again.
+func (s *Scheduler) RemoveBlackout(ctx context.Context, id int64) error {
+	rules, err := s.store.ListBlackoutRules(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list blackout rules: %w", err)
+	}
+	found := false
+	for _, r := range rules {
+		found = found || r.ID == id
+	}
+	if !found {
+		return ErrNoBlackout
+	}
+	if err := s.store.DeleteBlackoutRule(ctx, id); err != nil {
+		return err
+	}
+	s.replan(ctx)
+	return nil
+}
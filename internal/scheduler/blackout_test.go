@@ -0,0 +1,100 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/korjavin/dutyassistant/internal/store"
+)
+
+func TestParseBlackout(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+		days  []string // Days it matches
+		not   []string // Days it doesn't
+	}{
+		{"12-25", "12-25", []string{"2025-12-25", "2026-12-25"}, []string{"2025-12-24"}},
+		{" 12-24..12-26 ", "12-24..12-26", []string{"2025-12-24", "2025-12-26"}, []string{"2025-12-27"}},
+		{"12-31..01-01", "12-31..01-01", []string{"2025-12-31", "2026-01-01"}, []string{"2026-01-02"}},
+		{"Easter", "easter", []string{"2025-04-20", "2026-04-05"}, []string{"2025-04-21"}},
+		{"easter-2", "easter-2", []string{"2025-04-18", "2026-04-03"}, []string{"2025-04-20"}},
+		{"easter+1", "easter+1", []string{"2025-04-21", "2024-04-01"}, []string{"2025-04-20"}},
+	}
+	for _, tt := range tests {
+		b, err := ParseBlackout(tt.value)
+		if err != nil {
+			t.Errorf("ParseBlackout(%q) failed: %v", tt.value, err)
+			continue
+		}
+		if b.String() != tt.want {
+			t.Errorf("ParseBlackout(%q) = %q, want %q", tt.value, b, tt.want)
+		}
+		for _, day := range tt.days {
+			if d, _ := time.Parse("2006-01-02", day); !b.Matches(d) {
+				t.Errorf("%s should match %s", tt.want, day)
+			}
+		}
+		for _, day := range tt.not {
+			if d, _ := time.Parse("2006-01-02", day); b.Matches(d) {
+				t.Errorf("%s shouldn't match %s", tt.want, day)
+			}
+		}
+	}
+
+	for _, value := range []string{"", "christmas", "13-01", "12-24..", "easter2", "easter+x"} {
+		if _, err := ParseBlackout(value); err == nil {
+			t.Errorf("ParseBlackout(%q) should fail", value)
+		}
+	}
+}
+
+func TestScheduler_Blackout(t *testing.T) {
+	sched, s, users := newTestScheduler(t)
+	ctx := context.Background()
+	alice := users[0]
+	berlin, _ := time.LoadLocation("Europe/Berlin")
+	day := time.Date(2025, 12, 22, 0, 0, 0, 0, time.UTC)
+	sched.now = func() time.Time { return time.Date(2025, 12, 22, 8, 0, 0, 0, berlin) }
+	sched.Horizon = 4
+
+	christmasEve := day.AddDate(0, 0, 2)
+	if _, err := sched.AssignDutyTo(ctx, christmasEve, alice.ID, store.AssignmentTypeAdmin); err != nil {
+		t.Fatalf("AssignDutyTo failed: %v", err)
+	}
+	rule, removed, err := sched.AddBlackout(ctx, "12-24..12-25", "Christmas")
+	if err != nil {
+		t.Fatalf("AddBlackout failed: %v", err)
+	}
+	if len(removed) != 1 || !removed[0].DutyDate.Equal(christmasEve) {
+		t.Errorf("Expected Alice's duty on Christmas Eve removed, got %+v", removed)
+	}
+	for i := 0; i <= 4; i++ {
+		duty, _ := s.GetDutyByDate(ctx, day.AddDate(0, 0, i))
+		if blackedOut := i == 2 || i == 3; blackedOut != (duty == nil) {
+			t.Errorf("Day %d: blacked out %v, got duty %+v", i, blackedOut, duty)
+		}
+	}
+
+	// Nobody can be assigned by hand either, and it isn't an unassigned day
+	if _, err := sched.AssignDutyTo(ctx, christmasEve, alice.ID, store.AssignmentTypeAdmin); !errors.Is(err, ErrDaySkipped) {
+		t.Errorf("AssignDutyTo on a blackout day: expected ErrDaySkipped, got %v", err)
+	}
+	sched.now = func() time.Time { return time.Date(2025, 12, 24, 21, 0, 0, 0, berlin) }
+	if err := sched.CompleteTodaysDuty(ctx); err != nil {
+		t.Errorf("CompleteTodaysDuty on a blackout day: expected no error, got %v", err)
+	}
+
+	sched.now = func() time.Time { return time.Date(2025, 12, 22, 8, 0, 0, 0, berlin) }
+	if err := sched.RemoveBlackout(ctx, rule.ID); err != nil {
+		t.Fatalf("RemoveBlackout failed: %v", err)
+	}
+	if duty, _ := s.GetDutyByDate(ctx, christmasEve); duty == nil {
+		t.Error("Expected Christmas Eve planned again")
+	}
+	if err := sched.RemoveBlackout(ctx, rule.ID); !errors.Is(err, ErrNoBlackout) {
+		t.Errorf("RemoveBlackout twice: expected ErrNoBlackout, got %v", err)
+	}
+}
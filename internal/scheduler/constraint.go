@@ -0,0 +1,253 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/korjavin/dutyassistant/internal/store"
+)
+
+// Constraint limits who may be on duty on a day. Constraints are declared in
+// SCHEDULE_CONSTRAINTS, see ParseConstraints, and refer to users by handle.
+type Constraint interface {
+	// String is the constraint as it is declared.
+	String() string
+	// Allows reports whether user may be on duty on a day of the weekday.
+	Allows(weekday time.Weekday, user *store.User) bool
+	// Apart reports whether a and b must not be on duty on adjacent days.
+	Apart(a, b *store.User) bool
+	// Handles returns the handles of the users the constraint names.
+	Handles() []string
+}
+
+// apart keeps two users off adjacent days.
+type apart [2]string
+
+func (c apart) String() string { return fmt.Sprintf("apart %s %s", c[0], c[1]) }
+
+func (c apart) Allows(time.Weekday, *store.User) bool { return true }
+
+func (c apart) Apart(a, b *store.User) bool {
+	return (a.Handle == c[0] && b.Handle == c[1]) || (a.Handle == c[1] && b.Handle == c[0])
+}
+
+func (c apart) Handles() []string { return c[:] }
+
+// adult keeps junior users off some weekdays.
+type adult []time.Weekday
+
+func (c adult) String() string {
+	names := make([]string, len(c))
+	for i, d := range c {
+		names[i] = strings.ToLower(d.String()[:3])
+	}
+	return "adult " + strings.Join(names, ",")
+}
+
+func (c adult) Allows(weekday time.Weekday, user *store.User) bool {
+	if !user.IsJunior {
+		return true
+	}
+	for _, d := range c {
+		if d == weekday {
+			return false
+		}
+	}
+	return true
+}
+
+func (c adult) Apart(a, b *store.User) bool { return false }
+
+func (c adult) Handles() []string { return nil }
+
+// ParseConstraints parses constraints separated by semicolons:
+//
+//	apart <user> <user>  - the two users are never on duty on adjacent days
+//	adult <days>         - junior users are never on duty on these days, a
+//	                       comma-separated list of weekdays like sat,sun or
+//	                       "weekend"
+//
+// Users are given by handle. An empty value has no constraints.
+func ParseConstraints(value string) ([]Constraint, error) {
+	var constraints []Constraint
+	for _, decl := range strings.Split(value, ";") {
+		fields := strings.Fields(strings.ToLower(decl))
+		if len(fields) == 0 {
+			continue
+		}
+		switch {
+		case fields[0] == "apart" && len(fields) == 3:
+			if fields[1] == fields[2] {
+				return nil, fmt.Errorf("constraint %q: a user can't be apart from themselves", strings.TrimSpace(decl))
+			}
+			constraints = append(constraints, apart{fields[1], fields[2]})
+		case fields[0] == "adult" && len(fields) == 2:
+			days, err := parseWeekdays(fields[1])
+			if err != nil {
+				return nil, fmt.Errorf("constraint %q: %w", strings.TrimSpace(decl), err)
+			}
+			constraints = append(constraints, adult(days))
+		default:
+			return nil, fmt.Errorf("invalid constraint %q, expected \"apart <user> <user>\" or \"adult <days>\"", strings.TrimSpace(decl))
+		}
+	}
+	return constraints, nil
+}
+
+// parseWeekdays parses a comma-separated list of weekdays, or "weekend".
+func parseWeekdays(value string) ([]time.Weekday, error) {
+	if value == "weekend" {
+		return []time.Weekday{time.Saturday, time.Sunday}, nil
+	}
+	var days []time.Weekday
+	for _, name := range strings.Split(value, ",") {
+		found := false
+		for d := time.Sunday; d <= time.Saturday; d++ {
+			if len(name) >= 3 && strings.HasPrefix(strings.ToLower(d.String()), name) {
+				days, found = append(days, d), true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown weekday %q", name)
+		}
+	}
+	return days, nil
+}
+
+// CheckConstraints reports why the constraints can't always be met by users,
+// the users the daily assignment picks from. Off-duty periods and skip days
+// aren't taken into account; on days they leave nobody to pick, the
+// constraints are relaxed.
+func CheckConstraints(constraints []Constraint, users []*store.User) error {
+	if len(constraints) == 0 {
+		return nil
+	}
+	known := make(map[string]bool, len(users))
+	for _, u := range users {
+		known[u.Handle] = true
+	}
+	for _, c := range constraints {
+		for _, handle := range c.Handles() {
+			if !known[handle] {
+				return fmt.Errorf("constraint %q: there is no active user %q", c, handle)
+			}
+		}
+	}
+
+	// possible[d] is who can be on duty on weekday d with every day before it
+	// covered. Each week only keeps users who could follow someone possible
+	// the day before, so the sets shrink until they settle.
+	var possible [7][]*store.User
+	for d := range possible {
+		possible[d] = users
+	}
+	for changed := true; changed; {
+		changed = false
+		for d := time.Sunday; d <= time.Saturday; d++ {
+			before := possible[(d+6)%7]
+			var next []*store.User
+			for _, u := range possible[d] {
+				if allowed(constraints, d, u) && followsAny(constraints, u, before) {
+					next = append(next, u)
+				}
+			}
+			if len(next) == 0 {
+				return fmt.Errorf("nobody can be on duty on %ss", d)
+			}
+			changed = changed || len(next) != len(possible[d])
+			possible[d] = next
+		}
+	}
+	return nil
+}
+
+// allowed reports whether all constraints allow user on a day of the weekday.
+func allowed(constraints []Constraint, weekday time.Weekday, user *store.User) bool {
+	for _, c := range constraints {
+		if !c.Allows(weekday, user) {
+			return false
+		}
+	}
+	return true
+}
+
+// followsAny reports whether user may be on duty the day after one of users.
+func followsAny(constraints []Constraint, user *store.User, users []*store.User) bool {
+	for _, prev := range users {
+		if !keptApart(constraints, user, prev) {
+			return true
+		}
+	}
+	return false
+}
+
+// keptApart reports whether a constraint keeps a and b off adjacent days.
+func keptApart(constraints []Constraint, a, b *store.User) bool {
+	for _, c := range constraints {
+		if c.Apart(a, b) {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckConstraints reports why the scheduler's constraints can't always be
+// met by the active users.
+func (s *Scheduler) CheckConstraints(ctx context.Context) error {
+	users, err := s.store.ListActiveUsers(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list active users: %w", err)
+	}
+	return CheckConstraints(s.Constraints, users)
+}
+
+// filterConstrained removes the users the constraints keep off day, given
+// the duties the day before and the assigned duty the day after. Planned
+// duties after day don't count, they are planned again once day is.
+func (s *Scheduler) filterConstrained(ctx context.Context, st Store, day time.Time, users []*store.User) []*store.User {
+	if len(s.Constraints) == 0 || len(users) == 0 {
+		return users
+	}
+	all, err := st.ListAllUsers(ctx)
+	if err != nil {
+		log.Printf("[SCHEDULER] Failed to list users for the constraints: %v", err)
+		return users
+	}
+	byID := make(map[int64]*store.User, len(all))
+	for _, u := range all {
+		byID[u.ID] = u
+	}
+	var neighbours []*store.User
+	for _, d := range []time.Time{day.AddDate(0, 0, -1), day.AddDate(0, 0, 1)} {
+		duty, err := st.GetDutyByDate(ctx, d)
+		if err != nil {
+			log.Printf("[SCHEDULER] Failed to get the duty on %s for the constraints: %v", d.Format("2006-01-02"), err)
+			continue
+		}
+		if duty == nil || (d.After(day) && duty.Status == store.DutyStatusProvisional) || byID[duty.UserID] == nil {
+			continue
+		}
+		neighbours = append(neighbours, byID[duty.UserID])
+	}
+
+	var kept []*store.User
+	for _, u := range users {
+		// The passed users may lack the handle, e.g. from a join
+		full := byID[u.ID]
+		if full == nil {
+			full = u
+		}
+		ok := allowed(s.Constraints, day.Weekday(), full)
+		for _, n := range neighbours {
+			ok = ok && !keptApart(s.Constraints, full, n)
+		}
+		if ok {
+			kept = append(kept, u)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,122 @@
+package scheduler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/korjavin/dutyassistant/internal/store"
+	"github.com/korjavin/dutyassistant/internal/store/memory"
+)
+
+func TestParseConstraints(t *testing.T) {
+	constraints, err := ParseConstraints("apart alice bob; Adult weekend;; adult mon,fri")
+	if err != nil {
+		t.Fatalf("ParseConstraints failed: %v", err)
+	}
+	var got []string
+	for _, c := range constraints {
+		got = append(got, c.String())
+	}
+	want := []string{"apart alice bob", "adult sat,sun", "adult mon,fri"}
+	if len(got) != len(want) {
+		t.Fatalf("Expected %v, got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Constraint %d: expected %q, got %q", i, want[i], got[i])
+		}
+	}
+
+	for _, value := range []string{"apart alice", "apart alice alice", "adult", "adult someday", "adult sa", "never alice"} {
+		if _, err := ParseConstraints(value); err == nil {
+			t.Errorf("ParseConstraints(%q) should fail", value)
+		}
+	}
+}
+
+func TestCheckConstraints(t *testing.T) {
+	alice := &store.User{ID: 1, Handle: "alice"}
+	bob := &store.User{ID: 2, Handle: "bob"}
+	kid := &store.User{ID: 3, Handle: "kid", IsJunior: true}
+	constraints, _ := ParseConstraints("apart alice bob; adult weekend")
+	weekend := constraints[1:]
+
+	tests := []struct {
+		name        string
+		constraints []Constraint
+		users       []*store.User
+		ok          bool
+	}{
+		{"the kid fills in between", constraints, []*store.User{alice, bob, kid}, true},
+		// One of them can take several days in a row
+		{"only the pair", constraints, []*store.User{alice, bob}, true},
+		{"no adult for weekends", weekend, []*store.User{kid}, false},
+		{"unknown user", constraints, []*store.User{alice, kid}, false},
+		{"no constraints", nil, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckConstraints(tt.constraints, tt.users)
+			if (err == nil) != tt.ok {
+				t.Errorf("Expected ok=%v, got %v", tt.ok, err)
+			}
+		})
+	}
+}
+
+func TestScheduler_Constraints(t *testing.T) {
+	ctx := context.Background()
+	s := memory.New()
+	var users []*store.User
+	for _, u := range []*store.User{
+		{TelegramUserID: 1, FirstName: "Alice", IsActive: true},
+		{TelegramUserID: 2, FirstName: "Bob", IsActive: true},
+		{TelegramUserID: 3, FirstName: "Kid", IsActive: true, IsJunior: true},
+	} {
+		if err := s.CreateUser(ctx, u); err != nil {
+			t.Fatalf("Failed to create user: %v", err)
+		}
+		users = append(users, u)
+	}
+	alice, bob, kid := users[0], users[1], users[2]
+	sched := NewScheduler(s)
+	berlin, _ := time.LoadLocation("Europe/Berlin")
+	monday := time.Date(2025, 11, 3, 0, 0, 0, 0, time.UTC)
+	sched.now = func() time.Time { return time.Date(2025, 11, 3, 8, 0, 0, 0, berlin) }
+	sched.Horizon = 20
+	sched.Constraints, _ = ParseConstraints("apart alice bob; adult weekend")
+	if err := sched.CheckConstraints(ctx); err != nil {
+		t.Fatalf("CheckConstraints failed: %v", err)
+	}
+
+	// Alice's queue days wait for days Bob isn't next to
+	if err := sched.AddToVolunteerQueue(ctx, alice.ID, 2); err != nil {
+		t.Fatalf("AddToVolunteerQueue failed: %v", err)
+	}
+	var prev *store.Duty
+	for day := monday; !day.After(monday.AddDate(0, 0, 20)); day = day.AddDate(0, 0, 1) {
+		duty, _ := s.GetDutyByDate(ctx, day)
+		if duty == nil {
+			t.Fatalf("Expected %s planned", day.Format("2006-01-02"))
+		}
+		if wd := day.Weekday(); (wd == time.Saturday || wd == time.Sunday) && duty.UserID == kid.ID {
+			t.Errorf("Expected an adult on %s", day.Format("Mon 2006-01-02"))
+		}
+		if prev != nil && prev.UserID+duty.UserID == alice.ID+bob.ID && prev.UserID != duty.UserID {
+			t.Errorf("Alice and Bob are on duty on adjacent days %s and %s", prev.DutyDate.Format("2006-01-02"), day.Format("2006-01-02"))
+		}
+		prev = duty
+	}
+
+	// With both adults away on Saturday the constraint gives way
+	saturday := monday.AddDate(0, 0, 5)
+	for _, u := range []*store.User{alice, bob} {
+		if err := sched.SetOffDuty(ctx, u.ID, saturday, saturday); err != nil {
+			t.Fatalf("SetOffDuty failed: %v", err)
+		}
+	}
+	if duty, _ := s.GetDutyByDate(ctx, saturday); duty == nil || duty.UserID != kid.ID {
+		t.Errorf("Expected the kid on Saturday after all, got %+v", duty)
+	}
+}
@@ -0,0 +1,189 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/korjavin/dutyassistant/internal/store"
+)
+
+// Why a user couldn't be picked by the daily assignment, in the order they
+// are checked.
+const (
+	ExcludedInactive    = "inactive"      // Deactivated or waiting for approval
+	ExcludedOffDuty     = "off_duty"      // Off duty that day
+	ExcludedReturning   = "returning"     // Back from off duty within the grace days
+	ExcludedSeason      = "season"        // Not on the season's roster
+	ExcludedPool        = "pool"          // Their pool doesn't cover the day
+	ExcludedConstrained = "constrained"   // Kept off the day by a constraint
+	ExcludedNoQueue     = "no_queue_days" // Others had queue days for the day
+)
+
+// explain returns why the daily assignment picked user for day, with
+// candidates the users choose picked from. It is called before the pick is
+// stored, so the queues and fairness counts are those the pick was made
+// with.
+func (s *Scheduler) explain(ctx context.Context, day time.Time, user *store.User, assignType store.AssignmentType, candidates []*store.User) (*store.AssignmentExplanation, error) {
+	users, err := s.store.ListAllUsers(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list users: %w", err)
+	}
+	lookback := 14
+	if w, ok := liveStrategy.(window); ok {
+		lookback = int(w)
+	}
+	duties, err := s.fairness(s.store, day).GetCompletedDutiesInRange(ctx, day.AddDate(0, 0, -lookback-maxShield), day)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get completed duties: %w", err)
+	}
+	// Like the strategy, over each user's last days on duty
+	starts := make(map[int64]time.Time, len(users))
+	for _, u := range users {
+		off, err := loadOffDuty(ctx, s.store, u)
+		if err != nil {
+			return nil, err
+		}
+		starts[u.ID], _ = onDutyWindow(off, day, lookback)
+	}
+	counts := make(map[int64]int)
+	lastDuty := make(map[int64]time.Time)
+	for _, d := range duties {
+		if d.AssignmentType == store.AssignmentTypeAdmin || d.AssignmentType == store.AssignmentTypeExternal {
+			continue
+		}
+		if start, ok := starts[d.UserID]; !ok || d.DutyDate.Before(start) {
+			continue
+		}
+		counts[d.UserID]++
+		if d.DutyDate.After(lastDuty[d.UserID]) {
+			lastDuty[d.UserID] = d.DutyDate
+		}
+	}
+	pickedFrom := make(map[int64]bool, len(candidates))
+	for _, c := range candidates {
+		pickedFrom[c.ID] = true
+	}
+
+	e := &store.AssignmentExplanation{
+		Date:           day,
+		UserID:         user.ID,
+		AssignmentType: assignType,
+		Strategy:       liveStrategy.Name(),
+		CreatedAt:      s.now().UTC(),
+	}
+	for _, u := range users {
+		c := store.AssignmentCandidate{
+			UserID:             u.ID,
+			VolunteerQueueDays: u.VolunteerQueueDays,
+			AdminQueueDays:     u.AdminQueueDays,
+			RecentDuties:       counts[u.ID],
+		}
+		if last, ok := lastDuty[u.ID]; ok {
+			c.LastDuty = &last
+		}
+		if !pickedFrom[u.ID] {
+			if c.Excluded, err = s.excluded(ctx, day, u); err != nil {
+				return nil, err
+			}
+		}
+		e.Candidates = append(e.Candidates, c)
+	}
+	e.Reason = reason(user, assignType, candidates, counts, lookback)
+	return e, nil
+}
+
+// excluded returns why user wasn't among the users the daily assignment
+// picked from on day.
+func (s *Scheduler) excluded(ctx context.Context, day time.Time, user *store.User) (string, error) {
+	if !user.IsActive || user.IsPending {
+		return ExcludedInactive, nil
+	}
+	off, err := s.store.IsUserOffDuty(ctx, user.ID, day)
+	if err != nil {
+		return "", fmt.Errorf("failed to check off-duty status: %w", err)
+	}
+	if off {
+		return ExcludedOffDuty, nil
+	}
+	returning, err := s.returning(ctx, user, day)
+	if err != nil {
+		return "", err
+	}
+	if returning {
+		return ExcludedReturning, nil
+	}
+	if len(s.filterSeason([]*store.User{user}, day)) == 0 {
+		return ExcludedSeason, nil
+	}
+	if !user.Pool.Covers(day) {
+		return ExcludedPool, nil
+	}
+	if len(s.filterConstrained(ctx, s.store, day, []*store.User{user})) == 0 {
+		return ExcludedConstrained, nil
+	}
+	return ExcludedNoQueue, nil
+}
+
+// reason tells in words why user was picked from candidates. It leaves out
+// names, which may be hidden from whoever asks.
+func reason(user *store.User, assignType store.AssignmentType, candidates []*store.User, counts map[int64]int, lookback int) string {
+	if len(candidates) == 1 {
+		switch assignType {
+		case store.AssignmentTypeVoluntary:
+			return "The only available member with volunteer queue days."
+		case store.AssignmentTypeAdmin:
+			return "The only available member with admin queue days."
+		}
+		return "The only available member."
+	}
+
+	tied := candidates
+	var r string
+	switch assignType {
+	case store.AssignmentTypeVoluntary, store.AssignmentTypeAdmin:
+		// Like selectUserWithBalancing, by the larger of the two queues
+		queued := func(u *store.User) int { return max(u.VolunteerQueueDays, u.AdminQueueDays) }
+		tied = nil
+		for _, c := range candidates {
+			if queued(c) == queued(user) {
+				tied = append(tied, c)
+			}
+		}
+		kind := "volunteer"
+		if assignType == store.AssignmentTypeAdmin {
+			kind = "admin"
+		}
+		r = fmt.Sprintf("Had the most queue days (%d) of %d available members with %s queue days", queued(user), len(candidates), kind)
+		if len(tied) == 1 {
+			return r + "."
+		}
+		r += fmt.Sprintf(", tied with %d", len(tied)-1)
+		r += fmt.Sprintf("; of those, had the fewest duties in the last %d days on duty (%d)", lookback, counts[user.ID])
+	default:
+		r = fmt.Sprintf("Had the fewest duties in the last %d days on duty (%d) of %d available members", lookback, counts[user.ID], len(candidates))
+	}
+
+	same := 0
+	for _, c := range tied {
+		if c.ID != user.ID && counts[c.ID] == counts[user.ID] {
+			same++
+		}
+	}
+	if same == 0 {
+		return r + "."
+	}
+	return r + fmt.Sprintf(", tied with %d, and was next in the rotation.", same)
+}
+
+// recordExplanation stores e once its pick is assigned. A failure only costs
+// the explanation, never the assignment.
+func (s *Scheduler) recordExplanation(ctx context.Context, e *store.AssignmentExplanation) {
+	if e == nil {
+		return
+	}
+	if err := s.store.SaveAssignmentExplanation(ctx, e); err != nil {
+		log.Printf("[SCHEDULER] Failed to record why %s was assigned: %v", e.Date.Format("2006-01-02"), err)
+	}
+}
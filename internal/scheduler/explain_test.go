@@ -0,0 +1,86 @@
+package scheduler
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/korjavin/dutyassistant/internal/store"
+)
+
+func TestAssignTodaysDuty_Explanation(t *testing.T) {
+	sched, s, users := newTestScheduler(t)
+	ctx := context.Background()
+	alice, bob, charlie := users[0], users[1], users[2]
+	dave := &store.User{TelegramUserID: 4, FirstName: "Dave", IsActive: true}
+	if err := s.CreateUser(ctx, dave); err != nil {
+		t.Fatal(err)
+	}
+	day := today()
+	if err := s.SetOffDuty(ctx, dave.ID, day, day); err != nil {
+		t.Fatal(err)
+	}
+	// Alice did the dishes yesterday
+	yesterday := &store.Duty{UserID: alice.ID, DutyDate: day.AddDate(0, 0, -1), AssignmentType: store.AssignmentTypeRoundRobin}
+	if err := s.CreateDuty(ctx, yesterday); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.CompleteDuty(ctx, yesterday.DutyDate); err != nil {
+		t.Fatal(err)
+	}
+
+	duty, err := sched.AssignTodaysDuty(ctx, true)
+	if err != nil {
+		t.Fatalf("AssignTodaysDuty failed: %v", err)
+	}
+	e, err := s.GetAssignmentExplanation(ctx, day)
+	if err != nil || e == nil {
+		t.Fatalf("GetAssignmentExplanation = %+v, %v, want the explanation", e, err)
+	}
+	if e.UserID != duty.UserID || e.UserID != bob.ID || e.AssignmentType != store.AssignmentTypeRoundRobin || e.Strategy != liveStrategy.Name() {
+		t.Errorf("Explanation = %+v, want Bob picked by round-robin", e)
+	}
+	if !strings.Contains(e.Reason, "fewest duties in the last 14 days on duty (0) of 2 available members") {
+		t.Errorf("Reason = %q, want the fewest duties", e.Reason)
+	}
+
+	excluded := make(map[int64]string)
+	recent := make(map[int64]int)
+	for _, c := range e.Candidates {
+		excluded[c.UserID] = c.Excluded
+		recent[c.UserID] = c.RecentDuties
+	}
+	want := map[int64]string{alice.ID: "", bob.ID: "", charlie.ID: ExcludedInactive, dave.ID: ExcludedOffDuty}
+	for id, reason := range want {
+		if got, ok := excluded[id]; !ok || got != reason {
+			t.Errorf("Candidate %d excluded for %q, want %q", id, got, reason)
+		}
+	}
+	if recent[alice.ID] != 1 || recent[bob.ID] != 0 {
+		t.Errorf("Recent duties = %v, want 1 for Alice", recent)
+	}
+}
+
+func TestReason(t *testing.T) {
+	alice := &store.User{ID: 1, VolunteerQueueDays: 2}
+	bob := &store.User{ID: 2, VolunteerQueueDays: 2}
+	charlie := &store.User{ID: 3, VolunteerQueueDays: 1}
+	counts := map[int64]int{1: 1, 2: 1}
+
+	tests := []struct {
+		assignType store.AssignmentType
+		candidates []*store.User
+		want       string
+	}{
+		{store.AssignmentTypeVoluntary, []*store.User{alice}, "The only available member with volunteer queue days."},
+		{store.AssignmentTypeVoluntary, []*store.User{alice, charlie}, "Had the most queue days (2) of 2 available members with volunteer queue days."},
+		{store.AssignmentTypeAdmin, []*store.User{alice, bob, charlie},
+			"Had the most queue days (2) of 3 available members with admin queue days, tied with 1; of those, had the fewest duties in the last 14 days on duty (1), tied with 1, and was next in the rotation."},
+		{store.AssignmentTypeRoundRobin, []*store.User{alice, charlie}, "Had the fewest duties in the last 14 days on duty (1) of 2 available members."},
+	}
+	for _, tt := range tests {
+		if got := reason(alice, tt.assignType, tt.candidates, counts, 14); got != tt.want {
+			t.Errorf("reason(%s, %d candidates) = %q, want %q", tt.assignType, len(tt.candidates), got, tt.want)
+		}
+	}
+}
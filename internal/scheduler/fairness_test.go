@@ -0,0 +1,251 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"reflect"
+	"testing"
+	"testing/quick"
+	"time"
+
+	"github.com/korjavin/dutyassistant/internal/store"
+	"github.com/korjavin/dutyassistant/internal/store/memory"
+)
+
+// fairnessWindow mirrors the look-back period used by selectRoundRobinUser.
+const fairnessWindow = 14
+
+// event is something that happens on a simulated day before the 11:00 assignment.
+type event struct {
+	Day    int
+	User   int // index into scenario users
+	Kind   string
+	Amount int // queue days, or off-duty length
+}
+
+// scenario is a randomly generated household and history of queue and off-duty requests.
+type scenario struct {
+	Active []bool
+	Days   int
+	Events []event
+}
+
+// Generate implements quick.Generator.
+func (scenario) Generate(r *rand.Rand, size int) reflect.Value {
+	sc := scenario{Days: 1 + r.Intn(60)}
+	for i := 0; i < 2+r.Intn(5); i++ {
+		sc.Active = append(sc.Active, r.Intn(5) > 0)
+	}
+	kinds := []string{"volunteer", "admin", "offduty"}
+	for i := 0; i < r.Intn(size+1); i++ {
+		sc.Events = append(sc.Events, event{
+			Day:    r.Intn(sc.Days),
+			User:   r.Intn(len(sc.Active)),
+			Kind:   kinds[r.Intn(len(kinds))],
+			Amount: 1 + r.Intn(5),
+		})
+	}
+	return reflect.ValueOf(sc)
+}
+
+// simulation drives a scheduler day by day with a fake clock.
+type simulation struct {
+	sched *Scheduler
+	store *memory.Store
+	users []*store.User
+	start time.Time
+	day   int
+}
+
+func newSimulation(t *testing.T, active []bool) *simulation {
+	t.Helper()
+	ctx := context.Background()
+	sim := &simulation{store: memory.New(), start: time.Date(2025, 1, 6, 0, 0, 0, 0, time.UTC)}
+	for i, isActive := range active {
+		u := &store.User{TelegramUserID: int64(i + 1), FirstName: fmt.Sprintf("user%d", i), IsActive: isActive}
+		if err := sim.store.CreateUser(ctx, u); err != nil {
+			t.Fatalf("Failed to create user: %v", err)
+		}
+		sim.users = append(sim.users, u)
+	}
+	sim.sched = NewScheduler(sim.store)
+	sim.sched.now = func() time.Time { return sim.today().Add(12 * time.Hour) }
+	return sim
+}
+
+func (sim *simulation) today() time.Time {
+	return sim.start.AddDate(0, 0, sim.day)
+}
+
+// available returns the current state of active users who are not off-duty today.
+func (sim *simulation) available(ctx context.Context) []*store.User {
+	users, _ := sim.store.ListActiveUsers(ctx)
+	var available []*store.User
+	for _, u := range users {
+		if off, _ := sim.store.IsUserOffDuty(ctx, u.ID, sim.today()); !off {
+			available = append(available, u)
+		}
+	}
+	return available
+}
+
+// windowCounts counts completed non-admin duties per user in the fairness
+// window before end, and the days in it they weren't off duty. Off-duty days
+// don't count, so a user's window reaches back further by as many.
+func (sim *simulation) windowCounts(ctx context.Context, end time.Time) (counts, onDuty map[int64]int) {
+	users, _ := sim.store.ListActiveUsers(ctx)
+	starts := make(map[int64]time.Time)
+	onDuty = make(map[int64]int)
+	for _, u := range users {
+		for back := 1; back <= fairnessWindow+90 && onDuty[u.ID] < fairnessWindow; back++ {
+			starts[u.ID] = end.AddDate(0, 0, -back)
+			if off, _ := sim.store.IsUserOffDuty(ctx, u.ID, starts[u.ID]); !off {
+				onDuty[u.ID]++
+			}
+		}
+	}
+	duties, _ := sim.store.GetCompletedDutiesInRange(ctx, end.AddDate(0, 0, -fairnessWindow-90), end)
+	counts = make(map[int64]int)
+	for _, d := range duties {
+		if start, ok := starts[d.UserID]; ok && !d.DutyDate.Before(start) && d.AssignmentType != store.AssignmentTypeAdmin {
+			counts[d.UserID]++
+		}
+	}
+	return counts, onDuty
+}
+
+// checkDay runs one assignment and returns a description of the first violated invariant.
+func (sim *simulation) checkDay(ctx context.Context) string {
+	available := sim.available(ctx)
+	counts, onDuty := sim.windowCounts(ctx, sim.today())
+
+	expected := store.AssignmentTypeRoundRobin
+	for _, u := range available {
+		if u.AdminQueueDays > 0 {
+			expected = store.AssignmentTypeAdmin
+		}
+	}
+	for _, u := range available {
+		if u.VolunteerQueueDays > 0 {
+			expected = store.AssignmentTypeVoluntary
+		}
+	}
+
+	duty, err := sim.sched.AssignTodaysDuty(ctx, false)
+	if len(available) == 0 {
+		if err == nil {
+			return "assigned a duty although nobody was available"
+		}
+		return ""
+	}
+	if err != nil {
+		return fmt.Sprintf("assignment failed: %v", err)
+	}
+
+	var assignee *store.User
+	for _, u := range available {
+		if u.ID == duty.UserID {
+			assignee = u
+		}
+	}
+	if assignee == nil {
+		return fmt.Sprintf("assigned user %d who is inactive or off-duty", duty.UserID)
+	}
+	if duty.AssignmentType != expected {
+		return fmt.Sprintf("assigned %s duty while %s queue had priority", duty.AssignmentType, expected)
+	}
+	switch expected {
+	case store.AssignmentTypeVoluntary:
+		if assignee.VolunteerQueueDays == 0 {
+			return "voluntary duty given to a user with an empty volunteer queue"
+		}
+	case store.AssignmentTypeAdmin:
+		if assignee.AdminQueueDays == 0 {
+			return "admin duty given to a user with an empty admin queue"
+		}
+	case store.AssignmentTypeRoundRobin:
+		for _, u := range available {
+			if counts[u.ID]*max(onDuty[assignee.ID], 1) < counts[assignee.ID]*max(onDuty[u.ID], 1) {
+				return fmt.Sprintf("round-robin picked %s with %d duties in %d days on duty over %s with %d in %d",
+					assignee.FirstName, counts[assignee.ID], onDuty[assignee.ID], u.FirstName, counts[u.ID], onDuty[u.ID])
+			}
+		}
+	}
+
+	if err := sim.sched.CompleteTodaysDuty(ctx); err != nil {
+		return fmt.Sprintf("completion failed: %v", err)
+	}
+	return ""
+}
+
+func (sim *simulation) apply(ctx context.Context, e event) {
+	user := sim.users[e.User]
+	switch e.Kind {
+	case "volunteer":
+		sim.sched.VolunteerForDuty(ctx, user, e.Amount)
+	case "admin":
+		sim.sched.AssignDuty(ctx, user, e.Amount)
+	case "offduty":
+		sim.sched.SetOffDuty(ctx, user.ID, sim.today(), sim.today().AddDate(0, 0, e.Amount-1))
+	}
+}
+
+func TestFairness_AssignmentInvariants(t *testing.T) {
+	property := func(sc scenario) bool {
+		ctx := context.Background()
+		sim := newSimulation(t, sc.Active)
+		for sim.day = 0; sim.day < sc.Days; sim.day++ {
+			for _, e := range sc.Events {
+				if e.Day == sim.day {
+					sim.apply(ctx, e)
+				}
+			}
+			if violation := sim.checkDay(ctx); violation != "" {
+				t.Logf("Day %d: %s", sim.day, violation)
+				return false
+			}
+		}
+		return true
+	}
+
+	if err := quick.Check(property, &quick.Config{MaxCount: 200}); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestFairness_RoundRobinSpread(t *testing.T) {
+	// Without queues or absences nothing stops the schedule from being
+	// perfectly even, so every user's count in the window stays within one.
+	property := func(sc scenario) bool {
+		ctx := context.Background()
+		sim := newSimulation(t, sc.Active)
+		for sim.day = 0; sim.day < sc.Days; sim.day++ {
+			if violation := sim.checkDay(ctx); violation != "" {
+				t.Logf("Day %d: %s", sim.day, violation)
+				return false
+			}
+
+			counts, _ := sim.windowCounts(ctx, sim.today().AddDate(0, 0, 1))
+			minCount, maxCount := -1, 0
+			for _, u := range sim.available(ctx) {
+				c := counts[u.ID]
+				if minCount < 0 || c < minCount {
+					minCount = c
+				}
+				if c > maxCount {
+					maxCount = c
+				}
+			}
+			if minCount >= 0 && maxCount-minCount > 1 {
+				t.Logf("Day %d: duty spread %d-%d exceeds one", sim.day, minCount, maxCount)
+				return false
+			}
+		}
+		return true
+	}
+
+	if err := quick.Check(property, &quick.Config{MaxCount: 200}); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,352 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/korjavin/dutyassistant/internal/scheduler (interfaces: SchedulerInterface)
+//
+// Generated by this command:
+//
+//	mockgen -destination=mocks/scheduler.go -package=mocks . SchedulerInterface
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	scheduler "github.com/korjavin/dutyassistant/internal/scheduler"
+	store "github.com/korjavin/dutyassistant/internal/store"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockSchedulerInterface is a mock of SchedulerInterface interface.
+type MockSchedulerInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockSchedulerInterfaceMockRecorder
+	isgomock struct{}
+}
+
+// MockSchedulerInterfaceMockRecorder is the mock recorder for MockSchedulerInterface.
+type MockSchedulerInterfaceMockRecorder struct {
+	mock *MockSchedulerInterface
+}
+
+// NewMockSchedulerInterface creates a new mock instance.
+func NewMockSchedulerInterface(ctrl *gomock.Controller) *MockSchedulerInterface {
+	mock := &MockSchedulerInterface{ctrl: ctrl}
+	mock.recorder = &MockSchedulerInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSchedulerInterface) EXPECT() *MockSchedulerInterfaceMockRecorder {
+	return m.recorder
+}
+
+// AcknowledgeDuty mocks base method.
+func (m *MockSchedulerInterface) AcknowledgeDuty(ctx context.Context, date time.Time, userID int64) (*store.Duty, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AcknowledgeDuty", ctx, date, userID)
+	ret0, _ := ret[0].(*store.Duty)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AcknowledgeDuty indicates an expected call of AcknowledgeDuty.
+func (mr *MockSchedulerInterfaceMockRecorder) AcknowledgeDuty(ctx, date, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AcknowledgeDuty", reflect.TypeOf((*MockSchedulerInterface)(nil).AcknowledgeDuty), ctx, date, userID)
+}
+
+// AddBlackout mocks base method.
+func (m *MockSchedulerInterface) AddBlackout(ctx context.Context, rule, description string) (*store.BlackoutRule, []*store.Duty, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddBlackout", ctx, rule, description)
+	ret0, _ := ret[0].(*store.BlackoutRule)
+	ret1, _ := ret[1].([]*store.Duty)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// AddBlackout indicates an expected call of AddBlackout.
+func (mr *MockSchedulerInterfaceMockRecorder) AddBlackout(ctx, rule, description any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddBlackout", reflect.TypeOf((*MockSchedulerInterface)(nil).AddBlackout), ctx, rule, description)
+}
+
+// AddOffDutyPeriods mocks base method.
+func (m *MockSchedulerInterface) AddOffDutyPeriods(ctx context.Context, userID int64, periods []*store.OffDutyPeriod) ([]*store.OffDutyPeriod, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddOffDutyPeriods", ctx, userID, periods)
+	ret0, _ := ret[0].([]*store.OffDutyPeriod)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddOffDutyPeriods indicates an expected call of AddOffDutyPeriods.
+func (mr *MockSchedulerInterfaceMockRecorder) AddOffDutyPeriods(ctx, userID, periods any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddOffDutyPeriods", reflect.TypeOf((*MockSchedulerInterface)(nil).AddOffDutyPeriods), ctx, userID, periods)
+}
+
+// AssignDuty mocks base method.
+func (m *MockSchedulerInterface) AssignDuty(ctx context.Context, user *store.User, days int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AssignDuty", ctx, user, days)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AssignDuty indicates an expected call of AssignDuty.
+func (mr *MockSchedulerInterfaceMockRecorder) AssignDuty(ctx, user, days any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AssignDuty", reflect.TypeOf((*MockSchedulerInterface)(nil).AssignDuty), ctx, user, days)
+}
+
+// AssignDutyTo mocks base method.
+func (m *MockSchedulerInterface) AssignDutyTo(ctx context.Context, date time.Time, userID int64, assignType store.AssignmentType) (*store.Duty, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AssignDutyTo", ctx, date, userID, assignType)
+	ret0, _ := ret[0].(*store.Duty)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AssignDutyTo indicates an expected call of AssignDutyTo.
+func (mr *MockSchedulerInterfaceMockRecorder) AssignDutyTo(ctx, date, userID, assignType any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AssignDutyTo", reflect.TypeOf((*MockSchedulerInterface)(nil).AssignDutyTo), ctx, date, userID, assignType)
+}
+
+// AssignHeldDuty mocks base method.
+func (m *MockSchedulerInterface) AssignHeldDuty(ctx context.Context, date time.Time, userID int64, until time.Time) (*store.Duty, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AssignHeldDuty", ctx, date, userID, until)
+	ret0, _ := ret[0].(*store.Duty)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AssignHeldDuty indicates an expected call of AssignHeldDuty.
+func (mr *MockSchedulerInterfaceMockRecorder) AssignHeldDuty(ctx, date, userID, until any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AssignHeldDuty", reflect.TypeOf((*MockSchedulerInterface)(nil).AssignHeldDuty), ctx, date, userID, until)
+}
+
+// AutoAssignDuty mocks base method.
+func (m *MockSchedulerInterface) AutoAssignDuty(ctx context.Context, date time.Time) (*store.Duty, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AutoAssignDuty", ctx, date)
+	ret0, _ := ret[0].(*store.Duty)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AutoAssignDuty indicates an expected call of AutoAssignDuty.
+func (mr *MockSchedulerInterfaceMockRecorder) AutoAssignDuty(ctx, date any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AutoAssignDuty", reflect.TypeOf((*MockSchedulerInterface)(nil).AutoAssignDuty), ctx, date)
+}
+
+// BackfillDuty mocks base method.
+func (m *MockSchedulerInterface) BackfillDuty(ctx context.Context, date time.Time, userID int64) (*store.Duty, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BackfillDuty", ctx, date, userID)
+	ret0, _ := ret[0].(*store.Duty)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BackfillDuty indicates an expected call of BackfillDuty.
+func (mr *MockSchedulerInterfaceMockRecorder) BackfillDuty(ctx, date, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BackfillDuty", reflect.TypeOf((*MockSchedulerInterface)(nil).BackfillDuty), ctx, date, userID)
+}
+
+// ChangeDutyUser mocks base method.
+func (m *MockSchedulerInterface) ChangeDutyUser(ctx context.Context, date time.Time, newUserID int64, mode scheduler.ChangeMode) (*store.Duty, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChangeDutyUser", ctx, date, newUserID, mode)
+	ret0, _ := ret[0].(*store.Duty)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ChangeDutyUser indicates an expected call of ChangeDutyUser.
+func (mr *MockSchedulerInterfaceMockRecorder) ChangeDutyUser(ctx, date, newUserID, mode any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChangeDutyUser", reflect.TypeOf((*MockSchedulerInterface)(nil).ChangeDutyUser), ctx, date, newUserID, mode)
+}
+
+// DraftMonth mocks base method.
+func (m *MockSchedulerInterface) DraftMonth(ctx context.Context, month time.Time) ([]*store.Duty, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DraftMonth", ctx, month)
+	ret0, _ := ret[0].([]*store.Duty)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DraftMonth indicates an expected call of DraftMonth.
+func (mr *MockSchedulerInterfaceMockRecorder) DraftMonth(ctx, month any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DraftMonth", reflect.TypeOf((*MockSchedulerInterface)(nil).DraftMonth), ctx, month)
+}
+
+// Forecast mocks base method.
+func (m *MockSchedulerInterface) Forecast(ctx context.Context, days int) ([]*store.Duty, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Forecast", ctx, days)
+	ret0, _ := ret[0].([]*store.Duty)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Forecast indicates an expected call of Forecast.
+func (mr *MockSchedulerInterfaceMockRecorder) Forecast(ctx, days any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Forecast", reflect.TypeOf((*MockSchedulerInterface)(nil).Forecast), ctx, days)
+}
+
+// HoldDuty mocks base method.
+func (m *MockSchedulerInterface) HoldDuty(ctx context.Context, date time.Time, until *time.Time) (*store.Duty, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "HoldDuty", ctx, date, until)
+	ret0, _ := ret[0].(*store.Duty)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// HoldDuty indicates an expected call of HoldDuty.
+func (mr *MockSchedulerInterfaceMockRecorder) HoldDuty(ctx, date, until any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HoldDuty", reflect.TypeOf((*MockSchedulerInterface)(nil).HoldDuty), ctx, date, until)
+}
+
+// PublishMonth mocks base method.
+func (m *MockSchedulerInterface) PublishMonth(ctx context.Context, month time.Time) ([]*store.Duty, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PublishMonth", ctx, month)
+	ret0, _ := ret[0].([]*store.Duty)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PublishMonth indicates an expected call of PublishMonth.
+func (mr *MockSchedulerInterfaceMockRecorder) PublishMonth(ctx, month any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PublishMonth", reflect.TypeOf((*MockSchedulerInterface)(nil).PublishMonth), ctx, month)
+}
+
+// RemoveBlackout mocks base method.
+func (m *MockSchedulerInterface) RemoveBlackout(ctx context.Context, id int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveBlackout", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RemoveBlackout indicates an expected call of RemoveBlackout.
+func (mr *MockSchedulerInterfaceMockRecorder) RemoveBlackout(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveBlackout", reflect.TypeOf((*MockSchedulerInterface)(nil).RemoveBlackout), ctx, id)
+}
+
+// RemoveDuty mocks base method.
+func (m *MockSchedulerInterface) RemoveDuty(ctx context.Context, date time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveDuty", ctx, date)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RemoveDuty indicates an expected call of RemoveDuty.
+func (mr *MockSchedulerInterfaceMockRecorder) RemoveDuty(ctx, date any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveDuty", reflect.TypeOf((*MockSchedulerInterface)(nil).RemoveDuty), ctx, date)
+}
+
+// RunInTx mocks base method.
+func (m *MockSchedulerInterface) RunInTx(ctx context.Context, fn func(scheduler.SchedulerInterface) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RunInTx", ctx, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RunInTx indicates an expected call of RunInTx.
+func (mr *MockSchedulerInterfaceMockRecorder) RunInTx(ctx, fn any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RunInTx", reflect.TypeOf((*MockSchedulerInterface)(nil).RunInTx), ctx, fn)
+}
+
+// SetDutyCompletion mocks base method.
+func (m *MockSchedulerInterface) SetDutyCompletion(ctx context.Context, date time.Time, completed bool, actorID int64) (*store.Duty, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetDutyCompletion", ctx, date, completed, actorID)
+	ret0, _ := ret[0].(*store.Duty)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetDutyCompletion indicates an expected call of SetDutyCompletion.
+func (mr *MockSchedulerInterfaceMockRecorder) SetDutyCompletion(ctx, date, completed, actorID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetDutyCompletion", reflect.TypeOf((*MockSchedulerInterface)(nil).SetDutyCompletion), ctx, date, completed, actorID)
+}
+
+// SetOffDuty mocks base method.
+func (m *MockSchedulerInterface) SetOffDuty(ctx context.Context, userID int64, start, end time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetOffDuty", ctx, userID, start, end)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetOffDuty indicates an expected call of SetOffDuty.
+func (mr *MockSchedulerInterfaceMockRecorder) SetOffDuty(ctx, userID, start, end any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetOffDuty", reflect.TypeOf((*MockSchedulerInterface)(nil).SetOffDuty), ctx, userID, start, end)
+}
+
+// SkipDay mocks base method.
+func (m *MockSchedulerInterface) SkipDay(ctx context.Context, date time.Time, reason store.SkipReason) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SkipDay", ctx, date, reason)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SkipDay indicates an expected call of SkipDay.
+func (mr *MockSchedulerInterfaceMockRecorder) SkipDay(ctx, date, reason any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SkipDay", reflect.TypeOf((*MockSchedulerInterface)(nil).SkipDay), ctx, date, reason)
+}
+
+// UnskipDay mocks base method.
+func (m *MockSchedulerInterface) UnskipDay(ctx context.Context, date time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UnskipDay", ctx, date)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UnskipDay indicates an expected call of UnskipDay.
+func (mr *MockSchedulerInterfaceMockRecorder) UnskipDay(ctx, date any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UnskipDay", reflect.TypeOf((*MockSchedulerInterface)(nil).UnskipDay), ctx, date)
+}
+
+// VolunteerForDuty mocks base method.
+func (m *MockSchedulerInterface) VolunteerForDuty(ctx context.Context, user *store.User, days int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VolunteerForDuty", ctx, user, days)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// VolunteerForDuty indicates an expected call of VolunteerForDuty.
+func (mr *MockSchedulerInterfaceMockRecorder) VolunteerForDuty(ctx, user, days any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VolunteerForDuty", reflect.TypeOf((*MockSchedulerInterface)(nil).VolunteerForDuty), ctx, user, days)
+}
@@ -0,0 +1,364 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/korjavin/dutyassistant/internal/events"
+	"github.com/korjavin/dutyassistant/internal/store"
+)
+
+// PlanAhead assigns the days from today to Horizon days ahead provisionally,
+// the way the daily assignment would if nothing changed until then, so
+// members can plan. A month drafted with DraftMonth is kept planned to its
+// end. Only provisional duties are moved, since nobody was told about them
+// yet; duties with any other status and skip days are left alone.
+// Provisional duties are recomputed and only touched if the pick changed, and
+// the daily assignment announces the day's one. It does nothing if Horizon is
+// 0 and no month is drafted.
+func (s *Scheduler) PlanAhead(ctx context.Context) error {
+	now := s.now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	end, err := s.planEnd(ctx, today)
+	if err != nil {
+		return err
+	}
+	return s.planDays(ctx, today, end)
+}
+
+// planEnd returns the last day PlanAhead plans: Horizon days ahead, or the end
+// of the current or next month if it has provisional duties past that, i.e.
+// was drafted. It is before today if there is nothing to plan.
+func (s *Scheduler) planEnd(ctx context.Context, today time.Time) (time.Time, error) {
+	end := today.AddDate(0, 0, s.Horizon)
+	if s.Horizon <= 0 {
+		end = today.AddDate(0, 0, -1)
+	}
+	month := time.Date(today.Year(), today.Month(), 1, 0, 0, 0, 0, time.UTC)
+	for _, m := range []time.Time{month.AddDate(0, 1, 0), month} {
+		last := m.AddDate(0, 1, -1)
+		if !last.After(end) {
+			continue
+		}
+		duties, err := s.store.GetDutiesByMonth(ctx, m.Year(), m.Month())
+		if err != nil {
+			return time.Time{}, fmt.Errorf("failed to get duties of %s: %w", m.Format("2006-01"), err)
+		}
+		for _, d := range duties {
+			if d.Status == store.DutyStatusProvisional && d.DutyDate.After(end) {
+				return last, nil
+			}
+		}
+	}
+	return end, nil
+}
+
+// planDays assigns the days from today to end provisionally, see PlanAhead.
+func (s *Scheduler) planDays(ctx context.Context, today, end time.Time) error {
+	now := s.now()
+	return s.simulate(ctx, today, end, func(day time.Time, existing *store.Duty, user *store.User, assignType store.AssignmentType) error {
+		var err error
+		switch {
+		case user == nil && existing != nil:
+			err = s.store.DeleteDuty(ctx, day)
+		case user == nil:
+			return nil
+		case existing == nil:
+			err = s.store.CreateDuty(ctx, &store.Duty{
+				UserID: user.ID, DutyDate: day, AssignmentType: assignType, CreatedAt: now.UTC(), Status: store.DutyStatusProvisional,
+			})
+		case existing.UserID != user.ID || existing.AssignmentType != assignType:
+			existing.UserID, existing.AssignmentType, existing.User = user.ID, assignType, nil
+			err = s.store.UpdateDuty(ctx, existing)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to plan %s: %w", day.Format("2006-01-02"), err)
+		}
+		return nil
+	}, nil)
+}
+
+// Forecast predicts the duties from today to days ahead the way PlanAhead
+// would plan them, without changing anything, so it looks past Horizon too.
+// Duties assigned for real are returned as they are, the predicted ones as
+// provisional duties with their user. Days without a duty are left out.
+// Predictions are estimates: queues, availability and admins change them.
+func (s *Scheduler) Forecast(ctx context.Context, days int) ([]*store.Duty, error) {
+	now := s.now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	var forecast []*store.Duty
+	err := s.simulate(ctx, today, today.AddDate(0, 0, days), func(day time.Time, _ *store.Duty, user *store.User, assignType store.AssignmentType) error {
+		if user != nil {
+			forecast = append(forecast, &store.Duty{
+				UserID: user.ID, User: user, DutyDate: day, AssignmentType: assignType, Status: store.DutyStatusProvisional,
+			})
+		}
+		return nil
+	}, func(d *store.Duty) {
+		forecast = append(forecast, d)
+	})
+	return forecast, err
+}
+
+// simulate walks the days from today to end as the daily assignment would
+// see them, once the days before went as picked. Days with a duty that isn't
+// provisional keep it and are passed to fixed unless it is nil; for the
+// others pick is called with the provisional duty they have, if any, and whom
+// the daily assignment would choose, nil on skip days and days nobody is
+// available for.
+func (s *Scheduler) simulate(ctx context.Context, today, end time.Time, pick func(day time.Time, existing *store.Duty, user *store.User, assignType store.AssignmentType) error, fixed func(*store.Duty)) error {
+	p, err := newPlan(ctx, s.store)
+	if err != nil {
+		return err
+	}
+	for day := today; !day.After(end); day = day.AddDate(0, 0, 1) {
+		existing, err := s.store.GetDutyByDate(ctx, day)
+		if err != nil {
+			return fmt.Errorf("failed to get duty on %s: %w", day.Format("2006-01-02"), err)
+		}
+		// A published duty only changes by admin override, never by a plan
+		if existing != nil && (existing.Published || existing.Status != store.DutyStatusProvisional) {
+			if existing.CompletedAt == nil {
+				p.duties = append(p.duties, existing)
+				if fixed != nil {
+					fixed(existing)
+				}
+			}
+			continue
+		}
+
+		skip, err := s.skipDay(ctx, day)
+		if err != nil {
+			return fmt.Errorf("failed to check skip day: %w", err)
+		}
+		var user *store.User
+		var assignType store.AssignmentType
+		if skip == nil {
+			user, assignType, _, err = s.choose(ctx, p, day)
+			if err != nil && !errors.Is(err, ErrNoAvailableUsers) {
+				return err
+			}
+		}
+		if err := pick(day, existing, user, assignType); err != nil {
+			return err
+		}
+		if user != nil {
+			p.assign(user.ID, day, assignType, s.rotation(assignType, day))
+		}
+	}
+	return nil
+}
+
+// DraftMonth plans the month starting on month provisionally, along with the
+// days before it, so an admin can review the plan before publishing it with
+// PublishMonth. The draft follows changes to queues and availability like any
+// plan. Only the current and the next month can be drafted. It returns the
+// duties of the month.
+func (s *Scheduler) DraftMonth(ctx context.Context, month time.Time) ([]*store.Duty, error) {
+	now := s.now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	first, err := s.checkMonth(today, month)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.planDays(ctx, today, first.AddDate(0, 1, -1)); err != nil {
+		return nil, err
+	}
+	return s.store.GetDutiesByMonth(ctx, first.Year(), first.Month())
+}
+
+// PublishMonth publishes the drafted plan of the month starting on month:
+// its provisional duties from today on are assigned for real, and are
+// announced once with the whole month instead of day by day. From then on
+// they only change by admin override, like any announced duty. Queue days
+// are used up and the round-robin cursor moves as if the daily assignment
+// had picked them. It returns ErrNoDraft if the month has no draft left.
+// If anything fails, nothing is published.
+func (s *Scheduler) PublishMonth(ctx context.Context, month time.Time) ([]*store.Duty, error) {
+	now := s.now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	first, err := s.checkMonth(today, month)
+	if err != nil {
+		return nil, err
+	}
+
+	var published []*store.Duty
+	err = s.inTx(ctx, func(tx *Scheduler) error {
+		duties, err := tx.store.GetDutiesByMonth(ctx, first.Year(), first.Month())
+		if err != nil {
+			return fmt.Errorf("failed to get duties: %w", err)
+		}
+		for _, d := range duties {
+			if d.DutyDate.Before(today) || d.Published || d.Status != store.DutyStatusProvisional {
+				continue
+			}
+			d.Status = store.DutyStatusAnnounced
+			d.Published = true
+			if err := tx.store.UpdateDuty(ctx, d); err != nil {
+				return fmt.Errorf("failed to publish %s: %w", d.DutyDate.Format("2006-01-02"), err)
+			}
+			switch d.AssignmentType {
+			case store.AssignmentTypeVoluntary:
+				err = tx.store.DecrementVolunteerQueue(ctx, d.UserID)
+			case store.AssignmentTypeAdmin:
+				err = tx.store.DecrementAdminQueue(ctx, d.UserID)
+			case store.AssignmentTypeRoundRobin:
+				// Sorts after real picks like in a plan, so the cursor follows the month
+				if err := tx.store.RecordRoundRobinPick(ctx, tx.rotation(d.AssignmentType, d.DutyDate), d.UserID, d.DutyDate.Add(24*time.Hour)); err != nil {
+					log.Printf("[SCHEDULER] Failed to record round-robin pick: %v", err)
+				}
+			}
+			if err != nil {
+				return fmt.Errorf("failed to use up the queue day of %s: %w", d.DutyDate.Format("2006-01-02"), err)
+			}
+			published = append(published, d)
+		}
+		if len(published) == 0 {
+			return ErrNoDraft
+		}
+
+		tx.Events.Publish(ctx, events.MonthPublished{Month: first, Duties: published})
+		tx.replan(ctx)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return published, nil
+}
+
+// checkMonth returns the first day of month if it is the current or the
+// next month.
+func (s *Scheduler) checkMonth(today, month time.Time) (time.Time, error) {
+	first := time.Date(month.Year(), month.Month(), 1, 0, 0, 0, 0, time.UTC)
+	current := time.Date(today.Year(), today.Month(), 1, 0, 0, 0, 0, time.UTC)
+	if first.Before(current) {
+		return time.Time{}, ErrPastDate
+	}
+	if first.After(current.AddDate(0, 1, 0)) {
+		return time.Time{}, ErrMonthTooFar
+	}
+	return first, nil
+}
+
+// replan recomputes the provisional duties after queues, availability or the
+// schedule changed. A failure is only logged; the nightly run catches up.
+func (s *Scheduler) replan(ctx context.Context) {
+	if err := s.PlanAhead(ctx); err != nil {
+		log.Printf("[SCHEDULER] Failed to plan ahead: %v", err)
+	}
+}
+
+// plan is the store as the daily assignment would see it on a later day,
+// once the days before it went as planned: queue days are used up, planned
+// duties count as done and the cursors moved past the planned picks.
+type plan struct {
+	Store
+	users  []*store.User // Copies of all users, by ID, with the queue days left
+	duties []*store.Duty // Planned and upcoming duties that count as done
+	picks  map[string]map[int64]time.Time
+}
+
+func newPlan(ctx context.Context, st Store) (*plan, error) {
+	users, err := st.ListAllUsers(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list users: %w", err)
+	}
+	p := &plan{Store: st, picks: make(map[string]map[int64]time.Time)}
+	for _, u := range users {
+		cp := *u
+		p.users = append(p.users, &cp)
+	}
+	sort.Slice(p.users, func(i, j int) bool { return p.users[i].ID < p.users[j].ID })
+	return p, nil
+}
+
+// assign records a planned duty, using up the queue day it takes and moving
+// the cursor of rotation.
+func (p *plan) assign(userID int64, day time.Time, assignType store.AssignmentType, rotation string) {
+	p.duties = append(p.duties, &store.Duty{UserID: userID, DutyDate: day, AssignmentType: assignType})
+	for _, u := range p.users {
+		if u.ID != userID {
+			continue
+		}
+		switch assignType {
+		case store.AssignmentTypeVoluntary:
+			u.VolunteerQueueDays--
+		case store.AssignmentTypeAdmin:
+			u.AdminQueueDays--
+		}
+	}
+	if p.picks[rotation] == nil {
+		p.picks[rotation] = make(map[int64]time.Time)
+	}
+	// The end of the day sorts after any real pick made before it
+	p.picks[rotation][userID] = day.Add(24 * time.Hour)
+}
+
+func (p *plan) queued(days func(*store.User) int) []*store.User {
+	var users []*store.User
+	for _, u := range p.users {
+		if u.IsActive && days(u) > 0 {
+			cp := *u
+			users = append(users, &cp)
+		}
+	}
+	sort.SliceStable(users, func(i, j int) bool { return days(users[i]) > days(users[j]) })
+	return users
+}
+
+// GetUsersWithVolunteerQueue returns the active users with volunteer queue
+// days left after the planned days, largest queue first.
+func (p *plan) GetUsersWithVolunteerQueue(ctx context.Context) ([]*store.User, error) {
+	return p.queued(func(u *store.User) int { return u.VolunteerQueueDays }), nil
+}
+
+// GetUsersWithAdminQueue returns the active users with admin queue days left
+// after the planned days, largest queue first.
+func (p *plan) GetUsersWithAdminQueue(ctx context.Context) ([]*store.User, error) {
+	return p.queued(func(u *store.User) int { return u.AdminQueueDays }), nil
+}
+
+// GetCompletedDutiesInRange returns the completed duties in the range, as if
+// the planned ones were done.
+func (p *plan) GetCompletedDutiesInRange(ctx context.Context, start, end time.Time) ([]*store.Duty, error) {
+	duties, err := p.Store.GetCompletedDutiesInRange(ctx, start, end)
+	if err != nil {
+		return nil, err
+	}
+	for _, d := range p.duties {
+		if !d.DutyDate.Before(start) && d.DutyDate.Before(end) {
+			duties = append(duties, d)
+		}
+	}
+	return duties, nil
+}
+
+// GetRoundRobinStates returns the cursor of a rotation moved past the planned picks.
+func (p *plan) GetRoundRobinStates(ctx context.Context, rotation string) ([]*store.RoundRobinState, error) {
+	states, err := p.Store.GetRoundRobinStates(ctx, rotation)
+	if err != nil {
+		return nil, err
+	}
+	picks := p.picks[rotation]
+	for _, st := range states {
+		if at, ok := picks[st.UserID]; ok {
+			st.AssignmentCount++
+			st.LastAssignedTimestamp = at
+		}
+	}
+	for userID, at := range picks {
+		known := false
+		for _, st := range states {
+			known = known || st.UserID == userID
+		}
+		if !known {
+			states = append(states, &store.RoundRobinState{Rotation: rotation, UserID: userID, AssignmentCount: 1, LastAssignedTimestamp: at})
+		}
+	}
+	return states, nil
+}
@@ -0,0 +1,64 @@
+package scheduler
+
+import (
+	"context"
+	"time"
+
+	"github.com/korjavin/dutyassistant/internal/store"
+)
+
+// weekendSuffix names the weekend rotation after the round-robin one.
+const weekendSuffix = "_weekend"
+
+// rotation returns the rotation whose cursor a pick of assignType on day
+// moves. With WeekendRotation, round-robin weekends have a rotation of their own.
+func (s *Scheduler) rotation(assignType store.AssignmentType, day time.Time) string {
+	if s.weekendRotation(day) && assignType == store.AssignmentTypeRoundRobin && store.IsWeekend(day) {
+		return string(assignType) + weekendSuffix
+	}
+	return string(assignType)
+}
+
+// filterPool removes the users whose pool doesn't cover day.
+func filterPool(users []*store.User, day time.Time) []*store.User {
+	var pooled []*store.User
+	for _, user := range users {
+		if user.Pool.Covers(day) {
+			pooled = append(pooled, user)
+		}
+	}
+	return pooled
+}
+
+// fairness returns st as the round-robin strategies see it on day: with
+// WeekendRotation, only the duties of the same kind of day count, so those
+// who only do weekends are compared by their weekends alone.
+func (s *Scheduler) fairness(st Store, day time.Time) Store {
+	if !s.weekendRotation(day) {
+		return st
+	}
+	return rotationView{Store: st, weekend: store.IsWeekend(day)}
+}
+
+// rotationView is a store whose completed duties are those of weekends or
+// those of weekdays only.
+type rotationView struct {
+	Store
+	weekend bool
+}
+
+// GetCompletedDutiesInRange returns the completed duties in the range on the
+// view's kind of day.
+func (v rotationView) GetCompletedDutiesInRange(ctx context.Context, start, end time.Time) ([]*store.Duty, error) {
+	duties, err := v.Store.GetCompletedDutiesInRange(ctx, start, end)
+	if err != nil {
+		return nil, err
+	}
+	var same []*store.Duty
+	for _, d := range duties {
+		if store.IsWeekend(d.DutyDate) == v.weekend {
+			same = append(same, d)
+		}
+	}
+	return same, nil
+}
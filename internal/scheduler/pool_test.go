@@ -0,0 +1,94 @@
+package scheduler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/korjavin/dutyassistant/internal/store"
+)
+
+func TestScheduler_Pools(t *testing.T) {
+	sched, s, users := newTestScheduler(t)
+	ctx := context.Background()
+	berlin, _ := time.LoadLocation("Europe/Berlin")
+	alice, bob := users[0], users[1]
+	friday, saturday, sunday := time.Date(2025, 11, 7, 0, 0, 0, 0, time.UTC), time.Date(2025, 11, 8, 0, 0, 0, 0, time.UTC), time.Date(2025, 11, 9, 0, 0, 0, 0, time.UTC)
+
+	alice.Pool = store.PoolWeekends
+	alice.VolunteerQueueDays = 1
+	bob.Pool = store.PoolWeekdays
+	for _, u := range []*store.User{alice, bob} {
+		if err := s.UpdateUser(ctx, u); err != nil {
+			t.Fatal(err)
+		}
+	}
+	assign := func(day time.Time) *store.Duty {
+		t.Helper()
+		sched.now = func() time.Time { return time.Date(day.Year(), day.Month(), day.Day(), 11, 0, 0, 0, berlin) }
+		duty, err := sched.AssignTodaysDuty(ctx, false)
+		if err != nil {
+			t.Fatalf("AssignTodaysDuty on %s failed: %v", day.Format("2006-01-02"), err)
+		}
+		return duty
+	}
+
+	// Alice's volunteer day waits for the weekend
+	if d := assign(friday); d.UserID != bob.ID || d.AssignmentType != store.AssignmentTypeRoundRobin {
+		t.Errorf("Expected Bob by round-robin on Friday, got %+v", d)
+	}
+	if d := assign(saturday); d.UserID != alice.ID || d.AssignmentType != store.AssignmentTypeVoluntary {
+		t.Errorf("Expected Alice's volunteer day on Saturday, got %+v", d)
+	}
+
+	// Bob only stands in when nobody on the weekend roster can
+	s.SetOffDuty(ctx, alice.ID, sunday, sunday)
+	if d := assign(sunday); d.UserID != bob.ID {
+		t.Errorf("Expected Bob to stand in for Alice on Sunday, got %+v", d)
+	}
+}
+
+func TestScheduler_WeekendRotation(t *testing.T) {
+	sched, s, users := newTestScheduler(t)
+	ctx := context.Background()
+	berlin, _ := time.LoadLocation("Europe/Berlin")
+	alice, bob := users[0], users[1]
+	saturday := time.Date(2025, 11, 8, 0, 0, 0, 0, time.UTC)
+	sched.now = func() time.Time { return time.Date(2025, 11, 8, 11, 0, 0, 0, berlin) }
+
+	// Bob did the weekdays, Alice the last weekend
+	done := time.Date(2025, 11, 1, 21, 0, 0, 0, time.UTC)
+	for day, user := range map[time.Time]*store.User{
+		saturday.AddDate(0, 0, -6): alice,
+		saturday.AddDate(0, 0, -5): bob,
+		saturday.AddDate(0, 0, -4): bob,
+		saturday.AddDate(0, 0, -3): bob,
+	} {
+		s.CreateDuty(ctx, &store.Duty{UserID: user.ID, DutyDate: day, AssignmentType: store.AssignmentTypeRoundRobin, CreatedAt: done, CompletedAt: &done})
+	}
+
+	if got := sched.rotation(store.AssignmentTypeRoundRobin, saturday); got != "round_robin" {
+		t.Errorf("rotation() = %q without WeekendRotation, want round_robin", got)
+	}
+	if user, _, _, err := sched.choose(ctx, s, saturday); err != nil || user.ID != alice.ID {
+		t.Errorf("Expected Alice with fewer duties overall, got %+v, %v", user, err)
+	}
+
+	sched.WeekendRotation = true
+	if got := sched.rotation(store.AssignmentTypeRoundRobin, saturday); got != "round_robin_weekend" {
+		t.Errorf("rotation() = %q, want round_robin_weekend", got)
+	}
+	if got := sched.rotation(store.AssignmentTypeVoluntary, saturday); got != "voluntary" {
+		t.Errorf("rotation() = %q for a volunteer, want voluntary", got)
+	}
+	duty, err := sched.AssignTodaysDuty(ctx, false)
+	if err != nil || duty.UserID != bob.ID {
+		t.Fatalf("Expected Bob with no weekend duties, got %+v, %v", duty, err)
+	}
+	if states, _ := s.GetRoundRobinStates(ctx, "round_robin_weekend"); len(states) != 1 || states[0].UserID != bob.ID {
+		t.Errorf("Expected the pick on the weekend cursor, got %+v", states)
+	}
+	if states, _ := s.GetRoundRobinStates(ctx, "round_robin"); len(states) != 0 {
+		t.Errorf("Expected the weekday cursor untouched, got %+v", states)
+	}
+}
@@ -2,20 +2,153 @@ package scheduler
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log"
+	"slices"
+	"sort"
+	"strings"
+	"sync/atomic"
 	"time"
 
+	"github.com/korjavin/dutyassistant/internal/events"
+	"github.com/korjavin/dutyassistant/internal/service/checklist"
 	"github.com/korjavin/dutyassistant/internal/store"
 )
 
+var (
+	// ErrNoAvailableUsers is returned by AssignTodaysDuty when every user is
+	// inactive or off-duty, so an admin has to decide what happens with the day.
+	ErrNoAvailableUsers = errors.New("no available users for duty")
+	// ErrPastDate is returned when changing the schedule of a day that already passed.
+	ErrPastDate = errors.New("the date is in the past")
+	// ErrNotPastDate is returned when backfilling today or a future day.
+	ErrNotPastDate = errors.New("only past days can be backfilled")
+	// ErrFutureDate is returned when completing a day that hasn't come yet.
+	ErrFutureDate = errors.New("the date is in the future")
+	// ErrDutyTaken is returned when assigning a day that already has a duty.
+	ErrDutyTaken = errors.New("duty is already assigned for this date")
+	// ErrNoDuty is returned when changing or removing a day without a duty.
+	ErrNoDuty = errors.New("no duty found for this date")
+	// ErrDaySkipped is returned when assigning a day marked as "no duty".
+	ErrDaySkipped = errors.New("this date is marked as no duty")
+	// ErrInvalidHold is returned when a hold doesn't end between today and
+	// the day before the duty.
+	ErrInvalidHold = errors.New("a hold must end between today and the day before the duty")
+	// ErrTooEarly is returned by AssignTodaysDuty when it runs unforced before the cutoff.
+	ErrTooEarly = errors.New("too early to assign today's duty")
+	// ErrUnassignedDay is returned by CompleteTodaysDuty when nobody was on
+	// duty on a day that wasn't skipped, so an admin should look into it.
+	ErrUnassignedDay = errors.New("nobody was on duty today")
+	// ErrNoDraft is returned when publishing a month without a drafted plan.
+	ErrNoDraft = errors.New("there is no draft plan for this month")
+	// ErrMonthTooFar is returned when drafting or publishing a month after the next one.
+	ErrMonthTooFar = errors.New("only the current and the next month can be planned")
+	// ErrInvalidStatus is returned when a duty's status doesn't allow the change.
+	ErrInvalidStatus = errors.New("the duty's status doesn't allow this")
+)
+
+// Store is the part of store.Store the scheduler reads and writes.
+type Store interface {
+	store.UserStore
+	store.DutyStore
+	store.QueueStore
+	store.AvailabilityStore
+	store.TxStore
+}
+
+// DefaultCutoff is the Berlin time of day from which today's duty is assigned.
+const DefaultCutoff = 11 * time.Hour
+
 // Scheduler handles the business logic for duty assignments.
 type Scheduler struct {
-	store store.Store
+	store Store
+	now   func() time.Time // clock, replaced in tests to simulate many days
+	// Cutoff is the Berlin time of day, as the time since midnight, before
+	// which AssignTodaysDuty refuses to run unless forced.
+	Cutoff time.Duration
+	// DayRollover is the time of day, as the time since midnight, before
+	// which users still mean the previous day by "today", e.g. when they take
+	// over tonight's duty after midnight. It must be before Cutoff.
+	DayRollover time.Duration
+	// Events is optional; changes to the schedule are published on it.
+	Events *events.Bus
+	// Shadow is optional; it is asked whom it would have picked for every
+	// round-robin day, and its picks are recorded next to the live ones.
+	Shadow Strategy
+	// Horizon is how many days after today PlanAhead assigns provisionally.
+	// 0 turns planning ahead off.
+	Horizon int
+	// Constraints keep users off days on top of their off-duty periods. They
+	// are relaxed on days they would leave nobody to pick.
+	Constraints []Constraint
+	// ReturnGrace is how many days after an off-duty period users aren't
+	// picked yet, so nobody who landed at midnight has the dishes at 11:00.
+	// Like the constraints, it is relaxed on round-robin days it would leave
+	// nobody to pick. 0 turns it off.
+	ReturnGrace int
+	// WeekendRotation gives round-robin weekends a cursor and fairness count
+	// of their own, separate from weekdays.
+	WeekendRotation bool
+	// Seasons replace some of the settings above, and who is on the roster,
+	// during parts of the year.
+	Seasons []Season
+
+	// assignmentTime replaces Cutoff while it isn't 0. /settings changes it
+	// while the jobs read it, see SetAssignmentTime. Copies made for
+	// transactions share it.
+	assignmentTime *atomic.Int64
 }
 
 // NewScheduler creates a new Scheduler with the given data store.
-func NewScheduler(s store.Store) *Scheduler {
-	return &Scheduler{store: s}
+func NewScheduler(s Store) *Scheduler {
+	return &Scheduler{store: s, now: time.Now, Cutoff: DefaultCutoff, assignmentTime: new(atomic.Int64)}
+}
+
+// SetAssignmentTime replaces Cutoff from now on, or goes back to it for 0.
+// Unlike Cutoff, it may be changed while the scheduler is in use.
+func (s *Scheduler) SetAssignmentTime(d time.Duration) {
+	if s.assignmentTime == nil {
+		s.assignmentTime = new(atomic.Int64)
+	}
+	s.assignmentTime.Store(int64(d))
+}
+
+// cutoff returns the time of day the duty is assigned at outside seasons.
+func (s *Scheduler) cutoff() time.Duration {
+	if s.assignmentTime != nil {
+		if d := time.Duration(s.assignmentTime.Load()); d != 0 {
+			return d
+		}
+	}
+	return s.Cutoff
+}
+
+// ParseCutoff parses a time of day written as "HH:MM" into the time since midnight.
+func ParseCutoff(value string) (time.Duration, error) {
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time of day %q, expected HH:MM", value)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// formatCutoff formats a time of day like ParseCutoff expects it.
+func formatCutoff(d time.Duration) string {
+	return fmt.Sprintf("%02d:%02d", int(d.Hours()), int(d.Minutes())%60)
+}
+
+// Today returns the date users mean by "today" at now: the previous day
+// before rollover, the calendar date from then on.
+func Today(now time.Time, rollover time.Duration) time.Time {
+	now = now.Add(-rollover)
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// today is the date users mean by "today" right now. Changes users ask for
+// are checked against it; the daily jobs run on the calendar date.
+func (s *Scheduler) today() time.Time {
+	return Today(s.now(), s.DayRollover)
 }
 
 // AddToVolunteerQueue adds days to a user's volunteer queue.
@@ -23,7 +156,11 @@ func (s *Scheduler) AddToVolunteerQueue(ctx context.Context, userID int64, days
 	if days <= 0 {
 		return fmt.Errorf("days must be positive")
 	}
-	return s.store.AddToVolunteerQueue(ctx, userID, days)
+	if err := s.store.AddToVolunteerQueue(ctx, userID, days); err != nil {
+		return err
+	}
+	s.replan(ctx)
+	return nil
 }
 
 // AddToAdminQueue adds days to a user's admin assignment queue.
@@ -31,7 +168,11 @@ func (s *Scheduler) AddToAdminQueue(ctx context.Context, userID int64, days int)
 	if days <= 0 {
 		return fmt.Errorf("days must be positive")
 	}
-	return s.store.AddToAdminQueue(ctx, userID, days)
+	if err := s.store.AddToAdminQueue(ctx, userID, days); err != nil {
+		return err
+	}
+	s.replan(ctx)
+	return nil
 }
 
 // SetOffDuty sets a user's off-duty period.
@@ -40,116 +181,315 @@ func (s *Scheduler) SetOffDuty(ctx context.Context, userID int64, start, end tim
 	if end.Before(start) {
 		return fmt.Errorf("end date must be after start date")
 	}
-	return s.store.SetOffDuty(ctx, userID, start, end)
+	if err := s.store.SetOffDuty(ctx, userID, start, end); err != nil {
+		return err
+	}
+	s.Events.Publish(ctx, events.UserWentOffDuty{UserID: userID, Start: start, End: end})
+	s.replan(ctx)
+	return nil
+}
+
+// AddOffDutyPeriods adds dated off-duty periods to the ones a user has from
+// bulk imports, leaving out those they have already. It returns the periods
+// added.
+func (s *Scheduler) AddOffDutyPeriods(ctx context.Context, userID int64, periods []*store.OffDutyPeriod) ([]*store.OffDutyPeriod, error) {
+	existing, err := s.store.ListOffDutyPeriods(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list off-duty periods: %w", err)
+	}
+	var imported, added []*store.OffDutyPeriod
+	for _, p := range existing {
+		if p.Source == store.OffDutySourceImport {
+			imported = append(imported, p)
+		}
+	}
+	for _, p := range periods {
+		if p.EndDate.Before(p.StartDate) {
+			return nil, fmt.Errorf("end date must be after start date")
+		}
+		known := slices.ContainsFunc(imported, func(other *store.OffDutyPeriod) bool {
+			return other.StartDate.Equal(p.StartDate) && other.EndDate.Equal(p.EndDate)
+		})
+		if !known {
+			imported = append(imported, p)
+			added = append(added, p)
+		}
+	}
+	if len(added) == 0 {
+		return nil, nil
+	}
+	if err := s.store.ReplaceOffDutyPeriods(ctx, userID, store.OffDutySourceImport, imported); err != nil {
+		return nil, err
+	}
+	for _, p := range added {
+		s.Events.Publish(ctx, events.UserWentOffDuty{UserID: userID, Start: p.StartDate, End: p.EndDate})
+	}
+	s.replan(ctx)
+	return added, nil
+}
+
+// ReplaceCalendarPeriods replaces the off-duty periods imported from a user's
+// linked calendar. Periods the calendar didn't have before are announced as
+// UserWentOffDuty, and the plan is redone around them.
+func (s *Scheduler) ReplaceCalendarPeriods(ctx context.Context, userID int64, periods []*store.OffDutyPeriod) error {
+	existing, err := s.store.ListOffDutyPeriods(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to list off-duty periods: %w", err)
+	}
+	var previous, added []*store.OffDutyPeriod
+	for _, p := range existing {
+		if p.Source == store.OffDutySourceICal {
+			previous = append(previous, p)
+		}
+	}
+	for _, p := range periods {
+		known := slices.ContainsFunc(previous, func(other *store.OffDutyPeriod) bool {
+			return other.StartDate.Equal(p.StartDate) && other.EndDate.Equal(p.EndDate)
+		})
+		if !known {
+			added = append(added, p)
+		}
+	}
+	if err := s.store.ReplaceOffDutyPeriods(ctx, userID, store.OffDutySourceICal, periods); err != nil {
+		return err
+	}
+	for _, p := range added {
+		s.Events.Publish(ctx, events.UserWentOffDuty{UserID: userID, Start: p.StartDate, End: p.EndDate})
+	}
+	// A calendar that didn't change leaves the plan alone; one that lost
+	// periods frees days the plan left out
+	if len(added) > 0 || len(periods)-len(added) < len(previous) {
+		s.replan(ctx)
+	}
+	return nil
 }
 
 // ClearOffDuty clears a user's off-duty period.
 func (s *Scheduler) ClearOffDuty(ctx context.Context, userID int64) error {
-	return s.store.ClearOffDuty(ctx, userID)
+	if err := s.store.ClearOffDuty(ctx, userID); err != nil {
+		return err
+	}
+	s.replan(ctx)
+	return nil
 }
 
-// AssignTodaysDuty performs the daily assignment at 11:00 AM Berlin time.
+// AssignTodaysDuty performs the daily assignment, at 11:00 AM Berlin time
+// unless Cutoff or the day's season says otherwise. Before the cutoff it returns ErrTooEarly, so
+// queues aren't used up while people still volunteer, unless force is set for
+// an admin who wants the day assigned now.
 // Priority: Volunteer queue > Admin queue > Round-robin (with balancing).
-func (s *Scheduler) AssignTodaysDuty(ctx context.Context) (*store.Duty, error) {
-	now := time.Now()
+// It returns a nil duty if an admin marked today as a skip day.
+func (s *Scheduler) AssignTodaysDuty(ctx context.Context, force bool) (*store.Duty, error) {
+	now := s.now()
 	berlinLoc, _ := time.LoadLocation("Europe/Berlin")
 	berlinNow := now.In(berlinLoc)
 
-	// Check if it's past 11 AM in Berlin
-	if berlinNow.Hour() < 11 {
-		return nil, fmt.Errorf("too early to assign today's duty (before 11:00 AM Berlin time)")
-	}
-
 	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
 
-	// Check if already assigned
+	// Check if it's past the cutoff in Berlin
+	sinceMidnight := time.Duration(berlinNow.Hour())*time.Hour + time.Duration(berlinNow.Minute())*time.Minute
+	if cutoff := s.CutoffOn(today); !force && sinceMidnight < cutoff {
+		return nil, fmt.Errorf("%w (before %s Berlin time)", ErrTooEarly, formatCutoff(cutoff))
+	}
+
+	// Check if already assigned. A duty planned ahead is assigned for real now.
 	existingDuty, err := s.store.GetDutyByDate(ctx, today)
-	if err == nil && existingDuty != nil {
+	if err == nil && existingDuty != nil && existingDuty.Status != store.DutyStatusProvisional {
 		return existingDuty, nil
 	}
 
+	// Leave deliberate "no duty" days alone
+	skip, err := s.skipDay(ctx, today)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check skip day: %w", err)
+	}
+	if skip != nil {
+		return nil, nil
+	}
+
+	user, assignType, candidates, err := s.choose(ctx, s.store, today)
+	if err != nil {
+		return nil, err
+	}
+	explanation, err := s.explain(ctx, today, user, assignType, candidates)
+	if err != nil {
+		log.Printf("[SCHEDULER] Failed to explain the pick for %s: %v", today.Format("2006-01-02"), err)
+	}
+	duty, err := s.assignDuty(ctx, user, today, assignType)
+	if err != nil {
+		return nil, err
+	}
+	s.recordPick(ctx, s.rotation(assignType, today), user)
+	s.recordExplanation(ctx, explanation)
+
+	switch assignType {
+	case store.AssignmentTypeVoluntary:
+		s.store.DecrementVolunteerQueue(ctx, user.ID)
+	case store.AssignmentTypeAdmin:
+		s.store.DecrementAdminQueue(ctx, user.ID)
+	default:
+		s.compareShadow(ctx, today, candidates, user)
+	}
+	s.replan(ctx)
+	return duty, nil
+}
+
+// choose picks who is on duty on day, and with which assignment type, as the
+// daily assignment sees st. It also returns the users the pick was made from.
+// Priority: Volunteer queue > Admin queue > Round-robin (with balancing).
+func (s *Scheduler) choose(ctx context.Context, st Store, day time.Time) (*store.User, store.AssignmentType, []*store.User, error) {
 	// 1. Try volunteer queue first
-	volunteers, err := s.store.GetUsersWithVolunteerQueue(ctx)
+	volunteers, err := st.GetUsersWithVolunteerQueue(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get volunteers: %w", err)
+		return nil, "", nil, fmt.Errorf("failed to get volunteers: %w", err)
 	}
 
-	// Filter out off-duty users
-	volunteers = s.filterOffDutyUsers(ctx, volunteers, today)
+	// Filter out off-duty users and those not on the roster that day
+	if volunteers, err = s.filterOffDutyUsers(ctx, volunteers, day); err != nil {
+		return nil, "", nil, err
+	}
+	if volunteers, err = s.filterReturning(ctx, filterPool(s.filterSeason(volunteers, day), day), day); err != nil {
+		return nil, "", nil, err
+	}
+	volunteers = s.filterConstrained(ctx, st, day, volunteers)
 
 	if len(volunteers) > 0 {
 		// If multiple volunteers with same queue count, use round-robin to balance
-		user := s.selectUserWithBalancing(ctx, volunteers)
-		duty, err := s.assignDuty(ctx, user, today, store.AssignmentTypeVoluntary)
+		user, err := s.selectUserWithBalancing(ctx, st, day, store.AssignmentTypeVoluntary, volunteers)
 		if err != nil {
-			return nil, err
+			return nil, "", nil, err
 		}
-		// Decrement volunteer queue
-		s.store.DecrementVolunteerQueue(ctx, user.ID)
-		return duty, nil
+		return user, store.AssignmentTypeVoluntary, volunteers, nil
 	}
 
 	// 2. Try admin queue
-	adminAssigned, err := s.store.GetUsersWithAdminQueue(ctx)
+	adminAssigned, err := st.GetUsersWithAdminQueue(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get admin-assigned users: %w", err)
+		return nil, "", nil, fmt.Errorf("failed to get admin-assigned users: %w", err)
 	}
 
-	// Filter out off-duty users
-	adminAssigned = s.filterOffDutyUsers(ctx, adminAssigned, today)
+	// Filter out off-duty users and those not on the roster that day
+	if adminAssigned, err = s.filterOffDutyUsers(ctx, adminAssigned, day); err != nil {
+		return nil, "", nil, err
+	}
+	if adminAssigned, err = s.filterReturning(ctx, filterPool(s.filterSeason(adminAssigned, day), day), day); err != nil {
+		return nil, "", nil, err
+	}
+	adminAssigned = s.filterConstrained(ctx, st, day, adminAssigned)
 
 	if len(adminAssigned) > 0 {
 		// If multiple with same queue count, use round-robin to balance
-		user := s.selectUserWithBalancing(ctx, adminAssigned)
-		duty, err := s.assignDuty(ctx, user, today, store.AssignmentTypeAdmin)
+		user, err := s.selectUserWithBalancing(ctx, st, day, store.AssignmentTypeAdmin, adminAssigned)
 		if err != nil {
-			return nil, err
+			return nil, "", nil, err
 		}
-		// Decrement admin queue
-		s.store.DecrementAdminQueue(ctx, user.ID)
-		return duty, nil
+		return user, store.AssignmentTypeAdmin, adminAssigned, nil
 	}
 
 	// 3. Fall back to round-robin
-	allUsers, err := s.store.ListActiveUsers(ctx)
+	allUsers, err := st.ListActiveUsers(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get active users: %w", err)
+		return nil, "", nil, fmt.Errorf("failed to get active users: %w", err)
 	}
 
-	// Filter out off-duty users
-	allUsers = s.filterOffDutyUsers(ctx, allUsers, today)
+	// Filter out off-duty users and those the season leaves out
+	if allUsers, err = s.filterOffDutyUsers(ctx, allUsers, day); err != nil {
+		return nil, "", nil, err
+	}
+	allUsers = s.filterSeason(allUsers, day)
 
 	if len(allUsers) == 0 {
-		return nil, fmt.Errorf("no available users for duty")
+		return nil, "", nil, ErrNoAvailableUsers
 	}
-
-	// Select user with least duties in last 14 days (excluding admin assignments)
-	user := s.selectRoundRobinUser(ctx, allUsers)
-	duty, err := s.assignDuty(ctx, user, today, store.AssignmentTypeRoundRobin)
+	// Users of the other pool only step in if nobody of the day's can
+	if pooled := filterPool(allUsers, day); len(pooled) > 0 {
+		allUsers = pooled
+	} else {
+		log.Printf("[SCHEDULER] Nobody on the roster for %s is available, ignoring the pools", day.Format("2006-01-02"))
+	}
+	// Queued users who just came back wait for another day, but somebody
+	// has to do the dishes
+	rested, err := s.filterReturning(ctx, allUsers, day)
 	if err != nil {
-		return nil, err
+		return nil, "", nil, err
+	}
+	if len(rested) > 0 {
+		allUsers = rested
+	} else {
+		log.Printf("[SCHEDULER] Only users just back from off duty are available for %s, ignoring the grace days", day.Format("2006-01-02"))
+	}
+	// Queued users the constraints keep off the day wait for another one,
+	// but somebody has to do the dishes
+	if constrained := s.filterConstrained(ctx, st, day, allUsers); len(constrained) > 0 {
+		allUsers = constrained
+	} else {
+		log.Printf("[SCHEDULER] The constraints leave nobody for %s, ignoring them", day.Format("2006-01-02"))
 	}
 
-	return duty, nil
+	// Select user with least duties in their last 14 days on duty (excluding admin assignments)
+	allUsers = s.byCursor(ctx, st, s.rotation(store.AssignmentTypeRoundRobin, day), allUsers)
+	user, err := s.selectRoundRobinUser(ctx, s.fairness(st, day), day, allUsers)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	return user, store.AssignmentTypeRoundRobin, allUsers, nil
 }
 
 // filterOffDutyUsers removes users who are off-duty on the given date.
-func (s *Scheduler) filterOffDutyUsers(ctx context.Context, users []*store.User, date time.Time) []*store.User {
+func (s *Scheduler) filterOffDutyUsers(ctx context.Context, users []*store.User, date time.Time) ([]*store.User, error) {
 	var available []*store.User
 	for _, user := range users {
-		offDuty, _ := s.store.IsUserOffDuty(ctx, user.ID, date)
+		offDuty, err := s.store.IsUserOffDuty(ctx, user.ID, date)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check off-duty status: %w", err)
+		}
 		if !offDuty {
 			available = append(available, user)
 		}
 	}
-	return available
+	return available, nil
+}
+
+// filterReturning removes users who were off duty on one of the ReturnGrace
+// days before date.
+func (s *Scheduler) filterReturning(ctx context.Context, users []*store.User, date time.Time) ([]*store.User, error) {
+	if s.ReturnGrace <= 0 {
+		return users, nil
+	}
+	var rested []*store.User
+	for _, user := range users {
+		returning, err := s.returning(ctx, user, date)
+		if err != nil {
+			return nil, err
+		}
+		if !returning {
+			rested = append(rested, user)
+		}
+	}
+	return rested, nil
+}
+
+// returning reports whether user was off duty on one of the ReturnGrace days
+// before date.
+func (s *Scheduler) returning(ctx context.Context, user *store.User, date time.Time) (bool, error) {
+	for days := 1; days <= s.ReturnGrace; days++ {
+		offDuty, err := s.store.IsUserOffDuty(ctx, user.ID, date.AddDate(0, 0, -days))
+		if err != nil {
+			return false, fmt.Errorf("failed to check off-duty status: %w", err)
+		}
+		if offDuty {
+			return true, nil
+		}
+	}
+	return false, nil
 }
 
 // selectUserWithBalancing selects a user from those with the highest queue count.
-// If multiple users have the same highest count, it uses round-robin balancing.
-func (s *Scheduler) selectUserWithBalancing(ctx context.Context, users []*store.User) *store.User {
+// If multiple users have the same highest count, it uses round-robin balancing
+// with the cursor of the given rotation.
+func (s *Scheduler) selectUserWithBalancing(ctx context.Context, st Store, day time.Time, rotation store.AssignmentType, users []*store.User) (*store.User, error) {
 	if len(users) == 0 {
-		return nil
+		return nil, nil
 	}
 
 	// Find the maximum queue count
@@ -178,105 +518,585 @@ func (s *Scheduler) selectUserWithBalancing(ctx context.Context, users []*store.
 
 	// If only one user, return it
 	if len(maxQueueUsers) == 1 {
-		return maxQueueUsers[0]
+		return maxQueueUsers[0], nil
 	}
 
 	// Use round-robin balancing for multiple users
-	return s.selectRoundRobinUser(ctx, maxQueueUsers)
+	return s.selectRoundRobinUser(ctx, st, day, s.byCursor(ctx, st, string(rotation), maxQueueUsers))
 }
 
-// selectRoundRobinUser selects the user with the least completed duties in the last 14 days.
-func (s *Scheduler) selectRoundRobinUser(ctx context.Context, users []*store.User) *store.User {
+// selectRoundRobinUser selects the user with the least completed duties in the
+// 14 days before day. Ties go to the earlier user, so users should come ordered by byCursor.
+func (s *Scheduler) selectRoundRobinUser(ctx context.Context, st Store, day time.Time, users []*store.User) (*store.User, error) {
 	if len(users) == 0 {
-		return nil
+		return nil, nil
 	}
+	return liveStrategy.Pick(ctx, st, day, users)
+}
 
-	// Calculate last 14 days
-	now := time.Now()
+// byCursor orders users by the persisted cursor of a rotation: those it never
+// picked first, then whoever it picked least recently. Strategies break the
+// remaining ties by order, so without the cursor sparse history, e.g. after a
+// restart or when duties aren't completed, keeps favouring the first user.
+// If the cursor can't be read, users are returned as they are.
+func (s *Scheduler) byCursor(ctx context.Context, st Store, rotation string, users []*store.User) []*store.User {
+	states, err := st.GetRoundRobinStates(ctx, rotation)
+	if err != nil {
+		log.Printf("[SCHEDULER] Failed to read the %s cursor: %v", rotation, err)
+		return users
+	}
+	lastPicked := make(map[int64]time.Time, len(states))
+	for _, st := range states {
+		lastPicked[st.UserID] = st.LastAssignedTimestamp
+	}
+
+	ordered := append([]*store.User(nil), users...)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return lastPicked[ordered[i].ID].Before(lastPicked[ordered[j].ID])
+	})
+	return ordered
+}
+
+// recordPick moves the cursor of a rotation past the user it just assigned.
+// A failure only costs the tie-breaker, never the assignment.
+func (s *Scheduler) recordPick(ctx context.Context, rotation string, user *store.User) {
+	if err := s.store.RecordRoundRobinPick(ctx, rotation, user.ID, s.now()); err != nil {
+		log.Printf("[SCHEDULER] Failed to record the %s pick of user %d: %v", rotation, user.ID, err)
+	}
+}
+
+// assignDuty creates a new duty assignment.
+func (s *Scheduler) assignDuty(ctx context.Context, user *store.User, date time.Time, assignType store.AssignmentType) (*store.Duty, error) {
+	return s.createDuty(ctx, &store.Duty{UserID: user.ID, DutyDate: date, AssignmentType: assignType})
+}
+
+// createDuty stores a new duty and publishes it, which announces it. A duty
+// planned ahead for the day is replaced, keeping its note.
+func (s *Scheduler) createDuty(ctx context.Context, newDuty *store.Duty) (*store.Duty, error) {
+	newDuty.CreatedAt = s.now().UTC()
+	newDuty.Status = store.DutyStatusAnnounced
+	planned, err := s.store.GetDutyByDate(ctx, newDuty.DutyDate)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check planned duty: %w", err)
+	}
+	if planned != nil && planned.Status == store.DutyStatusProvisional {
+		newDuty.ID, newDuty.CreatedAt = planned.ID, planned.CreatedAt
+		if newDuty.Note == "" {
+			newDuty.Note = planned.Note
+		}
+		err = s.store.UpdateDuty(ctx, newDuty)
+	} else {
+		err = s.store.CreateDuty(ctx, newDuty)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create duty: %w", err)
+	}
+
+	s.Events.Publish(ctx, events.DutyAssigned{Duty: newDuty})
+	return newDuty, nil
+}
+
+// CompleteTodaysDuty marks today's duty as completed (runs at 21:00 PM Berlin time).
+// A duty with mandatory checklist items left open stays uncompleted, and so
+// does one an admin already completed or uncompleted by hand. Earlier duties
+// that were never completed, e.g. because the bot was down or their checklist
+// wasn't finished, are marked as missed. It returns ErrUnassignedDay once
+// that is done if nobody was on duty today although the day wasn't skipped.
+func (s *Scheduler) CompleteTodaysDuty(ctx context.Context) error {
+	now := s.now()
 	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
-	start := today.AddDate(0, 0, -14)
 
-	// Get completed duties in the last 14 days (excluding admin assignments)
-	duties, err := s.store.GetCompletedDutiesInRange(ctx, start, today)
+	duty, err := s.store.GetDutyByDate(ctx, today)
 	if err != nil {
-		// If error, just return first user
-		return users[0]
+		return fmt.Errorf("failed to get today's duty: %w", err)
+	}
+	if duty != nil && duty.Status == store.DutyStatusProvisional {
+		// Planned but never announced, so nobody was told to do it
+		duty = nil
 	}
 
-	// Count duties per user (excluding admin assignments)
-	dutyCounts := make(map[int64]int)
-	for _, duty := range duties {
-		if duty.AssignmentType != store.AssignmentTypeAdmin {
-			dutyCounts[duty.UserID]++
+	var anomaly error
+	switch {
+	case duty == nil:
+		skip, err := s.skipDay(ctx, today)
+		if err != nil {
+			return fmt.Errorf("failed to check skip day: %w", err)
+		}
+		if skip == nil {
+			anomaly = ErrUnassignedDay
+		}
+	case duty.CompletedAt != nil:
+		log.Printf("[SCHEDULER] Today's duty is already completed")
+	case duty.CompletionBy != 0:
+		log.Printf("[SCHEDULER] Today's duty was uncompleted by an admin, leaving it alone")
+	default:
+		if err := s.completeDuty(ctx, duty); err != nil {
+			return err
 		}
 	}
 
-	// Find user with minimum duty count
-	var selectedUser *store.User
-	minCount := int(^uint(0) >> 1) // max int
+	missed, err := s.store.MarkMissedDuties(ctx, today)
+	if err != nil {
+		return fmt.Errorf("failed to mark missed duties: %w", err)
+	}
+	if missed > 0 {
+		log.Printf("[SCHEDULER] Marked %d earlier duties as missed", missed)
+	}
+	return anomaly
+}
 
-	for _, user := range users {
-		count := dutyCounts[user.ID]
-		if count < minCount {
-			minCount = count
-			selectedUser = user
+// completeDuty completes duty unless its checklist has mandatory items open.
+func (s *Scheduler) completeDuty(ctx context.Context, duty *store.Duty) error {
+	items, err := checklist.New(s.store).Pending(ctx, duty)
+	if err != nil {
+		return fmt.Errorf("failed to check today's checklist: %w", err)
+	}
+	if len(items) > 0 {
+		log.Printf("[SCHEDULER] Today's duty is not completed, %d mandatory checklist item(s) are open", len(items))
+		return nil
+	}
+	completed, err := s.store.CompleteDuty(ctx, duty.DutyDate)
+	if err != nil {
+		return err
+	}
+	if completed {
+		s.Events.Publish(ctx, events.DutyCompleted{Date: duty.DutyDate, UserID: duty.UserID})
+	}
+	return nil
+}
+
+// AcknowledgeDuty records that the user on duty on date confirmed it. Only an
+// announced duty can be acknowledged; acknowledging it again changes nothing.
+// It returns ErrNoDuty if the user isn't on duty that day.
+func (s *Scheduler) AcknowledgeDuty(ctx context.Context, date time.Time, userID int64) (*store.Duty, error) {
+	dutyDate := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+	duty, err := s.store.GetDutyByDate(ctx, dutyDate)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get duty: %w", err)
+	}
+	if duty == nil || duty.UserID != userID {
+		return nil, ErrNoDuty
+	}
+	switch duty.Status {
+	case store.DutyStatusAcknowledged:
+		return duty, nil
+	case store.DutyStatusAnnounced:
+	default:
+		return nil, fmt.Errorf("%w: a %s duty can't be acknowledged", ErrInvalidStatus, duty.Status)
+	}
+
+	duty.Status = store.DutyStatusAcknowledged
+	if err := s.store.UpdateDuty(ctx, duty); err != nil {
+		return nil, fmt.Errorf("failed to acknowledge duty: %w", err)
+	}
+	return duty, nil
+}
+
+// SetDutyCompletion lets an admin mark the duty of today or a past day as
+// done, or take that back, when the 21:00 job got it wrong. The admin is
+// recorded as actorID. A cleared duty is missed if its day already passed.
+// It returns ErrNoDuty if nobody is on duty that day.
+func (s *Scheduler) SetDutyCompletion(ctx context.Context, date time.Time, completed bool, actorID int64) (*store.Duty, error) {
+	now := s.now()
+	today := s.today()
+	dutyDate := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+	if dutyDate.After(today) {
+		return nil, ErrFutureDate
+	}
+
+	duty, err := s.store.GetDutyByDate(ctx, dutyDate)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get duty: %w", err)
+	}
+	if duty == nil {
+		return nil, ErrNoDuty
+	}
+
+	wasCompleted := duty.CompletedAt != nil
+	switch {
+	case completed && !wasCompleted:
+		at := now.UTC()
+		duty.CompletedAt = &at
+		duty.Status = store.DutyStatusCompleted
+	case !completed && wasCompleted:
+		duty.CompletedAt = nil
+		duty.Status = store.DutyStatusAnnounced
+		if dutyDate.Before(today) {
+			duty.Status = store.DutyStatusMissed
 		}
+	default:
+		return duty, nil
+	}
+	duty.CompletionBy = actorID
+	if err := s.store.UpdateDuty(ctx, duty); err != nil {
+		return nil, fmt.Errorf("failed to update duty completion: %w", err)
+	}
+	if completed {
+		s.Events.Publish(ctx, events.DutyCompleted{Date: dutyDate, UserID: duty.UserID})
 	}
+	return duty, nil
+}
 
-	if selectedUser == nil {
-		return users[0]
+// AssignDutyTo lets an admin assign a free day to a specific user, regardless
+// of queues and off-duty periods, e.g. when nobody was available at 11:00.
+func (s *Scheduler) AssignDutyTo(ctx context.Context, date time.Time, userID int64, assignType store.AssignmentType) (*store.Duty, error) {
+	dutyDate := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+	if err := s.checkFreeDay(ctx, dutyDate); err != nil {
+		return nil, err
 	}
+	duty, err := s.assignDuty(ctx, &store.User{ID: userID}, dutyDate, assignType)
+	if err != nil {
+		return nil, err
+	}
+	s.replan(ctx)
+	return duty, nil
+}
 
-	return selectedUser
+// AssignHeldDuty is AssignDutyTo for an admin override that only holds until
+// the end of the given day. Unless it is confirmed by then, ReleaseExpiredHolds
+// gives the date back to the daily assignment.
+func (s *Scheduler) AssignHeldDuty(ctx context.Context, date time.Time, userID int64, until time.Time) (*store.Duty, error) {
+	dutyDate := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+	if err := s.checkFreeDay(ctx, dutyDate); err != nil {
+		return nil, err
+	}
+	hold, err := s.checkHold(dutyDate, until)
+	if err != nil {
+		return nil, err
+	}
+	duty, err := s.createDuty(ctx, &store.Duty{UserID: userID, DutyDate: dutyDate, AssignmentType: store.AssignmentTypeAdmin, HoldUntil: &hold})
+	if err != nil {
+		return nil, err
+	}
+	s.replan(ctx)
+	return duty, nil
 }
 
-// assignDuty creates a new duty assignment.
-func (s *Scheduler) assignDuty(ctx context.Context, user *store.User, date time.Time, assignType store.AssignmentType) (*store.Duty, error) {
-	newDuty := &store.Duty{
-		UserID:         user.ID,
-		DutyDate:       date,
-		AssignmentType: assignType,
-		CreatedAt:      time.Now().UTC(),
+// checkFreeDay returns an error unless an admin may assign date: it isn't in
+// the past, taken, skipped or blacked out. A day that is only planned ahead
+// is free.
+func (s *Scheduler) checkFreeDay(ctx context.Context, dutyDate time.Time) error {
+	today := s.today()
+
+	if dutyDate.Before(today) {
+		return fmt.Errorf("cannot assign duty: %w", ErrPastDate)
 	}
 
-	err := s.store.CreateDuty(ctx, newDuty)
+	existingDuty, err := s.store.GetDutyByDate(ctx, dutyDate)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create duty: %w", err)
+		return fmt.Errorf("failed to check existing duty: %w", err)
+	}
+	if existingDuty != nil && existingDuty.Status != store.DutyStatusProvisional {
+		return ErrDutyTaken
 	}
 
-	return newDuty, nil
+	skip, err := s.skipDay(ctx, dutyDate)
+	if err != nil {
+		return fmt.Errorf("failed to check skip day: %w", err)
+	}
+	if skip != nil {
+		return fmt.Errorf("%w (%s)", ErrDaySkipped, skip.Reason)
+	}
+	return nil
 }
 
-// CompleteTodaysDuty marks today's duty as completed (runs at 21:00 PM Berlin time).
-func (s *Scheduler) CompleteTodaysDuty(ctx context.Context) error {
-	now := time.Now()
+// checkHold returns until as a date if a hold of the duty on dutyDate may end then.
+func (s *Scheduler) checkHold(dutyDate, until time.Time) (time.Time, error) {
+	today := s.today()
+	hold := time.Date(until.Year(), until.Month(), until.Day(), 0, 0, 0, 0, time.UTC)
+	if hold.Before(today) || !hold.Before(dutyDate) {
+		return time.Time{}, ErrInvalidHold
+	}
+	return hold, nil
+}
+
+// HoldDuty puts today's or a future duty on hold until the end of the given
+// day, or confirms it if until is nil so it is no longer released.
+func (s *Scheduler) HoldDuty(ctx context.Context, date time.Time, until *time.Time) (*store.Duty, error) {
+	today := s.today()
+	dutyDate := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+
+	if dutyDate.Before(today) {
+		return nil, fmt.Errorf("cannot hold duty: %w", ErrPastDate)
+	}
+
+	existingDuty, err := s.store.GetDutyByDate(ctx, dutyDate)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check existing duty: %w", err)
+	}
+	if existingDuty == nil {
+		return nil, ErrNoDuty
+	}
+
+	existingDuty.HoldUntil = nil
+	if until != nil {
+		hold, err := s.checkHold(dutyDate, *until)
+		if err != nil {
+			return nil, err
+		}
+		existingDuty.HoldUntil = &hold
+	}
+	if err := s.store.UpdateDuty(ctx, existingDuty); err != nil {
+		return nil, fmt.Errorf("failed to update duty: %w", err)
+	}
+	return existingDuty, nil
+}
+
+// ReleaseExpiredHolds removes the duties whose hold ended before today without
+// being confirmed, so the daily assignment picks someone for those days
+// again. It returns the released duties.
+func (s *Scheduler) ReleaseExpiredHolds(ctx context.Context) ([]*store.Duty, error) {
+	now := s.now()
 	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
 
-	return s.store.CompleteDuty(ctx, today)
+	expired, err := s.store.GetExpiredHolds(ctx, today)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get expired holds: %w", err)
+	}
+
+	var released []*store.Duty
+	for _, duty := range expired {
+		if duty.DutyDate.Before(today) {
+			// Too late to give the day back; it just isn't held anymore
+			duty.HoldUntil = nil
+			if err := s.store.UpdateDuty(ctx, duty); err != nil {
+				return released, fmt.Errorf("failed to clear hold of %s: %w", duty.DutyDate.Format("2006-01-02"), err)
+			}
+			continue
+		}
+		if err := s.store.DeleteDuty(ctx, duty.DutyDate); err != nil {
+			return released, fmt.Errorf("failed to release %s: %w", duty.DutyDate.Format("2006-01-02"), err)
+		}
+		s.Events.Publish(ctx, events.DutyReleased{Duty: duty})
+		released = append(released, duty)
+	}
+	return released, nil
+}
+
+// BackfillDuty records who actually did the duty on a past day, either a day
+// nobody was assigned or one where someone else stepped in. The duty counts
+// towards stats and fairness like any other but stays marked as backfilled.
+func (s *Scheduler) BackfillDuty(ctx context.Context, date time.Time, userID int64) (*store.Duty, error) {
+	now := s.now()
+	today := s.today()
+	dutyDate := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+
+	if !dutyDate.Before(today) {
+		return nil, ErrNotPastDate
+	}
+
+	// Someone did the dishes after all, so the day is no longer skipped
+	if err := s.store.DeleteSkipDay(ctx, dutyDate); err != nil {
+		return nil, fmt.Errorf("failed to clear skip day: %w", err)
+	}
+
+	duty, err := s.store.BackfillDuty(ctx, dutyDate, userID, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to backfill duty: %w", err)
+	}
+	s.Events.Publish(ctx, events.DutyBackfilled{Duty: duty})
+	return duty, nil
+}
+
+// SkipDay marks today or a future date as "no duty". A duty already assigned
+// for that date is removed and the queue day it used up, if any, is given
+// back; a completed one can't be skipped anymore.
+func (s *Scheduler) SkipDay(ctx context.Context, date time.Time, reason store.SkipReason) error {
+	now := s.now()
+	today := s.today()
+	skipDate := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+
+	if skipDate.Before(today) {
+		return fmt.Errorf("cannot skip day: %w", ErrPastDate)
+	}
+
+	return s.inTx(ctx, func(tx *Scheduler) error {
+		existingDuty, err := tx.store.GetDutyByDate(ctx, skipDate)
+		if err != nil {
+			return fmt.Errorf("failed to check existing duty: %w", err)
+		}
+		if existingDuty != nil {
+			if existingDuty.CompletedAt != nil {
+				return fmt.Errorf("duty for this date is already completed")
+			}
+			if err := tx.removeDuty(ctx, existingDuty, reason); err != nil {
+				return fmt.Errorf("failed to remove assigned duty: %w", err)
+			}
+			// A duty planned ahead used up no queue day
+			if existingDuty.Status != store.DutyStatusProvisional {
+				if err := tx.returnQueueDay(ctx, existingDuty.AssignmentType, existingDuty.UserID); err != nil {
+					return err
+				}
+			}
+		}
+
+		if err := tx.store.SetSkipDay(ctx, &store.SkipDay{Date: skipDate, Reason: reason, CreatedAt: now.UTC()}); err != nil {
+			return err
+		}
+		tx.replan(ctx)
+		return nil
+	})
+}
+
+// UnskipDay removes the "no duty" mark from a date. If it is today and the
+// daily assignment already ran, the day stays unassigned until an admin acts.
+func (s *Scheduler) UnskipDay(ctx context.Context, date time.Time) error {
+	if err := s.store.DeleteSkipDay(ctx, time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)); err != nil {
+		return err
+	}
+	s.replan(ctx)
+	return nil
+}
+
+// ChangeMode says what ChangeDutyUser does to the queues of the users involved.
+type ChangeMode string
+
+const (
+	// ChangeModeKeep only changes the user. Queue days stay as they are.
+	ChangeModeKeep ChangeMode = "keep"
+	// ChangeModeRefund gives the previous user back the queue day a voluntary
+	// or admin duty used up, and takes one from the new user's matching queue
+	// if they have any. Round-robin fairness needs no refund, it counts the
+	// duty for whoever is on it.
+	ChangeModeRefund ChangeMode = "refund"
+)
+
+// ParseChangeMode returns the change mode with the given name. An empty name is ChangeModeKeep.
+func ParseChangeMode(name string) (ChangeMode, error) {
+	switch mode := ChangeMode(strings.ToLower(name)); mode {
+	case "", ChangeModeKeep:
+		return ChangeModeKeep, nil
+	case ChangeModeRefund:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("unknown mode %q, expected keep or refund", name)
+	}
 }
 
 // ChangeDutyUser allows admin to change today's or future duty to a different user.
-func (s *Scheduler) ChangeDutyUser(ctx context.Context, date time.Time, newUserID int64) (*store.Duty, error) {
+// A duty planned ahead becomes a real admin assignment; it used up no queue
+// day, so there is nothing to refund. An acknowledged duty has to be
+// acknowledged again by the new user.
+func (s *Scheduler) ChangeDutyUser(ctx context.Context, date time.Time, newUserID int64, mode ChangeMode) (*store.Duty, error) {
 	// Don't allow changing past duties
-	now := time.Now()
-	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	today := s.today()
 	dutyDate := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
 
 	if dutyDate.Before(today) {
-		return nil, fmt.Errorf("cannot change past duties")
+		return nil, fmt.Errorf("cannot change duty: %w", ErrPastDate)
 	}
 
-	existingDuty, err := s.store.GetDutyByDate(ctx, date)
-	if err != nil || existingDuty == nil {
-		return nil, fmt.Errorf("no duty found for this date")
+	// The duty and the refunded queue days change together, and the
+	// reassignment is only announced once both are stored
+	var duty *store.Duty
+	err := s.inTx(ctx, func(tx *Scheduler) error {
+		existingDuty, err := tx.store.GetDutyByDate(ctx, date)
+		if err != nil || existingDuty == nil {
+			return ErrNoDuty
+		}
+
+		// Update the duty; the joined user is the previous one now
+		previousUserID := existingDuty.UserID
+		planned := existingDuty.Status == store.DutyStatusProvisional
+		existingDuty.UserID = newUserID
+		existingDuty.User = nil
+		if planned {
+			existingDuty.AssignmentType = store.AssignmentTypeAdmin
+		}
+		// Whoever takes the duty over hasn't acknowledged it yet
+		if planned || (existingDuty.Status == store.DutyStatusAcknowledged && previousUserID != newUserID) {
+			existingDuty.Status = store.DutyStatusAnnounced
+		}
+		if err := tx.store.UpdateDuty(ctx, existingDuty); err != nil {
+			return fmt.Errorf("failed to update duty: %w", err)
+		}
+
+		if mode == ChangeModeRefund && !planned && previousUserID != newUserID {
+			if err := tx.refundQueueDay(ctx, existingDuty.AssignmentType, previousUserID, newUserID); err != nil {
+				return err
+			}
+		}
+
+		tx.Events.Publish(ctx, events.DutyReassigned{Duty: existingDuty, PreviousUserID: previousUserID})
+		tx.replan(ctx)
+		duty = existingDuty
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
+	return duty, nil
+}
 
-	// Update the duty
-	existingDuty.UserID = newUserID
-	err = s.store.UpdateDuty(ctx, existingDuty)
+// refundQueueDay moves the queue day a duty of the given type used up from
+// the previous user to the new one.
+func (s *Scheduler) refundQueueDay(ctx context.Context, assignType store.AssignmentType, previousUserID, newUserID int64) error {
+	var err error
+	switch assignType {
+	case store.AssignmentTypeVoluntary:
+		if err = s.store.AddToVolunteerQueue(ctx, previousUserID, 1); err == nil {
+			err = s.store.DecrementVolunteerQueue(ctx, newUserID)
+		}
+	case store.AssignmentTypeAdmin:
+		if err = s.store.AddToAdminQueue(ctx, previousUserID, 1); err == nil {
+			err = s.store.DecrementAdminQueue(ctx, newUserID)
+		}
+	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to update duty: %w", err)
+		return fmt.Errorf("failed to refund queue day: %w", err)
 	}
+	return nil
+}
 
-	return existingDuty, nil
+// returnQueueDay gives the user back the queue day a duty of the given type
+// used up.
+func (s *Scheduler) returnQueueDay(ctx context.Context, assignType store.AssignmentType, userID int64) error {
+	var err error
+	switch assignType {
+	case store.AssignmentTypeVoluntary:
+		err = s.store.AddToVolunteerQueue(ctx, userID, 1)
+	case store.AssignmentTypeAdmin:
+		err = s.store.AddToAdminQueue(ctx, userID, 1)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to refund queue day: %w", err)
+	}
+	return nil
+}
+
+// RemoveDuty removes today's or a future duty, leaving the day unassigned.
+// Within the planning horizon the day is planned again.
+func (s *Scheduler) RemoveDuty(ctx context.Context, date time.Time) error {
+	today := s.today()
+	dutyDate := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+
+	if dutyDate.Before(today) {
+		return fmt.Errorf("cannot remove duty: %w", ErrPastDate)
+	}
+
+	return s.inTx(ctx, func(tx *Scheduler) error {
+		existingDuty, err := tx.store.GetDutyByDate(ctx, dutyDate)
+		if err != nil {
+			return fmt.Errorf("failed to check existing duty: %w", err)
+		}
+		if existingDuty == nil {
+			return ErrNoDuty
+		}
+
+		if err := tx.removeDuty(ctx, existingDuty, ""); err != nil {
+			return err
+		}
+		tx.replan(ctx)
+		return nil
+	})
+}
+
+// removeDuty deletes a duty and publishes its removal, with the reason its
+// day is skipped if it is.
+func (s *Scheduler) removeDuty(ctx context.Context, duty *store.Duty, skip store.SkipReason) error {
+	if err := s.store.DeleteDuty(ctx, duty.DutyDate); err != nil {
+		return err
+	}
+	s.Events.Publish(ctx, events.DutyRemoved{Duty: duty, Skip: skip})
+	return nil
 }
@@ -3,231 +3,1233 @@ package scheduler
 import (
 	"context"
 	"errors"
+	"fmt"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/korjavin/dutyassistant/internal/events"
 	"github.com/korjavin/dutyassistant/internal/store"
+	"github.com/korjavin/dutyassistant/internal/store/memory"
 )
 
-// mockStore is a mock implementation of the store.Store interface for testing.
-type mockStore struct {
-	duties               map[string]*store.Duty
-	users                []*store.User
-	nextRoundRobinUser   *store.User
-	roundRobinError      error
-	assignmentCountError error
-	findUserError        error
+// newTestScheduler returns a scheduler backed by an in-memory store seeded
+// with two active users and one inactive user.
+func newTestScheduler(t *testing.T) (*Scheduler, *memory.Store, []*store.User) {
+	t.Helper()
+	ctx := context.Background()
+	s := memory.New()
+
+	users := []*store.User{
+		{TelegramUserID: 1, FirstName: "Alice", IsActive: true},
+		{TelegramUserID: 2, FirstName: "Bob", IsActive: true},
+		{TelegramUserID: 3, FirstName: "Charlie", IsActive: false},
+	}
+	for _, u := range users {
+		if err := s.CreateUser(ctx, u); err != nil {
+			t.Fatalf("Failed to create user: %v", err)
+		}
+	}
+	return NewScheduler(s), s, users
+}
+
+func today() time.Time {
+	now := time.Now()
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
 }
 
-// newMockStore initializes a new mockStore with some default data.
-func newMockStore() *mockStore {
-	return &mockStore{
-		duties: make(map[string]*store.Duty),
-		users: []*store.User{
-			{ID: 1, FirstName: "Alice", IsActive: true},
-			{ID: 2, FirstName: "Bob", IsActive: true, IsAdmin: true},
-			{ID: 3, FirstName: "Charlie", IsActive: false},
-		},
+func TestScheduler_Queues(t *testing.T) {
+	sched, s, users := newTestScheduler(t)
+	ctx := context.Background()
+	alice := users[0]
+
+	if err := sched.AddToVolunteerQueue(ctx, alice.ID, 0); err == nil {
+		t.Error("Expected an error for a non-positive volunteer queue increment")
+	}
+	if err := sched.AddToAdminQueue(ctx, alice.ID, -1); err == nil {
+		t.Error("Expected an error for a non-positive admin queue increment")
+	}
+
+	if err := sched.VolunteerForDuty(ctx, alice, 2); err != nil {
+		t.Fatalf("VolunteerForDuty failed: %v", err)
+	}
+	if err := sched.AssignDuty(ctx, alice, 3); err != nil {
+		t.Fatalf("AssignDuty failed: %v", err)
+	}
+
+	got, _ := s.GetUserByTelegramID(ctx, alice.TelegramUserID)
+	if got.VolunteerQueueDays != 2 || got.AdminQueueDays != 3 {
+		t.Errorf("Expected queues 2/3, got %d/%d", got.VolunteerQueueDays, got.AdminQueueDays)
+	}
+}
+
+func TestScheduler_SetOffDuty(t *testing.T) {
+	sched, s, users := newTestScheduler(t)
+	ctx := context.Background()
+	bob := users[1]
+	start := today()
+
+	if err := sched.SetOffDuty(ctx, bob.ID, start, start.AddDate(0, 0, -1)); err == nil {
+		t.Error("Expected an error when the end date is before the start date")
+	}
+
+	if err := sched.SetOffDuty(ctx, bob.ID, start, start.AddDate(0, 0, 3)); err != nil {
+		t.Fatalf("SetOffDuty failed: %v", err)
+	}
+	if offDuty, _ := s.IsUserOffDuty(ctx, bob.ID, start.AddDate(0, 0, 2)); !offDuty {
+		t.Error("Expected Bob to be off-duty within the period")
+	}
+
+	if err := sched.ClearOffDuty(ctx, bob.ID); err != nil {
+		t.Fatalf("ClearOffDuty failed: %v", err)
+	}
+	if offDuty, _ := s.IsUserOffDuty(ctx, bob.ID, start.AddDate(0, 0, 2)); offDuty {
+		t.Error("Expected Bob not to be off-duty after clearing")
 	}
 }
 
-func (m *mockStore) GetDutyByDate(ctx context.Context, date time.Time) (*store.Duty, error) {
-	key := date.Format("2006-01-02")
-	duty, exists := m.duties[key]
-	if !exists {
-		return nil, errors.New("not found")
+func TestScheduler_AddOffDutyPeriods(t *testing.T) {
+	sched, s, users := newTestScheduler(t)
+	ctx := context.Background()
+	bob := users[1]
+	start := today()
+	s.ReplaceOffDutyPeriods(ctx, bob.ID, store.OffDutySourceICal, []*store.OffDutyPeriod{{StartDate: start, EndDate: start}})
+
+	added, err := sched.AddOffDutyPeriods(ctx, bob.ID, []*store.OffDutyPeriod{
+		{StartDate: start.AddDate(0, 0, 2), EndDate: start.AddDate(0, 0, 3), Source: store.OffDutySourceImport, Summary: "Autumn break"},
+	})
+	if err != nil || len(added) != 1 {
+		t.Fatalf("AddOffDutyPeriods = %v, %v, want the period added", added, err)
+	}
+	// Imported again with another one
+	added, err = sched.AddOffDutyPeriods(ctx, bob.ID, []*store.OffDutyPeriod{
+		{StartDate: start.AddDate(0, 0, 2), EndDate: start.AddDate(0, 0, 3), Source: store.OffDutySourceImport},
+		{StartDate: start.AddDate(0, 0, 9), EndDate: start.AddDate(0, 0, 9), Source: store.OffDutySourceImport},
+	})
+	if err != nil || len(added) != 1 || !added[0].StartDate.Equal(start.AddDate(0, 0, 9)) {
+		t.Fatalf("AddOffDutyPeriods = %v, %v, want only the new period added", added, err)
+	}
+
+	periods, _ := s.ListOffDutyPeriods(ctx, bob.ID)
+	if len(periods) != 3 {
+		t.Errorf("Periods = %+v, want the calendar's and both imported", periods)
+	}
+	for _, day := range []int{0, 2, 3, 9} {
+		if offDuty, _ := s.IsUserOffDuty(ctx, bob.ID, start.AddDate(0, 0, day)); !offDuty {
+			t.Errorf("Expected Bob to be off-duty on day %d", day)
+		}
+	}
+	if _, err := sched.AddOffDutyPeriods(ctx, bob.ID, []*store.OffDutyPeriod{{StartDate: start, EndDate: start.AddDate(0, 0, -1)}}); err == nil {
+		t.Error("Expected an error when the end date is before the start date")
 	}
-	return duty, nil
 }
 
-func (m *mockStore) GetNextRoundRobinUser(ctx context.Context) (*store.User, error) {
-	if m.roundRobinError != nil {
-		return nil, m.roundRobinError
+func TestScheduler_ReplaceCalendarPeriods(t *testing.T) {
+	sched, s, users := newTestScheduler(t)
+	ctx := context.Background()
+	bob := users[1]
+	start := today()
+	var published []events.Event
+	sched.Events = events.NewBus()
+	sched.Events.Subscribe(func(_ context.Context, e events.Event) { published = append(published, e) })
+
+	trip := &store.OffDutyPeriod{StartDate: start.AddDate(0, 0, 2), EndDate: start.AddDate(0, 0, 3), Summary: "Trip"}
+	if err := sched.ReplaceCalendarPeriods(ctx, bob.ID, []*store.OffDutyPeriod{trip}); err != nil {
+		t.Fatalf("ReplaceCalendarPeriods failed: %v", err)
+	}
+	// The next sync keeps the trip and adds a vacation
+	vacation := &store.OffDutyPeriod{StartDate: start.AddDate(0, 0, 9), EndDate: start.AddDate(0, 0, 9), Summary: "Vacation"}
+	if err := sched.ReplaceCalendarPeriods(ctx, bob.ID, []*store.OffDutyPeriod{trip, vacation}); err != nil {
+		t.Fatalf("ReplaceCalendarPeriods failed: %v", err)
+	}
+
+	var announced []time.Time
+	for _, e := range published {
+		if e, ok := e.(events.UserWentOffDuty); ok && e.UserID == bob.ID {
+			announced = append(announced, e.Start)
+		}
+	}
+	if len(announced) != 2 || !announced[0].Equal(trip.StartDate) || !announced[1].Equal(vacation.StartDate) {
+		t.Errorf("Announced off-duty starts = %v, want the trip and the vacation once each", announced)
+	}
+	if offDuty, _ := s.IsUserOffDuty(ctx, bob.ID, start.AddDate(0, 0, 9)); !offDuty {
+		t.Error("Expected Bob to be off-duty on his vacation")
 	}
-	return m.nextRoundRobinUser, nil
 }
 
-func (m *mockStore) CreateDuty(ctx context.Context, duty *store.Duty) error {
-	key := duty.DutyDate.Format("2006-01-02")
-	if _, exists := m.duties[key]; exists {
-		return errors.New("duty already exists on this date")
+func TestScheduler_FilterOffDutyUsers(t *testing.T) {
+	sched, s, users := newTestScheduler(t)
+	ctx := context.Background()
+
+	s.SetOffDuty(ctx, users[0].ID, today(), today())
+
+	available, err := sched.filterOffDutyUsers(ctx, users[:2], today())
+	if err != nil || len(available) != 1 || available[0].ID != users[1].ID {
+		t.Errorf("Expected only Bob to be available, got %+v, %v", available, err)
 	}
-	duty.ID = int64(len(m.duties) + 1)
-	m.duties[key] = duty
-	return nil
 }
 
-func (m *mockStore) UpdateDuty(ctx context.Context, duty *store.Duty) error {
-	key := duty.DutyDate.Format("2006-01-02")
-	m.duties[key] = duty
-	return nil
+// failingOffDutyStore can't tell whether users are off duty.
+type failingOffDutyStore struct {
+	*memory.Store
+}
+
+func (failingOffDutyStore) IsUserOffDuty(context.Context, int64, time.Time) (bool, error) {
+	return false, errors.New("disk full")
+}
+
+func TestScheduler_OffDutyError(t *testing.T) {
+	_, s, users := newTestScheduler(t)
+	ctx := context.Background()
+	sched := NewScheduler(failingOffDutyStore{s})
+	sched.ReturnGrace = 1
+
+	// Nobody is treated as available when it's unknown who is away
+	if available, err := sched.filterOffDutyUsers(ctx, users[:2], today()); err == nil {
+		t.Errorf("Expected an error, got %+v", available)
+	}
+	if rested, err := sched.filterReturning(ctx, users[:2], today()); err == nil {
+		t.Errorf("Expected an error, got %+v", rested)
+	}
+	if reason, err := sched.excluded(ctx, today(), users[0]); err == nil {
+		t.Errorf("Expected an error, got %q", reason)
+	}
+	if user, _, _, err := sched.choose(ctx, sched.store, today()); err == nil {
+		t.Errorf("Expected the pick to fail, got %+v", user)
+	}
 }
 
-func (m *mockStore) FindUserByName(ctx context.Context, name string) (*store.User, error) {
-	if m.findUserError != nil {
-		return nil, m.findUserError
+func TestScheduler_ReturnGrace(t *testing.T) {
+	sched, s, users := newTestScheduler(t)
+	ctx := context.Background()
+	alice, bob := users[0], users[1]
+	berlin, _ := time.LoadLocation("Europe/Berlin")
+	monday := time.Date(2025, 11, 3, 0, 0, 0, 0, time.UTC)
+	sched.now = func() time.Time { return time.Date(2025, 11, 3, 8, 0, 0, 0, berlin) }
+	sched.Horizon = 3
+	sched.ReturnGrace = 1
+
+	// Alice is away Monday and Tuesday; her queue day waits until Thursday
+	if err := sched.SetOffDuty(ctx, alice.ID, monday, monday.AddDate(0, 0, 1)); err != nil {
+		t.Fatalf("SetOffDuty failed: %v", err)
+	}
+	if err := sched.AddToVolunteerQueue(ctx, alice.ID, 1); err != nil {
+		t.Fatalf("AddToVolunteerQueue failed: %v", err)
 	}
-	for _, u := range m.users {
-		if u.FirstName == name {
-			return u, nil
+	for i, want := range []int64{bob.ID, bob.ID, bob.ID, alice.ID} {
+		if duty, _ := s.GetDutyByDate(ctx, monday.AddDate(0, 0, i)); duty == nil || duty.UserID != want {
+			t.Errorf("Day %d: expected user %d, got %+v", i, want, duty)
 		}
 	}
-	return nil, errors.New("user not found")
+
+	// With Bob away on Wednesday too, Alice does it after all
+	wednesday := monday.AddDate(0, 0, 2)
+	if err := sched.SetOffDuty(ctx, bob.ID, wednesday, wednesday); err != nil {
+		t.Fatalf("SetOffDuty failed: %v", err)
+	}
+	if duty, _ := s.GetDutyByDate(ctx, wednesday); duty == nil || duty.UserID != alice.ID || duty.AssignmentType != store.AssignmentTypeRoundRobin {
+		t.Errorf("Expected Alice round-robin on Wednesday, got %+v", duty)
+	}
 }
 
-func (m *mockStore) IncrementAssignmentCount(ctx context.Context, userID int64, lastAssigned time.Time) error {
-	return m.assignmentCountError
+func TestScheduler_SelectRoundRobinUser(t *testing.T) {
+	sched, s, users := newTestScheduler(t)
+	ctx := context.Background()
+	alice, bob := users[0], users[1]
+
+	// Alice did two regular duties, Bob did one regular and two admin-assigned ones.
+	history := []struct {
+		user *store.User
+		typ  store.AssignmentType
+	}{
+		{alice, store.AssignmentTypeRoundRobin},
+		{alice, store.AssignmentTypeVoluntary},
+		{bob, store.AssignmentTypeRoundRobin},
+		{bob, store.AssignmentTypeAdmin},
+		{bob, store.AssignmentTypeAdmin},
+	}
+	for i, h := range history {
+		date := today().AddDate(0, 0, -(i + 1))
+		s.CreateDuty(ctx, &store.Duty{UserID: h.user.ID, DutyDate: date, AssignmentType: h.typ, CreatedAt: time.Now()})
+		s.CompleteDuty(ctx, date)
+	}
+
+	selected, err := sched.selectRoundRobinUser(ctx, sched.store, today(), []*store.User{alice, bob})
+	if err != nil {
+		t.Fatalf("Selecting failed: %v", err)
+	}
+	if selected.ID != bob.ID {
+		t.Errorf("Expected Bob (admin duties excluded from fairness), got %s", selected.FirstName)
+	}
 }
 
-// Stub implementations for remaining Store interface methods
-func (m *mockStore) GetUserByTelegramID(ctx context.Context, id int64) (*store.User, error) {
-	for _, u := range m.users {
-		if u.TelegramUserID == id {
-			return u, nil
+func TestScheduler_RoundRobinCursor(t *testing.T) {
+	sched, s, users := newTestScheduler(t)
+	ctx := context.Background()
+	alice, bob := users[0], users[1]
+
+	// Nobody completes their duties, so the strategy sees a tie every day and
+	// only the cursor keeps the rotation going.
+	berlin, _ := time.LoadLocation("Europe/Berlin")
+	start := time.Date(2025, 11, 3, 12, 0, 0, 0, berlin)
+	want := []int64{alice.ID, bob.ID, alice.ID, bob.ID}
+	for i, userID := range want {
+		sched.now = func() time.Time { return start.AddDate(0, 0, i) }
+		duty, err := sched.AssignTodaysDuty(ctx, false)
+		if err != nil {
+			t.Fatalf("AssignTodaysDuty failed: %v", err)
+		}
+		if duty.UserID != userID {
+			t.Errorf("Day %d: expected user %d, got %d", i, userID, duty.UserID)
 		}
 	}
-	return nil, errors.New("user not found")
+
+	// The cursor is persisted, so a new scheduler on the same store carries on
+	sched = NewScheduler(s)
+	sched.now = func() time.Time { return start.AddDate(0, 0, len(want)) }
+	if duty, _ := sched.AssignTodaysDuty(ctx, false); duty == nil || duty.UserID != alice.ID {
+		t.Errorf("Expected Alice after a restart, got %+v", duty)
+	}
+	states, _ := s.GetRoundRobinStates(ctx, string(store.AssignmentTypeRoundRobin))
+	if len(states) != 2 || states[1].UserID != alice.ID || states[1].AssignmentCount != 3 {
+		t.Errorf("Expected Alice picked last and 3 times, got %+v", states)
+	}
 }
 
-func (m *mockStore) ListActiveUsers(ctx context.Context) ([]*store.User, error) {
-	var active []*store.User
-	for _, u := range m.users {
-		if u.IsActive {
-			active = append(active, u)
-		}
+func TestScheduler_SelectUserWithBalancing(t *testing.T) {
+	sched, _, users := newTestScheduler(t)
+	ctx := context.Background()
+
+	alice := *users[0]
+	bob := *users[1]
+	alice.VolunteerQueueDays = 1
+	bob.VolunteerQueueDays = 3
+
+	selected, err := sched.selectUserWithBalancing(ctx, sched.store, today(), store.AssignmentTypeVoluntary, []*store.User{&alice, &bob})
+	if err != nil {
+		t.Fatalf("Selecting failed: %v", err)
+	}
+	if selected.ID != bob.ID {
+		t.Errorf("Expected the user with the largest queue, got %s", selected.FirstName)
 	}
-	return active, nil
 }
 
-func (m *mockStore) CreateUser(ctx context.Context, user *store.User) error {
-	user.ID = int64(len(m.users) + 1)
-	m.users = append(m.users, user)
-	return nil
+func TestScheduler_ChangeDutyUser(t *testing.T) {
+	sched, s, users := newTestScheduler(t)
+	ctx := context.Background()
+	alice, bob := users[0], users[1]
+	tomorrow := today().AddDate(0, 0, 1)
+
+	if _, err := sched.ChangeDutyUser(ctx, today().AddDate(0, 0, -1), bob.ID, ChangeModeKeep); !errors.Is(err, ErrPastDate) {
+		t.Errorf("Expected ErrPastDate when changing a past duty, got %v", err)
+	}
+	if _, err := sched.ChangeDutyUser(ctx, tomorrow, bob.ID, ChangeModeKeep); !errors.Is(err, ErrNoDuty) {
+		t.Errorf("Expected ErrNoDuty when no duty exists, got %v", err)
+	}
+
+	s.CreateDuty(ctx, &store.Duty{UserID: alice.ID, DutyDate: tomorrow, AssignmentType: store.AssignmentTypeRoundRobin, CreatedAt: time.Now()})
+	duty, err := sched.ChangeDutyUser(ctx, tomorrow, bob.ID, ChangeModeKeep)
+	if err != nil {
+		t.Fatalf("ChangeDutyUser failed: %v", err)
+	}
+	if duty.UserID != bob.ID {
+		t.Errorf("Expected duty to be reassigned to Bob, got user %d", duty.UserID)
+	}
+
+	stored, _ := s.GetDutyByDate(ctx, tomorrow)
+	if stored.UserID != bob.ID {
+		t.Errorf("Expected stored duty to belong to Bob, got user %d", stored.UserID)
+	}
 }
 
-func (m *mockStore) GetUserByName(ctx context.Context, name string) (*store.User, error) {
-	for _, u := range m.users {
-		if u.FirstName == name {
-			return u, nil
+func TestScheduler_ChangeDutyUser_Refund(t *testing.T) {
+	sched, s, users := newTestScheduler(t)
+	ctx := context.Background()
+	alice, bob := users[0], users[1]
+	tomorrow, dayAfter := today().AddDate(0, 0, 1), today().AddDate(0, 0, 2)
+
+	s.AddToVolunteerQueue(ctx, bob.ID, 2)
+	s.CreateDuty(ctx, &store.Duty{UserID: alice.ID, DutyDate: tomorrow, AssignmentType: store.AssignmentTypeVoluntary})
+	s.CreateDuty(ctx, &store.Duty{UserID: alice.ID, DutyDate: dayAfter, AssignmentType: store.AssignmentTypeAdmin})
+
+	if _, err := sched.ChangeDutyUser(ctx, tomorrow, bob.ID, ChangeModeRefund); err != nil {
+		t.Fatalf("ChangeDutyUser failed: %v", err)
+	}
+	// Bob has no admin queue days, so only Alice is credited
+	if _, err := sched.ChangeDutyUser(ctx, dayAfter, bob.ID, ChangeModeRefund); err != nil {
+		t.Fatalf("ChangeDutyUser failed: %v", err)
+	}
+
+	a, _ := s.GetUserByTelegramID(ctx, alice.TelegramUserID)
+	b, _ := s.GetUserByTelegramID(ctx, bob.TelegramUserID)
+	if a.VolunteerQueueDays != 1 || a.AdminQueueDays != 1 {
+		t.Errorf("Expected Alice to get a volunteer and an admin day back, got %d and %d", a.VolunteerQueueDays, a.AdminQueueDays)
+	}
+	if b.VolunteerQueueDays != 1 || b.AdminQueueDays != 0 {
+		t.Errorf("Expected Bob to be charged a volunteer day, got %d and %d", b.VolunteerQueueDays, b.AdminQueueDays)
+	}
+
+	// The default mode leaves queues alone
+	if _, err := sched.ChangeDutyUser(ctx, tomorrow, alice.ID, ChangeModeKeep); err != nil {
+		t.Fatalf("ChangeDutyUser failed: %v", err)
+	}
+	if a, _ := s.GetUserByTelegramID(ctx, alice.TelegramUserID); a.VolunteerQueueDays != 1 {
+		t.Errorf("Expected Alice's volunteer queue to stay at 1, got %d", a.VolunteerQueueDays)
+	}
+}
+
+func TestParseChangeMode(t *testing.T) {
+	for name, want := range map[string]ChangeMode{"": ChangeModeKeep, "keep": ChangeModeKeep, "Refund": ChangeModeRefund} {
+		if got, err := ParseChangeMode(name); err != nil || got != want {
+			t.Errorf("ParseChangeMode(%q) = %q, %v; want %q", name, got, err, want)
 		}
 	}
-	return nil, errors.New("user not found")
+	if _, err := ParseChangeMode("swap"); err == nil {
+		t.Error("Expected an error for an unknown mode")
+	}
 }
 
-func (m *mockStore) ListAllUsers(ctx context.Context) ([]*store.User, error) {
-	return m.users, nil
+func TestScheduler_AssignDutyTo(t *testing.T) {
+	sched, s, users := newTestScheduler(t)
+	ctx := context.Background()
+	alice, bob := users[0], users[1]
+	tomorrow := today().AddDate(0, 0, 1)
+
+	if _, err := sched.AssignDutyTo(ctx, today().AddDate(0, 0, -1), alice.ID, store.AssignmentTypeAdmin); !errors.Is(err, ErrPastDate) {
+		t.Errorf("Expected ErrPastDate when assigning a past duty, got %v", err)
+	}
+
+	// Off-duty users can still be picked by an admin
+	s.SetOffDuty(ctx, alice.ID, tomorrow, tomorrow)
+	duty, err := sched.AssignDutyTo(ctx, tomorrow, alice.ID, store.AssignmentTypeExternal)
+	if err != nil {
+		t.Fatalf("AssignDutyTo failed: %v", err)
+	}
+	stored, _ := s.GetDutyByDate(ctx, tomorrow)
+	if stored == nil || stored.ID != duty.ID || stored.UserID != alice.ID || stored.AssignmentType != store.AssignmentTypeExternal {
+		t.Errorf("Expected an external duty for Alice, got %+v", stored)
+	}
+
+	if _, err := sched.AssignDutyTo(ctx, tomorrow, bob.ID, store.AssignmentTypeAdmin); !errors.Is(err, ErrDutyTaken) {
+		t.Errorf("Expected ErrDutyTaken when the day is already assigned, got %v", err)
+	}
 }
 
-func (m *mockStore) UpdateUser(ctx context.Context, user *store.User) error {
-	return nil
+func TestScheduler_Holds(t *testing.T) {
+	sched, s, users := newTestScheduler(t)
+	ctx := context.Background()
+	alice, bob := users[0], users[1]
+	var published []events.Event
+	sched.Events = events.NewBus()
+	sched.Events.Subscribe(func(_ context.Context, e events.Event) { published = append(published, e) })
+
+	monday := time.Date(2025, 11, 3, 0, 0, 0, 0, time.UTC)
+	sched.now = func() time.Time { return monday.Add(9 * time.Hour) }
+	friday, saturday, sunday := monday.AddDate(0, 0, 4), monday.AddDate(0, 0, 5), monday.AddDate(0, 0, 6)
+
+	for _, until := range []time.Time{monday.AddDate(0, 0, -1), saturday, sunday} {
+		if _, err := sched.AssignHeldDuty(ctx, saturday, alice.ID, until); !errors.Is(err, ErrInvalidHold) {
+			t.Errorf("Expected ErrInvalidHold for a hold until %s, got %v", until.Format("2006-01-02"), err)
+		}
+	}
+	if d, _ := s.GetDutyByDate(ctx, saturday); d != nil {
+		t.Fatalf("Expected an invalid hold not to assign the day, got %+v", d)
+	}
+
+	duty, err := sched.AssignHeldDuty(ctx, saturday, alice.ID, friday)
+	if err != nil {
+		t.Fatalf("AssignHeldDuty failed: %v", err)
+	}
+	if duty.HoldUntil == nil || !duty.HoldUntil.Equal(friday) || duty.AssignmentType != store.AssignmentTypeAdmin {
+		t.Errorf("Expected an admin duty held until Friday, got %+v", duty)
+	}
+	if _, err := sched.AssignHeldDuty(ctx, sunday, bob.ID, friday); err != nil {
+		t.Fatalf("AssignHeldDuty failed: %v", err)
+	}
+	if _, err := sched.HoldDuty(ctx, sunday, nil); err != nil {
+		t.Fatalf("HoldDuty failed to confirm: %v", err)
+	}
+	if _, err := sched.HoldDuty(ctx, friday, nil); !errors.Is(err, ErrNoDuty) {
+		t.Errorf("Expected ErrNoDuty when holding a free day, got %v", err)
+	}
+
+	// Nothing is released while the hold lasts
+	sched.now = func() time.Time { return friday.Add(23 * time.Hour) }
+	if released, err := sched.ReleaseExpiredHolds(ctx); err != nil || len(released) != 0 {
+		t.Fatalf("Expected no release on Friday, got %v, %v", released, err)
+	}
+
+	sched.now = func() time.Time { return saturday.Add(time.Minute) }
+	released, err := sched.ReleaseExpiredHolds(ctx)
+	if err != nil {
+		t.Fatalf("ReleaseExpiredHolds failed: %v", err)
+	}
+	if len(released) != 1 || !released[0].DutyDate.Equal(saturday) {
+		t.Fatalf("Expected Saturday to be released, got %+v", released)
+	}
+	if d, _ := s.GetDutyByDate(ctx, saturday); d != nil {
+		t.Errorf("Expected Saturday to be free again, got %+v", d)
+	}
+	if d, _ := s.GetDutyByDate(ctx, sunday); d == nil || d.UserID != bob.ID {
+		t.Errorf("Expected Bob's confirmed Sunday to stay, got %+v", d)
+	}
+	if e, ok := published[len(published)-1].(events.DutyReleased); !ok || e.Duty.UserID != alice.ID {
+		t.Errorf("Expected DutyReleased for Alice, got %v", published)
+	}
 }
 
-func (m *mockStore) GetUserStats(ctx context.Context, userID int64) (*store.UserStats, error) {
-	return &store.UserStats{}, nil
+func TestScheduler_RemoveDuty(t *testing.T) {
+	sched, s, users := newTestScheduler(t)
+	ctx := context.Background()
+	alice := users[0]
+	yesterday, tomorrow := today().AddDate(0, 0, -1), today().AddDate(0, 0, 1)
+
+	s.CreateDuty(ctx, &store.Duty{UserID: alice.ID, DutyDate: yesterday, AssignmentType: store.AssignmentTypeRoundRobin, CreatedAt: time.Now()})
+	if err := sched.RemoveDuty(ctx, yesterday); !errors.Is(err, ErrPastDate) {
+		t.Errorf("Expected ErrPastDate when removing a past duty, got %v", err)
+	}
+	if err := sched.RemoveDuty(ctx, tomorrow); !errors.Is(err, ErrNoDuty) {
+		t.Errorf("Expected ErrNoDuty when no duty exists, got %v", err)
+	}
+
+	s.CreateDuty(ctx, &store.Duty{UserID: alice.ID, DutyDate: tomorrow, AssignmentType: store.AssignmentTypeVoluntary, CreatedAt: time.Now()})
+	if err := sched.RemoveDuty(ctx, tomorrow); err != nil {
+		t.Fatalf("RemoveDuty failed: %v", err)
+	}
+	if stored, _ := s.GetDutyByDate(ctx, tomorrow); stored != nil {
+		t.Errorf("Expected the duty to be removed, got %+v", stored)
+	}
 }
 
-func (m *mockStore) DeleteDuty(ctx context.Context, date time.Time) error {
-	key := date.Format("2006-01-02")
-	delete(m.duties, key)
-	return nil
+func TestScheduler_AssignTodaysDuty_Cutoff(t *testing.T) {
+	sched, s, _ := newTestScheduler(t)
+	ctx := context.Background()
+	berlin, _ := time.LoadLocation("Europe/Berlin")
+	day := time.Date(2025, 10, 27, 0, 0, 0, 0, time.UTC)
+	sched.Cutoff = 9*time.Hour + 30*time.Minute
+
+	sched.now = func() time.Time { return time.Date(2025, 10, 27, 9, 29, 0, 0, berlin) }
+	if _, err := sched.AssignTodaysDuty(ctx, false); !errors.Is(err, ErrTooEarly) || !strings.Contains(err.Error(), "before 09:30") {
+		t.Fatalf("Expected ErrTooEarly naming 09:30, got %v", err)
+	}
+
+	duty, err := sched.AssignTodaysDuty(ctx, true)
+	if err != nil || duty == nil {
+		t.Fatalf("Expected a forced assignment before the cutoff, got (%+v, %v)", duty, err)
+	}
+
+	// At the cutoff the day is already taken and stays as it is
+	sched.now = func() time.Time { return time.Date(2025, 10, 27, 9, 30, 0, 0, berlin) }
+	again, err := sched.AssignTodaysDuty(ctx, false)
+	if err != nil || again == nil || again.UserID != duty.UserID {
+		t.Errorf("Expected the forced duty to stay, got (%+v, %v)", again, err)
+	}
+	if duties, _ := s.GetDutiesByMonth(ctx, 2025, time.October); len(duties) != 1 || !duties[0].DutyDate.Equal(day) {
+		t.Errorf("Expected exactly one duty, got %+v", duties)
+	}
 }
 
-func (m *mockStore) GetDutiesByMonth(ctx context.Context, year int, month time.Month) ([]*store.Duty, error) {
-	var result []*store.Duty
-	for _, d := range m.duties {
-		result = append(result, d)
+func TestParseCutoff(t *testing.T) {
+	if d, err := ParseCutoff("09:30"); err != nil || d != 9*time.Hour+30*time.Minute {
+		t.Errorf("ParseCutoff(09:30) = %v, %v", d, err)
+	}
+	for _, value := range []string{"", "9", "25:00", "11:60", "11am"} {
+		if _, err := ParseCutoff(value); err == nil {
+			t.Errorf("ParseCutoff(%q) should fail", value)
+		}
 	}
-	return result, nil
 }
 
-func TestScheduler_AssignDutyAdmin(t *testing.T) {
-	mock := newMockStore()
-	scheduler := NewScheduler(mock)
+func TestScheduler_DayRollover(t *testing.T) {
+	sched, s, users := newTestScheduler(t)
 	ctx := context.Background()
+	alice, bob := users[0], users[1]
+	sunday, monday := time.Date(2025, 11, 2, 0, 0, 0, 0, time.UTC), time.Date(2025, 11, 3, 0, 0, 0, 0, time.UTC)
+	sched.now = func() time.Time { return monday.Add(90 * time.Minute) }
 
-	user := &store.User{ID: 1, FirstName: "Alice"}
-	date := time.Now().UTC().Truncate(24 * time.Hour)
-	dateStr := date.Format(dateLayout)
+	if _, err := sched.AssignDutyTo(ctx, sunday, alice.ID, store.AssignmentTypeAdmin); !errors.Is(err, ErrPastDate) {
+		t.Fatalf("Expected ErrPastDate for Sunday without a rollover, got %v", err)
+	}
 
-	// Admin assigns a new duty
-	duty, err := scheduler.AssignDutyAdmin(ctx, user, date)
+	// Until 04:00 on Monday, "today" is still Sunday
+	sched.DayRollover = 4 * time.Hour
+	if got := sched.today(); !got.Equal(sunday) {
+		t.Errorf("today() = %s, want Sunday", got.Format("2006-01-02"))
+	}
+	if _, err := sched.AssignDutyTo(ctx, sunday, alice.ID, store.AssignmentTypeAdmin); err != nil {
+		t.Fatalf("AssignDutyTo for Sunday before the rollover failed: %v", err)
+	}
+	if _, err := sched.ChangeDutyUser(ctx, sunday, bob.ID, ChangeModeKeep); err != nil {
+		t.Fatalf("ChangeDutyUser for Sunday before the rollover failed: %v", err)
+	}
+	if _, err := sched.BackfillDuty(ctx, sunday, alice.ID); !errors.Is(err, ErrNotPastDate) {
+		t.Errorf("Expected ErrNotPastDate when backfilling Sunday before the rollover, got %v", err)
+	}
+
+	sched.now = func() time.Time { return monday.Add(4 * time.Hour) }
+	if got := sched.today(); !got.Equal(monday) {
+		t.Errorf("today() = %s, want Monday", got.Format("2006-01-02"))
+	}
+	if _, err := sched.ChangeDutyUser(ctx, sunday, alice.ID, ChangeModeKeep); !errors.Is(err, ErrPastDate) {
+		t.Errorf("Expected ErrPastDate for Sunday after the rollover, got %v", err)
+	}
+	if d, _ := s.GetDutyByDate(ctx, sunday); d == nil || d.UserID != bob.ID {
+		t.Errorf("Expected Bob on Sunday, got %+v", d)
+	}
+}
+
+func TestScheduler_PlanAhead(t *testing.T) {
+	sched, s, users := newTestScheduler(t)
+	ctx := context.Background()
+	alice, bob := users[0], users[1]
+	berlin, _ := time.LoadLocation("Europe/Berlin")
+	day := time.Date(2025, 11, 3, 0, 0, 0, 0, time.UTC)
+	sched.now = func() time.Time { return time.Date(2025, 11, 3, 8, 0, 0, 0, berlin) }
+	sched.Horizon = 3
+
+	planned := func(want ...int64) {
+		t.Helper()
+		for i, userID := range want {
+			duty, err := s.GetDutyByDate(ctx, day.AddDate(0, 0, i))
+			switch {
+			case err != nil:
+				t.Fatalf("GetDutyByDate failed: %v", err)
+			case userID == 0 && duty != nil:
+				t.Errorf("Day %d: expected no duty, got %+v", i, duty)
+			case userID != 0 && (duty == nil || duty.UserID != userID || duty.Status != store.DutyStatusProvisional):
+				t.Errorf("Day %d: expected a provisional duty for user %d, got %+v", i, userID, duty)
+			}
+		}
+	}
+
+	// Bob's queue day is used up first, then the rotation takes turns
+	if err := sched.AddToVolunteerQueue(ctx, bob.ID, 1); err != nil {
+		t.Fatalf("AddToVolunteerQueue failed: %v", err)
+	}
+	planned(bob.ID, alice.ID, bob.ID, alice.ID)
+	if u, _ := s.GetUserByTelegramID(ctx, bob.TelegramUserID); u.VolunteerQueueDays != 1 {
+		t.Errorf("Planning must not use up queue days, got %d", u.VolunteerQueueDays)
+	}
+
+	// Availability changes replan the days after them
+	if err := sched.SetOffDuty(ctx, alice.ID, day.AddDate(0, 0, 1), day.AddDate(0, 0, 1)); err != nil {
+		t.Fatalf("SetOffDuty failed: %v", err)
+	}
+	planned(bob.ID, bob.ID, alice.ID, alice.ID)
+	if err := sched.SkipDay(ctx, day.AddDate(0, 0, 3), store.SkipReasonHoliday); err != nil {
+		t.Fatalf("SkipDay failed: %v", err)
+	}
+	planned(bob.ID, bob.ID, alice.ID, 0)
+
+	// The daily assignment turns today's plan into a real duty
+	planToday, _ := s.GetDutyByDate(ctx, day)
+	planToday.Note = "Bring the bins out"
+	s.UpdateDuty(ctx, planToday)
+	sched.now = func() time.Time { return time.Date(2025, 11, 3, 11, 0, 0, 0, berlin) }
+	duty, err := sched.AssignTodaysDuty(ctx, false)
 	if err != nil {
-		t.Fatalf("Expected no error, got %v", err)
+		t.Fatalf("AssignTodaysDuty failed: %v", err)
 	}
-	if duty.UserID != user.ID || duty.AssignmentType != store.AssignmentTypeAdmin {
-		t.Errorf("Duty was not assigned correctly. Got %+v", duty)
+	if duty.ID != planToday.ID || duty.UserID != bob.ID || duty.Status != store.DutyStatusAnnounced || duty.Note != planToday.Note {
+		t.Errorf("Expected the planned duty assigned for real with its note, got %+v", duty)
+	}
+	if u, _ := s.GetUserByTelegramID(ctx, bob.TelegramUserID); u.VolunteerQueueDays != 0 {
+		t.Errorf("Expected the queue day used up, got %d", u.VolunteerQueueDays)
 	}
 
-	// Admin overrides a voluntary assignment
-	mock.duties[dateStr] = &store.Duty{ID: 1, UserID: 2, DutyDate: date, AssignmentType: store.AssignmentTypeVoluntary}
-	duty, err = scheduler.AssignDutyAdmin(ctx, user, date)
+	// A planned day is still free for admins
+	if _, err := sched.AssignDutyTo(ctx, day.AddDate(0, 0, 2), bob.ID, store.AssignmentTypeAdmin); err != nil {
+		t.Fatalf("AssignDutyTo on a planned day failed: %v", err)
+	}
+	if duty, _ := s.GetDutyByDate(ctx, day.AddDate(0, 0, 2)); duty.UserID != bob.ID || duty.Status != store.DutyStatusAnnounced {
+		t.Errorf("Expected Bob assigned for real, got %+v", duty)
+	}
+}
+
+func TestScheduler_Forecast(t *testing.T) {
+	sched, s, users := newTestScheduler(t)
+	ctx := context.Background()
+	alice, bob := users[0], users[1]
+	berlin, _ := time.LoadLocation("Europe/Berlin")
+	day := time.Date(2025, 11, 3, 0, 0, 0, 0, time.UTC)
+	sched.now = func() time.Time { return time.Date(2025, 11, 3, 8, 0, 0, 0, berlin) }
+
+	// A real duty stays, the days around it are predicted like a plan
+	if err := s.CreateDuty(ctx, &store.Duty{UserID: alice.ID, DutyDate: day.AddDate(0, 0, 1), AssignmentType: store.AssignmentTypeAdmin, Status: store.DutyStatusAnnounced}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.AddToVolunteerQueue(ctx, bob.ID, 1); err != nil {
+		t.Fatal(err)
+	}
+	forecast, err := sched.Forecast(ctx, 3)
 	if err != nil {
-		t.Fatalf("Expected no error on override, got %v", err)
+		t.Fatalf("Forecast failed: %v", err)
+	}
+	want := []struct {
+		userID int64
+		status store.DutyStatus
+	}{{bob.ID, store.DutyStatusProvisional}, {alice.ID, store.DutyStatusAnnounced}, {alice.ID, store.DutyStatusProvisional}, {bob.ID, store.DutyStatusProvisional}}
+	if len(forecast) != len(want) {
+		t.Fatalf("Expected %d days, got %d", len(want), len(forecast))
+	}
+	for i, d := range forecast {
+		if !d.DutyDate.Equal(day.AddDate(0, 0, i)) || d.UserID != want[i].userID || d.Status != want[i].status {
+			t.Errorf("Day %d: expected user %d (%s), got %+v", i, want[i].userID, want[i].status, d)
+		}
+	}
+
+	// Nothing is stored or used up
+	if d, _ := s.GetDutyByDate(ctx, day); d != nil {
+		t.Errorf("Forecast must not assign, got %+v", d)
 	}
-	if duty.UserID != user.ID || duty.AssignmentType != store.AssignmentTypeAdmin {
-		t.Errorf("Duty was not overridden correctly. Got %+v", duty)
+	if u, _ := s.GetUserByTelegramID(ctx, bob.TelegramUserID); u.VolunteerQueueDays != 1 {
+		t.Errorf("Forecast must not use up queue days, got %d", u.VolunteerQueueDays)
 	}
 }
 
-func TestScheduler_AssignDutyVoluntary(t *testing.T) {
-	mock := newMockStore()
-	scheduler := NewScheduler(mock)
+func TestScheduler_DraftAndPublishMonth(t *testing.T) {
+	sched, s, users := newTestScheduler(t)
 	ctx := context.Background()
+	alice, bob := users[0], users[1]
+	berlin, _ := time.LoadLocation("Europe/Berlin")
+	sched.now = func() time.Time { return time.Date(2025, 10, 20, 8, 0, 0, 0, berlin) }
+	november := time.Date(2025, 11, 1, 0, 0, 0, 0, time.UTC)
+	var published []events.Event
+	sched.Events = events.NewBus()
+	sched.Events.Subscribe(func(_ context.Context, e events.Event) { published = append(published, e) })
 
-	user := &store.User{ID: 1, FirstName: "Alice"}
-	date := time.Now().UTC().Truncate(24 * time.Hour)
-	dateStr := date.Format(dateLayout)
+	if _, err := sched.DraftMonth(ctx, november.AddDate(0, -2, 0)); !errors.Is(err, ErrPastDate) {
+		t.Errorf("Expected ErrPastDate for September, got %v", err)
+	}
+	if _, err := sched.DraftMonth(ctx, november.AddDate(0, 2, 0)); !errors.Is(err, ErrMonthTooFar) {
+		t.Errorf("Expected ErrMonthTooFar for January, got %v", err)
+	}
+	if _, err := sched.PublishMonth(ctx, november); !errors.Is(err, ErrNoDraft) {
+		t.Errorf("Expected ErrNoDraft before drafting, got %v", err)
+	}
 
-	// Volunteer for a free date
-	duty, err := scheduler.AssignDutyVoluntary(ctx, user, date)
-	if err != nil {
-		t.Fatalf("Expected no error, got %v", err)
+	// Bob's queue lasts from today until November 3
+	if err := sched.AddToVolunteerQueue(ctx, bob.ID, 15); err != nil {
+		t.Fatalf("AddToVolunteerQueue failed: %v", err)
+	}
+	draft, err := sched.DraftMonth(ctx, november)
+	if err != nil || len(draft) != 30 {
+		t.Fatalf("Expected a draft of 30 days, got %d (%v)", len(draft), err)
+	}
+	for _, d := range draft {
+		if d.Status != store.DutyStatusProvisional || d.Published {
+			t.Fatalf("Expected only provisional duties in the draft, got %+v", d)
+		}
+	}
+
+	// The draft follows changes until it is published, even without a horizon
+	if err := sched.SetOffDuty(ctx, alice.ID, november.AddDate(0, 0, 10), november.AddDate(0, 0, 10)); err != nil {
+		t.Fatalf("SetOffDuty failed: %v", err)
+	}
+	if d, _ := s.GetDutyByDate(ctx, november.AddDate(0, 0, 10)); d == nil || d.UserID != bob.ID {
+		t.Errorf("Expected Bob on November 11 while Alice is away, got %+v", d)
+	}
+
+	published = nil
+	duties, err := sched.PublishMonth(ctx, november)
+	if err != nil || len(duties) != 30 {
+		t.Fatalf("Expected 30 published duties, got %d (%v)", len(duties), err)
+	}
+	for _, d := range duties {
+		stored, _ := s.GetDutyByDate(ctx, d.DutyDate)
+		if stored.Status != store.DutyStatusAnnounced || !stored.Published {
+			t.Errorf("Expected %s announced and published, got %+v", d.DutyDate.Format("2006-01-02"), stored)
+		}
+	}
+	if u, _ := s.GetUserByTelegramID(ctx, bob.TelegramUserID); u.VolunteerQueueDays != 12 {
+		t.Errorf("Expected the 3 November queue days used up, got %d left", u.VolunteerQueueDays)
+	}
+	if october, _ := s.GetDutyByDate(ctx, november.AddDate(0, 0, -1)); october.Status != store.DutyStatusProvisional || october.UserID != bob.ID {
+		t.Errorf("Expected October to stay planned, got %+v", october)
+	}
+	if len(published) != 1 {
+		t.Fatalf("Expected only the month announced, got %v", published)
+	}
+	if e, ok := published[0].(events.MonthPublished); !ok || !e.Month.Equal(november) || len(e.Duties) != 30 {
+		t.Errorf("Unexpected event %+v", published[0])
+	}
+	if _, err := sched.PublishMonth(ctx, november); !errors.Is(err, ErrNoDraft) {
+		t.Errorf("Expected ErrNoDraft when publishing twice, got %v", err)
+	}
+
+	// Published days aren't announced again by the daily assignment
+	first, _ := s.GetDutyByDate(ctx, november)
+	sched.now = func() time.Time { return time.Date(2025, 11, 1, 11, 0, 0, 0, berlin) }
+	duty, err := sched.AssignTodaysDuty(ctx, false)
+	if err != nil || duty.ID != first.ID || len(published) != 1 {
+		t.Errorf("Expected the published duty kept without an announcement, got (%+v, %v, %v)", duty, err, published)
+	}
+}
+
+// failingQueueStore fails to use up admin queue days.
+type failingQueueStore struct {
+	*memory.Store
+}
+
+func (f failingQueueStore) DecrementAdminQueue(context.Context, int64) error {
+	return errors.New("disk full")
+}
+
+func (f failingQueueStore) RunInTx(ctx context.Context, fn func(tx store.Store) error) error {
+	return f.Store.RunInTx(ctx, func(store.Store) error { return fn(f) })
+}
+
+func TestScheduler_PublishMonth_RollsBack(t *testing.T) {
+	_, s, users := newTestScheduler(t)
+	ctx := context.Background()
+	alice, bob := users[0], users[1]
+	sched := NewScheduler(failingQueueStore{s})
+	berlin, _ := time.LoadLocation("Europe/Berlin")
+	sched.now = func() time.Time { return time.Date(2025, 10, 20, 8, 0, 0, 0, berlin) }
+	november := time.Date(2025, 11, 1, 0, 0, 0, 0, time.UTC)
+	var published []events.Event
+	sched.Events = events.NewBus()
+	sched.Events.Subscribe(func(_ context.Context, e events.Event) { published = append(published, e) })
+
+	s.AddToAdminQueue(ctx, alice.ID, 1)
+	s.CreateDuty(ctx, &store.Duty{UserID: bob.ID, DutyDate: november, AssignmentType: store.AssignmentTypeRoundRobin, Status: store.DutyStatusProvisional})
+	s.CreateDuty(ctx, &store.Duty{UserID: alice.ID, DutyDate: november.AddDate(0, 0, 1), AssignmentType: store.AssignmentTypeAdmin, Status: store.DutyStatusProvisional})
+
+	if _, err := sched.PublishMonth(ctx, november); err == nil {
+		t.Fatal("Expected an error when the queue day can't be used up")
+	}
+	for _, day := range []time.Time{november, november.AddDate(0, 0, 1)} {
+		if d, _ := s.GetDutyByDate(ctx, day); d.Status != store.DutyStatusProvisional || d.Published {
+			t.Errorf("Expected %s to stay a draft, got %+v", day.Format("2006-01-02"), d)
+		}
+	}
+	if u, _ := s.GetUserByTelegramID(ctx, alice.TelegramUserID); u.AdminQueueDays != 1 {
+		t.Errorf("Expected Alice's admin day kept, got %d", u.AdminQueueDays)
+	}
+	if len(published) != 0 {
+		t.Errorf("Expected nothing announced, got %v", published)
+	}
+}
+
+func TestScheduler_ChangeDutyUser_RollsBack(t *testing.T) {
+	_, s, users := newTestScheduler(t)
+	ctx := context.Background()
+	alice, bob := users[0], users[1]
+	sched := NewScheduler(failingQueueStore{s})
+	tomorrow := today().AddDate(0, 0, 1)
+	var published []events.Event
+	sched.Events = events.NewBus()
+	sched.Events.Subscribe(func(_ context.Context, e events.Event) { published = append(published, e) })
+
+	s.CreateDuty(ctx, &store.Duty{UserID: alice.ID, DutyDate: tomorrow, AssignmentType: store.AssignmentTypeAdmin, Status: store.DutyStatusAnnounced})
+
+	if _, err := sched.ChangeDutyUser(ctx, tomorrow, bob.ID, ChangeModeRefund); err == nil {
+		t.Fatal("Expected an error when Bob's queue day can't be charged")
+	}
+	if d, _ := s.GetDutyByDate(ctx, tomorrow); d.UserID != alice.ID {
+		t.Errorf("Expected the duty to stay Alice's, got %+v", d)
+	}
+	if u, _ := s.GetUserByTelegramID(ctx, alice.TelegramUserID); u.AdminQueueDays != 0 {
+		t.Errorf("Expected Alice not to be refunded, got %d admin days", u.AdminQueueDays)
+	}
+	if len(published) != 0 {
+		t.Errorf("Expected nothing announced, got %v", published)
+	}
+}
+
+func TestScheduler_PlanAhead_KeepsPublished(t *testing.T) {
+	sched, s, users := newTestScheduler(t)
+	ctx := context.Background()
+	alice, bob := users[0], users[1]
+	sched.Horizon = 7
+	tomorrow := today().AddDate(0, 0, 1)
+
+	// Bob's queue would have the day, but it was published for Alice
+	s.AddToVolunteerQueue(ctx, bob.ID, 5)
+	s.CreateDuty(ctx, &store.Duty{UserID: alice.ID, DutyDate: tomorrow, AssignmentType: store.AssignmentTypeRoundRobin, Status: store.DutyStatusProvisional, Published: true})
+
+	if err := sched.PlanAhead(ctx); err != nil {
+		t.Fatalf("PlanAhead failed: %v", err)
+	}
+	if d, _ := s.GetDutyByDate(ctx, tomorrow); d.UserID != alice.ID || d.AssignmentType != store.AssignmentTypeRoundRobin {
+		t.Errorf("Expected the published duty left alone, got %+v", d)
+	}
+}
+
+func TestScheduler_DutyStatus(t *testing.T) {
+	sched, s, users := newTestScheduler(t)
+	ctx := context.Background()
+	alice, bob := users[0], users[1]
+	berlin, _ := time.LoadLocation("Europe/Berlin")
+	day := time.Date(2025, 11, 3, 0, 0, 0, 0, time.UTC)
+	sched.now = func() time.Time { return time.Date(2025, 11, 3, 12, 0, 0, 0, berlin) }
+
+	// A duty left open the day before
+	s.CreateDuty(ctx, &store.Duty{UserID: bob.ID, DutyDate: day.AddDate(0, 0, -1), AssignmentType: store.AssignmentTypeRoundRobin, CreatedAt: time.Now()})
+
+	duty, err := sched.AssignTodaysDuty(ctx, false)
+	if err != nil || duty.Status != store.DutyStatusAnnounced {
+		t.Fatalf("Expected an announced duty, got (%+v, %v)", duty, err)
+	}
+	other := alice.ID + bob.ID - duty.UserID
+	if _, err := sched.AcknowledgeDuty(ctx, day, other); !errors.Is(err, ErrNoDuty) {
+		t.Errorf("Expected ErrNoDuty for someone else's duty, got %v", err)
+	}
+	if acked, err := sched.AcknowledgeDuty(ctx, day, duty.UserID); err != nil || acked.Status != store.DutyStatusAcknowledged {
+		t.Fatalf("Expected the duty acknowledged, got (%+v, %v)", acked, err)
+	}
+
+	// The new user has to acknowledge a reassigned duty again
+	changed, err := sched.ChangeDutyUser(ctx, day, other, ChangeModeKeep)
+	if err != nil || changed.Status != store.DutyStatusAnnounced {
+		t.Errorf("Expected the reassigned duty announced again, got (%+v, %v)", changed, err)
 	}
-	if duty.UserID != user.ID || duty.AssignmentType != store.AssignmentTypeVoluntary {
-		t.Errorf("Duty was not assigned correctly. Got %+v", duty)
+
+	if err := sched.CompleteTodaysDuty(ctx); err != nil {
+		t.Fatalf("CompleteTodaysDuty failed: %v", err)
+	}
+	if _, err := sched.AcknowledgeDuty(ctx, day, other); !errors.Is(err, ErrInvalidStatus) {
+		t.Errorf("Expected ErrInvalidStatus for a completed duty, got %v", err)
+	}
+	if d, _ := s.GetDutyByDate(ctx, day); d.Status != store.DutyStatusCompleted {
+		t.Errorf("Expected today's duty completed, got %s", d.Status)
+	}
+	if d, _ := s.GetDutyByDate(ctx, day.AddDate(0, 0, -1)); d.Status != store.DutyStatusMissed {
+		t.Errorf("Expected yesterday's open duty missed, got %s", d.Status)
+	}
+}
+
+func TestScheduler_AssignTodaysDuty_NoAvailableUsers(t *testing.T) {
+	sched, s, users := newTestScheduler(t)
+	ctx := context.Background()
+	berlin, _ := time.LoadLocation("Europe/Berlin")
+	sched.now = func() time.Time { return time.Date(2025, 10, 27, 11, 0, 0, 0, berlin) }
+	day := time.Date(2025, 10, 27, 0, 0, 0, 0, time.UTC)
+
+	for _, u := range users[:2] {
+		s.SetOffDuty(ctx, u.ID, day, day)
 	}
 
-	// Attempt to override an admin assignment (should fail)
-	adminDuty := &store.Duty{ID: 2, UserID: 99, DutyDate: date, AssignmentType: store.AssignmentTypeAdmin}
-	mock.duties[dateStr] = adminDuty
-	_, err = scheduler.AssignDutyVoluntary(ctx, user, date)
-	if err == nil {
-		t.Fatal("Expected an error when trying to override an admin assignment, but got none")
+	duty, err := sched.AssignTodaysDuty(ctx, false)
+	if !errors.Is(err, ErrNoAvailableUsers) {
+		t.Fatalf("Expected ErrNoAvailableUsers, got (%+v, %v)", duty, err)
+	}
+	if stored, _ := s.GetDutyByDate(ctx, day); stored != nil {
+		t.Errorf("Expected the day to stay unassigned, got %+v", stored)
 	}
 }
 
-func TestScheduler_AssignDutyRoundRobin(t *testing.T) {
-	mock := newMockStore()
-	scheduler := NewScheduler(mock)
+func TestScheduler_BackfillDuty(t *testing.T) {
+	sched, s, users := newTestScheduler(t)
 	ctx := context.Background()
+	alice := users[0]
+	yesterday := today().AddDate(0, 0, -1)
 
-	nextUser := &store.User{ID: 3, FirstName: "Charlie"}
-	mock.nextRoundRobinUser = nextUser
-	date := time.Now().UTC().Truncate(24 * time.Hour)
+	if _, err := sched.BackfillDuty(ctx, today(), alice.ID); err == nil {
+		t.Error("Expected an error when backfilling today")
+	}
 
-	// Assign duty for a free date
-	duty, err := scheduler.AssignDutyRoundRobin(ctx, date)
+	s.SetSkipDay(ctx, &store.SkipDay{Date: yesterday, Reason: store.SkipReasonEatingOut, CreatedAt: time.Now()})
+	duty, err := sched.BackfillDuty(ctx, yesterday, alice.ID)
 	if err != nil {
-		t.Fatalf("Expected no error, got %v", err)
+		t.Fatalf("BackfillDuty failed: %v", err)
+	}
+	if duty.UserID != alice.ID || duty.CompletedAt == nil || duty.BackfilledAt == nil {
+		t.Errorf("Expected a completed, backfilled duty for Alice, got %+v", duty)
 	}
-	if duty.UserID != nextUser.ID || duty.AssignmentType != store.AssignmentTypeRoundRobin {
-		t.Errorf("Duty was not assigned correctly. Got %+v", duty)
+	if skip, _ := s.GetSkipDay(ctx, yesterday); skip != nil {
+		t.Errorf("Expected the skip day to be cleared, got %+v", skip)
+	}
+}
+
+func TestScheduler_SetDutyCompletion(t *testing.T) {
+	sched, s, users := newTestScheduler(t)
+	ctx := context.Background()
+	alice, admin := users[0], users[1]
+	yesterday := today().AddDate(0, 0, -1)
+
+	if _, err := sched.SetDutyCompletion(ctx, today().AddDate(0, 0, 1), true, admin.ID); !errors.Is(err, ErrFutureDate) {
+		t.Errorf("Expected ErrFutureDate for tomorrow, got %v", err)
+	}
+	if _, err := sched.SetDutyCompletion(ctx, yesterday, true, admin.ID); !errors.Is(err, ErrNoDuty) {
+		t.Errorf("Expected ErrNoDuty for a free day, got %v", err)
 	}
 
-	// Try to assign again on the same date (should do nothing)
-	duty2, err := scheduler.AssignDutyRoundRobin(ctx, date)
+	s.CreateDuty(ctx, &store.Duty{UserID: alice.ID, DutyDate: yesterday, AssignmentType: store.AssignmentTypeRoundRobin, Status: store.DutyStatusMissed, CreatedAt: time.Now()})
+	duty, err := sched.SetDutyCompletion(ctx, yesterday, true, admin.ID)
 	if err != nil {
-		t.Fatalf("Expected no error on second call, got %v", err)
+		t.Fatalf("SetDutyCompletion failed: %v", err)
 	}
-	if duty.ID != duty2.ID {
-		t.Errorf("Expected the same duty to be returned. Got %+v, want %+v", duty2, duty)
+	if duty.CompletedAt == nil || duty.Status != store.DutyStatusCompleted || duty.CompletionBy != admin.ID {
+		t.Errorf("Expected a duty completed by the admin, got %+v", duty)
 	}
 
-	// Test failure to increment count
-	mock.assignmentCountError = errors.New("db error")
-	date = date.Add(24 * time.Hour) // New date
-	_, err = scheduler.AssignDutyRoundRobin(ctx, date)
-	if err == nil {
-		t.Fatal("Expected an error when incrementing count fails, but got none")
+	// Taking it back makes a past duty missed again
+	if _, err := sched.SetDutyCompletion(ctx, yesterday, false, admin.ID); err != nil {
+		t.Fatalf("SetDutyCompletion failed: %v", err)
 	}
-}
\ No newline at end of file
+	stored, _ := s.GetDutyByDate(ctx, yesterday)
+	if stored.CompletedAt != nil || stored.Status != store.DutyStatusMissed || stored.CompletionBy != admin.ID {
+		t.Errorf("Expected a missed duty cleared by the admin, got %+v", stored)
+	}
+}
+
+func TestScheduler_SkipDay(t *testing.T) {
+	sched, s, users := newTestScheduler(t)
+	ctx := context.Background()
+	berlin, _ := time.LoadLocation("Europe/Berlin")
+	sched.now = func() time.Time { return time.Date(2025, 10, 27, 11, 0, 0, 0, berlin) }
+	day := time.Date(2025, 10, 27, 0, 0, 0, 0, time.UTC)
+	tomorrow := day.AddDate(0, 0, 1)
+
+	if err := sched.SkipDay(ctx, day.AddDate(0, 0, -1), store.SkipReasonHoliday); err == nil {
+		t.Error("Expected an error when skipping a past day")
+	}
+
+	// Skipping an assigned day removes the duty
+	s.CreateDuty(ctx, &store.Duty{UserID: users[0].ID, DutyDate: tomorrow, AssignmentType: store.AssignmentTypeAdmin, CreatedAt: time.Now()})
+	if err := sched.SkipDay(ctx, tomorrow, store.SkipReasonEatingOut); err != nil {
+		t.Fatalf("SkipDay failed: %v", err)
+	}
+	if duty, _ := s.GetDutyByDate(ctx, tomorrow); duty != nil {
+		t.Errorf("Expected the duty on a skipped day to be removed, got %+v", duty)
+	}
+	if _, err := sched.AssignDutyTo(ctx, tomorrow, users[1].ID, store.AssignmentTypeAdmin); err == nil {
+		t.Error("Expected an error when assigning a skipped day")
+	}
+
+	// The daily assignment leaves a skipped day alone
+	if err := sched.SkipDay(ctx, day, store.SkipReasonHoliday); err != nil {
+		t.Fatalf("SkipDay failed: %v", err)
+	}
+	duty, err := sched.AssignTodaysDuty(ctx, false)
+	if err != nil || duty != nil {
+		t.Errorf("Expected no assignment on a skipped day, got (%+v, %v)", duty, err)
+	}
+
+	if err := sched.UnskipDay(ctx, day); err != nil {
+		t.Fatalf("UnskipDay failed: %v", err)
+	}
+	if duty, err := sched.AssignTodaysDuty(ctx, false); err != nil || duty == nil {
+		t.Errorf("Expected an assignment after unskipping, got (%+v, %v)", duty, err)
+	}
+}
+
+func TestScheduler_SkipDay_Refund(t *testing.T) {
+	sched, s, users := newTestScheduler(t)
+	ctx := context.Background()
+	alice, bob := users[0], users[1]
+	days := []time.Time{today().AddDate(0, 0, 1), today().AddDate(0, 0, 2), today().AddDate(0, 0, 3)}
+
+	s.CreateDuty(ctx, &store.Duty{UserID: alice.ID, DutyDate: days[0], AssignmentType: store.AssignmentTypeVoluntary})
+	s.CreateDuty(ctx, &store.Duty{UserID: alice.ID, DutyDate: days[1], AssignmentType: store.AssignmentTypeAdmin})
+	// Planned ahead, it used up no queue day
+	s.CreateDuty(ctx, &store.Duty{UserID: bob.ID, DutyDate: days[2], AssignmentType: store.AssignmentTypeVoluntary, Status: store.DutyStatusProvisional})
+
+	for _, day := range days {
+		if err := sched.SkipDay(ctx, day, store.SkipReasonHoliday); err != nil {
+			t.Fatalf("SkipDay failed: %v", err)
+		}
+	}
+
+	a, _ := s.GetUserByTelegramID(ctx, alice.TelegramUserID)
+	b, _ := s.GetUserByTelegramID(ctx, bob.TelegramUserID)
+	if a.VolunteerQueueDays != 1 || a.AdminQueueDays != 1 {
+		t.Errorf("Expected Alice to get a volunteer and an admin day back, got %d and %d", a.VolunteerQueueDays, a.AdminQueueDays)
+	}
+	if b.VolunteerQueueDays != 0 {
+		t.Errorf("Expected Bob to get nothing back for a planned duty, got %d", b.VolunteerQueueDays)
+	}
+}
+
+func TestScheduler_CompleteTodaysDuty(t *testing.T) {
+	sched, s, users := newTestScheduler(t)
+	ctx := context.Background()
+
+	s.CreateDuty(ctx, &store.Duty{UserID: users[0].ID, DutyDate: today(), AssignmentType: store.AssignmentTypeRoundRobin, CreatedAt: time.Now()})
+	if err := sched.CompleteTodaysDuty(ctx); err != nil {
+		t.Fatalf("CompleteTodaysDuty failed: %v", err)
+	}
+
+	duty, _ := s.GetTodaysDuty(ctx)
+	if duty == nil || duty.CompletedAt == nil {
+		t.Errorf("Expected today's duty to be completed, got %+v", duty)
+	}
+}
+
+func TestScheduler_CompleteTodaysDuty_OpenChecklist(t *testing.T) {
+	sched, s, users := newTestScheduler(t)
+	ctx := context.Background()
+
+	s.CreateDuty(ctx, &store.Duty{UserID: users[0].ID, DutyDate: today(), AssignmentType: store.AssignmentTypeRoundRobin, CreatedAt: time.Now()})
+	dishwasher := &store.ChecklistItem{Text: "Load the dishwasher", Mandatory: true, CreatedAt: time.Now()}
+	s.CreateChecklistItem(ctx, dishwasher)
+	s.CreateChecklistItem(ctx, &store.ChecklistItem{Text: "Wipe the counters", CreatedAt: time.Now()})
+
+	if err := sched.CompleteTodaysDuty(ctx); err != nil {
+		t.Fatalf("CompleteTodaysDuty failed: %v", err)
+	}
+	if duty, _ := s.GetTodaysDuty(ctx); duty == nil || duty.CompletedAt != nil {
+		t.Errorf("Expected today's duty to stay open with a mandatory item unchecked, got %+v", duty)
+	}
+
+	// Optional items don't hold the duty back
+	s.SetChecklistCheck(ctx, &store.ChecklistCheck{Date: today(), ItemID: dishwasher.ID, CheckedAt: time.Now()})
+	if err := sched.CompleteTodaysDuty(ctx); err != nil {
+		t.Fatalf("CompleteTodaysDuty failed: %v", err)
+	}
+	if duty, _ := s.GetTodaysDuty(ctx); duty == nil || duty.CompletedAt == nil {
+		t.Errorf("Expected today's duty to be completed, got %+v", duty)
+	}
+}
+
+func TestScheduler_CompleteTodaysDuty_Guards(t *testing.T) {
+	sched, s, users := newTestScheduler(t)
+	ctx := context.Background()
+
+	var completions int
+	sched.Events = events.NewBus()
+	sched.Events.Subscribe(func(_ context.Context, e events.Event) {
+		if _, ok := e.(events.DutyCompleted); ok {
+			completions++
+		}
+	})
+
+	// Nobody on duty on a regular day is reported
+	if err := sched.CompleteTodaysDuty(ctx); !errors.Is(err, ErrUnassignedDay) {
+		t.Errorf("Expected ErrUnassignedDay without a duty, got %v", err)
+	}
+	s.SetSkipDay(ctx, &store.SkipDay{Date: today(), Reason: store.SkipReasonHoliday, CreatedAt: time.Now()})
+	if err := sched.CompleteTodaysDuty(ctx); err != nil {
+		t.Errorf("Expected a skipped day to be fine, got %v", err)
+	}
+	s.DeleteSkipDay(ctx, today())
+
+	// A completed duty isn't completed again
+	s.CreateDuty(ctx, &store.Duty{UserID: users[0].ID, DutyDate: today(), AssignmentType: store.AssignmentTypeRoundRobin, CreatedAt: time.Now()})
+	for i := 0; i < 2; i++ {
+		if err := sched.CompleteTodaysDuty(ctx); err != nil {
+			t.Fatalf("CompleteTodaysDuty failed: %v", err)
+		}
+	}
+	if completions != 1 {
+		t.Errorf("Expected one completion, got %d", completions)
+	}
+
+	// An admin's /uncomplete sticks
+	if _, err := sched.SetDutyCompletion(ctx, today(), false, users[1].ID); err != nil {
+		t.Fatalf("SetDutyCompletion failed: %v", err)
+	}
+	if err := sched.CompleteTodaysDuty(ctx); err != nil {
+		t.Fatalf("CompleteTodaysDuty failed: %v", err)
+	}
+	if duty, _ := s.GetTodaysDuty(ctx); duty.CompletedAt != nil {
+		t.Errorf("Expected the duty to stay uncompleted, got %+v", duty)
+	}
+}
+
+func TestScheduler_PublishesEvents(t *testing.T) {
+	sched, _, users := newTestScheduler(t)
+	ctx := context.Background()
+	alice, bob := users[0], users[1]
+
+	var published []events.Event
+	sched.Events = events.NewBus()
+	sched.Events.Subscribe(func(_ context.Context, e events.Event) { published = append(published, e) })
+
+	tomorrow := today().AddDate(0, 0, 1)
+	if _, err := sched.AssignDutyTo(ctx, today(), alice.ID, store.AssignmentTypeAdmin); err != nil {
+		t.Fatalf("AssignDutyTo failed: %v", err)
+	}
+	if _, err := sched.ChangeDutyUser(ctx, today(), bob.ID, ChangeModeKeep); err != nil {
+		t.Fatalf("ChangeDutyUser failed: %v", err)
+	}
+	if err := sched.CompleteTodaysDuty(ctx); err != nil {
+		t.Fatalf("CompleteTodaysDuty failed: %v", err)
+	}
+	if err := sched.SetOffDuty(ctx, alice.ID, tomorrow, tomorrow.AddDate(0, 0, 3)); err != nil {
+		t.Fatalf("SetOffDuty failed: %v", err)
+	}
+
+	want := []string{"duty_assigned", "duty_reassigned", "duty_completed", "user_went_off_duty"}
+	if len(published) != len(want) {
+		t.Fatalf("Expected events %v, got %v", want, published)
+	}
+	for i, name := range want {
+		if published[i].Name() != name {
+			t.Errorf("Event %d: expected %s, got %s", i, name, published[i].Name())
+		}
+	}
+	if e := published[1].(events.DutyReassigned); e.PreviousUserID != alice.ID || e.Duty.UserID != bob.ID {
+		t.Errorf("Expected the duty to move from Alice to Bob, got %+v", e)
+	}
+	if e := published[2].(events.DutyCompleted); e.UserID != bob.ID {
+		t.Errorf("Expected Bob's duty to be completed, got %+v", e)
+	}
+}
+
+func TestScheduler_PublishesRemovals(t *testing.T) {
+	sched, s, users := newTestScheduler(t)
+	ctx := context.Background()
+	alice, bob := users[0], users[1]
+	day1, day2 := today().AddDate(0, 0, 1), today().AddDate(0, 0, 2)
+
+	var published []events.Event
+	sched.Events = events.NewBus()
+	sched.Events.Subscribe(func(_ context.Context, e events.Event) {
+		if e.Name() == "duty_removed" {
+			published = append(published, e)
+		}
+	})
+
+	// Blacked out on the day after tomorrow, whatever the date is
+	blackout := fmt.Sprintf("%02d-%02d", day2.Month(), day2.Day())
+	for _, d := range []struct {
+		date time.Time
+		user *store.User
+	}{{today(), alice}, {day1, bob}, {day2, alice}} {
+		if _, err := sched.AssignDutyTo(ctx, d.date, d.user.ID, store.AssignmentTypeAdmin); err != nil {
+			t.Fatalf("AssignDutyTo failed: %v", err)
+		}
+	}
+
+	if err := sched.RemoveDuty(ctx, today()); err != nil {
+		t.Fatalf("RemoveDuty failed: %v", err)
+	}
+	if err := sched.SkipDay(ctx, day1, store.SkipReasonHoliday); err != nil {
+		t.Fatalf("SkipDay failed: %v", err)
+	}
+	if _, _, err := sched.AddBlackout(ctx, blackout, "Family day"); err != nil {
+		t.Fatalf("AddBlackout failed: %v", err)
+	}
+
+	want := []struct {
+		date time.Time
+		user int64
+		skip store.SkipReason
+	}{{today(), alice.ID, ""}, {day1, bob.ID, store.SkipReasonHoliday}, {day2, alice.ID, store.SkipReasonBlackout}}
+	if len(published) != len(want) {
+		t.Fatalf("Expected %d removals, got %v", len(want), published)
+	}
+	for i, w := range want {
+		e := published[i].(events.DutyRemoved)
+		if !e.Duty.DutyDate.Equal(w.date) || e.Duty.UserID != w.user || e.Skip != w.skip {
+			t.Errorf("Removal %d: expected the duty of user %d on %s skipped for %q, got %+v, %q", i, w.user, w.date.Format("2006-01-02"), w.skip, e.Duty, e.Skip)
+		}
+	}
+
+	// A skip that fails removes nothing and publishes nothing
+	published = nil
+	if _, err := sched.AssignDutyTo(ctx, today(), bob.ID, store.AssignmentTypeAdmin); err != nil {
+		t.Fatalf("AssignDutyTo failed: %v", err)
+	}
+	if _, err := s.CompleteDuty(ctx, today()); err != nil {
+		t.Fatalf("CompleteDuty failed: %v", err)
+	}
+	if err := sched.SkipDay(ctx, today(), store.SkipReasonAway); err == nil {
+		t.Fatal("Expected skipping a completed duty to fail")
+	}
+	if len(published) != 0 {
+		t.Errorf("Expected no removal for a failed skip, got %v", published)
+	}
+}
@@ -0,0 +1,249 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/korjavin/dutyassistant/internal/events"
+	"github.com/korjavin/dutyassistant/internal/store"
+)
+
+// Season is a part of every year, e.g. the summer holidays, with schedule
+// settings of its own. Seasons are declared in SEASONS, see ParseSeasons.
+type Season struct {
+	Name string
+	// From and Until are the first and last day of the season, both
+	// included. A season with Until before From spans New Year.
+	From, Until monthDay
+	// Cutoff replaces the scheduler's Cutoff during the season, 0 keeps it.
+	Cutoff time.Duration
+	// WeekendRotation replaces the scheduler's WeekendRotation during the
+	// season, nil keeps it.
+	WeekendRotation *bool
+	// Handles are the users on the roster during the season, nil for
+	// everyone. The others are treated as off duty.
+	Handles []string
+}
+
+// monthDay is a day of the year as month*100 + day, e.g. 1231.
+type monthDay int
+
+func monthDayOf(t time.Time) monthDay { return monthDay(int(t.Month())*100 + t.Day()) }
+
+func (d monthDay) String() string { return fmt.Sprintf("%02d-%02d", d/100, d%100) }
+
+// parseMonthDay parses a day of the year written as MM-DD.
+func parseMonthDay(value string) (monthDay, error) {
+	// A leap year, so that 02-29 is a valid day
+	t, err := time.Parse("2006-01-02", "2024-"+value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid day %q, expected MM-DD", value)
+	}
+	return monthDayOf(t), nil
+}
+
+// Covers reports whether day is in the season.
+func (s *Season) Covers(day time.Time) bool {
+	d := monthDayOf(day)
+	if s.From <= s.Until {
+		return d >= s.From && d <= s.Until
+	}
+	return d >= s.From || d <= s.Until
+}
+
+// Rosters reports whether user is on the roster during the season.
+func (s *Season) Rosters(user *store.User) bool {
+	return s.Handles == nil || slices.Contains(s.Handles, user.Handle)
+}
+
+// String is the season as it is declared.
+func (s *Season) String() string {
+	parts := []string{s.Name, s.From.String() + ".." + s.Until.String()}
+	if s.Cutoff != 0 {
+		parts = append(parts, "time="+formatCutoff(s.Cutoff))
+	}
+	if s.WeekendRotation != nil {
+		parts = append(parts, "weekend_rotation="+strconv.FormatBool(*s.WeekendRotation))
+	}
+	if s.Handles != nil {
+		parts = append(parts, "users="+strings.Join(s.Handles, ","))
+	}
+	return strings.Join(parts, " ")
+}
+
+// ParseSeasons parses seasons separated by semicolons, each written as
+//
+//	<name> <MM-DD>..<MM-DD> [time=HH:MM] [weekend_rotation=true|false] [users=<user>,...]
+//
+// The days are the first and last of the season, time is when the daily
+// assignment runs and users are the handles of those on the roster. Settings
+// left out are those of the rest of the year. Seasons must not overlap. An
+// empty value has no seasons.
+func ParseSeasons(value string) ([]Season, error) {
+	var seasons []Season
+	for _, decl := range strings.Split(value, ";") {
+		fields := strings.Fields(strings.ToLower(decl))
+		if len(fields) == 0 {
+			continue
+		}
+		decl = strings.TrimSpace(decl)
+		if len(fields) < 2 || !strings.Contains(fields[1], "..") {
+			return nil, fmt.Errorf("invalid season %q, expected \"<name> <MM-DD>..<MM-DD> [settings]\"", decl)
+		}
+		from, until, _ := strings.Cut(fields[1], "..")
+		season := Season{Name: fields[0]}
+		var err error
+		if season.From, err = parseMonthDay(from); err != nil {
+			return nil, fmt.Errorf("season %q: %w", decl, err)
+		}
+		if season.Until, err = parseMonthDay(until); err != nil {
+			return nil, fmt.Errorf("season %q: %w", decl, err)
+		}
+		for _, setting := range fields[2:] {
+			key, val, _ := strings.Cut(setting, "=")
+			switch key {
+			case "time":
+				if season.Cutoff, err = ParseCutoff(val); err != nil {
+					return nil, fmt.Errorf("season %q: %w", decl, err)
+				}
+			case "weekend_rotation":
+				on, err := strconv.ParseBool(val)
+				if err != nil {
+					return nil, fmt.Errorf("season %q: invalid weekend_rotation %q, expected true or false", decl, val)
+				}
+				season.WeekendRotation = &on
+			case "users":
+				if val == "" {
+					return nil, fmt.Errorf("season %q: users needs at least one user", decl)
+				}
+				season.Handles = strings.Split(val, ",")
+			default:
+				return nil, fmt.Errorf("season %q: unknown setting %q, expected time, weekend_rotation or users", decl, setting)
+			}
+		}
+		for _, other := range seasons {
+			if other.Name == season.Name {
+				return nil, fmt.Errorf("season %q is declared twice", season.Name)
+			}
+			if overlap(other, season) {
+				return nil, fmt.Errorf("seasons %q and %q overlap", other.Name, season.Name)
+			}
+		}
+		seasons = append(seasons, season)
+	}
+	return seasons, nil
+}
+
+// overlap reports whether two seasons share a day.
+func overlap(a, b Season) bool {
+	for day := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC); day.Year() == 2024; day = day.AddDate(0, 0, 1) {
+		if a.Covers(day) && b.Covers(day) {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckSeasons reports seasons whose users aren't all active users.
+func (s *Scheduler) CheckSeasons(ctx context.Context) error {
+	users, err := s.store.ListActiveUsers(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list active users: %w", err)
+	}
+	for _, season := range s.Seasons {
+		for _, handle := range season.Handles {
+			if !slices.ContainsFunc(users, func(u *store.User) bool { return u.Handle == handle }) {
+				return fmt.Errorf("season %q: there is no active user %q", season.Name, handle)
+			}
+		}
+	}
+	return nil
+}
+
+// SeasonOn returns the season day is in, or nil if it is in none.
+func (s *Scheduler) SeasonOn(day time.Time) *Season {
+	for i := range s.Seasons {
+		if s.Seasons[i].Covers(day) {
+			return &s.Seasons[i]
+		}
+	}
+	return nil
+}
+
+// CutoffOn returns the time of day the duty of day is assigned at.
+func (s *Scheduler) CutoffOn(day time.Time) time.Duration {
+	if season := s.SeasonOn(day); season != nil && season.Cutoff != 0 {
+		return season.Cutoff
+	}
+	return s.cutoff()
+}
+
+// Cutoffs returns every time of day a daily assignment runs at in the year,
+// earliest first.
+func (s *Scheduler) Cutoffs() []time.Duration {
+	cutoffs := []time.Duration{s.cutoff()}
+	for _, season := range s.Seasons {
+		if season.Cutoff != 0 && !slices.Contains(cutoffs, season.Cutoff) {
+			cutoffs = append(cutoffs, season.Cutoff)
+		}
+	}
+	slices.Sort(cutoffs)
+	return cutoffs
+}
+
+// weekendRotation reports whether weekends are a rotation of their own on day.
+func (s *Scheduler) weekendRotation(day time.Time) bool {
+	if season := s.SeasonOn(day); season != nil && season.WeekendRotation != nil {
+		return *season.WeekendRotation
+	}
+	return s.WeekendRotation
+}
+
+// filterSeason removes the users who aren't on the roster during the season
+// of day.
+func (s *Scheduler) filterSeason(users []*store.User, day time.Time) []*store.User {
+	season := s.SeasonOn(day)
+	if season == nil {
+		return users
+	}
+	var rostered []*store.User
+	for _, user := range users {
+		if season.Rosters(user) {
+			rostered = append(rostered, user)
+		}
+	}
+	return rostered
+}
+
+// AnnounceSeason publishes events.SeasonStarted if today is in another
+// season than yesterday, to run once a day just after midnight.
+func (s *Scheduler) AnnounceSeason(ctx context.Context) error {
+	// Just after midnight in Berlin, it may still be yesterday elsewhere
+	berlinLoc, _ := time.LoadLocation("Europe/Berlin")
+	today := Today(s.now().In(berlinLoc), 0)
+	season, previous := s.SeasonOn(today), s.SeasonOn(today.AddDate(0, 0, -1))
+	if season == previous {
+		return nil
+	}
+
+	e := events.SeasonStarted{AssignmentTime: s.CutoffOn(today), WeekendRotation: s.weekendRotation(today)}
+	if previous != nil {
+		e.Previous = previous.Name
+	}
+	if season != nil {
+		e.Season = season.Name
+		if season.Handles != nil {
+			users, err := s.store.ListActiveUsers(ctx)
+			if err != nil {
+				return fmt.Errorf("failed to list active users: %w", err)
+			}
+			e.Users = s.filterSeason(users, today)
+		}
+	}
+	s.Events.Publish(ctx, e)
+	return nil
+}
@@ -0,0 +1,128 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/korjavin/dutyassistant/internal/events"
+)
+
+func TestParseSeasons(t *testing.T) {
+	seasons, err := ParseSeasons("Summer 07-01..08-31 time=10:00 users=bob;; school 09-01..06-30 weekend_rotation=true")
+	if err != nil {
+		t.Fatalf("ParseSeasons failed: %v", err)
+	}
+	want := []string{"summer 07-01..08-31 time=10:00 users=bob", "school 09-01..06-30 weekend_rotation=true"}
+	if len(seasons) != len(want) {
+		t.Fatalf("Expected %v, got %v", want, seasons)
+	}
+	for i := range want {
+		if got := seasons[i].String(); got != want[i] {
+			t.Errorf("Season %d: expected %q, got %q", i, want[i], got)
+		}
+	}
+
+	// The school year spans New Year
+	for date, covered := range map[string]bool{"2025-12-31": true, "2026-01-01": true, "2026-06-30": true, "2026-07-01": false} {
+		day, _ := time.Parse("2006-01-02", date)
+		if got := seasons[1].Covers(day); got != covered {
+			t.Errorf("Covers(%s) = %v, want %v", date, got, covered)
+		}
+	}
+
+	for _, value := range []string{
+		"summer",
+		"summer 07-01",
+		"summer 07-01..13-01",
+		"summer 07-01..08-31 time=25:00",
+		"summer 07-01..08-31 weekend_rotation=maybe",
+		"summer 07-01..08-31 users=",
+		"summer 07-01..08-31 color=red",
+		"summer 07-01..08-31; summer 12-01..12-31",
+		"summer 07-01..08-31; holidays 08-31..09-10",
+		"winter 12-01..02-28; spring 02-01..03-31",
+	} {
+		if _, err := ParseSeasons(value); err == nil {
+			t.Errorf("ParseSeasons(%q) should fail", value)
+		}
+	}
+}
+
+func TestScheduler_Seasons(t *testing.T) {
+	sched, s, users := newTestScheduler(t)
+	ctx := context.Background()
+	berlin, _ := time.LoadLocation("Europe/Berlin")
+	alice, bob := users[0], users[1]
+	seasons, err := ParseSeasons("summer 07-01..08-31 time=10:00 users=" + bob.Handle)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sched.Seasons = seasons
+	if err := sched.CheckSeasons(ctx); err != nil {
+		t.Errorf("CheckSeasons failed: %v", err)
+	}
+
+	if got := sched.Cutoffs(); len(got) != 2 || got[0] != 10*time.Hour || got[1] != DefaultCutoff {
+		t.Errorf("Cutoffs() = %v, want [10h 11h]", got)
+	}
+
+	// In June, the duty is assigned at 11:00 from everyone
+	sched.now = func() time.Time { return time.Date(2025, 6, 30, 10, 30, 0, 0, berlin) }
+	if _, err := sched.AssignTodaysDuty(ctx, false); !errors.Is(err, ErrTooEarly) {
+		t.Errorf("Expected ErrTooEarly before 11:00 in June, got %v", err)
+	}
+	s.AddToVolunteerQueue(ctx, alice.ID, 2)
+	sched.now = func() time.Time { return time.Date(2025, 6, 30, 11, 0, 0, 0, berlin) }
+	if duty, err := sched.AssignTodaysDuty(ctx, false); err != nil || duty.UserID != alice.ID {
+		t.Errorf("Expected Alice's volunteer day in June, got %+v, %v", duty, err)
+	}
+
+	// In summer at 10:00, and only Bob is on the roster
+	sched.now = func() time.Time { return time.Date(2025, 7, 1, 10, 0, 0, 0, berlin) }
+	if duty, err := sched.AssignTodaysDuty(ctx, false); err != nil || duty.UserID != bob.ID {
+		t.Errorf("Expected Bob in summer, got %+v, %v", duty, err)
+	}
+	// With Bob away, nobody is
+	july2 := time.Date(2025, 7, 2, 0, 0, 0, 0, time.UTC)
+	s.SetOffDuty(ctx, bob.ID, july2, july2)
+	sched.now = func() time.Time { return time.Date(2025, 7, 2, 10, 0, 0, 0, berlin) }
+	if _, err := sched.AssignTodaysDuty(ctx, false); !errors.Is(err, ErrNoAvailableUsers) {
+		t.Errorf("Expected ErrNoAvailableUsers with Bob away, got %v", err)
+	}
+}
+
+func TestScheduler_AnnounceSeason(t *testing.T) {
+	sched, _, users := newTestScheduler(t)
+	ctx := context.Background()
+	berlin, _ := time.LoadLocation("Europe/Berlin")
+	bob := users[1]
+	sched.Seasons, _ = ParseSeasons("summer 07-01..08-31 time=10:00 users=" + bob.Handle)
+	var published []events.Event
+	sched.Events = events.NewBus()
+	sched.Events.Subscribe(func(_ context.Context, e events.Event) { published = append(published, e) })
+
+	for _, day := range []int{1, 2} {
+		// Midnight in Berlin is still the day before in UTC
+		sched.now = func() time.Time { return time.Date(2025, 7, day, 0, 0, 0, 0, berlin).UTC() }
+		if err := sched.AnnounceSeason(ctx); err != nil {
+			t.Fatalf("AnnounceSeason failed: %v", err)
+		}
+	}
+	if len(published) != 1 {
+		t.Fatalf("Expected the season announced once, got %v", published)
+	}
+	e, ok := published[0].(events.SeasonStarted)
+	if !ok || e.Season != "summer" || e.Previous != "" || e.AssignmentTime != 10*time.Hour || len(e.Users) != 1 || e.Users[0].ID != bob.ID {
+		t.Errorf("Unexpected event %+v", published[0])
+	}
+
+	sched.now = func() time.Time { return time.Date(2025, 9, 1, 0, 0, 0, 0, berlin) }
+	if err := sched.AnnounceSeason(ctx); err != nil {
+		t.Fatalf("AnnounceSeason failed: %v", err)
+	}
+	if e, ok := published[len(published)-1].(events.SeasonStarted); !ok || e.Season != "" || e.Previous != "summer" || e.AssignmentTime != DefaultCutoff || e.Users != nil {
+		t.Errorf("Expected the end of summer announced, got %+v", published[len(published)-1])
+	}
+}
@@ -0,0 +1,234 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/korjavin/dutyassistant/internal/store"
+)
+
+// Strategy picks who does a round-robin day among the available users.
+type Strategy interface {
+	// Name identifies the strategy in logs and shadow comparisons.
+	Name() string
+	// Pick returns one of users, which is never empty, for the day today.
+	Pick(ctx context.Context, s Store, today time.Time, users []*store.User) (*store.User, error)
+}
+
+// liveStrategy is the strategy the scheduler assigns duties with.
+var liveStrategy Strategy = Window(14)
+
+// Window returns the strategy that picks the user with the fewest completed
+// duties in the last days, not counting admin assignments and external help.
+// Days a user was off duty don't count towards their window, which reaches
+// further back instead, so their standing is frozen while they're away
+// rather than decayed. Ties go to whoever served least recently.
+func Window(days int) Strategy {
+	return window(days)
+}
+
+type window int
+
+func (w window) Name() string { return fmt.Sprintf("window%d", int(w)) }
+
+func (w window) Pick(ctx context.Context, s Store, today time.Time, users []*store.User) (*store.User, error) {
+	// Get completed duties as far back as a window reaches (excluding admin assignments and external help)
+	duties, err := s.GetCompletedDutiesInRange(ctx, today.AddDate(0, 0, -int(w)-maxShield), today)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get completed duties: %w", err)
+	}
+
+	// Count each user's duties in their window and remember when each served last
+	dutyCounts := make(map[int64]int)
+	available := make(map[int64]int)
+	lastDuty := make(map[int64]time.Time)
+	starts := make(map[int64]time.Time)
+	for _, user := range users {
+		off, err := loadOffDuty(ctx, s, user)
+		if err != nil {
+			return nil, err
+		}
+		starts[user.ID], available[user.ID] = onDutyWindow(off, today, int(w))
+	}
+	for _, duty := range duties {
+		start, ok := starts[duty.UserID]
+		if !ok || duty.DutyDate.Before(start) {
+			continue
+		}
+		if duty.AssignmentType != store.AssignmentTypeAdmin && duty.AssignmentType != store.AssignmentTypeExternal {
+			dutyCounts[duty.UserID]++
+			if duty.DutyDate.After(lastDuty[duty.UserID]) {
+				lastDuty[duty.UserID] = duty.DutyDate
+			}
+		}
+	}
+
+	// Find user with the fewest duties per day on duty, which is the fewest
+	// duties unless a window couldn't reach back far enough. Ties go to
+	// whoever served least recently, otherwise the same user would win every
+	// tie once the window starts sliding.
+	fewer := func(a, b *store.User) int {
+		return dutyCounts[a.ID]*max(available[b.ID], 1) - dutyCounts[b.ID]*max(available[a.ID], 1)
+	}
+	selectedUser := users[0]
+	for _, user := range users[1:] {
+		if c := fewer(user, selectedUser); c < 0 || (c == 0 && lastDuty[user.ID].Before(lastDuty[selectedUser.ID])) {
+			selectedUser = user
+		}
+	}
+	return selectedUser, nil
+}
+
+// maxShield is how many off-duty days a window reaches back past at most, so
+// those who were away for months aren't compared by what they did before.
+const maxShield = 90
+
+// onDutyWindow returns where a window of days before today starts for a user
+// when the days they were off duty don't count, and on how many days in it
+// they weren't off duty: days, unless it reached back maxShield days further.
+func onDutyWindow(off offDuty, today time.Time, days int) (time.Time, int) {
+	start, available := today, 0
+	for back := 1; back <= days+maxShield && available < days; back++ {
+		start = today.AddDate(0, 0, -back)
+		if !off.on(start) {
+			available++
+		}
+	}
+	return start, available
+}
+
+// offDuty is when a user is off duty, so that the strategies can look at a
+// few months of days without a query for each.
+type offDuty struct {
+	start, end *time.Time // The user's own off-duty period, if any
+	periods    []*store.OffDutyPeriod
+}
+
+// loadOffDuty loads the off-duty period of user and the imported ones.
+func loadOffDuty(ctx context.Context, s Store, user *store.User) (offDuty, error) {
+	periods, err := s.ListOffDutyPeriods(ctx, user.ID)
+	if err != nil {
+		return offDuty{}, fmt.Errorf("failed to list the off-duty periods of user %d: %w", user.ID, err)
+	}
+	return offDuty{start: user.OffDutyStart, end: user.OffDutyEnd, periods: periods}, nil
+}
+
+// on reports whether the user is off duty on day.
+func (o offDuty) on(day time.Time) bool {
+	key := day.Format("2006-01-02")
+	within := func(start, end time.Time) bool {
+		return key >= start.Format("2006-01-02") && key <= end.Format("2006-01-02")
+	}
+	if o.start != nil && o.end != nil && within(*o.start, *o.end) {
+		return true
+	}
+	for _, p := range o.periods {
+		if within(p.StartDate, p.EndDate) {
+			return true
+		}
+	}
+	return false
+}
+
+// LongestIdle is the strategy that picks whoever served least recently,
+// regardless of how often, looking back up to 90 days. Admin assignments and
+// external help don't count as serving, and days off duty don't count as
+// idle.
+var LongestIdle Strategy = longestIdle{}
+
+type longestIdle struct{}
+
+func (longestIdle) Name() string { return "longest_idle" }
+
+func (longestIdle) Pick(ctx context.Context, s Store, today time.Time, users []*store.User) (*store.User, error) {
+	duties, err := s.GetCompletedDutiesInRange(ctx, today.AddDate(0, 0, -90), today)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get completed duties: %w", err)
+	}
+
+	lastDuty := make(map[int64]time.Time)
+	for _, duty := range duties {
+		if duty.AssignmentType != store.AssignmentTypeAdmin && duty.AssignmentType != store.AssignmentTypeExternal &&
+			duty.DutyDate.After(lastDuty[duty.UserID]) {
+			lastDuty[duty.UserID] = duty.DutyDate
+		}
+	}
+
+	// Idle for the days on duty since the last duty, longest for those
+	// who didn't serve at all
+	idle := make(map[int64]int, len(users))
+	for _, user := range users {
+		last, ok := lastDuty[user.ID]
+		if !ok {
+			idle[user.ID] = math.MaxInt
+			continue
+		}
+		off, err := loadOffDuty(ctx, s, user)
+		if err != nil {
+			return nil, err
+		}
+		for day := last.AddDate(0, 0, 1); day.Before(today); day = day.AddDate(0, 0, 1) {
+			if !off.on(day) {
+				idle[user.ID]++
+			}
+		}
+	}
+
+	selected := users[0]
+	for _, user := range users[1:] {
+		if idle[user.ID] > idle[selected.ID] ||
+			(idle[user.ID] == idle[selected.ID] && lastDuty[user.ID].Before(lastDuty[selected.ID])) {
+			selected = user
+		}
+	}
+	return selected, nil
+}
+
+// ParseStrategy returns the strategy with the given name: "windowN" for
+// Window(N) or "longest_idle".
+func ParseStrategy(name string) (Strategy, error) {
+	if name == LongestIdle.Name() {
+		return LongestIdle, nil
+	}
+	if days, ok := strings.CutPrefix(name, "window"); ok {
+		n, err := strconv.Atoi(days)
+		if err == nil && n > 0 {
+			return Window(n), nil
+		}
+	}
+	return nil, fmt.Errorf("unknown strategy %q, expected windowN (e.g. window30) or longest_idle", name)
+}
+
+// compareShadow asks the shadow strategy whom it would have picked for a
+// round-robin day and records it next to the live pick. Discrepancies are
+// logged; failures only cost the comparison, never the assignment.
+func (s *Scheduler) compareShadow(ctx context.Context, today time.Time, users []*store.User, live *store.User) {
+	if s.Shadow == nil {
+		return
+	}
+	pick, err := s.Shadow.Pick(ctx, s.fairness(s.store, today), today, users)
+	if err != nil {
+		log.Printf("[SHADOW] Failed to pick for %s: %v", today.Format("2006-01-02"), err)
+		return
+	}
+
+	c := &store.ShadowComparison{
+		Date:         today,
+		Strategy:     s.Shadow.Name(),
+		LiveUserID:   live.ID,
+		ShadowUserID: pick.ID,
+		CreatedAt:    s.now().UTC(),
+	}
+	if err := s.store.CreateShadowComparison(ctx, c); err != nil {
+		log.Printf("[SHADOW] Failed to record comparison for %s: %v", today.Format("2006-01-02"), err)
+	}
+	if pick.ID != live.ID {
+		log.Printf("[SHADOW] %s: %s picked %s (%d), %s would have picked %s (%d)",
+			today.Format("2006-01-02"), liveStrategy.Name(), live.FirstName, live.ID, s.Shadow.Name(), pick.FirstName, pick.ID)
+	}
+}
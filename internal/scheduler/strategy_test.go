@@ -0,0 +1,195 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/korjavin/dutyassistant/internal/store"
+	"github.com/korjavin/dutyassistant/internal/store/memory"
+)
+
+// fixedStrategy always picks the user with the given ID if available.
+type fixedStrategy int64
+
+func (fixedStrategy) Name() string { return "fixed" }
+
+func (f fixedStrategy) Pick(ctx context.Context, s Store, today time.Time, users []*store.User) (*store.User, error) {
+	for _, u := range users {
+		if u.ID == int64(f) {
+			return u, nil
+		}
+	}
+	return users[0], nil
+}
+
+// pick returns whom st picks, failing the test if it can't.
+func pick(t *testing.T, st Strategy, s Store, day time.Time, users []*store.User) *store.User {
+	t.Helper()
+	u, err := st.Pick(context.Background(), s, day, users)
+	if err != nil {
+		t.Fatalf("%s failed: %v", st.Name(), err)
+	}
+	return u
+}
+
+func TestParseStrategy(t *testing.T) {
+	for _, name := range []string{"window14", "window30", "longest_idle"} {
+		st, err := ParseStrategy(name)
+		if err != nil {
+			t.Errorf("ParseStrategy(%q) failed: %v", name, err)
+			continue
+		}
+		if st.Name() != name {
+			t.Errorf("ParseStrategy(%q).Name() = %q", name, st.Name())
+		}
+	}
+	for _, name := range []string{"", "window", "window0", "window-3", "fastest"} {
+		if _, err := ParseStrategy(name); err == nil {
+			t.Errorf("ParseStrategy(%q) should fail", name)
+		}
+	}
+}
+
+func TestLongestIdle(t *testing.T) {
+	sched, s, users := newTestScheduler(t)
+	ctx := context.Background()
+	alice, bob := users[0], users[1]
+	day := today()
+
+	// Alice served twice long ago, Bob once yesterday: Window(14) only sees
+	// Bob's duty, LongestIdle sees that Alice has been idle longer.
+	for _, d := range []struct {
+		user *store.User
+		ago  int
+	}{{alice, 30}, {alice, 29}, {bob, 1}} {
+		date := day.AddDate(0, 0, -d.ago)
+		s.CreateDuty(ctx, &store.Duty{UserID: d.user.ID, DutyDate: date, AssignmentType: store.AssignmentTypeRoundRobin})
+		s.CompleteDuty(ctx, date)
+	}
+
+	available := []*store.User{alice, bob}
+	if got := pick(t, LongestIdle, sched.store, day, available); got.ID != alice.ID {
+		t.Errorf("LongestIdle picked %s, expected Alice", got.FirstName)
+	}
+	if got := pick(t, Window(60), sched.store, day, available); got.ID != bob.ID {
+		t.Errorf("Window(60) picked %s, expected Bob with fewer duties", got.FirstName)
+	}
+}
+
+func TestStrategies_OffDutyShield(t *testing.T) {
+	sched, s, users := newTestScheduler(t)
+	ctx := context.Background()
+	alice, bob := users[0], users[1]
+	day := today()
+
+	// Alice did six duties, then was off duty for the last ten days while
+	// Bob did four. Counting the days she was away would make her look idle.
+	for _, d := range []struct {
+		user *store.User
+		ago  int
+	}{{alice, 22}, {alice, 20}, {alice, 18}, {alice, 16}, {alice, 14}, {alice, 12}, {bob, 9}, {bob, 7}, {bob, 5}, {bob, 3}} {
+		date := day.AddDate(0, 0, -d.ago)
+		s.CreateDuty(ctx, &store.Duty{UserID: d.user.ID, DutyDate: date, AssignmentType: store.AssignmentTypeRoundRobin})
+		s.CompleteDuty(ctx, date)
+	}
+	if err := s.SetOffDuty(ctx, alice.ID, day.AddDate(0, 0, -10), day.AddDate(0, 0, -1)); err != nil {
+		t.Fatalf("SetOffDuty failed: %v", err)
+	}
+	// Strategies get the users as stored, with their off-duty period
+	alice, _ = s.GetUserByTelegramID(ctx, alice.TelegramUserID)
+
+	available := []*store.User{alice, bob}
+	// Her 14 days on duty reach back to before she left, with six duties
+	if got := pick(t, Window(14), sched.store, day, available); got.ID != bob.ID {
+		t.Errorf("Window(14) picked %s, expected Bob with fewer duties on his days on duty", got.FirstName)
+	}
+	// She was idle one day on duty since her last duty, Bob two
+	if got := pick(t, LongestIdle, sched.store, day, available); got.ID != bob.ID {
+		t.Errorf("LongestIdle picked %s, expected Bob who was idle longer on duty", got.FirstName)
+	}
+
+	// Without the absence, she would be due
+	if err := s.ClearOffDuty(ctx, alice.ID); err != nil {
+		t.Fatalf("ClearOffDuty failed: %v", err)
+	}
+	alice, _ = s.GetUserByTelegramID(ctx, alice.TelegramUserID)
+	available = []*store.User{alice, bob}
+	if got := pick(t, Window(14), sched.store, day, available); got.ID != alice.ID {
+		t.Errorf("Window(14) picked %s, expected Alice", got.FirstName)
+	}
+}
+
+// failingPeriodsStore can't list off-duty periods.
+type failingPeriodsStore struct {
+	*memory.Store
+}
+
+func (failingPeriodsStore) ListOffDutyPeriods(context.Context, int64) ([]*store.OffDutyPeriod, error) {
+	return nil, errors.New("disk full")
+}
+
+func TestStrategies_OffDutyError(t *testing.T) {
+	_, s, users := newTestScheduler(t)
+	ctx := context.Background()
+	alice, bob := users[0], users[1]
+	day := today()
+	yesterday := day.AddDate(0, 0, -1)
+	s.CreateDuty(ctx, &store.Duty{UserID: alice.ID, DutyDate: yesterday, AssignmentType: store.AssignmentTypeRoundRobin})
+	s.CompleteDuty(ctx, yesterday)
+
+	for _, st := range []Strategy{Window(14), LongestIdle} {
+		if u, err := st.Pick(ctx, failingPeriodsStore{s}, day, []*store.User{alice, bob}); err == nil {
+			t.Errorf("Expected %s to fail without the off-duty periods, picked %+v", st.Name(), u)
+		}
+	}
+}
+
+func TestScheduler_ShadowComparisons(t *testing.T) {
+	sched, s, users := newTestScheduler(t)
+	ctx := context.Background()
+	alice, bob := users[0], users[1]
+	sched.Shadow = fixedStrategy(bob.ID)
+
+	berlin, _ := time.LoadLocation("Europe/Berlin")
+	start := time.Date(2025, 10, 27, 12, 0, 0, 0, berlin)
+	for i := 0; i < 2; i++ {
+		sched.now = func() time.Time { return start.AddDate(0, 0, i) }
+		if _, err := sched.AssignTodaysDuty(ctx, false); err != nil {
+			t.Fatalf("AssignTodaysDuty failed: %v", err)
+		}
+		sched.CompleteTodaysDuty(ctx)
+	}
+
+	comparisons, err := s.ListShadowComparisons(ctx, time.Date(2025, 10, 27, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("ListShadowComparisons failed: %v", err)
+	}
+	if len(comparisons) != 2 {
+		t.Fatalf("Expected a comparison per round-robin day, got %+v", comparisons)
+	}
+	for _, c := range comparisons {
+		if c.Strategy != "fixed" || c.ShadowUserID != bob.ID {
+			t.Errorf("Expected the shadow to pick Bob, got %+v", c)
+		}
+	}
+	// The live strategy alternates, so exactly one day is a discrepancy
+	if comparisons[0].LiveUserID == comparisons[1].LiveUserID {
+		t.Errorf("Expected the live strategy to alternate, got %+v", comparisons)
+	}
+
+	// The shadow never changes the schedule
+	duty, _ := s.GetDutyByDate(ctx, time.Date(2025, 10, 27, 0, 0, 0, 0, time.UTC))
+	if duty == nil || duty.UserID != comparisons[0].LiveUserID {
+		t.Errorf("Expected the duty to go to the live pick, got %+v", duty)
+	}
+
+	// Queue days aren't decided by a strategy and aren't compared
+	sched.AddToVolunteerQueue(ctx, alice.ID, 1)
+	sched.now = func() time.Time { return start.AddDate(0, 0, 2) }
+	sched.AssignTodaysDuty(ctx, false)
+	if comparisons, _ := s.ListShadowComparisons(ctx, time.Time{}); len(comparisons) != 2 {
+		t.Errorf("Expected no comparison for a volunteer day, got %+v", comparisons)
+	}
+}
@@ -0,0 +1,137 @@
+// Package badge awards achievements for doing duties: a first voluntary duty,
+// ten completed duties and months in which every duty was completed. The
+// rules are evaluated whenever a duty is completed, and every new badge is
+// published on the event bus so the group hears about it.
+package badge
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/korjavin/dutyassistant/internal/events"
+	"github.com/korjavin/dutyassistant/internal/store"
+)
+
+// dutiesForTen is how many completed duties BadgeTenDuties takes.
+const dutiesForTen = 10
+
+// Store is the part of store.Store the badge rules read and write.
+type Store interface {
+	store.UserStore
+	store.DutyStore
+}
+
+// Service evaluates the badge rules and awards badges.
+type Service struct {
+	store  Store
+	events *events.Bus
+	now    func() time.Time
+}
+
+// New creates a new Service. New badges are published on bus, which may be nil.
+func New(s Store, bus *events.Bus) *Service {
+	return &Service{store: s, events: bus, now: time.Now}
+}
+
+// HandleEvent evaluates the badge rules when a duty is completed. It is
+// subscribed to the scheduler's event bus.
+func (s *Service) HandleEvent(ctx context.Context, e events.Event) {
+	completed, ok := e.(events.DutyCompleted)
+	if !ok {
+		return
+	}
+	if _, err := s.Evaluate(ctx, completed.Date, completed.UserID); err != nil {
+		log.Printf("[BADGE] Failed to evaluate badges for user %d: %v", completed.UserID, err)
+	}
+}
+
+// Evaluate awards the badges earned by completing the duty on date, and the
+// perfect months of everyone in the month before date, which is over by
+// then. It returns the badges that are new.
+func (s *Service) Evaluate(ctx context.Context, date time.Time, userID int64) ([]*store.Badge, error) {
+	var earned []*store.Badge
+
+	duty, err := s.store.GetDutyByDate(ctx, date)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get duty: %w", err)
+	}
+	if duty != nil && duty.UserID == userID && duty.AssignmentType == store.AssignmentTypeVoluntary {
+		earned = append(earned, &store.Badge{UserID: userID, Kind: store.BadgeFirstVolunteer})
+	}
+
+	stats, err := s.store.GetUserStats(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user stats: %w", err)
+	}
+	if stats.Completed >= dutiesForTen {
+		earned = append(earned, &store.Badge{UserID: userID, Kind: store.BadgeTenDuties})
+	}
+
+	perfect, err := s.perfectMonths(ctx, time.Date(date.Year(), date.Month(), 1, 0, 0, 0, 0, time.UTC).AddDate(0, -1, 0))
+	if err != nil {
+		return nil, err
+	}
+	earned = append(earned, perfect...)
+
+	var awarded []*store.Badge
+	for _, b := range earned {
+		b.AwardedAt = s.now()
+		isNew, err := s.store.AwardBadge(ctx, b)
+		if err != nil {
+			return awarded, fmt.Errorf("failed to award %s badge: %w", b.Kind, err)
+		}
+		if isNew {
+			awarded = append(awarded, b)
+			s.events.Publish(ctx, events.BadgeAwarded{Badge: b})
+		}
+	}
+	return awarded, nil
+}
+
+// perfectMonths returns a perfect month badge for every user all of whose
+// duties in the month starting on month were completed.
+func (s *Service) perfectMonths(ctx context.Context, month time.Time) ([]*store.Badge, error) {
+	duties, err := s.store.GetDutiesByMonth(ctx, month.Year(), month.Month())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get duties of %s: %w", month.Format("2006-01"), err)
+	}
+
+	perfect := make(map[int64]bool)
+	var users []int64
+	for _, d := range duties {
+		if _, seen := perfect[d.UserID]; !seen {
+			perfect[d.UserID] = true
+			users = append(users, d.UserID)
+		}
+		if d.Status != store.DutyStatusCompleted {
+			perfect[d.UserID] = false
+		}
+	}
+
+	var badges []*store.Badge
+	for _, id := range users {
+		if perfect[id] {
+			badges = append(badges, &store.Badge{UserID: id, Kind: store.BadgePerfectMonth, Period: month.Format("2006-01")})
+		}
+	}
+	return badges, nil
+}
+
+// Title describes a badge for humans, e.g. "🌟 Perfect month (October 2025)".
+func Title(b *store.Badge) string {
+	switch b.Kind {
+	case store.BadgeFirstVolunteer:
+		return "🙋 First volunteer"
+	case store.BadgeTenDuties:
+		return "🔟 Ten duties"
+	case store.BadgePerfectMonth:
+		if month, err := time.Parse("2006-01", b.Period); err == nil {
+			return fmt.Sprintf("🌟 Perfect month (%s)", month.Format("January 2006"))
+		}
+		return "🌟 Perfect month"
+	default:
+		return "🏅 " + string(b.Kind)
+	}
+}
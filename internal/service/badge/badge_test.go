@@ -0,0 +1,102 @@
+package badge
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/korjavin/dutyassistant/internal/events"
+	"github.com/korjavin/dutyassistant/internal/store"
+	"github.com/korjavin/dutyassistant/internal/store/memory"
+)
+
+func date(y int, m time.Month, d int) time.Time {
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+func kinds(badges []*store.Badge) map[store.BadgeKind]bool {
+	m := make(map[store.BadgeKind]bool)
+	for _, b := range badges {
+		m[b.Kind] = true
+	}
+	return m
+}
+
+func TestService_Evaluate(t *testing.T) {
+	ctx := context.Background()
+	s := memory.New()
+	var announced []*store.Badge
+	bus := events.NewBus()
+	bus.Subscribe(func(_ context.Context, e events.Event) {
+		if e, ok := e.(events.BadgeAwarded); ok {
+			announced = append(announced, e.Badge)
+		}
+	})
+	svc := New(s, bus)
+
+	alice := &store.User{TelegramUserID: 1, FirstName: "Alice", IsActive: true}
+	bob := &store.User{TelegramUserID: 2, FirstName: "Bob", IsActive: true}
+	s.CreateUser(ctx, alice)
+	s.CreateUser(ctx, bob)
+
+	// Alice did all her October duties, Bob missed one
+	for day, userID := range map[int]int64{6: alice.ID, 7: bob.ID, 8: alice.ID, 9: bob.ID} {
+		status := store.DutyStatusCompleted
+		if day == 9 {
+			status = store.DutyStatusMissed
+		}
+		s.CreateDuty(ctx, &store.Duty{UserID: userID, DutyDate: date(2025, time.October, day), AssignmentType: store.AssignmentTypeRoundRobin, Status: status})
+	}
+
+	// Alice's first voluntary duty in November also closes October
+	s.CreateDuty(ctx, &store.Duty{UserID: alice.ID, DutyDate: date(2025, time.November, 3), AssignmentType: store.AssignmentTypeVoluntary})
+	s.CompleteDuty(ctx, date(2025, time.November, 3))
+	awarded, err := svc.Evaluate(ctx, date(2025, time.November, 3), alice.ID)
+	if err != nil {
+		t.Fatalf("Evaluate failed: %v", err)
+	}
+	if got := kinds(awarded); len(awarded) != 2 || !got[store.BadgeFirstVolunteer] || !got[store.BadgePerfectMonth] {
+		t.Errorf("Expected a first volunteer and a perfect month badge, got %+v", awarded)
+	}
+	for _, b := range awarded {
+		if b.UserID != alice.ID {
+			t.Errorf("Expected Alice's badges only, got %+v", b)
+		}
+	}
+	if len(announced) != 2 {
+		t.Errorf("Expected both badges to be published, got %d", len(announced))
+	}
+
+	// Badges are only awarded once
+	if awarded, _ := svc.Evaluate(ctx, date(2025, time.November, 3), alice.ID); len(awarded) != 0 {
+		t.Errorf("Expected no new badges, got %+v", awarded)
+	}
+
+	// Ten completed duties
+	for day := 4; day <= 10; day++ {
+		s.CreateDuty(ctx, &store.Duty{UserID: alice.ID, DutyDate: date(2025, time.November, day), AssignmentType: store.AssignmentTypeRoundRobin, Status: store.DutyStatusCompleted})
+	}
+	awarded, err = svc.Evaluate(ctx, date(2025, time.November, 10), alice.ID)
+	if err != nil {
+		t.Fatalf("Evaluate failed: %v", err)
+	}
+	if len(awarded) != 1 || awarded[0].Kind != store.BadgeTenDuties {
+		t.Errorf("Expected a ten duties badge, got %+v", awarded)
+	}
+	if badges, _ := s.ListBadges(ctx, bob.ID); len(badges) != 0 {
+		t.Errorf("Expected Bob to have no badges, got %+v", badges)
+	}
+}
+
+func TestTitle(t *testing.T) {
+	tests := map[string]*store.Badge{
+		"🙋 First volunteer":              {Kind: store.BadgeFirstVolunteer},
+		"🔟 Ten duties":                   {Kind: store.BadgeTenDuties},
+		"🌟 Perfect month (October 2025)": {Kind: store.BadgePerfectMonth, Period: "2025-10"},
+	}
+	for want, b := range tests {
+		if got := Title(b); got != want {
+			t.Errorf("Title(%+v) = %q, want %q", b, got, want)
+		}
+	}
+}
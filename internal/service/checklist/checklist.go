@@ -0,0 +1,190 @@
+// Package checklist manages the tasks of a duty, like "load the dishwasher".
+// Items apply to every duty or to the duties of one assignment type; whoever
+// is on duty checks them off, and a duty with mandatory items left open isn't
+// completed.
+package checklist
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/korjavin/dutyassistant/internal/store"
+)
+
+var (
+	// ErrNotFound is returned when a checklist item doesn't exist or doesn't
+	// apply to the duty.
+	ErrNotFound = errors.New("checklist item not found")
+	// ErrNoDuty is returned when checking off an item of a duty that isn't the user's.
+	ErrNoDuty = errors.New("user is not on duty that day")
+	// ErrInvalidType is returned for an assignment type items can't apply to.
+	ErrInvalidType = errors.New("unknown assignment type")
+)
+
+// Types are the assignment types items can be limited to.
+var Types = []store.AssignmentType{
+	store.AssignmentTypeRoundRobin,
+	store.AssignmentTypeVoluntary,
+	store.AssignmentTypeAdmin,
+	store.AssignmentTypeExternal,
+}
+
+// Entry is an item of a duty's checklist and whether it is checked off.
+type Entry struct {
+	Item    *store.ChecklistItem
+	Checked bool
+}
+
+// Service manages checklist items and their checks.
+type Service struct {
+	store store.DutyStore
+	now   func() time.Time
+}
+
+// New creates a new Service.
+func New(s store.DutyStore) *Service {
+	return &Service{store: s, now: time.Now}
+}
+
+// AddItem stores a checklist item for the duties of assignmentType, or for
+// every duty if it is empty.
+func (s *Service) AddItem(ctx context.Context, assignmentType store.AssignmentType, text string, mandatory bool) (*store.ChecklistItem, error) {
+	if assignmentType != "" && !validType(assignmentType) {
+		return nil, fmt.Errorf("%w %q", ErrInvalidType, assignmentType)
+	}
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil, errors.New("the item text is empty")
+	}
+
+	item := &store.ChecklistItem{AssignmentType: assignmentType, Text: text, Mandatory: mandatory, CreatedAt: s.now().UTC()}
+	if err := s.store.CreateChecklistItem(ctx, item); err != nil {
+		return nil, fmt.Errorf("failed to create checklist item: %w", err)
+	}
+	return item, nil
+}
+
+// Items returns all checklist items, oldest first.
+func (s *Service) Items(ctx context.Context) ([]*store.ChecklistItem, error) {
+	items, err := s.store.ListChecklistItems(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list checklist items: %w", err)
+	}
+	return items, nil
+}
+
+// DeleteItem removes a checklist item, or returns ErrNotFound.
+func (s *Service) DeleteItem(ctx context.Context, id int64) error {
+	if _, err := s.item(ctx, id); err != nil {
+		return err
+	}
+	if err := s.store.DeleteChecklistItem(ctx, id); err != nil {
+		return fmt.Errorf("failed to delete checklist item: %w", err)
+	}
+	return nil
+}
+
+// ForDuty returns the checklist of a duty: the items that apply to its
+// assignment type, in the order they were added.
+func (s *Service) ForDuty(ctx context.Context, duty *store.Duty) ([]Entry, error) {
+	items, err := s.Items(ctx)
+	if err != nil {
+		return nil, err
+	}
+	checks, err := s.store.GetChecklistChecks(ctx, duty.DutyDate)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get checklist checks: %w", err)
+	}
+	checked := make(map[int64]bool, len(checks))
+	for _, c := range checks {
+		checked[c.ItemID] = true
+	}
+
+	var entries []Entry
+	for _, item := range items {
+		if appliesTo(item, duty) {
+			entries = append(entries, Entry{Item: item, Checked: checked[item.ID]})
+		}
+	}
+	return entries, nil
+}
+
+// Toggle checks off an item of the duty on date, or unchecks it if it was
+// checked, and returns the updated checklist. Only the user on duty can.
+func (s *Service) Toggle(ctx context.Context, date time.Time, userID, itemID int64) (*store.Duty, []Entry, error) {
+	duty, err := s.store.GetDutyByDate(ctx, date)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get duty: %w", err)
+	}
+	if duty == nil || duty.UserID != userID {
+		return nil, nil, ErrNoDuty
+	}
+	entries, err := s.ForDuty(ctx, duty)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for i, e := range entries {
+		if e.Item.ID != itemID {
+			continue
+		}
+		if e.Checked {
+			err = s.store.DeleteChecklistCheck(ctx, duty.DutyDate, itemID)
+		} else {
+			err = s.store.SetChecklistCheck(ctx, &store.ChecklistCheck{Date: duty.DutyDate, ItemID: itemID, CheckedAt: s.now().UTC()})
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to update checklist: %w", err)
+		}
+		entries[i].Checked = !e.Checked
+		return duty, entries, nil
+	}
+	return nil, nil, ErrNotFound
+}
+
+// Pending returns the mandatory items of a duty that aren't checked off yet.
+func (s *Service) Pending(ctx context.Context, duty *store.Duty) ([]*store.ChecklistItem, error) {
+	entries, err := s.ForDuty(ctx, duty)
+	if err != nil {
+		return nil, err
+	}
+	var pending []*store.ChecklistItem
+	for _, e := range entries {
+		if e.Item.Mandatory && !e.Checked {
+			pending = append(pending, e.Item)
+		}
+	}
+	return pending, nil
+}
+
+// item finds a checklist item by ID.
+func (s *Service) item(ctx context.Context, id int64) (*store.ChecklistItem, error) {
+	items, err := s.Items(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range items {
+		if item.ID == id {
+			return item, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+// appliesTo reports whether item is on the checklist of duty.
+func appliesTo(item *store.ChecklistItem, duty *store.Duty) bool {
+	return item.AssignmentType == "" || item.AssignmentType == duty.AssignmentType
+}
+
+// validType reports whether items can be limited to t.
+func validType(t store.AssignmentType) bool {
+	for _, valid := range Types {
+		if t == valid {
+			return true
+		}
+	}
+	return false
+}
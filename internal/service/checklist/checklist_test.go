@@ -0,0 +1,86 @@
+package checklist
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/korjavin/dutyassistant/internal/store"
+	"github.com/korjavin/dutyassistant/internal/store/memory"
+)
+
+func date(y int, m time.Month, d int) time.Time {
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+func TestService(t *testing.T) {
+	ctx := context.Background()
+	s := memory.New()
+	svc := New(s)
+
+	alice := &store.User{TelegramUserID: 1, FirstName: "Alice", IsActive: true}
+	bob := &store.User{TelegramUserID: 2, FirstName: "Bob", IsActive: true}
+	s.CreateUser(ctx, alice)
+	s.CreateUser(ctx, bob)
+	day := date(2025, 11, 4)
+	s.CreateDuty(ctx, &store.Duty{UserID: alice.ID, DutyDate: day, AssignmentType: store.AssignmentTypeRoundRobin})
+
+	if _, err := svc.AddItem(ctx, "weekly", "Dishes", true); !errors.Is(err, ErrInvalidType) {
+		t.Errorf("Expected ErrInvalidType for an unknown type, got %v", err)
+	}
+	if _, err := svc.AddItem(ctx, "", "  ", true); err == nil {
+		t.Error("Expected an empty item to be rejected")
+	}
+	dishwasher, err := svc.AddItem(ctx, "", " Load the dishwasher ", true)
+	if err != nil {
+		t.Fatalf("AddItem failed: %v", err)
+	}
+	counters, _ := svc.AddItem(ctx, "", "Wipe the counters", false)
+	external, _ := svc.AddItem(ctx, store.AssignmentTypeExternal, "Pay the helper", true)
+
+	duty, _ := s.GetDutyByDate(ctx, day)
+	entries, err := svc.ForDuty(ctx, duty)
+	if err != nil {
+		t.Fatalf("ForDuty failed: %v", err)
+	}
+	if len(entries) != 2 || entries[0].Item.Text != "Load the dishwasher" || entries[1].Item.ID != counters.ID || entries[0].Checked {
+		t.Fatalf("ForDuty: expected the two unchecked items for every duty, got %+v", entries)
+	}
+	if pending, _ := svc.Pending(ctx, duty); len(pending) != 1 || pending[0].ID != dishwasher.ID {
+		t.Errorf("Pending: expected only the dishwasher, got %+v", pending)
+	}
+
+	if _, _, err := svc.Toggle(ctx, day, bob.ID, dishwasher.ID); !errors.Is(err, ErrNoDuty) {
+		t.Errorf("Expected ErrNoDuty toggling someone else's duty, got %v", err)
+	}
+	if _, _, err := svc.Toggle(ctx, day, alice.ID, external.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound for an item of another assignment type, got %v", err)
+	}
+	_, entries, err = svc.Toggle(ctx, day, alice.ID, dishwasher.ID)
+	if err != nil {
+		t.Fatalf("Toggle failed: %v", err)
+	}
+	if !entries[0].Checked || entries[1].Checked {
+		t.Errorf("Toggle: expected the dishwasher checked, got %+v", entries)
+	}
+	if pending, _ := svc.Pending(ctx, duty); len(pending) != 0 {
+		t.Errorf("Pending: expected nothing left, got %+v", pending)
+	}
+
+	// Toggling again unchecks it
+	svc.Toggle(ctx, day, alice.ID, dishwasher.ID)
+	if pending, _ := svc.Pending(ctx, duty); len(pending) != 1 {
+		t.Errorf("Pending after unchecking: expected the dishwasher, got %+v", pending)
+	}
+
+	if err := svc.DeleteItem(ctx, dishwasher.ID); err != nil {
+		t.Fatalf("DeleteItem failed: %v", err)
+	}
+	if err := svc.DeleteItem(ctx, dishwasher.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound deleting twice, got %v", err)
+	}
+	if pending, _ := svc.Pending(ctx, duty); len(pending) != 0 {
+		t.Errorf("Pending without mandatory items: expected nothing, got %+v", pending)
+	}
+}
@@ -0,0 +1,124 @@
+// Package cleanup keeps chats free of stale interactive messages. The bot
+// tracks the menus it sends in reply to commands, like user pickers and
+// calendars; once a menu's interaction is done it is deleted after a delay,
+// and menus nobody finished are collapsed to their text after a timeout so
+// their buttons can't be pressed anymore.
+package cleanup
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/korjavin/dutyassistant/internal/store"
+)
+
+const (
+	// DefaultDelay is how long a finished menu stays before it is deleted.
+	DefaultDelay = 10 * time.Minute
+	// DefaultTimeout is how long an unfinished menu keeps its buttons.
+	DefaultTimeout = 24 * time.Hour
+	// deleteWindow is how old a message the bot can still delete. Older
+	// finished menus are collapsed instead.
+	deleteWindow = 48 * time.Hour
+)
+
+// Messenger changes messages the bot sent. The bot implements it.
+type Messenger interface {
+	DeleteMessage(chatID int64, messageID int) error
+	RemoveKeyboard(chatID int64, messageID int) error
+}
+
+// Result counts what a cleanup did.
+type Result struct {
+	Deleted   int
+	Collapsed int
+}
+
+// Service tracks interactive messages and cleans them up.
+type Service struct {
+	store     store.NotificationStore
+	messenger Messenger
+	now       func() time.Time
+
+	Delay   time.Duration // How long finished menus stay, DefaultDelay by default
+	Timeout time.Duration // How long unfinished menus stay, DefaultTimeout by default
+}
+
+// New creates a new Service backed by the given store, changing messages
+// through m.
+func New(s store.NotificationStore, m Messenger) *Service {
+	return &Service{store: s, messenger: m, now: time.Now, Delay: DefaultDelay, Timeout: DefaultTimeout}
+}
+
+// Track records that the bot sent a menu.
+func (s *Service) Track(ctx context.Context, chatID int64, messageID int) error {
+	msg := &store.InteractiveMessage{ChatID: chatID, MessageID: messageID, SentAt: s.now()}
+	if err := s.store.TrackInteractiveMessage(ctx, msg); err != nil {
+		return fmt.Errorf("failed to track message %d in chat %d: %w", messageID, chatID, err)
+	}
+	return nil
+}
+
+// Done records that the interaction of a menu completed, so it is deleted
+// after the delay.
+func (s *Service) Done(ctx context.Context, chatID int64, messageID int) error {
+	if err := s.store.MarkInteractiveMessageDone(ctx, chatID, messageID, s.now()); err != nil {
+		return fmt.Errorf("failed to mark message %d in chat %d done: %w", messageID, chatID, err)
+	}
+	return nil
+}
+
+// Run deletes the finished menus older than the delay and collapses the
+// unfinished ones older than the timeout. With force, it cleans up every
+// tracked menu regardless of its age, as /cleanup does.
+//
+// A message is only tried once: errors, e.g. for a message someone deleted
+// by hand, are logged and the message is forgotten.
+func (s *Service) Run(ctx context.Context, force bool) (Result, error) {
+	msgs, err := s.store.ListInteractiveMessages(ctx)
+	if err != nil {
+		return Result{}, fmt.Errorf("failed to list interactive messages: %w", err)
+	}
+
+	var result Result
+	now := s.now()
+	for _, msg := range msgs {
+		done := msg.DoneAt != nil
+		switch {
+		case done && (force || now.Sub(*msg.DoneAt) >= s.Delay):
+			if now.Sub(msg.SentAt) < deleteWindow && s.delete(msg) {
+				result.Deleted++
+			} else {
+				s.collapse(msg, &result)
+			}
+		case !done && (force || now.Sub(msg.SentAt) >= s.Timeout):
+			s.collapse(msg, &result)
+		default:
+			continue
+		}
+		if err := s.store.DeleteInteractiveMessage(ctx, msg.ChatID, msg.MessageID); err != nil {
+			return result, fmt.Errorf("failed to forget message %d in chat %d: %w", msg.MessageID, msg.ChatID, err)
+		}
+	}
+	return result, nil
+}
+
+// delete deletes a menu and reports whether it could.
+func (s *Service) delete(msg *store.InteractiveMessage) bool {
+	if err := s.messenger.DeleteMessage(msg.ChatID, msg.MessageID); err != nil {
+		log.Printf("[CLEANUP] Failed to delete message %d in chat %d, collapsing it: %v", msg.MessageID, msg.ChatID, err)
+		return false
+	}
+	return true
+}
+
+// collapse removes the buttons of a menu, keeping its text.
+func (s *Service) collapse(msg *store.InteractiveMessage, result *Result) {
+	if err := s.messenger.RemoveKeyboard(msg.ChatID, msg.MessageID); err != nil {
+		log.Printf("[CLEANUP] Failed to collapse message %d in chat %d: %v", msg.MessageID, msg.ChatID, err)
+		return
+	}
+	result.Collapsed++
+}
@@ -0,0 +1,93 @@
+package cleanup
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/korjavin/dutyassistant/internal/store/memory"
+)
+
+// fakeMessenger records the messages it changed and fails for those in fail.
+type fakeMessenger struct {
+	deleted, collapsed []int
+	fail               map[int]bool
+}
+
+func (m *fakeMessenger) DeleteMessage(_ int64, messageID int) error {
+	if m.fail[messageID] {
+		return errors.New("message can't be deleted")
+	}
+	m.deleted = append(m.deleted, messageID)
+	return nil
+}
+
+func (m *fakeMessenger) RemoveKeyboard(_ int64, messageID int) error {
+	m.collapsed = append(m.collapsed, messageID)
+	return nil
+}
+
+func TestRun(t *testing.T) {
+	ctx := context.Background()
+	st := memory.New()
+	messenger := &fakeMessenger{fail: map[int]bool{4: true}}
+	s := New(st, messenger)
+	now := time.Date(2025, 10, 27, 12, 0, 0, 0, time.UTC)
+	at := func(ago time.Duration) func() time.Time { return func() time.Time { return now.Add(-ago) } }
+
+	// 1 is finished, 2 finished just now, 3 abandoned, 4 finished but can't be
+	// deleted, 5 still in use
+	for _, m := range []struct {
+		id          int
+		sent, done  time.Duration
+		interaction bool
+	}{
+		{1, time.Hour, 30 * time.Minute, true},
+		{2, time.Hour, time.Minute, true},
+		{3, 25 * time.Hour, 0, false},
+		{4, time.Hour, 30 * time.Minute, true},
+		{5, time.Hour, 0, false},
+	} {
+		s.now = at(m.sent)
+		if err := s.Track(ctx, -100, m.id); err != nil {
+			t.Fatalf("Track failed: %v", err)
+		}
+		if m.interaction {
+			s.now = at(m.done)
+			if err := s.Done(ctx, -100, m.id); err != nil {
+				t.Fatalf("Done failed: %v", err)
+			}
+		}
+	}
+
+	s.now = at(0)
+	result, err := s.Run(ctx, false)
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if result != (Result{Deleted: 1, Collapsed: 2}) {
+		t.Errorf("Run = %+v, want 1 deleted and 2 collapsed", result)
+	}
+	if len(messenger.deleted) != 1 || messenger.deleted[0] != 1 {
+		t.Errorf("Expected message 1 to be deleted, got %v", messenger.deleted)
+	}
+	if len(messenger.collapsed) != 2 || messenger.collapsed[0] != 3 || messenger.collapsed[1] != 4 {
+		t.Errorf("Expected messages 3 and 4 to be collapsed, got %v", messenger.collapsed)
+	}
+	if msgs, _ := st.ListInteractiveMessages(ctx); len(msgs) != 2 {
+		t.Errorf("Expected messages 2 and 5 to be kept, got %+v", msgs)
+	}
+
+	// Forced, the rest goes too
+	result, err = s.Run(ctx, true)
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if result != (Result{Deleted: 1, Collapsed: 1}) {
+		t.Errorf("Forced Run = %+v, want 1 deleted and 1 collapsed", result)
+	}
+	if msgs, _ := st.ListInteractiveMessages(ctx); len(msgs) != 0 {
+		t.Errorf("Expected no tracked messages after a forced run, got %+v", msgs)
+	}
+}
@@ -0,0 +1,583 @@
+// Package config exports the setup of a roster, its users, settings, note
+// templates, checklist and blackout rules, to YAML and imports it again, so a household can
+// move the bot to another host or start another group with the same setup.
+// The history, duties, queues and off-duty periods, isn't part of it.
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/korjavin/dutyassistant/internal/i18n"
+	"github.com/korjavin/dutyassistant/internal/scheduler"
+	"github.com/korjavin/dutyassistant/internal/service/checklist"
+	"github.com/korjavin/dutyassistant/internal/service/ledger"
+	"github.com/korjavin/dutyassistant/internal/service/note"
+	"github.com/korjavin/dutyassistant/internal/service/settings"
+	"github.com/korjavin/dutyassistant/internal/service/user"
+	"github.com/korjavin/dutyassistant/internal/store"
+)
+
+// Version is the version of the file format Export writes. Import refuses
+// files of other versions.
+const Version = 1
+
+var (
+	// ErrInvalid is returned for configurations that can't be imported as
+	// they are, e.g. with an unknown pool or note rule.
+	ErrInvalid = errors.New("invalid configuration")
+	// ErrVersion is returned when importing a file of another format version.
+	ErrVersion = errors.New("unsupported configuration version")
+)
+
+// Config is the setup of a roster, as written to YAML.
+type Config struct {
+	Version   int         `yaml:"version"`
+	Settings  Settings    `yaml:"settings"`
+	Users     []User      `yaml:"users"`
+	Notes     []Note      `yaml:"notes,omitempty"`
+	Checklist []Checklist `yaml:"checklist,omitempty"`
+	Blackouts []Blackout  `yaml:"blackouts,omitempty"`
+}
+
+// Settings are the settings changed with /settings, and the language of the
+// group chat.
+type Settings struct {
+	GroupChat       int64   `yaml:"group_chat,omitempty"`
+	Admins          []int64 `yaml:"admins,omitempty"`
+	RequireApproval bool    `yaml:"require_approval"`
+	Locale          string  `yaml:"locale,omitempty"`
+	// PayoutFine is what a missed duty costs in payout mode, e.g. "4.50", in
+	// PayoutCurrency. Payout mode is off without it.
+	PayoutFine     string `yaml:"payout_fine,omitempty"`
+	PayoutCurrency string `yaml:"payout_currency,omitempty"`
+	// TripHints is whether trips announced in the group get an offer to set
+	// the off-duty period.
+	TripHints bool `yaml:"trip_hints,omitempty"`
+	// AnnouncementSections are the optional sections of the daily
+	// announcement, see settings.Sections.
+	AnnouncementSections []string `yaml:"announcement_sections,omitempty"`
+	// WeeklyPoll is whether the group is asked every Sunday who volunteers
+	// for which day of the next week.
+	WeeklyPoll bool `yaml:"weekly_poll,omitempty"`
+	// CoverWait is how many minutes the group has to take over a duty asked
+	// to be covered with /cover, the default without it. After that
+	// CoverFallback, a Telegram user ID, takes it over.
+	CoverWait     int   `yaml:"cover_wait,omitempty"`
+	CoverFallback int64 `yaml:"cover_fallback,omitempty"`
+}
+
+// User is a user on the roster. Users are matched by their Telegram ID.
+type User struct {
+	TelegramID    int64          `yaml:"telegram_id"`
+	Handle        string         `yaml:"handle"`
+	Name          string         `yaml:"name"`
+	CustomName    bool           `yaml:"custom_name,omitempty"`
+	Emoji         string         `yaml:"emoji,omitempty"`
+	Timezone      string         `yaml:"timezone,omitempty"`
+	Privacy       string         `yaml:"privacy,omitempty"`
+	Alias         string         `yaml:"alias,omitempty"`
+	Pool          string         `yaml:"pool"`
+	Admin         bool           `yaml:"admin,omitempty"`
+	Active        bool           `yaml:"active"`
+	Junior        bool           `yaml:"junior,omitempty"`
+	Pending       bool           `yaml:"pending,omitempty"`
+	Calendar      string         `yaml:"calendar,omitempty"`
+	Notifications *Notifications `yaml:"notifications,omitempty"`
+}
+
+// Notifications are a user's /notifications preferences.
+type Notifications struct {
+	GroupReminder bool `yaml:"group_reminder"`
+	PersonalDM    bool `yaml:"personal_dm"`
+	WeeklyStats   bool `yaml:"weekly_stats"`
+	SwapRequests  bool `yaml:"swap_requests"`
+	ReminderHour  int  `yaml:"reminder_hour"`
+}
+
+// Note is a note template, see note.Rule for its rule.
+type Note struct {
+	Rule string `yaml:"rule"`
+	Text string `yaml:"text"`
+}
+
+// Checklist is a checklist item.
+type Checklist struct {
+	Type      string `yaml:"type,omitempty"`
+	Text      string `yaml:"text"`
+	Mandatory bool   `yaml:"mandatory,omitempty"`
+}
+
+// Blackout is a blackout rule, see scheduler.ParseBlackout for its rule.
+type Blackout struct {
+	Rule        string `yaml:"rule"`
+	Description string `yaml:"description,omitempty"`
+}
+
+// Summary is what an import changed.
+type Summary struct {
+	UsersCreated   int
+	UsersUpdated   int
+	Notes          int
+	ChecklistItems int
+	Blackouts      int
+}
+
+// Service exports and imports configurations.
+type Service struct {
+	store store.Store
+	now   func() time.Time
+}
+
+// New creates a new Service backed by the given store.
+func New(s store.Store) *Service {
+	return &Service{store: s, now: time.Now}
+}
+
+// today is the date payout mode starts on when an import turns it on.
+func (s *Service) today() time.Time {
+	now := s.now()
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// Parse reads a configuration written as YAML. Unknown fields are an error,
+// so typos don't go unnoticed.
+func Parse(r io.Reader) (*Config, error) {
+	decoder := yaml.NewDecoder(r)
+	decoder.KnownFields(true)
+	var cfg Config
+	if err := decoder.Decode(&cfg); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalid, err)
+	}
+	return &cfg, nil
+}
+
+// Write writes cfg as YAML.
+func Write(w io.Writer, cfg *Config) error {
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(cfg); err != nil {
+		return fmt.Errorf("failed to write configuration: %w", err)
+	}
+	return encoder.Close()
+}
+
+// Export returns the configuration of the roster.
+func (s *Service) Export(ctx context.Context) (*Config, error) {
+	cfg := &Config{Version: Version}
+
+	botSettings := settings.New(s.store)
+	var err error
+	if cfg.Settings.GroupChat, err = botSettings.GroupChatID(ctx); err != nil {
+		return nil, err
+	}
+	if cfg.Settings.Admins, err = botSettings.AdminIDs(ctx); err != nil {
+		return nil, err
+	}
+	if cfg.Settings.RequireApproval, err = botSettings.RequireApproval(ctx); err != nil {
+		return nil, err
+	}
+	if cfg.Settings.TripHints, err = botSettings.TripHints(ctx); err != nil {
+		return nil, err
+	}
+	if cfg.Settings.AnnouncementSections, err = botSettings.AnnouncementSections(ctx); err != nil {
+		return nil, err
+	}
+	if cfg.Settings.WeeklyPoll, err = botSettings.WeeklyPoll(ctx); err != nil {
+		return nil, err
+	}
+	cover, err := botSettings.Cover(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if cover.Wait != settings.DefaultCoverWait {
+		cfg.Settings.CoverWait = int(cover.Wait.Minutes())
+	}
+	cfg.Settings.CoverFallback = cover.Fallback
+	payout, err := botSettings.Payout(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if payout.On() {
+		cfg.Settings.PayoutFine = strings.TrimSuffix(ledger.FormatAmount(payout.Fine, ""), " ")
+		cfg.Settings.PayoutCurrency = payout.Currency
+	}
+	if cfg.Settings.GroupChat != 0 {
+		if cfg.Settings.Locale, err = s.store.GetChatLocale(ctx, cfg.Settings.GroupChat); err != nil {
+			return nil, fmt.Errorf("failed to get the group chat's locale: %w", err)
+		}
+	}
+
+	users, err := s.store.ListAllUsers(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list users: %w", err)
+	}
+	for _, u := range users {
+		exported, err := s.exportUser(ctx, u)
+		if err != nil {
+			return nil, err
+		}
+		cfg.Users = append(cfg.Users, exported)
+	}
+
+	templates, err := note.New(s.store).Templates(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range templates {
+		cfg.Notes = append(cfg.Notes, Note{Rule: t.Rule, Text: t.Text})
+	}
+	items, err := checklist.New(s.store).Items(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range items {
+		cfg.Checklist = append(cfg.Checklist, Checklist{Type: string(item.AssignmentType), Text: item.Text, Mandatory: item.Mandatory})
+	}
+	rules, err := s.store.ListBlackoutRules(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range rules {
+		cfg.Blackouts = append(cfg.Blackouts, Blackout{Rule: r.Rule, Description: r.Description})
+	}
+	return cfg, nil
+}
+
+func (s *Service) exportUser(ctx context.Context, u *store.User) (User, error) {
+	pool := string(u.Pool)
+	if u.Pool == store.PoolAll {
+		pool = "all"
+	}
+	exported := User{
+		TelegramID: u.TelegramUserID,
+		Handle:     u.Handle,
+		Name:       u.FirstName,
+		CustomName: u.CustomName,
+		Emoji:      u.Emoji,
+		Timezone:   u.Timezone,
+		Privacy:    string(u.Privacy),
+		Alias:      u.Alias,
+		Pool:       pool,
+		Admin:      u.IsAdmin,
+		Active:     u.IsActive,
+		Junior:     u.IsJunior,
+		Pending:    u.IsPending,
+	}
+	link, err := s.store.GetCalendarLink(ctx, u.ID)
+	if err != nil {
+		return User{}, fmt.Errorf("failed to get calendar link of user %d: %w", u.ID, err)
+	}
+	if link != nil {
+		exported.Calendar = link.URL
+	}
+	prefs, err := s.store.GetNotificationPreferences(ctx, u.ID)
+	if err != nil {
+		return User{}, fmt.Errorf("failed to get notification preferences of user %d: %w", u.ID, err)
+	}
+	if prefs != nil {
+		exported.Notifications = &Notifications{
+			GroupReminder: prefs.GroupReminder,
+			PersonalDM:    prefs.PersonalDM,
+			WeeklyStats:   prefs.WeeklyStats,
+			SwapRequests:  prefs.SwapRequests,
+			ReminderHour:  prefs.ReminderHour,
+		}
+	}
+	return exported, nil
+}
+
+// Import applies cfg to the roster, all of it or, if anything fails, nothing.
+// Users already on the roster are updated, the others are created. Note
+// templates, checklist items and blackout rules are added unless the same one
+// exists, so
+// importing a file twice changes nothing the second time. Settings left out
+// of the file keep their value, except whether approval is required.
+func (s *Service) Import(ctx context.Context, cfg *Config) (*Summary, error) {
+	if cfg.Version != Version {
+		return nil, fmt.Errorf("%w %d, expected %d", ErrVersion, cfg.Version, Version)
+	}
+	if err := validate(cfg); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalid, err)
+	}
+
+	var summary *Summary
+	err := s.store.RunInTx(ctx, func(tx store.Store) error {
+		summary = &Summary{}
+		if err := importSettings(ctx, tx, cfg.Settings, s.today()); err != nil {
+			return err
+		}
+		for _, u := range cfg.Users {
+			created, err := importUser(ctx, tx, u)
+			if err != nil {
+				return err
+			}
+			if created {
+				summary.UsersCreated++
+			} else {
+				summary.UsersUpdated++
+			}
+		}
+		var err error
+		if summary.Notes, err = importNotes(ctx, tx, cfg.Notes); err != nil {
+			return err
+		}
+		if summary.ChecklistItems, err = importChecklist(ctx, tx, cfg.Checklist); err != nil {
+			return err
+		}
+		summary.Blackouts, err = s.importBlackouts(ctx, tx, cfg.Blackouts)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return summary, nil
+}
+
+// validate checks the parts of cfg the store doesn't, before anything is
+// changed.
+func validate(cfg *Config) error {
+	if cfg.Settings.Locale != "" {
+		if _, err := i18n.Parse(cfg.Settings.Locale); err != nil {
+			return err
+		}
+	}
+	if cfg.Settings.PayoutFine != "" {
+		if _, err := ledger.ParseAmount(cfg.Settings.PayoutFine); err != nil {
+			return err
+		}
+	}
+	if cfg.Settings.CoverWait < 0 {
+		return fmt.Errorf("cover wait of %d minutes is negative", cfg.Settings.CoverWait)
+	}
+	for _, section := range cfg.Settings.AnnouncementSections {
+		if !slices.Contains(settings.Sections, section) {
+			return fmt.Errorf("unknown announcement section %q", section)
+		}
+	}
+	seen := make(map[int64]bool)
+	for _, u := range cfg.Users {
+		if u.TelegramID == 0 {
+			return fmt.Errorf("user %q has no telegram_id", u.Name)
+		}
+		if seen[u.TelegramID] {
+			return fmt.Errorf("user %d is listed twice", u.TelegramID)
+		}
+		seen[u.TelegramID] = true
+		if u.Name == "" {
+			return fmt.Errorf("user %d has no name", u.TelegramID)
+		}
+		if _, err := user.ParsePool(u.Pool); err != nil {
+			return fmt.Errorf("user %d: %w %q", u.TelegramID, err, u.Pool)
+		}
+		if _, err := time.LoadLocation(u.Timezone); err != nil || u.Timezone == "Local" {
+			return fmt.Errorf("user %d: unknown timezone %q", u.TelegramID, u.Timezone)
+		}
+		switch store.Privacy(u.Privacy) {
+		case store.PrivacyOff, store.PrivacyInitials:
+		case store.PrivacyAlias:
+			if u.Alias == "" {
+				return fmt.Errorf("user %d: privacy alias without an alias", u.TelegramID)
+			}
+		default:
+			return fmt.Errorf("user %d: unknown privacy %q", u.TelegramID, u.Privacy)
+		}
+		if n := u.Notifications; n != nil && (n.ReminderHour < store.EarliestReminderHour || n.ReminderHour > store.LatestReminderHour) {
+			return fmt.Errorf("user %d: reminder hour %d is outside %d-%d", u.TelegramID, n.ReminderHour,
+				store.EarliestReminderHour, store.LatestReminderHour)
+		}
+	}
+	for _, n := range cfg.Notes {
+		if _, err := note.ParseRule(n.Rule); err != nil {
+			return fmt.Errorf("note %q: %w", n.Text, err)
+		}
+	}
+	for _, b := range cfg.Blackouts {
+		if _, err := scheduler.ParseBlackout(b.Rule); err != nil {
+			return fmt.Errorf("blackout %q: %w", b.Rule, err)
+		}
+	}
+	return nil
+}
+
+func importSettings(ctx context.Context, tx store.Store, cfg Settings, today time.Time) error {
+	botSettings := settings.New(tx)
+	if cfg.GroupChat != 0 {
+		if err := botSettings.SetGroupChatID(ctx, cfg.GroupChat); err != nil {
+			return fmt.Errorf("failed to set the group chat: %w", err)
+		}
+		if cfg.Locale != "" {
+			if err := tx.SetChatLocale(ctx, cfg.GroupChat, cfg.Locale); err != nil {
+				return fmt.Errorf("failed to set the group chat's locale: %w", err)
+			}
+		}
+	}
+	if len(cfg.Admins) > 0 {
+		if err := botSettings.SetAdminIDs(ctx, cfg.Admins); err != nil {
+			return fmt.Errorf("failed to set the admins: %w", err)
+		}
+	}
+	if err := botSettings.SetRequireApproval(ctx, cfg.RequireApproval); err != nil {
+		return fmt.Errorf("failed to set whether approval is required: %w", err)
+	}
+	if err := botSettings.SetTripHints(ctx, cfg.TripHints); err != nil {
+		return fmt.Errorf("failed to set whether trips are spotted: %w", err)
+	}
+	for _, section := range settings.Sections {
+		if err := botSettings.SetAnnouncementSection(ctx, section, slices.Contains(cfg.AnnouncementSections, section)); err != nil {
+			return fmt.Errorf("failed to set the %s section of the announcement: %w", section, err)
+		}
+	}
+	if err := botSettings.SetWeeklyPoll(ctx, cfg.WeeklyPoll); err != nil {
+		return fmt.Errorf("failed to set whether the group is polled for volunteers: %w", err)
+	}
+	cover := settings.Cover{Wait: settings.DefaultCoverWait, Fallback: cfg.CoverFallback}
+	if cfg.CoverWait != 0 {
+		cover.Wait = time.Duration(cfg.CoverWait) * time.Minute
+	}
+	if err := botSettings.SetCover(ctx, cover); err != nil {
+		return fmt.Errorf("failed to set how duties are covered: %w", err)
+	}
+	if cfg.PayoutFine != "" {
+		fine, _ := ledger.ParseAmount(cfg.PayoutFine) // Checked by validate
+		if err := botSettings.SetPayout(ctx, fine, cfg.PayoutCurrency, today); err != nil {
+			return fmt.Errorf("failed to set payout mode: %w", err)
+		}
+	}
+	return nil
+}
+
+// importUser creates or updates the user with the Telegram ID of u, and
+// reports whether it was created.
+func importUser(ctx context.Context, tx store.Store, u User) (bool, error) {
+	existing, err := tx.GetUserByTelegramID(ctx, u.TelegramID)
+	if err != nil {
+		return false, fmt.Errorf("failed to get user %d: %w", u.TelegramID, err)
+	}
+	pool, _ := user.ParsePool(u.Pool) // Checked by validate
+	imported := &store.User{TelegramUserID: u.TelegramID, Handle: u.Handle}
+	if existing != nil {
+		imported = existing
+	}
+	imported.FirstName = u.Name
+	imported.CustomName = u.CustomName
+	imported.Emoji = u.Emoji
+	imported.Timezone = u.Timezone
+	imported.Privacy = store.Privacy(u.Privacy)
+	imported.Alias = u.Alias
+	imported.Pool = pool
+	imported.IsAdmin = u.Admin
+	imported.IsActive = u.Active
+	imported.IsJunior = u.Junior
+	imported.IsPending = u.Pending
+	if existing != nil {
+		err = tx.UpdateUser(ctx, imported)
+	} else {
+		err = tx.CreateUser(ctx, imported)
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to save user %d: %w", u.TelegramID, err)
+	}
+
+	if u.Calendar != "" {
+		if err := tx.SetCalendarLink(ctx, &store.CalendarLink{UserID: imported.ID, URL: u.Calendar}); err != nil {
+			return false, fmt.Errorf("failed to link the calendar of user %d: %w", u.TelegramID, err)
+		}
+	}
+	if n := u.Notifications; n != nil {
+		prefs := &store.NotificationPreferences{
+			UserID:        imported.ID,
+			GroupReminder: n.GroupReminder,
+			PersonalDM:    n.PersonalDM,
+			WeeklyStats:   n.WeeklyStats,
+			SwapRequests:  n.SwapRequests,
+			ReminderHour:  n.ReminderHour,
+		}
+		if err := tx.SetNotificationPreferences(ctx, prefs); err != nil {
+			return false, fmt.Errorf("failed to set the notification preferences of user %d: %w", u.TelegramID, err)
+		}
+	}
+	return existing == nil, nil
+}
+
+// importNotes adds the note templates that don't exist yet and returns how
+// many it added.
+func importNotes(ctx context.Context, tx store.Store, notes []Note) (int, error) {
+	notesService := note.New(tx)
+	templates, err := notesService.Templates(ctx)
+	if err != nil {
+		return 0, err
+	}
+	added := 0
+	for _, n := range notes {
+		// Compared as AddTemplate stores them
+		rule, text := strings.ToLower(strings.TrimSpace(n.Rule)), strings.TrimSpace(n.Text)
+		if slices.ContainsFunc(templates, func(t *store.NoteTemplate) bool { return t.Rule == rule && t.Text == text }) {
+			continue
+		}
+		t, err := notesService.AddTemplate(ctx, rule, text)
+		if err != nil {
+			return 0, fmt.Errorf("note %q: %w", n.Text, err)
+		}
+		templates = append(templates, t)
+		added++
+	}
+	return added, nil
+}
+
+// importChecklist adds the checklist items that don't exist yet and returns
+// how many it added.
+func importChecklist(ctx context.Context, tx store.Store, items []Checklist) (int, error) {
+	checklistService := checklist.New(tx)
+	existing, err := checklistService.Items(ctx)
+	if err != nil {
+		return 0, err
+	}
+	added := 0
+	for _, item := range items {
+		text := strings.TrimSpace(item.Text)
+		if slices.ContainsFunc(existing, func(e *store.ChecklistItem) bool {
+			return string(e.AssignmentType) == item.Type && e.Text == text && e.Mandatory == item.Mandatory
+		}) {
+			continue
+		}
+		created, err := checklistService.AddItem(ctx, store.AssignmentType(item.Type), item.Text, item.Mandatory)
+		if err != nil {
+			return 0, fmt.Errorf("checklist item %q: %w", item.Text, err)
+		}
+		existing = append(existing, created)
+		added++
+	}
+	return added, nil
+}
+
+// importBlackouts adds the blackout rules that don't exist yet and returns how
+// many it added. Duties already assigned on their days are left alone.
+func (s *Service) importBlackouts(ctx context.Context, tx store.Store, blackouts []Blackout) (int, error) {
+	existing, err := tx.ListBlackoutRules(ctx)
+	if err != nil {
+		return 0, err
+	}
+	added := 0
+	for _, b := range blackouts {
+		// Compared as Scheduler.AddBlackout stores them, validate parsed them
+		parsed, _ := scheduler.ParseBlackout(b.Rule)
+		rule := &store.BlackoutRule{Rule: parsed.String(), Description: strings.TrimSpace(b.Description), CreatedAt: s.now().UTC()}
+		if slices.ContainsFunc(existing, func(e *store.BlackoutRule) bool { return e.Rule == rule.Rule && e.Description == rule.Description }) {
+			continue
+		}
+		if err := tx.CreateBlackoutRule(ctx, rule); err != nil {
+			return 0, fmt.Errorf("blackout %q: %w", b.Rule, err)
+		}
+		existing = append(existing, rule)
+		added++
+	}
+	return added, nil
+}
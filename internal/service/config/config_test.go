@@ -0,0 +1,137 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/korjavin/dutyassistant/internal/service/checklist"
+	"github.com/korjavin/dutyassistant/internal/service/note"
+	"github.com/korjavin/dutyassistant/internal/service/settings"
+	"github.com/korjavin/dutyassistant/internal/store"
+	"github.com/korjavin/dutyassistant/internal/store/memory"
+)
+
+func TestExportImport(t *testing.T) {
+	ctx := context.Background()
+	source := memory.New()
+	alice := &store.User{TelegramUserID: 1, FirstName: "Alice", Emoji: "🦊", Timezone: "Europe/London", IsAdmin: true, IsActive: true}
+	bob := &store.User{TelegramUserID: 2, FirstName: "Bobby", CustomName: true, Pool: store.PoolWeekends, IsJunior: true, Privacy: store.PrivacyAlias, Alias: "Captain"}
+	for _, u := range []*store.User{alice, bob} {
+		if err := source.CreateUser(ctx, u); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := source.SetCalendarLink(ctx, &store.CalendarLink{UserID: alice.ID, URL: "https://example.com/alice.ics"}); err != nil {
+		t.Fatal(err)
+	}
+	prefs := store.DefaultNotificationPreferences(bob.ID)
+	prefs.ReminderHour = 18
+	if err := source.SetNotificationPreferences(ctx, prefs); err != nil {
+		t.Fatal(err)
+	}
+	botSettings := settings.New(source)
+	if err := botSettings.Seed(ctx, -100, []int64{1}); err != nil {
+		t.Fatal(err)
+	}
+	if err := botSettings.SetRequireApproval(ctx, true); err != nil {
+		t.Fatal(err)
+	}
+	if err := botSettings.SetAnnouncementSection(ctx, settings.SectionFairness, true); err != nil {
+		t.Fatal(err)
+	}
+	if err := botSettings.SetCover(ctx, settings.Cover{Wait: 20 * time.Minute, Fallback: 1}); err != nil {
+		t.Fatal(err)
+	}
+	if err := botSettings.SetWeeklyPoll(ctx, true); err != nil {
+		t.Fatal(err)
+	}
+	if err := source.SetChatLocale(ctx, -100, "de"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := note.New(source).AddTemplate(ctx, "tue", "Bins are brown"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := checklist.New(source).AddItem(ctx, "", "Load the dishwasher", true); err != nil {
+		t.Fatal(err)
+	}
+	if err := source.CreateBlackoutRule(ctx, &store.BlackoutRule{Rule: "12-24..12-25", Description: "Christmas", CreatedAt: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+
+	exported, err := New(source).Export(ctx)
+	if err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+	var file bytes.Buffer
+	if err := Write(&file, exported); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"telegram_id: 1", "pool: weekends", "calendar: https://example.com/alice.ics", "locale: de", "rule: tue", "timezone: Europe/London", "- fairness", "weekly_poll: true", "cover_wait: 20", "cover_fallback: 1", "rule: 12-24..12-25", "privacy: alias", "alias: Captain"} {
+		if !strings.Contains(file.String(), want) {
+			t.Errorf("exported file lacks %q:\n%s", want, file.String())
+		}
+	}
+
+	parsed, err := Parse(&file)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	target := memory.New()
+	summary, err := New(target).Import(ctx, parsed)
+	if err != nil {
+		t.Fatalf("Import failed: %v", err)
+	}
+	if want := (Summary{UsersCreated: 2, Notes: 1, ChecklistItems: 1, Blackouts: 1}); *summary != want {
+		t.Errorf("Import summary = %+v, want %+v", *summary, want)
+	}
+	imported, err := New(target).Export(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(imported, exported) {
+		t.Errorf("Imported configuration = %+v, want %+v", imported, exported)
+	}
+
+	// Importing again only updates the users
+	summary, err = New(target).Import(ctx, parsed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (Summary{UsersUpdated: 2}); *summary != want {
+		t.Errorf("Second import summary = %+v, want %+v", *summary, want)
+	}
+}
+
+func TestImport_Invalid(t *testing.T) {
+	ctx := context.Background()
+	tests := map[string]string{
+		"version":       "version: 2\n",
+		"unknown field": "version: 1\ncolour: red\n",
+		"pool":          "version: 1\nusers:\n  - telegram_id: 1\n    name: Alice\n    pool: mondays\n",
+		"no id":         "version: 1\nusers:\n  - name: Alice\n    pool: all\n",
+		"rule":          "version: 1\nnotes:\n  - rule: sometimes\n    text: Bins\n",
+		"locale":        "version: 1\nsettings:\n  locale: xx\n",
+		"timezone":      "version: 1\nusers:\n  - telegram_id: 1\n    name: Alice\n    pool: all\n    timezone: Mars/Olympus\n",
+		"section":       "version: 1\nsettings:\n  announcement_sections: [weather]\n",
+		"blackout":      "version: 1\nblackouts:\n  - rule: 02-30\n",
+		"privacy":       "version: 1\nusers:\n  - telegram_id: 1\n    name: Alice\n    pool: all\n    privacy: hidden\n",
+	}
+	for name, file := range tests {
+		s := memory.New()
+		cfg, err := Parse(strings.NewReader(file))
+		if err == nil {
+			_, err = New(s).Import(ctx, cfg)
+		}
+		if !errors.Is(err, ErrInvalid) && !errors.Is(err, ErrVersion) {
+			t.Errorf("%s: got %v, want an invalid configuration", name, err)
+		}
+		if users, _ := s.ListAllUsers(ctx); len(users) != 0 {
+			t.Errorf("%s: users were imported anyway", name)
+		}
+	}
+}
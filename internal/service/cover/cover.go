@@ -0,0 +1,238 @@
+// Package cover finds someone to take over a duty at short notice. /cover
+// asks the group who covers for whoever is on duty, and the first to claim
+// the duty takes it over. If nobody does within the wait of the settings, the
+// fallback person of the settings takes it over, and whoever was on duty owes
+// a day: it is added to their admin queue, so the rotation gets it back from
+// them soon.
+package cover
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/korjavin/dutyassistant/internal/scheduler"
+	"github.com/korjavin/dutyassistant/internal/service/duty"
+	"github.com/korjavin/dutyassistant/internal/service/settings"
+	"github.com/korjavin/dutyassistant/internal/service/user"
+	"github.com/korjavin/dutyassistant/internal/store"
+)
+
+var (
+	// ErrNoDuty is returned when asking for cover of a day nobody is on duty.
+	ErrNoDuty = errors.New("nobody is on duty on this date")
+	// ErrDone is returned when asking for cover of a duty that is done.
+	ErrDone = errors.New("the duty is done already")
+	// ErrAsked is returned when the group is asked to cover the duty already.
+	ErrAsked = errors.New("the group is asked to cover the duty already")
+	// ErrNotOpen is returned when claiming a duty nobody is asked to cover
+	// (anymore).
+	ErrNotOpen = errors.New("the duty isn't up for cover")
+	// ErrOwnDuty is returned when whoever is on duty claims their own duty.
+	ErrOwnDuty = errors.New("the duty is the user's own")
+)
+
+// Ask is a cover request along with who it is about.
+type Ask struct {
+	Request  *store.CoverRequest
+	User     *store.User // Who is on duty
+	Fallback *store.User // Who takes over if nobody claims it, nil if nobody is set
+}
+
+// Expired is a cover request nobody claimed in time.
+type Expired struct {
+	Date     time.Time
+	User     *store.User // Who was on duty and asked for cover
+	Fallback *store.User // Who took the duty over, nil if nobody did and User keeps it
+}
+
+// Service asks for cover and hands duties over.
+type Service struct {
+	store    store.Store
+	sched    scheduler.SchedulerInterface
+	duties   *duty.Service
+	users    *user.Service
+	settings *settings.Service
+
+	// mu makes sure a request is claimed or runs out once, when a claim and
+	// Expire come at the same time
+	mu  sync.Mutex
+	now func() time.Time
+}
+
+// New creates a new Service.
+func New(s store.Store, sch scheduler.SchedulerInterface, duties *duty.Service, users *user.Service) *Service {
+	return &Service{store: s, sched: sch, duties: duties, users: users, settings: settings.New(s), now: time.Now}
+}
+
+// Request asks for cover of the duty on date, which the group has the wait of
+// the settings to claim.
+func (s *Service) Request(ctx context.Context, date time.Time) (*Ask, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	d, err := s.store.GetDutyByDate(ctx, date)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the duty: %w", err)
+	}
+	if d == nil {
+		return nil, ErrNoDuty
+	}
+	if d.CompletedAt != nil {
+		return nil, ErrDone
+	}
+	open, err := s.store.GetOpenCoverRequest(ctx, date)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the cover request: %w", err)
+	}
+	if open != nil {
+		return nil, ErrAsked
+	}
+	u, err := s.users.ByID(ctx, d.UserID)
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := s.settings.Cover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	r := &store.CoverRequest{DutyDate: d.DutyDate, UserID: d.UserID, Deadline: now.Add(cfg.Wait), CreatedAt: now}
+	if err := s.store.CreateCoverRequest(ctx, r); err != nil {
+		return nil, fmt.Errorf("failed to save the cover request: %w", err)
+	}
+	return &Ask{Request: r, User: u, Fallback: s.fallback(ctx, cfg)}, nil
+}
+
+// Claim hands the duty on date over to u, who covers for whoever asked, and
+// returns the request it resolved. Like any reassignment, u must be active
+// and not off duty then.
+func (s *Service) Claim(ctx context.Context, date time.Time, u *store.User) (*store.CoverRequest, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r, err := s.store.GetOpenCoverRequest(ctx, date)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the cover request: %w", err)
+	}
+	if r == nil {
+		return nil, ErrNotOpen
+	}
+	if r.UserID == u.ID {
+		return nil, ErrOwnDuty
+	}
+	if changed, err := s.closeIfChanged(ctx, r); err != nil {
+		return nil, err
+	} else if changed {
+		return nil, ErrNotOpen
+	}
+
+	if _, err := s.duties.Reassign(ctx, date, u.ID, scheduler.ChangeModeKeep); err != nil {
+		return nil, err
+	}
+	now := s.now()
+	if err := s.store.ResolveCoverRequest(ctx, r.ID, &u.ID, now); err != nil {
+		return nil, err
+	}
+	r.CoveredBy, r.ResolvedAt = &u.ID, &now
+	return r, nil
+}
+
+// Expire hands the duties nobody claimed by the deadline over to the
+// fallback person, and adds the day whoever was on duty owes to their admin
+// queue. Without a fallback person they keep the duty.
+func (s *Service) Expire(ctx context.Context) ([]Expired, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	requests, err := s.store.ListOpenCoverRequests(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the cover requests: %w", err)
+	}
+	var expired []Expired
+	now := s.now()
+	for _, r := range requests {
+		if r.Deadline.After(now) {
+			break
+		}
+		e, err := s.expire(ctx, r)
+		if err != nil {
+			return expired, fmt.Errorf("failed to expire the cover request of %s: %w", r.DutyDate.Format("2006-01-02"), err)
+		}
+		if e != nil {
+			expired = append(expired, *e)
+		}
+	}
+	return expired, nil
+}
+
+// expire runs out a cover request, returning nil if there was nothing left to
+// take over.
+func (s *Service) expire(ctx context.Context, r *store.CoverRequest) (*Expired, error) {
+	if changed, err := s.closeIfChanged(ctx, r); err != nil || changed {
+		return nil, err
+	}
+	u, err := s.users.ByID(ctx, r.UserID)
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := s.settings.Cover(ctx)
+	if err != nil {
+		return nil, err
+	}
+	fallback := s.fallback(ctx, cfg)
+	if fallback == nil || fallback.ID == u.ID {
+		if err := s.store.ResolveCoverRequest(ctx, r.ID, nil, s.now()); err != nil {
+			return nil, err
+		}
+		return &Expired{Date: r.DutyDate, User: u}, nil
+	}
+
+	// The fallback person is on call, so they take over whether or not
+	// they are off duty
+	_, err = s.sched.ChangeDutyUser(ctx, r.DutyDate, fallback.ID, scheduler.ChangeModeKeep)
+	if errors.Is(err, scheduler.ErrPastDate) {
+		// The bot was down until after the day
+		return nil, s.store.ResolveCoverRequest(ctx, r.ID, nil, s.now())
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := s.sched.AssignDuty(ctx, u, 1); err != nil {
+		return nil, fmt.Errorf("failed to add the day %s owes: %w", u.FirstName, err)
+	}
+	if err := s.store.ResolveCoverRequest(ctx, r.ID, &fallback.ID, s.now()); err != nil {
+		return nil, err
+	}
+	return &Expired{Date: r.DutyDate, User: u, Fallback: fallback}, nil
+}
+
+// closeIfChanged closes the request without anyone covering if its duty was
+// done, removed or handed to someone else in the meantime, and reports
+// whether it did.
+func (s *Service) closeIfChanged(ctx context.Context, r *store.CoverRequest) (bool, error) {
+	d, err := s.store.GetDutyByDate(ctx, r.DutyDate)
+	if err != nil {
+		return false, fmt.Errorf("failed to get the duty: %w", err)
+	}
+	if d != nil && d.UserID == r.UserID && d.CompletedAt == nil {
+		return false, nil
+	}
+	return true, s.store.ResolveCoverRequest(ctx, r.ID, nil, s.now())
+}
+
+// fallback returns the fallback person of the settings, or nil if none is set
+// or they aren't registered.
+func (s *Service) fallback(ctx context.Context, cfg settings.Cover) *store.User {
+	if cfg.Fallback == 0 {
+		return nil
+	}
+	u, err := s.users.ByTelegramID(ctx, cfg.Fallback)
+	if err != nil {
+		return nil
+	}
+	return u
+}
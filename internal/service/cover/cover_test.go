@@ -0,0 +1,154 @@
+package cover
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/korjavin/dutyassistant/internal/scheduler"
+	"github.com/korjavin/dutyassistant/internal/service/duty"
+	"github.com/korjavin/dutyassistant/internal/service/settings"
+	"github.com/korjavin/dutyassistant/internal/service/user"
+	"github.com/korjavin/dutyassistant/internal/store"
+	"github.com/korjavin/dutyassistant/internal/store/memory"
+)
+
+// setup creates a Service whose clock is at now, with Alice on duty today and
+// tomorrow and Bob and Carol to cover, Carol being the fallback.
+func setup(t *testing.T, now *time.Time) (*Service, *memory.Store, []*store.User, time.Time) {
+	t.Helper()
+	ctx := context.Background()
+	s := memory.New()
+	sch := scheduler.NewScheduler(s)
+	users := user.New(s)
+	svc := New(s, sch, duty.New(sch, users, s), users)
+	svc.now = func() time.Time { return *now }
+
+	var people []*store.User
+	for i, name := range []string{"Alice", "Bob", "Carol"} {
+		u := &store.User{TelegramUserID: int64(i + 1), FirstName: name, IsActive: true}
+		if err := s.CreateUser(ctx, u); err != nil {
+			t.Fatal(err)
+		}
+		people = append(people, u)
+	}
+	today := scheduler.Today(time.Now(), 0)
+	for _, date := range []time.Time{today, today.AddDate(0, 0, 1)} {
+		if err := s.CreateDuty(ctx, &store.Duty{UserID: people[0].ID, DutyDate: date, AssignmentType: store.AssignmentTypeRoundRobin, CreatedAt: time.Now()}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := settings.New(s).SetCover(ctx, settings.Cover{Wait: 30 * time.Minute, Fallback: people[2].TelegramUserID}); err != nil {
+		t.Fatal(err)
+	}
+	return svc, s, people, today
+}
+
+func TestClaim(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	svc, s, people, today := setup(t, &now)
+	alice, bob := people[0], people[1]
+
+	if _, err := svc.Claim(ctx, today, bob); !errors.Is(err, ErrNotOpen) {
+		t.Errorf("Claim before asking: got %v, want ErrNotOpen", err)
+	}
+	if _, err := svc.Request(ctx, today.AddDate(0, 0, 2)); !errors.Is(err, ErrNoDuty) {
+		t.Errorf("Request of a day without duty: got %v, want ErrNoDuty", err)
+	}
+	ask, err := svc.Request(ctx, today)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	if ask.User.ID != alice.ID || ask.Fallback == nil || ask.Fallback.FirstName != "Carol" || !ask.Request.Deadline.Equal(now.Add(30*time.Minute)) {
+		t.Errorf("Request = %+v, want Alice covered by Carol in 30 minutes", ask)
+	}
+	if _, err := svc.Request(ctx, today); !errors.Is(err, ErrAsked) {
+		t.Errorf("Request twice: got %v, want ErrAsked", err)
+	}
+
+	if _, err := svc.Claim(ctx, today, alice); !errors.Is(err, ErrOwnDuty) {
+		t.Errorf("Claiming one's own duty: got %v, want ErrOwnDuty", err)
+	}
+	r, err := svc.Claim(ctx, today, bob)
+	if err != nil || r.UserID != alice.ID || r.CoveredBy == nil || *r.CoveredBy != bob.ID {
+		t.Fatalf("Claim = %+v, %v, want Bob covering for Alice", r, err)
+	}
+	if d, _ := s.GetDutyByDate(ctx, today); d.UserID != bob.ID {
+		t.Errorf("Bob isn't on duty today, %d is", d.UserID)
+	}
+	if _, err := svc.Claim(ctx, today, people[2]); !errors.Is(err, ErrNotOpen) {
+		t.Errorf("Claim after Bob: got %v, want ErrNotOpen", err)
+	}
+
+	// Claiming isn't running out: nobody owes a day
+	now = now.Add(time.Hour)
+	if expired, err := svc.Expire(ctx); err != nil || len(expired) != 0 {
+		t.Errorf("Expire after the claim = %+v, %v, want nothing", expired, err)
+	}
+	if u, _ := svc.users.ByID(ctx, alice.ID); u.AdminQueueDays != 0 {
+		t.Errorf("Alice owes %d days after Bob covered, want 0", u.AdminQueueDays)
+	}
+}
+
+func TestExpire(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	svc, s, people, today := setup(t, &now)
+	alice, carol := people[0], people[2]
+	tomorrow := today.AddDate(0, 0, 1)
+
+	if _, err := svc.Request(ctx, today); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.Request(ctx, tomorrow); err != nil {
+		t.Fatal(err)
+	}
+	if expired, err := svc.Expire(ctx); err != nil || len(expired) != 0 {
+		t.Fatalf("Expire before the deadline = %+v, %v, want nothing", expired, err)
+	}
+
+	// An admin gave tomorrow to someone else in the meantime
+	if _, err := svc.sched.ChangeDutyUser(ctx, tomorrow, people[1].ID, scheduler.ChangeModeKeep); err != nil {
+		t.Fatal(err)
+	}
+	now = now.Add(31 * time.Minute)
+	expired, err := svc.Expire(ctx)
+	if err != nil || len(expired) != 1 || expired[0].User.ID != alice.ID || expired[0].Fallback == nil || expired[0].Fallback.ID != carol.ID {
+		t.Fatalf("Expire = %+v, %v, want today handed from Alice to Carol", expired, err)
+	}
+	if d, _ := s.GetDutyByDate(ctx, today); d.UserID != carol.ID {
+		t.Errorf("Carol isn't on duty today, %d is", d.UserID)
+	}
+	if u, _ := svc.users.ByID(ctx, alice.ID); u.AdminQueueDays != 1 {
+		t.Errorf("Alice owes %d days, want 1", u.AdminQueueDays)
+	}
+	if open, _ := s.ListOpenCoverRequests(ctx); len(open) != 0 {
+		t.Errorf("Expected no open requests, got %+v", open)
+	}
+}
+
+func TestExpire_NoFallback(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	svc, s, people, today := setup(t, &now)
+	if err := svc.settings.SetCover(ctx, settings.Cover{Wait: time.Minute}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.Request(ctx, today); err != nil {
+		t.Fatal(err)
+	}
+
+	now = now.Add(time.Hour)
+	expired, err := svc.Expire(ctx)
+	if err != nil || len(expired) != 1 || expired[0].Fallback != nil {
+		t.Fatalf("Expire = %+v, %v, want Alice to keep the duty", expired, err)
+	}
+	if d, _ := s.GetDutyByDate(ctx, today); d.UserID != people[0].ID {
+		t.Errorf("Expected Alice to stay on duty, %d is", d.UserID)
+	}
+	if u, _ := svc.users.ByID(ctx, people[0].ID); u.AdminQueueDays != 0 {
+		t.Errorf("Alice owes %d days without a fallback, want 0", u.AdminQueueDays)
+	}
+}
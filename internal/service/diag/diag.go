@@ -0,0 +1,203 @@
+// Package diag collects what the admin needs to check on the running bot from
+// Telegram: the build, uptime, database, cron jobs, pending queues and the
+// last errors. The /debug command shows its snapshot.
+package diag
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/robfig/cron/v3"
+
+	"github.com/korjavin/dutyassistant/internal/store"
+)
+
+// APIErrorSource reports the last failed Telegram API call. The bot implements it.
+type APIErrorSource interface {
+	LastAPIError() (time.Time, error)
+}
+
+// JobResult is the outcome of a cron job's last run.
+type JobResult struct {
+	At       time.Time
+	Duration time.Duration
+	Err      error
+}
+
+// Job is a cron job with its next fire time and last result.
+type Job struct {
+	Name string
+	Next time.Time
+	Last *JobResult // nil if it hasn't run since the start
+}
+
+// Snapshot is the state of the bot at one point in time.
+type Snapshot struct {
+	Version, Commit string
+	Uptime          time.Duration
+	DBPath          string    // Empty for the in-memory store
+	DBSize          int64     // Bytes, including the WAL file
+	LastBackup      time.Time // Zero if BackupDir is unset or holds no backups
+	BackupDir       string
+	Jobs            []Job // Ordered by next fire time
+	VolunteerQueue  []*store.User
+	AdminQueue      []*store.User
+	LastAPIErrorAt  time.Time // Zero if no Telegram API call failed
+	LastAPIError    error
+}
+
+// Service keeps track of the cron jobs and assembles snapshots.
+type Service struct {
+	Version   string
+	Commit    string         // Falls back to the VCS revision Go embedded in the binary
+	DBPath    string         // Empty for the in-memory store
+	BackupDir string         // Optional; where backups of the database end up
+	APIErrors APIErrorSource // Optional
+
+	store   store.QueueStore
+	cron    *cron.Cron
+	started time.Time
+	now     func() time.Time
+
+	mu      sync.Mutex
+	names   map[cron.EntryID]string
+	results map[string]JobResult
+}
+
+// New creates a Service for jobs scheduled on c, started now. Times are in
+// c's location.
+func New(s store.QueueStore, c *cron.Cron) *Service {
+	now := func() time.Time { return time.Now().In(c.Location()) }
+	return &Service{
+		store:   s,
+		cron:    c,
+		started: now(),
+		now:     now,
+		names:   make(map[cron.EntryID]string),
+		results: make(map[string]JobResult),
+	}
+}
+
+// AddJob schedules job on the cron scheduler like cron.AddFunc and remembers
+// the result of its last run under name.
+func (s *Service) AddJob(spec, name string, job func() error) error {
+	id, err := s.cron.AddFunc(spec, func() {
+		start := s.now()
+		err := job()
+		s.mu.Lock()
+		s.results[name] = JobResult{At: start, Duration: s.now().Sub(start), Err: err}
+		s.mu.Unlock()
+	})
+	if err != nil {
+		return fmt.Errorf("failed to schedule %s: %w", name, err)
+	}
+	s.mu.Lock()
+	s.names[id] = name
+	s.mu.Unlock()
+	return nil
+}
+
+// RemoveJobs unschedules the jobs added under a name starting with prefix,
+// so they can be added again at other times. Their last results are kept.
+func (s *Service) RemoveJobs(prefix string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id, name := range s.names {
+		if strings.HasPrefix(name, prefix) {
+			s.cron.Remove(id)
+			delete(s.names, id)
+		}
+	}
+}
+
+// Snapshot returns the current state of the bot. A database file that can't
+// be read doesn't fail the snapshot, it shows up as size 0.
+func (s *Service) Snapshot(ctx context.Context) (*Snapshot, error) {
+	snap := &Snapshot{
+		Version:   s.Version,
+		Commit:    s.Commit,
+		Uptime:    s.now().Sub(s.started),
+		DBPath:    s.DBPath,
+		BackupDir: s.BackupDir,
+	}
+	if snap.Commit == "" {
+		snap.Commit = vcsRevision()
+	}
+	if s.DBPath != "" {
+		for _, path := range []string{s.DBPath, s.DBPath + "-wal"} {
+			if info, err := os.Stat(path); err == nil {
+				snap.DBSize += info.Size()
+			}
+		}
+	}
+	if s.BackupDir != "" {
+		if snap.LastBackup = newestFile(s.BackupDir); !snap.LastBackup.IsZero() {
+			snap.LastBackup = snap.LastBackup.In(s.cron.Location())
+		}
+	}
+
+	s.mu.Lock()
+	for _, e := range s.cron.Entries() {
+		job := Job{Name: s.names[e.ID], Next: e.Next}
+		if r, ok := s.results[job.Name]; ok {
+			job.Last = &r
+		}
+		snap.Jobs = append(snap.Jobs, job)
+	}
+	s.mu.Unlock()
+	sort.SliceStable(snap.Jobs, func(i, j int) bool { return snap.Jobs[i].Next.Before(snap.Jobs[j].Next) })
+
+	var err error
+	if snap.VolunteerQueue, err = s.store.GetUsersWithVolunteerQueue(ctx); err != nil {
+		return nil, fmt.Errorf("failed to get volunteer queue: %w", err)
+	}
+	if snap.AdminQueue, err = s.store.GetUsersWithAdminQueue(ctx); err != nil {
+		return nil, fmt.Errorf("failed to get admin queue: %w", err)
+	}
+
+	if s.APIErrors != nil {
+		snap.LastAPIErrorAt, snap.LastAPIError = s.APIErrors.LastAPIError()
+		if !snap.LastAPIErrorAt.IsZero() {
+			snap.LastAPIErrorAt = snap.LastAPIErrorAt.In(s.cron.Location())
+		}
+	}
+	return snap, nil
+}
+
+// newestFile returns when the newest file in dir was last modified, or the
+// zero time if there is none.
+func newestFile(dir string) time.Time {
+	var newest time.Time
+	entries, _ := os.ReadDir(dir)
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		info, err := os.Stat(filepath.Join(dir, e.Name()))
+		if err == nil && info.ModTime().After(newest) {
+			newest = info.ModTime()
+		}
+	}
+	return newest
+}
+
+// vcsRevision returns the commit the binary was built from, if Go recorded it.
+func vcsRevision() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "unknown"
+	}
+	for _, setting := range info.Settings {
+		if setting.Key == "vcs.revision" {
+			return setting.Value
+		}
+	}
+	return "unknown"
+}
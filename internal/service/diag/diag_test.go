@@ -0,0 +1,106 @@
+package diag
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/robfig/cron/v3"
+
+	"github.com/korjavin/dutyassistant/internal/store"
+	"github.com/korjavin/dutyassistant/internal/store/memory"
+)
+
+// apiErrors is an APIErrorSource with a fixed last error.
+type apiErrors struct {
+	at  time.Time
+	err error
+}
+
+func (a apiErrors) LastAPIError() (time.Time, error) { return a.at, a.err }
+
+func TestService_Snapshot(t *testing.T) {
+	ctx := context.Background()
+	s := memory.New()
+	alice := &store.User{TelegramUserID: 1, FirstName: "Alice", IsActive: true}
+	s.CreateUser(ctx, alice)
+	s.AddToVolunteerQueue(ctx, alice.ID, 2)
+
+	dir := t.TempDir()
+	dbPath := filepath.Join(dir, "roster.db")
+	os.WriteFile(dbPath, make([]byte, 1000), 0o600)
+	os.WriteFile(dbPath+"-wal", make([]byte, 24), 0o600)
+	backups := filepath.Join(dir, "backups")
+	os.Mkdir(backups, 0o700)
+
+	c := cron.New()
+	svc := New(s, c)
+	svc.Version, svc.Commit = "v1.2.3", "abc"
+	svc.DBPath, svc.BackupDir = dbPath, backups
+	failed := time.Date(2025, 11, 3, 9, 0, 0, 0, time.UTC)
+	svc.APIErrors = apiErrors{at: failed, err: errors.New("Forbidden: bot was blocked by the user")}
+
+	if err := svc.AddJob("0 11 * * *", "daily assignment", func() error { return nil }); err != nil {
+		t.Fatalf("AddJob failed: %v", err)
+	}
+	if err := svc.AddJob("0 21 * * *", "daily completion", func() error { return errors.New("no duty today") }); err != nil {
+		t.Fatalf("AddJob failed: %v", err)
+	}
+	if err := svc.AddJob("not a spec", "broken", func() error { return nil }); err == nil {
+		t.Error("Expected AddJob to fail for an invalid spec")
+	}
+	// Run the completion job as cron would
+	for _, e := range c.Entries() {
+		if svc.names[e.ID] == "daily completion" {
+			e.WrappedJob.Run()
+		}
+	}
+
+	snap, err := svc.Snapshot(ctx)
+	if err != nil {
+		t.Fatalf("Snapshot failed: %v", err)
+	}
+	if snap.Version != "v1.2.3" || snap.Commit != "abc" {
+		t.Errorf("Unexpected build %s (%s)", snap.Version, snap.Commit)
+	}
+	if snap.DBSize != 1024 {
+		t.Errorf("Expected the database and its WAL to take 1024 bytes, got %d", snap.DBSize)
+	}
+	if !snap.LastBackup.IsZero() {
+		t.Errorf("Expected no backup in an empty directory, got %v", snap.LastBackup)
+	}
+	if len(snap.Jobs) != 2 {
+		t.Fatalf("Expected 2 jobs, got %+v", snap.Jobs)
+	}
+	for _, job := range snap.Jobs {
+		switch job.Name {
+		case "daily assignment":
+			if job.Last != nil {
+				t.Errorf("Expected the assignment not to have run, got %+v", job.Last)
+			}
+		case "daily completion":
+			if job.Last == nil || job.Last.Err == nil || job.Last.Err.Error() != "no duty today" {
+				t.Errorf("Expected the completion's error to be recorded, got %+v", job.Last)
+			}
+		default:
+			t.Errorf("Unexpected job %q", job.Name)
+		}
+	}
+	if len(snap.VolunteerQueue) != 1 || snap.VolunteerQueue[0].VolunteerQueueDays != 2 || len(snap.AdminQueue) != 0 {
+		t.Errorf("Expected Alice with 2 volunteer days, got %+v and %+v", snap.VolunteerQueue, snap.AdminQueue)
+	}
+	if !snap.LastAPIErrorAt.Equal(failed) || snap.LastAPIError == nil {
+		t.Errorf("Expected the last API error, got %v at %v", snap.LastAPIError, snap.LastAPIErrorAt)
+	}
+
+	backup := filepath.Join(backups, "roster-2025-11-03.db")
+	os.WriteFile(backup, nil, 0o600)
+	made := time.Date(2025, 11, 3, 2, 0, 0, 0, time.UTC)
+	os.Chtimes(backup, made, made)
+	if snap, _ := svc.Snapshot(ctx); !snap.LastBackup.Equal(made) {
+		t.Errorf("Expected the last backup at %v, got %v", made, snap.LastBackup)
+	}
+}
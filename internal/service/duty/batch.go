@@ -0,0 +1,81 @@
+package duty
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/korjavin/dutyassistant/internal/scheduler"
+	"github.com/korjavin/dutyassistant/internal/store"
+)
+
+// BatchAction is what an operation of a batch does to its day.
+type BatchAction string
+
+const (
+	// BatchCreate assigns a free day to a user, like Assign by an admin.
+	BatchCreate BatchAction = "create"
+	// BatchModify hands a duty over to another user, like Reassign.
+	BatchModify BatchAction = "modify"
+	// BatchDelete removes a duty, like Remove.
+	BatchDelete BatchAction = "delete"
+)
+
+// ErrBatchFailed is returned when an operation of a batch failed, so none of
+// them were applied.
+var ErrBatchFailed = errors.New("an operation of the batch failed, nothing was changed")
+
+// BatchOp is one change of a batch.
+type BatchOp struct {
+	Action BatchAction
+	Date   time.Time
+	UserID int64                // Unused by BatchDelete
+	Mode   scheduler.ChangeMode // Only used by BatchModify
+}
+
+// BatchResult is the outcome of one operation of a batch.
+type BatchResult struct {
+	Duty *store.Duty // The duty after the operation; nil for BatchDelete or if it failed
+	Err  error
+}
+
+// Batch applies the operations in order, as if one after the other, and
+// keeps their changes only if all of them succeed. Every operation is tried
+// even after one failed, so the results report each of them; in that case
+// nothing is changed and ErrBatchFailed is returned with the results.
+func (s *Service) Batch(ctx context.Context, ops []BatchOp) ([]BatchResult, error) {
+	results := make([]BatchResult, len(ops))
+	err := s.scheduler.RunInTx(ctx, func(tx scheduler.SchedulerInterface) error {
+		failed := false
+		for i, op := range ops {
+			// Each operation runs on its own, so a failing one leaves nothing
+			// behind that the next ones would see
+			results[i].Err = tx.RunInTx(ctx, func(opTx scheduler.SchedulerInterface) error {
+				var err error
+				results[i].Duty, err = (&Service{scheduler: opTx, users: s.users, availability: s.availability}).apply(ctx, op)
+				return err
+			})
+			failed = failed || results[i].Err != nil
+		}
+		if failed {
+			return ErrBatchFailed
+		}
+		return nil
+	})
+	return results, err
+}
+
+// apply makes the change of one operation.
+func (s *Service) apply(ctx context.Context, op BatchOp) (*store.Duty, error) {
+	switch op.Action {
+	case BatchCreate:
+		return s.Assign(ctx, op.Date, op.UserID, store.AssignmentTypeAdmin)
+	case BatchModify:
+		return s.Reassign(ctx, op.Date, op.UserID, op.Mode)
+	case BatchDelete:
+		return nil, s.Remove(ctx, op.Date)
+	default:
+		return nil, fmt.Errorf("unknown batch action %q", op.Action)
+	}
+}
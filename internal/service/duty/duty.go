@@ -0,0 +1,240 @@
+// Package duty holds the rules for changing the duty calendar by hand:
+// assigning, volunteering for, holding, reassigning, removing, backfilling and
+// completing days.
+// The Telegram bot and the HTTP API both go through it so a change behaves the
+// same way whichever interface it came from. Changes are announced by the
+// subscribers of the scheduler's events.
+package duty
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/korjavin/dutyassistant/internal/scheduler"
+	"github.com/korjavin/dutyassistant/internal/service/user"
+	"github.com/korjavin/dutyassistant/internal/store"
+)
+
+// MaxBackfillAge is how long ago a backfilled duty may be.
+const MaxBackfillAge = 365 * 24 * time.Hour
+
+var (
+	// ErrInactiveUser is returned when putting an inactive user on duty.
+	ErrInactiveUser = errors.New("the user is not active")
+	// ErrPendingUser is returned when putting a user on duty who still waits
+	// for an admin to approve their registration.
+	ErrPendingUser = errors.New("the user is waiting for approval")
+	// ErrOffDuty is returned when putting a user on duty on a day they are off duty.
+	ErrOffDuty = errors.New("the user is off duty on this date")
+	// ErrDateTooOld is returned when backfilling a day longer ago than MaxBackfillAge.
+	ErrDateTooOld = errors.New("the date is too long ago")
+)
+
+// Service changes duties on behalf of users and admins.
+type Service struct {
+	scheduler    scheduler.SchedulerInterface
+	users        *user.Service
+	availability store.AvailabilityStore
+}
+
+// New creates a new Service. availability tells when users are off duty.
+func New(sch scheduler.SchedulerInterface, users *user.Service, availability store.AvailabilityStore) *Service {
+	return &Service{scheduler: sch, users: users, availability: availability}
+}
+
+// Assign puts a user on duty for a free day, regardless of queues. The user
+// must be active and not off duty then, unless the day is recorded as
+// covered by external help.
+func (s *Service) Assign(ctx context.Context, date time.Time, userID int64, assignType store.AssignmentType) (*store.Duty, error) {
+	u, err := s.users.ByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if assignType != store.AssignmentTypeExternal {
+		if err := s.checkAvailable(ctx, u, date); err != nil {
+			return nil, err
+		}
+	} else if err := checkApproved(u); err != nil {
+		return nil, err
+	}
+	return s.assign(ctx, date, u, assignType)
+}
+
+// AssignAnyway is Assign by an admin for a day nobody is available for: the
+// user may be inactive or off duty, but not waiting for approval.
+func (s *Service) AssignAnyway(ctx context.Context, date time.Time, userID int64) (*store.Duty, error) {
+	u, err := s.users.ByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkApproved(u); err != nil {
+		return nil, err
+	}
+	return s.assign(ctx, date, u, store.AssignmentTypeAdmin)
+}
+
+// checkApproved returns ErrPendingUser if u still waits for approval. Pending
+// users are never put on duty, not even by admins.
+func checkApproved(u *store.User) error {
+	if u.IsPending {
+		return fmt.Errorf("%w: %s", ErrPendingUser, u.FirstName)
+	}
+	return nil
+}
+
+// checkAvailable returns ErrPendingUser, ErrInactiveUser or ErrOffDuty unless
+// u may be put on duty on date.
+func (s *Service) checkAvailable(ctx context.Context, u *store.User, date time.Time) error {
+	if err := checkApproved(u); err != nil {
+		return err
+	}
+	if !u.IsActive {
+		return fmt.Errorf("%w: %s", ErrInactiveUser, u.FirstName)
+	}
+	off, err := s.availability.IsUserOffDuty(ctx, u.ID, date)
+	if err != nil {
+		return fmt.Errorf("failed to check off-duty period: %w", err)
+	}
+	if off {
+		return fmt.Errorf("%w: %s", ErrOffDuty, u.FirstName)
+	}
+	return nil
+}
+
+// AssignHeld puts a user on duty for a free day like Assign, but gives the day
+// back to the daily assignment unless the duty is confirmed by the end of until.
+func (s *Service) AssignHeld(ctx context.Context, date time.Time, userID int64, until time.Time) (*store.Duty, error) {
+	u, err := s.users.ByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkAvailable(ctx, u, date); err != nil {
+		return nil, err
+	}
+
+	duty, err := s.scheduler.AssignHeldDuty(ctx, date, u.ID, until)
+	if err != nil {
+		return nil, err
+	}
+	duty.User = u
+	return duty, nil
+}
+
+// Hold puts today's or a future duty on hold until the end of until.
+func (s *Service) Hold(ctx context.Context, date, until time.Time) (*store.Duty, error) {
+	return s.scheduler.HoldDuty(ctx, date, &until)
+}
+
+// Confirm lifts the hold of today's or a future duty so it stays assigned.
+func (s *Service) Confirm(ctx context.Context, date time.Time) (*store.Duty, error) {
+	return s.scheduler.HoldDuty(ctx, date, nil)
+}
+
+// AssignToday runs today's daily assignment now instead of waiting for the
+// cutoff. It returns a nil duty if today is a skip day.
+func (s *Service) AssignToday(ctx context.Context) (*store.Duty, error) {
+	duty, err := s.scheduler.AutoAssignDuty(ctx, time.Now())
+	if err != nil || duty == nil {
+		return duty, err
+	}
+	if u, err := s.users.ByID(ctx, duty.UserID); err == nil {
+		duty.User = u
+	}
+	return duty, nil
+}
+
+// Acknowledge records that u confirmed their duty on date.
+func (s *Service) Acknowledge(ctx context.Context, date time.Time, u *store.User) (*store.Duty, error) {
+	duty, err := s.scheduler.AcknowledgeDuty(ctx, date, u.ID)
+	if err != nil {
+		return nil, err
+	}
+	duty.User = u
+	return duty, nil
+}
+
+// Volunteer puts a user on duty for a free day of their choice. Like
+// Assign, the user must be active and not off duty then.
+func (s *Service) Volunteer(ctx context.Context, date time.Time, u *store.User) (*store.Duty, error) {
+	if err := s.checkAvailable(ctx, u, date); err != nil {
+		return nil, err
+	}
+	return s.assign(ctx, date, u, store.AssignmentTypeVoluntary)
+}
+
+func (s *Service) assign(ctx context.Context, date time.Time, u *store.User, assignType store.AssignmentType) (*store.Duty, error) {
+	duty, err := s.scheduler.AssignDutyTo(ctx, date, u.ID, assignType)
+	if err != nil {
+		return nil, err
+	}
+	duty.User = u
+	return duty, nil
+}
+
+// Reassign hands today's or a future duty over to another user, who must be
+// active and not off duty then. The mode says whether queue days move along
+// with it.
+func (s *Service) Reassign(ctx context.Context, date time.Time, userID int64, mode scheduler.ChangeMode) (*store.Duty, error) {
+	u, err := s.users.ByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkAvailable(ctx, u, date); err != nil {
+		return nil, err
+	}
+
+	duty, err := s.scheduler.ChangeDutyUser(ctx, date, u.ID, mode)
+	if err != nil {
+		return nil, err
+	}
+	duty.User = u
+	return duty, nil
+}
+
+// Remove removes today's or a future duty, leaving the day unassigned.
+func (s *Service) Remove(ctx context.Context, date time.Time) error {
+	return s.scheduler.RemoveDuty(ctx, date)
+}
+
+// Backfill records who actually did the duty on a past day, at most
+// MaxBackfillAge ago. The user may have become inactive since, but can't be
+// waiting for approval.
+func (s *Service) Backfill(ctx context.Context, date time.Time, userID int64) (*store.Duty, error) {
+	if time.Since(date) > MaxBackfillAge {
+		return nil, ErrDateTooOld
+	}
+	u, err := s.users.ByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkApproved(u); err != nil {
+		return nil, err
+	}
+
+	duty, err := s.scheduler.BackfillDuty(ctx, date, u.ID)
+	if err != nil {
+		return nil, err
+	}
+	duty.User = u
+	return duty, nil
+}
+
+// SetCompletion marks the duty of today or a past day as done, or takes that
+// back, recording actor as who did it. actor may be nil for an admin who
+// isn't registered.
+func (s *Service) SetCompletion(ctx context.Context, date time.Time, completed bool, actor *store.User) (*store.Duty, error) {
+	var actorID int64
+	if actor != nil {
+		actorID = actor.ID
+	}
+	duty, err := s.scheduler.SetDutyCompletion(ctx, date, completed, actorID)
+	if err != nil {
+		return nil, err
+	}
+	if u, err := s.users.ByID(ctx, duty.UserID); err == nil {
+		duty.User = u
+	}
+	return duty, nil
+}
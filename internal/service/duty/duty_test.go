@@ -0,0 +1,306 @@
+package duty
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/korjavin/dutyassistant/internal/events"
+	"github.com/korjavin/dutyassistant/internal/scheduler"
+	"github.com/korjavin/dutyassistant/internal/service/user"
+	"github.com/korjavin/dutyassistant/internal/store"
+	"github.com/korjavin/dutyassistant/internal/store/memory"
+)
+
+// recorder remembers the events the scheduler published.
+type recorder struct {
+	events []events.Event
+}
+
+func (r *recorder) handle(_ context.Context, e events.Event) {
+	r.events = append(r.events, e)
+}
+
+// newTestService returns a service backed by an in-memory store seeded with
+// two active users.
+func newTestService(t *testing.T) (*Service, *recorder, []*store.User) {
+	t.Helper()
+	ctx := context.Background()
+	s := memory.New()
+
+	users := []*store.User{
+		{TelegramUserID: 1, FirstName: "Alice", IsActive: true},
+		{TelegramUserID: 2, FirstName: "Bob", IsActive: true},
+	}
+	for _, u := range users {
+		if err := s.CreateUser(ctx, u); err != nil {
+			t.Fatalf("Failed to create user: %v", err)
+		}
+	}
+
+	rec := &recorder{}
+	sched := scheduler.NewScheduler(s)
+	sched.Events = events.NewBus()
+	sched.Events.Subscribe(rec.handle)
+	return New(sched, user.New(s), s), rec, users
+}
+
+func today() time.Time {
+	now := time.Now()
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+func TestService_Assign(t *testing.T) {
+	svc, rec, users := newTestService(t)
+	ctx := context.Background()
+	alice, bob := users[0], users[1]
+
+	duty, err := svc.Assign(ctx, today(), alice.ID, store.AssignmentTypeAdmin)
+	if err != nil {
+		t.Fatalf("Assign failed: %v", err)
+	}
+	if duty.User == nil || duty.User.ID != alice.ID {
+		t.Errorf("Expected the duty to carry Alice, got %+v", duty.User)
+	}
+	if len(rec.events) != 1 {
+		t.Errorf("Expected one event for the assignment, got %v", rec.events)
+	}
+
+	if _, err := svc.Assign(ctx, today(), bob.ID, store.AssignmentTypeAdmin); !errors.Is(err, scheduler.ErrDutyTaken) {
+		t.Errorf("Expected ErrDutyTaken for a taken day, got %v", err)
+	}
+
+	tomorrow := today().AddDate(0, 0, 1)
+	if _, err := svc.Volunteer(ctx, tomorrow, bob); err != nil {
+		t.Fatalf("Volunteer failed: %v", err)
+	}
+	if e, ok := rec.events[len(rec.events)-1].(events.DutyAssigned); !ok || !e.Duty.DutyDate.Equal(tomorrow) {
+		t.Errorf("Expected DutyAssigned for tomorrow, got %v", rec.events)
+	}
+
+	if _, err := svc.Assign(ctx, tomorrow.AddDate(0, 0, 1), 42, store.AssignmentTypeAdmin); !errors.Is(err, user.ErrNotFound) {
+		t.Errorf("Expected user.ErrNotFound for an unknown user, got %v", err)
+	}
+}
+
+func TestService_HoldAndConfirm(t *testing.T) {
+	svc, _, users := newTestService(t)
+	ctx := context.Background()
+	alice := users[0]
+	inThreeDays := today().AddDate(0, 0, 3)
+
+	duty, err := svc.AssignHeld(ctx, inThreeDays, alice.ID, today())
+	if err != nil {
+		t.Fatalf("AssignHeld failed: %v", err)
+	}
+	if duty.User == nil || duty.User.ID != alice.ID || duty.HoldUntil == nil {
+		t.Errorf("Expected a held duty for Alice, got %+v", duty)
+	}
+
+	duty, err = svc.Hold(ctx, inThreeDays, today().AddDate(0, 0, 1))
+	if err != nil {
+		t.Fatalf("Hold failed: %v", err)
+	}
+	if !duty.HoldUntil.Equal(today().AddDate(0, 0, 1)) {
+		t.Errorf("Expected the hold to move to tomorrow, got %v", duty.HoldUntil)
+	}
+	if _, err := svc.Hold(ctx, inThreeDays, inThreeDays); !errors.Is(err, scheduler.ErrInvalidHold) {
+		t.Errorf("Expected ErrInvalidHold for a hold until the duty day, got %v", err)
+	}
+
+	duty, err = svc.Confirm(ctx, inThreeDays)
+	if err != nil {
+		t.Fatalf("Confirm failed: %v", err)
+	}
+	if duty.HoldUntil != nil {
+		t.Errorf("Expected the confirmed duty not to be held, got %v", duty.HoldUntil)
+	}
+}
+
+func TestService_ReassignAndRemove(t *testing.T) {
+	svc, rec, users := newTestService(t)
+	ctx := context.Background()
+	alice, bob := users[0], users[1]
+	tomorrow := today().AddDate(0, 0, 1)
+
+	if _, err := svc.Reassign(ctx, tomorrow, bob.ID, scheduler.ChangeModeKeep); !errors.Is(err, scheduler.ErrNoDuty) {
+		t.Errorf("Expected ErrNoDuty when reassigning a free day, got %v", err)
+	}
+
+	if _, err := svc.Assign(ctx, tomorrow, alice.ID, store.AssignmentTypeAdmin); err != nil {
+		t.Fatalf("Assign failed: %v", err)
+	}
+	duty, err := svc.Reassign(ctx, tomorrow, bob.ID, scheduler.ChangeModeKeep)
+	if err != nil {
+		t.Fatalf("Reassign failed: %v", err)
+	}
+	if duty.UserID != bob.ID || duty.User.FirstName != "Bob" {
+		t.Errorf("Expected the duty to move to Bob, got user %d", duty.UserID)
+	}
+	if e, ok := rec.events[len(rec.events)-1].(events.DutyReassigned); !ok || e.PreviousUserID != alice.ID {
+		t.Errorf("Expected DutyReassigned from Alice, got %v", rec.events)
+	}
+
+	if err := svc.Remove(ctx, tomorrow); err != nil {
+		t.Fatalf("Remove failed: %v", err)
+	}
+	if e, ok := rec.events[len(rec.events)-1].(events.DutyRemoved); !ok || e.Duty.UserID != bob.ID || e.Skip != "" {
+		t.Errorf("Expected DutyRemoved of Bob's duty, got %v", rec.events)
+	}
+	if err := svc.Remove(ctx, tomorrow); !errors.Is(err, scheduler.ErrNoDuty) {
+		t.Errorf("Expected ErrNoDuty when removing a free day, got %v", err)
+	}
+}
+
+func TestService_Backfill(t *testing.T) {
+	svc, rec, users := newTestService(t)
+	ctx := context.Background()
+	alice := users[0]
+
+	if _, err := svc.Backfill(ctx, today(), alice.ID); !errors.Is(err, scheduler.ErrNotPastDate) {
+		t.Errorf("Expected ErrNotPastDate for today, got %v", err)
+	}
+
+	yesterday := today().AddDate(0, 0, -1)
+	duty, err := svc.Backfill(ctx, yesterday, alice.ID)
+	if err != nil {
+		t.Fatalf("Backfill failed: %v", err)
+	}
+	if duty.BackfilledAt == nil {
+		t.Error("Expected the duty to be marked as backfilled")
+	}
+	// Only logged, not announced like an assignment
+	if len(rec.events) != 1 || rec.events[0].Name() != "duty_backfilled" {
+		t.Errorf("Expected backfilled duties to publish DutyBackfilled only, got %v", rec.events)
+	}
+
+	if _, err := svc.Backfill(ctx, today().AddDate(-2, 0, 0), alice.ID); !errors.Is(err, ErrDateTooOld) {
+		t.Errorf("Expected ErrDateTooOld two years back, got %v", err)
+	}
+}
+
+func TestService_Availability(t *testing.T) {
+	svc, _, users := newTestService(t)
+	ctx := context.Background()
+	alice, bob := users[0], users[1]
+	tomorrow := today().AddDate(0, 0, 1)
+
+	if err := svc.availability.SetOffDuty(ctx, alice.ID, tomorrow, tomorrow); err != nil {
+		t.Fatalf("SetOffDuty failed: %v", err)
+	}
+	if _, err := svc.Assign(ctx, tomorrow, alice.ID, store.AssignmentTypeAdmin); !errors.Is(err, ErrOffDuty) {
+		t.Errorf("Expected ErrOffDuty assigning Alice, got %v", err)
+	}
+	if _, err := svc.Volunteer(ctx, tomorrow, alice); !errors.Is(err, ErrOffDuty) {
+		t.Errorf("Expected ErrOffDuty for Alice volunteering, got %v", err)
+	}
+	if _, err := svc.AssignHeld(ctx, tomorrow, alice.ID, today()); !errors.Is(err, ErrOffDuty) {
+		t.Errorf("Expected ErrOffDuty holding a day for Alice, got %v", err)
+	}
+
+	if err := svc.users.ToggleActive(ctx, bob); err != nil {
+		t.Fatalf("ToggleActive failed: %v", err)
+	}
+	if _, err := svc.Assign(ctx, tomorrow, bob.ID, store.AssignmentTypeAdmin); !errors.Is(err, ErrInactiveUser) {
+		t.Errorf("Expected ErrInactiveUser assigning Bob, got %v", err)
+	}
+
+	// External help doesn't need the admin it is recorded on to be available
+	if _, err := svc.Assign(ctx, tomorrow, alice.ID, store.AssignmentTypeExternal); err != nil {
+		t.Errorf("Expected external help to be recorded, got %v", err)
+	}
+	if _, err := svc.Reassign(ctx, tomorrow, bob.ID, scheduler.ChangeModeKeep); !errors.Is(err, ErrInactiveUser) {
+		t.Errorf("Expected ErrInactiveUser reassigning to Bob, got %v", err)
+	}
+
+	// When nobody is available, the admin may pick anyone
+	if err := svc.Remove(ctx, tomorrow); err != nil {
+		t.Fatalf("Remove failed: %v", err)
+	}
+	if duty, err := svc.AssignAnyway(ctx, tomorrow, alice.ID); err != nil || duty.UserID != alice.ID {
+		t.Errorf("Expected AssignAnyway to put Alice on duty, got %v, %v", duty, err)
+	}
+}
+
+func TestService_PendingUser(t *testing.T) {
+	svc, _, _ := newTestService(t)
+	ctx := context.Background()
+	carol, err := svc.users.Register(ctx, 3, "Carol", false, true)
+	if err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+	tomorrow := today().AddDate(0, 0, 1)
+
+	// Not even an admin can put a user on duty before approving them
+	if _, err := svc.Assign(ctx, tomorrow, carol.ID, store.AssignmentTypeAdmin); !errors.Is(err, ErrPendingUser) {
+		t.Errorf("Expected ErrPendingUser assigning Carol, got %v", err)
+	}
+	if _, err := svc.AssignAnyway(ctx, tomorrow, carol.ID); !errors.Is(err, ErrPendingUser) {
+		t.Errorf("Expected ErrPendingUser assigning Carol anyway, got %v", err)
+	}
+	if _, err := svc.Backfill(ctx, today().AddDate(0, 0, -1), carol.ID); !errors.Is(err, ErrPendingUser) {
+		t.Errorf("Expected ErrPendingUser backfilling Carol, got %v", err)
+	}
+	if err := svc.users.ToggleActive(ctx, carol); !errors.Is(err, user.ErrPending) {
+		t.Errorf("Expected user.ErrPending activating Carol, got %v", err)
+	}
+
+	if err := svc.users.Approve(ctx, carol); err != nil {
+		t.Fatalf("Approve failed: %v", err)
+	}
+	if _, err := svc.Assign(ctx, tomorrow, carol.ID, store.AssignmentTypeAdmin); err != nil {
+		t.Errorf("Expected approved Carol to be assigned, got %v", err)
+	}
+}
+
+func TestService_Batch(t *testing.T) {
+	svc, rec, users := newTestService(t)
+	ctx := context.Background()
+	alice, bob := users[0], users[1]
+	day1, day2, day3 := today().AddDate(0, 0, 1), today().AddDate(0, 0, 2), today().AddDate(0, 0, 3)
+
+	if _, err := svc.Assign(ctx, day3, alice.ID, store.AssignmentTypeAdmin); err != nil {
+		t.Fatalf("Assign failed: %v", err)
+	}
+	rec.events = nil
+
+	// The second operation works on the duty the first one created
+	results, err := svc.Batch(ctx, []BatchOp{
+		{Action: BatchCreate, Date: day1, UserID: alice.ID},
+		{Action: BatchModify, Date: day1, UserID: bob.ID},
+		{Action: BatchDelete, Date: day3},
+	})
+	if err != nil {
+		t.Fatalf("Batch failed: %v", err)
+	}
+	if results[1].Duty == nil || results[1].Duty.UserID != bob.ID || results[2].Err != nil {
+		t.Errorf("Unexpected results %+v", results)
+	}
+	if len(rec.events) != 3 || rec.events[2].Name() != "duty_removed" {
+		t.Errorf("Expected the events to be published after the batch, got %v", rec.events)
+	}
+
+	// One failing operation rolls back the others
+	rec.events = nil
+	results, err = svc.Batch(ctx, []BatchOp{
+		{Action: BatchCreate, Date: day2, UserID: alice.ID},
+		{Action: BatchCreate, Date: day1, UserID: alice.ID},
+		{Action: BatchDelete, Date: day1},
+	})
+	if !errors.Is(err, ErrBatchFailed) {
+		t.Fatalf("Expected ErrBatchFailed, got %v", err)
+	}
+	if results[0].Err != nil || !errors.Is(results[1].Err, scheduler.ErrDutyTaken) || results[2].Err != nil {
+		t.Errorf("Unexpected results %+v", results)
+	}
+	if len(rec.events) != 0 {
+		t.Errorf("Expected no events for a failed batch, got %v", rec.events)
+	}
+	if _, err := svc.Assign(ctx, day2, bob.ID, store.AssignmentTypeAdmin); err != nil {
+		t.Errorf("Expected the duty created by the failed batch to be rolled back, got %v", err)
+	}
+	if err := svc.Remove(ctx, day1); err != nil {
+		t.Errorf("Expected the duty removed by the failed batch to be back, got %v", err)
+	}
+}
@@ -0,0 +1,145 @@
+// Package dutyjobs schedules the cron jobs of the duty: the daily assignment
+// and the completion check. Their times are kept in the settings, where
+// /settings time changes them, and fall back to ASSIGNMENT_TIME and 21:00.
+// Refresh moves the jobs when the times change, without a restart.
+//
+// There is one kind of duty so far. Once there are others, each gets its
+// times and jobs here the same way.
+package dutyjobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/korjavin/dutyassistant/internal/scheduler"
+	"github.com/korjavin/dutyassistant/internal/service/diag"
+	"github.com/korjavin/dutyassistant/internal/service/settings"
+)
+
+const (
+	// DefaultCompletion is when the duty is checked for completion unless
+	// the settings say otherwise.
+	DefaultCompletion = 21 * time.Hour
+	// latestAssignment is the last time the duty may be assigned at, the
+	// hour of the last reminders.
+	latestAssignment = 20 * time.Hour
+
+	assignmentJob = "daily assignment"
+	completionJob = "daily completion"
+)
+
+// ErrInvalidTime is returned for times the jobs can't run at.
+var ErrInvalidTime = errors.New("invalid time")
+
+// Manager schedules the jobs of the duty at the times of the settings.
+type Manager struct {
+	diag     *diag.Service
+	settings *settings.Service
+	sched    *scheduler.Scheduler
+	loc      *time.Location
+	assign   func() error
+	complete func() error
+
+	mu sync.Mutex // Refresh replaces the jobs one at a time
+}
+
+// New creates a Manager whose jobs run assign and complete, scheduled through
+// d in loc. Nothing is scheduled until Refresh.
+func New(d *diag.Service, st *settings.Service, sched *scheduler.Scheduler, loc *time.Location, assign, complete func() error) *Manager {
+	return &Manager{diag: d, settings: st, sched: sched, loc: loc, assign: assign, complete: complete}
+}
+
+// Times returns the times the jobs run at, with the defaults filled in.
+func (m *Manager) Times(ctx context.Context) (settings.DutyTimes, error) {
+	t, err := m.settings.DutyTimes(ctx)
+	if err != nil {
+		return settings.DutyTimes{}, err
+	}
+	if t.Assignment == 0 {
+		t.Assignment = m.sched.Cutoff
+	}
+	if t.Completion == 0 {
+		t.Completion = DefaultCompletion
+	}
+	return t, nil
+}
+
+// Set stores the times, 0 for the default of either, and moves the jobs. The
+// duty must be assigned after the day rollover and before the last
+// reminders, and checked after it is assigned, in every season.
+func (m *Manager) Set(ctx context.Context, t settings.DutyTimes) error {
+	assignment, completion := t.Assignment, t.Completion
+	if assignment == 0 {
+		assignment = m.sched.Cutoff
+	}
+	if completion == 0 {
+		completion = DefaultCompletion
+	}
+	if assignment <= m.sched.DayRollover || assignment >= latestAssignment {
+		return fmt.Errorf("%w: the duty must be assigned after %s and before the %s reminders",
+			ErrInvalidTime, formatTime(m.sched.DayRollover), formatTime(latestAssignment))
+	}
+	latest := assignment
+	for _, season := range m.sched.Seasons {
+		latest = max(latest, season.Cutoff)
+	}
+	if completion <= latest {
+		return fmt.Errorf("%w: the duty must be checked after it is assigned at %s", ErrInvalidTime, formatTime(latest))
+	}
+
+	if err := m.settings.SetDutyTimes(ctx, t); err != nil {
+		return fmt.Errorf("failed to store the times: %w", err)
+	}
+	return m.Refresh(ctx)
+}
+
+// Refresh schedules the jobs at the times of the settings, replacing the
+// ones scheduled before. Seasons with a time of their own get an assignment
+// job each, which only runs on their days.
+func (m *Manager) Refresh(ctx context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	t, err := m.settings.DutyTimes(ctx)
+	if err != nil {
+		return err
+	}
+	m.sched.SetAssignmentTime(t.Assignment)
+	completion := t.Completion
+	if completion == 0 {
+		completion = DefaultCompletion
+	}
+
+	m.diag.RemoveJobs(assignmentJob)
+	cutoffs := m.sched.Cutoffs()
+	for _, cutoff := range cutoffs {
+		name := assignmentJob
+		if len(cutoffs) > 1 {
+			name = fmt.Sprintf("%s %s", assignmentJob, formatTime(cutoff))
+		}
+		if err := m.diag.AddJob(spec(cutoff), name, func() error {
+			if m.sched.CutoffOn(scheduler.Today(time.Now().In(m.loc), 0)) != cutoff {
+				return nil
+			}
+			return m.assign()
+		}); err != nil {
+			return err
+		}
+	}
+
+	m.diag.RemoveJobs(completionJob)
+	return m.diag.AddJob(spec(completion), completionJob, m.complete)
+}
+
+// spec returns the cron spec of a job running daily at the time of day d.
+func spec(d time.Duration) string {
+	return fmt.Sprintf("%d %d * * *", int(d.Minutes())%60, int(d.Hours()))
+}
+
+// formatTime formats a time of day as HH:MM.
+func formatTime(d time.Duration) string {
+	return fmt.Sprintf("%02d:%02d", int(d.Hours()), int(d.Minutes())%60)
+}
@@ -0,0 +1,78 @@
+package dutyjobs
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/robfig/cron/v3"
+
+	"github.com/korjavin/dutyassistant/internal/scheduler"
+	"github.com/korjavin/dutyassistant/internal/service/diag"
+	"github.com/korjavin/dutyassistant/internal/service/settings"
+	"github.com/korjavin/dutyassistant/internal/store/memory"
+)
+
+func TestManager(t *testing.T) {
+	ctx := context.Background()
+	s := memory.New()
+	c := cron.New(cron.WithLocation(time.UTC))
+	sched := scheduler.NewScheduler(s)
+	sched.DayRollover = 4 * time.Hour
+	noop := func() error { return nil }
+	m := New(diag.New(s, c), settings.New(s), sched, time.UTC, noop, noop)
+
+	// times returns when the jobs run, earliest first
+	midnight := time.Date(2025, 11, 5, 0, 0, 0, 0, time.UTC)
+	times := func() []string {
+		var times []string
+		for _, e := range c.Entries() {
+			times = append(times, e.Schedule.Next(midnight).Format("15:04"))
+		}
+		sort.Strings(times)
+		return times
+	}
+
+	if err := m.Refresh(ctx); err != nil {
+		t.Fatalf("Refresh failed: %v", err)
+	}
+	if got := times(); len(got) != 2 || got[0] != "11:00" || got[1] != "21:00" {
+		t.Errorf("Jobs by default at %v, want 11:00 and 21:00", got)
+	}
+
+	if err := m.Set(ctx, settings.DutyTimes{Assignment: 13*time.Hour + 30*time.Minute, Completion: 22 * time.Hour}); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if got := times(); len(got) != 2 || got[0] != "13:30" || got[1] != "22:00" {
+		t.Errorf("Jobs after Set at %v, want 13:30 and 22:00", got)
+	}
+	if cutoff := sched.CutoffOn(midnight); cutoff != 13*time.Hour+30*time.Minute {
+		t.Errorf("The scheduler assigns at %v, want 13:30", cutoff)
+	}
+	if got, err := m.Times(ctx); err != nil || got.Assignment != 13*time.Hour+30*time.Minute {
+		t.Errorf("Times = %+v, %v, want the assignment at 13:30", got, err)
+	}
+
+	for _, invalid := range []settings.DutyTimes{
+		{Assignment: 3 * time.Hour},                              // before the day rollover
+		{Assignment: 20 * time.Hour},                             // after the last reminders
+		{Assignment: 12 * time.Hour, Completion: 12 * time.Hour}, // checked before it is assigned
+	} {
+		if err := m.Set(ctx, invalid); !errors.Is(err, ErrInvalidTime) {
+			t.Errorf("Set(%+v) = %v, want ErrInvalidTime", invalid, err)
+		}
+	}
+
+	// Back to the defaults
+	if err := m.Set(ctx, settings.DutyTimes{}); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if got := times(); len(got) != 2 || got[0] != "11:00" || got[1] != "21:00" {
+		t.Errorf("Jobs after going back to the defaults at %v, want 11:00 and 21:00", got)
+	}
+	if cutoff := sched.CutoffOn(midnight); cutoff != scheduler.DefaultCutoff {
+		t.Errorf("The scheduler assigns at %v, want the default", cutoff)
+	}
+}
@@ -0,0 +1,229 @@
+// Package history keeps versions of the monthly schedules. Whenever a month's
+// schedule changed, a snapshot of it is stored as its next version, so admins
+// can see the schedule as it was at some point and what changed between two
+// versions. Changes are noticed on the scheduler's events and, for those made
+// without one like plans following a queue, by a regular Sync. The events
+// also go into the change log the feed and Grafana annotations are made of.
+package history
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/korjavin/dutyassistant/internal/events"
+	"github.com/korjavin/dutyassistant/internal/store"
+)
+
+// ErrNoVersion is returned when a month has no version matching the request.
+var ErrNoVersion = errors.New("no such version of the schedule")
+
+// Change is a day that differs between two versions. Before or After is nil
+// if the day had no duty and wasn't skipped in that version.
+type Change struct {
+	Date   time.Time
+	Before *store.ScheduleDay
+	After  *store.ScheduleDay
+}
+
+// Service takes and reads the snapshots.
+type Service struct {
+	store store.Store
+	now   func() time.Time
+
+	mu sync.Mutex // Snapshot compares with the last version before adding one
+}
+
+// New creates a new Service backed by the given store.
+func New(s store.Store) *Service {
+	return &Service{store: s, now: time.Now}
+}
+
+// Snapshot stores the schedule of the month starting on month as its next
+// version if it differs from the last one. It returns the new version, or
+// nil if nothing changed.
+func (s *Service) Snapshot(ctx context.Context, month time.Time) (*store.ScheduleVersion, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	first := time.Date(month.Year(), month.Month(), 1, 0, 0, 0, 0, time.UTC)
+	duties, err := s.store.GetDutiesByMonth(ctx, first.Year(), first.Month())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get duties: %w", err)
+	}
+	skips, err := s.store.GetSkipDaysByMonth(ctx, first.Year(), first.Month())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get skip days: %w", err)
+	}
+	var days []store.ScheduleDay
+	for _, d := range duties {
+		days = append(days, store.ScheduleDay{Date: day(d.DutyDate), UserID: d.UserID, AssignmentType: d.AssignmentType, Status: d.Status})
+	}
+	for _, skip := range skips {
+		days = append(days, store.ScheduleDay{Date: day(skip.Date), SkipReason: skip.Reason})
+	}
+	sort.SliceStable(days, func(i, j int) bool { return days[i].Date.Before(days[j].Date) })
+
+	versions, err := s.store.ListScheduleVersions(ctx, first)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list versions: %w", err)
+	}
+	if len(versions) > 0 && len(Diff(versions[len(versions)-1].Days, days)) == 0 {
+		return nil, nil
+	}
+	v := &store.ScheduleVersion{Month: first, Days: days, CreatedAt: s.now()}
+	if err := s.store.CreateScheduleVersion(ctx, v); err != nil {
+		return nil, fmt.Errorf("failed to store version: %w", err)
+	}
+	return v, nil
+}
+
+// Sync takes a snapshot of the current and the next month, the ones the
+// schedule changes in. It runs as a cron job and after every event.
+func (s *Service) Sync(ctx context.Context) error {
+	now := s.now()
+	current := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	for _, month := range []time.Time{current, current.AddDate(0, 1, 0)} {
+		if _, err := s.Snapshot(ctx, month); err != nil {
+			return fmt.Errorf("%s: %w", month.Format("2006-01"), err)
+		}
+	}
+	return nil
+}
+
+// HandleEvent logs the change and takes snapshots after the schedule
+// changed: of the current and the next month, and of the month of the
+// changed day if it is another one, e.g. for a backfilled duty. It is
+// subscribed to the scheduler's event bus.
+func (s *Service) HandleEvent(ctx context.Context, e events.Event) {
+	var changed time.Time
+	switch e := e.(type) {
+	case events.DutyAssigned:
+		changed = e.Duty.DutyDate
+		s.logChange(ctx, e.Duty, store.DutyChangeAssigned)
+	case events.DutyReassigned:
+		changed = e.Duty.DutyDate
+		if e.PreviousUserID != e.Duty.UserID {
+			s.logChange(ctx, e.Duty, store.DutyChangeReassigned)
+		}
+	case events.DutyReleased:
+		changed = e.Duty.DutyDate
+		s.logChange(ctx, e.Duty, store.DutyChangeRemoved)
+	case events.DutyRemoved:
+		changed = e.Duty.DutyDate
+		// Planned days come and go with every replan and were never logged
+		if e.Duty.Status != store.DutyStatusProvisional {
+			s.logChange(ctx, e.Duty, store.DutyChangeRemoved)
+		}
+	case events.DutyBackfilled:
+		changed = e.Duty.DutyDate
+		s.logChange(ctx, e.Duty, store.DutyChangeBackfilled)
+	case events.MonthPublished:
+		changed = e.Month
+		for _, d := range e.Duties {
+			s.logChange(ctx, d, store.DutyChangeAssigned)
+		}
+	case events.UserWentOffDuty:
+	default:
+		return
+	}
+	if err := s.Sync(ctx); err != nil {
+		log.Printf("[HISTORY] Failed to take snapshots after %s: %v", e.Name(), err)
+	}
+	if !changed.IsZero() {
+		if _, err := s.Snapshot(ctx, changed); err != nil {
+			log.Printf("[HISTORY] Failed to take a snapshot of %s: %v", changed.Format("2006-01"), err)
+		}
+	}
+}
+
+// logChange adds what happened to a duty to the change log. A failure only
+// costs the log entry, the change itself is made.
+func (s *Service) logChange(ctx context.Context, duty *store.Duty, action store.DutyChangeAction) {
+	change := &store.DutyChange{DutyDate: duty.DutyDate, UserID: duty.UserID, Action: action, AssignmentType: duty.AssignmentType, ChangedAt: s.now()}
+	if err := s.store.CreateDutyChange(ctx, change); err != nil {
+		log.Printf("[HISTORY] Failed to log the %s duty on %s: %v", action, duty.DutyDate.Format("2006-01-02"), err)
+	}
+}
+
+// Versions returns the versions of the month starting on month, oldest first.
+func (s *Service) Versions(ctx context.Context, month time.Time) ([]*store.ScheduleVersion, error) {
+	return s.store.ListScheduleVersions(ctx, month)
+}
+
+// Version returns version n of the month starting on month.
+func (s *Service) Version(ctx context.Context, month time.Time, n int) (*store.ScheduleVersion, error) {
+	versions, err := s.store.ListScheduleVersions(ctx, month)
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range versions {
+		if v.Version == n {
+			return v, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s has no version %d", ErrNoVersion, month.Format("2006-01"), n)
+}
+
+// AsOf returns the version of the month starting on month that was current
+// at t: the last one taken at or before it.
+func (s *Service) AsOf(ctx context.Context, month time.Time, t time.Time) (*store.ScheduleVersion, error) {
+	versions, err := s.store.ListScheduleVersions(ctx, month)
+	if err != nil {
+		return nil, err
+	}
+	var found *store.ScheduleVersion
+	for _, v := range versions {
+		if !v.CreatedAt.After(t) {
+			found = v
+		}
+	}
+	if found == nil {
+		return nil, fmt.Errorf("%w: %s had no version yet at %s", ErrNoVersion, month.Format("2006-01"), t.Format("2006-01-02 15:04"))
+	}
+	return found, nil
+}
+
+// Diff returns the days that differ between before and after, by date.
+func Diff(before, after []store.ScheduleDay) []Change {
+	byDate := make(map[string]*Change)
+	for i := range before {
+		byDate[before[i].Date.Format("2006-01-02")] = &Change{Date: before[i].Date, Before: &before[i]}
+	}
+	for i := range after {
+		key := after[i].Date.Format("2006-01-02")
+		c, ok := byDate[key]
+		if !ok {
+			c = &Change{Date: after[i].Date}
+			byDate[key] = c
+		}
+		c.After = &after[i]
+	}
+
+	var changes []Change
+	for _, c := range byDate {
+		if c.Before == nil || c.After == nil || !same(*c.Before, *c.After) {
+			changes = append(changes, *c)
+		}
+	}
+	slices.SortFunc(changes, func(a, b Change) int { return a.Date.Compare(b.Date) })
+	return changes
+}
+
+// same reports whether a and b are the same day of the schedule. Of the
+// statuses only whether the duty is still a draft counts: duties being
+// acknowledged, completed or missed don't change the schedule.
+func same(a, b store.ScheduleDay) bool {
+	return a.UserID == b.UserID && a.AssignmentType == b.AssignmentType && a.SkipReason == b.SkipReason &&
+		(a.Status == store.DutyStatusProvisional) == (b.Status == store.DutyStatusProvisional)
+}
+
+// day returns the date of t at midnight UTC, as the store keeps dates.
+func day(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,151 @@
+package history
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/korjavin/dutyassistant/internal/events"
+	"github.com/korjavin/dutyassistant/internal/store"
+	"github.com/korjavin/dutyassistant/internal/store/memory"
+)
+
+func TestSnapshot(t *testing.T) {
+	ctx := context.Background()
+	s := memory.New()
+	alice := &store.User{TelegramUserID: 1, FirstName: "Alice", IsActive: true}
+	bob := &store.User{TelegramUserID: 2, FirstName: "Bob", IsActive: true}
+	for _, u := range []*store.User{alice, bob} {
+		if err := s.CreateUser(ctx, u); err != nil {
+			t.Fatal(err)
+		}
+	}
+	now := time.Date(2025, 11, 2, 10, 0, 0, 0, time.UTC)
+	h := New(s)
+	h.now = func() time.Time { return now }
+	month := time.Date(2025, 11, 1, 0, 0, 0, 0, time.UTC)
+	day := func(d int) time.Time { return time.Date(2025, 11, d, 0, 0, 0, 0, time.UTC) }
+
+	if err := s.CreateDuty(ctx, &store.Duty{UserID: alice.ID, DutyDate: day(5), AssignmentType: store.AssignmentTypeRoundRobin}); err != nil {
+		t.Fatal(err)
+	}
+	v, err := h.Snapshot(ctx, month)
+	if err != nil || v == nil || v.Version != 1 || len(v.Days) != 1 {
+		t.Fatalf("Snapshot = %+v, %v, want version 1 with a day", v, err)
+	}
+	if v, err := h.Snapshot(ctx, month); err != nil || v != nil {
+		t.Errorf("Snapshot of an unchanged month = %+v, %v, want none", v, err)
+	}
+	// Doing the duty doesn't change the schedule
+	if _, err := s.CompleteDuty(ctx, day(5)); err != nil {
+		t.Fatal(err)
+	}
+	if v, err := h.Snapshot(ctx, month); err != nil || v != nil {
+		t.Errorf("Snapshot after a completion = %+v, %v, want none", v, err)
+	}
+
+	// Handed to Bob and the 6th skipped a day later
+	now = now.AddDate(0, 0, 1)
+	if err := s.UpdateDuty(ctx, &store.Duty{ID: 1, UserID: bob.ID, DutyDate: day(5), AssignmentType: store.AssignmentTypeAdmin}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SetSkipDay(ctx, &store.SkipDay{Date: day(6), Reason: store.SkipReasonHoliday}); err != nil {
+		t.Fatal(err)
+	}
+	h.HandleEvent(ctx, events.DutyReassigned{Duty: &store.Duty{DutyDate: day(5)}, PreviousUserID: alice.ID})
+
+	versions, err := h.Versions(ctx, month)
+	if err != nil || len(versions) != 2 {
+		t.Fatalf("Versions = %d, %v, want 2", len(versions), err)
+	}
+	changes := Diff(versions[0].Days, versions[1].Days)
+	if len(changes) != 2 {
+		t.Fatalf("Diff = %+v, want the 5th and the 6th", changes)
+	}
+	if c := changes[0]; !c.Date.Equal(day(5)) || c.Before.UserID != alice.ID || c.After.UserID != bob.ID {
+		t.Errorf("Change = %+v, want the 5th from Alice to Bob", c)
+	}
+	if c := changes[1]; !c.Date.Equal(day(6)) || c.Before != nil || c.After.SkipReason != store.SkipReasonHoliday {
+		t.Errorf("Change = %+v, want the 6th skipped", c)
+	}
+
+	v, err = h.AsOf(ctx, month, time.Date(2025, 11, 2, 23, 59, 0, 0, time.UTC))
+	if err != nil || v.Version != 1 {
+		t.Errorf("AsOf the 2nd = %+v, %v, want version 1", v, err)
+	}
+	if _, err := h.AsOf(ctx, month, time.Date(2025, 11, 1, 0, 0, 0, 0, time.UTC)); !errors.Is(err, ErrNoVersion) {
+		t.Errorf("AsOf the 1st = %v, want ErrNoVersion", err)
+	}
+	if v, err := h.Version(ctx, month, 2); err != nil || !v.CreatedAt.Equal(now) {
+		t.Errorf("Version 2 = %+v, %v, want the one taken now", v, err)
+	}
+	if _, err := h.Version(ctx, month, 3); !errors.Is(err, ErrNoVersion) {
+		t.Errorf("Version 3 = %v, want ErrNoVersion", err)
+	}
+}
+
+func TestHandleEvent_LogsChanges(t *testing.T) {
+	ctx := context.Background()
+	s := memory.New()
+	alice := &store.User{TelegramUserID: 1, FirstName: "Alice", IsActive: true}
+	bob := &store.User{TelegramUserID: 2, FirstName: "Bob", IsActive: true}
+	for _, u := range []*store.User{alice, bob} {
+		if err := s.CreateUser(ctx, u); err != nil {
+			t.Fatal(err)
+		}
+	}
+	now := time.Date(2025, 11, 2, 10, 0, 0, 0, time.UTC)
+	h := New(s)
+	h.now = func() time.Time { return now }
+	day := func(d int) time.Time { return time.Date(2025, 11, d, 0, 0, 0, 0, time.UTC) }
+	duty := func(d int, u *store.User, status store.DutyStatus) *store.Duty {
+		return &store.Duty{DutyDate: day(d), UserID: u.ID, AssignmentType: store.AssignmentTypeAdmin, Status: status}
+	}
+
+	for _, e := range []events.Event{
+		events.DutyAssigned{Duty: duty(5, alice, store.DutyStatusAnnounced)},
+		// Handing a duty to whoever has it is no change
+		events.DutyReassigned{Duty: duty(5, alice, store.DutyStatusAnnounced), PreviousUserID: alice.ID},
+		events.DutyReassigned{Duty: duty(5, bob, store.DutyStatusAnnounced), PreviousUserID: alice.ID},
+		events.DutyRemoved{Duty: duty(5, bob, store.DutyStatusAnnounced), Skip: store.SkipReasonHoliday},
+		events.DutyRemoved{Duty: duty(6, bob, store.DutyStatusProvisional)},
+		events.DutyReleased{Duty: duty(7, alice, store.DutyStatusAnnounced)},
+		events.DutyBackfilled{Duty: duty(1, bob, store.DutyStatusCompleted)},
+		events.MonthPublished{Month: day(1), Duties: []*store.Duty{duty(10, alice, store.DutyStatusAnnounced), duty(11, bob, store.DutyStatusAnnounced)}},
+		events.DutyCompleted{Date: day(1), UserID: bob.ID},
+	} {
+		h.HandleEvent(ctx, e)
+		now = now.Add(time.Minute)
+	}
+
+	changes, err := s.GetRecentDutyChanges(ctx, 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []struct {
+		day    int
+		user   string
+		action store.DutyChangeAction
+	}{
+		{11, "Bob", store.DutyChangeAssigned},
+		{10, "Alice", store.DutyChangeAssigned},
+		{1, "Bob", store.DutyChangeBackfilled},
+		{7, "Alice", store.DutyChangeRemoved},
+		{5, "Bob", store.DutyChangeRemoved},
+		{5, "Bob", store.DutyChangeReassigned},
+		{5, "Alice", store.DutyChangeAssigned},
+	}
+	if len(changes) != len(want) {
+		t.Fatalf("GetRecentDutyChanges = %d changes, want %d: %+v", len(changes), len(want), changes)
+	}
+	for i, w := range want {
+		c := changes[i]
+		if !c.DutyDate.Equal(day(w.day)) || c.UserName != w.user || c.Action != w.action || c.AssignmentType != store.AssignmentTypeAdmin {
+			t.Errorf("Change %d = %s %s on %s, want %s %s on the %d.", i, c.UserName, c.Action, c.DutyDate.Format("2006-01-02"), w.user, w.action, w.day)
+		}
+	}
+	if first := changes[len(changes)-1]; !first.ChangedAt.Equal(time.Date(2025, 11, 2, 10, 0, 0, 0, time.UTC)) {
+		t.Errorf("ChangedAt = %v, want the time of the event", first.ChangedAt)
+	}
+}
@@ -0,0 +1,96 @@
+// Package invite lets admins invite people to the roster with one-time
+// links. The link starts the bot with a token; whoever starts it first joins
+// the roster, even if they aren't in the group chat. Only hashes of tokens are
+// stored.
+package invite
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/korjavin/dutyassistant/internal/store"
+)
+
+// DefaultTTL is how long an invitation link works unless the admin picks
+// another time.
+const DefaultTTL = 7 * 24 * time.Hour
+
+// ErrInvalidInvite is returned when an invite token is unknown, used or
+// expired.
+var ErrInvalidInvite = errors.New("invalid, used or expired invitation")
+
+// Service creates invites and redeems them.
+type Service struct {
+	store store.UserStore
+	now   func() time.Time
+}
+
+// New creates a new Service backed by the given store.
+func New(s store.UserStore) *Service {
+	return &Service{store: s, now: time.Now}
+}
+
+// Create creates an invite by the admin that works once until ttl passed, and
+// returns its token. With requireApproval, whoever joins with it stays out of
+// the rotation until an admin approves them.
+func (s *Service) Create(ctx context.Context, adminTelegramID int64, ttl time.Duration, requireApproval bool) (string, *store.Invite, error) {
+	token, err := randomString(18)
+	if err != nil {
+		return "", nil, err
+	}
+	now := s.now()
+	invite := &store.Invite{
+		TokenHash:        hash(token),
+		CreatedBy:        adminTelegramID,
+		RequiresApproval: requireApproval,
+		CreatedAt:        now,
+		ExpiresAt:        now.Add(ttl),
+	}
+	if err := s.store.CreateInvite(ctx, invite); err != nil {
+		return "", nil, fmt.Errorf("failed to create invite: %w", err)
+	}
+	return token, invite, nil
+}
+
+// Redeem uses up the invite with the token for the Telegram user and returns
+// it, or ErrInvalidInvite.
+func (s *Service) Redeem(ctx context.Context, token string, telegramUserID int64) (*store.Invite, error) {
+	invite, err := s.store.UseInvite(ctx, hash(token), telegramUserID, s.now())
+	if err != nil {
+		return nil, fmt.Errorf("failed to use invite: %w", err)
+	}
+	if invite == nil {
+		return nil, ErrInvalidInvite
+	}
+	return invite, nil
+}
+
+// Invited reports whether the Telegram user joined with an invite.
+func (s *Service) Invited(ctx context.Context, telegramUserID int64) (bool, error) {
+	invite, err := s.store.GetInviteUsedBy(ctx, telegramUserID)
+	if err != nil {
+		return false, fmt.Errorf("failed to get invite: %w", err)
+	}
+	return invite != nil, nil
+}
+
+// randomString returns n random bytes, encoded for use in deep links.
+func randomString(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate random bytes: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// hash is how tokens are stored.
+func hash(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,51 @@
+package invite
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/korjavin/dutyassistant/internal/store/memory"
+)
+
+func TestInvite(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2025, time.November, 3, 21, 0, 0, 0, time.UTC)
+	s := New(memory.New())
+	s.now = func() time.Time { return now }
+
+	token, invite, err := s.Create(ctx, 1, time.Hour, true)
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if len(token) > 64 {
+		t.Errorf("token %q is too long for a deep link", token)
+	}
+	if invite.TokenHash == token || !invite.RequiresApproval || !invite.ExpiresAt.Equal(now.Add(time.Hour)) {
+		t.Errorf("Create stored %+v", invite)
+	}
+
+	if invited, err := s.Invited(ctx, 42); err != nil || invited {
+		t.Errorf("Invited before joining = %v, %v, want false", invited, err)
+	}
+	if _, err := s.Redeem(ctx, "wrong", 42); !errors.Is(err, ErrInvalidInvite) {
+		t.Errorf("Redeem with a wrong token = %v, want ErrInvalidInvite", err)
+	}
+	if invite, err := s.Redeem(ctx, token, 42); err != nil || invite.CreatedBy != 1 {
+		t.Fatalf("Redeem = %+v, %v", invite, err)
+	}
+	if _, err := s.Redeem(ctx, token, 43); !errors.Is(err, ErrInvalidInvite) {
+		t.Errorf("Redeem twice = %v, want ErrInvalidInvite", err)
+	}
+	if invited, err := s.Invited(ctx, 42); err != nil || !invited {
+		t.Errorf("Invited after joining = %v, %v, want true", invited, err)
+	}
+
+	// Links expire
+	token, _, _ = s.Create(ctx, 1, time.Hour, false)
+	now = now.Add(time.Hour)
+	if _, err := s.Redeem(ctx, token, 43); !errors.Is(err, ErrInvalidInvite) {
+		t.Errorf("Redeem after expiry = %v, want ErrInvalidInvite", err)
+	}
+}
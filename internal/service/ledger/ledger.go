@@ -0,0 +1,292 @@
+// Package ledger keeps the balances of payout mode, in which a missed duty
+// costs money instead of a makeup day. Missed duties are charged the fine of
+// the settings, fines of duties that turn out to be done after all are
+// refunded, and admins record what users paid. A monthly statement sums it up.
+package ledger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/korjavin/dutyassistant/internal/service/settings"
+	"github.com/korjavin/dutyassistant/internal/store"
+)
+
+var (
+	// ErrOff is returned when payout mode is off.
+	ErrOff = errors.New("payout mode is off")
+	// ErrNothingOwed is returned when settling the balance of a user who
+	// doesn't owe anything.
+	ErrNothingOwed = errors.New("the user doesn't owe anything")
+	// ErrInvalidAmount is returned for amounts that aren't a positive sum of
+	// money, like "5" or "4.50".
+	ErrInvalidAmount = errors.New("invalid amount")
+)
+
+// Balance is what a user owes, in cents.
+type Balance struct {
+	User   *store.User
+	Amount int64
+}
+
+// Line is a user's part of a monthly statement.
+type Line struct {
+	User    *store.User
+	Missed  int   // Duties fined in the month, less those refunded
+	Charged int64 // Fines less refunds of the month's duties
+	Paid    int64 // Payments made in the month
+	Balance int64 // What the user owes now
+}
+
+// Statement is the settlement summary of a month: users whose balance
+// changed in the month or who still owe something.
+type Statement struct {
+	Month    time.Time // First day of the month
+	Currency string
+	Lines    []Line
+}
+
+// Service keeps the ledger.
+type Service struct {
+	store    store.Store
+	settings *settings.Service
+	now      func() time.Time
+}
+
+// New creates a new Service backed by the given store.
+func New(s store.Store) *Service {
+	return &Service{store: s, settings: settings.New(s), now: time.Now}
+}
+
+// dutyKey identifies the duty of a user on a day, which is fined at most once.
+type dutyKey struct {
+	date   string
+	userID int64
+}
+
+// Sync brings the fines in line with the duties: every duty missed since
+// payout mode was turned on is fined once, and the fine of a duty that is no
+// longer missed, e.g. completed or handed to someone else by an admin, is
+// refunded. It returns the new entries; with payout mode off it does nothing.
+func (s *Service) Sync(ctx context.Context) ([]*store.LedgerEntry, error) {
+	payout, err := s.settings.Payout(ctx)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := s.store.ListLedgerEntries(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list ledger entries: %w", err)
+	}
+	// What is charged for each duty, net of refunds
+	charged := make(map[dutyKey]int64)
+	dates := make(map[dutyKey]time.Time)
+	for _, e := range entries {
+		if e.DutyDate != nil {
+			key := dutyKey{e.DutyDate.Format("2006-01-02"), e.UserID}
+			charged[key] += e.Amount
+			dates[key] = *e.DutyDate
+		}
+	}
+
+	missed := make(map[dutyKey]bool)
+	if payout.On() {
+		duties, err := s.store.ListDuties(ctx, store.DutyFilter{From: payout.Since})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list duties: %w", err)
+		}
+		for _, d := range duties {
+			if d.Status == store.DutyStatusMissed {
+				key := dutyKey{d.DutyDate.Format("2006-01-02"), d.UserID}
+				missed[key] = true
+				dates[key] = d.DutyDate
+			}
+		}
+	}
+
+	var added []*store.LedgerEntry
+	add := func(key dutyKey, kind store.LedgerKind, amount int64) error {
+		date := dates[key]
+		e := &store.LedgerEntry{UserID: key.userID, Kind: kind, Amount: amount, DutyDate: &date, CreatedAt: s.now().UTC()}
+		if err := s.store.AddLedgerEntry(ctx, e); err != nil {
+			return fmt.Errorf("failed to add ledger entry: %w", err)
+		}
+		added = append(added, e)
+		return nil
+	}
+	for key := range missed {
+		if charged[key] <= 0 {
+			if err := add(key, store.LedgerFine, payout.Fine); err != nil {
+				return added, err
+			}
+		}
+	}
+	for key, amount := range charged {
+		if amount > 0 && !missed[key] {
+			if err := add(key, store.LedgerRefund, -amount); err != nil {
+				return added, err
+			}
+		}
+	}
+	// Map order is random, the ledger's isn't
+	sort.SliceStable(added, func(i, j int) bool { return added[i].DutyDate.Before(*added[j].DutyDate) })
+	return added, nil
+}
+
+// Balances returns what the users owe, most first. Users who are even are
+// left out.
+func (s *Service) Balances(ctx context.Context) ([]Balance, error) {
+	users, entries, err := s.load(ctx)
+	if err != nil {
+		return nil, err
+	}
+	amounts := make(map[int64]int64)
+	for _, e := range entries {
+		amounts[e.UserID] += e.Amount
+	}
+	var balances []Balance
+	for _, u := range users {
+		if amounts[u.ID] != 0 {
+			balances = append(balances, Balance{User: u, Amount: amounts[u.ID]})
+		}
+	}
+	sort.SliceStable(balances, func(i, j int) bool { return balances[i].Amount > balances[j].Amount })
+	return balances, nil
+}
+
+// Balance returns what the user owes, in cents.
+func (s *Service) Balance(ctx context.Context, userID int64) (int64, error) {
+	entries, err := s.store.ListLedgerEntries(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list ledger entries: %w", err)
+	}
+	var balance int64
+	for _, e := range entries {
+		if e.UserID == userID {
+			balance += e.Amount
+		}
+	}
+	return balance, nil
+}
+
+// RecordPayment records that the user paid amount cents, or their whole
+// balance if amount is 0. It returns the payment.
+func (s *Service) RecordPayment(ctx context.Context, userID, amount int64) (*store.LedgerEntry, error) {
+	if amount < 0 {
+		return nil, ErrInvalidAmount
+	}
+	if amount == 0 {
+		balance, err := s.Balance(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		if balance <= 0 {
+			return nil, ErrNothingOwed
+		}
+		amount = balance
+	}
+	e := &store.LedgerEntry{UserID: userID, Kind: store.LedgerPayment, Amount: -amount, CreatedAt: s.now().UTC()}
+	if err := s.store.AddLedgerEntry(ctx, e); err != nil {
+		return nil, fmt.Errorf("failed to record payment: %w", err)
+	}
+	return e, nil
+}
+
+// Statement returns the settlement summary of the month month is in. Fines
+// and refunds count for the month of their duty, payments for the month they
+// were recorded in. It returns ErrOff with payout mode off.
+func (s *Service) Statement(ctx context.Context, month time.Time) (*Statement, error) {
+	payout, err := s.settings.Payout(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !payout.On() {
+		return nil, ErrOff
+	}
+	users, entries, err := s.load(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	st := &Statement{Month: time.Date(month.Year(), month.Month(), 1, 0, 0, 0, 0, time.UTC), Currency: payout.Currency}
+	inMonth := func(t time.Time) bool { return t.Year() == st.Month.Year() && t.Month() == st.Month.Month() }
+	lines := make(map[int64]*Line)
+	for _, e := range entries {
+		line, ok := lines[e.UserID]
+		if !ok {
+			line = &Line{}
+			lines[e.UserID] = line
+		}
+		line.Balance += e.Amount
+		switch {
+		case e.Kind == store.LedgerPayment && inMonth(e.CreatedAt):
+			line.Paid -= e.Amount
+		case e.DutyDate != nil && inMonth(*e.DutyDate):
+			line.Charged += e.Amount
+			if e.Kind == store.LedgerFine {
+				line.Missed++
+			} else {
+				line.Missed--
+			}
+		}
+	}
+	for _, u := range users {
+		line, ok := lines[u.ID]
+		if !ok || (line.Charged == 0 && line.Paid == 0 && line.Balance == 0) {
+			continue
+		}
+		line.User = u
+		st.Lines = append(st.Lines, *line)
+	}
+	sort.SliceStable(st.Lines, func(i, j int) bool { return st.Lines[i].Balance > st.Lines[j].Balance })
+	return st, nil
+}
+
+// load returns all users and the ledger.
+func (s *Service) load(ctx context.Context) ([]*store.User, []*store.LedgerEntry, error) {
+	users, err := s.store.ListAllUsers(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list users: %w", err)
+	}
+	entries, err := s.store.ListLedgerEntries(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list ledger entries: %w", err)
+	}
+	return users, entries, nil
+}
+
+// ParseAmount parses a sum of money like "5", "4.50" or "4,50" into cents.
+func ParseAmount(s string) (int64, error) {
+	whole, fraction, _ := strings.Cut(strings.Replace(strings.TrimSpace(s), ",", ".", 1), ".")
+	units, err := strconv.ParseInt(whole, 10, 64)
+	if err != nil || len(whole) > 9 || len(fraction) > 2 || strings.HasPrefix(whole, "-") {
+		return 0, fmt.Errorf("%w %q, expected a sum like 5 or 4.50", ErrInvalidAmount, s)
+	}
+	var cents int64
+	if fraction != "" {
+		if cents, err = strconv.ParseInt(fraction, 10, 64); err != nil || strings.HasPrefix(fraction, "-") || strings.HasPrefix(fraction, "+") {
+			return 0, fmt.Errorf("%w %q, expected a sum like 5 or 4.50", ErrInvalidAmount, s)
+		}
+		if len(fraction) == 1 {
+			cents *= 10
+		}
+	}
+	amount := units*100 + cents
+	if amount <= 0 {
+		return 0, fmt.Errorf("%w %q, expected a sum like 5 or 4.50", ErrInvalidAmount, s)
+	}
+	return amount, nil
+}
+
+// FormatAmount formats cents of currency, e.g. "4.50 EUR" or "-2.00 EUR".
+func FormatAmount(cents int64, currency string) string {
+	sign := ""
+	if cents < 0 {
+		sign, cents = "-", -cents
+	}
+	return fmt.Sprintf("%s%d.%02d %s", sign, cents/100, cents%100, currency)
+}
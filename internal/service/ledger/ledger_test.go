@@ -0,0 +1,127 @@
+package ledger
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/korjavin/dutyassistant/internal/service/settings"
+	"github.com/korjavin/dutyassistant/internal/store"
+	"github.com/korjavin/dutyassistant/internal/store/memory"
+)
+
+func date(y int, m time.Month, d int) time.Time {
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+func TestService(t *testing.T) {
+	ctx := context.Background()
+	s := memory.New()
+	alice := &store.User{TelegramUserID: 1, FirstName: "Alice", IsActive: true}
+	bob := &store.User{TelegramUserID: 2, FirstName: "Bob", IsActive: true}
+	for _, u := range []*store.User{alice, bob} {
+		if err := s.CreateUser(ctx, u); err != nil {
+			t.Fatal(err)
+		}
+	}
+	duties := map[time.Time]*store.Duty{
+		date(2025, 10, 31): {UserID: alice.ID, Status: store.DutyStatusMissed}, // Before payout mode
+		date(2025, 11, 3):  {UserID: alice.ID, Status: store.DutyStatusMissed},
+		date(2025, 11, 4):  {UserID: bob.ID, Status: store.DutyStatusCompleted},
+		date(2025, 11, 5):  {UserID: bob.ID, Status: store.DutyStatusMissed},
+	}
+	for day, d := range duties {
+		d.DutyDate = day
+		d.AssignmentType = store.AssignmentTypeRoundRobin
+		if err := s.CreateDuty(ctx, d); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ledger := New(s)
+	ledger.now = func() time.Time { return time.Date(2025, 11, 6, 21, 0, 0, 0, time.UTC) }
+
+	// Nothing is charged while payout mode is off
+	if added, err := ledger.Sync(ctx); err != nil || len(added) != 0 {
+		t.Fatalf("Sync with payout mode off = %+v, %v, want nothing", added, err)
+	}
+	if _, err := ledger.Statement(ctx, date(2025, 11, 1)); !errors.Is(err, ErrOff) {
+		t.Errorf("Statement with payout mode off: got %v, want ErrOff", err)
+	}
+
+	if err := settings.New(s).SetPayout(ctx, 500, "", date(2025, 11, 1)); err != nil {
+		t.Fatal(err)
+	}
+	added, err := ledger.Sync(ctx)
+	if err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	if len(added) != 2 || added[0].UserID != alice.ID || added[1].UserID != bob.ID || added[1].Amount != 500 || added[1].Kind != store.LedgerFine {
+		t.Errorf("Sync: expected fines for Alice and Bob, got %+v", added)
+	}
+	// Fines are only charged once
+	if added, err := ledger.Sync(ctx); err != nil || len(added) != 0 {
+		t.Errorf("Second Sync = %+v, %v, want nothing", added, err)
+	}
+
+	// Bob's duty turns out to be done after all
+	bobsDuty, _ := s.GetDutyByDate(ctx, date(2025, 11, 5))
+	bobsDuty.Status = store.DutyStatusCompleted
+	if err := s.UpdateDuty(ctx, bobsDuty); err != nil {
+		t.Fatal(err)
+	}
+	added, err = ledger.Sync(ctx)
+	if err != nil || len(added) != 1 || added[0].Kind != store.LedgerRefund || added[0].Amount != -500 {
+		t.Errorf("Sync after completing the duty = %+v, %v, want a refund", added, err)
+	}
+
+	balances, err := ledger.Balances(ctx)
+	if err != nil || len(balances) != 1 || balances[0].User.ID != alice.ID || balances[0].Amount != 500 {
+		t.Errorf("Balances = %+v, %v, want Alice owing 500", balances, err)
+	}
+	if _, err := ledger.RecordPayment(ctx, bob.ID, 0); !errors.Is(err, ErrNothingOwed) {
+		t.Errorf("RecordPayment for Bob: got %v, want ErrNothingOwed", err)
+	}
+	if _, err := ledger.RecordPayment(ctx, alice.ID, 200); err != nil {
+		t.Fatal(err)
+	}
+
+	st, err := ledger.Statement(ctx, date(2025, 11, 20))
+	if err != nil {
+		t.Fatalf("Statement failed: %v", err)
+	}
+	if st.Currency != settings.DefaultCurrency || len(st.Lines) != 1 {
+		t.Fatalf("Statement = %+v, want a line for Alice only", st)
+	}
+	if want := (Line{User: st.Lines[0].User, Missed: 1, Charged: 500, Paid: 200, Balance: 300}); st.Lines[0] != want || want.User.ID != alice.ID {
+		t.Errorf("Statement line = %+v, want %+v", st.Lines[0], want)
+	}
+	if _, err := ledger.RecordPayment(ctx, alice.ID, 0); err != nil {
+		t.Fatal(err)
+	}
+	if balance, _ := ledger.Balance(ctx, alice.ID); balance != 0 {
+		t.Errorf("Balance after settling = %d, want 0", balance)
+	}
+}
+
+func TestParseAmount(t *testing.T) {
+	for s, want := range map[string]int64{"5": 500, "4.50": 450, "4,5": 450, "0.05": 5, " 12 ": 1200} {
+		if got, err := ParseAmount(s); err != nil || got != want {
+			t.Errorf("ParseAmount(%q) = %d, %v, want %d", s, got, err, want)
+		}
+	}
+	for _, s := range []string{"", "0", "-5", "4.555", "five", "1.-5", "1e3"} {
+		if _, err := ParseAmount(s); !errors.Is(err, ErrInvalidAmount) {
+			t.Errorf("ParseAmount(%q) should fail, got %v", s, err)
+		}
+	}
+}
+
+func TestFormatAmount(t *testing.T) {
+	if got := FormatAmount(450, "EUR"); got != "4.50 EUR" {
+		t.Errorf("FormatAmount(450) = %q", got)
+	}
+	if got := FormatAmount(-5, "CHF"); got != "-0.05 CHF" {
+		t.Errorf("FormatAmount(-5) = %q", got)
+	}
+}
@@ -0,0 +1,111 @@
+package ledger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/korjavin/dutyassistant/internal/store"
+)
+
+// StarsCurrency is the currency of invoices in Telegram Stars.
+const StarsCurrency = "XTR"
+
+// invoicePrefix starts the payload of fine invoices.
+const invoicePrefix = "fine:"
+
+var (
+	// ErrInvoiceOutdated is returned when an invoice is for more than the
+	// user owes now, e.g. because an admin recorded a cash payment since.
+	ErrInvoiceOutdated = errors.New("the invoice is for more than is owed")
+	// ErrInvalidInvoice is returned for a payload that isn't one of an
+	// invoice of a fine.
+	ErrInvalidInvoice = errors.New("not an invoice of a fine")
+)
+
+// Payments is how fines are paid in Telegram with an invoice: through the
+// payment provider of ProviderToken in the currency of the fine, or else in
+// Telegram Stars, StarsPerUnit of them for a unit of the currency like 1 EUR.
+// The zero value sends no invoices.
+type Payments struct {
+	ProviderToken string
+	StarsPerUnit  int64
+}
+
+// On reports whether fines can be paid with invoices.
+func (p Payments) On() bool {
+	return p.ProviderToken != "" || p.StarsPerUnit > 0
+}
+
+// Price returns the currency and amount of an invoice for cents of currency:
+// the cents through a provider, or the Stars they are worth, rounded up.
+func (p Payments) Price(cents int64, currency string) (string, int64) {
+	if p.ProviderToken != "" {
+		return currency, cents
+	}
+	return StarsCurrency, (cents*p.StarsPerUnit + 99) / 100
+}
+
+// Invoice is what an invoice pays off: Amount cents of the balance of the
+// user with the internal ID UserID. Anyone may pay it, e.g. a parent for a
+// child.
+type Invoice struct {
+	UserID int64
+	Amount int64
+}
+
+// Payload is the invoice payload Telegram hands back with the payment.
+func (i Invoice) Payload() string {
+	return fmt.Sprintf("%s%d:%d", invoicePrefix, i.UserID, i.Amount)
+}
+
+// ParseInvoice parses the payload of an invoice, see Invoice.Payload.
+func ParseInvoice(payload string) (Invoice, error) {
+	user, amount, ok := strings.Cut(strings.TrimPrefix(payload, invoicePrefix), ":")
+	if !ok || !strings.HasPrefix(payload, invoicePrefix) {
+		return Invoice{}, ErrInvalidInvoice
+	}
+	var i Invoice
+	var err error
+	if i.UserID, err = strconv.ParseInt(user, 10, 64); err != nil {
+		return Invoice{}, ErrInvalidInvoice
+	}
+	if i.Amount, err = strconv.ParseInt(amount, 10, 64); err != nil || i.Amount <= 0 {
+		return Invoice{}, ErrInvalidInvoice
+	}
+	return i, nil
+}
+
+// CheckInvoice returns ErrInvoiceOutdated unless the user of inv still owes
+// at least its amount. Telegram asks before it takes the money.
+func (s *Service) CheckInvoice(ctx context.Context, inv Invoice) error {
+	balance, err := s.Balance(ctx, inv.UserID)
+	if err != nil {
+		return err
+	}
+	if balance < inv.Amount {
+		return ErrInvoiceOutdated
+	}
+	return nil
+}
+
+// RecordInvoicePayment records the payment of inv that Telegram charged as
+// chargeID. Each charge is recorded once; it reports whether this one is new.
+func (s *Service) RecordInvoicePayment(ctx context.Context, inv Invoice, chargeID string) (*store.LedgerEntry, bool, error) {
+	entries, err := s.store.ListLedgerEntries(ctx)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to list ledger entries: %w", err)
+	}
+	for _, e := range entries {
+		if e.ChargeID == chargeID {
+			return e, false, nil
+		}
+	}
+	e := &store.LedgerEntry{UserID: inv.UserID, Kind: store.LedgerPayment, Amount: -inv.Amount, ChargeID: chargeID, CreatedAt: s.now().UTC()}
+	if err := s.store.AddLedgerEntry(ctx, e); err != nil {
+		return nil, false, fmt.Errorf("failed to record payment: %w", err)
+	}
+	return e, true, nil
+}
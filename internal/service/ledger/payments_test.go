@@ -0,0 +1,76 @@
+package ledger
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/korjavin/dutyassistant/internal/store"
+	"github.com/korjavin/dutyassistant/internal/store/memory"
+)
+
+func TestPayments_Price(t *testing.T) {
+	if (Payments{}).On() {
+		t.Error("Expected the zero Payments to be off")
+	}
+	if currency, amount := (Payments{ProviderToken: "token", StarsPerUnit: 50}).Price(450, "EUR"); currency != "EUR" || amount != 450 {
+		t.Errorf("Price through a provider = %d %s, want 450 EUR", amount, currency)
+	}
+	if currency, amount := (Payments{StarsPerUnit: 50}).Price(450, "EUR"); currency != StarsCurrency || amount != 225 {
+		t.Errorf("Price in Stars = %d %s, want 225 XTR", amount, currency)
+	}
+	// Stars are whole, so fractions round up
+	if _, amount := (Payments{StarsPerUnit: 3}).Price(150, "EUR"); amount != 5 {
+		t.Errorf("Price of 1.50 at 3 Stars = %d, want 5", amount)
+	}
+}
+
+func TestParseInvoice(t *testing.T) {
+	inv := Invoice{UserID: 7, Amount: 450}
+	if got, err := ParseInvoice(inv.Payload()); err != nil || got != inv {
+		t.Errorf("ParseInvoice(%q) = %+v, %v, want %+v", inv.Payload(), got, err, inv)
+	}
+	for _, payload := range []string{"", "fine:7", "fine:x:450", "fine:7:0", "tip:7:450"} {
+		if _, err := ParseInvoice(payload); !errors.Is(err, ErrInvalidInvoice) {
+			t.Errorf("ParseInvoice(%q): got %v, want ErrInvalidInvoice", payload, err)
+		}
+	}
+}
+
+func TestRecordInvoicePayment(t *testing.T) {
+	ctx := context.Background()
+	s := memory.New()
+	alice := &store.User{TelegramUserID: 1, FirstName: "Alice", IsActive: true}
+	if err := s.CreateUser(ctx, alice); err != nil {
+		t.Fatal(err)
+	}
+	missed := date(2025, 11, 3)
+	if err := s.AddLedgerEntry(ctx, &store.LedgerEntry{UserID: alice.ID, Kind: store.LedgerFine, Amount: 500, DutyDate: &missed, CreatedAt: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+	ledger := New(s)
+
+	inv := Invoice{UserID: alice.ID, Amount: 500}
+	if err := ledger.CheckInvoice(ctx, inv); err != nil {
+		t.Errorf("CheckInvoice of the balance failed: %v", err)
+	}
+	if err := ledger.CheckInvoice(ctx, Invoice{UserID: alice.ID, Amount: 600}); !errors.Is(err, ErrInvoiceOutdated) {
+		t.Errorf("CheckInvoice of more than the balance: got %v, want ErrInvoiceOutdated", err)
+	}
+
+	e, added, err := ledger.RecordInvoicePayment(ctx, inv, "charge-1")
+	if err != nil || !added || e.Kind != store.LedgerPayment || e.Amount != -500 || e.ChargeID != "charge-1" {
+		t.Fatalf("RecordInvoicePayment = %+v, %v, %v, want a payment of 500", e, added, err)
+	}
+	// Telegram may deliver the same payment twice
+	if _, added, err := ledger.RecordInvoicePayment(ctx, inv, "charge-1"); err != nil || added {
+		t.Errorf("RecordInvoicePayment of the same charge = %v, %v, want it left out", added, err)
+	}
+	if balance, _ := ledger.Balance(ctx, alice.ID); balance != 0 {
+		t.Errorf("Balance after paying = %d, want 0", balance)
+	}
+	if err := ledger.CheckInvoice(ctx, inv); !errors.Is(err, ErrInvoiceOutdated) {
+		t.Errorf("CheckInvoice after paying: got %v, want ErrInvoiceOutdated", err)
+	}
+}
@@ -0,0 +1,134 @@
+// Package login signs in browsers outside Telegram. The bot sends a user a
+// one-time code in a private chat, and redeeming the code starts a web
+// session the browser keeps in a cookie. Signing in with the Telegram Login
+// Widget starts the same kind of session. Only hashes of codes and session
+// tokens are stored, so a leaked database can't be used to sign in.
+package login
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/korjavin/dutyassistant/internal/store"
+)
+
+const (
+	// CodeTTL is how long a login code can be redeemed.
+	CodeTTL = 10 * time.Minute
+	// SessionTTL is how long a web session lasts.
+	SessionTTL = 30 * 24 * time.Hour
+)
+
+var (
+	// ErrInvalidCode is returned when a login code is unknown, used or expired.
+	ErrInvalidCode = errors.New("invalid or expired login code")
+	// ErrNoSession is returned when a session token is unknown or expired.
+	ErrNoSession = errors.New("no such session")
+)
+
+// Service issues login codes and manages the web sessions they start.
+type Service struct {
+	store store.UserStore
+	now   func() time.Time
+}
+
+// New creates a new Service backed by the given store.
+func New(s store.UserStore) *Service {
+	return &Service{store: s, now: time.Now}
+}
+
+// IssueCode creates a login code for the user and returns it.
+func (s *Service) IssueCode(ctx context.Context, userID int64) (string, error) {
+	now := s.now()
+	if err := s.store.DeleteExpiredLogins(ctx, now); err != nil {
+		return "", fmt.Errorf("failed to delete expired logins: %w", err)
+	}
+	code, err := randomString(18)
+	if err != nil {
+		return "", err
+	}
+	if err := s.store.CreateLoginCode(ctx, &store.LoginCode{
+		CodeHash:  hash(code),
+		UserID:    userID,
+		ExpiresAt: now.Add(CodeTTL),
+	}); err != nil {
+		return "", fmt.Errorf("failed to create login code: %w", err)
+	}
+	return code, nil
+}
+
+// Redeem uses up a login code and starts a session for its user. It returns
+// the session token for the browser to keep, and when the session expires.
+func (s *Service) Redeem(ctx context.Context, code string) (string, time.Time, error) {
+	lc, err := s.store.ConsumeLoginCode(ctx, hash(code))
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to consume login code: %w", err)
+	}
+	if lc == nil || !s.now().Before(lc.ExpiresAt) {
+		return "", time.Time{}, ErrInvalidCode
+	}
+	return s.Start(ctx, lc.UserID)
+}
+
+// Start starts a session for the user, e.g. one who signed in with the
+// Telegram Login Widget. It returns the session token for the browser to
+// keep, and when the session expires.
+func (s *Service) Start(ctx context.Context, userID int64) (string, time.Time, error) {
+	now := s.now()
+	token, err := randomString(32)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	session := &store.WebSession{
+		TokenHash: hash(token),
+		UserID:    userID,
+		CreatedAt: now,
+		ExpiresAt: now.Add(SessionTTL),
+	}
+	if err := s.store.CreateWebSession(ctx, session); err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to create session: %w", err)
+	}
+	return token, session.ExpiresAt, nil
+}
+
+// User returns the ID of the user signed in with the session token, or
+// ErrNoSession.
+func (s *Service) User(ctx context.Context, token string) (int64, error) {
+	session, err := s.store.GetWebSession(ctx, hash(token))
+	if err != nil {
+		return 0, fmt.Errorf("failed to get session: %w", err)
+	}
+	if session == nil || !s.now().Before(session.ExpiresAt) {
+		return 0, ErrNoSession
+	}
+	return session.UserID, nil
+}
+
+// Logout ends the session with the token.
+func (s *Service) Logout(ctx context.Context, token string) error {
+	if err := s.store.DeleteWebSession(ctx, hash(token)); err != nil {
+		return fmt.Errorf("failed to delete session: %w", err)
+	}
+	return nil
+}
+
+// randomString returns n random bytes, encoded for use in URLs and cookies.
+func randomString(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate random bytes: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// hash is how codes and tokens are stored.
+func hash(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,78 @@
+package login
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/korjavin/dutyassistant/internal/store"
+	"github.com/korjavin/dutyassistant/internal/store/memory"
+)
+
+func TestService(t *testing.T) {
+	ctx := context.Background()
+	s := memory.New()
+	alice := &store.User{TelegramUserID: 1, FirstName: "Alice", IsActive: true}
+	s.CreateUser(ctx, alice)
+	now := time.Date(2025, 11, 3, 8, 0, 0, 0, time.UTC)
+	svc := New(s)
+	svc.now = func() time.Time { return now }
+
+	code, err := svc.IssueCode(ctx, alice.ID)
+	if err != nil {
+		t.Fatalf("IssueCode failed: %v", err)
+	}
+	if _, _, err := svc.Redeem(ctx, "wrong"); !errors.Is(err, ErrInvalidCode) {
+		t.Errorf("Expected ErrInvalidCode for an unknown code, got %v", err)
+	}
+	token, expires, err := svc.Redeem(ctx, code)
+	if err != nil {
+		t.Fatalf("Redeem failed: %v", err)
+	}
+	if !expires.Equal(now.Add(SessionTTL)) {
+		t.Errorf("Expected the session to expire at %v, got %v", now.Add(SessionTTL), expires)
+	}
+	if _, _, err := svc.Redeem(ctx, code); !errors.Is(err, ErrInvalidCode) {
+		t.Errorf("Expected a code to work only once, got %v", err)
+	}
+	if id, err := svc.User(ctx, token); err != nil || id != alice.ID {
+		t.Errorf("Expected the session to be Alice's, got %d, %v", id, err)
+	}
+	if _, err := svc.User(ctx, "wrong"); !errors.Is(err, ErrNoSession) {
+		t.Errorf("Expected ErrNoSession for an unknown token, got %v", err)
+	}
+
+	if err := svc.Logout(ctx, token); err != nil {
+		t.Fatalf("Logout failed: %v", err)
+	}
+	if _, err := svc.User(ctx, token); !errors.Is(err, ErrNoSession) {
+		t.Errorf("Expected ErrNoSession after logging out, got %v", err)
+	}
+}
+
+func TestService_Expiry(t *testing.T) {
+	ctx := context.Background()
+	s := memory.New()
+	alice := &store.User{TelegramUserID: 1, FirstName: "Alice", IsActive: true}
+	s.CreateUser(ctx, alice)
+	now := time.Date(2025, 11, 3, 8, 0, 0, 0, time.UTC)
+	svc := New(s)
+	svc.now = func() time.Time { return now }
+
+	stale, _ := svc.IssueCode(ctx, alice.ID)
+	now = now.Add(CodeTTL)
+	if _, _, err := svc.Redeem(ctx, stale); !errors.Is(err, ErrInvalidCode) {
+		t.Errorf("Expected an expired code to be refused, got %v", err)
+	}
+
+	code, _ := svc.IssueCode(ctx, alice.ID)
+	token, _, err := svc.Redeem(ctx, code)
+	if err != nil {
+		t.Fatalf("Redeem failed: %v", err)
+	}
+	now = now.Add(SessionTTL)
+	if _, err := svc.User(ctx, token); !errors.Is(err, ErrNoSession) {
+		t.Errorf("Expected an expired session to be refused, got %v", err)
+	}
+}
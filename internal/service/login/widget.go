@@ -0,0 +1,56 @@
+package login
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// WidgetMaxAge is how old the data of a Telegram Login Widget sign-in may be.
+const WidgetMaxAge = 24 * time.Hour
+
+// ErrInvalidWidget is returned when Telegram Login Widget data isn't signed
+// with the bot's token or is too old.
+var ErrInvalidWidget = errors.New("invalid or expired login widget data")
+
+// VerifyWidget checks the data the Telegram Login Widget passed to the web app
+// (id, first_name, auth_date, hash and so on) as described on
+// https://core.telegram.org/widgets/login#checking-authorization, and returns
+// the Telegram ID of the user who signed in.
+func VerifyWidget(data url.Values, botToken string, now time.Time) (int64, error) {
+	hash := data.Get("hash")
+	if hash == "" || botToken == "" {
+		return 0, ErrInvalidWidget
+	}
+	var fields []string
+	for key := range data {
+		if key != "hash" {
+			fields = append(fields, key+"="+data.Get(key))
+		}
+	}
+	sort.Strings(fields)
+
+	secret := sha256.Sum256([]byte(botToken))
+	mac := hmac.New(sha256.New, secret[:])
+	mac.Write([]byte(strings.Join(fields, "\n")))
+	want := hex.EncodeToString(mac.Sum(nil))
+	if !hmac.Equal([]byte(want), []byte(strings.ToLower(hash))) {
+		return 0, ErrInvalidWidget
+	}
+
+	authDate, err := strconv.ParseInt(data.Get("auth_date"), 10, 64)
+	if err != nil || now.Sub(time.Unix(authDate, 0)) > WidgetMaxAge {
+		return 0, ErrInvalidWidget
+	}
+	id, err := strconv.ParseInt(data.Get("id"), 10, 64)
+	if err != nil || id == 0 {
+		return 0, ErrInvalidWidget
+	}
+	return id, nil
+}
@@ -0,0 +1,67 @@
+package login
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// signWidget signs data the way Telegram signs Login Widget data.
+func signWidget(data url.Values, botToken string) url.Values {
+	var fields []string
+	for key := range data {
+		fields = append(fields, key+"="+data.Get(key))
+	}
+	sort.Strings(fields)
+	secret := sha256.Sum256([]byte(botToken))
+	mac := hmac.New(sha256.New, secret[:])
+	mac.Write([]byte(strings.Join(fields, "\n")))
+	data.Set("hash", hex.EncodeToString(mac.Sum(nil)))
+	return data
+}
+
+func TestVerifyWidget(t *testing.T) {
+	now := time.Date(2025, 11, 3, 8, 0, 0, 0, time.UTC)
+	widget := func(authDate time.Time) url.Values {
+		return url.Values{
+			"id":         {"456"},
+			"first_name": {"Alice"},
+			"username":   {"alice"},
+			"auth_date":  {strconv.FormatInt(authDate.Unix(), 10)},
+		}
+	}
+
+	id, err := VerifyWidget(signWidget(widget(now.Add(-time.Minute)), "token"), "token", now)
+	if err != nil || id != 456 {
+		t.Errorf("Expected Telegram ID 456, got %d, %v", id, err)
+	}
+
+	tests := []struct {
+		name string
+		data url.Values
+	}{
+		{"other bot", signWidget(widget(now), "other")},
+		{"too old", signWidget(widget(now.Add(-WidgetMaxAge-time.Minute)), "token")},
+		{"no hash", widget(now)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := VerifyWidget(tt.data, "token", now); !errors.Is(err, ErrInvalidWidget) {
+				t.Errorf("Expected ErrInvalidWidget, got %v", err)
+			}
+		})
+	}
+
+	tampered := signWidget(widget(now), "token")
+	tampered.Set("id", "789")
+	if _, err := VerifyWidget(tampered, "token", now); !errors.Is(err, ErrInvalidWidget) {
+		t.Errorf("Expected a changed ID to be refused, got %v", err)
+	}
+}
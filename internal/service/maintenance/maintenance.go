@@ -0,0 +1,64 @@
+// Package maintenance keeps the database small and fast. Once a week it
+// deletes the log rows older than the retention, compacts the database and
+// refreshes the query planner's statistics, and records how big the database
+// was before and after so admins can follow its size over the weeks.
+package maintenance
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/korjavin/dutyassistant/internal/store"
+)
+
+const (
+	// DefaultRetention is how long log rows are kept.
+	DefaultRetention = 365 * 24 * time.Hour
+	// trendRuns is how many runs Run returns for the size trend.
+	trendRuns = 5
+)
+
+// Service runs the database maintenance.
+type Service struct {
+	store store.MaintenanceStore
+	now   func() time.Time
+
+	Retention time.Duration // How long log rows are kept, DefaultRetention by default
+}
+
+// New creates a new Service backed by the given store.
+func New(s store.MaintenanceStore) *Service {
+	return &Service{store: s, now: time.Now, Retention: DefaultRetention}
+}
+
+// Run deletes the log rows older than the retention, optimizes the database
+// and records the run. It returns the latest runs, newest and so this one
+// first, for the size trend.
+func (s *Service) Run(ctx context.Context) ([]*store.MaintenanceRun, error) {
+	now := s.now()
+	run := &store.MaintenanceRun{RanAt: now}
+
+	var err error
+	if run.SizeBefore, err = s.store.DatabaseSize(ctx); err != nil {
+		return nil, fmt.Errorf("failed to get database size: %w", err)
+	}
+	if run.Deleted, err = s.store.DeleteLogsBefore(ctx, now.Add(-s.Retention)); err != nil {
+		return nil, fmt.Errorf("failed to delete old logs: %w", err)
+	}
+	if err := s.store.Optimize(ctx); err != nil {
+		return nil, fmt.Errorf("failed to optimize database: %w", err)
+	}
+	if run.SizeAfter, err = s.store.DatabaseSize(ctx); err != nil {
+		return nil, fmt.Errorf("failed to get database size: %w", err)
+	}
+	if err := s.store.CreateMaintenanceRun(ctx, run); err != nil {
+		return nil, fmt.Errorf("failed to record maintenance run: %w", err)
+	}
+
+	runs, err := s.store.ListMaintenanceRuns(ctx, trendRuns)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list maintenance runs: %w", err)
+	}
+	return runs, nil
+}
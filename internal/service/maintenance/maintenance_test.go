@@ -0,0 +1,53 @@
+package maintenance
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/korjavin/dutyassistant/internal/store"
+	"github.com/korjavin/dutyassistant/internal/store/memory"
+)
+
+func TestRun(t *testing.T) {
+	ctx := context.Background()
+	st := memory.New()
+	s := New(st)
+	now := time.Date(2025, 10, 26, 4, 0, 0, 0, time.UTC)
+	s.now = func() time.Time { return now }
+
+	// A comparison from over a year ago and one from last week
+	for _, day := range []time.Time{now.AddDate(-1, 0, -7), now.AddDate(0, 0, -7)} {
+		c := &store.ShadowComparison{Date: day, Strategy: "window30", LiveUserID: 1, ShadowUserID: 2, CreatedAt: day}
+		if err := st.CreateShadowComparison(ctx, c); err != nil {
+			t.Fatalf("CreateShadowComparison failed: %v", err)
+		}
+	}
+	if err := st.CreateMaintenanceRun(ctx, &store.MaintenanceRun{RanAt: now.AddDate(0, 0, -7), Deleted: 2}); err != nil {
+		t.Fatalf("CreateMaintenanceRun failed: %v", err)
+	}
+
+	runs, err := s.Run(ctx)
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if len(runs) != 2 || !runs[0].RanAt.Equal(now) || runs[1].Deleted != 2 {
+		t.Fatalf("Run: expected this run and last week's, newest first, got %+v", runs)
+	}
+	if runs[0].Deleted != 1 {
+		t.Errorf("Run: expected the old comparison to be deleted, got %d rows", runs[0].Deleted)
+	}
+	if got, _ := st.ListShadowComparisons(ctx, now.AddDate(-2, 0, 0)); len(got) != 1 {
+		t.Errorf("Run: expected last week's comparison to be kept, got %+v", got)
+	}
+
+	// A shorter retention takes last week's rows too
+	s.Retention = 24 * time.Hour
+	s.now = func() time.Time { return now.Add(time.Hour) }
+	if runs, err = s.Run(ctx); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if runs[0].Deleted != 2 || len(runs) != 2 {
+		t.Errorf("Run: expected last week's comparison and run to be deleted, got %+v", runs)
+	}
+}
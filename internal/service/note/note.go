@@ -0,0 +1,296 @@
+// Package note attaches context to duties: a note the admin wrote for one
+// day, templates whose date rule says which days they apply to, such as
+// "bins are brown this week" every other Tuesday, the bins of the imported
+// waste-collection calendar and the handoff note whoever was on duty before
+// left for the next one. Reminders carry all of them.
+package note
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/korjavin/dutyassistant/internal/store"
+)
+
+var (
+	// ErrNotFound is returned when a note template doesn't exist.
+	ErrNotFound = errors.New("note template not found")
+	// ErrNoDuty is returned when setting the note of a day nobody is on duty.
+	ErrNoDuty = errors.New("no duty assigned for this date")
+	// ErrNotOnDuty is returned when someone else than the assignee leaves a
+	// handoff note.
+	ErrNotOnDuty = errors.New("not on duty on this date")
+	// ErrHandoffTooLong is returned for a handoff note over MaxHandoffLength.
+	ErrHandoffTooLong = errors.New("handoff note too long")
+)
+
+// MaxHandoffLength is how many characters a handoff note may have, it's meant
+// to be a short heads-up like "dishwasher tabs almost out".
+const MaxHandoffLength = 200
+
+// handoffLookback is how far back the duty before another one is looked for,
+// so a handoff note gets across skipped days.
+const handoffLookback = 7
+
+// weekdays maps the short weekday names rules use to weekdays.
+var weekdays = map[string]time.Weekday{
+	"mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday, "thu": time.Thursday,
+	"fri": time.Friday, "sat": time.Saturday, "sun": time.Sunday,
+}
+
+// Rule says on which days a note template applies. Rules are written as:
+//
+//	daily          every day
+//	tue            every Tuesday (any of mon, tue, ..., sun)
+//	2w:2025-11-04  every 2 weeks, starting on Tuesday 2025-11-04
+//	monthly:15     the 15th of every month
+type Rule struct {
+	weekly  bool
+	weekday time.Weekday
+	weeks   int       // Every this many weeks from anchor, if not 0
+	anchor  time.Time // First day of a rule with weeks
+	day     int       // Day of the month, if not 0
+}
+
+// ParseRule parses a rule written as described on Rule.
+func ParseRule(s string) (Rule, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if s == "daily" {
+		return Rule{}, nil
+	}
+	if weekday, ok := weekdays[s]; ok {
+		return Rule{weekly: true, weekday: weekday}, nil
+	}
+	if day, ok := strings.CutPrefix(s, "monthly:"); ok {
+		d, err := strconv.Atoi(day)
+		if err != nil || d < 1 || d > 31 {
+			return Rule{}, fmt.Errorf("invalid day of the month %q, expected 1 to 31", day)
+		}
+		return Rule{day: d}, nil
+	}
+	if weeks, anchor, ok := strings.Cut(s, "w:"); ok {
+		n, err := strconv.Atoi(weeks)
+		if err != nil || n < 1 {
+			return Rule{}, fmt.Errorf("invalid number of weeks %q", weeks)
+		}
+		start, err := time.Parse("2006-01-02", anchor)
+		if err != nil {
+			return Rule{}, fmt.Errorf("invalid start date %q, expected YYYY-MM-DD", anchor)
+		}
+		return Rule{weekly: true, weekday: start.Weekday(), weeks: n, anchor: start}, nil
+	}
+	return Rule{}, fmt.Errorf("unknown rule %q, expected daily, a weekday like tue, Nw:YYYY-MM-DD or monthly:D", s)
+}
+
+// Matches reports whether the rule applies on date.
+func (r Rule) Matches(date time.Time) bool {
+	day := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+	switch {
+	case r.day != 0:
+		return day.Day() == r.day
+	case r.weeks != 0:
+		if day.Before(r.anchor) {
+			return false
+		}
+		days := int(day.Sub(r.anchor).Hours() / 24)
+		return days%(7*r.weeks) == 0
+	case r.weekly:
+		return day.Weekday() == r.weekday
+	default:
+		return true
+	}
+}
+
+// String describes the rule in words, e.g. "every 2 weeks on Tuesday from Nov 4".
+func (r Rule) String() string {
+	switch {
+	case r.day != 0:
+		return fmt.Sprintf("monthly on day %d", r.day)
+	case r.weeks == 1:
+		return fmt.Sprintf("every %s from %s", r.weekday, r.anchor.Format("Jan 2"))
+	case r.weeks != 0:
+		return fmt.Sprintf("every %d weeks on %s from %s", r.weeks, r.weekday, r.anchor.Format("Jan 2"))
+	case r.weekly:
+		return "every " + r.weekday.String()
+	default:
+		return "every day"
+	}
+}
+
+// Service manages the notes of duties and the note templates.
+type Service struct {
+	store store.DutyStore
+	now   func() time.Time
+}
+
+// New creates a new Service.
+func New(s store.DutyStore) *Service {
+	return &Service{store: s, now: time.Now}
+}
+
+// AddTemplate stores a note template for the days rule matches.
+func (s *Service) AddTemplate(ctx context.Context, rule, text string) (*store.NoteTemplate, error) {
+	if _, err := ParseRule(rule); err != nil {
+		return nil, err
+	}
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil, errors.New("the note text is empty")
+	}
+
+	t := &store.NoteTemplate{Rule: strings.ToLower(strings.TrimSpace(rule)), Text: text, CreatedAt: s.now().UTC()}
+	if err := s.store.CreateNoteTemplate(ctx, t); err != nil {
+		return nil, fmt.Errorf("failed to create note template: %w", err)
+	}
+	return t, nil
+}
+
+// Templates returns all note templates, oldest first.
+func (s *Service) Templates(ctx context.Context) ([]*store.NoteTemplate, error) {
+	templates, err := s.store.ListNoteTemplates(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list note templates: %w", err)
+	}
+	return templates, nil
+}
+
+// DeleteTemplate removes a note template, or returns ErrNotFound.
+func (s *Service) DeleteTemplate(ctx context.Context, id int64) error {
+	templates, err := s.Templates(ctx)
+	if err != nil {
+		return err
+	}
+	for _, t := range templates {
+		if t.ID == id {
+			if err := s.store.DeleteNoteTemplate(ctx, id); err != nil {
+				return fmt.Errorf("failed to delete note template: %w", err)
+			}
+			return nil
+		}
+	}
+	return ErrNotFound
+}
+
+// SetDutyNote sets the note of the duty on date. An empty text removes it.
+func (s *Service) SetDutyNote(ctx context.Context, date time.Time, text string) (*store.Duty, error) {
+	duty, err := s.store.GetDutyByDate(ctx, date)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get duty: %w", err)
+	}
+	if duty == nil {
+		return nil, ErrNoDuty
+	}
+	duty.Note = strings.TrimSpace(text)
+	if err := s.store.UpdateDuty(ctx, duty); err != nil {
+		return nil, fmt.Errorf("failed to update duty: %w", err)
+	}
+	return duty, nil
+}
+
+// SetHandoffNote sets the handoff note the user on duty on date leaves for
+// whoever is next. An empty text removes it.
+func (s *Service) SetHandoffNote(ctx context.Context, date time.Time, userID int64, text string) (*store.Duty, error) {
+	text = strings.TrimSpace(text)
+	if utf8.RuneCountInString(text) > MaxHandoffLength {
+		return nil, ErrHandoffTooLong
+	}
+	duty, err := s.store.GetDutyByDate(ctx, date)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get duty: %w", err)
+	}
+	if duty == nil {
+		return nil, ErrNoDuty
+	}
+	if duty.UserID != userID {
+		return nil, ErrNotOnDuty
+	}
+	duty.HandoffNote = text
+	if err := s.store.UpdateDuty(ctx, duty); err != nil {
+		return nil, fmt.Errorf("failed to update duty: %w", err)
+	}
+	return duty, nil
+}
+
+// ForDuty returns the notes for whoever is on duty: the handoff note of the
+// duty before, the duty's own note, the templates whose rule matches its date
+// and the bins collected that day or the next.
+func (s *Service) ForDuty(ctx context.Context, duty *store.Duty) ([]string, error) {
+	var notes []string
+	handoff, err := s.handoffNote(ctx, duty.DutyDate)
+	if err != nil {
+		return notes, err
+	}
+	if handoff != "" {
+		notes = append(notes, handoff)
+	}
+	if duty.Note != "" {
+		notes = append(notes, duty.Note)
+	}
+	templates, err := s.Templates(ctx)
+	if err != nil {
+		return notes, err
+	}
+	for _, t := range templates {
+		// Rules are checked when a template is added
+		if rule, err := ParseRule(t.Rule); err == nil && rule.Matches(duty.DutyDate) {
+			notes = append(notes, t.Text)
+		}
+	}
+	bins, err := s.binNotes(ctx, duty.DutyDate)
+	if err != nil {
+		return notes, err
+	}
+	return append(notes, bins...), nil
+}
+
+// handoffNote returns the handoff note left on the last duty before date, if
+// it has one, with who left it.
+func (s *Service) handoffNote(ctx context.Context, date time.Time) (string, error) {
+	duties, err := s.store.ListDuties(ctx, store.DutyFilter{From: date.AddDate(0, 0, -handoffLookback), To: date})
+	if err != nil {
+		return "", fmt.Errorf("failed to get the duty before: %w", err)
+	}
+	if len(duties) == 0 {
+		return "", nil
+	}
+	before := duties[len(duties)-1]
+	if before.HandoffNote == "" {
+		return "", nil
+	}
+	from := "the last one on duty"
+	if before.User != nil {
+		from = before.User.Label()
+	}
+	return fmt.Sprintf("📨 Handoff from %s: %s", from, before.HandoffNote), nil
+}
+
+// binNotes tells whoever is on duty on date which bins were collected that
+// day, to bring back in, and which are collected the next morning, to put out.
+func (s *Service) binNotes(ctx context.Context, date time.Time) ([]string, error) {
+	collections, err := s.store.GetWasteCollections(ctx, date, date.AddDate(0, 0, 2))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get waste collections: %w", err)
+	}
+	var today, tomorrow []string
+	for _, c := range collections {
+		if c.Date.Equal(date) {
+			today = append(today, c.Bin)
+		} else {
+			tomorrow = append(tomorrow, c.Bin)
+		}
+	}
+
+	var notes []string
+	if len(today) > 0 {
+		notes = append(notes, "🗑️ Collected today, bring the bins back in: "+strings.Join(today, ", "))
+	}
+	if len(tomorrow) > 0 {
+		notes = append(notes, "🗑️ Put out tonight for tomorrow's collection: "+strings.Join(tomorrow, ", "))
+	}
+	return notes, nil
+}
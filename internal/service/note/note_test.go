@@ -0,0 +1,184 @@
+package note
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/korjavin/dutyassistant/internal/store"
+	"github.com/korjavin/dutyassistant/internal/store/memory"
+)
+
+func date(y int, m time.Month, d int) time.Time {
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+func TestParseRule(t *testing.T) {
+	tests := []struct {
+		rule  string
+		match []time.Time
+		miss  []time.Time
+		desc  string
+	}{
+		{"daily", []time.Time{date(2025, 11, 4), date(2025, 11, 5)}, nil, "every day"},
+		{"Tue", []time.Time{date(2025, 11, 4), date(2025, 11, 11)}, []time.Time{date(2025, 11, 5)}, "every Tuesday"},
+		{"2w:2025-11-04",
+			[]time.Time{date(2025, 11, 4), date(2025, 11, 18), date(2026, 1, 13)},
+			[]time.Time{date(2025, 10, 21), date(2025, 11, 11), date(2025, 11, 5)},
+			"every 2 weeks on Tuesday from Nov 4"},
+		{"1w:2025-11-05", []time.Time{date(2025, 11, 12)}, []time.Time{date(2025, 10, 29)}, "every Wednesday from Nov 5"},
+		{"monthly:15", []time.Time{date(2025, 11, 15), date(2026, 2, 15)}, []time.Time{date(2025, 11, 14)}, "monthly on day 15"},
+	}
+	for _, tt := range tests {
+		rule, err := ParseRule(tt.rule)
+		if err != nil {
+			t.Errorf("ParseRule(%q) failed: %v", tt.rule, err)
+			continue
+		}
+		for _, d := range tt.match {
+			if !rule.Matches(d) {
+				t.Errorf("%q should match %s", tt.rule, d.Format("2006-01-02"))
+			}
+		}
+		for _, d := range tt.miss {
+			if rule.Matches(d) {
+				t.Errorf("%q should not match %s", tt.rule, d.Format("2006-01-02"))
+			}
+		}
+		if got := rule.String(); got != tt.desc {
+			t.Errorf("%q described as %q, expected %q", tt.rule, got, tt.desc)
+		}
+	}
+
+	for _, rule := range []string{"", "weekly", "tuesday", "0w:2025-11-04", "2w:11/04", "monthly:0", "monthly:32"} {
+		if _, err := ParseRule(rule); err == nil {
+			t.Errorf("ParseRule(%q) should fail", rule)
+		}
+	}
+}
+
+func TestService(t *testing.T) {
+	ctx := context.Background()
+	s := memory.New()
+	svc := New(s)
+
+	alice := &store.User{TelegramUserID: 1, FirstName: "Alice", IsActive: true}
+	s.CreateUser(ctx, alice)
+	s.CreateDuty(ctx, &store.Duty{UserID: alice.ID, DutyDate: date(2025, 11, 4), AssignmentType: store.AssignmentTypeRoundRobin})
+
+	if _, err := svc.AddTemplate(ctx, "fortnightly", "bins"); err == nil {
+		t.Error("Expected an invalid rule to be rejected")
+	}
+	if _, err := svc.AddTemplate(ctx, "tue", "  "); err == nil {
+		t.Error("Expected an empty note to be rejected")
+	}
+	brown, err := svc.AddTemplate(ctx, "2w:2025-11-04", "Bins are brown this week")
+	if err != nil {
+		t.Fatalf("AddTemplate failed: %v", err)
+	}
+	svc.AddTemplate(ctx, "2w:2025-11-11", "Bins are blue this week")
+
+	if _, err := svc.SetDutyNote(ctx, date(2025, 11, 5), "guests"); !errors.Is(err, ErrNoDuty) {
+		t.Errorf("Expected ErrNoDuty for a free day, got %v", err)
+	}
+	duty, err := svc.SetDutyNote(ctx, date(2025, 11, 4), " Guests for dinner ")
+	if err != nil {
+		t.Fatalf("SetDutyNote failed: %v", err)
+	}
+
+	notes, err := svc.ForDuty(ctx, duty)
+	if err != nil {
+		t.Fatalf("ForDuty failed: %v", err)
+	}
+	if want := []string{"Guests for dinner", "Bins are brown this week"}; !reflect.DeepEqual(notes, want) {
+		t.Errorf("Expected notes %q, got %q", want, notes)
+	}
+
+	if err := svc.DeleteTemplate(ctx, brown.ID); err != nil {
+		t.Fatalf("DeleteTemplate failed: %v", err)
+	}
+	if err := svc.DeleteTemplate(ctx, brown.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound deleting twice, got %v", err)
+	}
+	duty, _ = svc.SetDutyNote(ctx, date(2025, 11, 4), "")
+	if notes, _ := svc.ForDuty(ctx, duty); len(notes) != 0 {
+		t.Errorf("Expected no notes after clearing, got %q", notes)
+	}
+}
+
+func TestService_ForDutyBins(t *testing.T) {
+	ctx := context.Background()
+	s := memory.New()
+	svc := New(s)
+	s.ReplaceWasteCollections(ctx, []*store.WasteCollection{
+		{Date: date(2025, 11, 4), Bin: "Bio"},
+		{Date: date(2025, 11, 5), Bin: "Paper"},
+		{Date: date(2025, 11, 5), Bin: "Plastic"},
+		{Date: date(2025, 11, 6), Bin: "Residual"},
+	})
+
+	notes, err := svc.ForDuty(ctx, &store.Duty{DutyDate: date(2025, 11, 4)})
+	if err != nil {
+		t.Fatalf("ForDuty failed: %v", err)
+	}
+	want := []string{
+		"🗑️ Collected today, bring the bins back in: Bio",
+		"🗑️ Put out tonight for tomorrow's collection: Paper, Plastic",
+	}
+	if !reflect.DeepEqual(notes, want) {
+		t.Errorf("Expected notes %q, got %q", want, notes)
+	}
+
+	if notes, _ := svc.ForDuty(ctx, &store.Duty{DutyDate: date(2025, 11, 7)}); len(notes) != 0 {
+		t.Errorf("Expected no notes without collections, got %q", notes)
+	}
+}
+
+func TestService_Handoff(t *testing.T) {
+	ctx := context.Background()
+	s := memory.New()
+	svc := New(s)
+
+	alice := &store.User{TelegramUserID: 1, FirstName: "Alice", IsActive: true}
+	bob := &store.User{TelegramUserID: 2, FirstName: "Bob", IsActive: true}
+	s.CreateUser(ctx, alice)
+	s.CreateUser(ctx, bob)
+	s.CreateDuty(ctx, &store.Duty{UserID: alice.ID, DutyDate: date(2025, 11, 4), AssignmentType: store.AssignmentTypeRoundRobin})
+	s.CreateDuty(ctx, &store.Duty{UserID: bob.ID, DutyDate: date(2025, 11, 6), AssignmentType: store.AssignmentTypeRoundRobin})
+
+	if _, err := svc.SetHandoffNote(ctx, date(2025, 11, 4), bob.ID, "tabs"); !errors.Is(err, ErrNotOnDuty) {
+		t.Errorf("Expected ErrNotOnDuty for someone else's duty, got %v", err)
+	}
+	if _, err := svc.SetHandoffNote(ctx, date(2025, 11, 5), alice.ID, "tabs"); !errors.Is(err, ErrNoDuty) {
+		t.Errorf("Expected ErrNoDuty for a free day, got %v", err)
+	}
+	if _, err := svc.SetHandoffNote(ctx, date(2025, 11, 4), alice.ID, strings.Repeat("a", MaxHandoffLength+1)); !errors.Is(err, ErrHandoffTooLong) {
+		t.Errorf("Expected ErrHandoffTooLong, got %v", err)
+	}
+	if _, err := svc.SetHandoffNote(ctx, date(2025, 11, 4), alice.ID, " Dishwasher tabs almost out "); err != nil {
+		t.Fatalf("SetHandoffNote failed: %v", err)
+	}
+
+	// Bob is next, after a free day
+	next, _ := s.GetDutyByDate(ctx, date(2025, 11, 6))
+	notes, err := svc.ForDuty(ctx, next)
+	if err != nil {
+		t.Fatalf("ForDuty failed: %v", err)
+	}
+	if want := []string{"📨 Handoff from Alice: Dishwasher tabs almost out"}; !reflect.DeepEqual(notes, want) {
+		t.Errorf("Expected notes %q, got %q", want, notes)
+	}
+	// Alice's own reminder doesn't carry it
+	duty, _ := s.GetDutyByDate(ctx, date(2025, 11, 4))
+	if notes, _ := svc.ForDuty(ctx, duty); len(notes) != 0 {
+		t.Errorf("Expected no notes for Alice, got %q", notes)
+	}
+
+	svc.SetHandoffNote(ctx, date(2025, 11, 4), alice.ID, "")
+	if notes, _ := svc.ForDuty(ctx, next); len(notes) != 0 {
+		t.Errorf("Expected no notes after clearing, got %q", notes)
+	}
+}
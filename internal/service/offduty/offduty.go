@@ -0,0 +1,123 @@
+// Package offduty reads lists of off-duty periods to import in bulk, like a
+// school's holidays: pasted lines of date ranges, or an iCal calendar.
+package offduty
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/korjavin/dutyassistant/internal/ical"
+)
+
+// MaxDays is the longest period accepted, in days, as for /offduty.
+const MaxDays = 366
+
+// MaxPeriods is the most periods accepted in one import.
+const MaxPeriods = 50
+
+// Period is an off-duty period to import, both days included.
+type Period struct {
+	Start   time.Time
+	End     time.Time
+	Summary string // What the period is, e.g. "Autumn break"; may be empty
+}
+
+// datePattern matches the dates of a line.
+var datePattern = regexp.MustCompile(`\d{4}-\d{2}-\d{2}`)
+
+// Parse reads the periods of text, an iCal calendar if it starts like one and
+// otherwise one period per line: a date or two, the first and last day, and
+// optionally what it is, e.g. "2025-10-27 2025-10-31 Autumn break". Empty
+// lines and lines starting with # are left out. Periods are returned in the
+// order given; any line that isn't a period fails the whole text.
+func Parse(text string) ([]Period, error) {
+	text = strings.TrimSpace(text)
+	var periods []Period
+	if strings.HasPrefix(strings.ToUpper(text), "BEGIN:VCALENDAR") {
+		events, err := ical.Parse(strings.NewReader(text))
+		if err != nil {
+			return nil, fmt.Errorf("invalid calendar: %w", err)
+		}
+		for _, e := range events {
+			p := Period{Start: date(e.Start), End: date(e.Start), Summary: e.Summary}
+			if e.AllDay {
+				p.End = e.LastDay()
+			} else if e.End.After(e.Start) {
+				p.End = date(e.End)
+			}
+			if err := p.check(); err != nil {
+				return nil, fmt.Errorf("event %q: %w", e.Summary, err)
+			}
+			periods = append(periods, p)
+		}
+	} else {
+		for i, line := range strings.Split(text, "\n") {
+			line = strings.TrimSpace(line)
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			p, err := parseLine(line)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", i+1, err)
+			}
+			periods = append(periods, p)
+		}
+	}
+
+	if len(periods) == 0 {
+		return nil, fmt.Errorf("no periods found")
+	}
+	if len(periods) > MaxPeriods {
+		return nil, fmt.Errorf("at most %d periods can be imported at once", MaxPeriods)
+	}
+	return periods, nil
+}
+
+// parseLine reads a line of a date or two and what the period is.
+func parseLine(line string) (Period, error) {
+	loc := datePattern.FindAllStringIndex(line, 2)
+	if len(loc) == 0 || loc[0][0] != 0 {
+		return Period{}, fmt.Errorf("expected YYYY-MM-DD [YYYY-MM-DD] [description], got %q", line)
+	}
+	start, err := time.Parse("2006-01-02", line[loc[0][0]:loc[0][1]])
+	if err != nil {
+		return Period{}, fmt.Errorf("invalid date %q", line[loc[0][0]:loc[0][1]])
+	}
+	p := Period{Start: start, End: start}
+	rest := line[loc[0][1]:]
+	// The last day may follow after a dash or "to"
+	if len(loc) == 2 {
+		between := strings.TrimSpace(line[loc[0][1]:loc[1][0]])
+		if between == "" || between == "-" || between == "–" || between == "to" || between == "..." {
+			end, err := time.Parse("2006-01-02", line[loc[1][0]:loc[1][1]])
+			if err != nil {
+				return Period{}, fmt.Errorf("invalid date %q", line[loc[1][0]:loc[1][1]])
+			}
+			p.End, rest = end, line[loc[1][1]:]
+		}
+	}
+	p.Summary = strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(rest), ":-–"))
+	return p, p.check()
+}
+
+// check rejects periods ending before they start, taking too long or
+// outside the years dates are taken in.
+func (p Period) check() error {
+	if p.Start.Year() < 2000 || p.End.Year() > 2100 {
+		return fmt.Errorf("date out of range")
+	}
+	if p.End.Before(p.Start) {
+		return fmt.Errorf("the last day %s is before the first", p.End.Format("2006-01-02"))
+	}
+	if int(p.End.Sub(p.Start).Hours()/24)+1 > MaxDays {
+		return fmt.Errorf("periods must be at most %d days", MaxDays)
+	}
+	return nil
+}
+
+// date returns the day of t in UTC.
+func date(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,82 @@
+package offduty
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func day(m time.Month, d int) time.Time {
+	return time.Date(2025, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+func TestParse(t *testing.T) {
+	periods, err := Parse(`
+# Autumn term
+2025-10-27 2025-10-31 Autumn break
+2025-11-01: All Saints
+2025-12-22 - 2026-01-06 – Christmas
+2025-11-18 to 2025-11-19
+`)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	want := []Period{
+		{day(time.October, 27), day(time.October, 31), "Autumn break"},
+		{day(time.November, 1), day(time.November, 1), "All Saints"},
+		{day(time.December, 22), time.Date(2026, 1, 6, 0, 0, 0, 0, time.UTC), "Christmas"},
+		{day(time.November, 18), day(time.November, 19), ""},
+	}
+	if len(periods) != len(want) {
+		t.Fatalf("Parse = %+v, want %d periods", periods, len(want))
+	}
+	for i, p := range periods {
+		if !p.Start.Equal(want[i].Start) || !p.End.Equal(want[i].End) || p.Summary != want[i].Summary {
+			t.Errorf("Period %d = %+v, want %+v", i, p, want[i])
+		}
+	}
+}
+
+func TestParse_Calendar(t *testing.T) {
+	periods, err := Parse(strings.Join([]string{
+		"BEGIN:VCALENDAR",
+		"BEGIN:VEVENT",
+		"SUMMARY:Herbstferien",
+		"DTSTART;VALUE=DATE:20251027",
+		"DTEND;VALUE=DATE:20251101",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"SUMMARY:Teacher training",
+		"DTSTART:20251118T080000Z",
+		"DTEND:20251118T150000Z",
+		"END:VEVENT",
+		"END:VCALENDAR",
+	}, "\r\n"))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if len(periods) != 2 {
+		t.Fatalf("Parse = %+v, want 2 periods", periods)
+	}
+	if p := periods[0]; !p.Start.Equal(day(time.October, 27)) || !p.End.Equal(day(time.October, 31)) || p.Summary != "Herbstferien" {
+		t.Errorf("Period = %+v, want the autumn holidays", p)
+	}
+	if p := periods[1]; !p.Start.Equal(day(time.November, 18)) || !p.End.Equal(day(time.November, 18)) {
+		t.Errorf("Period = %+v, want the 18th", p)
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	tests := map[string]string{
+		"":                                  "no periods",
+		"Autumn break 2025-10-27":           "line 1: expected YYYY-MM-DD",
+		"2025-10-27 2025-10-31\n2025-13-01": "line 2: invalid date",
+		"2025-10-31 2025-10-27":             "before the first",
+		"2025-01-01 2026-06-01":             "at most 366 days",
+	}
+	for text, want := range tests {
+		if _, err := Parse(text); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("Parse(%q) = %v, want %q", text, err, want)
+		}
+	}
+}
@@ -0,0 +1,168 @@
+// Package queue measures whether the volunteer and admin queues are
+// consumed fairly: how long queued days wait before a duty uses them up, and
+// which duties used up whose days. The store records every change of a
+// queue; days are matched up oldest first, the order the queue consumes them
+// in.
+package queue
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/korjavin/dutyassistant/internal/store"
+)
+
+// Wait sums up how the days of a queue waited.
+type Wait struct {
+	Added     int           // Days added
+	Consumed  int           // Days consumed by duties
+	Untracked int           // Days consumed that were added before changes were recorded
+	Pending   int           // Days added that are still waiting
+	Average   time.Duration // Of the tracked days consumed
+	Max       time.Duration // Of the tracked days consumed
+	Oldest    *time.Time    // When the longest waiting pending day was added
+
+	sum time.Duration // Of the tracked days consumed
+}
+
+// Consumption is a queued day a duty used up.
+type Consumption struct {
+	Queue   store.AssignmentType
+	AddedAt *time.Time // nil if the day was added before changes were recorded
+	At      time.Time
+	Wait    time.Duration
+}
+
+// UserMetrics are the metrics of a user's queues.
+type UserMetrics struct {
+	User      *store.User
+	Volunteer Wait
+	Admin     Wait
+	History   []Consumption // Oldest first
+}
+
+// Metrics are the metrics of both queues, overall and per user.
+type Metrics struct {
+	Volunteer Wait
+	Admin     Wait
+	Users     []*UserMetrics // Users whose queues ever changed, by name
+}
+
+// Service computes the metrics.
+type Service struct {
+	store store.Store
+}
+
+// New creates a new Service backed by the given store.
+func New(s store.Store) *Service {
+	return &Service{store: s}
+}
+
+// Metrics returns the metrics of a user, or of all users for userID 0.
+// A day handed back to a user, like when their volunteered duty is given to
+// someone else, waits anew.
+func (s *Service) Metrics(ctx context.Context, userID int64) (*Metrics, error) {
+	events, err := s.store.ListQueueEvents(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	users, err := s.store.ListAllUsers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[int64]*store.User, len(users))
+	for _, u := range users {
+		byID[u.ID] = u
+	}
+
+	m := &Metrics{}
+	perUser := make(map[int64]*UserMetrics)
+	// Days added and not consumed yet, oldest first, per user and queue
+	type key struct {
+		userID int64
+		queue  store.AssignmentType
+	}
+	waiting := make(map[key][]time.Time)
+	for _, e := range events {
+		um, ok := perUser[e.UserID]
+		if !ok {
+			um = &UserMetrics{User: byID[e.UserID]}
+			if um.User == nil {
+				um.User = &store.User{ID: e.UserID}
+			}
+			perUser[e.UserID] = um
+		}
+		total, own := &m.Volunteer, &um.Volunteer
+		if e.Queue == store.AssignmentTypeAdmin {
+			total, own = &m.Admin, &um.Admin
+		}
+		k := key{e.UserID, e.Queue}
+
+		if e.Days > 0 {
+			total.Added += e.Days
+			own.Added += e.Days
+			for i := 0; i < e.Days; i++ {
+				waiting[k] = append(waiting[k], e.At)
+			}
+			continue
+		}
+		c := Consumption{Queue: e.Queue, At: e.At}
+		total.Consumed++
+		own.Consumed++
+		if len(waiting[k]) == 0 {
+			total.Untracked++
+			own.Untracked++
+		} else {
+			added := waiting[k][0]
+			waiting[k] = waiting[k][1:]
+			c.AddedAt = &added
+			c.Wait = e.At.Sub(added)
+			total.sum += c.Wait
+			own.sum += c.Wait
+			total.Max = max(total.Max, c.Wait)
+			own.Max = max(own.Max, c.Wait)
+		}
+		um.History = append(um.History, c)
+	}
+
+	for k, days := range waiting {
+		if len(days) == 0 {
+			continue
+		}
+		um := perUser[k.userID]
+		total, own := &m.Volunteer, &um.Volunteer
+		if k.queue == store.AssignmentTypeAdmin {
+			total, own = &m.Admin, &um.Admin
+		}
+		total.Pending += len(days)
+		own.Pending += len(days)
+		oldest := days[0]
+		own.Oldest = &oldest
+		if total.Oldest == nil || oldest.Before(*total.Oldest) {
+			total.Oldest = &oldest
+		}
+	}
+
+	m.Volunteer.average()
+	m.Admin.average()
+	for _, um := range perUser {
+		um.Volunteer.average()
+		um.Admin.average()
+		m.Users = append(m.Users, um)
+	}
+	sort.Slice(m.Users, func(i, j int) bool {
+		if m.Users[i].User.FirstName != m.Users[j].User.FirstName {
+			return m.Users[i].User.FirstName < m.Users[j].User.FirstName
+		}
+		return m.Users[i].User.ID < m.Users[j].User.ID
+	})
+	return m, nil
+}
+
+// average sets the average wait of the tracked days consumed.
+func (w *Wait) average() {
+	if tracked := w.Consumed - w.Untracked; tracked > 0 {
+		w.Average = w.sum / time.Duration(tracked)
+	}
+}
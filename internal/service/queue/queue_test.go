@@ -0,0 +1,85 @@
+package queue
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/korjavin/dutyassistant/internal/store"
+	"github.com/korjavin/dutyassistant/internal/store/memory"
+)
+
+// eventStore returns the given queue events instead of recorded ones, whose
+// times are the real ones.
+type eventStore struct {
+	*memory.Store
+	events []*store.QueueEvent
+}
+
+func (s *eventStore) ListQueueEvents(ctx context.Context, userID int64) ([]*store.QueueEvent, error) {
+	var events []*store.QueueEvent
+	for _, e := range s.events {
+		if userID == 0 || e.UserID == userID {
+			events = append(events, e)
+		}
+	}
+	return events, nil
+}
+
+func TestMetrics(t *testing.T) {
+	ctx := context.Background()
+	s := &eventStore{Store: memory.New()}
+	alice := &store.User{TelegramUserID: 1, FirstName: "Alice", IsActive: true}
+	bob := &store.User{TelegramUserID: 2, FirstName: "Bob", IsActive: true}
+	for _, u := range []*store.User{bob, alice} {
+		if err := s.CreateUser(ctx, u); err != nil {
+			t.Fatal(err)
+		}
+	}
+	day := func(d int) time.Time { return time.Date(2025, 11, d, 11, 0, 0, 0, time.UTC) }
+	volunteer, admin := store.AssignmentTypeVoluntary, store.AssignmentTypeAdmin
+	s.events = []*store.QueueEvent{
+		{UserID: alice.ID, Queue: volunteer, Days: -1, At: day(1)}, // Added before changes were recorded
+		{UserID: alice.ID, Queue: volunteer, Days: 2, At: day(2)},
+		{UserID: bob.ID, Queue: admin, Days: 1, At: day(2)},
+		{UserID: alice.ID, Queue: volunteer, Days: -1, At: day(3)},
+		{UserID: alice.ID, Queue: volunteer, Days: -1, At: day(6)},
+		{UserID: bob.ID, Queue: volunteer, Days: 1, At: day(7)},
+	}
+
+	m, err := New(s).Metrics(ctx, 0)
+	if err != nil {
+		t.Fatalf("Metrics failed: %v", err)
+	}
+	v := m.Volunteer
+	if v.Added != 3 || v.Consumed != 3 || v.Untracked != 1 || v.Pending != 1 || v.Oldest == nil || !v.Oldest.Equal(day(7)) {
+		t.Errorf("Volunteer queue = %+v, want 3 added, 3 consumed of which 1 untracked, 1 pending since the 7th", v)
+	}
+	// One day waited a day, the other four
+	if v.Average != 60*time.Hour || v.Max != 96*time.Hour {
+		t.Errorf("Volunteer waits average %v, max %v, want 60h and 96h", v.Average, v.Max)
+	}
+	if a := m.Admin; a.Added != 1 || a.Consumed != 0 || a.Pending != 1 || a.Average != 0 {
+		t.Errorf("Admin queue = %+v, want 1 day pending", a)
+	}
+
+	if len(m.Users) != 2 || m.Users[0].User.ID != alice.ID || m.Users[1].User.ID != bob.ID {
+		t.Fatalf("Users = %+v, want Alice and Bob", m.Users)
+	}
+	history := m.Users[0].History
+	if len(history) != 3 || history[0].AddedAt != nil || !history[2].AddedAt.Equal(day(2)) || history[2].Wait != 96*time.Hour {
+		t.Errorf("Alice's history = %+v, want an untracked day, then two days added on the 2nd", history)
+	}
+	if b := m.Users[1]; b.Volunteer.Pending != 1 || b.Admin.Pending != 1 || len(b.History) != 0 {
+		t.Errorf("Bob = %+v, want a day pending in each queue", b)
+	}
+
+	// Of Bob alone
+	m, err = New(s).Metrics(ctx, bob.ID)
+	if err != nil {
+		t.Fatalf("Metrics failed: %v", err)
+	}
+	if len(m.Users) != 1 || m.Volunteer.Consumed != 0 || m.Volunteer.Pending != 1 {
+		t.Errorf("Metrics of Bob = %+v, want his day pending only", m)
+	}
+}
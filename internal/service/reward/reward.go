@@ -0,0 +1,261 @@
+// Package reward posts completed duties to chore-reward and allowance apps,
+// so doing the dishes earns points there too. Each app is an Integration
+// with its own request template and mapping of users, dates and points, read
+// from the YAML file in REWARD_WEBHOOKS. Completed duties are taken from the
+// event bus and posted in the background; failed posts are logged, not
+// retried.
+package reward
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/korjavin/dutyassistant/internal/events"
+	"github.com/korjavin/dutyassistant/internal/service/user"
+	"github.com/korjavin/dutyassistant/internal/store"
+	"gopkg.in/yaml.v3"
+)
+
+// Config is the file of REWARD_WEBHOOKS.
+type Config struct {
+	Integrations []*Integration `yaml:"integrations"`
+}
+
+// Integration is an app completed duties are posted to.
+type Integration struct {
+	Name    string            `yaml:"name"`
+	URL     string            `yaml:"url"`
+	Method  string            `yaml:"method,omitempty"` // POST unless set
+	Headers map[string]string `yaml:"headers,omitempty"`
+	// Body is a text/template of the request body, executed with an Event.
+	// Its json function quotes a value, e.g. {"child": {{json .User}}}.
+	Body string `yaml:"body"`
+	// Users maps Telegram IDs to the app's IDs for them. If it is set, the
+	// duties of users it doesn't have aren't posted, e.g. the parents'.
+	Users map[int64]string `yaml:"users,omitempty"`
+	// DateFormat is the Go layout of Event.Date, 2006-01-02 unless set.
+	DateFormat string `yaml:"date_format,omitempty"`
+	// Points is what a duty is worth, 1 unless set, and WeekendPoints what
+	// one on Saturday or Sunday is, Points unless set.
+	Points        int `yaml:"points,omitempty"`
+	WeekendPoints int `yaml:"weekend_points,omitempty"`
+
+	body *template.Template
+}
+
+// Event is what the body template of an integration is executed with.
+type Event struct {
+	Key        string // Identifies the duty, for apps that drop duplicates
+	User       string // The app's ID of the user, or their Telegram ID if not mapped
+	TelegramID int64
+	Name       string
+	Date       string // In the integration's DateFormat
+	Points     int
+	Assignment string // How the duty was assigned, e.g. voluntary
+}
+
+// Load reads the integrations from the YAML file at path, see Parse.
+func Load(path string) ([]*Integration, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return Parse(data)
+}
+
+// Parse parses and checks the integrations of a REWARD_WEBHOOKS file.
+func Parse(data []byte) ([]*Integration, error) {
+	var c Config
+	if err := yaml.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("invalid YAML: %w", err)
+	}
+	names := make(map[string]bool)
+	for i, in := range c.Integrations {
+		if in.Name == "" {
+			in.Name = strconv.Itoa(i + 1)
+		}
+		if names[in.Name] {
+			return nil, fmt.Errorf("integration %s: the name is used twice", in.Name)
+		}
+		names[in.Name] = true
+		if err := in.init(); err != nil {
+			return nil, fmt.Errorf("integration %s: %w", in.Name, err)
+		}
+	}
+	return c.Integrations, nil
+}
+
+// init checks the integration and fills in its defaults.
+func (in *Integration) init() error {
+	u, err := url.Parse(in.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid url %q, expected an http or https URL", in.URL)
+	}
+	in.Method = strings.ToUpper(in.Method)
+	if in.Method == "" {
+		in.Method = http.MethodPost
+	}
+	if in.Body == "" {
+		return fmt.Errorf("the body template is missing")
+	}
+	if in.body, err = template.New(in.Name).Funcs(template.FuncMap{"json": quote}).Parse(in.Body); err != nil {
+		return fmt.Errorf("invalid body template: %w", err)
+	}
+	if in.DateFormat == "" {
+		in.DateFormat = "2006-01-02"
+	}
+	if in.Points < 0 || in.WeekendPoints < 0 {
+		return fmt.Errorf("points can't be negative")
+	}
+	if in.Points == 0 {
+		in.Points = 1
+	}
+	if in.WeekendPoints == 0 {
+		in.WeekendPoints = in.Points
+	}
+	// Check the template against an event now rather than at the first duty
+	_, err = in.render(Event{Key: "duty-2006-01-02-1", User: "1", TelegramID: 1, Date: "2006-01-02", Points: 1})
+	return err
+}
+
+// quote is the json function of body templates.
+func quote(v any) (string, error) {
+	b, err := json.Marshal(v)
+	return string(b), err
+}
+
+// event returns the Event of the duty u completed on date, and false if the
+// integration doesn't post the duties of u.
+func (in *Integration) event(u *store.User, date time.Time, assignment store.AssignmentType) (Event, bool) {
+	id := strconv.FormatInt(u.TelegramUserID, 10)
+	if len(in.Users) > 0 {
+		mapped, ok := in.Users[u.TelegramUserID]
+		if !ok {
+			return Event{}, false
+		}
+		id = mapped
+	}
+	points := in.Points
+	if wd := date.Weekday(); wd == time.Saturday || wd == time.Sunday {
+		points = in.WeekendPoints
+	}
+	return Event{
+		Key:        fmt.Sprintf("duty-%s-%d", date.Format("2006-01-02"), u.TelegramUserID),
+		User:       id,
+		TelegramID: u.TelegramUserID,
+		Name:       u.FirstName,
+		Date:       date.Format(in.DateFormat),
+		Points:     points,
+		Assignment: string(assignment),
+	}, true
+}
+
+func (in *Integration) render(e Event) ([]byte, error) {
+	var b bytes.Buffer
+	if err := in.body.Execute(&b, e); err != nil {
+		return nil, fmt.Errorf("failed to render the body: %w", err)
+	}
+	return b.Bytes(), nil
+}
+
+// Store is the part of store.Store the integrations read.
+type Store interface {
+	store.UserStore
+	store.DutyStore
+}
+
+// Service posts completed duties to the integrations.
+type Service struct {
+	store        Store
+	integrations []*Integration
+	client       *http.Client
+	// posting runs a post; in the background unless tests replace it
+	posting func(func())
+}
+
+// New creates a new Service posting to integrations, which come from Parse.
+func New(s Store, integrations []*Integration) *Service {
+	return &Service{
+		store:        s,
+		integrations: integrations,
+		client:       &http.Client{Timeout: 10 * time.Second},
+		posting:      func(post func()) { go post() },
+	}
+}
+
+// HandleEvent posts a completed duty to every integration. It is subscribed
+// to the scheduler's event bus.
+func (s *Service) HandleEvent(ctx context.Context, e events.Event) {
+	completed, ok := e.(events.DutyCompleted)
+	if !ok || len(s.integrations) == 0 {
+		return
+	}
+	s.posting(func() {
+		// The bus's context may end with the request that completed the duty
+		ctx := context.WithoutCancel(ctx)
+		for _, err := range s.Post(ctx, completed.Date, completed.UserID) {
+			log.Printf("[REWARD] %v", err)
+		}
+	})
+}
+
+// Post posts the duty userID completed on date to every integration, and
+// returns what failed.
+func (s *Service) Post(ctx context.Context, date time.Time, userID int64) []error {
+	u, err := user.New(s.store).ByID(ctx, userID)
+	if err != nil {
+		return []error{fmt.Errorf("failed to get user %d: %w", userID, err)}
+	}
+	var assignment store.AssignmentType
+	if d, err := s.store.GetDutyByDate(ctx, date); err == nil && d != nil {
+		assignment = d.AssignmentType
+	}
+
+	var errs []error
+	for _, in := range s.integrations {
+		e, ok := in.event(u, date, assignment)
+		if !ok {
+			continue
+		}
+		if err := s.post(ctx, in, e); err != nil {
+			errs = append(errs, fmt.Errorf("failed to post the duty of %s on %s to %s: %w", u.FirstName, date.Format("2006-01-02"), in.Name, err))
+		}
+	}
+	return errs
+}
+
+func (s *Service) post(ctx context.Context, in *Integration, e Event) error {
+	body, err := in.render(e)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, in.Method, in.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range in.Headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 200))
+		return fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+package reward
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/korjavin/dutyassistant/internal/events"
+	"github.com/korjavin/dutyassistant/internal/store"
+	"github.com/korjavin/dutyassistant/internal/store/memory"
+)
+
+func TestParse(t *testing.T) {
+	integrations, err := Parse([]byte(`
+integrations:
+  - name: allowance
+    url: https://example.com/chores
+    body: '{"child": {{json .User}}, "points": {{.Points}}}'
+    points: 10
+`))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	in := integrations[0]
+	if in.Method != http.MethodPost || in.DateFormat != "2006-01-02" || in.Points != 10 || in.WeekendPoints != 10 {
+		t.Errorf("Expected the defaults to be filled in, got %+v", in)
+	}
+
+	for name, file := range map[string]string{
+		"url":        "integrations: [{url: 'ftp://example.com', body: '{}'}]",
+		"no body":    "integrations: [{url: 'https://example.com'}]",
+		"template":   "integrations: [{url: 'https://example.com', body: '{{.User'}]",
+		"field":      "integrations: [{url: 'https://example.com', body: '{{.Child}}'}]",
+		"points":     "integrations: [{url: 'https://example.com', body: '{}', points: -1}]",
+		"duplicates": "integrations: [{name: a, url: 'https://example.com', body: '{}'}, {name: a, url: 'https://example.com', body: '{}'}]",
+	} {
+		if _, err := Parse([]byte(file)); err == nil {
+			t.Errorf("Expected an error for the invalid %s", name)
+		}
+	}
+}
+
+func TestHandleEvent(t *testing.T) {
+	ctx := context.Background()
+	var bodies []string
+	var auth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(b))
+		auth = r.Header.Get("Authorization")
+	}))
+	defer server.Close()
+
+	integrations, err := Parse([]byte(`
+integrations:
+  - name: allowance
+    url: ` + server.URL + `
+    headers: {Authorization: Bearer secret}
+    body: '{"child": {{json .User}}, "day": {{json .Date}}, "points": {{.Points}}, "id": {{json .Key}}}'
+    users: {100: kid-1}
+    date_format: 02.01.2006
+    points: 5
+    weekend_points: 8
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := memory.New()
+	kid := &store.User{TelegramUserID: 100, FirstName: "Kid", IsActive: true}
+	parent := &store.User{TelegramUserID: 200, FirstName: "Parent", IsActive: true}
+	for _, u := range []*store.User{kid, parent} {
+		if err := s.CreateUser(ctx, u); err != nil {
+			t.Fatal(err)
+		}
+	}
+	svc := New(s, integrations)
+	svc.posting = func(post func()) { post() }
+
+	saturday := time.Date(2026, 3, 7, 0, 0, 0, 0, time.UTC)
+	svc.HandleEvent(ctx, events.DutyCompleted{Date: saturday, UserID: kid.ID})
+	svc.HandleEvent(ctx, events.DutyCompleted{Date: saturday.AddDate(0, 0, 2), UserID: parent.ID})
+	svc.HandleEvent(ctx, events.DutyAssigned{Duty: &store.Duty{UserID: kid.ID, DutyDate: saturday}})
+
+	want := `{"child": "kid-1", "day": "07.03.2026", "points": 8, "id": "duty-2026-03-07-100"}`
+	if len(bodies) != 1 || bodies[0] != want {
+		t.Fatalf("Expected only the kid's duty to be posted as %s, got %q", want, bodies)
+	}
+	if auth != "Bearer secret" {
+		t.Errorf("Expected the Authorization header to be sent, got %q", auth)
+	}
+}
+
+func TestPost_Failure(t *testing.T) {
+	ctx := context.Background()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unknown child", http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	integrations, err := Parse([]byte("integrations: [{name: allowance, url: '" + server.URL + "', body: '{}'}]"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := memory.New()
+	u := &store.User{TelegramUserID: 100, FirstName: "Kid", IsActive: true}
+	if err := s.CreateUser(ctx, u); err != nil {
+		t.Fatal(err)
+	}
+
+	errs := New(s, integrations).Post(ctx, time.Date(2026, 3, 9, 0, 0, 0, 0, time.UTC), u.ID)
+	if len(errs) != 1 || !strings.Contains(errs[0].Error(), "unknown child") {
+		t.Errorf("Expected the app's answer in the error, got %v", errs)
+	}
+}
@@ -0,0 +1,481 @@
+// Package settings keeps the bot's settings that admins can change at
+// runtime with /settings: the group chat announcements go to, the admins,
+// whether new users need their approval, the fine of payout mode, whether
+// trips announced in the group are spotted, when the duty is assigned and
+// checked, whether the bot is in read-only mode for maintenance, which
+// sections the daily announcement has, whether the group is asked for
+// volunteers every Sunday and how /cover finds someone at short notice.
+// They are seeded from DISH_GROUP and ADMIN_ID on the first run; after that
+// the stored values win, so changing them needs no restart.
+package settings
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/korjavin/dutyassistant/internal/store"
+)
+
+// Keys of the settings.
+const (
+	KeyGroupChat       = "group_chat_id"
+	KeyAdmins          = "admin_ids"
+	KeyRequireApproval = "require_approval"
+	KeyPayoutFine      = "payout_fine"
+	KeyPayoutCurrency  = "payout_currency"
+	KeyPayoutSince     = "payout_since"
+	KeyTripHints       = "trip_hints"
+	KeyAssignmentTime  = "assignment_time"
+	KeyCompletionTime  = "completion_time"
+	KeyReadOnly        = "read_only"
+	KeySections        = "announcement_sections"
+	KeyWeeklyPoll      = "weekly_poll"
+	KeyCoverWait       = "cover_wait"
+	KeyCoverFallback   = "cover_fallback"
+)
+
+// Optional sections of the daily announcement, after who is on duty.
+const (
+	SectionUpcoming = "upcoming" // Who is on duty the next days
+	SectionQueues   = "queues"   // The volunteer and admin queues
+	SectionFairness = "fairness" // A bar of the duties everyone did lately
+)
+
+// Sections are the optional sections of the daily announcement, in the order
+// they appear in it.
+var Sections = []string{SectionUpcoming, SectionQueues, SectionFairness}
+
+// DefaultCurrency is the currency of payout mode unless another one is set.
+const DefaultCurrency = "EUR"
+
+// Payout is payout mode, for households that settle missed duties with money
+// instead of makeup days: from Since on, each missed duty costs Fine cents of
+// Currency. It is off while Fine is 0.
+type Payout struct {
+	Fine     int64
+	Currency string
+	Since    time.Time
+}
+
+// On reports whether payout mode is on.
+func (p Payout) On() bool { return p.Fine > 0 }
+
+// DutyTimes are the Berlin times of day, as the time since midnight, the duty
+// is assigned at and checked for completion. 0 keeps the default of either.
+type DutyTimes struct {
+	Assignment time.Duration
+	Completion time.Duration
+}
+
+// DefaultCoverWait is how long the group has to claim a duty asked to be
+// covered with /cover, unless another time is set.
+const DefaultCoverWait = time.Hour
+
+// Cover is how /cover finds someone to take over a duty at short notice: the
+// group has Wait to claim it, after that Fallback takes it over. Without a
+// Fallback, whoever is on duty keeps it.
+type Cover struct {
+	Wait     time.Duration
+	Fallback int64 // Telegram user ID of the fallback person, 0 for none
+}
+
+// ErrUnknownSection is returned when turning on or off a section that isn't
+// one of Sections.
+var ErrUnknownSection = errors.New("unknown announcement section")
+
+// ErrLastAdmin is returned when removing the only admin, which would leave
+// nobody able to change the settings back.
+var ErrLastAdmin = errors.New("can't remove the last admin")
+
+// Service reads and changes the settings.
+type Service struct {
+	store store.SettingStore
+}
+
+// New creates a new Service backed by the given store.
+func New(s store.SettingStore) *Service {
+	return &Service{store: s}
+}
+
+// Seed stores the group chat and admins configured in the environment,
+// skipping settings that were stored before. Unconfigured ones stay unset,
+// so configuring them later still takes effect.
+func (s *Service) Seed(ctx context.Context, groupChatID int64, adminIDs []int64) error {
+	for key, value := range map[string]string{
+		KeyGroupChat: strconv.FormatInt(groupChatID, 10),
+		KeyAdmins:    formatIDs(adminIDs),
+	} {
+		if value == "" || value == "0" {
+			continue
+		}
+		stored, err := s.store.GetSetting(ctx, key)
+		if err != nil {
+			return fmt.Errorf("failed to get setting %s: %w", key, err)
+		}
+		if stored != "" {
+			continue
+		}
+		if err := s.store.SetSetting(ctx, key, value); err != nil {
+			return fmt.Errorf("failed to seed setting %s: %w", key, err)
+		}
+	}
+	return nil
+}
+
+// GroupChatID returns the ID of the group chat, or 0 if there is none.
+func (s *Service) GroupChatID(ctx context.Context) (int64, error) {
+	value, err := s.store.GetSetting(ctx, KeyGroupChat)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get setting %s: %w", KeyGroupChat, err)
+	}
+	if value == "" {
+		return 0, nil
+	}
+	id, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid setting %s %q: %w", KeyGroupChat, value, err)
+	}
+	return id, nil
+}
+
+// SetGroupChatID sets the group chat, 0 for none.
+func (s *Service) SetGroupChatID(ctx context.Context, id int64) error {
+	return s.store.SetSetting(ctx, KeyGroupChat, strconv.FormatInt(id, 10))
+}
+
+// AdminIDs returns the Telegram user IDs of the admins, in the order they
+// were added.
+func (s *Service) AdminIDs(ctx context.Context) ([]int64, error) {
+	value, err := s.store.GetSetting(ctx, KeyAdmins)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get setting %s: %w", KeyAdmins, err)
+	}
+	ids, err := parseIDs(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid setting %s %q: %w", KeyAdmins, value, err)
+	}
+	return ids, nil
+}
+
+// AddAdmin makes the Telegram user an admin. Adding an admin again is a
+// no-op.
+func (s *Service) AddAdmin(ctx context.Context, telegramUserID int64) error {
+	ids, err := s.AdminIDs(ctx)
+	if err != nil {
+		return err
+	}
+	if slices.Contains(ids, telegramUserID) {
+		return nil
+	}
+	return s.store.SetSetting(ctx, KeyAdmins, formatIDs(append(ids, telegramUserID)))
+}
+
+// SetAdminIDs replaces the admins, e.g. when a configuration is imported.
+func (s *Service) SetAdminIDs(ctx context.Context, ids []int64) error {
+	return s.store.SetSetting(ctx, KeyAdmins, formatIDs(ids))
+}
+
+// RemoveAdmin takes the admin rights of the Telegram user. It fails with
+// ErrLastAdmin for the only admin.
+func (s *Service) RemoveAdmin(ctx context.Context, telegramUserID int64) error {
+	ids, err := s.AdminIDs(ctx)
+	if err != nil {
+		return err
+	}
+	if !slices.Contains(ids, telegramUserID) {
+		return nil
+	}
+	if len(ids) == 1 {
+		return ErrLastAdmin
+	}
+	ids = slices.DeleteFunc(ids, func(id int64) bool { return id == telegramUserID })
+	return s.store.SetSetting(ctx, KeyAdmins, formatIDs(ids))
+}
+
+// RequireApproval reports whether users registering with /start wait for an
+// admin to approve them before they join the rotation. It is off unless
+// turned on.
+func (s *Service) RequireApproval(ctx context.Context) (bool, error) {
+	value, err := s.store.GetSetting(ctx, KeyRequireApproval)
+	if err != nil {
+		return false, fmt.Errorf("failed to get setting %s: %w", KeyRequireApproval, err)
+	}
+	if value == "" {
+		return false, nil
+	}
+	on, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("invalid setting %s %q: %w", KeyRequireApproval, value, err)
+	}
+	return on, nil
+}
+
+// SetRequireApproval turns the approval of new users on or off.
+func (s *Service) SetRequireApproval(ctx context.Context, on bool) error {
+	return s.store.SetSetting(ctx, KeyRequireApproval, strconv.FormatBool(on))
+}
+
+// TripHints reports whether the bot listens to the group for messages like
+// "we're away next week" and offers to set the off-duty period. It is off
+// unless turned on.
+func (s *Service) TripHints(ctx context.Context) (bool, error) {
+	value, err := s.store.GetSetting(ctx, KeyTripHints)
+	if err != nil {
+		return false, fmt.Errorf("failed to get setting %s: %w", KeyTripHints, err)
+	}
+	if value == "" {
+		return false, nil
+	}
+	on, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("invalid setting %s %q: %w", KeyTripHints, value, err)
+	}
+	return on, nil
+}
+
+// SetTripHints turns spotting trips in the group on or off.
+func (s *Service) SetTripHints(ctx context.Context, on bool) error {
+	return s.store.SetSetting(ctx, KeyTripHints, strconv.FormatBool(on))
+}
+
+// WeeklyPoll reports whether the group is asked every Sunday who volunteers
+// for which day of the next week. It is off unless turned on.
+func (s *Service) WeeklyPoll(ctx context.Context) (bool, error) {
+	value, err := s.store.GetSetting(ctx, KeyWeeklyPoll)
+	if err != nil {
+		return false, fmt.Errorf("failed to get setting %s: %w", KeyWeeklyPoll, err)
+	}
+	if value == "" {
+		return false, nil
+	}
+	on, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("invalid setting %s %q: %w", KeyWeeklyPoll, value, err)
+	}
+	return on, nil
+}
+
+// SetWeeklyPoll turns the Sunday poll for volunteers on or off.
+func (s *Service) SetWeeklyPoll(ctx context.Context, on bool) error {
+	return s.store.SetSetting(ctx, KeyWeeklyPoll, strconv.FormatBool(on))
+}
+
+// ReadOnly reports whether the bot is in read-only mode for maintenance,
+// refusing changes while still answering queries. It is off unless turned
+// on.
+func (s *Service) ReadOnly(ctx context.Context) (bool, error) {
+	value, err := s.store.GetSetting(ctx, KeyReadOnly)
+	if err != nil {
+		return false, fmt.Errorf("failed to get setting %s: %w", KeyReadOnly, err)
+	}
+	if value == "" {
+		return false, nil
+	}
+	on, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("invalid setting %s %q: %w", KeyReadOnly, value, err)
+	}
+	return on, nil
+}
+
+// SetReadOnly turns read-only mode on or off.
+func (s *Service) SetReadOnly(ctx context.Context, on bool) error {
+	return s.store.SetSetting(ctx, KeyReadOnly, strconv.FormatBool(on))
+}
+
+// AnnouncementSections returns the optional sections the daily announcement
+// has, in the order of Sections. It has none unless they are turned on.
+func (s *Service) AnnouncementSections(ctx context.Context) ([]string, error) {
+	value, err := s.store.GetSetting(ctx, KeySections)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get setting %s: %w", KeySections, err)
+	}
+	on := strings.Split(value, ",")
+	var sections []string
+	for _, section := range Sections {
+		if slices.Contains(on, section) {
+			sections = append(sections, section)
+		}
+	}
+	return sections, nil
+}
+
+// SetAnnouncementSection turns an optional section of the daily announcement
+// on or off. It fails with ErrUnknownSection unless it is one of Sections.
+func (s *Service) SetAnnouncementSection(ctx context.Context, section string, on bool) error {
+	if !slices.Contains(Sections, section) {
+		return ErrUnknownSection
+	}
+	sections, err := s.AnnouncementSections(ctx)
+	if err != nil {
+		return err
+	}
+	sections = slices.DeleteFunc(sections, func(other string) bool { return other == section })
+	if on {
+		sections = append(sections, section)
+	}
+	return s.store.SetSetting(ctx, KeySections, strings.Join(sections, ","))
+}
+
+// DutyTimes returns the times the duty is assigned at and checked for
+// completion.
+func (s *Service) DutyTimes(ctx context.Context) (DutyTimes, error) {
+	var t DutyTimes
+	for key, d := range map[string]*time.Duration{KeyAssignmentTime: &t.Assignment, KeyCompletionTime: &t.Completion} {
+		value, err := s.store.GetSetting(ctx, key)
+		if err != nil {
+			return DutyTimes{}, fmt.Errorf("failed to get setting %s: %w", key, err)
+		}
+		if value == "" {
+			continue
+		}
+		clock, err := time.Parse("15:04", value)
+		if err != nil {
+			return DutyTimes{}, fmt.Errorf("invalid setting %s %q: %w", key, value, err)
+		}
+		*d = time.Duration(clock.Hour())*time.Hour + time.Duration(clock.Minute())*time.Minute
+	}
+	return t, nil
+}
+
+// SetDutyTimes changes the times the duty is assigned at and checked for
+// completion.
+func (s *Service) SetDutyTimes(ctx context.Context, t DutyTimes) error {
+	for key, d := range map[string]time.Duration{KeyAssignmentTime: t.Assignment, KeyCompletionTime: t.Completion} {
+		value := ""
+		if d != 0 {
+			value = fmt.Sprintf("%02d:%02d", int(d.Hours()), int(d.Minutes())%60)
+		}
+		if err := s.store.SetSetting(ctx, key, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Payout returns the settings of payout mode.
+func (s *Service) Payout(ctx context.Context) (Payout, error) {
+	p := Payout{Currency: DefaultCurrency}
+	values := make(map[string]string)
+	for _, key := range []string{KeyPayoutFine, KeyPayoutCurrency, KeyPayoutSince} {
+		value, err := s.store.GetSetting(ctx, key)
+		if err != nil {
+			return Payout{}, fmt.Errorf("failed to get setting %s: %w", key, err)
+		}
+		values[key] = value
+	}
+	if value := values[KeyPayoutFine]; value != "" {
+		fine, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return Payout{}, fmt.Errorf("invalid setting %s %q: %w", KeyPayoutFine, value, err)
+		}
+		p.Fine = fine
+	}
+	if value := values[KeyPayoutCurrency]; value != "" {
+		p.Currency = value
+	}
+	if value := values[KeyPayoutSince]; value != "" {
+		since, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			return Payout{}, fmt.Errorf("invalid setting %s %q: %w", KeyPayoutSince, value, err)
+		}
+		p.Since = since
+	}
+	return p, nil
+}
+
+// SetPayout sets the fine of a missed duty in cents of currency, 0 to turn
+// payout mode off. Turning it on makes it count from today on, so earlier
+// missed duties aren't charged; changing the fine while it is on doesn't.
+func (s *Service) SetPayout(ctx context.Context, fine int64, currency string, today time.Time) error {
+	if fine < 0 {
+		return errors.New("the fine can't be negative")
+	}
+	current, err := s.Payout(ctx)
+	if err != nil {
+		return err
+	}
+	if fine > 0 && !current.On() {
+		if err := s.store.SetSetting(ctx, KeyPayoutSince, today.Format("2006-01-02")); err != nil {
+			return err
+		}
+	}
+	if currency != "" {
+		if err := s.store.SetSetting(ctx, KeyPayoutCurrency, strings.ToUpper(currency)); err != nil {
+			return err
+		}
+	}
+	return s.store.SetSetting(ctx, KeyPayoutFine, strconv.FormatInt(fine, 10))
+}
+
+// Cover returns how /cover finds someone to take over a duty.
+func (s *Service) Cover(ctx context.Context) (Cover, error) {
+	c := Cover{Wait: DefaultCoverWait}
+	value, err := s.store.GetSetting(ctx, KeyCoverWait)
+	if err != nil {
+		return Cover{}, fmt.Errorf("failed to get setting %s: %w", KeyCoverWait, err)
+	}
+	if value != "" {
+		minutes, err := strconv.Atoi(value)
+		if err != nil {
+			return Cover{}, fmt.Errorf("invalid setting %s %q: %w", KeyCoverWait, value, err)
+		}
+		c.Wait = time.Duration(minutes) * time.Minute
+	}
+	value, err = s.store.GetSetting(ctx, KeyCoverFallback)
+	if err != nil {
+		return Cover{}, fmt.Errorf("failed to get setting %s: %w", KeyCoverFallback, err)
+	}
+	if value != "" {
+		if c.Fallback, err = strconv.ParseInt(value, 10, 64); err != nil {
+			return Cover{}, fmt.Errorf("invalid setting %s %q: %w", KeyCoverFallback, value, err)
+		}
+	}
+	return c, nil
+}
+
+// SetCover changes how /cover finds someone to take over a duty. The wait is
+// kept in whole minutes, of at least one.
+func (s *Service) SetCover(ctx context.Context, c Cover) error {
+	if c.Wait < time.Minute {
+		return errors.New("the wait must be at least a minute")
+	}
+	if err := s.store.SetSetting(ctx, KeyCoverWait, strconv.Itoa(int(c.Wait/time.Minute))); err != nil {
+		return err
+	}
+	return s.store.SetSetting(ctx, KeyCoverFallback, strconv.FormatInt(c.Fallback, 10))
+}
+
+// parseIDs parses a comma-separated list of IDs, as admin lists are stored.
+func parseIDs(value string) ([]int64, error) {
+	var ids []int64
+	for _, field := range strings.Split(value, ",") {
+		if field = strings.TrimSpace(field); field == "" {
+			continue
+		}
+		id, err := strconv.ParseInt(field, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		if id != 0 {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+// formatIDs formats IDs as a comma-separated list.
+func formatIDs(ids []int64) string {
+	fields := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if id != 0 {
+			fields = append(fields, strconv.FormatInt(id, 10))
+		}
+	}
+	return strings.Join(fields, ",")
+}
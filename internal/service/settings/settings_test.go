@@ -0,0 +1,171 @@
+package settings
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/korjavin/dutyassistant/internal/store/memory"
+)
+
+func TestSeed(t *testing.T) {
+	ctx := context.Background()
+	s := New(memory.New())
+
+	// Nothing configured leaves the settings unset
+	if err := s.Seed(ctx, 0, nil); err != nil {
+		t.Fatalf("Seed failed: %v", err)
+	}
+	if id, err := s.GroupChatID(ctx); err != nil || id != 0 {
+		t.Errorf("GroupChatID = %d, %v, want 0", id, err)
+	}
+
+	if err := s.Seed(ctx, -100, []int64{1}); err != nil {
+		t.Fatalf("Seed failed: %v", err)
+	}
+	if id, err := s.GroupChatID(ctx); err != nil || id != -100 {
+		t.Errorf("GroupChatID = %d, %v, want -100", id, err)
+	}
+
+	// Stored settings win over the environment of later runs
+	if err := s.SetGroupChatID(ctx, -200); err != nil {
+		t.Fatalf("SetGroupChatID failed: %v", err)
+	}
+	if err := s.Seed(ctx, -100, []int64{3}); err != nil {
+		t.Fatalf("Seed failed: %v", err)
+	}
+	if id, err := s.GroupChatID(ctx); err != nil || id != -200 {
+		t.Errorf("GroupChatID after seeding again = %d, %v, want -200", id, err)
+	}
+	if ids, err := s.AdminIDs(ctx); err != nil || !reflect.DeepEqual(ids, []int64{1}) {
+		t.Errorf("AdminIDs after seeding again = %v, %v, want [1]", ids, err)
+	}
+}
+
+func TestAdmins(t *testing.T) {
+	ctx := context.Background()
+	s := New(memory.New())
+
+	if ids, err := s.AdminIDs(ctx); err != nil || len(ids) != 0 {
+		t.Errorf("AdminIDs without admins = %v, %v, want none", ids, err)
+	}
+	for _, id := range []int64{1, 2, 1} {
+		if err := s.AddAdmin(ctx, id); err != nil {
+			t.Fatalf("AddAdmin(%d) failed: %v", id, err)
+		}
+	}
+	if ids, err := s.AdminIDs(ctx); err != nil || !reflect.DeepEqual(ids, []int64{1, 2}) {
+		t.Errorf("AdminIDs = %v, %v, want [1 2]", ids, err)
+	}
+
+	if err := s.RemoveAdmin(ctx, 1); err != nil {
+		t.Fatalf("RemoveAdmin failed: %v", err)
+	}
+	if err := s.RemoveAdmin(ctx, 2); !errors.Is(err, ErrLastAdmin) {
+		t.Errorf("RemoveAdmin of the last admin = %v, want ErrLastAdmin", err)
+	}
+	if ids, err := s.AdminIDs(ctx); err != nil || !reflect.DeepEqual(ids, []int64{2}) {
+		t.Errorf("AdminIDs after removing = %v, %v, want [2]", ids, err)
+	}
+}
+
+func TestRequireApproval(t *testing.T) {
+	ctx := context.Background()
+	s := New(memory.New())
+
+	if on, err := s.RequireApproval(ctx); err != nil || on {
+		t.Errorf("RequireApproval by default = %v, %v, want false", on, err)
+	}
+	if err := s.SetRequireApproval(ctx, true); err != nil {
+		t.Fatalf("SetRequireApproval failed: %v", err)
+	}
+	if on, err := s.RequireApproval(ctx); err != nil || !on {
+		t.Errorf("RequireApproval after turning it on = %v, %v, want true", on, err)
+	}
+}
+
+func TestPayout(t *testing.T) {
+	ctx := context.Background()
+	s := New(memory.New())
+	day := func(d int) time.Time { return time.Date(2025, time.November, d, 0, 0, 0, 0, time.UTC) }
+
+	if p, err := s.Payout(ctx); err != nil || p.On() || p.Currency != DefaultCurrency {
+		t.Errorf("Payout by default = %+v, %v, want off in %s", p, err, DefaultCurrency)
+	}
+	if err := s.SetPayout(ctx, 500, "", day(3)); err != nil {
+		t.Fatalf("SetPayout failed: %v", err)
+	}
+	// Changing the fine keeps the day payout mode started
+	if err := s.SetPayout(ctx, 300, "chf", day(10)); err != nil {
+		t.Fatalf("SetPayout failed: %v", err)
+	}
+	if p, err := s.Payout(ctx); err != nil || p != (Payout{Fine: 300, Currency: "CHF", Since: day(3)}) {
+		t.Errorf("Payout = %+v, %v, want 300 CHF since Nov 3", p, err)
+	}
+
+	// Turning it on again starts over
+	if err := s.SetPayout(ctx, 0, "", day(12)); err != nil {
+		t.Fatal(err)
+	}
+	if p, _ := s.Payout(ctx); p.On() {
+		t.Errorf("Payout after turning it off = %+v", p)
+	}
+	if err := s.SetPayout(ctx, 300, "", day(20)); err != nil {
+		t.Fatal(err)
+	}
+	if p, _ := s.Payout(ctx); !p.Since.Equal(day(20)) {
+		t.Errorf("Payout after turning it on again = %+v, want it to start on Nov 20", p)
+	}
+	if err := s.SetPayout(ctx, -1, "", day(20)); err == nil {
+		t.Error("SetPayout with a negative fine should fail")
+	}
+}
+
+func TestAnnouncementSections(t *testing.T) {
+	ctx := context.Background()
+	s := New(memory.New())
+
+	if sections, err := s.AnnouncementSections(ctx); err != nil || len(sections) != 0 {
+		t.Errorf("AnnouncementSections by default = %v, %v, want none", sections, err)
+	}
+	for _, section := range []string{SectionFairness, SectionUpcoming, SectionUpcoming, SectionQueues} {
+		if err := s.SetAnnouncementSection(ctx, section, true); err != nil {
+			t.Fatalf("SetAnnouncementSection(%s) failed: %v", section, err)
+		}
+	}
+	if err := s.SetAnnouncementSection(ctx, SectionQueues, false); err != nil {
+		t.Fatalf("SetAnnouncementSection failed: %v", err)
+	}
+	if err := s.SetAnnouncementSection(ctx, "weather", true); !errors.Is(err, ErrUnknownSection) {
+		t.Errorf("SetAnnouncementSection(weather) = %v, want ErrUnknownSection", err)
+	}
+
+	sections, err := s.AnnouncementSections(ctx)
+	if err != nil {
+		t.Fatalf("AnnouncementSections failed: %v", err)
+	}
+	if want := []string{SectionUpcoming, SectionFairness}; !reflect.DeepEqual(sections, want) {
+		t.Errorf("AnnouncementSections = %v, want %v in their order", sections, want)
+	}
+}
+
+func TestCover(t *testing.T) {
+	ctx := context.Background()
+	s := New(memory.New())
+
+	if c, err := s.Cover(ctx); err != nil || c != (Cover{Wait: DefaultCoverWait}) {
+		t.Errorf("Cover by default = %+v, %v, want an hour without fallback", c, err)
+	}
+	want := Cover{Wait: 30 * time.Minute, Fallback: 123}
+	if err := s.SetCover(ctx, want); err != nil {
+		t.Fatalf("SetCover failed: %v", err)
+	}
+	if c, err := s.Cover(ctx); err != nil || c != want {
+		t.Errorf("Cover = %+v, %v, want %+v", c, err, want)
+	}
+	if err := s.SetCover(ctx, Cover{Wait: 30 * time.Second}); err == nil {
+		t.Error("SetCover with less than a minute should fail")
+	}
+}
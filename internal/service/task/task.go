@@ -0,0 +1,187 @@
+// Package task manages one-off tasks outside the rotation, like "clean the
+// garage on Saturday". Admins add them, members claim and do them, and done
+// tasks count towards the stats with their weight, apart from duty days.
+package task
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/korjavin/dutyassistant/internal/store"
+)
+
+const (
+	// DefaultWeight is what a task counts for unless it is given a weight:
+	// as much as a duty day.
+	DefaultWeight = 1
+	// MaxWeight is the most a task can count for.
+	MaxWeight = 10
+)
+
+var (
+	// ErrNotFound is returned when a task doesn't exist.
+	ErrNotFound = errors.New("task not found")
+	// ErrTaken is returned when a task is claimed by someone else.
+	ErrTaken = errors.New("the task is claimed by someone else")
+	// ErrDone is returned when a task is done already.
+	ErrDone = errors.New("the task is done already")
+	// ErrNotClaimed is returned when giving back or confirming a task nobody,
+	// or someone else, claimed.
+	ErrNotClaimed = errors.New("the task isn't claimed")
+	// ErrInvalidWeight is returned for weights outside 1 to MaxWeight.
+	ErrInvalidWeight = fmt.Errorf("the weight must be between 1 and %d", MaxWeight)
+)
+
+// Service manages one-off tasks.
+type Service struct {
+	store store.DutyStore
+	now   func() time.Time
+}
+
+// New creates a new Service.
+func New(s store.DutyStore) *Service {
+	return &Service{store: s, now: time.Now}
+}
+
+// Add stores a task that counts weight duty days and should be done by due,
+// if it isn't nil.
+func (s *Service) Add(ctx context.Context, title string, weight int, due *time.Time) (*store.Task, error) {
+	title = strings.TrimSpace(title)
+	if title == "" {
+		return nil, errors.New("the task title is empty")
+	}
+	if weight < 1 || weight > MaxWeight {
+		return nil, ErrInvalidWeight
+	}
+
+	t := &store.Task{Title: title, Weight: weight, DueDate: due, CreatedAt: s.now().UTC()}
+	if err := s.store.CreateTask(ctx, t); err != nil {
+		return nil, fmt.Errorf("failed to create task: %w", err)
+	}
+	return t, nil
+}
+
+// Get returns the task with the ID, or ErrNotFound.
+func (s *Service) Get(ctx context.Context, id int64) (*store.Task, error) {
+	t, err := s.store.GetTask(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get task: %w", err)
+	}
+	if t == nil {
+		return nil, ErrNotFound
+	}
+	return t, nil
+}
+
+// List returns all tasks, oldest first.
+func (s *Service) List(ctx context.Context) ([]*store.Task, error) {
+	tasks, err := s.store.ListTasks(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tasks: %w", err)
+	}
+	return tasks, nil
+}
+
+// Open returns the tasks that aren't done, those due first first and those
+// without a due date last.
+func (s *Service) Open(ctx context.Context) ([]*store.Task, error) {
+	tasks, err := s.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var open []*store.Task
+	for _, t := range tasks {
+		if t.DoneAt == nil {
+			open = append(open, t)
+		}
+	}
+	sort.SliceStable(open, func(i, j int) bool {
+		a, b := open[i].DueDate, open[j].DueDate
+		return a != nil && (b == nil || a.Before(*b))
+	})
+	return open, nil
+}
+
+// Claim gives the task to the user. Claiming one's own task again is fine.
+func (s *Service) Claim(ctx context.Context, id, userID int64) (*store.Task, error) {
+	ok, err := s.store.ClaimTask(ctx, id, userID, s.now())
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim task: %w", err)
+	}
+	t, err := s.Get(ctx, id)
+	if err != nil || ok {
+		return t, err
+	}
+	switch {
+	case t.DoneAt != nil:
+		return t, ErrDone
+	case *t.ClaimedBy != userID:
+		return t, ErrTaken
+	}
+	return t, nil
+}
+
+// Release gives back the user's claim of the task, so someone else can take
+// it.
+func (s *Service) Release(ctx context.Context, id, userID int64) (*store.Task, error) {
+	ok, err := s.store.ReleaseTask(ctx, id, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to release task: %w", err)
+	}
+	t, err := s.Get(ctx, id)
+	if err != nil || ok {
+		return t, err
+	}
+	if t.DoneAt != nil {
+		return t, ErrDone
+	}
+	return t, ErrNotClaimed
+}
+
+// Complete marks the task done by the user, who doesn't have to claim it
+// first unless someone else did.
+func (s *Service) Complete(ctx context.Context, id, userID int64) (*store.Task, error) {
+	ok, err := s.store.CompleteTask(ctx, id, userID, s.now())
+	if err != nil {
+		return nil, fmt.Errorf("failed to complete task: %w", err)
+	}
+	t, err := s.Get(ctx, id)
+	if err != nil || ok {
+		return t, err
+	}
+	if t.DoneAt != nil {
+		return t, ErrDone
+	}
+	return t, ErrTaken
+}
+
+// Confirm marks the task done by whoever claimed it, for admins recording
+// that it was done. It returns ErrNotClaimed if nobody did.
+func (s *Service) Confirm(ctx context.Context, id int64) (*store.Task, error) {
+	t, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if t.DoneAt != nil {
+		return t, ErrDone
+	}
+	if t.ClaimedBy == nil {
+		return t, ErrNotClaimed
+	}
+	return s.Complete(ctx, id, *t.ClaimedBy)
+}
+
+// Delete removes a task, or returns ErrNotFound.
+func (s *Service) Delete(ctx context.Context, id int64) error {
+	if _, err := s.Get(ctx, id); err != nil {
+		return err
+	}
+	if err := s.store.DeleteTask(ctx, id); err != nil {
+		return fmt.Errorf("failed to delete task: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/korjavin/dutyassistant/internal/store"
+	"github.com/korjavin/dutyassistant/internal/store/memory"
+)
+
+func date(y int, m time.Month, d int) time.Time {
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+func TestService(t *testing.T) {
+	ctx := context.Background()
+	s := memory.New()
+	svc := New(s)
+
+	alice := &store.User{TelegramUserID: 1, FirstName: "Alice", IsActive: true}
+	bob := &store.User{TelegramUserID: 2, FirstName: "Bob", IsActive: true}
+	s.CreateUser(ctx, alice)
+	s.CreateUser(ctx, bob)
+
+	if _, err := svc.Add(ctx, "Clean the garage", 0, nil); !errors.Is(err, ErrInvalidWeight) {
+		t.Errorf("Expected ErrInvalidWeight for a weight of 0, got %v", err)
+	}
+	if _, err := svc.Add(ctx, " ", 1, nil); err == nil {
+		t.Error("Expected an empty title to be rejected")
+	}
+	windows, err := svc.Add(ctx, "Wash the windows", DefaultWeight, nil)
+	if err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	saturday := date(2025, 11, 8)
+	garage, err := svc.Add(ctx, " Clean the garage ", 3, &saturday)
+	if err != nil || garage.Title != "Clean the garage" {
+		t.Fatalf("Add = %+v, %v, want the title trimmed", garage, err)
+	}
+
+	// Tasks that are due come first
+	open, err := svc.Open(ctx)
+	if err != nil || len(open) != 2 || open[0].ID != garage.ID {
+		t.Fatalf("Open = %+v, %v, want the garage first", open, err)
+	}
+
+	if _, err := svc.Claim(ctx, garage.ID, alice.ID); err != nil {
+		t.Fatalf("Claim failed: %v", err)
+	}
+	if _, err := svc.Claim(ctx, garage.ID, alice.ID); err != nil {
+		t.Errorf("Claiming one's own task again: got %v, want no error", err)
+	}
+	if _, err := svc.Claim(ctx, garage.ID, bob.ID); !errors.Is(err, ErrTaken) {
+		t.Errorf("Claiming Alice's task: got %v, want ErrTaken", err)
+	}
+	if _, err := svc.Complete(ctx, garage.ID, bob.ID); !errors.Is(err, ErrTaken) {
+		t.Errorf("Completing Alice's task: got %v, want ErrTaken", err)
+	}
+	if _, err := svc.Release(ctx, garage.ID, bob.ID); !errors.Is(err, ErrNotClaimed) {
+		t.Errorf("Releasing Alice's task: got %v, want ErrNotClaimed", err)
+	}
+	if _, err := svc.Confirm(ctx, windows.ID); !errors.Is(err, ErrNotClaimed) {
+		t.Errorf("Confirming an unclaimed task: got %v, want ErrNotClaimed", err)
+	}
+	done, err := svc.Confirm(ctx, garage.ID)
+	if err != nil || done.DoneAt == nil || *done.ClaimedBy != alice.ID {
+		t.Fatalf("Confirm = %+v, %v, want the garage done by Alice", done, err)
+	}
+	if _, err := svc.Claim(ctx, garage.ID, bob.ID); !errors.Is(err, ErrDone) {
+		t.Errorf("Claiming a done task: got %v, want ErrDone", err)
+	}
+
+	// Bob does the windows without claiming them first
+	if _, err := svc.Complete(ctx, windows.ID, bob.ID); err != nil {
+		t.Fatalf("Complete failed: %v", err)
+	}
+	if open, _ := svc.Open(ctx); len(open) != 0 {
+		t.Errorf("Open = %+v, want no open tasks", open)
+	}
+	if stats, _ := s.GetUserStats(ctx, alice.ID); stats.TasksDone != 1 || stats.TaskPoints != 3 {
+		t.Errorf("Alice's stats = %+v, want a task worth 3", stats)
+	}
+
+	if err := svc.Delete(ctx, 999); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Deleting an unknown task: got %v, want ErrNotFound", err)
+	}
+	if err := svc.Delete(ctx, windows.ID); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if _, err := svc.Claim(ctx, windows.ID, alice.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Claiming a deleted task: got %v, want ErrNotFound", err)
+	}
+}
@@ -0,0 +1,258 @@
+// Package transfer copies a roster's data from one store backend to another,
+// for roster-bot migrate-data: the users with their queues and off-duty
+// periods, the duties and the settings. A copy is checked by comparing the
+// row count and checksum of each kind of data in both stores, see Summarize.
+// The rest of the history, like schedule versions, badges or the ledger,
+// isn't part of it.
+package transfer
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/korjavin/dutyassistant/internal/store"
+)
+
+var (
+	// ErrNotEmpty is returned when copying into a store that has users or
+	// duties already, which the copy would clash with.
+	ErrNotEmpty = errors.New("the target store isn't empty")
+	// ErrMismatch is returned when the data in the target store differs from
+	// the source after copying it.
+	ErrMismatch = errors.New("the copy differs from the source")
+)
+
+// Table is the row count and checksum of one kind of data in a store.
+type Table struct {
+	Name     string
+	Rows     int
+	Checksum string
+}
+
+// Copy copies the users, duties and settings of from into to, all of them or,
+// if anything fails, nothing. Users are matched between the stores by their
+// Telegram ID, as their internal IDs are given by to. to must be empty.
+func Copy(ctx context.Context, from, to store.Store) error {
+	users, err := from.ListAllUsers(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list users: %w", err)
+	}
+	duties, err := from.ListDuties(ctx, store.DutyFilter{})
+	if err != nil {
+		return fmt.Errorf("failed to list duties: %w", err)
+	}
+	values, err := from.ListSettings(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list settings: %w", err)
+	}
+
+	return to.RunInTx(ctx, func(tx store.Store) error {
+		if err := checkEmpty(ctx, tx); err != nil {
+			return err
+		}
+		// Internal IDs of from mapped to those of to
+		ids := make(map[int64]int64, len(users))
+		for _, u := range users {
+			copied := *u
+			copied.ID = 0
+			if err := tx.CreateUser(ctx, &copied); err != nil {
+				return fmt.Errorf("failed to copy user %d: %w", u.TelegramUserID, err)
+			}
+			ids[u.ID] = copied.ID
+			periods, err := from.ListOffDutyPeriods(ctx, u.ID)
+			if err != nil {
+				return fmt.Errorf("failed to list the off-duty periods of user %d: %w", u.TelegramUserID, err)
+			}
+			if err := copyOffDutyPeriods(ctx, tx, copied.ID, periods); err != nil {
+				return fmt.Errorf("failed to copy the off-duty periods of user %d: %w", u.TelegramUserID, err)
+			}
+		}
+		for _, d := range duties {
+			if err := copyDuty(ctx, tx, d, ids); err != nil {
+				return fmt.Errorf("failed to copy the duty on %s: %w", d.DutyDate.Format("2006-01-02"), err)
+			}
+		}
+		for key, value := range values {
+			if err := tx.SetSetting(ctx, key, value); err != nil {
+				return fmt.Errorf("failed to copy setting %s: %w", key, err)
+			}
+		}
+		return nil
+	})
+}
+
+// checkEmpty returns ErrNotEmpty unless s has no users and no duties.
+func checkEmpty(ctx context.Context, s store.Store) error {
+	users, err := s.ListAllUsers(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list the target's users: %w", err)
+	}
+	duties, err := s.ListDuties(ctx, store.DutyFilter{})
+	if err != nil {
+		return fmt.Errorf("failed to list the target's duties: %w", err)
+	}
+	if len(users) > 0 || len(duties) > 0 {
+		return fmt.Errorf("%w: it has %d users and %d duties", ErrNotEmpty, len(users), len(duties))
+	}
+	return nil
+}
+
+// copyOffDutyPeriods stores the periods of a user, which are replaced by
+// their source.
+func copyOffDutyPeriods(ctx context.Context, tx store.Store, userID int64, periods []*store.OffDutyPeriod) error {
+	bySource := make(map[string][]*store.OffDutyPeriod)
+	var sources []string
+	for _, p := range periods {
+		if _, ok := bySource[p.Source]; !ok {
+			sources = append(sources, p.Source)
+		}
+		copied := *p
+		copied.UserID = userID
+		bySource[p.Source] = append(bySource[p.Source], &copied)
+	}
+	for _, source := range sources {
+		if err := tx.ReplaceOffDutyPeriods(ctx, userID, source, bySource[source]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// copyDuty creates d in tx, for the user ids maps its user to. The fields
+// CreateDuty leaves out are set after it the way the bot sets them.
+func copyDuty(ctx context.Context, tx store.Store, d *store.Duty, ids map[int64]int64) error {
+	userID, ok := ids[d.UserID]
+	if !ok {
+		return fmt.Errorf("unknown user %d", d.UserID)
+	}
+	copied := *d
+	copied.ID, copied.UserID, copied.User = 0, userID, nil
+	copied.CompletionBy = ids[d.CompletionBy]
+	if err := tx.CreateDuty(ctx, &copied); err != nil {
+		return err
+	}
+	if d.CompletionBy != 0 || d.Published {
+		if err := tx.UpdateDuty(ctx, &copied); err != nil {
+			return err
+		}
+	}
+	if d.BackfilledAt != nil {
+		if _, err := tx.BackfillDuty(ctx, d.DutyDate, userID, *d.BackfilledAt); err != nil {
+			return err
+		}
+	}
+	if d.AnnouncedAt != nil {
+		if err := tx.MarkDutyAnnounced(ctx, d.DutyDate, *d.AnnouncedAt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Verify compares the tables of both stores after a copy, and returns those
+// of to. If any differs it returns ErrMismatch naming them.
+func Verify(ctx context.Context, from, to store.Store) ([]Table, error) {
+	want, err := Summarize(ctx, from)
+	if err != nil {
+		return nil, fmt.Errorf("failed to summarize the source: %w", err)
+	}
+	got, err := Summarize(ctx, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to summarize the target: %w", err)
+	}
+	var differ []string
+	for i := range want {
+		if got[i] != want[i] {
+			differ = append(differ, fmt.Sprintf("%s (%d rows, expected %d)", want[i].Name, got[i].Rows, want[i].Rows))
+		}
+	}
+	if len(differ) > 0 {
+		return got, fmt.Errorf("%w: %s", ErrMismatch, strings.Join(differ, ", "))
+	}
+	return got, nil
+}
+
+// Summarize returns the tables Copy copies with their row count and a
+// checksum of their rows. Rows are written out independently of the backend:
+// users by Telegram ID instead of internal ID, times in UTC to the second, so
+// both stores of a copy have the same checksums.
+func Summarize(ctx context.Context, s store.Store) ([]Table, error) {
+	users, err := s.ListAllUsers(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list users: %w", err)
+	}
+	telegramIDs := make(map[int64]int64, len(users))
+	var userRows, periodRows []string
+	for _, u := range users {
+		telegramIDs[u.ID] = u.TelegramUserID
+		userRows = append(userRows, fmt.Sprint(u.TelegramUserID, u.Handle, u.FirstName, u.CustomName, u.IsJunior, u.Emoji,
+			u.Pool, u.IsPending, u.Timezone, u.Privacy, u.Alias, u.IsAdmin, u.IsActive, u.VolunteerQueueDays, u.AdminQueueDays,
+			formatDay(u.OffDutyStart), formatDay(u.OffDutyEnd)))
+		periods, err := s.ListOffDutyPeriods(ctx, u.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list the off-duty periods of user %d: %w", u.TelegramUserID, err)
+		}
+		for _, p := range periods {
+			periodRows = append(periodRows, fmt.Sprint(u.TelegramUserID, p.Source, p.ExternalID, p.StartDate.Format("2006-01-02"), p.EndDate.Format("2006-01-02"), p.Summary))
+		}
+	}
+
+	duties, err := s.ListDuties(ctx, store.DutyFilter{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list duties: %w", err)
+	}
+	var dutyRows []string
+	for _, d := range duties {
+		dutyRows = append(dutyRows, fmt.Sprint(d.DutyDate.Format("2006-01-02"), telegramIDs[d.UserID], d.AssignmentType, d.Status,
+			formatTime(&d.CreatedAt), formatTime(d.CompletedAt), telegramIDs[d.CompletionBy], d.Published, formatTime(d.BackfilledAt),
+			formatDay(d.HoldUntil), d.Note, d.HandoffNote, formatTime(d.AnnouncedAt)))
+	}
+
+	values, err := s.ListSettings(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list settings: %w", err)
+	}
+	var settingRows []string
+	for key, value := range values {
+		settingRows = append(settingRows, key+"="+value)
+	}
+
+	return []Table{
+		table("users", userRows),
+		table("off_duty_periods", periodRows),
+		table("duties", dutyRows),
+		table("settings", settingRows),
+	}, nil
+}
+
+// table returns the Table of rows, whose checksum doesn't depend on their
+// order.
+func table(name string, rows []string) Table {
+	sort.Strings(rows)
+	h := sha256.New()
+	for _, row := range rows {
+		h.Write([]byte(row))
+		h.Write([]byte{'\n'})
+	}
+	return Table{Name: name, Rows: len(rows), Checksum: hex.EncodeToString(h.Sum(nil))}
+}
+
+func formatDay(t *time.Time) string {
+	if t == nil {
+		return "-"
+	}
+	return t.Format("2006-01-02")
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return "-"
+	}
+	return t.UTC().Truncate(time.Second).Format(time.RFC3339)
+}
@@ -0,0 +1,127 @@
+package transfer
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/korjavin/dutyassistant/internal/store"
+	"github.com/korjavin/dutyassistant/internal/store/memory"
+	"github.com/korjavin/dutyassistant/internal/store/sqlite"
+)
+
+// seed fills s with two users, Bob off duty and owing a day, three duties
+// and a setting.
+func seed(t *testing.T, s store.Store) {
+	t.Helper()
+	ctx := context.Background()
+	day := func(d int) time.Time { return time.Date(2026, 3, d, 0, 0, 0, 0, time.UTC) }
+	at := time.Date(2026, 3, 2, 20, 15, 0, 0, time.UTC)
+	start, end := day(20), day(25)
+
+	alice := &store.User{TelegramUserID: 100, FirstName: "Alice", IsAdmin: true, IsActive: true, Emoji: "🦊", Pool: store.PoolWeekdays}
+	bob := &store.User{TelegramUserID: 200, FirstName: "Bob", Handle: "bob", IsActive: true, AdminQueueDays: 1,
+		Privacy: store.PrivacyAlias, Alias: "B", OffDutyStart: &start, OffDutyEnd: &end}
+	for _, u := range []*store.User{alice, bob} {
+		if err := s.CreateUser(ctx, u); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.ReplaceOffDutyPeriods(ctx, bob.ID, "ical", []*store.OffDutyPeriod{
+		{UserID: bob.ID, StartDate: day(10), EndDate: day(12), Source: "ical", ExternalID: "uid-1", Summary: "Trip"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	completed := &store.Duty{UserID: alice.ID, DutyDate: day(2), AssignmentType: store.AssignmentTypeRoundRobin, CreatedAt: at, Note: "guests"}
+	if err := s.CreateDuty(ctx, completed); err != nil {
+		t.Fatal(err)
+	}
+	completed.CompletedAt, completed.CompletionBy, completed.Status = &at, alice.ID, store.DutyStatusCompleted
+	if err := s.UpdateDuty(ctx, completed); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.MarkDutyAnnounced(ctx, day(2), at); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.BackfillDuty(ctx, day(1), bob.ID, at); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.CreateDuty(ctx, &store.Duty{UserID: bob.ID, DutyDate: day(3), AssignmentType: store.AssignmentTypeVoluntary, CreatedAt: at}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SetSetting(ctx, "cover", `{"wait":"30m"}`); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCopy(t *testing.T) {
+	ctx := context.Background()
+	from := memory.New()
+	seed(t, from)
+	to, err := sqlite.New(ctx, filepath.Join(t.TempDir(), "roster.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Copy(ctx, from, to); err != nil {
+		t.Fatalf("Copy failed: %v", err)
+	}
+	tables, err := Verify(ctx, from, to)
+	if err != nil {
+		t.Fatalf("Verify failed: %v", err)
+	}
+	rows := map[string]int{}
+	for _, table := range tables {
+		rows[table.Name] = table.Rows
+	}
+	want := map[string]int{"users": 2, "off_duty_periods": 1, "duties": 3, "settings": 1}
+	for name, n := range want {
+		if rows[name] != n {
+			t.Errorf("%s has %d rows, want %d", name, rows[name], n)
+		}
+	}
+
+	bob, err := to.GetUserByTelegramID(ctx, 200)
+	if err != nil || bob.Alias != "B" || bob.AdminQueueDays != 1 || bob.OffDutyEnd == nil {
+		t.Errorf("Bob = %+v, %v, want Bob copied with the alias, queue and off-duty period", bob, err)
+	}
+	d, err := to.GetDutyByDate(ctx, time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC))
+	if err != nil || d == nil || d.UserID != bob.ID || d.BackfilledAt == nil {
+		t.Errorf("Duty on 2026-03-01 = %+v, %v, want Bob's backfilled duty", d, err)
+	}
+}
+
+func TestCopy_NotEmpty(t *testing.T) {
+	ctx := context.Background()
+	from, to := memory.New(), memory.New()
+	seed(t, from)
+	if err := to.CreateUser(ctx, &store.User{TelegramUserID: 300, FirstName: "Carol", IsActive: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Copy(ctx, from, to); !errors.Is(err, ErrNotEmpty) {
+		t.Fatalf("Copy into a store with users: got %v, want ErrNotEmpty", err)
+	}
+	if users, _ := to.ListAllUsers(ctx); len(users) != 1 {
+		t.Errorf("Expected the target to be left as it was, it has %d users", len(users))
+	}
+}
+
+func TestVerify_Mismatch(t *testing.T) {
+	ctx := context.Background()
+	from, to := memory.New(), memory.New()
+	seed(t, from)
+	if err := Copy(ctx, from, to); err != nil {
+		t.Fatal(err)
+	}
+	if err := to.SetSetting(ctx, "cover", `{"wait":"1h"}`); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Verify(ctx, from, to); !errors.Is(err, ErrMismatch) {
+		t.Fatalf("Verify of a changed copy: got %v, want ErrMismatch", err)
+	}
+}
@@ -0,0 +1,131 @@
+// Package trip spots group messages announcing a trip, like "we're away next
+// week", so the bot can offer to set the matching off-duty period. It is a
+// handful of keyword rules in English and German, not language understanding:
+// a message needs a phrase about being away and one about when, and whoever
+// sent it still has to confirm the period.
+package trip
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MaxDays is the longest trip "for N days" or "for N weeks" is taken for.
+const MaxDays = 60
+
+// Period is an off-duty period suggested for a message, both days included.
+type Period struct {
+	Start time.Time
+	End   time.Time
+}
+
+// words compiles a pattern matching one of the phrases as whole words. Unlike
+// \b, it also knows letters like ü as parts of words.
+func words(phrases string) *regexp.Regexp {
+	return regexp.MustCompile(`(?:^|[^\pL\d])(?:` + phrases + `)(?:$|[^\pL\d])`)
+}
+
+// awayPattern matches phrases about not being home. A bare "away" isn't
+// enough, it has to be someone who is, as in "putting the dishes away
+// tomorrow" nobody is.
+var awayPattern = words(`(?:\pL+'(?:m|re|s)|am|are|is|be|go|going) away|on (?:vacation|holiday|a trip)|travell?ing|out of town|not (?:at )?home|abroad|` +
+	`(?:bin|sind|ist|seid|fahren|fahre)(?: [\pL\d]+){0,3} weg|verreist|im urlaub|unterwegs|nicht (?:da|zu hause|daheim)`)
+
+// negatedPattern matches phrases saying the opposite, like "we won't be
+// away".
+var negatedPattern = words(`(?:not|\pL+n't|nicht|kein) (?:be )?(?:away|weg|verreist|travell?ing|unterwegs|on (?:vacation|holiday|a trip)|im urlaub)`)
+
+// forPattern matches durations like "for 3 days" or "für 2 Wochen".
+var forPattern = words(`(?:for|für) (\d{1,2}) (days?|weeks?|tage?n?|wochen?)`)
+
+// whenRule turns a phrase about when into a period starting from today.
+type whenRule struct {
+	pattern *regexp.Regexp
+	period  func(today time.Time) Period
+}
+
+// whenRules are checked in order, so longer phrases come before the phrases
+// they contain.
+var whenRules = []whenRule{
+	{words(`next weekend|nächstes wochenende`), func(today time.Time) Period {
+		saturday := nextWeekday(today, time.Saturday).AddDate(0, 0, 7)
+		return Period{saturday, saturday.AddDate(0, 0, 1)}
+	}},
+	{words(`this weekend|over the weekend|on the weekend|dieses wochenende|am wochenende|übers wochenende`), thisWeekend},
+	{words(`next week|nächste woche|nächster woche`), func(today time.Time) Period {
+		monday := nextWeekday(today, time.Monday)
+		if monday.Equal(today) {
+			monday = monday.AddDate(0, 0, 7)
+		}
+		return Period{monday, monday.AddDate(0, 0, 6)}
+	}},
+	{words(`this week|rest of the week|diese woche|dieser woche`), func(today time.Time) Period {
+		return Period{today, nextWeekday(today, time.Sunday)}
+	}},
+	{words(`day after tomorrow|übermorgen`), func(today time.Time) Period {
+		day := today.AddDate(0, 0, 2)
+		return Period{day, day}
+	}},
+	{words(`tomorrow|morgen`), func(today time.Time) Period {
+		day := today.AddDate(0, 0, 1)
+		return Period{day, day}
+	}},
+	{words(`today|tonight|heute`), func(today time.Time) Period {
+		return Period{today, today}
+	}},
+}
+
+// Detect returns the off-duty period the text announces, counted from today,
+// or false if it doesn't look like a trip announcement.
+func Detect(text string, today time.Time) (Period, bool) {
+	text = strings.ToLower(strings.Join(strings.Fields(text), " "))
+	text = strings.ReplaceAll(text, "’", "'")
+	if !awayPattern.MatchString(text) || negatedPattern.MatchString(text) {
+		return Period{}, false
+	}
+	today = time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, time.UTC)
+
+	var start time.Time
+	var period Period
+	found := false
+	for _, rule := range whenRules {
+		if rule.pattern.MatchString(text) {
+			period, found = rule.period(today), true
+			start = period.Start
+			break
+		}
+	}
+	// "for N days" stretches the period from its start, or from today
+	if m := forPattern.FindStringSubmatch(text); m != nil {
+		n, _ := strconv.Atoi(m[1])
+		if strings.HasPrefix(m[2], "w") {
+			n *= 7
+		}
+		if n < 1 || n > MaxDays {
+			return Period{}, false
+		}
+		if !found {
+			start = today
+		}
+		period, found = Period{start, start.AddDate(0, 0, n-1)}, true
+	}
+	return period, found
+}
+
+// thisWeekend returns the coming Saturday and Sunday, or what is left of the
+// weekend if it is one.
+func thisWeekend(today time.Time) Period {
+	if today.Weekday() == time.Sunday {
+		return Period{today, today}
+	}
+	saturday := nextWeekday(today, time.Saturday)
+	return Period{saturday, saturday.AddDate(0, 0, 1)}
+}
+
+// nextWeekday returns the first day from today on, today included, that is
+// the weekday.
+func nextWeekday(today time.Time, weekday time.Weekday) time.Time {
+	return today.AddDate(0, 0, (int(weekday)-int(today.Weekday())+7)%7)
+}
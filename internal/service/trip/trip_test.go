@@ -0,0 +1,61 @@
+package trip
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDetect(t *testing.T) {
+	// A Wednesday
+	today := time.Date(2025, 11, 5, 15, 30, 0, 0, time.UTC)
+	day := func(d int) time.Time { return time.Date(2025, 11, d, 0, 0, 0, 0, time.UTC) }
+
+	tests := []struct {
+		text       string
+		start, end int // Days of November 2025, 0 if nothing is detected
+	}{
+		{"We're away next week", 10, 16},
+		{"we’re AWAY   next week!", 10, 16},
+		{"I'm on vacation this weekend", 8, 9},
+		{"I'm away next weekend", 15, 16},
+		{"Travelling tomorrow, back on Friday", 6, 6},
+		{"out of town the day after tomorrow", 7, 7},
+		{"Not home tonight", 5, 5},
+		{"I'm away for 3 days", 5, 7},
+		{"We're away from tomorrow for 2 weeks", 6, 19},
+		{"Wir sind nächste Woche weg", 10, 16},
+		{"Ich bin übermorgen nicht da", 7, 7},
+		{"Wir sind übers Wochenende verreist", 8, 9},
+		{"bin für 4 Tage unterwegs", 5, 8},
+
+		{"We're away", 0, 0},                                      // not when
+		{"Next week is busy", 0, 0},                               // not away
+		{"Put the dishes away tomorrow", 0, 0},                    // nobody is away
+		{"We won't be away next week after all", 0, 0},            // the opposite
+		{"Ich bringe morgen den Müll weg", 0, 0},                  // nobody is away
+		{"I'm away for 90 days", 0, 0},                            // too long to guess
+		{"The plumber is coming, we're home tomorrow", 0, 0},      // at home
+		{"Morgen ist Papiertonne, wir sind nicht verreist", 0, 0}, // the opposite
+	}
+	for _, tt := range tests {
+		period, ok := Detect(tt.text, today)
+		if tt.start == 0 {
+			if ok {
+				t.Errorf("Detect(%q) = %v, want nothing", tt.text, period)
+			}
+			continue
+		}
+		if !ok || !period.Start.Equal(day(tt.start)) || !period.End.Equal(day(tt.end)) {
+			t.Errorf("Detect(%q) = %v, %v, want Nov %d to %d", tt.text, period, ok, tt.start, tt.end)
+		}
+	}
+
+	// This weekend on a Sunday is what is left of it
+	if period, ok := Detect("I'm away this weekend", day(9)); !ok || !period.Start.Equal(day(9)) || !period.End.Equal(day(9)) {
+		t.Errorf("Detect on a Sunday = %v, %v, want Nov 9 only", period, ok)
+	}
+	// Next week on a Monday is the week after
+	if period, ok := Detect("I'm away next week", day(10)); !ok || !period.Start.Equal(day(17)) {
+		t.Errorf("Detect on a Monday = %v, %v, want it to start on Nov 17", period, ok)
+	}
+}
@@ -0,0 +1,324 @@
+// Package user holds the rules for looking up, registering and updating
+// household members. The Telegram bot and the HTTP API both go through it so
+// they treat users the same way.
+package user
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/korjavin/dutyassistant/internal/store"
+)
+
+var (
+	// ErrNotFound is returned when no user matches a lookup.
+	ErrNotFound = errors.New("user not found")
+	// ErrSameUser is returned when a user is merged into themselves.
+	ErrSameUser = errors.New("cannot merge a user into themselves")
+	// ErrInvalidEmoji is returned when a personal emoji isn't a single emoji.
+	ErrInvalidEmoji = errors.New("not a single emoji")
+	// ErrEmojiTaken is returned when another user already picked the emoji.
+	ErrEmojiTaken = errors.New("emoji already taken")
+	// ErrUnknownTimezone is returned for a timezone that isn't an IANA zone
+	// name like Europe/London.
+	ErrUnknownTimezone = errors.New("unknown timezone")
+	// ErrInvalidAlias is returned for an alias that is empty or too long.
+	ErrInvalidAlias = errors.New("alias must be 1 to 32 characters")
+	// ErrUnknownPool is returned for a pool other than all, weekdays or weekends.
+	ErrUnknownPool = errors.New("unknown pool, expected all, weekdays or weekends")
+	// ErrPending is returned when activating a user who still waits for an
+	// admin to approve their registration.
+	ErrPending = errors.New("user is waiting for approval")
+	// ErrNotPending is returned when approving or rejecting a user who isn't
+	// waiting for approval.
+	ErrNotPending = errors.New("user is not waiting for approval")
+)
+
+// Service looks up and updates users.
+type Service struct {
+	store store.UserStore
+}
+
+// New creates a new Service backed by the given store.
+func New(s store.UserStore) *Service {
+	return &Service{store: s}
+}
+
+// ByID returns the user with the given internal ID, or ErrNotFound.
+func (s *Service) ByID(ctx context.Context, id int64) (*store.User, error) {
+	users, err := s.store.ListAllUsers(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list users: %w", err)
+	}
+	for _, u := range users {
+		if u.ID == id {
+			return u, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+// ByTelegramID returns the user with the given Telegram ID, or ErrNotFound.
+func (s *Service) ByTelegramID(ctx context.Context, telegramID int64) (*store.User, error) {
+	u, err := s.store.GetUserByTelegramID(ctx, telegramID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	if u == nil {
+		return nil, ErrNotFound
+	}
+	return u, nil
+}
+
+// ByName returns the user with the given handle, or failing that display
+// name, or ErrNotFound.
+func (s *Service) ByName(ctx context.Context, name string) (*store.User, error) {
+	u, err := s.store.GetUserByName(ctx, name)
+	if err != nil || u == nil {
+		// The stores don't tell "not found" apart from other errors here
+		return nil, ErrNotFound
+	}
+	return u, nil
+}
+
+// Find returns the user referred to as "#<id>" by internal ID, or otherwise by
+// first name, for when two users share a name. It returns ErrNotFound if none match.
+func (s *Service) Find(ctx context.Context, ref string) (*store.User, error) {
+	if rest, ok := strings.CutPrefix(ref, "#"); ok {
+		id, err := strconv.ParseInt(rest, 10, 64)
+		if err != nil {
+			return nil, ErrNotFound
+		}
+		return s.ByID(ctx, id)
+	}
+	return s.ByName(ctx, ref)
+}
+
+// Merge folds the user fromID into toID, for someone who ended up with two
+// accounts. Duties, queue days and statistics move to toID, fromID is deleted
+// and the merge is recorded for auditing.
+func (s *Service) Merge(ctx context.Context, fromID, toID int64) (*store.UserMerge, error) {
+	if fromID == toID {
+		return nil, ErrSameUser
+	}
+	for _, id := range []int64{fromID, toID} {
+		if _, err := s.ByID(ctx, id); err != nil {
+			return nil, err
+		}
+	}
+	m, err := s.store.MergeUsers(ctx, fromID, toID, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("failed to merge users: %w", err)
+	}
+	return m, nil
+}
+
+// Register creates the user on their first contact, or updates their name if
+// it changed since and no admin renamed them. The admin starts out inactive so
+// they aren't put on duty. A pending user starts out inactive and pending until
+// an admin approves or rejects them; admins are never pending.
+func (s *Service) Register(ctx context.Context, telegramID int64, firstName string, isAdmin, pending bool) (*store.User, error) {
+	u, err := s.store.GetUserByTelegramID(ctx, telegramID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	if u == nil {
+		u = &store.User{
+			TelegramUserID: telegramID,
+			FirstName:      firstName,
+			IsActive:       !isAdmin && !pending,
+			IsAdmin:        isAdmin,
+			IsPending:      pending && !isAdmin,
+		}
+		if err := s.store.CreateUser(ctx, u); err != nil {
+			return nil, fmt.Errorf("failed to create user: %w", err)
+		}
+		return u, nil
+	}
+
+	if u.FirstName != firstName && !u.CustomName {
+		u.FirstName = firstName
+		if err := s.store.UpdateUser(ctx, u); err != nil {
+			return nil, fmt.Errorf("failed to update user: %w", err)
+		}
+	}
+	return u, nil
+}
+
+// Rename sets the display name of a user. It sticks even if their Telegram
+// name changes; the handle stays the same.
+func (s *Service) Rename(ctx context.Context, u *store.User, name string) error {
+	oldName, oldCustom := u.FirstName, u.CustomName
+	u.FirstName, u.CustomName = name, true
+	if err := s.store.UpdateUser(ctx, u); err != nil {
+		u.FirstName, u.CustomName = oldName, oldCustom
+		return fmt.Errorf("failed to update user: %w", err)
+	}
+	return nil
+}
+
+// Approve lets a pending user join the rotation.
+func (s *Service) Approve(ctx context.Context, u *store.User) error {
+	if !u.IsPending {
+		return ErrNotPending
+	}
+	u.IsPending, u.IsActive = false, true
+	if err := s.store.UpdateUser(ctx, u); err != nil {
+		u.IsPending, u.IsActive = true, false
+		return fmt.Errorf("failed to update user: %w", err)
+	}
+	return nil
+}
+
+// Reject deletes a pending user, so they can ask to join again with /start.
+func (s *Service) Reject(ctx context.Context, u *store.User) error {
+	if !u.IsPending {
+		return ErrNotPending
+	}
+	if err := s.store.DeleteUser(ctx, u.ID); err != nil {
+		return fmt.Errorf("failed to delete user: %w", err)
+	}
+	return nil
+}
+
+// ToggleActive switches whether a user takes part in the rotation. Pending
+// users are activated by approving them instead.
+func (s *Service) ToggleActive(ctx context.Context, u *store.User) error {
+	if u.IsPending {
+		return ErrPending
+	}
+	u.IsActive = !u.IsActive
+	if err := s.store.UpdateUser(ctx, u); err != nil {
+		u.IsActive = !u.IsActive
+		return fmt.Errorf("failed to update user: %w", err)
+	}
+	return nil
+}
+
+// ToggleJunior switches whether a user is limited to the kid-friendly
+// commands and web view.
+func (s *Service) ToggleJunior(ctx context.Context, u *store.User) error {
+	u.IsJunior = !u.IsJunior
+	if err := s.store.UpdateUser(ctx, u); err != nil {
+		u.IsJunior = !u.IsJunior
+		return fmt.Errorf("failed to update user: %w", err)
+	}
+	return nil
+}
+
+// ParsePool returns the pool with the given name: all, weekdays or weekends.
+func ParsePool(name string) (store.Pool, error) {
+	switch pool := store.Pool(strings.ToLower(name)); pool {
+	case "all":
+		return store.PoolAll, nil
+	case store.PoolWeekdays, store.PoolWeekends:
+		return pool, nil
+	default:
+		return "", ErrUnknownPool
+	}
+}
+
+// SetPool sets which days of the week a user is on the roster for.
+func (s *Service) SetPool(ctx context.Context, u *store.User, pool store.Pool) error {
+	old := u.Pool
+	u.Pool = pool
+	if err := s.store.UpdateUser(ctx, u); err != nil {
+		u.Pool = old
+		return fmt.Errorf("failed to update user: %w", err)
+	}
+	return nil
+}
+
+// SetEmoji sets the personal emoji that marks a user in calendars and
+// announcements, or clears it if emoji is empty. Two users can't share one,
+// or the calendar couldn't tell them apart.
+func (s *Service) SetEmoji(ctx context.Context, u *store.User, emoji string) error {
+	if emoji != "" {
+		if !IsEmoji(emoji) {
+			return ErrInvalidEmoji
+		}
+		users, err := s.store.ListAllUsers(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to list users: %w", err)
+		}
+		for _, other := range users {
+			if other.ID != u.ID && other.Emoji == emoji {
+				return ErrEmojiTaken
+			}
+		}
+	}
+
+	old := u.Emoji
+	u.Emoji = emoji
+	if err := s.store.UpdateUser(ctx, u); err != nil {
+		u.Emoji = old
+		return fmt.Errorf("failed to update user: %w", err)
+	}
+	return nil
+}
+
+// SetTimezone sets the IANA timezone, e.g. America/New_York, the user's
+// personal reminders follow while they're abroad, or clears it if name is
+// empty so they follow the household's again.
+func (s *Service) SetTimezone(ctx context.Context, u *store.User, name string) error {
+	if name != "" {
+		loc, err := time.LoadLocation(name)
+		if err != nil || name == "Local" {
+			return ErrUnknownTimezone
+		}
+		name = loc.String()
+	}
+
+	old := u.Timezone
+	u.Timezone = name
+	if err := s.store.UpdateUser(ctx, u); err != nil {
+		u.Timezone = old
+		return fmt.Errorf("failed to update user: %w", err)
+	}
+	return nil
+}
+
+// MaxAliasLength is how many characters an alias has at most.
+const MaxAliasLength = 32
+
+// SetPrivacy sets how messages everyone in the group can see name the user:
+// by their name, its initials or alias, which is only kept for
+// store.PrivacyAlias.
+func (s *Service) SetPrivacy(ctx context.Context, u *store.User, privacy store.Privacy, alias string) error {
+	alias = strings.TrimSpace(alias)
+	if privacy != store.PrivacyAlias {
+		alias = ""
+	} else if n := len([]rune(alias)); n == 0 || n > MaxAliasLength {
+		return ErrInvalidAlias
+	}
+
+	oldPrivacy, oldAlias := u.Privacy, u.Alias
+	u.Privacy, u.Alias = privacy, alias
+	if err := s.store.UpdateUser(ctx, u); err != nil {
+		u.Privacy, u.Alias = oldPrivacy, oldAlias
+		return fmt.Errorf("failed to update user: %w", err)
+	}
+	return nil
+}
+
+// IsEmoji reports whether s looks like a single emoji: a few code points, none
+// of them ASCII, letters or spaces. Sequences joined with zero-width joiners,
+// skin tones and flags are a few code points long.
+func IsEmoji(s string) bool {
+	runes := []rune(s)
+	if len(runes) == 0 || len(runes) > 8 {
+		return false
+	}
+	for _, r := range runes {
+		if r < 0x80 || unicode.IsLetter(r) || unicode.IsSpace(r) {
+			return false
+		}
+	}
+	return true
+}
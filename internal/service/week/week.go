@@ -0,0 +1,89 @@
+// Package week builds the Monday-to-Sunday overview of the duty calendar that
+// /week, the Sunday report and the HTTP API show.
+package week
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/korjavin/dutyassistant/internal/store"
+)
+
+// Day is one day of a week.
+type Day struct {
+	Date time.Time
+	Duty *store.Duty    // nil if nobody is on duty
+	Skip *store.SkipDay // Set if the day is deliberately without duty
+	Bins []string       // Bins the waste-collection calendar collects that day
+}
+
+// Week is the duty calendar from Monday to Sunday.
+type Week struct {
+	Start time.Time // Monday
+	Days  [7]Day
+}
+
+// Start returns the Monday of t's week.
+func Start(t time.Time) time.Time {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	offset := (int(day.Weekday()) + 6) % 7 // Monday is 0
+	return day.AddDate(0, 0, -offset)
+}
+
+// Load returns the week containing t.
+func Load(ctx context.Context, s store.DutyStore, t time.Time) (*Week, error) {
+	w := &Week{Start: Start(t)}
+	for i := range w.Days {
+		w.Days[i].Date = w.Start.AddDate(0, 0, i)
+	}
+
+	// A week spans at most two months
+	end := w.Days[6].Date
+	months := []time.Time{w.Start}
+	if end.Month() != w.Start.Month() {
+		months = append(months, end)
+	}
+	for _, m := range months {
+		duties, err := s.GetDutiesByMonth(ctx, m.Year(), m.Month())
+		if err != nil {
+			return nil, fmt.Errorf("failed to get duties: %w", err)
+		}
+		for _, d := range duties {
+			if i := w.index(d.DutyDate); i >= 0 {
+				w.Days[i].Duty = d
+			}
+		}
+
+		skipDays, err := s.GetSkipDaysByMonth(ctx, m.Year(), m.Month())
+		if err != nil {
+			return nil, fmt.Errorf("failed to get skip days: %w", err)
+		}
+		for _, skip := range skipDays {
+			if i := w.index(skip.Date); i >= 0 {
+				w.Days[i].Skip = skip
+			}
+		}
+	}
+
+	collections, err := s.GetWasteCollections(ctx, w.Start, w.Start.AddDate(0, 0, len(w.Days)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get waste collections: %w", err)
+	}
+	for _, c := range collections {
+		if i := w.index(c.Date); i >= 0 {
+			w.Days[i].Bins = append(w.Days[i].Bins, c.Bin)
+		}
+	}
+	return w, nil
+}
+
+// index returns the position of date in the week, or -1 if it's outside.
+func (w *Week) index(date time.Time) int {
+	day := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+	i := int(day.Sub(w.Start).Hours() / 24)
+	if i < 0 || i >= len(w.Days) {
+		return -1
+	}
+	return i
+}
@@ -0,0 +1,65 @@
+package week
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/korjavin/dutyassistant/internal/store"
+	"github.com/korjavin/dutyassistant/internal/store/memory"
+)
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func TestStart(t *testing.T) {
+	tests := []struct {
+		in, want time.Time
+	}{
+		{date(2025, time.October, 27), date(2025, time.October, 27)}, // Monday
+		{date(2025, time.October, 29), date(2025, time.October, 27)},
+		{date(2025, time.November, 2), date(2025, time.October, 27)}, // Sunday
+		{time.Date(2025, time.October, 29, 23, 30, 0, 0, time.UTC), date(2025, time.October, 27)},
+	}
+	for _, tt := range tests {
+		if got := Start(tt.in); !got.Equal(tt.want) {
+			t.Errorf("Start(%v) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestLoad_SpansTwoMonths(t *testing.T) {
+	ctx := context.Background()
+	s := memory.New()
+	alice := &store.User{TelegramUserID: 1, FirstName: "Alice", IsActive: true}
+	s.CreateUser(ctx, alice)
+	for _, d := range []time.Time{date(2025, time.October, 26), date(2025, time.October, 31), date(2025, time.November, 1)} {
+		s.CreateDuty(ctx, &store.Duty{UserID: alice.ID, DutyDate: d, AssignmentType: store.AssignmentTypeRoundRobin})
+	}
+	s.SetSkipDay(ctx, &store.SkipDay{Date: date(2025, time.November, 2), Reason: store.SkipReasonHoliday})
+	s.ReplaceWasteCollections(ctx, []*store.WasteCollection{
+		{Date: date(2025, time.October, 26), Bin: "Paper"},
+		{Date: date(2025, time.November, 1), Bin: "Bio"},
+	})
+
+	w, err := Load(ctx, s, date(2025, time.October, 29))
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if !w.Start.Equal(date(2025, time.October, 27)) {
+		t.Errorf("Expected the week to start on Oct 27, got %v", w.Start)
+	}
+	for i, day := range w.Days {
+		wantDuty := i == 4 || i == 5 // Friday Oct 31 and Saturday Nov 1; Sunday Oct 26 is the week before
+		if (day.Duty != nil) != wantDuty {
+			t.Errorf("Day %d (%v): expected duty %v, got %+v", i, day.Date, wantDuty, day.Duty)
+		}
+		if (day.Skip != nil) != (i == 6) {
+			t.Errorf("Day %d (%v): unexpected skip %+v", i, day.Date, day.Skip)
+		}
+		if wantBins := i == 5; (len(day.Bins) == 1 && day.Bins[0] == "Bio") != wantBins || (!wantBins && len(day.Bins) != 0) {
+			t.Errorf("Day %d (%v): unexpected bins %q", i, day.Date, day.Bins)
+		}
+	}
+}
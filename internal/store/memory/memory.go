@@ -0,0 +1,2366 @@
+// Package memory provides a thread-safe, in-memory implementation of
+// store.Store. It is meant for demos, tests and the --ephemeral run mode:
+// nothing is persisted, and results are returned in a deterministic order
+// that mirrors the SQLite store.
+package memory
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/korjavin/dutyassistant/internal/store"
+)
+
+const dateLayout = "2006-01-02"
+
+// Store is an in-memory implementation of the store.Store interface.
+// All returned values are copies, so callers may modify them freely.
+type Store struct {
+	mu sync.RWMutex
+	data
+}
+
+// data is everything the store holds, copied as a whole to roll back a
+// transaction.
+type data struct {
+	users         map[int64]*store.User
+	duties        map[string]*store.Duty // Keyed by duty date (YYYY-MM-DD)
+	changes       []*store.DutyChange
+	periods       []*store.OffDutyPeriod
+	calendarLinks map[int64]*store.CalendarLink
+	preferences   map[int64]*store.NotificationPreferences
+	subscriptions map[int64]*store.ChangeSubscription
+	snoozes       map[int64]*store.ReminderSnooze
+	skipDays      map[string]*store.SkipDay // Keyed by date (YYYY-MM-DD)
+	pending       map[int64]*store.PendingMessage
+	interactive   map[messageKey]*store.InteractiveMessage
+	states        map[string]*store.ConversationState
+	locales       map[int64]string // Keyed by chat ID
+	displays      map[int64]store.ChatDisplay
+	settings      map[string]string
+	comparisons   []*store.ShadowComparison
+	explanations  map[string]*store.AssignmentExplanation // Keyed by date (YYYY-MM-DD)
+	versions      []*store.ScheduleVersion
+	templates     []*store.NoteTemplate
+	blackouts     []*store.BlackoutRule
+	checklist     []*store.ChecklistItem
+	checks        []*store.ChecklistCheck
+	tasks         []*store.Task
+	covers        []*store.CoverRequest
+	waste         []*store.WasteCollection
+	rotations     map[string]map[int64]*store.RoundRobinState // Keyed by rotation, then user ID
+	merges        []*store.UserMerge
+	runs          []*store.MaintenanceRun
+	badges        []*store.Badge
+	ledger        []*store.LedgerEntry
+	queueEvents   []*store.QueueEvent
+	loginCodes    map[string]*store.LoginCode  // Keyed by code hash
+	invites       map[string]*store.Invite     // Keyed by token hash
+	sessions      map[string]*store.WebSession // Keyed by token hash
+
+	nextUserID    int64
+	nextDutyID    int64
+	nextChangeID  int64
+	nextPeriodID  int64
+	nextSnoozeID  int64
+	nextPendingID int64
+	nextShadowID  int64
+	nextNoteID    int64
+	nextRuleID    int64
+	nextMergeID   int64
+	nextItemID    int64
+	nextBadgeID   int64
+	nextEntryID   int64
+	nextQueueID   int64
+	nextVersionID int64
+	nextTaskID    int64
+	nextCoverID   int64
+	nextRunID     int64
+}
+
+// messageKey identifies a Telegram message.
+type messageKey struct {
+	chatID    int64
+	messageID int
+}
+
+// Verify that Store implements store.Store
+var _ store.Store = (*Store)(nil)
+
+// New creates an empty in-memory store.
+func New() *Store {
+	return &Store{data: data{
+		users:         make(map[int64]*store.User),
+		duties:        make(map[string]*store.Duty),
+		calendarLinks: make(map[int64]*store.CalendarLink),
+		preferences:   make(map[int64]*store.NotificationPreferences),
+		subscriptions: make(map[int64]*store.ChangeSubscription),
+		snoozes:       make(map[int64]*store.ReminderSnooze),
+		skipDays:      make(map[string]*store.SkipDay),
+		explanations:  make(map[string]*store.AssignmentExplanation),
+		pending:       make(map[int64]*store.PendingMessage),
+		interactive:   make(map[messageKey]*store.InteractiveMessage),
+		states:        make(map[string]*store.ConversationState),
+		locales:       make(map[int64]string),
+		displays:      make(map[int64]store.ChatDisplay),
+		settings:      make(map[string]string),
+		rotations:     make(map[string]map[int64]*store.RoundRobinState),
+		loginCodes:    make(map[string]*store.LoginCode),
+		invites:       make(map[string]*store.Invite),
+		sessions:      make(map[string]*store.WebSession),
+	}}
+}
+
+// RunInTx calls fn with the store and puts back everything it held before if
+// fn returns an error. Unlike a database transaction it doesn't isolate fn
+// from concurrent changes, which are rolled back too.
+func (s *Store) RunInTx(ctx context.Context, fn func(tx store.Store) error) error {
+	s.mu.RLock()
+	saved := s.data.clone()
+	s.mu.RUnlock()
+
+	if err := fn(s); err != nil {
+		s.mu.Lock()
+		s.data = saved
+		s.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// clone returns a copy of d whose records can be changed without affecting d.
+func (d data) clone() data {
+	c := d
+	c.users = cloneMap(d.users)
+	c.duties = cloneMap(d.duties)
+	c.changes = cloneSlice(d.changes)
+	c.periods = cloneSlice(d.periods)
+	c.calendarLinks = cloneMap(d.calendarLinks)
+	c.preferences = cloneMap(d.preferences)
+	c.subscriptions = cloneMap(d.subscriptions)
+	c.snoozes = cloneMap(d.snoozes)
+	c.skipDays = cloneMap(d.skipDays)
+	c.pending = cloneMap(d.pending)
+	c.interactive = cloneMap(d.interactive)
+	c.states = cloneMap(d.states)
+	c.locales = maps.Clone(d.locales)
+	c.displays = maps.Clone(d.displays)
+	c.settings = maps.Clone(d.settings)
+	c.comparisons = cloneSlice(d.comparisons)
+	c.runs = cloneSlice(d.runs)
+	c.explanations = cloneMap(d.explanations)
+	c.versions = cloneSlice(d.versions)
+	c.templates = cloneSlice(d.templates)
+	c.blackouts = cloneSlice(d.blackouts)
+	c.checklist = cloneSlice(d.checklist)
+	c.checks = cloneSlice(d.checks)
+	c.tasks = cloneSlice(d.tasks)
+	c.covers = cloneSlice(d.covers)
+	c.waste = cloneSlice(d.waste)
+	c.merges = cloneSlice(d.merges)
+	c.badges = cloneSlice(d.badges)
+	c.ledger = cloneSlice(d.ledger)
+	c.queueEvents = cloneSlice(d.queueEvents)
+	c.loginCodes = cloneMap(d.loginCodes)
+	c.invites = cloneMap(d.invites)
+	c.sessions = cloneMap(d.sessions)
+	c.rotations = make(map[string]map[int64]*store.RoundRobinState, len(d.rotations))
+	for rotation, states := range d.rotations {
+		c.rotations[rotation] = cloneMap(states)
+	}
+	return c
+}
+
+func cloneMap[K comparable, V any](m map[K]*V) map[K]*V {
+	c := make(map[K]*V, len(m))
+	for k, v := range m {
+		cp := *v
+		c[k] = &cp
+	}
+	return c
+}
+
+func cloneSlice[V any](s []*V) []*V {
+	c := make([]*V, 0, len(s))
+	for _, v := range s {
+		cp := *v
+		c = append(c, &cp)
+	}
+	return c
+}
+
+// dateKey normalizes a date to the key used for duties and date comparisons.
+func dateKey(t time.Time) string {
+	return t.Format(dateLayout)
+}
+
+// copyUser returns a deep copy of a user.
+func copyUser(u *store.User) *store.User {
+	c := *u
+	if u.OffDutyStart != nil {
+		t := *u.OffDutyStart
+		c.OffDutyStart = &t
+	}
+	if u.OffDutyEnd != nil {
+		t := *u.OffDutyEnd
+		c.OffDutyEnd = &t
+	}
+	return &c
+}
+
+// copyDuty returns a copy of a duty with its user joined, like the SQL queries do.
+func (s *Store) copyDuty(d *store.Duty) *store.Duty {
+	c := *d
+	if d.CompletedAt != nil {
+		t := *d.CompletedAt
+		c.CompletedAt = &t
+	}
+	if d.BackfilledAt != nil {
+		t := *d.BackfilledAt
+		c.BackfilledAt = &t
+	}
+	if d.HoldUntil != nil {
+		t := *d.HoldUntil
+		c.HoldUntil = &t
+	}
+	if d.AnnouncedAt != nil {
+		t := *d.AnnouncedAt
+		c.AnnouncedAt = &t
+	}
+	c.User = nil
+	if u, ok := s.users[d.UserID]; ok {
+		c.User = copyUser(u)
+	}
+	return &c
+}
+
+// sortedUsers returns copies of the users matching keep, ordered by ID.
+func (s *Store) sortedUsers(keep func(*store.User) bool) []*store.User {
+	var users []*store.User
+	for _, u := range s.users {
+		if keep(u) {
+			users = append(users, copyUser(u))
+		}
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].ID < users[j].ID })
+	return users
+}
+
+// findUser returns a copy of the first user (by ID) matching match, or nil.
+func (s *Store) findUser(match func(*store.User) bool) *store.User {
+	users := s.sortedUsers(match)
+	if len(users) == 0 {
+		return nil
+	}
+	return users[0]
+}
+
+// sortedDuties returns copies of the duties matching keep, ordered by date.
+func (s *Store) sortedDuties(keep func(*store.Duty) bool) []*store.Duty {
+	var duties []*store.Duty
+	for _, d := range s.duties {
+		if keep(d) {
+			duties = append(duties, s.copyDuty(d))
+		}
+	}
+	sort.Slice(duties, func(i, j int) bool { return duties[i].DutyDate.Before(duties[j].DutyDate) })
+	return duties
+}
+
+// GetUserByTelegramID retrieves a user by their Telegram ID. Returns nil if not found.
+func (s *Store) GetUserByTelegramID(ctx context.Context, id int64) (*store.User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.findUser(func(u *store.User) bool { return u.TelegramUserID == id }), nil
+}
+
+// GetUserByName retrieves a user by their handle, or failing that by their
+// display name. Returns nil if not found.
+func (s *Store) GetUserByName(ctx context.Context, name string) (*store.User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	handle := strings.ToLower(name)
+	if u := s.findUser(func(u *store.User) bool { return u.Handle == handle }); u != nil {
+		return u, nil
+	}
+	return s.findUser(func(u *store.User) bool { return u.FirstName == name }), nil
+}
+
+// ListActiveUsers retrieves all users who are currently active, ordered by ID.
+func (s *Store) ListActiveUsers(ctx context.Context) ([]*store.User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.sortedUsers(func(u *store.User) bool { return u.IsActive }), nil
+}
+
+// ListAllUsers retrieves all users, ordered by first name.
+func (s *Store) ListAllUsers(ctx context.Context) ([]*store.User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	users := s.sortedUsers(func(*store.User) bool { return true })
+	sort.SliceStable(users, func(i, j int) bool { return users[i].FirstName < users[j].FirstName })
+	return users, nil
+}
+
+// CreateUser adds a new user. The Telegram ID must be unique.
+func (s *Store) CreateUser(ctx context.Context, user *store.User) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, u := range s.users {
+		if u.TelegramUserID == user.TelegramUserID {
+			return fmt.Errorf("could not insert user: telegram user %d already exists", user.TelegramUserID)
+		}
+	}
+	if user.Handle == "" {
+		user.Handle = store.UniqueHandle(user.FirstName, func(handle string) bool {
+			return s.findUser(func(u *store.User) bool { return u.Handle == handle }) != nil
+		})
+	}
+	s.nextUserID++
+	user.ID = s.nextUserID
+	s.users[user.ID] = copyUser(user)
+	return nil
+}
+
+// UpdateUser updates a user's details.
+func (s *Store) UpdateUser(ctx context.Context, user *store.User) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, ok := s.users[user.ID]
+	if !ok {
+		return nil // Like an UPDATE matching no rows
+	}
+	updated := copyUser(user)
+	updated.TelegramUserID = existing.TelegramUserID
+	updated.Handle = existing.Handle
+	s.users[user.ID] = updated
+	return nil
+}
+
+// DeleteUser deletes a user along with what only they own, as the foreign
+// keys of the SQLite store cascade. Users who have duties are kept.
+func (s *Store) DeleteUser(ctx context.Context, id int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.users[id]; !ok {
+		return nil // Like a DELETE matching no rows
+	}
+	for _, d := range s.duties {
+		if d.UserID == id {
+			return fmt.Errorf("could not delete user: user %d has duties", id)
+		}
+	}
+	var periods []*store.OffDutyPeriod
+	for _, p := range s.periods {
+		if p.UserID != id {
+			periods = append(periods, p)
+		}
+	}
+	s.periods = periods
+	var badges []*store.Badge
+	for _, b := range s.badges {
+		if b.UserID != id {
+			badges = append(badges, b)
+		}
+	}
+	s.badges = badges
+	var ledger []*store.LedgerEntry
+	for _, e := range s.ledger {
+		if e.UserID != id {
+			ledger = append(ledger, e)
+		}
+	}
+	s.ledger = ledger
+	var queueEvents []*store.QueueEvent
+	for _, e := range s.queueEvents {
+		if e.UserID != id {
+			queueEvents = append(queueEvents, e)
+		}
+	}
+	s.queueEvents = queueEvents
+	var covers []*store.CoverRequest
+	for _, r := range s.covers {
+		if r.UserID != id && (r.CoveredBy == nil || *r.CoveredBy != id) {
+			covers = append(covers, r)
+		}
+	}
+	s.covers = covers
+	for _, t := range s.tasks {
+		if t.ClaimedBy != nil && *t.ClaimedBy == id {
+			t.ClaimedBy = nil
+		}
+	}
+	maps.DeleteFunc(s.snoozes, func(_ int64, sn *store.ReminderSnooze) bool { return sn.UserID == id })
+	maps.DeleteFunc(s.loginCodes, func(_ string, c *store.LoginCode) bool { return c.UserID == id })
+	maps.DeleteFunc(s.sessions, func(_ string, ws *store.WebSession) bool { return ws.UserID == id })
+	for _, states := range s.rotations {
+		delete(states, id)
+	}
+	delete(s.calendarLinks, id)
+	delete(s.preferences, id)
+	delete(s.subscriptions, id)
+	delete(s.users, id)
+	return nil
+}
+
+// GetUserStats retrieves aggregated statistics for a user.
+func (s *Store) GetUserStats(ctx context.Context, userID int64) (*store.UserStats, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	now := time.Now()
+	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	monthEnd := monthStart.AddDate(0, 1, 0)
+	today := dateKey(now)
+
+	stats := &store.UserStats{}
+	u := s.users[userID]
+	for _, d := range s.sortedDuties(func(d *store.Duty) bool { return d.UserID == userID }) {
+		stats.TotalDuties++
+		if !d.DutyDate.Before(monthStart) && d.DutyDate.Before(monthEnd) {
+			stats.DutiesThisMonth++
+		}
+		if stats.NextDutyDate == "" && dateKey(d.DutyDate) >= today {
+			stats.NextDutyDate = dateKey(d.DutyDate)
+		}
+		switch d.Status {
+		case store.DutyStatusCompleted:
+			stats.Completed++
+			stats.CurrentStreak++
+		case store.DutyStatusMissed:
+			// Missed while off duty, the streak and rate are frozen
+			if u != nil && s.isOffDuty(u, dateKey(d.DutyDate)) {
+				break
+			}
+			stats.Missed++
+			stats.CurrentStreak = 0
+		}
+		if d.AssignmentType == store.AssignmentTypeVoluntary {
+			stats.Volunteered++
+		}
+	}
+
+	for _, t := range s.tasks {
+		if t.ClaimedBy != nil && *t.ClaimedBy == userID && t.DoneAt != nil {
+			stats.TasksDone++
+			stats.TaskPoints += t.Weight
+		}
+	}
+
+	householdCompleted, activeUsers := 0, 0
+	for _, u := range s.users {
+		if u.IsActive {
+			activeUsers++
+		}
+	}
+	for _, d := range s.duties {
+		if u := s.users[d.UserID]; u != nil && u.IsActive && d.Status == store.DutyStatusCompleted {
+			householdCompleted++
+		}
+	}
+	if activeUsers > 0 {
+		stats.HouseholdAverage = float64(householdCompleted) / float64(activeUsers)
+	}
+	return stats, nil
+}
+
+// RebuildUserStats does nothing, the stats are counted when asked for.
+func (s *Store) RebuildUserStats(ctx context.Context) error {
+	return nil
+}
+
+// AwardBadge stores a badge and sets its ID unless the user already has its
+// kind for its period, and reports whether it did.
+func (s *Store) AwardBadge(ctx context.Context, b *store.Badge) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.hasBadge(b.UserID, b.Kind, b.Period) {
+		return false, nil
+	}
+	s.nextBadgeID++
+	b.ID = s.nextBadgeID
+	cp := *b
+	cp.AwardedAt = b.AwardedAt.UTC().Truncate(time.Second)
+	s.badges = append(s.badges, &cp)
+	return true, nil
+}
+
+// hasBadge reports whether the user has a badge of kind for period.
+func (s *Store) hasBadge(userID int64, kind store.BadgeKind, period string) bool {
+	for _, b := range s.badges {
+		if b.UserID == userID && b.Kind == kind && b.Period == period {
+			return true
+		}
+	}
+	return false
+}
+
+// ListBadges returns a user's badges, oldest first.
+func (s *Store) ListBadges(ctx context.Context, userID int64) ([]*store.Badge, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var badges []*store.Badge
+	for _, b := range s.badges {
+		if b.UserID == userID {
+			cp := *b
+			badges = append(badges, &cp)
+		}
+	}
+	sort.SliceStable(badges, func(i, j int) bool { return badges[i].AwardedAt.Before(badges[j].AwardedAt) })
+	return badges, nil
+}
+
+// AddLedgerEntry stores a ledger entry and sets its ID.
+func (s *Store) AddLedgerEntry(ctx context.Context, e *store.LedgerEntry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.nextEntryID++
+	e.ID = s.nextEntryID
+	cp := *e
+	cp.CreatedAt = e.CreatedAt.UTC().Truncate(time.Second)
+	if e.DutyDate != nil {
+		date := *e.DutyDate
+		cp.DutyDate = &date
+	}
+	s.ledger = append(s.ledger, &cp)
+	return nil
+}
+
+// ListLedgerEntries returns the ledger entries of all users, oldest first.
+func (s *Store) ListLedgerEntries(ctx context.Context) ([]*store.LedgerEntry, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	entries := cloneSlice(s.ledger)
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].CreatedAt.Before(entries[j].CreatedAt) })
+	return entries, nil
+}
+
+// CreateLoginCode stores a login code.
+func (s *Store) CreateLoginCode(ctx context.Context, code *store.LoginCode) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cp := *code
+	cp.ExpiresAt = code.ExpiresAt.UTC().Truncate(time.Second)
+	s.loginCodes[code.CodeHash] = &cp
+	return nil
+}
+
+// ConsumeLoginCode deletes the login code with the hash and returns it, or
+// nil if there is none.
+func (s *Store) ConsumeLoginCode(ctx context.Context, codeHash string) (*store.LoginCode, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	code, ok := s.loginCodes[codeHash]
+	if !ok {
+		return nil, nil
+	}
+	delete(s.loginCodes, codeHash)
+	return code, nil
+}
+
+// CreateInvite stores an invite.
+func (s *Store) CreateInvite(ctx context.Context, invite *store.Invite) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cp := *invite
+	cp.CreatedAt = invite.CreatedAt.UTC().Truncate(time.Second)
+	cp.ExpiresAt = invite.ExpiresAt.UTC().Truncate(time.Second)
+	cp.UsedBy, cp.UsedAt = 0, nil
+	s.invites[invite.TokenHash] = &cp
+	return nil
+}
+
+// UseInvite marks the unused invite with the hash as used by the Telegram
+// user and returns it, or nil if there is none or it expired before at.
+func (s *Store) UseInvite(ctx context.Context, tokenHash string, telegramUserID int64, at time.Time) (*store.Invite, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	invite, ok := s.invites[tokenHash]
+	if !ok || invite.UsedBy != 0 || !at.Before(invite.ExpiresAt) {
+		return nil, nil
+	}
+	usedAt := at.UTC().Truncate(time.Second)
+	invite.UsedBy, invite.UsedAt = telegramUserID, &usedAt
+	cp := *invite
+	return &cp, nil
+}
+
+// GetInviteUsedBy returns the invite the Telegram user joined with, or nil if
+// they didn't join with one.
+func (s *Store) GetInviteUsedBy(ctx context.Context, telegramUserID int64) (*store.Invite, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var found *store.Invite
+	for _, invite := range s.invites {
+		if invite.UsedBy == telegramUserID && (found == nil || invite.UsedAt.After(*found.UsedAt)) {
+			found = invite
+		}
+	}
+	if found == nil {
+		return nil, nil
+	}
+	cp := *found
+	return &cp, nil
+}
+
+// CreateWebSession stores a web session.
+func (s *Store) CreateWebSession(ctx context.Context, session *store.WebSession) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cp := *session
+	cp.CreatedAt = session.CreatedAt.UTC().Truncate(time.Second)
+	cp.ExpiresAt = session.ExpiresAt.UTC().Truncate(time.Second)
+	s.sessions[session.TokenHash] = &cp
+	return nil
+}
+
+// GetWebSession returns the web session with the token hash, or nil if there is none.
+func (s *Store) GetWebSession(ctx context.Context, tokenHash string) (*store.WebSession, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	session, ok := s.sessions[tokenHash]
+	if !ok {
+		return nil, nil
+	}
+	cp := *session
+	return &cp, nil
+}
+
+// DeleteWebSession deletes the web session with the token hash.
+func (s *Store) DeleteWebSession(ctx context.Context, tokenHash string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.sessions, tokenHash)
+	return nil
+}
+
+// DeleteExpiredLogins deletes the login codes and web sessions that expired before now.
+func (s *Store) DeleteExpiredLogins(ctx context.Context, now time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for hash, c := range s.loginCodes {
+		if c.ExpiresAt.Before(now) {
+			delete(s.loginCodes, hash)
+		}
+	}
+	for hash, ws := range s.sessions {
+		if ws.ExpiresAt.Before(now) {
+			delete(s.sessions, hash)
+		}
+	}
+	return nil
+}
+
+// MergeUsers moves everything that belongs to the user fromID to the user
+// toID and deletes fromID, recording an audit entry. Where only one of them
+// can be kept, toID's wins.
+func (s *Store) MergeUsers(ctx context.Context, fromID, toID int64, at time.Time) (*store.UserMerge, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	from, to := s.users[fromID], s.users[toID]
+	if from == nil {
+		return nil, fmt.Errorf("could not query merged user: user %d not found", fromID)
+	}
+	if to == nil {
+		return nil, fmt.Errorf("could not find surviving user %d", toID)
+	}
+
+	m := &store.UserMerge{
+		FromUserID:         fromID,
+		FromTelegramUserID: from.TelegramUserID,
+		FromName:           from.FirstName,
+		ToUserID:           toID,
+		VolunteerQueueDays: from.VolunteerQueueDays,
+		AdminQueueDays:     from.AdminQueueDays,
+		MergedAt:           at.UTC().Truncate(time.Second),
+	}
+	to.VolunteerQueueDays += from.VolunteerQueueDays
+	to.AdminQueueDays += from.AdminQueueDays
+	if to.OffDutyStart == nil {
+		to.OffDutyStart, to.OffDutyEnd = from.OffDutyStart, from.OffDutyEnd
+	}
+
+	for _, d := range s.duties {
+		if d.UserID == fromID {
+			d.UserID = toID
+			m.DutiesMoved++
+		}
+	}
+	for _, c := range s.changes {
+		if c.UserID == fromID {
+			c.UserID = toID
+		}
+	}
+	for _, p := range s.periods {
+		if p.UserID == fromID {
+			p.UserID = toID
+		}
+	}
+	for _, sn := range s.snoozes {
+		if sn.UserID == fromID {
+			sn.UserID = toID
+		}
+	}
+	for _, c := range s.comparisons {
+		if c.LiveUserID == fromID {
+			c.LiveUserID = toID
+		}
+		if c.ShadowUserID == fromID {
+			c.ShadowUserID = toID
+		}
+	}
+	for _, v := range s.versions {
+		// A new slice, as clones share them
+		days := slices.Clone(v.Days)
+		for i := range days {
+			if days[i].UserID == fromID {
+				days[i].UserID = toID
+			}
+		}
+		v.Days = days
+	}
+	for _, e := range s.explanations {
+		if e.UserID == fromID {
+			e.UserID = toID
+		}
+		// A new slice, as clones share them
+		var candidates []store.AssignmentCandidate
+		for _, c := range e.Candidates {
+			if c.UserID == fromID {
+				if slices.ContainsFunc(e.Candidates, func(other store.AssignmentCandidate) bool { return other.UserID == toID }) {
+					continue
+				}
+				c.UserID = toID
+			}
+			candidates = append(candidates, c)
+		}
+		e.Candidates = candidates
+	}
+	if link, ok := s.calendarLinks[fromID]; ok && s.calendarLinks[toID] == nil {
+		link.UserID = toID
+		s.calendarLinks[toID] = link
+	}
+	if prefs, ok := s.preferences[fromID]; ok && s.preferences[toID] == nil {
+		prefs.UserID = toID
+		s.preferences[toID] = prefs
+	}
+	if sub, ok := s.subscriptions[fromID]; ok && s.subscriptions[toID] == nil {
+		sub.UserID = toID
+		s.subscriptions[toID] = sub
+	}
+	var badges []*store.Badge
+	for _, b := range s.badges {
+		if b.UserID == fromID {
+			if s.hasBadge(toID, b.Kind, b.Period) {
+				continue
+			}
+			b.UserID = toID
+		}
+		badges = append(badges, b)
+	}
+	s.badges = badges
+	for _, e := range s.ledger {
+		if e.UserID == fromID {
+			e.UserID = toID
+		}
+	}
+	for _, e := range s.queueEvents {
+		if e.UserID == fromID {
+			e.UserID = toID
+		}
+	}
+	for _, t := range s.tasks {
+		if t.ClaimedBy != nil && *t.ClaimedBy == fromID {
+			t.ClaimedBy = &toID
+		}
+	}
+	for _, r := range s.covers {
+		if r.UserID == fromID {
+			r.UserID = toID
+		}
+		if r.CoveredBy != nil && *r.CoveredBy == fromID {
+			r.CoveredBy = &toID
+		}
+	}
+	for _, c := range s.loginCodes {
+		if c.UserID == fromID {
+			c.UserID = toID
+		}
+	}
+	for _, ws := range s.sessions {
+		if ws.UserID == fromID {
+			ws.UserID = toID
+		}
+	}
+	for _, states := range s.rotations {
+		st, ok := states[fromID]
+		if !ok {
+			continue
+		}
+		if kept, ok := states[toID]; ok {
+			kept.AssignmentCount += st.AssignmentCount
+			if st.LastAssignedTimestamp.After(kept.LastAssignedTimestamp) {
+				kept.LastAssignedTimestamp = st.LastAssignedTimestamp
+			}
+		} else {
+			st.UserID = toID
+			states[toID] = st
+		}
+		delete(states, fromID)
+	}
+	delete(s.calendarLinks, fromID)
+	delete(s.preferences, fromID)
+	delete(s.subscriptions, fromID)
+	delete(s.users, fromID)
+
+	s.nextMergeID++
+	m.ID = s.nextMergeID
+	s.merges = append(s.merges, m)
+	cp := *m
+	return &cp, nil
+}
+
+// ListUserMerges returns the audit records of merged users, oldest first.
+func (s *Store) ListUserMerges(ctx context.Context) ([]*store.UserMerge, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	merges := make([]*store.UserMerge, 0, len(s.merges))
+	for _, m := range s.merges {
+		cp := *m
+		merges = append(merges, &cp)
+	}
+	return merges, nil
+}
+
+// CreateDuty creates a new duty assignment. Only one duty may exist per date.
+func (s *Store) CreateDuty(ctx context.Context, duty *store.Duty) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := dateKey(duty.DutyDate)
+	if _, exists := s.duties[key]; exists {
+		return fmt.Errorf("could not insert duty: duty already exists on %s", key)
+	}
+
+	s.nextDutyID++
+	duty.ID = s.nextDutyID
+	stored := *duty
+	stored.DutyDate, _ = time.Parse(dateLayout, key)
+	stored.CreatedAt = duty.CreatedAt.UTC().Truncate(time.Second)
+	stored.User = nil
+	if duty.CompletedAt != nil {
+		t := duty.CompletedAt.UTC().Truncate(time.Second)
+		stored.CompletedAt = &t
+	}
+	stored.BackfilledAt = nil
+	stored.AnnouncedAt = nil
+	stored.HoldUntil = copyHoldUntil(duty.HoldUntil)
+	stored.Status = store.InitialStatus(duty)
+	duty.Status = stored.Status
+	s.duties[key] = &stored
+	return nil
+}
+
+// GetDutyByDate retrieves a duty by its date, including user info. Returns nil if not found.
+func (s *Store) GetDutyByDate(ctx context.Context, date time.Time) (*store.Duty, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	d, ok := s.duties[dateKey(date)]
+	if !ok {
+		return nil, nil
+	}
+	return s.copyDuty(d), nil
+}
+
+// UpdateDuty updates the user, type, completion, hold and note of the duty on duty.DutyDate.
+func (s *Store) UpdateDuty(ctx context.Context, duty *store.Duty) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, ok := s.duties[dateKey(duty.DutyDate)]
+	if !ok {
+		return nil // Like an UPDATE matching no rows
+	}
+
+	existing.UserID = duty.UserID
+	existing.AssignmentType = duty.AssignmentType
+	existing.CompletedAt = nil
+	if duty.CompletedAt != nil {
+		t := duty.CompletedAt.UTC().Truncate(time.Second)
+		existing.CompletedAt = &t
+	}
+	existing.CompletionBy = duty.CompletionBy
+	existing.Published = duty.Published
+	existing.HoldUntil = copyHoldUntil(duty.HoldUntil)
+	existing.Note = duty.Note
+	existing.HandoffNote = duty.HandoffNote
+	existing.Status = store.InitialStatus(duty)
+	return nil
+}
+
+// DeleteDuty removes a duty assignment for a specific date.
+func (s *Store) DeleteDuty(ctx context.Context, date time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.duties, dateKey(date))
+	return nil
+}
+
+// GetDutiesByMonth retrieves all duties for a given month and year, ordered by date.
+func (s *Store) GetDutiesByMonth(ctx context.Context, year int, month time.Month) ([]*store.Duty, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	start := time.Date(year, month, 1, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 1, 0)
+	return s.sortedDuties(func(d *store.Duty) bool {
+		return !d.DutyDate.Before(start) && d.DutyDate.Before(end)
+	}), nil
+}
+
+// ListDuties retrieves the duties matching the filter, ordered by date.
+func (s *Store) ListDuties(ctx context.Context, filter store.DutyFilter) ([]*store.Duty, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.sortedDuties(func(d *store.Duty) bool {
+		key := dateKey(d.DutyDate)
+		return (filter.UserID == 0 || d.UserID == filter.UserID) &&
+			(filter.From.IsZero() || key >= dateKey(filter.From)) &&
+			(filter.To.IsZero() || key < dateKey(filter.To))
+	}), nil
+}
+
+// CompleteDuty marks the duty on the given date as completed unless it
+// already is, and reports whether it did.
+func (s *Store) CompleteDuty(ctx context.Context, date time.Time) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	d, ok := s.duties[dateKey(date)]
+	if !ok || d.CompletedAt != nil {
+		return false, nil
+	}
+	now := time.Now().UTC().Truncate(time.Second)
+	d.CompletedAt = &now
+	d.Status = store.DutyStatusCompleted
+	return true, nil
+}
+
+// MarkDutyAnnounced records when the group was told about the duty on a date.
+func (s *Store) MarkDutyAnnounced(ctx context.Context, date, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if d, ok := s.duties[dateKey(date)]; ok {
+		t := at.UTC().Truncate(time.Second)
+		d.AnnouncedAt = &t
+	}
+	return nil
+}
+
+// copyHoldUntil normalizes a duty's hold to a date like the SQL store keeps it.
+func copyHoldUntil(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	d := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	return &d
+}
+
+// GetExpiredHolds returns the uncompleted duties whose hold ended before
+// today, ordered by date.
+func (s *Store) GetExpiredHolds(ctx context.Context, today time.Time) ([]*store.Duty, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.sortedDuties(func(d *store.Duty) bool {
+		return d.HoldUntil != nil && dateKey(*d.HoldUntil) < dateKey(today) && d.CompletedAt == nil
+	}), nil
+}
+
+// MarkMissedDuties marks the announced and acknowledged duties before the
+// given date as missed and returns how many there were.
+func (s *Store) MarkMissedDuties(ctx context.Context, before time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	marked := 0
+	for key, d := range s.duties {
+		if key < dateKey(before) && (d.Status == store.DutyStatusAnnounced || d.Status == store.DutyStatusAcknowledged) {
+			d.Status = store.DutyStatusMissed
+			marked++
+		}
+	}
+	return marked, nil
+}
+
+// GetTodaysDuty retrieves today's duty assignment.
+func (s *Store) GetTodaysDuty(ctx context.Context) (*store.Duty, error) {
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	return s.GetDutyByDate(ctx, today)
+}
+
+// GetCompletedDutiesInRange retrieves all completed duties with start <= date < end.
+func (s *Store) GetCompletedDutiesInRange(ctx context.Context, start, end time.Time) ([]*store.Duty, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	startKey, endKey := dateKey(start), dateKey(end)
+	return s.sortedDuties(func(d *store.Duty) bool {
+		key := dateKey(d.DutyDate)
+		return d.CompletedAt != nil && key >= startKey && key < endKey
+	}), nil
+}
+
+// CreateDutyChange appends to the schedule change log and sets the ID.
+func (s *Store) CreateDutyChange(ctx context.Context, change *store.DutyChange) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.nextChangeID++
+	change.ID = s.nextChangeID
+	cp := *change
+	cp.DutyDate = time.Date(change.DutyDate.Year(), change.DutyDate.Month(), change.DutyDate.Day(), 0, 0, 0, 0, time.UTC)
+	cp.UserName = ""
+	cp.ChangedAt = change.ChangedAt.UTC().Truncate(time.Second)
+	s.changes = append(s.changes, &cp)
+	return nil
+}
+
+// BackfillDuty records who actually did the duty on a past date. It creates a
+// completed voluntary duty if the day had none, or hands an existing duty to
+// userID, completing it if needed. Either way the duty is marked as backfilled.
+func (s *Store) BackfillDuty(ctx context.Context, date time.Time, userID int64, at time.Time) (*store.Duty, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	at = at.UTC().Truncate(time.Second)
+	key := dateKey(date)
+	duty, ok := s.duties[key]
+	if !ok {
+		s.nextDutyID++
+		day, _ := time.Parse(dateLayout, key)
+		duty = &store.Duty{ID: s.nextDutyID, DutyDate: day, AssignmentType: store.AssignmentTypeVoluntary, CreatedAt: at}
+		s.duties[key] = duty
+	}
+	duty.UserID = userID
+	if duty.CompletedAt == nil {
+		completedAt := at
+		duty.CompletedAt = &completedAt
+	}
+	duty.BackfilledAt = &at
+	duty.Status = store.DutyStatusCompleted
+	return s.copyDuty(duty), nil
+}
+
+// GetRecentDutyChanges returns the most recent schedule changes, newest first.
+func (s *Store) GetRecentDutyChanges(ctx context.Context, limit int) ([]*store.DutyChange, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var changes []*store.DutyChange
+	for i := len(s.changes) - 1; i >= 0 && len(changes) < limit; i-- {
+		c := *s.changes[i]
+		if u, ok := s.users[c.UserID]; ok {
+			c.UserName = u.FirstName
+		}
+		changes = append(changes, &c)
+	}
+	return changes, nil
+}
+
+// AddToVolunteerQueue adds days to a user's volunteer queue.
+func (s *Store) AddToVolunteerQueue(ctx context.Context, userID int64, days int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if u, ok := s.users[userID]; ok {
+		u.VolunteerQueueDays += days
+		s.addQueueEvent(userID, store.AssignmentTypeVoluntary, days)
+	}
+	return nil
+}
+
+// AddToAdminQueue adds days to a user's admin assignment queue.
+func (s *Store) AddToAdminQueue(ctx context.Context, userID int64, days int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if u, ok := s.users[userID]; ok {
+		u.AdminQueueDays += days
+		s.addQueueEvent(userID, store.AssignmentTypeAdmin, days)
+	}
+	return nil
+}
+
+// DecrementVolunteerQueue decrements a user's volunteer queue by 1 (minimum 0).
+func (s *Store) DecrementVolunteerQueue(ctx context.Context, userID int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if u, ok := s.users[userID]; ok && u.VolunteerQueueDays > 0 {
+		u.VolunteerQueueDays--
+		s.addQueueEvent(userID, store.AssignmentTypeVoluntary, -1)
+	}
+	return nil
+}
+
+// DecrementAdminQueue decrements a user's admin queue by 1 (minimum 0).
+func (s *Store) DecrementAdminQueue(ctx context.Context, userID int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if u, ok := s.users[userID]; ok && u.AdminQueueDays > 0 {
+		u.AdminQueueDays--
+		s.addQueueEvent(userID, store.AssignmentTypeAdmin, -1)
+	}
+	return nil
+}
+
+// addQueueEvent records a change of a queue. The caller holds the lock.
+func (s *Store) addQueueEvent(userID int64, queue store.AssignmentType, days int) {
+	if days == 0 {
+		return
+	}
+	s.nextQueueID++
+	s.queueEvents = append(s.queueEvents, &store.QueueEvent{
+		ID:     s.nextQueueID,
+		UserID: userID,
+		Queue:  queue,
+		Days:   days,
+		At:     time.Now().UTC().Truncate(time.Second),
+	})
+}
+
+// ListQueueEvents returns the queue events of a user, or of all users for
+// userID 0, oldest first.
+func (s *Store) ListQueueEvents(ctx context.Context, userID int64) ([]*store.QueueEvent, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var events []*store.QueueEvent
+	for _, e := range s.queueEvents {
+		if userID == 0 || e.UserID == userID {
+			cp := *e
+			events = append(events, &cp)
+		}
+	}
+	return events, nil
+}
+
+// GetUsersWithVolunteerQueue returns all active users with volunteer queue > 0,
+// largest queue first.
+func (s *Store) GetUsersWithVolunteerQueue(ctx context.Context) ([]*store.User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	users := s.sortedUsers(func(u *store.User) bool { return u.IsActive && u.VolunteerQueueDays > 0 })
+	sort.SliceStable(users, func(i, j int) bool { return users[i].VolunteerQueueDays > users[j].VolunteerQueueDays })
+	return users, nil
+}
+
+// GetUsersWithAdminQueue returns all active users with admin queue > 0,
+// largest queue first.
+func (s *Store) GetUsersWithAdminQueue(ctx context.Context) ([]*store.User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	users := s.sortedUsers(func(u *store.User) bool { return u.IsActive && u.AdminQueueDays > 0 })
+	sort.SliceStable(users, func(i, j int) bool { return users[i].AdminQueueDays > users[j].AdminQueueDays })
+	return users, nil
+}
+
+// SetOffDuty sets a user's off-duty period.
+func (s *Store) SetOffDuty(ctx context.Context, userID int64, start, end time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if u, ok := s.users[userID]; ok {
+		startDate, _ := time.Parse(dateLayout, dateKey(start))
+		endDate, _ := time.Parse(dateLayout, dateKey(end))
+		u.OffDutyStart, u.OffDutyEnd = &startDate, &endDate
+	}
+	return nil
+}
+
+// ClearOffDuty clears a user's off-duty period.
+func (s *Store) ClearOffDuty(ctx context.Context, userID int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if u, ok := s.users[userID]; ok {
+		u.OffDutyStart, u.OffDutyEnd = nil, nil
+	}
+	return nil
+}
+
+// isOffDuty reports whether a user is off-duty on the given date key, either
+// through the manual window or an imported period. The caller must hold the lock.
+func (s *Store) isOffDuty(u *store.User, key string) bool {
+	if u.OffDutyStart != nil && u.OffDutyEnd != nil &&
+		key >= dateKey(*u.OffDutyStart) && key <= dateKey(*u.OffDutyEnd) {
+		return true
+	}
+	for _, p := range s.periods {
+		if p.UserID == u.ID && key >= dateKey(p.StartDate) && key <= dateKey(p.EndDate) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsUserOffDuty checks if a user is off-duty on a specific date.
+func (s *Store) IsUserOffDuty(ctx context.Context, userID int64, date time.Time) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	u, ok := s.users[userID]
+	if !ok {
+		return false, nil
+	}
+	return s.isOffDuty(u, dateKey(date)), nil
+}
+
+// GetOffDutyUsers returns all users who are off-duty on a specific date.
+func (s *Store) GetOffDutyUsers(ctx context.Context, date time.Time) ([]*store.User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	key := dateKey(date)
+	return s.sortedUsers(func(u *store.User) bool { return s.isOffDuty(u, key) }), nil
+}
+
+// ListOffDutyPeriods returns all imported off-duty periods for a user, ordered by start date.
+func (s *Store) ListOffDutyPeriods(ctx context.Context, userID int64) ([]*store.OffDutyPeriod, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var periods []*store.OffDutyPeriod
+	for _, p := range s.periods {
+		if p.UserID == userID {
+			c := *p
+			periods = append(periods, &c)
+		}
+	}
+	sort.SliceStable(periods, func(i, j int) bool { return periods[i].StartDate.Before(periods[j].StartDate) })
+	return periods, nil
+}
+
+// ReplaceOffDutyPeriods atomically replaces all off-duty periods of the given source for a user.
+func (s *Store) ReplaceOffDutyPeriods(ctx context.Context, userID int64, source string, periods []*store.OffDutyPeriod) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.removePeriods(userID, source)
+	for _, p := range periods {
+		s.nextPeriodID++
+		p.ID = s.nextPeriodID
+		p.UserID = userID
+		p.Source = source
+		c := *p
+		c.StartDate, _ = time.Parse(dateLayout, dateKey(p.StartDate))
+		c.EndDate, _ = time.Parse(dateLayout, dateKey(p.EndDate))
+		s.periods = append(s.periods, &c)
+	}
+	return nil
+}
+
+// removePeriods deletes a user's periods of one source. The caller must hold the write lock.
+func (s *Store) removePeriods(userID int64, source string) {
+	kept := s.periods[:0]
+	for _, p := range s.periods {
+		if p.UserID != userID || p.Source != source {
+			kept = append(kept, p)
+		}
+	}
+	s.periods = kept
+}
+
+// SetCalendarLink creates or updates the iCal link of a user.
+func (s *Store) SetCalendarLink(ctx context.Context, link *store.CalendarLink) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c := *link
+	if link.LastSyncedAt != nil {
+		t := link.LastSyncedAt.UTC().Truncate(time.Second)
+		c.LastSyncedAt = &t
+	}
+	s.calendarLinks[link.UserID] = &c
+	return nil
+}
+
+// GetCalendarLink retrieves the iCal link of a user. Returns nil if none is linked.
+func (s *Store) GetCalendarLink(ctx context.Context, userID int64) (*store.CalendarLink, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	link, ok := s.calendarLinks[userID]
+	if !ok {
+		return nil, nil
+	}
+	c := *link
+	return &c, nil
+}
+
+// DeleteCalendarLink unlinks a user's calendar and removes the periods imported from it.
+func (s *Store) DeleteCalendarLink(ctx context.Context, userID int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.calendarLinks, userID)
+	s.removePeriods(userID, store.OffDutySourceICal)
+	return nil
+}
+
+// ListCalendarLinks returns all linked calendars, ordered by user ID.
+func (s *Store) ListCalendarLinks(ctx context.Context) ([]*store.CalendarLink, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var links []*store.CalendarLink
+	for _, link := range s.calendarLinks {
+		c := *link
+		links = append(links, &c)
+	}
+	sort.Slice(links, func(i, j int) bool { return links[i].UserID < links[j].UserID })
+	return links, nil
+}
+
+// GetNotificationPreferences retrieves a user's notification preferences.
+// It returns nil if the user never changed the defaults.
+func (s *Store) GetNotificationPreferences(ctx context.Context, userID int64) (*store.NotificationPreferences, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	prefs, ok := s.preferences[userID]
+	if !ok {
+		return nil, nil
+	}
+	c := *prefs
+	return &c, nil
+}
+
+// SetNotificationPreferences creates or replaces a user's notification preferences.
+func (s *Store) SetNotificationPreferences(ctx context.Context, prefs *store.NotificationPreferences) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c := *prefs
+	s.preferences[prefs.UserID] = &c
+	return nil
+}
+
+// CreateChangeSubscription subscribes a user to changes of their duties.
+// Subscribing again keeps the original subscription.
+func (s *Store) CreateChangeSubscription(ctx context.Context, sub *store.ChangeSubscription) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.subscriptions[sub.UserID]; ok {
+		return nil
+	}
+	c := *sub
+	c.CreatedAt = c.CreatedAt.UTC().Truncate(time.Second)
+	s.subscriptions[sub.UserID] = &c
+	return nil
+}
+
+// GetChangeSubscription retrieves a user's change subscription. It returns
+// nil if the user isn't subscribed.
+func (s *Store) GetChangeSubscription(ctx context.Context, userID int64) (*store.ChangeSubscription, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	sub, ok := s.subscriptions[userID]
+	if !ok {
+		return nil, nil
+	}
+	c := *sub
+	return &c, nil
+}
+
+// DeleteChangeSubscription unsubscribes a user from changes of their duties.
+func (s *Store) DeleteChangeSubscription(ctx context.Context, userID int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.subscriptions, userID)
+	return nil
+}
+
+// SetSkipDay marks a date as "no duty", replacing the reason if it was already skipped.
+func (s *Store) SetSkipDay(ctx context.Context, day *store.SkipDay) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c := *day
+	c.CreatedAt = day.CreatedAt.UTC().Truncate(time.Second)
+	s.skipDays[dateKey(day.Date)] = &c
+	return nil
+}
+
+// GetSkipDay retrieves the skip day on a date. Returns nil if the date is not skipped.
+func (s *Store) GetSkipDay(ctx context.Context, date time.Time) (*store.SkipDay, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	day, ok := s.skipDays[dateKey(date)]
+	if !ok {
+		return nil, nil
+	}
+	c := *day
+	return &c, nil
+}
+
+// DeleteSkipDay removes the "no duty" mark from a date.
+func (s *Store) DeleteSkipDay(ctx context.Context, date time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.skipDays, dateKey(date))
+	return nil
+}
+
+// GetSkipDaysByMonth retrieves all skip days of a month, ordered by date.
+func (s *Store) GetSkipDaysByMonth(ctx context.Context, year int, month time.Month) ([]*store.SkipDay, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	start := time.Date(year, month, 1, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 1, 0)
+	var days []*store.SkipDay
+	for _, day := range s.skipDays {
+		if !day.Date.Before(start) && day.Date.Before(end) {
+			c := *day
+			days = append(days, &c)
+		}
+	}
+	sort.Slice(days, func(i, j int) bool { return days[i].Date.Before(days[j].Date) })
+	return days, nil
+}
+
+// CreateReminderSnooze stores a postponed reminder and sets its ID.
+func (s *Store) CreateReminderSnooze(ctx context.Context, snooze *store.ReminderSnooze) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.nextSnoozeID++
+	snooze.ID = s.nextSnoozeID
+	c := *snooze
+	c.RemindAt = snooze.RemindAt.UTC().Truncate(time.Second)
+	s.snoozes[c.ID] = &c
+	return nil
+}
+
+// ListReminderSnoozes returns all pending reminders, earliest first.
+func (s *Store) ListReminderSnoozes(ctx context.Context) ([]*store.ReminderSnooze, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var snoozes []*store.ReminderSnooze
+	for _, snooze := range s.snoozes {
+		c := *snooze
+		snoozes = append(snoozes, &c)
+	}
+	sort.Slice(snoozes, func(i, j int) bool {
+		if !snoozes[i].RemindAt.Equal(snoozes[j].RemindAt) {
+			return snoozes[i].RemindAt.Before(snoozes[j].RemindAt)
+		}
+		return snoozes[i].ID < snoozes[j].ID
+	})
+	return snoozes, nil
+}
+
+// DeleteReminderSnooze removes a pending reminder. Deleting a missing one is not an error.
+func (s *Store) DeleteReminderSnooze(ctx context.Context, id int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.snoozes, id)
+	return nil
+}
+
+// CreatePendingMessage stores an unsent message and sets its ID.
+func (s *Store) CreatePendingMessage(ctx context.Context, msg *store.PendingMessage) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.nextPendingID++
+	msg.ID = s.nextPendingID
+	c := *msg
+	c.CreatedAt = msg.CreatedAt.UTC().Truncate(time.Second)
+	s.pending[c.ID] = &c
+	return nil
+}
+
+// ListPendingMessages returns all unsent messages, oldest first.
+func (s *Store) ListPendingMessages(ctx context.Context) ([]*store.PendingMessage, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var msgs []*store.PendingMessage
+	for _, msg := range s.pending {
+		c := *msg
+		msgs = append(msgs, &c)
+	}
+	sort.Slice(msgs, func(i, j int) bool {
+		if !msgs[i].CreatedAt.Equal(msgs[j].CreatedAt) {
+			return msgs[i].CreatedAt.Before(msgs[j].CreatedAt)
+		}
+		return msgs[i].ID < msgs[j].ID
+	})
+	return msgs, nil
+}
+
+// TrackInteractiveMessage stores an interactive message, replacing it if it
+// was tracked before.
+func (s *Store) TrackInteractiveMessage(ctx context.Context, msg *store.InteractiveMessage) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c := *msg
+	c.SentAt = msg.SentAt.UTC().Truncate(time.Second)
+	if msg.DoneAt != nil {
+		doneAt := msg.DoneAt.UTC().Truncate(time.Second)
+		c.DoneAt = &doneAt
+	}
+	s.interactive[messageKey{msg.ChatID, msg.MessageID}] = &c
+	return nil
+}
+
+// MarkInteractiveMessageDone records when the interaction of a message
+// completed. Untracked messages are ignored.
+func (s *Store) MarkInteractiveMessageDone(ctx context.Context, chatID int64, messageID int, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if msg, ok := s.interactive[messageKey{chatID, messageID}]; ok {
+		doneAt := at.UTC().Truncate(time.Second)
+		msg.DoneAt = &doneAt
+	}
+	return nil
+}
+
+// ListInteractiveMessages returns the tracked interactive messages, oldest first.
+func (s *Store) ListInteractiveMessages(ctx context.Context) ([]*store.InteractiveMessage, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var msgs []*store.InteractiveMessage
+	for _, msg := range s.interactive {
+		c := *msg
+		msgs = append(msgs, &c)
+	}
+	sort.Slice(msgs, func(i, j int) bool {
+		if !msgs[i].SentAt.Equal(msgs[j].SentAt) {
+			return msgs[i].SentAt.Before(msgs[j].SentAt)
+		}
+		if msgs[i].ChatID != msgs[j].ChatID {
+			return msgs[i].ChatID < msgs[j].ChatID
+		}
+		return msgs[i].MessageID < msgs[j].MessageID
+	})
+	return msgs, nil
+}
+
+// DeleteInteractiveMessage stops tracking a message. Deleting a missing one is not an error.
+func (s *Store) DeleteInteractiveMessage(ctx context.Context, chatID int64, messageID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.interactive, messageKey{chatID, messageID})
+	return nil
+}
+
+// SaveConversationState stores a conversation state, replacing the one with
+// its key.
+func (s *Store) SaveConversationState(ctx context.Context, state *store.ConversationState) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c := *state
+	c.ExpiresAt = state.ExpiresAt.UTC().Truncate(time.Second)
+	s.states[state.Key] = &c
+	return nil
+}
+
+// ListConversationStates returns the conversation states that expire after
+// now, by key.
+func (s *Store) ListConversationStates(ctx context.Context, now time.Time) ([]*store.ConversationState, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var states []*store.ConversationState
+	for _, state := range s.states {
+		if state.ExpiresAt.After(now) {
+			c := *state
+			states = append(states, &c)
+		}
+	}
+	sort.Slice(states, func(i, j int) bool { return states[i].Key < states[j].Key })
+	return states, nil
+}
+
+// DeleteConversationState deletes a conversation state. Deleting a missing
+// one is not an error.
+func (s *Store) DeleteConversationState(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.states, key)
+	return nil
+}
+
+// DeleteExpiredConversationStates deletes the conversation states that
+// expired by now.
+func (s *Store) DeleteExpiredConversationStates(ctx context.Context, now time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for key, state := range s.states {
+		if !state.ExpiresAt.After(now) {
+			delete(s.states, key)
+		}
+	}
+	return nil
+}
+
+// DeletePendingMessage removes an unsent message. Deleting a missing one is not an error.
+func (s *Store) DeletePendingMessage(ctx context.Context, id int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.pending, id)
+	return nil
+}
+
+// GetChatLocale returns the locale a chat picked, or "" if it didn't.
+func (s *Store) GetChatLocale(ctx context.Context, chatID int64) (string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.locales[chatID], nil
+}
+
+// SetChatLocale sets the locale of a chat, replacing the one it had.
+func (s *Store) SetChatLocale(ctx context.Context, chatID int64, locale string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.locales[chatID] = locale
+	return nil
+}
+
+// GetChatDisplay returns how a chat wants its calendars drawn, or nil if it
+// never said.
+func (s *Store) GetChatDisplay(ctx context.Context, chatID int64) (*store.ChatDisplay, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	d, ok := s.displays[chatID]
+	if !ok {
+		return nil, nil
+	}
+	return &d, nil
+}
+
+// SetChatDisplay sets how a chat wants its calendars drawn, replacing what it
+// had.
+func (s *Store) SetChatDisplay(ctx context.Context, chatID int64, display store.ChatDisplay) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.displays[chatID] = display
+	return nil
+}
+
+// GetSetting returns the value of a setting, or "" if it was never set.
+func (s *Store) GetSetting(ctx context.Context, key string) (string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.settings[key], nil
+}
+
+// SetSetting sets a setting, replacing its value.
+func (s *Store) SetSetting(ctx context.Context, key, value string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.settings[key] = value
+	return nil
+}
+
+// ListSettings returns all settings that were set, by key.
+func (s *Store) ListSettings(ctx context.Context) (map[string]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return maps.Clone(s.settings), nil
+}
+
+// CreateShadowComparison records a shadow strategy's pick and sets its ID.
+func (s *Store) CreateShadowComparison(ctx context.Context, c *store.ShadowComparison) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.nextShadowID++
+	c.ID = s.nextShadowID
+	cp := *c
+	cp.Date = time.Date(c.Date.Year(), c.Date.Month(), c.Date.Day(), 0, 0, 0, 0, time.UTC)
+	cp.CreatedAt = c.CreatedAt.UTC().Truncate(time.Second)
+	s.comparisons = append(s.comparisons, &cp)
+	return nil
+}
+
+// ListShadowComparisons returns the comparisons of days on or after since, oldest first.
+func (s *Store) ListShadowComparisons(ctx context.Context, since time.Time) ([]*store.ShadowComparison, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var comparisons []*store.ShadowComparison
+	for _, c := range s.comparisons {
+		if dateKey(c.Date) >= dateKey(since) {
+			cp := *c
+			comparisons = append(comparisons, &cp)
+		}
+	}
+	sort.SliceStable(comparisons, func(i, j int) bool {
+		return comparisons[i].Date.Before(comparisons[j].Date)
+	})
+	return comparisons, nil
+}
+
+// CreateScheduleVersion stores a snapshot as the next version of its month
+// and sets its ID and Version.
+func (s *Store) CreateScheduleVersion(ctx context.Context, v *store.ScheduleVersion) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	month := time.Date(v.Month.Year(), v.Month.Month(), 1, 0, 0, 0, 0, time.UTC)
+	version := 1
+	for _, other := range s.versions {
+		if other.Month.Equal(month) {
+			version = max(version, other.Version+1)
+		}
+	}
+	s.nextVersionID++
+	v.ID, v.Version = s.nextVersionID, version
+	cp := *v
+	cp.Month = month
+	cp.CreatedAt = v.CreatedAt.UTC().Truncate(time.Second)
+	cp.Days = slices.Clone(v.Days)
+	s.versions = append(s.versions, &cp)
+	return nil
+}
+
+// ListScheduleVersions returns the versions of a month, oldest first.
+func (s *Store) ListScheduleVersions(ctx context.Context, month time.Time) ([]*store.ScheduleVersion, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	first := time.Date(month.Year(), month.Month(), 1, 0, 0, 0, 0, time.UTC)
+	var versions []*store.ScheduleVersion
+	for _, v := range s.versions {
+		if v.Month.Equal(first) {
+			cp := *v
+			cp.Days = slices.Clone(v.Days)
+			versions = append(versions, &cp)
+		}
+	}
+	return versions, nil
+}
+
+// SaveAssignmentExplanation stores an explanation, replacing the one of its day.
+func (s *Store) SaveAssignmentExplanation(ctx context.Context, e *store.AssignmentExplanation) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cp := *e
+	cp.Date = time.Date(e.Date.Year(), e.Date.Month(), e.Date.Day(), 0, 0, 0, 0, time.UTC)
+	cp.CreatedAt = e.CreatedAt.UTC().Truncate(time.Second)
+	cp.Candidates = slices.Clone(e.Candidates)
+	s.explanations[dateKey(e.Date)] = &cp
+	return nil
+}
+
+// GetAssignmentExplanation returns the explanation of a day, or nil if there is none.
+func (s *Store) GetAssignmentExplanation(ctx context.Context, date time.Time) (*store.AssignmentExplanation, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	e, ok := s.explanations[dateKey(date)]
+	if !ok {
+		return nil, nil
+	}
+	cp := *e
+	// Those the winner was picked from first, like the SQLite store
+	cp.Candidates = slices.Clone(e.Candidates)
+	sort.SliceStable(cp.Candidates, func(i, j int) bool {
+		a, b := cp.Candidates[i], cp.Candidates[j]
+		if (a.Excluded == "") != (b.Excluded == "") {
+			return a.Excluded == ""
+		}
+		return a.UserID < b.UserID
+	})
+	return &cp, nil
+}
+
+// CreateNoteTemplate stores a context note template and sets its ID.
+func (s *Store) CreateNoteTemplate(ctx context.Context, t *store.NoteTemplate) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.nextNoteID++
+	t.ID = s.nextNoteID
+	cp := *t
+	cp.CreatedAt = t.CreatedAt.UTC().Truncate(time.Second)
+	s.templates = append(s.templates, &cp)
+	return nil
+}
+
+// ListNoteTemplates returns all context note templates, oldest first.
+func (s *Store) ListNoteTemplates(ctx context.Context) ([]*store.NoteTemplate, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	templates := make([]*store.NoteTemplate, 0, len(s.templates))
+	for _, t := range s.templates {
+		cp := *t
+		templates = append(templates, &cp)
+	}
+	return templates, nil
+}
+
+// DeleteNoteTemplate removes a context note template. Unknown IDs are ignored.
+func (s *Store) DeleteNoteTemplate(ctx context.Context, id int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, t := range s.templates {
+		if t.ID == id {
+			s.templates = append(s.templates[:i], s.templates[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+// CreateBlackoutRule stores a blackout rule and sets its ID.
+func (s *Store) CreateBlackoutRule(ctx context.Context, r *store.BlackoutRule) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.nextRuleID++
+	r.ID = s.nextRuleID
+	cp := *r
+	cp.CreatedAt = r.CreatedAt.UTC().Truncate(time.Second)
+	s.blackouts = append(s.blackouts, &cp)
+	return nil
+}
+
+// ListBlackoutRules returns all blackout rules, oldest first.
+func (s *Store) ListBlackoutRules(ctx context.Context) ([]*store.BlackoutRule, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	rules := make([]*store.BlackoutRule, 0, len(s.blackouts))
+	for _, r := range s.blackouts {
+		cp := *r
+		rules = append(rules, &cp)
+	}
+	return rules, nil
+}
+
+// DeleteBlackoutRule removes a blackout rule. Unknown IDs are ignored.
+func (s *Store) DeleteBlackoutRule(ctx context.Context, id int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, r := range s.blackouts {
+		if r.ID == id {
+			s.blackouts = append(s.blackouts[:i], s.blackouts[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+// CreateChecklistItem stores a checklist item and sets its ID.
+func (s *Store) CreateChecklistItem(ctx context.Context, item *store.ChecklistItem) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.nextItemID++
+	item.ID = s.nextItemID
+	cp := *item
+	cp.CreatedAt = item.CreatedAt.UTC().Truncate(time.Second)
+	s.checklist = append(s.checklist, &cp)
+	return nil
+}
+
+// ListChecklistItems returns all checklist items, oldest first.
+func (s *Store) ListChecklistItems(ctx context.Context) ([]*store.ChecklistItem, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	items := make([]*store.ChecklistItem, 0, len(s.checklist))
+	for _, item := range s.checklist {
+		cp := *item
+		items = append(items, &cp)
+	}
+	return items, nil
+}
+
+// DeleteChecklistItem removes a checklist item along with its checks.
+// Unknown IDs are ignored.
+func (s *Store) DeleteChecklistItem(ctx context.Context, id int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, item := range s.checklist {
+		if item.ID == id {
+			s.checklist = append(s.checklist[:i], s.checklist[i+1:]...)
+			break
+		}
+	}
+	kept := s.checks[:0]
+	for _, c := range s.checks {
+		if c.ItemID != id {
+			kept = append(kept, c)
+		}
+	}
+	s.checks = kept
+	return nil
+}
+
+// SetChecklistCheck checks an item off on the duty of a day. Checking it
+// again keeps the first check.
+func (s *Store) SetChecklistCheck(ctx context.Context, check *store.ChecklistCheck) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, c := range s.checks {
+		if c.Date.Equal(check.Date) && c.ItemID == check.ItemID {
+			return nil
+		}
+	}
+	cp := *check
+	cp.CheckedAt = check.CheckedAt.UTC().Truncate(time.Second)
+	s.checks = append(s.checks, &cp)
+	return nil
+}
+
+// DeleteChecklistCheck unchecks an item on the duty of a day.
+func (s *Store) DeleteChecklistCheck(ctx context.Context, date time.Time, itemID int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, c := range s.checks {
+		if c.Date.Equal(date) && c.ItemID == itemID {
+			s.checks = append(s.checks[:i], s.checks[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+// GetChecklistChecks returns the items checked off on the duty of a day,
+// ordered by item.
+func (s *Store) GetChecklistChecks(ctx context.Context, date time.Time) ([]*store.ChecklistCheck, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var checks []*store.ChecklistCheck
+	for _, c := range s.checks {
+		if c.Date.Equal(date) {
+			cp := *c
+			checks = append(checks, &cp)
+		}
+	}
+	sort.Slice(checks, func(i, j int) bool { return checks[i].ItemID < checks[j].ItemID })
+	return checks, nil
+}
+
+// CreateTask stores a one-off task and sets its ID.
+func (s *Store) CreateTask(ctx context.Context, t *store.Task) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.nextTaskID++
+	t.ID = s.nextTaskID
+	cp := copyTask(t)
+	cp.CreatedAt = t.CreatedAt.UTC().Truncate(time.Second)
+	s.tasks = append(s.tasks, cp)
+	return nil
+}
+
+// copyTask copies a task along with what its fields point to.
+func copyTask(t *store.Task) *store.Task {
+	cp := *t
+	if t.DueDate != nil {
+		date := *t.DueDate
+		cp.DueDate = &date
+	}
+	if t.ClaimedBy != nil {
+		id := *t.ClaimedBy
+		cp.ClaimedBy = &id
+	}
+	if t.ClaimedAt != nil {
+		at := *t.ClaimedAt
+		cp.ClaimedAt = &at
+	}
+	if t.DoneAt != nil {
+		at := *t.DoneAt
+		cp.DoneAt = &at
+	}
+	return &cp
+}
+
+// task returns the stored task with the ID, or nil if there is none.
+func (s *Store) task(id int64) *store.Task {
+	for _, t := range s.tasks {
+		if t.ID == id {
+			return t
+		}
+	}
+	return nil
+}
+
+// GetTask returns the task with the ID, or nil if there is none.
+func (s *Store) GetTask(ctx context.Context, id int64) (*store.Task, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if t := s.task(id); t != nil {
+		return copyTask(t), nil
+	}
+	return nil, nil
+}
+
+// ListTasks returns all tasks, oldest first.
+func (s *Store) ListTasks(ctx context.Context) ([]*store.Task, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var tasks []*store.Task
+	for _, t := range s.tasks {
+		tasks = append(tasks, copyTask(t))
+	}
+	return tasks, nil
+}
+
+// ClaimTask gives the open, unclaimed task to the user and reports whether
+// it did.
+func (s *Store) ClaimTask(ctx context.Context, id, userID int64, at time.Time) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t := s.task(id)
+	if t == nil || t.ClaimedBy != nil || t.DoneAt != nil {
+		return false, nil
+	}
+	claimedAt := at.UTC().Truncate(time.Second)
+	t.ClaimedBy, t.ClaimedAt = &userID, &claimedAt
+	return true, nil
+}
+
+// ReleaseTask takes back the user's claim of the open task and reports
+// whether there was one.
+func (s *Store) ReleaseTask(ctx context.Context, id, userID int64) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t := s.task(id)
+	if t == nil || t.ClaimedBy == nil || *t.ClaimedBy != userID || t.DoneAt != nil {
+		return false, nil
+	}
+	t.ClaimedBy, t.ClaimedAt = nil, nil
+	return true, nil
+}
+
+// CompleteTask marks the open task done by the user, claiming it for them if
+// nobody has, and reports whether it did.
+func (s *Store) CompleteTask(ctx context.Context, id, userID int64, at time.Time) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t := s.task(id)
+	if t == nil || (t.ClaimedBy != nil && *t.ClaimedBy != userID) || t.DoneAt != nil {
+		return false, nil
+	}
+	doneAt := at.UTC().Truncate(time.Second)
+	if t.ClaimedAt == nil {
+		t.ClaimedAt = &doneAt
+	}
+	t.ClaimedBy, t.DoneAt = &userID, &doneAt
+	return true, nil
+}
+
+// DeleteTask deletes a task. Unknown IDs are ignored.
+func (s *Store) DeleteTask(ctx context.Context, id int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var tasks []*store.Task
+	for _, t := range s.tasks {
+		if t.ID != id {
+			tasks = append(tasks, t)
+		}
+	}
+	s.tasks = tasks
+	return nil
+}
+
+// CreateCoverRequest stores a cover request and sets its ID.
+func (s *Store) CreateCoverRequest(ctx context.Context, r *store.CoverRequest) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.nextCoverID++
+	r.ID = s.nextCoverID
+	cp := copyCoverRequest(r)
+	cp.DutyDate = time.Date(r.DutyDate.Year(), r.DutyDate.Month(), r.DutyDate.Day(), 0, 0, 0, 0, time.UTC)
+	cp.Deadline = r.Deadline.UTC().Truncate(time.Second)
+	cp.CreatedAt = r.CreatedAt.UTC().Truncate(time.Second)
+	s.covers = append(s.covers, cp)
+	return nil
+}
+
+// copyCoverRequest copies a cover request along with what its fields point to.
+func copyCoverRequest(r *store.CoverRequest) *store.CoverRequest {
+	cp := *r
+	if r.CoveredBy != nil {
+		id := *r.CoveredBy
+		cp.CoveredBy = &id
+	}
+	if r.ResolvedAt != nil {
+		at := *r.ResolvedAt
+		cp.ResolvedAt = &at
+	}
+	return &cp
+}
+
+// GetOpenCoverRequest returns the open cover request of a day, or nil if
+// there is none.
+func (s *Store) GetOpenCoverRequest(ctx context.Context, date time.Time) (*store.CoverRequest, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	day := dateKey(date)
+	for i := len(s.covers) - 1; i >= 0; i-- {
+		if r := s.covers[i]; r.ResolvedAt == nil && dateKey(r.DutyDate) == day {
+			return copyCoverRequest(r), nil
+		}
+	}
+	return nil, nil
+}
+
+// ListOpenCoverRequests returns the open cover requests, earliest deadline
+// first.
+func (s *Store) ListOpenCoverRequests(ctx context.Context) ([]*store.CoverRequest, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var requests []*store.CoverRequest
+	for _, r := range s.covers {
+		if r.ResolvedAt == nil {
+			requests = append(requests, copyCoverRequest(r))
+		}
+	}
+	sort.SliceStable(requests, func(i, j int) bool { return requests[i].Deadline.Before(requests[j].Deadline) })
+	return requests, nil
+}
+
+// ResolveCoverRequest closes the cover request, taken over by coveredBy or by
+// nobody if it is nil.
+func (s *Store) ResolveCoverRequest(ctx context.Context, id int64, coveredBy *int64, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, r := range s.covers {
+		if r.ID == id {
+			resolvedAt := at.UTC().Truncate(time.Second)
+			r.ResolvedAt = &resolvedAt
+			r.CoveredBy = nil
+			if coveredBy != nil {
+				userID := *coveredBy
+				r.CoveredBy = &userID
+			}
+		}
+	}
+	return nil
+}
+
+// ReplaceWasteCollections replaces the imported waste-collection schedule.
+func (s *Store) ReplaceWasteCollections(ctx context.Context, collections []*store.WasteCollection) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.waste = nil
+	seen := make(map[string]bool, len(collections))
+	for _, c := range collections {
+		key := c.Date.Format("2006-01-02") + "|" + c.Bin
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		cp := *c
+		s.waste = append(s.waste, &cp)
+	}
+	return nil
+}
+
+// GetWasteCollections returns the waste collections from start up to, but
+// not including, end, ordered by date and bin.
+func (s *Store) GetWasteCollections(ctx context.Context, start, end time.Time) ([]*store.WasteCollection, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var collections []*store.WasteCollection
+	for _, c := range s.waste {
+		if !c.Date.Before(start) && c.Date.Before(end) {
+			cp := *c
+			collections = append(collections, &cp)
+		}
+	}
+	sort.Slice(collections, func(i, j int) bool {
+		if !collections[i].Date.Equal(collections[j].Date) {
+			return collections[i].Date.Before(collections[j].Date)
+		}
+		return collections[i].Bin < collections[j].Bin
+	})
+	return collections, nil
+}
+
+// GetRoundRobinStates returns the users a rotation picked before, least
+// recently picked first.
+func (s *Store) GetRoundRobinStates(ctx context.Context, rotation string) ([]*store.RoundRobinState, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	states := make([]*store.RoundRobinState, 0, len(s.rotations[rotation]))
+	for _, st := range s.rotations[rotation] {
+		cp := *st
+		states = append(states, &cp)
+	}
+	sort.Slice(states, func(i, j int) bool {
+		if !states[i].LastAssignedTimestamp.Equal(states[j].LastAssignedTimestamp) {
+			return states[i].LastAssignedTimestamp.Before(states[j].LastAssignedTimestamp)
+		}
+		return states[i].UserID < states[j].UserID
+	})
+	return states, nil
+}
+
+// RecordRoundRobinPick moves a rotation's cursor: it counts one more pick of
+// the user, made at the given time.
+func (s *Store) RecordRoundRobinPick(ctx context.Context, rotation string, userID int64, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.rotations[rotation] == nil {
+		s.rotations[rotation] = make(map[int64]*store.RoundRobinState)
+	}
+	st, ok := s.rotations[rotation][userID]
+	if !ok {
+		st = &store.RoundRobinState{Rotation: rotation, UserID: userID}
+		s.rotations[rotation][userID] = st
+	}
+	st.AssignmentCount++
+	st.LastAssignedTimestamp = at.UTC().Truncate(time.Second)
+	return nil
+}
+
+// DatabaseSize returns 0, as the store keeps no database.
+func (s *Store) DatabaseSize(ctx context.Context) (int64, error) {
+	return 0, nil
+}
+
+// Optimize does nothing, as there is no database to compact.
+func (s *Store) Optimize(ctx context.Context) error {
+	return nil
+}
+
+// DeleteLogsBefore deletes the shadow comparisons, assignment explanations
+// and maintenance runs of days before the given one, and returns how many
+// it deleted.
+func (s *Store) DeleteLogsBefore(ctx context.Context, before time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	day := dateKey(before)
+	n := len(s.comparisons) + len(s.explanations) + len(s.runs)
+	s.comparisons = slices.DeleteFunc(s.comparisons, func(c *store.ShadowComparison) bool { return dateKey(c.Date) < day })
+	maps.DeleteFunc(s.explanations, func(key string, _ *store.AssignmentExplanation) bool { return key < day })
+	s.runs = slices.DeleteFunc(s.runs, func(r *store.MaintenanceRun) bool { return dateKey(r.RanAt.UTC()) < day })
+	return n - len(s.comparisons) - len(s.explanations) - len(s.runs), nil
+}
+
+// CreateMaintenanceRun records a run and sets its ID.
+func (s *Store) CreateMaintenanceRun(ctx context.Context, run *store.MaintenanceRun) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.nextRunID++
+	run.ID = s.nextRunID
+	cp := *run
+	cp.RanAt = run.RanAt.UTC().Truncate(time.Second)
+	s.runs = append(s.runs, &cp)
+	return nil
+}
+
+// ListMaintenanceRuns returns the latest runs, at most limit, newest first.
+func (s *Store) ListMaintenanceRuns(ctx context.Context, limit int) ([]*store.MaintenanceRun, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	runs := make([]*store.MaintenanceRun, 0, len(s.runs))
+	for _, r := range s.runs {
+		cp := *r
+		runs = append(runs, &cp)
+	}
+	sort.SliceStable(runs, func(i, j int) bool {
+		if !runs[i].RanAt.Equal(runs[j].RanAt) {
+			return runs[i].RanAt.After(runs[j].RanAt)
+		}
+		return runs[i].ID > runs[j].ID
+	})
+	if len(runs) > limit {
+		runs = runs[:limit]
+	}
+	return runs, nil
+}
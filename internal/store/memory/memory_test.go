@@ -0,0 +1,100 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/korjavin/dutyassistant/internal/store"
+	"github.com/korjavin/dutyassistant/internal/store/storetest"
+)
+
+func TestStoreContract(t *testing.T) {
+	storetest.RunStoreTests(t, func(t *testing.T) store.Store { return New() })
+}
+
+func TestStore_ReturnsCopies(t *testing.T) {
+	s := New()
+	ctx := context.Background()
+
+	user := &store.User{TelegramUserID: 1, FirstName: "Alice", IsActive: true}
+	if err := s.CreateUser(ctx, user); err != nil {
+		t.Fatalf("CreateUser failed: %v", err)
+	}
+	user.FirstName = "Mallory"
+
+	got, _ := s.GetUserByTelegramID(ctx, 1)
+	if got.FirstName != "Alice" {
+		t.Errorf("Store was modified through the caller's pointer: %s", got.FirstName)
+	}
+	got.IsActive = false
+
+	again, _ := s.GetUserByTelegramID(ctx, 1)
+	if !again.IsActive {
+		t.Error("Store was modified through a returned value")
+	}
+}
+
+func TestStore_DeterministicOrdering(t *testing.T) {
+	s := New()
+	ctx := context.Background()
+
+	for i, name := range []string{"Charlie", "Alice", "Bob"} {
+		s.CreateUser(ctx, &store.User{TelegramUserID: int64(i + 1), FirstName: name, IsActive: true})
+	}
+
+	active, _ := s.ListActiveUsers(ctx)
+	all, _ := s.ListAllUsers(ctx)
+	for i, want := range []string{"Charlie", "Alice", "Bob"} {
+		if active[i].FirstName != want {
+			t.Errorf("ListActiveUsers[%d]: expected %s, got %s", i, want, active[i].FirstName)
+		}
+	}
+	for i, want := range []string{"Alice", "Bob", "Charlie"} {
+		if all[i].FirstName != want {
+			t.Errorf("ListAllUsers[%d]: expected %s, got %s", i, want, all[i].FirstName)
+		}
+	}
+}
+
+func TestStore_DuplicateDuty(t *testing.T) {
+	s := New()
+	ctx := context.Background()
+	date := time.Date(2025, 7, 14, 0, 0, 0, 0, time.UTC)
+
+	if err := s.CreateDuty(ctx, &store.Duty{UserID: 1, DutyDate: date, CreatedAt: time.Now()}); err != nil {
+		t.Fatalf("CreateDuty failed: %v", err)
+	}
+	if err := s.CreateDuty(ctx, &store.Duty{UserID: 2, DutyDate: date, CreatedAt: time.Now()}); err == nil {
+		t.Error("Expected an error for a second duty on the same date")
+	}
+}
+
+func TestStore_ConcurrentAccess(t *testing.T) {
+	s := New()
+	ctx := context.Background()
+	s.CreateUser(ctx, &store.User{TelegramUserID: 1, FirstName: "Alice", IsActive: true})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			s.AddToVolunteerQueue(ctx, 1, 1)
+			s.CreateUser(ctx, &store.User{TelegramUserID: int64(100 + i), FirstName: fmt.Sprintf("User%d", i)})
+			s.ListAllUsers(ctx)
+		}(i)
+	}
+	wg.Wait()
+
+	user, _ := s.GetUserByTelegramID(ctx, 1)
+	if user.VolunteerQueueDays != 50 {
+		t.Errorf("Expected 50 volunteer days, got %d", user.VolunteerQueueDays)
+	}
+	all, _ := s.ListAllUsers(ctx)
+	if len(all) != 51 {
+		t.Errorf("Expected 51 users, got %d", len(all))
+	}
+}
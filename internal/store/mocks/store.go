@@ -1,123 +1,3861 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/korjavin/dutyassistant/internal/store (interfaces: Store,UserStore,DutyStore,QueueStore,AvailabilityStore,NotificationStore,SettingStore,MaintenanceStore,TxStore)
+//
+// Generated by this command:
+//
+//	mockgen -destination=mocks/store.go -package=mocks . Store,UserStore,DutyStore,QueueStore,AvailabilityStore,NotificationStore,SettingStore,MaintenanceStore,TxStore
+//
+
+// Package mocks is a generated GoMock package.
 package mocks
 
 import (
-	"context"
-	"time"
+	context "context"
+	reflect "reflect"
+	time "time"
 
-	"github.com/korjavin/dutyassistant/internal/store"
-	"github.com/stretchr/testify/mock"
+	store "github.com/korjavin/dutyassistant/internal/store"
+	gomock "go.uber.org/mock/gomock"
 )
 
-// MockStore is a mock implementation of the store.Store interface,
-// to be used in unit tests.
+// MockStore is a mock of Store interface.
 type MockStore struct {
-	mock.Mock
+	ctrl     *gomock.Controller
+	recorder *MockStoreMockRecorder
+	isgomock struct{}
 }
 
-// GetUserByTelegramID mocks the GetUserByTelegramID method.
-func (m *MockStore) GetUserByTelegramID(ctx context.Context, id int64) (*store.User, error) {
-	args := m.Called(ctx, id)
-	if args.Get(0) == nil {
-		return nil, args.Error(1)
-	}
-	return args.Get(0).(*store.User), args.Error(1)
+// MockStoreMockRecorder is the mock recorder for MockStore.
+type MockStoreMockRecorder struct {
+	mock *MockStore
 }
 
-// ListActiveUsers mocks the ListActiveUsers method.
-func (m *MockStore) ListActiveUsers(ctx context.Context) ([]*store.User, error) {
-	args := m.Called(ctx)
-	if args.Get(0) == nil {
-		return nil, args.Error(1)
-	}
-	return args.Get(0).([]*store.User), args.Error(1)
+// NewMockStore creates a new mock instance.
+func NewMockStore(ctrl *gomock.Controller) *MockStore {
+	mock := &MockStore{ctrl: ctrl}
+	mock.recorder = &MockStoreMockRecorder{mock}
+	return mock
 }
 
-// ListAllUsers mocks the ListAllUsers method.
-func (m *MockStore) ListAllUsers(ctx context.Context) ([]*store.User, error) {
-	args := m.Called(ctx)
-	if args.Get(0) == nil {
-		return nil, args.Error(1)
-	}
-	return args.Get(0).([]*store.User), args.Error(1)
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockStore) EXPECT() *MockStoreMockRecorder {
+	return m.recorder
 }
 
-// CreateUser mocks the CreateUser method.
-func (m *MockStore) CreateUser(ctx context.Context, user *store.User) error {
-	args := m.Called(ctx, user)
-	return args.Error(0)
+// AddLedgerEntry mocks base method.
+func (m *MockStore) AddLedgerEntry(ctx context.Context, e *store.LedgerEntry) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddLedgerEntry", ctx, e)
+	ret0, _ := ret[0].(error)
+	return ret0
 }
 
-// UpdateUser mocks the UpdateUser method.
-func (m *MockStore) UpdateUser(ctx context.Context, user *store.User) error {
-	args := m.Called(ctx, user)
-	return args.Error(0)
+// AddLedgerEntry indicates an expected call of AddLedgerEntry.
+func (mr *MockStoreMockRecorder) AddLedgerEntry(ctx, e any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddLedgerEntry", reflect.TypeOf((*MockStore)(nil).AddLedgerEntry), ctx, e)
+}
+
+// AddToAdminQueue mocks base method.
+func (m *MockStore) AddToAdminQueue(ctx context.Context, userID int64, days int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddToAdminQueue", ctx, userID, days)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddToAdminQueue indicates an expected call of AddToAdminQueue.
+func (mr *MockStoreMockRecorder) AddToAdminQueue(ctx, userID, days any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddToAdminQueue", reflect.TypeOf((*MockStore)(nil).AddToAdminQueue), ctx, userID, days)
+}
+
+// AddToVolunteerQueue mocks base method.
+func (m *MockStore) AddToVolunteerQueue(ctx context.Context, userID int64, days int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddToVolunteerQueue", ctx, userID, days)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddToVolunteerQueue indicates an expected call of AddToVolunteerQueue.
+func (mr *MockStoreMockRecorder) AddToVolunteerQueue(ctx, userID, days any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddToVolunteerQueue", reflect.TypeOf((*MockStore)(nil).AddToVolunteerQueue), ctx, userID, days)
+}
+
+// AwardBadge mocks base method.
+func (m *MockStore) AwardBadge(ctx context.Context, b *store.Badge) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AwardBadge", ctx, b)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AwardBadge indicates an expected call of AwardBadge.
+func (mr *MockStoreMockRecorder) AwardBadge(ctx, b any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AwardBadge", reflect.TypeOf((*MockStore)(nil).AwardBadge), ctx, b)
+}
+
+// BackfillDuty mocks base method.
+func (m *MockStore) BackfillDuty(ctx context.Context, date time.Time, userID int64, at time.Time) (*store.Duty, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BackfillDuty", ctx, date, userID, at)
+	ret0, _ := ret[0].(*store.Duty)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BackfillDuty indicates an expected call of BackfillDuty.
+func (mr *MockStoreMockRecorder) BackfillDuty(ctx, date, userID, at any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BackfillDuty", reflect.TypeOf((*MockStore)(nil).BackfillDuty), ctx, date, userID, at)
+}
+
+// ClaimTask mocks base method.
+func (m *MockStore) ClaimTask(ctx context.Context, id, userID int64, at time.Time) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClaimTask", ctx, id, userID, at)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClaimTask indicates an expected call of ClaimTask.
+func (mr *MockStoreMockRecorder) ClaimTask(ctx, id, userID, at any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimTask", reflect.TypeOf((*MockStore)(nil).ClaimTask), ctx, id, userID, at)
+}
+
+// ClearOffDuty mocks base method.
+func (m *MockStore) ClearOffDuty(ctx context.Context, userID int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClearOffDuty", ctx, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ClearOffDuty indicates an expected call of ClearOffDuty.
+func (mr *MockStoreMockRecorder) ClearOffDuty(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClearOffDuty", reflect.TypeOf((*MockStore)(nil).ClearOffDuty), ctx, userID)
+}
+
+// CompleteDuty mocks base method.
+func (m *MockStore) CompleteDuty(ctx context.Context, date time.Time) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CompleteDuty", ctx, date)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
-// CreateDuty mocks the CreateDuty method.
+// CompleteDuty indicates an expected call of CompleteDuty.
+func (mr *MockStoreMockRecorder) CompleteDuty(ctx, date any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CompleteDuty", reflect.TypeOf((*MockStore)(nil).CompleteDuty), ctx, date)
+}
+
+// CompleteTask mocks base method.
+func (m *MockStore) CompleteTask(ctx context.Context, id, userID int64, at time.Time) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CompleteTask", ctx, id, userID, at)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CompleteTask indicates an expected call of CompleteTask.
+func (mr *MockStoreMockRecorder) CompleteTask(ctx, id, userID, at any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CompleteTask", reflect.TypeOf((*MockStore)(nil).CompleteTask), ctx, id, userID, at)
+}
+
+// ConsumeLoginCode mocks base method.
+func (m *MockStore) ConsumeLoginCode(ctx context.Context, codeHash string) (*store.LoginCode, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConsumeLoginCode", ctx, codeHash)
+	ret0, _ := ret[0].(*store.LoginCode)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ConsumeLoginCode indicates an expected call of ConsumeLoginCode.
+func (mr *MockStoreMockRecorder) ConsumeLoginCode(ctx, codeHash any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConsumeLoginCode", reflect.TypeOf((*MockStore)(nil).ConsumeLoginCode), ctx, codeHash)
+}
+
+// CreateBlackoutRule mocks base method.
+func (m *MockStore) CreateBlackoutRule(ctx context.Context, r *store.BlackoutRule) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateBlackoutRule", ctx, r)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateBlackoutRule indicates an expected call of CreateBlackoutRule.
+func (mr *MockStoreMockRecorder) CreateBlackoutRule(ctx, r any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateBlackoutRule", reflect.TypeOf((*MockStore)(nil).CreateBlackoutRule), ctx, r)
+}
+
+// CreateChangeSubscription mocks base method.
+func (m *MockStore) CreateChangeSubscription(ctx context.Context, sub *store.ChangeSubscription) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateChangeSubscription", ctx, sub)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateChangeSubscription indicates an expected call of CreateChangeSubscription.
+func (mr *MockStoreMockRecorder) CreateChangeSubscription(ctx, sub any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateChangeSubscription", reflect.TypeOf((*MockStore)(nil).CreateChangeSubscription), ctx, sub)
+}
+
+// CreateChecklistItem mocks base method.
+func (m *MockStore) CreateChecklistItem(ctx context.Context, item *store.ChecklistItem) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateChecklistItem", ctx, item)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateChecklistItem indicates an expected call of CreateChecklistItem.
+func (mr *MockStoreMockRecorder) CreateChecklistItem(ctx, item any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateChecklistItem", reflect.TypeOf((*MockStore)(nil).CreateChecklistItem), ctx, item)
+}
+
+// CreateCoverRequest mocks base method.
+func (m *MockStore) CreateCoverRequest(ctx context.Context, r *store.CoverRequest) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateCoverRequest", ctx, r)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateCoverRequest indicates an expected call of CreateCoverRequest.
+func (mr *MockStoreMockRecorder) CreateCoverRequest(ctx, r any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateCoverRequest", reflect.TypeOf((*MockStore)(nil).CreateCoverRequest), ctx, r)
+}
+
+// CreateDuty mocks base method.
 func (m *MockStore) CreateDuty(ctx context.Context, duty *store.Duty) error {
-	args := m.Called(ctx, duty)
-	return args.Error(0)
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateDuty", ctx, duty)
+	ret0, _ := ret[0].(error)
+	return ret0
 }
 
-// GetDutyByDate mocks the GetDutyByDate method.
-func (m *MockStore) GetDutyByDate(ctx context.Context, date time.Time) (*store.Duty, error) {
-	args := m.Called(ctx, date)
-	if args.Get(0) == nil {
-		return nil, args.Error(1)
-	}
-	return args.Get(0).(*store.Duty), args.Error(1)
+// CreateDuty indicates an expected call of CreateDuty.
+func (mr *MockStoreMockRecorder) CreateDuty(ctx, duty any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateDuty", reflect.TypeOf((*MockStore)(nil).CreateDuty), ctx, duty)
 }
 
-// GetDutiesByMonth mocks the GetDutiesByMonth method.
-func (m *MockStore) GetDutiesByMonth(ctx context.Context, year int, month time.Month) ([]*store.Duty, error) {
-	args := m.Called(ctx, year, month)
-	if args.Get(0) == nil {
-		return nil, args.Error(1)
-	}
-	return args.Get(0).([]*store.Duty), args.Error(1)
+// CreateDutyChange mocks base method.
+func (m *MockStore) CreateDutyChange(ctx context.Context, change *store.DutyChange) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateDutyChange", ctx, change)
+	ret0, _ := ret[0].(error)
+	return ret0
 }
 
-// UpdateDuty mocks the UpdateDuty method.
-func (m *MockStore) UpdateDuty(ctx context.Context, duty *store.Duty) error {
-	args := m.Called(ctx, duty)
-	return args.Error(0)
+// CreateDutyChange indicates an expected call of CreateDutyChange.
+func (mr *MockStoreMockRecorder) CreateDutyChange(ctx, change any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateDutyChange", reflect.TypeOf((*MockStore)(nil).CreateDutyChange), ctx, change)
+}
+
+// CreateInvite mocks base method.
+func (m *MockStore) CreateInvite(ctx context.Context, invite *store.Invite) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateInvite", ctx, invite)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateInvite indicates an expected call of CreateInvite.
+func (mr *MockStoreMockRecorder) CreateInvite(ctx, invite any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateInvite", reflect.TypeOf((*MockStore)(nil).CreateInvite), ctx, invite)
+}
+
+// CreateLoginCode mocks base method.
+func (m *MockStore) CreateLoginCode(ctx context.Context, code *store.LoginCode) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateLoginCode", ctx, code)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateLoginCode indicates an expected call of CreateLoginCode.
+func (mr *MockStoreMockRecorder) CreateLoginCode(ctx, code any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateLoginCode", reflect.TypeOf((*MockStore)(nil).CreateLoginCode), ctx, code)
+}
+
+// CreateMaintenanceRun mocks base method.
+func (m *MockStore) CreateMaintenanceRun(ctx context.Context, run *store.MaintenanceRun) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateMaintenanceRun", ctx, run)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateMaintenanceRun indicates an expected call of CreateMaintenanceRun.
+func (mr *MockStoreMockRecorder) CreateMaintenanceRun(ctx, run any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateMaintenanceRun", reflect.TypeOf((*MockStore)(nil).CreateMaintenanceRun), ctx, run)
+}
+
+// CreateNoteTemplate mocks base method.
+func (m *MockStore) CreateNoteTemplate(ctx context.Context, t *store.NoteTemplate) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateNoteTemplate", ctx, t)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateNoteTemplate indicates an expected call of CreateNoteTemplate.
+func (mr *MockStoreMockRecorder) CreateNoteTemplate(ctx, t any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateNoteTemplate", reflect.TypeOf((*MockStore)(nil).CreateNoteTemplate), ctx, t)
+}
+
+// CreatePendingMessage mocks base method.
+func (m *MockStore) CreatePendingMessage(ctx context.Context, msg *store.PendingMessage) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreatePendingMessage", ctx, msg)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreatePendingMessage indicates an expected call of CreatePendingMessage.
+func (mr *MockStoreMockRecorder) CreatePendingMessage(ctx, msg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreatePendingMessage", reflect.TypeOf((*MockStore)(nil).CreatePendingMessage), ctx, msg)
+}
+
+// CreateReminderSnooze mocks base method.
+func (m *MockStore) CreateReminderSnooze(ctx context.Context, snooze *store.ReminderSnooze) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateReminderSnooze", ctx, snooze)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateReminderSnooze indicates an expected call of CreateReminderSnooze.
+func (mr *MockStoreMockRecorder) CreateReminderSnooze(ctx, snooze any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateReminderSnooze", reflect.TypeOf((*MockStore)(nil).CreateReminderSnooze), ctx, snooze)
+}
+
+// CreateScheduleVersion mocks base method.
+func (m *MockStore) CreateScheduleVersion(ctx context.Context, v *store.ScheduleVersion) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateScheduleVersion", ctx, v)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateScheduleVersion indicates an expected call of CreateScheduleVersion.
+func (mr *MockStoreMockRecorder) CreateScheduleVersion(ctx, v any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateScheduleVersion", reflect.TypeOf((*MockStore)(nil).CreateScheduleVersion), ctx, v)
+}
+
+// CreateShadowComparison mocks base method.
+func (m *MockStore) CreateShadowComparison(ctx context.Context, c *store.ShadowComparison) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateShadowComparison", ctx, c)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateShadowComparison indicates an expected call of CreateShadowComparison.
+func (mr *MockStoreMockRecorder) CreateShadowComparison(ctx, c any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateShadowComparison", reflect.TypeOf((*MockStore)(nil).CreateShadowComparison), ctx, c)
+}
+
+// CreateTask mocks base method.
+func (m *MockStore) CreateTask(ctx context.Context, t *store.Task) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateTask", ctx, t)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateTask indicates an expected call of CreateTask.
+func (mr *MockStoreMockRecorder) CreateTask(ctx, t any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateTask", reflect.TypeOf((*MockStore)(nil).CreateTask), ctx, t)
+}
+
+// CreateUser mocks base method.
+func (m *MockStore) CreateUser(ctx context.Context, user *store.User) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateUser", ctx, user)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateUser indicates an expected call of CreateUser.
+func (mr *MockStoreMockRecorder) CreateUser(ctx, user any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUser", reflect.TypeOf((*MockStore)(nil).CreateUser), ctx, user)
+}
+
+// CreateWebSession mocks base method.
+func (m *MockStore) CreateWebSession(ctx context.Context, session *store.WebSession) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateWebSession", ctx, session)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateWebSession indicates an expected call of CreateWebSession.
+func (mr *MockStoreMockRecorder) CreateWebSession(ctx, session any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateWebSession", reflect.TypeOf((*MockStore)(nil).CreateWebSession), ctx, session)
+}
+
+// DatabaseSize mocks base method.
+func (m *MockStore) DatabaseSize(ctx context.Context) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DatabaseSize", ctx)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DatabaseSize indicates an expected call of DatabaseSize.
+func (mr *MockStoreMockRecorder) DatabaseSize(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DatabaseSize", reflect.TypeOf((*MockStore)(nil).DatabaseSize), ctx)
+}
+
+// DecrementAdminQueue mocks base method.
+func (m *MockStore) DecrementAdminQueue(ctx context.Context, userID int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DecrementAdminQueue", ctx, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DecrementAdminQueue indicates an expected call of DecrementAdminQueue.
+func (mr *MockStoreMockRecorder) DecrementAdminQueue(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DecrementAdminQueue", reflect.TypeOf((*MockStore)(nil).DecrementAdminQueue), ctx, userID)
+}
+
+// DecrementVolunteerQueue mocks base method.
+func (m *MockStore) DecrementVolunteerQueue(ctx context.Context, userID int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DecrementVolunteerQueue", ctx, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DecrementVolunteerQueue indicates an expected call of DecrementVolunteerQueue.
+func (mr *MockStoreMockRecorder) DecrementVolunteerQueue(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DecrementVolunteerQueue", reflect.TypeOf((*MockStore)(nil).DecrementVolunteerQueue), ctx, userID)
+}
+
+// DeleteBlackoutRule mocks base method.
+func (m *MockStore) DeleteBlackoutRule(ctx context.Context, id int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteBlackoutRule", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteBlackoutRule indicates an expected call of DeleteBlackoutRule.
+func (mr *MockStoreMockRecorder) DeleteBlackoutRule(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteBlackoutRule", reflect.TypeOf((*MockStore)(nil).DeleteBlackoutRule), ctx, id)
+}
+
+// DeleteCalendarLink mocks base method.
+func (m *MockStore) DeleteCalendarLink(ctx context.Context, userID int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteCalendarLink", ctx, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteCalendarLink indicates an expected call of DeleteCalendarLink.
+func (mr *MockStoreMockRecorder) DeleteCalendarLink(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteCalendarLink", reflect.TypeOf((*MockStore)(nil).DeleteCalendarLink), ctx, userID)
+}
+
+// DeleteChangeSubscription mocks base method.
+func (m *MockStore) DeleteChangeSubscription(ctx context.Context, userID int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteChangeSubscription", ctx, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
 }
 
-// DeleteDuty mocks the DeleteDuty method.
+// DeleteChangeSubscription indicates an expected call of DeleteChangeSubscription.
+func (mr *MockStoreMockRecorder) DeleteChangeSubscription(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteChangeSubscription", reflect.TypeOf((*MockStore)(nil).DeleteChangeSubscription), ctx, userID)
+}
+
+// DeleteChecklistCheck mocks base method.
+func (m *MockStore) DeleteChecklistCheck(ctx context.Context, date time.Time, itemID int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteChecklistCheck", ctx, date, itemID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteChecklistCheck indicates an expected call of DeleteChecklistCheck.
+func (mr *MockStoreMockRecorder) DeleteChecklistCheck(ctx, date, itemID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteChecklistCheck", reflect.TypeOf((*MockStore)(nil).DeleteChecklistCheck), ctx, date, itemID)
+}
+
+// DeleteChecklistItem mocks base method.
+func (m *MockStore) DeleteChecklistItem(ctx context.Context, id int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteChecklistItem", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteChecklistItem indicates an expected call of DeleteChecklistItem.
+func (mr *MockStoreMockRecorder) DeleteChecklistItem(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteChecklistItem", reflect.TypeOf((*MockStore)(nil).DeleteChecklistItem), ctx, id)
+}
+
+// DeleteConversationState mocks base method.
+func (m *MockStore) DeleteConversationState(ctx context.Context, key string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteConversationState", ctx, key)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteConversationState indicates an expected call of DeleteConversationState.
+func (mr *MockStoreMockRecorder) DeleteConversationState(ctx, key any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteConversationState", reflect.TypeOf((*MockStore)(nil).DeleteConversationState), ctx, key)
+}
+
+// DeleteDuty mocks base method.
 func (m *MockStore) DeleteDuty(ctx context.Context, date time.Time) error {
-	args := m.Called(ctx, date)
-	return args.Error(0)
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteDuty", ctx, date)
+	ret0, _ := ret[0].(error)
+	return ret0
 }
 
-// GetNextRoundRobinUser mocks the GetNextRoundRobinUser method.
-func (m *MockStore) GetNextRoundRobinUser(ctx context.Context) (*store.User, error) {
-	args := m.Called(ctx)
-	if args.Get(0) == nil {
-		return nil, args.Error(1)
-	}
-	return args.Get(0).(*store.User), args.Error(1)
+// DeleteDuty indicates an expected call of DeleteDuty.
+func (mr *MockStoreMockRecorder) DeleteDuty(ctx, date any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteDuty", reflect.TypeOf((*MockStore)(nil).DeleteDuty), ctx, date)
 }
 
-// IncrementAssignmentCount mocks the IncrementAssignmentCount method.
-func (m *MockStore) IncrementAssignmentCount(ctx context.Context, userID int64, lastAssigned time.Time) error {
-	args := m.Called(ctx, userID, lastAssigned)
-	return args.Error(0)
+// DeleteExpiredConversationStates mocks base method.
+func (m *MockStore) DeleteExpiredConversationStates(ctx context.Context, now time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteExpiredConversationStates", ctx, now)
+	ret0, _ := ret[0].(error)
+	return ret0
 }
 
-// GetUserByName mocks the GetUserByName method.
-func (m *MockStore) GetUserByName(ctx context.Context, name string) (*store.User, error) {
-	args := m.Called(ctx, name)
-	if args.Get(0) == nil {
-		return nil, args.Error(1)
-	}
-	return args.Get(0).(*store.User), args.Error(1)
+// DeleteExpiredConversationStates indicates an expected call of DeleteExpiredConversationStates.
+func (mr *MockStoreMockRecorder) DeleteExpiredConversationStates(ctx, now any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteExpiredConversationStates", reflect.TypeOf((*MockStore)(nil).DeleteExpiredConversationStates), ctx, now)
 }
 
-// GetUserStats mocks the GetUserStats method.
-func (m *MockStore) GetUserStats(ctx context.Context, userID int64) (*store.UserStats, error) {
-	args := m.Called(ctx, userID)
-	if args.Get(0) == nil {
-		return nil, args.Error(1)
-	}
-	return args.Get(0).(*store.UserStats), args.Error(1)
-}
\ No newline at end of file
+// DeleteExpiredLogins mocks base method.
+func (m *MockStore) DeleteExpiredLogins(ctx context.Context, now time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteExpiredLogins", ctx, now)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteExpiredLogins indicates an expected call of DeleteExpiredLogins.
+func (mr *MockStoreMockRecorder) DeleteExpiredLogins(ctx, now any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteExpiredLogins", reflect.TypeOf((*MockStore)(nil).DeleteExpiredLogins), ctx, now)
+}
+
+// DeleteInteractiveMessage mocks base method.
+func (m *MockStore) DeleteInteractiveMessage(ctx context.Context, chatID int64, messageID int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteInteractiveMessage", ctx, chatID, messageID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteInteractiveMessage indicates an expected call of DeleteInteractiveMessage.
+func (mr *MockStoreMockRecorder) DeleteInteractiveMessage(ctx, chatID, messageID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteInteractiveMessage", reflect.TypeOf((*MockStore)(nil).DeleteInteractiveMessage), ctx, chatID, messageID)
+}
+
+// DeleteLogsBefore mocks base method.
+func (m *MockStore) DeleteLogsBefore(ctx context.Context, before time.Time) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteLogsBefore", ctx, before)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteLogsBefore indicates an expected call of DeleteLogsBefore.
+func (mr *MockStoreMockRecorder) DeleteLogsBefore(ctx, before any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteLogsBefore", reflect.TypeOf((*MockStore)(nil).DeleteLogsBefore), ctx, before)
+}
+
+// DeleteNoteTemplate mocks base method.
+func (m *MockStore) DeleteNoteTemplate(ctx context.Context, id int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteNoteTemplate", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteNoteTemplate indicates an expected call of DeleteNoteTemplate.
+func (mr *MockStoreMockRecorder) DeleteNoteTemplate(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteNoteTemplate", reflect.TypeOf((*MockStore)(nil).DeleteNoteTemplate), ctx, id)
+}
+
+// DeletePendingMessage mocks base method.
+func (m *MockStore) DeletePendingMessage(ctx context.Context, id int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeletePendingMessage", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeletePendingMessage indicates an expected call of DeletePendingMessage.
+func (mr *MockStoreMockRecorder) DeletePendingMessage(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeletePendingMessage", reflect.TypeOf((*MockStore)(nil).DeletePendingMessage), ctx, id)
+}
+
+// DeleteReminderSnooze mocks base method.
+func (m *MockStore) DeleteReminderSnooze(ctx context.Context, id int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteReminderSnooze", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteReminderSnooze indicates an expected call of DeleteReminderSnooze.
+func (mr *MockStoreMockRecorder) DeleteReminderSnooze(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteReminderSnooze", reflect.TypeOf((*MockStore)(nil).DeleteReminderSnooze), ctx, id)
+}
+
+// DeleteSkipDay mocks base method.
+func (m *MockStore) DeleteSkipDay(ctx context.Context, date time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteSkipDay", ctx, date)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteSkipDay indicates an expected call of DeleteSkipDay.
+func (mr *MockStoreMockRecorder) DeleteSkipDay(ctx, date any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteSkipDay", reflect.TypeOf((*MockStore)(nil).DeleteSkipDay), ctx, date)
+}
+
+// DeleteTask mocks base method.
+func (m *MockStore) DeleteTask(ctx context.Context, id int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteTask", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteTask indicates an expected call of DeleteTask.
+func (mr *MockStoreMockRecorder) DeleteTask(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteTask", reflect.TypeOf((*MockStore)(nil).DeleteTask), ctx, id)
+}
+
+// DeleteUser mocks base method.
+func (m *MockStore) DeleteUser(ctx context.Context, id int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteUser", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteUser indicates an expected call of DeleteUser.
+func (mr *MockStoreMockRecorder) DeleteUser(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUser", reflect.TypeOf((*MockStore)(nil).DeleteUser), ctx, id)
+}
+
+// DeleteWebSession mocks base method.
+func (m *MockStore) DeleteWebSession(ctx context.Context, tokenHash string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteWebSession", ctx, tokenHash)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteWebSession indicates an expected call of DeleteWebSession.
+func (mr *MockStoreMockRecorder) DeleteWebSession(ctx, tokenHash any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteWebSession", reflect.TypeOf((*MockStore)(nil).DeleteWebSession), ctx, tokenHash)
+}
+
+// GetAssignmentExplanation mocks base method.
+func (m *MockStore) GetAssignmentExplanation(ctx context.Context, date time.Time) (*store.AssignmentExplanation, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAssignmentExplanation", ctx, date)
+	ret0, _ := ret[0].(*store.AssignmentExplanation)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAssignmentExplanation indicates an expected call of GetAssignmentExplanation.
+func (mr *MockStoreMockRecorder) GetAssignmentExplanation(ctx, date any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAssignmentExplanation", reflect.TypeOf((*MockStore)(nil).GetAssignmentExplanation), ctx, date)
+}
+
+// GetCalendarLink mocks base method.
+func (m *MockStore) GetCalendarLink(ctx context.Context, userID int64) (*store.CalendarLink, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCalendarLink", ctx, userID)
+	ret0, _ := ret[0].(*store.CalendarLink)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCalendarLink indicates an expected call of GetCalendarLink.
+func (mr *MockStoreMockRecorder) GetCalendarLink(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCalendarLink", reflect.TypeOf((*MockStore)(nil).GetCalendarLink), ctx, userID)
+}
+
+// GetChangeSubscription mocks base method.
+func (m *MockStore) GetChangeSubscription(ctx context.Context, userID int64) (*store.ChangeSubscription, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetChangeSubscription", ctx, userID)
+	ret0, _ := ret[0].(*store.ChangeSubscription)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetChangeSubscription indicates an expected call of GetChangeSubscription.
+func (mr *MockStoreMockRecorder) GetChangeSubscription(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetChangeSubscription", reflect.TypeOf((*MockStore)(nil).GetChangeSubscription), ctx, userID)
+}
+
+// GetChatDisplay mocks base method.
+func (m *MockStore) GetChatDisplay(ctx context.Context, chatID int64) (*store.ChatDisplay, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetChatDisplay", ctx, chatID)
+	ret0, _ := ret[0].(*store.ChatDisplay)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetChatDisplay indicates an expected call of GetChatDisplay.
+func (mr *MockStoreMockRecorder) GetChatDisplay(ctx, chatID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetChatDisplay", reflect.TypeOf((*MockStore)(nil).GetChatDisplay), ctx, chatID)
+}
+
+// GetChatLocale mocks base method.
+func (m *MockStore) GetChatLocale(ctx context.Context, chatID int64) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetChatLocale", ctx, chatID)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetChatLocale indicates an expected call of GetChatLocale.
+func (mr *MockStoreMockRecorder) GetChatLocale(ctx, chatID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetChatLocale", reflect.TypeOf((*MockStore)(nil).GetChatLocale), ctx, chatID)
+}
+
+// GetChecklistChecks mocks base method.
+func (m *MockStore) GetChecklistChecks(ctx context.Context, date time.Time) ([]*store.ChecklistCheck, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetChecklistChecks", ctx, date)
+	ret0, _ := ret[0].([]*store.ChecklistCheck)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetChecklistChecks indicates an expected call of GetChecklistChecks.
+func (mr *MockStoreMockRecorder) GetChecklistChecks(ctx, date any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetChecklistChecks", reflect.TypeOf((*MockStore)(nil).GetChecklistChecks), ctx, date)
+}
+
+// GetCompletedDutiesInRange mocks base method.
+func (m *MockStore) GetCompletedDutiesInRange(ctx context.Context, start, end time.Time) ([]*store.Duty, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCompletedDutiesInRange", ctx, start, end)
+	ret0, _ := ret[0].([]*store.Duty)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCompletedDutiesInRange indicates an expected call of GetCompletedDutiesInRange.
+func (mr *MockStoreMockRecorder) GetCompletedDutiesInRange(ctx, start, end any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCompletedDutiesInRange", reflect.TypeOf((*MockStore)(nil).GetCompletedDutiesInRange), ctx, start, end)
+}
+
+// GetDutiesByMonth mocks base method.
+func (m *MockStore) GetDutiesByMonth(ctx context.Context, year int, month time.Month) ([]*store.Duty, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDutiesByMonth", ctx, year, month)
+	ret0, _ := ret[0].([]*store.Duty)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDutiesByMonth indicates an expected call of GetDutiesByMonth.
+func (mr *MockStoreMockRecorder) GetDutiesByMonth(ctx, year, month any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDutiesByMonth", reflect.TypeOf((*MockStore)(nil).GetDutiesByMonth), ctx, year, month)
+}
+
+// GetDutyByDate mocks base method.
+func (m *MockStore) GetDutyByDate(ctx context.Context, date time.Time) (*store.Duty, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDutyByDate", ctx, date)
+	ret0, _ := ret[0].(*store.Duty)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDutyByDate indicates an expected call of GetDutyByDate.
+func (mr *MockStoreMockRecorder) GetDutyByDate(ctx, date any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDutyByDate", reflect.TypeOf((*MockStore)(nil).GetDutyByDate), ctx, date)
+}
+
+// GetExpiredHolds mocks base method.
+func (m *MockStore) GetExpiredHolds(ctx context.Context, today time.Time) ([]*store.Duty, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetExpiredHolds", ctx, today)
+	ret0, _ := ret[0].([]*store.Duty)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetExpiredHolds indicates an expected call of GetExpiredHolds.
+func (mr *MockStoreMockRecorder) GetExpiredHolds(ctx, today any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetExpiredHolds", reflect.TypeOf((*MockStore)(nil).GetExpiredHolds), ctx, today)
+}
+
+// GetInviteUsedBy mocks base method.
+func (m *MockStore) GetInviteUsedBy(ctx context.Context, telegramUserID int64) (*store.Invite, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetInviteUsedBy", ctx, telegramUserID)
+	ret0, _ := ret[0].(*store.Invite)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetInviteUsedBy indicates an expected call of GetInviteUsedBy.
+func (mr *MockStoreMockRecorder) GetInviteUsedBy(ctx, telegramUserID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetInviteUsedBy", reflect.TypeOf((*MockStore)(nil).GetInviteUsedBy), ctx, telegramUserID)
+}
+
+// GetNotificationPreferences mocks base method.
+func (m *MockStore) GetNotificationPreferences(ctx context.Context, userID int64) (*store.NotificationPreferences, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetNotificationPreferences", ctx, userID)
+	ret0, _ := ret[0].(*store.NotificationPreferences)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetNotificationPreferences indicates an expected call of GetNotificationPreferences.
+func (mr *MockStoreMockRecorder) GetNotificationPreferences(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetNotificationPreferences", reflect.TypeOf((*MockStore)(nil).GetNotificationPreferences), ctx, userID)
+}
+
+// GetOffDutyUsers mocks base method.
+func (m *MockStore) GetOffDutyUsers(ctx context.Context, date time.Time) ([]*store.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOffDutyUsers", ctx, date)
+	ret0, _ := ret[0].([]*store.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOffDutyUsers indicates an expected call of GetOffDutyUsers.
+func (mr *MockStoreMockRecorder) GetOffDutyUsers(ctx, date any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOffDutyUsers", reflect.TypeOf((*MockStore)(nil).GetOffDutyUsers), ctx, date)
+}
+
+// GetOpenCoverRequest mocks base method.
+func (m *MockStore) GetOpenCoverRequest(ctx context.Context, date time.Time) (*store.CoverRequest, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOpenCoverRequest", ctx, date)
+	ret0, _ := ret[0].(*store.CoverRequest)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOpenCoverRequest indicates an expected call of GetOpenCoverRequest.
+func (mr *MockStoreMockRecorder) GetOpenCoverRequest(ctx, date any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOpenCoverRequest", reflect.TypeOf((*MockStore)(nil).GetOpenCoverRequest), ctx, date)
+}
+
+// GetRecentDutyChanges mocks base method.
+func (m *MockStore) GetRecentDutyChanges(ctx context.Context, limit int) ([]*store.DutyChange, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRecentDutyChanges", ctx, limit)
+	ret0, _ := ret[0].([]*store.DutyChange)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRecentDutyChanges indicates an expected call of GetRecentDutyChanges.
+func (mr *MockStoreMockRecorder) GetRecentDutyChanges(ctx, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRecentDutyChanges", reflect.TypeOf((*MockStore)(nil).GetRecentDutyChanges), ctx, limit)
+}
+
+// GetRoundRobinStates mocks base method.
+func (m *MockStore) GetRoundRobinStates(ctx context.Context, rotation string) ([]*store.RoundRobinState, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRoundRobinStates", ctx, rotation)
+	ret0, _ := ret[0].([]*store.RoundRobinState)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRoundRobinStates indicates an expected call of GetRoundRobinStates.
+func (mr *MockStoreMockRecorder) GetRoundRobinStates(ctx, rotation any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRoundRobinStates", reflect.TypeOf((*MockStore)(nil).GetRoundRobinStates), ctx, rotation)
+}
+
+// GetSetting mocks base method.
+func (m *MockStore) GetSetting(ctx context.Context, key string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSetting", ctx, key)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSetting indicates an expected call of GetSetting.
+func (mr *MockStoreMockRecorder) GetSetting(ctx, key any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSetting", reflect.TypeOf((*MockStore)(nil).GetSetting), ctx, key)
+}
+
+// GetSkipDay mocks base method.
+func (m *MockStore) GetSkipDay(ctx context.Context, date time.Time) (*store.SkipDay, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSkipDay", ctx, date)
+	ret0, _ := ret[0].(*store.SkipDay)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSkipDay indicates an expected call of GetSkipDay.
+func (mr *MockStoreMockRecorder) GetSkipDay(ctx, date any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSkipDay", reflect.TypeOf((*MockStore)(nil).GetSkipDay), ctx, date)
+}
+
+// GetSkipDaysByMonth mocks base method.
+func (m *MockStore) GetSkipDaysByMonth(ctx context.Context, year int, month time.Month) ([]*store.SkipDay, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSkipDaysByMonth", ctx, year, month)
+	ret0, _ := ret[0].([]*store.SkipDay)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSkipDaysByMonth indicates an expected call of GetSkipDaysByMonth.
+func (mr *MockStoreMockRecorder) GetSkipDaysByMonth(ctx, year, month any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSkipDaysByMonth", reflect.TypeOf((*MockStore)(nil).GetSkipDaysByMonth), ctx, year, month)
+}
+
+// GetTask mocks base method.
+func (m *MockStore) GetTask(ctx context.Context, id int64) (*store.Task, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTask", ctx, id)
+	ret0, _ := ret[0].(*store.Task)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTask indicates an expected call of GetTask.
+func (mr *MockStoreMockRecorder) GetTask(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTask", reflect.TypeOf((*MockStore)(nil).GetTask), ctx, id)
+}
+
+// GetTodaysDuty mocks base method.
+func (m *MockStore) GetTodaysDuty(ctx context.Context) (*store.Duty, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTodaysDuty", ctx)
+	ret0, _ := ret[0].(*store.Duty)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTodaysDuty indicates an expected call of GetTodaysDuty.
+func (mr *MockStoreMockRecorder) GetTodaysDuty(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTodaysDuty", reflect.TypeOf((*MockStore)(nil).GetTodaysDuty), ctx)
+}
+
+// GetUserByName mocks base method.
+func (m *MockStore) GetUserByName(ctx context.Context, name string) (*store.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserByName", ctx, name)
+	ret0, _ := ret[0].(*store.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserByName indicates an expected call of GetUserByName.
+func (mr *MockStoreMockRecorder) GetUserByName(ctx, name any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserByName", reflect.TypeOf((*MockStore)(nil).GetUserByName), ctx, name)
+}
+
+// GetUserByTelegramID mocks base method.
+func (m *MockStore) GetUserByTelegramID(ctx context.Context, id int64) (*store.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserByTelegramID", ctx, id)
+	ret0, _ := ret[0].(*store.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserByTelegramID indicates an expected call of GetUserByTelegramID.
+func (mr *MockStoreMockRecorder) GetUserByTelegramID(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserByTelegramID", reflect.TypeOf((*MockStore)(nil).GetUserByTelegramID), ctx, id)
+}
+
+// GetUserStats mocks base method.
+func (m *MockStore) GetUserStats(ctx context.Context, userID int64) (*store.UserStats, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserStats", ctx, userID)
+	ret0, _ := ret[0].(*store.UserStats)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserStats indicates an expected call of GetUserStats.
+func (mr *MockStoreMockRecorder) GetUserStats(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserStats", reflect.TypeOf((*MockStore)(nil).GetUserStats), ctx, userID)
+}
+
+// GetUsersWithAdminQueue mocks base method.
+func (m *MockStore) GetUsersWithAdminQueue(ctx context.Context) ([]*store.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUsersWithAdminQueue", ctx)
+	ret0, _ := ret[0].([]*store.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUsersWithAdminQueue indicates an expected call of GetUsersWithAdminQueue.
+func (mr *MockStoreMockRecorder) GetUsersWithAdminQueue(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUsersWithAdminQueue", reflect.TypeOf((*MockStore)(nil).GetUsersWithAdminQueue), ctx)
+}
+
+// GetUsersWithVolunteerQueue mocks base method.
+func (m *MockStore) GetUsersWithVolunteerQueue(ctx context.Context) ([]*store.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUsersWithVolunteerQueue", ctx)
+	ret0, _ := ret[0].([]*store.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUsersWithVolunteerQueue indicates an expected call of GetUsersWithVolunteerQueue.
+func (mr *MockStoreMockRecorder) GetUsersWithVolunteerQueue(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUsersWithVolunteerQueue", reflect.TypeOf((*MockStore)(nil).GetUsersWithVolunteerQueue), ctx)
+}
+
+// GetWasteCollections mocks base method.
+func (m *MockStore) GetWasteCollections(ctx context.Context, start, end time.Time) ([]*store.WasteCollection, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWasteCollections", ctx, start, end)
+	ret0, _ := ret[0].([]*store.WasteCollection)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetWasteCollections indicates an expected call of GetWasteCollections.
+func (mr *MockStoreMockRecorder) GetWasteCollections(ctx, start, end any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWasteCollections", reflect.TypeOf((*MockStore)(nil).GetWasteCollections), ctx, start, end)
+}
+
+// GetWebSession mocks base method.
+func (m *MockStore) GetWebSession(ctx context.Context, tokenHash string) (*store.WebSession, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWebSession", ctx, tokenHash)
+	ret0, _ := ret[0].(*store.WebSession)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetWebSession indicates an expected call of GetWebSession.
+func (mr *MockStoreMockRecorder) GetWebSession(ctx, tokenHash any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWebSession", reflect.TypeOf((*MockStore)(nil).GetWebSession), ctx, tokenHash)
+}
+
+// IsUserOffDuty mocks base method.
+func (m *MockStore) IsUserOffDuty(ctx context.Context, userID int64, date time.Time) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsUserOffDuty", ctx, userID, date)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IsUserOffDuty indicates an expected call of IsUserOffDuty.
+func (mr *MockStoreMockRecorder) IsUserOffDuty(ctx, userID, date any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsUserOffDuty", reflect.TypeOf((*MockStore)(nil).IsUserOffDuty), ctx, userID, date)
+}
+
+// ListActiveUsers mocks base method.
+func (m *MockStore) ListActiveUsers(ctx context.Context) ([]*store.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListActiveUsers", ctx)
+	ret0, _ := ret[0].([]*store.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListActiveUsers indicates an expected call of ListActiveUsers.
+func (mr *MockStoreMockRecorder) ListActiveUsers(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListActiveUsers", reflect.TypeOf((*MockStore)(nil).ListActiveUsers), ctx)
+}
+
+// ListAllUsers mocks base method.
+func (m *MockStore) ListAllUsers(ctx context.Context) ([]*store.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAllUsers", ctx)
+	ret0, _ := ret[0].([]*store.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListAllUsers indicates an expected call of ListAllUsers.
+func (mr *MockStoreMockRecorder) ListAllUsers(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAllUsers", reflect.TypeOf((*MockStore)(nil).ListAllUsers), ctx)
+}
+
+// ListBadges mocks base method.
+func (m *MockStore) ListBadges(ctx context.Context, userID int64) ([]*store.Badge, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListBadges", ctx, userID)
+	ret0, _ := ret[0].([]*store.Badge)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListBadges indicates an expected call of ListBadges.
+func (mr *MockStoreMockRecorder) ListBadges(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListBadges", reflect.TypeOf((*MockStore)(nil).ListBadges), ctx, userID)
+}
+
+// ListBlackoutRules mocks base method.
+func (m *MockStore) ListBlackoutRules(ctx context.Context) ([]*store.BlackoutRule, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListBlackoutRules", ctx)
+	ret0, _ := ret[0].([]*store.BlackoutRule)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListBlackoutRules indicates an expected call of ListBlackoutRules.
+func (mr *MockStoreMockRecorder) ListBlackoutRules(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListBlackoutRules", reflect.TypeOf((*MockStore)(nil).ListBlackoutRules), ctx)
+}
+
+// ListCalendarLinks mocks base method.
+func (m *MockStore) ListCalendarLinks(ctx context.Context) ([]*store.CalendarLink, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListCalendarLinks", ctx)
+	ret0, _ := ret[0].([]*store.CalendarLink)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListCalendarLinks indicates an expected call of ListCalendarLinks.
+func (mr *MockStoreMockRecorder) ListCalendarLinks(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListCalendarLinks", reflect.TypeOf((*MockStore)(nil).ListCalendarLinks), ctx)
+}
+
+// ListChecklistItems mocks base method.
+func (m *MockStore) ListChecklistItems(ctx context.Context) ([]*store.ChecklistItem, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListChecklistItems", ctx)
+	ret0, _ := ret[0].([]*store.ChecklistItem)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListChecklistItems indicates an expected call of ListChecklistItems.
+func (mr *MockStoreMockRecorder) ListChecklistItems(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListChecklistItems", reflect.TypeOf((*MockStore)(nil).ListChecklistItems), ctx)
+}
+
+// ListConversationStates mocks base method.
+func (m *MockStore) ListConversationStates(ctx context.Context, now time.Time) ([]*store.ConversationState, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListConversationStates", ctx, now)
+	ret0, _ := ret[0].([]*store.ConversationState)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListConversationStates indicates an expected call of ListConversationStates.
+func (mr *MockStoreMockRecorder) ListConversationStates(ctx, now any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListConversationStates", reflect.TypeOf((*MockStore)(nil).ListConversationStates), ctx, now)
+}
+
+// ListDuties mocks base method.
+func (m *MockStore) ListDuties(ctx context.Context, filter store.DutyFilter) ([]*store.Duty, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListDuties", ctx, filter)
+	ret0, _ := ret[0].([]*store.Duty)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListDuties indicates an expected call of ListDuties.
+func (mr *MockStoreMockRecorder) ListDuties(ctx, filter any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListDuties", reflect.TypeOf((*MockStore)(nil).ListDuties), ctx, filter)
+}
+
+// ListInteractiveMessages mocks base method.
+func (m *MockStore) ListInteractiveMessages(ctx context.Context) ([]*store.InteractiveMessage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListInteractiveMessages", ctx)
+	ret0, _ := ret[0].([]*store.InteractiveMessage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListInteractiveMessages indicates an expected call of ListInteractiveMessages.
+func (mr *MockStoreMockRecorder) ListInteractiveMessages(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListInteractiveMessages", reflect.TypeOf((*MockStore)(nil).ListInteractiveMessages), ctx)
+}
+
+// ListLedgerEntries mocks base method.
+func (m *MockStore) ListLedgerEntries(ctx context.Context) ([]*store.LedgerEntry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListLedgerEntries", ctx)
+	ret0, _ := ret[0].([]*store.LedgerEntry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListLedgerEntries indicates an expected call of ListLedgerEntries.
+func (mr *MockStoreMockRecorder) ListLedgerEntries(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListLedgerEntries", reflect.TypeOf((*MockStore)(nil).ListLedgerEntries), ctx)
+}
+
+// ListMaintenanceRuns mocks base method.
+func (m *MockStore) ListMaintenanceRuns(ctx context.Context, limit int) ([]*store.MaintenanceRun, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListMaintenanceRuns", ctx, limit)
+	ret0, _ := ret[0].([]*store.MaintenanceRun)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListMaintenanceRuns indicates an expected call of ListMaintenanceRuns.
+func (mr *MockStoreMockRecorder) ListMaintenanceRuns(ctx, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListMaintenanceRuns", reflect.TypeOf((*MockStore)(nil).ListMaintenanceRuns), ctx, limit)
+}
+
+// ListNoteTemplates mocks base method.
+func (m *MockStore) ListNoteTemplates(ctx context.Context) ([]*store.NoteTemplate, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListNoteTemplates", ctx)
+	ret0, _ := ret[0].([]*store.NoteTemplate)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListNoteTemplates indicates an expected call of ListNoteTemplates.
+func (mr *MockStoreMockRecorder) ListNoteTemplates(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListNoteTemplates", reflect.TypeOf((*MockStore)(nil).ListNoteTemplates), ctx)
+}
+
+// ListOffDutyPeriods mocks base method.
+func (m *MockStore) ListOffDutyPeriods(ctx context.Context, userID int64) ([]*store.OffDutyPeriod, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListOffDutyPeriods", ctx, userID)
+	ret0, _ := ret[0].([]*store.OffDutyPeriod)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListOffDutyPeriods indicates an expected call of ListOffDutyPeriods.
+func (mr *MockStoreMockRecorder) ListOffDutyPeriods(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListOffDutyPeriods", reflect.TypeOf((*MockStore)(nil).ListOffDutyPeriods), ctx, userID)
+}
+
+// ListOpenCoverRequests mocks base method.
+func (m *MockStore) ListOpenCoverRequests(ctx context.Context) ([]*store.CoverRequest, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListOpenCoverRequests", ctx)
+	ret0, _ := ret[0].([]*store.CoverRequest)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListOpenCoverRequests indicates an expected call of ListOpenCoverRequests.
+func (mr *MockStoreMockRecorder) ListOpenCoverRequests(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListOpenCoverRequests", reflect.TypeOf((*MockStore)(nil).ListOpenCoverRequests), ctx)
+}
+
+// ListPendingMessages mocks base method.
+func (m *MockStore) ListPendingMessages(ctx context.Context) ([]*store.PendingMessage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListPendingMessages", ctx)
+	ret0, _ := ret[0].([]*store.PendingMessage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListPendingMessages indicates an expected call of ListPendingMessages.
+func (mr *MockStoreMockRecorder) ListPendingMessages(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListPendingMessages", reflect.TypeOf((*MockStore)(nil).ListPendingMessages), ctx)
+}
+
+// ListQueueEvents mocks base method.
+func (m *MockStore) ListQueueEvents(ctx context.Context, userID int64) ([]*store.QueueEvent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListQueueEvents", ctx, userID)
+	ret0, _ := ret[0].([]*store.QueueEvent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListQueueEvents indicates an expected call of ListQueueEvents.
+func (mr *MockStoreMockRecorder) ListQueueEvents(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListQueueEvents", reflect.TypeOf((*MockStore)(nil).ListQueueEvents), ctx, userID)
+}
+
+// ListReminderSnoozes mocks base method.
+func (m *MockStore) ListReminderSnoozes(ctx context.Context) ([]*store.ReminderSnooze, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListReminderSnoozes", ctx)
+	ret0, _ := ret[0].([]*store.ReminderSnooze)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListReminderSnoozes indicates an expected call of ListReminderSnoozes.
+func (mr *MockStoreMockRecorder) ListReminderSnoozes(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListReminderSnoozes", reflect.TypeOf((*MockStore)(nil).ListReminderSnoozes), ctx)
+}
+
+// ListScheduleVersions mocks base method.
+func (m *MockStore) ListScheduleVersions(ctx context.Context, month time.Time) ([]*store.ScheduleVersion, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListScheduleVersions", ctx, month)
+	ret0, _ := ret[0].([]*store.ScheduleVersion)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListScheduleVersions indicates an expected call of ListScheduleVersions.
+func (mr *MockStoreMockRecorder) ListScheduleVersions(ctx, month any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListScheduleVersions", reflect.TypeOf((*MockStore)(nil).ListScheduleVersions), ctx, month)
+}
+
+// ListSettings mocks base method.
+func (m *MockStore) ListSettings(ctx context.Context) (map[string]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListSettings", ctx)
+	ret0, _ := ret[0].(map[string]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListSettings indicates an expected call of ListSettings.
+func (mr *MockStoreMockRecorder) ListSettings(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListSettings", reflect.TypeOf((*MockStore)(nil).ListSettings), ctx)
+}
+
+// ListShadowComparisons mocks base method.
+func (m *MockStore) ListShadowComparisons(ctx context.Context, since time.Time) ([]*store.ShadowComparison, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListShadowComparisons", ctx, since)
+	ret0, _ := ret[0].([]*store.ShadowComparison)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListShadowComparisons indicates an expected call of ListShadowComparisons.
+func (mr *MockStoreMockRecorder) ListShadowComparisons(ctx, since any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListShadowComparisons", reflect.TypeOf((*MockStore)(nil).ListShadowComparisons), ctx, since)
+}
+
+// ListTasks mocks base method.
+func (m *MockStore) ListTasks(ctx context.Context) ([]*store.Task, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListTasks", ctx)
+	ret0, _ := ret[0].([]*store.Task)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListTasks indicates an expected call of ListTasks.
+func (mr *MockStoreMockRecorder) ListTasks(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListTasks", reflect.TypeOf((*MockStore)(nil).ListTasks), ctx)
+}
+
+// ListUserMerges mocks base method.
+func (m *MockStore) ListUserMerges(ctx context.Context) ([]*store.UserMerge, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListUserMerges", ctx)
+	ret0, _ := ret[0].([]*store.UserMerge)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListUserMerges indicates an expected call of ListUserMerges.
+func (mr *MockStoreMockRecorder) ListUserMerges(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListUserMerges", reflect.TypeOf((*MockStore)(nil).ListUserMerges), ctx)
+}
+
+// MarkDutyAnnounced mocks base method.
+func (m *MockStore) MarkDutyAnnounced(ctx context.Context, date, at time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkDutyAnnounced", ctx, date, at)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkDutyAnnounced indicates an expected call of MarkDutyAnnounced.
+func (mr *MockStoreMockRecorder) MarkDutyAnnounced(ctx, date, at any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkDutyAnnounced", reflect.TypeOf((*MockStore)(nil).MarkDutyAnnounced), ctx, date, at)
+}
+
+// MarkInteractiveMessageDone mocks base method.
+func (m *MockStore) MarkInteractiveMessageDone(ctx context.Context, chatID int64, messageID int, at time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkInteractiveMessageDone", ctx, chatID, messageID, at)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkInteractiveMessageDone indicates an expected call of MarkInteractiveMessageDone.
+func (mr *MockStoreMockRecorder) MarkInteractiveMessageDone(ctx, chatID, messageID, at any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkInteractiveMessageDone", reflect.TypeOf((*MockStore)(nil).MarkInteractiveMessageDone), ctx, chatID, messageID, at)
+}
+
+// MarkMissedDuties mocks base method.
+func (m *MockStore) MarkMissedDuties(ctx context.Context, before time.Time) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkMissedDuties", ctx, before)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MarkMissedDuties indicates an expected call of MarkMissedDuties.
+func (mr *MockStoreMockRecorder) MarkMissedDuties(ctx, before any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkMissedDuties", reflect.TypeOf((*MockStore)(nil).MarkMissedDuties), ctx, before)
+}
+
+// MergeUsers mocks base method.
+func (m *MockStore) MergeUsers(ctx context.Context, fromID, toID int64, at time.Time) (*store.UserMerge, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MergeUsers", ctx, fromID, toID, at)
+	ret0, _ := ret[0].(*store.UserMerge)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MergeUsers indicates an expected call of MergeUsers.
+func (mr *MockStoreMockRecorder) MergeUsers(ctx, fromID, toID, at any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MergeUsers", reflect.TypeOf((*MockStore)(nil).MergeUsers), ctx, fromID, toID, at)
+}
+
+// Optimize mocks base method.
+func (m *MockStore) Optimize(ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Optimize", ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Optimize indicates an expected call of Optimize.
+func (mr *MockStoreMockRecorder) Optimize(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Optimize", reflect.TypeOf((*MockStore)(nil).Optimize), ctx)
+}
+
+// RebuildUserStats mocks base method.
+func (m *MockStore) RebuildUserStats(ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RebuildUserStats", ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RebuildUserStats indicates an expected call of RebuildUserStats.
+func (mr *MockStoreMockRecorder) RebuildUserStats(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RebuildUserStats", reflect.TypeOf((*MockStore)(nil).RebuildUserStats), ctx)
+}
+
+// RecordRoundRobinPick mocks base method.
+func (m *MockStore) RecordRoundRobinPick(ctx context.Context, rotation string, userID int64, at time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecordRoundRobinPick", ctx, rotation, userID, at)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RecordRoundRobinPick indicates an expected call of RecordRoundRobinPick.
+func (mr *MockStoreMockRecorder) RecordRoundRobinPick(ctx, rotation, userID, at any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordRoundRobinPick", reflect.TypeOf((*MockStore)(nil).RecordRoundRobinPick), ctx, rotation, userID, at)
+}
+
+// ReleaseTask mocks base method.
+func (m *MockStore) ReleaseTask(ctx context.Context, id, userID int64) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReleaseTask", ctx, id, userID)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReleaseTask indicates an expected call of ReleaseTask.
+func (mr *MockStoreMockRecorder) ReleaseTask(ctx, id, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReleaseTask", reflect.TypeOf((*MockStore)(nil).ReleaseTask), ctx, id, userID)
+}
+
+// ReplaceOffDutyPeriods mocks base method.
+func (m *MockStore) ReplaceOffDutyPeriods(ctx context.Context, userID int64, source string, periods []*store.OffDutyPeriod) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReplaceOffDutyPeriods", ctx, userID, source, periods)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReplaceOffDutyPeriods indicates an expected call of ReplaceOffDutyPeriods.
+func (mr *MockStoreMockRecorder) ReplaceOffDutyPeriods(ctx, userID, source, periods any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReplaceOffDutyPeriods", reflect.TypeOf((*MockStore)(nil).ReplaceOffDutyPeriods), ctx, userID, source, periods)
+}
+
+// ReplaceWasteCollections mocks base method.
+func (m *MockStore) ReplaceWasteCollections(ctx context.Context, collections []*store.WasteCollection) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReplaceWasteCollections", ctx, collections)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReplaceWasteCollections indicates an expected call of ReplaceWasteCollections.
+func (mr *MockStoreMockRecorder) ReplaceWasteCollections(ctx, collections any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReplaceWasteCollections", reflect.TypeOf((*MockStore)(nil).ReplaceWasteCollections), ctx, collections)
+}
+
+// ResolveCoverRequest mocks base method.
+func (m *MockStore) ResolveCoverRequest(ctx context.Context, id int64, coveredBy *int64, at time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResolveCoverRequest", ctx, id, coveredBy, at)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ResolveCoverRequest indicates an expected call of ResolveCoverRequest.
+func (mr *MockStoreMockRecorder) ResolveCoverRequest(ctx, id, coveredBy, at any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResolveCoverRequest", reflect.TypeOf((*MockStore)(nil).ResolveCoverRequest), ctx, id, coveredBy, at)
+}
+
+// RunInTx mocks base method.
+func (m *MockStore) RunInTx(ctx context.Context, fn func(store.Store) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RunInTx", ctx, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RunInTx indicates an expected call of RunInTx.
+func (mr *MockStoreMockRecorder) RunInTx(ctx, fn any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RunInTx", reflect.TypeOf((*MockStore)(nil).RunInTx), ctx, fn)
+}
+
+// SaveAssignmentExplanation mocks base method.
+func (m *MockStore) SaveAssignmentExplanation(ctx context.Context, e *store.AssignmentExplanation) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveAssignmentExplanation", ctx, e)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveAssignmentExplanation indicates an expected call of SaveAssignmentExplanation.
+func (mr *MockStoreMockRecorder) SaveAssignmentExplanation(ctx, e any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveAssignmentExplanation", reflect.TypeOf((*MockStore)(nil).SaveAssignmentExplanation), ctx, e)
+}
+
+// SaveConversationState mocks base method.
+func (m *MockStore) SaveConversationState(ctx context.Context, state *store.ConversationState) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveConversationState", ctx, state)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveConversationState indicates an expected call of SaveConversationState.
+func (mr *MockStoreMockRecorder) SaveConversationState(ctx, state any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveConversationState", reflect.TypeOf((*MockStore)(nil).SaveConversationState), ctx, state)
+}
+
+// SetCalendarLink mocks base method.
+func (m *MockStore) SetCalendarLink(ctx context.Context, link *store.CalendarLink) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetCalendarLink", ctx, link)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetCalendarLink indicates an expected call of SetCalendarLink.
+func (mr *MockStoreMockRecorder) SetCalendarLink(ctx, link any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetCalendarLink", reflect.TypeOf((*MockStore)(nil).SetCalendarLink), ctx, link)
+}
+
+// SetChatDisplay mocks base method.
+func (m *MockStore) SetChatDisplay(ctx context.Context, chatID int64, display store.ChatDisplay) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetChatDisplay", ctx, chatID, display)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetChatDisplay indicates an expected call of SetChatDisplay.
+func (mr *MockStoreMockRecorder) SetChatDisplay(ctx, chatID, display any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetChatDisplay", reflect.TypeOf((*MockStore)(nil).SetChatDisplay), ctx, chatID, display)
+}
+
+// SetChatLocale mocks base method.
+func (m *MockStore) SetChatLocale(ctx context.Context, chatID int64, locale string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetChatLocale", ctx, chatID, locale)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetChatLocale indicates an expected call of SetChatLocale.
+func (mr *MockStoreMockRecorder) SetChatLocale(ctx, chatID, locale any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetChatLocale", reflect.TypeOf((*MockStore)(nil).SetChatLocale), ctx, chatID, locale)
+}
+
+// SetChecklistCheck mocks base method.
+func (m *MockStore) SetChecklistCheck(ctx context.Context, check *store.ChecklistCheck) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetChecklistCheck", ctx, check)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetChecklistCheck indicates an expected call of SetChecklistCheck.
+func (mr *MockStoreMockRecorder) SetChecklistCheck(ctx, check any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetChecklistCheck", reflect.TypeOf((*MockStore)(nil).SetChecklistCheck), ctx, check)
+}
+
+// SetNotificationPreferences mocks base method.
+func (m *MockStore) SetNotificationPreferences(ctx context.Context, prefs *store.NotificationPreferences) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetNotificationPreferences", ctx, prefs)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetNotificationPreferences indicates an expected call of SetNotificationPreferences.
+func (mr *MockStoreMockRecorder) SetNotificationPreferences(ctx, prefs any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetNotificationPreferences", reflect.TypeOf((*MockStore)(nil).SetNotificationPreferences), ctx, prefs)
+}
+
+// SetOffDuty mocks base method.
+func (m *MockStore) SetOffDuty(ctx context.Context, userID int64, start, end time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetOffDuty", ctx, userID, start, end)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetOffDuty indicates an expected call of SetOffDuty.
+func (mr *MockStoreMockRecorder) SetOffDuty(ctx, userID, start, end any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetOffDuty", reflect.TypeOf((*MockStore)(nil).SetOffDuty), ctx, userID, start, end)
+}
+
+// SetSetting mocks base method.
+func (m *MockStore) SetSetting(ctx context.Context, key, value string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetSetting", ctx, key, value)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetSetting indicates an expected call of SetSetting.
+func (mr *MockStoreMockRecorder) SetSetting(ctx, key, value any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetSetting", reflect.TypeOf((*MockStore)(nil).SetSetting), ctx, key, value)
+}
+
+// SetSkipDay mocks base method.
+func (m *MockStore) SetSkipDay(ctx context.Context, day *store.SkipDay) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetSkipDay", ctx, day)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetSkipDay indicates an expected call of SetSkipDay.
+func (mr *MockStoreMockRecorder) SetSkipDay(ctx, day any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetSkipDay", reflect.TypeOf((*MockStore)(nil).SetSkipDay), ctx, day)
+}
+
+// TrackInteractiveMessage mocks base method.
+func (m *MockStore) TrackInteractiveMessage(ctx context.Context, msg *store.InteractiveMessage) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TrackInteractiveMessage", ctx, msg)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// TrackInteractiveMessage indicates an expected call of TrackInteractiveMessage.
+func (mr *MockStoreMockRecorder) TrackInteractiveMessage(ctx, msg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TrackInteractiveMessage", reflect.TypeOf((*MockStore)(nil).TrackInteractiveMessage), ctx, msg)
+}
+
+// UpdateDuty mocks base method.
+func (m *MockStore) UpdateDuty(ctx context.Context, duty *store.Duty) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateDuty", ctx, duty)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateDuty indicates an expected call of UpdateDuty.
+func (mr *MockStoreMockRecorder) UpdateDuty(ctx, duty any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateDuty", reflect.TypeOf((*MockStore)(nil).UpdateDuty), ctx, duty)
+}
+
+// UpdateUser mocks base method.
+func (m *MockStore) UpdateUser(ctx context.Context, user *store.User) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateUser", ctx, user)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateUser indicates an expected call of UpdateUser.
+func (mr *MockStoreMockRecorder) UpdateUser(ctx, user any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateUser", reflect.TypeOf((*MockStore)(nil).UpdateUser), ctx, user)
+}
+
+// UseInvite mocks base method.
+func (m *MockStore) UseInvite(ctx context.Context, tokenHash string, telegramUserID int64, at time.Time) (*store.Invite, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UseInvite", ctx, tokenHash, telegramUserID, at)
+	ret0, _ := ret[0].(*store.Invite)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UseInvite indicates an expected call of UseInvite.
+func (mr *MockStoreMockRecorder) UseInvite(ctx, tokenHash, telegramUserID, at any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UseInvite", reflect.TypeOf((*MockStore)(nil).UseInvite), ctx, tokenHash, telegramUserID, at)
+}
+
+// MockUserStore is a mock of UserStore interface.
+type MockUserStore struct {
+	ctrl     *gomock.Controller
+	recorder *MockUserStoreMockRecorder
+	isgomock struct{}
+}
+
+// MockUserStoreMockRecorder is the mock recorder for MockUserStore.
+type MockUserStoreMockRecorder struct {
+	mock *MockUserStore
+}
+
+// NewMockUserStore creates a new mock instance.
+func NewMockUserStore(ctrl *gomock.Controller) *MockUserStore {
+	mock := &MockUserStore{ctrl: ctrl}
+	mock.recorder = &MockUserStoreMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockUserStore) EXPECT() *MockUserStoreMockRecorder {
+	return m.recorder
+}
+
+// AddLedgerEntry mocks base method.
+func (m *MockUserStore) AddLedgerEntry(ctx context.Context, e *store.LedgerEntry) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddLedgerEntry", ctx, e)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddLedgerEntry indicates an expected call of AddLedgerEntry.
+func (mr *MockUserStoreMockRecorder) AddLedgerEntry(ctx, e any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddLedgerEntry", reflect.TypeOf((*MockUserStore)(nil).AddLedgerEntry), ctx, e)
+}
+
+// AwardBadge mocks base method.
+func (m *MockUserStore) AwardBadge(ctx context.Context, b *store.Badge) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AwardBadge", ctx, b)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AwardBadge indicates an expected call of AwardBadge.
+func (mr *MockUserStoreMockRecorder) AwardBadge(ctx, b any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AwardBadge", reflect.TypeOf((*MockUserStore)(nil).AwardBadge), ctx, b)
+}
+
+// ConsumeLoginCode mocks base method.
+func (m *MockUserStore) ConsumeLoginCode(ctx context.Context, codeHash string) (*store.LoginCode, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConsumeLoginCode", ctx, codeHash)
+	ret0, _ := ret[0].(*store.LoginCode)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ConsumeLoginCode indicates an expected call of ConsumeLoginCode.
+func (mr *MockUserStoreMockRecorder) ConsumeLoginCode(ctx, codeHash any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConsumeLoginCode", reflect.TypeOf((*MockUserStore)(nil).ConsumeLoginCode), ctx, codeHash)
+}
+
+// CreateInvite mocks base method.
+func (m *MockUserStore) CreateInvite(ctx context.Context, invite *store.Invite) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateInvite", ctx, invite)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateInvite indicates an expected call of CreateInvite.
+func (mr *MockUserStoreMockRecorder) CreateInvite(ctx, invite any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateInvite", reflect.TypeOf((*MockUserStore)(nil).CreateInvite), ctx, invite)
+}
+
+// CreateLoginCode mocks base method.
+func (m *MockUserStore) CreateLoginCode(ctx context.Context, code *store.LoginCode) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateLoginCode", ctx, code)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateLoginCode indicates an expected call of CreateLoginCode.
+func (mr *MockUserStoreMockRecorder) CreateLoginCode(ctx, code any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateLoginCode", reflect.TypeOf((*MockUserStore)(nil).CreateLoginCode), ctx, code)
+}
+
+// CreateUser mocks base method.
+func (m *MockUserStore) CreateUser(ctx context.Context, user *store.User) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateUser", ctx, user)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateUser indicates an expected call of CreateUser.
+func (mr *MockUserStoreMockRecorder) CreateUser(ctx, user any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUser", reflect.TypeOf((*MockUserStore)(nil).CreateUser), ctx, user)
+}
+
+// CreateWebSession mocks base method.
+func (m *MockUserStore) CreateWebSession(ctx context.Context, session *store.WebSession) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateWebSession", ctx, session)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateWebSession indicates an expected call of CreateWebSession.
+func (mr *MockUserStoreMockRecorder) CreateWebSession(ctx, session any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateWebSession", reflect.TypeOf((*MockUserStore)(nil).CreateWebSession), ctx, session)
+}
+
+// DeleteExpiredLogins mocks base method.
+func (m *MockUserStore) DeleteExpiredLogins(ctx context.Context, now time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteExpiredLogins", ctx, now)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteExpiredLogins indicates an expected call of DeleteExpiredLogins.
+func (mr *MockUserStoreMockRecorder) DeleteExpiredLogins(ctx, now any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteExpiredLogins", reflect.TypeOf((*MockUserStore)(nil).DeleteExpiredLogins), ctx, now)
+}
+
+// DeleteUser mocks base method.
+func (m *MockUserStore) DeleteUser(ctx context.Context, id int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteUser", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteUser indicates an expected call of DeleteUser.
+func (mr *MockUserStoreMockRecorder) DeleteUser(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUser", reflect.TypeOf((*MockUserStore)(nil).DeleteUser), ctx, id)
+}
+
+// DeleteWebSession mocks base method.
+func (m *MockUserStore) DeleteWebSession(ctx context.Context, tokenHash string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteWebSession", ctx, tokenHash)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteWebSession indicates an expected call of DeleteWebSession.
+func (mr *MockUserStoreMockRecorder) DeleteWebSession(ctx, tokenHash any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteWebSession", reflect.TypeOf((*MockUserStore)(nil).DeleteWebSession), ctx, tokenHash)
+}
+
+// GetInviteUsedBy mocks base method.
+func (m *MockUserStore) GetInviteUsedBy(ctx context.Context, telegramUserID int64) (*store.Invite, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetInviteUsedBy", ctx, telegramUserID)
+	ret0, _ := ret[0].(*store.Invite)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetInviteUsedBy indicates an expected call of GetInviteUsedBy.
+func (mr *MockUserStoreMockRecorder) GetInviteUsedBy(ctx, telegramUserID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetInviteUsedBy", reflect.TypeOf((*MockUserStore)(nil).GetInviteUsedBy), ctx, telegramUserID)
+}
+
+// GetUserByName mocks base method.
+func (m *MockUserStore) GetUserByName(ctx context.Context, name string) (*store.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserByName", ctx, name)
+	ret0, _ := ret[0].(*store.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserByName indicates an expected call of GetUserByName.
+func (mr *MockUserStoreMockRecorder) GetUserByName(ctx, name any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserByName", reflect.TypeOf((*MockUserStore)(nil).GetUserByName), ctx, name)
+}
+
+// GetUserByTelegramID mocks base method.
+func (m *MockUserStore) GetUserByTelegramID(ctx context.Context, id int64) (*store.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserByTelegramID", ctx, id)
+	ret0, _ := ret[0].(*store.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserByTelegramID indicates an expected call of GetUserByTelegramID.
+func (mr *MockUserStoreMockRecorder) GetUserByTelegramID(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserByTelegramID", reflect.TypeOf((*MockUserStore)(nil).GetUserByTelegramID), ctx, id)
+}
+
+// GetUserStats mocks base method.
+func (m *MockUserStore) GetUserStats(ctx context.Context, userID int64) (*store.UserStats, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserStats", ctx, userID)
+	ret0, _ := ret[0].(*store.UserStats)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserStats indicates an expected call of GetUserStats.
+func (mr *MockUserStoreMockRecorder) GetUserStats(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserStats", reflect.TypeOf((*MockUserStore)(nil).GetUserStats), ctx, userID)
+}
+
+// GetWebSession mocks base method.
+func (m *MockUserStore) GetWebSession(ctx context.Context, tokenHash string) (*store.WebSession, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWebSession", ctx, tokenHash)
+	ret0, _ := ret[0].(*store.WebSession)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetWebSession indicates an expected call of GetWebSession.
+func (mr *MockUserStoreMockRecorder) GetWebSession(ctx, tokenHash any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWebSession", reflect.TypeOf((*MockUserStore)(nil).GetWebSession), ctx, tokenHash)
+}
+
+// ListActiveUsers mocks base method.
+func (m *MockUserStore) ListActiveUsers(ctx context.Context) ([]*store.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListActiveUsers", ctx)
+	ret0, _ := ret[0].([]*store.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListActiveUsers indicates an expected call of ListActiveUsers.
+func (mr *MockUserStoreMockRecorder) ListActiveUsers(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListActiveUsers", reflect.TypeOf((*MockUserStore)(nil).ListActiveUsers), ctx)
+}
+
+// ListAllUsers mocks base method.
+func (m *MockUserStore) ListAllUsers(ctx context.Context) ([]*store.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAllUsers", ctx)
+	ret0, _ := ret[0].([]*store.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListAllUsers indicates an expected call of ListAllUsers.
+func (mr *MockUserStoreMockRecorder) ListAllUsers(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAllUsers", reflect.TypeOf((*MockUserStore)(nil).ListAllUsers), ctx)
+}
+
+// ListBadges mocks base method.
+func (m *MockUserStore) ListBadges(ctx context.Context, userID int64) ([]*store.Badge, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListBadges", ctx, userID)
+	ret0, _ := ret[0].([]*store.Badge)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListBadges indicates an expected call of ListBadges.
+func (mr *MockUserStoreMockRecorder) ListBadges(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListBadges", reflect.TypeOf((*MockUserStore)(nil).ListBadges), ctx, userID)
+}
+
+// ListLedgerEntries mocks base method.
+func (m *MockUserStore) ListLedgerEntries(ctx context.Context) ([]*store.LedgerEntry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListLedgerEntries", ctx)
+	ret0, _ := ret[0].([]*store.LedgerEntry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListLedgerEntries indicates an expected call of ListLedgerEntries.
+func (mr *MockUserStoreMockRecorder) ListLedgerEntries(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListLedgerEntries", reflect.TypeOf((*MockUserStore)(nil).ListLedgerEntries), ctx)
+}
+
+// ListUserMerges mocks base method.
+func (m *MockUserStore) ListUserMerges(ctx context.Context) ([]*store.UserMerge, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListUserMerges", ctx)
+	ret0, _ := ret[0].([]*store.UserMerge)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListUserMerges indicates an expected call of ListUserMerges.
+func (mr *MockUserStoreMockRecorder) ListUserMerges(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListUserMerges", reflect.TypeOf((*MockUserStore)(nil).ListUserMerges), ctx)
+}
+
+// MergeUsers mocks base method.
+func (m *MockUserStore) MergeUsers(ctx context.Context, fromID, toID int64, at time.Time) (*store.UserMerge, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MergeUsers", ctx, fromID, toID, at)
+	ret0, _ := ret[0].(*store.UserMerge)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MergeUsers indicates an expected call of MergeUsers.
+func (mr *MockUserStoreMockRecorder) MergeUsers(ctx, fromID, toID, at any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MergeUsers", reflect.TypeOf((*MockUserStore)(nil).MergeUsers), ctx, fromID, toID, at)
+}
+
+// RebuildUserStats mocks base method.
+func (m *MockUserStore) RebuildUserStats(ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RebuildUserStats", ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RebuildUserStats indicates an expected call of RebuildUserStats.
+func (mr *MockUserStoreMockRecorder) RebuildUserStats(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RebuildUserStats", reflect.TypeOf((*MockUserStore)(nil).RebuildUserStats), ctx)
+}
+
+// UpdateUser mocks base method.
+func (m *MockUserStore) UpdateUser(ctx context.Context, user *store.User) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateUser", ctx, user)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateUser indicates an expected call of UpdateUser.
+func (mr *MockUserStoreMockRecorder) UpdateUser(ctx, user any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateUser", reflect.TypeOf((*MockUserStore)(nil).UpdateUser), ctx, user)
+}
+
+// UseInvite mocks base method.
+func (m *MockUserStore) UseInvite(ctx context.Context, tokenHash string, telegramUserID int64, at time.Time) (*store.Invite, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UseInvite", ctx, tokenHash, telegramUserID, at)
+	ret0, _ := ret[0].(*store.Invite)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UseInvite indicates an expected call of UseInvite.
+func (mr *MockUserStoreMockRecorder) UseInvite(ctx, tokenHash, telegramUserID, at any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UseInvite", reflect.TypeOf((*MockUserStore)(nil).UseInvite), ctx, tokenHash, telegramUserID, at)
+}
+
+// MockDutyStore is a mock of DutyStore interface.
+type MockDutyStore struct {
+	ctrl     *gomock.Controller
+	recorder *MockDutyStoreMockRecorder
+	isgomock struct{}
+}
+
+// MockDutyStoreMockRecorder is the mock recorder for MockDutyStore.
+type MockDutyStoreMockRecorder struct {
+	mock *MockDutyStore
+}
+
+// NewMockDutyStore creates a new mock instance.
+func NewMockDutyStore(ctrl *gomock.Controller) *MockDutyStore {
+	mock := &MockDutyStore{ctrl: ctrl}
+	mock.recorder = &MockDutyStoreMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockDutyStore) EXPECT() *MockDutyStoreMockRecorder {
+	return m.recorder
+}
+
+// BackfillDuty mocks base method.
+func (m *MockDutyStore) BackfillDuty(ctx context.Context, date time.Time, userID int64, at time.Time) (*store.Duty, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BackfillDuty", ctx, date, userID, at)
+	ret0, _ := ret[0].(*store.Duty)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BackfillDuty indicates an expected call of BackfillDuty.
+func (mr *MockDutyStoreMockRecorder) BackfillDuty(ctx, date, userID, at any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BackfillDuty", reflect.TypeOf((*MockDutyStore)(nil).BackfillDuty), ctx, date, userID, at)
+}
+
+// ClaimTask mocks base method.
+func (m *MockDutyStore) ClaimTask(ctx context.Context, id, userID int64, at time.Time) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClaimTask", ctx, id, userID, at)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClaimTask indicates an expected call of ClaimTask.
+func (mr *MockDutyStoreMockRecorder) ClaimTask(ctx, id, userID, at any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimTask", reflect.TypeOf((*MockDutyStore)(nil).ClaimTask), ctx, id, userID, at)
+}
+
+// CompleteDuty mocks base method.
+func (m *MockDutyStore) CompleteDuty(ctx context.Context, date time.Time) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CompleteDuty", ctx, date)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CompleteDuty indicates an expected call of CompleteDuty.
+func (mr *MockDutyStoreMockRecorder) CompleteDuty(ctx, date any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CompleteDuty", reflect.TypeOf((*MockDutyStore)(nil).CompleteDuty), ctx, date)
+}
+
+// CompleteTask mocks base method.
+func (m *MockDutyStore) CompleteTask(ctx context.Context, id, userID int64, at time.Time) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CompleteTask", ctx, id, userID, at)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CompleteTask indicates an expected call of CompleteTask.
+func (mr *MockDutyStoreMockRecorder) CompleteTask(ctx, id, userID, at any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CompleteTask", reflect.TypeOf((*MockDutyStore)(nil).CompleteTask), ctx, id, userID, at)
+}
+
+// CreateBlackoutRule mocks base method.
+func (m *MockDutyStore) CreateBlackoutRule(ctx context.Context, r *store.BlackoutRule) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateBlackoutRule", ctx, r)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateBlackoutRule indicates an expected call of CreateBlackoutRule.
+func (mr *MockDutyStoreMockRecorder) CreateBlackoutRule(ctx, r any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateBlackoutRule", reflect.TypeOf((*MockDutyStore)(nil).CreateBlackoutRule), ctx, r)
+}
+
+// CreateChecklistItem mocks base method.
+func (m *MockDutyStore) CreateChecklistItem(ctx context.Context, item *store.ChecklistItem) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateChecklistItem", ctx, item)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateChecklistItem indicates an expected call of CreateChecklistItem.
+func (mr *MockDutyStoreMockRecorder) CreateChecklistItem(ctx, item any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateChecklistItem", reflect.TypeOf((*MockDutyStore)(nil).CreateChecklistItem), ctx, item)
+}
+
+// CreateCoverRequest mocks base method.
+func (m *MockDutyStore) CreateCoverRequest(ctx context.Context, r *store.CoverRequest) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateCoverRequest", ctx, r)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateCoverRequest indicates an expected call of CreateCoverRequest.
+func (mr *MockDutyStoreMockRecorder) CreateCoverRequest(ctx, r any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateCoverRequest", reflect.TypeOf((*MockDutyStore)(nil).CreateCoverRequest), ctx, r)
+}
+
+// CreateDuty mocks base method.
+func (m *MockDutyStore) CreateDuty(ctx context.Context, duty *store.Duty) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateDuty", ctx, duty)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateDuty indicates an expected call of CreateDuty.
+func (mr *MockDutyStoreMockRecorder) CreateDuty(ctx, duty any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateDuty", reflect.TypeOf((*MockDutyStore)(nil).CreateDuty), ctx, duty)
+}
+
+// CreateDutyChange mocks base method.
+func (m *MockDutyStore) CreateDutyChange(ctx context.Context, change *store.DutyChange) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateDutyChange", ctx, change)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateDutyChange indicates an expected call of CreateDutyChange.
+func (mr *MockDutyStoreMockRecorder) CreateDutyChange(ctx, change any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateDutyChange", reflect.TypeOf((*MockDutyStore)(nil).CreateDutyChange), ctx, change)
+}
+
+// CreateNoteTemplate mocks base method.
+func (m *MockDutyStore) CreateNoteTemplate(ctx context.Context, t *store.NoteTemplate) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateNoteTemplate", ctx, t)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateNoteTemplate indicates an expected call of CreateNoteTemplate.
+func (mr *MockDutyStoreMockRecorder) CreateNoteTemplate(ctx, t any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateNoteTemplate", reflect.TypeOf((*MockDutyStore)(nil).CreateNoteTemplate), ctx, t)
+}
+
+// CreateScheduleVersion mocks base method.
+func (m *MockDutyStore) CreateScheduleVersion(ctx context.Context, v *store.ScheduleVersion) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateScheduleVersion", ctx, v)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateScheduleVersion indicates an expected call of CreateScheduleVersion.
+func (mr *MockDutyStoreMockRecorder) CreateScheduleVersion(ctx, v any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateScheduleVersion", reflect.TypeOf((*MockDutyStore)(nil).CreateScheduleVersion), ctx, v)
+}
+
+// CreateShadowComparison mocks base method.
+func (m *MockDutyStore) CreateShadowComparison(ctx context.Context, c *store.ShadowComparison) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateShadowComparison", ctx, c)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateShadowComparison indicates an expected call of CreateShadowComparison.
+func (mr *MockDutyStoreMockRecorder) CreateShadowComparison(ctx, c any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateShadowComparison", reflect.TypeOf((*MockDutyStore)(nil).CreateShadowComparison), ctx, c)
+}
+
+// CreateTask mocks base method.
+func (m *MockDutyStore) CreateTask(ctx context.Context, t *store.Task) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateTask", ctx, t)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateTask indicates an expected call of CreateTask.
+func (mr *MockDutyStoreMockRecorder) CreateTask(ctx, t any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateTask", reflect.TypeOf((*MockDutyStore)(nil).CreateTask), ctx, t)
+}
+
+// DeleteBlackoutRule mocks base method.
+func (m *MockDutyStore) DeleteBlackoutRule(ctx context.Context, id int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteBlackoutRule", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteBlackoutRule indicates an expected call of DeleteBlackoutRule.
+func (mr *MockDutyStoreMockRecorder) DeleteBlackoutRule(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteBlackoutRule", reflect.TypeOf((*MockDutyStore)(nil).DeleteBlackoutRule), ctx, id)
+}
+
+// DeleteChecklistCheck mocks base method.
+func (m *MockDutyStore) DeleteChecklistCheck(ctx context.Context, date time.Time, itemID int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteChecklistCheck", ctx, date, itemID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteChecklistCheck indicates an expected call of DeleteChecklistCheck.
+func (mr *MockDutyStoreMockRecorder) DeleteChecklistCheck(ctx, date, itemID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteChecklistCheck", reflect.TypeOf((*MockDutyStore)(nil).DeleteChecklistCheck), ctx, date, itemID)
+}
+
+// DeleteChecklistItem mocks base method.
+func (m *MockDutyStore) DeleteChecklistItem(ctx context.Context, id int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteChecklistItem", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteChecklistItem indicates an expected call of DeleteChecklistItem.
+func (mr *MockDutyStoreMockRecorder) DeleteChecklistItem(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteChecklistItem", reflect.TypeOf((*MockDutyStore)(nil).DeleteChecklistItem), ctx, id)
+}
+
+// DeleteDuty mocks base method.
+func (m *MockDutyStore) DeleteDuty(ctx context.Context, date time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteDuty", ctx, date)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteDuty indicates an expected call of DeleteDuty.
+func (mr *MockDutyStoreMockRecorder) DeleteDuty(ctx, date any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteDuty", reflect.TypeOf((*MockDutyStore)(nil).DeleteDuty), ctx, date)
+}
+
+// DeleteNoteTemplate mocks base method.
+func (m *MockDutyStore) DeleteNoteTemplate(ctx context.Context, id int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteNoteTemplate", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteNoteTemplate indicates an expected call of DeleteNoteTemplate.
+func (mr *MockDutyStoreMockRecorder) DeleteNoteTemplate(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteNoteTemplate", reflect.TypeOf((*MockDutyStore)(nil).DeleteNoteTemplate), ctx, id)
+}
+
+// DeleteSkipDay mocks base method.
+func (m *MockDutyStore) DeleteSkipDay(ctx context.Context, date time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteSkipDay", ctx, date)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteSkipDay indicates an expected call of DeleteSkipDay.
+func (mr *MockDutyStoreMockRecorder) DeleteSkipDay(ctx, date any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteSkipDay", reflect.TypeOf((*MockDutyStore)(nil).DeleteSkipDay), ctx, date)
+}
+
+// DeleteTask mocks base method.
+func (m *MockDutyStore) DeleteTask(ctx context.Context, id int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteTask", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteTask indicates an expected call of DeleteTask.
+func (mr *MockDutyStoreMockRecorder) DeleteTask(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteTask", reflect.TypeOf((*MockDutyStore)(nil).DeleteTask), ctx, id)
+}
+
+// GetAssignmentExplanation mocks base method.
+func (m *MockDutyStore) GetAssignmentExplanation(ctx context.Context, date time.Time) (*store.AssignmentExplanation, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAssignmentExplanation", ctx, date)
+	ret0, _ := ret[0].(*store.AssignmentExplanation)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAssignmentExplanation indicates an expected call of GetAssignmentExplanation.
+func (mr *MockDutyStoreMockRecorder) GetAssignmentExplanation(ctx, date any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAssignmentExplanation", reflect.TypeOf((*MockDutyStore)(nil).GetAssignmentExplanation), ctx, date)
+}
+
+// GetChecklistChecks mocks base method.
+func (m *MockDutyStore) GetChecklistChecks(ctx context.Context, date time.Time) ([]*store.ChecklistCheck, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetChecklistChecks", ctx, date)
+	ret0, _ := ret[0].([]*store.ChecklistCheck)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetChecklistChecks indicates an expected call of GetChecklistChecks.
+func (mr *MockDutyStoreMockRecorder) GetChecklistChecks(ctx, date any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetChecklistChecks", reflect.TypeOf((*MockDutyStore)(nil).GetChecklistChecks), ctx, date)
+}
+
+// GetCompletedDutiesInRange mocks base method.
+func (m *MockDutyStore) GetCompletedDutiesInRange(ctx context.Context, start, end time.Time) ([]*store.Duty, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCompletedDutiesInRange", ctx, start, end)
+	ret0, _ := ret[0].([]*store.Duty)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCompletedDutiesInRange indicates an expected call of GetCompletedDutiesInRange.
+func (mr *MockDutyStoreMockRecorder) GetCompletedDutiesInRange(ctx, start, end any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCompletedDutiesInRange", reflect.TypeOf((*MockDutyStore)(nil).GetCompletedDutiesInRange), ctx, start, end)
+}
+
+// GetDutiesByMonth mocks base method.
+func (m *MockDutyStore) GetDutiesByMonth(ctx context.Context, year int, month time.Month) ([]*store.Duty, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDutiesByMonth", ctx, year, month)
+	ret0, _ := ret[0].([]*store.Duty)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDutiesByMonth indicates an expected call of GetDutiesByMonth.
+func (mr *MockDutyStoreMockRecorder) GetDutiesByMonth(ctx, year, month any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDutiesByMonth", reflect.TypeOf((*MockDutyStore)(nil).GetDutiesByMonth), ctx, year, month)
+}
+
+// GetDutyByDate mocks base method.
+func (m *MockDutyStore) GetDutyByDate(ctx context.Context, date time.Time) (*store.Duty, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDutyByDate", ctx, date)
+	ret0, _ := ret[0].(*store.Duty)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDutyByDate indicates an expected call of GetDutyByDate.
+func (mr *MockDutyStoreMockRecorder) GetDutyByDate(ctx, date any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDutyByDate", reflect.TypeOf((*MockDutyStore)(nil).GetDutyByDate), ctx, date)
+}
+
+// GetExpiredHolds mocks base method.
+func (m *MockDutyStore) GetExpiredHolds(ctx context.Context, today time.Time) ([]*store.Duty, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetExpiredHolds", ctx, today)
+	ret0, _ := ret[0].([]*store.Duty)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetExpiredHolds indicates an expected call of GetExpiredHolds.
+func (mr *MockDutyStoreMockRecorder) GetExpiredHolds(ctx, today any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetExpiredHolds", reflect.TypeOf((*MockDutyStore)(nil).GetExpiredHolds), ctx, today)
+}
+
+// GetOpenCoverRequest mocks base method.
+func (m *MockDutyStore) GetOpenCoverRequest(ctx context.Context, date time.Time) (*store.CoverRequest, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOpenCoverRequest", ctx, date)
+	ret0, _ := ret[0].(*store.CoverRequest)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOpenCoverRequest indicates an expected call of GetOpenCoverRequest.
+func (mr *MockDutyStoreMockRecorder) GetOpenCoverRequest(ctx, date any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOpenCoverRequest", reflect.TypeOf((*MockDutyStore)(nil).GetOpenCoverRequest), ctx, date)
+}
+
+// GetRecentDutyChanges mocks base method.
+func (m *MockDutyStore) GetRecentDutyChanges(ctx context.Context, limit int) ([]*store.DutyChange, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRecentDutyChanges", ctx, limit)
+	ret0, _ := ret[0].([]*store.DutyChange)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRecentDutyChanges indicates an expected call of GetRecentDutyChanges.
+func (mr *MockDutyStoreMockRecorder) GetRecentDutyChanges(ctx, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRecentDutyChanges", reflect.TypeOf((*MockDutyStore)(nil).GetRecentDutyChanges), ctx, limit)
+}
+
+// GetRoundRobinStates mocks base method.
+func (m *MockDutyStore) GetRoundRobinStates(ctx context.Context, rotation string) ([]*store.RoundRobinState, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRoundRobinStates", ctx, rotation)
+	ret0, _ := ret[0].([]*store.RoundRobinState)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRoundRobinStates indicates an expected call of GetRoundRobinStates.
+func (mr *MockDutyStoreMockRecorder) GetRoundRobinStates(ctx, rotation any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRoundRobinStates", reflect.TypeOf((*MockDutyStore)(nil).GetRoundRobinStates), ctx, rotation)
+}
+
+// GetSkipDay mocks base method.
+func (m *MockDutyStore) GetSkipDay(ctx context.Context, date time.Time) (*store.SkipDay, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSkipDay", ctx, date)
+	ret0, _ := ret[0].(*store.SkipDay)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSkipDay indicates an expected call of GetSkipDay.
+func (mr *MockDutyStoreMockRecorder) GetSkipDay(ctx, date any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSkipDay", reflect.TypeOf((*MockDutyStore)(nil).GetSkipDay), ctx, date)
+}
+
+// GetSkipDaysByMonth mocks base method.
+func (m *MockDutyStore) GetSkipDaysByMonth(ctx context.Context, year int, month time.Month) ([]*store.SkipDay, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSkipDaysByMonth", ctx, year, month)
+	ret0, _ := ret[0].([]*store.SkipDay)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSkipDaysByMonth indicates an expected call of GetSkipDaysByMonth.
+func (mr *MockDutyStoreMockRecorder) GetSkipDaysByMonth(ctx, year, month any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSkipDaysByMonth", reflect.TypeOf((*MockDutyStore)(nil).GetSkipDaysByMonth), ctx, year, month)
+}
+
+// GetTask mocks base method.
+func (m *MockDutyStore) GetTask(ctx context.Context, id int64) (*store.Task, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTask", ctx, id)
+	ret0, _ := ret[0].(*store.Task)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTask indicates an expected call of GetTask.
+func (mr *MockDutyStoreMockRecorder) GetTask(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTask", reflect.TypeOf((*MockDutyStore)(nil).GetTask), ctx, id)
+}
+
+// GetTodaysDuty mocks base method.
+func (m *MockDutyStore) GetTodaysDuty(ctx context.Context) (*store.Duty, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTodaysDuty", ctx)
+	ret0, _ := ret[0].(*store.Duty)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTodaysDuty indicates an expected call of GetTodaysDuty.
+func (mr *MockDutyStoreMockRecorder) GetTodaysDuty(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTodaysDuty", reflect.TypeOf((*MockDutyStore)(nil).GetTodaysDuty), ctx)
+}
+
+// GetWasteCollections mocks base method.
+func (m *MockDutyStore) GetWasteCollections(ctx context.Context, start, end time.Time) ([]*store.WasteCollection, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWasteCollections", ctx, start, end)
+	ret0, _ := ret[0].([]*store.WasteCollection)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetWasteCollections indicates an expected call of GetWasteCollections.
+func (mr *MockDutyStoreMockRecorder) GetWasteCollections(ctx, start, end any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWasteCollections", reflect.TypeOf((*MockDutyStore)(nil).GetWasteCollections), ctx, start, end)
+}
+
+// ListBlackoutRules mocks base method.
+func (m *MockDutyStore) ListBlackoutRules(ctx context.Context) ([]*store.BlackoutRule, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListBlackoutRules", ctx)
+	ret0, _ := ret[0].([]*store.BlackoutRule)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListBlackoutRules indicates an expected call of ListBlackoutRules.
+func (mr *MockDutyStoreMockRecorder) ListBlackoutRules(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListBlackoutRules", reflect.TypeOf((*MockDutyStore)(nil).ListBlackoutRules), ctx)
+}
+
+// ListChecklistItems mocks base method.
+func (m *MockDutyStore) ListChecklistItems(ctx context.Context) ([]*store.ChecklistItem, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListChecklistItems", ctx)
+	ret0, _ := ret[0].([]*store.ChecklistItem)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListChecklistItems indicates an expected call of ListChecklistItems.
+func (mr *MockDutyStoreMockRecorder) ListChecklistItems(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListChecklistItems", reflect.TypeOf((*MockDutyStore)(nil).ListChecklistItems), ctx)
+}
+
+// ListDuties mocks base method.
+func (m *MockDutyStore) ListDuties(ctx context.Context, filter store.DutyFilter) ([]*store.Duty, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListDuties", ctx, filter)
+	ret0, _ := ret[0].([]*store.Duty)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListDuties indicates an expected call of ListDuties.
+func (mr *MockDutyStoreMockRecorder) ListDuties(ctx, filter any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListDuties", reflect.TypeOf((*MockDutyStore)(nil).ListDuties), ctx, filter)
+}
+
+// ListNoteTemplates mocks base method.
+func (m *MockDutyStore) ListNoteTemplates(ctx context.Context) ([]*store.NoteTemplate, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListNoteTemplates", ctx)
+	ret0, _ := ret[0].([]*store.NoteTemplate)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListNoteTemplates indicates an expected call of ListNoteTemplates.
+func (mr *MockDutyStoreMockRecorder) ListNoteTemplates(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListNoteTemplates", reflect.TypeOf((*MockDutyStore)(nil).ListNoteTemplates), ctx)
+}
+
+// ListOpenCoverRequests mocks base method.
+func (m *MockDutyStore) ListOpenCoverRequests(ctx context.Context) ([]*store.CoverRequest, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListOpenCoverRequests", ctx)
+	ret0, _ := ret[0].([]*store.CoverRequest)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListOpenCoverRequests indicates an expected call of ListOpenCoverRequests.
+func (mr *MockDutyStoreMockRecorder) ListOpenCoverRequests(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListOpenCoverRequests", reflect.TypeOf((*MockDutyStore)(nil).ListOpenCoverRequests), ctx)
+}
+
+// ListScheduleVersions mocks base method.
+func (m *MockDutyStore) ListScheduleVersions(ctx context.Context, month time.Time) ([]*store.ScheduleVersion, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListScheduleVersions", ctx, month)
+	ret0, _ := ret[0].([]*store.ScheduleVersion)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListScheduleVersions indicates an expected call of ListScheduleVersions.
+func (mr *MockDutyStoreMockRecorder) ListScheduleVersions(ctx, month any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListScheduleVersions", reflect.TypeOf((*MockDutyStore)(nil).ListScheduleVersions), ctx, month)
+}
+
+// ListShadowComparisons mocks base method.
+func (m *MockDutyStore) ListShadowComparisons(ctx context.Context, since time.Time) ([]*store.ShadowComparison, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListShadowComparisons", ctx, since)
+	ret0, _ := ret[0].([]*store.ShadowComparison)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListShadowComparisons indicates an expected call of ListShadowComparisons.
+func (mr *MockDutyStoreMockRecorder) ListShadowComparisons(ctx, since any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListShadowComparisons", reflect.TypeOf((*MockDutyStore)(nil).ListShadowComparisons), ctx, since)
+}
+
+// ListTasks mocks base method.
+func (m *MockDutyStore) ListTasks(ctx context.Context) ([]*store.Task, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListTasks", ctx)
+	ret0, _ := ret[0].([]*store.Task)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListTasks indicates an expected call of ListTasks.
+func (mr *MockDutyStoreMockRecorder) ListTasks(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListTasks", reflect.TypeOf((*MockDutyStore)(nil).ListTasks), ctx)
+}
+
+// MarkDutyAnnounced mocks base method.
+func (m *MockDutyStore) MarkDutyAnnounced(ctx context.Context, date, at time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkDutyAnnounced", ctx, date, at)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkDutyAnnounced indicates an expected call of MarkDutyAnnounced.
+func (mr *MockDutyStoreMockRecorder) MarkDutyAnnounced(ctx, date, at any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkDutyAnnounced", reflect.TypeOf((*MockDutyStore)(nil).MarkDutyAnnounced), ctx, date, at)
+}
+
+// MarkMissedDuties mocks base method.
+func (m *MockDutyStore) MarkMissedDuties(ctx context.Context, before time.Time) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkMissedDuties", ctx, before)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MarkMissedDuties indicates an expected call of MarkMissedDuties.
+func (mr *MockDutyStoreMockRecorder) MarkMissedDuties(ctx, before any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkMissedDuties", reflect.TypeOf((*MockDutyStore)(nil).MarkMissedDuties), ctx, before)
+}
+
+// RecordRoundRobinPick mocks base method.
+func (m *MockDutyStore) RecordRoundRobinPick(ctx context.Context, rotation string, userID int64, at time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecordRoundRobinPick", ctx, rotation, userID, at)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RecordRoundRobinPick indicates an expected call of RecordRoundRobinPick.
+func (mr *MockDutyStoreMockRecorder) RecordRoundRobinPick(ctx, rotation, userID, at any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordRoundRobinPick", reflect.TypeOf((*MockDutyStore)(nil).RecordRoundRobinPick), ctx, rotation, userID, at)
+}
+
+// ReleaseTask mocks base method.
+func (m *MockDutyStore) ReleaseTask(ctx context.Context, id, userID int64) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReleaseTask", ctx, id, userID)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReleaseTask indicates an expected call of ReleaseTask.
+func (mr *MockDutyStoreMockRecorder) ReleaseTask(ctx, id, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReleaseTask", reflect.TypeOf((*MockDutyStore)(nil).ReleaseTask), ctx, id, userID)
+}
+
+// ReplaceWasteCollections mocks base method.
+func (m *MockDutyStore) ReplaceWasteCollections(ctx context.Context, collections []*store.WasteCollection) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReplaceWasteCollections", ctx, collections)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReplaceWasteCollections indicates an expected call of ReplaceWasteCollections.
+func (mr *MockDutyStoreMockRecorder) ReplaceWasteCollections(ctx, collections any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReplaceWasteCollections", reflect.TypeOf((*MockDutyStore)(nil).ReplaceWasteCollections), ctx, collections)
+}
+
+// ResolveCoverRequest mocks base method.
+func (m *MockDutyStore) ResolveCoverRequest(ctx context.Context, id int64, coveredBy *int64, at time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResolveCoverRequest", ctx, id, coveredBy, at)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ResolveCoverRequest indicates an expected call of ResolveCoverRequest.
+func (mr *MockDutyStoreMockRecorder) ResolveCoverRequest(ctx, id, coveredBy, at any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResolveCoverRequest", reflect.TypeOf((*MockDutyStore)(nil).ResolveCoverRequest), ctx, id, coveredBy, at)
+}
+
+// SaveAssignmentExplanation mocks base method.
+func (m *MockDutyStore) SaveAssignmentExplanation(ctx context.Context, e *store.AssignmentExplanation) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveAssignmentExplanation", ctx, e)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveAssignmentExplanation indicates an expected call of SaveAssignmentExplanation.
+func (mr *MockDutyStoreMockRecorder) SaveAssignmentExplanation(ctx, e any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveAssignmentExplanation", reflect.TypeOf((*MockDutyStore)(nil).SaveAssignmentExplanation), ctx, e)
+}
+
+// SetChecklistCheck mocks base method.
+func (m *MockDutyStore) SetChecklistCheck(ctx context.Context, check *store.ChecklistCheck) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetChecklistCheck", ctx, check)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetChecklistCheck indicates an expected call of SetChecklistCheck.
+func (mr *MockDutyStoreMockRecorder) SetChecklistCheck(ctx, check any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetChecklistCheck", reflect.TypeOf((*MockDutyStore)(nil).SetChecklistCheck), ctx, check)
+}
+
+// SetSkipDay mocks base method.
+func (m *MockDutyStore) SetSkipDay(ctx context.Context, day *store.SkipDay) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetSkipDay", ctx, day)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetSkipDay indicates an expected call of SetSkipDay.
+func (mr *MockDutyStoreMockRecorder) SetSkipDay(ctx, day any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetSkipDay", reflect.TypeOf((*MockDutyStore)(nil).SetSkipDay), ctx, day)
+}
+
+// UpdateDuty mocks base method.
+func (m *MockDutyStore) UpdateDuty(ctx context.Context, duty *store.Duty) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateDuty", ctx, duty)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateDuty indicates an expected call of UpdateDuty.
+func (mr *MockDutyStoreMockRecorder) UpdateDuty(ctx, duty any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateDuty", reflect.TypeOf((*MockDutyStore)(nil).UpdateDuty), ctx, duty)
+}
+
+// MockQueueStore is a mock of QueueStore interface.
+type MockQueueStore struct {
+	ctrl     *gomock.Controller
+	recorder *MockQueueStoreMockRecorder
+	isgomock struct{}
+}
+
+// MockQueueStoreMockRecorder is the mock recorder for MockQueueStore.
+type MockQueueStoreMockRecorder struct {
+	mock *MockQueueStore
+}
+
+// NewMockQueueStore creates a new mock instance.
+func NewMockQueueStore(ctrl *gomock.Controller) *MockQueueStore {
+	mock := &MockQueueStore{ctrl: ctrl}
+	mock.recorder = &MockQueueStoreMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockQueueStore) EXPECT() *MockQueueStoreMockRecorder {
+	return m.recorder
+}
+
+// AddToAdminQueue mocks base method.
+func (m *MockQueueStore) AddToAdminQueue(ctx context.Context, userID int64, days int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddToAdminQueue", ctx, userID, days)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddToAdminQueue indicates an expected call of AddToAdminQueue.
+func (mr *MockQueueStoreMockRecorder) AddToAdminQueue(ctx, userID, days any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddToAdminQueue", reflect.TypeOf((*MockQueueStore)(nil).AddToAdminQueue), ctx, userID, days)
+}
+
+// AddToVolunteerQueue mocks base method.
+func (m *MockQueueStore) AddToVolunteerQueue(ctx context.Context, userID int64, days int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddToVolunteerQueue", ctx, userID, days)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddToVolunteerQueue indicates an expected call of AddToVolunteerQueue.
+func (mr *MockQueueStoreMockRecorder) AddToVolunteerQueue(ctx, userID, days any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddToVolunteerQueue", reflect.TypeOf((*MockQueueStore)(nil).AddToVolunteerQueue), ctx, userID, days)
+}
+
+// DecrementAdminQueue mocks base method.
+func (m *MockQueueStore) DecrementAdminQueue(ctx context.Context, userID int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DecrementAdminQueue", ctx, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DecrementAdminQueue indicates an expected call of DecrementAdminQueue.
+func (mr *MockQueueStoreMockRecorder) DecrementAdminQueue(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DecrementAdminQueue", reflect.TypeOf((*MockQueueStore)(nil).DecrementAdminQueue), ctx, userID)
+}
+
+// DecrementVolunteerQueue mocks base method.
+func (m *MockQueueStore) DecrementVolunteerQueue(ctx context.Context, userID int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DecrementVolunteerQueue", ctx, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DecrementVolunteerQueue indicates an expected call of DecrementVolunteerQueue.
+func (mr *MockQueueStoreMockRecorder) DecrementVolunteerQueue(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DecrementVolunteerQueue", reflect.TypeOf((*MockQueueStore)(nil).DecrementVolunteerQueue), ctx, userID)
+}
+
+// GetUsersWithAdminQueue mocks base method.
+func (m *MockQueueStore) GetUsersWithAdminQueue(ctx context.Context) ([]*store.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUsersWithAdminQueue", ctx)
+	ret0, _ := ret[0].([]*store.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUsersWithAdminQueue indicates an expected call of GetUsersWithAdminQueue.
+func (mr *MockQueueStoreMockRecorder) GetUsersWithAdminQueue(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUsersWithAdminQueue", reflect.TypeOf((*MockQueueStore)(nil).GetUsersWithAdminQueue), ctx)
+}
+
+// GetUsersWithVolunteerQueue mocks base method.
+func (m *MockQueueStore) GetUsersWithVolunteerQueue(ctx context.Context) ([]*store.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUsersWithVolunteerQueue", ctx)
+	ret0, _ := ret[0].([]*store.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUsersWithVolunteerQueue indicates an expected call of GetUsersWithVolunteerQueue.
+func (mr *MockQueueStoreMockRecorder) GetUsersWithVolunteerQueue(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUsersWithVolunteerQueue", reflect.TypeOf((*MockQueueStore)(nil).GetUsersWithVolunteerQueue), ctx)
+}
+
+// ListQueueEvents mocks base method.
+func (m *MockQueueStore) ListQueueEvents(ctx context.Context, userID int64) ([]*store.QueueEvent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListQueueEvents", ctx, userID)
+	ret0, _ := ret[0].([]*store.QueueEvent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListQueueEvents indicates an expected call of ListQueueEvents.
+func (mr *MockQueueStoreMockRecorder) ListQueueEvents(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListQueueEvents", reflect.TypeOf((*MockQueueStore)(nil).ListQueueEvents), ctx, userID)
+}
+
+// MockAvailabilityStore is a mock of AvailabilityStore interface.
+type MockAvailabilityStore struct {
+	ctrl     *gomock.Controller
+	recorder *MockAvailabilityStoreMockRecorder
+	isgomock struct{}
+}
+
+// MockAvailabilityStoreMockRecorder is the mock recorder for MockAvailabilityStore.
+type MockAvailabilityStoreMockRecorder struct {
+	mock *MockAvailabilityStore
+}
+
+// NewMockAvailabilityStore creates a new mock instance.
+func NewMockAvailabilityStore(ctrl *gomock.Controller) *MockAvailabilityStore {
+	mock := &MockAvailabilityStore{ctrl: ctrl}
+	mock.recorder = &MockAvailabilityStoreMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAvailabilityStore) EXPECT() *MockAvailabilityStoreMockRecorder {
+	return m.recorder
+}
+
+// ClearOffDuty mocks base method.
+func (m *MockAvailabilityStore) ClearOffDuty(ctx context.Context, userID int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClearOffDuty", ctx, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ClearOffDuty indicates an expected call of ClearOffDuty.
+func (mr *MockAvailabilityStoreMockRecorder) ClearOffDuty(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClearOffDuty", reflect.TypeOf((*MockAvailabilityStore)(nil).ClearOffDuty), ctx, userID)
+}
+
+// DeleteCalendarLink mocks base method.
+func (m *MockAvailabilityStore) DeleteCalendarLink(ctx context.Context, userID int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteCalendarLink", ctx, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteCalendarLink indicates an expected call of DeleteCalendarLink.
+func (mr *MockAvailabilityStoreMockRecorder) DeleteCalendarLink(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteCalendarLink", reflect.TypeOf((*MockAvailabilityStore)(nil).DeleteCalendarLink), ctx, userID)
+}
+
+// GetCalendarLink mocks base method.
+func (m *MockAvailabilityStore) GetCalendarLink(ctx context.Context, userID int64) (*store.CalendarLink, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCalendarLink", ctx, userID)
+	ret0, _ := ret[0].(*store.CalendarLink)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCalendarLink indicates an expected call of GetCalendarLink.
+func (mr *MockAvailabilityStoreMockRecorder) GetCalendarLink(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCalendarLink", reflect.TypeOf((*MockAvailabilityStore)(nil).GetCalendarLink), ctx, userID)
+}
+
+// GetOffDutyUsers mocks base method.
+func (m *MockAvailabilityStore) GetOffDutyUsers(ctx context.Context, date time.Time) ([]*store.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOffDutyUsers", ctx, date)
+	ret0, _ := ret[0].([]*store.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOffDutyUsers indicates an expected call of GetOffDutyUsers.
+func (mr *MockAvailabilityStoreMockRecorder) GetOffDutyUsers(ctx, date any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOffDutyUsers", reflect.TypeOf((*MockAvailabilityStore)(nil).GetOffDutyUsers), ctx, date)
+}
+
+// IsUserOffDuty mocks base method.
+func (m *MockAvailabilityStore) IsUserOffDuty(ctx context.Context, userID int64, date time.Time) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsUserOffDuty", ctx, userID, date)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IsUserOffDuty indicates an expected call of IsUserOffDuty.
+func (mr *MockAvailabilityStoreMockRecorder) IsUserOffDuty(ctx, userID, date any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsUserOffDuty", reflect.TypeOf((*MockAvailabilityStore)(nil).IsUserOffDuty), ctx, userID, date)
+}
+
+// ListCalendarLinks mocks base method.
+func (m *MockAvailabilityStore) ListCalendarLinks(ctx context.Context) ([]*store.CalendarLink, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListCalendarLinks", ctx)
+	ret0, _ := ret[0].([]*store.CalendarLink)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListCalendarLinks indicates an expected call of ListCalendarLinks.
+func (mr *MockAvailabilityStoreMockRecorder) ListCalendarLinks(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListCalendarLinks", reflect.TypeOf((*MockAvailabilityStore)(nil).ListCalendarLinks), ctx)
+}
+
+// ListOffDutyPeriods mocks base method.
+func (m *MockAvailabilityStore) ListOffDutyPeriods(ctx context.Context, userID int64) ([]*store.OffDutyPeriod, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListOffDutyPeriods", ctx, userID)
+	ret0, _ := ret[0].([]*store.OffDutyPeriod)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListOffDutyPeriods indicates an expected call of ListOffDutyPeriods.
+func (mr *MockAvailabilityStoreMockRecorder) ListOffDutyPeriods(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListOffDutyPeriods", reflect.TypeOf((*MockAvailabilityStore)(nil).ListOffDutyPeriods), ctx, userID)
+}
+
+// ReplaceOffDutyPeriods mocks base method.
+func (m *MockAvailabilityStore) ReplaceOffDutyPeriods(ctx context.Context, userID int64, source string, periods []*store.OffDutyPeriod) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReplaceOffDutyPeriods", ctx, userID, source, periods)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReplaceOffDutyPeriods indicates an expected call of ReplaceOffDutyPeriods.
+func (mr *MockAvailabilityStoreMockRecorder) ReplaceOffDutyPeriods(ctx, userID, source, periods any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReplaceOffDutyPeriods", reflect.TypeOf((*MockAvailabilityStore)(nil).ReplaceOffDutyPeriods), ctx, userID, source, periods)
+}
+
+// SetCalendarLink mocks base method.
+func (m *MockAvailabilityStore) SetCalendarLink(ctx context.Context, link *store.CalendarLink) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetCalendarLink", ctx, link)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetCalendarLink indicates an expected call of SetCalendarLink.
+func (mr *MockAvailabilityStoreMockRecorder) SetCalendarLink(ctx, link any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetCalendarLink", reflect.TypeOf((*MockAvailabilityStore)(nil).SetCalendarLink), ctx, link)
+}
+
+// SetOffDuty mocks base method.
+func (m *MockAvailabilityStore) SetOffDuty(ctx context.Context, userID int64, start, end time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetOffDuty", ctx, userID, start, end)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetOffDuty indicates an expected call of SetOffDuty.
+func (mr *MockAvailabilityStoreMockRecorder) SetOffDuty(ctx, userID, start, end any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetOffDuty", reflect.TypeOf((*MockAvailabilityStore)(nil).SetOffDuty), ctx, userID, start, end)
+}
+
+// MockNotificationStore is a mock of NotificationStore interface.
+type MockNotificationStore struct {
+	ctrl     *gomock.Controller
+	recorder *MockNotificationStoreMockRecorder
+	isgomock struct{}
+}
+
+// MockNotificationStoreMockRecorder is the mock recorder for MockNotificationStore.
+type MockNotificationStoreMockRecorder struct {
+	mock *MockNotificationStore
+}
+
+// NewMockNotificationStore creates a new mock instance.
+func NewMockNotificationStore(ctrl *gomock.Controller) *MockNotificationStore {
+	mock := &MockNotificationStore{ctrl: ctrl}
+	mock.recorder = &MockNotificationStoreMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockNotificationStore) EXPECT() *MockNotificationStoreMockRecorder {
+	return m.recorder
+}
+
+// CreateChangeSubscription mocks base method.
+func (m *MockNotificationStore) CreateChangeSubscription(ctx context.Context, sub *store.ChangeSubscription) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateChangeSubscription", ctx, sub)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateChangeSubscription indicates an expected call of CreateChangeSubscription.
+func (mr *MockNotificationStoreMockRecorder) CreateChangeSubscription(ctx, sub any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateChangeSubscription", reflect.TypeOf((*MockNotificationStore)(nil).CreateChangeSubscription), ctx, sub)
+}
+
+// CreatePendingMessage mocks base method.
+func (m *MockNotificationStore) CreatePendingMessage(ctx context.Context, msg *store.PendingMessage) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreatePendingMessage", ctx, msg)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreatePendingMessage indicates an expected call of CreatePendingMessage.
+func (mr *MockNotificationStoreMockRecorder) CreatePendingMessage(ctx, msg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreatePendingMessage", reflect.TypeOf((*MockNotificationStore)(nil).CreatePendingMessage), ctx, msg)
+}
+
+// CreateReminderSnooze mocks base method.
+func (m *MockNotificationStore) CreateReminderSnooze(ctx context.Context, snooze *store.ReminderSnooze) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateReminderSnooze", ctx, snooze)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateReminderSnooze indicates an expected call of CreateReminderSnooze.
+func (mr *MockNotificationStoreMockRecorder) CreateReminderSnooze(ctx, snooze any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateReminderSnooze", reflect.TypeOf((*MockNotificationStore)(nil).CreateReminderSnooze), ctx, snooze)
+}
+
+// DeleteChangeSubscription mocks base method.
+func (m *MockNotificationStore) DeleteChangeSubscription(ctx context.Context, userID int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteChangeSubscription", ctx, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteChangeSubscription indicates an expected call of DeleteChangeSubscription.
+func (mr *MockNotificationStoreMockRecorder) DeleteChangeSubscription(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteChangeSubscription", reflect.TypeOf((*MockNotificationStore)(nil).DeleteChangeSubscription), ctx, userID)
+}
+
+// DeleteConversationState mocks base method.
+func (m *MockNotificationStore) DeleteConversationState(ctx context.Context, key string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteConversationState", ctx, key)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteConversationState indicates an expected call of DeleteConversationState.
+func (mr *MockNotificationStoreMockRecorder) DeleteConversationState(ctx, key any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteConversationState", reflect.TypeOf((*MockNotificationStore)(nil).DeleteConversationState), ctx, key)
+}
+
+// DeleteExpiredConversationStates mocks base method.
+func (m *MockNotificationStore) DeleteExpiredConversationStates(ctx context.Context, now time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteExpiredConversationStates", ctx, now)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteExpiredConversationStates indicates an expected call of DeleteExpiredConversationStates.
+func (mr *MockNotificationStoreMockRecorder) DeleteExpiredConversationStates(ctx, now any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteExpiredConversationStates", reflect.TypeOf((*MockNotificationStore)(nil).DeleteExpiredConversationStates), ctx, now)
+}
+
+// DeleteInteractiveMessage mocks base method.
+func (m *MockNotificationStore) DeleteInteractiveMessage(ctx context.Context, chatID int64, messageID int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteInteractiveMessage", ctx, chatID, messageID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteInteractiveMessage indicates an expected call of DeleteInteractiveMessage.
+func (mr *MockNotificationStoreMockRecorder) DeleteInteractiveMessage(ctx, chatID, messageID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteInteractiveMessage", reflect.TypeOf((*MockNotificationStore)(nil).DeleteInteractiveMessage), ctx, chatID, messageID)
+}
+
+// DeletePendingMessage mocks base method.
+func (m *MockNotificationStore) DeletePendingMessage(ctx context.Context, id int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeletePendingMessage", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeletePendingMessage indicates an expected call of DeletePendingMessage.
+func (mr *MockNotificationStoreMockRecorder) DeletePendingMessage(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeletePendingMessage", reflect.TypeOf((*MockNotificationStore)(nil).DeletePendingMessage), ctx, id)
+}
+
+// DeleteReminderSnooze mocks base method.
+func (m *MockNotificationStore) DeleteReminderSnooze(ctx context.Context, id int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteReminderSnooze", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteReminderSnooze indicates an expected call of DeleteReminderSnooze.
+func (mr *MockNotificationStoreMockRecorder) DeleteReminderSnooze(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteReminderSnooze", reflect.TypeOf((*MockNotificationStore)(nil).DeleteReminderSnooze), ctx, id)
+}
+
+// GetChangeSubscription mocks base method.
+func (m *MockNotificationStore) GetChangeSubscription(ctx context.Context, userID int64) (*store.ChangeSubscription, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetChangeSubscription", ctx, userID)
+	ret0, _ := ret[0].(*store.ChangeSubscription)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetChangeSubscription indicates an expected call of GetChangeSubscription.
+func (mr *MockNotificationStoreMockRecorder) GetChangeSubscription(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetChangeSubscription", reflect.TypeOf((*MockNotificationStore)(nil).GetChangeSubscription), ctx, userID)
+}
+
+// GetChatDisplay mocks base method.
+func (m *MockNotificationStore) GetChatDisplay(ctx context.Context, chatID int64) (*store.ChatDisplay, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetChatDisplay", ctx, chatID)
+	ret0, _ := ret[0].(*store.ChatDisplay)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetChatDisplay indicates an expected call of GetChatDisplay.
+func (mr *MockNotificationStoreMockRecorder) GetChatDisplay(ctx, chatID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetChatDisplay", reflect.TypeOf((*MockNotificationStore)(nil).GetChatDisplay), ctx, chatID)
+}
+
+// GetChatLocale mocks base method.
+func (m *MockNotificationStore) GetChatLocale(ctx context.Context, chatID int64) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetChatLocale", ctx, chatID)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetChatLocale indicates an expected call of GetChatLocale.
+func (mr *MockNotificationStoreMockRecorder) GetChatLocale(ctx, chatID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetChatLocale", reflect.TypeOf((*MockNotificationStore)(nil).GetChatLocale), ctx, chatID)
+}
+
+// GetNotificationPreferences mocks base method.
+func (m *MockNotificationStore) GetNotificationPreferences(ctx context.Context, userID int64) (*store.NotificationPreferences, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetNotificationPreferences", ctx, userID)
+	ret0, _ := ret[0].(*store.NotificationPreferences)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetNotificationPreferences indicates an expected call of GetNotificationPreferences.
+func (mr *MockNotificationStoreMockRecorder) GetNotificationPreferences(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetNotificationPreferences", reflect.TypeOf((*MockNotificationStore)(nil).GetNotificationPreferences), ctx, userID)
+}
+
+// ListConversationStates mocks base method.
+func (m *MockNotificationStore) ListConversationStates(ctx context.Context, now time.Time) ([]*store.ConversationState, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListConversationStates", ctx, now)
+	ret0, _ := ret[0].([]*store.ConversationState)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListConversationStates indicates an expected call of ListConversationStates.
+func (mr *MockNotificationStoreMockRecorder) ListConversationStates(ctx, now any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListConversationStates", reflect.TypeOf((*MockNotificationStore)(nil).ListConversationStates), ctx, now)
+}
+
+// ListInteractiveMessages mocks base method.
+func (m *MockNotificationStore) ListInteractiveMessages(ctx context.Context) ([]*store.InteractiveMessage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListInteractiveMessages", ctx)
+	ret0, _ := ret[0].([]*store.InteractiveMessage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListInteractiveMessages indicates an expected call of ListInteractiveMessages.
+func (mr *MockNotificationStoreMockRecorder) ListInteractiveMessages(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListInteractiveMessages", reflect.TypeOf((*MockNotificationStore)(nil).ListInteractiveMessages), ctx)
+}
+
+// ListPendingMessages mocks base method.
+func (m *MockNotificationStore) ListPendingMessages(ctx context.Context) ([]*store.PendingMessage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListPendingMessages", ctx)
+	ret0, _ := ret[0].([]*store.PendingMessage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListPendingMessages indicates an expected call of ListPendingMessages.
+func (mr *MockNotificationStoreMockRecorder) ListPendingMessages(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListPendingMessages", reflect.TypeOf((*MockNotificationStore)(nil).ListPendingMessages), ctx)
+}
+
+// ListReminderSnoozes mocks base method.
+func (m *MockNotificationStore) ListReminderSnoozes(ctx context.Context) ([]*store.ReminderSnooze, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListReminderSnoozes", ctx)
+	ret0, _ := ret[0].([]*store.ReminderSnooze)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListReminderSnoozes indicates an expected call of ListReminderSnoozes.
+func (mr *MockNotificationStoreMockRecorder) ListReminderSnoozes(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListReminderSnoozes", reflect.TypeOf((*MockNotificationStore)(nil).ListReminderSnoozes), ctx)
+}
+
+// MarkInteractiveMessageDone mocks base method.
+func (m *MockNotificationStore) MarkInteractiveMessageDone(ctx context.Context, chatID int64, messageID int, at time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkInteractiveMessageDone", ctx, chatID, messageID, at)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkInteractiveMessageDone indicates an expected call of MarkInteractiveMessageDone.
+func (mr *MockNotificationStoreMockRecorder) MarkInteractiveMessageDone(ctx, chatID, messageID, at any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkInteractiveMessageDone", reflect.TypeOf((*MockNotificationStore)(nil).MarkInteractiveMessageDone), ctx, chatID, messageID, at)
+}
+
+// SaveConversationState mocks base method.
+func (m *MockNotificationStore) SaveConversationState(ctx context.Context, state *store.ConversationState) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveConversationState", ctx, state)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveConversationState indicates an expected call of SaveConversationState.
+func (mr *MockNotificationStoreMockRecorder) SaveConversationState(ctx, state any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveConversationState", reflect.TypeOf((*MockNotificationStore)(nil).SaveConversationState), ctx, state)
+}
+
+// SetChatDisplay mocks base method.
+func (m *MockNotificationStore) SetChatDisplay(ctx context.Context, chatID int64, display store.ChatDisplay) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetChatDisplay", ctx, chatID, display)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetChatDisplay indicates an expected call of SetChatDisplay.
+func (mr *MockNotificationStoreMockRecorder) SetChatDisplay(ctx, chatID, display any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetChatDisplay", reflect.TypeOf((*MockNotificationStore)(nil).SetChatDisplay), ctx, chatID, display)
+}
+
+// SetChatLocale mocks base method.
+func (m *MockNotificationStore) SetChatLocale(ctx context.Context, chatID int64, locale string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetChatLocale", ctx, chatID, locale)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetChatLocale indicates an expected call of SetChatLocale.
+func (mr *MockNotificationStoreMockRecorder) SetChatLocale(ctx, chatID, locale any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetChatLocale", reflect.TypeOf((*MockNotificationStore)(nil).SetChatLocale), ctx, chatID, locale)
+}
+
+// SetNotificationPreferences mocks base method.
+func (m *MockNotificationStore) SetNotificationPreferences(ctx context.Context, prefs *store.NotificationPreferences) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetNotificationPreferences", ctx, prefs)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetNotificationPreferences indicates an expected call of SetNotificationPreferences.
+func (mr *MockNotificationStoreMockRecorder) SetNotificationPreferences(ctx, prefs any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetNotificationPreferences", reflect.TypeOf((*MockNotificationStore)(nil).SetNotificationPreferences), ctx, prefs)
+}
+
+// TrackInteractiveMessage mocks base method.
+func (m *MockNotificationStore) TrackInteractiveMessage(ctx context.Context, msg *store.InteractiveMessage) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TrackInteractiveMessage", ctx, msg)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// TrackInteractiveMessage indicates an expected call of TrackInteractiveMessage.
+func (mr *MockNotificationStoreMockRecorder) TrackInteractiveMessage(ctx, msg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TrackInteractiveMessage", reflect.TypeOf((*MockNotificationStore)(nil).TrackInteractiveMessage), ctx, msg)
+}
+
+// MockSettingStore is a mock of SettingStore interface.
+type MockSettingStore struct {
+	ctrl     *gomock.Controller
+	recorder *MockSettingStoreMockRecorder
+	isgomock struct{}
+}
+
+// MockSettingStoreMockRecorder is the mock recorder for MockSettingStore.
+type MockSettingStoreMockRecorder struct {
+	mock *MockSettingStore
+}
+
+// NewMockSettingStore creates a new mock instance.
+func NewMockSettingStore(ctrl *gomock.Controller) *MockSettingStore {
+	mock := &MockSettingStore{ctrl: ctrl}
+	mock.recorder = &MockSettingStoreMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSettingStore) EXPECT() *MockSettingStoreMockRecorder {
+	return m.recorder
+}
+
+// GetSetting mocks base method.
+func (m *MockSettingStore) GetSetting(ctx context.Context, key string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSetting", ctx, key)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSetting indicates an expected call of GetSetting.
+func (mr *MockSettingStoreMockRecorder) GetSetting(ctx, key any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSetting", reflect.TypeOf((*MockSettingStore)(nil).GetSetting), ctx, key)
+}
+
+// ListSettings mocks base method.
+func (m *MockSettingStore) ListSettings(ctx context.Context) (map[string]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListSettings", ctx)
+	ret0, _ := ret[0].(map[string]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListSettings indicates an expected call of ListSettings.
+func (mr *MockSettingStoreMockRecorder) ListSettings(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListSettings", reflect.TypeOf((*MockSettingStore)(nil).ListSettings), ctx)
+}
+
+// SetSetting mocks base method.
+func (m *MockSettingStore) SetSetting(ctx context.Context, key, value string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetSetting", ctx, key, value)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetSetting indicates an expected call of SetSetting.
+func (mr *MockSettingStoreMockRecorder) SetSetting(ctx, key, value any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetSetting", reflect.TypeOf((*MockSettingStore)(nil).SetSetting), ctx, key, value)
+}
+
+// MockMaintenanceStore is a mock of MaintenanceStore interface.
+type MockMaintenanceStore struct {
+	ctrl     *gomock.Controller
+	recorder *MockMaintenanceStoreMockRecorder
+	isgomock struct{}
+}
+
+// MockMaintenanceStoreMockRecorder is the mock recorder for MockMaintenanceStore.
+type MockMaintenanceStoreMockRecorder struct {
+	mock *MockMaintenanceStore
+}
+
+// NewMockMaintenanceStore creates a new mock instance.
+func NewMockMaintenanceStore(ctrl *gomock.Controller) *MockMaintenanceStore {
+	mock := &MockMaintenanceStore{ctrl: ctrl}
+	mock.recorder = &MockMaintenanceStoreMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockMaintenanceStore) EXPECT() *MockMaintenanceStoreMockRecorder {
+	return m.recorder
+}
+
+// CreateMaintenanceRun mocks base method.
+func (m *MockMaintenanceStore) CreateMaintenanceRun(ctx context.Context, run *store.MaintenanceRun) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateMaintenanceRun", ctx, run)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateMaintenanceRun indicates an expected call of CreateMaintenanceRun.
+func (mr *MockMaintenanceStoreMockRecorder) CreateMaintenanceRun(ctx, run any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateMaintenanceRun", reflect.TypeOf((*MockMaintenanceStore)(nil).CreateMaintenanceRun), ctx, run)
+}
+
+// DatabaseSize mocks base method.
+func (m *MockMaintenanceStore) DatabaseSize(ctx context.Context) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DatabaseSize", ctx)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DatabaseSize indicates an expected call of DatabaseSize.
+func (mr *MockMaintenanceStoreMockRecorder) DatabaseSize(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DatabaseSize", reflect.TypeOf((*MockMaintenanceStore)(nil).DatabaseSize), ctx)
+}
+
+// DeleteLogsBefore mocks base method.
+func (m *MockMaintenanceStore) DeleteLogsBefore(ctx context.Context, before time.Time) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteLogsBefore", ctx, before)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteLogsBefore indicates an expected call of DeleteLogsBefore.
+func (mr *MockMaintenanceStoreMockRecorder) DeleteLogsBefore(ctx, before any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteLogsBefore", reflect.TypeOf((*MockMaintenanceStore)(nil).DeleteLogsBefore), ctx, before)
+}
+
+// ListMaintenanceRuns mocks base method.
+func (m *MockMaintenanceStore) ListMaintenanceRuns(ctx context.Context, limit int) ([]*store.MaintenanceRun, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListMaintenanceRuns", ctx, limit)
+	ret0, _ := ret[0].([]*store.MaintenanceRun)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListMaintenanceRuns indicates an expected call of ListMaintenanceRuns.
+func (mr *MockMaintenanceStoreMockRecorder) ListMaintenanceRuns(ctx, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListMaintenanceRuns", reflect.TypeOf((*MockMaintenanceStore)(nil).ListMaintenanceRuns), ctx, limit)
+}
+
+// Optimize mocks base method.
+func (m *MockMaintenanceStore) Optimize(ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Optimize", ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Optimize indicates an expected call of Optimize.
+func (mr *MockMaintenanceStoreMockRecorder) Optimize(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Optimize", reflect.TypeOf((*MockMaintenanceStore)(nil).Optimize), ctx)
+}
+
+// MockTxStore is a mock of TxStore interface.
+type MockTxStore struct {
+	ctrl     *gomock.Controller
+	recorder *MockTxStoreMockRecorder
+	isgomock struct{}
+}
+
+// MockTxStoreMockRecorder is the mock recorder for MockTxStore.
+type MockTxStoreMockRecorder struct {
+	mock *MockTxStore
+}
+
+// NewMockTxStore creates a new mock instance.
+func NewMockTxStore(ctrl *gomock.Controller) *MockTxStore {
+	mock := &MockTxStore{ctrl: ctrl}
+	mock.recorder = &MockTxStoreMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockTxStore) EXPECT() *MockTxStoreMockRecorder {
+	return m.recorder
+}
+
+// RunInTx mocks base method.
+func (m *MockTxStore) RunInTx(ctx context.Context, fn func(store.Store) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RunInTx", ctx, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RunInTx indicates an expected call of RunInTx.
+func (mr *MockTxStoreMockRecorder) RunInTx(ctx, fn any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RunInTx", reflect.TypeOf((*MockTxStore)(nil).RunInTx), ctx, fn)
+}
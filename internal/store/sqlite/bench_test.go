@@ -0,0 +1,86 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/korjavin/dutyassistant/internal/store"
+)
+
+// benchmarkYears is how much history the benchmarks seed: a household that
+// has used the bot daily for several years.
+const benchmarkYears = 5
+
+// seedHistory creates an on-disk database with users and a duty for every day
+// of the last benchmarkYears years.
+func seedHistory(b *testing.B, users int) *SQLiteStore {
+	b.Helper()
+	ctx := context.Background()
+	s, err := New(ctx, filepath.Join(b.TempDir(), "bench.db"))
+	if err != nil {
+		b.Fatalf("Failed to create database: %v", err)
+	}
+	b.Cleanup(func() { s.db.Close() })
+
+	var ids []int64
+	for i := 0; i < users; i++ {
+		u := &store.User{TelegramUserID: int64(i + 1), FirstName: fmt.Sprintf("User %d", i), IsActive: true}
+		if err := s.CreateUser(ctx, u); err != nil {
+			b.Fatalf("CreateUser failed: %v", err)
+		}
+		ids = append(ids, u.ID)
+	}
+
+	end := time.Date(2025, 12, 31, 0, 0, 0, 0, time.UTC)
+	for i, date := 0, end.AddDate(-benchmarkYears, 0, 1); !date.After(end); i, date = i+1, date.AddDate(0, 0, 1) {
+		duty := &store.Duty{UserID: ids[i%len(ids)], DutyDate: date, AssignmentType: store.AssignmentTypeRoundRobin, CreatedAt: date}
+		if err := s.CreateDuty(ctx, duty); err != nil {
+			b.Fatalf("CreateDuty failed: %v", err)
+		}
+	}
+	return s
+}
+
+func BenchmarkGetDutiesByMonth(b *testing.B) {
+	s := seedHistory(b, 6)
+	ctx := context.Background()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := s.GetDutiesByMonth(ctx, 2021+i%benchmarkYears, time.Month(1+i%12)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkGetDutiesByMonthParallel(b *testing.B) {
+	s := seedHistory(b, 6)
+	ctx := context.Background()
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			if _, err := s.GetDutiesByMonth(ctx, 2021+i%benchmarkYears, time.Month(1+i%12)); err != nil {
+				b.Error(err)
+				return
+			}
+			i++
+		}
+	})
+}
+
+func BenchmarkGetUserStats(b *testing.B) {
+	s := seedHistory(b, 6)
+	ctx := context.Background()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := s.GetUserStats(ctx, int64(1+i%6)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
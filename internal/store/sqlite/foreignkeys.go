@@ -0,0 +1,170 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// onDelete is what happens to a table's rows when the row their foreign key
+// references is deleted. Duties are the roster's history and keep their user
+// from being deleted: a user who has done duties is deactivated or merged
+// instead. Everything else a user owns goes with them, tasks they claimed are
+// open to others again, an item's checks go with the checklist item and a
+// schedule version's days with the version.
+var onDelete = map[string]string{
+	"duties":                   "RESTRICT",
+	"off_duty_periods":         "CASCADE",
+	"calendar_links":           "CASCADE",
+	"notification_preferences": "CASCADE",
+	"change_subscriptions":     "CASCADE",
+	"reminder_snoozes":         "CASCADE",
+	"checklist_checks":         "CASCADE",
+	"round_robin_state":        "CASCADE",
+	"user_stats":               "CASCADE",
+	"badges":                   "CASCADE",
+	"ledger_entries":           "CASCADE",
+	"queue_events":             "CASCADE",
+	"schedule_version_days":    "CASCADE",
+	"tasks":                    "SET NULL",
+	"cover_requests":           "CASCADE",
+	"login_codes":              "CASCADE",
+	"web_sessions":             "CASCADE",
+}
+
+// referencesClause matches a foreign key's REFERENCES clause along with the
+// ON DELETE action it may already have.
+var referencesClause = regexp.MustCompile(`(?i)(REFERENCES\s+\w+\s*\(\s*\w+\s*\))(\s+ON\s+DELETE\s+(SET\s+NULL|SET\s+DEFAULT|CASCADE|RESTRICT|NO\s+ACTION))?`)
+
+// tableName matches the start of a table's definition up to its name.
+var tableName = regexp.MustCompile(`^CREATE TABLE\s+"?\w+"?`)
+
+// foreignKey is a row of PRAGMA foreign_key_list.
+type foreignKey struct {
+	parent   string
+	from     string
+	onDelete string
+}
+
+// foreignKeys returns the foreign keys of a table.
+func foreignKeys(ctx context.Context, q querier, table string) ([]foreignKey, error) {
+	rows, err := q.QueryContext(ctx, `SELECT "table", "from", on_delete FROM pragma_foreign_key_list(?)`, table)
+	if err != nil {
+		return nil, fmt.Errorf("could not query foreign keys of %s: %w", table, err)
+	}
+	defer rows.Close()
+
+	var keys []foreignKey
+	for rows.Next() {
+		var fk foreignKey
+		if err := rows.Scan(&fk.parent, &fk.from, &fk.onDelete); err != nil {
+			return nil, fmt.Errorf("could not scan foreign key row: %w", err)
+		}
+		keys = append(keys, fk)
+	}
+	return keys, rows.Err()
+}
+
+// migrateForeignKeys rebuilds the tables whose foreign keys were created
+// without their onDelete action, as SQLite can't alter a table's constraints.
+// Rows of cascading tables whose parent is already gone are dropped on the
+// way, as deleting the parent would have done.
+func (s *SQLiteStore) migrateForeignKeys(ctx context.Context) error {
+	var stale []string
+	for table, action := range onDelete {
+		keys, err := foreignKeys(ctx, s.db, table)
+		if err != nil {
+			return err
+		}
+		for _, fk := range keys {
+			if !strings.EqualFold(fk.onDelete, action) {
+				stale = append(stale, table)
+				break
+			}
+		}
+	}
+	if len(stale) == 0 {
+		return nil
+	}
+
+	// Foreign keys must be off while a table is dropped and renamed, and the
+	// pragma only applies to the connection it is run on, outside transactions
+	conn, err := s.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("could not get a connection: %w", err)
+	}
+	defer conn.Close()
+	if _, err := conn.ExecContext(ctx, `PRAGMA foreign_keys = OFF`); err != nil {
+		return fmt.Errorf("could not turn off foreign keys: %w", err)
+	}
+	defer conn.ExecContext(context.Background(), `PRAGMA foreign_keys = ON`)
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("could not begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	for _, table := range stale {
+		if err := rebuildTable(ctx, tx, table, onDelete[table]); err != nil {
+			return err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("could not commit foreign key migration: %w", err)
+	}
+	return nil
+}
+
+// rebuildTable recreates a table with the given ON DELETE action on its
+// foreign keys, keeping its rows and indexes.
+func rebuildTable(ctx context.Context, tx *sql.Tx, table, action string) error {
+	var definition string
+	if err := tx.QueryRowContext(ctx, `SELECT sql FROM sqlite_master WHERE type = 'table' AND name = ?`, table).Scan(&definition); err != nil {
+		return fmt.Errorf("could not query definition of %s: %w", table, err)
+	}
+	if !tableName.MatchString(definition) {
+		return fmt.Errorf("could not rebuild %s: unexpected definition %q", table, definition)
+	}
+	definition = tableName.ReplaceAllString(definition, "CREATE TABLE "+table+"_new")
+	definition = referencesClause.ReplaceAllString(definition, "$1 ON DELETE "+action)
+
+	rows, err := tx.QueryContext(ctx, `SELECT sql FROM sqlite_master WHERE type = 'index' AND tbl_name = ? AND sql IS NOT NULL`, table)
+	if err != nil {
+		return fmt.Errorf("could not query indexes of %s: %w", table, err)
+	}
+	var indexes []string
+	for rows.Next() {
+		var index string
+		if err := rows.Scan(&index); err != nil {
+			rows.Close()
+			return fmt.Errorf("could not scan index row: %w", err)
+		}
+		indexes = append(indexes, index)
+	}
+	rows.Close()
+
+	keys, err := foreignKeys(ctx, tx, table)
+	if err != nil {
+		return err
+	}
+	statements := []string{definition}
+	if action == "CASCADE" {
+		for _, fk := range keys {
+			statements = append(statements, fmt.Sprintf(`DELETE FROM %s WHERE %s NOT IN (SELECT id FROM %s)`, table, fk.from, fk.parent))
+		}
+	}
+	statements = append(statements,
+		fmt.Sprintf(`INSERT INTO %s_new SELECT * FROM %s`, table, table),
+		fmt.Sprintf(`DROP TABLE %s`, table),
+		fmt.Sprintf(`ALTER TABLE %s_new RENAME TO %s`, table, table),
+	)
+	statements = append(statements, indexes...)
+	for _, statement := range statements {
+		if _, err := tx.ExecContext(ctx, statement); err != nil {
+			return fmt.Errorf("could not rebuild %s: %w", table, err)
+		}
+	}
+	return nil
+}
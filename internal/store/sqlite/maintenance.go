@@ -0,0 +1,113 @@
+package sqlite
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/korjavin/dutyassistant/internal/store"
+)
+
+// DatabaseSize returns how many bytes the database file takes, not counting
+// the write-ahead log.
+func (s *SQLiteStore) DatabaseSize(ctx context.Context) (int64, error) {
+	var pages, pageSize int64
+	if err := s.conn().QueryRowContext(ctx, `PRAGMA page_count`).Scan(&pages); err != nil {
+		return 0, fmt.Errorf("could not query page count: %w", err)
+	}
+	if err := s.conn().QueryRowContext(ctx, `PRAGMA page_size`).Scan(&pageSize); err != nil {
+		return 0, fmt.Errorf("could not query page size: %w", err)
+	}
+	return pages * pageSize, nil
+}
+
+// Optimize rebuilds the database file without its free pages, refreshes the
+// statistics the query planner relies on, and truncates the write-ahead log
+// that VACUUM filled with a copy of the whole database.
+func (s *SQLiteStore) Optimize(ctx context.Context) error {
+	if s.tx != nil {
+		return errors.New("could not optimize database: it can't be optimized in a transaction")
+	}
+	for _, stmt := range []string{`VACUUM`, `PRAGMA optimize`, `PRAGMA wal_checkpoint(TRUNCATE)`} {
+		if _, err := s.db.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("could not optimize database: %s: %w", stmt, err)
+		}
+	}
+	return nil
+}
+
+// DeleteLogsBefore deletes the shadow comparisons, assignment explanations
+// and maintenance runs of days before the given one, and returns how many
+// rows it deleted.
+func (s *SQLiteStore) DeleteLogsBefore(ctx context.Context, before time.Time) (int, error) {
+	tx, err := s.begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("could not begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	day := before.Format("2006-01-02")
+	queries := []string{
+		`DELETE FROM shadow_comparisons WHERE date < ?`,
+		`DELETE FROM assignment_candidates WHERE date < ?`,
+		`DELETE FROM assignment_explanations WHERE date < ?`,
+		`DELETE FROM maintenance_runs WHERE date(ran_at) < ?`,
+	}
+	deleted := 0
+	for _, q := range queries {
+		res, err := tx.ExecContext(ctx, q, day)
+		if err != nil {
+			return 0, fmt.Errorf("could not delete old log rows: %w", err)
+		}
+		n, err := res.RowsAffected()
+		if err != nil {
+			return 0, fmt.Errorf("could not get deleted log rows: %w", err)
+		}
+		deleted += int(n)
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("could not commit transaction: %w", err)
+	}
+	return deleted, nil
+}
+
+// CreateMaintenanceRun records a run and sets its ID.
+func (s *SQLiteStore) CreateMaintenanceRun(ctx context.Context, run *store.MaintenanceRun) error {
+	res, err := s.conn().ExecContext(ctx, `INSERT INTO maintenance_runs (ran_at, size_before, size_after, deleted) VALUES (?, ?, ?, ?)`,
+		run.RanAt.UTC().Format(time.RFC3339), run.SizeBefore, run.SizeAfter, run.Deleted)
+	if err != nil {
+		return fmt.Errorf("could not create maintenance run: %w", err)
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("could not get last insert ID for maintenance run: %w", err)
+	}
+	run.ID = id
+	return nil
+}
+
+// ListMaintenanceRuns returns the latest runs, at most limit, newest first.
+func (s *SQLiteStore) ListMaintenanceRuns(ctx context.Context, limit int) ([]*store.MaintenanceRun, error) {
+	rows, err := s.conn().QueryContext(ctx, `
+		SELECT id, ran_at, size_before, size_after, deleted
+		FROM maintenance_runs ORDER BY ran_at DESC, id DESC LIMIT ?`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("could not query maintenance runs: %w", err)
+	}
+	defer rows.Close()
+
+	var runs []*store.MaintenanceRun
+	for rows.Next() {
+		run := &store.MaintenanceRun{}
+		var ranAt string
+		if err := rows.Scan(&run.ID, &ranAt, &run.SizeBefore, &run.SizeAfter, &run.Deleted); err != nil {
+			return nil, fmt.Errorf("could not scan maintenance run row: %w", err)
+		}
+		if run.RanAt, err = time.Parse(time.RFC3339, ranAt); err != nil {
+			return nil, fmt.Errorf("could not parse ran at: %w", err)
+		}
+		runs = append(runs, run)
+	}
+	return runs, rows.Err()
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/korjavin/dutyassistant/internal/store"
@@ -13,12 +14,20 @@ import (
 
 // SQLiteStore is a concrete implementation of the store.Store interface for SQLite.
 type SQLiteStore struct {
-	db *sql.DB
+	db    *sql.DB
+	tx    *sql.Tx // Set on the stores RunInTx hands out
+	depth int     // How deeply those are nested, to name savepoints
 }
 
+// connectionPragmas are applied to every pooled connection. WAL lets the web
+// app keep reading the schedule while the bot writes, the busy timeout makes
+// concurrent writers wait instead of failing with SQLITE_BUSY, and SQLite only
+// enforces foreign keys on connections that ask for it.
+var connectionPragmas = []string{"busy_timeout(5000)", "journal_mode(WAL)", "synchronous(NORMAL)", "foreign_keys(1)"}
+
 // New creates a new SQLiteStore instance.
 func New(ctx context.Context, dataSourceName string) (*SQLiteStore, error) {
-	db, err := sql.Open("sqlite", dataSourceName)
+	db, err := sql.Open("sqlite", withPragmas(dataSourceName))
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
@@ -36,6 +45,26 @@ func New(ctx context.Context, dataSourceName string) (*SQLiteStore, error) {
 	return s, nil
 }
 
+// withPragmas appends connectionPragmas to the data source name unless the
+// caller already set them.
+func withPragmas(dataSourceName string) string {
+	var params []string
+	for _, pragma := range connectionPragmas {
+		name := pragma[:strings.Index(pragma, "(")]
+		if !strings.Contains(dataSourceName, name) {
+			params = append(params, "_pragma="+pragma)
+		}
+	}
+	if len(params) == 0 {
+		return dataSourceName
+	}
+	separator := "?"
+	if strings.Contains(dataSourceName, "?") {
+		separator = "&"
+	}
+	return dataSourceName + separator + strings.Join(params, "&")
+}
+
 // migrate creates the necessary database tables if they don't exist.
 func (s *SQLiteStore) migrate(ctx context.Context) error {
 	const schema = `
@@ -48,7 +77,16 @@ func (s *SQLiteStore) migrate(ctx context.Context) error {
 			volunteer_queue_days INTEGER NOT NULL DEFAULT 0,
 			admin_queue_days INTEGER NOT NULL DEFAULT 0,
 			off_duty_start TEXT,
-			off_duty_end TEXT
+			off_duty_end TEXT,
+			handle TEXT NOT NULL DEFAULT '',
+			custom_name INTEGER NOT NULL DEFAULT 0,
+			is_junior INTEGER NOT NULL DEFAULT 0,
+			emoji TEXT NOT NULL DEFAULT '',
+			pool TEXT NOT NULL DEFAULT '',
+			is_pending INTEGER NOT NULL DEFAULT 0,
+			timezone TEXT NOT NULL DEFAULT '',
+			privacy TEXT NOT NULL DEFAULT '',
+			alias TEXT NOT NULL DEFAULT ''
 		);
 
 		CREATE TABLE IF NOT EXISTS duties (
@@ -58,10 +96,320 @@ func (s *SQLiteStore) migrate(ctx context.Context) error {
 			assignment_type TEXT NOT NULL,
 			created_at TEXT NOT NULL,
 			completed_at TEXT,
-			FOREIGN KEY(user_id) REFERENCES users(id)
+			completion_by INTEGER NOT NULL DEFAULT 0,
+			published INTEGER NOT NULL DEFAULT 0,
+			backfilled_at TEXT,
+			hold_until TEXT,
+			note TEXT NOT NULL DEFAULT '',
+			status TEXT NOT NULL DEFAULT 'announced',
+			announced_at TEXT,
+			handoff_note TEXT NOT NULL DEFAULT '',
+			FOREIGN KEY(user_id) REFERENCES users(id) ON DELETE RESTRICT
+		);
+
+		CREATE INDEX IF NOT EXISTS idx_duties_user ON duties(user_id, duty_date);
+
+		CREATE TABLE IF NOT EXISTS duty_changes (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			duty_date TEXT NOT NULL,
+			user_id INTEGER NOT NULL,
+			action TEXT NOT NULL,
+			assignment_type TEXT NOT NULL,
+			changed_at TEXT NOT NULL
+		);
+
+		CREATE TABLE IF NOT EXISTS off_duty_periods (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			user_id INTEGER NOT NULL,
+			start_date TEXT NOT NULL,
+			end_date TEXT NOT NULL,
+			source TEXT NOT NULL,
+			external_id TEXT NOT NULL DEFAULT '',
+			summary TEXT NOT NULL DEFAULT '',
+			FOREIGN KEY(user_id) REFERENCES users(id) ON DELETE CASCADE
+		);
+
+		CREATE INDEX IF NOT EXISTS idx_off_duty_periods_user ON off_duty_periods(user_id);
+
+		CREATE TABLE IF NOT EXISTS calendar_links (
+			user_id INTEGER PRIMARY KEY,
+			url TEXT NOT NULL,
+			last_synced_at TEXT,
+			last_error TEXT NOT NULL DEFAULT '',
+			FOREIGN KEY(user_id) REFERENCES users(id) ON DELETE CASCADE
+		);
+
+		CREATE TABLE IF NOT EXISTS notification_preferences (
+			user_id INTEGER PRIMARY KEY,
+			group_reminder INTEGER NOT NULL DEFAULT 0,
+			personal_dm INTEGER NOT NULL DEFAULT 1,
+			weekly_stats INTEGER NOT NULL DEFAULT 1,
+			swap_requests INTEGER NOT NULL DEFAULT 1,
+			reminder_hour INTEGER NOT NULL DEFAULT 11,
+			FOREIGN KEY(user_id) REFERENCES users(id) ON DELETE CASCADE
+		);
+
+		CREATE TABLE IF NOT EXISTS change_subscriptions (
+			user_id INTEGER PRIMARY KEY,
+			created_at TEXT NOT NULL,
+			FOREIGN KEY(user_id) REFERENCES users(id) ON DELETE CASCADE
+		);
+
+		CREATE TABLE IF NOT EXISTS skip_days (
+			date TEXT PRIMARY KEY,
+			reason TEXT NOT NULL,
+			created_at TEXT NOT NULL
+		);
+
+		CREATE TABLE IF NOT EXISTS reminder_snoozes (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			user_id INTEGER NOT NULL,
+			duty_date TEXT NOT NULL,
+			remind_at TEXT NOT NULL,
+			FOREIGN KEY(user_id) REFERENCES users(id) ON DELETE CASCADE
+		);
+
+		CREATE TABLE IF NOT EXISTS pending_messages (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			chat_id INTEGER NOT NULL,
+			text TEXT NOT NULL,
+			created_at TEXT NOT NULL
+		);
+
+		CREATE TABLE IF NOT EXISTS interactive_messages (
+			chat_id INTEGER NOT NULL,
+			message_id INTEGER NOT NULL,
+			sent_at TEXT NOT NULL,
+			done_at TEXT,
+			PRIMARY KEY (chat_id, message_id)
+		);
+
+		CREATE TABLE IF NOT EXISTS conversation_states (
+			key TEXT PRIMARY KEY,
+			value TEXT NOT NULL,
+			expires_at TEXT NOT NULL
+		);
+
+		CREATE TABLE IF NOT EXISTS chat_locales (
+			chat_id INTEGER PRIMARY KEY,
+			locale TEXT NOT NULL
+		);
+
+		CREATE TABLE IF NOT EXISTS chat_display (
+			chat_id INTEGER PRIMARY KEY,
+			week_numbers BOOLEAN NOT NULL DEFAULT 0,
+			adjacent_days BOOLEAN NOT NULL DEFAULT 0
+		);
+
+		CREATE TABLE IF NOT EXISTS settings (
+			key TEXT PRIMARY KEY,
+			value TEXT NOT NULL
+		);
+
+		CREATE TABLE IF NOT EXISTS shadow_comparisons (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			date TEXT NOT NULL,
+			strategy TEXT NOT NULL,
+			live_user_id INTEGER NOT NULL,
+			shadow_user_id INTEGER NOT NULL,
+			created_at TEXT NOT NULL
+		);
+
+		CREATE TABLE IF NOT EXISTS schedule_versions (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			month TEXT NOT NULL,
+			version INTEGER NOT NULL,
+			created_at TEXT NOT NULL,
+			UNIQUE(month, version)
+		);
+
+		CREATE TABLE IF NOT EXISTS schedule_version_days (
+			version_id INTEGER NOT NULL,
+			date TEXT NOT NULL,
+			user_id INTEGER NOT NULL DEFAULT 0,
+			assignment_type TEXT NOT NULL DEFAULT '',
+			status TEXT NOT NULL DEFAULT '',
+			skip_reason TEXT NOT NULL DEFAULT '',
+			PRIMARY KEY(version_id, date),
+			FOREIGN KEY(version_id) REFERENCES schedule_versions(id) ON DELETE CASCADE
+		);
+
+		CREATE TABLE IF NOT EXISTS assignment_explanations (
+			date TEXT PRIMARY KEY,
+			user_id INTEGER NOT NULL,
+			assignment_type TEXT NOT NULL,
+			strategy TEXT NOT NULL,
+			reason TEXT NOT NULL,
+			created_at TEXT NOT NULL
+		);
+
+		CREATE TABLE IF NOT EXISTS assignment_candidates (
+			date TEXT NOT NULL,
+			user_id INTEGER NOT NULL,
+			excluded TEXT NOT NULL DEFAULT '',
+			volunteer_queue_days INTEGER NOT NULL DEFAULT 0,
+			admin_queue_days INTEGER NOT NULL DEFAULT 0,
+			recent_duties INTEGER NOT NULL DEFAULT 0,
+			last_duty TEXT,
+			PRIMARY KEY(date, user_id)
+		);
+
+		CREATE TABLE IF NOT EXISTS checklist_items (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			assignment_type TEXT NOT NULL DEFAULT '',
+			text TEXT NOT NULL,
+			mandatory INTEGER NOT NULL DEFAULT 1,
+			created_at TEXT NOT NULL
+		);
+
+		CREATE TABLE IF NOT EXISTS checklist_checks (
+			date TEXT NOT NULL,
+			item_id INTEGER NOT NULL,
+			checked_at TEXT NOT NULL,
+			PRIMARY KEY(date, item_id),
+			FOREIGN KEY(item_id) REFERENCES checklist_items(id) ON DELETE CASCADE
+		);
+
+		CREATE TABLE IF NOT EXISTS tasks (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			title TEXT NOT NULL,
+			weight INTEGER NOT NULL DEFAULT 1,
+			due_date TEXT,
+			claimed_by INTEGER,
+			claimed_at TEXT,
+			done_at TEXT,
+			created_at TEXT NOT NULL,
+			FOREIGN KEY(claimed_by) REFERENCES users(id) ON DELETE SET NULL
+		);
+
+		CREATE TABLE IF NOT EXISTS cover_requests (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			duty_date TEXT NOT NULL,
+			user_id INTEGER NOT NULL,
+			deadline TEXT NOT NULL,
+			covered_by INTEGER,
+			resolved_at TEXT,
+			created_at TEXT NOT NULL,
+			FOREIGN KEY(user_id) REFERENCES users(id) ON DELETE CASCADE,
+			FOREIGN KEY(covered_by) REFERENCES users(id) ON DELETE CASCADE
+		);
+		CREATE INDEX IF NOT EXISTS idx_cover_requests_open ON cover_requests(duty_date) WHERE resolved_at IS NULL;
+
+		CREATE TABLE IF NOT EXISTS waste_collections (
+			date TEXT NOT NULL,
+			bin TEXT NOT NULL,
+			PRIMARY KEY(date, bin)
+		);
+
+		CREATE TABLE IF NOT EXISTS note_templates (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			rule TEXT NOT NULL,
+			text TEXT NOT NULL,
+			created_at TEXT NOT NULL
+		);
+
+		CREATE TABLE IF NOT EXISTS blackout_rules (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			rule TEXT NOT NULL,
+			description TEXT NOT NULL DEFAULT '',
+			created_at TEXT NOT NULL
+		);
+
+		CREATE TABLE IF NOT EXISTS round_robin_state (
+			rotation TEXT NOT NULL,
+			user_id INTEGER NOT NULL,
+			assignment_count INTEGER NOT NULL DEFAULT 0,
+			last_assigned_at TEXT NOT NULL,
+			PRIMARY KEY (rotation, user_id),
+			FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+		);
+
+		CREATE TABLE IF NOT EXISTS user_stats (
+			user_id INTEGER PRIMARY KEY,
+			total_duties INTEGER NOT NULL DEFAULT 0,
+			completed INTEGER NOT NULL DEFAULT 0,
+			missed INTEGER NOT NULL DEFAULT 0,
+			volunteered INTEGER NOT NULL DEFAULT 0,
+			FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+		);
+
+		CREATE TABLE IF NOT EXISTS badges (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			user_id INTEGER NOT NULL,
+			kind TEXT NOT NULL,
+			period TEXT NOT NULL DEFAULT '',
+			awarded_at TEXT NOT NULL,
+			UNIQUE(user_id, kind, period),
+			FOREIGN KEY(user_id) REFERENCES users(id) ON DELETE CASCADE
+		);
+
+		CREATE TABLE IF NOT EXISTS ledger_entries (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			user_id INTEGER NOT NULL,
+			kind TEXT NOT NULL,
+			amount INTEGER NOT NULL,
+			duty_date TEXT,
+			charge_id TEXT NOT NULL DEFAULT '',
+			created_at TEXT NOT NULL,
+			FOREIGN KEY(user_id) REFERENCES users(id) ON DELETE CASCADE
+		);
+
+		CREATE TABLE IF NOT EXISTS queue_events (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			user_id INTEGER NOT NULL,
+			queue TEXT NOT NULL,
+			days INTEGER NOT NULL,
+			at TEXT NOT NULL,
+			FOREIGN KEY(user_id) REFERENCES users(id) ON DELETE CASCADE
+		);
+		CREATE INDEX IF NOT EXISTS idx_queue_events_user ON queue_events(user_id);
+
+		CREATE TABLE IF NOT EXISTS login_codes (
+			code_hash TEXT PRIMARY KEY,
+			user_id INTEGER NOT NULL,
+			expires_at TEXT NOT NULL,
+			FOREIGN KEY(user_id) REFERENCES users(id) ON DELETE CASCADE
+		);
+
+		CREATE TABLE IF NOT EXISTS web_sessions (
+			token_hash TEXT PRIMARY KEY,
+			user_id INTEGER NOT NULL,
+			created_at TEXT NOT NULL,
+			expires_at TEXT NOT NULL,
+			FOREIGN KEY(user_id) REFERENCES users(id) ON DELETE CASCADE
+		);
+
+		CREATE TABLE IF NOT EXISTS invites (
+			token_hash TEXT PRIMARY KEY,
+			created_by INTEGER NOT NULL,
+			requires_approval BOOLEAN NOT NULL DEFAULT 0,
+			created_at TEXT NOT NULL,
+			expires_at TEXT NOT NULL,
+			used_by INTEGER NOT NULL DEFAULT 0,
+			used_at TEXT
+		);
+
+		CREATE TABLE IF NOT EXISTS user_merges (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			from_user_id INTEGER NOT NULL,
+			from_telegram_user_id INTEGER NOT NULL,
+			from_name TEXT NOT NULL,
+			to_user_id INTEGER NOT NULL,
+			duties_moved INTEGER NOT NULL,
+			volunteer_queue_days INTEGER NOT NULL,
+			admin_queue_days INTEGER NOT NULL,
+			merged_at TEXT NOT NULL
+		);
+
+		CREATE TABLE IF NOT EXISTS maintenance_runs (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			ran_at TEXT NOT NULL,
+			size_before INTEGER NOT NULL,
+			size_after INTEGER NOT NULL,
+			deleted INTEGER NOT NULL
 		);
 	`
-	if _, err := s.db.ExecContext(ctx, schema); err != nil {
+	if _, err := s.conn().ExecContext(ctx, schema); err != nil {
 		return err
 	}
 
@@ -71,23 +419,127 @@ func (s *SQLiteStore) migrate(ctx context.Context) error {
 		`ALTER TABLE users ADD COLUMN admin_queue_days INTEGER NOT NULL DEFAULT 0`,
 		`ALTER TABLE users ADD COLUMN off_duty_start TEXT`,
 		`ALTER TABLE users ADD COLUMN off_duty_end TEXT`,
+		`ALTER TABLE users ADD COLUMN handle TEXT NOT NULL DEFAULT ''`,
+		`ALTER TABLE users ADD COLUMN custom_name INTEGER NOT NULL DEFAULT 0`,
+		`ALTER TABLE users ADD COLUMN is_junior INTEGER NOT NULL DEFAULT 0`,
+		`ALTER TABLE users ADD COLUMN emoji TEXT NOT NULL DEFAULT ''`,
+		`ALTER TABLE users ADD COLUMN pool TEXT NOT NULL DEFAULT ''`,
+		`ALTER TABLE users ADD COLUMN is_pending INTEGER NOT NULL DEFAULT 0`,
+		`ALTER TABLE users ADD COLUMN timezone TEXT NOT NULL DEFAULT ''`,
+		`ALTER TABLE users ADD COLUMN privacy TEXT NOT NULL DEFAULT ''`,
+		`ALTER TABLE users ADD COLUMN alias TEXT NOT NULL DEFAULT ''`,
 		`ALTER TABLE duties ADD COLUMN completed_at TEXT`,
+		`ALTER TABLE duties ADD COLUMN completion_by INTEGER NOT NULL DEFAULT 0`,
+		`ALTER TABLE duties ADD COLUMN published INTEGER NOT NULL DEFAULT 0`,
+		`ALTER TABLE duties ADD COLUMN backfilled_at TEXT`,
+		`ALTER TABLE duties ADD COLUMN hold_until TEXT`,
+		`ALTER TABLE duties ADD COLUMN note TEXT NOT NULL DEFAULT ''`,
+		`ALTER TABLE duties ADD COLUMN status TEXT NOT NULL DEFAULT 'announced'`,
+		`ALTER TABLE duties ADD COLUMN handoff_note TEXT NOT NULL DEFAULT ''`,
+		`ALTER TABLE ledger_entries ADD COLUMN charge_id TEXT NOT NULL DEFAULT ''`,
 	}
 
 	for _, alteration := range alterations {
 		// Ignore errors for columns that already exist
-		s.db.ExecContext(ctx, alteration)
+		s.conn().ExecContext(ctx, alteration)
+	}
+
+	// Duties from before announcements were recorded count as announced, so
+	// today's isn't announced again
+	if _, err := s.conn().ExecContext(ctx, `ALTER TABLE duties ADD COLUMN announced_at TEXT`); err == nil {
+		if _, err := s.conn().ExecContext(ctx, `UPDATE duties SET announced_at = created_at WHERE status != 'provisional'`); err != nil {
+			return fmt.Errorf("could not migrate duty announcements: %w", err)
+		}
+	}
+
+	// Duties completed before they had a status, whose stats are counted
+	// again
+	res, err := s.conn().ExecContext(ctx, `UPDATE duties SET status = 'completed' WHERE completed_at IS NOT NULL AND status = 'announced'`)
+	if err != nil {
+		return fmt.Errorf("could not migrate duty statuses: %w", err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n > 0 {
+		if err := s.RebuildUserStats(ctx); err != nil {
+			return err
+		}
+	}
+	// Duties from before their stats were kept
+	if err := s.migrateUserStats(ctx); err != nil {
+		return err
 	}
 
+	// Tables created before their foreign keys said what happens on delete
+	if err := s.migrateForeignKeys(ctx); err != nil {
+		return err
+	}
+
+	// Users created before they had a handle
+	if err := s.migrateHandles(ctx); err != nil {
+		return err
+	}
+	if _, err := s.conn().ExecContext(ctx, `CREATE UNIQUE INDEX IF NOT EXISTS idx_users_handle ON users(handle)`); err != nil {
+		return fmt.Errorf("could not create handle index: %w", err)
+	}
+
+	return nil
+}
+
+// migrateHandles gives the users without a handle one derived from their name,
+// oldest user first.
+func (s *SQLiteStore) migrateHandles(ctx context.Context) error {
+	rows, err := s.conn().QueryContext(ctx, `SELECT id, first_name FROM users WHERE handle = '' ORDER BY id`)
+	if err != nil {
+		return fmt.Errorf("could not query users without a handle: %w", err)
+	}
+	type pending struct {
+		id   int64
+		name string
+	}
+	var users []pending
+	for rows.Next() {
+		var u pending
+		if err := rows.Scan(&u.id, &u.name); err != nil {
+			rows.Close()
+			return fmt.Errorf("could not scan user row: %w", err)
+		}
+		users = append(users, u)
+	}
+	rows.Close()
+
+	for _, u := range users {
+		handle, err := s.uniqueHandle(ctx, u.name)
+		if err != nil {
+			return err
+		}
+		if _, err := s.conn().ExecContext(ctx, `UPDATE users SET handle = ? WHERE id = ?`, handle, u.id); err != nil {
+			return fmt.Errorf("could not set handle: %w", err)
+		}
+	}
 	return nil
 }
 
+// uniqueHandle returns a handle derived from name that no user has yet.
+func (s *SQLiteStore) uniqueHandle(ctx context.Context, name string) (string, error) {
+	var err error
+	handle := store.UniqueHandle(name, func(handle string) bool {
+		var count int
+		if err == nil {
+			err = s.conn().QueryRowContext(ctx, `SELECT COUNT(*) FROM users WHERE handle = ?`, handle).Scan(&count)
+		}
+		return err == nil && count > 0
+	})
+	if err != nil {
+		return "", fmt.Errorf("could not check handle: %w", err)
+	}
+	return handle, nil
+}
+
 // scanUser is a helper to scan a user row with all fields including new ones
 func scanUser(row *sql.Row) (*store.User, error) {
 	user := &store.User{}
 	var offDutyStart, offDutyEnd sql.NullString
 	err := row.Scan(&user.ID, &user.TelegramUserID, &user.FirstName, &user.IsAdmin, &user.IsActive,
-		&user.VolunteerQueueDays, &user.AdminQueueDays, &offDutyStart, &offDutyEnd)
+		&user.VolunteerQueueDays, &user.AdminQueueDays, &offDutyStart, &offDutyEnd, &user.Handle, &user.CustomName, &user.IsJunior, &user.Emoji, &user.Pool, &user.IsPending, &user.Timezone, &user.Privacy, &user.Alias)
 	if err != nil {
 		return nil, err
 	}
@@ -109,7 +561,7 @@ func scanUserRows(rows *sql.Rows) (*store.User, error) {
 	user := &store.User{}
 	var offDutyStart, offDutyEnd sql.NullString
 	err := rows.Scan(&user.ID, &user.TelegramUserID, &user.FirstName, &user.IsAdmin, &user.IsActive,
-		&user.VolunteerQueueDays, &user.AdminQueueDays, &offDutyStart, &offDutyEnd)
+		&user.VolunteerQueueDays, &user.AdminQueueDays, &offDutyStart, &offDutyEnd, &user.Handle, &user.CustomName, &user.IsJunior, &user.Emoji, &user.Pool, &user.IsPending, &user.Timezone, &user.Privacy, &user.Alias)
 	if err != nil {
 		return nil, err
 	}
@@ -128,8 +580,16 @@ func scanUserRows(rows *sql.Rows) (*store.User, error) {
 
 // CreateUser adds a new user to the database.
 func (s *SQLiteStore) CreateUser(ctx context.Context, user *store.User) error {
-	query := `INSERT INTO users (telegram_user_id, first_name, is_admin, is_active, volunteer_queue_days, admin_queue_days, off_duty_start, off_duty_end)
-	          VALUES (?, ?, ?, ?, ?, ?, ?, ?)`
+	query := `INSERT INTO users (telegram_user_id, first_name, is_admin, is_active, volunteer_queue_days, admin_queue_days, off_duty_start, off_duty_end, handle, custom_name, is_junior, emoji, pool, is_pending, timezone, privacy, alias)
+	          VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+	handle := user.Handle
+	if handle == "" {
+		var err error
+		if handle, err = s.uniqueHandle(ctx, user.FirstName); err != nil {
+			return err
+		}
+	}
 
 	var offDutyStart, offDutyEnd interface{}
 	if user.OffDutyStart != nil {
@@ -139,8 +599,8 @@ func (s *SQLiteStore) CreateUser(ctx context.Context, user *store.User) error {
 		offDutyEnd = user.OffDutyEnd.Format("2006-01-02")
 	}
 
-	res, err := s.db.ExecContext(ctx, query, user.TelegramUserID, user.FirstName, user.IsAdmin, user.IsActive,
-		user.VolunteerQueueDays, user.AdminQueueDays, offDutyStart, offDutyEnd)
+	res, err := s.conn().ExecContext(ctx, query, user.TelegramUserID, user.FirstName, user.IsAdmin, user.IsActive,
+		user.VolunteerQueueDays, user.AdminQueueDays, offDutyStart, offDutyEnd, handle, user.CustomName, user.IsJunior, user.Emoji, user.Pool, user.IsPending, user.Timezone, user.Privacy, user.Alias)
 	if err != nil {
 		return fmt.Errorf("could not insert user: %w", err)
 	}
@@ -149,14 +609,15 @@ func (s *SQLiteStore) CreateUser(ctx context.Context, user *store.User) error {
 		return fmt.Errorf("could not retrieve last insert ID: %w", err)
 	}
 	user.ID = id
+	user.Handle = handle
 	return nil
 }
 
 // GetUserByTelegramID retrieves a user by their Telegram ID.
 func (s *SQLiteStore) GetUserByTelegramID(ctx context.Context, id int64) (*store.User, error) {
-	query := `SELECT id, telegram_user_id, first_name, is_admin, is_active, volunteer_queue_days, admin_queue_days, off_duty_start, off_duty_end
+	query := `SELECT id, telegram_user_id, first_name, is_admin, is_active, volunteer_queue_days, admin_queue_days, off_duty_start, off_duty_end, handle, custom_name, is_junior, emoji, pool, is_pending, timezone, privacy, alias
 	          FROM users WHERE telegram_user_id = ?`
-	row := s.db.QueryRowContext(ctx, query, id)
+	row := s.conn().QueryRowContext(ctx, query, id)
 	user, err := scanUser(row)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -169,9 +630,9 @@ func (s *SQLiteStore) GetUserByTelegramID(ctx context.Context, id int64) (*store
 
 // ListActiveUsers retrieves all users who are currently active.
 func (s *SQLiteStore) ListActiveUsers(ctx context.Context) ([]*store.User, error) {
-	query := `SELECT id, telegram_user_id, first_name, is_admin, is_active, volunteer_queue_days, admin_queue_days, off_duty_start, off_duty_end
+	query := `SELECT id, telegram_user_id, first_name, is_admin, is_active, volunteer_queue_days, admin_queue_days, off_duty_start, off_duty_end, handle, custom_name, is_junior, emoji, pool, is_pending, timezone, privacy, alias
 	          FROM users WHERE is_active = 1`
-	rows, err := s.db.QueryContext(ctx, query)
+	rows, err := s.conn().QueryContext(ctx, query)
 	if err != nil {
 		return nil, fmt.Errorf("could not query active users: %w", err)
 	}
@@ -188,11 +649,13 @@ func (s *SQLiteStore) ListActiveUsers(ctx context.Context) ([]*store.User, error
 	return users, nil
 }
 
-// GetUserByName retrieves a user by their first name.
+// GetUserByName retrieves a user by their handle, or failing that by their
+// display name.
 func (s *SQLiteStore) GetUserByName(ctx context.Context, name string) (*store.User, error) {
-	query := `SELECT id, telegram_user_id, first_name, is_admin, is_active, volunteer_queue_days, admin_queue_days, off_duty_start, off_duty_end
-	          FROM users WHERE first_name = ?`
-	row := s.db.QueryRowContext(ctx, query, name)
+	query := `SELECT id, telegram_user_id, first_name, is_admin, is_active, volunteer_queue_days, admin_queue_days, off_duty_start, off_duty_end, handle, custom_name, is_junior, emoji, pool, is_pending, timezone, privacy, alias
+	          FROM users WHERE handle = ? OR first_name = ?
+	          ORDER BY handle = ? DESC, id LIMIT 1`
+	row := s.conn().QueryRowContext(ctx, query, strings.ToLower(name), name, strings.ToLower(name))
 	user, err := scanUser(row)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -205,9 +668,9 @@ func (s *SQLiteStore) GetUserByName(ctx context.Context, name string) (*store.Us
 
 // ListAllUsers retrieves all users (both active and inactive).
 func (s *SQLiteStore) ListAllUsers(ctx context.Context) ([]*store.User, error) {
-	query := `SELECT id, telegram_user_id, first_name, is_admin, is_active, volunteer_queue_days, admin_queue_days, off_duty_start, off_duty_end
+	query := `SELECT id, telegram_user_id, first_name, is_admin, is_active, volunteer_queue_days, admin_queue_days, off_duty_start, off_duty_end, handle, custom_name, is_junior, emoji, pool, is_pending, timezone, privacy, alias
 	          FROM users ORDER BY first_name`
-	rows, err := s.db.QueryContext(ctx, query)
+	rows, err := s.conn().QueryContext(ctx, query)
 	if err != nil {
 		return nil, fmt.Errorf("could not query all users: %w", err)
 	}
@@ -228,17 +691,28 @@ func (s *SQLiteStore) ListAllUsers(ctx context.Context) ([]*store.User, error) {
 func (s *SQLiteStore) GetUserStats(ctx context.Context, userID int64) (*store.UserStats, error) {
 	stats := &store.UserStats{}
 
-	// Get total duties
-	err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM duties WHERE user_id = ?`, userID).Scan(&stats.TotalDuties)
+	// Counted as the user's duties change
+	err := s.conn().QueryRowContext(ctx,
+		`SELECT total_duties, completed, missed, volunteered FROM user_stats WHERE user_id = ?`,
+		userID).Scan(&stats.TotalDuties, &stats.Completed, &stats.Missed, &stats.Volunteered)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("could not query user stats: %w", err)
+	}
+
+	// Missed while off duty, the streak and rate are frozen
+	var missedOffDuty int
+	err = s.conn().QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM duties d WHERE d.user_id = ? AND d.status = 'missed' AND `+dutyOffDuty, userID).Scan(&missedOffDuty)
 	if err != nil {
-		return nil, fmt.Errorf("could not count total duties: %w", err)
+		return nil, fmt.Errorf("could not count duties missed off duty: %w", err)
 	}
+	stats.Missed -= missedOffDuty
 
 	// Get duties this month
 	now := time.Now()
 	start := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
 	end := start.AddDate(0, 1, 0)
-	err = s.db.QueryRowContext(ctx,
+	err = s.conn().QueryRowContext(ctx,
 		`SELECT COUNT(*) FROM duties WHERE user_id = ? AND duty_date >= ? AND duty_date < ?`,
 		userID, start.Format("2006-01-02"), end.Format("2006-01-02")).Scan(&stats.DutiesThisMonth)
 	if err != nil {
@@ -247,7 +721,7 @@ func (s *SQLiteStore) GetUserStats(ctx context.Context, userID int64) (*store.Us
 
 	// Get next duty date
 	var nextDate string
-	err = s.db.QueryRowContext(ctx,
+	err = s.conn().QueryRowContext(ctx,
 		`SELECT duty_date FROM duties WHERE user_id = ? AND duty_date >= ? ORDER BY duty_date LIMIT 1`,
 		userID, time.Now().Format("2006-01-02")).Scan(&nextDate)
 	if err != nil && err != sql.ErrNoRows {
@@ -255,138 +729,667 @@ func (s *SQLiteStore) GetUserStats(ctx context.Context, userID int64) (*store.Us
 	}
 	stats.NextDutyDate = nextDate
 
-	return stats, nil
-}
-
-// UpdateUser updates a user's details.
-func (s *SQLiteStore) UpdateUser(ctx context.Context, user *store.User) error {
-	query := `UPDATE users SET first_name = ?, is_admin = ?, is_active = ?, volunteer_queue_days = ?, admin_queue_days = ?, off_duty_start = ?, off_duty_end = ? WHERE id = ?`
-
-	var offDutyStart, offDutyEnd interface{}
-	if user.OffDutyStart != nil {
-		offDutyStart = user.OffDutyStart.Format("2006-01-02")
+	err = s.conn().QueryRowContext(ctx,
+		`SELECT COUNT(*), COALESCE(SUM(weight), 0) FROM tasks WHERE claimed_by = ? AND done_at IS NOT NULL`,
+		userID).Scan(&stats.TasksDone, &stats.TaskPoints)
+	if err != nil {
+		return nil, fmt.Errorf("could not count done tasks: %w", err)
 	}
-	if user.OffDutyEnd != nil {
-		offDutyEnd = user.OffDutyEnd.Format("2006-01-02")
+
+	if stats.CurrentStreak, err = s.currentStreak(ctx, userID); err != nil {
+		return nil, err
 	}
 
-	_, err := s.db.ExecContext(ctx, query, user.FirstName, user.IsAdmin, user.IsActive,
-		user.VolunteerQueueDays, user.AdminQueueDays, offDutyStart, offDutyEnd, user.ID)
+	var householdCompleted, activeUsers int
+	err = s.conn().QueryRowContext(ctx,
+		`SELECT COALESCE(SUM(st.completed), 0), COUNT(*) FROM users u LEFT JOIN user_stats st ON st.user_id = u.id WHERE u.is_active = 1`).
+		Scan(&householdCompleted, &activeUsers)
 	if err != nil {
-		return fmt.Errorf("could not update user: %w", err)
+		return nil, fmt.Errorf("could not count household duties: %w", err)
 	}
-	return nil
+	if activeUsers > 0 {
+		stats.HouseholdAverage = float64(householdCompleted) / float64(activeUsers)
+	}
+
+	return stats, nil
 }
 
-// CreateDuty creates a new duty assignment.
-func (s *SQLiteStore) CreateDuty(ctx context.Context, duty *store.Duty) error {
-	query := `INSERT INTO duties (user_id, duty_date, assignment_type, created_at, completed_at) VALUES (?, ?, ?, ?, ?)`
+// dutyOffDuty is the condition that the duty d fell on a day its user was off
+// duty, by their off-duty period or an imported one.
+const dutyOffDuty = `(EXISTS (SELECT 1 FROM users u WHERE u.id = d.user_id AND u.off_duty_start IS NOT NULL AND u.off_duty_end IS NOT NULL
+		AND d.duty_date >= u.off_duty_start AND d.duty_date <= u.off_duty_end)
+	OR EXISTS (SELECT 1 FROM off_duty_periods p WHERE p.user_id = d.user_id AND d.duty_date >= p.start_date AND d.duty_date <= p.end_date))`
+
+// currentStreak counts the user's completed duties back from the latest
+// finished one to the last missed one. Duties missed while off duty don't
+// end it.
+func (s *SQLiteStore) currentStreak(ctx context.Context, userID int64) (int, error) {
+	rows, err := s.conn().QueryContext(ctx,
+		`SELECT d.status FROM duties d WHERE d.user_id = ? AND (d.status = 'completed' OR (d.status = 'missed' AND NOT `+dutyOffDuty+`))
+		ORDER BY d.duty_date DESC`, userID)
+	if err != nil {
+		return 0, fmt.Errorf("could not query finished duties: %w", err)
+	}
+	defer rows.Close()
 
-	var completedAt interface{}
-	if duty.CompletedAt != nil {
-		completedAt = duty.CompletedAt.UTC().Format(time.RFC3339)
+	streak := 0
+	for rows.Next() {
+		var status string
+		if err := rows.Scan(&status); err != nil {
+			return 0, fmt.Errorf("could not scan duty status: %w", err)
+		}
+		if status != string(store.DutyStatusCompleted) {
+			break
+		}
+		streak++
 	}
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("could not iterate finished duties: %w", err)
+	}
+	return streak, nil
+}
 
-	res, err := s.db.ExecContext(ctx, query, duty.UserID, duty.DutyDate.Format("2006-01-02"), string(duty.AssignmentType), duty.CreatedAt.UTC().Format(time.RFC3339), completedAt)
+// AwardBadge stores a badge and sets its ID unless the user already has its
+// kind for its period, and reports whether it did.
+func (s *SQLiteStore) AwardBadge(ctx context.Context, b *store.Badge) (bool, error) {
+	res, err := s.conn().ExecContext(ctx,
+		`INSERT INTO badges (user_id, kind, period, awarded_at) VALUES (?, ?, ?, ?) ON CONFLICT(user_id, kind, period) DO NOTHING`,
+		b.UserID, string(b.Kind), b.Period, b.AwardedAt.UTC().Format(time.RFC3339))
 	if err != nil {
-		return fmt.Errorf("could not insert duty: %w", err)
+		return false, fmt.Errorf("could not award badge: %w", err)
 	}
-	id, err := res.LastInsertId()
+	n, err := res.RowsAffected()
 	if err != nil {
-		return fmt.Errorf("could not retrieve last insert ID for duty: %w", err)
+		return false, fmt.Errorf("could not count awarded badges: %w", err)
 	}
-	duty.ID = id
-	return nil
+	if n == 0 {
+		return false, nil
+	}
+	if b.ID, err = res.LastInsertId(); err != nil {
+		return false, fmt.Errorf("could not get last insert ID for badge: %w", err)
+	}
+	return true, nil
 }
 
-// GetDutyByDate retrieves a duty by its date, including user info.
-func (s *SQLiteStore) GetDutyByDate(ctx context.Context, date time.Time) (*store.Duty, error) {
-	query := `
-		SELECT d.id, d.user_id, d.duty_date, d.assignment_type, d.created_at, d.completed_at,
-		       u.id, u.telegram_user_id, u.first_name, u.is_admin, u.is_active
-		FROM duties d
-		JOIN users u ON d.user_id = u.id
-		WHERE d.duty_date = ?
-	`
-	row := s.db.QueryRowContext(ctx, query, date.Format("2006-01-02"))
-	duty := &store.Duty{User: &store.User{}}
-	var dutyDateStr, assignmentTypeStr, createdAtStr string
-	var completedAtStr sql.NullString
-
-	err := row.Scan(
-		&duty.ID, &duty.UserID, &dutyDateStr, &assignmentTypeStr, &createdAtStr, &completedAtStr,
-		&duty.User.ID, &duty.User.TelegramUserID, &duty.User.FirstName, &duty.User.IsAdmin, &duty.User.IsActive,
-	)
+// ListBadges returns a user's badges, oldest first.
+func (s *SQLiteStore) ListBadges(ctx context.Context, userID int64) ([]*store.Badge, error) {
+	rows, err := s.conn().QueryContext(ctx,
+		`SELECT id, user_id, kind, period, awarded_at FROM badges WHERE user_id = ? ORDER BY awarded_at, id`, userID)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, nil // Not found
+		return nil, fmt.Errorf("could not query badges: %w", err)
+	}
+	defer rows.Close()
+
+	var badges []*store.Badge
+	for rows.Next() {
+		b := &store.Badge{}
+		var kind, awardedAt string
+		if err := rows.Scan(&b.ID, &b.UserID, &kind, &b.Period, &awardedAt); err != nil {
+			return nil, fmt.Errorf("could not scan badge row: %w", err)
 		}
-		return nil, fmt.Errorf("could not query duty by date: %w", err)
+		b.Kind = store.BadgeKind(kind)
+		if b.AwardedAt, err = time.Parse(time.RFC3339, awardedAt); err != nil {
+			return nil, fmt.Errorf("could not parse awarded at: %w", err)
+		}
+		badges = append(badges, b)
 	}
+	return badges, rows.Err()
+}
 
-	duty.DutyDate, err = time.Parse("2006-01-02", dutyDateStr)
-	if err != nil {
-		return nil, fmt.Errorf("could not parse duty date: %w", err)
+// AddLedgerEntry stores a ledger entry and sets its ID.
+func (s *SQLiteStore) AddLedgerEntry(ctx context.Context, e *store.LedgerEntry) error {
+	var dutyDate sql.NullString
+	if e.DutyDate != nil {
+		dutyDate = sql.NullString{String: e.DutyDate.Format("2006-01-02"), Valid: true}
 	}
-	duty.CreatedAt, err = time.Parse(time.RFC3339, createdAtStr)
+	res, err := s.conn().ExecContext(ctx,
+		`INSERT INTO ledger_entries (user_id, kind, amount, duty_date, charge_id, created_at) VALUES (?, ?, ?, ?, ?, ?)`,
+		e.UserID, string(e.Kind), e.Amount, dutyDate, e.ChargeID, e.CreatedAt.UTC().Format(time.RFC3339))
 	if err != nil {
-		return nil, fmt.Errorf("could not parse created at: %w", err)
+		return fmt.Errorf("could not insert ledger entry: %w", err)
 	}
-	if completedAtStr.Valid {
-		t, err := time.Parse(time.RFC3339, completedAtStr.String)
-		if err != nil {
-			return nil, fmt.Errorf("could not parse completed at: %w", err)
-		}
-		duty.CompletedAt = &t
+	if e.ID, err = res.LastInsertId(); err != nil {
+		return fmt.Errorf("could not get last insert ID for ledger entry: %w", err)
 	}
-	duty.AssignmentType = store.AssignmentType(assignmentTypeStr)
-
-	return duty, nil
+	return nil
 }
 
-// UpdateDuty updates an existing duty.
-func (s *SQLiteStore) UpdateDuty(ctx context.Context, duty *store.Duty) error {
-	query := `UPDATE duties SET user_id = ?, assignment_type = ?, completed_at = ? WHERE duty_date = ?`
+// ListLedgerEntries returns the ledger entries of all users, oldest first.
+func (s *SQLiteStore) ListLedgerEntries(ctx context.Context) ([]*store.LedgerEntry, error) {
+	rows, err := s.conn().QueryContext(ctx,
+		`SELECT id, user_id, kind, amount, duty_date, charge_id, created_at FROM ledger_entries ORDER BY created_at, id`)
+	if err != nil {
+		return nil, fmt.Errorf("could not query ledger entries: %w", err)
+	}
+	defer rows.Close()
 
-	var completedAt interface{}
-	if duty.CompletedAt != nil {
-		completedAt = duty.CompletedAt.UTC().Format(time.RFC3339)
+	var entries []*store.LedgerEntry
+	for rows.Next() {
+		e := &store.LedgerEntry{}
+		var kind, createdAt string
+		var dutyDate sql.NullString
+		if err := rows.Scan(&e.ID, &e.UserID, &kind, &e.Amount, &dutyDate, &e.ChargeID, &createdAt); err != nil {
+			return nil, fmt.Errorf("could not scan ledger entry row: %w", err)
+		}
+		e.Kind = store.LedgerKind(kind)
+		if dutyDate.Valid {
+			date, err := time.Parse("2006-01-02", dutyDate.String)
+			if err != nil {
+				return nil, fmt.Errorf("could not parse duty date: %w", err)
+			}
+			e.DutyDate = &date
+		}
+		if e.CreatedAt, err = time.Parse(time.RFC3339, createdAt); err != nil {
+			return nil, fmt.Errorf("could not parse created at: %w", err)
+		}
+		entries = append(entries, e)
 	}
+	return entries, rows.Err()
+}
 
-	_, err := s.db.ExecContext(ctx, query, duty.UserID, string(duty.AssignmentType), completedAt, duty.DutyDate.Format("2006-01-02"))
+// CreateLoginCode stores a login code.
+func (s *SQLiteStore) CreateLoginCode(ctx context.Context, code *store.LoginCode) error {
+	_, err := s.conn().ExecContext(ctx, `INSERT INTO login_codes (code_hash, user_id, expires_at) VALUES (?, ?, ?)`,
+		code.CodeHash, code.UserID, code.ExpiresAt.UTC().Format(time.RFC3339))
 	if err != nil {
-		return fmt.Errorf("could not update duty: %w", err)
+		return fmt.Errorf("could not create login code: %w", err)
 	}
 	return nil
 }
 
-// DeleteDuty removes a duty assignment for a specific date.
-func (s *SQLiteStore) DeleteDuty(ctx context.Context, date time.Time) error {
-	query := `DELETE FROM duties WHERE duty_date = ?`
-	_, err := s.db.ExecContext(ctx, query, date.Format("2006-01-02"))
+// ConsumeLoginCode deletes the login code with the hash and returns it, or
+// nil if there is none.
+func (s *SQLiteStore) ConsumeLoginCode(ctx context.Context, codeHash string) (*store.LoginCode, error) {
+	code := &store.LoginCode{}
+	var expiresAt string
+	err := s.conn().QueryRowContext(ctx,
+		`DELETE FROM login_codes WHERE code_hash = ? RETURNING code_hash, user_id, expires_at`, codeHash,
+	).Scan(&code.CodeHash, &code.UserID, &expiresAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
 	if err != nil {
+		return nil, fmt.Errorf("could not consume login code: %w", err)
+	}
+	if code.ExpiresAt, err = time.Parse(time.RFC3339, expiresAt); err != nil {
+		return nil, fmt.Errorf("could not parse login code expiry: %w", err)
+	}
+	return code, nil
+}
+
+// CreateWebSession stores a web session.
+func (s *SQLiteStore) CreateWebSession(ctx context.Context, session *store.WebSession) error {
+	_, err := s.conn().ExecContext(ctx, `INSERT INTO web_sessions (token_hash, user_id, created_at, expires_at) VALUES (?, ?, ?, ?)`,
+		session.TokenHash, session.UserID, session.CreatedAt.UTC().Format(time.RFC3339), session.ExpiresAt.UTC().Format(time.RFC3339))
+	if err != nil {
+		return fmt.Errorf("could not create web session: %w", err)
+	}
+	return nil
+}
+
+// GetWebSession returns the web session with the token hash, or nil if there is none.
+func (s *SQLiteStore) GetWebSession(ctx context.Context, tokenHash string) (*store.WebSession, error) {
+	session := &store.WebSession{}
+	var createdAt, expiresAt string
+	err := s.conn().QueryRowContext(ctx,
+		`SELECT token_hash, user_id, created_at, expires_at FROM web_sessions WHERE token_hash = ?`, tokenHash,
+	).Scan(&session.TokenHash, &session.UserID, &createdAt, &expiresAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not get web session: %w", err)
+	}
+	if session.CreatedAt, err = time.Parse(time.RFC3339, createdAt); err != nil {
+		return nil, fmt.Errorf("could not parse web session creation: %w", err)
+	}
+	if session.ExpiresAt, err = time.Parse(time.RFC3339, expiresAt); err != nil {
+		return nil, fmt.Errorf("could not parse web session expiry: %w", err)
+	}
+	return session, nil
+}
+
+// DeleteWebSession deletes the web session with the token hash.
+func (s *SQLiteStore) DeleteWebSession(ctx context.Context, tokenHash string) error {
+	if _, err := s.conn().ExecContext(ctx, `DELETE FROM web_sessions WHERE token_hash = ?`, tokenHash); err != nil {
+		return fmt.Errorf("could not delete web session: %w", err)
+	}
+	return nil
+}
+
+// DeleteExpiredLogins deletes the login codes and web sessions that expired before now.
+func (s *SQLiteStore) DeleteExpiredLogins(ctx context.Context, now time.Time) error {
+	cutoff := now.UTC().Format(time.RFC3339)
+	for _, table := range []string{"login_codes", "web_sessions"} {
+		if _, err := s.conn().ExecContext(ctx, `DELETE FROM `+table+` WHERE expires_at < ?`, cutoff); err != nil {
+			return fmt.Errorf("could not delete expired %s: %w", table, err)
+		}
+	}
+	return nil
+}
+
+// CreateInvite stores an invite.
+func (s *SQLiteStore) CreateInvite(ctx context.Context, invite *store.Invite) error {
+	_, err := s.conn().ExecContext(ctx, `INSERT INTO invites (token_hash, created_by, requires_approval, created_at, expires_at) VALUES (?, ?, ?, ?, ?)`,
+		invite.TokenHash, invite.CreatedBy, invite.RequiresApproval,
+		invite.CreatedAt.UTC().Format(time.RFC3339), invite.ExpiresAt.UTC().Format(time.RFC3339))
+	if err != nil {
+		return fmt.Errorf("could not create invite: %w", err)
+	}
+	return nil
+}
+
+// UseInvite marks the unused invite with the hash as used by the Telegram
+// user and returns it, or nil if there is none or it expired before at.
+func (s *SQLiteStore) UseInvite(ctx context.Context, tokenHash string, telegramUserID int64, at time.Time) (*store.Invite, error) {
+	row := s.conn().QueryRowContext(ctx,
+		`UPDATE invites SET used_by = ?, used_at = ? WHERE token_hash = ? AND used_by = 0 AND expires_at > ? RETURNING `+inviteColumns,
+		telegramUserID, at.UTC().Format(time.RFC3339), tokenHash, at.UTC().Format(time.RFC3339))
+	invite, err := scanInvite(row)
+	if err != nil {
+		return nil, fmt.Errorf("could not use invite: %w", err)
+	}
+	return invite, nil
+}
+
+// GetInviteUsedBy returns the invite the Telegram user joined with, or nil if
+// they didn't join with one.
+func (s *SQLiteStore) GetInviteUsedBy(ctx context.Context, telegramUserID int64) (*store.Invite, error) {
+	row := s.conn().QueryRowContext(ctx, `SELECT `+inviteColumns+` FROM invites WHERE used_by = ? ORDER BY used_at DESC LIMIT 1`, telegramUserID)
+	invite, err := scanInvite(row)
+	if err != nil {
+		return nil, fmt.Errorf("could not query invite: %w", err)
+	}
+	return invite, nil
+}
+
+const inviteColumns = `token_hash, created_by, requires_approval, created_at, expires_at, used_by, used_at`
+
+// scanInvite scans a row of inviteColumns, returning nil if there is none.
+func scanInvite(row *sql.Row) (*store.Invite, error) {
+	invite := &store.Invite{}
+	var createdAt, expiresAt string
+	var usedAt sql.NullString
+	err := row.Scan(&invite.TokenHash, &invite.CreatedBy, &invite.RequiresApproval, &createdAt, &expiresAt, &invite.UsedBy, &usedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil // Not found is not an error
+	}
+	if err != nil {
+		return nil, err
+	}
+	if invite.CreatedAt, err = time.Parse(time.RFC3339, createdAt); err != nil {
+		return nil, err
+	}
+	if invite.ExpiresAt, err = time.Parse(time.RFC3339, expiresAt); err != nil {
+		return nil, err
+	}
+	if usedAt.Valid {
+		t, err := time.Parse(time.RFC3339, usedAt.String)
+		if err != nil {
+			return nil, err
+		}
+		invite.UsedAt = &t
+	}
+	return invite, nil
+}
+
+// MergeUsers moves everything that belongs to the user fromID to the user
+// toID and deletes fromID, in one transaction with an audit record: duties and
+// their change log, queue days, off-duty periods, round-robin history and
+// reminders. Where only one of them can be kept, like the manual off-duty
+// window, the calendar link or notification settings, toID's wins.
+func (s *SQLiteStore) MergeUsers(ctx context.Context, fromID, toID int64, at time.Time) (*store.UserMerge, error) {
+	tx, err := s.begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("could not begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	m := &store.UserMerge{FromUserID: fromID, ToUserID: toID, MergedAt: at.UTC().Truncate(time.Second)}
+	var offDutyStart, offDutyEnd sql.NullString
+	err = tx.QueryRowContext(ctx, `
+		SELECT telegram_user_id, first_name, volunteer_queue_days, admin_queue_days, off_duty_start, off_duty_end
+		FROM users WHERE id = ?`, fromID).
+		Scan(&m.FromTelegramUserID, &m.FromName, &m.VolunteerQueueDays, &m.AdminQueueDays, &offDutyStart, &offDutyEnd)
+	if err != nil {
+		return nil, fmt.Errorf("could not query merged user: %w", err)
+	}
+
+	res, err := tx.ExecContext(ctx, `
+		UPDATE users SET volunteer_queue_days = volunteer_queue_days + ?, admin_queue_days = admin_queue_days + ?,
+		       off_duty_start = COALESCE(off_duty_start, ?), off_duty_end = COALESCE(off_duty_end, ?)
+		WHERE id = ?`, m.VolunteerQueueDays, m.AdminQueueDays, offDutyStart, offDutyEnd, toID)
+	if err != nil {
+		return nil, fmt.Errorf("could not update surviving user: %w", err)
+	}
+	if n, err := res.RowsAffected(); err != nil || n == 0 {
+		return nil, fmt.Errorf("could not find surviving user %d", toID)
+	}
+
+	res, err = tx.ExecContext(ctx, `UPDATE duties SET user_id = ? WHERE user_id = ?`, toID, fromID)
+	if err != nil {
+		return nil, fmt.Errorf("could not move duties: %w", err)
+	}
+	moved, err := res.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("could not count moved duties: %w", err)
+	}
+	m.DutiesMoved = int(moved)
+
+	statements := []string{
+		`UPDATE duty_changes SET user_id = ? WHERE user_id = ?`,
+		`UPDATE off_duty_periods SET user_id = ? WHERE user_id = ?`,
+		`UPDATE reminder_snoozes SET user_id = ? WHERE user_id = ?`,
+		`UPDATE shadow_comparisons SET live_user_id = ? WHERE live_user_id = ?`,
+		`UPDATE shadow_comparisons SET shadow_user_id = ? WHERE shadow_user_id = ?`,
+		`UPDATE assignment_explanations SET user_id = ? WHERE user_id = ?`,
+		`UPDATE schedule_version_days SET user_id = ? WHERE user_id = ?`,
+		`UPDATE OR IGNORE assignment_candidates SET user_id = ? WHERE user_id = ?`,
+		`UPDATE OR IGNORE calendar_links SET user_id = ? WHERE user_id = ?`,
+		`UPDATE OR IGNORE notification_preferences SET user_id = ? WHERE user_id = ?`,
+		`UPDATE OR IGNORE change_subscriptions SET user_id = ? WHERE user_id = ?`,
+		`UPDATE OR IGNORE badges SET user_id = ? WHERE user_id = ?`,
+		`UPDATE ledger_entries SET user_id = ? WHERE user_id = ?`,
+		`UPDATE queue_events SET user_id = ? WHERE user_id = ?`,
+		`UPDATE tasks SET claimed_by = ? WHERE claimed_by = ?`,
+		`UPDATE cover_requests SET user_id = ? WHERE user_id = ?`,
+		`UPDATE cover_requests SET covered_by = ? WHERE covered_by = ?`,
+		`UPDATE login_codes SET user_id = ? WHERE user_id = ?`,
+		`UPDATE web_sessions SET user_id = ? WHERE user_id = ?`,
+		`INSERT INTO round_robin_state (rotation, user_id, assignment_count, last_assigned_at)
+		 SELECT rotation, ?, assignment_count, last_assigned_at FROM round_robin_state WHERE user_id = ?
+		 ON CONFLICT(rotation, user_id) DO UPDATE SET
+			assignment_count = assignment_count + excluded.assignment_count,
+			last_assigned_at = MAX(last_assigned_at, excluded.last_assigned_at)`,
+		`INSERT INTO user_stats (user_id, total_duties, completed, missed, volunteered)
+		 SELECT ?, total_duties, completed, missed, volunteered FROM user_stats WHERE user_id = ?
+		 ON CONFLICT(user_id) DO UPDATE SET
+			total_duties = total_duties + excluded.total_duties,
+			completed = completed + excluded.completed,
+			missed = missed + excluded.missed,
+			volunteered = volunteered + excluded.volunteered`,
+	}
+	for _, statement := range statements {
+		if _, err := tx.ExecContext(ctx, statement, toID, fromID); err != nil {
+			return nil, fmt.Errorf("could not move user data: %w", err)
+		}
+	}
+	// What is left of fromID lost to toID's own
+	deletions := []string{
+		`DELETE FROM calendar_links WHERE user_id = ?`,
+		`DELETE FROM notification_preferences WHERE user_id = ?`,
+		`DELETE FROM change_subscriptions WHERE user_id = ?`,
+		`DELETE FROM badges WHERE user_id = ?`,
+		`DELETE FROM round_robin_state WHERE user_id = ?`,
+		`DELETE FROM assignment_candidates WHERE user_id = ?`,
+		`DELETE FROM user_stats WHERE user_id = ?`,
+		`DELETE FROM users WHERE id = ?`,
+	}
+	for _, statement := range deletions {
+		if _, err := tx.ExecContext(ctx, statement, fromID); err != nil {
+			return nil, fmt.Errorf("could not delete merged user: %w", err)
+		}
+	}
+
+	res, err = tx.ExecContext(ctx, `
+		INSERT INTO user_merges (from_user_id, from_telegram_user_id, from_name, to_user_id, duties_moved, volunteer_queue_days, admin_queue_days, merged_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		m.FromUserID, m.FromTelegramUserID, m.FromName, m.ToUserID, m.DutiesMoved, m.VolunteerQueueDays, m.AdminQueueDays, m.MergedAt.Format(time.RFC3339))
+	if err != nil {
+		return nil, fmt.Errorf("could not record user merge: %w", err)
+	}
+	if m.ID, err = res.LastInsertId(); err != nil {
+		return nil, fmt.Errorf("could not get last insert ID for user merge: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("could not commit user merge: %w", err)
+	}
+	return m, nil
+}
+
+// ListUserMerges returns the audit records of merged users, oldest first.
+func (s *SQLiteStore) ListUserMerges(ctx context.Context) ([]*store.UserMerge, error) {
+	rows, err := s.conn().QueryContext(ctx, `
+		SELECT id, from_user_id, from_telegram_user_id, from_name, to_user_id, duties_moved, volunteer_queue_days, admin_queue_days, merged_at
+		FROM user_merges ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("could not query user merges: %w", err)
+	}
+	defer rows.Close()
+
+	var merges []*store.UserMerge
+	for rows.Next() {
+		m := &store.UserMerge{}
+		var mergedAt string
+		if err := rows.Scan(&m.ID, &m.FromUserID, &m.FromTelegramUserID, &m.FromName, &m.ToUserID, &m.DutiesMoved, &m.VolunteerQueueDays, &m.AdminQueueDays, &mergedAt); err != nil {
+			return nil, fmt.Errorf("could not scan user merge row: %w", err)
+		}
+		if m.MergedAt, err = time.Parse(time.RFC3339, mergedAt); err != nil {
+			return nil, fmt.Errorf("could not parse merged at: %w", err)
+		}
+		merges = append(merges, m)
+	}
+	return merges, nil
+}
+
+// UpdateUser updates a user's details.
+func (s *SQLiteStore) UpdateUser(ctx context.Context, user *store.User) error {
+	query := `UPDATE users SET first_name = ?, custom_name = ?, is_junior = ?, emoji = ?, pool = ?, is_pending = ?, timezone = ?, privacy = ?, alias = ?, is_admin = ?, is_active = ?, volunteer_queue_days = ?, admin_queue_days = ?, off_duty_start = ?, off_duty_end = ? WHERE id = ?`
+
+	var offDutyStart, offDutyEnd interface{}
+	if user.OffDutyStart != nil {
+		offDutyStart = user.OffDutyStart.Format("2006-01-02")
+	}
+	if user.OffDutyEnd != nil {
+		offDutyEnd = user.OffDutyEnd.Format("2006-01-02")
+	}
+
+	_, err := s.conn().ExecContext(ctx, query, user.FirstName, user.CustomName, user.IsJunior, user.Emoji, user.Pool, user.IsPending, user.Timezone, user.Privacy, user.Alias, user.IsAdmin, user.IsActive,
+		user.VolunteerQueueDays, user.AdminQueueDays, offDutyStart, offDutyEnd, user.ID)
+	if err != nil {
+		return fmt.Errorf("could not update user: %w", err)
+	}
+	return nil
+}
+
+// DeleteUser deletes a user. The foreign keys delete what only the user owns
+// and keep users who have duties.
+func (s *SQLiteStore) DeleteUser(ctx context.Context, id int64) error {
+	if _, err := s.conn().ExecContext(ctx, `DELETE FROM users WHERE id = ?`, id); err != nil {
+		return fmt.Errorf("could not delete user: %w", err)
+	}
+	return nil
+}
+
+// CreateDuty creates a new duty assignment.
+func (s *SQLiteStore) CreateDuty(ctx context.Context, duty *store.Duty) error {
+	query := `INSERT INTO duties (user_id, duty_date, assignment_type, created_at, completed_at, hold_until, note, handoff_note, status) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+	var completedAt interface{}
+	if duty.CompletedAt != nil {
+		completedAt = duty.CompletedAt.UTC().Format(time.RFC3339)
+	}
+	status := store.InitialStatus(duty)
+
+	tx, err := s.begin(ctx)
+	if err != nil {
+		return fmt.Errorf("could not begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, query, duty.UserID, duty.DutyDate.Format("2006-01-02"), string(duty.AssignmentType), duty.CreatedAt.UTC().Format(time.RFC3339), completedAt, formatHoldUntil(duty.HoldUntil), duty.Note, duty.HandoffNote, string(status))
+	if err != nil {
+		return fmt.Errorf("could not insert duty: %w", err)
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("could not retrieve last insert ID for duty: %w", err)
+	}
+
+	if err := countDuty(ctx, tx, tally{duty.UserID, status, duty.AssignmentType}, 1); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("could not commit duty: %w", err)
+	}
+	duty.ID = id
+	duty.Status = status
+	return nil
+}
+
+// GetDutyByDate retrieves a duty by its date, including user info.
+func (s *SQLiteStore) GetDutyByDate(ctx context.Context, date time.Time) (*store.Duty, error) {
+	query := `
+		SELECT d.id, d.user_id, d.duty_date, d.assignment_type, d.created_at, d.completed_at, d.completion_by, d.published, d.backfilled_at, d.hold_until, d.note, d.handoff_note, d.status, d.announced_at,
+		       u.id, u.telegram_user_id, u.first_name, u.is_admin, u.is_active, u.emoji, u.privacy, u.alias
+		FROM duties d
+		JOIN users u ON d.user_id = u.id
+		WHERE d.duty_date = ?
+	`
+	row := s.conn().QueryRowContext(ctx, query, date.Format("2006-01-02"))
+	duty := &store.Duty{User: &store.User{}}
+	var dutyDateStr, assignmentTypeStr, createdAtStr string
+	var completedAtStr, backfilledAtStr, holdUntilStr, announcedAtStr sql.NullString
+
+	err := row.Scan(
+		&duty.ID, &duty.UserID, &dutyDateStr, &assignmentTypeStr, &createdAtStr, &completedAtStr, &duty.CompletionBy, &duty.Published, &backfilledAtStr, &holdUntilStr, &duty.Note, &duty.HandoffNote, &duty.Status, &announcedAtStr,
+		&duty.User.ID, &duty.User.TelegramUserID, &duty.User.FirstName, &duty.User.IsAdmin, &duty.User.IsActive, &duty.User.Emoji, &duty.User.Privacy, &duty.User.Alias,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil // Not found
+		}
+		return nil, fmt.Errorf("could not query duty by date: %w", err)
+	}
+
+	duty.DutyDate, err = time.Parse("2006-01-02", dutyDateStr)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse duty date: %w", err)
+	}
+	duty.CreatedAt, err = time.Parse(time.RFC3339, createdAtStr)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse created at: %w", err)
+	}
+	if completedAtStr.Valid {
+		t, err := time.Parse(time.RFC3339, completedAtStr.String)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse completed at: %w", err)
+		}
+		duty.CompletedAt = &t
+	}
+	if duty.BackfilledAt, err = parseBackfilledAt(backfilledAtStr); err != nil {
+		return nil, err
+	}
+	if duty.HoldUntil, err = parseHoldUntil(holdUntilStr); err != nil {
+		return nil, err
+	}
+	if duty.AnnouncedAt, err = parseAnnouncedAt(announcedAtStr); err != nil {
+		return nil, err
+	}
+	duty.AssignmentType = store.AssignmentType(assignmentTypeStr)
+
+	return duty, nil
+}
+
+// UpdateDuty updates an existing duty.
+func (s *SQLiteStore) UpdateDuty(ctx context.Context, duty *store.Duty) error {
+	query := `UPDATE duties SET user_id = ?, assignment_type = ?, completed_at = ?, completion_by = ?, published = ?, hold_until = ?, note = ?, handoff_note = ?, status = ? WHERE duty_date = ?`
+
+	var completedAt interface{}
+	if duty.CompletedAt != nil {
+		completedAt = duty.CompletedAt.UTC().Format(time.RFC3339)
+	}
+
+	tx, err := s.begin(ctx)
+	if err != nil {
+		return fmt.Errorf("could not begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	previous, err := queryTally(ctx, tx, duty.DutyDate.Format("2006-01-02"))
+	if err != nil {
+		return err
+	}
+
+	status := store.InitialStatus(duty)
+	_, err = tx.ExecContext(ctx, query, duty.UserID, string(duty.AssignmentType), completedAt, duty.CompletionBy, duty.Published, formatHoldUntil(duty.HoldUntil), duty.Note, duty.HandoffNote, string(status), duty.DutyDate.Format("2006-01-02"))
+	if err != nil {
+		return fmt.Errorf("could not update duty: %w", err)
+	}
+
+	if previous.userID != 0 {
+		if err := recountDuty(ctx, tx, previous, tally{duty.UserID, status, duty.AssignmentType}); err != nil {
+			return err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("could not commit duty update: %w", err)
+	}
+	return nil
+}
+
+// DeleteDuty removes a duty assignment for a specific date.
+func (s *SQLiteStore) DeleteDuty(ctx context.Context, date time.Time) error {
+	tx, err := s.begin(ctx)
+	if err != nil {
+		return fmt.Errorf("could not begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	previous, err := queryTally(ctx, tx, date.Format("2006-01-02"))
+	if err != nil {
+		return err
+	}
+
+	query := `DELETE FROM duties WHERE duty_date = ?`
+	if _, err := tx.ExecContext(ctx, query, date.Format("2006-01-02")); err != nil {
 		return fmt.Errorf("could not delete duty: %w", err)
 	}
+
+	if previous.userID != 0 {
+		if err := countDuty(ctx, tx, previous, -1); err != nil {
+			return err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("could not commit duty deletion: %w", err)
+	}
 	return nil
 }
 
 // GetDutiesByMonth retrieves all duties for a given month and year.
 func (s *SQLiteStore) GetDutiesByMonth(ctx context.Context, year int, month time.Month) ([]*store.Duty, error) {
 	start := time.Date(year, month, 1, 0, 0, 0, 0, time.UTC)
-	end := start.AddDate(0, 1, 0)
+	return s.ListDuties(ctx, store.DutyFilter{From: start, To: start.AddDate(0, 1, 0)})
+}
 
+// ListDuties retrieves the duties matching the filter, ordered by date.
+func (s *SQLiteStore) ListDuties(ctx context.Context, filter store.DutyFilter) ([]*store.Duty, error) {
+	var where []string
+	var args []any
+	if filter.UserID != 0 {
+		where, args = append(where, "d.user_id = ?"), append(args, filter.UserID)
+	}
+	if !filter.From.IsZero() {
+		where, args = append(where, "d.duty_date >= ?"), append(args, filter.From.Format("2006-01-02"))
+	}
+	if !filter.To.IsZero() {
+		where, args = append(where, "d.duty_date < ?"), append(args, filter.To.Format("2006-01-02"))
+	}
 	query := `
-		SELECT d.id, d.user_id, d.duty_date, d.assignment_type, d.created_at, d.completed_at,
-		       u.id, u.telegram_user_id, u.first_name, u.is_admin, u.is_active,
+		SELECT d.id, d.user_id, d.duty_date, d.assignment_type, d.created_at, d.completed_at, d.completion_by, d.published, d.backfilled_at, d.hold_until, d.note, d.handoff_note, d.status, d.announced_at,
+		       u.id, u.telegram_user_id, u.first_name, u.is_admin, u.is_active, u.emoji, u.privacy, u.alias,
 		       u.volunteer_queue_days, u.admin_queue_days, u.off_duty_start, u.off_duty_end
 		FROM duties d
 		JOIN users u ON d.user_id = u.id
-		WHERE d.duty_date >= ? AND d.duty_date < ?
-		ORDER BY d.duty_date
 	`
-	rows, err := s.db.QueryContext(ctx, query, start.Format("2006-01-02"), end.Format("2006-01-02"))
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	query += " ORDER BY d.duty_date"
+	rows, err := s.conn().QueryContext(ctx, query, args...)
 	if err != nil {
-		return nil, fmt.Errorf("could not query duties by month: %w", err)
+		return nil, fmt.Errorf("could not query duties: %w", err)
 	}
 	defer rows.Close()
 
@@ -394,10 +1397,10 @@ func (s *SQLiteStore) GetDutiesByMonth(ctx context.Context, year int, month time
 	for rows.Next() {
 		duty := &store.Duty{User: &store.User{}}
 		var dutyDateStr, assignmentTypeStr, createdAtStr string
-		var completedAtStr, offDutyStart, offDutyEnd sql.NullString
+		var completedAtStr, backfilledAtStr, holdUntilStr, announcedAtStr, offDutyStart, offDutyEnd sql.NullString
 		err := rows.Scan(
-			&duty.ID, &duty.UserID, &dutyDateStr, &assignmentTypeStr, &createdAtStr, &completedAtStr,
-			&duty.User.ID, &duty.User.TelegramUserID, &duty.User.FirstName, &duty.User.IsAdmin, &duty.User.IsActive,
+			&duty.ID, &duty.UserID, &dutyDateStr, &assignmentTypeStr, &createdAtStr, &completedAtStr, &duty.CompletionBy, &duty.Published, &backfilledAtStr, &holdUntilStr, &duty.Note, &duty.HandoffNote, &duty.Status, &announcedAtStr,
+			&duty.User.ID, &duty.User.TelegramUserID, &duty.User.FirstName, &duty.User.IsAdmin, &duty.User.IsActive, &duty.User.Emoji, &duty.User.Privacy, &duty.User.Alias,
 			&duty.User.VolunteerQueueDays, &duty.User.AdminQueueDays, &offDutyStart, &offDutyEnd,
 		)
 		if err != nil {
@@ -405,19 +1408,28 @@ func (s *SQLiteStore) GetDutiesByMonth(ctx context.Context, year int, month time
 		}
 		duty.DutyDate, err = time.Parse("2006-01-02", dutyDateStr)
 		if err != nil {
-			return nil, fmt.Errorf("could not parse duty date from month query: %w", err)
+			return nil, fmt.Errorf("could not parse duty date from duty query: %w", err)
 		}
 		duty.CreatedAt, err = time.Parse(time.RFC3339, createdAtStr)
 		if err != nil {
-			return nil, fmt.Errorf("could not parse created at from month query: %w", err)
+			return nil, fmt.Errorf("could not parse created at from duty query: %w", err)
 		}
 		if completedAtStr.Valid {
 			t, err := time.Parse(time.RFC3339, completedAtStr.String)
 			if err != nil {
-				return nil, fmt.Errorf("could not parse completed at from month query: %w", err)
+				return nil, fmt.Errorf("could not parse completed at from duty query: %w", err)
 			}
 			duty.CompletedAt = &t
 		}
+		if duty.BackfilledAt, err = parseBackfilledAt(backfilledAtStr); err != nil {
+			return nil, err
+		}
+		if duty.HoldUntil, err = parseHoldUntil(holdUntilStr); err != nil {
+			return nil, err
+		}
+		if duty.AnnouncedAt, err = parseAnnouncedAt(announcedAtStr); err != nil {
+			return nil, err
+		}
 		if offDutyStart.Valid {
 			t, _ := time.Parse("2006-01-02", offDutyStart.String)
 			duty.User.OffDutyStart = &t
@@ -435,53 +1447,93 @@ func (s *SQLiteStore) GetDutiesByMonth(ctx context.Context, year int, month time
 // AddToVolunteerQueue adds days to a user's volunteer queue.
 func (s *SQLiteStore) AddToVolunteerQueue(ctx context.Context, userID int64, days int) error {
 	query := `UPDATE users SET volunteer_queue_days = volunteer_queue_days + ? WHERE id = ?`
-	_, err := s.db.ExecContext(ctx, query, days, userID)
+	res, err := s.conn().ExecContext(ctx, query, days, userID)
 	if err != nil {
 		return fmt.Errorf("could not add to volunteer queue: %w", err)
 	}
-	return nil
+	return s.addQueueEvent(ctx, res, userID, store.AssignmentTypeVoluntary, days)
 }
 
 // AddToAdminQueue adds days to a user's admin assignment queue.
 func (s *SQLiteStore) AddToAdminQueue(ctx context.Context, userID int64, days int) error {
 	query := `UPDATE users SET admin_queue_days = admin_queue_days + ? WHERE id = ?`
-	_, err := s.db.ExecContext(ctx, query, days, userID)
+	res, err := s.conn().ExecContext(ctx, query, days, userID)
 	if err != nil {
 		return fmt.Errorf("could not add to admin queue: %w", err)
 	}
-	return nil
+	return s.addQueueEvent(ctx, res, userID, store.AssignmentTypeAdmin, days)
 }
 
 // DecrementVolunteerQueue decrements a user's volunteer queue by 1 (minimum 0).
 func (s *SQLiteStore) DecrementVolunteerQueue(ctx context.Context, userID int64) error {
-	query := `UPDATE users SET volunteer_queue_days = MAX(0, volunteer_queue_days - 1) WHERE id = ?`
-	_, err := s.db.ExecContext(ctx, query, userID)
+	query := `UPDATE users SET volunteer_queue_days = volunteer_queue_days - 1 WHERE id = ? AND volunteer_queue_days > 0`
+	res, err := s.conn().ExecContext(ctx, query, userID)
 	if err != nil {
 		return fmt.Errorf("could not decrement volunteer queue: %w", err)
 	}
-	return nil
+	return s.addQueueEvent(ctx, res, userID, store.AssignmentTypeVoluntary, -1)
 }
 
 // DecrementAdminQueue decrements a user's admin queue by 1 (minimum 0).
 func (s *SQLiteStore) DecrementAdminQueue(ctx context.Context, userID int64) error {
-	query := `UPDATE users SET admin_queue_days = MAX(0, admin_queue_days - 1) WHERE id = ?`
-	_, err := s.db.ExecContext(ctx, query, userID)
+	query := `UPDATE users SET admin_queue_days = admin_queue_days - 1 WHERE id = ? AND admin_queue_days > 0`
+	res, err := s.conn().ExecContext(ctx, query, userID)
 	if err != nil {
 		return fmt.Errorf("could not decrement admin queue: %w", err)
 	}
+	return s.addQueueEvent(ctx, res, userID, store.AssignmentTypeAdmin, -1)
+}
+
+// addQueueEvent records the change of a queue made by res, if it changed
+// anything.
+func (s *SQLiteStore) addQueueEvent(ctx context.Context, res sql.Result, userID int64, queue store.AssignmentType, days int) error {
+	if n, err := res.RowsAffected(); err != nil || n == 0 || days == 0 {
+		return err
+	}
+	_, err := s.conn().ExecContext(ctx, `INSERT INTO queue_events (user_id, queue, days, at) VALUES (?, ?, ?, ?)`,
+		userID, string(queue), days, time.Now().UTC().Format(time.RFC3339))
+	if err != nil {
+		return fmt.Errorf("could not insert queue event: %w", err)
+	}
 	return nil
 }
 
+// ListQueueEvents returns the queue events of a user, or of all users for
+// userID 0, oldest first.
+func (s *SQLiteStore) ListQueueEvents(ctx context.Context, userID int64) ([]*store.QueueEvent, error) {
+	rows, err := s.conn().QueryContext(ctx,
+		`SELECT id, user_id, queue, days, at FROM queue_events WHERE ? = 0 OR user_id = ? ORDER BY at, id`, userID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("could not query queue events: %w", err)
+	}
+	defer rows.Close()
+
+	var events []*store.QueueEvent
+	for rows.Next() {
+		e := &store.QueueEvent{}
+		var queue, at string
+		if err := rows.Scan(&e.ID, &e.UserID, &queue, &e.Days, &at); err != nil {
+			return nil, fmt.Errorf("could not scan queue event row: %w", err)
+		}
+		e.Queue = store.AssignmentType(queue)
+		if e.At, err = time.Parse(time.RFC3339, at); err != nil {
+			return nil, fmt.Errorf("could not parse queue event time: %w", err)
+		}
+		events = append(events, e)
+	}
+	return events, rows.Err()
+}
+
 // GetUsersWithVolunteerQueue returns all active users with volunteer queue > 0.
 func (s *SQLiteStore) GetUsersWithVolunteerQueue(ctx context.Context) ([]*store.User, error) {
 	query := `
 		SELECT id, telegram_user_id, first_name, is_admin, is_active,
-		       volunteer_queue_days, admin_queue_days, off_duty_start, off_duty_end
+		       volunteer_queue_days, admin_queue_days, off_duty_start, off_duty_end, handle, custom_name, is_junior, emoji, pool, is_pending, timezone, privacy, alias
 		FROM users
 		WHERE is_active = 1 AND volunteer_queue_days > 0
 		ORDER BY volunteer_queue_days DESC
 	`
-	rows, err := s.db.QueryContext(ctx, query)
+	rows, err := s.conn().QueryContext(ctx, query)
 	if err != nil {
 		return nil, fmt.Errorf("could not query users with volunteer queue: %w", err)
 	}
@@ -502,12 +1554,12 @@ func (s *SQLiteStore) GetUsersWithVolunteerQueue(ctx context.Context) ([]*store.
 func (s *SQLiteStore) GetUsersWithAdminQueue(ctx context.Context) ([]*store.User, error) {
 	query := `
 		SELECT id, telegram_user_id, first_name, is_admin, is_active,
-		       volunteer_queue_days, admin_queue_days, off_duty_start, off_duty_end
+		       volunteer_queue_days, admin_queue_days, off_duty_start, off_duty_end, handle, custom_name, is_junior, emoji, pool, is_pending, timezone, privacy, alias
 		FROM users
 		WHERE is_active = 1 AND admin_queue_days > 0
 		ORDER BY admin_queue_days DESC
 	`
-	rows, err := s.db.QueryContext(ctx, query)
+	rows, err := s.conn().QueryContext(ctx, query)
 	if err != nil {
 		return nil, fmt.Errorf("could not query users with admin queue: %w", err)
 	}
@@ -527,7 +1579,7 @@ func (s *SQLiteStore) GetUsersWithAdminQueue(ctx context.Context) ([]*store.User
 // SetOffDuty sets a user's off-duty period.
 func (s *SQLiteStore) SetOffDuty(ctx context.Context, userID int64, start, end time.Time) error {
 	query := `UPDATE users SET off_duty_start = ?, off_duty_end = ? WHERE id = ?`
-	_, err := s.db.ExecContext(ctx, query, start.Format("2006-01-02"), end.Format("2006-01-02"), userID)
+	_, err := s.conn().ExecContext(ctx, query, start.Format("2006-01-02"), end.Format("2006-01-02"), userID)
 	if err != nil {
 		return fmt.Errorf("could not set off-duty: %w", err)
 	}
@@ -537,7 +1589,7 @@ func (s *SQLiteStore) SetOffDuty(ctx context.Context, userID int64, start, end t
 // ClearOffDuty clears a user's off-duty period.
 func (s *SQLiteStore) ClearOffDuty(ctx context.Context, userID int64) error {
 	query := `UPDATE users SET off_duty_start = NULL, off_duty_end = NULL WHERE id = ?`
-	_, err := s.db.ExecContext(ctx, query, userID)
+	_, err := s.conn().ExecContext(ctx, query, userID)
 	if err != nil {
 		return fmt.Errorf("could not clear off-duty: %w", err)
 	}
@@ -547,13 +1599,17 @@ func (s *SQLiteStore) ClearOffDuty(ctx context.Context, userID int64) error {
 // IsUserOffDuty checks if a user is off-duty on a specific date.
 func (s *SQLiteStore) IsUserOffDuty(ctx context.Context, userID int64, date time.Time) (bool, error) {
 	query := `
-		SELECT COUNT(*) FROM users
-		WHERE id = ? AND off_duty_start IS NOT NULL AND off_duty_end IS NOT NULL
-		AND ? >= off_duty_start AND ? <= off_duty_end
+		SELECT
+			(SELECT COUNT(*) FROM users
+			 WHERE id = ? AND off_duty_start IS NOT NULL AND off_duty_end IS NOT NULL
+			 AND ? >= off_duty_start AND ? <= off_duty_end)
+			+
+			(SELECT COUNT(*) FROM off_duty_periods
+			 WHERE user_id = ? AND ? >= start_date AND ? <= end_date)
 	`
 	dateStr := date.Format("2006-01-02")
 	var count int
-	err := s.db.QueryRowContext(ctx, query, userID, dateStr, dateStr).Scan(&count)
+	err := s.conn().QueryRowContext(ctx, query, userID, dateStr, dateStr, userID, dateStr, dateStr).Scan(&count)
 	if err != nil {
 		return false, fmt.Errorf("could not check off-duty status: %w", err)
 	}
@@ -564,13 +1620,14 @@ func (s *SQLiteStore) IsUserOffDuty(ctx context.Context, userID int64, date time
 func (s *SQLiteStore) GetOffDutyUsers(ctx context.Context, date time.Time) ([]*store.User, error) {
 	query := `
 		SELECT id, telegram_user_id, first_name, is_admin, is_active,
-		       volunteer_queue_days, admin_queue_days, off_duty_start, off_duty_end
+		       volunteer_queue_days, admin_queue_days, off_duty_start, off_duty_end, handle, custom_name, is_junior, emoji, pool, is_pending, timezone, privacy, alias
 		FROM users
-		WHERE off_duty_start IS NOT NULL AND off_duty_end IS NOT NULL
-		AND ? >= off_duty_start AND ? <= off_duty_end
+		WHERE (off_duty_start IS NOT NULL AND off_duty_end IS NOT NULL
+		       AND ? >= off_duty_start AND ? <= off_duty_end)
+		   OR id IN (SELECT user_id FROM off_duty_periods WHERE ? >= start_date AND ? <= end_date)
 	`
 	dateStr := date.Format("2006-01-02")
-	rows, err := s.db.QueryContext(ctx, query, dateStr, dateStr)
+	rows, err := s.conn().QueryContext(ctx, query, dateStr, dateStr, dateStr, dateStr)
 	if err != nil {
 		return nil, fmt.Errorf("could not query off-duty users: %w", err)
 	}
@@ -587,12 +1644,1386 @@ func (s *SQLiteStore) GetOffDutyUsers(ctx context.Context, date time.Time) ([]*s
 	return users, nil
 }
 
-// CompleteDuty marks a duty as completed by setting completed_at timestamp.
-func (s *SQLiteStore) CompleteDuty(ctx context.Context, date time.Time) error {
-	query := `UPDATE duties SET completed_at = ? WHERE duty_date = ?`
-	_, err := s.db.ExecContext(ctx, query, time.Now().UTC().Format(time.RFC3339), date.Format("2006-01-02"))
+// ListOffDutyPeriods returns all imported off-duty periods for a user, ordered by start date.
+func (s *SQLiteStore) ListOffDutyPeriods(ctx context.Context, userID int64) ([]*store.OffDutyPeriod, error) {
+	query := `
+		SELECT id, user_id, start_date, end_date, source, external_id, summary
+		FROM off_duty_periods
+		WHERE user_id = ?
+		ORDER BY start_date, id
+	`
+	rows, err := s.conn().QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("could not query off-duty periods: %w", err)
+	}
+	defer rows.Close()
+
+	var periods []*store.OffDutyPeriod
+	for rows.Next() {
+		p := &store.OffDutyPeriod{}
+		var startStr, endStr string
+		if err := rows.Scan(&p.ID, &p.UserID, &startStr, &endStr, &p.Source, &p.ExternalID, &p.Summary); err != nil {
+			return nil, fmt.Errorf("could not scan off-duty period row: %w", err)
+		}
+		if p.StartDate, err = time.Parse("2006-01-02", startStr); err != nil {
+			return nil, fmt.Errorf("could not parse off-duty start date: %w", err)
+		}
+		if p.EndDate, err = time.Parse("2006-01-02", endStr); err != nil {
+			return nil, fmt.Errorf("could not parse off-duty end date: %w", err)
+		}
+		periods = append(periods, p)
+	}
+	return periods, nil
+}
+
+// ReplaceOffDutyPeriods atomically replaces all off-duty periods of the given source for a user.
+func (s *SQLiteStore) ReplaceOffDutyPeriods(ctx context.Context, userID int64, source string, periods []*store.OffDutyPeriod) error {
+	tx, err := s.begin(ctx)
+	if err != nil {
+		return fmt.Errorf("could not begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM off_duty_periods WHERE user_id = ? AND source = ?`, userID, source); err != nil {
+		return fmt.Errorf("could not clear off-duty periods: %w", err)
+	}
+
+	query := `INSERT INTO off_duty_periods (user_id, start_date, end_date, source, external_id, summary) VALUES (?, ?, ?, ?, ?, ?)`
+	for _, p := range periods {
+		res, err := tx.ExecContext(ctx, query, userID, p.StartDate.Format("2006-01-02"), p.EndDate.Format("2006-01-02"), source, p.ExternalID, p.Summary)
+		if err != nil {
+			return fmt.Errorf("could not insert off-duty period: %w", err)
+		}
+		if p.ID, err = res.LastInsertId(); err != nil {
+			return fmt.Errorf("could not retrieve last insert ID for off-duty period: %w", err)
+		}
+		p.UserID = userID
+		p.Source = source
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("could not commit off-duty periods: %w", err)
+	}
+	return nil
+}
+
+// SetCalendarLink creates or updates the iCal link of a user.
+func (s *SQLiteStore) SetCalendarLink(ctx context.Context, link *store.CalendarLink) error {
+	query := `
+		INSERT INTO calendar_links (user_id, url, last_synced_at, last_error) VALUES (?, ?, ?, ?)
+		ON CONFLICT(user_id) DO UPDATE SET url = excluded.url, last_synced_at = excluded.last_synced_at, last_error = excluded.last_error
+	`
+	var lastSyncedAt interface{}
+	if link.LastSyncedAt != nil {
+		lastSyncedAt = link.LastSyncedAt.UTC().Format(time.RFC3339)
+	}
+	if _, err := s.conn().ExecContext(ctx, query, link.UserID, link.URL, lastSyncedAt, link.LastError); err != nil {
+		return fmt.Errorf("could not set calendar link: %w", err)
+	}
+	return nil
+}
+
+// GetCalendarLink retrieves the iCal link of a user. Returns nil if none is linked.
+func (s *SQLiteStore) GetCalendarLink(ctx context.Context, userID int64) (*store.CalendarLink, error) {
+	row := s.conn().QueryRowContext(ctx, `SELECT user_id, url, last_synced_at, last_error FROM calendar_links WHERE user_id = ?`, userID)
+	link, err := scanCalendarLink(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil // Not found is not an error
+		}
+		return nil, fmt.Errorf("could not query calendar link: %w", err)
+	}
+	return link, nil
+}
+
+// DeleteCalendarLink unlinks a user's calendar and removes the periods imported from it.
+func (s *SQLiteStore) DeleteCalendarLink(ctx context.Context, userID int64) error {
+	tx, err := s.begin(ctx)
+	if err != nil {
+		return fmt.Errorf("could not begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM calendar_links WHERE user_id = ?`, userID); err != nil {
+		return fmt.Errorf("could not delete calendar link: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM off_duty_periods WHERE user_id = ? AND source = ?`, userID, store.OffDutySourceICal); err != nil {
+		return fmt.Errorf("could not delete imported off-duty periods: %w", err)
+	}
+	return tx.Commit()
+}
+
+// ListCalendarLinks returns all linked calendars.
+func (s *SQLiteStore) ListCalendarLinks(ctx context.Context) ([]*store.CalendarLink, error) {
+	rows, err := s.conn().QueryContext(ctx, `SELECT user_id, url, last_synced_at, last_error FROM calendar_links ORDER BY user_id`)
+	if err != nil {
+		return nil, fmt.Errorf("could not query calendar links: %w", err)
+	}
+	defer rows.Close()
+
+	var links []*store.CalendarLink
+	for rows.Next() {
+		link, err := scanCalendarLink(rows)
+		if err != nil {
+			return nil, fmt.Errorf("could not scan calendar link row: %w", err)
+		}
+		links = append(links, link)
+	}
+	return links, nil
+}
+
+// scanCalendarLink scans a calendar link from either *sql.Row or *sql.Rows.
+func scanCalendarLink(row interface{ Scan(...interface{}) error }) (*store.CalendarLink, error) {
+	link := &store.CalendarLink{}
+	var lastSyncedAt sql.NullString
+	if err := row.Scan(&link.UserID, &link.URL, &lastSyncedAt, &link.LastError); err != nil {
+		return nil, err
+	}
+	if lastSyncedAt.Valid {
+		t, err := time.Parse(time.RFC3339, lastSyncedAt.String)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse last synced at: %w", err)
+		}
+		link.LastSyncedAt = &t
+	}
+	return link, nil
+}
+
+// GetNotificationPreferences retrieves a user's notification preferences.
+// It returns nil if the user never changed the defaults.
+func (s *SQLiteStore) GetNotificationPreferences(ctx context.Context, userID int64) (*store.NotificationPreferences, error) {
+	query := `
+		SELECT user_id, group_reminder, personal_dm, weekly_stats, swap_requests, reminder_hour
+		FROM notification_preferences WHERE user_id = ?
+	`
+	prefs := &store.NotificationPreferences{}
+	err := s.conn().QueryRowContext(ctx, query, userID).Scan(
+		&prefs.UserID, &prefs.GroupReminder, &prefs.PersonalDM, &prefs.WeeklyStats, &prefs.SwapRequests, &prefs.ReminderHour,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil // Not found is not an error
+		}
+		return nil, fmt.Errorf("could not query notification preferences: %w", err)
+	}
+	return prefs, nil
+}
+
+// SetNotificationPreferences creates or replaces a user's notification preferences.
+func (s *SQLiteStore) SetNotificationPreferences(ctx context.Context, prefs *store.NotificationPreferences) error {
+	query := `
+		INSERT INTO notification_preferences (user_id, group_reminder, personal_dm, weekly_stats, swap_requests, reminder_hour)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT(user_id) DO UPDATE SET
+			group_reminder = excluded.group_reminder, personal_dm = excluded.personal_dm,
+			weekly_stats = excluded.weekly_stats, swap_requests = excluded.swap_requests,
+			reminder_hour = excluded.reminder_hour
+	`
+	_, err := s.conn().ExecContext(ctx, query,
+		prefs.UserID, prefs.GroupReminder, prefs.PersonalDM, prefs.WeeklyStats, prefs.SwapRequests, prefs.ReminderHour)
+	if err != nil {
+		return fmt.Errorf("could not set notification preferences: %w", err)
+	}
+	return nil
+}
+
+// CreateChangeSubscription subscribes a user to changes of their duties.
+// Subscribing again keeps the original subscription.
+func (s *SQLiteStore) CreateChangeSubscription(ctx context.Context, sub *store.ChangeSubscription) error {
+	query := `INSERT INTO change_subscriptions (user_id, created_at) VALUES (?, ?) ON CONFLICT(user_id) DO NOTHING`
+	if _, err := s.conn().ExecContext(ctx, query, sub.UserID, sub.CreatedAt.UTC().Format(time.RFC3339)); err != nil {
+		return fmt.Errorf("could not create change subscription: %w", err)
+	}
+	return nil
+}
+
+// GetChangeSubscription retrieves a user's change subscription. It returns
+// nil if the user isn't subscribed.
+func (s *SQLiteStore) GetChangeSubscription(ctx context.Context, userID int64) (*store.ChangeSubscription, error) {
+	sub := &store.ChangeSubscription{}
+	var createdAt string
+	err := s.conn().QueryRowContext(ctx, `SELECT user_id, created_at FROM change_subscriptions WHERE user_id = ?`, userID).
+		Scan(&sub.UserID, &createdAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil // Not found is not an error
+		}
+		return nil, fmt.Errorf("could not query change subscription: %w", err)
+	}
+	if sub.CreatedAt, err = time.Parse(time.RFC3339, createdAt); err != nil {
+		return nil, fmt.Errorf("could not parse created at: %w", err)
+	}
+	return sub, nil
+}
+
+// DeleteChangeSubscription unsubscribes a user from changes of their duties.
+func (s *SQLiteStore) DeleteChangeSubscription(ctx context.Context, userID int64) error {
+	if _, err := s.conn().ExecContext(ctx, `DELETE FROM change_subscriptions WHERE user_id = ?`, userID); err != nil {
+		return fmt.Errorf("could not delete change subscription: %w", err)
+	}
+	return nil
+}
+
+// SetSkipDay marks a date as "no duty", replacing the reason if it was already skipped.
+func (s *SQLiteStore) SetSkipDay(ctx context.Context, day *store.SkipDay) error {
+	query := `
+		INSERT INTO skip_days (date, reason, created_at) VALUES (?, ?, ?)
+		ON CONFLICT(date) DO UPDATE SET reason = excluded.reason, created_at = excluded.created_at
+	`
+	_, err := s.conn().ExecContext(ctx, query,
+		day.Date.Format("2006-01-02"), string(day.Reason), day.CreatedAt.UTC().Format(time.RFC3339))
+	if err != nil {
+		return fmt.Errorf("could not set skip day: %w", err)
+	}
+	return nil
+}
+
+// GetSkipDay retrieves the skip day on a date. Returns nil if the date is not skipped.
+func (s *SQLiteStore) GetSkipDay(ctx context.Context, date time.Time) (*store.SkipDay, error) {
+	row := s.conn().QueryRowContext(ctx, `SELECT date, reason, created_at FROM skip_days WHERE date = ?`, date.Format("2006-01-02"))
+	day, err := scanSkipDay(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil // Not found is not an error
+		}
+		return nil, fmt.Errorf("could not query skip day: %w", err)
+	}
+	return day, nil
+}
+
+// DeleteSkipDay removes the "no duty" mark from a date.
+func (s *SQLiteStore) DeleteSkipDay(ctx context.Context, date time.Time) error {
+	if _, err := s.conn().ExecContext(ctx, `DELETE FROM skip_days WHERE date = ?`, date.Format("2006-01-02")); err != nil {
+		return fmt.Errorf("could not delete skip day: %w", err)
+	}
+	return nil
+}
+
+// GetSkipDaysByMonth retrieves all skip days of a month, ordered by date.
+func (s *SQLiteStore) GetSkipDaysByMonth(ctx context.Context, year int, month time.Month) ([]*store.SkipDay, error) {
+	start := time.Date(year, month, 1, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 1, 0)
+
+	rows, err := s.conn().QueryContext(ctx, `SELECT date, reason, created_at FROM skip_days WHERE date >= ? AND date < ? ORDER BY date`,
+		start.Format("2006-01-02"), end.Format("2006-01-02"))
+	if err != nil {
+		return nil, fmt.Errorf("could not query skip days by month: %w", err)
+	}
+	defer rows.Close()
+
+	var days []*store.SkipDay
+	for rows.Next() {
+		day, err := scanSkipDay(rows)
+		if err != nil {
+			return nil, fmt.Errorf("could not scan skip day row: %w", err)
+		}
+		days = append(days, day)
+	}
+	return days, nil
+}
+
+// scanSkipDay scans a skip day from either *sql.Row or *sql.Rows.
+func scanSkipDay(row interface{ Scan(...interface{}) error }) (*store.SkipDay, error) {
+	day := &store.SkipDay{}
+	var date, reason, createdAt string
+	if err := row.Scan(&date, &reason, &createdAt); err != nil {
+		return nil, err
+	}
+	var err error
+	if day.Date, err = time.Parse("2006-01-02", date); err != nil {
+		return nil, fmt.Errorf("could not parse skip date: %w", err)
+	}
+	if day.CreatedAt, err = time.Parse(time.RFC3339, createdAt); err != nil {
+		return nil, fmt.Errorf("could not parse created at: %w", err)
+	}
+	day.Reason = store.SkipReason(reason)
+	return day, nil
+}
+
+// CreateReminderSnooze stores a postponed reminder and sets its ID.
+func (s *SQLiteStore) CreateReminderSnooze(ctx context.Context, snooze *store.ReminderSnooze) error {
+	query := `INSERT INTO reminder_snoozes (user_id, duty_date, remind_at) VALUES (?, ?, ?)`
+	res, err := s.conn().ExecContext(ctx, query,
+		snooze.UserID, snooze.DutyDate.Format("2006-01-02"), snooze.RemindAt.UTC().Format(time.RFC3339))
+	if err != nil {
+		return fmt.Errorf("could not create reminder snooze: %w", err)
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("could not get last insert ID for reminder snooze: %w", err)
+	}
+	snooze.ID = id
+	return nil
+}
+
+// ListReminderSnoozes returns all pending reminders, earliest first.
+func (s *SQLiteStore) ListReminderSnoozes(ctx context.Context) ([]*store.ReminderSnooze, error) {
+	rows, err := s.conn().QueryContext(ctx, `SELECT id, user_id, duty_date, remind_at FROM reminder_snoozes ORDER BY remind_at, id`)
+	if err != nil {
+		return nil, fmt.Errorf("could not query reminder snoozes: %w", err)
+	}
+	defer rows.Close()
+
+	var snoozes []*store.ReminderSnooze
+	for rows.Next() {
+		snooze := &store.ReminderSnooze{}
+		var dutyDate, remindAt string
+		if err := rows.Scan(&snooze.ID, &snooze.UserID, &dutyDate, &remindAt); err != nil {
+			return nil, fmt.Errorf("could not scan reminder snooze row: %w", err)
+		}
+		if snooze.DutyDate, err = time.Parse("2006-01-02", dutyDate); err != nil {
+			return nil, fmt.Errorf("could not parse duty date: %w", err)
+		}
+		if snooze.RemindAt, err = time.Parse(time.RFC3339, remindAt); err != nil {
+			return nil, fmt.Errorf("could not parse remind at: %w", err)
+		}
+		snoozes = append(snoozes, snooze)
+	}
+	return snoozes, nil
+}
+
+// DeleteReminderSnooze removes a pending reminder. Deleting a missing one is not an error.
+func (s *SQLiteStore) DeleteReminderSnooze(ctx context.Context, id int64) error {
+	if _, err := s.conn().ExecContext(ctx, `DELETE FROM reminder_snoozes WHERE id = ?`, id); err != nil {
+		return fmt.Errorf("could not delete reminder snooze: %w", err)
+	}
+	return nil
+}
+
+// CreatePendingMessage stores an unsent message and sets its ID.
+func (s *SQLiteStore) CreatePendingMessage(ctx context.Context, msg *store.PendingMessage) error {
+	query := `INSERT INTO pending_messages (chat_id, text, created_at) VALUES (?, ?, ?)`
+	res, err := s.conn().ExecContext(ctx, query, msg.ChatID, msg.Text, msg.CreatedAt.UTC().Format(time.RFC3339))
+	if err != nil {
+		return fmt.Errorf("could not create pending message: %w", err)
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("could not get last insert ID for pending message: %w", err)
+	}
+	msg.ID = id
+	return nil
+}
+
+// ListPendingMessages returns all unsent messages, oldest first.
+func (s *SQLiteStore) ListPendingMessages(ctx context.Context) ([]*store.PendingMessage, error) {
+	rows, err := s.conn().QueryContext(ctx, `SELECT id, chat_id, text, created_at FROM pending_messages ORDER BY created_at, id`)
+	if err != nil {
+		return nil, fmt.Errorf("could not query pending messages: %w", err)
+	}
+	defer rows.Close()
+
+	var msgs []*store.PendingMessage
+	for rows.Next() {
+		msg := &store.PendingMessage{}
+		var createdAt string
+		if err := rows.Scan(&msg.ID, &msg.ChatID, &msg.Text, &createdAt); err != nil {
+			return nil, fmt.Errorf("could not scan pending message row: %w", err)
+		}
+		if msg.CreatedAt, err = time.Parse(time.RFC3339, createdAt); err != nil {
+			return nil, fmt.Errorf("could not parse created at: %w", err)
+		}
+		msgs = append(msgs, msg)
+	}
+	return msgs, nil
+}
+
+// DeletePendingMessage removes an unsent message. Deleting a missing one is not an error.
+func (s *SQLiteStore) DeletePendingMessage(ctx context.Context, id int64) error {
+	if _, err := s.conn().ExecContext(ctx, `DELETE FROM pending_messages WHERE id = ?`, id); err != nil {
+		return fmt.Errorf("could not delete pending message: %w", err)
+	}
+	return nil
+}
+
+// TrackInteractiveMessage stores an interactive message, replacing it if it
+// was tracked before.
+func (s *SQLiteStore) TrackInteractiveMessage(ctx context.Context, msg *store.InteractiveMessage) error {
+	var doneAt interface{}
+	if msg.DoneAt != nil {
+		doneAt = msg.DoneAt.UTC().Format(time.RFC3339)
+	}
+	query := `INSERT INTO interactive_messages (chat_id, message_id, sent_at, done_at) VALUES (?, ?, ?, ?)
+	          ON CONFLICT(chat_id, message_id) DO UPDATE SET sent_at = excluded.sent_at, done_at = excluded.done_at`
+	if _, err := s.conn().ExecContext(ctx, query, msg.ChatID, msg.MessageID, msg.SentAt.UTC().Format(time.RFC3339), doneAt); err != nil {
+		return fmt.Errorf("could not track interactive message: %w", err)
+	}
+	return nil
+}
+
+// MarkInteractiveMessageDone records when the interaction of a message
+// completed. Untracked messages are ignored.
+func (s *SQLiteStore) MarkInteractiveMessageDone(ctx context.Context, chatID int64, messageID int, at time.Time) error {
+	query := `UPDATE interactive_messages SET done_at = ? WHERE chat_id = ? AND message_id = ?`
+	if _, err := s.conn().ExecContext(ctx, query, at.UTC().Format(time.RFC3339), chatID, messageID); err != nil {
+		return fmt.Errorf("could not mark interactive message done: %w", err)
+	}
+	return nil
+}
+
+// ListInteractiveMessages returns the tracked interactive messages, oldest first.
+func (s *SQLiteStore) ListInteractiveMessages(ctx context.Context) ([]*store.InteractiveMessage, error) {
+	rows, err := s.conn().QueryContext(ctx, `SELECT chat_id, message_id, sent_at, done_at FROM interactive_messages ORDER BY sent_at, chat_id, message_id`)
+	if err != nil {
+		return nil, fmt.Errorf("could not query interactive messages: %w", err)
+	}
+	defer rows.Close()
+
+	var msgs []*store.InteractiveMessage
+	for rows.Next() {
+		msg := &store.InteractiveMessage{}
+		var sentAt string
+		var doneAt sql.NullString
+		if err := rows.Scan(&msg.ChatID, &msg.MessageID, &sentAt, &doneAt); err != nil {
+			return nil, fmt.Errorf("could not scan interactive message row: %w", err)
+		}
+		if msg.SentAt, err = time.Parse(time.RFC3339, sentAt); err != nil {
+			return nil, fmt.Errorf("could not parse sent at: %w", err)
+		}
+		if doneAt.Valid {
+			t, err := time.Parse(time.RFC3339, doneAt.String)
+			if err != nil {
+				return nil, fmt.Errorf("could not parse done at: %w", err)
+			}
+			msg.DoneAt = &t
+		}
+		msgs = append(msgs, msg)
+	}
+	return msgs, rows.Err()
+}
+
+// DeleteInteractiveMessage stops tracking a message. Deleting a missing one is not an error.
+func (s *SQLiteStore) DeleteInteractiveMessage(ctx context.Context, chatID int64, messageID int) error {
+	if _, err := s.conn().ExecContext(ctx, `DELETE FROM interactive_messages WHERE chat_id = ? AND message_id = ?`, chatID, messageID); err != nil {
+		return fmt.Errorf("could not delete interactive message: %w", err)
+	}
+	return nil
+}
+
+// SaveConversationState stores a conversation state, replacing the one with
+// its key.
+func (s *SQLiteStore) SaveConversationState(ctx context.Context, state *store.ConversationState) error {
+	query := `INSERT INTO conversation_states (key, value, expires_at) VALUES (?, ?, ?)
+	          ON CONFLICT(key) DO UPDATE SET value = excluded.value, expires_at = excluded.expires_at`
+	if _, err := s.conn().ExecContext(ctx, query, state.Key, state.Value, state.ExpiresAt.UTC().Format(time.RFC3339)); err != nil {
+		return fmt.Errorf("could not save conversation state: %w", err)
+	}
+	return nil
+}
+
+// ListConversationStates returns the conversation states that expire after
+// now, by key.
+func (s *SQLiteStore) ListConversationStates(ctx context.Context, now time.Time) ([]*store.ConversationState, error) {
+	query := `SELECT key, value, expires_at FROM conversation_states WHERE expires_at > ? ORDER BY key`
+	rows, err := s.conn().QueryContext(ctx, query, now.UTC().Format(time.RFC3339))
+	if err != nil {
+		return nil, fmt.Errorf("could not query conversation states: %w", err)
+	}
+	defer rows.Close()
+
+	var states []*store.ConversationState
+	for rows.Next() {
+		state := &store.ConversationState{}
+		var expiresAt string
+		if err := rows.Scan(&state.Key, &state.Value, &expiresAt); err != nil {
+			return nil, fmt.Errorf("could not scan conversation state row: %w", err)
+		}
+		if state.ExpiresAt, err = time.Parse(time.RFC3339, expiresAt); err != nil {
+			return nil, fmt.Errorf("could not parse expires at: %w", err)
+		}
+		states = append(states, state)
+	}
+	return states, rows.Err()
+}
+
+// DeleteConversationState deletes a conversation state. Deleting a missing
+// one is not an error.
+func (s *SQLiteStore) DeleteConversationState(ctx context.Context, key string) error {
+	if _, err := s.conn().ExecContext(ctx, `DELETE FROM conversation_states WHERE key = ?`, key); err != nil {
+		return fmt.Errorf("could not delete conversation state: %w", err)
+	}
+	return nil
+}
+
+// DeleteExpiredConversationStates deletes the conversation states that
+// expired by now.
+func (s *SQLiteStore) DeleteExpiredConversationStates(ctx context.Context, now time.Time) error {
+	query := `DELETE FROM conversation_states WHERE expires_at <= ?`
+	if _, err := s.conn().ExecContext(ctx, query, now.UTC().Format(time.RFC3339)); err != nil {
+		return fmt.Errorf("could not delete expired conversation states: %w", err)
+	}
+	return nil
+}
+
+// GetChatLocale returns the locale a chat picked, or "" if it didn't.
+func (s *SQLiteStore) GetChatLocale(ctx context.Context, chatID int64) (string, error) {
+	var locale string
+	err := s.conn().QueryRowContext(ctx, `SELECT locale FROM chat_locales WHERE chat_id = ?`, chatID).Scan(&locale)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return "", nil // Not found is not an error
+		}
+		return "", fmt.Errorf("could not query chat locale: %w", err)
+	}
+	return locale, nil
+}
+
+// SetChatLocale sets the locale of a chat, replacing the one it had.
+func (s *SQLiteStore) SetChatLocale(ctx context.Context, chatID int64, locale string) error {
+	query := `INSERT INTO chat_locales (chat_id, locale) VALUES (?, ?) ON CONFLICT(chat_id) DO UPDATE SET locale = excluded.locale`
+	if _, err := s.conn().ExecContext(ctx, query, chatID, locale); err != nil {
+		return fmt.Errorf("could not set chat locale: %w", err)
+	}
+	return nil
+}
+
+// GetChatDisplay returns how a chat wants its calendars drawn, or nil if it
+// never said.
+func (s *SQLiteStore) GetChatDisplay(ctx context.Context, chatID int64) (*store.ChatDisplay, error) {
+	var d store.ChatDisplay
+	err := s.conn().QueryRowContext(ctx, `SELECT week_numbers, adjacent_days FROM chat_display WHERE chat_id = ?`, chatID).
+		Scan(&d.WeekNumbers, &d.AdjacentDays)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil // Not found is not an error
+		}
+		return nil, fmt.Errorf("could not query chat display: %w", err)
+	}
+	return &d, nil
+}
+
+// SetChatDisplay sets how a chat wants its calendars drawn, replacing what it
+// had.
+func (s *SQLiteStore) SetChatDisplay(ctx context.Context, chatID int64, display store.ChatDisplay) error {
+	query := `INSERT INTO chat_display (chat_id, week_numbers, adjacent_days) VALUES (?, ?, ?)
+		ON CONFLICT(chat_id) DO UPDATE SET week_numbers = excluded.week_numbers, adjacent_days = excluded.adjacent_days`
+	if _, err := s.conn().ExecContext(ctx, query, chatID, display.WeekNumbers, display.AdjacentDays); err != nil {
+		return fmt.Errorf("could not set chat display: %w", err)
+	}
+	return nil
+}
+
+// GetSetting returns the value of a setting, or "" if it was never set.
+func (s *SQLiteStore) GetSetting(ctx context.Context, key string) (string, error) {
+	var value string
+	err := s.conn().QueryRowContext(ctx, `SELECT value FROM settings WHERE key = ?`, key).Scan(&value)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return "", nil // Not found is not an error
+		}
+		return "", fmt.Errorf("could not query setting: %w", err)
+	}
+	return value, nil
+}
+
+// SetSetting sets a setting, replacing its value.
+func (s *SQLiteStore) SetSetting(ctx context.Context, key, value string) error {
+	query := `INSERT INTO settings (key, value) VALUES (?, ?) ON CONFLICT(key) DO UPDATE SET value = excluded.value`
+	if _, err := s.conn().ExecContext(ctx, query, key, value); err != nil {
+		return fmt.Errorf("could not set setting: %w", err)
+	}
+	return nil
+}
+
+// ListSettings returns all settings that were set, by key.
+func (s *SQLiteStore) ListSettings(ctx context.Context) (map[string]string, error) {
+	rows, err := s.conn().QueryContext(ctx, `SELECT key, value FROM settings`)
+	if err != nil {
+		return nil, fmt.Errorf("could not query settings: %w", err)
+	}
+	defer rows.Close()
+
+	values := make(map[string]string)
+	for rows.Next() {
+		var key, value string
+		if err := rows.Scan(&key, &value); err != nil {
+			return nil, fmt.Errorf("could not scan setting: %w", err)
+		}
+		values[key] = value
+	}
+	return values, rows.Err()
+}
+
+// CreateShadowComparison records a shadow strategy's pick and sets its ID.
+func (s *SQLiteStore) CreateShadowComparison(ctx context.Context, c *store.ShadowComparison) error {
+	query := `INSERT INTO shadow_comparisons (date, strategy, live_user_id, shadow_user_id, created_at) VALUES (?, ?, ?, ?, ?)`
+	res, err := s.conn().ExecContext(ctx, query, c.Date.Format("2006-01-02"), c.Strategy, c.LiveUserID, c.ShadowUserID,
+		c.CreatedAt.UTC().Format(time.RFC3339))
+	if err != nil {
+		return fmt.Errorf("could not create shadow comparison: %w", err)
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("could not get last insert ID for shadow comparison: %w", err)
+	}
+	c.ID = id
+	return nil
+}
+
+// ListShadowComparisons returns the comparisons of days on or after since, oldest first.
+func (s *SQLiteStore) ListShadowComparisons(ctx context.Context, since time.Time) ([]*store.ShadowComparison, error) {
+	rows, err := s.conn().QueryContext(ctx, `
+		SELECT id, date, strategy, live_user_id, shadow_user_id, created_at
+		FROM shadow_comparisons WHERE date >= ? ORDER BY date, id`, since.Format("2006-01-02"))
+	if err != nil {
+		return nil, fmt.Errorf("could not query shadow comparisons: %w", err)
+	}
+	defer rows.Close()
+
+	var comparisons []*store.ShadowComparison
+	for rows.Next() {
+		c := &store.ShadowComparison{}
+		var date, createdAt string
+		if err := rows.Scan(&c.ID, &date, &c.Strategy, &c.LiveUserID, &c.ShadowUserID, &createdAt); err != nil {
+			return nil, fmt.Errorf("could not scan shadow comparison row: %w", err)
+		}
+		if c.Date, err = time.Parse("2006-01-02", date); err != nil {
+			return nil, fmt.Errorf("could not parse shadow comparison date: %w", err)
+		}
+		if c.CreatedAt, err = time.Parse(time.RFC3339, createdAt); err != nil {
+			return nil, fmt.Errorf("could not parse created at: %w", err)
+		}
+		comparisons = append(comparisons, c)
+	}
+	return comparisons, nil
+}
+
+// CreateScheduleVersion stores a snapshot as the next version of its month
+// and sets its ID and Version.
+func (s *SQLiteStore) CreateScheduleVersion(ctx context.Context, v *store.ScheduleVersion) error {
+	tx, err := s.begin(ctx)
+	if err != nil {
+		return fmt.Errorf("could not begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	month := v.Month.Format("2006-01")
+	var version int
+	if err := tx.QueryRowContext(ctx, `SELECT COALESCE(MAX(version), 0) + 1 FROM schedule_versions WHERE month = ?`, month).Scan(&version); err != nil {
+		return fmt.Errorf("could not query schedule version: %w", err)
+	}
+	res, err := tx.ExecContext(ctx, `INSERT INTO schedule_versions (month, version, created_at) VALUES (?, ?, ?)`,
+		month, version, v.CreatedAt.UTC().Format(time.RFC3339))
+	if err != nil {
+		return fmt.Errorf("could not create schedule version: %w", err)
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("could not get last insert ID for schedule version: %w", err)
+	}
+	for _, d := range v.Days {
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO schedule_version_days (version_id, date, user_id, assignment_type, status, skip_reason)
+			VALUES (?, ?, ?, ?, ?, ?)`,
+			id, d.Date.Format("2006-01-02"), d.UserID, string(d.AssignmentType), string(d.Status), string(d.SkipReason))
+		if err != nil {
+			return fmt.Errorf("could not create schedule version day: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("could not commit schedule version: %w", err)
+	}
+	v.ID, v.Version = id, version
+	return nil
+}
+
+// ListScheduleVersions returns the versions of a month, oldest first.
+func (s *SQLiteStore) ListScheduleVersions(ctx context.Context, month time.Time) ([]*store.ScheduleVersion, error) {
+	rows, err := s.conn().QueryContext(ctx, `
+		SELECT v.id, v.version, v.created_at, d.date, d.user_id, d.assignment_type, d.status, d.skip_reason
+		FROM schedule_versions v LEFT JOIN schedule_version_days d ON d.version_id = v.id
+		WHERE v.month = ? ORDER BY v.version, d.date`, month.Format("2006-01"))
+	if err != nil {
+		return nil, fmt.Errorf("could not query schedule versions: %w", err)
+	}
+	defer rows.Close()
+
+	first := time.Date(month.Year(), month.Month(), 1, 0, 0, 0, 0, time.UTC)
+	var versions []*store.ScheduleVersion
+	for rows.Next() {
+		var id int64
+		var version int
+		var createdAt string
+		var date, assignmentType, status, skipReason sql.NullString
+		var userID sql.NullInt64
+		if err := rows.Scan(&id, &version, &createdAt, &date, &userID, &assignmentType, &status, &skipReason); err != nil {
+			return nil, fmt.Errorf("could not scan schedule version row: %w", err)
+		}
+		if len(versions) == 0 || versions[len(versions)-1].ID != id {
+			v := &store.ScheduleVersion{ID: id, Month: first, Version: version}
+			if v.CreatedAt, err = time.Parse(time.RFC3339, createdAt); err != nil {
+				return nil, fmt.Errorf("could not parse created at: %w", err)
+			}
+			versions = append(versions, v)
+		}
+		if !date.Valid {
+			continue // A month without duties
+		}
+		d := store.ScheduleDay{
+			UserID:         userID.Int64,
+			AssignmentType: store.AssignmentType(assignmentType.String),
+			Status:         store.DutyStatus(status.String),
+			SkipReason:     store.SkipReason(skipReason.String),
+		}
+		if d.Date, err = time.Parse("2006-01-02", date.String); err != nil {
+			return nil, fmt.Errorf("could not parse schedule version date: %w", err)
+		}
+		v := versions[len(versions)-1]
+		v.Days = append(v.Days, d)
+	}
+	return versions, rows.Err()
+}
+
+// SaveAssignmentExplanation stores an explanation, replacing the one of its day.
+func (s *SQLiteStore) SaveAssignmentExplanation(ctx context.Context, e *store.AssignmentExplanation) error {
+	tx, err := s.begin(ctx)
+	if err != nil {
+		return fmt.Errorf("could not begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	date := e.Date.Format("2006-01-02")
+	if _, err := tx.ExecContext(ctx, `DELETE FROM assignment_candidates WHERE date = ?`, date); err != nil {
+		return fmt.Errorf("could not delete assignment candidates: %w", err)
+	}
+	_, err = tx.ExecContext(ctx, `
+		INSERT OR REPLACE INTO assignment_explanations (date, user_id, assignment_type, strategy, reason, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		date, e.UserID, string(e.AssignmentType), e.Strategy, e.Reason, e.CreatedAt.UTC().Format(time.RFC3339))
+	if err != nil {
+		return fmt.Errorf("could not save assignment explanation: %w", err)
+	}
+	for _, c := range e.Candidates {
+		var lastDuty sql.NullString
+		if c.LastDuty != nil {
+			lastDuty = sql.NullString{String: c.LastDuty.Format("2006-01-02"), Valid: true}
+		}
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO assignment_candidates (date, user_id, excluded, volunteer_queue_days, admin_queue_days, recent_duties, last_duty)
+			VALUES (?, ?, ?, ?, ?, ?, ?)`,
+			date, c.UserID, c.Excluded, c.VolunteerQueueDays, c.AdminQueueDays, c.RecentDuties, lastDuty)
+		if err != nil {
+			return fmt.Errorf("could not save assignment candidate: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("could not commit assignment explanation: %w", err)
+	}
+	return nil
+}
+
+// GetAssignmentExplanation returns the explanation of a day, or nil if there is none.
+func (s *SQLiteStore) GetAssignmentExplanation(ctx context.Context, date time.Time) (*store.AssignmentExplanation, error) {
+	day := date.Format("2006-01-02")
+	e := &store.AssignmentExplanation{Date: time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)}
+	var assignmentType, createdAt string
+	err := s.conn().QueryRowContext(ctx, `
+		SELECT user_id, assignment_type, strategy, reason, created_at FROM assignment_explanations WHERE date = ?`, day).
+		Scan(&e.UserID, &assignmentType, &e.Strategy, &e.Reason, &createdAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not query assignment explanation: %w", err)
+	}
+	e.AssignmentType = store.AssignmentType(assignmentType)
+	if e.CreatedAt, err = time.Parse(time.RFC3339, createdAt); err != nil {
+		return nil, fmt.Errorf("could not parse created at: %w", err)
+	}
+
+	rows, err := s.conn().QueryContext(ctx, `
+		SELECT user_id, excluded, volunteer_queue_days, admin_queue_days, recent_duties, last_duty
+		FROM assignment_candidates WHERE date = ? ORDER BY excluded != '', user_id`, day)
+	if err != nil {
+		return nil, fmt.Errorf("could not query assignment candidates: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var c store.AssignmentCandidate
+		var lastDuty sql.NullString
+		if err := rows.Scan(&c.UserID, &c.Excluded, &c.VolunteerQueueDays, &c.AdminQueueDays, &c.RecentDuties, &lastDuty); err != nil {
+			return nil, fmt.Errorf("could not scan assignment candidate row: %w", err)
+		}
+		if lastDuty.Valid {
+			last, err := time.Parse("2006-01-02", lastDuty.String)
+			if err != nil {
+				return nil, fmt.Errorf("could not parse last duty: %w", err)
+			}
+			c.LastDuty = &last
+		}
+		e.Candidates = append(e.Candidates, c)
+	}
+	return e, rows.Err()
+}
+
+// CreateNoteTemplate stores a context note template and sets its ID.
+func (s *SQLiteStore) CreateNoteTemplate(ctx context.Context, t *store.NoteTemplate) error {
+	res, err := s.conn().ExecContext(ctx, `INSERT INTO note_templates (rule, text, created_at) VALUES (?, ?, ?)`,
+		t.Rule, t.Text, t.CreatedAt.UTC().Format(time.RFC3339))
+	if err != nil {
+		return fmt.Errorf("could not create note template: %w", err)
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("could not get last insert ID for note template: %w", err)
+	}
+	t.ID = id
+	return nil
+}
+
+// ListNoteTemplates returns all context note templates, oldest first.
+func (s *SQLiteStore) ListNoteTemplates(ctx context.Context) ([]*store.NoteTemplate, error) {
+	rows, err := s.conn().QueryContext(ctx, `SELECT id, rule, text, created_at FROM note_templates ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("could not query note templates: %w", err)
+	}
+	defer rows.Close()
+
+	var templates []*store.NoteTemplate
+	for rows.Next() {
+		t := &store.NoteTemplate{}
+		var createdAt string
+		if err := rows.Scan(&t.ID, &t.Rule, &t.Text, &createdAt); err != nil {
+			return nil, fmt.Errorf("could not scan note template row: %w", err)
+		}
+		if t.CreatedAt, err = time.Parse(time.RFC3339, createdAt); err != nil {
+			return nil, fmt.Errorf("could not parse created at: %w", err)
+		}
+		templates = append(templates, t)
+	}
+	return templates, nil
+}
+
+// DeleteNoteTemplate removes a context note template. Unknown IDs are ignored.
+func (s *SQLiteStore) DeleteNoteTemplate(ctx context.Context, id int64) error {
+	if _, err := s.conn().ExecContext(ctx, `DELETE FROM note_templates WHERE id = ?`, id); err != nil {
+		return fmt.Errorf("could not delete note template: %w", err)
+	}
+	return nil
+}
+
+// CreateBlackoutRule stores a blackout rule and sets its ID.
+func (s *SQLiteStore) CreateBlackoutRule(ctx context.Context, r *store.BlackoutRule) error {
+	res, err := s.conn().ExecContext(ctx, `INSERT INTO blackout_rules (rule, description, created_at) VALUES (?, ?, ?)`,
+		r.Rule, r.Description, r.CreatedAt.UTC().Format(time.RFC3339))
+	if err != nil {
+		return fmt.Errorf("could not create blackout rule: %w", err)
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("could not get last insert ID for blackout rule: %w", err)
+	}
+	r.ID = id
+	return nil
+}
+
+// ListBlackoutRules returns all blackout rules, oldest first.
+func (s *SQLiteStore) ListBlackoutRules(ctx context.Context) ([]*store.BlackoutRule, error) {
+	rows, err := s.conn().QueryContext(ctx, `SELECT id, rule, description, created_at FROM blackout_rules ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("could not query blackout rules: %w", err)
+	}
+	defer rows.Close()
+
+	var rules []*store.BlackoutRule
+	for rows.Next() {
+		r := &store.BlackoutRule{}
+		var createdAt string
+		if err := rows.Scan(&r.ID, &r.Rule, &r.Description, &createdAt); err != nil {
+			return nil, fmt.Errorf("could not scan blackout rule row: %w", err)
+		}
+		if r.CreatedAt, err = time.Parse(time.RFC3339, createdAt); err != nil {
+			return nil, fmt.Errorf("could not parse created at: %w", err)
+		}
+		rules = append(rules, r)
+	}
+	return rules, nil
+}
+
+// DeleteBlackoutRule removes a blackout rule. Unknown IDs are ignored.
+func (s *SQLiteStore) DeleteBlackoutRule(ctx context.Context, id int64) error {
+	if _, err := s.conn().ExecContext(ctx, `DELETE FROM blackout_rules WHERE id = ?`, id); err != nil {
+		return fmt.Errorf("could not delete blackout rule: %w", err)
+	}
+	return nil
+}
+
+// CreateChecklistItem stores a checklist item and sets its ID.
+func (s *SQLiteStore) CreateChecklistItem(ctx context.Context, item *store.ChecklistItem) error {
+	res, err := s.conn().ExecContext(ctx, `INSERT INTO checklist_items (assignment_type, text, mandatory, created_at) VALUES (?, ?, ?, ?)`,
+		string(item.AssignmentType), item.Text, item.Mandatory, item.CreatedAt.UTC().Format(time.RFC3339))
+	if err != nil {
+		return fmt.Errorf("could not create checklist item: %w", err)
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("could not get last insert ID for checklist item: %w", err)
+	}
+	item.ID = id
+	return nil
+}
+
+// ListChecklistItems returns all checklist items, oldest first.
+func (s *SQLiteStore) ListChecklistItems(ctx context.Context) ([]*store.ChecklistItem, error) {
+	rows, err := s.conn().QueryContext(ctx, `SELECT id, assignment_type, text, mandatory, created_at FROM checklist_items ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("could not query checklist items: %w", err)
+	}
+	defer rows.Close()
+
+	var items []*store.ChecklistItem
+	for rows.Next() {
+		item := &store.ChecklistItem{}
+		var assignmentType, createdAt string
+		if err := rows.Scan(&item.ID, &assignmentType, &item.Text, &item.Mandatory, &createdAt); err != nil {
+			return nil, fmt.Errorf("could not scan checklist item row: %w", err)
+		}
+		item.AssignmentType = store.AssignmentType(assignmentType)
+		if item.CreatedAt, err = time.Parse(time.RFC3339, createdAt); err != nil {
+			return nil, fmt.Errorf("could not parse created at: %w", err)
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// DeleteChecklistItem removes a checklist item along with its checks.
+// Unknown IDs are ignored.
+func (s *SQLiteStore) DeleteChecklistItem(ctx context.Context, id int64) error {
+	tx, err := s.begin(ctx)
+	if err != nil {
+		return fmt.Errorf("could not begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM checklist_checks WHERE item_id = ?`, id); err != nil {
+		return fmt.Errorf("could not delete checklist checks: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM checklist_items WHERE id = ?`, id); err != nil {
+		return fmt.Errorf("could not delete checklist item: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("could not commit checklist item deletion: %w", err)
+	}
+	return nil
+}
+
+// SetChecklistCheck checks an item off on the duty of a day. Checking it
+// again keeps the first check.
+func (s *SQLiteStore) SetChecklistCheck(ctx context.Context, check *store.ChecklistCheck) error {
+	query := `INSERT INTO checklist_checks (date, item_id, checked_at) VALUES (?, ?, ?) ON CONFLICT(date, item_id) DO NOTHING`
+	_, err := s.conn().ExecContext(ctx, query,
+		check.Date.Format("2006-01-02"), check.ItemID, check.CheckedAt.UTC().Format(time.RFC3339))
+	if err != nil {
+		return fmt.Errorf("could not set checklist check: %w", err)
+	}
+	return nil
+}
+
+// DeleteChecklistCheck unchecks an item on the duty of a day.
+func (s *SQLiteStore) DeleteChecklistCheck(ctx context.Context, date time.Time, itemID int64) error {
+	if _, err := s.conn().ExecContext(ctx, `DELETE FROM checklist_checks WHERE date = ? AND item_id = ?`,
+		date.Format("2006-01-02"), itemID); err != nil {
+		return fmt.Errorf("could not delete checklist check: %w", err)
+	}
+	return nil
+}
+
+// GetChecklistChecks returns the items checked off on the duty of a day,
+// ordered by item.
+func (s *SQLiteStore) GetChecklistChecks(ctx context.Context, date time.Time) ([]*store.ChecklistCheck, error) {
+	rows, err := s.conn().QueryContext(ctx, `SELECT date, item_id, checked_at FROM checklist_checks WHERE date = ? ORDER BY item_id`,
+		date.Format("2006-01-02"))
+	if err != nil {
+		return nil, fmt.Errorf("could not query checklist checks: %w", err)
+	}
+	defer rows.Close()
+
+	var checks []*store.ChecklistCheck
+	for rows.Next() {
+		c := &store.ChecklistCheck{}
+		var day, checkedAt string
+		if err := rows.Scan(&day, &c.ItemID, &checkedAt); err != nil {
+			return nil, fmt.Errorf("could not scan checklist check row: %w", err)
+		}
+		if c.Date, err = time.Parse("2006-01-02", day); err != nil {
+			return nil, fmt.Errorf("could not parse check date: %w", err)
+		}
+		if c.CheckedAt, err = time.Parse(time.RFC3339, checkedAt); err != nil {
+			return nil, fmt.Errorf("could not parse checked at: %w", err)
+		}
+		checks = append(checks, c)
+	}
+	return checks, nil
+}
+
+// CreateTask stores a one-off task and sets its ID.
+func (s *SQLiteStore) CreateTask(ctx context.Context, t *store.Task) error {
+	res, err := s.conn().ExecContext(ctx, `INSERT INTO tasks (title, weight, due_date, created_at) VALUES (?, ?, ?, ?)`,
+		t.Title, t.Weight, formatHoldUntil(t.DueDate), t.CreatedAt.UTC().Format(time.RFC3339))
+	if err != nil {
+		return fmt.Errorf("could not create task: %w", err)
+	}
+	if t.ID, err = res.LastInsertId(); err != nil {
+		return fmt.Errorf("could not get last insert ID for task: %w", err)
+	}
+	return nil
+}
+
+const taskColumns = `id, title, weight, due_date, claimed_by, claimed_at, done_at, created_at`
+
+// scanTask scans a row of taskColumns.
+func scanTask(row interface{ Scan(...interface{}) error }) (*store.Task, error) {
+	t := &store.Task{}
+	var dueDate, claimedAt, doneAt sql.NullString
+	var claimedBy sql.NullInt64
+	var createdAt string
+	if err := row.Scan(&t.ID, &t.Title, &t.Weight, &dueDate, &claimedBy, &claimedAt, &doneAt, &createdAt); err != nil {
+		return nil, err
+	}
+	if dueDate.Valid {
+		date, err := time.Parse("2006-01-02", dueDate.String)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse due date: %w", err)
+		}
+		t.DueDate = &date
+	}
+	if claimedBy.Valid {
+		t.ClaimedBy = &claimedBy.Int64
+	}
+	if claimedAt.Valid {
+		at, err := time.Parse(time.RFC3339, claimedAt.String)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse claimed at: %w", err)
+		}
+		t.ClaimedAt = &at
+	}
+	if doneAt.Valid {
+		at, err := time.Parse(time.RFC3339, doneAt.String)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse done at: %w", err)
+		}
+		t.DoneAt = &at
+	}
+	var err error
+	if t.CreatedAt, err = time.Parse(time.RFC3339, createdAt); err != nil {
+		return nil, fmt.Errorf("could not parse created at: %w", err)
+	}
+	return t, nil
+}
+
+// GetTask returns the task with the ID, or nil if there is none.
+func (s *SQLiteStore) GetTask(ctx context.Context, id int64) (*store.Task, error) {
+	t, err := scanTask(s.conn().QueryRowContext(ctx, `SELECT `+taskColumns+` FROM tasks WHERE id = ?`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not get task: %w", err)
+	}
+	return t, nil
+}
+
+// ListTasks returns all tasks, oldest first.
+func (s *SQLiteStore) ListTasks(ctx context.Context) ([]*store.Task, error) {
+	rows, err := s.conn().QueryContext(ctx, `SELECT `+taskColumns+` FROM tasks ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("could not query tasks: %w", err)
+	}
+	defer rows.Close()
+
+	var tasks []*store.Task
+	for rows.Next() {
+		t, err := scanTask(rows)
+		if err != nil {
+			return nil, fmt.Errorf("could not scan task row: %w", err)
+		}
+		tasks = append(tasks, t)
+	}
+	return tasks, rows.Err()
+}
+
+// ClaimTask gives the open, unclaimed task to the user and reports whether
+// it did.
+func (s *SQLiteStore) ClaimTask(ctx context.Context, id, userID int64, at time.Time) (bool, error) {
+	res, err := s.conn().ExecContext(ctx,
+		`UPDATE tasks SET claimed_by = ?, claimed_at = ? WHERE id = ? AND claimed_by IS NULL AND done_at IS NULL`,
+		userID, at.UTC().Format(time.RFC3339), id)
+	if err != nil {
+		return false, fmt.Errorf("could not claim task: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("could not count claimed tasks: %w", err)
+	}
+	return n > 0, nil
+}
+
+// ReleaseTask takes back the user's claim of the open task and reports
+// whether there was one.
+func (s *SQLiteStore) ReleaseTask(ctx context.Context, id, userID int64) (bool, error) {
+	res, err := s.conn().ExecContext(ctx,
+		`UPDATE tasks SET claimed_by = NULL, claimed_at = NULL WHERE id = ? AND claimed_by = ? AND done_at IS NULL`, id, userID)
+	if err != nil {
+		return false, fmt.Errorf("could not release task: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("could not count released tasks: %w", err)
+	}
+	return n > 0, nil
+}
+
+// CompleteTask marks the open task done by the user, claiming it for them if
+// nobody has, and reports whether it did.
+func (s *SQLiteStore) CompleteTask(ctx context.Context, id, userID int64, at time.Time) (bool, error) {
+	stamp := at.UTC().Format(time.RFC3339)
+	res, err := s.conn().ExecContext(ctx, `
+		UPDATE tasks SET claimed_by = ?, claimed_at = COALESCE(claimed_at, ?), done_at = ?
+		WHERE id = ? AND (claimed_by IS NULL OR claimed_by = ?) AND done_at IS NULL`,
+		userID, stamp, stamp, id, userID)
+	if err != nil {
+		return false, fmt.Errorf("could not complete task: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("could not count completed tasks: %w", err)
+	}
+	return n > 0, nil
+}
+
+// DeleteTask deletes a task. Unknown IDs are ignored.
+func (s *SQLiteStore) DeleteTask(ctx context.Context, id int64) error {
+	if _, err := s.conn().ExecContext(ctx, `DELETE FROM tasks WHERE id = ?`, id); err != nil {
+		return fmt.Errorf("could not delete task: %w", err)
+	}
+	return nil
+}
+
+// CreateCoverRequest stores a cover request and sets its ID.
+func (s *SQLiteStore) CreateCoverRequest(ctx context.Context, r *store.CoverRequest) error {
+	res, err := s.conn().ExecContext(ctx, `INSERT INTO cover_requests (duty_date, user_id, deadline, created_at) VALUES (?, ?, ?, ?)`,
+		r.DutyDate.Format("2006-01-02"), r.UserID, r.Deadline.UTC().Format(time.RFC3339), r.CreatedAt.UTC().Format(time.RFC3339))
+	if err != nil {
+		return fmt.Errorf("could not create cover request: %w", err)
+	}
+	if r.ID, err = res.LastInsertId(); err != nil {
+		return fmt.Errorf("could not get last insert ID for cover request: %w", err)
+	}
+	return nil
+}
+
+const coverRequestColumns = `id, duty_date, user_id, deadline, covered_by, resolved_at, created_at`
+
+// scanCoverRequest scans a row of coverRequestColumns.
+func scanCoverRequest(row interface{ Scan(...interface{}) error }) (*store.CoverRequest, error) {
+	r := &store.CoverRequest{}
+	var dutyDate, deadline, createdAt string
+	var coveredBy sql.NullInt64
+	var resolvedAt sql.NullString
+	if err := row.Scan(&r.ID, &dutyDate, &r.UserID, &deadline, &coveredBy, &resolvedAt, &createdAt); err != nil {
+		return nil, err
+	}
+	var err error
+	if r.DutyDate, err = time.Parse("2006-01-02", dutyDate); err != nil {
+		return nil, fmt.Errorf("could not parse duty date: %w", err)
+	}
+	if r.Deadline, err = time.Parse(time.RFC3339, deadline); err != nil {
+		return nil, fmt.Errorf("could not parse deadline: %w", err)
+	}
+	if coveredBy.Valid {
+		r.CoveredBy = &coveredBy.Int64
+	}
+	if resolvedAt.Valid {
+		at, err := time.Parse(time.RFC3339, resolvedAt.String)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse resolved at: %w", err)
+		}
+		r.ResolvedAt = &at
+	}
+	if r.CreatedAt, err = time.Parse(time.RFC3339, createdAt); err != nil {
+		return nil, fmt.Errorf("could not parse created at: %w", err)
+	}
+	return r, nil
+}
+
+// GetOpenCoverRequest returns the open cover request of a day, or nil if
+// there is none.
+func (s *SQLiteStore) GetOpenCoverRequest(ctx context.Context, date time.Time) (*store.CoverRequest, error) {
+	r, err := scanCoverRequest(s.conn().QueryRowContext(ctx,
+		`SELECT `+coverRequestColumns+` FROM cover_requests WHERE duty_date = ? AND resolved_at IS NULL ORDER BY id DESC LIMIT 1`,
+		date.Format("2006-01-02")))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not get cover request: %w", err)
+	}
+	return r, nil
+}
+
+// ListOpenCoverRequests returns the open cover requests, earliest deadline
+// first.
+func (s *SQLiteStore) ListOpenCoverRequests(ctx context.Context) ([]*store.CoverRequest, error) {
+	rows, err := s.conn().QueryContext(ctx,
+		`SELECT `+coverRequestColumns+` FROM cover_requests WHERE resolved_at IS NULL ORDER BY deadline, id`)
+	if err != nil {
+		return nil, fmt.Errorf("could not query cover requests: %w", err)
+	}
+	defer rows.Close()
+
+	var requests []*store.CoverRequest
+	for rows.Next() {
+		r, err := scanCoverRequest(rows)
+		if err != nil {
+			return nil, fmt.Errorf("could not scan cover request row: %w", err)
+		}
+		requests = append(requests, r)
+	}
+	return requests, rows.Err()
+}
+
+// ResolveCoverRequest closes the cover request, taken over by coveredBy or by
+// nobody if it is nil.
+func (s *SQLiteStore) ResolveCoverRequest(ctx context.Context, id int64, coveredBy *int64, at time.Time) error {
+	_, err := s.conn().ExecContext(ctx, `UPDATE cover_requests SET covered_by = ?, resolved_at = ? WHERE id = ?`,
+		coveredBy, at.UTC().Format(time.RFC3339), id)
+	if err != nil {
+		return fmt.Errorf("could not resolve cover request: %w", err)
+	}
+	return nil
+}
+
+// ReplaceWasteCollections replaces the imported waste-collection schedule.
+func (s *SQLiteStore) ReplaceWasteCollections(ctx context.Context, collections []*store.WasteCollection) error {
+	tx, err := s.begin(ctx)
+	if err != nil {
+		return fmt.Errorf("could not begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM waste_collections`); err != nil {
+		return fmt.Errorf("could not clear waste collections: %w", err)
+	}
+	for _, c := range collections {
+		if _, err := tx.ExecContext(ctx, `INSERT OR IGNORE INTO waste_collections (date, bin) VALUES (?, ?)`,
+			c.Date.Format("2006-01-02"), c.Bin); err != nil {
+			return fmt.Errorf("could not insert waste collection: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("could not commit waste collections: %w", err)
+	}
+	return nil
+}
+
+// GetWasteCollections returns the waste collections from start up to, but
+// not including, end, ordered by date and bin.
+func (s *SQLiteStore) GetWasteCollections(ctx context.Context, start, end time.Time) ([]*store.WasteCollection, error) {
+	rows, err := s.conn().QueryContext(ctx, `SELECT date, bin FROM waste_collections WHERE date >= ? AND date < ? ORDER BY date, bin`,
+		start.Format("2006-01-02"), end.Format("2006-01-02"))
 	if err != nil {
-		return fmt.Errorf("could not complete duty: %w", err)
+		return nil, fmt.Errorf("could not query waste collections: %w", err)
+	}
+	defer rows.Close()
+
+	var collections []*store.WasteCollection
+	for rows.Next() {
+		c := &store.WasteCollection{}
+		var date string
+		if err := rows.Scan(&date, &c.Bin); err != nil {
+			return nil, fmt.Errorf("could not scan waste collection row: %w", err)
+		}
+		if c.Date, err = time.Parse("2006-01-02", date); err != nil {
+			return nil, fmt.Errorf("could not parse collection date: %w", err)
+		}
+		collections = append(collections, c)
+	}
+	return collections, nil
+}
+
+// GetRoundRobinStates returns the users a rotation picked before, least
+// recently picked first.
+func (s *SQLiteStore) GetRoundRobinStates(ctx context.Context, rotation string) ([]*store.RoundRobinState, error) {
+	rows, err := s.conn().QueryContext(ctx, `
+		SELECT rotation, user_id, assignment_count, last_assigned_at
+		FROM round_robin_state WHERE rotation = ? ORDER BY last_assigned_at, user_id`, rotation)
+	if err != nil {
+		return nil, fmt.Errorf("could not query round-robin state: %w", err)
+	}
+	defer rows.Close()
+
+	var states []*store.RoundRobinState
+	for rows.Next() {
+		st := &store.RoundRobinState{}
+		var lastAssigned string
+		if err := rows.Scan(&st.Rotation, &st.UserID, &st.AssignmentCount, &lastAssigned); err != nil {
+			return nil, fmt.Errorf("could not scan round-robin state row: %w", err)
+		}
+		if st.LastAssignedTimestamp, err = time.Parse(time.RFC3339, lastAssigned); err != nil {
+			return nil, fmt.Errorf("could not parse last assigned at: %w", err)
+		}
+		states = append(states, st)
+	}
+	return states, nil
+}
+
+// RecordRoundRobinPick moves a rotation's cursor: it counts one more pick of
+// the user, made at the given time.
+func (s *SQLiteStore) RecordRoundRobinPick(ctx context.Context, rotation string, userID int64, at time.Time) error {
+	query := `
+		INSERT INTO round_robin_state (rotation, user_id, assignment_count, last_assigned_at) VALUES (?, ?, 1, ?)
+		ON CONFLICT (rotation, user_id) DO UPDATE SET
+			assignment_count = assignment_count + 1,
+			last_assigned_at = excluded.last_assigned_at`
+	if _, err := s.conn().ExecContext(ctx, query, rotation, userID, at.UTC().Format(time.RFC3339)); err != nil {
+		return fmt.Errorf("could not record round-robin pick: %w", err)
+	}
+	return nil
+}
+
+// CompleteDuty marks a duty as completed by setting completed_at timestamp.
+// A duty that is already completed keeps its timestamp.
+func (s *SQLiteStore) CompleteDuty(ctx context.Context, date time.Time) (bool, error) {
+	tx, err := s.begin(ctx)
+	if err != nil {
+		return false, fmt.Errorf("could not begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	previous, err := queryTally(ctx, tx, date.Format("2006-01-02"))
+	if err != nil {
+		return false, err
+	}
+	query := `UPDATE duties SET completed_at = ?, status = 'completed' WHERE duty_date = ? AND completed_at IS NULL`
+	res, err := tx.ExecContext(ctx, query, time.Now().UTC().Format(time.RFC3339), date.Format("2006-01-02"))
+	if err != nil {
+		return false, fmt.Errorf("could not complete duty: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("could not count completed duties: %w", err)
+	}
+	if n == 0 {
+		return false, nil
+	}
+	completed := previous
+	completed.status = store.DutyStatusCompleted
+	if err := recountDuty(ctx, tx, previous, completed); err != nil {
+		return false, err
+	}
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("could not commit completed duty: %w", err)
+	}
+	return true, nil
+}
+
+// MarkDutyAnnounced records when the group was told about the duty on a date.
+func (s *SQLiteStore) MarkDutyAnnounced(ctx context.Context, date, at time.Time) error {
+	query := `UPDATE duties SET announced_at = ? WHERE duty_date = ?`
+	if _, err := s.conn().ExecContext(ctx, query, at.UTC().Format(time.RFC3339), date.Format("2006-01-02")); err != nil {
+		return fmt.Errorf("could not mark duty announced: %w", err)
 	}
 	return nil
 }
@@ -607,14 +3038,14 @@ func (s *SQLiteStore) GetTodaysDuty(ctx context.Context) (*store.Duty, error) {
 // GetCompletedDutiesInRange retrieves all completed duties in a date range.
 func (s *SQLiteStore) GetCompletedDutiesInRange(ctx context.Context, start, end time.Time) ([]*store.Duty, error) {
 	query := `
-		SELECT d.id, d.user_id, d.duty_date, d.assignment_type, d.created_at, d.completed_at,
-		       u.id, u.telegram_user_id, u.first_name, u.is_admin, u.is_active
+		SELECT d.id, d.user_id, d.duty_date, d.assignment_type, d.created_at, d.completed_at, d.backfilled_at,
+		       u.id, u.telegram_user_id, u.first_name, u.is_admin, u.is_active, u.emoji, u.privacy, u.alias
 		FROM duties d
 		JOIN users u ON d.user_id = u.id
 		WHERE d.duty_date >= ? AND d.duty_date < ? AND d.completed_at IS NOT NULL
 		ORDER BY d.duty_date
 	`
-	rows, err := s.db.QueryContext(ctx, query, start.Format("2006-01-02"), end.Format("2006-01-02"))
+	rows, err := s.conn().QueryContext(ctx, query, start.Format("2006-01-02"), end.Format("2006-01-02"))
 	if err != nil {
 		return nil, fmt.Errorf("could not query completed duties: %w", err)
 	}
@@ -624,9 +3055,10 @@ func (s *SQLiteStore) GetCompletedDutiesInRange(ctx context.Context, start, end
 	for rows.Next() {
 		duty := &store.Duty{User: &store.User{}}
 		var dutyDateStr, assignmentTypeStr, createdAtStr, completedAtStr string
+		var backfilledAtStr sql.NullString
 		err := rows.Scan(
-			&duty.ID, &duty.UserID, &dutyDateStr, &assignmentTypeStr, &createdAtStr, &completedAtStr,
-			&duty.User.ID, &duty.User.TelegramUserID, &duty.User.FirstName, &duty.User.IsAdmin, &duty.User.IsActive,
+			&duty.ID, &duty.UserID, &dutyDateStr, &assignmentTypeStr, &createdAtStr, &completedAtStr, &backfilledAtStr,
+			&duty.User.ID, &duty.User.TelegramUserID, &duty.User.FirstName, &duty.User.IsAdmin, &duty.User.IsActive, &duty.User.Emoji, &duty.User.Privacy, &duty.User.Alias,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("could not scan completed duty row: %w", err)
@@ -644,8 +3076,220 @@ func (s *SQLiteStore) GetCompletedDutiesInRange(ctx context.Context, start, end
 			return nil, fmt.Errorf("could not parse completed at: %w", err)
 		}
 		duty.CompletedAt = &t
+		if duty.BackfilledAt, err = parseBackfilledAt(backfilledAtStr); err != nil {
+			return nil, err
+		}
 		duty.AssignmentType = store.AssignmentType(assignmentTypeStr)
 		duties = append(duties, duty)
 	}
 	return duties, nil
-}
\ No newline at end of file
+}
+
+// parseBackfilledAt parses the optional backfilled_at column of a duty.
+func parseBackfilledAt(ns sql.NullString) (*time.Time, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, ns.String)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse backfilled at: %w", err)
+	}
+	return &t, nil
+}
+
+// parseAnnouncedAt parses the optional announced_at column of a duty.
+func parseAnnouncedAt(ns sql.NullString) (*time.Time, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, ns.String)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse announced at: %w", err)
+	}
+	return &t, nil
+}
+
+// formatHoldUntil formats the optional hold_until column of a duty.
+func formatHoldUntil(t *time.Time) interface{} {
+	if t == nil {
+		return nil
+	}
+	return t.Format("2006-01-02")
+}
+
+// parseHoldUntil parses the optional hold_until column of a duty.
+func parseHoldUntil(ns sql.NullString) (*time.Time, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	t, err := time.Parse("2006-01-02", ns.String)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse hold until: %w", err)
+	}
+	return &t, nil
+}
+
+// GetExpiredHolds returns the uncompleted duties whose hold ended before
+// today, ordered by date.
+func (s *SQLiteStore) GetExpiredHolds(ctx context.Context, today time.Time) ([]*store.Duty, error) {
+	rows, err := s.conn().QueryContext(ctx, `
+		SELECT duty_date FROM duties
+		WHERE hold_until IS NOT NULL AND hold_until < ? AND completed_at IS NULL
+		ORDER BY duty_date`, today.Format("2006-01-02"))
+	if err != nil {
+		return nil, fmt.Errorf("could not query expired holds: %w", err)
+	}
+	var dates []time.Time
+	for rows.Next() {
+		var dateStr string
+		if err := rows.Scan(&dateStr); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("could not scan expired hold: %w", err)
+		}
+		date, err := time.Parse("2006-01-02", dateStr)
+		if err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("could not parse duty date: %w", err)
+		}
+		dates = append(dates, date)
+	}
+	rows.Close()
+
+	var duties []*store.Duty
+	for _, date := range dates {
+		duty, err := s.GetDutyByDate(ctx, date)
+		if err != nil {
+			return nil, err
+		}
+		if duty != nil {
+			duties = append(duties, duty)
+		}
+	}
+	return duties, nil
+}
+
+// MarkMissedDuties marks the announced and acknowledged duties before the
+// given date as missed and returns how many there were.
+func (s *SQLiteStore) MarkMissedDuties(ctx context.Context, before time.Time) (int, error) {
+	tx, err := s.begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("could not begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Neither status counts towards the stats, so they only gain the misses
+	_, err = tx.ExecContext(ctx, `
+		UPDATE user_stats SET missed = missed + (
+			SELECT COUNT(*) FROM duties d
+			WHERE d.user_id = user_stats.user_id AND d.duty_date < ? AND d.status IN ('announced', 'acknowledged'))`,
+		before.Format("2006-01-02"))
+	if err != nil {
+		return 0, fmt.Errorf("could not update user stats: %w", err)
+	}
+	res, err := tx.ExecContext(ctx, `
+		UPDATE duties SET status = 'missed'
+		WHERE duty_date < ? AND status IN ('announced', 'acknowledged')`, before.Format("2006-01-02"))
+	if err != nil {
+		return 0, fmt.Errorf("could not mark missed duties: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("could not count missed duties: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("could not commit missed duties: %w", err)
+	}
+	return int(n), nil
+}
+
+// BackfillDuty records who actually did the duty on a past date. It creates a
+// completed voluntary duty if the day had none, or hands an existing duty to
+// userID, completing it if needed. Either way the duty is marked as backfilled.
+func (s *SQLiteStore) BackfillDuty(ctx context.Context, date time.Time, userID int64, at time.Time) (*store.Duty, error) {
+	dateStr := date.Format("2006-01-02")
+	atStr := at.UTC().Format(time.RFC3339)
+
+	tx, err := s.begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("could not begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	previous, err := queryTally(ctx, tx, dateStr)
+	if err != nil {
+		return nil, err
+	}
+	assignmentType := store.AssignmentTypeVoluntary
+	switch {
+	case previous.userID == 0:
+		query := `INSERT INTO duties (user_id, duty_date, assignment_type, created_at, completed_at, backfilled_at, status) VALUES (?, ?, ?, ?, ?, ?, 'completed')`
+		if _, err := tx.ExecContext(ctx, query, userID, dateStr, string(assignmentType), atStr, atStr, atStr); err != nil {
+			return nil, fmt.Errorf("could not insert backfilled duty: %w", err)
+		}
+	default:
+		assignmentType = previous.assignmentType
+		query := `UPDATE duties SET user_id = ?, completed_at = COALESCE(completed_at, ?), backfilled_at = ?, status = 'completed' WHERE duty_date = ?`
+		if _, err := tx.ExecContext(ctx, query, userID, atStr, atStr, dateStr); err != nil {
+			return nil, fmt.Errorf("could not update backfilled duty: %w", err)
+		}
+	}
+
+	if err := recountDuty(ctx, tx, previous, tally{userID, store.DutyStatusCompleted, assignmentType}); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("could not commit backfilled duty: %w", err)
+	}
+	return s.GetDutyByDate(ctx, date)
+}
+
+// CreateDutyChange appends to the schedule change log and sets the ID.
+func (s *SQLiteStore) CreateDutyChange(ctx context.Context, change *store.DutyChange) error {
+	query := `INSERT INTO duty_changes (duty_date, user_id, action, assignment_type, changed_at) VALUES (?, ?, ?, ?, ?)`
+	res, err := s.conn().ExecContext(ctx, query, change.DutyDate.Format("2006-01-02"), change.UserID, string(change.Action), string(change.AssignmentType),
+		change.ChangedAt.UTC().Format(time.RFC3339))
+	if err != nil {
+		return fmt.Errorf("could not record duty change: %w", err)
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("could not get last insert ID for duty change: %w", err)
+	}
+	change.ID = id
+	return nil
+}
+
+// GetRecentDutyChanges returns the most recent schedule changes, newest first.
+func (s *SQLiteStore) GetRecentDutyChanges(ctx context.Context, limit int) ([]*store.DutyChange, error) {
+	query := `
+		SELECT c.id, c.duty_date, c.user_id, COALESCE(u.first_name, ''), c.action, c.assignment_type, c.changed_at
+		FROM duty_changes c
+		LEFT JOIN users u ON c.user_id = u.id
+		ORDER BY c.changed_at DESC, c.id DESC
+		LIMIT ?
+	`
+	rows, err := s.conn().QueryContext(ctx, query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("could not query duty changes: %w", err)
+	}
+	defer rows.Close()
+
+	var changes []*store.DutyChange
+	for rows.Next() {
+		change := &store.DutyChange{}
+		var dutyDateStr, actionStr, assignmentTypeStr, changedAtStr string
+		if err := rows.Scan(&change.ID, &dutyDateStr, &change.UserID, &change.UserName, &actionStr, &assignmentTypeStr, &changedAtStr); err != nil {
+			return nil, fmt.Errorf("could not scan duty change: %w", err)
+		}
+		if change.DutyDate, err = time.Parse("2006-01-02", dutyDateStr); err != nil {
+			return nil, fmt.Errorf("could not parse duty date: %w", err)
+		}
+		if change.ChangedAt, err = time.Parse(time.RFC3339, changedAtStr); err != nil {
+			return nil, fmt.Errorf("could not parse changed at: %w", err)
+		}
+		change.Action = store.DutyChangeAction(actionStr)
+		change.AssignmentType = store.AssignmentType(assignmentTypeStr)
+		changes = append(changes, change)
+	}
+	return changes, rows.Err()
+}
@@ -2,10 +2,13 @@ package sqlite
 
 import (
 	"context"
+	"database/sql"
+	"path/filepath"
 	"testing"
 	"time"
 
 	"github.com/korjavin/dutyassistant/internal/store"
+	"github.com/korjavin/dutyassistant/internal/store/storetest"
 )
 
 // setupTestDB creates a new in-memory SQLite database for testing.
@@ -160,54 +163,187 @@ func TestDutyLifecycle(t *testing.T) {
 	}
 }
 
-func TestRoundRobin(t *testing.T) {
-	s := setupTestDB(t)
+func TestWithPragmas(t *testing.T) {
+	if got, want := withPragmas("roster.db"), "roster.db?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_pragma=synchronous(NORMAL)&_pragma=foreign_keys(1)"; got != want {
+		t.Errorf("withPragmas() = %q, want %q", got, want)
+	}
+	if got, want := withPragmas(":memory:?_pragma=foreign_keys(0)"), ":memory:?_pragma=foreign_keys(0)&_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_pragma=synchronous(NORMAL)"; got != want {
+		t.Errorf("withPragmas() = %q, want %q", got, want)
+	}
+}
+
+func TestForeignKeys(t *testing.T) {
 	ctx := context.Background()
+	// Without asking for foreign keys, as in production
+	s, err := New(ctx, filepath.Join(t.TempDir(), "roster.db"))
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	defer s.db.Close()
 
-	// Create users
-	user1 := &store.User{TelegramUserID: 1, FirstName: "User1", IsActive: true}
-	user2 := &store.User{TelegramUserID: 2, FirstName: "User2", IsActive: true}
-	user3 := &store.User{TelegramUserID: 3, FirstName: "User3", IsActive: false} // Inactive
-	s.CreateUser(ctx, user1)
-	s.CreateUser(ctx, user2)
-	s.CreateUser(ctx, user3)
+	for _, table := range tables(t, s.db) {
+		keys, err := foreignKeys(ctx, s.db, table)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, fk := range keys {
+			if want, ok := onDelete[table]; !ok || fk.onDelete != want {
+				t.Errorf("%s.%s: ON DELETE %s, want %q from onDelete", table, fk.from, fk.onDelete, want)
+			}
+		}
+	}
 
-	// 1. Get next user (should be user1 as it's the first one)
-	nextUser, err := s.GetNextRoundRobinUser(ctx)
-	if err != nil {
-		t.Fatalf("GetNextRoundRobinUser failed: %v", err)
+	day := time.Date(2023, 10, 27, 0, 0, 0, 0, time.UTC)
+	if err := s.CreateDuty(ctx, &store.Duty{UserID: 999, DutyDate: day, AssignmentType: store.AssignmentTypeVoluntary, CreatedAt: day}); err == nil {
+		t.Error("CreateDuty for a missing user succeeded, want a foreign key error")
+	}
+
+	alice := &store.User{TelegramUserID: 1, FirstName: "Alice", IsActive: true}
+	bob := &store.User{TelegramUserID: 2, FirstName: "Bob", IsActive: true}
+	for _, u := range []*store.User{alice, bob} {
+		if err := s.CreateUser(ctx, u); err != nil {
+			t.Fatalf("CreateUser failed: %v", err)
+		}
+		if err := s.SetNotificationPreferences(ctx, &store.NotificationPreferences{UserID: u.ID, PersonalDM: true, ReminderHour: 11}); err != nil {
+			t.Fatalf("SetNotificationPreferences failed: %v", err)
+		}
+	}
+	if err := s.CreateDuty(ctx, &store.Duty{UserID: alice.ID, DutyDate: day, AssignmentType: store.AssignmentTypeVoluntary, CreatedAt: day}); err != nil {
+		t.Fatalf("CreateDuty failed: %v", err)
+	}
+
+	// A user with duties can't be deleted
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM users WHERE id = ?`, alice.ID); err == nil {
+		t.Error("deleting a user with duties succeeded, want a foreign key error")
 	}
-	if nextUser.ID != user1.ID {
-		t.Errorf("Expected user1 to be next, got user with ID %d", nextUser.ID)
+	// A user without takes their preferences along
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM users WHERE id = ?`, bob.ID); err != nil {
+		t.Fatalf("deleting a user without duties failed: %v", err)
 	}
+	var n int
+	if err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM notification_preferences WHERE user_id = ?`, bob.ID).Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+	if n != 0 {
+		t.Errorf("%d notification preferences left of the deleted user, want 0", n)
+	}
+}
 
-	// 2. Increment user1's count
-	err = s.IncrementAssignmentCount(ctx, user1.ID, time.Now())
+func TestMigrateForeignKeys(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "roster.db")
+
+	// A database from before the foreign keys had ON DELETE actions, with
+	// preferences left behind by a user deleted while they weren't enforced
+	legacy, err := sql.Open("sqlite", path)
 	if err != nil {
-		t.Fatalf("IncrementAssignmentCount failed: %v", err)
-	}
+		t.Fatal(err)
+	}
+	for _, statement := range []string{
+		`CREATE TABLE users (id INTEGER PRIMARY KEY AUTOINCREMENT, telegram_user_id INTEGER UNIQUE NOT NULL, first_name TEXT NOT NULL, is_admin INTEGER NOT NULL DEFAULT 0, is_active INTEGER NOT NULL DEFAULT 1)`,
+		`CREATE TABLE duties (id INTEGER PRIMARY KEY AUTOINCREMENT, user_id INTEGER NOT NULL, duty_date TEXT UNIQUE NOT NULL, assignment_type TEXT NOT NULL, created_at TEXT NOT NULL, FOREIGN KEY(user_id) REFERENCES users(id))`,
+		`CREATE TABLE off_duty_periods (id INTEGER PRIMARY KEY AUTOINCREMENT, user_id INTEGER NOT NULL, start_date TEXT NOT NULL, end_date TEXT NOT NULL, source TEXT NOT NULL, FOREIGN KEY(user_id) REFERENCES users(id))`,
+		`CREATE INDEX idx_off_duty_periods_user ON off_duty_periods(user_id)`,
+		`CREATE TABLE notification_preferences (user_id INTEGER PRIMARY KEY, personal_dm INTEGER NOT NULL DEFAULT 1, FOREIGN KEY(user_id) REFERENCES users(id))`,
+		`INSERT INTO users (id, telegram_user_id, first_name) VALUES (1, 1, 'Alice')`,
+		`INSERT INTO duties (user_id, duty_date, assignment_type, created_at) VALUES (1, '2023-10-27', 'voluntary', '2023-10-26T10:00:00Z')`,
+		`INSERT INTO off_duty_periods (user_id, start_date, end_date, source) VALUES (1, '2023-11-01', '2023-11-05', 'manual')`,
+		`INSERT INTO notification_preferences (user_id, personal_dm) VALUES (1, 0), (2, 0)`,
+	} {
+		if _, err := legacy.ExecContext(ctx, statement); err != nil {
+			t.Fatalf("%s: %v", statement, err)
+		}
+	}
+	legacy.Close()
 
-	// 3. Get next user (should be user2)
-	nextUser, err = s.GetNextRoundRobinUser(ctx)
+	s, err := New(ctx, path)
 	if err != nil {
-		t.Fatalf("GetNextRoundRobinUser failed: %v", err)
+		t.Fatalf("New failed: %v", err)
 	}
-	if nextUser.ID != user2.ID {
-		t.Errorf("Expected user2 to be next, got user with ID %d", nextUser.ID)
+	defer s.db.Close()
+
+	for table, want := range onDelete {
+		keys, err := foreignKeys(ctx, s.db, table)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, fk := range keys {
+			if fk.onDelete != want {
+				t.Errorf("%s.%s: ON DELETE %s, want %s", table, fk.from, fk.onDelete, want)
+			}
+		}
 	}
 
-	// 4. Increment user2's count
-	err = s.IncrementAssignmentCount(ctx, user2.ID, time.Now())
-	if err != nil {
-		t.Fatalf("IncrementAssignmentCount failed: %v", err)
+	duty, err := s.GetDutyByDate(ctx, time.Date(2023, 10, 27, 0, 0, 0, 0, time.UTC))
+	if err != nil || duty == nil || duty.UserID != 1 {
+		t.Errorf("GetDutyByDate = %+v, %v, want the migrated duty", duty, err)
 	}
+	if duty != nil && (duty.AnnouncedAt == nil || !duty.AnnouncedAt.Equal(duty.CreatedAt)) {
+		t.Errorf("AnnouncedAt = %v, want the old duty announced when it was created", duty.AnnouncedAt)
+	}
+	if stats, err := s.GetUserStats(ctx, 1); err != nil || stats.TotalDuties != 1 || stats.Volunteered != 1 {
+		t.Errorf("GetUserStats = %+v, %v, want the old duty counted", stats, err)
+	}
+	var n int
+	if err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM sqlite_master WHERE type = 'index' AND name = 'idx_off_duty_periods_user'`).Scan(&n); err != nil || n != 1 {
+		t.Errorf("index of off_duty_periods: %d, %v, want kept", n, err)
+	}
+	if err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM off_duty_periods`).Scan(&n); err != nil || n != 1 {
+		t.Errorf("off duty periods: %d, %v, want 1", n, err)
+	}
+	if err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM notification_preferences`).Scan(&n); err != nil || n != 1 {
+		t.Errorf("notification preferences: %d, %v, want only Alice's", n, err)
+	}
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM users WHERE id = 1`); err == nil {
+		t.Error("deleting a user with duties succeeded after the migration, want a foreign key error")
+	}
+}
 
-	// 5. Get next user (should be user1 again)
-	nextUser, err = s.GetNextRoundRobinUser(ctx)
-	if err != nil {
-		t.Fatalf("GetNextRoundRobinUser failed: %v", err)
+func TestRebuildUserStats(t *testing.T) {
+	ctx := context.Background()
+	s := setupTestDB(t)
+	alice := &store.User{TelegramUserID: 1, FirstName: "Alice", IsActive: true}
+	if err := s.CreateUser(ctx, alice); err != nil {
+		t.Fatal(err)
 	}
-	if nextUser.ID != user1.ID {
-		t.Errorf("Expected user1 to be next again, got user with ID %d", nextUser.ID)
+	for day := 1; day <= 3; day++ {
+		duty := &store.Duty{UserID: alice.ID, DutyDate: time.Date(2024, 5, day, 0, 0, 0, 0, time.UTC), AssignmentType: store.AssignmentTypeRoundRobin, CreatedAt: time.Now()}
+		if err := s.CreateDuty(ctx, duty); err != nil {
+			t.Fatal(err)
+		}
 	}
-}
\ No newline at end of file
+
+	// The counts drift, say from a duty changed by hand
+	if _, err := s.db.ExecContext(ctx, `UPDATE user_stats SET total_duties = 7, missed = 2`); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.RebuildUserStats(ctx); err != nil {
+		t.Fatalf("RebuildUserStats failed: %v", err)
+	}
+	if stats, err := s.GetUserStats(ctx, alice.ID); err != nil || stats.TotalDuties != 3 || stats.Missed != 0 {
+		t.Errorf("GetUserStats = %+v, %v, want 3 duties and none missed", stats, err)
+	}
+}
+
+// tables returns the names of the database's tables.
+func tables(t *testing.T, db *sql.DB) []string {
+	t.Helper()
+	rows, err := db.Query(`SELECT name FROM sqlite_master WHERE type = 'table' AND name NOT LIKE 'sqlite_%'`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, name)
+	}
+	return names
+}
+
+func TestStoreContract(t *testing.T) {
+	storetest.RunStoreTests(t, func(t *testing.T) store.Store { return setupTestDB(t) })
+}
@@ -0,0 +1,98 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/korjavin/dutyassistant/internal/store"
+)
+
+// querier is what the queries run on: the database, or the transaction of a
+// store handed out by RunInTx.
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// txn is a transaction, or a savepoint within one.
+type txn interface {
+	querier
+	Commit() error
+	Rollback() error
+}
+
+// conn returns what queries run on.
+func (s *SQLiteStore) conn() querier {
+	if s.tx != nil {
+		return s.tx
+	}
+	return s.db
+}
+
+// begin starts a transaction, or a savepoint if the store already runs in one
+// so that its changes can still be undone on their own.
+func (s *SQLiteStore) begin(ctx context.Context) (txn, error) {
+	if s.tx == nil {
+		return s.db.BeginTx(ctx, nil)
+	}
+	name := fmt.Sprintf("sp%d", s.depth+1)
+	if _, err := s.tx.ExecContext(ctx, "SAVEPOINT "+name); err != nil {
+		return nil, err
+	}
+	return &savepoint{Tx: s.tx, ctx: ctx, name: name}, nil
+}
+
+// savepoint is a nested transaction. Like a transaction, rolling it back
+// after it was committed does nothing.
+type savepoint struct {
+	*sql.Tx
+	ctx  context.Context
+	name string
+	done bool
+}
+
+func (sp *savepoint) Commit() error {
+	if sp.done {
+		return sql.ErrTxDone
+	}
+	sp.done = true
+	_, err := sp.ExecContext(sp.ctx, "RELEASE "+sp.name)
+	return err
+}
+
+func (sp *savepoint) Rollback() error {
+	if sp.done {
+		return sql.ErrTxDone
+	}
+	sp.done = true
+	if _, err := sp.ExecContext(sp.ctx, "ROLLBACK TO "+sp.name); err != nil {
+		return err
+	}
+	_, err := sp.ExecContext(sp.ctx, "RELEASE "+sp.name)
+	return err
+}
+
+// RunInTx calls fn with a store whose changes are committed together if fn
+// returns nil and rolled back otherwise. Called on such a store, it nests
+// with a savepoint.
+func (s *SQLiteStore) RunInTx(ctx context.Context, fn func(tx store.Store) error) error {
+	t, err := s.begin(ctx)
+	if err != nil {
+		return fmt.Errorf("could not begin transaction: %w", err)
+	}
+	defer t.Rollback()
+
+	inner := &SQLiteStore{db: s.db, tx: s.tx, depth: s.depth + 1}
+	if sqlTx, ok := t.(*sql.Tx); ok {
+		inner.tx = sqlTx
+	}
+	if err := fn(inner); err != nil {
+		return err
+	}
+	if err := t.Commit(); err != nil {
+		return fmt.Errorf("could not commit transaction: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/korjavin/dutyassistant/internal/store"
+)
+
+// tally is what a duty counts towards in its user's stats.
+type tally struct {
+	userID         int64
+	status         store.DutyStatus
+	assignmentType store.AssignmentType
+}
+
+// queryTally returns the tally of the duty on dateStr, or the zero tally if
+// the day has no duty.
+func queryTally(ctx context.Context, q querier, dateStr string) (tally, error) {
+	var t tally
+	err := q.QueryRowContext(ctx, `SELECT user_id, status, assignment_type FROM duties WHERE duty_date = ?`, dateStr).
+		Scan(&t.userID, &t.status, &t.assignmentType)
+	if err != nil && err != sql.ErrNoRows {
+		return tally{}, fmt.Errorf("could not query current duty: %w", err)
+	}
+	return t, nil
+}
+
+// countDuty adds the duty's tally to its user's stats, or takes it off for a
+// negative sign.
+func countDuty(ctx context.Context, q querier, t tally, sign int) error {
+	if t.userID == 0 {
+		return nil
+	}
+	var completed, missed, volunteered int
+	if t.status == store.DutyStatusCompleted {
+		completed = sign
+	}
+	if t.status == store.DutyStatusMissed {
+		missed = sign
+	}
+	if t.assignmentType == store.AssignmentTypeVoluntary {
+		volunteered = sign
+	}
+	_, err := q.ExecContext(ctx, `
+		INSERT INTO user_stats (user_id, total_duties, completed, missed, volunteered) VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(user_id) DO UPDATE SET
+			total_duties = total_duties + excluded.total_duties,
+			completed = completed + excluded.completed,
+			missed = missed + excluded.missed,
+			volunteered = volunteered + excluded.volunteered`,
+		t.userID, sign, completed, missed, volunteered)
+	if err != nil {
+		return fmt.Errorf("could not update user stats: %w", err)
+	}
+	return nil
+}
+
+// recountDuty moves a duty's count in the stats from what it was before a
+// change to what it is after.
+func recountDuty(ctx context.Context, q querier, before, after tally) error {
+	if before == after {
+		return nil
+	}
+	if err := countDuty(ctx, q, before, -1); err != nil {
+		return err
+	}
+	return countDuty(ctx, q, after, 1)
+}
+
+// rebuildUserStats counts the stats of all users again from their duties.
+func rebuildUserStats(ctx context.Context, q querier) error {
+	statements := []string{
+		`DELETE FROM user_stats`,
+		`INSERT INTO user_stats (user_id, total_duties, completed, missed, volunteered)
+		 SELECT user_id, COUNT(*), SUM(status = 'completed'), SUM(status = 'missed'), SUM(assignment_type = 'voluntary')
+		 FROM duties GROUP BY user_id`,
+	}
+	for _, statement := range statements {
+		if _, err := q.ExecContext(ctx, statement); err != nil {
+			return fmt.Errorf("could not rebuild user stats: %w", err)
+		}
+	}
+	return nil
+}
+
+// RebuildUserStats counts the stats of all users again from their duties, in
+// case the counts kept as duties change went wrong.
+func (s *SQLiteStore) RebuildUserStats(ctx context.Context) error {
+	tx, err := s.begin(ctx)
+	if err != nil {
+		return fmt.Errorf("could not begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := rebuildUserStats(ctx, tx); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("could not commit user stats: %w", err)
+	}
+	return nil
+}
+
+// migrateUserStats counts the stats of the duties from before they were kept.
+func (s *SQLiteStore) migrateUserStats(ctx context.Context) error {
+	var stale bool
+	err := s.conn().QueryRowContext(ctx,
+		`SELECT EXISTS (SELECT 1 FROM duties) AND NOT EXISTS (SELECT 1 FROM user_stats)`).Scan(&stale)
+	if err != nil {
+		return fmt.Errorf("could not check user stats: %w", err)
+	}
+	if !stale {
+		return nil
+	}
+	return s.RebuildUserStats(ctx)
+}
@@ -3,7 +3,10 @@ package store
 
 import (
 	"context"
+	"strconv"
+	"strings"
 	"time"
+	"unicode"
 )
 
 // AssignmentType defines the type of duty assignment.
@@ -16,13 +19,102 @@ const (
 	AssignmentTypeVoluntary AssignmentType = "voluntary"
 	// AssignmentTypeAdmin is for duties assigned by an administrator.
 	AssignmentTypeAdmin AssignmentType = "admin"
+	// AssignmentTypeExternal is for days covered by outside help. The duty is
+	// recorded on the admin who arranged it.
+	AssignmentTypeExternal AssignmentType = "external"
 )
 
-// User represents a user in the system.
+// DutyStatus is where a duty is in its lifecycle: planned ahead, announced
+// when assigned for real, acknowledged by the user on duty and finally
+// completed or missed.
+type DutyStatus string
+
+const (
+	// DutyStatusProvisional is for duties planned ahead. Only these may be
+	// moved silently when queues or availability change.
+	DutyStatusProvisional DutyStatus = "provisional"
+	// DutyStatusAnnounced is for duties assigned for real and announced.
+	DutyStatusAnnounced DutyStatus = "announced"
+	// DutyStatusAcknowledged is for duties the user on duty confirmed.
+	DutyStatusAcknowledged DutyStatus = "acknowledged"
+	// DutyStatusCompleted is for duties that were done.
+	DutyStatusCompleted DutyStatus = "completed"
+	// DutyStatusMissed is for duties whose day passed without them being completed.
+	DutyStatusMissed DutyStatus = "missed"
+)
+
+// InitialStatus returns the status a duty created without one is stored
+// with: completed if it has a completion time, announced otherwise.
+func InitialStatus(d *Duty) DutyStatus {
+	switch {
+	case d.Status != "":
+		return d.Status
+	case d.CompletedAt != nil:
+		return DutyStatusCompleted
+	default:
+		return DutyStatusAnnounced
+	}
+}
+
+// Pool is which days of the week a user is on the roster for.
+type Pool string
+
+const (
+	// PoolAll is for users on duty any day.
+	PoolAll Pool = ""
+	// PoolWeekdays is for users only on duty from Monday to Friday.
+	PoolWeekdays Pool = "weekdays"
+	// PoolWeekends is for users only on duty on Saturdays and Sundays.
+	PoolWeekends Pool = "weekends"
+)
+
+// Privacy is how messages everyone in the group can see name a user. Private
+// chats and signed-in views always use their name.
+type Privacy string
+
+const (
+	// PrivacyOff names the user by their name.
+	PrivacyOff Privacy = ""
+	// PrivacyInitials names the user by the initials of their name, e.g. "M. A."
+	PrivacyInitials Privacy = "initials"
+	// PrivacyAlias names the user by the alias they picked.
+	PrivacyAlias Privacy = "alias"
+)
+
+// IsWeekend reports whether day is a Saturday or Sunday.
+func IsWeekend(day time.Time) bool {
+	return day.Weekday() == time.Saturday || day.Weekday() == time.Sunday
+}
+
+// Covers reports whether a user in the pool can be on duty on day.
+func (p Pool) Covers(day time.Time) bool {
+	switch p {
+	case PoolWeekdays:
+		return !IsWeekend(day)
+	case PoolWeekends:
+		return IsWeekend(day)
+	default:
+		return true
+	}
+}
+
+// User represents a user in the system. FirstName is the display name; it
+// follows the Telegram name unless an admin renamed the user. Handle is
+// derived from the first display name and doesn't change, so commands
+// referring to a user keep working after a rename.
 type User struct {
 	ID                 int64
 	TelegramUserID     int64
+	Handle             string
 	FirstName          string
+	CustomName         bool    // Set by /rename, the Telegram name no longer overwrites FirstName
+	IsJunior           bool    // Set by /junior, limits the user to the kid-friendly commands
+	Emoji              string  // Picked with /me emoji, marks the user in calendars and announcements
+	Pool               Pool    // Set by /pool, the days of the week the user is on the roster for
+	IsPending          bool    // Waiting for an admin to approve the registration, never on duty meanwhile
+	Timezone           string  // IANA zone set by /me timezone, personal reminders follow it; "" is the household's
+	Privacy            Privacy // Set by /me privacy, how group messages name the user
+	Alias              string  // The name group messages use with PrivacyAlias
 	IsAdmin            bool
 	IsActive           bool
 	VolunteerQueueDays int
@@ -31,6 +123,79 @@ type User struct {
 	OffDutyEnd         *time.Time
 }
 
+// Label is the user's display name, after their emoji if they picked one.
+func (u *User) Label() string {
+	if u.Emoji == "" {
+		return u.FirstName
+	}
+	return u.Emoji + " " + u.FirstName
+}
+
+// GroupName is how messages everyone in the group can see name the user: their
+// name, its initials or their alias, depending on their Privacy.
+func (u *User) GroupName() string {
+	switch {
+	case u.Privacy == PrivacyInitials:
+		return Initials(u.FirstName)
+	case u.Privacy == PrivacyAlias && u.Alias != "":
+		return u.Alias
+	default:
+		return u.FirstName
+	}
+}
+
+// GroupLabel is the user's GroupName, after their emoji if they picked one.
+func (u *User) GroupLabel() string {
+	if u.Emoji == "" {
+		return u.GroupName()
+	}
+	return u.Emoji + " " + u.GroupName()
+}
+
+// Initials returns the first letter of every word of name followed by a dot,
+// e.g. "M. A." for "Mary Ann", or name itself if it has no letters.
+func Initials(name string) string {
+	var initials []string
+	for _, word := range strings.Fields(name) {
+		for _, r := range word {
+			if unicode.IsLetter(r) {
+				initials = append(initials, string(unicode.ToUpper(r))+".")
+				break
+			}
+		}
+	}
+	if len(initials) == 0 {
+		return name
+	}
+	return strings.Join(initials, " ")
+}
+
+// HandleFor turns a display name into a handle: its letters and digits in
+// lower case, or "user" if it has none.
+func HandleFor(name string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(name) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+		}
+	}
+	if b.Len() == 0 {
+		return "user"
+	}
+	return b.String()
+}
+
+// UniqueHandle returns the handle for name, with a number appended if taken
+// says it already belongs to someone else.
+func UniqueHandle(name string, taken func(handle string) bool) string {
+	base := HandleFor(name)
+	handle := base
+	for n := 2; taken(handle); n++ {
+		handle = base + strconv.Itoa(n)
+	}
+	return handle
+}
+
 // Duty represents a duty assignment in the system.
 type Duty struct {
 	ID             int64
@@ -39,55 +204,758 @@ type Duty struct {
 	AssignmentType AssignmentType
 	CreatedAt      time.Time
 	CompletedAt    *time.Time
-	User           *User // Used to join user data
+	CompletionBy   int64      // User who last set or cleared CompletedAt by hand, 0 if only the 21:00 job did
+	Published      bool       // Part of a month plan an admin published with /publish
+	BackfilledAt   *time.Time // Set when an admin recorded or corrected the duty after the fact
+	HoldUntil      *time.Time // Set on a manual override that is released unless confirmed by this day
+	Note           string     // Context for whoever is on duty, e.g. "guests for dinner"
+	HandoffNote    string     // Left by whoever is on duty for whoever is next, e.g. "dishwasher tabs almost out"
+	Status         DutyStatus // Where the duty is in its lifecycle, see InitialStatus for the default
+	AnnouncedAt    *time.Time // Set when the group was told about the duty on its day, see MarkDutyAnnounced
+	User           *User      // Used to join user data
+}
+
+// SkipReason explains why a day deliberately has no duty.
+type SkipReason string
+
+const (
+	SkipReasonHoliday   SkipReason = "holiday"
+	SkipReasonEatingOut SkipReason = "eating_out"
+	SkipReasonAway      SkipReason = "away"
+	// SkipReasonBlackout is the reason of days a blackout rule matches. It
+	// is never stored, the rules are.
+	SkipReasonBlackout SkipReason = "blackout"
+)
+
+// SkipReasons lists the valid skip reasons.
+var SkipReasons = []SkipReason{SkipReasonHoliday, SkipReasonEatingOut, SkipReasonAway}
+
+// SkipDay is a date an admin marked as "no duty". The daily assignment leaves
+// it alone and calendars show it as a deliberate skip.
+type SkipDay struct {
+	Date      time.Time
+	Reason    SkipReason
+	CreatedAt time.Time
+}
+
+// BlackoutRule keeps the whole household off duty on the days its rule
+// matches every year, e.g. on Dec 24 and 25. Unlike skip days, the days
+// aren't stored one by one.
+type BlackoutRule struct {
+	ID          int64
+	Rule        string // A day rule, see scheduler.ParseBlackout
+	Description string
+	CreatedAt   time.Time
+}
+
+// ScheduleVersion is a snapshot of a month's schedule, taken whenever it
+// changed, so earlier versions can be looked at and compared.
+type ScheduleVersion struct {
+	ID        int64
+	Month     time.Time     // First day of the month
+	Version   int           // Counting up from 1 within the month
+	Days      []ScheduleDay // The days with a duty or skipped, by date
+	CreatedAt time.Time
+}
+
+// ScheduleDay is a day of a schedule snapshot: its duty, or why it has none.
+type ScheduleDay struct {
+	Date           time.Time
+	UserID         int64 // 0 on skip days
+	AssignmentType AssignmentType
+	Status         DutyStatus
+	SkipReason     SkipReason // Set on skip days
 }
 
-// RoundRobinState represents the state of the round-robin algorithm for a user.
+// RoundRobinState is a user's place in a rotation: how often the rotation
+// picked them and when it last did. The scheduler keeps one rotation per
+// assignment type and breaks ties in favour of whoever it picked least recently.
 type RoundRobinState struct {
+	Rotation              string // e.g. "round_robin" or "voluntary"
 	UserID                int64
 	AssignmentCount       int
 	LastAssignedTimestamp time.Time
 }
 
+// OffDutySourceICal marks off-duty periods imported from a linked iCal calendar.
+const OffDutySourceICal = "ical"
+
+// OffDutySourceImport marks off-duty periods an admin imported in bulk with
+// /offduty_import, like school holidays.
+const OffDutySourceImport = "import"
+
+// OffDutyPeriod is a dated absence imported from an external source, such as a
+// linked iCal calendar. It complements the manual off-duty window on User.
+type OffDutyPeriod struct {
+	ID         int64
+	UserID     int64
+	StartDate  time.Time
+	EndDate    time.Time // Inclusive
+	Source     string    // e.g. "ical"
+	ExternalID string    // Source-specific identifier (iCal UID)
+	Summary    string
+}
+
+// CalendarLink is an external iCal URL a user has linked for availability sync.
+type CalendarLink struct {
+	UserID       int64
+	URL          string
+	LastSyncedAt *time.Time
+	LastError    string
+}
+
+// DutyChangeAction describes what happened to a duty in the change log.
+type DutyChangeAction string
+
+const (
+	DutyChangeAssigned   DutyChangeAction = "assigned"
+	DutyChangeReassigned DutyChangeAction = "reassigned"
+	DutyChangeRemoved    DutyChangeAction = "removed"
+	DutyChangeBackfilled DutyChangeAction = "backfilled"
+)
+
+// DutyChange is an entry in the schedule change log, recorded by the history
+// service whenever the scheduler assigns, hands over, removes or backfills a
+// duty.
+type DutyChange struct {
+	ID             int64
+	DutyDate       time.Time
+	UserID         int64
+	UserName       string
+	Action         DutyChangeAction
+	AssignmentType AssignmentType
+	ChangedAt      time.Time
+}
+
+// Default notification settings for users who never opened /notifications.
+const (
+	DefaultReminderHour  = 11 // Berlin time, right after the daily assignment
+	EarliestReminderHour = 11
+	LatestReminderHour   = 20
+)
+
+// NotificationPreferences controls which bot notifications a user receives
+// privately and when the daily ones are delivered.
+type NotificationPreferences struct {
+	UserID        int64
+	GroupReminder bool // daily reminder of who is on duty, like the group announcement
+	PersonalDM    bool // reminder on days the user is on duty
+	WeeklyStats   bool // Sunday weekly report
+	SwapRequests  bool // requests from others to swap duties
+	ReminderHour  int  // Berlin hour for the daily notifications
+}
+
+// DefaultNotificationPreferences returns the settings used until a user changes them.
+func DefaultNotificationPreferences(userID int64) *NotificationPreferences {
+	return &NotificationPreferences{
+		UserID:       userID,
+		PersonalDM:   true,
+		WeeklyStats:  true,
+		SwapRequests: true,
+		ReminderHour: DefaultReminderHour,
+	}
+}
+
+// ChangeSubscription asks for a private message whenever one of the user's
+// duties is assigned, moved away or released.
+type ChangeSubscription struct {
+	UserID    int64
+	CreatedAt time.Time
+}
+
+// ReminderSnooze is a personal duty reminder a user postponed. It is stored so
+// the reminder survives a restart of the bot.
+type ReminderSnooze struct {
+	ID       int64
+	UserID   int64
+	DutyDate time.Time // The duty the reminder is about
+	RemindAt time.Time
+}
+
+// PendingMessage is a message the bot could not send before it stopped. It is
+// stored so it can be sent on the next start.
+type PendingMessage struct {
+	ID        int64
+	ChatID    int64
+	Text      string
+	CreatedAt time.Time
+}
+
+// InteractiveMessage is a message with buttons the bot sent in reply to a
+// command, such as a user picker or a calendar. It is tracked so that it can
+// be cleaned up once its interaction is done or abandoned.
+type InteractiveMessage struct {
+	ChatID    int64
+	MessageID int
+	SentAt    time.Time
+	DoneAt    *time.Time // When its interaction completed, nil while it is open
+}
+
+// ConversationState is a step of an interactive flow the bot is in the
+// middle of, like who opened a menu or an import waiting to be confirmed. It
+// is stored so the flow survives a restart, until it expires.
+type ConversationState struct {
+	Key       string // What the state is of, like "menu:<chat>:<message>"
+	Value     string // Encoded by whoever keeps the state, usually as JSON
+	ExpiresAt time.Time
+}
+
+// ChatDisplay is how a chat wants its calendars drawn.
+type ChatDisplay struct {
+	WeekNumbers  bool // ISO week numbers in a column left of the days
+	AdjacentDays bool // The days of the previous and next month, greyed out
+}
+
+// MaintenanceRun records a run of the weekly database maintenance.
+type MaintenanceRun struct {
+	ID         int64
+	RanAt      time.Time
+	SizeBefore int64 // Bytes the database took before the run
+	SizeAfter  int64 // And after it
+	Deleted    int   // Log rows removed for being older than the retention
+}
+
+// ShadowComparison records who a shadow strategy would have picked for a
+// round-robin day next to who the live strategy actually picked.
+type ShadowComparison struct {
+	ID           int64
+	Date         time.Time
+	Strategy     string // Name of the shadow strategy
+	LiveUserID   int64
+	ShadowUserID int64
+	CreatedAt    time.Time
+}
+
+// AssignmentExplanation records why the daily assignment picked who it did
+// on a day: whom it considered, why some of them couldn't be picked, and how
+// the winner was chosen among the rest.
+type AssignmentExplanation struct {
+	Date           time.Time
+	UserID         int64 // Who was picked
+	AssignmentType AssignmentType
+	Strategy       string // Name of the round-robin strategy
+	Reason         string // Why the winner was picked, in words
+	Candidates     []AssignmentCandidate
+	CreatedAt      time.Time
+}
+
+// AssignmentCandidate is a user the daily assignment considered.
+type AssignmentCandidate struct {
+	UserID             int64
+	Excluded           string // Why the user couldn't be picked, see scheduler.Excluded*; empty for those the winner was picked from
+	VolunteerQueueDays int
+	AdminQueueDays     int
+	RecentDuties       int        // Completed duties the strategy counts for fairness
+	LastDuty           *time.Time // The most recent of them
+}
+
+// NoteTemplate is a context note added to the reminders of every day its
+// rule matches, e.g. "bins are brown this week" every other Tuesday.
+type NoteTemplate struct {
+	ID        int64
+	Rule      string // A date rule, see note.ParseRule
+	Text      string
+	CreatedAt time.Time
+}
+
+// ChecklistItem is a task on the checklist of duties, e.g. "load the
+// dishwasher". A duty only counts as completed once its mandatory items are
+// checked off.
+type ChecklistItem struct {
+	ID             int64
+	AssignmentType AssignmentType // The duties it applies to, or empty for every duty
+	Text           string
+	Mandatory      bool
+	CreatedAt      time.Time
+}
+
+// Task is a one-off chore outside the rotation, e.g. "clean the garage",
+// that a member claims and does. Done tasks count towards the stats with
+// their weight.
+type Task struct {
+	ID        int64
+	Title     string
+	Weight    int        // What the task counts for in the stats, in duty days
+	DueDate   *time.Time // When it should be done by, if it has to be
+	ClaimedBy *int64     // The user doing it, if anyone claimed it
+	ClaimedAt *time.Time
+	DoneAt    *time.Time
+	CreatedAt time.Time
+}
+
+// CoverRequest asks the group at short notice who takes over the duty of a
+// day from whoever is on it. If nobody claims it by Deadline, the fallback
+// person of the settings does.
+type CoverRequest struct {
+	ID         int64
+	DutyDate   time.Time
+	UserID     int64      // Who was on duty when cover was asked for
+	Deadline   time.Time  // When the fallback person takes over
+	CoveredBy  *int64     // Who took the duty over, nil while open or if nobody did
+	ResolvedAt *time.Time // When it was claimed or ran out, nil while open
+	CreatedAt  time.Time
+}
+
+// ChecklistCheck records that an item was checked off on the duty of a day.
+type ChecklistCheck struct {
+	Date      time.Time
+	ItemID    int64
+	CheckedAt time.Time
+}
+
+// WasteCollection is a day the municipality collects a type of bin, imported
+// from its waste-collection calendar.
+type WasteCollection struct {
+	Date time.Time
+	Bin  string // e.g. "Paper" or "Bio", as named by the calendar
+}
+
 // UserStats holds aggregated statistics for a user.
 type UserStats struct {
 	TotalDuties     int
 	DutiesThisMonth int
 	NextDutyDate    string // YYYY-MM-DD, or empty if none
+	Completed       int    // Duties with the status completed
+	Missed          int    // Duties with the status missed, except on days the user was off duty
+	Volunteered     int    // Voluntary duties, of TotalDuties
+	CurrentStreak   int    // Completed duties since the last missed one, not counting those missed off duty
+	TasksDone       int    // One-off tasks the user did
+	TaskPoints      int    // The weight of TasksDone
+
+	// HouseholdAverage is the number of completed duties per active user, to
+	// compare Completed against.
+	HouseholdAverage float64
 }
 
-// Store defines the interface for all data operations.
-type Store interface {
-	// User methods
+// CompletionRate returns the share of the user's finished duties that were
+// completed rather than missed, or 0 if none finished yet.
+func (s *UserStats) CompletionRate() float64 {
+	if s.Completed+s.Missed == 0 {
+		return 0
+	}
+	return float64(s.Completed) / float64(s.Completed+s.Missed)
+}
+
+// VolunteerRatio returns the share of the user's duties they volunteered
+// for, or 0 if they have none.
+func (s *UserStats) VolunteerRatio() float64 {
+	if s.TotalDuties == 0 {
+		return 0
+	}
+	return float64(s.Volunteered) / float64(s.TotalDuties)
+}
+
+// BadgeKind is what a badge was awarded for.
+type BadgeKind string
+
+const (
+	// BadgeFirstVolunteer is for completing a first voluntary duty.
+	BadgeFirstVolunteer BadgeKind = "first_volunteer"
+	// BadgeTenDuties is for completing ten duties.
+	BadgeTenDuties BadgeKind = "ten_duties"
+	// BadgePerfectMonth is for a month in which every duty of the user was
+	// completed. It can be awarded once per month.
+	BadgePerfectMonth BadgeKind = "perfect_month"
+)
+
+// Badge is an achievement awarded to a user. A user has each kind of badge at
+// most once per period.
+type Badge struct {
+	ID        int64
+	UserID    int64
+	Kind      BadgeKind
+	Period    string // YYYY-MM for BadgePerfectMonth, empty for one-time badges
+	AwardedAt time.Time
+}
+
+// LedgerKind is what a ledger entry records.
+type LedgerKind string
+
+const (
+	// LedgerFine is charged for a missed duty in payout mode.
+	LedgerFine LedgerKind = "fine"
+	// LedgerRefund takes back a fine, e.g. when the duty was completed after all.
+	LedgerRefund LedgerKind = "refund"
+	// LedgerPayment is money a user paid to settle their balance.
+	LedgerPayment LedgerKind = "payment"
+)
+
+// LedgerEntry is a change of what a user owes in payout mode. A user's
+// balance is the sum of their entries' amounts.
+type LedgerEntry struct {
+	ID        int64
+	UserID    int64
+	Kind      LedgerKind
+	Amount    int64      // In cents; positive for fines, negative for refunds and payments
+	DutyDate  *time.Time // The missed duty of a fine or refund
+	ChargeID  string     // Telegram's charge ID of a payment made with an invoice, empty otherwise
+	CreatedAt time.Time
+}
+
+// QueueEvent is a change of a user's volunteer or admin queue: days added to
+// it, or a day of it consumed by a duty. They let the queue's days be matched
+// up oldest first to see how long they waited.
+type QueueEvent struct {
+	ID     int64
+	UserID int64
+	Queue  AssignmentType // AssignmentTypeVoluntary or AssignmentTypeAdmin
+	Days   int            // Positive for days added, -1 for a day consumed
+	At     time.Time
+}
+
+// LoginCode is a one-time code the bot sent a user in a private chat to sign
+// in a browser with. Only a hash of the code is stored.
+type LoginCode struct {
+	CodeHash  string
+	UserID    int64
+	ExpiresAt time.Time
+}
+
+// Invite is a one-time invitation link an admin created with /invite. Only
+// a hash of its token is stored.
+type Invite struct {
+	TokenHash string
+	CreatedBy int64 // Telegram user ID of the admin who created it
+	// RequiresApproval keeps whoever joins with it out of the rotation until
+	// an admin approves them.
+	RequiresApproval bool
+	CreatedAt        time.Time
+	ExpiresAt        time.Time
+	UsedBy           int64 // Telegram user ID of who joined with it, 0 while unused
+	UsedAt           *time.Time
+}
+
+// WebSession is a browser signed in with a login code. Only a hash of its
+// token, which the browser keeps in a cookie, is stored.
+type WebSession struct {
+	TokenHash string
+	UserID    int64
+	CreatedAt time.Time
+	ExpiresAt time.Time
+}
+
+// DutyFilter selects duties for ListDuties. Zero fields don't filter.
+type DutyFilter struct {
+	UserID int64     // Only this user's duties
+	From   time.Time // Only duties on or after this date
+	To     time.Time // Only duties before this date
+}
+
+// UserMerge is the audit record of a user account merged into another one,
+// e.g. after someone re-registered with a new Telegram account.
+type UserMerge struct {
+	ID                 int64
+	FromUserID         int64 // Deleted by the merge
+	FromTelegramUserID int64
+	FromName           string
+	ToUserID           int64
+	DutiesMoved        int
+	VolunteerQueueDays int // Queue days added to the surviving user
+	AdminQueueDays     int
+	MergedAt           time.Time
+}
+
+//go:generate go run go.uber.org/mock/mockgen -destination=mocks/store.go -package=mocks . Store,UserStore,DutyStore,QueueStore,AvailabilityStore,NotificationStore,SettingStore,MaintenanceStore,TxStore
+
+// UserStore covers the household members and their statistics.
+type UserStore interface {
 	GetUserByTelegramID(ctx context.Context, id int64) (*User, error)
+	// GetUserByName finds a user by handle, falling back to the display name.
 	GetUserByName(ctx context.Context, name string) (*User, error)
 	ListActiveUsers(ctx context.Context) ([]*User, error)
 	ListAllUsers(ctx context.Context) ([]*User, error)
 	CreateUser(ctx context.Context, user *User) error
 	UpdateUser(ctx context.Context, user *User) error
+	// DeleteUser deletes a user and what only they own, such as off-duty
+	// periods and preferences. It fails for users who have duties.
+	DeleteUser(ctx context.Context, id int64) error
+	// GetUserStats returns a user's stats, whose counts are kept up to date as
+	// duties change.
 	GetUserStats(ctx context.Context, userID int64) (*UserStats, error)
+	// RebuildUserStats counts the kept stats of all users again from their
+	// duties.
+	RebuildUserStats(ctx context.Context) error
+
+	// Badges
+	// AwardBadge stores b and sets its ID unless the user already has its
+	// kind for its period, and reports whether it did.
+	AwardBadge(ctx context.Context, b *Badge) (bool, error)
+	// ListBadges returns a user's badges, oldest first.
+	ListBadges(ctx context.Context, userID int64) ([]*Badge, error)
+
+	// Payout ledger
+	// AddLedgerEntry stores e and sets its ID.
+	AddLedgerEntry(ctx context.Context, e *LedgerEntry) error
+	// ListLedgerEntries returns the ledger entries of all users, oldest first.
+	ListLedgerEntries(ctx context.Context) ([]*LedgerEntry, error)
+
+	// Web logins
+	CreateLoginCode(ctx context.Context, code *LoginCode) error
+	// ConsumeLoginCode deletes the code with the hash and returns it, or nil
+	// if there is none, so a code can only be used once.
+	ConsumeLoginCode(ctx context.Context, codeHash string) (*LoginCode, error)
+	CreateWebSession(ctx context.Context, session *WebSession) error
+	// GetWebSession returns the session with the token hash, or nil if there is none.
+	GetWebSession(ctx context.Context, tokenHash string) (*WebSession, error)
+	DeleteWebSession(ctx context.Context, tokenHash string) error
+	// DeleteExpiredLogins deletes the codes and sessions that expired before now.
+	DeleteExpiredLogins(ctx context.Context, now time.Time) error
+
+	// Invitations
+	CreateInvite(ctx context.Context, invite *Invite) error
+	// UseInvite marks the unused invite with the hash as used by the Telegram
+	// user and returns it, or nil if there is none or it expired before at, so
+	// an invite can only be used once.
+	UseInvite(ctx context.Context, tokenHash string, telegramUserID int64, at time.Time) (*Invite, error)
+	// GetInviteUsedBy returns the invite the Telegram user joined with, or nil
+	// if they didn't join with one.
+	GetInviteUsedBy(ctx context.Context, telegramUserID int64) (*Invite, error)
+
+	// Merging accounts
+	MergeUsers(ctx context.Context, fromID, toID int64, at time.Time) (*UserMerge, error)
+	ListUserMerges(ctx context.Context) ([]*UserMerge, error)
+}
 
-	// Duty methods
+// DutyStore covers the duty calendar: assigned duties, their change log and
+// days deliberately left without duty.
+type DutyStore interface {
 	CreateDuty(ctx context.Context, duty *Duty) error
 	GetDutyByDate(ctx context.Context, date time.Time) (*Duty, error)
 	UpdateDuty(ctx context.Context, duty *Duty) error
 	DeleteDuty(ctx context.Context, date time.Time) error
 	GetDutiesByMonth(ctx context.Context, year int, month time.Month) ([]*Duty, error)
-	CompleteDuty(ctx context.Context, date time.Time) error
+	// ListDuties returns the duties matching the filter, ordered by date.
+	ListDuties(ctx context.Context, filter DutyFilter) ([]*Duty, error)
+	// CompleteDuty marks the duty on date as completed unless it already is,
+	// and reports whether it did.
+	CompleteDuty(ctx context.Context, date time.Time) (bool, error)
+	// MarkDutyAnnounced records that the group was told about the duty on
+	// date at at. Without a duty on date it does nothing.
+	MarkDutyAnnounced(ctx context.Context, date, at time.Time) error
 	GetTodaysDuty(ctx context.Context) (*Duty, error)
 	GetCompletedDutiesInRange(ctx context.Context, start, end time.Time) ([]*Duty, error)
+	// CreateDutyChange appends to the schedule change log and sets the ID.
+	CreateDutyChange(ctx context.Context, change *DutyChange) error
+	GetRecentDutyChanges(ctx context.Context, limit int) ([]*DutyChange, error)
+	BackfillDuty(ctx context.Context, date time.Time, userID int64, at time.Time) (*Duty, error)
+	GetExpiredHolds(ctx context.Context, today time.Time) ([]*Duty, error)
+	MarkMissedDuties(ctx context.Context, before time.Time) (int, error)
+
+	// Skip days
+	SetSkipDay(ctx context.Context, day *SkipDay) error
+	GetSkipDay(ctx context.Context, date time.Time) (*SkipDay, error)
+	DeleteSkipDay(ctx context.Context, date time.Time) error
+	GetSkipDaysByMonth(ctx context.Context, year int, month time.Month) ([]*SkipDay, error)
+
+	// Blackout rules
+	// CreateBlackoutRule stores r and sets its ID.
+	CreateBlackoutRule(ctx context.Context, r *BlackoutRule) error
+	// ListBlackoutRules returns all blackout rules, oldest first.
+	ListBlackoutRules(ctx context.Context) ([]*BlackoutRule, error)
+	// DeleteBlackoutRule removes a blackout rule. Unknown IDs are ignored.
+	DeleteBlackoutRule(ctx context.Context, id int64) error
+
+	// Schedule versions
+	// CreateScheduleVersion stores v as the next version of its month and
+	// sets its ID and Version.
+	CreateScheduleVersion(ctx context.Context, v *ScheduleVersion) error
+	// ListScheduleVersions returns the versions of a month, oldest first.
+	ListScheduleVersions(ctx context.Context, month time.Time) ([]*ScheduleVersion, error)
+
+	// Shadow strategy comparisons
+	CreateShadowComparison(ctx context.Context, c *ShadowComparison) error
+	ListShadowComparisons(ctx context.Context, since time.Time) ([]*ShadowComparison, error)
+
+	// Assignment explanations
+	// SaveAssignmentExplanation stores e, replacing the explanation of its day.
+	SaveAssignmentExplanation(ctx context.Context, e *AssignmentExplanation) error
+	// GetAssignmentExplanation returns the explanation of a day, or nil if
+	// there is none.
+	GetAssignmentExplanation(ctx context.Context, date time.Time) (*AssignmentExplanation, error)
+
+	// Context note templates
+	CreateNoteTemplate(ctx context.Context, t *NoteTemplate) error
+	ListNoteTemplates(ctx context.Context) ([]*NoteTemplate, error)
+	DeleteNoteTemplate(ctx context.Context, id int64) error
+
+	// Duty checklists
+	CreateChecklistItem(ctx context.Context, item *ChecklistItem) error
+	ListChecklistItems(ctx context.Context) ([]*ChecklistItem, error)
+	// DeleteChecklistItem removes an item along with its checks.
+	DeleteChecklistItem(ctx context.Context, id int64) error
+	SetChecklistCheck(ctx context.Context, check *ChecklistCheck) error
+	DeleteChecklistCheck(ctx context.Context, date time.Time, itemID int64) error
+	GetChecklistChecks(ctx context.Context, date time.Time) ([]*ChecklistCheck, error)
+
+	// One-off tasks
+	// CreateTask stores t and sets its ID.
+	CreateTask(ctx context.Context, t *Task) error
+	// GetTask returns the task with the ID, or nil if there is none.
+	GetTask(ctx context.Context, id int64) (*Task, error)
+	// ListTasks returns all tasks, oldest first.
+	ListTasks(ctx context.Context) ([]*Task, error)
+	// ClaimTask gives the open, unclaimed task to the user and reports
+	// whether it did.
+	ClaimTask(ctx context.Context, id, userID int64, at time.Time) (bool, error)
+	// ReleaseTask takes back the user's claim of the open task and reports
+	// whether there was one.
+	ReleaseTask(ctx context.Context, id, userID int64) (bool, error)
+	// CompleteTask marks the open task done by the user, claiming it for
+	// them if nobody has. It reports false if the task is done already or
+	// claimed by someone else.
+	CompleteTask(ctx context.Context, id, userID int64, at time.Time) (bool, error)
+	DeleteTask(ctx context.Context, id int64) error
+
+	// Cover requests
+	// CreateCoverRequest stores r and sets its ID.
+	CreateCoverRequest(ctx context.Context, r *CoverRequest) error
+	// GetOpenCoverRequest returns the open cover request of a day, or nil if
+	// there is none.
+	GetOpenCoverRequest(ctx context.Context, date time.Time) (*CoverRequest, error)
+	// ListOpenCoverRequests returns the open cover requests, by deadline.
+	ListOpenCoverRequests(ctx context.Context) ([]*CoverRequest, error)
+	// ResolveCoverRequest closes the cover request, taken over by coveredBy
+	// or by nobody if it is nil.
+	ResolveCoverRequest(ctx context.Context, id int64, coveredBy *int64, at time.Time) error
+
+	// Waste collection days
+	ReplaceWasteCollections(ctx context.Context, collections []*WasteCollection) error
+	// GetWasteCollections returns the collections from start up to, but not including, end.
+	GetWasteCollections(ctx context.Context, start, end time.Time) ([]*WasteCollection, error)
 
-	// Queue management methods
+	// Round-robin cursors
+	GetRoundRobinStates(ctx context.Context, rotation string) ([]*RoundRobinState, error)
+	RecordRoundRobinPick(ctx context.Context, rotation string, userID int64, at time.Time) error
+}
+
+// QueueStore covers the volunteer and admin queues.
+type QueueStore interface {
 	AddToVolunteerQueue(ctx context.Context, userID int64, days int) error
 	AddToAdminQueue(ctx context.Context, userID int64, days int) error
 	DecrementVolunteerQueue(ctx context.Context, userID int64) error
 	DecrementAdminQueue(ctx context.Context, userID int64) error
 	GetUsersWithVolunteerQueue(ctx context.Context) ([]*User, error)
 	GetUsersWithAdminQueue(ctx context.Context) ([]*User, error)
+	// ListQueueEvents returns the queue events of a user, or of all users
+	// for userID 0, oldest first. The Add and Decrement methods record them.
+	ListQueueEvents(ctx context.Context, userID int64) ([]*QueueEvent, error)
+}
 
-	// Off-duty management methods
+// AvailabilityStore covers when users are off duty, whether set by hand or
+// imported from their linked calendars.
+type AvailabilityStore interface {
 	SetOffDuty(ctx context.Context, userID int64, start, end time.Time) error
 	ClearOffDuty(ctx context.Context, userID int64) error
 	IsUserOffDuty(ctx context.Context, userID int64, date time.Time) (bool, error)
 	GetOffDutyUsers(ctx context.Context, date time.Time) ([]*User, error)
+	ListOffDutyPeriods(ctx context.Context, userID int64) ([]*OffDutyPeriod, error)
+	ReplaceOffDutyPeriods(ctx context.Context, userID int64, source string, periods []*OffDutyPeriod) error
+
+	// Calendar links
+	SetCalendarLink(ctx context.Context, link *CalendarLink) error
+	GetCalendarLink(ctx context.Context, userID int64) (*CalendarLink, error)
+	DeleteCalendarLink(ctx context.Context, userID int64) error
+	ListCalendarLinks(ctx context.Context) ([]*CalendarLink, error)
+}
+
+// NotificationStore covers per-user notification preferences, snoozed
+// reminders and messages left unsent at shutdown.
+type NotificationStore interface {
+	GetNotificationPreferences(ctx context.Context, userID int64) (*NotificationPreferences, error)
+	SetNotificationPreferences(ctx context.Context, prefs *NotificationPreferences) error
+
+	// Schedule change subscriptions
+	CreateChangeSubscription(ctx context.Context, sub *ChangeSubscription) error
+	GetChangeSubscription(ctx context.Context, userID int64) (*ChangeSubscription, error)
+	DeleteChangeSubscription(ctx context.Context, userID int64) error
+
+	// Reminder snoozes
+	CreateReminderSnooze(ctx context.Context, snooze *ReminderSnooze) error
+	ListReminderSnoozes(ctx context.Context) ([]*ReminderSnooze, error)
+	DeleteReminderSnooze(ctx context.Context, id int64) error
+
+	// Pending messages
+	CreatePendingMessage(ctx context.Context, msg *PendingMessage) error
+	ListPendingMessages(ctx context.Context) ([]*PendingMessage, error)
+	DeletePendingMessage(ctx context.Context, id int64) error
+
+	// Interactive messages
+	// TrackInteractiveMessage stores the message, replacing it if it was
+	// tracked before.
+	TrackInteractiveMessage(ctx context.Context, msg *InteractiveMessage) error
+	// MarkInteractiveMessageDone records that the interaction of the message
+	// completed at at. Untracked messages are ignored.
+	MarkInteractiveMessageDone(ctx context.Context, chatID int64, messageID int, at time.Time) error
+	// ListInteractiveMessages returns the tracked messages, oldest first.
+	ListInteractiveMessages(ctx context.Context) ([]*InteractiveMessage, error)
+	DeleteInteractiveMessage(ctx context.Context, chatID int64, messageID int) error
+
+	// Conversation state
+	// SaveConversationState stores the state, replacing the one with its key.
+	SaveConversationState(ctx context.Context, state *ConversationState) error
+	// ListConversationStates returns the states that expire after now, by key.
+	ListConversationStates(ctx context.Context, now time.Time) ([]*ConversationState, error)
+	// DeleteConversationState deletes the state with the key. Deleting a
+	// missing one is not an error.
+	DeleteConversationState(ctx context.Context, key string) error
+	// DeleteExpiredConversationStates deletes the states that expired by now.
+	DeleteExpiredConversationStates(ctx context.Context, now time.Time) error
+
+	// Chat locales. GetChatLocale returns "" for chats that didn't pick one.
+	GetChatLocale(ctx context.Context, chatID int64) (string, error)
+	SetChatLocale(ctx context.Context, chatID int64, locale string) error
+	// Chat calendar display. GetChatDisplay returns nil for chats that
+	// didn't set one.
+	GetChatDisplay(ctx context.Context, chatID int64) (*ChatDisplay, error)
+	SetChatDisplay(ctx context.Context, chatID int64, display ChatDisplay) error
+}
+
+// SettingStore covers the bot's settings that can be changed at runtime,
+// such as the group chat and the admins. GetSetting returns "" for settings
+// that were never set.
+type SettingStore interface {
+	GetSetting(ctx context.Context, key string) (string, error)
+	SetSetting(ctx context.Context, key, value string) error
+	// ListSettings returns all settings that were set, by key.
+	ListSettings(ctx context.Context) (map[string]string, error)
+}
+
+// MaintenanceStore covers keeping the database small and fast: dropping old
+// log rows, compacting the file and remembering how that went.
+type MaintenanceStore interface {
+	// DatabaseSize returns how many bytes the database takes, 0 for stores
+	// that don't keep one.
+	DatabaseSize(ctx context.Context) (int64, error)
+	// Optimize compacts the database and refreshes the planner's statistics.
+	// It can't run in a transaction.
+	Optimize(ctx context.Context) error
+	// DeleteLogsBefore deletes the shadow comparisons, assignment
+	// explanations and maintenance runs of days before the given one, and
+	// returns how many rows it deleted.
+	DeleteLogsBefore(ctx context.Context, before time.Time) (int, error)
+	// CreateMaintenanceRun records a run and sets its ID.
+	CreateMaintenanceRun(ctx context.Context, run *MaintenanceRun) error
+	// ListMaintenanceRuns returns the latest runs, at most limit, newest first.
+	ListMaintenanceRuns(ctx context.Context, limit int) ([]*MaintenanceRun, error)
+}
+
+// Store defines the interface for all data operations. Consumers that only
+// need part of it should depend on the narrower interfaces it is made of.
+type Store interface {
+	UserStore
+	DutyStore
+	QueueStore
+	AvailabilityStore
+	NotificationStore
+	SettingStore
+	MaintenanceStore
+	TxStore
+}
+
+// TxStore groups changes so they are made together or not at all.
+type TxStore interface {
+	// RunInTx calls fn with a store whose changes are committed if fn returns
+	// nil and rolled back otherwise. It nests: a failing inner call only
+	// undoes its own changes.
+	RunInTx(ctx context.Context, fn func(tx Store) error) error
 }
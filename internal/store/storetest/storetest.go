@@ -0,0 +1,2325 @@
+// Package storetest provides a conformance suite for store.Store
+// implementations. Every backend should pass it, so that code written and
+// tested against one store behaves the same on another:
+//
+//	func TestStoreContract(t *testing.T) {
+//		storetest.RunStoreTests(t, func(t *testing.T) store.Store { return newStore(t) })
+//	}
+package storetest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/korjavin/dutyassistant/internal/store"
+)
+
+// Factory returns a new, empty store for a single test.
+type Factory func(t *testing.T) store.Store
+
+// RunStoreTests runs the conformance suite against stores created by newStore.
+func RunStoreTests(t *testing.T, newStore Factory) {
+	tests := []struct {
+		name string
+		fn   func(t *testing.T, s store.Store)
+	}{
+		{"Users", testUsers},
+		{"UserStats", testUserStats},
+		{"UserStatsFollowDuties", testUserStatsFollowDuties},
+		{"Duties", testDuties},
+		{"DutiesByMonth", testDutiesByMonth},
+		{"ListDuties", testListDuties},
+		{"CompletedDuties", testCompletedDuties},
+		{"DutyChangeLog", testDutyChangeLog},
+		{"BackfillDuty", testBackfillDuty},
+		{"DutyAnnouncements", testDutyAnnouncements},
+		{"Holds", testHolds},
+		{"Queues", testQueues},
+		{"QueueEvents", testQueueEvents},
+		{"OffDuty", testOffDuty},
+		{"OffDutyPeriods", testOffDutyPeriods},
+		{"CalendarLinks", testCalendarLinks},
+		{"NotificationPreferences", testNotificationPreferences},
+		{"ChangeSubscriptions", testChangeSubscriptions},
+		{"SkipDays", testSkipDays},
+		{"ReminderSnoozes", testReminderSnoozes},
+		{"PendingMessages", testPendingMessages},
+		{"InteractiveMessages", testInteractiveMessages},
+		{"ConversationStates", testConversationStates},
+		{"ChatLocales", testChatLocales},
+		{"ChatDisplay", testChatDisplay},
+		{"Settings", testSettings},
+		{"ShadowComparisons", testShadowComparisons},
+		{"AssignmentExplanations", testAssignmentExplanations},
+		{"Maintenance", testMaintenance},
+		{"ScheduleVersions", testScheduleVersions},
+		{"Notes", testNotes},
+		{"BlackoutRules", testBlackoutRules},
+		{"Checklists", testChecklists},
+		{"WasteCollections", testWasteCollections},
+		{"RoundRobinState", testRoundRobinState},
+		{"DutyStatus", testDutyStatus},
+		{"Badges", testBadges},
+		{"Ledger", testLedger},
+		{"Tasks", testTasks},
+		{"CoverRequests", testCoverRequests},
+		{"WebLogins", testWebLogins},
+		{"Invites", testInvites},
+		{"MergeUsers", testMergeUsers},
+		{"DeleteUser", testDeleteUser},
+		{"UserHandles", testUserHandles},
+		{"Transactions", testTransactions},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.fn(t, newStore(t))
+		})
+	}
+}
+
+func date(y int, m time.Month, d int) time.Time {
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+func today() time.Time {
+	now := time.Now()
+	return date(now.Year(), now.Month(), now.Day())
+}
+
+// mustCreateUser creates a user or fails the test.
+func mustCreateUser(t *testing.T, s store.Store, telegramID int64, name string, active bool) *store.User {
+	t.Helper()
+	user := &store.User{TelegramUserID: telegramID, FirstName: name, IsActive: active}
+	if err := s.CreateUser(context.Background(), user); err != nil {
+		t.Fatalf("CreateUser(%s) failed: %v", name, err)
+	}
+	if user.ID == 0 {
+		t.Fatalf("CreateUser(%s) did not set the ID", name)
+	}
+	return user
+}
+
+// mustCreateDuty creates a duty or fails the test.
+func mustCreateDuty(t *testing.T, s store.Store, userID int64, day time.Time, typ store.AssignmentType) *store.Duty {
+	t.Helper()
+	duty := &store.Duty{UserID: userID, DutyDate: day, AssignmentType: typ, CreatedAt: time.Now()}
+	if err := s.CreateDuty(context.Background(), duty); err != nil {
+		t.Fatalf("CreateDuty(%s) failed: %v", day.Format("2006-01-02"), err)
+	}
+	if duty.ID == 0 {
+		t.Fatalf("CreateDuty(%s) did not set the ID", day.Format("2006-01-02"))
+	}
+	return duty
+}
+
+func userNames(users []*store.User) []string {
+	names := make([]string, 0, len(users))
+	for _, u := range users {
+		names = append(names, u.FirstName)
+	}
+	return names
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func testUsers(t *testing.T, s store.Store) {
+	ctx := context.Background()
+
+	if u, err := s.GetUserByTelegramID(ctx, 42); err != nil || u != nil {
+		t.Errorf("GetUserByTelegramID on a missing user: expected (nil, nil), got (%v, %v)", u, err)
+	}
+	if u, err := s.GetUserByName(ctx, "Nobody"); err != nil || u != nil {
+		t.Errorf("GetUserByName on a missing user: expected (nil, nil), got (%v, %v)", u, err)
+	}
+
+	charlie := mustCreateUser(t, s, 3, "Charlie", true)
+	alice := mustCreateUser(t, s, 1, "Alice", true)
+	mustCreateUser(t, s, 2, "Bob", false)
+
+	if err := s.CreateUser(ctx, &store.User{TelegramUserID: 1, FirstName: "Alice again"}); err == nil {
+		t.Error("Expected an error when creating a user with a duplicate Telegram ID")
+	}
+
+	got, err := s.GetUserByTelegramID(ctx, 1)
+	if err != nil || got == nil || got.ID != alice.ID || got.FirstName != "Alice" {
+		t.Fatalf("GetUserByTelegramID: expected Alice, got (%+v, %v)", got, err)
+	}
+	got, err = s.GetUserByName(ctx, "Charlie")
+	if err != nil || got == nil || got.ID != charlie.ID {
+		t.Fatalf("GetUserByName: expected Charlie, got (%+v, %v)", got, err)
+	}
+
+	all, err := s.ListAllUsers(ctx)
+	if err != nil {
+		t.Fatalf("ListAllUsers failed: %v", err)
+	}
+	if names := userNames(all); !equalStrings(names, []string{"Alice", "Bob", "Charlie"}) {
+		t.Errorf("ListAllUsers: expected users ordered by name, got %v", names)
+	}
+
+	active, err := s.ListActiveUsers(ctx)
+	if err != nil {
+		t.Fatalf("ListActiveUsers failed: %v", err)
+	}
+	if len(active) != 2 {
+		t.Errorf("ListActiveUsers: expected 2 active users, got %v", userNames(active))
+	}
+
+	// Update persists all mutable fields
+	alice.FirstName = "Alicia"
+	alice.IsAdmin = true
+	alice.IsActive = false
+	alice.VolunteerQueueDays = 2
+	alice.AdminQueueDays = 1
+	alice.IsJunior = true
+	alice.Emoji = "🦊"
+	alice.Pool = store.PoolWeekends
+	alice.IsPending = true
+	alice.Timezone = "America/New_York"
+	alice.Privacy, alice.Alias = store.PrivacyAlias, "Captain"
+	if err := s.UpdateUser(ctx, alice); err != nil {
+		t.Fatalf("UpdateUser failed: %v", err)
+	}
+	got, _ = s.GetUserByTelegramID(ctx, 1)
+	if got.FirstName != "Alicia" || !got.IsAdmin || got.IsActive || got.VolunteerQueueDays != 2 || got.AdminQueueDays != 1 || !got.IsJunior || got.Emoji != "🦊" || got.Pool != store.PoolWeekends || !got.IsPending || got.Timezone != "America/New_York" ||
+		got.Privacy != store.PrivacyAlias || got.Alias != "Captain" {
+		t.Errorf("UpdateUser: fields not persisted, got %+v", got)
+	}
+}
+
+func testUserStats(t *testing.T, s store.Store) {
+	ctx := context.Background()
+	alice := mustCreateUser(t, s, 1, "Alice", true)
+
+	stats, err := s.GetUserStats(ctx, alice.ID)
+	if err != nil {
+		t.Fatalf("GetUserStats failed: %v", err)
+	}
+	if stats.TotalDuties != 0 || stats.DutiesThisMonth != 0 || stats.NextDutyDate != "" {
+		t.Errorf("Expected empty stats, got %+v", stats)
+	}
+
+	// One duty long ago, one today and one next year.
+	mustCreateDuty(t, s, alice.ID, date(2020, time.January, 1), store.AssignmentTypeRoundRobin)
+	mustCreateDuty(t, s, alice.ID, today(), store.AssignmentTypeRoundRobin)
+	mustCreateDuty(t, s, alice.ID, today().AddDate(1, 0, 0), store.AssignmentTypeVoluntary)
+
+	stats, err = s.GetUserStats(ctx, alice.ID)
+	if err != nil {
+		t.Fatalf("GetUserStats failed: %v", err)
+	}
+	if stats.TotalDuties != 3 {
+		t.Errorf("Expected 3 total duties, got %d", stats.TotalDuties)
+	}
+	if stats.DutiesThisMonth != 1 {
+		t.Errorf("Expected 1 duty this month, got %d", stats.DutiesThisMonth)
+	}
+	if stats.NextDutyDate != today().Format("2006-01-02") {
+		t.Errorf("Expected next duty date %s, got %q", today().Format("2006-01-02"), stats.NextDutyDate)
+	}
+	if stats.Volunteered != 1 {
+		t.Errorf("Expected 1 voluntary duty, got %d", stats.Volunteered)
+	}
+
+	// Completed, missed, completed twice: the streak starts after the miss.
+	// Bob completed one duty, so the household averages 2.5.
+	bob := mustCreateUser(t, s, 2, "Bob", true)
+	for day, status := range map[int]store.DutyStatus{
+		2: store.DutyStatusCompleted, 3: store.DutyStatusMissed, 4: store.DutyStatusCompleted, 5: store.DutyStatusCompleted,
+	} {
+		if err := s.CreateDuty(ctx, &store.Duty{UserID: alice.ID, DutyDate: date(2020, time.January, day), AssignmentType: store.AssignmentTypeRoundRobin, Status: status, CreatedAt: time.Now()}); err != nil {
+			t.Fatalf("CreateDuty failed: %v", err)
+		}
+	}
+	if _, err := s.CompleteDuty(ctx, date(2020, time.January, 1)); err != nil {
+		t.Fatalf("CompleteDuty failed: %v", err)
+	}
+	mustCreateDuty(t, s, bob.ID, date(2020, time.January, 6), store.AssignmentTypeRoundRobin)
+	if _, err := s.CompleteDuty(ctx, date(2020, time.January, 6)); err != nil {
+		t.Fatalf("CompleteDuty failed: %v", err)
+	}
+
+	stats, err = s.GetUserStats(ctx, alice.ID)
+	if err != nil {
+		t.Fatalf("GetUserStats failed: %v", err)
+	}
+	if stats.Completed != 4 || stats.Missed != 1 || stats.CurrentStreak != 2 {
+		t.Errorf("Expected 4 completed, 1 missed and a streak of 2, got %+v", stats)
+	}
+	if stats.HouseholdAverage != 2.5 {
+		t.Errorf("Expected a household average of 2.5, got %v", stats.HouseholdAverage)
+	}
+
+	// A duty missed while off duty neither counts as missed nor ends the streak
+	if err := s.ReplaceOffDutyPeriods(ctx, alice.ID, store.OffDutySourceImport, []*store.OffDutyPeriod{
+		{StartDate: date(2020, time.January, 7), EndDate: date(2020, time.January, 9)},
+	}); err != nil {
+		t.Fatalf("ReplaceOffDutyPeriods failed: %v", err)
+	}
+	if err := s.CreateDuty(ctx, &store.Duty{UserID: alice.ID, DutyDate: date(2020, time.January, 8), AssignmentType: store.AssignmentTypeRoundRobin, Status: store.DutyStatusMissed, CreatedAt: time.Now()}); err != nil {
+		t.Fatalf("CreateDuty failed: %v", err)
+	}
+	stats, err = s.GetUserStats(ctx, alice.ID)
+	if err != nil {
+		t.Fatalf("GetUserStats failed: %v", err)
+	}
+	if stats.Missed != 1 || stats.CurrentStreak != 2 {
+		t.Errorf("Expected 1 missed and a streak of 2 after a duty missed off duty, got %+v", stats)
+	}
+}
+
+func testUserStatsFollowDuties(t *testing.T, s store.Store) {
+	ctx := context.Background()
+	alice := mustCreateUser(t, s, 1, "Alice", true)
+	bob := mustCreateUser(t, s, 2, "Bob", true)
+	charlie := mustCreateUser(t, s, 3, "Charlie", true)
+	expect := func(step string, u *store.User, total, completed, missed, volunteered int) {
+		t.Helper()
+		stats, err := s.GetUserStats(ctx, u.ID)
+		if err != nil {
+			t.Fatalf("%s: GetUserStats failed: %v", step, err)
+		}
+		if stats.TotalDuties != total || stats.Completed != completed || stats.Missed != missed || stats.Volunteered != volunteered {
+			t.Errorf("%s: expected %s to have %d duties, %d completed, %d missed and %d volunteered, got %+v",
+				step, u.FirstName, total, completed, missed, volunteered, stats)
+		}
+	}
+
+	mustCreateDuty(t, s, alice.ID, date(2020, time.March, 1), store.AssignmentTypeRoundRobin)
+	mustCreateDuty(t, s, alice.ID, date(2020, time.March, 2), store.AssignmentTypeVoluntary)
+	mustCreateDuty(t, s, bob.ID, date(2020, time.March, 3), store.AssignmentTypeRoundRobin)
+	expect("created", alice, 2, 0, 0, 1)
+
+	if _, err := s.CompleteDuty(ctx, date(2020, time.March, 1)); err != nil {
+		t.Fatalf("CompleteDuty failed: %v", err)
+	}
+	if _, err := s.CompleteDuty(ctx, date(2020, time.March, 1)); err != nil {
+		t.Fatalf("CompleteDuty failed: %v", err)
+	}
+	expect("completed twice", alice, 2, 1, 0, 1)
+
+	if _, err := s.MarkMissedDuties(ctx, date(2020, time.March, 4)); err != nil {
+		t.Fatalf("MarkMissedDuties failed: %v", err)
+	}
+	expect("missed", alice, 2, 1, 1, 1)
+	expect("missed", bob, 1, 0, 1, 0)
+
+	// The missed voluntary duty goes to Bob
+	duty, _ := s.GetDutyByDate(ctx, date(2020, time.March, 2))
+	duty.UserID = bob.ID
+	if err := s.UpdateDuty(ctx, duty); err != nil {
+		t.Fatalf("UpdateDuty failed: %v", err)
+	}
+	expect("reassigned", alice, 1, 1, 0, 0)
+	expect("reassigned", bob, 2, 0, 2, 1)
+
+	// Charlie actually did Bob's
+	if _, err := s.BackfillDuty(ctx, date(2020, time.March, 3), charlie.ID, time.Now()); err != nil {
+		t.Fatalf("BackfillDuty failed: %v", err)
+	}
+	expect("backfilled", bob, 1, 0, 1, 1)
+	expect("backfilled", charlie, 1, 1, 0, 0)
+
+	if err := s.DeleteDuty(ctx, date(2020, time.March, 1)); err != nil {
+		t.Fatalf("DeleteDuty failed: %v", err)
+	}
+	expect("deleted", alice, 0, 0, 0, 0)
+
+	if _, err := s.MergeUsers(ctx, bob.ID, charlie.ID, time.Now()); err != nil {
+		t.Fatalf("MergeUsers failed: %v", err)
+	}
+	expect("merged", charlie, 2, 1, 1, 1)
+
+	if err := s.RebuildUserStats(ctx); err != nil {
+		t.Fatalf("RebuildUserStats failed: %v", err)
+	}
+	expect("rebuilt", charlie, 2, 1, 1, 1)
+	expect("rebuilt", alice, 0, 0, 0, 0)
+}
+
+func testDuties(t *testing.T, s store.Store) {
+	ctx := context.Background()
+	alice := mustCreateUser(t, s, 1, "Alice", true)
+	bob := mustCreateUser(t, s, 2, "Bob", true)
+	day := date(2025, time.July, 14)
+
+	if d, err := s.GetDutyByDate(ctx, day); err != nil || d != nil {
+		t.Errorf("GetDutyByDate on a free day: expected (nil, nil), got (%v, %v)", d, err)
+	}
+
+	mustCreateDuty(t, s, alice.ID, day, store.AssignmentTypeVoluntary)
+	if err := s.CreateDuty(ctx, &store.Duty{UserID: bob.ID, DutyDate: day, AssignmentType: store.AssignmentTypeAdmin, CreatedAt: time.Now()}); err == nil {
+		t.Error("Expected an error when creating a second duty on the same date")
+	}
+
+	got, err := s.GetDutyByDate(ctx, day)
+	if err != nil || got == nil {
+		t.Fatalf("GetDutyByDate: expected a duty, got (%v, %v)", got, err)
+	}
+	if got.UserID != alice.ID || got.AssignmentType != store.AssignmentTypeVoluntary || !got.DutyDate.Equal(day) {
+		t.Errorf("GetDutyByDate: unexpected duty %+v", got)
+	}
+	if got.User == nil || got.User.FirstName != "Alice" {
+		t.Errorf("GetDutyByDate: expected joined user Alice, got %+v", got.User)
+	}
+	if got.CompletedAt != nil {
+		t.Error("A new duty must not be completed")
+	}
+
+	got.UserID = bob.ID
+	got.AssignmentType = store.AssignmentTypeAdmin
+	got.CompletionBy = alice.ID
+	got.Published = true
+	if err := s.UpdateDuty(ctx, got); err != nil {
+		t.Fatalf("UpdateDuty failed: %v", err)
+	}
+	updated, _ := s.GetDutyByDate(ctx, day)
+	if updated.UserID != bob.ID || updated.AssignmentType != store.AssignmentTypeAdmin || updated.User.FirstName != "Bob" || updated.CompletionBy != alice.ID || !updated.Published {
+		t.Errorf("UpdateDuty: changes not persisted, got %+v", updated)
+	}
+
+	if changed, err := s.CompleteDuty(ctx, day); err != nil || !changed {
+		t.Fatalf("CompleteDuty: expected the duty completed, got %v, %v", changed, err)
+	}
+	completed, _ := s.GetDutyByDate(ctx, day)
+	if completed.CompletedAt == nil {
+		t.Error("CompleteDuty did not set CompletedAt")
+	}
+	if changed, err := s.CompleteDuty(ctx, day); err != nil || changed {
+		t.Errorf("CompleteDuty on a completed duty should be a no-op, got %v, %v", changed, err)
+	}
+	if changed, err := s.CompleteDuty(ctx, day.AddDate(0, 0, 1)); err != nil || changed {
+		t.Errorf("CompleteDuty on a free day should be a no-op, got %v, %v", changed, err)
+	}
+
+	if err := s.DeleteDuty(ctx, day); err != nil {
+		t.Fatalf("DeleteDuty failed: %v", err)
+	}
+	if d, _ := s.GetDutyByDate(ctx, day); d != nil {
+		t.Error("DeleteDuty: duty still present")
+	}
+	if err := s.DeleteDuty(ctx, day); err != nil {
+		t.Errorf("DeleteDuty on a free day should be a no-op, got %v", err)
+	}
+
+	if d, err := s.GetTodaysDuty(ctx); err != nil || d != nil {
+		t.Errorf("GetTodaysDuty without a duty: expected (nil, nil), got (%v, %v)", d, err)
+	}
+	mustCreateDuty(t, s, alice.ID, today(), store.AssignmentTypeRoundRobin)
+	if d, err := s.GetTodaysDuty(ctx); err != nil || d == nil || d.UserID != alice.ID {
+		t.Errorf("GetTodaysDuty: expected Alice's duty, got (%v, %v)", d, err)
+	}
+}
+
+func testDutyAnnouncements(t *testing.T, s store.Store) {
+	ctx := context.Background()
+	alice := mustCreateUser(t, s, 1, "Alice", true)
+	bob := mustCreateUser(t, s, 2, "Bob", true)
+	day := date(2025, time.July, 14)
+	at := time.Date(2025, time.July, 14, 9, 0, 5, 0, time.UTC)
+
+	mustCreateDuty(t, s, alice.ID, day, store.AssignmentTypeRoundRobin)
+	if d, _ := s.GetDutyByDate(ctx, day); d.AnnouncedAt != nil {
+		t.Errorf("A new duty must not be announced, got %v", d.AnnouncedAt)
+	}
+	if err := s.MarkDutyAnnounced(ctx, day, at); err != nil {
+		t.Fatalf("MarkDutyAnnounced failed: %v", err)
+	}
+	if err := s.MarkDutyAnnounced(ctx, day.AddDate(0, 0, 1), at); err != nil {
+		t.Errorf("MarkDutyAnnounced on a free day should be a no-op, got %v", err)
+	}
+	d, _ := s.GetDutyByDate(ctx, day)
+	if d.AnnouncedAt == nil || !d.AnnouncedAt.Equal(at) {
+		t.Errorf("GetDutyByDate: expected the duty announced at %v, got %v", at, d.AnnouncedAt)
+	}
+	if duties, _ := s.ListDuties(ctx, store.DutyFilter{}); len(duties) != 1 || duties[0].AnnouncedAt == nil || !duties[0].AnnouncedAt.Equal(at) {
+		t.Errorf("ListDuties: expected the duty announced at %v, got %+v", at, duties)
+	}
+
+	// Handing the duty to someone else doesn't change whether it was announced
+	d.UserID = bob.ID
+	if err := s.UpdateDuty(ctx, d); err != nil {
+		t.Fatalf("UpdateDuty failed: %v", err)
+	}
+	if d, _ := s.GetDutyByDate(ctx, day); d.AnnouncedAt == nil || !d.AnnouncedAt.Equal(at) {
+		t.Errorf("UpdateDuty: expected the duty still announced at %v, got %v", at, d.AnnouncedAt)
+	}
+
+	// A new duty on the day is new to the group too
+	if err := s.DeleteDuty(ctx, day); err != nil {
+		t.Fatalf("DeleteDuty failed: %v", err)
+	}
+	mustCreateDuty(t, s, alice.ID, day, store.AssignmentTypeRoundRobin)
+	if d, _ := s.GetDutyByDate(ctx, day); d.AnnouncedAt != nil {
+		t.Errorf("A duty created again must not be announced, got %v", d.AnnouncedAt)
+	}
+}
+
+func testDutiesByMonth(t *testing.T, s store.Store) {
+	ctx := context.Background()
+	alice := mustCreateUser(t, s, 1, "Alice", true)
+
+	// Boundaries: last day of June and first day of August are excluded.
+	for _, day := range []time.Time{
+		date(2025, time.July, 31),
+		date(2025, time.June, 30),
+		date(2025, time.July, 1),
+		date(2025, time.August, 1),
+		date(2025, time.July, 15),
+	} {
+		mustCreateDuty(t, s, alice.ID, day, store.AssignmentTypeRoundRobin)
+	}
+
+	duties, err := s.GetDutiesByMonth(ctx, 2025, time.July)
+	if err != nil {
+		t.Fatalf("GetDutiesByMonth failed: %v", err)
+	}
+	want := []string{"2025-07-01", "2025-07-15", "2025-07-31"}
+	var got []string
+	for _, d := range duties {
+		got = append(got, d.DutyDate.Format("2006-01-02"))
+		if d.User == nil || d.User.FirstName != "Alice" {
+			t.Errorf("GetDutiesByMonth: expected joined user on %s", d.DutyDate.Format("2006-01-02"))
+		}
+	}
+	if !equalStrings(got, want) {
+		t.Errorf("GetDutiesByMonth: expected %v, got %v", want, got)
+	}
+
+	if duties, _ := s.GetDutiesByMonth(ctx, 2025, time.March); len(duties) != 0 {
+		t.Errorf("GetDutiesByMonth on an empty month: expected none, got %d", len(duties))
+	}
+}
+
+func testListDuties(t *testing.T, s store.Store) {
+	ctx := context.Background()
+	alice := mustCreateUser(t, s, 1, "Alice", true)
+	bob := mustCreateUser(t, s, 2, "Bob", true)
+	for day := 1; day <= 6; day++ {
+		userID := alice.ID
+		if day%2 == 0 {
+			userID = bob.ID
+		}
+		mustCreateDuty(t, s, userID, date(2025, time.July, day), store.AssignmentTypeRoundRobin)
+	}
+
+	tests := []struct {
+		name   string
+		filter store.DutyFilter
+		want   []string
+	}{
+		{"all", store.DutyFilter{}, []string{"2025-07-01", "2025-07-02", "2025-07-03", "2025-07-04", "2025-07-05", "2025-07-06"}},
+		{"user", store.DutyFilter{UserID: bob.ID}, []string{"2025-07-02", "2025-07-04", "2025-07-06"}},
+		{"from", store.DutyFilter{UserID: alice.ID, From: date(2025, time.July, 3)}, []string{"2025-07-03", "2025-07-05"}},
+		{"to", store.DutyFilter{To: date(2025, time.July, 3)}, []string{"2025-07-01", "2025-07-02"}},
+		{"range", store.DutyFilter{UserID: bob.ID, From: date(2025, time.July, 3), To: date(2025, time.July, 6)}, []string{"2025-07-04"}},
+	}
+	for _, tt := range tests {
+		duties, err := s.ListDuties(ctx, tt.filter)
+		if err != nil {
+			t.Fatalf("ListDuties %s failed: %v", tt.name, err)
+		}
+		var got []string
+		for _, d := range duties {
+			got = append(got, d.DutyDate.Format("2006-01-02"))
+			if d.User == nil || d.User.ID != d.UserID {
+				t.Errorf("ListDuties %s: expected joined user on %s", tt.name, d.DutyDate.Format("2006-01-02"))
+			}
+		}
+		if !equalStrings(got, tt.want) {
+			t.Errorf("ListDuties %s: expected %v, got %v", tt.name, tt.want, got)
+		}
+	}
+}
+
+func testCompletedDuties(t *testing.T, s store.Store) {
+	ctx := context.Background()
+	alice := mustCreateUser(t, s, 1, "Alice", true)
+
+	for day := 1; day <= 5; day++ {
+		mustCreateDuty(t, s, alice.ID, date(2025, time.July, day), store.AssignmentTypeRoundRobin)
+	}
+	// Complete every day except the 3rd.
+	for _, day := range []int{1, 2, 4, 5} {
+		if _, err := s.CompleteDuty(ctx, date(2025, time.July, day)); err != nil {
+			t.Fatalf("CompleteDuty failed: %v", err)
+		}
+	}
+
+	// The range end is exclusive.
+	duties, err := s.GetCompletedDutiesInRange(ctx, date(2025, time.July, 2), date(2025, time.July, 5))
+	if err != nil {
+		t.Fatalf("GetCompletedDutiesInRange failed: %v", err)
+	}
+	var got []string
+	for _, d := range duties {
+		got = append(got, d.DutyDate.Format("2006-01-02"))
+		if d.CompletedAt == nil {
+			t.Errorf("GetCompletedDutiesInRange returned an uncompleted duty on %s", d.DutyDate.Format("2006-01-02"))
+		}
+	}
+	if want := []string{"2025-07-02", "2025-07-04"}; !equalStrings(got, want) {
+		t.Errorf("GetCompletedDutiesInRange: expected %v, got %v", want, got)
+	}
+}
+
+func testDutyChangeLog(t *testing.T, s store.Store) {
+	ctx := context.Background()
+	alice := mustCreateUser(t, s, 1, "Alice", true)
+	bob := mustCreateUser(t, s, 2, "Bob", true)
+	day := date(2025, time.July, 14)
+	changedAt := time.Date(2025, 7, 10, 9, 0, 0, 0, time.UTC)
+
+	// The store doesn't log its own writes, the history service does
+	duty := mustCreateDuty(t, s, alice.ID, day, store.AssignmentTypeRoundRobin)
+	if err := s.DeleteDuty(ctx, duty.DutyDate); err != nil {
+		t.Fatalf("DeleteDuty failed: %v", err)
+	}
+	if changes, err := s.GetRecentDutyChanges(ctx, 10); err != nil || len(changes) != 0 {
+		t.Fatalf("GetRecentDutyChanges: expected no changes yet, got %+v, %v", changes, err)
+	}
+
+	for i, c := range []*store.DutyChange{
+		{DutyDate: day, UserID: alice.ID, Action: store.DutyChangeAssigned, AssignmentType: store.AssignmentTypeRoundRobin},
+		{DutyDate: day, UserID: bob.ID, Action: store.DutyChangeReassigned, AssignmentType: store.AssignmentTypeAdmin},
+		{DutyDate: day, UserID: bob.ID, Action: store.DutyChangeRemoved, AssignmentType: store.AssignmentTypeAdmin},
+	} {
+		c.ChangedAt = changedAt.Add(time.Duration(i) * time.Minute)
+		if err := s.CreateDutyChange(ctx, c); err != nil {
+			t.Fatalf("CreateDutyChange failed: %v", err)
+		}
+		if c.ID == 0 {
+			t.Fatal("CreateDutyChange did not set the ID")
+		}
+	}
+
+	changes, err := s.GetRecentDutyChanges(ctx, 10)
+	if err != nil {
+		t.Fatalf("GetRecentDutyChanges failed: %v", err)
+	}
+	if len(changes) != 3 {
+		t.Fatalf("Expected 3 changes, got %d", len(changes))
+	}
+
+	expected := []struct {
+		action store.DutyChangeAction
+		user   string
+	}{
+		{store.DutyChangeRemoved, "Bob"},
+		{store.DutyChangeReassigned, "Bob"},
+		{store.DutyChangeAssigned, "Alice"},
+	}
+	for i, want := range expected {
+		if changes[i].Action != want.action || changes[i].UserName != want.user {
+			t.Errorf("Change %d: expected %s by %s, got %s by %s", i, want.action, want.user, changes[i].Action, changes[i].UserName)
+		}
+		if !changes[i].DutyDate.Equal(day) {
+			t.Errorf("Change %d: expected date %s, got %s", i, day.Format("2006-01-02"), changes[i].DutyDate.Format("2006-01-02"))
+		}
+	}
+	if c := changes[1]; c.AssignmentType != store.AssignmentTypeAdmin || !c.ChangedAt.Equal(changedAt.Add(time.Minute)) {
+		t.Errorf("Change 1: expected an admin duty changed at %v, got %+v", changedAt.Add(time.Minute), c)
+	}
+
+	if limited, _ := s.GetRecentDutyChanges(ctx, 1); len(limited) != 1 || limited[0].Action != store.DutyChangeRemoved {
+		t.Errorf("GetRecentDutyChanges with limit 1: expected the latest change, got %+v", limited)
+	}
+}
+
+func testQueues(t *testing.T, s store.Store) {
+	ctx := context.Background()
+	alice := mustCreateUser(t, s, 1, "Alice", true)
+	bob := mustCreateUser(t, s, 2, "Bob", true)
+	charlie := mustCreateUser(t, s, 3, "Charlie", false)
+
+	s.AddToVolunteerQueue(ctx, alice.ID, 1)
+	s.AddToVolunteerQueue(ctx, bob.ID, 3)
+	s.AddToVolunteerQueue(ctx, charlie.ID, 5) // Inactive, must not be listed
+	s.AddToAdminQueue(ctx, alice.ID, 2)
+
+	volunteers, err := s.GetUsersWithVolunteerQueue(ctx)
+	if err != nil {
+		t.Fatalf("GetUsersWithVolunteerQueue failed: %v", err)
+	}
+	if names := userNames(volunteers); !equalStrings(names, []string{"Bob", "Alice"}) {
+		t.Errorf("GetUsersWithVolunteerQueue: expected [Bob Alice] (largest queue first, active only), got %v", names)
+	}
+
+	admins, err := s.GetUsersWithAdminQueue(ctx)
+	if err != nil {
+		t.Fatalf("GetUsersWithAdminQueue failed: %v", err)
+	}
+	if names := userNames(admins); !equalStrings(names, []string{"Alice"}) {
+		t.Errorf("GetUsersWithAdminQueue: expected [Alice], got %v", names)
+	}
+
+	// Decrements floor at zero
+	for i := 0; i < 3; i++ {
+		if err := s.DecrementVolunteerQueue(ctx, alice.ID); err != nil {
+			t.Fatalf("DecrementVolunteerQueue failed: %v", err)
+		}
+		if err := s.DecrementAdminQueue(ctx, alice.ID); err != nil {
+			t.Fatalf("DecrementAdminQueue failed: %v", err)
+		}
+	}
+	got, _ := s.GetUserByTelegramID(ctx, 1)
+	if got.VolunteerQueueDays != 0 || got.AdminQueueDays != 0 {
+		t.Errorf("Expected queues to floor at 0, got %d/%d", got.VolunteerQueueDays, got.AdminQueueDays)
+	}
+
+	if admins, _ := s.GetUsersWithAdminQueue(ctx); len(admins) != 0 {
+		t.Errorf("Expected no users with an admin queue, got %v", userNames(admins))
+	}
+}
+
+func testQueueEvents(t *testing.T, s store.Store) {
+	ctx := context.Background()
+	alice := mustCreateUser(t, s, 1, "Alice", true)
+	bob := mustCreateUser(t, s, 2, "Bob", true)
+
+	s.AddToVolunteerQueue(ctx, alice.ID, 2)
+	s.AddToAdminQueue(ctx, bob.ID, 1)
+	s.DecrementVolunteerQueue(ctx, alice.ID)
+	s.DecrementAdminQueue(ctx, bob.ID)
+	s.DecrementAdminQueue(ctx, bob.ID) // Empty already, nothing consumed
+
+	events, err := s.ListQueueEvents(ctx, 0)
+	if err != nil {
+		t.Fatalf("ListQueueEvents failed: %v", err)
+	}
+	if len(events) != 4 {
+		t.Fatalf("ListQueueEvents: expected 4 events, got %d", len(events))
+	}
+	if e := events[0]; e.UserID != alice.ID || e.Queue != store.AssignmentTypeVoluntary || e.Days != 2 || e.At.IsZero() {
+		t.Errorf("ListQueueEvents: expected Alice's 2 volunteered days first, got %+v", e)
+	}
+	if e := events[3]; e.UserID != bob.ID || e.Queue != store.AssignmentTypeAdmin || e.Days != -1 {
+		t.Errorf("ListQueueEvents: expected Bob's consumed admin day last, got %+v", e)
+	}
+
+	events, _ = s.ListQueueEvents(ctx, alice.ID)
+	if len(events) != 2 || events[1].Days != -1 {
+		t.Errorf("ListQueueEvents(Alice): expected her added and consumed days, got %+v", events)
+	}
+
+	// Events follow a merged user
+	if _, err := s.MergeUsers(ctx, bob.ID, alice.ID, time.Now()); err != nil {
+		t.Fatalf("MergeUsers failed: %v", err)
+	}
+	if events, _ = s.ListQueueEvents(ctx, alice.ID); len(events) != 4 {
+		t.Errorf("ListQueueEvents: expected Bob's events to be Alice's after the merge, got %+v", events)
+	}
+}
+
+func testOffDuty(t *testing.T, s store.Store) {
+	ctx := context.Background()
+	alice := mustCreateUser(t, s, 1, "Alice", true)
+	mustCreateUser(t, s, 2, "Bob", true)
+
+	start, end := date(2025, time.July, 10), date(2025, time.July, 12)
+	if err := s.SetOffDuty(ctx, alice.ID, start, end); err != nil {
+		t.Fatalf("SetOffDuty failed: %v", err)
+	}
+
+	// Both boundaries are inclusive.
+	cases := map[time.Time]bool{
+		start.AddDate(0, 0, -1):   false,
+		start:                     true,
+		date(2025, time.July, 11): true,
+		end:                       true,
+		end.AddDate(0, 0, 1):      false,
+	}
+	for day, want := range cases {
+		got, err := s.IsUserOffDuty(ctx, alice.ID, day)
+		if err != nil {
+			t.Fatalf("IsUserOffDuty failed: %v", err)
+		}
+		if got != want {
+			t.Errorf("IsUserOffDuty(%s): expected %v, got %v", day.Format("2006-01-02"), want, got)
+		}
+	}
+
+	got, _ := s.GetUserByTelegramID(ctx, 1)
+	if got.OffDutyStart == nil || got.OffDutyEnd == nil || !got.OffDutyStart.Equal(start) || !got.OffDutyEnd.Equal(end) {
+		t.Errorf("SetOffDuty: period not stored on user, got %v - %v", got.OffDutyStart, got.OffDutyEnd)
+	}
+
+	offDuty, err := s.GetOffDutyUsers(ctx, end)
+	if err != nil {
+		t.Fatalf("GetOffDutyUsers failed: %v", err)
+	}
+	if names := userNames(offDuty); !equalStrings(names, []string{"Alice"}) {
+		t.Errorf("GetOffDutyUsers: expected [Alice], got %v", names)
+	}
+
+	if err := s.ClearOffDuty(ctx, alice.ID); err != nil {
+		t.Fatalf("ClearOffDuty failed: %v", err)
+	}
+	if off, _ := s.IsUserOffDuty(ctx, alice.ID, start); off {
+		t.Error("Expected Alice to be available after ClearOffDuty")
+	}
+	if offDuty, _ := s.GetOffDutyUsers(ctx, start); len(offDuty) != 0 {
+		t.Errorf("Expected no off-duty users after ClearOffDuty, got %v", userNames(offDuty))
+	}
+}
+
+func testOffDutyPeriods(t *testing.T, s store.Store) {
+	ctx := context.Background()
+	alice := mustCreateUser(t, s, 1, "Alice", true)
+
+	if periods, err := s.ListOffDutyPeriods(ctx, alice.ID); err != nil || len(periods) != 0 {
+		t.Errorf("ListOffDutyPeriods: expected none, got (%v, %v)", periods, err)
+	}
+
+	imported := []*store.OffDutyPeriod{
+		{StartDate: date(2025, time.August, 1), EndDate: date(2025, time.August, 3), ExternalID: "b", Summary: "Trip"},
+		{StartDate: date(2025, time.July, 10), EndDate: date(2025, time.July, 12), ExternalID: "a", Summary: "Vacation"},
+	}
+	if err := s.ReplaceOffDutyPeriods(ctx, alice.ID, store.OffDutySourceICal, imported); err != nil {
+		t.Fatalf("ReplaceOffDutyPeriods failed: %v", err)
+	}
+	if err := s.ReplaceOffDutyPeriods(ctx, alice.ID, "manual", []*store.OffDutyPeriod{
+		{StartDate: date(2025, time.September, 1), EndDate: date(2025, time.September, 1)},
+	}); err != nil {
+		t.Fatalf("ReplaceOffDutyPeriods failed: %v", err)
+	}
+
+	periods, err := s.ListOffDutyPeriods(ctx, alice.ID)
+	if err != nil {
+		t.Fatalf("ListOffDutyPeriods failed: %v", err)
+	}
+	if len(periods) != 3 {
+		t.Fatalf("Expected 3 periods, got %d", len(periods))
+	}
+	if periods[0].ExternalID != "a" || periods[0].Source != store.OffDutySourceICal || periods[0].UserID != alice.ID || periods[0].ID == 0 {
+		t.Errorf("Expected periods ordered by start date with IDs set, got %+v", periods[0])
+	}
+
+	for day, want := range map[time.Time]bool{
+		date(2025, time.July, 9):      false,
+		date(2025, time.July, 10):     true,
+		date(2025, time.July, 12):     true,
+		date(2025, time.July, 13):     false,
+		date(2025, time.September, 1): true,
+	} {
+		if got, _ := s.IsUserOffDuty(ctx, alice.ID, day); got != want {
+			t.Errorf("IsUserOffDuty(%s) with imported periods: expected %v, got %v", day.Format("2006-01-02"), want, got)
+		}
+	}
+	if offDuty, _ := s.GetOffDutyUsers(ctx, date(2025, time.August, 2)); len(offDuty) != 1 {
+		t.Errorf("GetOffDutyUsers: expected Alice from an imported period, got %v", userNames(offDuty))
+	}
+
+	// Replacing one source leaves the others untouched
+	if err := s.ReplaceOffDutyPeriods(ctx, alice.ID, store.OffDutySourceICal, nil); err != nil {
+		t.Fatalf("ReplaceOffDutyPeriods failed: %v", err)
+	}
+	periods, _ = s.ListOffDutyPeriods(ctx, alice.ID)
+	if len(periods) != 1 || periods[0].Source != "manual" {
+		t.Errorf("Expected only the manual period to remain, got %+v", periods)
+	}
+}
+
+func testCalendarLinks(t *testing.T, s store.Store) {
+	ctx := context.Background()
+	alice := mustCreateUser(t, s, 1, "Alice", true)
+	bob := mustCreateUser(t, s, 2, "Bob", true)
+
+	if link, err := s.GetCalendarLink(ctx, alice.ID); err != nil || link != nil {
+		t.Errorf("GetCalendarLink without a link: expected (nil, nil), got (%v, %v)", link, err)
+	}
+
+	if err := s.SetCalendarLink(ctx, &store.CalendarLink{UserID: bob.ID, URL: "https://example.com/bob.ics"}); err != nil {
+		t.Fatalf("SetCalendarLink failed: %v", err)
+	}
+	if err := s.SetCalendarLink(ctx, &store.CalendarLink{UserID: alice.ID, URL: "https://example.com/old.ics"}); err != nil {
+		t.Fatalf("SetCalendarLink failed: %v", err)
+	}
+
+	// Setting again updates in place
+	synced := time.Date(2025, 7, 1, 12, 0, 0, 0, time.UTC)
+	if err := s.SetCalendarLink(ctx, &store.CalendarLink{UserID: alice.ID, URL: "https://example.com/alice.ics", LastSyncedAt: &synced, LastError: "boom"}); err != nil {
+		t.Fatalf("SetCalendarLink failed: %v", err)
+	}
+	link, err := s.GetCalendarLink(ctx, alice.ID)
+	if err != nil || link == nil {
+		t.Fatalf("GetCalendarLink: expected a link, got (%v, %v)", link, err)
+	}
+	if link.URL != "https://example.com/alice.ics" || link.LastError != "boom" || link.LastSyncedAt == nil || !link.LastSyncedAt.Equal(synced) {
+		t.Errorf("GetCalendarLink: unexpected link %+v", link)
+	}
+
+	links, err := s.ListCalendarLinks(ctx)
+	if err != nil {
+		t.Fatalf("ListCalendarLinks failed: %v", err)
+	}
+	if len(links) != 2 || links[0].UserID != alice.ID || links[1].UserID != bob.ID {
+		t.Errorf("ListCalendarLinks: expected Alice and Bob ordered by user ID, got %+v", links)
+	}
+
+	// Unlinking removes imported periods but keeps other sources
+	s.ReplaceOffDutyPeriods(ctx, alice.ID, store.OffDutySourceICal, []*store.OffDutyPeriod{{StartDate: date(2025, time.July, 1), EndDate: date(2025, time.July, 2)}})
+	s.ReplaceOffDutyPeriods(ctx, alice.ID, "manual", []*store.OffDutyPeriod{{StartDate: date(2025, time.July, 5), EndDate: date(2025, time.July, 5)}})
+	if err := s.DeleteCalendarLink(ctx, alice.ID); err != nil {
+		t.Fatalf("DeleteCalendarLink failed: %v", err)
+	}
+	if link, _ := s.GetCalendarLink(ctx, alice.ID); link != nil {
+		t.Error("DeleteCalendarLink: link still present")
+	}
+	periods, _ := s.ListOffDutyPeriods(ctx, alice.ID)
+	if len(periods) != 1 || periods[0].Source != "manual" {
+		t.Errorf("DeleteCalendarLink: expected only the manual period to remain, got %+v", periods)
+	}
+}
+
+func testNotificationPreferences(t *testing.T, s store.Store) {
+	ctx := context.Background()
+	alice := mustCreateUser(t, s, 1, "Alice", true)
+
+	if prefs, err := s.GetNotificationPreferences(ctx, alice.ID); err != nil || prefs != nil {
+		t.Errorf("GetNotificationPreferences without settings: expected (nil, nil), got (%v, %v)", prefs, err)
+	}
+
+	prefs := store.DefaultNotificationPreferences(alice.ID)
+	prefs.GroupReminder = true
+	prefs.WeeklyStats = false
+	prefs.ReminderHour = 18
+	if err := s.SetNotificationPreferences(ctx, prefs); err != nil {
+		t.Fatalf("SetNotificationPreferences failed: %v", err)
+	}
+	got, err := s.GetNotificationPreferences(ctx, alice.ID)
+	if err != nil || got == nil || *got != *prefs {
+		t.Fatalf("GetNotificationPreferences: expected %+v, got (%+v, %v)", prefs, got, err)
+	}
+
+	// Setting again replaces the previous values
+	prefs.PersonalDM = false
+	prefs.ReminderHour = 12
+	if err := s.SetNotificationPreferences(ctx, prefs); err != nil {
+		t.Fatalf("SetNotificationPreferences failed: %v", err)
+	}
+	if got, _ := s.GetNotificationPreferences(ctx, alice.ID); got == nil || *got != *prefs {
+		t.Errorf("GetNotificationPreferences after update: expected %+v, got %+v", prefs, got)
+	}
+}
+
+func testChangeSubscriptions(t *testing.T, s store.Store) {
+	ctx := context.Background()
+	alice := mustCreateUser(t, s, 1, "Alice", true)
+
+	if sub, err := s.GetChangeSubscription(ctx, alice.ID); err != nil || sub != nil {
+		t.Errorf("GetChangeSubscription without subscribing: expected (nil, nil), got (%v, %v)", sub, err)
+	}
+
+	first := time.Date(2025, 10, 27, 9, 30, 0, 0, time.UTC)
+	if err := s.CreateChangeSubscription(ctx, &store.ChangeSubscription{UserID: alice.ID, CreatedAt: first}); err != nil {
+		t.Fatalf("CreateChangeSubscription failed: %v", err)
+	}
+	// Subscribing again keeps the original subscription
+	if err := s.CreateChangeSubscription(ctx, &store.ChangeSubscription{UserID: alice.ID, CreatedAt: first.Add(time.Hour)}); err != nil {
+		t.Fatalf("CreateChangeSubscription again failed: %v", err)
+	}
+	sub, err := s.GetChangeSubscription(ctx, alice.ID)
+	if err != nil || sub == nil || sub.UserID != alice.ID || !sub.CreatedAt.Equal(first) {
+		t.Fatalf("GetChangeSubscription: expected user %d since %v, got (%+v, %v)", alice.ID, first, sub, err)
+	}
+
+	if err := s.DeleteChangeSubscription(ctx, alice.ID); err != nil {
+		t.Fatalf("DeleteChangeSubscription failed: %v", err)
+	}
+	if sub, err := s.GetChangeSubscription(ctx, alice.ID); err != nil || sub != nil {
+		t.Errorf("GetChangeSubscription after deleting: expected (nil, nil), got (%v, %v)", sub, err)
+	}
+}
+
+func testReminderSnoozes(t *testing.T, s store.Store) {
+	ctx := context.Background()
+	alice := mustCreateUser(t, s, 1, "Alice", true)
+	day := date(2025, time.October, 27)
+	later := &store.ReminderSnooze{UserID: alice.ID, DutyDate: day, RemindAt: time.Date(2025, 10, 27, 14, 0, 0, 0, time.UTC)}
+	sooner := &store.ReminderSnooze{UserID: alice.ID, DutyDate: day, RemindAt: time.Date(2025, 10, 27, 12, 0, 0, 0, time.UTC)}
+
+	for _, snooze := range []*store.ReminderSnooze{later, sooner} {
+		if err := s.CreateReminderSnooze(ctx, snooze); err != nil {
+			t.Fatalf("CreateReminderSnooze failed: %v", err)
+		}
+		if snooze.ID == 0 {
+			t.Fatal("CreateReminderSnooze did not set the ID")
+		}
+	}
+
+	snoozes, err := s.ListReminderSnoozes(ctx)
+	if err != nil {
+		t.Fatalf("ListReminderSnoozes failed: %v", err)
+	}
+	if len(snoozes) != 2 || snoozes[0].ID != sooner.ID || snoozes[1].ID != later.ID {
+		t.Fatalf("ListReminderSnoozes: expected [%d %d] earliest first, got %+v", sooner.ID, later.ID, snoozes)
+	}
+	if got := snoozes[0]; got.UserID != alice.ID || !got.DutyDate.Equal(day) || !got.RemindAt.Equal(sooner.RemindAt) {
+		t.Errorf("ListReminderSnoozes: expected %+v, got %+v", sooner, got)
+	}
+
+	if err := s.DeleteReminderSnooze(ctx, sooner.ID); err != nil {
+		t.Fatalf("DeleteReminderSnooze failed: %v", err)
+	}
+	if err := s.DeleteReminderSnooze(ctx, sooner.ID); err != nil {
+		t.Errorf("DeleteReminderSnooze of a missing snooze should not fail: %v", err)
+	}
+	if snoozes, _ := s.ListReminderSnoozes(ctx); len(snoozes) != 1 || snoozes[0].ID != later.ID {
+		t.Errorf("ListReminderSnoozes after delete: expected only %d, got %+v", later.ID, snoozes)
+	}
+}
+
+func testPendingMessages(t *testing.T, s store.Store) {
+	ctx := context.Background()
+	newer := &store.PendingMessage{ChatID: -100, Text: "🔄 2 duty changes", CreatedAt: time.Date(2025, 10, 27, 21, 5, 0, 0, time.UTC)}
+	older := &store.PendingMessage{ChatID: -100, Text: "🔄 Mon, Oct 27: @Bob is now on duty", CreatedAt: time.Date(2025, 10, 27, 21, 0, 0, 0, time.UTC)}
+
+	for _, msg := range []*store.PendingMessage{newer, older} {
+		if err := s.CreatePendingMessage(ctx, msg); err != nil {
+			t.Fatalf("CreatePendingMessage failed: %v", err)
+		}
+		if msg.ID == 0 {
+			t.Fatal("CreatePendingMessage did not set the ID")
+		}
+	}
+
+	msgs, err := s.ListPendingMessages(ctx)
+	if err != nil {
+		t.Fatalf("ListPendingMessages failed: %v", err)
+	}
+	if len(msgs) != 2 || msgs[0].ID != older.ID || msgs[1].ID != newer.ID {
+		t.Fatalf("ListPendingMessages: expected [%d %d] oldest first, got %+v", older.ID, newer.ID, msgs)
+	}
+	if got := msgs[0]; got.ChatID != older.ChatID || got.Text != older.Text || !got.CreatedAt.Equal(older.CreatedAt) {
+		t.Errorf("ListPendingMessages: expected %+v, got %+v", older, got)
+	}
+
+	if err := s.DeletePendingMessage(ctx, older.ID); err != nil {
+		t.Fatalf("DeletePendingMessage failed: %v", err)
+	}
+	if err := s.DeletePendingMessage(ctx, older.ID); err != nil {
+		t.Errorf("DeletePendingMessage of a missing message should not fail: %v", err)
+	}
+	if msgs, _ := s.ListPendingMessages(ctx); len(msgs) != 1 || msgs[0].ID != newer.ID {
+		t.Errorf("ListPendingMessages after delete: expected only %d, got %+v", newer.ID, msgs)
+	}
+}
+
+func testInteractiveMessages(t *testing.T, s store.Store) {
+	ctx := context.Background()
+	sentAt := time.Date(2025, 10, 27, 21, 0, 0, 0, time.UTC)
+	older := &store.InteractiveMessage{ChatID: -100, MessageID: 7, SentAt: sentAt}
+	newer := &store.InteractiveMessage{ChatID: 42, MessageID: 3, SentAt: sentAt.Add(time.Minute)}
+	for _, msg := range []*store.InteractiveMessage{newer, older} {
+		if err := s.TrackInteractiveMessage(ctx, msg); err != nil {
+			t.Fatalf("TrackInteractiveMessage failed: %v", err)
+		}
+	}
+
+	doneAt := sentAt.Add(2 * time.Minute)
+	if err := s.MarkInteractiveMessageDone(ctx, -100, 7, doneAt); err != nil {
+		t.Fatalf("MarkInteractiveMessageDone failed: %v", err)
+	}
+	if err := s.MarkInteractiveMessageDone(ctx, -100, 8, doneAt); err != nil {
+		t.Errorf("MarkInteractiveMessageDone of an untracked message should not fail: %v", err)
+	}
+
+	msgs, err := s.ListInteractiveMessages(ctx)
+	if err != nil {
+		t.Fatalf("ListInteractiveMessages failed: %v", err)
+	}
+	if len(msgs) != 2 || msgs[0].MessageID != 7 || msgs[1].MessageID != 3 {
+		t.Fatalf("ListInteractiveMessages: expected messages 7 and 3 oldest first, got %+v", msgs)
+	}
+	if got := msgs[0]; got.ChatID != -100 || !got.SentAt.Equal(sentAt) || got.DoneAt == nil || !got.DoneAt.Equal(doneAt) {
+		t.Errorf("ListInteractiveMessages: expected message 7 done at %v, got %+v", doneAt, got)
+	}
+	if msgs[1].DoneAt != nil {
+		t.Errorf("ListInteractiveMessages: expected message 3 to be open, got %+v", msgs[1])
+	}
+
+	// Tracking a message again reopens it
+	if err := s.TrackInteractiveMessage(ctx, older); err != nil {
+		t.Fatalf("TrackInteractiveMessage failed: %v", err)
+	}
+	if msgs, _ := s.ListInteractiveMessages(ctx); len(msgs) != 2 || msgs[0].DoneAt != nil {
+		t.Errorf("ListInteractiveMessages after tracking again: expected message 7 open, got %+v", msgs)
+	}
+
+	if err := s.DeleteInteractiveMessage(ctx, -100, 7); err != nil {
+		t.Fatalf("DeleteInteractiveMessage failed: %v", err)
+	}
+	if err := s.DeleteInteractiveMessage(ctx, -100, 7); err != nil {
+		t.Errorf("DeleteInteractiveMessage of a missing message should not fail: %v", err)
+	}
+	if msgs, _ := s.ListInteractiveMessages(ctx); len(msgs) != 1 || msgs[0].MessageID != 3 {
+		t.Errorf("ListInteractiveMessages after delete: expected only message 3, got %+v", msgs)
+	}
+}
+
+func testConversationStates(t *testing.T, s store.Store) {
+	ctx := context.Background()
+	now := time.Date(2025, 10, 27, 21, 0, 0, 0, time.UTC)
+	states := []*store.ConversationState{
+		{Key: "menu:42:3", Value: `{"owner":1}`, ExpiresAt: now.Add(time.Hour)},
+		{Key: "import:1", Value: `{"users":[1]}`, ExpiresAt: now.Add(time.Minute)},
+		{Key: "menu:42:1", Value: `{"owner":2}`, ExpiresAt: now},
+	}
+	for _, state := range states {
+		if err := s.SaveConversationState(ctx, state); err != nil {
+			t.Fatalf("SaveConversationState failed: %v", err)
+		}
+	}
+
+	got, err := s.ListConversationStates(ctx, now)
+	if err != nil {
+		t.Fatalf("ListConversationStates failed: %v", err)
+	}
+	if len(got) != 2 || got[0].Key != "import:1" || got[1].Key != "menu:42:3" {
+		t.Fatalf("ListConversationStates: expected import:1 and menu:42:3 by key without the expired one, got %+v", got)
+	}
+	if got[1].Value != `{"owner":1}` || !got[1].ExpiresAt.Equal(now.Add(time.Hour)) {
+		t.Errorf("ListConversationStates: expected menu:42:3 as saved, got %+v", got[1])
+	}
+
+	// Saving a key again replaces its state
+	if err := s.SaveConversationState(ctx, &store.ConversationState{Key: "menu:42:3", Value: `{"owner":3}`, ExpiresAt: now.Add(2 * time.Hour)}); err != nil {
+		t.Fatalf("SaveConversationState failed: %v", err)
+	}
+	if got, _ := s.ListConversationStates(ctx, now); len(got) != 2 || got[1].Value != `{"owner":3}` || !got[1].ExpiresAt.Equal(now.Add(2*time.Hour)) {
+		t.Errorf("ListConversationStates after saving again: expected menu:42:3 replaced, got %+v", got)
+	}
+
+	if err := s.DeleteConversationState(ctx, "import:1"); err != nil {
+		t.Fatalf("DeleteConversationState failed: %v", err)
+	}
+	if err := s.DeleteConversationState(ctx, "import:1"); err != nil {
+		t.Errorf("DeleteConversationState of a missing state should not fail: %v", err)
+	}
+	if err := s.DeleteExpiredConversationStates(ctx, now.Add(time.Hour)); err != nil {
+		t.Fatalf("DeleteExpiredConversationStates failed: %v", err)
+	}
+	if got, _ := s.ListConversationStates(ctx, now.Add(-time.Hour)); len(got) != 1 || got[0].Key != "menu:42:3" {
+		t.Errorf("ListConversationStates after deleting: expected only menu:42:3, got %+v", got)
+	}
+}
+
+func testChatLocales(t *testing.T, s store.Store) {
+	ctx := context.Background()
+
+	if locale, err := s.GetChatLocale(ctx, -100); err != nil || locale != "" {
+		t.Errorf("GetChatLocale without a locale: expected (\"\", nil), got (%q, %v)", locale, err)
+	}
+	if err := s.SetChatLocale(ctx, -100, "de"); err != nil {
+		t.Fatalf("SetChatLocale failed: %v", err)
+	}
+	if err := s.SetChatLocale(ctx, 42, "en"); err != nil {
+		t.Fatalf("SetChatLocale failed: %v", err)
+	}
+	if locale, err := s.GetChatLocale(ctx, -100); err != nil || locale != "de" {
+		t.Errorf("GetChatLocale: expected de, got (%q, %v)", locale, err)
+	}
+	// Setting it again replaces it
+	if err := s.SetChatLocale(ctx, -100, "en"); err != nil {
+		t.Fatalf("SetChatLocale again failed: %v", err)
+	}
+	if locale, err := s.GetChatLocale(ctx, -100); err != nil || locale != "en" {
+		t.Errorf("GetChatLocale after replacing: expected en, got (%q, %v)", locale, err)
+	}
+}
+
+func testChatDisplay(t *testing.T, s store.Store) {
+	ctx := context.Background()
+
+	if d, err := s.GetChatDisplay(ctx, -100); err != nil || d != nil {
+		t.Errorf("GetChatDisplay without a display: expected (nil, nil), got (%+v, %v)", d, err)
+	}
+	if err := s.SetChatDisplay(ctx, -100, store.ChatDisplay{WeekNumbers: true}); err != nil {
+		t.Fatalf("SetChatDisplay failed: %v", err)
+	}
+	if d, err := s.GetChatDisplay(ctx, -100); err != nil || d == nil || *d != (store.ChatDisplay{WeekNumbers: true}) {
+		t.Errorf("GetChatDisplay: expected week numbers only, got (%+v, %v)", d, err)
+	}
+	// Setting it again replaces it
+	if err := s.SetChatDisplay(ctx, -100, store.ChatDisplay{AdjacentDays: true}); err != nil {
+		t.Fatalf("SetChatDisplay again failed: %v", err)
+	}
+	if d, err := s.GetChatDisplay(ctx, -100); err != nil || d == nil || *d != (store.ChatDisplay{AdjacentDays: true}) {
+		t.Errorf("GetChatDisplay after replacing: expected adjacent days only, got (%+v, %v)", d, err)
+	}
+	if d, err := s.GetChatDisplay(ctx, 42); err != nil || d != nil {
+		t.Errorf("GetChatDisplay of another chat: expected (nil, nil), got (%+v, %v)", d, err)
+	}
+}
+
+func testSettings(t *testing.T, s store.Store) {
+	ctx := context.Background()
+
+	if value, err := s.GetSetting(ctx, "group_chat_id"); err != nil || value != "" {
+		t.Errorf("GetSetting without a value: expected (\"\", nil), got (%q, %v)", value, err)
+	}
+	if err := s.SetSetting(ctx, "group_chat_id", "-100"); err != nil {
+		t.Fatalf("SetSetting failed: %v", err)
+	}
+	if err := s.SetSetting(ctx, "admin_ids", "1,2"); err != nil {
+		t.Fatalf("SetSetting failed: %v", err)
+	}
+	if value, err := s.GetSetting(ctx, "group_chat_id"); err != nil || value != "-100" {
+		t.Errorf("GetSetting: expected -100, got (%q, %v)", value, err)
+	}
+	// Setting it again replaces it
+	if err := s.SetSetting(ctx, "group_chat_id", "-200"); err != nil {
+		t.Fatalf("SetSetting again failed: %v", err)
+	}
+	if value, err := s.GetSetting(ctx, "group_chat_id"); err != nil || value != "-200" {
+		t.Errorf("GetSetting after replacing: expected -200, got (%q, %v)", value, err)
+	}
+	if values, err := s.ListSettings(ctx); err != nil || len(values) != 2 || values["group_chat_id"] != "-200" || values["admin_ids"] != "1,2" {
+		t.Errorf("ListSettings: expected group_chat_id and admin_ids, got (%v, %v)", values, err)
+	}
+}
+
+func testHolds(t *testing.T, s store.Store) {
+	ctx := context.Background()
+	user := &store.User{TelegramUserID: 1, FirstName: "Alice", IsActive: true}
+	if err := s.CreateUser(ctx, user); err != nil {
+		t.Fatalf("CreateUser failed: %v", err)
+	}
+
+	friday := date(2025, time.October, 31)
+	thursday := date(2025, time.October, 30)
+	held := &store.Duty{UserID: user.ID, DutyDate: date(2025, time.November, 3), AssignmentType: store.AssignmentTypeAdmin, CreatedAt: time.Now(), HoldUntil: &friday}
+	if err := s.CreateDuty(ctx, held); err != nil {
+		t.Fatalf("CreateDuty failed: %v", err)
+	}
+	if err := s.CreateDuty(ctx, &store.Duty{UserID: user.ID, DutyDate: date(2025, time.November, 4), AssignmentType: store.AssignmentTypeAdmin, CreatedAt: time.Now()}); err != nil {
+		t.Fatalf("CreateDuty failed: %v", err)
+	}
+
+	got, _ := s.GetDutyByDate(ctx, held.DutyDate)
+	if got == nil || got.HoldUntil == nil || !got.HoldUntil.Equal(friday) {
+		t.Fatalf("GetDutyByDate: expected a hold until %v, got %+v", friday, got)
+	}
+	if duties, _ := s.GetDutiesByMonth(ctx, 2025, time.November); len(duties) != 2 || duties[0].HoldUntil == nil || duties[1].HoldUntil != nil {
+		t.Errorf("GetDutiesByMonth: expected only the first duty to be held, got %+v", duties)
+	}
+
+	// The hold lasts through Friday
+	if expired, err := s.GetExpiredHolds(ctx, friday); err != nil || len(expired) != 0 {
+		t.Errorf("GetExpiredHolds on the last day: expected none, got %+v (err %v)", expired, err)
+	}
+	expired, err := s.GetExpiredHolds(ctx, friday.AddDate(0, 0, 1))
+	if err != nil {
+		t.Fatalf("GetExpiredHolds failed: %v", err)
+	}
+	if len(expired) != 1 || !expired[0].DutyDate.Equal(held.DutyDate) || expired[0].User == nil {
+		t.Errorf("GetExpiredHolds: expected the held duty with its user, got %+v", expired)
+	}
+
+	// UpdateDuty moves or clears the hold
+	got.HoldUntil = &thursday
+	if err := s.UpdateDuty(ctx, got); err != nil {
+		t.Fatalf("UpdateDuty failed: %v", err)
+	}
+	if d, _ := s.GetDutyByDate(ctx, held.DutyDate); d.HoldUntil == nil || !d.HoldUntil.Equal(thursday) {
+		t.Errorf("UpdateDuty: expected the hold to move to %v, got %+v", thursday, d.HoldUntil)
+	}
+	got.HoldUntil = nil
+	if err := s.UpdateDuty(ctx, got); err != nil {
+		t.Fatalf("UpdateDuty failed: %v", err)
+	}
+	if expired, _ := s.GetExpiredHolds(ctx, date(2025, time.December, 1)); len(expired) != 0 {
+		t.Errorf("GetExpiredHolds after confirming: expected none, got %+v", expired)
+	}
+}
+
+func testShadowComparisons(t *testing.T, s store.Store) {
+	ctx := context.Background()
+	createdAt := time.Date(2025, 10, 28, 11, 0, 0, 0, time.UTC)
+	later := &store.ShadowComparison{Date: date(2025, time.October, 28), Strategy: "window30", LiveUserID: 1, ShadowUserID: 2, CreatedAt: createdAt}
+	earlier := &store.ShadowComparison{Date: date(2025, time.October, 27), Strategy: "window30", LiveUserID: 1, ShadowUserID: 1, CreatedAt: createdAt}
+	old := &store.ShadowComparison{Date: date(2025, time.September, 30), Strategy: "window30", LiveUserID: 2, ShadowUserID: 1, CreatedAt: createdAt}
+
+	for _, c := range []*store.ShadowComparison{later, earlier, old} {
+		if err := s.CreateShadowComparison(ctx, c); err != nil {
+			t.Fatalf("CreateShadowComparison failed: %v", err)
+		}
+		if c.ID == 0 {
+			t.Fatal("CreateShadowComparison did not set the ID")
+		}
+	}
+
+	got, err := s.ListShadowComparisons(ctx, date(2025, time.October, 1))
+	if err != nil {
+		t.Fatalf("ListShadowComparisons failed: %v", err)
+	}
+	if len(got) != 2 || got[0].ID != earlier.ID || got[1].ID != later.ID {
+		t.Fatalf("ListShadowComparisons: expected [%d %d] oldest first, got %+v", earlier.ID, later.ID, got)
+	}
+	c := got[1]
+	if !c.Date.Equal(later.Date) || c.Strategy != "window30" || c.LiveUserID != 1 || c.ShadowUserID != 2 || !c.CreatedAt.Equal(createdAt) {
+		t.Errorf("ListShadowComparisons: expected %+v, got %+v", later, c)
+	}
+}
+
+func testAssignmentExplanations(t *testing.T, s store.Store) {
+	ctx := context.Background()
+	alice := mustCreateUser(t, s, 1, "Alice", true)
+	bob := mustCreateUser(t, s, 2, "Bob", true)
+	charlie := mustCreateUser(t, s, 3, "Charlie", true)
+	day := date(2025, time.November, 5)
+	last := date(2025, time.November, 1)
+	createdAt := time.Date(2025, 11, 5, 10, 0, 0, 0, time.UTC)
+
+	if e, err := s.GetAssignmentExplanation(ctx, day); err != nil || e != nil {
+		t.Fatalf("GetAssignmentExplanation: expected nothing yet, got %+v, %v", e, err)
+	}
+
+	e := &store.AssignmentExplanation{
+		Date: day, UserID: bob.ID, AssignmentType: store.AssignmentTypeRoundRobin, Strategy: "window14", Reason: "Fewest duties", CreatedAt: createdAt,
+		Candidates: []store.AssignmentCandidate{
+			{UserID: alice.ID, Excluded: "off_duty"},
+			{UserID: charlie.ID, RecentDuties: 2, LastDuty: &last},
+			{UserID: bob.ID, RecentDuties: 1, VolunteerQueueDays: 1},
+		},
+	}
+	if err := s.SaveAssignmentExplanation(ctx, e); err != nil {
+		t.Fatalf("SaveAssignmentExplanation failed: %v", err)
+	}
+	got, err := s.GetAssignmentExplanation(ctx, day)
+	if err != nil || got == nil {
+		t.Fatalf("GetAssignmentExplanation failed: %+v, %v", got, err)
+	}
+	if got.UserID != bob.ID || got.AssignmentType != store.AssignmentTypeRoundRobin || got.Strategy != "window14" ||
+		got.Reason != "Fewest duties" || !got.CreatedAt.Equal(createdAt) || !got.Date.Equal(day) {
+		t.Errorf("GetAssignmentExplanation: expected %+v, got %+v", e, got)
+	}
+	// Those the winner was picked from first, then by user
+	if len(got.Candidates) != 3 || got.Candidates[0].UserID != bob.ID || got.Candidates[1].UserID != charlie.ID || got.Candidates[2].Excluded != "off_duty" {
+		t.Fatalf("GetAssignmentExplanation: expected Bob, Charlie and off-duty Alice, got %+v", got.Candidates)
+	}
+	if c := got.Candidates[1]; c.RecentDuties != 2 || c.LastDuty == nil || !c.LastDuty.Equal(last) || got.Candidates[0].VolunteerQueueDays != 1 {
+		t.Errorf("GetAssignmentExplanation: expected the counts to be kept, got %+v", got.Candidates)
+	}
+
+	// Saving the day again replaces it
+	e.UserID, e.Candidates = charlie.ID, e.Candidates[1:2]
+	if err := s.SaveAssignmentExplanation(ctx, e); err != nil {
+		t.Fatalf("SaveAssignmentExplanation failed: %v", err)
+	}
+	if got, _ = s.GetAssignmentExplanation(ctx, day); got.UserID != charlie.ID || len(got.Candidates) != 1 {
+		t.Errorf("GetAssignmentExplanation: expected Charlie alone after saving again, got %+v", got)
+	}
+
+	// Explanations follow a merged user
+	if _, err := s.MergeUsers(ctx, charlie.ID, alice.ID, createdAt); err != nil {
+		t.Fatalf("MergeUsers failed: %v", err)
+	}
+	if got, _ = s.GetAssignmentExplanation(ctx, day); got.UserID != alice.ID || got.Candidates[0].UserID != alice.ID {
+		t.Errorf("GetAssignmentExplanation: expected Charlie to be Alice after the merge, got %+v", got)
+	}
+}
+
+func testMaintenance(t *testing.T, s store.Store) {
+	ctx := context.Background()
+	alice := mustCreateUser(t, s, 1, "Alice", true)
+	bob := mustCreateUser(t, s, 2, "Bob", true)
+	old, recent := date(2024, time.March, 4), date(2025, time.March, 4)
+	createdAt := time.Date(2025, 3, 4, 10, 0, 0, 0, time.UTC)
+
+	for _, day := range []time.Time{old, recent} {
+		if err := s.CreateShadowComparison(ctx, &store.ShadowComparison{Date: day, Strategy: "window30", LiveUserID: alice.ID, ShadowUserID: bob.ID, CreatedAt: createdAt}); err != nil {
+			t.Fatalf("CreateShadowComparison failed: %v", err)
+		}
+		e := &store.AssignmentExplanation{Date: day, UserID: alice.ID, AssignmentType: store.AssignmentTypeRoundRobin, Strategy: "window30", Reason: "Fewest duties",
+			Candidates: []store.AssignmentCandidate{{UserID: alice.ID}, {UserID: bob.ID, Excluded: "off_duty"}}, CreatedAt: createdAt}
+		if err := s.SaveAssignmentExplanation(ctx, e); err != nil {
+			t.Fatalf("SaveAssignmentExplanation failed: %v", err)
+		}
+	}
+
+	if runs, err := s.ListMaintenanceRuns(ctx, 10); err != nil || len(runs) != 0 {
+		t.Fatalf("ListMaintenanceRuns: expected no runs yet, got %+v, %v", runs, err)
+	}
+	earlier := &store.MaintenanceRun{RanAt: old.Add(4 * time.Hour), SizeBefore: 4096, SizeAfter: 2048, Deleted: 3}
+	later := &store.MaintenanceRun{RanAt: recent.Add(4 * time.Hour), SizeBefore: 8192, SizeAfter: 4096, Deleted: 5}
+	for _, run := range []*store.MaintenanceRun{earlier, later} {
+		if err := s.CreateMaintenanceRun(ctx, run); err != nil {
+			t.Fatalf("CreateMaintenanceRun failed: %v", err)
+		}
+		if run.ID == 0 {
+			t.Fatal("CreateMaintenanceRun did not set the ID")
+		}
+	}
+	runs, err := s.ListMaintenanceRuns(ctx, 10)
+	if err != nil || len(runs) != 2 || runs[0].ID != later.ID || runs[1].ID != earlier.ID {
+		t.Fatalf("ListMaintenanceRuns: expected [%d %d] newest first, got %+v, %v", later.ID, earlier.ID, runs, err)
+	}
+	if r := runs[0]; !r.RanAt.Equal(later.RanAt) || r.SizeBefore != 8192 || r.SizeAfter != 4096 || r.Deleted != 5 {
+		t.Errorf("ListMaintenanceRuns: expected %+v, got %+v", later, r)
+	}
+	if runs, _ = s.ListMaintenanceRuns(ctx, 1); len(runs) != 1 || runs[0].ID != later.ID {
+		t.Errorf("ListMaintenanceRuns: expected only the latest run, got %+v", runs)
+	}
+
+	// A comparison, an explanation with its two candidates, and a run are older
+	deleted, err := s.DeleteLogsBefore(ctx, date(2025, time.January, 1))
+	if err != nil {
+		t.Fatalf("DeleteLogsBefore failed: %v", err)
+	}
+	if deleted < 3 {
+		t.Errorf("DeleteLogsBefore: expected at least 3 rows deleted, got %d", deleted)
+	}
+	if got, _ := s.ListShadowComparisons(ctx, old); len(got) != 1 || !got[0].Date.Equal(recent) {
+		t.Errorf("DeleteLogsBefore: expected only the recent comparison to be kept, got %+v", got)
+	}
+	if e, _ := s.GetAssignmentExplanation(ctx, old); e != nil {
+		t.Errorf("DeleteLogsBefore: expected the old explanation to be deleted, got %+v", e)
+	}
+	if e, _ := s.GetAssignmentExplanation(ctx, recent); e == nil || len(e.Candidates) != 2 {
+		t.Errorf("DeleteLogsBefore: expected the recent explanation to be kept, got %+v", e)
+	}
+	if runs, _ = s.ListMaintenanceRuns(ctx, 10); len(runs) != 1 || runs[0].ID != later.ID {
+		t.Errorf("DeleteLogsBefore: expected only the recent run to be kept, got %+v", runs)
+	}
+
+	if _, err := s.DatabaseSize(ctx); err != nil {
+		t.Errorf("DatabaseSize failed: %v", err)
+	}
+	if err := s.Optimize(ctx); err != nil {
+		t.Errorf("Optimize failed: %v", err)
+	}
+	if e, _ := s.GetAssignmentExplanation(ctx, recent); e == nil {
+		t.Error("Optimize: expected the data to be kept")
+	}
+}
+
+func testScheduleVersions(t *testing.T, s store.Store) {
+	ctx := context.Background()
+	alice := mustCreateUser(t, s, 1, "Alice", true)
+	bob := mustCreateUser(t, s, 2, "Bob", true)
+	november := date(2025, time.November, 1)
+	createdAt := time.Date(2025, 11, 2, 10, 0, 0, 0, time.UTC)
+
+	first := &store.ScheduleVersion{Month: november, CreatedAt: createdAt, Days: []store.ScheduleDay{
+		{Date: date(2025, time.November, 3), UserID: alice.ID, AssignmentType: store.AssignmentTypeRoundRobin, Status: store.DutyStatusAnnounced},
+		{Date: date(2025, time.November, 4), SkipReason: store.SkipReasonHoliday},
+	}}
+	empty := &store.ScheduleVersion{Month: date(2025, time.December, 1), CreatedAt: createdAt}
+	second := &store.ScheduleVersion{Month: november, CreatedAt: createdAt.Add(time.Hour), Days: []store.ScheduleDay{
+		{Date: date(2025, time.November, 3), UserID: bob.ID, AssignmentType: store.AssignmentTypeAdmin, Status: store.DutyStatusAnnounced},
+	}}
+	for _, v := range []*store.ScheduleVersion{first, empty, second} {
+		if err := s.CreateScheduleVersion(ctx, v); err != nil {
+			t.Fatalf("CreateScheduleVersion failed: %v", err)
+		}
+		if v.ID == 0 {
+			t.Fatal("CreateScheduleVersion did not set the ID")
+		}
+	}
+	if first.Version != 1 || empty.Version != 1 || second.Version != 2 {
+		t.Errorf("CreateScheduleVersion: expected versions 1, 1 and 2, got %d, %d and %d", first.Version, empty.Version, second.Version)
+	}
+
+	versions, err := s.ListScheduleVersions(ctx, november)
+	if err != nil {
+		t.Fatalf("ListScheduleVersions failed: %v", err)
+	}
+	if len(versions) != 2 || versions[0].Version != 1 || versions[1].Version != 2 {
+		t.Fatalf("ListScheduleVersions: expected versions 1 and 2, got %+v", versions)
+	}
+	v := versions[0]
+	if !v.Month.Equal(november) || !v.CreatedAt.Equal(createdAt) || len(v.Days) != 2 {
+		t.Fatalf("ListScheduleVersions: expected %+v, got %+v", first, v)
+	}
+	if d := v.Days[0]; d.UserID != alice.ID || d.AssignmentType != store.AssignmentTypeRoundRobin || d.Status != store.DutyStatusAnnounced {
+		t.Errorf("ListScheduleVersions: expected Alice's duty first, got %+v", d)
+	}
+	if d := v.Days[1]; d.UserID != 0 || d.SkipReason != store.SkipReasonHoliday || !d.Date.Equal(date(2025, time.November, 4)) {
+		t.Errorf("ListScheduleVersions: expected a holiday second, got %+v", d)
+	}
+	if versions, _ := s.ListScheduleVersions(ctx, date(2025, time.December, 1)); len(versions) != 1 || len(versions[0].Days) != 0 {
+		t.Errorf("ListScheduleVersions: expected an empty December, got %+v", versions)
+	}
+
+	// Versions follow a merged user
+	if _, err := s.MergeUsers(ctx, bob.ID, alice.ID, createdAt); err != nil {
+		t.Fatalf("MergeUsers failed: %v", err)
+	}
+	if versions, _ = s.ListScheduleVersions(ctx, november); versions[1].Days[0].UserID != alice.ID {
+		t.Errorf("ListScheduleVersions: expected Bob's day to be Alice's after the merge, got %+v", versions[1].Days)
+	}
+}
+
+func testSkipDays(t *testing.T, s store.Store) {
+	ctx := context.Background()
+	createdAt := time.Date(2025, 10, 1, 9, 30, 0, 0, time.UTC)
+
+	if day, err := s.GetSkipDay(ctx, date(2025, time.October, 24)); err != nil || day != nil {
+		t.Errorf("GetSkipDay of a regular day: expected (nil, nil), got (%v, %v)", day, err)
+	}
+
+	for _, day := range []*store.SkipDay{
+		{Date: date(2025, time.October, 31), Reason: store.SkipReasonAway, CreatedAt: createdAt},
+		{Date: date(2025, time.October, 24), Reason: store.SkipReasonEatingOut, CreatedAt: createdAt},
+		{Date: date(2025, time.November, 1), Reason: store.SkipReasonHoliday, CreatedAt: createdAt},
+	} {
+		if err := s.SetSkipDay(ctx, day); err != nil {
+			t.Fatalf("SetSkipDay(%s) failed: %v", day.Date.Format("2006-01-02"), err)
+		}
+	}
+
+	got, err := s.GetSkipDay(ctx, date(2025, time.October, 24))
+	if err != nil || got == nil {
+		t.Fatalf("GetSkipDay: expected a skip day, got (%v, %v)", got, err)
+	}
+	if got.Reason != store.SkipReasonEatingOut || !got.CreatedAt.Equal(createdAt) {
+		t.Errorf("GetSkipDay: expected eating_out created at %v, got %+v", createdAt, got)
+	}
+
+	october, err := s.GetSkipDaysByMonth(ctx, 2025, time.October)
+	if err != nil {
+		t.Fatalf("GetSkipDaysByMonth failed: %v", err)
+	}
+	if len(october) != 2 || october[0].Date.Day() != 24 || october[1].Date.Day() != 31 {
+		t.Errorf("GetSkipDaysByMonth: expected Oct 24 and 31 in order, got %+v", october)
+	}
+
+	// Setting again replaces the reason
+	if err := s.SetSkipDay(ctx, &store.SkipDay{Date: date(2025, time.October, 24), Reason: store.SkipReasonHoliday, CreatedAt: createdAt}); err != nil {
+		t.Fatalf("SetSkipDay failed: %v", err)
+	}
+	if got, _ := s.GetSkipDay(ctx, date(2025, time.October, 24)); got == nil || got.Reason != store.SkipReasonHoliday {
+		t.Errorf("GetSkipDay after update: expected holiday, got %+v", got)
+	}
+
+	if err := s.DeleteSkipDay(ctx, date(2025, time.October, 24)); err != nil {
+		t.Fatalf("DeleteSkipDay failed: %v", err)
+	}
+	if got, _ := s.GetSkipDay(ctx, date(2025, time.October, 24)); got != nil {
+		t.Errorf("GetSkipDay after delete: expected nil, got %+v", got)
+	}
+}
+
+func testBackfillDuty(t *testing.T, s store.Store) {
+	ctx := context.Background()
+	alice := mustCreateUser(t, s, 1, "Alice", true)
+	bob := mustCreateUser(t, s, 2, "Bob", true)
+	at := time.Date(2025, 7, 20, 18, 0, 0, 0, time.UTC)
+
+	// A day without any assignment gets a completed, backfilled duty
+	empty := date(2025, time.July, 14)
+	duty, err := s.BackfillDuty(ctx, empty, alice.ID, at)
+	if err != nil {
+		t.Fatalf("BackfillDuty on an empty day failed: %v", err)
+	}
+	if duty == nil || duty.ID == 0 || duty.UserID != alice.ID || duty.User == nil || duty.User.FirstName != "Alice" {
+		t.Fatalf("BackfillDuty: expected a duty for Alice, got %+v", duty)
+	}
+	if duty.AssignmentType != store.AssignmentTypeVoluntary || duty.CompletedAt == nil || duty.BackfilledAt == nil || !duty.BackfilledAt.Equal(at) {
+		t.Errorf("BackfillDuty: expected a completed voluntary duty backfilled at %v, got %+v", at, duty)
+	}
+
+	// Correcting an existing duty keeps its type and completion time
+	assigned := date(2025, time.July, 15)
+	original := mustCreateDuty(t, s, alice.ID, assigned, store.AssignmentTypeRoundRobin)
+	completedAt := time.Date(2025, 7, 15, 19, 0, 0, 0, time.UTC)
+	original.CompletedAt = &completedAt
+	s.UpdateDuty(ctx, original)
+
+	corrected, err := s.BackfillDuty(ctx, assigned, bob.ID, at)
+	if err != nil {
+		t.Fatalf("BackfillDuty on an assigned day failed: %v", err)
+	}
+	if corrected.ID != original.ID || corrected.UserID != bob.ID || corrected.AssignmentType != store.AssignmentTypeRoundRobin {
+		t.Errorf("BackfillDuty: expected duty %d handed to Bob as round robin, got %+v", original.ID, corrected)
+	}
+	if corrected.CompletedAt == nil || !corrected.CompletedAt.Equal(completedAt) || corrected.BackfilledAt == nil {
+		t.Errorf("BackfillDuty: expected completion at %v and a backfill mark, got %+v", completedAt, corrected)
+	}
+
+	// Backfilled duties count as completed and stay marked in every query
+	completed, err := s.GetCompletedDutiesInRange(ctx, empty, assigned.AddDate(0, 0, 1))
+	if err != nil || len(completed) != 2 {
+		t.Fatalf("GetCompletedDutiesInRange: expected 2 duties, got %d (%v)", len(completed), err)
+	}
+	month, _ := s.GetDutiesByMonth(ctx, 2025, time.July)
+	for _, duties := range [][]*store.Duty{completed, month} {
+		for _, d := range duties {
+			if d.BackfilledAt == nil {
+				t.Errorf("Expected duty on %s to be marked as backfilled", d.DutyDate.Format("2006-01-02"))
+			}
+		}
+	}
+
+}
+
+func testNotes(t *testing.T, s store.Store) {
+	ctx := context.Background()
+	alice := mustCreateUser(t, s, 1, "Alice", true)
+
+	day := date(2025, time.November, 4)
+	if err := s.CreateDuty(ctx, &store.Duty{UserID: alice.ID, DutyDate: day, AssignmentType: store.AssignmentTypeAdmin, CreatedAt: time.Now(), Note: "guests for dinner"}); err != nil {
+		t.Fatalf("CreateDuty failed: %v", err)
+	}
+	duty, _ := s.GetDutyByDate(ctx, day)
+	if duty == nil || duty.Note != "guests for dinner" {
+		t.Fatalf("GetDutyByDate: expected the note, got %+v", duty)
+	}
+	duty.Note = ""
+	duty.HandoffNote = "dishwasher tabs almost out"
+	if err := s.UpdateDuty(ctx, duty); err != nil {
+		t.Fatalf("UpdateDuty failed: %v", err)
+	}
+	if duties, _ := s.GetDutiesByMonth(ctx, 2025, time.November); len(duties) != 1 || duties[0].Note != "" {
+		t.Errorf("GetDutiesByMonth: expected the note to be cleared, got %+v", duties)
+	} else if duties[0].HandoffNote != "dishwasher tabs almost out" {
+		t.Errorf("GetDutiesByMonth: expected the handoff note, got %q", duties[0].HandoffNote)
+	}
+
+	createdAt := time.Date(2025, 11, 1, 10, 0, 0, 0, time.UTC)
+	brown := &store.NoteTemplate{Rule: "2w:2025-11-04", Text: "bins are brown this week", CreatedAt: createdAt}
+	blue := &store.NoteTemplate{Rule: "2w:2025-11-11", Text: "bins are blue this week", CreatedAt: createdAt}
+	for _, tmpl := range []*store.NoteTemplate{brown, blue} {
+		if err := s.CreateNoteTemplate(ctx, tmpl); err != nil {
+			t.Fatalf("CreateNoteTemplate failed: %v", err)
+		}
+		if tmpl.ID == 0 {
+			t.Fatal("CreateNoteTemplate did not set the ID")
+		}
+	}
+	templates, err := s.ListNoteTemplates(ctx)
+	if err != nil {
+		t.Fatalf("ListNoteTemplates failed: %v", err)
+	}
+	if len(templates) != 2 || *templates[0] != *brown || *templates[1] != *blue {
+		t.Fatalf("ListNoteTemplates: expected [%+v %+v], got %+v", brown, blue, templates)
+	}
+
+	if err := s.DeleteNoteTemplate(ctx, brown.ID); err != nil {
+		t.Fatalf("DeleteNoteTemplate failed: %v", err)
+	}
+	if err := s.DeleteNoteTemplate(ctx, 999); err != nil {
+		t.Errorf("DeleteNoteTemplate of an unknown ID: expected no error, got %v", err)
+	}
+	if templates, _ := s.ListNoteTemplates(ctx); len(templates) != 1 || templates[0].ID != blue.ID {
+		t.Errorf("ListNoteTemplates after delete: expected only %d, got %+v", blue.ID, templates)
+	}
+}
+
+func testBlackoutRules(t *testing.T, s store.Store) {
+	ctx := context.Background()
+	createdAt := time.Date(2025, 11, 1, 10, 0, 0, 0, time.UTC)
+	christmas := &store.BlackoutRule{Rule: "12-24..12-26", Description: "Christmas", CreatedAt: createdAt}
+	easter := &store.BlackoutRule{Rule: "easter", CreatedAt: createdAt}
+	for _, r := range []*store.BlackoutRule{christmas, easter} {
+		if err := s.CreateBlackoutRule(ctx, r); err != nil {
+			t.Fatalf("CreateBlackoutRule failed: %v", err)
+		}
+		if r.ID == 0 {
+			t.Fatal("CreateBlackoutRule did not set the ID")
+		}
+	}
+	rules, err := s.ListBlackoutRules(ctx)
+	if err != nil {
+		t.Fatalf("ListBlackoutRules failed: %v", err)
+	}
+	if len(rules) != 2 || *rules[0] != *christmas || *rules[1] != *easter {
+		t.Fatalf("ListBlackoutRules: expected [%+v %+v], got %+v", christmas, easter, rules)
+	}
+
+	if err := s.DeleteBlackoutRule(ctx, christmas.ID); err != nil {
+		t.Fatalf("DeleteBlackoutRule failed: %v", err)
+	}
+	if err := s.DeleteBlackoutRule(ctx, 999); err != nil {
+		t.Errorf("DeleteBlackoutRule of an unknown ID: expected no error, got %v", err)
+	}
+	if rules, _ := s.ListBlackoutRules(ctx); len(rules) != 1 || rules[0].ID != easter.ID {
+		t.Errorf("ListBlackoutRules after delete: expected only %d, got %+v", easter.ID, rules)
+	}
+}
+
+func testChecklists(t *testing.T, s store.Store) {
+	ctx := context.Background()
+	createdAt := time.Date(2025, 11, 1, 10, 0, 0, 0, time.UTC)
+	dishwasher := &store.ChecklistItem{Text: "Load the dishwasher", Mandatory: true, CreatedAt: createdAt}
+	counters := &store.ChecklistItem{AssignmentType: store.AssignmentTypeRoundRobin, Text: "Wipe the counters", CreatedAt: createdAt}
+	for _, item := range []*store.ChecklistItem{dishwasher, counters} {
+		if err := s.CreateChecklistItem(ctx, item); err != nil {
+			t.Fatalf("CreateChecklistItem failed: %v", err)
+		}
+		if item.ID == 0 {
+			t.Fatal("CreateChecklistItem did not set the ID")
+		}
+	}
+	items, err := s.ListChecklistItems(ctx)
+	if err != nil {
+		t.Fatalf("ListChecklistItems failed: %v", err)
+	}
+	if len(items) != 2 || *items[0] != *dishwasher || *items[1] != *counters {
+		t.Fatalf("ListChecklistItems: expected [%+v %+v], got %+v", dishwasher, counters, items)
+	}
+
+	day := date(2025, time.November, 4)
+	checkedAt := time.Date(2025, 11, 4, 19, 0, 0, 0, time.UTC)
+	for _, id := range []int64{counters.ID, dishwasher.ID, dishwasher.ID} {
+		if err := s.SetChecklistCheck(ctx, &store.ChecklistCheck{Date: day, ItemID: id, CheckedAt: checkedAt}); err != nil {
+			t.Fatalf("SetChecklistCheck failed: %v", err)
+		}
+	}
+	if err := s.SetChecklistCheck(ctx, &store.ChecklistCheck{Date: day.AddDate(0, 0, 1), ItemID: dishwasher.ID, CheckedAt: checkedAt}); err != nil {
+		t.Fatalf("SetChecklistCheck failed: %v", err)
+	}
+	checks, err := s.GetChecklistChecks(ctx, day)
+	if err != nil {
+		t.Fatalf("GetChecklistChecks failed: %v", err)
+	}
+	want := []store.ChecklistCheck{{Date: day, ItemID: dishwasher.ID, CheckedAt: checkedAt}, {Date: day, ItemID: counters.ID, CheckedAt: checkedAt}}
+	if len(checks) != 2 || *checks[0] != want[0] || *checks[1] != want[1] {
+		t.Fatalf("GetChecklistChecks: expected %+v ordered by item, got %+v", want, checks)
+	}
+
+	if err := s.DeleteChecklistCheck(ctx, day, counters.ID); err != nil {
+		t.Fatalf("DeleteChecklistCheck failed: %v", err)
+	}
+	if checks, _ := s.GetChecklistChecks(ctx, day); len(checks) != 1 || checks[0].ItemID != dishwasher.ID {
+		t.Errorf("GetChecklistChecks after unchecking: expected only item %d, got %+v", dishwasher.ID, checks)
+	}
+
+	// Deleting an item drops its checks on every day
+	if err := s.DeleteChecklistItem(ctx, dishwasher.ID); err != nil {
+		t.Fatalf("DeleteChecklistItem failed: %v", err)
+	}
+	if items, _ := s.ListChecklistItems(ctx); len(items) != 1 || items[0].ID != counters.ID {
+		t.Errorf("ListChecklistItems after delete: expected only %d, got %+v", counters.ID, items)
+	}
+	for _, d := range []time.Time{day, day.AddDate(0, 0, 1)} {
+		if checks, _ := s.GetChecklistChecks(ctx, d); len(checks) != 0 {
+			t.Errorf("GetChecklistChecks on %v after deleting the item: expected none, got %+v", d, checks)
+		}
+	}
+}
+
+func testWasteCollections(t *testing.T, s store.Store) {
+	ctx := context.Background()
+	paper := &store.WasteCollection{Date: date(2025, time.November, 4), Bin: "Paper"}
+	bio := &store.WasteCollection{Date: date(2025, time.November, 4), Bin: "Bio"}
+	rest := &store.WasteCollection{Date: date(2025, time.November, 11), Bin: "Residual"}
+	// The duplicate is stored once
+	if err := s.ReplaceWasteCollections(ctx, []*store.WasteCollection{rest, paper, bio, paper}); err != nil {
+		t.Fatalf("ReplaceWasteCollections failed: %v", err)
+	}
+
+	got, err := s.GetWasteCollections(ctx, date(2025, time.November, 1), date(2025, time.December, 1))
+	if err != nil {
+		t.Fatalf("GetWasteCollections failed: %v", err)
+	}
+	if len(got) != 3 || *got[0] != *bio || *got[1] != *paper || *got[2] != *rest {
+		t.Fatalf("GetWasteCollections: expected [%+v %+v %+v] ordered by date and bin, got %+v", bio, paper, rest, got)
+	}
+	if got, _ := s.GetWasteCollections(ctx, date(2025, time.November, 5), date(2025, time.November, 11)); len(got) != 0 {
+		t.Errorf("GetWasteCollections: expected the end to be exclusive, got %+v", got)
+	}
+
+	// Replacing drops what the new schedule doesn't have
+	if err := s.ReplaceWasteCollections(ctx, []*store.WasteCollection{rest}); err != nil {
+		t.Fatalf("ReplaceWasteCollections failed: %v", err)
+	}
+	if got, _ := s.GetWasteCollections(ctx, date(2025, time.November, 1), date(2025, time.December, 1)); len(got) != 1 || *got[0] != *rest {
+		t.Errorf("GetWasteCollections after replacing: expected only %+v, got %+v", rest, got)
+	}
+}
+
+func testRoundRobinState(t *testing.T, s store.Store) {
+	ctx := context.Background()
+	alice := mustCreateUser(t, s, 1, "Alice", true)
+	bob := mustCreateUser(t, s, 2, "Bob", true)
+
+	if states, err := s.GetRoundRobinStates(ctx, "round_robin"); err != nil || len(states) != 0 {
+		t.Fatalf("GetRoundRobinStates: expected no state yet, got %+v, %v", states, err)
+	}
+
+	monday := time.Date(2025, 11, 3, 11, 0, 0, 0, time.UTC)
+	for _, pick := range []struct {
+		rotation string
+		userID   int64
+		at       time.Time
+	}{
+		{"round_robin", alice.ID, monday},
+		{"round_robin", bob.ID, monday.AddDate(0, 0, 1)},
+		{"round_robin", alice.ID, monday.AddDate(0, 0, 2)},
+		{"voluntary", bob.ID, monday.AddDate(0, 0, 3)},
+	} {
+		if err := s.RecordRoundRobinPick(ctx, pick.rotation, pick.userID, pick.at); err != nil {
+			t.Fatalf("RecordRoundRobinPick failed: %v", err)
+		}
+	}
+
+	states, err := s.GetRoundRobinStates(ctx, "round_robin")
+	if err != nil {
+		t.Fatalf("GetRoundRobinStates failed: %v", err)
+	}
+	want := []store.RoundRobinState{
+		{Rotation: "round_robin", UserID: bob.ID, AssignmentCount: 1, LastAssignedTimestamp: monday.AddDate(0, 0, 1)},
+		{Rotation: "round_robin", UserID: alice.ID, AssignmentCount: 2, LastAssignedTimestamp: monday.AddDate(0, 0, 2)},
+	}
+	if len(states) != len(want) || *states[0] != want[0] || *states[1] != want[1] {
+		t.Errorf("GetRoundRobinStates: expected %+v least recent first, got %+v", want, states)
+	}
+	if states, _ := s.GetRoundRobinStates(ctx, "voluntary"); len(states) != 1 || states[0].UserID != bob.ID {
+		t.Errorf("GetRoundRobinStates: expected rotations to be separate, got %+v", states)
+	}
+}
+
+func testDutyStatus(t *testing.T, s store.Store) {
+	ctx := context.Background()
+	alice := mustCreateUser(t, s, 1, "Alice", true)
+
+	planned, announced, old := date(2025, time.November, 5), date(2025, time.November, 4), date(2025, time.November, 1)
+	if err := s.CreateDuty(ctx, &store.Duty{UserID: alice.ID, DutyDate: planned, AssignmentType: store.AssignmentTypeRoundRobin, CreatedAt: time.Now(), Status: store.DutyStatusProvisional}); err != nil {
+		t.Fatalf("CreateDuty failed: %v", err)
+	}
+	duty, _ := s.GetDutyByDate(ctx, planned)
+	if duty == nil || duty.Status != store.DutyStatusProvisional {
+		t.Fatalf("GetDutyByDate: expected a provisional duty, got %+v", duty)
+	}
+	duty.Status = store.DutyStatusAcknowledged
+	if err := s.UpdateDuty(ctx, duty); err != nil {
+		t.Fatalf("UpdateDuty failed: %v", err)
+	}
+
+	// Without a status a duty is announced, or completed if it has been done
+	unset := &store.Duty{UserID: alice.ID, DutyDate: announced, AssignmentType: store.AssignmentTypeRoundRobin, CreatedAt: time.Now()}
+	if err := s.CreateDuty(ctx, unset); err != nil || unset.Status != store.DutyStatusAnnounced {
+		t.Fatalf("CreateDuty: expected an announced duty, got %q, %v", unset.Status, err)
+	}
+	if _, err := s.BackfillDuty(ctx, old, alice.ID, time.Now()); err != nil {
+		t.Fatalf("BackfillDuty failed: %v", err)
+	}
+
+	// Duties before the date that weren't completed are missed
+	n, err := s.MarkMissedDuties(ctx, planned.AddDate(0, 0, 1))
+	if err != nil || n != 2 {
+		t.Fatalf("MarkMissedDuties: expected 2 duties, got %d, %v", n, err)
+	}
+	want := map[string]store.DutyStatus{"2025-11-01": store.DutyStatusCompleted, "2025-11-04": store.DutyStatusMissed, "2025-11-05": store.DutyStatusMissed}
+	duties, _ := s.GetDutiesByMonth(ctx, 2025, time.November)
+	for _, d := range duties {
+		if key := d.DutyDate.Format("2006-01-02"); d.Status != want[key] {
+			t.Errorf("GetDutiesByMonth: expected %s on %s, got %s", want[key], key, d.Status)
+		}
+	}
+
+	if _, err := s.CompleteDuty(ctx, announced); err != nil {
+		t.Fatalf("CompleteDuty failed: %v", err)
+	}
+	if duty, _ := s.GetDutyByDate(ctx, announced); duty.Status != store.DutyStatusCompleted {
+		t.Errorf("CompleteDuty: expected the duty completed, got %s", duty.Status)
+	}
+}
+
+func testBadges(t *testing.T, s store.Store) {
+	ctx := context.Background()
+	alice := mustCreateUser(t, s, 1, "Alice", true)
+	bob := mustCreateUser(t, s, 2, "Bob", true)
+	at := time.Date(2025, time.November, 3, 21, 0, 0, 0, time.UTC)
+
+	volunteer := &store.Badge{UserID: alice.ID, Kind: store.BadgeFirstVolunteer, AwardedAt: at}
+	if awarded, err := s.AwardBadge(ctx, volunteer); err != nil || !awarded || volunteer.ID == 0 {
+		t.Fatalf("AwardBadge: expected a new badge with an ID, got %v, %v, %+v", awarded, err, volunteer)
+	}
+	if awarded, err := s.AwardBadge(ctx, &store.Badge{UserID: alice.ID, Kind: store.BadgeFirstVolunteer, AwardedAt: at}); err != nil || awarded {
+		t.Errorf("AwardBadge: expected a second first volunteer badge to be ignored, got %v, %v", awarded, err)
+	}
+	// Perfect months can be awarded once per month
+	for _, period := range []string{"2025-10", "2025-09"} {
+		at = at.Add(time.Hour)
+		if awarded, err := s.AwardBadge(ctx, &store.Badge{UserID: alice.ID, Kind: store.BadgePerfectMonth, Period: period, AwardedAt: at}); err != nil || !awarded {
+			t.Errorf("AwardBadge: expected the perfect month %s, got %v, %v", period, awarded, err)
+		}
+	}
+
+	badges, err := s.ListBadges(ctx, alice.ID)
+	if err != nil {
+		t.Fatalf("ListBadges failed: %v", err)
+	}
+	if len(badges) != 3 || badges[0].Kind != store.BadgeFirstVolunteer || badges[2].Period != "2025-09" || !badges[2].AwardedAt.Equal(at) {
+		t.Errorf("ListBadges: expected 3 badges, oldest first, got %+v", badges)
+	}
+	if badges, _ := s.ListBadges(ctx, bob.ID); len(badges) != 0 {
+		t.Errorf("ListBadges: expected no badges for Bob, got %+v", badges)
+	}
+}
+
+func testLedger(t *testing.T, s store.Store) {
+	ctx := context.Background()
+	alice := mustCreateUser(t, s, 1, "Alice", true)
+	bob := mustCreateUser(t, s, 2, "Bob", true)
+	at := time.Date(2025, time.November, 3, 21, 0, 0, 0, time.UTC)
+	missed := date(2025, time.November, 2)
+
+	fine := &store.LedgerEntry{UserID: alice.ID, Kind: store.LedgerFine, Amount: 500, DutyDate: &missed, CreatedAt: at}
+	if err := s.AddLedgerEntry(ctx, fine); err != nil || fine.ID == 0 {
+		t.Fatalf("AddLedgerEntry: expected an ID, got %+v, %v", fine, err)
+	}
+	if err := s.AddLedgerEntry(ctx, &store.LedgerEntry{UserID: bob.ID, Kind: store.LedgerPayment, Amount: -200, ChargeID: "charge-1", CreatedAt: at.Add(-time.Hour)}); err != nil {
+		t.Fatalf("AddLedgerEntry failed: %v", err)
+	}
+
+	entries, err := s.ListLedgerEntries(ctx)
+	if err != nil {
+		t.Fatalf("ListLedgerEntries failed: %v", err)
+	}
+	if len(entries) != 2 || entries[0].UserID != bob.ID || entries[0].DutyDate != nil || entries[0].ChargeID != "charge-1" || entries[1].Kind != store.LedgerFine ||
+		entries[1].Amount != 500 || !entries[1].DutyDate.Equal(missed) || !entries[1].CreatedAt.Equal(at) {
+		t.Errorf("ListLedgerEntries: expected Bob's payment, then Alice's fine, got %+v", entries)
+	}
+
+	// Entries follow a merged user
+	if _, err := s.MergeUsers(ctx, bob.ID, alice.ID, at); err != nil {
+		t.Fatalf("MergeUsers failed: %v", err)
+	}
+	entries, _ = s.ListLedgerEntries(ctx)
+	if len(entries) != 2 || entries[0].UserID != alice.ID {
+		t.Errorf("ListLedgerEntries: expected Bob's payment to be Alice's after the merge, got %+v", entries)
+	}
+}
+
+func testTasks(t *testing.T, s store.Store) {
+	ctx := context.Background()
+	alice := mustCreateUser(t, s, 1, "Alice", true)
+	bob := mustCreateUser(t, s, 2, "Bob", true)
+	at := time.Date(2025, time.November, 3, 21, 0, 0, 0, time.UTC)
+	due := date(2025, time.November, 8)
+
+	garage := &store.Task{Title: "Clean the garage", Weight: 3, DueDate: &due, CreatedAt: at}
+	if err := s.CreateTask(ctx, garage); err != nil || garage.ID == 0 {
+		t.Fatalf("CreateTask: expected an ID, got %+v, %v", garage, err)
+	}
+	windows := &store.Task{Title: "Wash the windows", Weight: 1, CreatedAt: at}
+	if err := s.CreateTask(ctx, windows); err != nil {
+		t.Fatalf("CreateTask failed: %v", err)
+	}
+	got, err := s.GetTask(ctx, garage.ID)
+	if err != nil || got == nil || got.Title != garage.Title || got.Weight != 3 || !got.DueDate.Equal(due) || got.ClaimedBy != nil || !got.CreatedAt.Equal(at) {
+		t.Fatalf("GetTask: expected the garage, got %+v, %v", got, err)
+	}
+	if got, err := s.GetTask(ctx, 999); err != nil || got != nil {
+		t.Errorf("GetTask of an unknown ID: expected nil, got %+v, %v", got, err)
+	}
+
+	// Only one user can claim a task
+	if ok, err := s.ClaimTask(ctx, garage.ID, alice.ID, at); err != nil || !ok {
+		t.Fatalf("ClaimTask = %v, %v, want true", ok, err)
+	}
+	if ok, _ := s.ClaimTask(ctx, garage.ID, bob.ID, at); ok {
+		t.Error("ClaimTask: Bob claimed Alice's task")
+	}
+	if ok, _ := s.CompleteTask(ctx, garage.ID, bob.ID, at); ok {
+		t.Error("CompleteTask: Bob completed Alice's task")
+	}
+	if ok, _ := s.ReleaseTask(ctx, garage.ID, bob.ID); ok {
+		t.Error("ReleaseTask: Bob released Alice's task")
+	}
+	if ok, err := s.ReleaseTask(ctx, garage.ID, alice.ID); err != nil || !ok {
+		t.Fatalf("ReleaseTask = %v, %v, want true", ok, err)
+	}
+	if ok, _ := s.ClaimTask(ctx, garage.ID, bob.ID, at); !ok {
+		t.Fatal("ClaimTask: Bob couldn't claim the released task")
+	}
+	if ok, err := s.CompleteTask(ctx, garage.ID, bob.ID, at.Add(time.Hour)); err != nil || !ok {
+		t.Fatalf("CompleteTask = %v, %v, want true", ok, err)
+	}
+	if ok, _ := s.CompleteTask(ctx, garage.ID, bob.ID, at); ok {
+		t.Error("CompleteTask: a task was done twice")
+	}
+	// Doing an unclaimed task claims it
+	if ok, _ := s.CompleteTask(ctx, windows.ID, alice.ID, at); !ok {
+		t.Fatal("CompleteTask of an unclaimed task failed")
+	}
+
+	tasks, err := s.ListTasks(ctx)
+	if err != nil {
+		t.Fatalf("ListTasks failed: %v", err)
+	}
+	if len(tasks) != 2 || tasks[0].ID != garage.ID || *tasks[0].ClaimedBy != bob.ID || !tasks[0].DoneAt.Equal(at.Add(time.Hour)) ||
+		*tasks[1].ClaimedBy != alice.ID || tasks[1].ClaimedAt == nil {
+		t.Errorf("ListTasks: expected the garage done by Bob and the windows by Alice, got %+v", tasks)
+	}
+	stats, err := s.GetUserStats(ctx, bob.ID)
+	if err != nil || stats.TasksDone != 1 || stats.TaskPoints != 3 {
+		t.Errorf("GetUserStats: expected Bob to have done a task worth 3, got %+v, %v", stats, err)
+	}
+
+	// Tasks follow a merged user
+	if _, err := s.MergeUsers(ctx, bob.ID, alice.ID, at); err != nil {
+		t.Fatalf("MergeUsers failed: %v", err)
+	}
+	if got, _ := s.GetTask(ctx, garage.ID); got == nil || *got.ClaimedBy != alice.ID {
+		t.Errorf("GetTask: expected Bob's task to be Alice's after the merge, got %+v", got)
+	}
+
+	if err := s.DeleteTask(ctx, windows.ID); err != nil {
+		t.Fatalf("DeleteTask failed: %v", err)
+	}
+	if tasks, _ := s.ListTasks(ctx); len(tasks) != 1 {
+		t.Errorf("ListTasks after deleting: expected 1 task, got %d", len(tasks))
+	}
+}
+
+func testCoverRequests(t *testing.T, s store.Store) {
+	ctx := context.Background()
+	alice := mustCreateUser(t, s, 1, "Alice", true)
+	bob := mustCreateUser(t, s, 2, "Bob", true)
+	at := time.Date(2025, time.November, 3, 9, 0, 0, 0, time.UTC)
+	monday, tuesday := date(2025, time.November, 3), date(2025, time.November, 4)
+
+	if got, err := s.GetOpenCoverRequest(ctx, monday); err != nil || got != nil {
+		t.Fatalf("GetOpenCoverRequest without requests: expected nil, got %+v, %v", got, err)
+	}
+	later := &store.CoverRequest{DutyDate: tuesday, UserID: bob.ID, Deadline: at.Add(2 * time.Hour), CreatedAt: at}
+	if err := s.CreateCoverRequest(ctx, later); err != nil || later.ID == 0 {
+		t.Fatalf("CreateCoverRequest: expected an ID, got %+v, %v", later, err)
+	}
+	sooner := &store.CoverRequest{DutyDate: monday, UserID: alice.ID, Deadline: at.Add(time.Hour), CreatedAt: at}
+	if err := s.CreateCoverRequest(ctx, sooner); err != nil {
+		t.Fatalf("CreateCoverRequest failed: %v", err)
+	}
+	got, err := s.GetOpenCoverRequest(ctx, monday)
+	if err != nil || got == nil || got.ID != sooner.ID || got.UserID != alice.ID || !got.DutyDate.Equal(monday) ||
+		!got.Deadline.Equal(at.Add(time.Hour)) || got.CoveredBy != nil || got.ResolvedAt != nil || !got.CreatedAt.Equal(at) {
+		t.Fatalf("GetOpenCoverRequest: expected Alice's request, got %+v, %v", got, err)
+	}
+	open, err := s.ListOpenCoverRequests(ctx)
+	if err != nil || len(open) != 2 || open[0].ID != sooner.ID || open[1].ID != later.ID {
+		t.Fatalf("ListOpenCoverRequests: expected both requests, sooner deadline first, got %+v, %v", open, err)
+	}
+
+	// Resolved requests aren't open anymore
+	if err := s.ResolveCoverRequest(ctx, sooner.ID, &bob.ID, at.Add(time.Minute)); err != nil {
+		t.Fatalf("ResolveCoverRequest failed: %v", err)
+	}
+	if got, _ := s.GetOpenCoverRequest(ctx, monday); got != nil {
+		t.Errorf("GetOpenCoverRequest: expected the resolved request to be closed, got %+v", got)
+	}
+	if err := s.ResolveCoverRequest(ctx, later.ID, nil, at.Add(2*time.Hour)); err != nil {
+		t.Fatalf("ResolveCoverRequest by nobody failed: %v", err)
+	}
+	if open, _ := s.ListOpenCoverRequests(ctx); len(open) != 0 {
+		t.Errorf("ListOpenCoverRequests: expected none open, got %+v", open)
+	}
+
+	// A request follows a merged user
+	again := &store.CoverRequest{DutyDate: tuesday, UserID: bob.ID, Deadline: at.Add(3 * time.Hour), CreatedAt: at}
+	if err := s.CreateCoverRequest(ctx, again); err != nil {
+		t.Fatalf("CreateCoverRequest failed: %v", err)
+	}
+	if _, err := s.MergeUsers(ctx, bob.ID, alice.ID, at); err != nil {
+		t.Fatalf("MergeUsers failed: %v", err)
+	}
+	if got, _ := s.GetOpenCoverRequest(ctx, tuesday); got == nil || got.UserID != alice.ID {
+		t.Errorf("GetOpenCoverRequest: expected Bob's request to be Alice's after the merge, got %+v", got)
+	}
+}
+
+func testWebLogins(t *testing.T, s store.Store) {
+	ctx := context.Background()
+	alice := mustCreateUser(t, s, 1, "Alice", true)
+	now := time.Date(2025, time.November, 3, 21, 0, 0, 0, time.UTC)
+
+	if err := s.CreateLoginCode(ctx, &store.LoginCode{CodeHash: "code", UserID: alice.ID, ExpiresAt: now.Add(10 * time.Minute)}); err != nil {
+		t.Fatalf("CreateLoginCode failed: %v", err)
+	}
+	code, err := s.ConsumeLoginCode(ctx, "code")
+	if err != nil || code == nil || code.UserID != alice.ID || !code.ExpiresAt.Equal(now.Add(10*time.Minute)) {
+		t.Fatalf("ConsumeLoginCode: expected Alice's code, got %+v, %v", code, err)
+	}
+	if code, err := s.ConsumeLoginCode(ctx, "code"); err != nil || code != nil {
+		t.Errorf("ConsumeLoginCode: expected a code to work only once, got %+v, %v", code, err)
+	}
+
+	for hash, expires := range map[string]time.Time{"old": now.Add(-time.Hour), "new": now.Add(time.Hour)} {
+		if err := s.CreateWebSession(ctx, &store.WebSession{TokenHash: hash, UserID: alice.ID, CreatedAt: now.Add(-2 * time.Hour), ExpiresAt: expires}); err != nil {
+			t.Fatalf("CreateWebSession failed: %v", err)
+		}
+	}
+	session, err := s.GetWebSession(ctx, "new")
+	if err != nil || session == nil || session.UserID != alice.ID || !session.CreatedAt.Equal(now.Add(-2*time.Hour)) {
+		t.Fatalf("GetWebSession: expected Alice's session, got %+v, %v", session, err)
+	}
+	s.CreateLoginCode(ctx, &store.LoginCode{CodeHash: "stale", UserID: alice.ID, ExpiresAt: now.Add(-time.Minute)})
+	if err := s.DeleteExpiredLogins(ctx, now); err != nil {
+		t.Fatalf("DeleteExpiredLogins failed: %v", err)
+	}
+	if session, _ := s.GetWebSession(ctx, "old"); session != nil {
+		t.Errorf("DeleteExpiredLogins: expected the expired session deleted, got %+v", session)
+	}
+	if code, _ := s.ConsumeLoginCode(ctx, "stale"); code != nil {
+		t.Errorf("DeleteExpiredLogins: expected the expired code deleted, got %+v", code)
+	}
+	if err := s.DeleteWebSession(ctx, "new"); err != nil {
+		t.Fatalf("DeleteWebSession failed: %v", err)
+	}
+	if session, _ := s.GetWebSession(ctx, "new"); session != nil {
+		t.Errorf("DeleteWebSession: expected the session deleted, got %+v", session)
+	}
+}
+
+func testInvites(t *testing.T, s store.Store) {
+	ctx := context.Background()
+	now := time.Date(2025, time.November, 3, 21, 0, 0, 0, time.UTC)
+
+	for hash, expires := range map[string]time.Time{"open": now.Add(time.Hour), "stale": now.Add(-time.Minute)} {
+		invite := &store.Invite{TokenHash: hash, CreatedBy: 1, RequiresApproval: true, CreatedAt: now.Add(-2 * time.Hour), ExpiresAt: expires}
+		if err := s.CreateInvite(ctx, invite); err != nil {
+			t.Fatalf("CreateInvite failed: %v", err)
+		}
+	}
+	if invite, err := s.GetInviteUsedBy(ctx, 42); err != nil || invite != nil {
+		t.Errorf("GetInviteUsedBy before joining: expected nil, got %+v, %v", invite, err)
+	}
+
+	invite, err := s.UseInvite(ctx, "open", 42, now)
+	if err != nil || invite == nil || invite.CreatedBy != 1 || !invite.RequiresApproval || invite.UsedBy != 42 ||
+		invite.UsedAt == nil || !invite.UsedAt.Equal(now) || !invite.ExpiresAt.Equal(now.Add(time.Hour)) {
+		t.Fatalf("UseInvite: expected the open invite used by 42, got %+v, %v", invite, err)
+	}
+	if invite, err := s.UseInvite(ctx, "open", 43, now); err != nil || invite != nil {
+		t.Errorf("UseInvite: expected an invite to work only once, got %+v, %v", invite, err)
+	}
+	if invite, err := s.UseInvite(ctx, "stale", 43, now); err != nil || invite != nil {
+		t.Errorf("UseInvite: expected an expired invite not to work, got %+v, %v", invite, err)
+	}
+	if invite, err := s.UseInvite(ctx, "unknown", 43, now); err != nil || invite != nil {
+		t.Errorf("UseInvite: expected an unknown invite not to work, got %+v, %v", invite, err)
+	}
+
+	if invite, err := s.GetInviteUsedBy(ctx, 42); err != nil || invite == nil || invite.TokenHash != "open" {
+		t.Errorf("GetInviteUsedBy: expected the open invite, got %+v, %v", invite, err)
+	}
+	if invite, err := s.GetInviteUsedBy(ctx, 43); err != nil || invite != nil {
+		t.Errorf("GetInviteUsedBy without joining: expected nil, got %+v, %v", invite, err)
+	}
+}
+
+func testMergeUsers(t *testing.T, s store.Store) {
+	ctx := context.Background()
+	alice := mustCreateUser(t, s, 1, "Alice", true)
+	alias := mustCreateUser(t, s, 2, "Ali", true)
+	bob := mustCreateUser(t, s, 3, "Bob", true)
+
+	for i, userID := range []int64{alias.ID, alice.ID, alias.ID, bob.ID} {
+		d := &store.Duty{UserID: userID, DutyDate: date(2025, time.December, i+1), AssignmentType: store.AssignmentTypeRoundRobin, CreatedAt: time.Now()}
+		if err := s.CreateDuty(ctx, d); err != nil {
+			t.Fatalf("CreateDuty failed: %v", err)
+		}
+	}
+	if err := s.AddToVolunteerQueue(ctx, alias.ID, 2); err != nil {
+		t.Fatalf("AddToVolunteerQueue failed: %v", err)
+	}
+	if err := s.AddToVolunteerQueue(ctx, alice.ID, 1); err != nil {
+		t.Fatalf("AddToVolunteerQueue failed: %v", err)
+	}
+	at := time.Date(2025, time.December, 1, 10, 0, 0, 0, time.UTC)
+	if err := s.RecordRoundRobinPick(ctx, "round_robin", alias.ID, at.Add(time.Hour)); err != nil {
+		t.Fatalf("RecordRoundRobinPick failed: %v", err)
+	}
+	if err := s.RecordRoundRobinPick(ctx, "round_robin", alice.ID, at); err != nil {
+		t.Fatalf("RecordRoundRobinPick failed: %v", err)
+	}
+
+	// Both have the perfect month of November, only the alias has October's
+	for _, b := range []*store.Badge{
+		{UserID: alias.ID, Kind: store.BadgePerfectMonth, Period: "2025-10", AwardedAt: at},
+		{UserID: alias.ID, Kind: store.BadgePerfectMonth, Period: "2025-11", AwardedAt: at},
+		{UserID: alice.ID, Kind: store.BadgePerfectMonth, Period: "2025-11", AwardedAt: at},
+	} {
+		if _, err := s.AwardBadge(ctx, b); err != nil {
+			t.Fatalf("AwardBadge failed: %v", err)
+		}
+	}
+
+	m, err := s.MergeUsers(ctx, alias.ID, alice.ID, at)
+	if err != nil {
+		t.Fatalf("MergeUsers failed: %v", err)
+	}
+	if m.ID == 0 || m.DutiesMoved != 2 || m.VolunteerQueueDays != 2 || m.FromName != "Ali" || m.FromTelegramUserID != 2 || m.ToUserID != alice.ID {
+		t.Errorf("MergeUsers: unexpected record %+v", m)
+	}
+
+	if u, _ := s.GetUserByTelegramID(ctx, 2); u != nil {
+		t.Errorf("MergeUsers: expected the merged user to be deleted, got %+v", u)
+	}
+	u, _ := s.GetUserByTelegramID(ctx, 1)
+	if u == nil || u.VolunteerQueueDays != 3 {
+		t.Errorf("MergeUsers: expected 3 volunteer queue days, got %+v", u)
+	}
+	duties, _ := s.GetDutiesByMonth(ctx, 2025, time.December)
+	counts := make(map[int64]int)
+	for _, d := range duties {
+		counts[d.UserID]++
+	}
+	if counts[alice.ID] != 3 || counts[bob.ID] != 1 || counts[alias.ID] != 0 {
+		t.Errorf("MergeUsers: unexpected duties per user %v", counts)
+	}
+	states, _ := s.GetRoundRobinStates(ctx, "round_robin")
+	if len(states) != 1 || states[0].UserID != alice.ID || states[0].AssignmentCount != 2 || !states[0].LastAssignedTimestamp.Equal(at.Add(time.Hour)) {
+		t.Errorf("MergeUsers: unexpected round robin state %+v", states)
+	}
+
+	if badges, _ := s.ListBadges(ctx, alice.ID); len(badges) != 2 {
+		t.Errorf("MergeUsers: expected 2 badges without duplicates, got %+v", badges)
+	}
+
+	if _, err := s.MergeUsers(ctx, alias.ID, alice.ID, at); err == nil {
+		t.Error("MergeUsers: expected an error for a missing user")
+	}
+	merges, err := s.ListUserMerges(ctx)
+	if err != nil || len(merges) != 1 || merges[0].FromUserID != alias.ID {
+		t.Errorf("ListUserMerges: expected the merge, got %+v, %v", merges, err)
+	}
+}
+
+func testDeleteUser(t *testing.T, s store.Store) {
+	ctx := context.Background()
+	alice := mustCreateUser(t, s, 1, "Alice", true)
+	bob := mustCreateUser(t, s, 2, "Bob", true)
+	mustCreateDuty(t, s, alice.ID, date(2025, time.December, 1), store.AssignmentTypeRoundRobin)
+
+	// What only Bob owns goes with him
+	if err := s.SetOffDuty(ctx, bob.ID, date(2025, time.December, 1), date(2025, time.December, 3)); err != nil {
+		t.Fatalf("SetOffDuty failed: %v", err)
+	}
+	if err := s.ReplaceOffDutyPeriods(ctx, bob.ID, "ical", []*store.OffDutyPeriod{
+		{StartDate: date(2025, time.December, 5), EndDate: date(2025, time.December, 6), ExternalID: "trip"},
+	}); err != nil {
+		t.Fatalf("ReplaceOffDutyPeriods failed: %v", err)
+	}
+	if err := s.RecordRoundRobinPick(ctx, "round_robin", bob.ID, time.Now()); err != nil {
+		t.Fatalf("RecordRoundRobinPick failed: %v", err)
+	}
+	if _, err := s.AwardBadge(ctx, &store.Badge{UserID: bob.ID, Kind: store.BadgePerfectMonth, Period: "2025-11", AwardedAt: time.Now()}); err != nil {
+		t.Fatalf("AwardBadge failed: %v", err)
+	}
+
+	if err := s.DeleteUser(ctx, bob.ID); err != nil {
+		t.Fatalf("DeleteUser failed: %v", err)
+	}
+	if u, _ := s.GetUserByTelegramID(ctx, 2); u != nil {
+		t.Errorf("DeleteUser: expected the user to be gone, got %+v", u)
+	}
+	if periods, _ := s.ListOffDutyPeriods(ctx, bob.ID); len(periods) != 0 {
+		t.Errorf("DeleteUser: expected the off-duty periods to be gone, got %+v", periods)
+	}
+	if states, _ := s.GetRoundRobinStates(ctx, "round_robin"); len(states) != 0 {
+		t.Errorf("DeleteUser: expected the round robin state to be gone, got %+v", states)
+	}
+	if badges, _ := s.ListBadges(ctx, bob.ID); len(badges) != 0 {
+		t.Errorf("DeleteUser: expected the badges to be gone, got %+v", badges)
+	}
+
+	// Duties are history, so users who have them stay
+	if err := s.DeleteUser(ctx, alice.ID); err == nil {
+		t.Error("DeleteUser: expected an error for a user with duties")
+	}
+	if u, _ := s.GetUserByTelegramID(ctx, 1); u == nil {
+		t.Error("DeleteUser: expected the user with duties to be kept")
+	}
+}
+
+func testUserHandles(t *testing.T, s store.Store) {
+	ctx := context.Background()
+	alice := mustCreateUser(t, s, 1, "Alice", true)
+	other := mustCreateUser(t, s, 2, "Alice", true)
+	if alice.Handle != "alice" || other.Handle != "alice2" {
+		t.Fatalf("CreateUser: expected handles alice and alice2, got %q and %q", alice.Handle, other.Handle)
+	}
+
+	// A new display name keeps the handle, and the old name no longer matches
+	other.FirstName, other.CustomName, other.Handle = "Ally", true, "changed"
+	if err := s.UpdateUser(ctx, other); err != nil {
+		t.Fatalf("UpdateUser failed: %v", err)
+	}
+	got, err := s.GetUserByName(ctx, "Alice2")
+	if err != nil || got == nil || got.ID != other.ID || got.Handle != "alice2" || got.FirstName != "Ally" || !got.CustomName {
+		t.Errorf("GetUserByName by handle: expected Ally, got (%+v, %v)", got, err)
+	}
+	if got, _ := s.GetUserByName(ctx, "Ally"); got == nil || got.ID != other.ID {
+		t.Errorf("GetUserByName by display name: expected Ally, got %+v", got)
+	}
+
+	// A handle wins over someone else's display name
+	bob := mustCreateUser(t, s, 3, "alice2", true)
+	if got, _ := s.GetUserByName(ctx, "alice2"); got == nil || got.ID != other.ID {
+		t.Errorf("GetUserByName: expected the handle's owner, got %+v", got)
+	}
+	if bob.Handle != "alice22" {
+		t.Errorf("CreateUser: expected handle alice22, got %q", bob.Handle)
+	}
+}
+
+func testTransactions(t *testing.T, s store.Store) {
+	ctx := context.Background()
+	alice := mustCreateUser(t, s, 1, "Alice", true)
+	create := func(tx store.Store, day int) error {
+		return tx.CreateDuty(ctx, &store.Duty{UserID: alice.ID, DutyDate: date(2026, time.January, day), AssignmentType: store.AssignmentTypeAdmin, CreatedAt: time.Now()})
+	}
+
+	failed := errors.New("failed")
+	err := s.RunInTx(ctx, func(tx store.Store) error {
+		if err := create(tx, 1); err != nil {
+			return err
+		}
+		if err := tx.AddToAdminQueue(ctx, alice.ID, 2); err != nil {
+			return err
+		}
+		return failed
+	})
+	if !errors.Is(err, failed) {
+		t.Fatalf("RunInTx: expected fn's error, got %v", err)
+	}
+	if d, _ := s.GetDutyByDate(ctx, date(2026, time.January, 1)); d != nil {
+		t.Errorf("RunInTx: expected the duty to be rolled back, got %+v", d)
+	}
+	if u, _ := s.GetUserByTelegramID(ctx, 1); u.AdminQueueDays != 0 {
+		t.Errorf("RunInTx: expected the queue to be rolled back, got %d days", u.AdminQueueDays)
+	}
+
+	// A failing nested call only undoes its own changes
+	err = s.RunInTx(ctx, func(tx store.Store) error {
+		if err := create(tx, 2); err != nil {
+			return err
+		}
+		if err := tx.RunInTx(ctx, func(inner store.Store) error {
+			if err := create(inner, 3); err != nil {
+				return err
+			}
+			return failed
+		}); !errors.Is(err, failed) {
+			return fmt.Errorf("expected the nested error, got %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("RunInTx failed: %v", err)
+	}
+	duties, _ := s.GetDutiesByMonth(ctx, 2026, time.January)
+	if len(duties) != 1 || duties[0].DutyDate.Day() != 2 {
+		t.Errorf("RunInTx: expected only the duty on the 2nd, got %+v", duties)
+	}
+}
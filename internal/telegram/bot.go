@@ -2,29 +2,60 @@ package telegram
 
 import (
 	"context"
+	"expvar"
 	"fmt"
 	"log"
-	"strings"
+	"net/http"
+	"runtime/debug"
+	"slices"
+	"sync"
+	"time"
 
+	"github.com/korjavin/dutyassistant/internal/notification"
 	"github.com/korjavin/dutyassistant/internal/telegram/handlers"
 	"github.com/korjavin/dutyassistant/internal/telegram/keyboard"
+	"github.com/korjavin/dutyassistant/internal/telegram/parse"
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 )
 
+// panicMessage is sent to the user whose update made a handler panic.
+const panicMessage = "😵 Sorry, something went wrong while handling that. The admin has been told, please try again later."
+
+// maxStackLength keeps the admin alert within Telegram's message size limit.
+const maxStackLength = 3500
+
+// updatePanics counts the updates whose handler panicked.
+var updatePanics = expvar.NewInt("telegram_update_panics")
+
 // Bot represents the Telegram bot application.
 type Bot struct {
 	api      *tgbotapi.BotAPI
 	handlers *handlers.Handlers
-	groupID  int64 // DISH_GROUP ID for access control
+	groupID  int64 // DISH_GROUP ID for access control, unless the settings have another
 	ownerID  int64 // Owner ID for access control
+
+	stop     chan struct{} // closed by Shutdown to stop taking updates
+	stopOnce sync.Once
+	done     chan struct{} // closed when Start returns
+
+	apiErrMu sync.Mutex
+	apiErrAt time.Time // when the last Telegram API call failed
+	apiErr   error
 }
 
 // NewBot creates a new Bot instance.
 func NewBot(apiToken string, h *handlers.Handlers, groupID, ownerID int64) (*Bot, error) {
-	api, err := tgbotapi.NewBotAPI(apiToken)
+	// All API calls go through the throttled client, so they respect
+	// Telegram's rate limits and are tried again when it asks
+	api, err := tgbotapi.NewBotAPIWithClient(apiToken, tgbotapi.APIEndpoint, newThrottledClient(&http.Client{}))
 	if err != nil {
 		return nil, err
 	}
+	return newBot(api, h, groupID, ownerID), nil
+}
+
+// newBot creates a Bot making its calls with api.
+func newBot(api *tgbotapi.BotAPI, h *handlers.Handlers, groupID, ownerID int64) *Bot {
 	api.Debug = false // Set to true for verbose logging
 	log.Printf("Authorized on account %s", api.Self.UserName)
 
@@ -33,18 +64,80 @@ func NewBot(apiToken string, h *handlers.Handlers, groupID, ownerID int64) (*Bot
 		handlers: h,
 		groupID:  groupID,
 		ownerID:  ownerID,
-	}, nil
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+}
+
+// Username returns the bot's Telegram username, e.g. for the Login Widget.
+func (b *Bot) Username() string {
+	return b.api.Self.UserName
 }
 
 // SendMessage sends a text message to a specific chat ID.
 func (b *Bot) SendMessage(chatID int64, text string) error {
 	msg := tgbotapi.NewMessage(chatID, text)
-	_, err := b.api.Send(msg)
+	return b.send(msg)
+}
+
+// SendMessageWithButtons sends a text message with a single row of inline buttons.
+func (b *Bot) SendMessageWithButtons(chatID int64, text string, buttons []notification.Button) error {
+	row := make([]tgbotapi.InlineKeyboardButton, 0, len(buttons))
+	for _, button := range buttons {
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData(button.Text, button.Data))
+	}
+	msg := tgbotapi.NewMessage(chatID, text)
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(row)
+	return b.send(msg)
+}
+
+// DeleteMessage deletes a message the bot sent.
+func (b *Bot) DeleteMessage(chatID int64, messageID int) error {
+	return b.request(tgbotapi.NewDeleteMessage(chatID, messageID))
+}
+
+// RemoveKeyboard takes the inline buttons off a message the bot sent.
+func (b *Bot) RemoveKeyboard(chatID int64, messageID int) error {
+	return b.request(tgbotapi.NewEditMessageReplyMarkup(chatID, messageID,
+		tgbotapi.InlineKeyboardMarkup{InlineKeyboard: [][]tgbotapi.InlineKeyboardButton{}}))
+}
+
+// send sends c, remembering the error if the Telegram API call fails.
+func (b *Bot) send(c tgbotapi.Chattable) error {
+	_, err := b.api.Send(c)
+	b.recordAPIError(err)
+	return err
+}
+
+// request makes a Telegram API call that doesn't send a message, remembering
+// the error if it fails.
+func (b *Bot) request(c tgbotapi.Chattable) error {
+	_, err := b.api.Request(c)
+	b.recordAPIError(err)
 	return err
 }
 
+func (b *Bot) recordAPIError(err error) {
+	if err == nil {
+		return
+	}
+	b.apiErrMu.Lock()
+	b.apiErrAt, b.apiErr = time.Now(), err
+	b.apiErrMu.Unlock()
+}
+
+// LastAPIError returns when the last Telegram API call failed and why, or a
+// zero time and nil if none has failed since the start.
+func (b *Bot) LastAPIError() (time.Time, error) {
+	b.apiErrMu.Lock()
+	defer b.apiErrMu.Unlock()
+	return b.apiErrAt, b.apiErr
+}
+
 // checkAccess verifies if a user has access to the bot.
-// Returns true if the user is the owner or a member of the DISH_GROUP.
+// Returns true if the user is the owner, an admin or a member of the group
+// chat. Admins and the group chat are read from the settings if there are
+// any, so /settings changes them right away.
 func (b *Bot) checkAccess(userID int64) bool {
 	// Owner always has access
 	if b.ownerID != 0 && userID == b.ownerID {
@@ -52,17 +145,42 @@ func (b *Bot) checkAccess(userID int64) bool {
 		return true
 	}
 
+	groupID := b.groupID
+	if s := b.handlers.Settings; s != nil {
+		ctx := context.Background()
+		// Admins have access too
+		if admins, err := s.AdminIDs(ctx); err != nil {
+			log.Printf("[ACCESS] Failed to get the admins: %v", err)
+		} else if slices.Contains(admins, userID) {
+			log.Printf("[ACCESS] User %d granted access as admin", userID)
+			return true
+		}
+		if id, err := s.GroupChatID(ctx); err != nil {
+			log.Printf("[ACCESS] Failed to get the group chat, using %d: %v", groupID, err)
+		} else {
+			groupID = id
+		}
+	}
+
+	// Users who joined with an invitation don't need to be in the group
+	if invited, err := b.handlers.Invites.Invited(context.Background(), userID); err != nil {
+		log.Printf("[ACCESS] Failed to check whether user %d was invited: %v", userID, err)
+	} else if invited {
+		log.Printf("[ACCESS] User %d granted access as invitee", userID)
+		return true
+	}
+
 	// If no group is configured, allow access
-	if b.groupID == 0 {
+	if groupID == 0 {
 		log.Printf("[ACCESS] User %d granted access (no group restriction)", userID)
 		return true
 	}
 
 	// Check if user is a member of the group
-	log.Printf("[ACCESS] Checking group membership for user %d in group %d", userID, b.groupID)
+	log.Printf("[ACCESS] Checking group membership for user %d in group %d", userID, groupID)
 	chatMember, err := b.api.GetChatMember(tgbotapi.GetChatMemberConfig{
 		ChatConfigWithUser: tgbotapi.ChatConfigWithUser{
-			ChatID: b.groupID,
+			ChatID: groupID,
 			UserID: userID,
 		},
 	})
@@ -79,8 +197,18 @@ func (b *Bot) checkAccess(userID int64) bool {
 	return allowed
 }
 
-// Start begins listening for and processing updates from Telegram.
+// isInviteStart reports whether m is /start with a token, as invitation links
+// send it.
+func isInviteStart(m *tgbotapi.Message) bool {
+	return m != nil && m.IsCommand() && m.Command() == "start" && m.CommandArguments() != ""
+}
+
+// Start begins listening for and processing updates from Telegram. It
+// returns when ctx is done or, after handling the updates already received,
+// when Shutdown is called.
 func (b *Bot) Start(ctx context.Context) {
+	defer close(b.done)
+
 	u := tgbotapi.NewUpdate(0)
 	u.Timeout = 60
 
@@ -88,16 +216,56 @@ func (b *Bot) Start(ctx context.Context) {
 
 	for {
 		select {
-		case update := <-updates:
+		case update, ok := <-updates:
+			if !ok {
+				return
+			}
 			b.handleUpdate(update)
 		case <-ctx.Done():
 			return
+		case <-b.stop:
+			b.drain(updates)
+			return
 		}
 	}
 }
 
+// drain handles the updates that were received but not handled yet.
+func (b *Bot) drain(updates tgbotapi.UpdatesChannel) {
+	for {
+		select {
+		case update, ok := <-updates:
+			if !ok {
+				return
+			}
+			b.handleUpdate(update)
+		default:
+			return
+		}
+	}
+}
+
+// Shutdown stops taking new updates from Telegram and waits until the update
+// being handled, and those already received, are done. It gives up when ctx
+// is done, e.g. when a handler hangs.
+func (b *Bot) Shutdown(ctx context.Context) error {
+	b.stopOnce.Do(func() {
+		b.api.StopReceivingUpdates()
+		close(b.stop)
+	})
+
+	select {
+	case <-b.done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("gave up waiting for in-flight updates: %w", ctx.Err())
+	}
+}
+
 // handleUpdate is the central dispatcher for all incoming updates.
 func (b *Bot) handleUpdate(update tgbotapi.Update) {
+	defer b.recoverUpdate(update)
+
 	var err error
 	var response tgbotapi.Chattable
 
@@ -109,18 +277,19 @@ func (b *Bot) handleUpdate(update tgbotapi.Update) {
 		chatID = update.Message.Chat.ID
 	} else if update.CallbackQuery != nil {
 		userID = update.CallbackQuery.From.ID
-		chatID = update.CallbackQuery.Message.Chat.ID
+		chatID = callbackChatID(update.CallbackQuery)
 	}
 
-	// Verify user has access
-	if userID != 0 && !b.checkAccess(userID) {
+	// Verify user has access. Invitation links start the bot with a token,
+	// which the /start handler checks.
+	if userID != 0 && !isInviteStart(update.Message) && !b.checkAccess(userID) {
 		log.Printf("Access denied for user %d", userID)
 		ownerMention := ""
 		if b.ownerID != 0 {
 			ownerMention = fmt.Sprintf(" Please contact the bot owner (ID: %d) for access.", b.ownerID)
 		}
 		response = tgbotapi.NewMessage(chatID, fmt.Sprintf("🚫 Access denied. You must be a member of the authorized group to use this bot.%s", ownerMention))
-		if _, err := b.api.Send(response); err != nil {
+		if err := b.send(response); err != nil {
 			log.Printf("Error sending access denied message: %v", err)
 		}
 		return
@@ -131,6 +300,14 @@ func (b *Bot) handleUpdate(update tgbotapi.Update) {
 		response, err = b.handleCommand(update.Message)
 	case update.CallbackQuery != nil:
 		response, err = b.handleCallbackQuery(update.CallbackQuery)
+	case update.PreCheckoutQuery != nil:
+		// Answered with a request, it isn't a message; Telegram cancels the
+		// payment unless it gets the answer within 10 seconds
+		err = b.request(b.handlers.HandlePreCheckout(update.PreCheckoutQuery))
+	case update.Message != nil:
+		// Other messages are only read for replies to /handoff and trips
+		// announced in the group
+		response, err = b.handlers.HandleMessage(update.Message)
 	}
 
 	if err != nil {
@@ -139,7 +316,7 @@ func (b *Bot) handleUpdate(update tgbotapi.Update) {
 		if update.Message != nil {
 			chatID = update.Message.Chat.ID
 		} else if update.CallbackQuery != nil {
-			chatID = update.CallbackQuery.Message.Chat.ID
+			chatID = callbackChatID(update.CallbackQuery)
 		}
 		if chatID != 0 {
 			response = tgbotapi.NewMessage(chatID, "An unexpected error occurred. Please try again.")
@@ -149,52 +326,117 @@ func (b *Bot) handleUpdate(update tgbotapi.Update) {
 	}
 
 	if response != nil {
-		if _, err := b.api.Send(response); err != nil {
+		sent, err := b.api.Send(response)
+		b.recordAPIError(err)
+		if err != nil {
 			log.Printf("Error sending response: %v", err)
+		} else if update.Message != nil && sent.Chat != nil && sent.ReplyMarkup != nil {
+			// Only whoever opened a menu may press its buttons
+			b.handlers.BindMenu(sent.Chat.ID, sent.MessageID, update.Message.From.ID)
+			if b.handlers.Cleanup != nil {
+				if err := b.handlers.Cleanup.Track(context.Background(), sent.Chat.ID, sent.MessageID); err != nil {
+					log.Printf("Error tracking menu %d: %v", sent.MessageID, err)
+				}
+			}
+		} else if update.CallbackQuery != nil && b.handlers.Cleanup != nil && finishesMenu(response, update.CallbackQuery) {
+			q := update.CallbackQuery
+			if err := b.handlers.Cleanup.Done(context.Background(), q.Message.Chat.ID, q.Message.MessageID); err != nil {
+				log.Printf("Error marking menu %d done: %v", q.Message.MessageID, err)
+			}
+		}
+	}
+}
+
+// callbackChatID returns the chat to answer q in: the chat of its message, or
+// the sender's private chat for buttons of inline-mode messages, which come
+// without one.
+func callbackChatID(q *tgbotapi.CallbackQuery) int64 {
+	if q.Message == nil {
+		return q.From.ID
+	}
+	return q.Message.Chat.ID
+}
+
+// finishesMenu reports whether response to a button press replaces the menu
+// with a text without buttons, which is how a finished interaction ends.
+func finishesMenu(response tgbotapi.Chattable, q *tgbotapi.CallbackQuery) bool {
+	edit, ok := response.(tgbotapi.EditMessageTextConfig)
+	return ok && q.Message != nil && edit.ChatID == q.Message.Chat.ID &&
+		edit.MessageID == q.Message.MessageID && edit.ReplyMarkup == nil
+}
+
+// recoverUpdate stops a panic in a handler from taking the bot down. The user
+// gets an apology and the owner gets the stack trace.
+func (b *Bot) recoverUpdate(update tgbotapi.Update) {
+	r := recover()
+	if r == nil {
+		return
+	}
+	updatePanics.Add(1)
+	stack := string(debug.Stack())
+	log.Printf("[PANIC] Recovered while handling %s: %v\n%s", describeUpdate(update), r, stack)
+
+	chatID := int64(0)
+	if update.Message != nil {
+		chatID = update.Message.Chat.ID
+	} else if update.CallbackQuery != nil && update.CallbackQuery.Message != nil {
+		chatID = update.CallbackQuery.Message.Chat.ID
+	}
+	if chatID != 0 {
+		if err := b.SendMessage(chatID, panicMessage); err != nil {
+			log.Printf("[PANIC] Failed to apologize in chat %d: %v", chatID, err)
 		}
 	}
+
+	if b.ownerID == 0 {
+		return
+	}
+	if len(stack) > maxStackLength {
+		stack = stack[:maxStackLength] + "\n…"
+	}
+	alert := fmt.Sprintf("🔥 Panic while handling %s: %v\n\n%s", describeUpdate(update), r, stack)
+	if err := b.SendMessage(b.ownerID, alert); err != nil {
+		log.Printf("[PANIC] Failed to alert the owner: %v", err)
+	}
+}
+
+// describeUpdate names an update for logs and alerts.
+func describeUpdate(update tgbotapi.Update) string {
+	switch {
+	case update.Message != nil && update.Message.IsCommand():
+		return fmt.Sprintf("update %d (/%s)", update.UpdateID, update.Message.Command())
+	case update.CallbackQuery != nil:
+		return fmt.Sprintf("update %d (callback %s)", update.UpdateID, parse.Action(update.CallbackQuery.Data))
+	default:
+		return fmt.Sprintf("update %d", update.UpdateID)
+	}
 }
 
 // handleCommand routes a command to the appropriate handler.
 func (b *Bot) handleCommand(m *tgbotapi.Message) (tgbotapi.Chattable, error) {
-	switch m.Command() {
-	case "start":
-		return b.handlers.HandleStart(m)
-	case "help":
-		return b.handlers.HandleHelp(m)
-	case "status":
-		return b.handlers.HandleStatus(m)
-	case "schedule":
-		return b.handlers.HandleSchedule(m)
-	case "volunteer":
-		return b.handlers.HandleVolunteer(m)
-	case "assign":
-		return b.handlers.HandleAssign(m)
-	case "modify":
-		return b.handlers.HandleModify(m)
-	case "change":
-		return b.handlers.HandleChange(m)
-	case "offduty":
-		return b.handlers.HandleOffDuty(m)
-	case "users":
-		return b.handlers.HandleUsers(m)
-	case "toggle_active", "toggleactive":
-		return b.handlers.HandleToggleActive(m)
-	default:
-		msg := tgbotapi.NewMessage(m.Chat.ID, "Unknown command. Use /help for a list of commands.")
-		return msg, nil
+	if refusal := b.handlers.AuthorizeCommand(m); refusal != nil {
+		return refusal, nil
+	}
+
+	if cmd := handlers.LookupCommand(m.Command()); cmd != nil {
+		return cmd.Handle(b.handlers, m)
 	}
+	return b.handlers.HandleUnknownCommand(m)
 }
 
 // handleCallbackQuery routes a callback query to the appropriate handler.
 func (b *Bot) handleCallbackQuery(q *tgbotapi.CallbackQuery) (tgbotapi.Chattable, error) {
 	// Answer the callback query to remove the "loading" state on the user's side.
 	callback := tgbotapi.NewCallback(q.ID, "")
-	if _, err := b.api.Request(callback); err != nil {
+	if err := b.request(callback); err != nil {
 		log.Printf("failed to answer callback query: %v", err)
 	}
 
-	action := strings.Split(q.Data, ":")[0]
+	if refusal := b.handlers.AuthorizeCallback(q); refusal != nil {
+		return refusal, nil
+	}
+
+	action := parse.Action(q.Data)
 
 	switch action {
 	case keyboard.ActionPrevMonth, keyboard.ActionNextMonth:
@@ -205,6 +447,8 @@ func (b *Bot) handleCallbackQuery(q *tgbotapi.CallbackQuery) (tgbotapi.Chattable
 		return nil, nil
 	case keyboard.ActionIgnore:
 		return nil, nil // Do nothing for ignore actions
+	case keyboard.ActionPage:
+		return b.handlers.HandlePageCallback(q)
 	case "assign_user":
 		return b.handlers.HandleAssignUserCallback(q)
 	case "assign_days":
@@ -223,6 +467,32 @@ func (b *Bot) handleCallbackQuery(q *tgbotapi.CallbackQuery) (tgbotapi.Chattable
 		return b.handlers.HandleToggleUserCallback(q)
 	case "offduty_user":
 		return b.handlers.HandleOffDutyUserCallback(q)
+	case "offimport_ok", "offimport_cancel":
+		return b.handlers.HandleOffDutyImportCallback(q)
+	case "notif_toggle", "notif_time", "notif_hour", "notif_back":
+		return b.handlers.HandleNotificationsCallback(q)
+	case notification.SnoozeAction:
+		return b.handlers.HandleSnoozeCallback(q)
+	case notification.AcknowledgeAction:
+		return b.handlers.HandleAcknowledgeCallback(q)
+	case "check_item":
+		return b.handlers.HandleChecklistCallback(q)
+	case notification.TakeoverAssignAction, notification.TakeoverSkipAction, notification.TakeoverExternalAction, "takeover_user":
+		return b.handlers.HandleTakeoverCallback(q)
+	case notification.ApproveUserAction, notification.RejectUserAction:
+		return b.handlers.HandleApprovalCallback(q)
+	case notification.ClaimTaskAction, notification.CompleteTaskAction, notification.ReleaseTaskAction:
+		return b.handlers.HandleTaskCallback(q)
+	case "trip_offduty", "trip_dismiss":
+		return b.handlers.HandleTripCallback(q)
+	case notification.VolunteerPollAction:
+		return b.handlers.HandlePollCallback(q)
+	case notification.CoverAction:
+		return b.handlers.HandleCoverCallback(q)
+	case notification.PayFineAction:
+		return b.handlers.HandlePayFineCallback(q)
+	case notification.ReassignTodayAction, notification.SkipTodayAction:
+		return b.handlers.HandleCorrectionCallback(q)
 	default:
 		log.Printf("Unknown callback action: %s", action)
 		return nil, nil
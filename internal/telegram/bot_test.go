@@ -0,0 +1,104 @@
+package telegram
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/korjavin/dutyassistant/internal/scheduler"
+	"github.com/korjavin/dutyassistant/internal/store"
+	"github.com/korjavin/dutyassistant/internal/store/memory"
+	"github.com/korjavin/dutyassistant/internal/telegram/handlers"
+	"github.com/korjavin/dutyassistant/internal/telegram/telegramtest"
+	"github.com/stretchr/testify/assert"
+)
+
+// startBot runs a bot with an empty roster against a fake Bot API until the
+// test ends.
+func startBot(t *testing.T) (*telegramtest.Server, store.Store) {
+	t.Helper()
+	srv := telegramtest.NewServer(t)
+	s := memory.New()
+	b := newBot(srv.API(t), handlers.New(s, scheduler.NewScheduler(s)), 0, 0)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go b.Start(ctx)
+	t.Cleanup(func() {
+		shutdownCtx, done := context.WithTimeout(context.Background(), 5*time.Second)
+		defer done()
+		if err := b.Shutdown(shutdownCtx); err != nil {
+			t.Errorf("Shutdown failed: %v", err)
+		}
+		cancel()
+	})
+	return srv, s
+}
+
+func TestBot_Menu(t *testing.T) {
+	srv, s := startBot(t)
+	ctx := context.Background()
+	admin := &tgbotapi.User{ID: 123, FirstName: "Admin"}
+	alice := &tgbotapi.User{ID: 456, FirstName: "Alice"}
+	if err := s.CreateUser(ctx, &store.User{TelegramUserID: admin.ID, FirstName: "Admin", IsAdmin: true, IsActive: true}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.CreateUser(ctx, &store.User{TelegramUserID: alice.ID, FirstName: "Alice", IsActive: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	srv.SendText(admin, -100, "/toggle_active")
+	call := srv.Expect(t, "sendMessage")
+	assert.Equal(t, "-100", call.Params["chat_id"])
+	assert.Equal(t, "HTML", call.Params["parse_mode"])
+	menu := call.Message
+	assert.Equal(t, "🔄 <b>Toggle user active status</b>\n\nSelect a user:", menu.Text)
+	var buttons []string
+	for _, row := range menu.ReplyMarkup.InlineKeyboard {
+		for _, button := range row {
+			buttons = append(buttons, button.Text)
+		}
+	}
+	assert.Equal(t, []string{"✅ Admin", "✅ Alice"}, buttons)
+	data := telegramtest.ButtonData(t, menu, "✅ Alice")
+
+	// The menu is the admin's, Alice can't press its buttons
+	srv.Press(alice, menu, data)
+	srv.Expect(t, "answerCallbackQuery")
+	call = srv.Expect(t, "sendMessage")
+	assert.Equal(t, "This menu was opened by someone else. Send the command yourself to get your own.", call.Message.Text)
+	assert.Nil(t, call.Message.ReplyMarkup)
+	assert.Equal(t, menu, srv.Message(-100, menu.MessageID), "The menu is left alone")
+
+	srv.Press(admin, menu, data)
+	srv.Expect(t, "answerCallbackQuery")
+	call = srv.Expect(t, "editMessageText")
+	assert.Equal(t, "✅ Successfully set status for <b>Alice</b> to inactive.", call.Message.Text)
+	assert.Nil(t, call.Message.ReplyMarkup, "The buttons are gone once the menu is done")
+	assert.Equal(t, call.Message, srv.Message(-100, menu.MessageID))
+
+	u, err := s.GetUserByTelegramID(ctx, alice.ID)
+	assert.NoError(t, err)
+	assert.False(t, u.IsActive)
+}
+
+func TestBot_CommandTypo(t *testing.T) {
+	srv, _ := startBot(t)
+	srv.SendText(&tgbotapi.User{ID: 456, FirstName: "Alice"}, 456, "/shcedule")
+	call := srv.Expect(t, "sendMessage")
+	assert.Equal(t, "456", call.Params["chat_id"])
+	assert.Equal(t, "Unknown command. Did you mean /schedule? Use /help for a list of commands.", call.Message.Text)
+}
+
+func TestBot_InlineCallback(t *testing.T) {
+	srv, _ := startBot(t)
+	alice := &tgbotapi.User{ID: 456, FirstName: "Alice"}
+
+	// Buttons of inline-mode messages come without the message
+	srv.AddUpdate(tgbotapi.Update{CallbackQuery: &tgbotapi.CallbackQuery{
+		ID: "1", From: alice, InlineMessageID: "inline", Data: "assign_days:2:7",
+	}})
+	srv.Expect(t, "answerCallbackQuery")
+	call := srv.Expect(t, "sendMessage")
+	assert.Equal(t, "456", call.Params["chat_id"], "the refusal goes to the sender")
+}
@@ -4,10 +4,11 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"slices"
 	"strings"
-	"time"
 
-	"github.com/korjavin/dutyassistant/internal/store"
+	"github.com/korjavin/dutyassistant/internal/scheduler"
+	"github.com/korjavin/dutyassistant/internal/telegram/parse"
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 )
 
@@ -20,28 +21,60 @@ const (
 	modifyFailureMessage  = "Failed to modify duty for date %s."
 	toggleSuccessMessage  = "Successfully set status for %s to %s."
 	toggleFailureMessage  = "Failed to update user status."
+	togglePendingMessage  = "%s is waiting for approval. Use the buttons sent to the admins to approve or reject them."
 	invalidDateMessage    = "Invalid date format. Please use YYYY-MM-DD."
+	modifyUsageMessage    = "⚠️ Invalid format.\n\nUsage: <code>/modify date username [keep|refund]</code>\n\n" +
+		"Example: <code>/modify 2025-10-10 John refund</code>\n\n" +
+		"With <i>refund</i> the previous user gets back the queue day the duty used up and the new user's queue is charged instead."
 )
 
+// adminIDs returns the Telegram user IDs of the admins: those in the
+// settings, or without them the one from the ADMIN_ID env var.
+func (h *Handlers) adminIDs(ctx context.Context) ([]int64, error) {
+	if h.Settings != nil {
+		return h.Settings.AdminIDs(ctx)
+	}
+	if h.AdminID == 0 {
+		return nil, nil
+	}
+	return []int64{h.AdminID}, nil
+}
+
 // checkAdmin is a helper function to verify if a user is an admin.
-// Admin is determined by matching the Telegram user ID against the ADMIN_ID env var.
+// Admins are those configured in the settings, seeded from the ADMIN_ID env var.
 func (h *Handlers) checkAdmin(telegramUserID int64) (bool, error) {
-	if h.AdminID == 0 {
-		log.Printf("[checkAdmin] AdminID not configured (0), falling back to database flag for user %d", telegramUserID)
-		// Fallback to database flag if AdminID is not configured
-		user, err := h.Store.GetUserByTelegramID(context.Background(), telegramUserID)
-		if err != nil || user == nil {
+	admins, err := h.adminIDs(context.Background())
+	if err != nil {
+		log.Printf("[checkAdmin] Failed to get the admins for user %d: %v", telegramUserID, err)
+		return false, err
+	}
+	if len(admins) == 0 {
+		log.Printf("[checkAdmin] No admins configured, falling back to database flag for user %d", telegramUserID)
+		// Fallback to database flag if no admins are configured
+		user, err := h.Users.ByTelegramID(context.Background(), telegramUserID)
+		if err != nil {
 			log.Printf("[checkAdmin] User %d not found in database or error: %v", telegramUserID, err)
 			return false, err
 		}
 		log.Printf("[checkAdmin] User %d IsAdmin flag from database: %v", telegramUserID, user.IsAdmin)
 		return user.IsAdmin, nil
 	}
-	isAdmin := telegramUserID == h.AdminID
-	log.Printf("[checkAdmin] Configured AdminID=%d, User=%d, isAdmin=%v", h.AdminID, telegramUserID, isAdmin)
+	isAdmin := slices.Contains(admins, telegramUserID)
+	log.Printf("[checkAdmin] Configured admins=%v, User=%d, isAdmin=%v", admins, telegramUserID, isAdmin)
 	return isAdmin, nil
 }
 
+// refuseNonAdminCallback checks again that whoever pressed an admin button is
+// an admin, in case the dispatcher's check is bypassed. If not, it returns the
+// edit replacing the menu and false.
+func (h *Handlers) refuseNonAdminCallback(q *tgbotapi.CallbackQuery) (tgbotapi.EditMessageTextConfig, bool) {
+	isAdmin, err := h.checkAdmin(q.From.ID)
+	if err != nil || !isAdmin {
+		return tgbotapi.NewEditMessageText(q.Message.Chat.ID, q.Message.MessageID, adminOnlyMessage), false
+	}
+	return tgbotapi.EditMessageTextConfig{}, true
+}
+
 // HandleAssign handles the /assign command for admins. Format: /assign [username] [days]
 func (h *Handlers) HandleAssign(m *tgbotapi.Message) (tgbotapi.MessageConfig, error) {
 	isAdmin, err := h.checkAdmin(m.From.ID)
@@ -53,26 +86,12 @@ func (h *Handlers) HandleAssign(m *tgbotapi.Message) (tgbotapi.MessageConfig, er
 
 	// If no arguments provided, show user selection buttons
 	if len(args) == 0 {
-		users, err := h.Store.ListActiveUsers(context.Background())
-		if err != nil || len(users) == 0 {
+		text, keyboard, err := h.userPicker(context.Background(), "assign_user", 0)
+		if err != nil {
 			msg := tgbotapi.NewMessage(m.Chat.ID, "No active users found.")
 			return msg, nil
 		}
-
-		// Create inline keyboard with user buttons
-		var buttons [][]tgbotapi.InlineKeyboardButton
-		for _, u := range users {
-			row := []tgbotapi.InlineKeyboardButton{
-				tgbotapi.NewInlineKeyboardButtonData(
-					fmt.Sprintf("👤 %s", u.FirstName),
-					fmt.Sprintf("assign_user:%d", u.ID),
-				),
-			}
-			buttons = append(buttons, row)
-		}
-
-		keyboard := tgbotapi.NewInlineKeyboardMarkup(buttons...)
-		msg := tgbotapi.NewMessage(m.Chat.ID, "📋 <b>Assign days to admin queue</b>\n\nSelect a user:")
+		msg := tgbotapi.NewMessage(m.Chat.ID, text)
 		msg.ParseMode = tgbotapi.ModeHTML
 		msg.ReplyMarkup = keyboard
 		return msg, nil
@@ -86,16 +105,15 @@ func (h *Handlers) HandleAssign(m *tgbotapi.Message) (tgbotapi.MessageConfig, er
 	}
 
 	userName := args[0]
-	var days int
-	_, err = fmt.Sscanf(args[1], "%d", &days)
-	if err != nil || days <= 0 {
-		msg := tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("⚠️ '%s' is not a valid number of days.\n\nPlease use a positive number.\n\nExample: <code>/assign %s 3</code>", args[1], userName))
+	days, err := parse.Days(args[1])
+	if err != nil {
+		msg := tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("⚠️ %s.\n\nPlease use a number between 1 and %d.\n\nExample: <code>/assign %s 3</code>", escapeHTML(err.Error()), parse.MaxDays, escapeHTML(userName)))
 		msg.ParseMode = tgbotapi.ModeHTML
 		return msg, nil
 	}
 
-	user, err := h.Store.GetUserByName(context.Background(), userName)
-	if err != nil || user == nil {
+	user, err := h.Users.ByName(context.Background(), userName)
+	if err != nil {
 		// Get list of users for suggestion
 		users, _ := h.Store.ListActiveUsers(context.Background())
 		suggestions := ""
@@ -111,6 +129,8 @@ func (h *Handlers) HandleAssign(m *tgbotapi.Message) (tgbotapi.MessageConfig, er
 	if err := h.Scheduler.AssignDuty(context.Background(), user, days); err != nil {
 		return tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("❌ Failed to assign %d days to %s: %v", days, userName, err)), nil
 	}
+	// Calendars show the admin queue
+	h.InvalidateCalendars(context.Background(), nil)
 
 	return tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("✅ Successfully added %d day(s) to admin queue for %s.", days, userName)), nil
 }
@@ -127,11 +147,11 @@ func (h *Handlers) HandleModify(m *tgbotapi.Message) (tgbotapi.MessageConfig, er
 
 	// No args - show date selection buttons (today + next 7 days)
 	if len(args) == 0 {
-		now := time.Now()
+		today := h.today()
 		var buttons [][]tgbotapi.InlineKeyboardButton
 
 		for i := 0; i < 7; i++ {
-			date := now.AddDate(0, 0, i)
+			date := today.AddDate(0, 0, i)
 			dateStr := date.Format("2006-01-02")
 			label := dateStr
 			if i == 0 {
@@ -152,56 +172,52 @@ func (h *Handlers) HandleModify(m *tgbotapi.Message) (tgbotapi.MessageConfig, er
 
 	// One arg (date) - show user selection buttons
 	if len(args) == 1 {
-		dateStr := args[0]
-		_, err := time.Parse("2006-01-02", dateStr)
+		date, err := parse.Date(args[0])
 		if err != nil {
 			msg := tgbotapi.NewMessage(m.Chat.ID,
-				fmt.Sprintf("⚠️ Invalid date '%s'\n\nPlease use format: YYYY-MM-DD\n\nExample: <code>/modify 2025-10-10 John</code>", dateStr))
+				fmt.Sprintf("⚠️ Invalid date '%s'\n\nPlease use format: YYYY-MM-DD\n\nExample: <code>/modify 2025-10-10 John</code>", escapeHTML(args[0])))
 			msg.ParseMode = tgbotapi.ModeHTML
 			return msg, nil
 		}
+		dateStr := date.Format(parse.DateLayout)
 
-		users, err := h.Store.ListActiveUsers(context.Background())
-		if err != nil || len(users) == 0 {
+		text, keyboard, err := h.userPicker(context.Background(), "modify_user:"+dateStr, 0)
+		if err != nil {
 			return tgbotapi.NewMessage(m.Chat.ID, "No active users found."), nil
 		}
-
-		var buttons [][]tgbotapi.InlineKeyboardButton
-		for _, u := range users {
-			row := []tgbotapi.InlineKeyboardButton{
-				tgbotapi.NewInlineKeyboardButtonData(
-					fmt.Sprintf("👤 %s", u.FirstName),
-					fmt.Sprintf("modify_user:%s:%d", dateStr, u.ID),
-				),
-			}
-			buttons = append(buttons, row)
-		}
-
-		keyboard := tgbotapi.NewInlineKeyboardMarkup(buttons...)
-		msg := tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("🔄 <b>Modify duty for %s</b>\n\nSelect the new user:", dateStr))
+		msg := tgbotapi.NewMessage(m.Chat.ID, text)
 		msg.ParseMode = tgbotapi.ModeHTML
 		msg.ReplyMarkup = keyboard
 		return msg, nil
 	}
 
-	if len(args) != 2 {
-		msg := tgbotapi.NewMessage(m.Chat.ID, "⚠️ Invalid format.\n\nUsage: <code>/modify date username</code>\n\nExample: <code>/modify 2025-10-10 John</code>")
+	if len(args) != 2 && len(args) != 3 {
+		msg := tgbotapi.NewMessage(m.Chat.ID, modifyUsageMessage)
 		msg.ParseMode = tgbotapi.ModeHTML
 		return msg, nil
 	}
 
+	mode := scheduler.ChangeModeKeep
+	if len(args) == 3 {
+		if mode, err = scheduler.ParseChangeMode(args[2]); err != nil {
+			msg := tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("⚠️ Unknown mode '%s'.\n\n%s", escapeHTML(args[2]), modifyUsageMessage))
+			msg.ParseMode = tgbotapi.ModeHTML
+			return msg, nil
+		}
+	}
+
 	dateStr, userName := args[0], args[1]
-	dutyDate, err := time.Parse("2006-01-02", dateStr)
+	dutyDate, err := parse.Date(dateStr)
 	if err != nil {
 		return tgbotapi.NewMessage(m.Chat.ID, invalidDateMessage), nil
 	}
 
-	user, err := h.Store.GetUserByName(context.Background(), userName)
-	if err != nil || user == nil {
+	user, err := h.Users.ByName(context.Background(), userName)
+	if err != nil {
 		return tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf(userNotFoundMessage, userName)), nil
 	}
 
-	if _, err := h.Scheduler.ChangeDutyUser(context.Background(), dutyDate, user.ID); err != nil {
+	if _, err := h.Duties.Reassign(context.Background(), dutyDate, user.ID, mode); err != nil {
 		return tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("Failed to change duty for %s: %v", dateStr, err)), nil
 	}
 
@@ -228,7 +244,9 @@ func (h *Handlers) HandleUsers(m *tgbotapi.Message) (tgbotapi.MessageConfig, err
 	builder.WriteString("<b>📋 User List</b>\n\n")
 	for _, u := range users {
 		status := "✅ Active"
-		if !u.IsActive {
+		if u.IsPending {
+			status = "⏳ Pending"
+		} else if !u.IsActive {
 			status = "❌ Inactive"
 		}
 		adminStatus := ""
@@ -236,7 +254,7 @@ func (h *Handlers) HandleUsers(m *tgbotapi.Message) (tgbotapi.MessageConfig, err
 			adminStatus = " 👑"
 		}
 
-		builder.WriteString(fmt.Sprintf("<b>%s</b>%s: %s\n", u.FirstName, adminStatus, status))
+		builder.WriteString(fmt.Sprintf("<b>%s</b>%s: %s <code>#%d</code> <code>%s</code>\n", u.FirstName, adminStatus, status, u.ID, u.Handle))
 
 		// Show queues if any
 		if u.VolunteerQueueDays > 0 || u.AdminQueueDays > 0 {
@@ -266,42 +284,29 @@ func (h *Handlers) HandleToggleActive(m *tgbotapi.Message) (tgbotapi.MessageConf
 
 	userName := m.CommandArguments()
 	if userName == "" {
-		users, err := h.Store.ListAllUsers(context.Background())
-		if err != nil || len(users) == 0 {
+		text, keyboard, err := h.userPicker(context.Background(), "toggle_user", 0)
+		if err != nil {
 			return tgbotapi.NewMessage(m.Chat.ID, "No users found."), nil
 		}
-
-		var buttons [][]tgbotapi.InlineKeyboardButton
-		for _, u := range users {
-			status := "✅"
-			if !u.IsActive {
-				status = "❌"
-			}
-			row := []tgbotapi.InlineKeyboardButton{
-				tgbotapi.NewInlineKeyboardButtonData(
-					fmt.Sprintf("%s %s", status, u.FirstName),
-					fmt.Sprintf("toggle_user:%d", u.ID),
-				),
-			}
-			buttons = append(buttons, row)
-		}
-
-		keyboard := tgbotapi.NewInlineKeyboardMarkup(buttons...)
-		msg := tgbotapi.NewMessage(m.Chat.ID, "🔄 <b>Toggle user active status</b>\n\nSelect a user:")
+		msg := tgbotapi.NewMessage(m.Chat.ID, text)
 		msg.ParseMode = tgbotapi.ModeHTML
 		msg.ReplyMarkup = keyboard
 		return msg, nil
 	}
 
-	user, err := h.Store.GetUserByName(context.Background(), userName)
-	if err != nil || user == nil {
+	user, err := h.Users.ByName(context.Background(), userName)
+	if err != nil {
 		return tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf(userNotFoundMessage, userName)), nil
 	}
 
-	user.IsActive = !user.IsActive
-	if err := h.Store.UpdateUser(context.Background(), user); err != nil {
+	if user.IsPending {
+		return tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf(togglePendingMessage, user.FirstName)), nil
+	}
+	if err := h.Users.ToggleActive(context.Background(), user); err != nil {
 		return tgbotapi.NewMessage(m.Chat.ID, toggleFailureMessage), nil
 	}
+	// Only active users show up in the calendar legend
+	h.InvalidateCalendars(context.Background(), nil)
 
 	newStatus := "Active"
 	if !user.IsActive {
@@ -321,25 +326,12 @@ func (h *Handlers) HandleOffDuty(m *tgbotapi.Message) (tgbotapi.MessageConfig, e
 
 	// If no arguments, show user selection with buttons
 	if len(args) == 0 {
-		users, err := h.Store.ListActiveUsers(context.Background())
-		if err != nil || len(users) == 0 {
+		text, keyboard, err := h.userPicker(context.Background(), "offduty_user", 0)
+		if err != nil {
 			msg := tgbotapi.NewMessage(m.Chat.ID, "No active users found.")
 			return msg, nil
 		}
-
-		var buttons [][]tgbotapi.InlineKeyboardButton
-		for _, u := range users {
-			row := []tgbotapi.InlineKeyboardButton{
-				tgbotapi.NewInlineKeyboardButtonData(
-					fmt.Sprintf("👤 %s", u.FirstName),
-					fmt.Sprintf("offduty_user:%d:%s", u.ID, u.FirstName),
-				),
-			}
-			buttons = append(buttons, row)
-		}
-
-		keyboard := tgbotapi.NewInlineKeyboardMarkup(buttons...)
-		msg := tgbotapi.NewMessage(m.Chat.ID, "🏖 <b>Set off-duty period</b>\n\nSelect a user:")
+		msg := tgbotapi.NewMessage(m.Chat.ID, text)
 		msg.ParseMode = tgbotapi.ModeHTML
 		msg.ReplyMarkup = keyboard
 		return msg, nil
@@ -366,30 +358,19 @@ func (h *Handlers) HandleOffDuty(m *tgbotapi.Message) (tgbotapi.MessageConfig, e
 	}
 
 	userName := args[0]
-	startDate, err := time.Parse("2006-01-02", args[1])
+	startDate, endDate, err := parse.DateRange(args[1], args[2])
 	if err != nil {
 		msg := tgbotapi.NewMessage(m.Chat.ID,
-			fmt.Sprintf("⚠️ Invalid start date '%s'\n\n"+
+			fmt.Sprintf("⚠️ Invalid off-duty period: %s\n\n"+
 			"Please use format: YYYY-MM-DD\n\n"+
 			"Example: <code>/offduty %s 2025-10-10 2025-10-15</code>",
-			args[1], userName))
+			escapeHTML(err.Error()), escapeHTML(userName)))
 		msg.ParseMode = tgbotapi.ModeHTML
 		return msg, nil
 	}
 
-	endDate, err := time.Parse("2006-01-02", args[2])
+	user, err := h.Users.ByName(context.Background(), userName)
 	if err != nil {
-		msg := tgbotapi.NewMessage(m.Chat.ID,
-			fmt.Sprintf("⚠️ Invalid end date '%s'\n\n"+
-			"Please use format: YYYY-MM-DD\n\n"+
-			"Example: <code>/offduty %s %s 2025-10-15</code>",
-			args[2], userName, args[1]))
-		msg.ParseMode = tgbotapi.ModeHTML
-		return msg, nil
-	}
-
-	user, err := h.Store.GetUserByName(context.Background(), userName)
-	if err != nil || user == nil {
 		users, _ := h.Store.ListActiveUsers(context.Background())
 		suggestions := ""
 		if len(users) > 0 {
@@ -416,29 +397,22 @@ func (h *Handlers) HandleChange(m *tgbotapi.Message) (tgbotapi.MessageConfig, er
 
 // HandleAssignUserCallback handles the callback when a user is selected from inline keyboard
 func (h *Handlers) HandleAssignUserCallback(q *tgbotapi.CallbackQuery) (tgbotapi.EditMessageTextConfig, error) {
-	parts := strings.Split(q.Data, ":")
-	if len(parts) != 2 {
-		return tgbotapi.EditMessageTextConfig{}, fmt.Errorf("invalid callback data")
+	if refusal, ok := h.refuseNonAdminCallback(q); !ok {
+		return refusal, nil
 	}
 
-	userID := parts[1]
-
-	// Get user info
-	var id int64
-	fmt.Sscanf(userID, "%d", &id)
-	user, err := h.Store.GetUserByTelegramID(context.Background(), id)
-	if err != nil || user == nil {
-		// Try by ID directly
-		users, _ := h.Store.ListAllUsers(context.Background())
-		for _, u := range users {
-			if u.ID == id {
-				user = u
-				break
-			}
-		}
+	cb := parse.ParseCallback(q.Data)
+	if err := cb.Expect(1); err != nil {
+		return tgbotapi.EditMessageTextConfig{}, err
+	}
+	id, err := cb.ID(0)
+	if err != nil {
+		return tgbotapi.EditMessageTextConfig{}, err
 	}
 
-	if user == nil {
+	// Get user info
+	user, err := h.Users.ByID(context.Background(), id)
+	if err != nil {
 		edit := tgbotapi.NewEditMessageText(q.Message.Chat.ID, q.Message.MessageID, "❌ User not found")
 		return edit, nil
 	}
@@ -474,32 +448,32 @@ func (h *Handlers) HandleAssignUserCallback(q *tgbotapi.CallbackQuery) (tgbotapi
 
 // HandleAssignDaysCallback handles the final confirmation when days are selected
 func (h *Handlers) HandleAssignDaysCallback(q *tgbotapi.CallbackQuery) (tgbotapi.EditMessageTextConfig, error) {
-	parts := strings.Split(q.Data, ":")
-	if len(parts) != 3 {
-		return tgbotapi.EditMessageTextConfig{}, fmt.Errorf("invalid callback data")
+	if refusal, ok := h.refuseNonAdminCallback(q); !ok {
+		return refusal, nil
 	}
 
-	var userID, days int64
-	fmt.Sscanf(parts[1], "%d", &userID)
-	fmt.Sscanf(parts[2], "%d", &days)
-
-	// Get user
-	users, _ := h.Store.ListAllUsers(context.Background())
-	var user *store.User
-	for _, u := range users {
-		if u.ID == userID {
-			user = u
-			break
-		}
+	cb := parse.ParseCallback(q.Data)
+	if err := cb.Expect(2); err != nil {
+		return tgbotapi.EditMessageTextConfig{}, err
+	}
+	userID, err := cb.ID(0)
+	if err != nil {
+		return tgbotapi.EditMessageTextConfig{}, err
+	}
+	days, err := cb.Days(1)
+	if err != nil {
+		return tgbotapi.EditMessageTextConfig{}, err
 	}
 
-	if user == nil {
+	// Get user
+	user, err := h.Users.ByID(context.Background(), userID)
+	if err != nil {
 		edit := tgbotapi.NewEditMessageText(q.Message.Chat.ID, q.Message.MessageID, "❌ User not found")
 		return edit, nil
 	}
 
 	// Assign the days
-	err := h.Scheduler.AssignDuty(context.Background(), user, int(days))
+	err = h.Scheduler.AssignDuty(context.Background(), user, days)
 	if err != nil {
 		edit := tgbotapi.NewEditMessageText(
 			q.Message.Chat.ID,
@@ -508,6 +482,7 @@ func (h *Handlers) HandleAssignDaysCallback(q *tgbotapi.CallbackQuery) (tgbotapi
 		)
 		return edit, nil
 	}
+	h.InvalidateCalendars(context.Background(), nil)
 
 	edit := tgbotapi.NewEditMessageText(
 		q.Message.Chat.ID,
@@ -520,23 +495,21 @@ func (h *Handlers) HandleAssignDaysCallback(q *tgbotapi.CallbackQuery) (tgbotapi
 
 // HandleAssignCustomCallback handles custom day input request
 func (h *Handlers) HandleAssignCustomCallback(q *tgbotapi.CallbackQuery) (tgbotapi.EditMessageTextConfig, error) {
-	parts := strings.Split(q.Data, ":")
-	if len(parts) != 2 {
-		return tgbotapi.EditMessageTextConfig{}, fmt.Errorf("invalid callback data")
+	if refusal, ok := h.refuseNonAdminCallback(q); !ok {
+		return refusal, nil
 	}
 
-	var userID int64
-	fmt.Sscanf(parts[1], "%d", &userID)
+	cb := parse.ParseCallback(q.Data)
+	if err := cb.Expect(1); err != nil {
+		return tgbotapi.EditMessageTextConfig{}, err
+	}
+	userID, err := cb.ID(0)
+	if err != nil {
+		return tgbotapi.EditMessageTextConfig{}, err
+	}
 
 	// Get user
-	users, _ := h.Store.ListAllUsers(context.Background())
-	var user *store.User
-	for _, u := range users {
-		if u.ID == userID {
-			user = u
-			break
-		}
-	}
+	user, _ := h.Users.ByID(context.Background(), userID)
 
 	userName := "user"
 	if user != nil {
@@ -554,36 +527,26 @@ func (h *Handlers) HandleAssignCustomCallback(q *tgbotapi.CallbackQuery) (tgbota
 
 // HandleModifyDateCallback handles date selection for modify command
 func (h *Handlers) HandleModifyDateCallback(q *tgbotapi.CallbackQuery) (tgbotapi.EditMessageTextConfig, error) {
-	parts := strings.Split(q.Data, ":")
-	if len(parts) != 2 {
-		return tgbotapi.EditMessageTextConfig{}, fmt.Errorf("invalid callback data")
+	if refusal, ok := h.refuseNonAdminCallback(q); !ok {
+		return refusal, nil
 	}
 
-	dateStr := parts[1]
+	cb := parse.ParseCallback(q.Data)
+	if err := cb.Expect(1); err != nil {
+		return tgbotapi.EditMessageTextConfig{}, err
+	}
+	date, err := cb.Date(0)
+	if err != nil {
+		return tgbotapi.EditMessageTextConfig{}, err
+	}
+	dateStr := date.Format(parse.DateLayout)
 
-	users, err := h.Store.ListActiveUsers(context.Background())
-	if err != nil || len(users) == 0 {
+	text, keyboard, err := h.userPicker(context.Background(), "modify_user:"+dateStr, 0)
+	if err != nil {
 		edit := tgbotapi.NewEditMessageText(q.Message.Chat.ID, q.Message.MessageID, "❌ No active users found.")
 		return edit, nil
 	}
-
-	var buttons [][]tgbotapi.InlineKeyboardButton
-	for _, u := range users {
-		row := []tgbotapi.InlineKeyboardButton{
-			tgbotapi.NewInlineKeyboardButtonData(
-				fmt.Sprintf("👤 %s", u.FirstName),
-				fmt.Sprintf("modify_user:%s:%d", dateStr, u.ID),
-			),
-		}
-		buttons = append(buttons, row)
-	}
-
-	keyboard := tgbotapi.NewInlineKeyboardMarkup(buttons...)
-	edit := tgbotapi.NewEditMessageText(
-		q.Message.Chat.ID,
-		q.Message.MessageID,
-		fmt.Sprintf("🔄 <b>Modify duty for %s</b>\n\nSelect the new user:", dateStr),
-	)
+	edit := tgbotapi.NewEditMessageText(q.Message.Chat.ID, q.Message.MessageID, text)
 	edit.ParseMode = tgbotapi.ModeHTML
 	edit.ReplyMarkup = &keyboard
 	return edit, nil
@@ -591,40 +554,31 @@ func (h *Handlers) HandleModifyDateCallback(q *tgbotapi.CallbackQuery) (tgbotapi
 
 // HandleModifyUserCallback handles user selection for modify command
 func (h *Handlers) HandleModifyUserCallback(q *tgbotapi.CallbackQuery) (tgbotapi.EditMessageTextConfig, error) {
-	parts := strings.Split(q.Data, ":")
-	if len(parts) != 3 {
-		return tgbotapi.EditMessageTextConfig{}, fmt.Errorf("invalid callback data")
+	if refusal, ok := h.refuseNonAdminCallback(q); !ok {
+		return refusal, nil
 	}
 
-	dateStr := parts[1]
-	var userID int64
-	fmt.Sscanf(parts[2], "%d", &userID)
-
-	dutyDate, err := time.Parse("2006-01-02", dateStr)
+	cb := parse.ParseCallback(q.Data)
+	if err := cb.Expect(2); err != nil {
+		return tgbotapi.EditMessageTextConfig{}, err
+	}
+	dutyDate, err := cb.Date(0)
 	if err != nil {
-		edit := tgbotapi.NewEditMessageText(
-			q.Message.Chat.ID,
-			q.Message.MessageID,
-			fmt.Sprintf("❌ Invalid date: %s", dateStr),
-		)
-		return edit, nil
+		return tgbotapi.EditMessageTextConfig{}, err
 	}
-
-	users, _ := h.Store.ListAllUsers(context.Background())
-	var user *store.User
-	for _, u := range users {
-		if u.ID == userID {
-			user = u
-			break
-		}
+	userID, err := cb.ID(1)
+	if err != nil {
+		return tgbotapi.EditMessageTextConfig{}, err
 	}
+	dateStr := dutyDate.Format(parse.DateLayout)
 
-	if user == nil {
+	user, err := h.Users.ByID(context.Background(), userID)
+	if err != nil {
 		edit := tgbotapi.NewEditMessageText(q.Message.Chat.ID, q.Message.MessageID, "❌ User not found")
 		return edit, nil
 	}
 
-	if _, err := h.Scheduler.ChangeDutyUser(context.Background(), dutyDate, user.ID); err != nil {
+	if _, err := h.Duties.Reassign(context.Background(), dutyDate, user.ID, scheduler.ChangeModeKeep); err != nil {
 		edit := tgbotapi.NewEditMessageText(
 			q.Message.Chat.ID,
 			q.Message.MessageID,
@@ -644,30 +598,26 @@ func (h *Handlers) HandleModifyUserCallback(q *tgbotapi.CallbackQuery) (tgbotapi
 
 // HandleToggleUserCallback handles user selection for toggle_active command
 func (h *Handlers) HandleToggleUserCallback(q *tgbotapi.CallbackQuery) (tgbotapi.EditMessageTextConfig, error) {
-	parts := strings.Split(q.Data, ":")
-	if len(parts) != 2 {
-		return tgbotapi.EditMessageTextConfig{}, fmt.Errorf("invalid callback data")
+	if refusal, ok := h.refuseNonAdminCallback(q); !ok {
+		return refusal, nil
 	}
 
-	var userID int64
-	fmt.Sscanf(parts[1], "%d", &userID)
-
-	users, _ := h.Store.ListAllUsers(context.Background())
-	var user *store.User
-	for _, u := range users {
-		if u.ID == userID {
-			user = u
-			break
-		}
+	cb := parse.ParseCallback(q.Data)
+	if err := cb.Expect(1); err != nil {
+		return tgbotapi.EditMessageTextConfig{}, err
+	}
+	userID, err := cb.ID(0)
+	if err != nil {
+		return tgbotapi.EditMessageTextConfig{}, err
 	}
 
-	if user == nil {
+	user, err := h.Users.ByID(context.Background(), userID)
+	if err != nil {
 		edit := tgbotapi.NewEditMessageText(q.Message.Chat.ID, q.Message.MessageID, "❌ User not found")
 		return edit, nil
 	}
 
-	user.IsActive = !user.IsActive
-	if err := h.Store.UpdateUser(context.Background(), user); err != nil {
+	if err := h.Users.ToggleActive(context.Background(), user); err != nil {
 		edit := tgbotapi.NewEditMessageText(
 			q.Message.Chat.ID,
 			q.Message.MessageID,
@@ -675,6 +625,7 @@ func (h *Handlers) HandleToggleUserCallback(q *tgbotapi.CallbackQuery) (tgbotapi
 		)
 		return edit, nil
 	}
+	h.InvalidateCalendars(context.Background(), nil)
 
 	statusText := "active"
 	if !user.IsActive {
@@ -692,12 +643,25 @@ func (h *Handlers) HandleToggleUserCallback(q *tgbotapi.CallbackQuery) (tgbotapi
 
 // HandleOffDutyUserCallback handles user selection for offduty command
 func (h *Handlers) HandleOffDutyUserCallback(q *tgbotapi.CallbackQuery) (tgbotapi.EditMessageTextConfig, error) {
-	parts := strings.Split(q.Data, ":")
-	if len(parts) != 3 {
-		return tgbotapi.EditMessageTextConfig{}, fmt.Errorf("invalid callback data")
+	if refusal, ok := h.refuseNonAdminCallback(q); !ok {
+		return refusal, nil
 	}
 
-	userName := parts[2]
+	cb := parse.ParseCallback(q.Data)
+	if err := cb.Expect(1); err != nil {
+		return tgbotapi.EditMessageTextConfig{}, err
+	}
+	userID, err := cb.ID(0)
+	if err != nil {
+		return tgbotapi.EditMessageTextConfig{}, err
+	}
+
+	user, err := h.Users.ByID(context.Background(), userID)
+	if err != nil {
+		edit := tgbotapi.NewEditMessageText(q.Message.Chat.ID, q.Message.MessageID, "❌ User not found")
+		return edit, nil
+	}
+	userName := escapeHTML(user.FirstName)
 
 	edit := tgbotapi.NewEditMessageText(
 		q.Message.Chat.ID,
@@ -2,35 +2,56 @@ package handlers_test
 
 import (
 	"errors"
+	"fmt"
 	"testing"
 	"time"
 
-	"github.com/korjavin/dutyassistant/internal/mocks"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/korjavin/dutyassistant/internal/scheduler"
+	schedulermocks "github.com/korjavin/dutyassistant/internal/scheduler/mocks"
+	"github.com/korjavin/dutyassistant/internal/service/diag"
 	"github.com/korjavin/dutyassistant/internal/store"
+	storemocks "github.com/korjavin/dutyassistant/internal/store/mocks"
 	"github.com/korjavin/dutyassistant/internal/telegram/handlers"
-	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/korjavin/dutyassistant/internal/telegram/keyboard"
+	"github.com/robfig/cron/v3"
 	"github.com/stretchr/testify/assert"
-	"github.com/stretchr/testify/mock"
+	"go.uber.org/mock/gomock"
 )
 
 // setupAdminTest is a helper to create mocks and an admin user for testing.
-func setupAdminTest(t *testing.T) (*mocks.MockStore, *mocks.MockScheduler, *handlers.Handlers) {
-	mockStore := new(mocks.MockStore)
-	mockScheduler := new(mocks.MockScheduler)
+func setupAdminTest(t *testing.T) (*storemocks.MockStore, *schedulermocks.MockSchedulerInterface, *handlers.Handlers) {
+	ctrl := gomock.NewController(t)
+	mockStore := storemocks.NewMockStore(ctrl)
+	mockScheduler := schedulermocks.NewMockSchedulerInterface(ctrl)
 	h := handlers.New(mockStore, mockScheduler)
 
 	adminUser := &store.User{ID: 1, TelegramUserID: 123, IsAdmin: true}
-	mockStore.On("GetUserByTelegramID", mock.Anything, int64(123)).Return(adminUser, nil).Maybe()
+	mockStore.EXPECT().GetUserByTelegramID(gomock.Any(), int64(123)).Return(adminUser, nil).AnyTimes()
 
 	return mockStore, mockScheduler, h
 }
 
+// adminCommand builds a command message sent by the admin.
+func adminCommand(command, args string) *tgbotapi.Message {
+	text := "/" + command
+	if args != "" {
+		text += " " + args
+	}
+	return &tgbotapi.Message{
+		Chat:     &tgbotapi.Chat{ID: 789},
+		From:     &tgbotapi.User{ID: 123},
+		Text:     text,
+		Entities: []tgbotapi.MessageEntity{{Type: "bot_command", Offset: 0, Length: len(command) + 1}},
+	}
+}
+
 func TestAdminCommands_NotAdmin(t *testing.T) {
-	mockStore := new(mocks.MockStore)
+	mockStore := storemocks.NewMockStore(gomock.NewController(t))
 	h := handlers.New(mockStore, nil)
 
 	nonAdminUser := &store.User{ID: 2, TelegramUserID: 456, IsAdmin: false}
-	mockStore.On("GetUserByTelegramID", mock.Anything, int64(456)).Return(nonAdminUser, nil)
+	mockStore.EXPECT().GetUserByTelegramID(gomock.Any(), int64(456)).Return(nonAdminUser, nil).AnyTimes()
 
 	message := &tgbotapi.Message{
 		Chat: &tgbotapi.Chat{ID: 789},
@@ -45,6 +66,21 @@ func TestAdminCommands_NotAdmin(t *testing.T) {
 		{"Modify", h.HandleModify},
 		{"Users", h.HandleUsers},
 		{"ToggleActive", h.HandleToggleActive},
+		{"OffDuty", h.HandleOffDuty},
+		{"Skip", h.HandleSkip},
+		{"Unskip", h.HandleUnskip},
+		{"Backfill", h.HandleBackfill},
+		{"Complete", h.HandleComplete},
+		{"Uncomplete", h.HandleUncomplete},
+		{"Publish", h.HandlePublish},
+		{"Hold", h.HandleHold},
+		{"Debug", h.HandleDebug},
+		{"Note", h.HandleNote},
+		{"AssignToday", h.HandleAssignToday},
+		{"MergeUsers", h.HandleMergeUsers},
+		{"Rename", h.HandleRename},
+		{"Junior", h.HandleJunior},
+		{"Pool", h.HandlePool},
 	}
 
 	for _, tc := range testCases {
@@ -59,99 +95,471 @@ func TestAdminCommands_NotAdmin(t *testing.T) {
 func TestHandleAssign_Success(t *testing.T) {
 	mockStore, mockScheduler, h := setupAdminTest(t)
 
-	message := &tgbotapi.Message{
-		Chat:     &tgbotapi.Chat{ID: 789},
-		From:     &tgbotapi.User{ID: 123},
-		Text:     "/assign TestUser 2023-12-25",
-		Entities: []tgbotapi.MessageEntity{{Type: "bot_command", Offset: 0, Length: 7}},
+	targetUser := &store.User{ID: 2, FirstName: "TestUser"}
+	mockStore.EXPECT().GetUserByName(gomock.Any(), "TestUser").Return(targetUser, nil)
+	mockScheduler.EXPECT().AssignDuty(gomock.Any(), targetUser, 2).Return(nil)
+
+	msg, err := h.HandleAssign(adminCommand("assign", "TestUser 2"))
+	assert.NoError(t, err)
+	assert.Equal(t, "✅ Successfully added 2 day(s) to admin queue for TestUser.", msg.Text)
+}
+
+func TestHandleAssign_InvalidatesCalendars(t *testing.T) {
+	mockStore, mockScheduler, h := setupAdminTest(t)
+	mockStore.EXPECT().GetChatLocale(gomock.Any(), int64(789)).Return("", nil).AnyTimes()
+	mockStore.EXPECT().GetChatDisplay(gomock.Any(), int64(789)).Return(nil, nil).AnyTimes()
+
+	// Queue days are shown by the calendar but added without an event
+	mockStore.EXPECT().GetDutiesByMonth(gomock.Any(), 2023, time.June).Return([]*store.Duty{}, nil).Times(2)
+	mockStore.EXPECT().ListActiveUsers(gomock.Any()).Return([]*store.User{}, nil).Times(2)
+	mockStore.EXPECT().GetSkipDaysByMonth(gomock.Any(), 2023, time.June).Return(nil, nil).Times(2)
+	tap := func(messageID int) {
+		_, err := h.HandleCalendarCallback(&tgbotapi.CallbackQuery{
+			Message: &tgbotapi.Message{Chat: &tgbotapi.Chat{ID: 789}, MessageID: messageID},
+			Data:    keyboard.ActionNextMonth + ":2023-05-15",
+		})
+		assert.NoError(t, err)
 	}
+	tap(1)
 
 	targetUser := &store.User{ID: 2, FirstName: "TestUser"}
-	dutyDate, _ := time.Parse("2006-01-02", "2023-12-25")
-	mockStore.On("GetUserByName", mock.Anything, "TestUser").Return(targetUser, nil)
-	mockScheduler.On("AssignDuty", mock.Anything, targetUser, dutyDate).Return(nil)
-
-	msg, err := h.HandleAssign(message)
+	mockStore.EXPECT().GetUserByName(gomock.Any(), "TestUser").Return(targetUser, nil)
+	mockScheduler.EXPECT().AssignDuty(gomock.Any(), targetUser, 2).Return(nil)
+	_, err := h.HandleAssign(adminCommand("assign", "TestUser 2"))
 	assert.NoError(t, err)
-	assert.Equal(t, "Successfully assigned TestUser to duty on 2023-12-25.", msg.Text)
-	mockStore.AssertExpectations(t)
-	mockScheduler.AssertExpectations(t)
+
+	tap(2)
 }
 
 func TestHandleUsers_Success(t *testing.T) {
 	mockStore, _, h := setupAdminTest(t)
 
-	message := &tgbotapi.Message{
-		Chat: &tgbotapi.Chat{ID: 789},
-		From: &tgbotapi.User{ID: 123},
-	}
-
 	userList := []*store.User{
 		{FirstName: "Alice", IsActive: true, IsAdmin: true},
 		{FirstName: "Bob", IsActive: false, IsAdmin: false},
 	}
-	mockStore.On("ListAllUsers", mock.Anything).Return(userList, nil)
+	mockStore.EXPECT().ListAllUsers(gomock.Any()).Return(userList, nil)
 
-	msg, err := h.HandleUsers(message)
+	msg, err := h.HandleUsers(adminCommand("users", ""))
 	assert.NoError(t, err)
-	assert.Contains(t, msg.Text, "<b>User List:</b>")
-	assert.Contains(t, msg.Text, "- Alice (Admin): Active")
-	assert.Contains(t, msg.Text, "- Bob: Inactive")
+	assert.Contains(t, msg.Text, "<b>📋 User List</b>")
+	assert.Contains(t, msg.Text, "<b>Alice</b> 👑: ✅ Active")
+	assert.Contains(t, msg.Text, "<b>Bob</b>: ❌ Inactive")
 	assert.Equal(t, tgbotapi.ModeHTML, msg.ParseMode)
-	mockStore.AssertExpectations(t)
 }
 
 func TestHandleToggleActive_Success(t *testing.T) {
 	mockStore, _, h := setupAdminTest(t)
 
-	message := &tgbotapi.Message{
-		Chat:     &tgbotapi.Chat{ID: 789},
-		From:     &tgbotapi.User{ID: 123},
-		Text:     "/toggle_active Bob",
-		Entities: []tgbotapi.MessageEntity{{Type: "bot_command", Offset: 0, Length: 14}},
-	}
-
 	bob := &store.User{ID: 2, FirstName: "Bob", IsActive: true}
-	mockStore.On("GetUserByName", mock.Anything, "Bob").Return(bob, nil)
-	mockStore.On("UpdateUser", mock.Anything, mock.MatchedBy(func(u *store.User) bool {
+	mockStore.EXPECT().GetUserByName(gomock.Any(), "Bob").Return(bob, nil)
+	mockStore.EXPECT().UpdateUser(gomock.Any(), gomock.Cond(func(u *store.User) bool {
 		return u.ID == 2 && !u.IsActive // Check that IsActive is toggled to false
 	})).Return(nil)
 
-	msg, err := h.HandleToggleActive(message)
+	msg, err := h.HandleToggleActive(adminCommand("toggle_active", "Bob"))
 	assert.NoError(t, err)
 	assert.Equal(t, "Successfully set status for Bob to Inactive.", msg.Text)
-	mockStore.AssertExpectations(t)
+}
+
+func TestHandleToggleActive_Pages(t *testing.T) {
+	mockStore, _, h := setupAdminTest(t)
+	var users []*store.User
+	for i := int64(1); i <= 10; i++ {
+		users = append(users, &store.User{ID: i, FirstName: fmt.Sprintf("User%d", i), IsActive: i != 10})
+	}
+	mockStore.EXPECT().ListAllUsers(gomock.Any()).Return(users, nil).Times(2)
+
+	msg, err := h.HandleToggleActive(adminCommand("toggleactive", ""))
+	assert.NoError(t, err)
+	rows := msg.ReplyMarkup.(tgbotapi.InlineKeyboardMarkup).InlineKeyboard
+	assert.Len(t, rows, 9, "a page of users and the page row")
+	assert.Equal(t, "toggle_user:1", *rows[0][0].CallbackData)
+	assert.Equal(t, "1/2", rows[8][1].Text)
+	assert.Equal(t, "page:toggle_user:1", *rows[8][2].CallbackData)
+
+	edit, err := h.HandlePageCallback(&tgbotapi.CallbackQuery{
+		From:    &tgbotapi.User{ID: 123},
+		Message: &tgbotapi.Message{MessageID: 1, Chat: &tgbotapi.Chat{ID: 789}},
+		Data:    *rows[8][2].CallbackData,
+	})
+	assert.NoError(t, err)
+	assert.Contains(t, edit.Text, "Toggle user active status")
+	rows = edit.ReplyMarkup.InlineKeyboard
+	assert.Len(t, rows, 3)
+	assert.Equal(t, "❌ User10", rows[1][0].Text)
+	assert.Equal(t, "toggle_user:10", *rows[1][0].CallbackData)
+	assert.Equal(t, "page:toggle_user:0", *rows[2][0].CallbackData)
 }
 
 func TestHandleAssign_UserNotFound(t *testing.T) {
 	mockStore, _, h := setupAdminTest(t)
 
-	message := &tgbotapi.Message{
-		Chat:     &tgbotapi.Chat{ID: 789},
-		From:     &tgbotapi.User{ID: 123},
-		Text:     "/assign UnknownUser 2023-12-25",
-		Entities: []tgbotapi.MessageEntity{{Type: "bot_command", Offset: 0, Length: 7}},
-	}
+	mockStore.EXPECT().GetUserByName(gomock.Any(), "UnknownUser").Return(nil, errors.New("not found"))
+	mockStore.EXPECT().ListActiveUsers(gomock.Any()).Return([]*store.User{{FirstName: "Alice"}}, nil)
+
+	msg, err := h.HandleAssign(adminCommand("assign", "UnknownUser 2"))
+	assert.NoError(t, err)
+	assert.Equal(t, "❌ User 'UnknownUser' not found.\n\nAvailable users:\n  • Alice\n", msg.Text)
+}
+
+func TestHandleAssign_InvalidDays(t *testing.T) {
+	_, _, h := setupAdminTest(t)
+
+	msg, err := h.HandleAssign(adminCommand("assign", "TestUser many"))
+	assert.NoError(t, err)
+	assert.Contains(t, msg.Text, "Please use a number between 1 and")
+}
+
+func TestHandleBackfill_Success(t *testing.T) {
+	mockStore, mockScheduler, h := setupAdminTest(t)
 
-	mockStore.On("GetUserByName", mock.Anything, "UnknownUser").Return(nil, errors.New("not found"))
+	alice := &store.User{ID: 2, FirstName: "Alice"}
+	date := time.Date(2025, 10, 20, 0, 0, 0, 0, time.UTC)
+	mockStore.EXPECT().GetUserByName(gomock.Any(), "Alice").Return(alice, nil)
+	mockStore.EXPECT().ListAllUsers(gomock.Any()).Return([]*store.User{alice}, nil)
+	mockScheduler.EXPECT().BackfillDuty(gomock.Any(), date, alice.ID).Return(&store.Duty{UserID: alice.ID, DutyDate: date}, nil)
 
-	msg, err := h.HandleAssign(message)
+	msg, err := h.HandleBackfill(adminCommand("backfill", "2025-10-20 Alice"))
 	assert.NoError(t, err)
-	assert.Equal(t, "Could not find user: UnknownUser", msg.Text)
-	mockStore.AssertExpectations(t)
+	assert.Equal(t, "🕰 Recorded Alice as having done the duty on 2025-10-20.", msg.Text)
 }
 
-func TestHandleAssign_InvalidDate(t *testing.T) {
+func TestHandleBackfill_InvalidDate(t *testing.T) {
 	_, _, h := setupAdminTest(t)
 
+	msg, err := h.HandleBackfill(adminCommand("backfill", "2025/10/20 Alice"))
+	assert.NoError(t, err)
+	assert.Equal(t, "Invalid date format. Please use YYYY-MM-DD.", msg.Text)
+}
+
+func TestHandleComplete(t *testing.T) {
+	mockStore, mockScheduler, h := setupAdminTest(t)
+
+	alice := &store.User{ID: 2, FirstName: "Alice"}
+	date := time.Date(2025, 10, 20, 0, 0, 0, 0, time.UTC)
+	mockStore.EXPECT().ListAllUsers(gomock.Any()).Return([]*store.User{alice}, nil)
+	// The admin is recorded as who completed the duty
+	mockScheduler.EXPECT().SetDutyCompletion(gomock.Any(), date, true, int64(1)).Return(&store.Duty{UserID: alice.ID, DutyDate: date}, nil)
+
+	msg, err := h.HandleComplete(adminCommand("complete", "2025-10-20"))
+	assert.NoError(t, err)
+	assert.Equal(t, "✅ The duty of Alice on 2025-10-20 is marked as done.", msg.Text)
+}
+
+func TestHandleUncomplete_NoDuty(t *testing.T) {
+	_, mockScheduler, h := setupAdminTest(t)
+
+	date := time.Date(2025, 10, 20, 0, 0, 0, 0, time.UTC)
+	mockScheduler.EXPECT().SetDutyCompletion(gomock.Any(), date, false, int64(1)).Return(nil, scheduler.ErrNoDuty)
+
+	msg, err := h.HandleUncomplete(adminCommand("uncomplete", "2025-10-20"))
+	assert.NoError(t, err)
+	assert.Equal(t, "❌ Failed to update 2025-10-20: no duty found for this date", msg.Text)
+}
+
+func TestHandlePublish_Draft(t *testing.T) {
+	_, mockScheduler, h := setupAdminTest(t)
+	month := time.Date(2025, 11, 1, 0, 0, 0, 0, time.UTC)
+
+	mockScheduler.EXPECT().DraftMonth(gomock.Any(), month).Return([]*store.Duty{
+		{DutyDate: month, User: &store.User{FirstName: "Alice"}, Status: store.DutyStatusAnnounced},
+		{DutyDate: month.AddDate(0, 0, 1), User: &store.User{FirstName: "Bob"}, Status: store.DutyStatusProvisional},
+	}, nil)
+
+	msg, err := h.HandlePublish(adminCommand("publish", "draft 2025-11"))
+	assert.NoError(t, err)
+	assert.Contains(t, msg.Text, "Sat, Nov 1: Alice\nSun, Nov 2: Bob (draft)")
+	assert.Contains(t, msg.Text, "Publish the 1 draft day(s) with <code>/publish 2025-11</code>.")
+}
+
+func TestHandlePublish(t *testing.T) {
+	_, mockScheduler, h := setupAdminTest(t)
+	month := time.Date(2025, 11, 1, 0, 0, 0, 0, time.UTC)
+
+	mockScheduler.EXPECT().PublishMonth(gomock.Any(), month).Return([]*store.Duty{{}, {}}, nil)
+	msg, err := h.HandlePublish(adminCommand("publish", "2025-11"))
+	assert.NoError(t, err)
+	assert.Equal(t, "📣 Published 2 day(s) of November 2025 and announced them to the group.", msg.Text)
+
+	mockScheduler.EXPECT().PublishMonth(gomock.Any(), month).Return(nil, scheduler.ErrNoDraft)
+	msg, err = h.HandlePublish(adminCommand("publish", "2025-11"))
+	assert.NoError(t, err)
+	assert.Equal(t, "There is no draft for November 2025. Review one with /publish draft 2025-11 first.", msg.Text)
+
+	msg, err = h.HandlePublish(adminCommand("publish", "november"))
+	assert.NoError(t, err)
+	assert.Equal(t, "❌ invalid month 'november', expected YYYY-MM", msg.Text)
+}
+
+func TestHandleMergeUsers(t *testing.T) {
+	mockStore, _, h := setupAdminTest(t)
+
+	alice := &store.User{ID: 2, FirstName: "Alice"}
+	alias := &store.User{ID: 4, FirstName: "Alice"}
+	mockStore.EXPECT().GetUserByName(gomock.Any(), "Alice").Return(alice, nil)
+	mockStore.EXPECT().ListAllUsers(gomock.Any()).Return([]*store.User{alice, alias}, nil).AnyTimes()
+	mockStore.EXPECT().MergeUsers(gomock.Any(), alias.ID, alice.ID, gomock.Any()).
+		Return(&store.UserMerge{FromUserID: alias.ID, FromName: "Alice", ToUserID: alice.ID, DutiesMoved: 3, VolunteerQueueDays: 1}, nil)
+
+	msg, err := h.HandleMergeUsers(adminCommand("merge_users", "#4 Alice"))
+	assert.NoError(t, err)
+	assert.Equal(t, "🔀 Merged Alice into Alice: 3 duties, 1 volunteer and 0 admin queue days moved.", msg.Text)
+}
+
+func TestHandleMergeUsers_SameUser(t *testing.T) {
+	mockStore, _, h := setupAdminTest(t)
+
+	alice := &store.User{ID: 2, FirstName: "Alice"}
+	mockStore.EXPECT().GetUserByName(gomock.Any(), "Alice").Return(alice, nil)
+	mockStore.EXPECT().ListAllUsers(gomock.Any()).Return([]*store.User{alice}, nil)
+
+	msg, err := h.HandleMergeUsers(adminCommand("merge_users", "Alice #2"))
+	assert.NoError(t, err)
+	assert.Equal(t, "❌ Pick two different users to merge.", msg.Text)
+}
+
+func TestHandleRename(t *testing.T) {
+	mockStore, _, h := setupAdminTest(t)
+
+	alice := &store.User{ID: 2, Handle: "alice", FirstName: "Alice"}
+	mockStore.EXPECT().GetUserByName(gomock.Any(), "alice").Return(alice, nil)
+	mockStore.EXPECT().UpdateUser(gomock.Any(), gomock.Cond(func(u *store.User) bool {
+		return u.ID == 2 && u.FirstName == "Grandma Alice" && u.CustomName && u.Handle == "alice"
+	})).Return(nil)
+
+	msg, err := h.HandleRename(adminCommand("rename", "alice Grandma Alice"))
+	assert.NoError(t, err)
+	assert.Equal(t, "✏️ Alice is now shown as <b>Grandma Alice</b>; the handle stays <code>alice</code>.", msg.Text)
+}
+
+func TestHandleJunior(t *testing.T) {
+	mockStore, _, h := setupAdminTest(t)
+
+	alice := &store.User{ID: 2, Handle: "alice", FirstName: "Alice"}
+	mockStore.EXPECT().GetUserByName(gomock.Any(), "alice").Return(alice, nil).Times(2)
+	mockStore.EXPECT().UpdateUser(gomock.Any(), alice).Return(nil).Times(2)
+
+	msg, err := h.HandleJunior(adminCommand("junior", "alice"))
+	assert.NoError(t, err)
+	assert.True(t, alice.IsJunior)
+	assert.Equal(t, "🧒 <b>Alice</b> is now a junior member with the reduced commands.", msg.Text)
+
+	msg, err = h.HandleJunior(adminCommand("junior", "alice"))
+	assert.NoError(t, err)
+	assert.False(t, alice.IsJunior)
+	assert.Equal(t, "👤 <b>Alice</b> is a regular member again.", msg.Text)
+}
+
+func TestHandlePool(t *testing.T) {
+	mockStore, _, h := setupAdminTest(t)
+
+	alice := &store.User{ID: 2, Handle: "alice", FirstName: "Alice"}
+	mockStore.EXPECT().GetUserByName(gomock.Any(), "alice").Return(alice, nil).Times(3)
+	mockStore.EXPECT().UpdateUser(gomock.Any(), alice).Return(nil)
+
+	msg, err := h.HandlePool(adminCommand("pool", "alice Weekends"))
+	assert.NoError(t, err)
+	assert.Equal(t, store.PoolWeekends, alice.Pool)
+	assert.Equal(t, "📆 <b>Alice</b> is on the roster weekends only.", msg.Text)
+
+	msg, err = h.HandlePool(adminCommand("pool", "alice"))
+	assert.NoError(t, err)
+	assert.Equal(t, "📆 <b>Alice</b> is on the roster weekends only.", msg.Text)
+
+	msg, err = h.HandlePool(adminCommand("pool", "alice mondays"))
+	assert.NoError(t, err)
+	assert.Contains(t, msg.Text, "Usage: <code>/pool user all|weekdays|weekends</code>")
+	assert.Equal(t, store.PoolWeekends, alice.Pool)
+}
+
+func TestAdminCallbacks_NotAdmin(t *testing.T) {
+	mockStore := storemocks.NewMockStore(gomock.NewController(t))
+	h := handlers.New(mockStore, nil)
+	mockStore.EXPECT().GetUserByTelegramID(gomock.Any(), int64(456)).Return(&store.User{ID: 2, TelegramUserID: 456}, nil).AnyTimes()
+
+	testCases := []struct {
+		data    string
+		handler func(*tgbotapi.CallbackQuery) (tgbotapi.EditMessageTextConfig, error)
+	}{
+		{"assign_user:2", h.HandleAssignUserCallback},
+		{"assign_days:2:7", h.HandleAssignDaysCallback},
+		{"assign_custom:2", h.HandleAssignCustomCallback},
+		{"modify_date:2025-11-08", h.HandleModifyDateCallback},
+		{"modify_user:2025-11-08:2", h.HandleModifyUserCallback},
+		{"toggle_user:2", h.HandleToggleUserCallback},
+		{"offduty_user:2", h.HandleOffDutyUserCallback},
+		{"takeover_skip:2025-11-08", h.HandleTakeoverCallback},
+		{"page:toggle_user:1", h.HandlePageCallback},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.data, func(t *testing.T) {
+			q := &tgbotapi.CallbackQuery{
+				From:    &tgbotapi.User{ID: 456},
+				Message: &tgbotapi.Message{MessageID: 1, Chat: &tgbotapi.Chat{ID: 789}},
+				Data:    tc.data,
+			}
+			edit, err := tc.handler(q)
+			assert.NoError(t, err)
+			assert.Equal(t, "Sorry, this command is for admins only.", edit.Text)
+		})
+	}
+}
+
+func TestHandleHold_Success(t *testing.T) {
+	mockStore, mockScheduler, h := setupAdminTest(t)
+
+	alice := &store.User{ID: 2, FirstName: "Alice", IsActive: true}
+	date := time.Date(2025, 11, 8, 0, 0, 0, 0, time.UTC)
+	until := time.Date(2025, 11, 7, 0, 0, 0, 0, time.UTC)
+	mockStore.EXPECT().GetUserByName(gomock.Any(), "Alice").Return(alice, nil)
+	mockStore.EXPECT().ListAllUsers(gomock.Any()).Return([]*store.User{alice}, nil)
+	mockStore.EXPECT().IsUserOffDuty(gomock.Any(), alice.ID, date).Return(false, nil)
+	mockScheduler.EXPECT().AssignHeldDuty(gomock.Any(), date, alice.ID, until).Return(&store.Duty{UserID: alice.ID, DutyDate: date, HoldUntil: &until}, nil)
+
+	msg, err := h.HandleHold(adminCommand("hold", "2025-11-08 Alice 2025-11-07"))
+	assert.NoError(t, err)
+	assert.Equal(t, "⏳ Alice is on duty on 2025-11-08 if confirmed by the end of 2025-11-07.", msg.Text)
+}
+
+func TestHandleConfirm(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockStore := storemocks.NewMockStore(ctrl)
+	mockScheduler := schedulermocks.NewMockSchedulerInterface(ctrl)
+	h := handlers.New(mockStore, mockScheduler)
+
+	alice := &store.User{ID: 2, TelegramUserID: 456, FirstName: "Alice"}
+	date := time.Date(2025, 11, 8, 0, 0, 0, 0, time.UTC)
+	mockStore.EXPECT().GetUserByTelegramID(gomock.Any(), int64(456)).Return(alice, nil).AnyTimes()
 	message := &tgbotapi.Message{
 		Chat:     &tgbotapi.Chat{ID: 789},
-		From:     &tgbotapi.User{ID: 123},
-		Text:     "/assign TestUser 2023/12/25", // Invalid date format
-		Entities: []tgbotapi.MessageEntity{{Type: "bot_command", Offset: 0, Length: 7}},
+		From:     &tgbotapi.User{ID: 456},
+		Text:     "/confirm 2025-11-08",
+		Entities: []tgbotapi.MessageEntity{{Type: "bot_command", Offset: 0, Length: len("/confirm")}},
 	}
 
-	msg, err := h.HandleAssign(message)
+	// Someone else's duty
+	mockStore.EXPECT().GetDutyByDate(gomock.Any(), date).Return(&store.Duty{UserID: 3, DutyDate: date}, nil)
+	msg, err := h.HandleConfirm(message)
 	assert.NoError(t, err)
-	assert.Equal(t, "Invalid date format. Please use YYYY-MM-DD.", msg.Text)
-}
\ No newline at end of file
+	assert.Equal(t, "❌ You are not on duty on 2025-11-08.", msg.Text)
+
+	// Their own duty
+	mockStore.EXPECT().GetDutyByDate(gomock.Any(), date).Return(&store.Duty{UserID: alice.ID, DutyDate: date}, nil)
+	mockScheduler.EXPECT().HoldDuty(gomock.Any(), date, nil).Return(&store.Duty{UserID: alice.ID, DutyDate: date}, nil)
+	msg, err = h.HandleConfirm(message)
+	assert.NoError(t, err)
+	assert.Equal(t, "✅ The duty on 2025-11-08 is confirmed.", msg.Text)
+}
+
+func TestHandleModify_Refund(t *testing.T) {
+	mockStore, mockScheduler, h := setupAdminTest(t)
+
+	bob := &store.User{ID: 3, FirstName: "Bob", IsActive: true}
+	date := time.Date(2025, 10, 10, 0, 0, 0, 0, time.UTC)
+	mockStore.EXPECT().GetUserByName(gomock.Any(), "Bob").Return(bob, nil)
+	mockStore.EXPECT().ListAllUsers(gomock.Any()).Return([]*store.User{bob}, nil)
+	mockStore.EXPECT().IsUserOffDuty(gomock.Any(), bob.ID, date).Return(false, nil)
+	mockScheduler.EXPECT().ChangeDutyUser(gomock.Any(), date, bob.ID, scheduler.ChangeModeRefund).Return(&store.Duty{UserID: bob.ID, DutyDate: date}, nil)
+
+	msg, err := h.HandleModify(adminCommand("modify", "2025-10-10 Bob refund"))
+	assert.NoError(t, err)
+	assert.Equal(t, "Successfully modified duty for 2025-10-10 to be handled by Bob.", msg.Text)
+
+	msg, err = h.HandleModify(adminCommand("modify", "2025-10-10 Bob swap"))
+	assert.NoError(t, err)
+	assert.Contains(t, msg.Text, "Unknown mode 'swap'")
+}
+
+func TestHandleDebug(t *testing.T) {
+	mockStore, _, h := setupAdminTest(t)
+
+	msg, err := h.HandleDebug(adminCommand("debug", ""))
+	assert.NoError(t, err)
+	assert.Equal(t, "Diagnostics are not available.", msg.Text)
+
+	alice := &store.User{ID: 2, FirstName: "Alice", VolunteerQueueDays: 2}
+	mockStore.EXPECT().GetUsersWithVolunteerQueue(gomock.Any()).Return([]*store.User{alice}, nil)
+	mockStore.EXPECT().GetUsersWithAdminQueue(gomock.Any()).Return(nil, nil)
+	c := cron.New()
+	h.Diag = diag.New(mockStore, c)
+	h.Diag.Version = "v1.2.3"
+	assert.NoError(t, h.Diag.AddJob("0 11 * * *", "daily assignment", func() error { return nil }))
+
+	msg, err = h.HandleDebug(adminCommand("debug", ""))
+	assert.NoError(t, err)
+	assert.Contains(t, msg.Text, "<b>Build:</b> v1.2.3")
+	assert.Contains(t, msg.Text, "<b>Database:</b> in memory")
+	assert.Contains(t, msg.Text, "<b>Last backup:</b> not configured")
+	assert.Contains(t, msg.Text, "• daily assignment - next ")
+	assert.Contains(t, msg.Text, "not run yet")
+	assert.Contains(t, msg.Text, "• Volunteer: Alice 2")
+	assert.Contains(t, msg.Text, "• Admin: empty")
+	assert.Contains(t, msg.Text, "<b>Last Telegram API error:</b> none")
+}
+
+func TestHandleNote(t *testing.T) {
+	mockStore, _, h := setupAdminTest(t)
+	date := time.Date(2025, 11, 4, 0, 0, 0, 0, time.UTC)
+
+	mockStore.EXPECT().CreateNoteTemplate(gomock.Any(), gomock.Any()).DoAndReturn(func(_ any, nt *store.NoteTemplate) error {
+		assert.Equal(t, "2w:2025-11-04", nt.Rule)
+		assert.Equal(t, "Bins are brown this week", nt.Text)
+		nt.ID = 1
+		return nil
+	})
+	msg, err := h.HandleNote(adminCommand("note", "add 2w:2025-11-04 Bins are brown this week"))
+	assert.NoError(t, err)
+	assert.Equal(t, "✅ Note #1 added, every 2 weeks on Tuesday from Nov 4.", msg.Text)
+
+	msg, err = h.HandleNote(adminCommand("note", "add fortnightly Bins"))
+	assert.NoError(t, err)
+	assert.Contains(t, msg.Text, "❌ Failed to add the note: unknown rule")
+
+	mockStore.EXPECT().ListNoteTemplates(gomock.Any()).Return([]*store.NoteTemplate{{ID: 1, Rule: "2w:2025-11-04", Text: "Bins <brown>"}}, nil)
+	msg, err = h.HandleNote(adminCommand("note", "list"))
+	assert.NoError(t, err)
+	assert.Contains(t, msg.Text, "#1 every 2 weeks on Tuesday from Nov 4: Bins &lt;brown&gt;")
+
+	mockStore.EXPECT().ListNoteTemplates(gomock.Any()).Return(nil, nil)
+	msg, err = h.HandleNote(adminCommand("note", "del 2"))
+	assert.NoError(t, err)
+	assert.Equal(t, "❌ There is no note #2.", msg.Text)
+
+	mockStore.EXPECT().GetDutyByDate(gomock.Any(), date).Return(&store.Duty{ID: 5, UserID: 2, DutyDate: date}, nil)
+	mockStore.EXPECT().UpdateDuty(gomock.Any(), &store.Duty{ID: 5, UserID: 2, DutyDate: date, Note: "Guests for dinner"}).Return(nil)
+	msg, err = h.HandleNote(adminCommand("note", "set 2025-11-04 Guests for dinner"))
+	assert.NoError(t, err)
+	assert.Equal(t, "📝 The duty on 2025-11-04 has the note: Guests for dinner", msg.Text)
+
+	mockStore.EXPECT().GetDutyByDate(gomock.Any(), date).Return(nil, nil)
+	msg, err = h.HandleNote(adminCommand("note", "clear 2025-11-04"))
+	assert.NoError(t, err)
+	assert.Equal(t, "❌ Nobody is on duty on 2025-11-04.", msg.Text)
+
+	msg, err = h.HandleNote(adminCommand("note", "del"))
+	assert.NoError(t, err)
+	assert.Contains(t, msg.Text, "<b>Duty notes</b>")
+}
+
+func TestHandleAssignToday(t *testing.T) {
+	mockStore, mockScheduler, h := setupAdminTest(t)
+	today := time.Date(2025, 11, 3, 0, 0, 0, 0, time.UTC)
+	alice := &store.User{ID: 2, FirstName: "Alice"}
+
+	mockScheduler.EXPECT().AutoAssignDuty(gomock.Any(), gomock.Any()).Return(&store.Duty{UserID: alice.ID, DutyDate: today}, nil)
+	mockStore.EXPECT().ListAllUsers(gomock.Any()).Return([]*store.User{alice}, nil)
+	msg, err := h.HandleAssignToday(adminCommand("assigntoday", ""))
+	assert.NoError(t, err)
+	assert.Equal(t, "✅ Alice is on duty today (2025-11-03).", msg.Text)
+
+	mockScheduler.EXPECT().AutoAssignDuty(gomock.Any(), gomock.Any()).Return(nil, nil)
+	msg, err = h.HandleAssignToday(adminCommand("assigntoday", ""))
+	assert.NoError(t, err)
+	assert.Equal(t, "⏭ Today is marked as a day without duty.", msg.Text)
+
+	mockScheduler.EXPECT().AutoAssignDuty(gomock.Any(), gomock.Any()).Return(nil, scheduler.ErrNoAvailableUsers)
+	msg, err = h.HandleAssignToday(adminCommand("assigntoday", ""))
+	assert.NoError(t, err)
+	assert.Contains(t, msg.Text, "Nobody is available today")
+}
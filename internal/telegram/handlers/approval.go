@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"log"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/korjavin/dutyassistant/internal/notification"
+	"github.com/korjavin/dutyassistant/internal/service/user"
+	"github.com/korjavin/dutyassistant/internal/store"
+	"github.com/korjavin/dutyassistant/internal/telegram/parse"
+)
+
+const (
+	pendingMessage      = "⏳ An admin still has to approve you before you can use this. You'll get a message when they did."
+	startPendingMessage = "👋 Welcome, %s! An admin has to approve you before you join the rotation, you'll get a message when they did.\n\n" +
+		"Until then you can look around with /schedule and /week."
+	approvedMessage = "✅ You're on the rotation now, welcome aboard! /schedule shows when it's your turn."
+	rejectedMessage = "❌ An admin declined your request to join the roster."
+)
+
+// requiresApproval reports whether new users wait for an admin's approval.
+// Without settings, or if they can't be read, nobody waits: failing open
+// keeps people joining rather than stuck without anyone being asked.
+func (h *Handlers) requiresApproval(ctx context.Context) bool {
+	if h.Settings == nil {
+		return false
+	}
+	on, err := h.Settings.RequireApproval(ctx)
+	if err != nil {
+		log.Printf("[HandleStart] Failed to get whether new users need approval: %v", err)
+		return false
+	}
+	return on
+}
+
+// requestApproval asks every admin to approve or reject the user. Errors are
+// only logged, admins also find the user in /users.
+func (h *Handlers) requestApproval(ctx context.Context, user *store.User) {
+	if h.Notifier == nil {
+		return
+	}
+	admins, err := h.adminIDs(ctx)
+	if err != nil {
+		log.Printf("[HandleStart] Failed to get the admins to approve user %d: %v", user.ID, err)
+		return
+	}
+	for _, id := range admins {
+		if err := h.Notifier.RequestApproval(id, user); err != nil {
+			log.Printf("[HandleStart] %v", err)
+		}
+	}
+}
+
+// HandleApprovalCallback approves a pending user, adding them to the
+// rotation, or rejects them, deleting their registration, and tells them.
+// Format: approve_user:<user ID> or reject_user:<user ID>
+func (h *Handlers) HandleApprovalCallback(q *tgbotapi.CallbackQuery) (tgbotapi.EditMessageTextConfig, error) {
+	if refusal, ok := h.refuseNonAdminCallback(q); !ok {
+		return refusal, nil
+	}
+	cb := parse.ParseCallback(q.Data)
+	if err := cb.Expect(1); err != nil {
+		return tgbotapi.EditMessageTextConfig{}, err
+	}
+	userID, err := cb.ID(0)
+	if err != nil {
+		return tgbotapi.EditMessageTextConfig{}, err
+	}
+
+	ctx := context.Background()
+	u, err := h.Users.ByID(ctx, userID)
+	if err != nil {
+		// Another admin may have rejected them already
+		return tgbotapi.NewEditMessageText(q.Message.Chat.ID, q.Message.MessageID, "❌ User not found, they may have been rejected already."), nil
+	}
+	name := html.EscapeString(u.FirstName)
+
+	var text, tell string
+	if cb.Action == notification.RejectUserAction {
+		err = h.Users.Reject(ctx, u)
+		text, tell = fmt.Sprintf("❌ <b>%s</b> was rejected.", name), rejectedMessage
+	} else {
+		err = h.Users.Approve(ctx, u)
+		text, tell = fmt.Sprintf("✅ <b>%s</b> is on the rotation now.", name), approvedMessage
+	}
+	switch {
+	case errors.Is(err, user.ErrNotPending):
+		// Another admin decided already
+		text = fmt.Sprintf("ℹ️ <b>%s</b> isn't waiting for approval anymore.", name)
+	case err != nil:
+		log.Printf("[HandleApprovalCallback] Failed to handle %s: %v", q.Data, err)
+		return tgbotapi.NewEditMessageText(q.Message.Chat.ID, q.Message.MessageID,
+			fmt.Sprintf("❌ Failed to update %s: %v", u.FirstName, err)), nil
+	default:
+		log.Printf("[HandleApprovalCallback] Admin %d handled %s", q.From.ID, q.Data)
+		h.tellUser(ctx, u, tell)
+		// Approved users show up in the calendar legend
+		h.InvalidateCalendars(ctx, nil)
+	}
+	edit := tgbotapi.NewEditMessageText(q.Message.Chat.ID, q.Message.MessageID, text)
+	edit.ParseMode = tgbotapi.ModeHTML
+	return edit, nil
+}
+
+// tellUser sends the user a private message. Errors are only logged.
+func (h *Handlers) tellUser(ctx context.Context, u *store.User, text string) {
+	if h.Notifier == nil {
+		return
+	}
+	if _, err := h.Notifier.Notify(ctx, u, notification.KindPersonalDM, text); err != nil {
+		log.Printf("[HandleApprovalCallback] Failed to tell user %d: %v", u.ID, err)
+	}
+}
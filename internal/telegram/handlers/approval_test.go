@@ -0,0 +1,79 @@
+package handlers_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/korjavin/dutyassistant/internal/service/settings"
+	"github.com/korjavin/dutyassistant/internal/store"
+	storemocks "github.com/korjavin/dutyassistant/internal/store/mocks"
+	"github.com/korjavin/dutyassistant/internal/telegram/handlers"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestHandleStart_RequireApproval(t *testing.T) {
+	mockStore := storemocks.NewMockStore(gomock.NewController(t))
+	h := handlers.New(mockStore, nil)
+	h.Settings = settings.New(mockStore)
+	values := map[string]string{settings.KeyAdmins: "1", settings.KeyRequireApproval: "true"}
+	mockStore.EXPECT().GetSetting(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, key string) (string, error) {
+		return values[key], nil
+	}).AnyTimes()
+
+	var created *store.User
+	mockStore.EXPECT().GetUserByTelegramID(gomock.Any(), int64(456)).Return(nil, nil).Times(2)
+	mockStore.EXPECT().CreateUser(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, u *store.User) error {
+		created = u
+		return nil
+	})
+	m := &tgbotapi.Message{Chat: &tgbotapi.Chat{ID: 456}, From: &tgbotapi.User{ID: 456, FirstName: "Carol"}}
+	msg, err := h.HandleStart(m)
+	assert.NoError(t, err)
+	assert.Contains(t, msg.Text, "An admin has to approve you")
+	if assert.NotNil(t, created) {
+		assert.True(t, created.IsPending)
+		assert.False(t, created.IsActive)
+	}
+
+	// Pending users may look around, but not use commands for members
+	mockStore.EXPECT().GetUserByTelegramID(gomock.Any(), int64(456)).Return(created, nil).AnyTimes()
+	volunteer := adminCommand("volunteer", "1")
+	volunteer.From.ID = 456
+	if refusal, ok := h.AuthorizeCommand(volunteer).(tgbotapi.MessageConfig); assert.True(t, ok) {
+		assert.Contains(t, refusal.Text, "An admin still has to approve you")
+	}
+	schedule := adminCommand("schedule", "")
+	schedule.From.ID = 456
+	assert.Nil(t, h.AuthorizeCommand(schedule))
+}
+
+func TestHandleApprovalCallback(t *testing.T) {
+	mockStore, _, h := setupAdminTest(t)
+	carol := &store.User{ID: 3, TelegramUserID: 456, FirstName: "Carol", IsPending: true}
+	dave := &store.User{ID: 4, TelegramUserID: 789, FirstName: "Dave", IsPending: true}
+	mockStore.EXPECT().ListAllUsers(gomock.Any()).Return([]*store.User{carol, dave}, nil).AnyTimes()
+	callback := func(action string, u *store.User) string {
+		edit, err := h.HandleApprovalCallback(&tgbotapi.CallbackQuery{
+			From:    &tgbotapi.User{ID: 123},
+			Message: &tgbotapi.Message{MessageID: 1, Chat: &tgbotapi.Chat{ID: 789}},
+			Data:    fmt.Sprintf("%s:%d", action, u.ID),
+		})
+		assert.NoError(t, err)
+		return edit.Text
+	}
+
+	mockStore.EXPECT().UpdateUser(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, u *store.User) error {
+		assert.True(t, u.IsActive)
+		assert.False(t, u.IsPending)
+		return nil
+	})
+	assert.Equal(t, "✅ <b>Carol</b> is on the rotation now.", callback("approve_user", carol))
+	// Another admin pressing the buttons too changes nothing
+	assert.Equal(t, "ℹ️ <b>Carol</b> isn't waiting for approval anymore.", callback("reject_user", carol))
+
+	mockStore.EXPECT().DeleteUser(gomock.Any(), dave.ID).Return(nil)
+	assert.Equal(t, "❌ <b>Dave</b> was rejected.", callback("reject_user", dave))
+}
@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/korjavin/dutyassistant/internal/scheduler"
+	"github.com/korjavin/dutyassistant/internal/telegram/parse"
+)
+
+// HandleAssignToday runs today's daily assignment for admins right away,
+// without waiting for the cutoff. Format: /assigntoday
+func (h *Handlers) HandleAssignToday(m *tgbotapi.Message) (tgbotapi.MessageConfig, error) {
+	isAdmin, err := h.checkAdmin(m.From.ID)
+	if err != nil || !isAdmin {
+		return tgbotapi.NewMessage(m.Chat.ID, adminOnlyMessage), nil
+	}
+
+	duty, err := h.Duties.AssignToday(context.Background())
+	switch {
+	case errors.Is(err, scheduler.ErrNoAvailableUsers):
+		return tgbotapi.NewMessage(m.Chat.ID, "❌ Nobody is available today. Use /assign or /skip to decide what happens with the day."), nil
+	case err != nil:
+		return tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("❌ Failed to assign today's duty: %v", err)), nil
+	case duty == nil:
+		return tgbotapi.NewMessage(m.Chat.ID, "⏭ Today is marked as a day without duty."), nil
+	}
+
+	name := "Unknown"
+	if duty.User != nil {
+		name = duty.User.FirstName
+	}
+	return tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("✅ %s is on duty today (%s).", name, duty.DutyDate.Format(parse.DateLayout))), nil
+}
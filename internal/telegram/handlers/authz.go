@@ -0,0 +1,177 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/korjavin/dutyassistant/internal/notification"
+	"github.com/korjavin/dutyassistant/internal/telegram/keyboard"
+	"github.com/korjavin/dutyassistant/internal/telegram/parse"
+)
+
+// Role is what a user needs to be to use a command or button.
+type Role int
+
+const (
+	// RoleAnyone is for everyone the bot's access check lets in.
+	RoleAnyone Role = iota
+	// RoleMember is for users registered with /start, once an admin approved
+	// them if that is required.
+	RoleMember
+	// RoleAdmin is for admins.
+	RoleAdmin
+)
+
+// CallbackRoles maps every callback action to the role needed to press its
+// button. Missing actions are for admins only, so a forgotten entry fails
+// closed.
+var CallbackRoles = map[string]Role{
+	keyboard.ActionPrevMonth: RoleAnyone,
+	keyboard.ActionNextMonth: RoleAnyone,
+	keyboard.ActionSelectDay: RoleAnyone,
+	keyboard.ActionIgnore:    RoleAnyone,
+
+	"volunteer_days":                    RoleMember,
+	"volunteer_custom":                  RoleMember,
+	"notif_toggle":                      RoleMember,
+	"notif_time":                        RoleMember,
+	"notif_hour":                        RoleMember,
+	"notif_back":                        RoleMember,
+	notification.SnoozeAction:           RoleMember,
+	notification.AcknowledgeAction:      RoleMember, // The handler checks the duty is the user's
+	checklistCheckAction:                RoleMember, // The handler checks the duty is the user's
+	notification.ClaimTaskAction:        RoleMember,
+	notification.VolunteerPollAction:    RoleMember,
+	notification.CoverAction:            RoleMember,
+	notification.PayFineAction:          RoleMember, // Anyone may pay, e.g. a parent for a child
+	notification.CompleteTaskAction:     RoleMember, // The handler checks the task is the user's
+	notification.ReleaseTaskAction:      RoleMember, // The handler checks the task is the user's
+	tripOffDutyAction:                   RoleMember, // Only the user the hint was for can press it
+	tripDismissAction:                   RoleMember,
+	"assign_user":                       RoleAdmin,
+	"assign_days":                       RoleAdmin,
+	"assign_custom":                     RoleAdmin,
+	"modify_date":                       RoleAdmin,
+	"modify_user":                       RoleAdmin,
+	"toggle_user":                       RoleAdmin,
+	"offduty_user":                      RoleAdmin,
+	offDutyImportAction:                 RoleAdmin,
+	offDutyImportCancelAction:           RoleAdmin,
+	notification.TakeoverAssignAction:   RoleAdmin,
+	notification.TakeoverSkipAction:     RoleAdmin,
+	notification.TakeoverExternalAction: RoleAdmin,
+	takeoverUserAction:                  RoleAdmin,
+	notification.ApproveUserAction:      RoleAdmin,
+	notification.ReassignTodayAction:    RoleAdmin,
+	notification.SkipTodayAction:        RoleAdmin,
+	notification.RejectUserAction:       RoleAdmin,
+}
+
+// requiredRole looks up name in roles, defaulting to admins only.
+func requiredRole(roles map[string]Role, name string) Role {
+	if role, ok := roles[name]; ok {
+		return role
+	}
+	return RoleAdmin
+}
+
+// hasRole reports whether the Telegram user has at least the given role.
+func (h *Handlers) hasRole(telegramUserID int64, role Role) bool {
+	switch role {
+	case RoleAnyone:
+		return true
+	case RoleMember:
+		if u, err := h.Users.ByTelegramID(context.Background(), telegramUserID); err == nil && !u.IsPending {
+			return true
+		}
+		// The configured admin may not have run /start yet
+		isAdmin, _ := h.checkAdmin(telegramUserID)
+		return isAdmin
+	default:
+		isAdmin, err := h.checkAdmin(telegramUserID)
+		return err == nil && isAdmin
+	}
+}
+
+// roleOf returns the highest role the Telegram user has.
+func (h *Handlers) roleOf(telegramUserID int64) Role {
+	for _, role := range []Role{RoleAdmin, RoleMember} {
+		if h.hasRole(telegramUserID, role) {
+			return role
+		}
+	}
+	return RoleAnyone
+}
+
+// refusal is the reply to the Telegram user who lacks role.
+func (h *Handlers) refusal(telegramUserID int64, role Role) string {
+	if role == RoleAdmin {
+		return adminOnlyMessage
+	}
+	if u, err := h.Users.ByTelegramID(context.Background(), telegramUserID); err == nil && u.IsPending {
+		return pendingMessage
+	}
+	return volunteerUserNotFoundMessage
+}
+
+// AuthorizeCommand returns the reply refusing m if its sender lacks the role
+// its command needs or it would change something in read-only mode, or nil
+// if the command may run. Commands missing from
+// Commands pass, as there is nothing to run but the unknown command reply.
+func (h *Handlers) AuthorizeCommand(m *tgbotapi.Message) tgbotapi.Chattable {
+	cmd := LookupCommand(m.Command())
+	if cmd == nil {
+		return nil
+	}
+	if h.hasRole(m.From.ID, cmd.Role) {
+		if !cmd.Junior && h.isJunior(m.From.ID) {
+			log.Printf("[AUTHZ] Junior user %d refused /%s", m.From.ID, m.Command())
+			return tgbotapi.NewMessage(m.Chat.ID, juniorRefusalMessage)
+		}
+		if !cmd.onlyReads(m.CommandArguments()) && h.readOnly() {
+			log.Printf("[AUTHZ] User %d refused /%s in read-only mode", m.From.ID, m.Command())
+			return tgbotapi.NewMessage(m.Chat.ID, readOnlyMessage)
+		}
+		return nil
+	}
+	log.Printf("[AUTHZ] User %d refused /%s", m.From.ID, m.Command())
+	return tgbotapi.NewMessage(m.Chat.ID, h.refusal(m.From.ID, cmd.Role))
+}
+
+// AuthorizeCallback returns the reply refusing q if its sender lacks the role
+// its action needs, pressed a button of a menu someone else opened or would
+// change something in read-only mode, or nil if the callback may run.
+func (h *Handlers) AuthorizeCallback(q *tgbotapi.CallbackQuery) tgbotapi.Chattable {
+	action := parse.Action(q.Data)
+	// Flipping the pages of a list needs the role of pressing its buttons
+	if action == keyboard.ActionPage {
+		action = parse.Action(strings.TrimPrefix(q.Data, keyboard.ActionPage+":"))
+	}
+	// Buttons of inline-mode messages come without the message, the refusal
+	// goes to the sender privately then
+	chatID := q.From.ID
+	if q.Message != nil {
+		chatID = q.Message.Chat.ID
+	}
+	// Leave the keyboard alone when refusing, it may be someone else's to press
+	if q.Message != nil && !h.ownsMenu(q.Message.Chat.ID, q.Message.MessageID, q.From.ID) {
+		log.Printf("[AUTHZ] User %d refused callback %s on someone else's menu", q.From.ID, action)
+		return tgbotapi.NewMessage(chatID, menuNotYoursMessage)
+	}
+	role := requiredRole(CallbackRoles, action)
+	if h.hasRole(q.From.ID, role) {
+		if !JuniorCallbacks[action] && h.isJunior(q.From.ID) {
+			log.Printf("[AUTHZ] Junior user %d refused callback %s", q.From.ID, action)
+			return tgbotapi.NewMessage(chatID, juniorRefusalMessage)
+		}
+		if !QueryCallbacks[action] && h.readOnly() {
+			log.Printf("[AUTHZ] User %d refused callback %s in read-only mode", q.From.ID, action)
+			return tgbotapi.NewMessage(chatID, readOnlyMessage)
+		}
+		return nil
+	}
+	log.Printf("[AUTHZ] User %d refused callback %s", q.From.ID, action)
+	return tgbotapi.NewMessage(chatID, h.refusal(q.From.ID, role))
+}
@@ -0,0 +1,151 @@
+package handlers_test
+
+import (
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/korjavin/dutyassistant/internal/store"
+	storemocks "github.com/korjavin/dutyassistant/internal/store/mocks"
+	"github.com/korjavin/dutyassistant/internal/telegram/handlers"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+// setupAuthzTest returns handlers knowing an admin (Telegram ID 1), a member
+// (2), a stranger who never ran /start (3) and a junior member (4).
+func setupAuthzTest(t *testing.T) *handlers.Handlers {
+	mockStore := storemocks.NewMockStore(gomock.NewController(t))
+	mockStore.EXPECT().GetUserByTelegramID(gomock.Any(), int64(1)).Return(&store.User{ID: 1, TelegramUserID: 1, IsAdmin: true}, nil).AnyTimes()
+	mockStore.EXPECT().GetUserByTelegramID(gomock.Any(), int64(2)).Return(&store.User{ID: 2, TelegramUserID: 2}, nil).AnyTimes()
+	mockStore.EXPECT().GetUserByTelegramID(gomock.Any(), int64(3)).Return(nil, nil).AnyTimes()
+	mockStore.EXPECT().GetUserByTelegramID(gomock.Any(), int64(4)).Return(&store.User{ID: 4, TelegramUserID: 4, IsJunior: true}, nil).AnyTimes()
+	// Who opened a menu is stored
+	mockStore.EXPECT().SaveConversationState(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	return handlers.NewWithAdminID(mockStore, nil, 1)
+}
+
+var authzCallers = []struct {
+	name       string
+	telegramID int64
+	role       handlers.Role
+}{
+	{"admin", 1, handlers.RoleAdmin},
+	{"member", 2, handlers.RoleMember},
+	{"stranger", 3, handlers.RoleAnyone},
+}
+
+func TestAuthorizeCommand(t *testing.T) {
+	h := setupAuthzTest(t)
+
+	for _, cmd := range handlers.Commands {
+		for _, command := range append([]string{cmd.Name}, cmd.Aliases...) {
+			for _, caller := range authzCallers {
+				m := adminCommand(command, "")
+				m.From.ID = caller.telegramID
+				refusal := h.AuthorizeCommand(m)
+				if caller.role >= cmd.Role {
+					assert.Nil(t, refusal, "/%s by %s", command, caller.name)
+				} else {
+					assert.NotNil(t, refusal, "/%s by %s", command, caller.name)
+				}
+			}
+		}
+	}
+	m := adminCommand("modify", "")
+	m.From.ID = 2
+	assert.Equal(t, "Sorry, this command is for admins only.", h.AuthorizeCommand(m).(tgbotapi.MessageConfig).Text)
+
+	// Unknown commands have no handler to reach, only the unknown command reply
+	m = adminCommand("something_new", "")
+	m.From.ID = 3
+	assert.Nil(t, h.AuthorizeCommand(m))
+}
+
+func TestAuthorizeCommand_Junior(t *testing.T) {
+	h := setupAuthzTest(t)
+
+	for _, cmd := range handlers.Commands {
+		m := adminCommand(cmd.Name, "")
+		m.From.ID = 4
+		refusal := h.AuthorizeCommand(m)
+		if cmd.Role <= handlers.RoleMember && cmd.Junior {
+			assert.Nil(t, refusal, "/%s by a junior", cmd.Name)
+		} else {
+			assert.NotNil(t, refusal, "/%s by a junior", cmd.Name)
+		}
+	}
+
+	m := adminCommand("volunteer", "")
+	m.From.ID = 4
+	assert.Equal(t, "Sorry, this command isn't available for you. Use /help to see what you can do.",
+		h.AuthorizeCommand(m).(tgbotapi.MessageConfig).Text)
+}
+
+func TestAuthorizeCallback(t *testing.T) {
+	h := setupAuthzTest(t)
+
+	for action, role := range handlers.CallbackRoles {
+		for _, caller := range authzCallers {
+			q := &tgbotapi.CallbackQuery{
+				From:    &tgbotapi.User{ID: caller.telegramID},
+				Message: &tgbotapi.Message{Chat: &tgbotapi.Chat{ID: 789}},
+				Data:    action + ":1",
+			}
+			refusal := h.AuthorizeCallback(q)
+			if caller.role >= role {
+				assert.Nil(t, refusal, "%s by %s", action, caller.name)
+			} else {
+				assert.NotNil(t, refusal, "%s by %s", action, caller.name)
+			}
+		}
+	}
+
+	// The buttons that used to reach their handler unchecked
+	q := &tgbotapi.CallbackQuery{
+		From:    &tgbotapi.User{ID: 2},
+		Message: &tgbotapi.Message{Chat: &tgbotapi.Chat{ID: 789}},
+		Data:    "assign_days:2:7",
+	}
+	assert.Equal(t, "Sorry, this command is for admins only.", h.AuthorizeCallback(q).(tgbotapi.MessageConfig).Text)
+
+	// Flipping through a list needs the role of its buttons
+	q.Data = "page:modify_user:2025-11-08:1"
+	assert.Equal(t, "Sorry, this command is for admins only.", h.AuthorizeCallback(q).(tgbotapi.MessageConfig).Text)
+	q.From.ID = 1
+	assert.Nil(t, h.AuthorizeCallback(q))
+
+	// Juniors only press the buttons of their reduced commands
+	q.From.ID = 4
+	q.Data = "check_item:2025-11-08:1"
+	assert.Nil(t, h.AuthorizeCallback(q))
+	q.Data = "volunteer_days:3"
+	assert.NotNil(t, h.AuthorizeCallback(q))
+
+	// Inline-mode buttons have no message, the sender is answered privately
+	for caller, data := range map[int64]string{2: "assign_days:2:7", 4: "volunteer_days:3"} {
+		q := &tgbotapi.CallbackQuery{From: &tgbotapi.User{ID: caller}, Data: data}
+		if refusal, ok := h.AuthorizeCallback(q).(tgbotapi.MessageConfig); assert.True(t, ok, data) {
+			assert.Equal(t, caller, refusal.ChatID)
+		}
+	}
+}
+
+func TestAuthorizeCallback_MenuOwner(t *testing.T) {
+	h := setupAuthzTest(t)
+
+	press := func(telegramID int64) tgbotapi.Chattable {
+		return h.AuthorizeCallback(&tgbotapi.CallbackQuery{
+			From:    &tgbotapi.User{ID: telegramID},
+			Message: &tgbotapi.Message{MessageID: 5, Chat: &tgbotapi.Chat{ID: 789}},
+			Data:    "volunteer_days:3",
+		})
+	}
+	assert.Nil(t, press(1), "an unbound menu is anyone's with the role")
+
+	h.BindMenu(789, 5, 2)
+	assert.Nil(t, press(2))
+	refusal := press(1)
+	if assert.NotNil(t, refusal, "even an admin can't press someone else's menu") {
+		assert.Contains(t, refusal.(tgbotapi.MessageConfig).Text, "opened by someone else")
+	}
+}
@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/korjavin/dutyassistant/internal/telegram/parse"
+)
+
+const backfillUsageMessage = "🕰 <b>Record who actually did a past duty</b>\n\n" +
+	"Usage: <code>/backfill date username</code>\n\n" +
+	"Example: <code>/backfill 2025-10-20 Alice</code>\n\n" +
+	"Backfilled duties count towards stats and are marked as retroactive."
+
+// HandleBackfill records or corrects a past duty for admins. Format: /backfill <date> <user>
+func (h *Handlers) HandleBackfill(m *tgbotapi.Message) (tgbotapi.MessageConfig, error) {
+	isAdmin, err := h.checkAdmin(m.From.ID)
+	if err != nil || !isAdmin {
+		return tgbotapi.NewMessage(m.Chat.ID, adminOnlyMessage), nil
+	}
+
+	args := strings.Fields(m.CommandArguments())
+	if len(args) != 2 {
+		msg := tgbotapi.NewMessage(m.Chat.ID, backfillUsageMessage)
+		msg.ParseMode = tgbotapi.ModeHTML
+		return msg, nil
+	}
+
+	date, err := parse.Date(args[0])
+	if err != nil {
+		return tgbotapi.NewMessage(m.Chat.ID, invalidDateMessage), nil
+	}
+
+	ctx := context.Background()
+	user, err := h.Users.ByName(ctx, args[1])
+	if err != nil {
+		return tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf(userNotFoundMessage, args[1])), nil
+	}
+
+	dateStr := date.Format(parse.DateLayout)
+	if _, err := h.Duties.Backfill(ctx, date, user.ID); err != nil {
+		return tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("❌ Failed to backfill %s: %v", dateStr, err)), nil
+	}
+	return tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("🕰 Recorded %s as having done the duty on %s.", user.FirstName, dateStr)), nil
+}
@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"log"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/korjavin/dutyassistant/internal/notification"
+	"github.com/korjavin/dutyassistant/internal/service/ledger"
+	"github.com/korjavin/dutyassistant/internal/service/settings"
+)
+
+const (
+	balanceOffMessage   = "💰 Payout mode is off, missed duties are made up with other days. Admins turn it on with /settings fine <amount>."
+	balanceUsageMessage = "⚠️ Invalid format.\n\nUsage:\n" +
+		"<code>/balance</code> - show what everyone owes\n" +
+		"<code>/balance pay</code> - pay what you owe in Telegram\n" +
+		"<code>/balance paid &lt;user&gt; [amount]</code> - record a payment, the whole balance if no amount is given"
+)
+
+// HandleBalance shows what everyone owes in payout mode, and lets admins
+// record payments.
+// Format: /balance [paid <user> [amount]]
+func (h *Handlers) HandleBalance(m *tgbotapi.Message) (tgbotapi.MessageConfig, error) {
+	ctx := context.Background()
+	args := strings.Fields(m.CommandArguments())
+	var reply string
+	var err error
+	switch {
+	case len(args) == 0:
+		reply, err = h.balancesText(ctx)
+	case args[0] == "pay" && len(args) == 1:
+		return h.payBalance(ctx, m)
+	case args[0] == "paid" && (len(args) == 2 || len(args) == 3):
+		if isAdmin, adminErr := h.checkAdmin(m.From.ID); adminErr != nil || !isAdmin {
+			return tgbotapi.NewMessage(m.Chat.ID, adminOnlyMessage), nil
+		}
+		reply, err = h.recordPayment(ctx, args[1], args[2:])
+	default:
+		reply = balanceUsageMessage
+	}
+	if err != nil {
+		log.Printf("[HandleBalance] Failed to handle %q: %v", m.Text, err)
+		return tgbotapi.NewMessage(m.Chat.ID, genericErrorMessage), nil
+	}
+	msg := tgbotapi.NewMessage(m.Chat.ID, reply)
+	msg.ParseMode = tgbotapi.ModeHTML
+	return msg, nil
+}
+
+// balancesText lists what everyone owes, with the fines of the latest missed
+// duties charged.
+func (h *Handlers) balancesText(ctx context.Context) (string, error) {
+	payout, err := settings.New(h.Store).Payout(ctx)
+	if err != nil {
+		return "", err
+	}
+	if !payout.On() {
+		return html.EscapeString(balanceOffMessage), nil
+	}
+	if _, err := h.Ledger.Sync(ctx); err != nil {
+		return "", err
+	}
+	balances, err := h.Ledger.Balances(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "💰 <b>Balances</b>\n\nA missed duty costs %s.\n\n", ledger.FormatAmount(payout.Fine, payout.Currency))
+	if len(balances) == 0 {
+		b.WriteString("Nobody owes anything. 🎉")
+		return b.String(), nil
+	}
+	for _, balance := range balances {
+		fmt.Fprintf(&b, "• %s: %s\n", html.EscapeString(balance.User.Label()), ledger.FormatAmount(balance.Amount, payout.Currency))
+	}
+	return strings.TrimSuffix(b.String(), "\n"), nil
+}
+
+// payBalance tells the user what they owe, with a button for an invoice of it
+// if fines are paid in Telegram.
+func (h *Handlers) payBalance(ctx context.Context, m *tgbotapi.Message) (tgbotapi.MessageConfig, error) {
+	if !h.Payments.On() {
+		return tgbotapi.NewMessage(m.Chat.ID, paymentsOffMessage), nil
+	}
+	user, err := h.Users.ByTelegramID(ctx, m.From.ID)
+	if err != nil {
+		return tgbotapi.NewMessage(m.Chat.ID, volunteerUserNotFoundMessage), nil
+	}
+	payout, err := settings.New(h.Store).Payout(ctx)
+	if err != nil {
+		log.Printf("[HandleBalance] Failed to get the payout settings: %v", err)
+		return tgbotapi.NewMessage(m.Chat.ID, genericErrorMessage), nil
+	}
+	if _, err := h.Ledger.Sync(ctx); err != nil {
+		log.Printf("[HandleBalance] Failed to charge missed duties: %v", err)
+		return tgbotapi.NewMessage(m.Chat.ID, genericErrorMessage), nil
+	}
+	balance, err := h.Ledger.Balance(ctx, user.ID)
+	if err != nil {
+		log.Printf("[HandleBalance] Failed to get the balance of user %d: %v", user.ID, err)
+		return tgbotapi.NewMessage(m.Chat.ID, genericErrorMessage), nil
+	}
+	if balance <= 0 {
+		return tgbotapi.NewMessage(m.Chat.ID, "You don't owe anything. 🎉"), nil
+	}
+	button := notification.PayButton(user.ID)
+	msg := tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("💰 You owe %s for missed duties.", ledger.FormatAmount(balance, payout.Currency)))
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData(button.Text, button.Data)))
+	return msg, nil
+}
+
+// recordPayment records that the user paid the amount in args, or their
+// whole balance.
+func (h *Handlers) recordPayment(ctx context.Context, ref string, args []string) (string, error) {
+	user, err := h.Users.Find(ctx, ref)
+	if err != nil {
+		return fmt.Sprintf(userNotFoundMessage, html.EscapeString(ref)), nil
+	}
+	var amount int64
+	if len(args) > 0 {
+		if amount, err = ledger.ParseAmount(args[0]); err != nil {
+			return "❌ " + html.EscapeString(err.Error()), nil
+		}
+	}
+	payout, err := settings.New(h.Store).Payout(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	payment, err := h.Ledger.RecordPayment(ctx, user.ID, amount)
+	if errors.Is(err, ledger.ErrNothingOwed) {
+		return fmt.Sprintf("❌ %s doesn't owe anything.", html.EscapeString(user.FirstName)), nil
+	} else if err != nil {
+		return "", err
+	}
+	balance, err := h.Ledger.Balance(ctx, user.ID)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("✅ Recorded %s paid by %s, who now owes %s.", ledger.FormatAmount(-payment.Amount, payout.Currency),
+		html.EscapeString(user.FirstName), ledger.FormatAmount(balance, payout.Currency)), nil
+}
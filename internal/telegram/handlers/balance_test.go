@@ -0,0 +1,128 @@
+package handlers_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/korjavin/dutyassistant/internal/service/ledger"
+	"github.com/korjavin/dutyassistant/internal/service/settings"
+	"github.com/korjavin/dutyassistant/internal/store"
+	"github.com/korjavin/dutyassistant/internal/store/memory"
+	"github.com/korjavin/dutyassistant/internal/telegram/handlers"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandleBalance(t *testing.T) {
+	ctx := context.Background()
+	s := memory.New()
+	h := handlers.New(s, nil)
+	if err := s.CreateUser(ctx, &store.User{TelegramUserID: 123, FirstName: "Admin", IsAdmin: true, IsActive: true}); err != nil {
+		t.Fatal(err)
+	}
+	alice := &store.User{TelegramUserID: 456, FirstName: "Alice", IsActive: true}
+	if err := s.CreateUser(ctx, alice); err != nil {
+		t.Fatal(err)
+	}
+
+	msg, err := h.HandleBalance(adminCommand("balance", ""))
+	assert.NoError(t, err)
+	assert.Contains(t, msg.Text, "Payout mode is off")
+
+	yesterday := time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, -1)
+	if err := settings.New(s).SetPayout(ctx, 450, "", yesterday); err != nil {
+		t.Fatal(err)
+	}
+	missed := &store.Duty{UserID: alice.ID, DutyDate: yesterday, AssignmentType: store.AssignmentTypeRoundRobin, Status: store.DutyStatusMissed}
+	if err := s.CreateDuty(ctx, missed); err != nil {
+		t.Fatal(err)
+	}
+
+	msg, err = h.HandleBalance(adminCommand("balance", ""))
+	assert.NoError(t, err)
+	assert.Contains(t, msg.Text, "A missed duty costs 4.50 EUR.")
+	assert.Contains(t, msg.Text, "• Alice: 4.50 EUR")
+
+	msg, err = h.HandleBalance(adminCommand("balance", "paid alice 2"))
+	assert.NoError(t, err)
+	assert.Equal(t, "✅ Recorded 2.00 EUR paid by Alice, who now owes 2.50 EUR.", msg.Text)
+	msg, err = h.HandleBalance(adminCommand("balance", "paid alice"))
+	assert.NoError(t, err)
+	assert.Equal(t, "✅ Recorded 2.50 EUR paid by Alice, who now owes 0.00 EUR.", msg.Text)
+	msg, err = h.HandleBalance(adminCommand("balance", "paid alice"))
+	assert.NoError(t, err)
+	assert.Equal(t, "❌ Alice doesn't owe anything.", msg.Text)
+
+	msg, err = h.HandleBalance(adminCommand("balance", ""))
+	assert.NoError(t, err)
+	assert.Contains(t, msg.Text, "Nobody owes anything.")
+}
+
+func TestHandleBalance_Pay(t *testing.T) {
+	ctx := context.Background()
+	s := memory.New()
+	h := handlers.New(s, nil)
+	admin := &store.User{TelegramUserID: 123, FirstName: "Admin", IsAdmin: true, IsActive: true}
+	if err := s.CreateUser(ctx, admin); err != nil {
+		t.Fatal(err)
+	}
+	yesterday := time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, -1)
+	if err := settings.New(s).SetPayout(ctx, 450, "", yesterday); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.CreateDuty(ctx, &store.Duty{UserID: admin.ID, DutyDate: yesterday, AssignmentType: store.AssignmentTypeRoundRobin, Status: store.DutyStatusMissed}); err != nil {
+		t.Fatal(err)
+	}
+
+	msg, err := h.HandleBalance(adminCommand("balance", "pay"))
+	assert.NoError(t, err)
+	assert.Contains(t, msg.Text, "can't be paid in Telegram")
+
+	h.Payments = ledger.Payments{StarsPerUnit: 50}
+	msg, err = h.HandleBalance(adminCommand("balance", "pay"))
+	assert.NoError(t, err)
+	assert.Equal(t, "💰 You owe 4.50 EUR for missed duties.", msg.Text)
+	markup, ok := msg.ReplyMarkup.(tgbotapi.InlineKeyboardMarkup)
+	if !assert.True(t, ok) {
+		t.FailNow()
+	}
+	data := *markup.InlineKeyboard[0][0].CallbackData
+	assert.Equal(t, fmt.Sprintf("pay_fine:%d", admin.ID), data)
+
+	response, err := h.HandlePayFineCallback(&tgbotapi.CallbackQuery{From: &tgbotapi.User{ID: 123}, Message: &tgbotapi.Message{Chat: &tgbotapi.Chat{ID: 123}}, Data: data})
+	assert.NoError(t, err)
+	invoice, ok := response.(tgbotapi.InvoiceConfig)
+	if !assert.True(t, ok, "expected an invoice, got %T", response) {
+		t.FailNow()
+	}
+	assert.Equal(t, ledger.StarsCurrency, invoice.Currency)
+	assert.Equal(t, []tgbotapi.LabeledPrice{{Label: "Fines", Amount: 225}}, invoice.Prices)
+
+	checkout := &tgbotapi.PreCheckoutQuery{ID: "q1", From: &tgbotapi.User{ID: 123}, Currency: "XTR", TotalAmount: 225, InvoicePayload: invoice.Payload}
+	assert.True(t, h.HandlePreCheckout(checkout).OK)
+	tampered := *checkout
+	tampered.TotalAmount = 1
+	assert.False(t, h.HandlePreCheckout(&tampered).OK)
+
+	paid := &tgbotapi.Message{Chat: &tgbotapi.Chat{ID: 123}, From: &tgbotapi.User{ID: 123}, SuccessfulPayment: &tgbotapi.SuccessfulPayment{
+		Currency: "XTR", TotalAmount: 225, InvoicePayload: invoice.Payload, TelegramPaymentChargeID: "charge-1"}}
+	response, err = h.HandleMessage(paid)
+	assert.NoError(t, err)
+	if reply, ok := response.(tgbotapi.MessageConfig); assert.True(t, ok) {
+		assert.Equal(t, "✅ Thanks! Recorded 4.50 EUR paid for Admin, who now owes 0.00 EUR.", reply.Text)
+	}
+	// Telegram may deliver it twice, it counts once
+	response, err = h.HandleMessage(paid)
+	assert.NoError(t, err)
+	assert.Nil(t, response)
+
+	// The invoice can't be paid again once the balance is settled
+	refusal := h.HandlePreCheckout(checkout)
+	assert.False(t, refusal.OK)
+	assert.Contains(t, refusal.ErrorMessage, "balance changed")
+	msg, err = h.HandleBalance(adminCommand("balance", "pay"))
+	assert.NoError(t, err)
+	assert.Equal(t, "You don't owe anything. 🎉", msg.Text)
+}
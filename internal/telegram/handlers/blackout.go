@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/korjavin/dutyassistant/internal/scheduler"
+	"github.com/korjavin/dutyassistant/internal/telegram/parse"
+)
+
+const blackoutUsageMessage = "🚫 <b>Blackout days</b>\n\n" +
+	"Nobody in the household is on duty on blackout days, every year.\n\n" +
+	"<code>/blackout list</code> - list the blackout rules\n" +
+	"<code>/blackout add rule [description]</code> - add a rule\n" +
+	"<code>/blackout del id</code> - delete a rule\n\n" +
+	"Rules: a day like <code>12-25</code>, days like <code>12-24..12-26</code>, " +
+	"<code>easter</code> or days from it like <code>easter-2</code> for Good Friday\n\n" +
+	"Example: <code>/blackout add 12-24..12-25 Christmas</code>"
+
+// HandleBlackout manages the household's blackout rules for admins.
+// Format: /blackout [list | add <rule> [description] | del <id>]
+func (h *Handlers) HandleBlackout(m *tgbotapi.Message) (tgbotapi.MessageConfig, error) {
+	isAdmin, err := h.checkAdmin(m.From.ID)
+	if err != nil || !isAdmin {
+		return tgbotapi.NewMessage(m.Chat.ID, adminOnlyMessage), nil
+	}
+
+	args := strings.Fields(m.CommandArguments())
+	ctx := context.Background()
+	switch {
+	case len(args) == 0 || (args[0] == "list" && len(args) == 1):
+		return h.listBlackouts(ctx, m.Chat.ID)
+	case args[0] == "add" && len(args) >= 2:
+		rule, removed, err := h.Scheduler.AddBlackout(ctx, args[1], strings.Join(args[2:], " "))
+		if err != nil && rule == nil {
+			return tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("❌ Failed to add the blackout: %v", err)), nil
+		}
+		// Blacked out days are skipped even where nobody was on duty
+		h.InvalidateCalendars(ctx, nil)
+		var b strings.Builder
+		fmt.Fprintf(&b, "🚫 Blackout #%d added, nobody is on duty on %s.", rule.ID, rule.Rule)
+		if len(removed) > 0 {
+			days := make([]string, 0, len(removed))
+			for _, d := range removed {
+				days = append(days, d.DutyDate.Format(parse.DateLayout))
+			}
+			fmt.Fprintf(&b, "\n\nThe duties on %s are removed.", strings.Join(days, ", "))
+		}
+		if err != nil {
+			fmt.Fprintf(&b, "\n\n⚠️ %v", err)
+		}
+		return tgbotapi.NewMessage(m.Chat.ID, b.String()), nil
+	case args[0] == "del" && len(args) == 2:
+		id, err := strconv.ParseInt(strings.TrimPrefix(args[1], "#"), 10, 64)
+		if err != nil {
+			return tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("❌ Invalid blackout ID: %s", args[1])), nil
+		}
+		if err := h.Scheduler.RemoveBlackout(ctx, id); errors.Is(err, scheduler.ErrNoBlackout) {
+			return tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("❌ There is no blackout #%d.", id)), nil
+		} else if err != nil {
+			return tgbotapi.NewMessage(m.Chat.ID, genericErrorMessage), nil
+		}
+		h.InvalidateCalendars(ctx, nil)
+		return tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("🗑 Blackout #%d deleted, its days are assigned as usual again.", id)), nil
+	default:
+		msg := tgbotapi.NewMessage(m.Chat.ID, blackoutUsageMessage)
+		msg.ParseMode = tgbotapi.ModeHTML
+		return msg, nil
+	}
+}
+
+// listBlackouts lists the blackout rules with their IDs and descriptions.
+func (h *Handlers) listBlackouts(ctx context.Context, chatID int64) (tgbotapi.MessageConfig, error) {
+	rules, err := h.Store.ListBlackoutRules(ctx)
+	if err != nil {
+		return tgbotapi.NewMessage(chatID, genericErrorMessage), nil
+	}
+	if len(rules) == 0 {
+		msg := tgbotapi.NewMessage(chatID, "There are no blackout rules yet.\n\n"+blackoutUsageMessage)
+		msg.ParseMode = tgbotapi.ModeHTML
+		return msg, nil
+	}
+
+	var b strings.Builder
+	b.WriteString("🚫 <b>Blackout rules</b>\n")
+	for _, r := range rules {
+		fmt.Fprintf(&b, "\n#%d %s", r.ID, escapeHTML(r.Rule))
+		if r.Description != "" {
+			fmt.Fprintf(&b, ": %s", escapeHTML(r.Description))
+		}
+	}
+	msg := tgbotapi.NewMessage(chatID, b.String())
+	msg.ParseMode = tgbotapi.ModeHTML
+	return msg, nil
+}
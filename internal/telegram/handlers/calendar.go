@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/korjavin/dutyassistant/internal/store"
+)
+
+const (
+	calendarUsageMessage = "📆 <b>Family calendar sync</b>\n\n" +
+		"Link an iCal URL and all-day events such as \"Vacation\" or \"Trip\" will automatically mark you off-duty.\n\n" +
+		"Usage:\n<code>/calendar https://example.com/family.ics</code> - link a calendar\n" +
+		"<code>/calendar off</code> - unlink it"
+	calendarInvalidURLMessage  = "⚠️ That doesn't look like a calendar URL. Please use an http(s):// or webcal:// link."
+	calendarPrivateOnlyMessage = "📆 Calendar links are secret, so they're only managed in a private chat. Send me /calendar directly."
+	calendarSyncFailedMessage  = "⚠️ Your calendar is linked, but I couldn't import it. " +
+		"Check that the link is a public iCal URL; I'll keep retrying periodically and /calendar shows the last error."
+)
+
+// calendarSyncTimeout bounds the first sync of a newly linked calendar.
+const calendarSyncTimeout = 30 * time.Second
+
+// HandleCalendar links or unlinks a user's external iCal calendar. Format: /calendar [url|off]
+func (h *Handlers) HandleCalendar(m *tgbotapi.Message) (tgbotapi.MessageConfig, error) {
+	if !m.Chat.IsPrivate() {
+		return tgbotapi.NewMessage(m.Chat.ID, calendarPrivateOnlyMessage), nil
+	}
+	ctx := context.Background()
+	user, err := h.Users.ByTelegramID(ctx, m.From.ID)
+	if err != nil {
+		return tgbotapi.NewMessage(m.Chat.ID, volunteerUserNotFoundMessage), nil
+	}
+
+	arg := strings.TrimSpace(m.CommandArguments())
+	switch strings.ToLower(arg) {
+	case "":
+		return h.calendarStatus(ctx, m.Chat.ID, user)
+	case "off", "remove", "unlink":
+		if err := h.Store.DeleteCalendarLink(ctx, user.ID); err != nil {
+			log.Printf("[HandleCalendar] Failed to unlink calendar for user %d: %v", user.ID, err)
+			return tgbotapi.NewMessage(m.Chat.ID, genericErrorMessage), nil
+		}
+		return tgbotapi.NewMessage(m.Chat.ID, "✅ Calendar unlinked. Imported off-duty periods were removed."), nil
+	}
+
+	calendarURL, ok := normalizeCalendarURL(arg)
+	if !ok {
+		return tgbotapi.NewMessage(m.Chat.ID, calendarInvalidURLMessage), nil
+	}
+
+	link := &store.CalendarLink{UserID: user.ID, URL: calendarURL}
+	if err := h.Store.SetCalendarLink(ctx, link); err != nil {
+		log.Printf("[HandleCalendar] Failed to link calendar for user %d: %v", user.ID, err)
+		return tgbotapi.NewMessage(m.Chat.ID, genericErrorMessage), nil
+	}
+
+	if h.Calendars == nil {
+		return tgbotapi.NewMessage(m.Chat.ID, "✅ Calendar linked. It will be checked during the next sync."), nil
+	}
+
+	// Fetching can take a while, so don't hold up the update loop for it
+	go h.firstCalendarSync(user, link)
+	return tgbotapi.NewMessage(m.Chat.ID, "✅ Calendar linked. I'm importing it now and will tell you how it went."), nil
+}
+
+// firstCalendarSync imports a newly linked calendar and tells the user the
+// outcome. Fetch errors can quote the secret URL, so they're only logged.
+func (h *Handlers) firstCalendarSync(user *store.User, link *store.CalendarLink) {
+	ctx, cancel := context.WithTimeout(context.Background(), calendarSyncTimeout)
+	defer cancel()
+
+	count, err := h.Calendars.Sync(ctx, link)
+	if err != nil {
+		log.Printf("[HandleCalendar] First sync failed for user %d: %v", user.ID, err)
+		h.tellUser(ctx, user, calendarSyncFailedMessage)
+		return
+	}
+	h.tellUser(ctx, user, fmt.Sprintf("✅ Calendar imported: %d off-duty period(s).", count))
+}
+
+// calendarStatus describes the user's current calendar link and imported periods.
+func (h *Handlers) calendarStatus(ctx context.Context, chatID int64, user *store.User) (tgbotapi.MessageConfig, error) {
+	link, err := h.Store.GetCalendarLink(ctx, user.ID)
+	if err != nil {
+		log.Printf("[HandleCalendar] Failed to get calendar link for user %d: %v", user.ID, err)
+		return tgbotapi.NewMessage(chatID, genericErrorMessage), nil
+	}
+	if link == nil {
+		msg := tgbotapi.NewMessage(chatID, calendarUsageMessage)
+		msg.ParseMode = tgbotapi.ModeHTML
+		return msg, nil
+	}
+
+	var builder strings.Builder
+	builder.WriteString("📆 <b>Linked calendar</b>\n\n")
+	if link.LastSyncedAt != nil {
+		builder.WriteString(fmt.Sprintf("Last sync: %s\n", link.LastSyncedAt.Format("2006-01-02 15:04 MST")))
+	} else {
+		builder.WriteString("Last sync: never\n")
+	}
+	if link.LastError != "" {
+		builder.WriteString(fmt.Sprintf("⚠️ Last error: %s\n", escapeHTML(link.LastError)))
+	}
+
+	periods, err := h.Store.ListOffDutyPeriods(ctx, user.ID)
+	if err == nil && len(periods) > 0 {
+		builder.WriteString("\n🏖 <b>Imported off-duty periods:</b>\n")
+		for _, p := range periods {
+			if p.Source != store.OffDutySourceICal {
+				continue
+			}
+			builder.WriteString(fmt.Sprintf("  • %s to %s %s\n",
+				p.StartDate.Format("2006-01-02"), p.EndDate.Format("2006-01-02"), escapeHTML(p.Summary)))
+		}
+	}
+	builder.WriteString("\nUse <code>/calendar off</code> to unlink.")
+
+	msg := tgbotapi.NewMessage(chatID, builder.String())
+	msg.ParseMode = tgbotapi.ModeHTML
+	return msg, nil
+}
+
+// normalizeCalendarURL validates a calendar URL and rewrites webcal:// to https://.
+func normalizeCalendarURL(raw string) (string, bool) {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return "", false
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "webcal", "webcals":
+		u.Scheme = "https"
+	case "http", "https":
+	default:
+		return "", false
+	}
+	return u.String(), true
+}
+
+// escapeHTML escapes text for Telegram's HTML parse mode.
+func escapeHTML(s string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(s)
+}
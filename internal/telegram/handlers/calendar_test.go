@@ -0,0 +1,33 @@
+package handlers_test
+
+import (
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/korjavin/dutyassistant/internal/store"
+	storemocks "github.com/korjavin/dutyassistant/internal/store/mocks"
+	"github.com/korjavin/dutyassistant/internal/telegram/handlers"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestHandleCalendar_PrivateOnly(t *testing.T) {
+	mockStore := storemocks.NewMockStore(gomock.NewController(t))
+	h := handlers.NewWithAdminID(mockStore, nil, 1)
+	mockStore.EXPECT().GetUserByTelegramID(gomock.Any(), int64(2)).Return(&store.User{ID: 7, TelegramUserID: 2}, nil).AnyTimes()
+	calendar := func(chat *tgbotapi.Chat, args string) string {
+		m := adminCommand("calendar", args)
+		m.Chat, m.From.ID = chat, 2
+		msg, err := h.HandleCalendar(m)
+		assert.NoError(t, err)
+		return msg.Text
+	}
+
+	// The URL is secret, so a group never sees it linked or echoed back
+	group := &tgbotapi.Chat{ID: -100, Type: "group"}
+	assert.Contains(t, calendar(group, "https://example.com/family.ics"), "only managed in a private chat")
+
+	private := &tgbotapi.Chat{ID: 2, Type: "private"}
+	mockStore.EXPECT().SetCalendarLink(gomock.Any(), &store.CalendarLink{UserID: 7, URL: "https://example.com/family.ics"}).Return(nil)
+	assert.Contains(t, calendar(private, "webcal://example.com/family.ics"), "Calendar linked")
+}
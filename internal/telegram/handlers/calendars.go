@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/korjavin/dutyassistant/internal/events"
+)
+
+// calendarCacheTTL is how long a rendered calendar is reused. Changes made
+// through the bot or the scheduler invalidate the cache right away; this only
+// bounds how long changes made elsewhere, e.g. queue days added through the
+// HTTP API, take to show up.
+const calendarCacheTTL = 5 * time.Minute
+
+// calendarKey identifies a rendered /schedule calendar: a month in a chat,
+// optionally highlighting one user.
+type calendarKey struct {
+	chatID int64
+	month  string // 2006-01
+	userID int64  // 0 for the calendar of everyone
+}
+
+type renderedCalendar struct {
+	version    int64
+	text       string
+	markup     tgbotapi.InlineKeyboardMarkup
+	renderedAt time.Time
+}
+
+type shownCalendar struct {
+	key     calendarKey
+	version int64
+}
+
+// calendarCache keeps rendered calendars so that flipping through months
+// doesn't query the whole month on every tap, and remembers what each
+// calendar message shows so identical edits, which Telegram refuses with
+// "message is not modified", aren't sent at all.
+type calendarCache struct {
+	mu       sync.Mutex
+	version  int64 // Bumped whenever duties, skip days or queues may have changed
+	rendered map[calendarKey]renderedCalendar
+	shown    map[menuKey]shownCalendar
+}
+
+// InvalidateCalendars drops every rendered calendar. It is subscribed to the
+// scheduler's event bus, and handlers call it themselves after changing
+// something calendars show that publishes no event, like queue days, skip
+// days or user names.
+func (h *Handlers) InvalidateCalendars(ctx context.Context, e events.Event) {
+	h.calendars.mu.Lock()
+	defer h.calendars.mu.Unlock()
+	h.calendars.version++
+	h.calendars.rendered = nil
+}
+
+// cachedCalendar returns the calendar rendered for key since the last
+// invalidation, if any.
+func (h *Handlers) cachedCalendar(key calendarKey) (renderedCalendar, bool) {
+	h.calendars.mu.Lock()
+	defer h.calendars.mu.Unlock()
+	r, ok := h.calendars.rendered[key]
+	if !ok || r.version != h.calendars.version || time.Since(r.renderedAt) > calendarCacheTTL {
+		return renderedCalendar{}, false
+	}
+	return r, true
+}
+
+// cacheCalendar stores a calendar rendered for key and returns it stamped
+// with the current version.
+func (h *Handlers) cacheCalendar(key calendarKey, text string, markup tgbotapi.InlineKeyboardMarkup) renderedCalendar {
+	h.calendars.mu.Lock()
+	defer h.calendars.mu.Unlock()
+
+	now := time.Now()
+	if h.calendars.rendered == nil {
+		h.calendars.rendered = make(map[calendarKey]renderedCalendar)
+	}
+	for k, r := range h.calendars.rendered {
+		if now.Sub(r.renderedAt) > calendarCacheTTL {
+			delete(h.calendars.rendered, k)
+		}
+	}
+	r := renderedCalendar{version: h.calendars.version, text: text, markup: markup, renderedAt: now}
+	h.calendars.rendered[key] = r
+	return r
+}
+
+// showCalendar records that the message now shows r for key. It returns false
+// if the message already showed exactly that, so the edit can be skipped.
+func (h *Handlers) showCalendar(chatID int64, messageID int, key calendarKey, r renderedCalendar) bool {
+	h.calendars.mu.Lock()
+	defer h.calendars.mu.Unlock()
+
+	if h.calendars.shown == nil {
+		h.calendars.shown = make(map[menuKey]shownCalendar)
+	}
+	shown := shownCalendar{key: key, version: r.version}
+	msg := menuKey{chatID, messageID}
+	if h.calendars.shown[msg] == shown {
+		return false
+	}
+	// Calendar messages are only tracked while they are being flipped through
+	if len(h.calendars.shown) > 1000 {
+		h.calendars.shown = make(map[menuKey]shownCalendar)
+	}
+	h.calendars.shown[msg] = shown
+	return true
+}
@@ -0,0 +1,225 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/korjavin/dutyassistant/internal/service/checklist"
+	"github.com/korjavin/dutyassistant/internal/store"
+	"github.com/korjavin/dutyassistant/internal/telegram/parse"
+)
+
+// checklistCheckAction is the callback action of the checklist buttons. Its
+// arguments are the duty date and the item ID.
+const checklistCheckAction = "check_item"
+
+const (
+	checklistUsageMessage = "📋 <b>Duty checklist</b>\n\n" +
+		"Whoever is on duty ticks off the items with <code>/checklist</code>. " +
+		"A duty is only completed once its mandatory items are done.\n\n" +
+		"<code>/checklist list</code> - list the items\n" +
+		"<code>/checklist add type text</code> - add a mandatory item\n" +
+		"<code>/checklist optional type text</code> - add an optional item\n" +
+		"<code>/checklist del id</code> - delete an item\n\n" +
+		"Type is <code>all</code> or an assignment type: <code>round_robin</code>, <code>voluntary</code>, " +
+		"<code>admin</code> or <code>external</code>.\n\n" +
+		"Example: <code>/checklist add all Load the dishwasher</code>"
+	checklistNotOnDutyMessage = "You're not on duty today, so there is no checklist to tick off."
+	checklistEmptyMessage     = "📋 Today's duty has no checklist."
+)
+
+// HandleChecklist shows today's checklist to whoever is on duty, with a button
+// per item, and lets admins manage the items.
+// Format: /checklist [list | add <type> <text> | optional <type> <text> | del <id>]
+func (h *Handlers) HandleChecklist(m *tgbotapi.Message) (tgbotapi.MessageConfig, error) {
+	ctx := context.Background()
+	args := strings.Fields(m.CommandArguments())
+	if len(args) == 0 {
+		return h.todaysChecklist(ctx, m)
+	}
+
+	isAdmin, err := h.checkAdmin(m.From.ID)
+	if err != nil || !isAdmin {
+		return tgbotapi.NewMessage(m.Chat.ID, adminOnlyMessage), nil
+	}
+	switch {
+	case args[0] == "list" && len(args) == 1:
+		return h.listChecklistItems(ctx, m.Chat.ID)
+	case (args[0] == "add" || args[0] == "optional") && len(args) >= 3:
+		var assignmentType store.AssignmentType
+		if args[1] != "all" {
+			assignmentType = store.AssignmentType(args[1])
+		}
+		item, err := h.Checklist.AddItem(ctx, assignmentType, strings.Join(args[2:], " "), args[0] == "add")
+		if err != nil {
+			return tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("❌ Failed to add the item: %v", err)), nil
+		}
+		return tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("✅ Item #%d added to %s.", item.ID, checklistScope(item))), nil
+	case args[0] == "del" && len(args) == 2:
+		id, err := strconv.ParseInt(strings.TrimPrefix(args[1], "#"), 10, 64)
+		if err != nil {
+			return tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("❌ Invalid item ID: %s", args[1])), nil
+		}
+		if err := h.Checklist.DeleteItem(ctx, id); errors.Is(err, checklist.ErrNotFound) {
+			return tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("❌ There is no item #%d.", id)), nil
+		} else if err != nil {
+			return tgbotapi.NewMessage(m.Chat.ID, genericErrorMessage), nil
+		}
+		return tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("🗑 Item #%d deleted.", id)), nil
+	default:
+		msg := tgbotapi.NewMessage(m.Chat.ID, checklistUsageMessage)
+		msg.ParseMode = tgbotapi.ModeHTML
+		return msg, nil
+	}
+}
+
+// todaysChecklist shows the checklist of today's duty if the sender is on it.
+func (h *Handlers) todaysChecklist(ctx context.Context, m *tgbotapi.Message) (tgbotapi.MessageConfig, error) {
+	user, err := h.Users.ByTelegramID(ctx, m.From.ID)
+	if err != nil {
+		return tgbotapi.NewMessage(m.Chat.ID, volunteerUserNotFoundMessage), nil
+	}
+	duty, err := h.Store.GetDutyByDate(ctx, h.today())
+	if err != nil {
+		log.Printf("[HandleChecklist] Failed to get today's duty: %v", err)
+		return tgbotapi.NewMessage(m.Chat.ID, genericErrorMessage), nil
+	}
+	if duty == nil || duty.UserID != user.ID {
+		return tgbotapi.NewMessage(m.Chat.ID, checklistNotOnDutyMessage), nil
+	}
+
+	entries, err := h.Checklist.ForDuty(ctx, duty)
+	if err != nil {
+		log.Printf("[HandleChecklist] Failed to get the checklist: %v", err)
+		return tgbotapi.NewMessage(m.Chat.ID, genericErrorMessage), nil
+	}
+	if len(entries) == 0 {
+		return tgbotapi.NewMessage(m.Chat.ID, checklistEmptyMessage), nil
+	}
+	msg := tgbotapi.NewMessage(m.Chat.ID, checklistText(duty, entries))
+	msg.ReplyMarkup = checklistKeyboard(duty, entries)
+	return msg, nil
+}
+
+// HandleChecklistCallback checks off or unchecks an item of the checklist of
+// the user's duty.
+func (h *Handlers) HandleChecklistCallback(q *tgbotapi.CallbackQuery) (tgbotapi.EditMessageTextConfig, error) {
+	cb := parse.ParseCallback(q.Data)
+	if err := cb.Expect(2); err != nil {
+		return tgbotapi.EditMessageTextConfig{}, err
+	}
+	date, err := cb.Date(0)
+	if err != nil {
+		return tgbotapi.EditMessageTextConfig{}, err
+	}
+	itemID, err := cb.ID(1)
+	if err != nil {
+		return tgbotapi.EditMessageTextConfig{}, err
+	}
+
+	ctx := context.Background()
+	user, err := h.Users.ByTelegramID(ctx, q.From.ID)
+	if err != nil {
+		return tgbotapi.NewEditMessageText(q.Message.Chat.ID, q.Message.MessageID, "❌ "+volunteerUserNotFoundMessage), nil
+	}
+	duty, entries, err := h.Checklist.Toggle(ctx, date, user.ID, itemID)
+	switch {
+	case errors.Is(err, checklist.ErrNoDuty):
+		return tgbotapi.NewEditMessageText(q.Message.Chat.ID, q.Message.MessageID, checklistNotOnDutyMessage), nil
+	case errors.Is(err, checklist.ErrNotFound):
+		// The item was deleted since the menu was opened, show what is left
+		duty, err = h.Store.GetDutyByDate(ctx, date)
+		if err != nil || duty == nil {
+			return tgbotapi.NewEditMessageText(q.Message.Chat.ID, q.Message.MessageID, genericErrorMessage), nil
+		}
+		if entries, err = h.Checklist.ForDuty(ctx, duty); err != nil {
+			return tgbotapi.NewEditMessageText(q.Message.Chat.ID, q.Message.MessageID, genericErrorMessage), nil
+		}
+	case err != nil:
+		log.Printf("[HandleChecklistCallback] Failed to toggle item %d for user %d: %v", itemID, user.ID, err)
+		return tgbotapi.NewEditMessageText(q.Message.Chat.ID, q.Message.MessageID, genericErrorMessage), nil
+	}
+
+	if len(entries) == 0 {
+		return tgbotapi.NewEditMessageText(q.Message.Chat.ID, q.Message.MessageID, checklistEmptyMessage), nil
+	}
+	edit := tgbotapi.NewEditMessageText(q.Message.Chat.ID, q.Message.MessageID, checklistText(duty, entries))
+	keyboard := checklistKeyboard(duty, entries)
+	edit.ReplyMarkup = &keyboard
+	return edit, nil
+}
+
+// checklistText summarizes a duty's checklist above its buttons.
+func checklistText(duty *store.Duty, entries []checklist.Entry) string {
+	open := 0
+	for _, e := range entries {
+		if e.Item.Mandatory && !e.Checked {
+			open++
+		}
+	}
+	text := fmt.Sprintf("📋 Checklist for %s\n\nTap an item when it's done.", duty.DutyDate.Format("Monday, January 2"))
+	if open == 0 {
+		return text + " All mandatory items are done 🎉"
+	}
+	return text + fmt.Sprintf(" %d mandatory item(s) left.", open)
+}
+
+// checklistKeyboard has a toggle button per checklist item. Optional items
+// are marked as such.
+func checklistKeyboard(duty *store.Duty, entries []checklist.Entry) tgbotapi.InlineKeyboardMarkup {
+	date := duty.DutyDate.Format(parse.DateLayout)
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for _, e := range entries {
+		mark := "⬜"
+		if e.Checked {
+			mark = "✅"
+		}
+		label := fmt.Sprintf("%s %s", mark, e.Item.Text)
+		if !e.Item.Mandatory {
+			label += " (optional)"
+		}
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData(
+			label, fmt.Sprintf("%s:%s:%d", checklistCheckAction, date, e.Item.ID),
+		)))
+	}
+	return tgbotapi.NewInlineKeyboardMarkup(rows...)
+}
+
+// listChecklistItems lists the checklist items with their IDs and scope.
+func (h *Handlers) listChecklistItems(ctx context.Context, chatID int64) (tgbotapi.MessageConfig, error) {
+	items, err := h.Checklist.Items(ctx)
+	if err != nil {
+		return tgbotapi.NewMessage(chatID, genericErrorMessage), nil
+	}
+	if len(items) == 0 {
+		msg := tgbotapi.NewMessage(chatID, "There are no checklist items yet.\n\n"+checklistUsageMessage)
+		msg.ParseMode = tgbotapi.ModeHTML
+		return msg, nil
+	}
+
+	var b strings.Builder
+	b.WriteString("📋 <b>Checklist items</b>\n")
+	for _, item := range items {
+		optional := ""
+		if !item.Mandatory {
+			optional = " (optional)"
+		}
+		fmt.Fprintf(&b, "\n#%d %s, %s%s", item.ID, escapeHTML(item.Text), checklistScope(item), optional)
+	}
+	msg := tgbotapi.NewMessage(chatID, b.String())
+	msg.ParseMode = tgbotapi.ModeHTML
+	return msg, nil
+}
+
+// checklistScope describes which duties an item is on.
+func checklistScope(item *store.ChecklistItem) string {
+	if item.AssignmentType == "" {
+		return "every duty"
+	}
+	return fmt.Sprintf("%s duties", strings.ReplaceAll(string(item.AssignmentType), "_", " "))
+}
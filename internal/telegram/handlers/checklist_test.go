@@ -0,0 +1,106 @@
+package handlers_test
+
+import (
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/korjavin/dutyassistant/internal/store"
+	storemocks "github.com/korjavin/dutyassistant/internal/store/mocks"
+	"github.com/korjavin/dutyassistant/internal/telegram/handlers"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestHandleChecklist_OnDuty(t *testing.T) {
+	mockStore := storemocks.NewMockStore(gomock.NewController(t))
+	h := handlers.New(mockStore, nil)
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	user := &store.User{ID: 1, TelegramUserID: 456}
+
+	mockStore.EXPECT().GetUserByTelegramID(gomock.Any(), int64(456)).Return(user, nil)
+	mockStore.EXPECT().GetDutyByDate(gomock.Any(), today).Return(&store.Duty{UserID: 1, DutyDate: today, AssignmentType: store.AssignmentTypeRoundRobin}, nil)
+	mockStore.EXPECT().ListChecklistItems(gomock.Any()).Return([]*store.ChecklistItem{
+		{ID: 1, Text: "Load the dishwasher", Mandatory: true},
+		{ID: 2, Text: "Wipe the counters"},
+		{ID: 3, AssignmentType: store.AssignmentTypeExternal, Text: "Pay the helper", Mandatory: true},
+	}, nil)
+	mockStore.EXPECT().GetChecklistChecks(gomock.Any(), today).Return([]*store.ChecklistCheck{{Date: today, ItemID: 2}}, nil)
+
+	msg, err := h.HandleChecklist(&tgbotapi.Message{
+		Chat:     &tgbotapi.Chat{ID: 123},
+		From:     &tgbotapi.User{ID: 456},
+		Text:     "/checklist",
+		Entities: []tgbotapi.MessageEntity{{Type: "bot_command", Offset: 0, Length: 10}},
+	})
+	assert.NoError(t, err)
+	assert.Contains(t, msg.Text, "1 mandatory item(s) left")
+	keyboard, ok := msg.ReplyMarkup.(tgbotapi.InlineKeyboardMarkup)
+	if assert.True(t, ok) && assert.Len(t, keyboard.InlineKeyboard, 2, "the external item isn't on a round-robin duty") {
+		assert.Equal(t, "⬜ Load the dishwasher", keyboard.InlineKeyboard[0][0].Text)
+		assert.Equal(t, "check_item:"+today.Format("2006-01-02")+":1", *keyboard.InlineKeyboard[0][0].CallbackData)
+		assert.Equal(t, "✅ Wipe the counters (optional)", keyboard.InlineKeyboard[1][0].Text)
+	}
+}
+
+func TestHandleChecklist_NotOnDuty(t *testing.T) {
+	mockStore := storemocks.NewMockStore(gomock.NewController(t))
+	h := handlers.New(mockStore, nil)
+
+	mockStore.EXPECT().GetUserByTelegramID(gomock.Any(), int64(456)).Return(&store.User{ID: 1, TelegramUserID: 456}, nil)
+	mockStore.EXPECT().GetDutyByDate(gomock.Any(), gomock.Any()).Return(&store.Duty{UserID: 2}, nil)
+
+	msg, err := h.HandleChecklist(&tgbotapi.Message{
+		Chat:     &tgbotapi.Chat{ID: 123},
+		From:     &tgbotapi.User{ID: 456},
+		Text:     "/checklist",
+		Entities: []tgbotapi.MessageEntity{{Type: "bot_command", Offset: 0, Length: 10}},
+	})
+	assert.NoError(t, err)
+	assert.Contains(t, msg.Text, "You're not on duty today")
+}
+
+func TestHandleChecklist_Add(t *testing.T) {
+	mockStore, _, h := setupAdminTest(t)
+
+	mockStore.EXPECT().CreateChecklistItem(gomock.Any(), gomock.Cond(func(item *store.ChecklistItem) bool {
+		return item.Text == "Wipe the counters" && !item.Mandatory && item.AssignmentType == store.AssignmentTypeVoluntary
+	})).DoAndReturn(func(_ any, item *store.ChecklistItem) error {
+		item.ID = 4
+		return nil
+	})
+
+	msg, err := h.HandleChecklist(adminCommand("checklist", "optional voluntary Wipe the counters"))
+	assert.NoError(t, err)
+	assert.Equal(t, "✅ Item #4 added to voluntary duties.", msg.Text)
+
+	msg, err = h.HandleChecklist(adminCommand("checklist", "add weekly Dishes"))
+	assert.NoError(t, err)
+	assert.Contains(t, msg.Text, "unknown assignment type")
+}
+
+func TestHandleChecklistCallback(t *testing.T) {
+	mockStore := storemocks.NewMockStore(gomock.NewController(t))
+	h := handlers.New(mockStore, nil)
+	day := time.Date(2025, 11, 4, 0, 0, 0, 0, time.UTC)
+
+	mockStore.EXPECT().GetUserByTelegramID(gomock.Any(), int64(456)).Return(&store.User{ID: 1, TelegramUserID: 456}, nil)
+	mockStore.EXPECT().GetDutyByDate(gomock.Any(), day).Return(&store.Duty{UserID: 1, DutyDate: day}, nil)
+	mockStore.EXPECT().ListChecklistItems(gomock.Any()).Return([]*store.ChecklistItem{{ID: 1, Text: "Load the dishwasher", Mandatory: true}}, nil)
+	mockStore.EXPECT().GetChecklistChecks(gomock.Any(), day).Return(nil, nil)
+	mockStore.EXPECT().SetChecklistCheck(gomock.Any(), gomock.Cond(func(c *store.ChecklistCheck) bool {
+		return c.Date.Equal(day) && c.ItemID == 1
+	})).Return(nil)
+
+	edit, err := h.HandleChecklistCallback(&tgbotapi.CallbackQuery{
+		From:    &tgbotapi.User{ID: 456},
+		Message: &tgbotapi.Message{MessageID: 7, Chat: &tgbotapi.Chat{ID: 123}},
+		Data:    "check_item:2025-11-04:1",
+	})
+	assert.NoError(t, err)
+	assert.Contains(t, edit.Text, "All mandatory items are done")
+	if assert.NotNil(t, edit.ReplyMarkup) {
+		assert.Equal(t, "✅ Load the dishwasher", edit.ReplyMarkup.InlineKeyboard[0][0].Text)
+	}
+}
@@ -0,0 +1,33 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// HandleCleanup cleans up the menus the bot sent right away, for admins:
+// finished ones are deleted and open ones lose their buttons, whatever their
+// age. The cleanup job does the same once they timed out.
+func (h *Handlers) HandleCleanup(m *tgbotapi.Message) (tgbotapi.MessageConfig, error) {
+	isAdmin, err := h.checkAdmin(m.From.ID)
+	if err != nil || !isAdmin {
+		return tgbotapi.NewMessage(m.Chat.ID, adminOnlyMessage), nil
+	}
+	if h.Cleanup == nil {
+		return tgbotapi.NewMessage(m.Chat.ID, "Message cleanup is not available."), nil
+	}
+
+	result, err := h.Cleanup.Run(context.Background(), true)
+	if err != nil {
+		log.Printf("[HandleCleanup] Cleanup failed: %v", err)
+		return tgbotapi.NewMessage(m.Chat.ID, genericErrorMessage), nil
+	}
+	if result.Deleted == 0 && result.Collapsed == 0 {
+		return tgbotapi.NewMessage(m.Chat.ID, "🧹 Nothing to clean up."), nil
+	}
+	return tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("🧹 Deleted %d finished menu(s) and took the buttons off %d other(s).",
+		result.Deleted, result.Collapsed)), nil
+}
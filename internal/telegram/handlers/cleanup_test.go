@@ -0,0 +1,47 @@
+package handlers_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/korjavin/dutyassistant/internal/service/cleanup"
+	"github.com/korjavin/dutyassistant/internal/store"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+// fakeMessenger counts the messages the cleanup changed.
+type fakeMessenger struct {
+	deleted, collapsed int
+}
+
+func (m *fakeMessenger) DeleteMessage(int64, int) error  { m.deleted++; return nil }
+func (m *fakeMessenger) RemoveKeyboard(int64, int) error { m.collapsed++; return nil }
+
+func TestHandleCleanup(t *testing.T) {
+	mockStore, _, h := setupAdminTest(t)
+
+	msg, err := h.HandleCleanup(adminCommand("cleanup", ""))
+	assert.NoError(t, err)
+	assert.Equal(t, "Message cleanup is not available.", msg.Text)
+
+	messenger := &fakeMessenger{}
+	h.Cleanup = cleanup.New(mockStore, messenger)
+	mockStore.EXPECT().ListInteractiveMessages(gomock.Any()).Return(nil, nil)
+	msg, err = h.HandleCleanup(adminCommand("cleanup", ""))
+	assert.NoError(t, err)
+	assert.Equal(t, "🧹 Nothing to clean up.", msg.Text)
+
+	// Even menus that were just used are cleaned up
+	now := time.Now()
+	mockStore.EXPECT().ListInteractiveMessages(gomock.Any()).Return([]*store.InteractiveMessage{
+		{ChatID: 789, MessageID: 1, SentAt: now, DoneAt: &now},
+		{ChatID: 789, MessageID: 2, SentAt: now},
+	}, nil)
+	mockStore.EXPECT().DeleteInteractiveMessage(gomock.Any(), int64(789), gomock.Any()).Return(nil).Times(2)
+	msg, err = h.HandleCleanup(adminCommand("cleanup", ""))
+	assert.NoError(t, err)
+	assert.Equal(t, "🧹 Deleted 1 finished menu(s) and took the buttons off 1 other(s).", msg.Text)
+	assert.Equal(t, 1, messenger.deleted)
+	assert.Equal(t, 1, messenger.collapsed)
+}
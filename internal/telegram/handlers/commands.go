@@ -4,9 +4,12 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"slices"
+	"strings"
 
-	"github.com/korjavin/dutyassistant/internal/store"
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/korjavin/dutyassistant/internal/service/badge"
+	"github.com/korjavin/dutyassistant/internal/store"
 )
 
 const (
@@ -15,24 +18,15 @@ const (
 		"Use /volunteer to sign up for a duty.\n" +
 		"Use /help to see all available commands."
 
-	helpMessage = "Here are the available commands:\n\n" +
-		"/start - Show the welcome message and register you.\n" +
-		"/help - Show this help message.\n" +
-		"/status - Show your current duty statistics.\n" +
-		"/schedule - View the duty schedule for the current month.\n" +
-		"/volunteer <days> - Add days to your volunteer queue.\n\n" +
-		"*Admin Commands:*\n" +
-		"/assign <username> <days> - Add days to user's admin queue.\n" +
-		"/change <date> <username> - Change assigned user for a date.\n" +
-		"/offduty <username> <start> <end> - Set off-duty period (YYYY-MM-DD).\n" +
-		"/users - List all users and their status.\n" +
-		"/toggle\\_active <username> - Toggle a user's participation in the rotation."
-
 	statusMessage = "<b>Duty Status for %s:</b>\n\n" +
 		"📊 <b>Statistics:</b>\n" +
 		"  • Total duties: %d\n" +
 		"  • This month: %d\n" +
 		"  • Next duty: %s\n\n" +
+		"%s" +
+		"✅ <b>Track record:</b>\n" +
+		"%s\n" +
+		"%s" +
 		"📋 <b>Queues:</b>\n" +
 		"  • Volunteer queue: %d day(s)\n" +
 		"  • Admin queue: %d day(s)\n\n" +
@@ -45,56 +39,61 @@ const (
 func (h *Handlers) HandleStart(m *tgbotapi.Message) (tgbotapi.MessageConfig, error) {
 	log.Printf("[HandleStart] User %d (%s) triggered /start", m.From.ID, m.From.FirstName)
 
-	user, err := h.Store.GetUserByTelegramID(context.Background(), m.From.ID)
-	if err != nil {
-		log.Printf("[HandleStart] Error getting user %d: %v", m.From.ID, err)
-		return tgbotapi.MessageConfig{}, fmt.Errorf("database error: %w", err)
-	}
-
-	if user == nil {
-		// User doesn't exist, create them
-		log.Printf("[HandleStart] User %d not found, creating new user", m.From.ID)
-
-		// Check if this user is the admin
-		isAdmin := h.AdminID != 0 && m.From.ID == h.AdminID
-
-		newUser := &store.User{
-			TelegramUserID: m.From.ID,
-			FirstName:      m.From.FirstName,
-			IsActive:       !isAdmin, // Admin should be inactive by default
-			IsAdmin:        isAdmin,
+	// Admins are registered too, but start out inactive
+	ctx := context.Background()
+	admins, _ := h.adminIDs(ctx)
+	isAdmin := slices.Contains(admins, m.From.ID)
+	// Invitation links start the bot with their token
+	if token := strings.TrimSpace(m.CommandArguments()); token != "" {
+		msg, err := h.startWithInvite(ctx, m, token, isAdmin)
+		if err != nil {
+			log.Printf("[HandleStart] FAILED to register user %d with an invite: %v", m.From.ID, err)
 		}
-		if createErr := h.Store.CreateUser(context.Background(), newUser); createErr != nil {
-			log.Printf("[HandleStart] FAILED to create user %d: %v", m.From.ID, createErr)
-			return tgbotapi.MessageConfig{}, fmt.Errorf("failed to create user: %w", createErr)
-		}
-		log.Printf("[HandleStart] Successfully created user %d with ID %d (IsAdmin=%v, IsActive=%v)", m.From.ID, newUser.ID, newUser.IsAdmin, newUser.IsActive)
-	} else if user.FirstName != m.From.FirstName {
-		// User exists, update their name if it's different
-		log.Printf("[HandleStart] Updating user %d name from '%s' to '%s'", m.From.ID, user.FirstName, m.From.FirstName)
-		user.FirstName = m.From.FirstName
-		if updateErr := h.Store.UpdateUser(context.Background(), user); updateErr != nil {
-			log.Printf("[HandleStart] Failed to update user's first name: %v", updateErr)
+		return msg, err
+	}
+	// New users wait for an admin if approval is required; the admins are
+	// only asked once, not on every /start while they wait
+	pending := !isAdmin && h.requiresApproval(ctx)
+	if pending {
+		if _, err := h.Users.ByTelegramID(ctx, m.From.ID); err == nil {
+			pending = false
 		}
-	} else {
-		log.Printf("[HandleStart] User %d already exists, no changes needed", m.From.ID)
 	}
+	user, err := h.Users.Register(ctx, m.From.ID, m.From.FirstName, isAdmin, pending)
+	if err != nil {
+		log.Printf("[HandleStart] FAILED to register user %d: %v", m.From.ID, err)
+		return tgbotapi.MessageConfig{}, err
+	}
+	log.Printf("[HandleStart] User %d registered with ID %d (IsAdmin=%v, IsActive=%v, IsPending=%v)", m.From.ID, user.ID, user.IsAdmin, user.IsActive, user.IsPending)
 
+	if pending {
+		h.requestApproval(ctx, user)
+	}
+	if user.IsPending {
+		return tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf(startPendingMessage, user.FirstName)), nil
+	}
 	msg := tgbotapi.NewMessage(m.Chat.ID, startMessage)
 	return msg, nil
 }
 
-// HandleHelp provides a list of available commands.
+// HandleHelp lists the commands the sender may use, see Commands.
 func (h *Handlers) HandleHelp(m *tgbotapi.Message) (tgbotapi.MessageConfig, error) {
-	msg := tgbotapi.NewMessage(m.Chat.ID, helpMessage)
+	role := RoleAnyone
+	if m.From != nil {
+		if h.isJunior(m.From.ID) {
+			return tgbotapi.NewMessage(m.Chat.ID, juniorHelpText()), nil
+		}
+		role = h.roleOf(m.From.ID)
+	}
+	msg := tgbotapi.NewMessage(m.Chat.ID, helpText(role))
 	msg.ParseMode = tgbotapi.ModeMarkdown
 	return msg, nil
 }
 
 // HandleStatus fetches and displays the user's duty statistics.
 func (h *Handlers) HandleStatus(m *tgbotapi.Message) (tgbotapi.MessageConfig, error) {
-	user, err := h.Store.GetUserByTelegramID(context.Background(), m.From.ID)
-	if err != nil || user == nil {
+	user, err := h.Users.ByTelegramID(context.Background(), m.From.ID)
+	if err != nil {
 		return tgbotapi.NewMessage(m.Chat.ID, "Could not find your user profile. Please use /start first."), nil
 	}
 
@@ -104,6 +103,18 @@ func (h *Handlers) HandleStatus(m *tgbotapi.Message) (tgbotapi.MessageConfig, er
 		return tgbotapi.NewMessage(m.Chat.ID, genericErrorMessage), nil
 	}
 
+	// The stats are worth showing without the badges
+	badgesText := ""
+	if badges, err := h.Store.ListBadges(context.Background(), user.ID); err != nil {
+		log.Printf("Error getting badges for user %d: %v", user.ID, err)
+	} else if len(badges) > 0 {
+		titles := make([]string, len(badges))
+		for i, b := range badges {
+			titles[i] = badge.Title(b)
+		}
+		badgesText = "🏅 <b>Badges:</b> " + strings.Join(titles, ", ") + "\n\n"
+	}
+
 	nextDuty := stats.NextDutyDate
 	if nextDuty == "" {
 		nextDuty = "Not scheduled"
@@ -122,6 +133,9 @@ func (h *Handlers) HandleStatus(m *tgbotapi.Message) (tgbotapi.MessageConfig, er
 		stats.TotalDuties,
 		stats.DutiesThisMonth,
 		nextDuty,
+		h.forecast(context.Background(), m.Chat.ID, user),
+		formatTrackRecord(stats),
+		badgesText,
 		user.VolunteerQueueDays,
 		user.AdminQueueDays,
 		offDutyText)
@@ -129,4 +143,35 @@ func (h *Handlers) HandleStatus(m *tgbotapi.Message) (tgbotapi.MessageConfig, er
 	msg := tgbotapi.NewMessage(m.Chat.ID, message)
 	msg.ParseMode = tgbotapi.ModeHTML
 	return msg, nil
+}
+
+// formatTrackRecord lists how reliably a user did their duties, compared to
+// the rest of the household.
+func formatTrackRecord(stats *store.UserStats) string {
+	var b strings.Builder
+	finished := stats.Completed + stats.Missed
+	if finished == 0 {
+		b.WriteString("  • Completed: none yet\n")
+	} else {
+		fmt.Fprintf(&b, "  • Completed: %d of %d (%.0f%%)\n", stats.Completed, finished, stats.CompletionRate()*100)
+		fmt.Fprintf(&b, "  • Missed: %d\n", stats.Missed)
+	}
+	if stats.TotalDuties > 0 {
+		fmt.Fprintf(&b, "  • Volunteered: %d of %d (%.0f%%)\n", stats.Volunteered, stats.TotalDuties, stats.VolunteerRatio()*100)
+	}
+	fmt.Fprintf(&b, "  • Streak: %d in a row\n", stats.CurrentStreak)
+	if stats.TasksDone > 0 {
+		fmt.Fprintf(&b, "  • Extra tasks: %d done, worth %d duty day(s)\n", stats.TasksDone, stats.TaskPoints)
+	}
+
+	diff := float64(stats.Completed) - stats.HouseholdAverage
+	switch {
+	case diff >= 0.5:
+		fmt.Fprintf(&b, "  • Household average: %.1f, you're %.1f above\n", stats.HouseholdAverage, diff)
+	case diff <= -0.5:
+		fmt.Fprintf(&b, "  • Household average: %.1f, you're %.1f below\n", stats.HouseholdAverage, -diff)
+	default:
+		fmt.Fprintf(&b, "  • Household average: %.1f, you're right on it\n", stats.HouseholdAverage)
+	}
+	return b.String()
 }
\ No newline at end of file
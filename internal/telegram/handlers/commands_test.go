@@ -3,17 +3,19 @@ package handlers_test
 import (
 	"errors"
 	"testing"
+	"time"
 
-	"github.com/korjavin/dutyassistant/internal/mocks"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	schedulermocks "github.com/korjavin/dutyassistant/internal/scheduler/mocks"
 	"github.com/korjavin/dutyassistant/internal/store"
+	storemocks "github.com/korjavin/dutyassistant/internal/store/mocks"
 	"github.com/korjavin/dutyassistant/internal/telegram/handlers"
-	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	"github.com/stretchr/testify/assert"
-	"github.com/stretchr/testify/mock"
+	"go.uber.org/mock/gomock"
 )
 
 func TestHandleStart_NewUser(t *testing.T) {
-	mockStore := new(mocks.MockStore)
+	mockStore := storemocks.NewMockStore(gomock.NewController(t))
 	h := handlers.New(mockStore, nil)
 
 	message := &tgbotapi.Message{
@@ -21,19 +23,18 @@ func TestHandleStart_NewUser(t *testing.T) {
 		From: &tgbotapi.User{ID: 456, FirstName: "NewUser"},
 	}
 
-	mockStore.On("GetUserByTelegramID", mock.Anything, int64(456)).Return(nil, errors.New("not found"))
-	mockStore.On("CreateUser", mock.Anything, mock.MatchedBy(func(u *store.User) bool {
-		return u.TelegramUserID == 456 && u.FirstName == "NewUser"
+	mockStore.EXPECT().GetUserByTelegramID(gomock.Any(), int64(456)).Return(nil, nil)
+	mockStore.EXPECT().CreateUser(gomock.Any(), gomock.Cond(func(u *store.User) bool {
+		return u.TelegramUserID == 456 && u.FirstName == "NewUser" && u.IsActive
 	})).Return(nil)
 
 	msg, err := h.HandleStart(message)
 	assert.NoError(t, err)
 	assert.Contains(t, msg.Text, "Welcome to the Roster Bot!")
-	mockStore.AssertExpectations(t)
 }
 
 func TestHandleStart_ExistingUser(t *testing.T) {
-	mockStore := new(mocks.MockStore)
+	mockStore := storemocks.NewMockStore(gomock.NewController(t))
 	h := handlers.New(mockStore, nil)
 
 	message := &tgbotapi.Message{
@@ -42,15 +43,47 @@ func TestHandleStart_ExistingUser(t *testing.T) {
 	}
 
 	existingUser := &store.User{ID: 1, TelegramUserID: 456, FirstName: "OldName"}
-	mockStore.On("GetUserByTelegramID", mock.Anything, int64(456)).Return(existingUser, nil)
-	mockStore.On("UpdateUser", mock.Anything, mock.MatchedBy(func(u *store.User) bool {
+	mockStore.EXPECT().GetUserByTelegramID(gomock.Any(), int64(456)).Return(existingUser, nil)
+	mockStore.EXPECT().UpdateUser(gomock.Any(), gomock.Cond(func(u *store.User) bool {
 		return u.ID == 1 && u.FirstName == "UpdatedName"
 	})).Return(nil)
 
 	msg, err := h.HandleStart(message)
 	assert.NoError(t, err)
 	assert.Contains(t, msg.Text, "Welcome to the Roster Bot!")
-	mockStore.AssertExpectations(t)
+}
+
+func TestHandleStart_KeepsCustomName(t *testing.T) {
+	mockStore := storemocks.NewMockStore(gomock.NewController(t))
+	h := handlers.New(mockStore, nil)
+
+	message := &tgbotapi.Message{
+		Chat: &tgbotapi.Chat{ID: 123},
+		From: &tgbotapi.User{ID: 456, FirstName: "UpdatedName"},
+	}
+
+	// Renamed by an admin, so no UpdateUser call is expected
+	existingUser := &store.User{ID: 1, TelegramUserID: 456, FirstName: "Grandma", CustomName: true}
+	mockStore.EXPECT().GetUserByTelegramID(gomock.Any(), int64(456)).Return(existingUser, nil)
+
+	msg, err := h.HandleStart(message)
+	assert.NoError(t, err)
+	assert.Contains(t, msg.Text, "Welcome to the Roster Bot!")
+}
+
+func TestHandleStart_DatabaseError(t *testing.T) {
+	mockStore := storemocks.NewMockStore(gomock.NewController(t))
+	h := handlers.New(mockStore, nil)
+
+	message := &tgbotapi.Message{
+		Chat: &tgbotapi.Chat{ID: 123},
+		From: &tgbotapi.User{ID: 456, FirstName: "NewUser"},
+	}
+
+	mockStore.EXPECT().GetUserByTelegramID(gomock.Any(), int64(456)).Return(nil, errors.New("db error"))
+
+	_, err := h.HandleStart(message)
+	assert.Error(t, err)
 }
 
 func TestHandleHelp(t *testing.T) {
@@ -63,8 +96,49 @@ func TestHandleHelp(t *testing.T) {
 	assert.Equal(t, tgbotapi.ModeMarkdown, msg.ParseMode)
 }
 
+func TestHandleHelp_Junior(t *testing.T) {
+	mockStore := storemocks.NewMockStore(gomock.NewController(t))
+	h := handlers.New(mockStore, nil)
+	mockStore.EXPECT().GetUserByTelegramID(gomock.Any(), int64(456)).Return(&store.User{ID: 2, TelegramUserID: 456, IsJunior: true}, nil)
+
+	msg, err := h.HandleHelp(&tgbotapi.Message{Chat: &tgbotapi.Chat{ID: 123}, From: &tgbotapi.User{ID: 456}})
+	assert.NoError(t, err)
+	assert.Contains(t, msg.Text, "/checklist")
+	assert.NotContains(t, msg.Text, "/volunteer")
+	assert.NotContains(t, msg.Text, "Admin")
+}
+
+func TestHandleMe(t *testing.T) {
+	mockStore := storemocks.NewMockStore(gomock.NewController(t))
+	h := handlers.New(mockStore, nil)
+	alice := &store.User{ID: 2, TelegramUserID: 456, FirstName: "Alice"}
+	bob := &store.User{ID: 3, TelegramUserID: 789, FirstName: "Bob", Emoji: "🐻"}
+	mockStore.EXPECT().GetUserByTelegramID(gomock.Any(), int64(456)).Return(alice, nil).AnyTimes()
+	mockStore.EXPECT().ListAllUsers(gomock.Any()).Return([]*store.User{alice, bob}, nil).AnyTimes()
+	me := func(args string) string {
+		msg, err := h.HandleMe(&tgbotapi.Message{
+			Text:     "/me " + args,
+			Entities: []tgbotapi.MessageEntity{{Type: "bot_command", Offset: 0, Length: 3}},
+			Chat:     &tgbotapi.Chat{ID: 123},
+			From:     &tgbotapi.User{ID: 456},
+		})
+		assert.NoError(t, err)
+		return msg.Text
+	}
+
+	assert.Contains(t, me(""), "Emoji: none yet")
+	assert.Equal(t, "❌ abc isn't a single emoji. Try something like /me emoji 🦊", me("emoji abc"))
+	assert.Equal(t, "❌ Someone else already picked 🐻, choose another one.", me("emoji 🐻"))
+
+	mockStore.EXPECT().UpdateUser(gomock.Any(), alice).Return(nil).Times(2)
+	assert.Equal(t, "✅ 🦊 now marks your days.", me("emoji 🦊"))
+	assert.Equal(t, "🦊", alice.Emoji)
+	assert.Equal(t, "✅ Your emoji is removed, the calendar shows a number for you again.", me("emoji off"))
+	assert.Empty(t, alice.Emoji)
+}
+
 func TestHandleStatus_Success(t *testing.T) {
-	mockStore := new(mocks.MockStore)
+	mockStore := storemocks.NewMockStore(gomock.NewController(t))
 	h := handlers.New(mockStore, nil)
 
 	message := &tgbotapi.Message{
@@ -72,21 +146,63 @@ func TestHandleStatus_Success(t *testing.T) {
 		From: &tgbotapi.User{ID: 456, FirstName: "TestUser"},
 	}
 
+	user := &store.User{ID: 1, TelegramUserID: 456, VolunteerQueueDays: 3}
+	stats := &store.UserStats{TotalDuties: 5, DutiesThisMonth: 2, NextDutyDate: "2023-12-31",
+		Completed: 3, Missed: 1, Volunteered: 2, CurrentStreak: 2, HouseholdAverage: 2}
+
+	mockStore.EXPECT().GetUserByTelegramID(gomock.Any(), int64(456)).Return(user, nil)
+	mockStore.EXPECT().GetUserStats(gomock.Any(), user.ID).Return(stats, nil)
+	mockStore.EXPECT().ListBadges(gomock.Any(), user.ID).Return([]*store.Badge{
+		{UserID: user.ID, Kind: store.BadgeFirstVolunteer},
+		{UserID: user.ID, Kind: store.BadgePerfectMonth, Period: "2023-11"},
+	}, nil)
+
+	msg, err := h.HandleStatus(message)
+	assert.NoError(t, err)
+	assert.Contains(t, msg.Text, "Total duties: 5")
+	assert.Contains(t, msg.Text, "Next duty: 2023-12-31")
+	assert.Contains(t, msg.Text, "Volunteer queue: 3 day(s)")
+	assert.Contains(t, msg.Text, "Completed: 3 of 4 (75%)")
+	assert.Contains(t, msg.Text, "Volunteered: 2 of 5 (40%)")
+	assert.Contains(t, msg.Text, "Streak: 2 in a row")
+	assert.Contains(t, msg.Text, "Household average: 2.0, you're 1.0 above")
+	assert.Contains(t, msg.Text, "Badges:</b> 🙋 First volunteer, 🌟 Perfect month (November 2023)")
+}
+
+func TestHandleStatus_Forecast(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockStore := storemocks.NewMockStore(ctrl)
+	mockScheduler := schedulermocks.NewMockSchedulerInterface(ctrl)
+	h := handlers.New(mockStore, mockScheduler)
+
+	message := &tgbotapi.Message{
+		Chat: &tgbotapi.Chat{ID: 123},
+		From: &tgbotapi.User{ID: 456, FirstName: "TestUser"},
+	}
 	user := &store.User{ID: 1, TelegramUserID: 456}
-	stats := &store.UserStats{TotalDuties: 5, DutiesThisMonth: 2, NextDutyDate: "2023-12-31"}
+	day := func(d int) time.Time { return time.Date(2025, 11, d, 0, 0, 0, 0, time.UTC) }
 
-	mockStore.On("GetUserByTelegramID", mock.Anything, int64(456)).Return(user, nil)
-	mockStore.On("GetUserStats", mock.Anything, user.ID).Return(stats, nil)
+	mockStore.EXPECT().GetUserByTelegramID(gomock.Any(), int64(456)).Return(user, nil)
+	mockStore.EXPECT().GetUserStats(gomock.Any(), user.ID).Return(&store.UserStats{NextDutyDate: "2025-11-03"}, nil)
+	mockStore.EXPECT().ListBadges(gomock.Any(), user.ID).Return(nil, nil)
+	mockStore.EXPECT().GetChatLocale(gomock.Any(), int64(123)).Return("", nil)
+	// The real duty is the next duty, someone else's days aren't listed
+	mockScheduler.EXPECT().Forecast(gomock.Any(), gomock.Any()).Return([]*store.Duty{
+		{UserID: 1, DutyDate: day(3), Status: store.DutyStatusAnnounced},
+		{UserID: 2, DutyDate: day(4), Status: store.DutyStatusProvisional},
+		{UserID: 1, DutyDate: day(5), Status: store.DutyStatusProvisional},
+		{UserID: 1, DutyDate: day(7), Status: store.DutyStatusProvisional},
+		{UserID: 1, DutyDate: day(9), Status: store.DutyStatusProvisional},
+		{UserID: 1, DutyDate: day(11), Status: store.DutyStatusProvisional},
+	}, nil)
 
 	msg, err := h.HandleStatus(message)
 	assert.NoError(t, err)
-	assert.Contains(t, msg.Text, "Total Duties Assigned: 5")
-	assert.Contains(t, msg.Text, "Next scheduled duty: 2023-12-31")
-	mockStore.AssertExpectations(t)
+	assert.Contains(t, msg.Text, "Next duties (estimated):</b> Wed, Nov 5 · Fri, Nov 7 · Sun, Nov 9\n")
 }
 
 func TestHandleStatus_UserNotFound(t *testing.T) {
-	mockStore := new(mocks.MockStore)
+	mockStore := storemocks.NewMockStore(gomock.NewController(t))
 	h := handlers.New(mockStore, nil)
 
 	message := &tgbotapi.Message{
@@ -94,10 +210,62 @@ func TestHandleStatus_UserNotFound(t *testing.T) {
 		From: &tgbotapi.User{ID: 456},
 	}
 
-	mockStore.On("GetUserByTelegramID", mock.Anything, int64(456)).Return(nil, nil) // Return nil user
+	mockStore.EXPECT().GetUserByTelegramID(gomock.Any(), int64(456)).Return(nil, nil) // Return nil user
 
 	msg, err := h.HandleStatus(message)
 	assert.NoError(t, err)
 	assert.Equal(t, "Could not find your user profile. Please use /start first.", msg.Text)
-	mockStore.AssertExpectations(t)
-}
\ No newline at end of file
+}
+
+func TestHandleSubscribe(t *testing.T) {
+	mockStore := storemocks.NewMockStore(gomock.NewController(t))
+	h := handlers.New(mockStore, nil)
+
+	message := &tgbotapi.Message{
+		Chat: &tgbotapi.Chat{ID: 123},
+		From: &tgbotapi.User{ID: 456},
+	}
+	user := &store.User{ID: 1, TelegramUserID: 456}
+
+	mockStore.EXPECT().GetUserByTelegramID(gomock.Any(), int64(456)).Return(user, nil).Times(2)
+	mockStore.EXPECT().CreateChangeSubscription(gomock.Any(), gomock.Cond(func(sub *store.ChangeSubscription) bool {
+		return sub.UserID == user.ID
+	})).Return(nil)
+	mockStore.EXPECT().DeleteChangeSubscription(gomock.Any(), user.ID).Return(nil)
+
+	msg, err := h.HandleSubscribe(message)
+	assert.NoError(t, err)
+	assert.Contains(t, msg.Text, "whenever one of your days is assigned, moved or released")
+
+	msg, err = h.HandleUnsubscribe(message)
+	assert.NoError(t, err)
+	assert.Contains(t, msg.Text, "won't get messages about changes to your days")
+}
+
+func TestHandleLogin(t *testing.T) {
+	mockStore := storemocks.NewMockStore(gomock.NewController(t))
+	h := handlers.New(mockStore, nil)
+	h.WebURL = "https://duty.example.com"
+	user := &store.User{ID: 1, TelegramUserID: 456}
+
+	group := &tgbotapi.Message{Chat: &tgbotapi.Chat{ID: -100, Type: "group"}, From: &tgbotapi.User{ID: 456}}
+	msg, err := h.HandleLogin(group)
+	assert.NoError(t, err)
+	assert.Contains(t, msg.Text, "only sent in a private chat")
+
+	mockStore.EXPECT().GetUserByTelegramID(gomock.Any(), int64(456)).Return(user, nil)
+	mockStore.EXPECT().DeleteExpiredLogins(gomock.Any(), gomock.Any()).Return(nil)
+	mockStore.EXPECT().CreateLoginCode(gomock.Any(), gomock.Cond(func(code *store.LoginCode) bool {
+		return code.UserID == user.ID && code.CodeHash != ""
+	})).Return(nil)
+
+	private := &tgbotapi.Message{Chat: &tgbotapi.Chat{ID: 456, Type: "private"}, From: &tgbotapi.User{ID: 456}}
+	msg, err = h.HandleLogin(private)
+	assert.NoError(t, err)
+	assert.Contains(t, msg.Text, "https://duty.example.com/login?code=")
+
+	h.WebURL = ""
+	msg, err = h.HandleLogin(private)
+	assert.NoError(t, err)
+	assert.Contains(t, msg.Text, "isn't set up")
+}
@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/korjavin/dutyassistant/internal/telegram/parse"
+)
+
+const completeUsageMessage = "✅ <b>Mark a duty as done</b>\n\n" +
+	"Usage: <code>/complete date</code> or <code>/uncomplete date</code>\n\n" +
+	"Example: <code>/complete 2025-10-20</code>\n\n" +
+	"Use it for today or a past day when the 21:00 check got it wrong. " +
+	"An uncompleted past duty counts as missed."
+
+// HandleComplete marks the duty of a day as done for admins. Format: /complete <date>
+func (h *Handlers) HandleComplete(m *tgbotapi.Message) (tgbotapi.MessageConfig, error) {
+	return h.setCompletion(m, true)
+}
+
+// HandleUncomplete takes back the completion of a day's duty for admins. Format: /uncomplete <date>
+func (h *Handlers) HandleUncomplete(m *tgbotapi.Message) (tgbotapi.MessageConfig, error) {
+	return h.setCompletion(m, false)
+}
+
+func (h *Handlers) setCompletion(m *tgbotapi.Message, completed bool) (tgbotapi.MessageConfig, error) {
+	isAdmin, err := h.checkAdmin(m.From.ID)
+	if err != nil || !isAdmin {
+		return tgbotapi.NewMessage(m.Chat.ID, adminOnlyMessage), nil
+	}
+
+	args := strings.Fields(m.CommandArguments())
+	if len(args) != 1 {
+		msg := tgbotapi.NewMessage(m.Chat.ID, completeUsageMessage)
+		msg.ParseMode = tgbotapi.ModeHTML
+		return msg, nil
+	}
+
+	date, err := parse.Date(args[0])
+	if err != nil {
+		return tgbotapi.NewMessage(m.Chat.ID, invalidDateMessage), nil
+	}
+
+	ctx := context.Background()
+	// The configured admin may not have run /start yet, then nobody is recorded
+	actor, _ := h.Users.ByTelegramID(ctx, m.From.ID)
+
+	dateStr := date.Format(parse.DateLayout)
+	duty, err := h.Duties.SetCompletion(ctx, date, completed, actor)
+	if err != nil {
+		return tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("❌ Failed to update %s: %v", dateStr, err)), nil
+	}
+	name := "the user on duty"
+	if duty.User != nil {
+		name = duty.User.FirstName
+	}
+	if completed {
+		return tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("✅ The duty of %s on %s is marked as done.", name, dateStr)), nil
+	}
+	return tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("↩️ The duty of %s on %s is no longer marked as done.", name, dateStr)), nil
+}
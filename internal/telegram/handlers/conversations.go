@@ -0,0 +1,153 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/korjavin/dutyassistant/internal/service/offduty"
+	"github.com/korjavin/dutyassistant/internal/store"
+	"github.com/korjavin/dutyassistant/internal/telegram/parse"
+)
+
+// Key prefixes of the conversation states, see RestoreConversations.
+const (
+	menuStatePrefix    = "menu:"           // menu:<chat ID>:<message ID>
+	importStatePrefix  = "offduty_import:" // offduty_import:<import ID>
+	handoffStatePrefix = "handoff:"        // handoff:<chat ID>:<Telegram user ID>
+)
+
+// menuState is who opened a menu, as stored.
+type menuState struct {
+	TelegramUserID int64     `json:"telegram_user_id"`
+	BoundAt        time.Time `json:"bound_at"`
+}
+
+// importState is an import waiting to be confirmed, as stored.
+type importState struct {
+	UserIDs   []int64          `json:"user_ids"`
+	Periods   []offduty.Period `json:"periods"`
+	CreatedAt time.Time        `json:"created_at"`
+}
+
+// handoffState is a /handoff prompt waiting for its reply, as stored. It
+// expires with the state.
+type handoffState struct {
+	Date string `json:"date"` // Of the duty, YYYY-MM-DD
+}
+
+func menuStateKey(chatID int64, messageID int) string {
+	return fmt.Sprintf("%s%d:%d", menuStatePrefix, chatID, messageID)
+}
+
+func importStateKey(id int) string {
+	return importStatePrefix + strconv.Itoa(id)
+}
+
+func handoffStateKey(key handoffKey) string {
+	return fmt.Sprintf("%s%d:%d", handoffStatePrefix, key.chatID, key.telegramUserID)
+}
+
+// saveState stores the state of a conversation until it expires, so it
+// survives a restart. Failing to is only logged, the conversation goes on
+// anyway until the next restart.
+func (h *Handlers) saveState(ctx context.Context, key string, value any, expiresAt time.Time) {
+	encoded, err := json.Marshal(value)
+	if err == nil {
+		err = h.Store.SaveConversationState(ctx, &store.ConversationState{Key: key, Value: string(encoded), ExpiresAt: expiresAt})
+	}
+	if err != nil {
+		log.Printf("[CONVERSATION] Failed to save %s: %v", key, err)
+	}
+}
+
+// deleteState forgets the state of a conversation that ended.
+func (h *Handlers) deleteState(ctx context.Context, key string) {
+	if err := h.Store.DeleteConversationState(ctx, key); err != nil {
+		log.Printf("[CONVERSATION] Failed to delete %s: %v", key, err)
+	}
+}
+
+// RestoreConversations picks up the conversations that were going on before
+// a restart: who opened which menu, the /offduty_import previews waiting to
+// be confirmed and the /handoff prompts waiting for a reply. Expired ones are deleted, and states that can't be read
+// anymore are skipped.
+func (h *Handlers) RestoreConversations(ctx context.Context) error {
+	now := time.Now()
+	if err := h.Store.DeleteExpiredConversationStates(ctx, now); err != nil {
+		return fmt.Errorf("failed to delete expired conversations: %w", err)
+	}
+	states, err := h.Store.ListConversationStates(ctx, now)
+	if err != nil {
+		return fmt.Errorf("failed to list conversations: %w", err)
+	}
+
+	restored := 0
+	for _, state := range states {
+		if err := h.restoreState(ctx, state); err != nil {
+			log.Printf("[CONVERSATION] Skipping %s: %v", state.Key, err)
+			continue
+		}
+		restored++
+	}
+	log.Printf("[CONVERSATION] Restored %d conversation(s)", restored)
+	return nil
+}
+
+func (h *Handlers) restoreState(ctx context.Context, state *store.ConversationState) error {
+	switch {
+	case strings.HasPrefix(state.Key, menuStatePrefix):
+		var chatID int64
+		var messageID int
+		if _, err := fmt.Sscanf(strings.TrimPrefix(state.Key, menuStatePrefix), "%d:%d", &chatID, &messageID); err != nil {
+			return fmt.Errorf("invalid key: %w", err)
+		}
+		var menu menuState
+		if err := json.Unmarshal([]byte(state.Value), &menu); err != nil {
+			return err
+		}
+		h.menus.bind(menuKey{chatID, messageID}, menuOwner{telegramUserID: menu.TelegramUserID, boundAt: menu.BoundAt})
+
+	case strings.HasPrefix(state.Key, importStatePrefix):
+		id, err := strconv.Atoi(strings.TrimPrefix(state.Key, importStatePrefix))
+		if err != nil {
+			return fmt.Errorf("invalid key: %w", err)
+		}
+		var imp importState
+		if err := json.Unmarshal([]byte(state.Value), &imp); err != nil {
+			return err
+		}
+		users := make([]*store.User, 0, len(imp.UserIDs))
+		for _, userID := range imp.UserIDs {
+			u, err := h.Users.ByID(ctx, userID)
+			if err != nil {
+				return err
+			}
+			users = append(users, u)
+		}
+		h.imports.restore(id, &offDutyImport{users: users, periods: imp.Periods, createdAt: imp.CreatedAt})
+
+	case strings.HasPrefix(state.Key, handoffStatePrefix):
+		var key handoffKey
+		if _, err := fmt.Sscanf(strings.TrimPrefix(state.Key, handoffStatePrefix), "%d:%d", &key.chatID, &key.telegramUserID); err != nil {
+			return fmt.Errorf("invalid key: %w", err)
+		}
+		var handoff handoffState
+		if err := json.Unmarshal([]byte(state.Value), &handoff); err != nil {
+			return err
+		}
+		date, err := parse.Date(handoff.Date)
+		if err != nil {
+			return err
+		}
+		h.handoffs.add(key, handoffPrompt{date: date, expiresAt: state.ExpiresAt})
+
+	default:
+		return fmt.Errorf("unknown kind of conversation")
+	}
+	return nil
+}
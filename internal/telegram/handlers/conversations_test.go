@@ -0,0 +1,72 @@
+package handlers_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/korjavin/dutyassistant/internal/scheduler"
+	"github.com/korjavin/dutyassistant/internal/store"
+	"github.com/korjavin/dutyassistant/internal/store/memory"
+	"github.com/korjavin/dutyassistant/internal/telegram/handlers"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRestoreConversations(t *testing.T) {
+	ctx := context.Background()
+	s := memory.New()
+	before := handlers.New(s, scheduler.NewScheduler(s))
+	if err := s.CreateUser(ctx, &store.User{TelegramUserID: 123, FirstName: "Admin", IsAdmin: true}); err != nil {
+		t.Fatal(err)
+	}
+	alice := &store.User{TelegramUserID: 456, FirstName: "Alice", IsActive: true}
+	if err := s.CreateUser(ctx, alice); err != nil {
+		t.Fatal(err)
+	}
+	year := time.Now().Year() + 1
+
+	// A menu and an import preview are open when the bot restarts
+	before.BindMenu(789, 5, 456)
+	msg, err := before.HandleOffDutyImport(adminCommand("offduty_import", fmt.Sprintf("Alice\n%d-03-02 %d-03-06", year, year)))
+	assert.NoError(t, err)
+	confirm := *msg.ReplyMarkup.(tgbotapi.InlineKeyboardMarkup).InlineKeyboard[0][0].CallbackData
+	// and a conversation from long ago is left over
+	assert.NoError(t, s.SaveConversationState(ctx, &store.ConversationState{Key: "menu:789:1", Value: `{}`, ExpiresAt: time.Now().Add(-time.Hour)}))
+
+	h := handlers.New(s, scheduler.NewScheduler(s))
+	assert.NoError(t, h.RestoreConversations(ctx))
+
+	refusal := h.AuthorizeCallback(&tgbotapi.CallbackQuery{
+		From:    &tgbotapi.User{ID: 123},
+		Message: &tgbotapi.Message{MessageID: 5, Chat: &tgbotapi.Chat{ID: 789}},
+		Data:    "volunteer_days:3",
+	})
+	if assert.NotNil(t, refusal, "the menu is still Alice's") {
+		assert.Contains(t, refusal.(tgbotapi.MessageConfig).Text, "opened by someone else")
+	}
+
+	// A new import doesn't take the ID of the restored one
+	msg, _ = h.HandleOffDutyImport(adminCommand("offduty_import", fmt.Sprintf("Alice\n%d-05-01", year)))
+	assert.NotEqual(t, confirm, *msg.ReplyMarkup.(tgbotapi.InlineKeyboardMarkup).InlineKeyboard[0][0].CallbackData)
+
+	edit, err := h.HandleOffDutyImportCallback(&tgbotapi.CallbackQuery{
+		From:    &tgbotapi.User{ID: 123},
+		Message: &tgbotapi.Message{MessageID: 8, Chat: &tgbotapi.Chat{ID: 789}},
+		Data:    confirm,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "✅ Imported 1 off-duty period(s) for 1 user(s).", edit.Text)
+	off, _ := s.IsUserOffDuty(ctx, alice.ID, time.Date(year, time.March, 4, 0, 0, 0, 0, time.UTC))
+	assert.True(t, off)
+
+	// Confirmed, the import is forgotten, and so is the expired menu
+	states, err := s.ListConversationStates(ctx, time.Now().Add(-24*time.Hour))
+	assert.NoError(t, err)
+	var keys []string
+	for _, state := range states {
+		keys = append(keys, state.Key)
+	}
+	assert.Equal(t, []string{"menu:789:5", "offduty_import:2"}, keys)
+}
@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/korjavin/dutyassistant/internal/notification"
+	"github.com/korjavin/dutyassistant/internal/store"
+	"github.com/korjavin/dutyassistant/internal/telegram/parse"
+)
+
+// HandleCorrectionCallback handles the buttons under the announcement of
+// today's duty, with which an admin corrects the daily assignment: reassign
+// sends the user picker of /modify for the day, skip leaves the day without
+// duty and says so in the announcement.
+// Format: today_reassign:<date> or today_skip:<date>
+func (h *Handlers) HandleCorrectionCallback(q *tgbotapi.CallbackQuery) (tgbotapi.Chattable, error) {
+	cb := parse.ParseCallback(q.Data)
+	if err := cb.Expect(1); err != nil {
+		return nil, err
+	}
+	date, err := cb.Date(0)
+	if err != nil {
+		return nil, err
+	}
+	chatID := q.Message.Chat.ID
+	// Refuse with a message of its own, the announcement is the group's
+	if isAdmin, err := h.checkAdmin(q.From.ID); err != nil || !isAdmin {
+		return tgbotapi.NewMessage(chatID, adminOnlyMessage), nil
+	}
+
+	ctx := context.Background()
+	dateStr := date.Format(parse.DateLayout)
+	duty, err := h.Store.GetDutyByDate(ctx, date)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the duty on %s: %w", dateStr, err)
+	}
+	if duty == nil {
+		return tgbotapi.NewMessage(chatID, fmt.Sprintf("There is no duty on %s anymore.", dateStr)), nil
+	}
+	if duty.CompletedAt != nil {
+		return tgbotapi.NewMessage(chatID, fmt.Sprintf("The duty on %s is done already.", dateStr)), nil
+	}
+
+	switch cb.Action {
+	case notification.ReassignTodayAction:
+		text, keyboard, err := h.userPicker(ctx, "modify_user:"+dateStr, 0)
+		if err != nil {
+			return tgbotapi.NewMessage(chatID, "❌ No active users found."), nil
+		}
+		msg := tgbotapi.NewMessage(chatID, text)
+		msg.ParseMode = tgbotapi.ModeHTML
+		msg.ReplyMarkup = keyboard
+		msg.ReplyToMessageID = q.Message.MessageID
+		return msg, nil
+
+	case notification.SkipTodayAction:
+		if err := h.Scheduler.SkipDay(ctx, date, store.SkipReasonAway); err != nil {
+			return tgbotapi.NewMessage(chatID, fmt.Sprintf("❌ Failed to skip %s: %v", dateStr, err)), nil
+		}
+		// The buttons go, the announcement stays with the correction under it
+		return tgbotapi.NewEditMessageText(chatID, q.Message.MessageID,
+			fmt.Sprintf("%s\n\n⏭ Skipped by %s, there is no duty on %s.", q.Message.Text, q.From.FirstName, dateStr)), nil
+	}
+	return nil, parse.ErrInvalidCallback
+}
@@ -0,0 +1,66 @@
+package handlers_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/korjavin/dutyassistant/internal/notification"
+	"github.com/korjavin/dutyassistant/internal/scheduler"
+	"github.com/korjavin/dutyassistant/internal/store"
+	"github.com/korjavin/dutyassistant/internal/store/memory"
+	"github.com/korjavin/dutyassistant/internal/telegram/handlers"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandleCorrectionCallback(t *testing.T) {
+	ctx := context.Background()
+	s := memory.New()
+	h := handlers.New(s, scheduler.NewScheduler(s))
+	admin := &store.User{TelegramUserID: 123, FirstName: "Alice", IsActive: true, IsAdmin: true}
+	bob := &store.User{TelegramUserID: 456, FirstName: "Bob", IsActive: true}
+	for _, u := range []*store.User{admin, bob} {
+		if err := s.CreateUser(ctx, u); err != nil {
+			t.Fatal(err)
+		}
+	}
+	today := scheduler.Today(time.Now(), 0)
+	if err := s.CreateDuty(ctx, &store.Duty{UserID: bob.ID, DutyDate: today, AssignmentType: store.AssignmentTypeRoundRobin, CreatedAt: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+	press := func(telegramUserID int64, action string) tgbotapi.Chattable {
+		response, err := h.HandleCorrectionCallback(&tgbotapi.CallbackQuery{
+			From:    &tgbotapi.User{ID: telegramUserID, FirstName: "Alice"},
+			Message: &tgbotapi.Message{MessageID: 1, Chat: &tgbotapi.Chat{ID: 789}, Text: "@Bob is on duty today!"},
+			Data:    action + ":" + today.Format("2006-01-02"),
+		})
+		assert.NoError(t, err)
+		return response
+	}
+
+	// The announcement isn't replaced by the refusal
+	refusal, ok := press(456, notification.SkipTodayAction).(tgbotapi.MessageConfig)
+	if assert.True(t, ok) {
+		assert.Contains(t, refusal.Text, "admin")
+	}
+
+	picker, ok := press(123, notification.ReassignTodayAction).(tgbotapi.MessageConfig)
+	if assert.True(t, ok, "the picker is sent under the announcement") {
+		assert.Equal(t, 1, picker.ReplyToMessageID)
+		if markup, ok := picker.ReplyMarkup.(tgbotapi.InlineKeyboardMarkup); assert.True(t, ok) {
+			assert.Contains(t, *markup.InlineKeyboard[0][0].CallbackData, "modify_user:"+today.Format("2006-01-02"))
+		}
+	}
+
+	edit, ok := press(123, notification.SkipTodayAction).(tgbotapi.EditMessageTextConfig)
+	if assert.True(t, ok, "the announcement is updated") {
+		assert.Equal(t, "@Bob is on duty today!\n\n⏭ Skipped by Alice, there is no duty on "+today.Format("2006-01-02")+".", edit.Text)
+	}
+	d, _ := s.GetDutyByDate(ctx, today)
+	assert.Nil(t, d)
+	msg, ok := press(123, notification.ReassignTodayAction).(tgbotapi.MessageConfig)
+	if assert.True(t, ok) {
+		assert.Contains(t, msg.Text, "no duty")
+	}
+}
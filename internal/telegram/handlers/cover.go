@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/korjavin/dutyassistant/internal/notification"
+	"github.com/korjavin/dutyassistant/internal/service/cover"
+	"github.com/korjavin/dutyassistant/internal/service/duty"
+	"github.com/korjavin/dutyassistant/internal/telegram/parse"
+)
+
+const coverUsageMessage = "🆘 <b>Ask for cover</b>\n\n" +
+	"Usage: <code>/cover [date]</code>\n\n" +
+	"Asks the group who takes over the duty of today, or of the date (YYYY-MM-DD), at short notice. " +
+	"If nobody does in time, the fallback person of <code>/settings cover</code> takes over and whoever was on duty owes a day."
+
+// HandleCover asks the group who takes over a duty at short notice, for
+// whoever is on it or an admin. If nobody claims it within the wait of the
+// settings, the fallback person does.
+// Format: /cover [date]
+func (h *Handlers) HandleCover(m *tgbotapi.Message) (tgbotapi.MessageConfig, error) {
+	args := strings.Fields(m.CommandArguments())
+	if len(args) > 1 {
+		msg := tgbotapi.NewMessage(m.Chat.ID, coverUsageMessage)
+		msg.ParseMode = tgbotapi.ModeHTML
+		return msg, nil
+	}
+	date := h.today()
+	if len(args) == 1 {
+		var err error
+		if date, err = parse.Date(args[0]); err != nil {
+			return tgbotapi.NewMessage(m.Chat.ID, invalidDateMessage), nil
+		}
+	}
+	dateStr := date.Format(parse.DateLayout)
+	if date.Before(h.today()) {
+		return tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("❌ %s is over already.", dateStr)), nil
+	}
+
+	ctx := context.Background()
+	d, err := h.Store.GetDutyByDate(ctx, date)
+	if err != nil {
+		return tgbotapi.NewMessage(m.Chat.ID, genericErrorMessage), nil
+	}
+	if isAdmin, _ := h.checkAdmin(m.From.ID); !isAdmin {
+		user, err := h.Users.ByTelegramID(ctx, m.From.ID)
+		if err != nil {
+			return tgbotapi.NewMessage(m.Chat.ID, volunteerUserNotFoundMessage), nil
+		}
+		if d == nil || d.UserID != user.ID {
+			return tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("❌ Only whoever is on duty on %s, or an admin, can ask for cover.", dateStr)), nil
+		}
+	}
+
+	ask, err := h.Covers.Request(ctx, date)
+	switch {
+	case errors.Is(err, cover.ErrNoDuty):
+		return tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("❌ Nobody is on duty on %s.", dateStr)), nil
+	case errors.Is(err, cover.ErrDone):
+		return tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("✅ The duty of %s is done already.", dateStr)), nil
+	case errors.Is(err, cover.ErrAsked):
+		return tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("⏳ The group is asked to cover %s already.", dateStr)), nil
+	case err != nil:
+		return tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("❌ Couldn't ask for cover of %s: %v", dateStr, err)), nil
+	}
+
+	if h.Notifier != nil {
+		sent, err := h.Notifier.AskForCover(ctx, ask)
+		if err != nil {
+			log.Printf("[HandleCover] %v", err)
+		}
+		if sent {
+			return tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("✅ Asked the group who covers for %s on %s.", ask.User.FirstName, dateStr)), nil
+		}
+	}
+	// Without a group chat to ask, the question goes to this one
+	text := notification.FormatCoverRequest(h.locale(ctx, m.Chat.ID), date, ask.User, ask.Fallback, ask.Request.Deadline.Sub(ask.Request.CreatedAt))
+	button := notification.CoverButton(date)
+	msg := tgbotapi.NewMessage(m.Chat.ID, text)
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData(button.Text, button.Data)))
+	return msg, nil
+}
+
+// HandleCoverCallback hands the duty of a cover request over to whoever
+// pressed its button, and updates the request to say who covers.
+// Format: cover_claim:<date>
+func (h *Handlers) HandleCoverCallback(q *tgbotapi.CallbackQuery) (tgbotapi.Chattable, error) {
+	cb := parse.ParseCallback(q.Data)
+	if err := cb.Expect(1); err != nil {
+		return nil, err
+	}
+	date, err := cb.Date(0)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := context.Background()
+	chatID, messageID := q.Message.Chat.ID, q.Message.MessageID
+	user, err := h.Users.ByTelegramID(ctx, q.From.ID)
+	if err != nil {
+		return tgbotapi.NewMessage(chatID, volunteerUserNotFoundMessage), nil
+	}
+
+	// Refusals are for the one who pressed, the request stays as it is for everyone
+	dateStr := date.Format(parse.DateLayout)
+	r, err := h.Covers.Claim(ctx, date, user)
+	switch {
+	case errors.Is(err, cover.ErrNotOpen):
+		return tgbotapi.NewMessage(chatID, fmt.Sprintf("%s doesn't need cover anymore.", dateStr)), nil
+	case errors.Is(err, cover.ErrOwnDuty):
+		return tgbotapi.NewMessage(chatID, fmt.Sprintf("%s, that's your own duty.", user.GroupName())), nil
+	case errors.Is(err, duty.ErrOffDuty):
+		return tgbotapi.NewMessage(chatID, fmt.Sprintf("%s, you're off duty on %s.", user.GroupName(), dateStr)), nil
+	case err != nil:
+		return tgbotapi.NewMessage(chatID, fmt.Sprintf("❌ Couldn't take over %s: %v", dateStr, err)), nil
+	}
+
+	covered, err := h.Users.ByID(ctx, r.UserID)
+	if err != nil {
+		log.Printf("[HandleCoverCallback] Failed to get user %d: %v", r.UserID, err)
+		return tgbotapi.NewEditMessageText(chatID, messageID, fmt.Sprintf("✅ %s is on duty on %s.", user.GroupName(), dateStr)), nil
+	}
+	return tgbotapi.NewEditMessageText(chatID, messageID, notification.FormatCoverClaimed(h.locale(ctx, chatID), date, covered, user)), nil
+}
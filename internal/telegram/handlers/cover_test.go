@@ -0,0 +1,72 @@
+package handlers_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/korjavin/dutyassistant/internal/notification"
+	"github.com/korjavin/dutyassistant/internal/scheduler"
+	"github.com/korjavin/dutyassistant/internal/store"
+	"github.com/korjavin/dutyassistant/internal/store/memory"
+	"github.com/korjavin/dutyassistant/internal/telegram/handlers"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandleCover(t *testing.T) {
+	ctx := context.Background()
+	s := memory.New()
+	h := handlers.New(s, scheduler.NewScheduler(s))
+	alice := &store.User{TelegramUserID: 123, FirstName: "Alice", IsActive: true}
+	bob := &store.User{TelegramUserID: 456, FirstName: "Bob", IsActive: true}
+	for _, u := range []*store.User{alice, bob} {
+		if err := s.CreateUser(ctx, u); err != nil {
+			t.Fatal(err)
+		}
+	}
+	today := scheduler.Today(time.Now(), 0)
+	if err := s.CreateDuty(ctx, &store.Duty{UserID: alice.ID, DutyDate: today, AssignmentType: store.AssignmentTypeRoundRobin, CreatedAt: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+	coverCommand := func(from int64, args string) tgbotapi.MessageConfig {
+		m := adminCommand("cover", args)
+		m.From.ID = from
+		msg, err := h.HandleCover(m)
+		assert.NoError(t, err)
+		return msg
+	}
+	press := func(telegramUserID int64) tgbotapi.Chattable {
+		response, err := h.HandleCoverCallback(&tgbotapi.CallbackQuery{
+			From:    &tgbotapi.User{ID: telegramUserID},
+			Message: &tgbotapi.Message{MessageID: 1, Chat: &tgbotapi.Chat{ID: 789}},
+			Data:    notification.CoverAction + ":" + today.Format("2006-01-02"),
+		})
+		assert.NoError(t, err)
+		return response
+	}
+
+	assert.Contains(t, coverCommand(456, "").Text, "Only whoever is on duty")
+	assert.Contains(t, coverCommand(123, "2020-01-01").Text, "is over already")
+
+	// Without a group chat, the question is asked right here
+	msg := coverCommand(123, "")
+	assert.Contains(t, msg.Text, "Who can cover for Alice")
+	assert.Contains(t, msg.Text, "Alice stays on duty")
+	if markup, ok := msg.ReplyMarkup.(tgbotapi.InlineKeyboardMarkup); assert.True(t, ok) {
+		assert.Equal(t, notification.CoverAction+":"+today.Format("2006-01-02"), *markup.InlineKeyboard[0][0].CallbackData)
+	}
+	assert.Contains(t, coverCommand(123, "").Text, "asked to cover")
+
+	refusal, ok := press(123).(tgbotapi.MessageConfig)
+	if assert.True(t, ok, "a refusal leaves the request alone") {
+		assert.Equal(t, "Alice, that's your own duty.", refusal.Text)
+	}
+	edit, ok := press(456).(tgbotapi.EditMessageTextConfig)
+	if assert.True(t, ok, "the request is updated") {
+		assert.Contains(t, edit.Text, "Bob covers for Alice")
+	}
+	if d, _ := s.GetDutyByDate(ctx, today); assert.NotNil(t, d) {
+		assert.Equal(t, bob.ID, d.UserID)
+	}
+}
@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/korjavin/dutyassistant/internal/notification"
+	"github.com/korjavin/dutyassistant/internal/service/diag"
+	"github.com/korjavin/dutyassistant/internal/store"
+)
+
+// HandleDebug shows admins a diagnostic snapshot of the running bot.
+func (h *Handlers) HandleDebug(m *tgbotapi.Message) (tgbotapi.MessageConfig, error) {
+	isAdmin, err := h.checkAdmin(m.From.ID)
+	if err != nil || !isAdmin {
+		return tgbotapi.NewMessage(m.Chat.ID, adminOnlyMessage), nil
+	}
+	if h.Diag == nil {
+		return tgbotapi.NewMessage(m.Chat.ID, "Diagnostics are not available."), nil
+	}
+
+	snap, err := h.Diag.Snapshot(context.Background())
+	if err != nil {
+		return tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("❌ Failed to collect diagnostics: %v", err)), nil
+	}
+	msg := tgbotapi.NewMessage(m.Chat.ID, formatSnapshot(snap))
+	msg.ParseMode = tgbotapi.ModeHTML
+	return msg, nil
+}
+
+// formatSnapshot renders a diagnostic snapshot as an HTML message.
+func formatSnapshot(snap *diag.Snapshot) string {
+	var b strings.Builder
+	b.WriteString("🩺 <b>Diagnostics</b>\n\n")
+	fmt.Fprintf(&b, "<b>Build:</b> %s (%s)\n", escapeHTML(snap.Version), escapeHTML(shortCommit(snap.Commit)))
+	fmt.Fprintf(&b, "<b>Uptime:</b> %s\n", formatUptime(snap.Uptime))
+
+	if snap.DBPath == "" {
+		b.WriteString("<b>Database:</b> in memory\n")
+	} else {
+		fmt.Fprintf(&b, "<b>Database:</b> %s (%s)\n", notification.FormatBytes(snap.DBSize), escapeHTML(snap.DBPath))
+	}
+	switch {
+	case snap.BackupDir == "":
+		b.WriteString("<b>Last backup:</b> not configured\n")
+	case snap.LastBackup.IsZero():
+		fmt.Fprintf(&b, "<b>Last backup:</b> ⚠️ none in %s\n", escapeHTML(snap.BackupDir))
+	default:
+		fmt.Fprintf(&b, "<b>Last backup:</b> %s\n", snap.LastBackup.Format("2006-01-02 15:04"))
+	}
+
+	b.WriteString("\n<b>Jobs:</b>\n")
+	for _, job := range snap.Jobs {
+		fmt.Fprintf(&b, "• %s - next %s", escapeHTML(job.Name), job.Next.Format("Mon 15:04"))
+		switch {
+		case job.Last == nil:
+			b.WriteString(", not run yet\n")
+		case job.Last.Err != nil:
+			fmt.Fprintf(&b, ", last %s ❌ %s\n", job.Last.At.Format("Mon 15:04"), escapeHTML(job.Last.Err.Error()))
+		default:
+			fmt.Fprintf(&b, ", last %s ✅ (%s)\n", job.Last.At.Format("Mon 15:04"), job.Last.Duration.Round(time.Millisecond))
+		}
+	}
+
+	b.WriteString("\n<b>Queues:</b>\n")
+	fmt.Fprintf(&b, "• Volunteer: %s\n", formatQueue(snap.VolunteerQueue, func(u *store.User) int { return u.VolunteerQueueDays }))
+	fmt.Fprintf(&b, "• Admin: %s\n", formatQueue(snap.AdminQueue, func(u *store.User) int { return u.AdminQueueDays }))
+
+	b.WriteString("\n<b>Last Telegram API error:</b> ")
+	if snap.LastAPIError == nil {
+		b.WriteString("none")
+	} else {
+		fmt.Fprintf(&b, "%s %s", snap.LastAPIErrorAt.Format("2006-01-02 15:04"), escapeHTML(snap.LastAPIError.Error()))
+	}
+	return b.String()
+}
+
+// formatQueue lists the users in a queue with their days, e.g. "Alice 2, Bob 1".
+func formatQueue(users []*store.User, days func(*store.User) int) string {
+	if len(users) == 0 {
+		return "empty"
+	}
+	parts := make([]string, 0, len(users))
+	for _, u := range users {
+		parts = append(parts, fmt.Sprintf("%s %d", escapeHTML(u.FirstName), days(u)))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// formatUptime formats d as days, hours and minutes, e.g. "3d 4h 12m".
+func formatUptime(d time.Duration) string {
+	minutes := int(d.Minutes())
+	days, hours := minutes/(24*60), minutes/60%24
+	if days > 0 {
+		return fmt.Sprintf("%dd %dh %dm", days, hours, minutes%60)
+	}
+	return fmt.Sprintf("%dh %dm", hours, minutes%60)
+}
+
+// shortCommit shortens a commit hash to the usual 7 characters.
+func shortCommit(commit string) string {
+	if len(commit) > 7 {
+		return commit[:7]
+	}
+	return commit
+}
@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/korjavin/dutyassistant/internal/store"
+)
+
+const displayMessage = "📅 Calendars in this chat show:\n" +
+	"• Week numbers: <b>%s</b>\n" +
+	"• Days of the previous and next month: <b>%s</b>\n\n" +
+	"Use <code>/display weeks on|off</code> or <code>/display adjacent on|off</code> to change them."
+
+const displayUsage = "❌ Usage: /display [weeks on|off] [adjacent on|off]"
+
+// HandleDisplay shows or sets how the /schedule calendar is drawn in this
+// chat: with a column of ISO week numbers, and with the first and last week
+// filled with the days of the adjacent months. In a group only admins may
+// change it.
+// Format: /display [weeks on|off] [adjacent on|off]
+func (h *Handlers) HandleDisplay(m *tgbotapi.Message) (tgbotapi.MessageConfig, error) {
+	ctx := context.Background()
+	args := strings.Fields(strings.ToLower(m.CommandArguments()))
+	if len(args) == 0 {
+		return displayStatus(m.Chat.ID, h.display(ctx, m.Chat.ID)), nil
+	}
+	if len(args)%2 != 0 {
+		return tgbotapi.NewMessage(m.Chat.ID, displayUsage), nil
+	}
+
+	if !m.Chat.IsPrivate() {
+		if isAdmin, err := h.checkAdmin(m.From.ID); err != nil || !isAdmin {
+			return tgbotapi.NewMessage(m.Chat.ID, adminOnlyMessage), nil
+		}
+	}
+	d := h.display(ctx, m.Chat.ID)
+	for i := 0; i < len(args); i += 2 {
+		var on bool
+		switch args[i+1] {
+		case "on":
+			on = true
+		case "off":
+		default:
+			return tgbotapi.NewMessage(m.Chat.ID, displayUsage), nil
+		}
+		switch args[i] {
+		case "weeks":
+			d.WeekNumbers = on
+		case "adjacent":
+			d.AdjacentDays = on
+		default:
+			return tgbotapi.NewMessage(m.Chat.ID, displayUsage), nil
+		}
+	}
+	if err := h.Store.SetChatDisplay(ctx, m.Chat.ID, d); err != nil {
+		log.Printf("[HandleDisplay] Failed to set the display of chat %d: %v", m.Chat.ID, err)
+		return tgbotapi.NewMessage(m.Chat.ID, genericErrorMessage), nil
+	}
+	// Rendered calendars are drawn the old way
+	h.InvalidateCalendars(ctx, nil)
+	return tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("✅ Calendars in this chat now show week numbers: %s, days of the previous and next month: %s.",
+		onOff(d.WeekNumbers), onOff(d.AdjacentDays))), nil
+}
+
+// displayStatus lists how calendars are drawn in a chat.
+func displayStatus(chatID int64, d store.ChatDisplay) tgbotapi.MessageConfig {
+	msg := tgbotapi.NewMessage(chatID, fmt.Sprintf(displayMessage, onOff(d.WeekNumbers), onOff(d.AdjacentDays)))
+	msg.ParseMode = tgbotapi.ModeHTML
+	return msg
+}
+
+// onOff names a setting's state.
+func onOff(on bool) string {
+	if on {
+		return "on"
+	}
+	return "off"
+}
+
+// display returns how a chat wants its calendars drawn, plain if it never
+// said or the store fails.
+func (h *Handlers) display(ctx context.Context, chatID int64) store.ChatDisplay {
+	d, err := h.Store.GetChatDisplay(ctx, chatID)
+	if err != nil {
+		log.Printf("Warning: could not get the calendar display of chat %d: %v", chatID, err)
+	}
+	if d == nil {
+		return store.ChatDisplay{}
+	}
+	return *d
+}
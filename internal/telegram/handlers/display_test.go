@@ -0,0 +1,44 @@
+package handlers_test
+
+import (
+	"context"
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/korjavin/dutyassistant/internal/store"
+	"github.com/korjavin/dutyassistant/internal/store/memory"
+	"github.com/korjavin/dutyassistant/internal/telegram/handlers"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandleDisplay(t *testing.T) {
+	ctx := context.Background()
+	s := memory.New()
+	h := handlers.NewWithAdminID(s, nil, 1)
+	if err := s.CreateUser(ctx, &store.User{TelegramUserID: 2, FirstName: "Bob", IsActive: true}); err != nil {
+		t.Fatal(err)
+	}
+	display := func(chat *tgbotapi.Chat, from int64, args string) string {
+		m := adminCommand("display", args)
+		m.Chat, m.From.ID = chat, from
+		msg, err := h.HandleDisplay(m)
+		assert.NoError(t, err)
+		return msg.Text
+	}
+	group := &tgbotapi.Chat{ID: -100, Type: "group"}
+	private := &tgbotapi.Chat{ID: 2, Type: "private"}
+
+	assert.Contains(t, display(group, 2, ""), "Week numbers: <b>off</b>")
+
+	// Only admins change the display of a group
+	assert.Equal(t, "Sorry, this command is for admins only.", display(group, 2, "weeks on"))
+	assert.Contains(t, display(group, 1, "weeks on adjacent ON"), "week numbers: on, days of the previous and next month: on")
+	assert.Contains(t, display(group, 1, "adjacent off"), "week numbers: on, days of the previous and next month: off")
+	d, _ := s.GetChatDisplay(ctx, -100)
+	assert.Equal(t, &store.ChatDisplay{WeekNumbers: true}, d)
+
+	// Anyone changes the display of their private chat
+	assert.Contains(t, display(private, 2, "adjacent on"), "week numbers: off, days of the previous and next month: on")
+	assert.Contains(t, display(private, 2, "weeks maybe"), "Usage")
+	assert.Contains(t, display(private, 2, "weeks"), "Usage")
+}
@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/korjavin/dutyassistant/internal/i18n"
+	"github.com/korjavin/dutyassistant/internal/store"
+)
+
+const (
+	// forecastDays is how many days after today /status predicts.
+	forecastDays = 42
+	// forecastCount is how many predicted duties /status lists at most.
+	forecastCount = 3
+)
+
+// forecast returns the /status section with the next duties the scheduler
+// predicts for user, or "" if there are none in the next forecastDays days.
+// Duties assigned for real are left out, they are the next duty.
+func (h *Handlers) forecast(ctx context.Context, chatID int64, user *store.User) string {
+	if h.Scheduler == nil {
+		return ""
+	}
+	duties, err := h.Scheduler.Forecast(ctx, forecastDays)
+	if err != nil {
+		log.Printf("Error forecasting duties for user %d: %v", user.ID, err)
+		return ""
+	}
+	var days []time.Time
+	for _, d := range duties {
+		if d.UserID == user.ID && d.Status == store.DutyStatusProvisional && len(days) < forecastCount {
+			days = append(days, d.DutyDate)
+		}
+	}
+	return formatForecast(h.locale(ctx, chatID), days)
+}
+
+// formatForecast formats predicted duty days on one line, e.g.
+// "Mon, Nov 3 · Thu, Nov 6", marked as estimates. It returns "" for none.
+func formatForecast(l i18n.Locale, days []time.Time) string {
+	if len(days) == 0 {
+		return ""
+	}
+	dates := make([]string, len(days))
+	for i, day := range days {
+		dates[i] = l.Format(day, "Mon, Jan 2")
+	}
+	return "🔮 <b>Next duties (estimated):</b> " + strings.Join(dates, " · ") +
+		"\n  <i>A forecast of the rotation, it changes as people volunteer or go off duty.</i>\n\n"
+}
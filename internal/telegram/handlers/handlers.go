@@ -1,7 +1,27 @@
 package handlers
 
 import (
+	"context"
+	"time"
+
+	"github.com/korjavin/dutyassistant/internal/i18n"
+	"github.com/korjavin/dutyassistant/internal/ical"
+	"github.com/korjavin/dutyassistant/internal/notification"
 	"github.com/korjavin/dutyassistant/internal/scheduler"
+	"github.com/korjavin/dutyassistant/internal/service/checklist"
+	"github.com/korjavin/dutyassistant/internal/service/cleanup"
+	"github.com/korjavin/dutyassistant/internal/service/cover"
+	"github.com/korjavin/dutyassistant/internal/service/diag"
+	"github.com/korjavin/dutyassistant/internal/service/duty"
+	"github.com/korjavin/dutyassistant/internal/service/dutyjobs"
+	"github.com/korjavin/dutyassistant/internal/service/history"
+	"github.com/korjavin/dutyassistant/internal/service/invite"
+	"github.com/korjavin/dutyassistant/internal/service/ledger"
+	"github.com/korjavin/dutyassistant/internal/service/login"
+	"github.com/korjavin/dutyassistant/internal/service/note"
+	"github.com/korjavin/dutyassistant/internal/service/settings"
+	"github.com/korjavin/dutyassistant/internal/service/task"
+	"github.com/korjavin/dutyassistant/internal/service/user"
 	"github.com/korjavin/dutyassistant/internal/store"
 )
 
@@ -10,22 +30,83 @@ import (
 type Handlers struct {
 	Store     store.Store
 	Scheduler scheduler.SchedulerInterface
-	AdminID   int64 // Telegram user ID of the admin from ADMIN_ID env var
+	Users     *user.Service          // User lookups and updates shared with the HTTP API
+	Duties    *duty.Service          // Manual duty changes shared with the HTTP API
+	Notes     *note.Service          // Duty notes and note templates
+	Checklist *checklist.Service     // Duty checklists
+	Tasks     *task.Service          // One-off tasks outside the rotation
+	Covers    *cover.Service         // Duties taken over at short notice, backs /cover
+	History   *history.Service       // Versions of the monthly schedules, backs /history
+	Sessions  *login.Service         // Login codes for the web app, shared with the HTTP API
+	Invites   *invite.Service        // One-time invitation links
+	Ledger    *ledger.Service        // Balances of payout mode, backs /balance
+	Payments  ledger.Payments        // Optional; how fines are paid with Telegram invoices, backs /balance pay
+	AdminID   int64                  // Telegram user ID of the admin from ADMIN_ID env var
+	Settings  *settings.Service      // Optional; admins changed with /settings, used instead of AdminID
+	Calendars *ical.Importer         // Optional; syncs a calendar right after it is linked
+	Notifier  *notification.Notifier // Optional; sends reminders and snoozes them
+	Diag      *diag.Service          // Optional; backs /debug
+	Cleanup   *cleanup.Service       // Optional; tracks menus and cleans them up, backs /cleanup
+	DutyJobs  *dutyjobs.Manager      // Optional; moves the assignment and completion jobs for /settings time
+	WebURL    string                 // Optional; base URL of the web app for /login links
+	// BotUsername is the bot's Telegram username that /invite links start
+	BotUsername string
+	// DayRollover is the time of day before which "today" still means the
+	// previous day, as in the scheduler
+	DayRollover time.Duration
+	// Locale formats the dates of chats that didn't pick a locale with /language
+	Locale i18n.Locale
+
+	menus     menuOwners     // Who opened which interactive menu
+	calendars calendarCache  // Rendered /schedule calendars
+	imports   offDutyImports // Previewed /offduty_import imports
+	handoffs  handoffPrompts // /handoff prompts waiting for a reply
 }
 
 // New creates a new Handlers instance with the provided dependencies.
 func New(s store.Store, sch scheduler.SchedulerInterface) *Handlers {
+	users := user.New(s)
+	duties := duty.New(sch, users, s)
 	return &Handlers{
 		Store:     s,
 		Scheduler: sch,
+		Users:     users,
+		Duties:    duties,
+		Notes:     note.New(s),
+		Checklist: checklist.New(s),
+		Tasks:     task.New(s),
+		Covers:    cover.New(s, sch, duties, users),
+		History:   history.New(s),
+		Sessions:  login.New(s),
+		Invites:   invite.New(s),
+		Ledger:    ledger.New(s),
+		Locale:    i18n.Default,
+	}
+}
+
+// today is the date users mean by "today" right now.
+func (h *Handlers) today() time.Time {
+	return scheduler.Today(time.Now(), h.DayRollover)
+}
+
+// locale returns the locale to format dates in for a chat.
+func (h *Handlers) locale(ctx context.Context, chatID int64) i18n.Locale {
+	return i18n.ForChat(ctx, h.Store, chatID, h.Locale)
+}
+
+// names returns how messages to a chat name users: by their name in private
+// chats, which have the positive ID of the user, and as they chose with
+// /me privacy in groups.
+func names(chatID int64) notification.Names {
+	if chatID > 0 {
+		return notification.NamesFull
 	}
+	return notification.NamesPublic
 }
 
 // NewWithAdminID creates a new Handlers instance with admin ID configured.
 func NewWithAdminID(s store.Store, sch scheduler.SchedulerInterface, adminID int64) *Handlers {
-	return &Handlers{
-		Store:     s,
-		Scheduler: sch,
-		AdminID:   adminID,
-	}
-}
\ No newline at end of file
+	h := New(s, sch)
+	h.AdminID = adminID
+	return h
+}
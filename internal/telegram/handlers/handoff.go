@@ -0,0 +1,171 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/korjavin/dutyassistant/internal/service/note"
+	"github.com/korjavin/dutyassistant/internal/telegram/parse"
+)
+
+// handoffPromptTTL is how long the reply to a /handoff prompt is waited for.
+const handoffPromptTTL = 12 * time.Hour
+
+const handoffPromptMessage = "📨 What should whoever is on duty next know? Reply to this message with a short note, " +
+	"like \"dishwasher tabs almost out\"."
+
+const handoffNotOnDutyMessage = "❌ Only whoever is on duty today can leave a handoff note."
+
+// handoffKey is who was asked for a handoff note in which chat.
+type handoffKey struct {
+	chatID         int64
+	telegramUserID int64
+}
+
+// handoffPrompt is a /handoff prompt waiting for its reply.
+type handoffPrompt struct {
+	date      time.Time // The duty the note is left on
+	expiresAt time.Time
+}
+
+// handoffPrompts are the /handoff prompts waiting for their replies.
+type handoffPrompts struct {
+	mu      sync.Mutex
+	pending map[handoffKey]handoffPrompt
+}
+
+// add waits for the reply of the user in the chat, replacing an earlier prompt.
+func (p *handoffPrompts) add(key handoffKey, prompt handoffPrompt) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.pending == nil {
+		p.pending = make(map[handoffKey]handoffPrompt)
+	}
+	now := time.Now()
+	for k, other := range p.pending {
+		if now.After(other.expiresAt) {
+			delete(p.pending, k)
+		}
+	}
+	p.pending[key] = prompt
+}
+
+// take removes and returns the prompt of the user in the chat, if it's still
+// waiting.
+func (p *handoffPrompts) take(key handoffKey) (handoffPrompt, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	prompt, ok := p.pending[key]
+	if !ok || time.Now().After(prompt.expiresAt) {
+		return handoffPrompt{}, false
+	}
+	delete(p.pending, key)
+	return prompt, true
+}
+
+// HandleHandoff lets whoever is on duty today leave a note for whoever is
+// next, which comes with their reminder. Without a text it asks for the note
+// and takes the reply to the question.
+// Format: /handoff [text | clear]
+func (h *Handlers) HandleHandoff(m *tgbotapi.Message) (tgbotapi.MessageConfig, error) {
+	ctx := context.Background()
+	user, err := h.Users.ByTelegramID(ctx, m.From.ID)
+	if err != nil {
+		return tgbotapi.NewMessage(m.Chat.ID, volunteerUserNotFoundMessage), nil
+	}
+	today := h.today()
+	duty, err := h.Store.GetDutyByDate(ctx, today)
+	if err != nil {
+		return tgbotapi.NewMessage(m.Chat.ID, genericErrorMessage), nil
+	}
+	if duty == nil || duty.UserID != user.ID {
+		return tgbotapi.NewMessage(m.Chat.ID, handoffNotOnDutyMessage), nil
+	}
+
+	text := strings.TrimSpace(m.CommandArguments())
+	switch {
+	case text == "":
+		key := handoffKey{chatID: m.Chat.ID, telegramUserID: m.From.ID}
+		prompt := handoffPrompt{date: today, expiresAt: time.Now().Add(handoffPromptTTL)}
+		h.handoffs.add(key, prompt)
+		h.saveState(ctx, handoffStateKey(key), handoffState{Date: today.Format(parse.DateLayout)}, prompt.expiresAt)
+
+		question := handoffPromptMessage
+		if duty.HandoffNote != "" {
+			question += "\n\nYour note so far: " + duty.HandoffNote
+		}
+		msg := tgbotapi.NewMessage(m.Chat.ID, question)
+		msg.ReplyToMessageID = m.MessageID
+		// Selective, so only they are asked in the group
+		msg.ReplyMarkup = tgbotapi.ForceReply{ForceReply: true, Selective: true, InputFieldPlaceholder: "Handoff note"}
+		return msg, nil
+	case strings.EqualFold(text, "clear"):
+		text = ""
+	}
+	return h.setHandoffNote(ctx, m.Chat.ID, today, user.ID, text), nil
+}
+
+// HandleHandoffReply takes a reply to a /handoff prompt as the handoff note.
+// It reports false for all other messages, which are left to whatever else
+// reads them.
+func (h *Handlers) HandleHandoffReply(m *tgbotapi.Message) (tgbotapi.Chattable, bool) {
+	if m.From == nil || m.ReplyToMessage == nil || m.ReplyToMessage.From == nil || !m.ReplyToMessage.From.IsBot {
+		return nil, false
+	}
+	key := handoffKey{chatID: m.Chat.ID, telegramUserID: m.From.ID}
+	prompt, ok := h.handoffs.take(key)
+	if !ok {
+		return nil, false
+	}
+	ctx := context.Background()
+	h.deleteState(ctx, handoffStateKey(key))
+
+	if h.readOnly() {
+		return tgbotapi.NewMessage(m.Chat.ID, readOnlyMessage), true
+	}
+	if prompt.date.Format(parse.DateLayout) != h.today().Format(parse.DateLayout) {
+		return tgbotapi.NewMessage(m.Chat.ID, "⌛ Your duty is over, the note is too late for the handoff."), true
+	}
+	user, err := h.Users.ByTelegramID(ctx, m.From.ID)
+	if err != nil {
+		return tgbotapi.NewMessage(m.Chat.ID, volunteerUserNotFoundMessage), true
+	}
+	return h.setHandoffNote(ctx, m.Chat.ID, prompt.date, user.ID, m.Text), true
+}
+
+// HandleMessage reads a message that isn't a command: a reply to a /handoff
+// prompt, or else a trip announced in the group.
+func (h *Handlers) HandleMessage(m *tgbotapi.Message) (tgbotapi.Chattable, error) {
+	if m.SuccessfulPayment != nil {
+		return h.HandleSuccessfulPayment(m)
+	}
+	if response, ok := h.HandleHandoffReply(m); ok {
+		return response, nil
+	}
+	return h.HandleTripHint(m)
+}
+
+// setHandoffNote sets the handoff note of the user's duty on date and says
+// what happened.
+func (h *Handlers) setHandoffNote(ctx context.Context, chatID int64, date time.Time, userID int64, text string) tgbotapi.MessageConfig {
+	_, err := h.Notes.SetHandoffNote(ctx, date, userID, text)
+	switch {
+	case errors.Is(err, note.ErrNoDuty), errors.Is(err, note.ErrNotOnDuty):
+		return tgbotapi.NewMessage(chatID, handoffNotOnDutyMessage)
+	case errors.Is(err, note.ErrHandoffTooLong):
+		return tgbotapi.NewMessage(chatID, fmt.Sprintf("❌ Please keep the note under %d characters.", note.MaxHandoffLength))
+	case err != nil:
+		return tgbotapi.NewMessage(chatID, genericErrorMessage)
+	}
+	if strings.TrimSpace(text) == "" {
+		return tgbotapi.NewMessage(chatID, "🗑 Your handoff note is removed.")
+	}
+	return tgbotapi.NewMessage(chatID, "📨 Thanks! Whoever is on duty next gets your note with their reminder.")
+}
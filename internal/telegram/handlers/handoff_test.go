@@ -0,0 +1,106 @@
+package handlers_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/korjavin/dutyassistant/internal/scheduler"
+	"github.com/korjavin/dutyassistant/internal/store"
+	"github.com/korjavin/dutyassistant/internal/store/memory"
+	"github.com/korjavin/dutyassistant/internal/telegram/handlers"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandleHandoff(t *testing.T) {
+	ctx := context.Background()
+	s := memory.New()
+	h := handlers.New(s, scheduler.NewScheduler(s))
+	alice := &store.User{TelegramUserID: 123, FirstName: "Alice", IsActive: true}
+	bob := &store.User{TelegramUserID: 456, FirstName: "Bob", IsActive: true}
+	for _, u := range []*store.User{alice, bob} {
+		if err := s.CreateUser(ctx, u); err != nil {
+			t.Fatal(err)
+		}
+	}
+	today := scheduler.Today(time.Now(), 0)
+	if err := s.CreateDuty(ctx, &store.Duty{UserID: alice.ID, DutyDate: today, AssignmentType: store.AssignmentTypeRoundRobin, CreatedAt: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+	bot := &tgbotapi.User{ID: 1, IsBot: true}
+	reply := func(from int64, text string) *tgbotapi.Message {
+		return &tgbotapi.Message{Chat: &tgbotapi.Chat{ID: 789}, From: &tgbotapi.User{ID: from}, Text: text,
+			ReplyToMessage: &tgbotapi.Message{MessageID: 5, From: bot}}
+	}
+	handoffNote := func() string {
+		duty, _ := s.GetDutyByDate(ctx, today)
+		return duty.HandoffNote
+	}
+
+	// Bob isn't on duty
+	msg, err := h.HandleHandoff(&tgbotapi.Message{Chat: &tgbotapi.Chat{ID: 789}, From: &tgbotapi.User{ID: 456}, Text: "/handoff",
+		Entities: []tgbotapi.MessageEntity{{Type: "bot_command", Length: 8}}})
+	assert.NoError(t, err)
+	assert.Equal(t, "❌ Only whoever is on duty today can leave a handoff note.", msg.Text)
+
+	msg, err = h.HandleHandoff(adminCommand("handoff", "Dishwasher tabs almost out"))
+	assert.NoError(t, err)
+	assert.Contains(t, msg.Text, "Thanks!")
+	assert.Equal(t, "Dishwasher tabs almost out", handoffNote())
+
+	// Without a text, the reply to the question is the note
+	msg, err = h.HandleHandoff(adminCommand("handoff", ""))
+	assert.NoError(t, err)
+	assert.Contains(t, msg.Text, "Your note so far: Dishwasher tabs almost out")
+	assert.Equal(t, tgbotapi.ForceReply{ForceReply: true, Selective: true, InputFieldPlaceholder: "Handoff note"}, msg.ReplyMarkup)
+
+	_, handled := h.HandleHandoffReply(reply(456, "Bob chiming in"))
+	assert.False(t, handled, "Bob wasn't asked")
+	_, handled = h.HandleHandoffReply(&tgbotapi.Message{Chat: &tgbotapi.Chat{ID: 789}, From: &tgbotapi.User{ID: 123}, Text: "Not a reply"})
+	assert.False(t, handled)
+
+	response, handled := h.HandleHandoffReply(reply(123, "Out of salt too"))
+	assert.True(t, handled)
+	assert.Contains(t, response.(tgbotapi.MessageConfig).Text, "Thanks!")
+	assert.Equal(t, "Out of salt too", handoffNote())
+
+	// The question is answered, later replies are just messages
+	_, handled = h.HandleHandoffReply(reply(123, "Another one"))
+	assert.False(t, handled)
+
+	msg, err = h.HandleHandoff(adminCommand("handoff", "clear"))
+	assert.NoError(t, err)
+	assert.Equal(t, "🗑 Your handoff note is removed.", msg.Text)
+	assert.Empty(t, handoffNote())
+}
+
+func TestHandleHandoff_Restored(t *testing.T) {
+	ctx := context.Background()
+	s := memory.New()
+	before := handlers.New(s, scheduler.NewScheduler(s))
+	alice := &store.User{TelegramUserID: 123, FirstName: "Alice", IsActive: true}
+	if err := s.CreateUser(ctx, alice); err != nil {
+		t.Fatal(err)
+	}
+	today := scheduler.Today(time.Now(), 0)
+	if err := s.CreateDuty(ctx, &store.Duty{UserID: alice.ID, DutyDate: today, AssignmentType: store.AssignmentTypeRoundRobin, CreatedAt: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+	_, err := before.HandleHandoff(adminCommand("handoff", ""))
+	assert.NoError(t, err)
+
+	// The bot restarts before Alice replies
+	h := handlers.New(s, scheduler.NewScheduler(s))
+	assert.NoError(t, h.RestoreConversations(ctx))
+	response, handled := h.HandleHandoffReply(&tgbotapi.Message{Chat: &tgbotapi.Chat{ID: 789}, From: &tgbotapi.User{ID: 123}, Text: "Bins are out",
+		ReplyToMessage: &tgbotapi.Message{MessageID: 5, From: &tgbotapi.User{ID: 1, IsBot: true}}})
+	assert.True(t, handled)
+	assert.Contains(t, response.(tgbotapi.MessageConfig).Text, "Thanks!")
+	duty, _ := s.GetDutyByDate(ctx, today)
+	assert.Equal(t, "Bins are out", duty.HandoffNote)
+
+	states, err := s.ListConversationStates(ctx, time.Now())
+	assert.NoError(t, err)
+	assert.Empty(t, states, "the answered question is forgotten")
+}
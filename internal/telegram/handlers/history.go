@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/korjavin/dutyassistant/internal/service/history"
+	"github.com/korjavin/dutyassistant/internal/store"
+	"github.com/korjavin/dutyassistant/internal/telegram/parse"
+)
+
+const historyUsageMessage = "🕰 <b>Schedule history</b>\n\n" +
+	"<code>/history [YYYY-MM]</code> - list the versions of a month\n" +
+	"<code>/history [YYYY-MM] &lt;version|date&gt;</code> - show the schedule of a version, or as of the end of a day\n" +
+	"<code>/history [YYYY-MM] &lt;version|date&gt; &lt;version|date&gt;</code> - show what changed between the two\n\n" +
+	"The month defaults to the current one. A new version is kept whenever the month's schedule changes."
+
+// historyListLimit is how many of the latest versions /history lists.
+const historyListLimit = 20
+
+// HandleHistory shows the versions of a month's schedule for admins.
+// Format: /history [YYYY-MM] [version|date] [version|date]
+func (h *Handlers) HandleHistory(m *tgbotapi.Message) (tgbotapi.MessageConfig, error) {
+	isAdmin, err := h.checkAdmin(m.From.ID)
+	if err != nil || !isAdmin {
+		return tgbotapi.NewMessage(m.Chat.ID, adminOnlyMessage), nil
+	}
+
+	args := strings.Fields(m.CommandArguments())
+	now := time.Now()
+	month := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	if len(args) > 0 {
+		if t, err := parse.Month(args[0]); err == nil {
+			month, args = t, args[1:]
+		}
+	}
+	if len(args) > 2 {
+		msg := tgbotapi.NewMessage(m.Chat.ID, historyUsageMessage)
+		msg.ParseMode = tgbotapi.ModeHTML
+		return msg, nil
+	}
+
+	ctx := context.Background()
+	var versions []*store.ScheduleVersion
+	for _, arg := range args {
+		v, err := h.historyVersion(ctx, month, arg)
+		if err != nil {
+			return tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("❌ %v", err)), nil
+		}
+		versions = append(versions, v)
+	}
+	users, err := h.Store.ListAllUsers(ctx)
+	if err != nil {
+		return tgbotapi.NewMessage(m.Chat.ID, "❌ Failed to get users."), nil
+	}
+	names := make(map[int64]string, len(users))
+	for _, u := range users {
+		names[u.ID] = u.FirstName
+	}
+
+	var text string
+	switch len(versions) {
+	case 0:
+		all, err := h.History.Versions(ctx, month)
+		if err != nil {
+			return tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("❌ Failed to get the versions: %v", err)), nil
+		}
+		text = historyListText(month, all)
+	case 1:
+		text = historyVersionText(month, versions[0], names)
+	case 2:
+		text = historyDiffText(month, versions[0], versions[1], names)
+	}
+	msg := tgbotapi.NewMessage(m.Chat.ID, text)
+	msg.ParseMode = tgbotapi.ModeHTML
+	return msg, nil
+}
+
+// historyVersion returns the version of month an argument of /history refers
+// to: a version number, or a date for the version current at its end.
+func (h *Handlers) historyVersion(ctx context.Context, month time.Time, arg string) (*store.ScheduleVersion, error) {
+	if n, err := strconv.Atoi(strings.TrimPrefix(arg, "v")); err == nil {
+		return h.History.Version(ctx, month, n)
+	}
+	date, err := parse.Date(arg)
+	if err != nil {
+		return nil, fmt.Errorf("invalid version '%s', expected a number or YYYY-MM-DD", arg)
+	}
+	return h.History.AsOf(ctx, month, time.Date(date.Year(), date.Month(), date.Day()+1, 0, 0, 0, 0, time.Local))
+}
+
+// historyListText lists the latest versions of a month with how many days
+// each one changed.
+func historyListText(month time.Time, versions []*store.ScheduleVersion) string {
+	if len(versions) == 0 {
+		return fmt.Sprintf("🕰 There are no versions of %s yet.", month.Format("January 2006"))
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "🕰 <b>Versions of %s</b>\n", month.Format("January 2006"))
+	start := max(0, len(versions)-historyListLimit)
+	if start > 0 {
+		fmt.Fprintf(&b, "\n%d older version(s) not shown", start)
+	}
+	for i := start; i < len(versions); i++ {
+		v := versions[i]
+		fmt.Fprintf(&b, "\nv%d %s: ", v.Version, v.CreatedAt.Local().Format("Mon, Jan 2 15:04"))
+		if i == 0 {
+			fmt.Fprintf(&b, "first version, %d day(s)", len(v.Days))
+		} else {
+			fmt.Fprintf(&b, "%d day(s) changed", len(history.Diff(versions[i-1].Days, v.Days)))
+		}
+	}
+	fmt.Fprintf(&b, "\n\nShow one with <code>/history %s &lt;version|date&gt;</code>, compare two with <code>/history %s &lt;from&gt; &lt;to&gt;</code>.",
+		month.Format(parse.MonthLayout), month.Format(parse.MonthLayout))
+	return b.String()
+}
+
+// historyVersionText shows the schedule of a version.
+func historyVersionText(month time.Time, v *store.ScheduleVersion, names map[int64]string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "🗓 <b>%s, version %d</b> (%s)\n", month.Format("January 2006"), v.Version, v.CreatedAt.Local().Format("Mon, Jan 2 15:04"))
+	if len(v.Days) == 0 {
+		b.WriteString("\nNo duties were scheduled.")
+	}
+	for i := range v.Days {
+		fmt.Fprintf(&b, "\n%s: %s", v.Days[i].Date.Format("Mon, Jan 2"), historyDayText(&v.Days[i], names))
+	}
+	return b.String()
+}
+
+// historyDiffText shows the days that changed between two versions.
+func historyDiffText(month time.Time, from, to *store.ScheduleVersion, names map[int64]string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "🔀 <b>%s, version %d → %d</b>\n", month.Format("January 2006"), from.Version, to.Version)
+	changes := history.Diff(from.Days, to.Days)
+	if len(changes) == 0 {
+		b.WriteString("\nNothing changed.")
+	}
+	for _, c := range changes {
+		fmt.Fprintf(&b, "\n%s: %s → %s", c.Date.Format("Mon, Jan 2"), historyDayText(c.Before, names), historyDayText(c.After, names))
+	}
+	return b.String()
+}
+
+// historyDayText describes a day of a version, "—" if it had no duty.
+func historyDayText(d *store.ScheduleDay, names map[int64]string) string {
+	switch {
+	case d == nil:
+		return "—"
+	case d.SkipReason != "":
+		return fmt.Sprintf("skipped (%s)", d.SkipReason)
+	}
+	name, ok := names[d.UserID]
+	if !ok {
+		name = "unknown"
+	}
+	text := escapeHTML(name)
+	if d.Status == store.DutyStatusProvisional {
+		text += " (draft)"
+	}
+	return text
+}
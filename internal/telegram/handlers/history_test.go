@@ -0,0 +1,68 @@
+package handlers_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/korjavin/dutyassistant/internal/store"
+	"github.com/korjavin/dutyassistant/internal/store/memory"
+	"github.com/korjavin/dutyassistant/internal/telegram/handlers"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandleHistory(t *testing.T) {
+	ctx := context.Background()
+	s := memory.New()
+	h := handlers.New(s, nil)
+	if err := s.CreateUser(ctx, &store.User{TelegramUserID: 123, FirstName: "Admin", IsAdmin: true, IsActive: true}); err != nil {
+		t.Fatal(err)
+	}
+	alice := &store.User{TelegramUserID: 456, FirstName: "Alice", IsActive: true}
+	bob := &store.User{TelegramUserID: 789, FirstName: "Bob", IsActive: true}
+	for _, u := range []*store.User{alice, bob} {
+		if err := s.CreateUser(ctx, u); err != nil {
+			t.Fatal(err)
+		}
+	}
+	month := time.Date(2025, 11, 1, 0, 0, 0, 0, time.UTC)
+	day := func(d int) time.Time { return time.Date(2025, 11, d, 0, 0, 0, 0, time.UTC) }
+
+	msg, err := h.HandleHistory(adminCommand("history", "2025-11"))
+	assert.NoError(t, err)
+	assert.Equal(t, "🕰 There are no versions of November 2025 yet.", msg.Text)
+
+	// Versions taken on the 2nd and the 3rd
+	s.CreateScheduleVersion(ctx, &store.ScheduleVersion{Month: month, CreatedAt: time.Date(2025, 11, 2, 12, 0, 0, 0, time.Local), Days: []store.ScheduleDay{
+		{Date: day(5), UserID: alice.ID, AssignmentType: store.AssignmentTypeRoundRobin, Status: store.DutyStatusAnnounced},
+	}})
+	s.CreateScheduleVersion(ctx, &store.ScheduleVersion{Month: month, CreatedAt: time.Date(2025, 11, 3, 12, 0, 0, 0, time.Local), Days: []store.ScheduleDay{
+		{Date: day(5), UserID: bob.ID, AssignmentType: store.AssignmentTypeAdmin, Status: store.DutyStatusAnnounced},
+		{Date: day(6), SkipReason: store.SkipReasonHoliday},
+	}})
+
+	msg, err = h.HandleHistory(adminCommand("history", "2025-11"))
+	assert.NoError(t, err)
+	assert.Contains(t, msg.Text, "v1 Sun, Nov 2 12:00: first version, 1 day(s)")
+	assert.Contains(t, msg.Text, "v2 Mon, Nov 3 12:00: 2 day(s) changed")
+
+	msg, err = h.HandleHistory(adminCommand("history", "2025-11 2025-11-02"))
+	assert.NoError(t, err)
+	assert.Contains(t, msg.Text, "November 2025, version 1")
+	assert.Contains(t, msg.Text, "Wed, Nov 5: Alice")
+
+	msg, err = h.HandleHistory(adminCommand("history", "2025-11 1 v2"))
+	assert.NoError(t, err)
+	assert.Contains(t, msg.Text, "Wed, Nov 5: Alice → Bob")
+	assert.Contains(t, msg.Text, "Thu, Nov 6: — → skipped (holiday)")
+
+	msg, err = h.HandleHistory(adminCommand("history", "2025-11 2025-11-01"))
+	assert.NoError(t, err)
+	assert.Contains(t, msg.Text, "had no version yet")
+	msg, err = h.HandleHistory(adminCommand("history", "2025-11 3"))
+	assert.NoError(t, err)
+	assert.Contains(t, msg.Text, "has no version 3")
+	msg, err = h.HandleHistory(adminCommand("history", "2025-11 someday"))
+	assert.NoError(t, err)
+	assert.Contains(t, msg.Text, "invalid version 'someday'")
+}
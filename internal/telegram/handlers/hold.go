@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/korjavin/dutyassistant/internal/telegram/parse"
+)
+
+const holdUsageMessage = "⏳ <b>Assign a day until further notice</b>\n\n" +
+	"Usage: <code>/hold date username until</code>\n\n" +
+	"Example: <code>/hold 2025-11-08 Alice 2025-11-07</code>\n\n" +
+	"Unless the duty is confirmed with <code>/confirm date</code> by the end of <i>until</i>, " +
+	"the day goes back to the daily assignment."
+
+// HandleHold assigns a free day to a user for admins, holding it only until
+// the given day. Format: /hold <date> <user> <until>
+func (h *Handlers) HandleHold(m *tgbotapi.Message) (tgbotapi.MessageConfig, error) {
+	isAdmin, err := h.checkAdmin(m.From.ID)
+	if err != nil || !isAdmin {
+		return tgbotapi.NewMessage(m.Chat.ID, adminOnlyMessage), nil
+	}
+
+	args := strings.Fields(m.CommandArguments())
+	if len(args) != 3 {
+		msg := tgbotapi.NewMessage(m.Chat.ID, holdUsageMessage)
+		msg.ParseMode = tgbotapi.ModeHTML
+		return msg, nil
+	}
+
+	date, err := parse.Date(args[0])
+	if err != nil {
+		return tgbotapi.NewMessage(m.Chat.ID, invalidDateMessage), nil
+	}
+	until, err := parse.Date(args[2])
+	if err != nil {
+		return tgbotapi.NewMessage(m.Chat.ID, invalidDateMessage), nil
+	}
+
+	ctx := context.Background()
+	user, err := h.Users.ByName(ctx, args[1])
+	if err != nil {
+		return tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf(userNotFoundMessage, args[1])), nil
+	}
+
+	dateStr := date.Format(parse.DateLayout)
+	if _, err := h.Duties.AssignHeld(ctx, date, user.ID, until); err != nil {
+		return tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("❌ Failed to hold %s: %v", dateStr, err)), nil
+	}
+	return tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("⏳ %s is on duty on %s if confirmed by the end of %s.",
+		user.FirstName, dateStr, until.Format(parse.DateLayout))), nil
+}
+
+// HandleConfirm confirms a held duty so it stays assigned. Admins and the
+// user on duty may confirm it. Format: /confirm <date>
+func (h *Handlers) HandleConfirm(m *tgbotapi.Message) (tgbotapi.MessageConfig, error) {
+	args := strings.Fields(m.CommandArguments())
+	if len(args) != 1 {
+		msg := tgbotapi.NewMessage(m.Chat.ID, "Usage: <code>/confirm date</code>\n\nExample: <code>/confirm 2025-11-08</code>")
+		msg.ParseMode = tgbotapi.ModeHTML
+		return msg, nil
+	}
+
+	date, err := parse.Date(args[0])
+	if err != nil {
+		return tgbotapi.NewMessage(m.Chat.ID, invalidDateMessage), nil
+	}
+
+	ctx := context.Background()
+	dateStr := date.Format(parse.DateLayout)
+	if isAdmin, _ := h.checkAdmin(m.From.ID); !isAdmin {
+		user, err := h.Users.ByTelegramID(ctx, m.From.ID)
+		if err != nil {
+			return tgbotapi.NewMessage(m.Chat.ID, volunteerUserNotFoundMessage), nil
+		}
+		duty, err := h.Store.GetDutyByDate(ctx, date)
+		if err != nil {
+			return tgbotapi.NewMessage(m.Chat.ID, genericErrorMessage), nil
+		}
+		if duty == nil || duty.UserID != user.ID {
+			return tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("❌ You are not on duty on %s.", dateStr)), nil
+		}
+	}
+
+	if _, err := h.Duties.Confirm(ctx, date); err != nil {
+		return tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("❌ Failed to confirm %s: %v", dateStr, err)), nil
+	}
+	return tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("✅ The duty on %s is confirmed.", dateStr)), nil
+}
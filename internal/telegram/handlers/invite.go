@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/korjavin/dutyassistant/internal/service/invite"
+)
+
+const (
+	inviteUsageMessage = "⚠️ Invalid format.\n\nUsage: <code>/invite [days] [approve]</code>\n\n" +
+		"The link works once, for 7 days unless you give another number of days. " +
+		"With <i>approve</i>, whoever joins with it waits for an admin before they are put on duty."
+	inviteNotConfiguredMessage = "Invitation links need the bot's username, which isn't known yet. Try again in a moment."
+	inviteMessage              = "🔗 Invitation link:\n%s\n\nIt works once and expires on %s. Anyone who opens it joins the roster, so only send it to the person you invite."
+	inviteInvalidMessage       = "❌ This invitation link is invalid, was already used or expired. Ask an admin for a new one."
+	inviteApprovalMessage      = "\n\nAn admin still has to add you to the rotation, you'll get a message when they did. Until then you can look around."
+	onboardingMessage          = "👋 Welcome to the roster, %s!\n\nHere is how it works:\n" +
+		"• Every day one person is on duty, picked fairly by the bot. You get a reminder on your days.\n" +
+		"• /schedule shows this month's duties and /week this week's.\n" +
+		"• /volunteer takes extra days, /calendar marks your vacations off-duty automatically.\n" +
+		"• /notifications picks which reminders you get and when.\n\n" +
+		"Use /help to see all commands."
+)
+
+// maxInviteDays caps how long an invitation link can work.
+const maxInviteDays = 90
+
+// HandleInvite creates a one-time link that adds whoever opens it to the
+// roster, for admins.
+// Format: /invite [days] [approve]
+func (h *Handlers) HandleInvite(m *tgbotapi.Message) (tgbotapi.MessageConfig, error) {
+	isAdmin, err := h.checkAdmin(m.From.ID)
+	if err != nil || !isAdmin {
+		return tgbotapi.NewMessage(m.Chat.ID, adminOnlyMessage), nil
+	}
+	if h.BotUsername == "" {
+		return tgbotapi.NewMessage(m.Chat.ID, inviteNotConfiguredMessage), nil
+	}
+
+	ttl, requireApproval := invite.DefaultTTL, false
+	for _, arg := range strings.Fields(m.CommandArguments()) {
+		if arg == "approve" {
+			requireApproval = true
+			continue
+		}
+		days, err := strconv.Atoi(arg)
+		if err != nil || days < 1 || days > maxInviteDays {
+			msg := tgbotapi.NewMessage(m.Chat.ID, inviteUsageMessage)
+			msg.ParseMode = tgbotapi.ModeHTML
+			return msg, nil
+		}
+		ttl = time.Duration(days) * 24 * time.Hour
+	}
+
+	ctx := context.Background()
+	token, created, err := h.Invites.Create(ctx, m.From.ID, ttl, requireApproval)
+	if err != nil {
+		log.Printf("[HandleInvite] Failed to create an invite for %d: %v", m.From.ID, err)
+		return tgbotapi.NewMessage(m.Chat.ID, genericErrorMessage), nil
+	}
+	link := fmt.Sprintf("https://t.me/%s?start=%s", h.BotUsername, token)
+	text := fmt.Sprintf(inviteMessage, link, h.locale(ctx, m.Chat.ID).Format(created.ExpiresAt.In(time.Local), "January 2, 2006"))
+	if requireApproval {
+		text += "\n\nYou'll be asked to approve them before they are put on duty."
+	}
+	msg := tgbotapi.NewMessage(m.Chat.ID, text)
+	msg.DisableWebPagePreview = true
+	return msg, nil
+}
+
+// startWithInvite registers a new user who started the bot with an
+// invitation link and greets them. Users who are registered already don't use
+// up the link.
+func (h *Handlers) startWithInvite(ctx context.Context, m *tgbotapi.Message, token string, isAdmin bool) (tgbotapi.MessageConfig, error) {
+	if _, err := h.Users.ByTelegramID(ctx, m.From.ID); err == nil {
+		return tgbotapi.NewMessage(m.Chat.ID, "👋 You're on the roster already.\n\n"+startMessage), nil
+	}
+
+	inv, err := h.Invites.Redeem(ctx, token, m.From.ID)
+	if errors.Is(err, invite.ErrInvalidInvite) {
+		log.Printf("[HandleStart] User %d started the bot with an invalid invite", m.From.ID)
+		return tgbotapi.NewMessage(m.Chat.ID, inviteInvalidMessage), nil
+	} else if err != nil {
+		return tgbotapi.MessageConfig{}, err
+	}
+	pending := inv.RequiresApproval && !isAdmin
+	user, err := h.Users.Register(ctx, m.From.ID, m.From.FirstName, isAdmin, pending)
+	if err != nil {
+		return tgbotapi.MessageConfig{}, err
+	}
+	log.Printf("[HandleStart] User %d joined with an invite by %d (pending=%v)", m.From.ID, inv.CreatedBy, pending)
+
+	text := fmt.Sprintf(onboardingMessage, user.FirstName)
+	if pending {
+		h.requestApproval(ctx, user)
+		text += inviteApprovalMessage
+	}
+	return tgbotapi.NewMessage(m.Chat.ID, text), nil
+}
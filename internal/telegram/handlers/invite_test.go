@@ -0,0 +1,75 @@
+package handlers_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/korjavin/dutyassistant/internal/store"
+	storemocks "github.com/korjavin/dutyassistant/internal/store/mocks"
+	"github.com/korjavin/dutyassistant/internal/telegram/handlers"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestHandleInvite(t *testing.T) {
+	mockStore, _, h := setupAdminTest(t)
+	h.AdminID = 123
+	h.BotUsername = "roster_bot"
+	mockStore.EXPECT().GetChatLocale(gomock.Any(), gomock.Any()).Return("", nil).AnyTimes()
+
+	var created *store.Invite
+	mockStore.EXPECT().CreateInvite(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, invite *store.Invite) error {
+		created = invite
+		return nil
+	})
+	msg, err := h.HandleInvite(adminCommand("invite", "3 approve"))
+	assert.NoError(t, err)
+	assert.Contains(t, msg.Text, "https://t.me/roster_bot?start=")
+	assert.Contains(t, msg.Text, "approve them")
+	if assert.NotNil(t, created) {
+		assert.Equal(t, int64(123), created.CreatedBy)
+		assert.True(t, created.RequiresApproval)
+		assert.Equal(t, 72*time.Hour, created.ExpiresAt.Sub(created.CreatedAt))
+		assert.NotContains(t, msg.Text, created.TokenHash, "only the hash is stored")
+	}
+
+	msg, err = h.HandleInvite(adminCommand("invite", "1000"))
+	assert.NoError(t, err)
+	assert.Contains(t, msg.Text, "Usage:")
+}
+
+func TestHandleStart_Invite(t *testing.T) {
+	mockStore := storemocks.NewMockStore(gomock.NewController(t))
+	h := handlers.NewWithAdminID(mockStore, nil, 1)
+	start := func(token string) string {
+		m := adminCommand("start", token)
+		m.From.FirstName = "Carol"
+		msg, err := h.HandleStart(m)
+		assert.NoError(t, err)
+		return msg.Text
+	}
+
+	// Unknown, used and expired links don't register anyone
+	mockStore.EXPECT().GetUserByTelegramID(gomock.Any(), int64(123)).Return(nil, nil)
+	mockStore.EXPECT().UseInvite(gomock.Any(), gomock.Any(), int64(123), gomock.Any()).Return(nil, nil)
+	assert.Contains(t, start("bogus"), "invalid, was already used or expired")
+
+	// Whoever joins with an invite needing approval stays out of the rotation
+	mockStore.EXPECT().GetUserByTelegramID(gomock.Any(), int64(123)).Return(nil, nil).Times(2)
+	mockStore.EXPECT().UseInvite(gomock.Any(), gomock.Any(), int64(123), gomock.Any()).Return(&store.Invite{CreatedBy: 1, RequiresApproval: true}, nil)
+	mockStore.EXPECT().CreateUser(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, u *store.User) error {
+		assert.Equal(t, "Carol", u.FirstName)
+		assert.False(t, u.IsActive)
+		assert.True(t, u.IsPending)
+		return nil
+	})
+	text := start("token")
+	assert.Contains(t, text, "Welcome to the roster, Carol!")
+	assert.Contains(t, text, "An admin still has to add you to the rotation")
+
+	// Registered users don't use up links
+	mockStore.EXPECT().GetUserByTelegramID(gomock.Any(), int64(123)).Return(&store.User{ID: 3, TelegramUserID: 123}, nil)
+	assert.True(t, strings.HasPrefix(start("token"), "👋 You're on the roster already."))
+}
@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/korjavin/dutyassistant/internal/notification"
+	"github.com/korjavin/dutyassistant/internal/telegram/keyboard"
+)
+
+const (
+	juniorUsageMessage = "🧒 <b>Junior members</b>\n\n" +
+		"Usage: <code>/junior user</code>\n\n" +
+		"Juniors still take part in the rotation, but only get a short /help with " +
+		"the commands about their own duties and a simpler web view without other people's stats. " +
+		"Run it again to make them a regular member."
+
+	juniorRefusalMessage = "Sorry, this command isn't available for you. Use /help to see what you can do."
+)
+
+// JuniorCallbacks are the callback actions a junior member may press.
+var JuniorCallbacks = map[string]bool{
+	keyboard.ActionPrevMonth:        true,
+	keyboard.ActionNextMonth:        true,
+	keyboard.ActionSelectDay:        true,
+	keyboard.ActionIgnore:           true,
+	checklistCheckAction:            true,
+	notification.ClaimTaskAction:    true,
+	notification.CompleteTaskAction: true,
+	notification.ReleaseTaskAction:  true,
+}
+
+// HandleJunior toggles whether a user is a junior member for admins.
+// Format: /junior <user>
+func (h *Handlers) HandleJunior(m *tgbotapi.Message) (tgbotapi.MessageConfig, error) {
+	isAdmin, err := h.checkAdmin(m.From.ID)
+	if err != nil || !isAdmin {
+		return tgbotapi.NewMessage(m.Chat.ID, adminOnlyMessage), nil
+	}
+
+	args := strings.Fields(m.CommandArguments())
+	if len(args) != 1 {
+		msg := tgbotapi.NewMessage(m.Chat.ID, juniorUsageMessage)
+		msg.ParseMode = tgbotapi.ModeHTML
+		return msg, nil
+	}
+
+	ctx := context.Background()
+	user, err := h.Users.Find(ctx, args[0])
+	if err != nil {
+		return tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf(userNotFoundMessage, args[0])), nil
+	}
+	if err := h.Users.ToggleJunior(ctx, user); err != nil {
+		return tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("❌ Failed to update %s: %v", user.FirstName, err)), nil
+	}
+	text := fmt.Sprintf("🧒 <b>%s</b> is now a junior member with the reduced commands.", html.EscapeString(user.FirstName))
+	if !user.IsJunior {
+		text = fmt.Sprintf("👤 <b>%s</b> is a regular member again.", html.EscapeString(user.FirstName))
+	}
+	msg := tgbotapi.NewMessage(m.Chat.ID, text)
+	msg.ParseMode = tgbotapi.ModeHTML
+	return msg, nil
+}
+
+// isJunior reports whether the Telegram user is a registered junior member.
+// Admins are never limited, so they can't lock themselves out.
+func (h *Handlers) isJunior(telegramUserID int64) bool {
+	user, err := h.Users.ByTelegramID(context.Background(), telegramUserID)
+	return err == nil && user.IsJunior && !user.IsAdmin
+}
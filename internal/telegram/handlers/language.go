@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/korjavin/dutyassistant/internal/i18n"
+)
+
+const languageMessage = "🌐 Dates in this chat are written in <b>%s</b>.\n\n" +
+	"Use <code>/language code</code> to change it, one of: %s"
+
+// HandleLanguage shows or sets the language dates are written in in this chat:
+// reminders, announcements, /week and the /schedule calendar. In a group only
+// admins may change it.
+// Format: /language [code]
+func (h *Handlers) HandleLanguage(m *tgbotapi.Message) (tgbotapi.MessageConfig, error) {
+	ctx := context.Background()
+	code := strings.TrimSpace(m.CommandArguments())
+	if code == "" {
+		msg := tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf(languageMessage, h.locale(ctx, m.Chat.ID), i18n.Codes()))
+		msg.ParseMode = tgbotapi.ModeHTML
+		return msg, nil
+	}
+
+	if !m.Chat.IsPrivate() {
+		if isAdmin, err := h.checkAdmin(m.From.ID); err != nil || !isAdmin {
+			return tgbotapi.NewMessage(m.Chat.ID, adminOnlyMessage), nil
+		}
+	}
+	locale, err := i18n.Parse(code)
+	if err != nil {
+		return tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("❌ I don't speak %q, pick one of: %s", code, i18n.Codes())), nil
+	}
+	if err := h.Store.SetChatLocale(ctx, m.Chat.ID, string(locale)); err != nil {
+		log.Printf("[HandleLanguage] Failed to set the locale of chat %d: %v", m.Chat.ID, err)
+		return tgbotapi.NewMessage(m.Chat.ID, genericErrorMessage), nil
+	}
+	// Rendered calendars name the months in the old language
+	h.InvalidateCalendars(ctx, nil)
+	return tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("✅ Dates in this chat are now written in %s, e.g. %s.",
+		locale, locale.Format(h.today(), "Monday, January 2"))), nil
+}
@@ -0,0 +1,40 @@
+package handlers_test
+
+import (
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/korjavin/dutyassistant/internal/store"
+	storemocks "github.com/korjavin/dutyassistant/internal/store/mocks"
+	"github.com/korjavin/dutyassistant/internal/telegram/handlers"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestHandleLanguage(t *testing.T) {
+	mockStore := storemocks.NewMockStore(gomock.NewController(t))
+	h := handlers.NewWithAdminID(mockStore, nil, 1)
+	mockStore.EXPECT().GetUserByTelegramID(gomock.Any(), gomock.Any()).Return(&store.User{ID: 2, TelegramUserID: 2}, nil).AnyTimes()
+	language := func(chat *tgbotapi.Chat, from int64, args string) string {
+		m := adminCommand("language", args)
+		m.Chat, m.From.ID = chat, from
+		msg, err := h.HandleLanguage(m)
+		assert.NoError(t, err)
+		return msg.Text
+	}
+	group := &tgbotapi.Chat{ID: -100, Type: "group"}
+	private := &tgbotapi.Chat{ID: 2, Type: "private"}
+
+	mockStore.EXPECT().GetChatLocale(gomock.Any(), int64(-100)).Return("", nil)
+	assert.Contains(t, language(group, 2, ""), "written in <b>en</b>")
+
+	// Only admins change the language of a group
+	assert.Equal(t, "Sorry, this command is for admins only.", language(group, 2, "de"))
+	mockStore.EXPECT().SetChatLocale(gomock.Any(), int64(-100), "de").Return(nil)
+	assert.Contains(t, language(group, 1, "DE"), "now written in de")
+
+	// Anyone changes the language of their private chat
+	mockStore.EXPECT().SetChatLocale(gomock.Any(), int64(2), "en").Return(nil)
+	assert.Contains(t, language(private, 2, "en-GB"), "now written in en")
+	assert.Contains(t, language(private, 2, "fr"), "I don't speak \"fr\"")
+}
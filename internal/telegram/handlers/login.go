@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/korjavin/dutyassistant/internal/service/login"
+)
+
+const (
+	loginPrivateOnlyMessage   = "🔑 Login links are only sent in a private chat. Send me /login directly."
+	loginNotConfiguredMessage = "Web login isn't set up on this bot, ask the admin to configure DNS_NAME."
+	loginMessage              = "🔑 Open this link to use the calendar in your browser:\n%s\n\n" +
+		"It works once and expires in %d minutes. Don't share it, it signs in as you."
+)
+
+// HandleLogin sends the user a one-time link that signs a browser outside
+// Telegram in as them. Format: /login
+func (h *Handlers) HandleLogin(m *tgbotapi.Message) (tgbotapi.MessageConfig, error) {
+	if !m.Chat.IsPrivate() {
+		return tgbotapi.NewMessage(m.Chat.ID, loginPrivateOnlyMessage), nil
+	}
+	if h.WebURL == "" {
+		return tgbotapi.NewMessage(m.Chat.ID, loginNotConfiguredMessage), nil
+	}
+
+	ctx := context.Background()
+	user, err := h.Users.ByTelegramID(ctx, m.From.ID)
+	if err != nil {
+		return tgbotapi.NewMessage(m.Chat.ID, volunteerUserNotFoundMessage), nil
+	}
+	code, err := h.Sessions.IssueCode(ctx, user.ID)
+	if err != nil {
+		log.Printf("[HandleLogin] Failed to issue a login code for user %d: %v", user.ID, err)
+		return tgbotapi.NewMessage(m.Chat.ID, genericErrorMessage), nil
+	}
+	link := fmt.Sprintf("%s/login?code=%s", strings.TrimRight(h.WebURL, "/"), url.QueryEscape(code))
+	msg := tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf(loginMessage, link, int(login.CodeTTL.Minutes())))
+	msg.DisableWebPagePreview = true
+	return msg, nil
+}
@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/korjavin/dutyassistant/internal/service/user"
+	"github.com/korjavin/dutyassistant/internal/store"
+)
+
+const meUsageMessage = "🙋 <b>Your profile</b>\n\n" +
+	"<code>/me emoji 🦊</code> - pick the emoji that marks your days in the calendar and announcements\n" +
+	"<code>/me emoji off</code> - go back to a number in the calendar\n" +
+	"<code>/me timezone Europe/London</code> - get your reminders at your time there while you're abroad\n" +
+	"<code>/me timezone off</code> - get them at home time again\n" +
+	"<code>/me privacy initials</code> - be named by your initials in the group, your name stays in private chats\n" +
+	"<code>/me privacy alias Captain</code> - be named by an alias in the group instead\n" +
+	"<code>/me privacy off</code> - be named by your name in the group again"
+
+// HandleMe shows and changes the user's own profile.
+// Format: /me [emoji <emoji>|off] [timezone <zone>|off] [privacy initials|alias <name>|off]
+func (h *Handlers) HandleMe(m *tgbotapi.Message) (tgbotapi.MessageConfig, error) {
+	ctx := context.Background()
+	u, err := h.Users.ByTelegramID(ctx, m.From.ID)
+	if err != nil {
+		return tgbotapi.NewMessage(m.Chat.ID, volunteerUserNotFoundMessage), nil
+	}
+
+	args := strings.Fields(m.CommandArguments())
+	if len(args) == 2 && args[0] == "timezone" {
+		return h.setTimezone(ctx, m.Chat.ID, u, args[1])
+	}
+	if len(args) >= 2 && args[0] == "privacy" {
+		return h.setPrivacy(ctx, m.Chat.ID, u, args[1], strings.Join(args[2:], " "))
+	}
+	if len(args) != 2 || args[0] != "emoji" {
+		emoji := u.Emoji
+		if emoji == "" {
+			emoji = "none yet"
+		}
+		timezone := u.Timezone
+		if timezone == "" {
+			timezone = "home time"
+		}
+		msg := tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("%s\n\nName: %s\nIn the group: %s\nEmoji: %s\nTimezone: %s",
+			meUsageMessage, escapeHTML(u.FirstName), escapeHTML(u.GroupName()), emoji, timezone))
+		msg.ParseMode = tgbotapi.ModeHTML
+		return msg, nil
+	}
+
+	emoji := args[1]
+	if emoji == "off" {
+		emoji = ""
+	}
+	switch err := h.Users.SetEmoji(ctx, u, emoji); {
+	case errors.Is(err, user.ErrInvalidEmoji):
+		return tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("❌ %s isn't a single emoji. Try something like /me emoji 🦊", args[1])), nil
+	case errors.Is(err, user.ErrEmojiTaken):
+		return tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("❌ Someone else already picked %s, choose another one.", args[1])), nil
+	case err != nil:
+		log.Printf("[HandleMe] Failed to set the emoji of user %d: %v", u.ID, err)
+		return tgbotapi.NewMessage(m.Chat.ID, genericErrorMessage), nil
+	}
+	// Calendars mark this user's days with the emoji
+	h.InvalidateCalendars(ctx, nil)
+	if emoji == "" {
+		return tgbotapi.NewMessage(m.Chat.ID, "✅ Your emoji is removed, the calendar shows a number for you again."), nil
+	}
+	return tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("✅ %s now marks your days.", emoji)), nil
+}
+
+// setTimezone sets the timezone the user's reminders follow, from an IANA
+// zone name or "off".
+func (h *Handlers) setTimezone(ctx context.Context, chatID int64, u *store.User, name string) (tgbotapi.MessageConfig, error) {
+	if name == "off" {
+		name = ""
+	}
+	switch err := h.Users.SetTimezone(ctx, u, name); {
+	case errors.Is(err, user.ErrUnknownTimezone):
+		return tgbotapi.NewMessage(chatID, fmt.Sprintf("❌ I don't know the timezone %s. Use a name like Europe/London or America/New_York.", name)), nil
+	case err != nil:
+		log.Printf("[HandleMe] Failed to set the timezone of user %d: %v", u.ID, err)
+		return tgbotapi.NewMessage(chatID, genericErrorMessage), nil
+	}
+	if name == "" {
+		return tgbotapi.NewMessage(chatID, "✅ Your reminders come at home time again."), nil
+	}
+	return tgbotapi.NewMessage(chatID, fmt.Sprintf("✅ Your reminders now come at your reminder time in %s. "+
+		"Duties still change at midnight at home, and reminders still come between the assignment and the evening there.", u.Timezone)), nil
+}
+
+// setPrivacy sets how group messages name the user: "initials", "alias" with
+// the alias, or "off" for their name.
+func (h *Handlers) setPrivacy(ctx context.Context, chatID int64, u *store.User, mode, alias string) (tgbotapi.MessageConfig, error) {
+	var privacy store.Privacy
+	switch mode {
+	case "off":
+		privacy = store.PrivacyOff
+	case "initials":
+		privacy = store.PrivacyInitials
+	case "alias":
+		privacy = store.PrivacyAlias
+	default:
+		msg := tgbotapi.NewMessage(chatID, meUsageMessage)
+		msg.ParseMode = tgbotapi.ModeHTML
+		return msg, nil
+	}
+	switch err := h.Users.SetPrivacy(ctx, u, privacy, alias); {
+	case errors.Is(err, user.ErrInvalidAlias):
+		return tgbotapi.NewMessage(chatID, fmt.Sprintf("❌ An alias has 1 to %d characters. Try something like /me privacy alias Captain", user.MaxAliasLength)), nil
+	case err != nil:
+		log.Printf("[HandleMe] Failed to set the privacy of user %d: %v", u.ID, err)
+		return tgbotapi.NewMessage(chatID, genericErrorMessage), nil
+	}
+	if privacy == store.PrivacyOff {
+		return tgbotapi.NewMessage(chatID, "✅ Group messages name you by your name again."), nil
+	}
+	return tgbotapi.NewMessage(chatID, fmt.Sprintf("✅ Group messages now name you %s. "+
+		"Private chats and the signed-in web app still show your name.", u.GroupName())), nil
+}
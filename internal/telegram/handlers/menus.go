@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// menuOwnerTTL is how long a menu stays bound to whoever opened it. Older
+// menus are forgotten and only the role check applies to them. Who opened
+// the newer ones is stored, so it is known after a restart too.
+const menuOwnerTTL = 24 * time.Hour
+
+const menuNotYoursMessage = "This menu was opened by someone else. Send the command yourself to get your own."
+
+type menuKey struct {
+	chatID    int64
+	messageID int
+}
+
+type menuOwner struct {
+	telegramUserID int64
+	boundAt        time.Time
+}
+
+// menuOwners remembers who opened each interactive menu, so that in a group
+// only they can press its buttons.
+type menuOwners struct {
+	mu     sync.Mutex
+	owners map[menuKey]menuOwner
+}
+
+// BindMenu records that the user with the Telegram ID opened the menu sent as
+// the message, so buttons pressed by anyone else are refused.
+func (h *Handlers) BindMenu(chatID int64, messageID int, telegramUserID int64) {
+	now := time.Now()
+	h.menus.bind(menuKey{chatID, messageID}, menuOwner{telegramUserID: telegramUserID, boundAt: now})
+	h.saveState(context.Background(), menuStateKey(chatID, messageID),
+		menuState{TelegramUserID: telegramUserID, BoundAt: now}, now.Add(menuOwnerTTL))
+}
+
+// bind records the owner of the menu, forgetting the expired ones.
+func (m *menuOwners) bind(key menuKey, owner menuOwner) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.owners == nil {
+		m.owners = make(map[menuKey]menuOwner)
+	}
+	for k, o := range m.owners {
+		if time.Since(o.boundAt) > menuOwnerTTL {
+			delete(m.owners, k)
+		}
+	}
+	m.owners[key] = owner
+}
+
+// ownsMenu reports whether the user may press the buttons of the message: it
+// is their menu, or not bound to anyone.
+func (h *Handlers) ownsMenu(chatID int64, messageID int, telegramUserID int64) bool {
+	h.menus.mu.Lock()
+	defer h.menus.mu.Unlock()
+
+	owner, ok := h.menus.owners[menuKey{chatID, messageID}]
+	if !ok || time.Since(owner.boundAt) > menuOwnerTTL {
+		return true
+	}
+	return owner.telegramUserID == telegramUserID
+}
@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/korjavin/dutyassistant/internal/service/user"
+)
+
+const mergeUsersUsageMessage = "🔀 <b>Merge a duplicate account</b>\n\n" +
+	"Usage: <code>/merge_users from to</code>\n\n" +
+	"Example: <code>/merge_users #4 Alice</code>\n\n" +
+	"Duties, queue days and stats of <i>from</i> move to <i>to</i>, and <i>from</i> is deleted. " +
+	"Refer to users by name, or by the <code>#ID</code> shown in /users when names are the same."
+
+// HandleMergeUsers folds one user into another for admins, for someone who
+// ended up with two accounts. Format: /merge_users <from> <to>
+func (h *Handlers) HandleMergeUsers(m *tgbotapi.Message) (tgbotapi.MessageConfig, error) {
+	isAdmin, err := h.checkAdmin(m.From.ID)
+	if err != nil || !isAdmin {
+		return tgbotapi.NewMessage(m.Chat.ID, adminOnlyMessage), nil
+	}
+
+	args := strings.Fields(m.CommandArguments())
+	if len(args) != 2 {
+		msg := tgbotapi.NewMessage(m.Chat.ID, mergeUsersUsageMessage)
+		msg.ParseMode = tgbotapi.ModeHTML
+		return msg, nil
+	}
+
+	ctx := context.Background()
+	from, err := h.Users.Find(ctx, args[0])
+	if err != nil {
+		return tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf(userNotFoundMessage, args[0])), nil
+	}
+	to, err := h.Users.Find(ctx, args[1])
+	if err != nil {
+		return tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf(userNotFoundMessage, args[1])), nil
+	}
+
+	merge, err := h.Users.Merge(ctx, from.ID, to.ID)
+	switch {
+	case errors.Is(err, user.ErrSameUser):
+		return tgbotapi.NewMessage(m.Chat.ID, "❌ Pick two different users to merge."), nil
+	case err != nil:
+		return tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("❌ Failed to merge %s into %s: %v", from.FirstName, to.FirstName, err)), nil
+	}
+	// The duties and queue days moved over without scheduler events
+	h.InvalidateCalendars(ctx, nil)
+	return tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf(
+		"🔀 Merged %s into %s: %d duties, %d volunteer and %d admin queue days moved.",
+		merge.FromName, to.FirstName, merge.DutiesMoved, merge.VolunteerQueueDays, merge.AdminQueueDays)), nil
+}
@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/korjavin/dutyassistant/internal/service/note"
+	"github.com/korjavin/dutyassistant/internal/telegram/parse"
+)
+
+const noteUsageMessage = "📝 <b>Duty notes</b>\n\n" +
+	"Notes are added to the reminders of whoever is on duty.\n\n" +
+	"<code>/note list</code> - list the note templates\n" +
+	"<code>/note add rule text</code> - add a note for the days the rule matches\n" +
+	"<code>/note del id</code> - delete a note template\n" +
+	"<code>/note set date text</code> - set the note of one duty\n" +
+	"<code>/note clear date</code> - remove the note of one duty\n\n" +
+	"Rules: <code>daily</code>, a weekday like <code>tue</code>, " +
+	"<code>2w:2025-11-04</code> for every 2 weeks from that day, <code>monthly:15</code>\n\n" +
+	"Example: <code>/note add 2w:2025-11-04 Bins are brown this week</code>"
+
+// HandleNote manages the notes of duties for admins.
+// Format: /note [list | add <rule> <text> | del <id> | set <date> <text> | clear <date>]
+func (h *Handlers) HandleNote(m *tgbotapi.Message) (tgbotapi.MessageConfig, error) {
+	isAdmin, err := h.checkAdmin(m.From.ID)
+	if err != nil || !isAdmin {
+		return tgbotapi.NewMessage(m.Chat.ID, adminOnlyMessage), nil
+	}
+
+	args := strings.Fields(m.CommandArguments())
+	ctx := context.Background()
+	switch {
+	case len(args) == 0 || (args[0] == "list" && len(args) == 1):
+		return h.listNoteTemplates(ctx, m.Chat.ID)
+	case args[0] == "add" && len(args) >= 3:
+		t, err := h.Notes.AddTemplate(ctx, args[1], strings.Join(args[2:], " "))
+		if err != nil {
+			return tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("❌ Failed to add the note: %v", err)), nil
+		}
+		rule, _ := note.ParseRule(t.Rule)
+		return tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("✅ Note #%d added, %s.", t.ID, rule)), nil
+	case args[0] == "del" && len(args) == 2:
+		id, err := strconv.ParseInt(strings.TrimPrefix(args[1], "#"), 10, 64)
+		if err != nil {
+			return tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("❌ Invalid note ID: %s", args[1])), nil
+		}
+		if err := h.Notes.DeleteTemplate(ctx, id); errors.Is(err, note.ErrNotFound) {
+			return tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("❌ There is no note #%d.", id)), nil
+		} else if err != nil {
+			return tgbotapi.NewMessage(m.Chat.ID, genericErrorMessage), nil
+		}
+		return tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("🗑 Note #%d deleted.", id)), nil
+	case (args[0] == "set" && len(args) >= 3) || (args[0] == "clear" && len(args) == 2):
+		date, err := parse.Date(args[1])
+		if err != nil {
+			return tgbotapi.NewMessage(m.Chat.ID, invalidDateMessage), nil
+		}
+		text := strings.Join(args[2:], " ")
+		dateStr := date.Format(parse.DateLayout)
+		if _, err := h.Notes.SetDutyNote(ctx, date, text); errors.Is(err, note.ErrNoDuty) {
+			return tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("❌ Nobody is on duty on %s.", dateStr)), nil
+		} else if err != nil {
+			return tgbotapi.NewMessage(m.Chat.ID, genericErrorMessage), nil
+		}
+		if text == "" {
+			return tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("🗑 The note on %s is removed.", dateStr)), nil
+		}
+		return tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("📝 The duty on %s has the note: %s", dateStr, text)), nil
+	default:
+		msg := tgbotapi.NewMessage(m.Chat.ID, noteUsageMessage)
+		msg.ParseMode = tgbotapi.ModeHTML
+		return msg, nil
+	}
+}
+
+// listNoteTemplates lists the note templates with their IDs and rules.
+func (h *Handlers) listNoteTemplates(ctx context.Context, chatID int64) (tgbotapi.MessageConfig, error) {
+	templates, err := h.Notes.Templates(ctx)
+	if err != nil {
+		return tgbotapi.NewMessage(chatID, genericErrorMessage), nil
+	}
+	if len(templates) == 0 {
+		msg := tgbotapi.NewMessage(chatID, "There are no note templates yet.\n\n"+noteUsageMessage)
+		msg.ParseMode = tgbotapi.ModeHTML
+		return msg, nil
+	}
+
+	var b strings.Builder
+	b.WriteString("📝 <b>Note templates</b>\n")
+	for _, t := range templates {
+		rule, _ := note.ParseRule(t.Rule)
+		fmt.Fprintf(&b, "\n#%d %s: %s", t.ID, escapeHTML(rule.String()), escapeHTML(t.Text))
+	}
+	msg := tgbotapi.NewMessage(chatID, b.String())
+	msg.ParseMode = tgbotapi.ModeHTML
+	return msg, nil
+}
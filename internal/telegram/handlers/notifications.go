@@ -0,0 +1,203 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/korjavin/dutyassistant/internal/notification"
+	"github.com/korjavin/dutyassistant/internal/scheduler"
+	"github.com/korjavin/dutyassistant/internal/store"
+	"github.com/korjavin/dutyassistant/internal/telegram/parse"
+)
+
+const notificationsMenuText = "🔔 <b>Notification settings</b>\n\n" +
+	"Tap a setting to turn it on or off. Daily reminders arrive at the time below (Berlin time)."
+
+// notificationLabels are the menu labels for each notification kind.
+var notificationLabels = map[notification.Kind]string{
+	notification.KindGroupReminder: "Daily reminder of who's on duty",
+	notification.KindPersonalDM:    "Reminder when I'm on duty",
+	notification.KindWeeklyStats:   "Weekly stats",
+	notification.KindSwapRequests:  "Swap requests",
+}
+
+// HandleNotifications shows the user's notification settings menu. Format: /notifications
+func (h *Handlers) HandleNotifications(m *tgbotapi.Message) (tgbotapi.MessageConfig, error) {
+	ctx := context.Background()
+	user, err := h.Users.ByTelegramID(ctx, m.From.ID)
+	if err != nil {
+		return tgbotapi.NewMessage(m.Chat.ID, volunteerUserNotFoundMessage), nil
+	}
+
+	prefs, err := notification.Preferences(ctx, h.Store, user.ID)
+	if err != nil {
+		log.Printf("[HandleNotifications] Failed to load preferences for user %d: %v", user.ID, err)
+		return tgbotapi.NewMessage(m.Chat.ID, genericErrorMessage), nil
+	}
+
+	msg := tgbotapi.NewMessage(m.Chat.ID, notificationsMenuText)
+	msg.ParseMode = tgbotapi.ModeHTML
+	msg.ReplyMarkup = notificationsKeyboard(prefs)
+	return msg, nil
+}
+
+// HandleNotificationsCallback handles taps in the notification settings menu.
+// Callbacks only ever change the settings of the user who tapped.
+func (h *Handlers) HandleNotificationsCallback(q *tgbotapi.CallbackQuery) (tgbotapi.EditMessageTextConfig, error) {
+	ctx := context.Background()
+	user, err := h.Users.ByTelegramID(ctx, q.From.ID)
+	if err != nil {
+		return tgbotapi.NewEditMessageText(q.Message.Chat.ID, q.Message.MessageID, "❌ "+volunteerUserNotFoundMessage), nil
+	}
+	prefs, err := notification.Preferences(ctx, h.Store, user.ID)
+	if err != nil {
+		return tgbotapi.EditMessageTextConfig{}, fmt.Errorf("failed to load notification preferences: %w", err)
+	}
+
+	cb := parse.ParseCallback(q.Data)
+	keyboard := notificationsKeyboard(prefs)
+	switch cb.Action {
+	case "notif_toggle":
+		if err := cb.Expect(1); err != nil {
+			return tgbotapi.EditMessageTextConfig{}, err
+		}
+		kind := notification.Kind(cb.Args[0])
+		if _, ok := notificationLabels[kind]; !ok {
+			return tgbotapi.EditMessageTextConfig{}, parse.ErrInvalidCallback
+		}
+		notification.SetWants(prefs, kind, !notification.Wants(prefs, kind))
+		if err := h.Store.SetNotificationPreferences(ctx, prefs); err != nil {
+			return tgbotapi.EditMessageTextConfig{}, fmt.Errorf("failed to save notification preferences: %w", err)
+		}
+		keyboard = notificationsKeyboard(prefs)
+	case "notif_time":
+		keyboard = reminderHourKeyboard(prefs.ReminderHour)
+	case "notif_hour":
+		hour, err := cb.IntInRange(0, store.EarliestReminderHour, store.LatestReminderHour)
+		if err != nil {
+			return tgbotapi.EditMessageTextConfig{}, err
+		}
+		prefs.ReminderHour = hour
+		if err := h.Store.SetNotificationPreferences(ctx, prefs); err != nil {
+			return tgbotapi.EditMessageTextConfig{}, fmt.Errorf("failed to save notification preferences: %w", err)
+		}
+		keyboard = notificationsKeyboard(prefs)
+	}
+
+	edit := tgbotapi.NewEditMessageText(q.Message.Chat.ID, q.Message.MessageID, notificationsMenuText)
+	edit.ParseMode = tgbotapi.ModeHTML
+	edit.ReplyMarkup = &keyboard
+	return edit, nil
+}
+
+// notificationsKeyboard builds the settings menu: one toggle per kind and the reminder time.
+func notificationsKeyboard(prefs *store.NotificationPreferences) tgbotapi.InlineKeyboardMarkup {
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for _, kind := range notification.Kinds {
+		mark := "❌"
+		if notification.Wants(prefs, kind) {
+			mark = "✅"
+		}
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData(
+			fmt.Sprintf("%s %s", mark, notificationLabels[kind]),
+			fmt.Sprintf("notif_toggle:%s", kind),
+		)))
+	}
+	rows = append(rows, tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData(
+		fmt.Sprintf("🕐 Reminder time: %02d:00", prefs.ReminderHour), "notif_time",
+	)))
+	return tgbotapi.NewInlineKeyboardMarkup(rows...)
+}
+
+// reminderHourKeyboard lets the user pick the hour of their daily reminders.
+// Reminders can't come before the 11:00 assignment.
+func reminderHourKeyboard(current int) tgbotapi.InlineKeyboardMarkup {
+	var rows [][]tgbotapi.InlineKeyboardButton
+	var row []tgbotapi.InlineKeyboardButton
+	for hour := store.EarliestReminderHour; hour <= store.LatestReminderHour; hour++ {
+		label := fmt.Sprintf("%02d:00", hour)
+		if hour == current {
+			label = "• " + label
+		}
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData(label, fmt.Sprintf("notif_hour:%d", hour)))
+		if len(row) == 5 {
+			rows = append(rows, row)
+			row = nil
+		}
+	}
+	if len(row) > 0 {
+		rows = append(rows, row)
+	}
+	rows = append(rows, tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData("« Back", "notif_back")))
+	return tgbotapi.NewInlineKeyboardMarkup(rows...)
+}
+
+// HandleSnoozeCallback postpones the personal duty reminder the button was attached to.
+func (h *Handlers) HandleSnoozeCallback(q *tgbotapi.CallbackQuery) (tgbotapi.EditMessageTextConfig, error) {
+	cb := parse.ParseCallback(q.Data)
+	if err := cb.Expect(1); err != nil {
+		return tgbotapi.EditMessageTextConfig{}, err
+	}
+	hours, err := cb.IntInRange(0, 1, notification.SnoozeHours[len(notification.SnoozeHours)-1])
+	if err != nil {
+		return tgbotapi.EditMessageTextConfig{}, err
+	}
+
+	ctx := context.Background()
+	user, err := h.Users.ByTelegramID(ctx, q.From.ID)
+	if err != nil {
+		return tgbotapi.NewEditMessageText(q.Message.Chat.ID, q.Message.MessageID, "❌ "+volunteerUserNotFoundMessage), nil
+	}
+	if h.Notifier == nil {
+		return tgbotapi.NewEditMessageText(q.Message.Chat.ID, q.Message.MessageID, genericErrorMessage), nil
+	}
+
+	remindAt, err := h.Notifier.Snooze(ctx, user, hours)
+	if errors.Is(err, notification.ErrNotOnDuty) {
+		return tgbotapi.NewEditMessageText(q.Message.Chat.ID, q.Message.MessageID,
+			q.Message.Text+"\n\n✅ Nothing to remind you about anymore, you're not on duty today."), nil
+	}
+	if err != nil {
+		log.Printf("[HandleSnoozeCallback] Failed to snooze reminder for user %d: %v", user.ID, err)
+		return tgbotapi.NewEditMessageText(q.Message.Chat.ID, q.Message.MessageID, genericErrorMessage), nil
+	}
+
+	// Editing the text drops the buttons, so the reminder can't be snoozed twice.
+	return tgbotapi.NewEditMessageText(q.Message.Chat.ID, q.Message.MessageID,
+		fmt.Sprintf("%s\n\n⏰ Snoozed. I'll remind you again at %s.", q.Message.Text, remindAt.Format("15:04"))), nil
+}
+
+// HandleAcknowledgeCallback records that the user on duty confirmed the duty
+// of the personal reminder the button was attached to.
+func (h *Handlers) HandleAcknowledgeCallback(q *tgbotapi.CallbackQuery) (tgbotapi.EditMessageTextConfig, error) {
+	cb := parse.ParseCallback(q.Data)
+	if err := cb.Expect(1); err != nil {
+		return tgbotapi.EditMessageTextConfig{}, err
+	}
+	date, err := cb.Date(0)
+	if err != nil {
+		return tgbotapi.EditMessageTextConfig{}, err
+	}
+
+	ctx := context.Background()
+	user, err := h.Users.ByTelegramID(ctx, q.From.ID)
+	if err != nil {
+		return tgbotapi.NewEditMessageText(q.Message.Chat.ID, q.Message.MessageID, "❌ "+volunteerUserNotFoundMessage), nil
+	}
+
+	_, err = h.Duties.Acknowledge(ctx, date, user)
+	if errors.Is(err, scheduler.ErrNoDuty) {
+		return tgbotapi.NewEditMessageText(q.Message.Chat.ID, q.Message.MessageID,
+			q.Message.Text+"\n\n✅ Nothing to do, you're not on duty that day anymore."), nil
+	}
+	if err != nil {
+		log.Printf("[HandleAcknowledgeCallback] Failed to acknowledge duty for user %d: %v", user.ID, err)
+		return tgbotapi.NewEditMessageText(q.Message.Chat.ID, q.Message.MessageID, genericErrorMessage), nil
+	}
+
+	// Editing the text drops the buttons, as after a snooze.
+	return tgbotapi.NewEditMessageText(q.Message.Chat.ID, q.Message.MessageID, q.Message.Text+"\n\n👍 Thanks, noted!"), nil
+}
@@ -0,0 +1,307 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/korjavin/dutyassistant/internal/scheduler"
+	"github.com/korjavin/dutyassistant/internal/service/offduty"
+	"github.com/korjavin/dutyassistant/internal/store"
+	"github.com/korjavin/dutyassistant/internal/telegram/parse"
+)
+
+// Callback actions of the buttons under an import preview.
+const (
+	offDutyImportAction       = "offimport_ok"
+	offDutyImportCancelAction = "offimport_cancel"
+)
+
+// offDutyImportTTL is how long a previewed import waits for its confirmation.
+// The imports are stored, so they can be confirmed after a restart too.
+const offDutyImportTTL = time.Hour
+
+const offDutyImportUsageMessage = "🏫 <b>Import off-duty periods</b>\n\n" +
+	"Put the users on the first line, <code>all</code> for every active member, and the periods on the next ones:\n\n" +
+	"<code>/offduty_import Alice, Bob\n" +
+	"2025-10-27 2025-10-31 Autumn break\n" +
+	"2025-12-22 2026-01-06 Christmas</code>\n\n" +
+	"One period per line, a single date for a single day. Instead of lines, you can paste an iCal calendar " +
+	"(BEGIN:VCALENDAR ...) and each of its events is imported. You'll see a preview to confirm first."
+
+// offDutyImport is an import previewed to an admin, waiting to be confirmed.
+// Like any menu, only the admin who asked for it may press its buttons.
+type offDutyImport struct {
+	users     []*store.User
+	periods   []offduty.Period
+	createdAt time.Time
+}
+
+// offDutyImports are the previewed imports by ID.
+type offDutyImports struct {
+	mu      sync.Mutex
+	next    int
+	pending map[int]*offDutyImport
+}
+
+// add keeps imp until it's confirmed and returns its ID.
+func (p *offDutyImports) add(imp *offDutyImport) int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.pending == nil {
+		p.pending = make(map[int]*offDutyImport)
+	}
+	for id, other := range p.pending {
+		if time.Since(other.createdAt) > offDutyImportTTL {
+			delete(p.pending, id)
+		}
+	}
+	p.next++
+	p.pending[p.next] = imp
+	return p.next
+}
+
+// restore puts back an import with the ID stored before a restart.
+func (p *offDutyImports) restore(id int, imp *offDutyImport) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.pending == nil {
+		p.pending = make(map[int]*offDutyImport)
+	}
+	p.pending[id] = imp
+	p.next = max(p.next, id)
+}
+
+// take removes and returns the import with the ID, or nil if it's gone.
+func (p *offDutyImports) take(id int) *offDutyImport {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	imp, ok := p.pending[id]
+	if !ok || time.Since(imp.createdAt) > offDutyImportTTL {
+		return nil
+	}
+	delete(p.pending, id)
+	return imp
+}
+
+// HandleOffDutyImport previews off-duty periods to import for several users
+// at once, like a school's holidays, with buttons to import them.
+// Format: /offduty_import <users|all>, then one period per line or a calendar
+func (h *Handlers) HandleOffDutyImport(m *tgbotapi.Message) (tgbotapi.MessageConfig, error) {
+	isAdmin, err := h.checkAdmin(m.From.ID)
+	if err != nil || !isAdmin {
+		return tgbotapi.NewMessage(m.Chat.ID, adminOnlyMessage), nil
+	}
+
+	first, rest, _ := strings.Cut(m.CommandArguments(), "\n")
+	if strings.TrimSpace(first) == "" || strings.TrimSpace(rest) == "" {
+		msg := tgbotapi.NewMessage(m.Chat.ID, offDutyImportUsageMessage)
+		msg.ParseMode = tgbotapi.ModeHTML
+		return msg, nil
+	}
+
+	ctx := context.Background()
+	users, err := h.importUsers(ctx, first)
+	if err != nil {
+		return tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("❌ %v", err)), nil
+	}
+	periods, err := offduty.Parse(rest)
+	if err != nil {
+		return tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("❌ Invalid periods: %v", err)), nil
+	}
+	// Periods that are over don't matter for the rotation anymore
+	today := h.today()
+	var upcoming []offduty.Period
+	for _, p := range periods {
+		if !p.End.Before(today) {
+			upcoming = append(upcoming, p)
+		}
+	}
+	if len(upcoming) == 0 {
+		return tgbotapi.NewMessage(m.Chat.ID, "⌛ All these periods are over already, there is nothing to import."), nil
+	}
+
+	l := h.locale(ctx, m.Chat.ID)
+	var b strings.Builder
+	b.WriteString("🏫 <b>Import off-duty periods</b>\n\nFor: ")
+	names := make([]string, 0, len(users))
+	for _, u := range users {
+		names = append(names, escapeHTML(u.FirstName))
+	}
+	b.WriteString(strings.Join(names, ", "))
+	b.WriteString("\n")
+	for _, p := range upcoming {
+		fmt.Fprintf(&b, "\n• %s", l.Format(p.Start, "Mon, Jan 2 2006"))
+		if !p.End.Equal(p.Start) {
+			fmt.Fprintf(&b, " – %s", l.Format(p.End, "Mon, Jan 2 2006"))
+		}
+		if p.Summary != "" {
+			fmt.Fprintf(&b, ": %s", escapeHTML(p.Summary))
+		}
+	}
+	if skipped := len(periods) - len(upcoming); skipped > 0 {
+		fmt.Fprintf(&b, "\n\n%d period(s) that are over already are left out.", skipped)
+	}
+	clashes, err := h.importClashes(ctx, users, upcoming)
+	if err != nil {
+		return tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("❌ Failed to check the duties: %v", err)), nil
+	}
+	if len(clashes) > 0 {
+		b.WriteString("\n\n⚠️ These duties are assigned already and stay as they are, change them with /change:")
+		for _, d := range clashes {
+			fmt.Fprintf(&b, "\n%s: %s", l.Format(d.DutyDate, "Mon, Jan 2"), escapeHTML(d.User.FirstName))
+		}
+	}
+	fmt.Fprintf(&b, "\n\nImport %d period(s) for %d user(s)?", len(upcoming), len(users))
+
+	createdAt := time.Now()
+	id := h.imports.add(&offDutyImport{users: users, periods: upcoming, createdAt: createdAt})
+	userIDs := make([]int64, 0, len(users))
+	for _, u := range users {
+		userIDs = append(userIDs, u.ID)
+	}
+	h.saveState(ctx, importStateKey(id), importState{UserIDs: userIDs, Periods: upcoming, CreatedAt: createdAt},
+		createdAt.Add(offDutyImportTTL))
+	msg := tgbotapi.NewMessage(m.Chat.ID, b.String())
+	msg.ParseMode = tgbotapi.ModeHTML
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData("✅ Import", fmt.Sprintf("%s:%d", offDutyImportAction, id)),
+		tgbotapi.NewInlineKeyboardButtonData("✖️ Cancel", fmt.Sprintf("%s:%d", offDutyImportCancelAction, id)),
+	))
+	return msg, nil
+}
+
+// importUsers returns the users of the first line of /offduty_import: names
+// or #IDs separated by commas or spaces, or "all" for the active members.
+func (h *Handlers) importUsers(ctx context.Context, line string) ([]*store.User, error) {
+	refs := strings.FieldsFunc(line, func(r rune) bool { return r == ',' || r == ' ' || r == '\t' })
+	if len(refs) == 1 && strings.EqualFold(refs[0], "all") {
+		active, err := h.Store.ListActiveUsers(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get users: %v", err)
+		}
+		var users []*store.User
+		for _, u := range active {
+			if !u.IsPending {
+				users = append(users, u)
+			}
+		}
+		if len(users) == 0 {
+			return nil, fmt.Errorf("there are no active users")
+		}
+		return users, nil
+	}
+
+	var users []*store.User
+	seen := make(map[int64]bool)
+	for _, ref := range refs {
+		u, err := h.Users.Find(ctx, ref)
+		if err != nil {
+			return nil, fmt.Errorf(userNotFoundMessage, ref)
+		}
+		if !seen[u.ID] {
+			seen[u.ID] = true
+			users = append(users, u)
+		}
+	}
+	return users, nil
+}
+
+// importClashes returns the duties of users, other than drafts, on the days
+// of periods.
+func (h *Handlers) importClashes(ctx context.Context, users []*store.User, periods []offduty.Period) ([]*store.Duty, error) {
+	importing := make(map[int64]bool, len(users))
+	for _, u := range users {
+		importing[u.ID] = true
+	}
+	months := make(map[time.Time]bool)
+	for _, p := range periods {
+		for month := time.Date(p.Start.Year(), p.Start.Month(), 1, 0, 0, 0, 0, time.UTC); !month.After(p.End); month = month.AddDate(0, 1, 0) {
+			months[month] = true
+		}
+	}
+
+	var clashes []*store.Duty
+	for month := range months {
+		duties, err := h.Store.GetDutiesByMonth(ctx, month.Year(), month.Month())
+		if err != nil {
+			return nil, err
+		}
+		for _, d := range duties {
+			if !importing[d.UserID] || d.User == nil || d.Status == store.DutyStatusProvisional || d.DutyDate.Before(h.today()) {
+				continue
+			}
+			for _, p := range periods {
+				if !d.DutyDate.Before(p.Start) && !d.DutyDate.After(p.End) {
+					clashes = append(clashes, d)
+					break
+				}
+			}
+		}
+	}
+	slices.SortFunc(clashes, func(a, b *store.Duty) int { return a.DutyDate.Compare(b.DutyDate) })
+	return clashes, nil
+}
+
+// HandleOffDutyImportCallback imports the periods of a preview, all or none
+// of them, or cancels it.
+func (h *Handlers) HandleOffDutyImportCallback(q *tgbotapi.CallbackQuery) (tgbotapi.EditMessageTextConfig, error) {
+	if refusal, ok := h.refuseNonAdminCallback(q); !ok {
+		return refusal, nil
+	}
+	cb := parse.ParseCallback(q.Data)
+	if err := cb.Expect(1); err != nil {
+		return tgbotapi.EditMessageTextConfig{}, err
+	}
+	id, err := cb.ID(0)
+	if err != nil {
+		return tgbotapi.EditMessageTextConfig{}, err
+	}
+	ctx := context.Background()
+	imp := h.imports.take(int(id))
+	h.deleteState(ctx, importStateKey(int(id)))
+	if imp == nil {
+		return tgbotapi.NewEditMessageText(q.Message.Chat.ID, q.Message.MessageID,
+			"⌛ This import expired. Send /offduty_import again."), nil
+	}
+	if cb.Action == offDutyImportCancelAction {
+		return tgbotapi.NewEditMessageText(q.Message.Chat.ID, q.Message.MessageID, "👌 Import cancelled."), nil
+	}
+
+	added := 0
+	err = h.Scheduler.RunInTx(ctx, func(tx scheduler.SchedulerInterface) error {
+		for _, u := range imp.users {
+			periods := make([]*store.OffDutyPeriod, 0, len(imp.periods))
+			for _, p := range imp.periods {
+				periods = append(periods, &store.OffDutyPeriod{
+					UserID: u.ID, StartDate: p.Start, EndDate: p.End, Source: store.OffDutySourceImport, Summary: p.Summary,
+				})
+			}
+			periodsAdded, err := tx.AddOffDutyPeriods(ctx, u.ID, periods)
+			if err != nil {
+				return fmt.Errorf("%s: %w", u.FirstName, err)
+			}
+			added += len(periodsAdded)
+		}
+		return nil
+	})
+	if err != nil {
+		log.Printf("[HandleOffDutyImportCallback] Failed to import off-duty periods: %v", err)
+		return tgbotapi.NewEditMessageText(q.Message.Chat.ID, q.Message.MessageID,
+			fmt.Sprintf("❌ Failed to import, nothing was changed: %v", err)), nil
+	}
+
+	text := fmt.Sprintf("✅ Imported %d off-duty period(s) for %d user(s).", added, len(imp.users))
+	if known := len(imp.users)*len(imp.periods) - added; known > 0 {
+		text += fmt.Sprintf(" %d were there already.", known)
+	}
+	return tgbotapi.NewEditMessageText(q.Message.Chat.ID, q.Message.MessageID, text), nil
+}
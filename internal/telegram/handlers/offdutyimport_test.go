@@ -0,0 +1,88 @@
+package handlers_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/korjavin/dutyassistant/internal/scheduler"
+	"github.com/korjavin/dutyassistant/internal/store"
+	"github.com/korjavin/dutyassistant/internal/store/memory"
+	"github.com/korjavin/dutyassistant/internal/telegram/handlers"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandleOffDutyImport(t *testing.T) {
+	ctx := context.Background()
+	s := memory.New()
+	h := handlers.New(s, scheduler.NewScheduler(s))
+	if err := s.CreateUser(ctx, &store.User{TelegramUserID: 123, FirstName: "Admin", IsAdmin: true}); err != nil {
+		t.Fatal(err)
+	}
+	alice := &store.User{TelegramUserID: 456, FirstName: "Alice", IsActive: true}
+	bob := &store.User{TelegramUserID: 789, FirstName: "Bob", IsActive: true}
+	for _, u := range []*store.User{alice, bob} {
+		if err := s.CreateUser(ctx, u); err != nil {
+			t.Fatal(err)
+		}
+	}
+	now := time.Now()
+	year := now.Year() + 1
+	day := func(m time.Month, d int) time.Time { return time.Date(year, m, d, 0, 0, 0, 0, time.UTC) }
+	if err := s.CreateDuty(ctx, &store.Duty{UserID: bob.ID, DutyDate: day(time.March, 3), AssignmentType: store.AssignmentTypeAdmin, Status: store.DutyStatusAnnounced}); err != nil {
+		t.Fatal(err)
+	}
+	periods := fmt.Sprintf("%d-03-02 %d-03-06 Spring break\n%d-05-01 May Day\n2001-01-01 Long ago", year, year, year)
+	press := func(data string) string {
+		edit, err := h.HandleOffDutyImportCallback(&tgbotapi.CallbackQuery{
+			From:    &tgbotapi.User{ID: 123},
+			Message: &tgbotapi.Message{MessageID: 8, Chat: &tgbotapi.Chat{ID: 789}},
+			Data:    data,
+		})
+		assert.NoError(t, err)
+		return edit.Text
+	}
+
+	msg, err := h.HandleOffDutyImport(adminCommand("offduty_import", "Alice"))
+	assert.NoError(t, err)
+	assert.Contains(t, msg.Text, "Put the users on the first line")
+	msg, err = h.HandleOffDutyImport(adminCommand("offduty_import", "Alice, Nobody\n"+periods))
+	assert.NoError(t, err)
+	assert.Equal(t, "❌ Could not find user: Nobody", msg.Text)
+	msg, err = h.HandleOffDutyImport(adminCommand("offduty_import", "all\nSpring break"))
+	assert.NoError(t, err)
+	assert.Contains(t, msg.Text, "❌ Invalid periods: line 1")
+
+	msg, err = h.HandleOffDutyImport(adminCommand("offduty_import", "all\n"+periods))
+	assert.NoError(t, err)
+	assert.Contains(t, msg.Text, "For: Alice, Bob")
+	assert.Contains(t, msg.Text, "Spring break")
+	assert.Contains(t, msg.Text, "1 period(s) that are over already are left out.")
+	assert.Contains(t, msg.Text, "assigned already and stay as they are", "Bob's duty during the break is shown")
+	assert.Contains(t, msg.Text, "Import 2 period(s) for 2 user(s)?")
+	buttons := msg.ReplyMarkup.(tgbotapi.InlineKeyboardMarkup).InlineKeyboard[0]
+	assert.Len(t, buttons, 2)
+
+	// Nothing is set off duty before it is confirmed
+	off, _ := s.IsUserOffDuty(ctx, alice.ID, day(time.March, 4))
+	assert.False(t, off)
+	assert.Equal(t, "✅ Imported 4 off-duty period(s) for 2 user(s).", press(*buttons[0].CallbackData))
+	for _, u := range []*store.User{alice, bob} {
+		off, _ := s.IsUserOffDuty(ctx, u.ID, day(time.March, 4))
+		assert.True(t, off, "%s is off duty during the break", u.FirstName)
+		off, _ = s.IsUserOffDuty(ctx, u.ID, day(time.May, 1))
+		assert.True(t, off, "%s is off duty on May Day", u.FirstName)
+	}
+	assert.Contains(t, press(*buttons[0].CallbackData), "This import expired")
+
+	// Imported again, the periods are known already
+	msg, _ = h.HandleOffDutyImport(adminCommand("offduty_import", "Alice\n"+periods))
+	buttons = msg.ReplyMarkup.(tgbotapi.InlineKeyboardMarkup).InlineKeyboard[0]
+	assert.Equal(t, "✅ Imported 0 off-duty period(s) for 1 user(s). 2 were there already.", press(*buttons[0].CallbackData))
+
+	msg, _ = h.HandleOffDutyImport(adminCommand("offduty_import", "Alice\n"+periods))
+	buttons = msg.ReplyMarkup.(tgbotapi.InlineKeyboardMarkup).InlineKeyboard[0]
+	assert.Equal(t, "👌 Import cancelled.", press(*buttons[1].CallbackData))
+}
@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/korjavin/dutyassistant/internal/service/ledger"
+	"github.com/korjavin/dutyassistant/internal/service/settings"
+	"github.com/korjavin/dutyassistant/internal/telegram/parse"
+)
+
+const paymentsOffMessage = "❌ Fines can't be paid in Telegram here. Please pay an admin, who records it with /balance paid."
+
+// HandlePayFineCallback sends an invoice of the balance of the user of the
+// button, for whoever pressed it to pay in Telegram.
+// Format: pay_fine:<user ID>
+func (h *Handlers) HandlePayFineCallback(q *tgbotapi.CallbackQuery) (tgbotapi.Chattable, error) {
+	cb := parse.ParseCallback(q.Data)
+	if err := cb.Expect(1); err != nil {
+		return nil, err
+	}
+	userID, err := cb.ID(0)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := context.Background()
+	chatID := q.Message.Chat.ID
+	if !h.Payments.On() {
+		return tgbotapi.NewMessage(chatID, paymentsOffMessage), nil
+	}
+	user, err := h.Users.ByID(ctx, userID)
+	if err != nil {
+		return tgbotapi.NewMessage(chatID, "❌ This user isn't on the roster anymore."), nil
+	}
+	payout, err := settings.New(h.Store).Payout(ctx)
+	if err != nil {
+		return nil, err
+	}
+	balance, err := h.Ledger.Balance(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if balance <= 0 {
+		return tgbotapi.NewMessage(chatID, fmt.Sprintf("✅ %s doesn't owe anything.", user.FirstName)), nil
+	}
+
+	inv := ledger.Invoice{UserID: user.ID, Amount: balance}
+	currency, amount := h.Payments.Price(balance, payout.Currency)
+	invoice := tgbotapi.NewInvoice(chatID, "Fines for missed duties",
+		fmt.Sprintf("Pays off the %s %s owes for missed duties.", ledger.FormatAmount(balance, payout.Currency), user.FirstName),
+		inv.Payload(), h.Payments.ProviderToken, "", currency, []tgbotapi.LabeledPrice{{Label: "Fines", Amount: int(amount)}})
+	// An empty list, as nil would be sent as null
+	invoice.SuggestedTipAmounts = []int{}
+	return invoice, nil
+}
+
+// HandlePreCheckout answers whether Telegram may take the money for an
+// invoice of a fine: only while the user still owes what it is for, and not
+// in read-only mode, when the payment couldn't be recorded.
+func (h *Handlers) HandlePreCheckout(q *tgbotapi.PreCheckoutQuery) tgbotapi.PreCheckoutConfig {
+	refuse := func(reason string) tgbotapi.PreCheckoutConfig {
+		return tgbotapi.PreCheckoutConfig{PreCheckoutQueryID: q.ID, ErrorMessage: reason}
+	}
+	inv, err := ledger.ParseInvoice(q.InvoicePayload)
+	if err != nil || !h.Payments.On() {
+		return refuse("This invoice can't be paid anymore.")
+	}
+	if h.readOnly() {
+		return refuse("The bot is under maintenance, please try again later.")
+	}
+
+	ctx := context.Background()
+	payout, err := settings.New(h.Store).Payout(ctx)
+	if err != nil {
+		log.Printf("[HandlePreCheckout] Failed to get the payout settings: %v", err)
+		return refuse("Something went wrong, please try again later.")
+	}
+	if currency, amount := h.Payments.Price(inv.Amount, payout.Currency); q.Currency != currency || int64(q.TotalAmount) != amount {
+		return refuse("This invoice can't be paid anymore.")
+	}
+	if err := h.Ledger.CheckInvoice(ctx, inv); errors.Is(err, ledger.ErrInvoiceOutdated) {
+		return refuse("The balance changed since this invoice was sent. Please ask for a new one with /balance pay.")
+	} else if err != nil {
+		log.Printf("[HandlePreCheckout] Failed to check invoice %s: %v", q.InvoicePayload, err)
+		return refuse("Something went wrong, please try again later.")
+	}
+	return tgbotapi.PreCheckoutConfig{PreCheckoutQueryID: q.ID, OK: true}
+}
+
+// HandleSuccessfulPayment records the payment of a fine invoice in the ledger,
+// settling the balance it was for.
+func (h *Handlers) HandleSuccessfulPayment(m *tgbotapi.Message) (tgbotapi.Chattable, error) {
+	p := m.SuccessfulPayment
+	inv, err := ledger.ParseInvoice(p.InvoicePayload)
+	if err != nil {
+		log.Printf("[HandleSuccessfulPayment] Ignoring payment %s with payload %q", p.TelegramPaymentChargeID, p.InvoicePayload)
+		return nil, nil
+	}
+
+	ctx := context.Background()
+	_, added, err := h.Ledger.RecordInvoicePayment(ctx, inv, p.TelegramPaymentChargeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to record payment %s: %w", p.TelegramPaymentChargeID, err)
+	}
+	if !added {
+		return nil, nil
+	}
+	log.Printf("[PAYMENT] User %d paid %d cents of user %d's fines (%s)", m.From.ID, inv.Amount, inv.UserID, p.TelegramPaymentChargeID)
+
+	user, err := h.Users.ByID(ctx, inv.UserID)
+	if err != nil {
+		return nil, err
+	}
+	payout, err := settings.New(h.Store).Payout(ctx)
+	if err != nil {
+		return nil, err
+	}
+	balance, err := h.Ledger.Balance(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	return tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("✅ Thanks! Recorded %s paid for %s, who now owes %s.",
+		ledger.FormatAmount(inv.Amount, payout.Currency), user.FirstName, ledger.FormatAmount(balance, payout.Currency))), nil
+}
@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/korjavin/dutyassistant/internal/notification"
+	"github.com/korjavin/dutyassistant/internal/scheduler"
+	"github.com/korjavin/dutyassistant/internal/service/duty"
+	"github.com/korjavin/dutyassistant/internal/store"
+	"github.com/korjavin/dutyassistant/internal/telegram/parse"
+)
+
+// HandlePollCallback volunteers whoever pressed a day of the Sunday poll for
+// that day, and updates the poll to show it taken.
+// Format: poll_volunteer:<date>
+func (h *Handlers) HandlePollCallback(q *tgbotapi.CallbackQuery) (tgbotapi.Chattable, error) {
+	cb := parse.ParseCallback(q.Data)
+	if err := cb.Expect(1); err != nil {
+		return nil, err
+	}
+	date, err := cb.Date(0)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := context.Background()
+	chatID, messageID := q.Message.Chat.ID, q.Message.MessageID
+	user, err := h.Users.ByTelegramID(ctx, q.From.ID)
+	if err != nil {
+		return tgbotapi.NewMessage(chatID, volunteerUserNotFoundMessage), nil
+	}
+
+	// Refusals are for the one who pressed, the poll stays as it is for everyone
+	dateStr := date.Format(parse.DateLayout)
+	_, err = h.Duties.Volunteer(ctx, date, user)
+	switch {
+	case errors.Is(err, scheduler.ErrDutyTaken):
+		return tgbotapi.NewMessage(chatID, fmt.Sprintf("Someone took %s already.", dateStr)), nil
+	case errors.Is(err, scheduler.ErrPastDate):
+		return tgbotapi.NewMessage(chatID, fmt.Sprintf("%s is over already.", dateStr)), nil
+	case errors.Is(err, duty.ErrOffDuty):
+		return tgbotapi.NewMessage(chatID, fmt.Sprintf("%s, you're off duty on %s.", user.GroupName(), dateStr)), nil
+	case err != nil:
+		return tgbotapi.NewMessage(chatID, fmt.Sprintf("❌ Couldn't take %s: %v", dateStr, err)), nil
+	}
+
+	from := notification.PollWeek(date)
+	duties, err := h.Store.ListDuties(ctx, store.DutyFilter{From: from, To: from.AddDate(0, 0, 7)})
+	if err != nil {
+		log.Printf("[HandlePollCallback] Failed to list the duties of the week from %s: %v", from.Format(parse.DateLayout), err)
+		return tgbotapi.NewMessage(chatID, fmt.Sprintf("✅ %s is on duty on %s.", user.GroupName(), dateStr)), nil
+	}
+	l := h.locale(ctx, chatID)
+	edit := tgbotapi.NewEditMessageText(chatID, messageID, notification.FormatWeeklyPoll(l, from, duties))
+	if buttons := notification.PollButtons(l, from, duties); len(buttons) > 0 {
+		row := make([]tgbotapi.InlineKeyboardButton, 0, len(buttons))
+		for _, button := range buttons {
+			row = append(row, tgbotapi.NewInlineKeyboardButtonData(button.Text, button.Data))
+		}
+		markup := tgbotapi.NewInlineKeyboardMarkup(row)
+		edit.ReplyMarkup = &markup
+	}
+	return edit, nil
+}
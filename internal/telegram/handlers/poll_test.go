@@ -0,0 +1,55 @@
+package handlers_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/korjavin/dutyassistant/internal/notification"
+	"github.com/korjavin/dutyassistant/internal/scheduler"
+	"github.com/korjavin/dutyassistant/internal/store"
+	"github.com/korjavin/dutyassistant/internal/store/memory"
+	"github.com/korjavin/dutyassistant/internal/telegram/handlers"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandlePollCallback(t *testing.T) {
+	ctx := context.Background()
+	s := memory.New()
+	h := handlers.New(s, scheduler.NewScheduler(s))
+	alice := &store.User{TelegramUserID: 123, FirstName: "Alice", IsActive: true}
+	bob := &store.User{TelegramUserID: 456, FirstName: "Bob", IsActive: true}
+	for _, u := range []*store.User{alice, bob} {
+		if err := s.CreateUser(ctx, u); err != nil {
+			t.Fatal(err)
+		}
+	}
+	from := notification.PollWeek(scheduler.Today(time.Now(), 0).AddDate(0, 0, 7))
+	tuesday := from.AddDate(0, 0, 1).Format("2006-01-02")
+	press := func(telegramUserID int64, date string) tgbotapi.Chattable {
+		response, err := h.HandlePollCallback(&tgbotapi.CallbackQuery{
+			From:    &tgbotapi.User{ID: telegramUserID},
+			Message: &tgbotapi.Message{MessageID: 1, Chat: &tgbotapi.Chat{ID: -100}},
+			Data:    notification.VolunteerPollAction + ":" + date,
+		})
+		assert.NoError(t, err)
+		return response
+	}
+
+	edit, ok := press(123, tuesday).(tgbotapi.EditMessageTextConfig)
+	if assert.True(t, ok, "the poll is updated") {
+		assert.Contains(t, edit.Text, "Alice 🙋")
+		assert.Len(t, edit.ReplyMarkup.InlineKeyboard[0], 6, "Tuesday is taken")
+	}
+	duty, _ := s.GetDutyByDate(ctx, from.AddDate(0, 0, 1))
+	if assert.NotNil(t, duty) {
+		assert.Equal(t, alice.ID, duty.UserID)
+		assert.Equal(t, store.AssignmentTypeVoluntary, duty.AssignmentType)
+	}
+
+	msg, ok := press(456, tuesday).(tgbotapi.MessageConfig)
+	if assert.True(t, ok, "a refusal leaves the poll alone") {
+		assert.Equal(t, "Someone took "+tuesday+" already.", msg.Text)
+	}
+}
@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/korjavin/dutyassistant/internal/service/user"
+	"github.com/korjavin/dutyassistant/internal/store"
+)
+
+const poolUsageMessage = "📆 <b>Rotation pools</b>\n\n" +
+	"Usage: <code>/pool user all|weekdays|weekends</code>\n\n" +
+	"Users in the weekdays or weekends pool are only picked on those days, " +
+	"and only stand in on the others if nobody else can. " +
+	"<code>/pool user</code> shows a user's pool."
+
+// poolLabels describe each pool in messages.
+var poolLabels = map[store.Pool]string{
+	store.PoolAll:      "every day",
+	store.PoolWeekdays: "weekdays only",
+	store.PoolWeekends: "weekends only",
+}
+
+// HandlePool shows or sets which days of the week a user is on the roster
+// for, for admins. Format: /pool <user> [all|weekdays|weekends]
+func (h *Handlers) HandlePool(m *tgbotapi.Message) (tgbotapi.MessageConfig, error) {
+	isAdmin, err := h.checkAdmin(m.From.ID)
+	if err != nil || !isAdmin {
+		return tgbotapi.NewMessage(m.Chat.ID, adminOnlyMessage), nil
+	}
+
+	args := strings.Fields(m.CommandArguments())
+	if len(args) < 1 || len(args) > 2 {
+		msg := tgbotapi.NewMessage(m.Chat.ID, poolUsageMessage)
+		msg.ParseMode = tgbotapi.ModeHTML
+		return msg, nil
+	}
+
+	ctx := context.Background()
+	u, err := h.Users.Find(ctx, args[0])
+	if err != nil {
+		return tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf(userNotFoundMessage, args[0])), nil
+	}
+	if len(args) == 2 {
+		pool, err := user.ParsePool(args[1])
+		if err != nil {
+			msg := tgbotapi.NewMessage(m.Chat.ID, poolUsageMessage)
+			msg.ParseMode = tgbotapi.ModeHTML
+			return msg, nil
+		}
+		if err := h.Users.SetPool(ctx, u, pool); err != nil {
+			return tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("❌ Failed to update %s: %v", u.FirstName, err)), nil
+		}
+	}
+	msg := tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("📆 <b>%s</b> is on the roster %s.", html.EscapeString(u.FirstName), poolLabels[u.Pool]))
+	msg.ParseMode = tgbotapi.ModeHTML
+	return msg, nil
+}
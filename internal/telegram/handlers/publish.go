@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/korjavin/dutyassistant/internal/scheduler"
+	"github.com/korjavin/dutyassistant/internal/store"
+	"github.com/korjavin/dutyassistant/internal/telegram/parse"
+)
+
+const publishUsageMessage = "📣 <b>Publish a month plan</b>\n\n" +
+	"<code>/publish draft [YYYY-MM]</code> - plan the month and show the draft\n" +
+	"<code>/publish [YYYY-MM]</code> - publish the draft\n\n" +
+	"The month defaults to the next one. A draft follows queue and availability changes " +
+	"until it is published; published days are announced once and only change by admin override."
+
+// HandlePublish drafts and publishes the plan of a month for admins.
+// Format: /publish [draft] [YYYY-MM]
+func (h *Handlers) HandlePublish(m *tgbotapi.Message) (tgbotapi.MessageConfig, error) {
+	isAdmin, err := h.checkAdmin(m.From.ID)
+	if err != nil || !isAdmin {
+		return tgbotapi.NewMessage(m.Chat.ID, adminOnlyMessage), nil
+	}
+
+	args := strings.Fields(m.CommandArguments())
+	draft := len(args) > 0 && args[0] == "draft"
+	if draft {
+		args = args[1:]
+	}
+	now := time.Now()
+	month := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC).AddDate(0, 1, 0)
+	switch len(args) {
+	case 0:
+	case 1:
+		if month, err = parse.Month(args[0]); err != nil {
+			return tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("❌ %v", err)), nil
+		}
+	default:
+		msg := tgbotapi.NewMessage(m.Chat.ID, publishUsageMessage)
+		msg.ParseMode = tgbotapi.ModeHTML
+		return msg, nil
+	}
+
+	ctx := context.Background()
+	monthStr := month.Format("January 2006")
+	if draft {
+		duties, err := h.Scheduler.DraftMonth(ctx, month)
+		if err != nil {
+			return tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("❌ Failed to draft %s: %v", monthStr, err)), nil
+		}
+		// Drafts are shown to admins but announced to nobody
+		h.InvalidateCalendars(ctx, nil)
+		msg := tgbotapi.NewMessage(m.Chat.ID, draftText(month, duties))
+		msg.ParseMode = tgbotapi.ModeHTML
+		return msg, nil
+	}
+
+	duties, err := h.Scheduler.PublishMonth(ctx, month)
+	if errors.Is(err, scheduler.ErrNoDraft) {
+		return tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf(
+			"There is no draft for %s. Review one with /publish draft %s first.", monthStr, month.Format(parse.MonthLayout))), nil
+	} else if err != nil {
+		return tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("❌ Failed to publish %s: %v", monthStr, err)), nil
+	}
+	return tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("📣 Published %d day(s) of %s and announced them to the group.", len(duties), monthStr)), nil
+}
+
+// draftText lists the duties of a drafted month, marking the ones that are
+// still provisional and would be published.
+func draftText(month time.Time, duties []*store.Duty) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "📝 <b>Draft for %s</b>\n", month.Format("January 2006"))
+	pending := 0
+	for _, d := range duties {
+		name := "unknown"
+		if d.User != nil {
+			name = escapeHTML(d.User.FirstName)
+		}
+		mark := ""
+		if d.Status == store.DutyStatusProvisional {
+			mark = " (draft)"
+			pending++
+		}
+		fmt.Fprintf(&b, "\n%s: %s%s", d.DutyDate.Format("Mon, Jan 2"), name, mark)
+	}
+	if pending == 0 {
+		b.WriteString("\n\nNothing left to publish.")
+		return b.String()
+	}
+	fmt.Fprintf(&b, "\n\nPublish the %d draft day(s) with <code>/publish %s</code>.", pending, month.Format(parse.MonthLayout))
+	return b.String()
+}
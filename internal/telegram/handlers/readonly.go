@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"slices"
+	"strings"
+
+	"github.com/korjavin/dutyassistant/internal/telegram/keyboard"
+)
+
+const readOnlyMessage = "🚧 Maintenance in progress. You can still look at the schedule, but changes are paused until it's over."
+
+// QueryCallbacks are the callback actions that only show something, so their
+// buttons still work in read-only mode.
+var QueryCallbacks = map[string]bool{
+	keyboard.ActionPrevMonth: true,
+	keyboard.ActionNextMonth: true,
+	keyboard.ActionSelectDay: true,
+	keyboard.ActionIgnore:    true,
+	"notif_time":             true,
+	"notif_back":             true,
+}
+
+// onlyReads reports whether the command with args only looks things up, so it
+// may run in read-only mode.
+func (c *Command) onlyReads(args string) bool {
+	if c.Query {
+		return true
+	}
+	first, _, _ := strings.Cut(strings.TrimSpace(args), " ")
+	return slices.Contains(c.QueryArgs, first)
+}
+
+// readOnly reports whether the bot is in read-only mode for maintenance. If
+// the setting can't be read, changes are let through, as they'd most likely
+// fail anyway.
+func (h *Handlers) readOnly() bool {
+	if h.Settings == nil {
+		return false
+	}
+	on, err := h.Settings.ReadOnly(context.Background())
+	if err != nil {
+		log.Printf("[AUTHZ] Failed to check read-only mode: %v", err)
+		return false
+	}
+	return on
+}
@@ -0,0 +1,62 @@
+package handlers_test
+
+import (
+	"context"
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/korjavin/dutyassistant/internal/scheduler"
+	"github.com/korjavin/dutyassistant/internal/service/settings"
+	"github.com/korjavin/dutyassistant/internal/store"
+	"github.com/korjavin/dutyassistant/internal/store/memory"
+	"github.com/korjavin/dutyassistant/internal/telegram/handlers"
+	"github.com/korjavin/dutyassistant/internal/telegram/keyboard"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadOnlyMode(t *testing.T) {
+	ctx := context.Background()
+	s := memory.New()
+	h := handlers.New(s, scheduler.NewScheduler(s))
+	h.Settings = settings.New(s)
+	if err := s.CreateUser(ctx, &store.User{TelegramUserID: 123, FirstName: "Admin", IsAdmin: true, IsActive: true}); err != nil {
+		t.Fatal(err)
+	}
+	refused := func(command, args string) bool {
+		refusal := h.AuthorizeCommand(adminCommand(command, args))
+		if refusal == nil {
+			return false
+		}
+		assert.Contains(t, refusal.(tgbotapi.MessageConfig).Text, "Maintenance in progress")
+		return true
+	}
+	press := func(data string) tgbotapi.Chattable {
+		return h.AuthorizeCallback(&tgbotapi.CallbackQuery{
+			From:    &tgbotapi.User{ID: 123},
+			Message: &tgbotapi.Message{MessageID: 5, Chat: &tgbotapi.Chat{ID: 789}},
+			Data:    data,
+		})
+	}
+
+	assert.False(t, refused("volunteer", "2"))
+	msg, err := h.HandleSettings(adminCommand("settings", "readonly on"))
+	assert.NoError(t, err)
+	assert.Contains(t, msg.Text, "Read-only mode is on")
+
+	assert.True(t, refused("volunteer", "2"))
+	assert.True(t, refused("balance", "paid Admin"))
+	assert.True(t, refused("checklist", "add daily Sweep"))
+	assert.False(t, refused("schedule", ""))
+	assert.False(t, refused("balance", ""))
+	assert.False(t, refused("checklist", "list"))
+	assert.NotNil(t, press("volunteer_days:3"))
+	assert.Nil(t, press(keyboard.ActionNextMonth+":2025-11"))
+
+	// The admin can still turn it off again
+	assert.False(t, refused("settings", "readonly off"))
+	msg, err = h.HandleSettings(adminCommand("settings", "readonly off"))
+	assert.NoError(t, err)
+	assert.Equal(t, "✅ Read-only mode is off, changes work again.", msg.Text)
+	assert.False(t, refused("volunteer", "2"))
+	assert.Nil(t, press("volunteer_days:3"))
+}
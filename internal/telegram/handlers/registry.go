@@ -0,0 +1,233 @@
+package handlers
+
+import (
+	"fmt"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const unknownCommandMessage = "Unknown command. Use /help for a list of commands."
+
+// Command is a bot command: how /help lists it, who may use it and the
+// handler it is routed to.
+type Command struct {
+	Name    string
+	Aliases []string // Other names routed to the same handler, not listed by /help
+	// Usage are the arguments /help shows after the name, e.g. "<date> <user>".
+	Usage       string
+	Description string
+	// AdminUsage and AdminDescription list what admins can do on top with a
+	// command for members, under the admin commands.
+	AdminUsage       string
+	AdminDescription string
+	Role             Role
+	// Junior commands may be used by junior members too. JuniorHelp is how
+	// their /help describes the command in plain words.
+	Junior     bool
+	JuniorHelp string
+	// Query commands only look things up, so they still work in read-only
+	// mode. Others do with the first arguments in QueryArgs, "" for none,
+	// like /balance showing the balances but not /balance paid.
+	Query     bool
+	QueryArgs []string
+	Handle    func(*Handlers, *tgbotapi.Message) (tgbotapi.MessageConfig, error)
+}
+
+// Commands are all commands of the bot, in the order /help lists them.
+// Commands missing here can't be used, so a forgotten entry fails closed.
+var Commands []Command
+
+func init() {
+	// Set in init, as HandleHelp reads the list it is part of
+	Commands = []Command{
+		{Name: "start", Description: "Show the welcome message and register you.", Role: RoleAnyone, Junior: true, Handle: (*Handlers).HandleStart},
+		{Name: "help", Description: "Show this help message.", Role: RoleAnyone, Junior: true, Query: true, Handle: (*Handlers).HandleHelp},
+		{Name: "status", Description: "Show your current duty statistics.", Role: RoleAnyone, Junior: true,
+			JuniorHelp: "See how many duties you did and when your next one is.", Query: true, Handle: (*Handlers).HandleStatus},
+		{Name: "schedule", Usage: "[name|text]", Description: "View the duty schedule for the current month, optionally highlighting one user, or as plain text for screen readers.", Role: RoleAnyone, Junior: true,
+			JuniorHelp: "See this month's duties.", Query: true, Handle: (*Handlers).HandleSchedule},
+		{Name: "week", Description: "Show who is on duty each day of this week.", Role: RoleAnyone, Junior: true,
+			JuniorHelp: "See who is on duty this week.", Query: true, Handle: (*Handlers).HandleWeek},
+		{Name: "volunteer", Usage: "<days>", Description: "Add days to your volunteer queue.", Role: RoleMember, Handle: (*Handlers).HandleVolunteer},
+		{Name: "calendar", Usage: "<url>", Description: "Link an iCal calendar to mark vacations off-duty automatically (private chat only).", Role: RoleMember, QueryArgs: []string{""}, Handle: (*Handlers).HandleCalendar},
+		{Name: "notifications", Description: "Choose which reminders you get and when.", Role: RoleMember, QueryArgs: []string{""}, Handle: (*Handlers).HandleNotifications},
+		{Name: "me", Usage: "emoji <emoji>|timezone <zone>", Description: "Pick the emoji that marks your days in the calendar and announcements, or the timezone of your reminders while you're abroad.", Role: RoleMember, Junior: true,
+			JuniorHelp: "Pick the emoji that shows your days, like /me emoji 🦊", QueryArgs: []string{""}, Handle: (*Handlers).HandleMe},
+		{Name: "subscribe", Description: "Get a private message when one of your days changes.", Role: RoleMember, Handle: (*Handlers).HandleSubscribe},
+		{Name: "unsubscribe", Description: "Stop the messages about changes to your days.", Role: RoleMember, Handle: (*Handlers).HandleUnsubscribe},
+		// The handler checks the duty is the user's
+		{Name: "handoff", Usage: "[text|clear]", Description: "Leave a short note for whoever is on duty after you today, or reply to the question with it.", Role: RoleMember, Handle: (*Handlers).HandleHandoff},
+		// The handler checks the duty is the user's or they're an admin
+		{Name: "cover", Usage: "[date]", Description: "Ask the group who takes over your duty at short notice.", Role: RoleMember, Handle: (*Handlers).HandleCover},
+		// The handler checks the duty is the user's
+		{Name: "confirm", Usage: "<date>", Description: "Confirm a held duty so it stays yours.", Role: RoleMember, Handle: (*Handlers).HandleConfirm},
+		// Managing the items is checked for admins in the handler
+		{Name: "checklist", Description: "Tick off the tasks of your duty today.", Role: RoleMember, Junior: true,
+			AdminUsage: "list|add|optional|del", AdminDescription: "Manage the tasks on duty checklists.",
+			JuniorHelp: "Tick off your tasks when it's your turn.", QueryArgs: []string{"", "list"}, Handle: (*Handlers).HandleChecklist},
+		// Managing the tasks is checked for admins in the handler
+		{Name: "tasks", Description: "List the extra tasks outside the rotation and claim one.", Role: RoleMember, Junior: true,
+			AdminUsage: "add|done|del", AdminDescription: "Add one-off tasks, announced in the group, mark them done or delete them.",
+			JuniorHelp: "See extra chores and pick one to do.", QueryArgs: []string{""}, Handle: (*Handlers).HandleTasks},
+		// Recording payments is checked for admins in the handler
+		{Name: "balance", Usage: "[pay]", Description: "Show what everyone owes for missed duties in payout mode, or pay what you owe in Telegram.", Role: RoleMember,
+			AdminUsage: "paid <user> [amount]", AdminDescription: "Record a payment in payout mode, the whole balance if no amount is given.", QueryArgs: []string{""}, Handle: (*Handlers).HandleBalance},
+		{Name: "language", Usage: "[code]", Description: "Show or change the language of dates in this chat.", Role: RoleMember, QueryArgs: []string{""}, Handle: (*Handlers).HandleLanguage},
+		{Name: "display", Usage: "[weeks on|off] [adjacent on|off]", Description: "Show or change whether the calendar in this chat shows week numbers and the days of adjacent months.", Role: RoleMember, QueryArgs: []string{""}, Handle: (*Handlers).HandleDisplay},
+		{Name: "login", Description: "Get a one-time link to use the calendar in a browser outside Telegram (private chat only).", Role: RoleMember, Query: true, Handle: (*Handlers).HandleLogin},
+
+		{Name: "assign", Usage: "<username> <days>", Description: "Add days to user's admin queue.", Role: RoleAdmin, Handle: (*Handlers).HandleAssign},
+		{Name: "change", Aliases: []string{"modify"}, Usage: "<date> <username> [refund]", Description: "Change assigned user for a date, optionally moving the queue day too.", Role: RoleAdmin, Handle: (*Handlers).HandleChange},
+		{Name: "offduty", Usage: "<username> <start> <end>", Description: "Set off-duty period (YYYY-MM-DD).", Role: RoleAdmin, Handle: (*Handlers).HandleOffDuty},
+		{Name: "offduty_import", Usage: "<users|all>, then one <start> [end] [what] per line", Description: "Set many off-duty periods, like school holidays, for several users at once, from lines or a pasted iCal calendar, after a preview.", Role: RoleAdmin, Handle: (*Handlers).HandleOffDutyImport},
+		{Name: "skip", Usage: "<date> [holiday|eating_out|away]", Description: "Mark a day without duty.", Role: RoleAdmin, Handle: (*Handlers).HandleSkip},
+		{Name: "blackout", Usage: "[list|add <rule> [description]|del <id>]", Description: "Manage the days nobody is on duty every year, like Christmas.", Role: RoleAdmin, QueryArgs: []string{"", "list"}, Handle: (*Handlers).HandleBlackout},
+		{Name: "unskip", Usage: "<date>", Description: "Make a skipped day a regular duty day again.", Role: RoleAdmin, Handle: (*Handlers).HandleUnskip},
+		{Name: "backfill", Usage: "<date> <user>", Description: "Record who actually did a past duty.", Role: RoleAdmin, Handle: (*Handlers).HandleBackfill},
+		{Name: "complete", Usage: "<date>", Description: "Mark the duty of today or a past day as done.", Role: RoleAdmin, Handle: (*Handlers).HandleComplete},
+		{Name: "uncomplete", Usage: "<date>", Description: "Take back a duty's completion.", Role: RoleAdmin, Handle: (*Handlers).HandleUncomplete},
+		{Name: "publish", Usage: "[draft] [YYYY-MM]", Description: "Review next month's plan, then publish it.", Role: RoleAdmin, Handle: (*Handlers).HandlePublish},
+		{Name: "history", Usage: "[YYYY-MM] [version|date] [version|date]", Description: "List the versions of a month's schedule, show one or what changed between two.", Role: RoleAdmin, Query: true, Handle: (*Handlers).HandleHistory},
+		{Name: "hold", Usage: "<date> <user> <until>", Description: "Assign a day unless it isn't confirmed by <until>.", Role: RoleAdmin, Handle: (*Handlers).HandleHold},
+		{Name: "assigntoday", Description: "Run today's assignment now instead of waiting for the assignment time.", Role: RoleAdmin, Handle: (*Handlers).HandleAssignToday},
+		{Name: "merge_users", Usage: "<from> <to>", Description: "Merge a duplicate account into another one.", Role: RoleAdmin, Handle: (*Handlers).HandleMergeUsers},
+		{Name: "rename", Usage: "<user> <name>", Description: "Change how a user is shown; commands keep using their handle.", Role: RoleAdmin, Handle: (*Handlers).HandleRename},
+		{Name: "invite", Usage: "[days] [approve]", Description: "Create a one-time link that adds someone to the roster, optionally after your approval.", Role: RoleAdmin, Handle: (*Handlers).HandleInvite},
+		{Name: "junior", Usage: "<user>", Description: "Limit a user to the kid-friendly commands, or lift the limit again.", Role: RoleAdmin, Handle: (*Handlers).HandleJunior},
+		{Name: "pool", Usage: "<user> all|weekdays|weekends", Description: "Set which days of the week a user is on duty.", Role: RoleAdmin, Handle: (*Handlers).HandlePool},
+		{Name: "note", Description: "Manage notes added to duty reminders.", Role: RoleAdmin, QueryArgs: []string{"", "list"}, Handle: (*Handlers).HandleNote},
+		{Name: "users", Description: "List all users and their status.", Role: RoleAdmin, Query: true, Handle: (*Handlers).HandleUsers},
+		// Works in read-only mode, which it turns off again
+		{Name: "settings", Usage: "[group <chat>|admin add|remove <user>|approval on|off|fine <amount> [currency]|off|readonly on|off|announce <section> on|off|poll on|off|cover wait <minutes>|cover fallback <user>|none]", Description: "Show or change the group chat, the admins, whether new users need approval, the fine of payout mode, read-only mode for maintenance, the sections of the daily announcement, the Sunday poll for volunteers and how /cover finds someone, without a restart.", Role: RoleAdmin, Query: true, Handle: (*Handlers).HandleSettings},
+		{Name: "cleanup", Description: "Delete finished menus and take the buttons off open ones now.", Role: RoleAdmin, Handle: (*Handlers).HandleCleanup},
+		{Name: "debug", Description: "Show the bot's version, uptime, jobs, queues and last errors.", Role: RoleAdmin, Query: true, Handle: (*Handlers).HandleDebug},
+		{Name: "toggle_active", Aliases: []string{"toggleactive"}, Usage: "<username>", Description: "Toggle a user's participation in the rotation.", Role: RoleAdmin, Handle: (*Handlers).HandleToggleActive},
+	}
+}
+
+// LookupCommand returns the command with the name or alias, or nil if there
+// is none.
+func LookupCommand(name string) *Command {
+	for i, c := range Commands {
+		if c.Name == name {
+			return &Commands[i]
+		}
+		for _, alias := range c.Aliases {
+			if alias == name {
+				return &Commands[i]
+			}
+		}
+	}
+	return nil
+}
+
+// allows reports whether someone with role, and who is a junior member or
+// not, may use the command.
+func (c *Command) allows(role Role, junior bool) bool {
+	return role >= c.Role && (c.Junior || !junior || role == RoleAdmin)
+}
+
+// helpText renders /help for someone with role: the commands they may use,
+// and for admins those only they may use in a section of their own. It is
+// Markdown, so underscores are escaped.
+func helpText(role Role) string {
+	escape := strings.NewReplacer("_", "\\_").Replace
+	line := func(b *strings.Builder, name, usage, description string) {
+		if usage != "" {
+			name += " " + usage
+		}
+		fmt.Fprintf(b, "/%s - %s\n", escape(name), escape(description))
+	}
+
+	var b strings.Builder
+	b.WriteString("Here are the available commands:\n\n")
+	for _, c := range Commands {
+		if c.Role < RoleAdmin && role >= c.Role {
+			line(&b, c.Name, c.Usage, c.Description)
+		}
+	}
+	if role < RoleMember {
+		b.WriteString("\nSend /start to join the roster and get more commands.")
+		return b.String()
+	}
+	if role == RoleAdmin {
+		b.WriteString("\n*Admin Commands:*\n")
+		for _, c := range Commands {
+			if c.Role == RoleAdmin {
+				line(&b, c.Name, c.Usage, c.Description)
+			} else if c.AdminDescription != "" {
+				line(&b, c.Name, c.AdminUsage, c.AdminDescription)
+			}
+		}
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// juniorHelpText renders /help for junior members in plain words.
+func juniorHelpText() string {
+	var b strings.Builder
+	b.WriteString("Here is what you can do:\n")
+	for _, c := range Commands {
+		if c.Junior && c.JuniorHelp != "" {
+			fmt.Fprintf(&b, "\n/%s - %s", c.Name, c.JuniorHelp)
+		}
+	}
+	return b.String()
+}
+
+// HandleUnknownCommand answers a command missing from Commands, suggesting
+// the closest one the sender may use if there is one.
+func (h *Handlers) HandleUnknownCommand(m *tgbotapi.Message) (tgbotapi.MessageConfig, error) {
+	role, junior := RoleAnyone, false
+	if m.From != nil {
+		role, junior = h.roleOf(m.From.ID), h.isJunior(m.From.ID)
+	}
+	if suggestion := closestCommand(m.Command(), role, junior); suggestion != "" {
+		return tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("Unknown command. Did you mean /%s? Use /help for a list of commands.", suggestion)), nil
+	}
+	return tgbotapi.NewMessage(m.Chat.ID, unknownCommandMessage), nil
+}
+
+// closestCommand returns the name of the command someone with role most
+// likely meant by name: one it starts, or else the one within two typos of
+// it. It returns "" if none is close enough.
+func closestCommand(name string, role Role, junior bool) string {
+	name = strings.ToLower(name)
+	best, bestDistance := "", 3
+	for _, c := range Commands {
+		if !c.allows(role, junior) {
+			continue
+		}
+		for _, candidate := range append([]string{c.Name}, c.Aliases...) {
+			if len(name) >= 3 && strings.HasPrefix(candidate, name) {
+				return c.Name
+			}
+			if d := editDistance(name, candidate); d < bestDistance {
+				best, bestDistance = c.Name, d
+			}
+		}
+	}
+	return best
+}
+
+// editDistance is the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
@@ -0,0 +1,72 @@
+package handlers_test
+
+import (
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/korjavin/dutyassistant/internal/telegram/handlers"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCommands(t *testing.T) {
+	seen := map[string]bool{}
+	for _, cmd := range handlers.Commands {
+		for _, name := range append([]string{cmd.Name}, cmd.Aliases...) {
+			assert.False(t, seen[name], "/%s is registered twice", name)
+			seen[name] = true
+			if assert.NotNil(t, handlers.LookupCommand(name), "/%s", name) {
+				assert.Equal(t, cmd.Name, handlers.LookupCommand(name).Name)
+			}
+		}
+		assert.NotEmpty(t, cmd.Description, "/%s", cmd.Name)
+		assert.NotNil(t, cmd.Handle, "/%s", cmd.Name)
+	}
+	assert.Nil(t, handlers.LookupCommand("something_new"))
+}
+
+func TestHandleHelp_Roles(t *testing.T) {
+	h := setupAuthzTest(t)
+	help := func(telegramID int64) tgbotapi.MessageConfig {
+		msg, err := h.HandleHelp(&tgbotapi.Message{Chat: &tgbotapi.Chat{ID: 789}, From: &tgbotapi.User{ID: telegramID}})
+		assert.NoError(t, err)
+		return msg
+	}
+
+	admin := help(1)
+	assert.Equal(t, tgbotapi.ModeMarkdown, admin.ParseMode)
+	for _, text := range []string{"/volunteer <days>", "*Admin Commands:*", "/merge\\_users <from> <to>", "/checklist list|add|optional|del"} {
+		assert.Contains(t, admin.Text, text)
+	}
+
+	member := help(2).Text
+	assert.Contains(t, member, "/volunteer <days>")
+	assert.Contains(t, member, "/checklist - Tick off")
+	assert.NotContains(t, member, "Admin")
+	assert.NotContains(t, member, "/assign")
+
+	stranger := help(3).Text
+	assert.Contains(t, stranger, "/schedule [name|text]")
+	assert.NotContains(t, stranger, "/volunteer")
+	assert.Contains(t, stranger, "Send /start")
+}
+
+func TestHandleUnknownCommand(t *testing.T) {
+	h := setupAuthzTest(t)
+	reply := func(command string, telegramID int64) string {
+		m := adminCommand(command, "")
+		m.From.ID = telegramID
+		msg, err := h.HandleUnknownCommand(m)
+		assert.NoError(t, err)
+		return msg.Text
+	}
+
+	assert.Equal(t, "Unknown command. Did you mean /schedule? Use /help for a list of commands.", reply("shedule", 2))
+	assert.Contains(t, reply("volunter", 2), "/volunteer?")
+	assert.Contains(t, reply("toggle", 1), "/toggle_active?")
+	// Only commands the sender may use are suggested
+	assert.Contains(t, reply("asign", 1), "/assign?")
+	assert.Equal(t, "Unknown command. Use /help for a list of commands.", reply("asign", 2))
+	assert.Equal(t, "Unknown command. Use /help for a list of commands.", reply("something_new", 1))
+	// Juniors only get their own commands
+	assert.NotContains(t, reply("volunter", 4), "/volunteer")
+}
@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const renameUsageMessage = "✏️ <b>Change how a user is shown</b>\n\n" +
+	"Usage: <code>/rename user new name</code>\n\n" +
+	"Example: <code>/rename alice Grandma</code>\n\n" +
+	"The new name sticks even if their Telegram name changes. " +
+	"Commands keep finding the user by the handle shown in /users."
+
+// HandleRename sets the display name of a user for admins.
+// Format: /rename <user> <new name>
+func (h *Handlers) HandleRename(m *tgbotapi.Message) (tgbotapi.MessageConfig, error) {
+	isAdmin, err := h.checkAdmin(m.From.ID)
+	if err != nil || !isAdmin {
+		return tgbotapi.NewMessage(m.Chat.ID, adminOnlyMessage), nil
+	}
+
+	args := strings.Fields(m.CommandArguments())
+	if len(args) < 2 {
+		msg := tgbotapi.NewMessage(m.Chat.ID, renameUsageMessage)
+		msg.ParseMode = tgbotapi.ModeHTML
+		return msg, nil
+	}
+
+	ctx := context.Background()
+	user, err := h.Users.Find(ctx, args[0])
+	if err != nil {
+		return tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf(userNotFoundMessage, args[0])), nil
+	}
+
+	oldName, name := user.FirstName, strings.Join(args[1:], " ")
+	if err := h.Users.Rename(ctx, user, name); err != nil {
+		return tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("❌ Failed to rename %s: %v", oldName, err)), nil
+	}
+	// Calendars list users by name
+	h.InvalidateCalendars(ctx, nil)
+	msg := tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("✏️ %s is now shown as <b>%s</b>; the handle stays <code>%s</code>.",
+		html.EscapeString(oldName), html.EscapeString(name), html.EscapeString(user.Handle)))
+	msg.ParseMode = tgbotapi.ModeHTML
+	return msg, nil
+}
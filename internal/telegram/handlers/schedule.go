@@ -7,83 +7,152 @@ import (
 	"strings"
 	"time"
 
+	"github.com/korjavin/dutyassistant/internal/i18n"
+	"github.com/korjavin/dutyassistant/internal/notification"
 	"github.com/korjavin/dutyassistant/internal/store"
 	"github.com/korjavin/dutyassistant/internal/telegram/keyboard"
+	"github.com/korjavin/dutyassistant/internal/telegram/parse"
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 )
 
 const (
-	scheduleMessage = "Duty schedule for %s"
+	scheduleMessage         = "Duty schedule for %s"
+	userScheduleMessage     = "Duty schedule of %s for %s"
+	scheduleUserNotFoundMsg = "❌ User '%s' not found."
 )
 
 // HandleSchedule handles the /schedule command, displaying a calendar with duty information.
+// With a name, e.g. /schedule @Alice, only that user's days are highlighted.
+// /schedule text lists the days as plain text instead, for screen readers.
 func (h *Handlers) HandleSchedule(m *tgbotapi.Message) (tgbotapi.MessageConfig, error) {
+	ctx := context.Background()
 	now := time.Now()
+	if strings.EqualFold(strings.TrimSpace(m.CommandArguments()), "text") {
+		return h.scheduleText(ctx, m.Chat.ID, now)
+	}
 
-	duties, err := h.Store.GetDutiesByMonth(context.Background(), now.Year(), now.Month())
-	if err != nil {
-		return tgbotapi.MessageConfig{}, fmt.Errorf("could not get duties for schedule: %w", err)
+	var user *store.User
+	if name := strings.TrimPrefix(strings.TrimSpace(m.CommandArguments()), "@"); name != "" {
+		u, err := h.Users.ByName(ctx, name)
+		if err != nil {
+			return tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf(scheduleUserNotFoundMsg, name)), nil
+		}
+		user = u
 	}
 
-	// Also fetch all active users to show queue information
-	users, err := h.Store.ListActiveUsers(context.Background())
+	duties, err := h.Store.GetDutiesByMonth(ctx, now.Year(), now.Month())
 	if err != nil {
-		log.Printf("Warning: could not get active users for schedule: %v", err)
-		users = []*store.User{}
+		return tgbotapi.MessageConfig{}, fmt.Errorf("could not get duties for schedule: %w", err)
 	}
 
-	text := fmt.Sprintf(scheduleMessage, now.Format("January 2006"))
-	markup := keyboard.Calendar(now, duties, users)
-
+	text, markup := h.scheduleCalendar(ctx, h.locale(ctx, m.Chat.ID), h.display(ctx, m.Chat.ID), now, duties, user)
 	msg := tgbotapi.NewMessage(m.Chat.ID, text)
 	msg.ReplyMarkup = markup
 	return msg, nil
 }
 
-// HandleCalendarCallback handles callbacks for month navigation in the schedule view.
-func (h *Handlers) HandleCalendarCallback(q *tgbotapi.CallbackQuery) (tgbotapi.EditMessageTextConfig, error) {
-	parts := strings.Split(q.Data, ":")
-	if len(parts) != 2 {
-		return tgbotapi.EditMessageTextConfig{}, fmt.Errorf("invalid callback data format: %s", q.Data)
+// scheduleText lists the duties of t's month one line per day, without the
+// calendar's emoji grid.
+func (h *Handlers) scheduleText(ctx context.Context, chatID int64, t time.Time) (tgbotapi.MessageConfig, error) {
+	duties, err := h.Store.GetDutiesByMonth(ctx, t.Year(), t.Month())
+	if err != nil {
+		return tgbotapi.MessageConfig{}, fmt.Errorf("could not get duties for schedule: %w", err)
+	}
+	skipDays, err := h.Store.GetSkipDaysByMonth(ctx, t.Year(), t.Month())
+	if err != nil {
+		return tgbotapi.MessageConfig{}, fmt.Errorf("could not get skip days for schedule: %w", err)
 	}
-	dateStr := parts[1]
+	text := notification.FormatScheduleText(h.locale(ctx, chatID), t, duties, skipDays, h.today(), names(chatID))
+	return tgbotapi.NewMessage(chatID, text), nil
+}
 
-	t, err := time.Parse("2006-01-02", dateStr)
+// HandleCalendarCallback handles callbacks for month navigation in the schedule view.
+// The callback data carries the user ID after the date when a single user's
+// schedule is shown. Rendered months are cached until the schedule changes,
+// and no edit is returned if the message already shows the month.
+func (h *Handlers) HandleCalendarCallback(q *tgbotapi.CallbackQuery) (tgbotapi.Chattable, error) {
+	ctx := context.Background()
+	cb := parse.ParseCallback(q.Data)
+	if len(cb.Args) != 1 && len(cb.Args) != 2 {
+		return nil, fmt.Errorf("invalid callback data format: %s", q.Data)
+	}
+	t, err := cb.Date(0)
 	if err != nil {
-		return tgbotapi.EditMessageTextConfig{}, fmt.Errorf("failed to parse date from callback: %w", err)
+		return nil, fmt.Errorf("failed to parse date from callback: %w", err)
 	}
 
 	var newTime time.Time
-	if parts[0] == keyboard.ActionPrevMonth {
+	if cb.Action == keyboard.ActionPrevMonth {
 		newTime = t.AddDate(0, -1, 0)
-	} else if parts[0] == keyboard.ActionNextMonth {
+	} else if cb.Action == keyboard.ActionNextMonth {
 		newTime = t.AddDate(0, 1, 0)
 	} else {
-		return tgbotapi.EditMessageTextConfig{}, fmt.Errorf("unexpected action in calendar callback: %s", parts[0])
+		return nil, fmt.Errorf("unexpected action in calendar callback: %s", cb.Action)
 	}
 
-	duties, err := h.Store.GetDutiesByMonth(context.Background(), newTime.Year(), newTime.Month())
-	if err != nil {
-		// Log the error but still show the calendar
-		log.Printf("Could not get duties for schedule refresh: %v", err)
-		duties = []*store.Duty{} // Send empty slice to render an empty calendar
+	var userID int64
+	if len(cb.Args) == 2 {
+		if userID, err = cb.ID(1); err != nil {
+			return nil, fmt.Errorf("failed to parse user from callback: %w", err)
+		}
 	}
 
-	// Also fetch all active users to show queue information
-	users, err := h.Store.ListActiveUsers(context.Background())
-	if err != nil {
-		log.Printf("Warning: could not get active users for schedule refresh: %v", err)
-		users = []*store.User{}
-	}
+	key := calendarKey{chatID: q.Message.Chat.ID, month: newTime.Format("2006-01"), userID: userID}
+	rendered, ok := h.cachedCalendar(key)
+	if !ok {
+		var user *store.User
+		if userID != 0 {
+			if user, err = h.Users.ByID(ctx, userID); err != nil {
+				return nil, fmt.Errorf("could not get user %d for schedule: %w", userID, err)
+			}
+		}
 
-	text := fmt.Sprintf(scheduleMessage, newTime.Format("January 2006"))
-	newMarkup := keyboard.Calendar(newTime, duties, users)
+		duties, err := h.Store.GetDutiesByMonth(ctx, newTime.Year(), newTime.Month())
+		if err != nil {
+			// Log the error but still show the calendar
+			log.Printf("Could not get duties for schedule refresh: %v", err)
+			duties = []*store.Duty{} // Send empty slice to render an empty calendar
+		}
 
+		text, markup := h.scheduleCalendar(ctx, h.locale(ctx, q.Message.Chat.ID), h.display(ctx, q.Message.Chat.ID), newTime, duties, user)
+		rendered = h.cacheCalendar(key, text, markup)
+	}
+
+	if !h.showCalendar(q.Message.Chat.ID, q.Message.MessageID, key, rendered) {
+		return nil, nil
+	}
 	edit := tgbotapi.NewEditMessageText(
 		q.Message.Chat.ID,
 		q.Message.MessageID,
-		text,
+		rendered.text,
 	)
-	edit.ReplyMarkup = &newMarkup
+	markup := rendered.markup
+	edit.ReplyMarkup = &markup
 	return edit, nil
-}
\ No newline at end of file
+}
+
+// scheduleCalendar renders the schedule text and calendar for t's month. If
+// user is set, the calendar highlights their days and dims everyone else's.
+// The month and weekdays are named in locale l, and d is how the chat wants
+// the calendar drawn.
+func (h *Handlers) scheduleCalendar(ctx context.Context, l i18n.Locale, d store.ChatDisplay, t time.Time, duties []*store.Duty, user *store.User) (string, tgbotapi.InlineKeyboardMarkup) {
+	skipDays, err := h.Store.GetSkipDaysByMonth(ctx, t.Year(), t.Month())
+	if err != nil {
+		log.Printf("Warning: could not get skip days for schedule: %v", err)
+	}
+
+	if user != nil {
+		text := fmt.Sprintf(userScheduleMessage, user.FirstName, l.Format(t, "January 2006"))
+		return text, keyboard.UserCalendar(l, d, t, duties, user, skipDays)
+	}
+
+	// Also fetch all active users to show queue information
+	users, err := h.Store.ListActiveUsers(ctx)
+	if err != nil {
+		log.Printf("Warning: could not get active users for schedule: %v", err)
+		users = []*store.User{}
+	}
+
+	text := fmt.Sprintf(scheduleMessage, l.Format(t, "January 2006"))
+	return text, keyboard.Calendar(l, d, t, duties, users, skipDays)
+}
@@ -1,29 +1,35 @@
 package handlers_test
 
 import (
+	"context"
 	"fmt"
 	"testing"
 	"time"
 
-	"github.com/korjavin/dutyassistant/internal/mocks"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	"github.com/korjavin/dutyassistant/internal/store"
+	storemocks "github.com/korjavin/dutyassistant/internal/store/mocks"
 	"github.com/korjavin/dutyassistant/internal/telegram/handlers"
 	"github.com/korjavin/dutyassistant/internal/telegram/keyboard"
-	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	"github.com/stretchr/testify/assert"
-	"github.com/stretchr/testify/mock"
+	"go.uber.org/mock/gomock"
 )
 
 func TestHandleSchedule(t *testing.T) {
-	mockStore := new(mocks.MockStore)
+	mockStore := storemocks.NewMockStore(gomock.NewController(t))
 	h := handlers.New(mockStore, nil)
+	mockStore.EXPECT().GetChatLocale(gomock.Any(), int64(123)).Return("", nil).AnyTimes()
+	mockStore.EXPECT().GetChatDisplay(gomock.Any(), int64(123)).Return(nil, nil).AnyTimes()
 	message := &tgbotapi.Message{Chat: &tgbotapi.Chat{ID: 123}}
+	now := time.Now()
 
 	// Mock store to return some duties
 	duties := []*store.Duty{
-		{DutyDate: time.Now(), User: &store.User{FirstName: "Test"}},
+		{DutyDate: now, User: &store.User{FirstName: "Test"}},
 	}
-	mockStore.On("GetDutiesByMonth", mock.Anything, time.Now().Year(), time.Now().Month()).Return(duties, nil)
+	mockStore.EXPECT().GetDutiesByMonth(gomock.Any(), now.Year(), now.Month()).Return(duties, nil)
+	mockStore.EXPECT().ListActiveUsers(gomock.Any()).Return([]*store.User{{FirstName: "Test", IsActive: true}}, nil)
+	mockStore.EXPECT().GetSkipDaysByMonth(gomock.Any(), now.Year(), now.Month()).Return(nil, nil)
 
 	msg, err := h.HandleSchedule(message)
 
@@ -31,16 +37,19 @@ func TestHandleSchedule(t *testing.T) {
 	assert.Equal(t, int64(123), msg.ChatID)
 	assert.Contains(t, msg.Text, "Duty schedule for")
 	assert.NotNil(t, msg.ReplyMarkup)
-	mockStore.AssertExpectations(t)
 }
 
 func TestHandleCalendarCallback(t *testing.T) {
-	mockStore := new(mocks.MockStore)
+	mockStore := storemocks.NewMockStore(gomock.NewController(t))
 	h := handlers.New(mockStore, nil)
+	mockStore.EXPECT().GetChatLocale(gomock.Any(), int64(123)).Return("", nil).AnyTimes()
+	mockStore.EXPECT().GetChatDisplay(gomock.Any(), int64(123)).Return(nil, nil).AnyTimes()
 	now := time.Date(2023, 5, 15, 0, 0, 0, 0, time.UTC)
 
-	// Mock store to return empty duties for any month query
-	mockStore.On("GetDutiesByMonth", mock.Anything, mock.Anything, mock.Anything).Return([]*store.Duty{}, nil)
+	// Mock store to return empty data for both the next and previous month
+	mockStore.EXPECT().GetDutiesByMonth(gomock.Any(), 2023, gomock.Any()).Return([]*store.Duty{}, nil).Times(2)
+	mockStore.EXPECT().ListActiveUsers(gomock.Any()).Return([]*store.User{}, nil).Times(2)
+	mockStore.EXPECT().GetSkipDaysByMonth(gomock.Any(), 2023, gomock.Any()).Return(nil, nil).Times(2)
 
 	testCases := []struct {
 		name          string
@@ -63,14 +72,159 @@ func TestHandleCalendarCallback(t *testing.T) {
 				Data: callbackData,
 			}
 
-			editMsg, err := h.HandleCalendarCallback(callbackQuery)
+			response, err := h.HandleCalendarCallback(callbackQuery)
 
 			assert.NoError(t, err)
+			editMsg := response.(tgbotapi.EditMessageTextConfig)
 			assert.Equal(t, int64(123), editMsg.ChatID)
 			assert.Contains(t, editMsg.Text, tc.expectedMonth)
 			assert.NotNil(t, editMsg.ReplyMarkup)
 		})
 	}
-	// Assert that GetDutiesByMonth was called for both next and previous month
-	mockStore.AssertNumberOfCalls(t, "GetDutiesByMonth", 2)
-}
\ No newline at end of file
+}
+
+func TestHandleSchedule_User(t *testing.T) {
+	mockStore := storemocks.NewMockStore(gomock.NewController(t))
+	h := handlers.New(mockStore, nil)
+	mockStore.EXPECT().GetChatLocale(gomock.Any(), int64(123)).Return("", nil).AnyTimes()
+	mockStore.EXPECT().GetChatDisplay(gomock.Any(), int64(123)).Return(nil, nil).AnyTimes()
+	now := time.Now()
+	alice := &store.User{ID: 3, FirstName: "Alice", IsActive: true}
+
+	mockStore.EXPECT().GetUserByName(gomock.Any(), "Alice").Return(alice, nil)
+	mockStore.EXPECT().GetDutiesByMonth(gomock.Any(), now.Year(), now.Month()).Return([]*store.Duty{
+		{UserID: 3, DutyDate: now, User: alice},
+	}, nil)
+	mockStore.EXPECT().GetSkipDaysByMonth(gomock.Any(), now.Year(), now.Month()).Return(nil, nil)
+
+	message := &tgbotapi.Message{
+		Chat:     &tgbotapi.Chat{ID: 123},
+		Text:     "/schedule @Alice",
+		Entities: []tgbotapi.MessageEntity{{Type: "bot_command", Offset: 0, Length: 9}},
+	}
+	msg, err := h.HandleSchedule(message)
+
+	assert.NoError(t, err)
+	assert.Contains(t, msg.Text, "Duty schedule of Alice for")
+	markup := msg.ReplyMarkup.(tgbotapi.InlineKeyboardMarkup)
+	assert.Equal(t, fmt.Sprintf("%s:%s:3", keyboard.ActionNextMonth, now.Format("2006-01-02")), *markup.InlineKeyboard[0][2].CallbackData)
+}
+
+func TestHandleSchedule_Text(t *testing.T) {
+	mockStore := storemocks.NewMockStore(gomock.NewController(t))
+	h := handlers.New(mockStore, nil)
+	mockStore.EXPECT().GetChatLocale(gomock.Any(), int64(123)).Return("", nil).AnyTimes()
+	mockStore.EXPECT().GetChatDisplay(gomock.Any(), int64(123)).Return(nil, nil).AnyTimes()
+	now := time.Now()
+	first := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+
+	mockStore.EXPECT().GetDutiesByMonth(gomock.Any(), now.Year(), now.Month()).Return([]*store.Duty{
+		{DutyDate: first, User: &store.User{FirstName: "Alice"}, CompletedAt: &first},
+	}, nil)
+	mockStore.EXPECT().GetSkipDaysByMonth(gomock.Any(), now.Year(), now.Month()).Return(nil, nil)
+
+	message := &tgbotapi.Message{
+		Chat:     &tgbotapi.Chat{ID: 123},
+		Text:     "/schedule text",
+		Entities: []tgbotapi.MessageEntity{{Type: "bot_command", Offset: 0, Length: 9}},
+	}
+	msg, err := h.HandleSchedule(message)
+
+	assert.NoError(t, err)
+	assert.Contains(t, msg.Text, first.Format("Monday, January 2")+": Alice, done\n")
+	assert.Nil(t, msg.ReplyMarkup, "No calendar grid")
+}
+
+func TestHandleSchedule_UnknownUser(t *testing.T) {
+	mockStore := storemocks.NewMockStore(gomock.NewController(t))
+	h := handlers.New(mockStore, nil)
+	mockStore.EXPECT().GetChatLocale(gomock.Any(), int64(123)).Return("", nil).AnyTimes()
+	mockStore.EXPECT().GetChatDisplay(gomock.Any(), int64(123)).Return(nil, nil).AnyTimes()
+
+	mockStore.EXPECT().GetUserByName(gomock.Any(), "Nobody").Return(nil, nil)
+
+	message := &tgbotapi.Message{
+		Chat:     &tgbotapi.Chat{ID: 123},
+		Text:     "/schedule Nobody",
+		Entities: []tgbotapi.MessageEntity{{Type: "bot_command", Offset: 0, Length: 9}},
+	}
+	msg, err := h.HandleSchedule(message)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "❌ User 'Nobody' not found.", msg.Text)
+}
+
+func TestHandleCalendarCallback_User(t *testing.T) {
+	mockStore := storemocks.NewMockStore(gomock.NewController(t))
+	h := handlers.New(mockStore, nil)
+	mockStore.EXPECT().GetChatLocale(gomock.Any(), int64(123)).Return("", nil).AnyTimes()
+	mockStore.EXPECT().GetChatDisplay(gomock.Any(), int64(123)).Return(nil, nil).AnyTimes()
+	alice := &store.User{ID: 3, FirstName: "Alice", IsActive: true}
+
+	mockStore.EXPECT().ListAllUsers(gomock.Any()).Return([]*store.User{alice}, nil)
+	mockStore.EXPECT().GetDutiesByMonth(gomock.Any(), 2023, time.June).Return([]*store.Duty{}, nil)
+	mockStore.EXPECT().GetSkipDaysByMonth(gomock.Any(), 2023, time.June).Return(nil, nil)
+
+	response, err := h.HandleCalendarCallback(&tgbotapi.CallbackQuery{
+		ID:      "test_callback_id",
+		Message: &tgbotapi.Message{Chat: &tgbotapi.Chat{ID: 123}, MessageID: 789},
+		Data:    keyboard.ActionNextMonth + ":2023-05-15:3",
+	})
+
+	assert.NoError(t, err)
+	editMsg := response.(tgbotapi.EditMessageTextConfig)
+	assert.Equal(t, "Duty schedule of Alice for June 2023", editMsg.Text)
+	assert.Equal(t, keyboard.ActionNextMonth+":2023-06-15:3", *editMsg.ReplyMarkup.InlineKeyboard[0][2].CallbackData)
+}
+
+func TestHandleCalendarCallback_Cache(t *testing.T) {
+	mockStore := storemocks.NewMockStore(gomock.NewController(t))
+	h := handlers.New(mockStore, nil)
+	mockStore.EXPECT().GetChatLocale(gomock.Any(), int64(123)).Return("", nil).AnyTimes()
+	mockStore.EXPECT().GetChatDisplay(gomock.Any(), int64(123)).Return(nil, nil).AnyTimes()
+
+	// June is rendered once until the schedule changes
+	mockStore.EXPECT().GetDutiesByMonth(gomock.Any(), 2023, time.June).Return([]*store.Duty{}, nil).Times(2)
+	mockStore.EXPECT().ListActiveUsers(gomock.Any()).Return([]*store.User{}, nil).Times(2)
+	mockStore.EXPECT().GetSkipDaysByMonth(gomock.Any(), 2023, time.June).Return(nil, nil).Times(2)
+
+	tap := func(messageID int) tgbotapi.Chattable {
+		response, err := h.HandleCalendarCallback(&tgbotapi.CallbackQuery{
+			Message: &tgbotapi.Message{Chat: &tgbotapi.Chat{ID: 123}, MessageID: messageID},
+			Data:    keyboard.ActionNextMonth + ":2023-05-15",
+		})
+		assert.NoError(t, err)
+		return response
+	}
+
+	assert.NotNil(t, tap(1))
+	assert.Nil(t, tap(1), "a double tap doesn't send the same edit again")
+	assert.NotNil(t, tap(2), "another message gets the cached calendar")
+
+	h.InvalidateCalendars(context.Background(), nil)
+	assert.NotNil(t, tap(1), "a changed schedule is rendered again")
+}
+
+func TestHandleSchedule_Locale(t *testing.T) {
+	mockStore := storemocks.NewMockStore(gomock.NewController(t))
+	h := handlers.New(mockStore, nil)
+	mockStore.EXPECT().GetChatLocale(gomock.Any(), int64(123)).Return("de", nil).AnyTimes()
+	mockStore.EXPECT().GetChatDisplay(gomock.Any(), int64(123)).Return(nil, nil).AnyTimes()
+	mockStore.EXPECT().GetDutiesByMonth(gomock.Any(), 2023, time.March).Return(nil, nil)
+	mockStore.EXPECT().ListActiveUsers(gomock.Any()).Return(nil, nil)
+	mockStore.EXPECT().GetSkipDaysByMonth(gomock.Any(), 2023, time.March).Return(nil, nil)
+
+	response, err := h.HandleCalendarCallback(&tgbotapi.CallbackQuery{
+		Message: &tgbotapi.Message{Chat: &tgbotapi.Chat{ID: 123}, MessageID: 789},
+		Data:    keyboard.ActionNextMonth + ":2023-02-01",
+	})
+
+	assert.NoError(t, err)
+	edit := response.(tgbotapi.EditMessageTextConfig)
+	assert.Equal(t, "Duty schedule for März 2023", edit.Text)
+	rows := edit.ReplyMarkup.InlineKeyboard
+	assert.Equal(t, "Mär 2023", rows[0][1].Text)
+	assert.Equal(t, "Mo", rows[1][0].Text)
+	assert.Equal(t, "Di", rows[1][1].Text)
+	assert.Equal(t, "So", rows[1][6].Text)
+}
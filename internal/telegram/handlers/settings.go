@@ -0,0 +1,410 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/korjavin/dutyassistant/internal/scheduler"
+	"github.com/korjavin/dutyassistant/internal/service/dutyjobs"
+	"github.com/korjavin/dutyassistant/internal/service/ledger"
+	"github.com/korjavin/dutyassistant/internal/service/settings"
+)
+
+const settingsUsageMessage = "⚠️ Invalid format.\n\nUsage:\n" +
+	"<code>/settings</code> - show the settings\n" +
+	"<code>/settings group here|none|&lt;chat id&gt;</code> - set the group chat announcements go to\n" +
+	"<code>/settings admin add|remove &lt;user&gt;</code> - add or remove an admin by name or Telegram ID\n" +
+	"<code>/settings approval on|off</code> - whether new users wait for an admin's approval before joining the rotation\n" +
+	"<code>/settings fine &lt;amount&gt; [currency]|off</code> - what a missed duty costs in payout mode, or turn it off\n" +
+	"<code>/settings trips on|off</code> - whether messages like \"we're away next week\" in the group get an offer to set the off-duty period\n" +
+	"<code>/settings time assign|complete HH:MM|default</code> - when the duty is assigned and checked for completion\n" +
+	"<code>/settings readonly on|off</code> - read-only mode for maintenance: changes are refused, queries still work\n" +
+	"<code>/settings announce upcoming|queues|fairness on|off</code> - add the next days, the queues or a bar of who did how many duties to the daily announcement\n" +
+	"<code>/settings poll on|off</code> - whether the group is asked every Sunday who volunteers for which day of the next week\n" +
+	"<code>/settings cover wait &lt;minutes&gt;</code> - how long the group has to take over a duty asked to be covered with /cover\n" +
+	"<code>/settings cover fallback &lt;user&gt;|none</code> - who takes the duty over if nobody does in time"
+
+// HandleSettings shows and changes the settings kept in the database: the
+// group chat, the admins, whether new users need approval, the fine of
+// payout mode, whether trips are spotted in the group, when the duty is
+// assigned and checked, read-only mode, the sections of the daily
+// announcement, the Sunday poll for volunteers and how /cover finds someone.
+// Changes apply right away, without a restart.
+// Format: /settings [group <chat>|admin add|remove <user>|approval on|off|fine <amount> [currency]|off|trips on|off|time assign|complete <HH:MM>|default|readonly on|off|announce <section> on|off|poll on|off|cover wait <minutes>|cover fallback <user>|none]
+func (h *Handlers) HandleSettings(m *tgbotapi.Message) (tgbotapi.MessageConfig, error) {
+	isAdmin, err := h.checkAdmin(m.From.ID)
+	if err != nil || !isAdmin {
+		return tgbotapi.NewMessage(m.Chat.ID, adminOnlyMessage), nil
+	}
+	if h.Settings == nil {
+		return tgbotapi.NewMessage(m.Chat.ID, "⚠️ Settings are not available, they are configured with DISH_GROUP and ADMIN_ID."), nil
+	}
+
+	ctx := context.Background()
+	args := strings.Fields(m.CommandArguments())
+	var reply string
+	switch {
+	case len(args) == 0:
+		reply, err = h.settingsText(ctx)
+	case len(args) == 2 && args[0] == "group":
+		reply, err = h.setGroupChat(ctx, m.Chat.ID, args[1])
+	case len(args) == 3 && args[0] == "admin" && (args[1] == "add" || args[1] == "remove"):
+		reply, err = h.setAdmin(ctx, args[1] == "add", args[2])
+	case len(args) == 2 && args[0] == "approval" && (args[1] == "on" || args[1] == "off"):
+		reply, err = h.setRequireApproval(ctx, args[1] == "on")
+	case (len(args) == 2 || len(args) == 3) && args[0] == "fine":
+		reply, err = h.setFine(ctx, args[1:])
+	case len(args) == 2 && args[0] == "trips" && (args[1] == "on" || args[1] == "off"):
+		reply, err = h.setTripHints(ctx, args[1] == "on")
+	case len(args) == 3 && args[0] == "time" && (args[1] == "assign" || args[1] == "complete"):
+		reply, err = h.setDutyTime(ctx, args[1] == "assign", args[2])
+	case len(args) == 2 && args[0] == "readonly" && (args[1] == "on" || args[1] == "off"):
+		reply, err = h.setReadOnly(ctx, args[1] == "on")
+	case len(args) == 3 && args[0] == "announce" && (args[2] == "on" || args[2] == "off"):
+		reply, err = h.setAnnouncementSection(ctx, args[1], args[2] == "on")
+	case len(args) == 2 && args[0] == "poll" && (args[1] == "on" || args[1] == "off"):
+		reply, err = h.setWeeklyPoll(ctx, args[1] == "on")
+	case len(args) == 3 && args[0] == "cover" && (args[1] == "wait" || args[1] == "fallback"):
+		reply, err = h.setCover(ctx, args[1] == "wait", args[2])
+	default:
+		reply = settingsUsageMessage
+	}
+	if err != nil {
+		log.Printf("[HandleSettings] Failed to handle %q: %v", m.Text, err)
+		return tgbotapi.NewMessage(m.Chat.ID, genericErrorMessage), nil
+	}
+	msg := tgbotapi.NewMessage(m.Chat.ID, reply)
+	msg.ParseMode = tgbotapi.ModeHTML
+	return msg, nil
+}
+
+// settingsText lists the settings, naming the admins who registered.
+func (h *Handlers) settingsText(ctx context.Context) (string, error) {
+	groupID, err := h.Settings.GroupChatID(ctx)
+	if err != nil {
+		return "", err
+	}
+	admins, err := h.Settings.AdminIDs(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	b.WriteString("⚙️ <b>Settings</b>\n\n")
+	if groupID == 0 {
+		b.WriteString("Group chat: none\n")
+	} else {
+		fmt.Fprintf(&b, "Group chat: <code>%d</code>\n", groupID)
+	}
+	if len(admins) == 0 {
+		b.WriteString("Admins: none configured, users flagged as admins in the database are admins\n")
+	} else {
+		b.WriteString("Admins:\n")
+		for _, id := range admins {
+			if user, err := h.Users.ByTelegramID(ctx, id); err == nil {
+				fmt.Fprintf(&b, "  • %s (<code>%d</code>)\n", html.EscapeString(user.FirstName), id)
+			} else {
+				fmt.Fprintf(&b, "  • <code>%d</code>\n", id)
+			}
+		}
+	}
+	requireApproval, err := h.Settings.RequireApproval(ctx)
+	if err != nil {
+		return "", err
+	}
+	if requireApproval {
+		b.WriteString("Approval of new users: on\n")
+	} else {
+		b.WriteString("Approval of new users: off\n")
+	}
+	payout, err := h.Settings.Payout(ctx)
+	if err != nil {
+		return "", err
+	}
+	if payout.On() {
+		fmt.Fprintf(&b, "Payout mode: a missed duty costs %s\n", ledger.FormatAmount(payout.Fine, payout.Currency))
+	} else {
+		b.WriteString("Payout mode: off\n")
+	}
+	tripHints, err := h.Settings.TripHints(ctx)
+	if err != nil {
+		return "", err
+	}
+	if tripHints {
+		b.WriteString("Spotting trips in the group: on\n")
+	} else {
+		b.WriteString("Spotting trips in the group: off\n")
+	}
+	if h.DutyJobs != nil {
+		times, err := h.DutyJobs.Times(ctx)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(&b, "Duty: assigned at %s, checked at %s\n", formatClock(times.Assignment), formatClock(times.Completion))
+	}
+	readOnly, err := h.Settings.ReadOnly(ctx)
+	if err != nil {
+		return "", err
+	}
+	if readOnly {
+		b.WriteString("Read-only mode: on, changes are refused\n")
+	} else {
+		b.WriteString("Read-only mode: off\n")
+	}
+	sections, err := h.Settings.AnnouncementSections(ctx)
+	if err != nil {
+		return "", err
+	}
+	fmt.Fprintf(&b, "Announcement: %s\n", strings.Join(append([]string{"who is on duty"}, sections...), ", "))
+	weeklyPoll, err := h.Settings.WeeklyPoll(ctx)
+	if err != nil {
+		return "", err
+	}
+	if weeklyPoll {
+		b.WriteString("Sunday poll for volunteers: on\n")
+	} else {
+		b.WriteString("Sunday poll for volunteers: off\n")
+	}
+	cover, err := h.Settings.Cover(ctx)
+	if err != nil {
+		return "", err
+	}
+	fmt.Fprintf(&b, "Cover: the group has %d minutes to take over a duty, then %s\n", int(cover.Wait.Minutes()), h.coverFallbackText(ctx, cover.Fallback))
+	b.WriteString("\nChange them with <code>/settings group</code>, <code>/settings admin</code>, <code>/settings approval</code>, <code>/settings fine</code>, <code>/settings trips</code>, <code>/settings time</code>, <code>/settings readonly</code>, <code>/settings announce</code>, <code>/settings poll</code> and <code>/settings cover</code>.")
+	return b.String(), nil
+}
+
+// setGroupChat sets the group chat to the chat the command was sent in
+// ("here"), none or the chat with the given ID.
+func (h *Handlers) setGroupChat(ctx context.Context, chatID int64, arg string) (string, error) {
+	var groupID int64
+	switch arg {
+	case "here":
+		groupID = chatID
+	case "none", "0":
+	default:
+		id, err := strconv.ParseInt(arg, 10, 64)
+		if err != nil {
+			return fmt.Sprintf("❌ Invalid chat ID: %s", html.EscapeString(arg)), nil
+		}
+		groupID = id
+	}
+	if err := h.Settings.SetGroupChatID(ctx, groupID); err != nil {
+		return "", err
+	}
+	if groupID == 0 {
+		return "✅ There is no group chat anymore, nothing is announced.", nil
+	}
+	return fmt.Sprintf("✅ Announcements now go to chat <code>%d</code>.", groupID), nil
+}
+
+// setAdmin adds or removes an admin, referred to by Telegram ID or, if they
+// registered, like other commands refer to users.
+func (h *Handlers) setAdmin(ctx context.Context, add bool, ref string) (string, error) {
+	telegramUserID, err := strconv.ParseInt(ref, 10, 64)
+	name := ref
+	if err != nil {
+		user, err := h.Users.Find(ctx, ref)
+		if err != nil {
+			return fmt.Sprintf(userNotFoundMessage, html.EscapeString(ref)), nil
+		}
+		telegramUserID, name = user.TelegramUserID, user.FirstName
+	}
+
+	if add {
+		if err := h.Settings.AddAdmin(ctx, telegramUserID); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("✅ %s is an admin now.", html.EscapeString(name)), nil
+	}
+	if err := h.Settings.RemoveAdmin(ctx, telegramUserID); errors.Is(err, settings.ErrLastAdmin) {
+		return "❌ That's the last admin, add another one first.", nil
+	} else if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("✅ %s is no admin anymore.", html.EscapeString(name)), nil
+}
+
+// setRequireApproval turns the approval of new users on or off.
+func (h *Handlers) setRequireApproval(ctx context.Context, on bool) (string, error) {
+	if err := h.Settings.SetRequireApproval(ctx, on); err != nil {
+		return "", err
+	}
+	if on {
+		return "✅ New users now wait for an admin's approval before they join the rotation.", nil
+	}
+	return "✅ New users join the rotation right away now.", nil
+}
+
+// setTripHints turns spotting trips announced in the group on or off.
+func (h *Handlers) setTripHints(ctx context.Context, on bool) (string, error) {
+	if err := h.Settings.SetTripHints(ctx, on); err != nil {
+		return "", err
+	}
+	if on {
+		return "✅ When someone writes in the group that they're away, like \"we're away next week\", I'll offer to set them off duty. " +
+			"Bots only see all messages of a group with their privacy mode off, see BotFather's /setprivacy.", nil
+	}
+	return "✅ I won't look for trips in the group messages anymore.", nil
+}
+
+// setReadOnly turns read-only mode for maintenance on or off.
+func (h *Handlers) setReadOnly(ctx context.Context, on bool) (string, error) {
+	if err := h.Settings.SetReadOnly(ctx, on); err != nil {
+		return "", err
+	}
+	if on {
+		return "🚧 Read-only mode is on: everyone can still look at the schedule, but changes are refused until you turn it off with <code>/settings readonly off</code>. " +
+			"Scheduled jobs, like the daily assignment, keep running.", nil
+	}
+	return "✅ Read-only mode is off, changes work again.", nil
+}
+
+// setAnnouncementSection adds a section to the daily announcement or takes
+// it out.
+func (h *Handlers) setAnnouncementSection(ctx context.Context, section string, on bool) (string, error) {
+	if err := h.Settings.SetAnnouncementSection(ctx, section, on); errors.Is(err, settings.ErrUnknownSection) {
+		return fmt.Sprintf("❌ Unknown section %s, expected one of %s.", html.EscapeString(section), strings.Join(settings.Sections, ", ")), nil
+	} else if err != nil {
+		return "", err
+	}
+	sections, err := h.Settings.AnnouncementSections(ctx)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("✅ The daily announcement now has: %s.", strings.Join(append([]string{"who is on duty"}, sections...), ", ")), nil
+}
+
+// setDutyTime sets when the duty is assigned or checked for completion, from
+// "HH:MM" or "default", and moves the job.
+func (h *Handlers) setDutyTime(ctx context.Context, assign bool, arg string) (string, error) {
+	if h.DutyJobs == nil {
+		return "⚠️ The times can't be changed here, the jobs aren't scheduled by this bot.", nil
+	}
+	var d time.Duration
+	if arg != "default" {
+		var err error
+		if d, err = scheduler.ParseCutoff(arg); err != nil || d == 0 {
+			return fmt.Sprintf("❌ Invalid time %s, expected HH:MM or default.", html.EscapeString(arg)), nil
+		}
+	}
+	times, err := h.Settings.DutyTimes(ctx)
+	if err != nil {
+		return "", err
+	}
+	if assign {
+		times.Assignment = d
+	} else {
+		times.Completion = d
+	}
+	if err := h.DutyJobs.Set(ctx, times); errors.Is(err, dutyjobs.ErrInvalidTime) {
+		return "❌ " + html.EscapeString(err.Error()), nil
+	} else if err != nil {
+		return "", err
+	}
+
+	if times, err = h.DutyJobs.Times(ctx); err != nil {
+		return "", err
+	}
+	if assign {
+		return fmt.Sprintf("✅ The duty is assigned at %s from now on.", formatClock(times.Assignment)), nil
+	}
+	return fmt.Sprintf("✅ The duty is checked for completion at %s from now on.", formatClock(times.Completion)), nil
+}
+
+// formatClock formats a time of day, as the time since midnight, as HH:MM.
+func formatClock(d time.Duration) string {
+	return fmt.Sprintf("%02d:%02d", int(d.Hours()), int(d.Minutes())%60)
+}
+
+// setFine sets what a missed duty costs in payout mode, from args "<amount>
+// [currency]", or turns payout mode off with "off".
+func (h *Handlers) setFine(ctx context.Context, args []string) (string, error) {
+	var fine int64
+	var currency string
+	if args[0] != "off" {
+		var err error
+		if fine, err = ledger.ParseAmount(args[0]); err != nil {
+			return "❌ " + html.EscapeString(err.Error()), nil
+		}
+		if len(args) > 1 {
+			currency = args[1]
+		}
+	} else if len(args) > 1 {
+		return settingsUsageMessage, nil
+	}
+	if err := h.Settings.SetPayout(ctx, fine, currency, h.today()); err != nil {
+		return "", err
+	}
+	if fine == 0 {
+		return "✅ Payout mode is off, missed duties don't cost anything anymore. Balances are kept.", nil
+	}
+	payout, err := h.Settings.Payout(ctx)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("✅ Payout mode is on: a missed duty costs %s from %s on. See /balance.",
+		ledger.FormatAmount(payout.Fine, payout.Currency), payout.Since.Format("2006-01-02")), nil
+}
+
+// setWeeklyPoll turns the Sunday poll for volunteers on or off.
+func (h *Handlers) setWeeklyPoll(ctx context.Context, on bool) (string, error) {
+	if err := h.Settings.SetWeeklyPoll(ctx, on); err != nil {
+		return "", err
+	}
+	if on {
+		return "✅ Every Sunday at 18:00 I'll ask the group who volunteers for which day of the next week. The days nobody takes are left to the rotation.", nil
+	}
+	return "✅ No more Sunday polls for volunteers.", nil
+}
+
+// setCover sets how long the group has to take over a duty asked to be
+// covered, from minutes, or who takes it over after that, from a user or
+// "none".
+func (h *Handlers) setCover(ctx context.Context, wait bool, arg string) (string, error) {
+	cover, err := h.Settings.Cover(ctx)
+	if err != nil {
+		return "", err
+	}
+	if wait {
+		minutes, err := strconv.Atoi(arg)
+		if err != nil || minutes < 1 {
+			return fmt.Sprintf("❌ Invalid wait %s, expected a number of minutes.", html.EscapeString(arg)), nil
+		}
+		cover.Wait = time.Duration(minutes) * time.Minute
+	} else if arg == "none" {
+		cover.Fallback = 0
+	} else {
+		user, err := h.Users.Find(ctx, arg)
+		if err != nil {
+			return fmt.Sprintf(userNotFoundMessage, html.EscapeString(arg)), nil
+		}
+		cover.Fallback = user.TelegramUserID
+	}
+	if err := h.Settings.SetCover(ctx, cover); err != nil {
+		return "", err
+	}
+	if wait {
+		return fmt.Sprintf("✅ The group now has %d minutes to take over a duty asked to be covered.", int(cover.Wait.Minutes())), nil
+	}
+	return fmt.Sprintf("✅ If nobody takes over a duty in time, %s.", h.coverFallbackText(ctx, cover.Fallback)), nil
+}
+
+// coverFallbackText says who takes over a duty nobody covered, the fallback
+// person with the given Telegram ID or, for 0, nobody.
+func (h *Handlers) coverFallbackText(ctx context.Context, telegramUserID int64) string {
+	if telegramUserID == 0 {
+		return "whoever is on duty keeps it"
+	}
+	if user, err := h.Users.ByTelegramID(ctx, telegramUserID); err == nil {
+		return html.EscapeString(user.FirstName) + " takes it over"
+	}
+	return fmt.Sprintf("<code>%d</code> takes it over", telegramUserID)
+}
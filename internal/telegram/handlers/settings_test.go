@@ -0,0 +1,131 @@
+package handlers_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/korjavin/dutyassistant/internal/scheduler"
+	"github.com/korjavin/dutyassistant/internal/service/diag"
+	"github.com/korjavin/dutyassistant/internal/service/dutyjobs"
+	"github.com/korjavin/dutyassistant/internal/service/settings"
+	"github.com/korjavin/dutyassistant/internal/store"
+	"github.com/korjavin/dutyassistant/internal/store/memory"
+	storemocks "github.com/korjavin/dutyassistant/internal/store/mocks"
+	"github.com/korjavin/dutyassistant/internal/telegram/handlers"
+	"github.com/robfig/cron/v3"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestHandleSettings(t *testing.T) {
+	mockStore := storemocks.NewMockStore(gomock.NewController(t))
+	h := handlers.New(mockStore, nil)
+	h.Settings = settings.New(mockStore)
+	values := map[string]string{settings.KeyGroupChat: "-100", settings.KeyAdmins: "123"}
+	mockStore.EXPECT().GetSetting(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, key string) (string, error) {
+		return values[key], nil
+	}).AnyTimes()
+	mockStore.EXPECT().SetSetting(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, key, value string) error {
+		values[key] = value
+		return nil
+	}).AnyTimes()
+	mockStore.EXPECT().GetUserByTelegramID(gomock.Any(), int64(123)).Return(&store.User{ID: 1, TelegramUserID: 123, FirstName: "Alice"}, nil).AnyTimes()
+	mockStore.EXPECT().GetUserByName(gomock.Any(), "Bob").Return(&store.User{ID: 2, TelegramUserID: 456, FirstName: "Bob"}, nil).AnyTimes()
+	settingsCommand := func(from int64, args string) string {
+		m := adminCommand("settings", args)
+		m.From.ID = from
+		msg, err := h.HandleSettings(m)
+		assert.NoError(t, err)
+		return msg.Text
+	}
+
+	text := settingsCommand(123, "")
+	assert.Contains(t, text, "Group chat: <code>-100</code>")
+	assert.Contains(t, text, "Alice (<code>123</code>)")
+
+	// The chat the command is sent in becomes the group chat
+	assert.Contains(t, settingsCommand(123, "group here"), "now go to chat <code>789</code>")
+	assert.Equal(t, "789", values[settings.KeyGroupChat])
+	assert.Contains(t, settingsCommand(123, "group none"), "no group chat anymore")
+	assert.Equal(t, "0", values[settings.KeyGroupChat])
+
+	// New admins may use admin commands right away, removed ones not anymore
+	assert.Equal(t, "Sorry, this command is for admins only.", settingsCommand(456, ""))
+	assert.Contains(t, settingsCommand(123, "admin add Bob"), "Bob is an admin now")
+	assert.Contains(t, settingsCommand(456, "admin remove 123"), "123 is no admin anymore")
+	assert.Equal(t, "456", values[settings.KeyAdmins])
+	assert.Equal(t, "Sorry, this command is for admins only.", settingsCommand(123, ""))
+	assert.Contains(t, settingsCommand(456, "admin remove Bob"), "last admin")
+
+	assert.Contains(t, settingsCommand(456, "approval on"), "wait for an admin's approval")
+	assert.Equal(t, "true", values[settings.KeyRequireApproval])
+	mockStore.EXPECT().GetUserByTelegramID(gomock.Any(), int64(456)).Return(&store.User{ID: 2, TelegramUserID: 456, FirstName: "Bob"}, nil)
+	assert.Contains(t, settingsCommand(456, ""), "Approval of new users: on")
+
+	assert.Contains(t, settingsCommand(456, "admin promote Bob"), "Usage:")
+
+	assert.Contains(t, settingsCommand(456, "announce upcoming on"), "now has: who is on duty, upcoming.")
+	assert.Equal(t, "✅ The daily announcement now has: who is on duty, upcoming, fairness.", settingsCommand(456, "announce fairness on"))
+	assert.Contains(t, settingsCommand(456, "announce weather on"), "Unknown section weather")
+	assert.Equal(t, "upcoming,fairness", values[settings.KeySections])
+	mockStore.EXPECT().GetUserByTelegramID(gomock.Any(), int64(456)).Return(&store.User{ID: 2, TelegramUserID: 456, FirstName: "Bob"}, nil)
+	assert.Contains(t, settingsCommand(456, ""), "Announcement: who is on duty, upcoming, fairness")
+
+	assert.Contains(t, settingsCommand(456, "poll on"), "Every Sunday at 18:00")
+	assert.Equal(t, "true", values[settings.KeyWeeklyPoll])
+	mockStore.EXPECT().GetUserByTelegramID(gomock.Any(), int64(456)).Return(&store.User{ID: 2, TelegramUserID: 456, FirstName: "Bob"}, nil)
+	assert.Contains(t, settingsCommand(456, ""), "Sunday poll for volunteers: on")
+
+	assert.Equal(t, "✅ The group now has 20 minutes to take over a duty asked to be covered.", settingsCommand(456, "cover wait 20"))
+	assert.Contains(t, settingsCommand(456, "cover wait soon"), "Invalid wait soon")
+	// Bob is named in the reply, then as an admin and the fallback in the settings
+	mockStore.EXPECT().GetUserByTelegramID(gomock.Any(), int64(456)).Return(&store.User{ID: 2, TelegramUserID: 456, FirstName: "Bob"}, nil).Times(3)
+	assert.Equal(t, "✅ If nobody takes over a duty in time, Bob takes it over.", settingsCommand(456, "cover fallback Bob"))
+	assert.Equal(t, "20", values[settings.KeyCoverWait])
+	assert.Equal(t, "456", values[settings.KeyCoverFallback])
+	assert.Contains(t, settingsCommand(456, ""), "Cover: the group has 20 minutes to take over a duty, then Bob takes it over")
+}
+
+func TestHandleStart_SettingsAdmin(t *testing.T) {
+	mockStore := storemocks.NewMockStore(gomock.NewController(t))
+	h := handlers.New(mockStore, nil)
+	h.Settings = settings.New(mockStore)
+	mockStore.EXPECT().GetSetting(gomock.Any(), settings.KeyAdmins).Return("1,123", nil)
+	mockStore.EXPECT().GetUserByTelegramID(gomock.Any(), int64(123)).Return(nil, nil)
+	mockStore.EXPECT().CreateUser(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, u *store.User) error {
+		assert.True(t, u.IsAdmin, "admins in the settings are registered as admins")
+		return nil
+	})
+
+	_, err := h.HandleStart(adminCommand("start", ""))
+	assert.NoError(t, err)
+}
+
+func TestHandleSettings_Time(t *testing.T) {
+	ctx := context.Background()
+	s := memory.New()
+	if err := s.CreateUser(ctx, &store.User{TelegramUserID: 123, FirstName: "Admin", IsAdmin: true, IsActive: true}); err != nil {
+		t.Fatal(err)
+	}
+	sched := scheduler.NewScheduler(s)
+	h := handlers.New(s, sched)
+	h.Settings = settings.New(s)
+	settingsCommand := func(args string) string {
+		msg, err := h.HandleSettings(adminCommand("settings", args))
+		assert.NoError(t, err)
+		return msg.Text
+	}
+
+	assert.Contains(t, settingsCommand("time assign 13:00"), "can't be changed here")
+
+	noop := func() error { return nil }
+	h.DutyJobs = dutyjobs.New(diag.New(s, cron.New()), h.Settings, sched, time.UTC, noop, noop)
+	assert.Contains(t, settingsCommand(""), "Duty: assigned at 11:00, checked at 21:00")
+	assert.Equal(t, "✅ The duty is assigned at 13:00 from now on.", settingsCommand("time assign 13:00"))
+	assert.Equal(t, "✅ The duty is checked for completion at 22:30 from now on.", settingsCommand("time complete 22:30"))
+	assert.Contains(t, settingsCommand("time complete 12:00"), "must be checked after it is assigned at 13:00")
+	assert.Contains(t, settingsCommand("time assign noon"), "Invalid time noon")
+	assert.Equal(t, "✅ The duty is assigned at 11:00 from now on.", settingsCommand("time assign default"))
+	assert.Contains(t, settingsCommand(""), "Duty: assigned at 11:00, checked at 22:30")
+}
@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/korjavin/dutyassistant/internal/store"
+	"github.com/korjavin/dutyassistant/internal/telegram/parse"
+)
+
+const skipUsageMessage = "⏭ <b>Mark a day without duty</b>\n\n" +
+	"Usage: <code>/skip date [holiday|eating_out|away]</code>\n\n" +
+	"Example: <code>/skip 2025-12-25 holiday</code>\n\n" +
+	"The daily assignment leaves skipped days alone. Use <code>/unskip date</code> to undo."
+
+// skipReasonLabels are the human-readable names of the skip reasons.
+var skipReasonLabels = map[store.SkipReason]string{
+	store.SkipReasonHoliday:   "holiday",
+	store.SkipReasonEatingOut: "eating out",
+	store.SkipReasonAway:      "away",
+}
+
+// HandleSkip marks a day as "no duty" for admins. Format: /skip <date> [reason]
+func (h *Handlers) HandleSkip(m *tgbotapi.Message) (tgbotapi.MessageConfig, error) {
+	isAdmin, err := h.checkAdmin(m.From.ID)
+	if err != nil || !isAdmin {
+		return tgbotapi.NewMessage(m.Chat.ID, adminOnlyMessage), nil
+	}
+
+	args := strings.Fields(m.CommandArguments())
+	if len(args) == 0 || len(args) > 2 {
+		msg := tgbotapi.NewMessage(m.Chat.ID, skipUsageMessage)
+		msg.ParseMode = tgbotapi.ModeHTML
+		return msg, nil
+	}
+
+	date, err := parse.Date(args[0])
+	if err != nil {
+		return tgbotapi.NewMessage(m.Chat.ID, invalidDateMessage), nil
+	}
+
+	reason := store.SkipReasonHoliday
+	if len(args) == 2 {
+		reason = store.SkipReason(strings.ToLower(args[1]))
+		if _, ok := skipReasonLabels[reason]; !ok {
+			msg := tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("⚠️ Unknown reason '%s'.\n\n%s", escapeHTML(args[1]), skipUsageMessage))
+			msg.ParseMode = tgbotapi.ModeHTML
+			return msg, nil
+		}
+	}
+
+	dateStr := date.Format(parse.DateLayout)
+	if err := h.Scheduler.SkipDay(context.Background(), date, reason); err != nil {
+		return tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("❌ Failed to skip %s: %v", dateStr, err)), nil
+	}
+	// Skipping a day nobody is on duty publishes no event
+	h.InvalidateCalendars(context.Background(), nil)
+	return tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("⏭ %s is marked as no duty (%s).", dateStr, skipReasonLabels[reason])), nil
+}
+
+// HandleUnskip removes the "no duty" mark from a day for admins. Format: /unskip <date>
+func (h *Handlers) HandleUnskip(m *tgbotapi.Message) (tgbotapi.MessageConfig, error) {
+	isAdmin, err := h.checkAdmin(m.From.ID)
+	if err != nil || !isAdmin {
+		return tgbotapi.NewMessage(m.Chat.ID, adminOnlyMessage), nil
+	}
+
+	args := strings.Fields(m.CommandArguments())
+	if len(args) != 1 {
+		msg := tgbotapi.NewMessage(m.Chat.ID, "Usage: <code>/unskip date</code>\n\nExample: <code>/unskip 2025-12-25</code>")
+		msg.ParseMode = tgbotapi.ModeHTML
+		return msg, nil
+	}
+
+	date, err := parse.Date(args[0])
+	if err != nil {
+		return tgbotapi.NewMessage(m.Chat.ID, invalidDateMessage), nil
+	}
+
+	dateStr := date.Format(parse.DateLayout)
+	if err := h.Scheduler.UnskipDay(context.Background(), date); err != nil {
+		return tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("❌ Failed to unskip %s: %v", dateStr, err)), nil
+	}
+	h.InvalidateCalendars(context.Background(), nil)
+	return tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("✅ %s is a regular duty day again.", dateStr)), nil
+}
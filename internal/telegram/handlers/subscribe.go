@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/korjavin/dutyassistant/internal/store"
+)
+
+const (
+	subscribedMessage = "🔔 You'll get a private message whenever one of your days is assigned, moved or released. " +
+		"Make sure you've started a private chat with me so I can reach you.\n\nUse /unsubscribe to stop."
+	unsubscribedMessage = "🔕 You won't get messages about changes to your days anymore. Use /subscribe to turn them back on."
+)
+
+// HandleSubscribe subscribes the user to changes of their own duties. Format: /subscribe
+func (h *Handlers) HandleSubscribe(m *tgbotapi.Message) (tgbotapi.MessageConfig, error) {
+	ctx := context.Background()
+	user, err := h.Users.ByTelegramID(ctx, m.From.ID)
+	if err != nil {
+		return tgbotapi.NewMessage(m.Chat.ID, volunteerUserNotFoundMessage), nil
+	}
+
+	sub := &store.ChangeSubscription{UserID: user.ID, CreatedAt: time.Now()}
+	if err := h.Store.CreateChangeSubscription(ctx, sub); err != nil {
+		log.Printf("[HandleSubscribe] Failed to subscribe user %d: %v", user.ID, err)
+		return tgbotapi.NewMessage(m.Chat.ID, genericErrorMessage), nil
+	}
+	return tgbotapi.NewMessage(m.Chat.ID, subscribedMessage), nil
+}
+
+// HandleUnsubscribe stops the messages HandleSubscribe asked for. Format: /unsubscribe
+func (h *Handlers) HandleUnsubscribe(m *tgbotapi.Message) (tgbotapi.MessageConfig, error) {
+	ctx := context.Background()
+	user, err := h.Users.ByTelegramID(ctx, m.From.ID)
+	if err != nil {
+		return tgbotapi.NewMessage(m.Chat.ID, volunteerUserNotFoundMessage), nil
+	}
+
+	if err := h.Store.DeleteChangeSubscription(ctx, user.ID); err != nil {
+		log.Printf("[HandleUnsubscribe] Failed to unsubscribe user %d: %v", user.ID, err)
+		return tgbotapi.NewMessage(m.Chat.ID, genericErrorMessage), nil
+	}
+	return tgbotapi.NewMessage(m.Chat.ID, unsubscribedMessage), nil
+}
@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/korjavin/dutyassistant/internal/notification"
+	"github.com/korjavin/dutyassistant/internal/store"
+	"github.com/korjavin/dutyassistant/internal/telegram/parse"
+)
+
+// takeoverUserAction is the callback action for the person picked after "Assign anyway".
+const takeoverUserAction = "takeover_user"
+
+// HandleTakeoverCallback handles the admin's decision about a day nobody was
+// available for: assign anyway to a chosen person, skip it, or mark it as
+// covered by external help.
+func (h *Handlers) HandleTakeoverCallback(q *tgbotapi.CallbackQuery) (tgbotapi.EditMessageTextConfig, error) {
+	if refusal, ok := h.refuseNonAdminCallback(q); !ok {
+		return refusal, nil
+	}
+
+	cb := parse.ParseCallback(q.Data)
+	expected := 1
+	if cb.Action == takeoverUserAction {
+		expected = 2
+	}
+	if err := cb.Expect(expected); err != nil {
+		return tgbotapi.EditMessageTextConfig{}, err
+	}
+	date, err := cb.Date(0)
+	if err != nil {
+		return tgbotapi.EditMessageTextConfig{}, err
+	}
+	dateStr := date.Format(parse.DateLayout)
+	ctx := context.Background()
+
+	switch cb.Action {
+	case notification.TakeoverAssignAction:
+		return h.takeoverUserPicker(ctx, q, date)
+
+	case takeoverUserAction:
+		userID, err := cb.ID(1)
+		if err != nil {
+			return tgbotapi.EditMessageTextConfig{}, err
+		}
+		return h.takeoverAssign(ctx, q, date, userID, store.AssignmentTypeAdmin)
+
+	case notification.TakeoverExternalAction:
+		admin, err := h.Users.ByTelegramID(ctx, q.From.ID)
+		if err != nil {
+			return tgbotapi.NewEditMessageText(q.Message.Chat.ID, q.Message.MessageID,
+				"❌ External help is recorded on your account, but you're not registered yet. Use /start first."), nil
+		}
+		return h.takeoverAssign(ctx, q, date, admin.ID, store.AssignmentTypeExternal)
+
+	case notification.TakeoverSkipAction:
+		if err := h.Scheduler.SkipDay(ctx, date, store.SkipReasonAway); err != nil {
+			return tgbotapi.NewEditMessageText(q.Message.Chat.ID, q.Message.MessageID,
+				fmt.Sprintf("❌ Failed to skip %s: %v", dateStr, err)), nil
+		}
+		// Nobody took the day, so skipping it publishes no event
+		h.InvalidateCalendars(ctx, nil)
+		return tgbotapi.NewEditMessageText(q.Message.Chat.ID, q.Message.MessageID,
+			fmt.Sprintf("⏭ Skipped. There is no duty on %s.", dateStr)), nil
+	}
+	return tgbotapi.EditMessageTextConfig{}, parse.ErrInvalidCallback
+}
+
+// takeoverUserPicker lists all active users, including those who are off-duty.
+func (h *Handlers) takeoverUserPicker(ctx context.Context, q *tgbotapi.CallbackQuery, date time.Time) (tgbotapi.EditMessageTextConfig, error) {
+	text, keyboard, err := h.userPicker(ctx, takeoverUserAction+":"+date.Format(parse.DateLayout), 0)
+	if err != nil {
+		return tgbotapi.NewEditMessageText(q.Message.Chat.ID, q.Message.MessageID, "❌ No active users found."), nil
+	}
+	edit := tgbotapi.NewEditMessageText(q.Message.Chat.ID, q.Message.MessageID, text)
+	edit.ParseMode = tgbotapi.ModeHTML
+	edit.ReplyMarkup = &keyboard
+	return edit, nil
+}
+
+// takeoverAssign records the admin's choice and announces it to the group.
+func (h *Handlers) takeoverAssign(ctx context.Context, q *tgbotapi.CallbackQuery, date time.Time, userID int64, assignType store.AssignmentType) (tgbotapi.EditMessageTextConfig, error) {
+	dateStr := date.Format(parse.DateLayout)
+	assign := h.Duties.Assign
+	if assignType == store.AssignmentTypeAdmin {
+		// Nobody was available, so the admin may pick someone who is off duty
+		assign = func(ctx context.Context, date time.Time, userID int64, _ store.AssignmentType) (*store.Duty, error) {
+			return h.Duties.AssignAnyway(ctx, date, userID)
+		}
+	}
+	duty, err := assign(ctx, date, userID, assignType)
+	if err != nil {
+		return tgbotapi.NewEditMessageText(q.Message.Chat.ID, q.Message.MessageID,
+			fmt.Sprintf("❌ Failed to assign duty for %s: %v", dateStr, err)), nil
+	}
+
+	text := fmt.Sprintf("🤝 %s is marked as covered by external help.", dateStr)
+	if assignType != store.AssignmentTypeExternal {
+		text = fmt.Sprintf("✅ <b>%s</b> is on duty on %s.", escapeHTML(duty.User.FirstName), dateStr)
+	}
+	edit := tgbotapi.NewEditMessageText(q.Message.Chat.ID, q.Message.MessageID, text)
+	edit.ParseMode = tgbotapi.ModeHTML
+	return edit, nil
+}
@@ -0,0 +1,273 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/korjavin/dutyassistant/internal/i18n"
+	"github.com/korjavin/dutyassistant/internal/notification"
+	"github.com/korjavin/dutyassistant/internal/service/task"
+	"github.com/korjavin/dutyassistant/internal/store"
+	"github.com/korjavin/dutyassistant/internal/telegram/parse"
+)
+
+// taskListArg is the second callback argument of the buttons of a /tasks
+// list, whose presses show the list again instead of the single task.
+const taskListArg = "list"
+
+const (
+	tasksUsageMessage = "🧰 <b>Extra tasks</b>\n\n" +
+		"One-off chores outside the rotation. Claim one with its button and tap ✅ once it's done; " +
+		"it counts towards your /status with its weight.\n\n" +
+		"<code>/tasks</code> - list the open tasks\n" +
+		"<code>/tasks add [weight] [date] title</code> - add a task and announce it in the group\n" +
+		"<code>/tasks done id</code> - mark a claimed task done\n" +
+		"<code>/tasks del id</code> - delete a task\n\n" +
+		"Weight is how many duty days the task counts for, 1 to 10 (default 1); date is when it's due, YYYY-MM-DD.\n\n" +
+		"Example: <code>/tasks add 3 2025-11-08 Clean the garage</code>"
+	tasksEmptyMessage = "🧰 There are no open tasks right now."
+)
+
+// HandleTasks lists the open one-off tasks with buttons to claim them, and
+// lets admins manage them.
+// Format: /tasks [add [weight] [date] <title> | done <id> | del <id>]
+func (h *Handlers) HandleTasks(m *tgbotapi.Message) (tgbotapi.MessageConfig, error) {
+	ctx := context.Background()
+	args := strings.Fields(m.CommandArguments())
+	if len(args) == 0 {
+		return h.tasksList(ctx, m.Chat.ID, m.From.ID)
+	}
+
+	isAdmin, err := h.checkAdmin(m.From.ID)
+	if err != nil || !isAdmin {
+		return tgbotapi.NewMessage(m.Chat.ID, adminOnlyMessage), nil
+	}
+	switch {
+	case args[0] == "add" && len(args) >= 2:
+		return h.addTask(ctx, m.Chat.ID, args[1:])
+	case (args[0] == "done" || args[0] == "del") && len(args) == 2:
+		id, err := strconv.ParseInt(strings.TrimPrefix(args[1], "#"), 10, 64)
+		if err != nil {
+			return tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("❌ Invalid task ID: %s", args[1])), nil
+		}
+		var reply string
+		if args[0] == "done" {
+			_, err = h.Tasks.Confirm(ctx, id)
+			reply = fmt.Sprintf("✅ Task #%d is done.", id)
+		} else {
+			err = h.Tasks.Delete(ctx, id)
+			reply = fmt.Sprintf("🗑 Task #%d deleted.", id)
+		}
+		switch {
+		case errors.Is(err, task.ErrNotFound):
+			reply = fmt.Sprintf("❌ There is no task #%d.", id)
+		case errors.Is(err, task.ErrNotClaimed):
+			reply = fmt.Sprintf("❌ Nobody claimed task #%d yet.", id)
+		case errors.Is(err, task.ErrDone):
+			reply = fmt.Sprintf("Task #%d is done already.", id)
+		case err != nil:
+			log.Printf("[HandleTasks] Failed to handle %q: %v", m.Text, err)
+			reply = genericErrorMessage
+		}
+		return tgbotapi.NewMessage(m.Chat.ID, reply), nil
+	default:
+		msg := tgbotapi.NewMessage(m.Chat.ID, tasksUsageMessage)
+		msg.ParseMode = tgbotapi.ModeHTML
+		return msg, nil
+	}
+}
+
+// addTask adds a task from the arguments of /tasks add, an optional weight
+// and due date followed by the title, and announces it in the group.
+func (h *Handlers) addTask(ctx context.Context, chatID int64, args []string) (tgbotapi.MessageConfig, error) {
+	weight := task.DefaultWeight
+	if n, err := strconv.Atoi(args[0]); err == nil && len(args) > 1 {
+		weight, args = n, args[1:]
+	}
+	var due *time.Time
+	if date, err := parse.Date(args[0]); err == nil && len(args) > 1 {
+		due, args = &date, args[1:]
+	}
+	t, err := h.Tasks.Add(ctx, strings.Join(args, " "), weight, due)
+	if err != nil {
+		return tgbotapi.NewMessage(chatID, fmt.Sprintf("❌ Failed to add the task: %v", err)), nil
+	}
+	if h.Notifier == nil {
+		return tgbotapi.NewMessage(chatID, fmt.Sprintf("✅ Task #%d added, members claim it with /tasks.", t.ID)), nil
+	}
+	if err := h.Notifier.AnnounceTask(ctx, t); err != nil {
+		log.Printf("[HandleTasks] %v", err)
+		return tgbotapi.NewMessage(chatID, fmt.Sprintf("✅ Task #%d added, but announcing it failed. Members claim it with /tasks.", t.ID)), nil
+	}
+	return tgbotapi.NewMessage(chatID, fmt.Sprintf("✅ Task #%d added and announced in the group.", t.ID)), nil
+}
+
+// HandleTaskCallback claims, completes or gives back a task. Pressed under a
+// /tasks list it shows the list again, under an announced task that task.
+// Format: task_claim|task_done|task_release:<task ID>[:list]
+func (h *Handlers) HandleTaskCallback(q *tgbotapi.CallbackQuery) (tgbotapi.Chattable, error) {
+	cb := parse.ParseCallback(q.Data)
+	if len(cb.Args) != 1 && (len(cb.Args) != 2 || cb.Args[1] != taskListArg) {
+		return nil, parse.ErrInvalidCallback
+	}
+	id, err := cb.ID(0)
+	if err != nil {
+		return nil, err
+	}
+	list := len(cb.Args) == 2
+
+	ctx := context.Background()
+	chatID, messageID := q.Message.Chat.ID, q.Message.MessageID
+	user, err := h.Users.ByTelegramID(ctx, q.From.ID)
+	if err != nil {
+		return tgbotapi.NewMessage(chatID, volunteerUserNotFoundMessage), nil
+	}
+	var t *store.Task
+	switch cb.Action {
+	case notification.ClaimTaskAction:
+		t, err = h.Tasks.Claim(ctx, id, user.ID)
+	case notification.CompleteTaskAction:
+		t, err = h.Tasks.Complete(ctx, id, user.ID)
+	case notification.ReleaseTaskAction:
+		t, err = h.Tasks.Release(ctx, id, user.ID)
+	default:
+		return nil, parse.ErrInvalidCallback
+	}
+
+	// Someone else's press mustn't change the message for everyone, so
+	// refusals get a message of their own
+	switch {
+	case errors.Is(err, task.ErrNotFound):
+		if !list {
+			return tgbotapi.NewEditMessageText(chatID, messageID, "🗑 This task was deleted."), nil
+		}
+	case errors.Is(err, task.ErrTaken):
+		return tgbotapi.NewMessage(chatID, fmt.Sprintf("Someone else is on task #%d already.", id)), nil
+	case errors.Is(err, task.ErrNotClaimed):
+		return tgbotapi.NewMessage(chatID, fmt.Sprintf("Task #%d isn't yours to give back.", id)), nil
+	case errors.Is(err, task.ErrDone):
+		if !list {
+			return tgbotapi.NewMessage(chatID, fmt.Sprintf("Task #%d is done already.", id)), nil
+		}
+	case err != nil:
+		log.Printf("[HandleTaskCallback] Failed to handle %q for user %d: %v", q.Data, user.ID, err)
+		return tgbotapi.NewEditMessageText(chatID, messageID, genericErrorMessage), nil
+	}
+
+	l := h.locale(ctx, chatID)
+	if list {
+		text, keyboard, err := h.tasksListText(ctx, l, q.From.ID)
+		if err != nil {
+			log.Printf("[HandleTaskCallback] Failed to list tasks: %v", err)
+			return tgbotapi.NewEditMessageText(chatID, messageID, genericErrorMessage), nil
+		}
+		edit := tgbotapi.NewEditMessageText(chatID, messageID, text)
+		edit.ParseMode = tgbotapi.ModeHTML
+		edit.ReplyMarkup = keyboard
+		return edit, nil
+	}
+	edit := tgbotapi.NewEditMessageText(chatID, messageID, notification.FormatTask(l, t, h.taskClaimer(ctx, t)))
+	edit.ReplyMarkup = taskKeyboard(t)
+	return edit, nil
+}
+
+// taskClaimer returns the user who claimed the task, or nil if nobody did or
+// they can't be found.
+func (h *Handlers) taskClaimer(ctx context.Context, t *store.Task) *store.User {
+	if t.ClaimedBy == nil {
+		return nil
+	}
+	u, err := h.Users.ByID(ctx, *t.ClaimedBy)
+	if err != nil {
+		return nil
+	}
+	return u
+}
+
+// taskKeyboard has the buttons of an announced task: to claim it while it is
+// free, to mark it done or give it back while it is claimed, none once done.
+func taskKeyboard(t *store.Task) *tgbotapi.InlineKeyboardMarkup {
+	var row []tgbotapi.InlineKeyboardButton
+	switch {
+	case t.DoneAt != nil:
+		return nil
+	case t.ClaimedBy == nil:
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData("🙋 I'll do it", fmt.Sprintf("%s:%d", notification.ClaimTaskAction, t.ID)))
+	default:
+		row = append(row,
+			tgbotapi.NewInlineKeyboardButtonData("✅ Done", fmt.Sprintf("%s:%d", notification.CompleteTaskAction, t.ID)),
+			tgbotapi.NewInlineKeyboardButtonData("↩️ Give back", fmt.Sprintf("%s:%d", notification.ReleaseTaskAction, t.ID)))
+	}
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(row)
+	return &keyboard
+}
+
+// tasksList lists the open tasks, with buttons for the sender to claim the
+// free ones and finish or give back their own.
+func (h *Handlers) tasksList(ctx context.Context, chatID, telegramUserID int64) (tgbotapi.MessageConfig, error) {
+	text, keyboard, err := h.tasksListText(ctx, h.locale(ctx, chatID), telegramUserID)
+	if err != nil {
+		log.Printf("[HandleTasks] Failed to list tasks: %v", err)
+		return tgbotapi.NewMessage(chatID, genericErrorMessage), nil
+	}
+	msg := tgbotapi.NewMessage(chatID, text)
+	msg.ParseMode = tgbotapi.ModeHTML
+	if keyboard != nil {
+		msg.ReplyMarkup = *keyboard
+	}
+	return msg, nil
+}
+
+// tasksListText renders the open tasks and the buttons of the user with the
+// Telegram ID, nil if they have none to press.
+func (h *Handlers) tasksListText(ctx context.Context, l i18n.Locale, telegramUserID int64) (string, *tgbotapi.InlineKeyboardMarkup, error) {
+	open, err := h.Tasks.Open(ctx)
+	if err != nil {
+		return "", nil, err
+	}
+	if len(open) == 0 {
+		return tasksEmptyMessage, nil, nil
+	}
+	var userID int64
+	if u, err := h.Users.ByTelegramID(ctx, telegramUserID); err == nil {
+		userID = u.ID
+	}
+
+	var b strings.Builder
+	var rows [][]tgbotapi.InlineKeyboardButton
+	b.WriteString("🧰 <b>Extra tasks</b>\n")
+	for _, t := range open {
+		fmt.Fprintf(&b, "\n#%d %s, %s", t.ID, escapeHTML(t.Title), notification.FormatTaskWeight(t.Weight))
+		if t.DueDate != nil {
+			fmt.Fprintf(&b, ", due %s", l.Format(*t.DueDate, "Mon, Jan 2"))
+		}
+		switch {
+		case t.ClaimedBy == nil:
+			b.WriteString("\n    Up for grabs")
+			rows = append(rows, tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData(
+				fmt.Sprintf("🙋 #%d %s", t.ID, t.Title), fmt.Sprintf("%s:%d:%s", notification.ClaimTaskAction, t.ID, taskListArg))))
+		case *t.ClaimedBy == userID:
+			b.WriteString("\n    🙋 You're on it")
+			rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData(fmt.Sprintf("✅ #%d done", t.ID), fmt.Sprintf("%s:%d:%s", notification.CompleteTaskAction, t.ID, taskListArg)),
+				tgbotapi.NewInlineKeyboardButtonData(fmt.Sprintf("↩️ Give back #%d", t.ID), fmt.Sprintf("%s:%d:%s", notification.ReleaseTaskAction, t.ID, taskListArg))))
+		default:
+			name := "Someone"
+			if u := h.taskClaimer(ctx, t); u != nil {
+				name = escapeHTML(u.Label())
+			}
+			fmt.Fprintf(&b, "\n    🙋 %s is on it", name)
+		}
+	}
+	if len(rows) == 0 {
+		return b.String(), nil, nil
+	}
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(rows...)
+	return b.String(), &keyboard, nil
+}
@@ -0,0 +1,84 @@
+package handlers_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/korjavin/dutyassistant/internal/notification"
+	"github.com/korjavin/dutyassistant/internal/store"
+	"github.com/korjavin/dutyassistant/internal/store/memory"
+	"github.com/korjavin/dutyassistant/internal/telegram/handlers"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandleTasks(t *testing.T) {
+	ctx := context.Background()
+	s := memory.New()
+	h := handlers.New(s, nil)
+	if err := s.CreateUser(ctx, &store.User{TelegramUserID: 123, FirstName: "Admin", IsAdmin: true, IsActive: true}); err != nil {
+		t.Fatal(err)
+	}
+	alice := &store.User{TelegramUserID: 456, FirstName: "Alice", IsActive: true}
+	if err := s.CreateUser(ctx, alice); err != nil {
+		t.Fatal(err)
+	}
+	press := func(telegramUserID int64, data string) tgbotapi.Chattable {
+		response, err := h.HandleTaskCallback(&tgbotapi.CallbackQuery{
+			From:    &tgbotapi.User{ID: telegramUserID},
+			Message: &tgbotapi.Message{MessageID: 1, Chat: &tgbotapi.Chat{ID: -100}},
+			Data:    data,
+		})
+		assert.NoError(t, err)
+		return response
+	}
+
+	msg, err := h.HandleTasks(adminCommand("tasks", ""))
+	assert.NoError(t, err)
+	assert.Equal(t, "🧰 There are no open tasks right now.", msg.Text)
+
+	msg, err = h.HandleTasks(adminCommand("tasks", "add 3 2025-11-08 Clean the garage"))
+	assert.NoError(t, err)
+	assert.Equal(t, "✅ Task #1 added, members claim it with /tasks.", msg.Text)
+	msg, err = h.HandleTasks(adminCommand("tasks", "add 11 Wash the windows"))
+	assert.NoError(t, err)
+	assert.Contains(t, msg.Text, "the weight must be between 1 and 10")
+
+	msg, err = h.HandleTasks(adminCommand("tasks", ""))
+	assert.NoError(t, err)
+	assert.Contains(t, msg.Text, "#1 Clean the garage, counts as 3 duty days, due Sat, Nov 8\n    Up for grabs")
+
+	// Alice claims it from the announcement, the admin can't take it over
+	claim := fmt.Sprintf("%s:1", notification.ClaimTaskAction)
+	edit, ok := press(456, claim).(tgbotapi.EditMessageTextConfig)
+	assert.True(t, ok)
+	assert.Contains(t, edit.Text, "🙋 @Alice is on it.")
+	assert.Len(t, edit.ReplyMarkup.InlineKeyboard[0], 2)
+	refusal, ok := press(123, fmt.Sprintf("%s:1", notification.CompleteTaskAction)).(tgbotapi.MessageConfig)
+	assert.True(t, ok)
+	assert.Equal(t, "Someone else is on task #1 already.", refusal.Text)
+
+	// Her /tasks list has her buttons
+	msg, err = h.HandleTasks(&tgbotapi.Message{Chat: &tgbotapi.Chat{ID: 456}, From: &tgbotapi.User{ID: 456}, Text: "/tasks",
+		Entities: []tgbotapi.MessageEntity{{Type: "bot_command", Length: 6}}})
+	assert.NoError(t, err)
+	assert.Contains(t, msg.Text, "🙋 You're on it")
+	edit, ok = press(456, fmt.Sprintf("%s:1:list", notification.CompleteTaskAction)).(tgbotapi.EditMessageTextConfig)
+	assert.True(t, ok)
+	assert.Equal(t, "🧰 There are no open tasks right now.", edit.Text)
+
+	stats, err := s.GetUserStats(ctx, alice.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, stats.TaskPoints)
+
+	msg, err = h.HandleTasks(adminCommand("tasks", "done 1"))
+	assert.NoError(t, err)
+	assert.Equal(t, "Task #1 is done already.", msg.Text)
+	msg, err = h.HandleTasks(adminCommand("tasks", "del 1"))
+	assert.NoError(t, err)
+	assert.Equal(t, "🗑 Task #1 deleted.", msg.Text)
+	edit, ok = press(456, claim).(tgbotapi.EditMessageTextConfig)
+	assert.True(t, ok)
+	assert.Equal(t, "🗑 This task was deleted.", edit.Text)
+}
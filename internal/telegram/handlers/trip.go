@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"log"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/korjavin/dutyassistant/internal/service/trip"
+	"github.com/korjavin/dutyassistant/internal/telegram/parse"
+)
+
+// Callback actions of the buttons offered for a trip spotted in the group.
+const (
+	tripOffDutyAction = "trip_offduty"
+	tripDismissAction = "trip_dismiss"
+)
+
+// HandleTripHint looks at a message sent to the group chat and, if it
+// announces a trip of a member like "we're away next week", replies with
+// buttons to set the matching off-duty period for them. It returns nil for
+// all other messages, and unless /settings trips is on.
+func (h *Handlers) HandleTripHint(m *tgbotapi.Message) (tgbotapi.Chattable, error) {
+	if h.Settings == nil || m.From == nil || m.Text == "" {
+		return nil, nil
+	}
+	ctx := context.Background()
+	on, err := h.Settings.TripHints(ctx)
+	if err != nil || !on {
+		return nil, err
+	}
+	if groupID, err := h.Settings.GroupChatID(ctx); err != nil || groupID != m.Chat.ID {
+		return nil, err
+	}
+	period, ok := trip.Detect(m.Text, h.today())
+	if !ok {
+		return nil, nil
+	}
+
+	// Only members in the rotation get hints. Juniors' trips are up to the
+	// grown-ups.
+	user, err := h.Users.ByTelegramID(ctx, m.From.ID)
+	if err != nil || user.IsPending || !user.IsActive || user.IsJunior {
+		return nil, nil
+	}
+	// Nothing to offer if they're off duty then already
+	offAtStart, err := h.Store.IsUserOffDuty(ctx, user.ID, period.Start)
+	if err != nil {
+		return nil, err
+	}
+	offAtEnd, err := h.Store.IsUserOffDuty(ctx, user.ID, period.End)
+	if err != nil || (offAtStart && offAtEnd) {
+		return nil, err
+	}
+
+	l := h.locale(ctx, m.Chat.ID)
+	text := fmt.Sprintf("🏖 Sounds like a trip, %s! Shall I set you off duty from %s to %s?",
+		html.EscapeString(user.GroupName()), l.Format(period.Start, "Mon, Jan 2"), l.Format(period.End, "Mon, Jan 2"))
+	if user.OffDutyStart != nil && user.OffDutyEnd != nil && !user.OffDutyEnd.Before(h.today()) {
+		text += fmt.Sprintf("\n\nThat replaces your off-duty period from %s to %s.",
+			l.Format(*user.OffDutyStart, "Mon, Jan 2"), l.Format(*user.OffDutyEnd, "Mon, Jan 2"))
+	}
+	msg := tgbotapi.NewMessage(m.Chat.ID, text)
+	msg.ParseMode = tgbotapi.ModeHTML
+	msg.ReplyToMessageID = m.MessageID
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData("✅ Set off duty", fmt.Sprintf("%s:%s:%s", tripOffDutyAction,
+			period.Start.Format(parse.DateLayout), period.End.Format(parse.DateLayout))),
+		tgbotapi.NewInlineKeyboardButtonData("✖️ No thanks", tripDismissAction),
+	))
+	return msg, nil
+}
+
+// HandleTripCallback sets the off-duty period offered by HandleTripHint for
+// whoever the hint was for, or dismisses the hint.
+func (h *Handlers) HandleTripCallback(q *tgbotapi.CallbackQuery) (tgbotapi.EditMessageTextConfig, error) {
+	cb := parse.ParseCallback(q.Data)
+	if cb.Action == tripDismissAction {
+		return tgbotapi.NewEditMessageText(q.Message.Chat.ID, q.Message.MessageID, "👌 Never mind then."), nil
+	}
+	if err := cb.Expect(2); err != nil {
+		return tgbotapi.EditMessageTextConfig{}, err
+	}
+	start, err := cb.Date(0)
+	if err != nil {
+		return tgbotapi.EditMessageTextConfig{}, err
+	}
+	end, err := cb.Date(1)
+	if err != nil {
+		return tgbotapi.EditMessageTextConfig{}, err
+	}
+
+	ctx := context.Background()
+	user, err := h.Users.ByTelegramID(ctx, q.From.ID)
+	if err != nil {
+		return tgbotapi.NewEditMessageText(q.Message.Chat.ID, q.Message.MessageID, "❌ "+volunteerUserNotFoundMessage), nil
+	}
+	if end.Before(h.today()) {
+		return tgbotapi.NewEditMessageText(q.Message.Chat.ID, q.Message.MessageID, "⌛ That trip is over already."), nil
+	}
+	if err := h.Scheduler.SetOffDuty(ctx, user.ID, start, end); err != nil {
+		log.Printf("[HandleTripCallback] Failed to set user %d off duty: %v", user.ID, err)
+		return tgbotapi.NewEditMessageText(q.Message.Chat.ID, q.Message.MessageID, genericErrorMessage), nil
+	}
+
+	l := h.locale(ctx, q.Message.Chat.ID)
+	edit := tgbotapi.NewEditMessageText(q.Message.Chat.ID, q.Message.MessageID,
+		fmt.Sprintf("🏖 %s is off duty from %s to %s. Have a good trip!",
+			html.EscapeString(user.GroupName()), l.Format(start, "Mon, Jan 2"), l.Format(end, "Mon, Jan 2")))
+	edit.ParseMode = tgbotapi.ModeHTML
+	return edit, nil
+}
@@ -0,0 +1,75 @@
+package handlers_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/korjavin/dutyassistant/internal/scheduler"
+	"github.com/korjavin/dutyassistant/internal/service/settings"
+	"github.com/korjavin/dutyassistant/internal/store"
+	"github.com/korjavin/dutyassistant/internal/store/memory"
+	"github.com/korjavin/dutyassistant/internal/telegram/handlers"
+	"github.com/korjavin/dutyassistant/internal/telegram/parse"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandleTripHint(t *testing.T) {
+	ctx := context.Background()
+	s := memory.New()
+	h := handlers.New(s, scheduler.NewScheduler(s))
+	h.Settings = settings.New(s)
+	alice := &store.User{TelegramUserID: 456, FirstName: "Alice", IsActive: true}
+	if err := s.CreateUser(ctx, alice); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.Settings.SetGroupChatID(ctx, -100); err != nil {
+		t.Fatal(err)
+	}
+	say := func(chatID, from int64, text string) tgbotapi.Chattable {
+		response, err := h.HandleTripHint(&tgbotapi.Message{MessageID: 7, Chat: &tgbotapi.Chat{ID: chatID}, From: &tgbotapi.User{ID: from}, Text: text})
+		assert.NoError(t, err)
+		return response
+	}
+
+	assert.Nil(t, say(-100, 456, "We're away next week"), "hints are off by default")
+	if err := h.Settings.SetTripHints(ctx, true); err != nil {
+		t.Fatal(err)
+	}
+	assert.Nil(t, say(-100, 456, "Dinner is at 7"))
+	assert.Nil(t, say(456, 456, "We're away next week"), "only the group is listened to")
+	assert.Nil(t, say(-100, 999, "We're away next week"), "only members get hints")
+
+	msg, ok := say(-100, 456, "We're away next week").(tgbotapi.MessageConfig)
+	if !ok {
+		t.Fatal("Expected a hint for Alice's trip")
+	}
+	assert.Contains(t, msg.Text, "Sounds like a trip, Alice! Shall I set you off duty")
+	assert.Equal(t, 7, msg.ReplyToMessageID)
+	buttons := msg.ReplyMarkup.(tgbotapi.InlineKeyboardMarkup).InlineKeyboard[0]
+	assert.Len(t, buttons, 2)
+
+	press := func(data string) string {
+		edit, err := h.HandleTripCallback(&tgbotapi.CallbackQuery{
+			From:    &tgbotapi.User{ID: 456},
+			Message: &tgbotapi.Message{MessageID: 8, Chat: &tgbotapi.Chat{ID: -100}},
+			Data:    data,
+		})
+		assert.NoError(t, err)
+		return edit.Text
+	}
+	assert.Equal(t, "👌 Never mind then.", press(*buttons[1].CallbackData))
+	assert.Contains(t, press(*buttons[0].CallbackData), "Alice is off duty from")
+
+	cb := parse.ParseCallback(*buttons[0].CallbackData)
+	start, _ := cb.Date(0)
+	end, _ := cb.Date(1)
+	for _, day := range []time.Time{start, end} {
+		off, err := s.IsUserOffDuty(ctx, alice.ID, day)
+		assert.NoError(t, err)
+		assert.True(t, off)
+	}
+	assert.Nil(t, say(-100, 456, "Reminder: we're away next week"), "Alice is off duty then already")
+	assert.Contains(t, press("trip_offduty:2020-01-01:2020-01-02"), "over already")
+}
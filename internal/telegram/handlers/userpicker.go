@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/korjavin/dutyassistant/internal/store"
+	"github.com/korjavin/dutyassistant/internal/telegram/keyboard"
+	"github.com/korjavin/dutyassistant/internal/telegram/parse"
+)
+
+// errNoUsers is returned by userPicker when there is nobody to pick.
+var errNoUsers = errors.New("no users to pick from")
+
+// userPicker returns the message and a page of the keyboard picking a user
+// for list, the callback data the picked user's ID is appended to, e.g.
+// "modify_user:2025-10-10". Pages are flipped with HandlePageCallback.
+func (h *Handlers) userPicker(ctx context.Context, list string, page int) (string, tgbotapi.InlineKeyboardMarkup, error) {
+	cb := parse.ParseCallback(list)
+	listUsers, label := h.Store.ListActiveUsers, func(u *store.User) string { return "👤 " + u.FirstName }
+	var text string
+	switch cb.Action {
+	case "assign_user", "offduty_user", "toggle_user":
+		if err := cb.Expect(0); err != nil {
+			return "", tgbotapi.InlineKeyboardMarkup{}, err
+		}
+		switch cb.Action {
+		case "assign_user":
+			text = "📋 <b>Assign days to admin queue</b>\n\nSelect a user:"
+		case "offduty_user":
+			text = "🏖 <b>Set off-duty period</b>\n\nSelect a user:"
+		default:
+			text = "🔄 <b>Toggle user active status</b>\n\nSelect a user:"
+			listUsers = h.Store.ListAllUsers
+			label = func(u *store.User) string {
+				if u.IsPending {
+					return "⏳ " + u.FirstName
+				}
+				if !u.IsActive {
+					return "❌ " + u.FirstName
+				}
+				return "✅ " + u.FirstName
+			}
+		}
+	case "modify_user", takeoverUserAction:
+		if err := cb.Expect(1); err != nil {
+			return "", tgbotapi.InlineKeyboardMarkup{}, err
+		}
+		date, err := cb.Date(0)
+		if err != nil {
+			return "", tgbotapi.InlineKeyboardMarkup{}, err
+		}
+		dateStr := date.Format(parse.DateLayout)
+		text = fmt.Sprintf("🔄 <b>Modify duty for %s</b>\n\nSelect the new user:", dateStr)
+		if cb.Action == takeoverUserAction {
+			// Off-duty users are listed too, that's the point of assigning anyway
+			text = fmt.Sprintf("👤 <b>Assign duty for %s anyway</b>\n\nSelect the user:", dateStr)
+		}
+	default:
+		return "", tgbotapi.InlineKeyboardMarkup{}, parse.ErrInvalidCallback
+	}
+
+	users, err := listUsers(ctx)
+	if err != nil || len(users) == 0 {
+		return "", tgbotapi.InlineKeyboardMarkup{}, errNoUsers
+	}
+	buttons := make([]tgbotapi.InlineKeyboardButton, len(users))
+	for i, u := range users {
+		buttons[i] = tgbotapi.NewInlineKeyboardButtonData(label(u), fmt.Sprintf("%s:%d", list, u.ID))
+	}
+	return text, keyboard.Paginate(buttons, list, page), nil
+}
+
+// HandlePageCallback shows another page of a user picker.
+// Format: page:<list>:<page>
+func (h *Handlers) HandlePageCallback(q *tgbotapi.CallbackQuery) (tgbotapi.EditMessageTextConfig, error) {
+	cb := parse.ParseCallback(q.Data)
+	if len(cb.Args) < 2 {
+		return tgbotapi.EditMessageTextConfig{}, parse.ErrInvalidCallback
+	}
+	page, err := cb.IntInRange(len(cb.Args)-1, 0, 1000)
+	if err != nil {
+		return tgbotapi.EditMessageTextConfig{}, err
+	}
+	list := strings.Join(cb.Args[:len(cb.Args)-1], ":")
+	if requiredRole(CallbackRoles, parse.Action(list)) == RoleAdmin {
+		if refusal, ok := h.refuseNonAdminCallback(q); !ok {
+			return refusal, nil
+		}
+	}
+
+	text, markup, err := h.userPicker(context.Background(), list, page)
+	if errors.Is(err, errNoUsers) {
+		return tgbotapi.NewEditMessageText(q.Message.Chat.ID, q.Message.MessageID, "❌ No users found."), nil
+	} else if err != nil {
+		return tgbotapi.EditMessageTextConfig{}, err
+	}
+	edit := tgbotapi.NewEditMessageTextAndMarkup(q.Message.Chat.ID, q.Message.MessageID, text, markup)
+	edit.ParseMode = tgbotapi.ModeHTML
+	return edit, nil
+}
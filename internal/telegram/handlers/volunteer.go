@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/korjavin/dutyassistant/internal/telegram/parse"
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 )
 
@@ -44,19 +45,18 @@ func (h *Handlers) HandleVolunteer(m *tgbotapi.Message) (tgbotapi.MessageConfig,
 		return msg, nil
 	}
 
-	var days int
-	_, err := fmt.Sscanf(args, "%d", &days)
-	if err != nil || days <= 0 {
+	days, err := parse.Days(strings.Fields(args)[0])
+	if err != nil {
 		msg := tgbotapi.NewMessage(m.Chat.ID,
-			fmt.Sprintf("⚠️ '%s' is not a valid number of days.\n\n"+
-			"Please use a positive number.\n\n"+
-			"Example: <code>/volunteer 3</code>", args))
+			fmt.Sprintf("⚠️ %s.\n\n"+
+			"Please use a number between 1 and %d.\n\n"+
+			"Example: <code>/volunteer 3</code>", escapeHTML(err.Error()), parse.MaxDays))
 		msg.ParseMode = tgbotapi.ModeHTML
 		return msg, nil
 	}
 
-	user, err := h.Store.GetUserByTelegramID(context.Background(), m.From.ID)
-	if err != nil || user == nil {
+	user, err := h.Users.ByTelegramID(context.Background(), m.From.ID)
+	if err != nil {
 		return tgbotapi.NewMessage(m.Chat.ID, volunteerUserNotFoundMessage), nil
 	}
 
@@ -64,22 +64,25 @@ func (h *Handlers) HandleVolunteer(m *tgbotapi.Message) (tgbotapi.MessageConfig,
 	if err != nil {
 		return tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("❌ "+volunteerFailureMessage, err)), nil
 	}
+	// Calendars show the volunteer queue
+	h.InvalidateCalendars(context.Background(), nil)
 
 	return tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("✅ "+volunteerSuccessMessage, days)), nil
 }
 
 // HandleVolunteerDaysCallback handles the callback when days are selected from inline keyboard
 func (h *Handlers) HandleVolunteerDaysCallback(q *tgbotapi.CallbackQuery) (tgbotapi.EditMessageTextConfig, error) {
-	parts := strings.Split(q.Data, ":")
-	if len(parts) != 2 {
-		return tgbotapi.EditMessageTextConfig{}, fmt.Errorf("invalid callback data")
+	cb := parse.ParseCallback(q.Data)
+	if err := cb.Expect(1); err != nil {
+		return tgbotapi.EditMessageTextConfig{}, err
+	}
+	days, err := cb.Days(0)
+	if err != nil {
+		return tgbotapi.EditMessageTextConfig{}, err
 	}
 
-	var days int
-	fmt.Sscanf(parts[1], "%d", &days)
-
-	user, err := h.Store.GetUserByTelegramID(context.Background(), q.From.ID)
-	if err != nil || user == nil {
+	user, err := h.Users.ByTelegramID(context.Background(), q.From.ID)
+	if err != nil {
 		edit := tgbotapi.NewEditMessageText(q.Message.Chat.ID, q.Message.MessageID, "❌ "+volunteerUserNotFoundMessage)
 		return edit, nil
 	}
@@ -93,6 +96,7 @@ func (h *Handlers) HandleVolunteerDaysCallback(q *tgbotapi.CallbackQuery) (tgbot
 		)
 		return edit, nil
 	}
+	h.InvalidateCalendars(context.Background(), nil)
 
 	edit := tgbotapi.NewEditMessageText(
 		q.Message.Chat.ID,
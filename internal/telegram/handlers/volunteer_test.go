@@ -2,19 +2,27 @@ package handlers_test
 
 import (
 	"errors"
-	"fmt"
 	"testing"
-	"time"
 
-	"github.com/korjavin/dutyassistant/internal/mocks"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	schedulermocks "github.com/korjavin/dutyassistant/internal/scheduler/mocks"
 	"github.com/korjavin/dutyassistant/internal/store"
+	storemocks "github.com/korjavin/dutyassistant/internal/store/mocks"
 	"github.com/korjavin/dutyassistant/internal/telegram/handlers"
-	"github.com/korjavin/dutyassistant/internal/telegram/keyboard"
-	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	"github.com/stretchr/testify/assert"
-	"github.com/stretchr/testify/mock"
+	"go.uber.org/mock/gomock"
 )
 
+// volunteerDaysCallback builds the callback sent when a user picks a number of days.
+func volunteerDaysCallback(data string) *tgbotapi.CallbackQuery {
+	return &tgbotapi.CallbackQuery{
+		ID:      "test_callback_id",
+		From:    &tgbotapi.User{ID: 456, FirstName: "Test"},
+		Message: &tgbotapi.Message{Chat: &tgbotapi.Chat{ID: 123}, MessageID: 789},
+		Data:    data,
+	}
+}
+
 func TestHandleVolunteer(t *testing.T) {
 	h := handlers.New(nil, nil)
 	message := &tgbotapi.Message{Chat: &tgbotapi.Chat{ID: 123}}
@@ -22,85 +30,59 @@ func TestHandleVolunteer(t *testing.T) {
 	msg, err := h.HandleVolunteer(message)
 
 	assert.NoError(t, err)
-	assert.Equal(t, "Please select a date to volunteer for duty.", msg.Text)
+	assert.Contains(t, msg.Text, "How many days would you like to volunteer for?")
 	assert.NotNil(t, msg.ReplyMarkup)
 }
 
-func TestHandleVolunteerCallback_Success(t *testing.T) {
-	mockStore := new(mocks.MockStore)
-	mockScheduler := new(mocks.MockScheduler)
+func TestHandleVolunteerDaysCallback_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockStore := storemocks.NewMockStore(ctrl)
+	mockScheduler := schedulermocks.NewMockSchedulerInterface(ctrl)
 	h := handlers.New(mockStore, mockScheduler)
 
-	dateStr := "2023-05-20"
-	dutyDate, _ := time.Parse("2006-01-02", dateStr)
-	callbackData := fmt.Sprintf("%s:%s", keyboard.ActionSelectDay, dateStr)
-	user := &tgbotapi.User{ID: 456, FirstName: "Test"}
-	callbackQuery := &tgbotapi.CallbackQuery{
-		ID:      "test_callback_id",
-		From:    user,
-		Message: &tgbotapi.Message{Chat: &tgbotapi.Chat{ID: 123}, MessageID: 789},
-		Data:    callbackData,
-	}
-
 	storeUser := &store.User{ID: 1, TelegramUserID: 456}
-	mockStore.On("GetUserByTelegramID", mock.Anything, user.ID).Return(storeUser, nil)
-	mockScheduler.On("VolunteerForDuty", mock.Anything, storeUser, dutyDate).Return(nil)
+	mockStore.EXPECT().GetUserByTelegramID(gomock.Any(), int64(456)).Return(storeUser, nil)
+	mockScheduler.EXPECT().VolunteerForDuty(gomock.Any(), storeUser, 3).Return(nil)
 
-	editMsg, err := h.HandleVolunteerCallback(callbackQuery)
+	editMsg, err := h.HandleVolunteerDaysCallback(volunteerDaysCallback("volunteer_days:3"))
 
 	assert.NoError(t, err)
-	assert.Equal(t, fmt.Sprintf("Thank you for volunteering for duty on %s!", dateStr), editMsg.Text)
+	assert.Equal(t, "✅ Thank you for volunteering! Added 3 day(s) to your volunteer queue.", editMsg.Text)
 	assert.Nil(t, editMsg.ReplyMarkup, "Keyboard should be removed on success")
-	mockStore.AssertExpectations(t)
-	mockScheduler.AssertExpectations(t)
 }
 
-func TestHandleVolunteerCallback_Failure(t *testing.T) {
-	mockStore := new(mocks.MockStore)
-	mockScheduler := new(mocks.MockScheduler)
+func TestHandleVolunteerDaysCallback_Failure(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockStore := storemocks.NewMockStore(ctrl)
+	mockScheduler := schedulermocks.NewMockSchedulerInterface(ctrl)
 	h := handlers.New(mockStore, mockScheduler)
 
-	dateStr := "2023-05-20"
-	dutyDate, _ := time.Parse("2006-01-02", dateStr)
-	callbackData := fmt.Sprintf("%s:%s", keyboard.ActionSelectDay, dateStr)
-	user := &tgbotapi.User{ID: 456, FirstName: "Test"}
-	callbackQuery := &tgbotapi.CallbackQuery{
-		ID:      "test_callback_id",
-		From:    user,
-		Message: &tgbotapi.Message{Chat: &tgbotapi.Chat{ID: 123}, MessageID: 789},
-		Data:    callbackData,
-	}
-
 	storeUser := &store.User{ID: 1, TelegramUserID: 456}
-	mockStore.On("GetUserByTelegramID", mock.Anything, user.ID).Return(storeUser, nil)
-	mockScheduler.On("VolunteerForDuty", mock.Anything, storeUser, dutyDate).Return(errors.New("scheduler error"))
+	mockStore.EXPECT().GetUserByTelegramID(gomock.Any(), int64(456)).Return(storeUser, nil)
+	mockScheduler.EXPECT().VolunteerForDuty(gomock.Any(), storeUser, 3).Return(errors.New("scheduler error"))
 
-	editMsg, err := h.HandleVolunteerCallback(callbackQuery)
+	editMsg, err := h.HandleVolunteerDaysCallback(volunteerDaysCallback("volunteer_days:3"))
 
 	assert.NoError(t, err)
-	assert.Contains(t, editMsg.Text, "Sorry, we couldn't process your request")
-	mockScheduler.AssertExpectations(t)
+	assert.Contains(t, editMsg.Text, "Sorry, we couldn't process your volunteer request")
 }
 
-func TestHandleVolunteerCallback_UserNotFound(t *testing.T) {
-	mockStore := new(mocks.MockStore)
+func TestHandleVolunteerDaysCallback_UserNotFound(t *testing.T) {
+	mockStore := storemocks.NewMockStore(gomock.NewController(t))
 	h := handlers.New(mockStore, nil)
 
-	dateStr := "2023-05-20"
-	callbackData := fmt.Sprintf("%s:%s", keyboard.ActionSelectDay, dateStr)
-	user := &tgbotapi.User{ID: 456, FirstName: "Test"}
-	callbackQuery := &tgbotapi.CallbackQuery{
-		ID:      "test_callback_id",
-		From:    user,
-		Message: &tgbotapi.Message{Chat: &tgbotapi.Chat{ID: 123}, MessageID: 789},
-		Data:    callbackData,
-	}
-
-	mockStore.On("GetUserByTelegramID", mock.Anything, user.ID).Return(nil, nil)
+	mockStore.EXPECT().GetUserByTelegramID(gomock.Any(), int64(456)).Return(nil, nil)
 
-	editMsg, err := h.HandleVolunteerCallback(callbackQuery)
+	editMsg, err := h.HandleVolunteerDaysCallback(volunteerDaysCallback("volunteer_days:3"))
 
 	assert.NoError(t, err)
-	assert.Equal(t, "Could not find your user profile. Please use /start first.", editMsg.Text)
-	mockStore.AssertExpectations(t)
-}
\ No newline at end of file
+	assert.Equal(t, "❌ Could not find your user profile. Please use /start first.", editMsg.Text)
+}
+
+func TestHandleVolunteerDaysCallback_InvalidDays(t *testing.T) {
+	h := handlers.New(nil, nil)
+
+	_, err := h.HandleVolunteerDaysCallback(volunteerDaysCallback("volunteer_days:0"))
+
+	assert.Error(t, err)
+}
@@ -0,0 +1,22 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/korjavin/dutyassistant/internal/notification"
+	"github.com/korjavin/dutyassistant/internal/service/week"
+)
+
+// HandleWeek handles the /week command, showing who is on duty each day of the
+// current week and whether they're done.
+func (h *Handlers) HandleWeek(m *tgbotapi.Message) (tgbotapi.MessageConfig, error) {
+	ctx := context.Background()
+	today := h.today()
+	w, err := week.Load(ctx, h.Store, today)
+	if err != nil {
+		return tgbotapi.MessageConfig{}, fmt.Errorf("could not load the week: %w", err)
+	}
+	return tgbotapi.NewMessage(m.Chat.ID, notification.FormatWeek(h.locale(ctx, m.Chat.ID), w, today, names(m.Chat.ID))), nil
+}
@@ -0,0 +1,37 @@
+package handlers_test
+
+import (
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/korjavin/dutyassistant/internal/service/week"
+	"github.com/korjavin/dutyassistant/internal/store"
+	storemocks "github.com/korjavin/dutyassistant/internal/store/mocks"
+	"github.com/korjavin/dutyassistant/internal/telegram/handlers"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestHandleWeek(t *testing.T) {
+	mockStore := storemocks.NewMockStore(gomock.NewController(t))
+	h := handlers.New(mockStore, nil)
+	mockStore.EXPECT().GetChatLocale(gomock.Any(), int64(123)).Return("", nil).AnyTimes()
+	monday := week.Start(time.Now())
+
+	// The week may reach into the next month
+	mockStore.EXPECT().GetDutiesByMonth(gomock.Any(), gomock.Any(), gomock.Any()).Return([]*store.Duty{
+		{DutyDate: monday, User: &store.User{FirstName: "Alice"}},
+	}, nil).MinTimes(1)
+	mockStore.EXPECT().GetSkipDaysByMonth(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, nil).MinTimes(1)
+	mockStore.EXPECT().GetWasteCollections(gomock.Any(), monday, monday.AddDate(0, 0, 7)).Return([]*store.WasteCollection{
+		{Date: monday, Bin: "Paper"},
+	}, nil)
+
+	msg, err := h.HandleWeek(&tgbotapi.Message{Chat: &tgbotapi.Chat{ID: 123}})
+
+	assert.NoError(t, err)
+	assert.Contains(t, msg.Text, "📅 Week of "+monday.Format("Jan 2"))
+	assert.Contains(t, msg.Text, "Mon: Alice")
+	assert.Contains(t, msg.Text, "🗑️ Paper")
+}
@@ -5,6 +5,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/korjavin/dutyassistant/internal/i18n"
 	"github.com/korjavin/dutyassistant/internal/store"
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 )
@@ -17,10 +18,16 @@ const (
 )
 
 // Calendar creates an inline keyboard markup for a given month and year.
-// Assigns each user a number and shows number+emoji on calendar days.
+// Assigns each user a number and shows their emoji, or else the number, on calendar days.
 // The allUsers parameter allows showing queue info even when there are no duties yet.
-func Calendar(t time.Time, duties []*store.Duty, allUsers []*store.User) tgbotapi.InlineKeyboardMarkup {
+// Days in skipDays are marked as deliberately without duty. The month and
+// weekdays are named in locale l, and d is how the chat wants it drawn.
+func Calendar(l i18n.Locale, d store.ChatDisplay, t time.Time, duties []*store.Duty, allUsers []*store.User, skipDays []*store.SkipDay) tgbotapi.InlineKeyboardMarkup {
 	dutyMap := make(map[int]*store.Duty)
+	skipped := make(map[int]bool)
+	for _, skip := range skipDays {
+		skipped[skip.Date.Day()] = true
+	}
 	userAssignments := make(map[int64]map[store.AssignmentType]bool) // Track user->assignment types
 	userNumbers := make(map[int64]int)                               // Assign each user a number
 	userMap := make(map[int64]*store.User)                           // Map user ID to user
@@ -59,106 +66,32 @@ func Calendar(t time.Time, duties []*store.Duty, allUsers []*store.User) tgbotap
 		}
 	}
 
-	// Number circles: ① ② ③ ④ ⑤ ⑥ ⑦ ⑧ ⑨
-	numberCircles := []string{"①", "②", "③", "④", "⑤", "⑥", "⑦", "⑧", "⑨", "⑩"}
-
-	year, month, _ := t.Date()
-
-	// Header: << Month Year >>
-	header := []tgbotapi.InlineKeyboardButton{
-		tgbotapi.NewInlineKeyboardButtonData("«", fmt.Sprintf("%s:%s", ActionPrevMonth, t.Format("2006-01-02"))),
-		tgbotapi.NewInlineKeyboardButtonData(t.Format("Jan 2006"), ActionIgnore),
-		tgbotapi.NewInlineKeyboardButtonData("»", fmt.Sprintf("%s:%s", ActionNextMonth, t.Format("2006-01-02"))),
-	}
-
-	// Days of the week
-	daysOfWeek := []tgbotapi.InlineKeyboardButton{
-		tgbotapi.NewInlineKeyboardButtonData("Mo", ActionIgnore),
-		tgbotapi.NewInlineKeyboardButtonData("Tu", ActionIgnore),
-		tgbotapi.NewInlineKeyboardButtonData("We", ActionIgnore),
-		tgbotapi.NewInlineKeyboardButtonData("Th", ActionIgnore),
-		tgbotapi.NewInlineKeyboardButtonData("Fr", ActionIgnore),
-		tgbotapi.NewInlineKeyboardButtonData("Sa", ActionIgnore),
-		tgbotapi.NewInlineKeyboardButtonData("Su", ActionIgnore),
-	}
-
-	keyboard := [][]tgbotapi.InlineKeyboardButton{header, daysOfWeek}
-
-	firstDay := time.Date(year, month, 1, 0, 0, 0, 0, t.Location())
-	lastDay := firstDay.AddDate(0, 1, -1)
-
-	offset := int(firstDay.Weekday())
-	if offset == 0 { // Sunday
-		offset = 6
-	} else {
-		offset-- // Monday is 0
-	}
-
-	// Get today for marking
-	now := time.Now()
-	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
-
-	row := make([]tgbotapi.InlineKeyboardButton, 7)
-	day := 1
-	for day <= lastDay.Day() {
-		for i := 0; i < 7; i++ {
-			if (len(keyboard) == 2 && i < offset) || day > lastDay.Day() {
-				row[i] = tgbotapi.NewInlineKeyboardButtonData(" ", ActionIgnore)
-			} else {
-				date := time.Date(year, month, day, 0, 0, 0, 0, t.Location())
-
-				// Format: day number + emoji (compact for Telegram button width limits)
-				var dayText string
-				isToday := date.Year() == today.Year() && date.Month() == today.Month() && date.Day() == today.Day()
-
-				if duty, ok := dutyMap[day]; ok {
-					// Show day number and user number circle
-					userNum := userNumbers[duty.UserID]
-					var numberCircle string
-					if userNum > 0 && userNum <= len(numberCircles) {
-						numberCircle = numberCircles[userNum-1]
-					} else {
-						numberCircle = fmt.Sprintf("%d", userNum)
-					}
-
-					if isToday {
-						dayText = fmt.Sprintf("·%d%s", day, numberCircle)
-					} else {
-						dayText = fmt.Sprintf("%d%s", day, numberCircle)
-					}
-				} else {
-					// No duty - show day number, mark today with dot prefix
-					if isToday {
-						dayText = fmt.Sprintf("·%d", day)
-					} else {
-						dayText = fmt.Sprintf("%d", day)
-					}
-				}
-
-				row[i] = tgbotapi.NewInlineKeyboardButtonData(
-					dayText,
-					fmt.Sprintf("%s:%s", ActionSelectDay, date.Format("2006-01-02")),
-				)
-				day++
-			}
+	keyboard := monthGrid(l, d, t, "", func(day int, isToday bool) string {
+		// Format: day number + emoji (compact for Telegram button width limits)
+		var dayText string
+		if duty, ok := dutyMap[day]; ok {
+			// Show day number and the user's emoji or number circle
+			dayText = fmt.Sprintf("%d%s", day, userSymbol(duty.User, userNumbers[duty.UserID]))
+		} else if skipped[day] {
+			// Deliberate "no duty" day
+			dayText = fmt.Sprintf("%d🚫", day)
+		} else {
+			dayText = fmt.Sprintf("%d", day)
 		}
-		keyboard = append(keyboard, row)
-		row = make([]tgbotapi.InlineKeyboardButton, 7)
-	}
+		// Mark today with dot prefix
+		if isToday {
+			dayText = "·" + dayText
+		}
+		return dayText
+	})
 
 	// Add legend type explanation
-	legendType := tgbotapi.NewInlineKeyboardButtonData("🟢=Volunteer 🔵=Admin ⚪=Auto", ActionIgnore)
+	legendType := tgbotapi.NewInlineKeyboardButtonData("🟢=Volunteer 🔵=Admin ⚪=Auto 🚫=No duty", ActionIgnore)
 	keyboard = append(keyboard, []tgbotapi.InlineKeyboardButton{legendType})
 
-	// Build user legend showing number -> name + emojis
+	// Build user legend showing number or emoji -> name + emojis
 	for idx, user := range userList {
-		userNum := idx + 1
-		var numberCircle string
-		if userNum <= len(numberCircles) {
-			numberCircle = numberCircles[userNum-1]
-		} else {
-			numberCircle = fmt.Sprintf("%d", userNum)
-		}
+		numberCircle := userSymbol(user, idx+1)
 
 		// Collect all emojis for this user
 		var emojis []string
@@ -192,4 +125,131 @@ func Calendar(t time.Time, duties []*store.Duty, allUsers []*store.User) tgbotap
 	}
 
 	return tgbotapi.NewInlineKeyboardMarkup(keyboard...)
-}
\ No newline at end of file
+}
+
+// numberCircles mark users without a personal emoji: ① ② ③ ④ ⑤ ⑥ ⑦ ⑧ ⑨ ⑩
+var numberCircles = []string{"①", "②", "③", "④", "⑤", "⑥", "⑦", "⑧", "⑨", "⑩"}
+
+// userSymbol is how the calendar marks a user: the emoji they picked with
+// /me, or else the number circle of their place in the legend.
+func userSymbol(user *store.User, userNum int) string {
+	if user != nil && user.Emoji != "" {
+		return user.Emoji
+	}
+	if userNum > 0 && userNum <= len(numberCircles) {
+		return numberCircles[userNum-1]
+	}
+	return fmt.Sprintf("%d", userNum)
+}
+
+// UserCalendar creates a calendar for a single user: their duties are
+// highlighted with a star, while other assignments are dimmed to a plain day
+// number. The navigation buttons keep the user so flipping months stays on
+// their view. Display d is as for Calendar.
+func UserCalendar(l i18n.Locale, d store.ChatDisplay, t time.Time, duties []*store.Duty, user *store.User, skipDays []*store.SkipDay) tgbotapi.InlineKeyboardMarkup {
+	own := make(map[int]bool)
+	for _, duty := range duties {
+		if duty.UserID == user.ID {
+			own[duty.DutyDate.Day()] = true
+		}
+	}
+	skipped := make(map[int]bool)
+	for _, skip := range skipDays {
+		skipped[skip.Date.Day()] = true
+	}
+
+	keyboard := monthGrid(l, d, t, fmt.Sprintf(":%d", user.ID), func(day int, isToday bool) string {
+		dayText := fmt.Sprintf("%d", day)
+		if own[day] {
+			dayText += "⭐"
+		} else if skipped[day] {
+			dayText += "🚫"
+		}
+		if isToday {
+			dayText = "·" + dayText
+		}
+		return dayText
+	})
+
+	legend := fmt.Sprintf("⭐=%s: %d day(s) this month", user.FirstName, len(own))
+	var queueInfo []string
+	if user.VolunteerQueueDays > 0 {
+		queueInfo = append(queueInfo, fmt.Sprintf("V:%d", user.VolunteerQueueDays))
+	}
+	if user.AdminQueueDays > 0 {
+		queueInfo = append(queueInfo, fmt.Sprintf("A:%d", user.AdminQueueDays))
+	}
+	if len(queueInfo) > 0 {
+		legend += fmt.Sprintf(" (%s)", strings.Join(queueInfo, " "))
+	}
+	keyboard = append(keyboard, []tgbotapi.InlineKeyboardButton{tgbotapi.NewInlineKeyboardButtonData(legend, ActionIgnore)})
+
+	return tgbotapi.NewInlineKeyboardMarkup(keyboard...)
+}
+
+// monthGrid builds the navigation header, the weekday row and one row per
+// week of t's month. dayText labels each day; suffix is appended to the
+// navigation callback data after the date. The month and weekdays are named
+// in locale l, and weeks start on its first weekday. Display d adds a column
+// of ISO week numbers and fills the first and last week with the days of the
+// adjacent months.
+func monthGrid(l i18n.Locale, d store.ChatDisplay, t time.Time, suffix string, dayText func(day int, isToday bool) string) [][]tgbotapi.InlineKeyboardButton {
+	year, month, _ := t.Date()
+
+	// Header: << Month Year >>
+	header := []tgbotapi.InlineKeyboardButton{
+		tgbotapi.NewInlineKeyboardButtonData("«", fmt.Sprintf("%s:%s%s", ActionPrevMonth, t.Format("2006-01-02"), suffix)),
+		tgbotapi.NewInlineKeyboardButtonData(l.Format(t, "Jan 2006"), ActionIgnore),
+		tgbotapi.NewInlineKeyboardButtonData("»", fmt.Sprintf("%s:%s%s", ActionNextMonth, t.Format("2006-01-02"), suffix)),
+	}
+
+	// Days of the week, starting on the locale's first day
+	first := l.FirstWeekday()
+	var daysOfWeek []tgbotapi.InlineKeyboardButton
+	if d.WeekNumbers {
+		daysOfWeek = append(daysOfWeek, tgbotapi.NewInlineKeyboardButtonData(l.WeekAbbr(), ActionIgnore))
+	}
+	for i := 0; i < 7; i++ {
+		daysOfWeek = append(daysOfWeek, tgbotapi.NewInlineKeyboardButtonData(l.WeekdayShort((first+time.Weekday(i))%7), ActionIgnore))
+	}
+
+	keyboard := [][]tgbotapi.InlineKeyboardButton{header, daysOfWeek}
+
+	firstDay := time.Date(year, month, 1, 0, 0, 0, 0, t.Location())
+	lastDay := firstDay.AddDate(0, 1, -1)
+
+	// Days of the previous month before the 1st, in the first week
+	offset := (int(firstDay.Weekday()) - int(first) + 7) % 7
+
+	// Get today for marking
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+
+	for week := firstDay.AddDate(0, 0, -offset); !week.After(lastDay); week = week.AddDate(0, 0, 7) {
+		var row []tgbotapi.InlineKeyboardButton
+		if d.WeekNumbers {
+			// The week of the row's Monday, as weeks starting on Sunday
+			// straddle two ISO weeks
+			_, number := week.AddDate(0, 0, (int(time.Monday)-int(first)+7)%7).ISOWeek()
+			row = append(row, tgbotapi.NewInlineKeyboardButtonData(fmt.Sprintf("%d", number), ActionIgnore))
+		}
+		for i := 0; i < 7; i++ {
+			date := week.AddDate(0, 0, i)
+			switch {
+			case date.Month() != month && d.AdjacentDays:
+				// Buttons can't be grey, so parentheses set the days apart
+				row = append(row, tgbotapi.NewInlineKeyboardButtonData(fmt.Sprintf("(%d)", date.Day()), ActionIgnore))
+			case date.Month() != month:
+				row = append(row, tgbotapi.NewInlineKeyboardButtonData(" ", ActionIgnore))
+			default:
+				isToday := date.Year() == today.Year() && date.Month() == today.Month() && date.Day() == today.Day()
+				row = append(row, tgbotapi.NewInlineKeyboardButtonData(
+					dayText(date.Day(), isToday),
+					fmt.Sprintf("%s:%s", ActionSelectDay, date.Format("2006-01-02")),
+				))
+			}
+		}
+		keyboard = append(keyboard, row)
+	}
+	return keyboard
+}
@@ -0,0 +1,74 @@
+package keyboard
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/korjavin/dutyassistant/internal/i18n"
+	"github.com/korjavin/dutyassistant/internal/store"
+)
+
+func TestCalendar_FirstWeekday(t *testing.T) {
+	march := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC) // Starts on a Saturday
+	tests := []struct {
+		locale   i18n.Locale
+		header   []string
+		firstRow []string
+	}{
+		{i18n.English, []string{"Mo", "Tu", "We", "Th", "Fr", "Sa", "Su"}, []string{" ", " ", " ", " ", " ", "1", "2"}},
+		{i18n.German, []string{"Mo", "Di", "Mi", "Do", "Fr", "Sa", "So"}, []string{" ", " ", " ", " ", " ", "1", "2"}},
+		{i18n.AmericanEnglish, []string{"Su", "Mo", "Tu", "We", "Th", "Fr", "Sa"}, []string{" ", " ", " ", " ", " ", " ", "1"}},
+	}
+	for _, tt := range tests {
+		rows := Calendar(tt.locale, store.ChatDisplay{}, march, nil, nil, nil).InlineKeyboard
+		for i, want := range tt.header {
+			if got := rows[1][i].Text; got != want {
+				t.Errorf("%s: weekday %d = %q, want %q", tt.locale, i, got, want)
+			}
+		}
+		for i, want := range tt.firstRow {
+			if got := rows[2][i].Text; got != want {
+				t.Errorf("%s: first week, day %d = %q, want %q", tt.locale, i, got, want)
+			}
+		}
+	}
+	if got := Calendar(i18n.German, store.ChatDisplay{}, march, nil, nil, nil).InlineKeyboard[0][1].Text; got != "Mär 2025" {
+		t.Errorf("German month header = %q, want Mär 2025", got)
+	}
+}
+
+func TestCalendar_Display(t *testing.T) {
+	march := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC) // Starts on a Saturday, ends on a Monday
+	texts := func(row []tgbotapi.InlineKeyboardButton) []string {
+		var texts []string
+		for _, b := range row {
+			texts = append(texts, b.Text)
+		}
+		return texts
+	}
+
+	rows := Calendar(i18n.English, store.ChatDisplay{WeekNumbers: true, AdjacentDays: true}, march, nil, nil, nil).InlineKeyboard
+	if got, want := texts(rows[1]), []string{"Wk", "Mo", "Tu", "We", "Th", "Fr", "Sa", "Su"}; !slices.Equal(got, want) {
+		t.Errorf("header = %q, want %q", got, want)
+	}
+	if got, want := texts(rows[2]), []string{"9", "(24)", "(25)", "(26)", "(27)", "(28)", "1", "2"}; !slices.Equal(got, want) {
+		t.Errorf("first week = %q, want %q", got, want)
+	}
+	if got, want := texts(rows[7]), []string{"14", "31", "(1)", "(2)", "(3)", "(4)", "(5)", "(6)"}; !slices.Equal(got, want) {
+		t.Errorf("last week = %q, want %q", got, want)
+	}
+	if got := *rows[2][1].CallbackData; got != ActionIgnore {
+		t.Errorf("a day of February can be selected: %q", got)
+	}
+
+	// Weeks starting on Sunday are numbered by their Monday
+	rows = UserCalendar(i18n.AmericanEnglish, store.ChatDisplay{WeekNumbers: true}, march, nil, &store.User{ID: 1}, nil).InlineKeyboard
+	if got, want := texts(rows[2]), []string{"9", " ", " ", " ", " ", " ", " ", "1"}; !slices.Equal(got, want) {
+		t.Errorf("en-US first week = %q, want %q", got, want)
+	}
+	if got, want := texts(rows[3]), []string{"10", "2", "3", "4", "5", "6", "7", "8"}; !slices.Equal(got, want) {
+		t.Errorf("en-US second week = %q, want %q", got, want)
+	}
+}
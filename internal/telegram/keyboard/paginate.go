@@ -0,0 +1,48 @@
+package keyboard
+
+import (
+	"fmt"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// ActionPage is the callback action of the buttons flipping the pages of a
+// paginated keyboard, as "page:<list>:<page>". list is the callback data the
+// buttons of the list start with, e.g. "modify_user:2025-10-10", so whoever
+// builds the list can build any of its pages again.
+const ActionPage = "page"
+
+// PageSize is how many buttons a page of a paginated keyboard shows.
+const PageSize = 8
+
+// Paginate returns the keyboard of a page of buttons, one per row, counting
+// from 0. Pages out of range show the nearest one. If the buttons don't fit on
+// one page, a row to flip through them follows.
+func Paginate(buttons []tgbotapi.InlineKeyboardButton, list string, page int) tgbotapi.InlineKeyboardMarkup {
+	pages := max((len(buttons)+PageSize-1)/PageSize, 1)
+	page = min(max(page, 0), pages-1)
+
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for _, button := range buttons[page*PageSize : min((page+1)*PageSize, len(buttons))] {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(button))
+	}
+	if pages > 1 {
+		nav := []tgbotapi.InlineKeyboardButton{tgbotapi.NewInlineKeyboardButtonData(" ", ActionIgnore)}
+		if page > 0 {
+			nav[0] = PageButton("«", list, page-1)
+		}
+		nav = append(nav, tgbotapi.NewInlineKeyboardButtonData(fmt.Sprintf("%d/%d", page+1, pages), ActionIgnore))
+		if page < pages-1 {
+			nav = append(nav, PageButton("»", list, page+1))
+		} else {
+			nav = append(nav, tgbotapi.NewInlineKeyboardButtonData(" ", ActionIgnore))
+		}
+		rows = append(rows, nav)
+	}
+	return tgbotapi.NewInlineKeyboardMarkup(rows...)
+}
+
+// PageButton returns a button showing a page of list.
+func PageButton(text, list string, page int) tgbotapi.InlineKeyboardButton {
+	return tgbotapi.NewInlineKeyboardButtonData(text, fmt.Sprintf("%s:%s:%d", ActionPage, list, page))
+}
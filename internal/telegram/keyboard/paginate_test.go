@@ -0,0 +1,62 @@
+package keyboard
+
+import (
+	"fmt"
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+func TestPaginate(t *testing.T) {
+	buttons := func(n int) []tgbotapi.InlineKeyboardButton {
+		var b []tgbotapi.InlineKeyboardButton
+		for i := 1; i <= n; i++ {
+			b = append(b, tgbotapi.NewInlineKeyboardButtonData(fmt.Sprint(i), fmt.Sprintf("pick:%d", i)))
+		}
+		return b
+	}
+	data := func(row []tgbotapi.InlineKeyboardButton) []string {
+		var d []string
+		for _, b := range row {
+			d = append(d, *b.CallbackData)
+		}
+		return d
+	}
+
+	tests := []struct {
+		name  string
+		count int
+		page  int
+		first string   // Callback data of the first button on the page
+		rows  int      // Rows including the page row
+		nav   []string // Callback data of the page row, nil for none
+	}{
+		{"fits", PageSize, 0, "pick:1", PageSize, nil},
+		{"first page", 20, 0, "pick:1", PageSize + 1, []string{ActionIgnore, ActionIgnore, "page:pick:1"}},
+		{"middle page", 20, 1, "pick:9", PageSize + 1, []string{"page:pick:0", ActionIgnore, "page:pick:2"}},
+		{"last page", 20, 2, "pick:17", 5, []string{"page:pick:1", ActionIgnore, ActionIgnore}},
+		{"past the end", 20, 7, "pick:17", 5, []string{"page:pick:1", ActionIgnore, ActionIgnore}},
+		{"negative", 20, -1, "pick:1", PageSize + 1, []string{ActionIgnore, ActionIgnore, "page:pick:1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows := Paginate(buttons(tt.count), "pick", tt.page).InlineKeyboard
+			if len(rows) != tt.rows {
+				t.Fatalf("Expected %d rows, got %d", tt.rows, len(rows))
+			}
+			if got := *rows[0][0].CallbackData; got != tt.first {
+				t.Errorf("Expected the page to start with %s, got %s", tt.first, got)
+			}
+			if tt.nav == nil {
+				return
+			}
+			if got := data(rows[len(rows)-1]); fmt.Sprint(got) != fmt.Sprint(tt.nav) {
+				t.Errorf("Expected the page row %v, got %v", tt.nav, got)
+			}
+		})
+	}
+
+	if rows := Paginate(nil, "pick", 0).InlineKeyboard; len(rows) != 0 {
+		t.Errorf("Expected no rows without buttons, got %d", len(rows))
+	}
+}
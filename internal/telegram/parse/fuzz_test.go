@@ -0,0 +1,84 @@
+package parse
+
+import (
+	"strings"
+	"testing"
+)
+
+func FuzzDays(f *testing.F) {
+	for _, seed := range []string{"3", "0", "-1", "365", "366", "3abc", " 7 ", "99999999999999999999"} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, s string) {
+		days, err := Days(s)
+		if err == nil && (days < 1 || days > MaxDays) {
+			t.Errorf("Days(%q) = %d, outside [1, %d]", s, days, MaxDays)
+		}
+	})
+}
+
+func FuzzDate(f *testing.F) {
+	for _, seed := range []string{"2025-10-10", "2025-02-29", "0001-01-01", "9999-12-31", "2025-1-1", ""} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, s string) {
+		date, err := Date(s)
+		if err != nil {
+			return
+		}
+		if date.Before(minDate) || date.After(maxDate) {
+			t.Errorf("Date(%q) = %v, outside the accepted range", s, date)
+		}
+		if date.Format(DateLayout) != strings.TrimSpace(s) {
+			t.Errorf("Date(%q) does not round-trip: %s", s, date.Format(DateLayout))
+		}
+	})
+}
+
+func FuzzDateRange(f *testing.F) {
+	f.Add("2025-10-10", "2025-10-15")
+	f.Add("2025-10-15", "2025-10-10")
+	f.Add("2025-01-01", "2026-01-02")
+	f.Fuzz(func(t *testing.T, start, end string) {
+		s, e, err := DateRange(start, end)
+		if err != nil {
+			return
+		}
+		if e.Before(s) {
+			t.Errorf("DateRange(%q, %q) returned an inverted range", start, end)
+		}
+		if days := int(e.Sub(s).Hours()/24) + 1; days > MaxRangeDays {
+			t.Errorf("DateRange(%q, %q) spans %d days", start, end, days)
+		}
+	})
+}
+
+func FuzzCallback(f *testing.F) {
+	for _, seed := range []string{
+		"assign_user:1", "assign_days:1:3", "modify_user:2025-10-10:2", "offduty_user:1",
+		"prev_month:2025-10-01", "notif_hour:18", "volunteer_custom", "::", "", "toggle_user:-1", "assign_days:1:99999999999999999999",
+	} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, data string) {
+		c := ParseCallback(data)
+		if c.Action != Action(data) {
+			t.Errorf("ParseCallback and Action disagree on %q", data)
+		}
+		if got := strings.Join(append([]string{c.Action}, c.Args...), ":"); got != data {
+			t.Errorf("ParseCallback(%q) lost data: %q", data, got)
+		}
+		for i := -1; i <= len(c.Args); i++ {
+			if id, err := c.ID(i); err == nil && id <= 0 {
+				t.Errorf("ID(%d) of %q = %d", i, data, id)
+			}
+			if days, err := c.Days(i); err == nil && (days < 1 || days > MaxDays) {
+				t.Errorf("Days(%d) of %q = %d", i, data, days)
+			}
+			if n, err := c.IntInRange(i, 11, 20); err == nil && (n < 11 || n > 20) {
+				t.Errorf("IntInRange(%d) of %q = %d", i, data, n)
+			}
+			c.Date(i)
+		}
+	})
+}
@@ -0,0 +1,170 @@
+// Package parse contains the parsers for Telegram command arguments and
+// inline keyboard callback data. Everything here works on untrusted user
+// input, so functions never panic and reject anything they do not fully
+// understand instead of guessing.
+package parse
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DateLayout is the date format used in commands and callback data.
+const DateLayout = "2006-01-02"
+
+// MonthLayout is the month format used in commands.
+const MonthLayout = "2006-01"
+
+// MaxDays is the largest number of days accepted for a queue in one command.
+const MaxDays = 365
+
+// MaxRangeDays is the longest off-duty period, in days, accepted in one command.
+const MaxRangeDays = 366
+
+// Dates outside this range are rejected as typos (e.g. 0025-10-10).
+var (
+	minDate = time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+	maxDate = time.Date(2100, 12, 31, 0, 0, 0, 0, time.UTC)
+)
+
+// ErrInvalidCallback is returned for malformed callback data.
+var ErrInvalidCallback = errors.New("invalid callback data")
+
+// Days parses a positive number of days, at most MaxDays.
+func Days(s string) (int, error) {
+	s = strings.TrimSpace(s)
+	days, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("'%s' is not a number", s)
+	}
+	if days <= 0 {
+		return 0, fmt.Errorf("number of days must be positive")
+	}
+	if days > MaxDays {
+		return 0, fmt.Errorf("number of days must be at most %d", MaxDays)
+	}
+	return days, nil
+}
+
+// Date parses a YYYY-MM-DD date in UTC, within a sane range of years.
+func Date(s string) (time.Time, error) {
+	t, err := time.Parse(DateLayout, strings.TrimSpace(s))
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date '%s', expected YYYY-MM-DD", s)
+	}
+	if t.Before(minDate) || t.After(maxDate) {
+		return time.Time{}, fmt.Errorf("date %s is out of range", s)
+	}
+	return t, nil
+}
+
+// Month parses a YYYY-MM month into its first day in UTC, within the same
+// range of years as Date.
+func Month(s string) (time.Time, error) {
+	t, err := time.Parse(MonthLayout, strings.TrimSpace(s))
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid month '%s', expected YYYY-MM", s)
+	}
+	if t.Before(minDate) || t.After(maxDate) {
+		return time.Time{}, fmt.Errorf("month %s is out of range", s)
+	}
+	return t, nil
+}
+
+// DateRange parses an inclusive start and end date. The end must not be
+// before the start and the period must not exceed MaxRangeDays.
+func DateRange(start, end string) (time.Time, time.Time, error) {
+	startDate, err := Date(start)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("start: %w", err)
+	}
+	endDate, err := Date(end)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("end: %w", err)
+	}
+	if endDate.Before(startDate) {
+		return time.Time{}, time.Time{}, fmt.Errorf("end date must not be before start date")
+	}
+	if int(endDate.Sub(startDate).Hours()/24)+1 > MaxRangeDays {
+		return time.Time{}, time.Time{}, fmt.Errorf("period must be at most %d days", MaxRangeDays)
+	}
+	return startDate, endDate, nil
+}
+
+// Callback is parsed inline keyboard callback data of the form
+// "action:arg1:arg2".
+type Callback struct {
+	Action string
+	Args   []string
+}
+
+// ParseCallback splits callback data into its action and arguments.
+func ParseCallback(data string) Callback {
+	parts := strings.Split(data, ":")
+	return Callback{Action: parts[0], Args: parts[1:]}
+}
+
+// Action returns only the action part of callback data.
+func Action(data string) string {
+	action, _, _ := strings.Cut(data, ":")
+	return action
+}
+
+// Expect returns ErrInvalidCallback unless the callback has exactly n arguments.
+func (c Callback) Expect(n int) error {
+	if len(c.Args) != n {
+		return ErrInvalidCallback
+	}
+	return nil
+}
+
+// ID parses argument i as a positive database ID.
+func (c Callback) ID(i int) (int64, error) {
+	if i < 0 || i >= len(c.Args) {
+		return 0, ErrInvalidCallback
+	}
+	id, err := strconv.ParseInt(c.Args[i], 10, 64)
+	if err != nil || id <= 0 {
+		return 0, ErrInvalidCallback
+	}
+	return id, nil
+}
+
+// Days parses argument i with Days.
+func (c Callback) Days(i int) (int, error) {
+	if i < 0 || i >= len(c.Args) {
+		return 0, ErrInvalidCallback
+	}
+	days, err := Days(c.Args[i])
+	if err != nil {
+		return 0, ErrInvalidCallback
+	}
+	return days, nil
+}
+
+// IntInRange parses argument i as an integer between min and max, inclusive.
+func (c Callback) IntInRange(i, min, max int) (int, error) {
+	if i < 0 || i >= len(c.Args) {
+		return 0, ErrInvalidCallback
+	}
+	n, err := strconv.Atoi(c.Args[i])
+	if err != nil || n < min || n > max {
+		return 0, ErrInvalidCallback
+	}
+	return n, nil
+}
+
+// Date parses argument i with Date.
+func (c Callback) Date(i int) (time.Time, error) {
+	if i < 0 || i >= len(c.Args) {
+		return time.Time{}, ErrInvalidCallback
+	}
+	t, err := Date(c.Args[i])
+	if err != nil {
+		return time.Time{}, ErrInvalidCallback
+	}
+	return t, nil
+}
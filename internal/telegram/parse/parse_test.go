@@ -0,0 +1,88 @@
+package parse
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDays(t *testing.T) {
+	valid := map[string]int{"1": 1, "7": 7, " 3 ": 3, "365": 365}
+	for in, want := range valid {
+		got, err := Days(in)
+		if err != nil || got != want {
+			t.Errorf("Days(%q) = (%d, %v), want %d", in, got, err, want)
+		}
+	}
+
+	for _, in := range []string{"", "0", "-1", "366", "3abc", "1e3", "0x10", "99999999999999999999", "３"} {
+		if _, err := Days(in); err == nil {
+			t.Errorf("Days(%q): expected an error", in)
+		}
+	}
+}
+
+func TestDate(t *testing.T) {
+	got, err := Date("2025-10-10")
+	if err != nil || !got.Equal(time.Date(2025, 10, 10, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Date(2025-10-10) = (%v, %v)", got, err)
+	}
+
+	for _, in := range []string{"", "2025-13-01", "2025-02-30", "25-10-10", "0025-10-10", "2025/10/10", "2025-10-10T00:00:00Z"} {
+		if _, err := Date(in); err == nil {
+			t.Errorf("Date(%q): expected an error", in)
+		}
+	}
+}
+
+func TestMonth(t *testing.T) {
+	got, err := Month("2025-11")
+	if err != nil || !got.Equal(time.Date(2025, 11, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Month(2025-11) = (%v, %v)", got, err)
+	}
+
+	for _, in := range []string{"", "2025-13", "2025-11-01", "0025-11", "2025/11"} {
+		if _, err := Month(in); err == nil {
+			t.Errorf("Month(%q): expected an error", in)
+		}
+	}
+}
+
+func TestDateRange(t *testing.T) {
+	if _, _, err := DateRange("2025-10-10", "2025-10-10"); err != nil {
+		t.Errorf("Single-day range should be valid: %v", err)
+	}
+	if _, _, err := DateRange("2025-10-10", "2025-10-09"); err == nil {
+		t.Error("Expected an error for an inverted range")
+	}
+	if _, _, err := DateRange("2025-01-01", "2026-12-31"); err == nil {
+		t.Error("Expected an error for an overly long range")
+	}
+}
+
+func TestCallback(t *testing.T) {
+	c := ParseCallback("modify_user:2025-10-10:42")
+	if c.Action != "modify_user" || c.Expect(2) != nil {
+		t.Fatalf("Unexpected callback %+v", c)
+	}
+	if date, err := c.Date(0); err != nil || date.Day() != 10 {
+		t.Errorf("Date(0) = (%v, %v)", date, err)
+	}
+	if id, err := c.ID(1); err != nil || id != 42 {
+		t.Errorf("ID(1) = (%d, %v)", id, err)
+	}
+	if _, err := c.ID(2); err == nil {
+		t.Error("Expected an error for an out-of-range argument")
+	}
+	if _, err := ParseCallback("toggle_user:-5").ID(0); err == nil {
+		t.Error("Expected an error for a negative ID")
+	}
+	if n, err := ParseCallback("notif_hour:18").IntInRange(0, 11, 20); err != nil || n != 18 {
+		t.Errorf("IntInRange = (%d, %v)", n, err)
+	}
+	if _, err := ParseCallback("notif_hour:7").IntInRange(0, 11, 20); err == nil {
+		t.Error("Expected an error for an out-of-range integer")
+	}
+	if Action("volunteer_custom") != "volunteer_custom" || Action("assign_days:1:2") != "assign_days" {
+		t.Error("Action did not extract the action name")
+	}
+}
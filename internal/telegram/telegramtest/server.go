@@ -0,0 +1,348 @@
+// Package telegramtest provides a fake Telegram Bot API server for tests.
+// The bot talks to it like to Telegram: it polls the updates a test sends,
+// and the server records the messages it sends, edits and deletes, so a
+// test can check exactly what a user would see:
+//
+//	srv := telegramtest.NewServer(t)
+//	bot := newBot(srv.API(t), h, 0, 0)
+//	go bot.Start(ctx)
+//
+//	srv.SendText(admin, 789, "/users")
+//	msg := srv.Expect(t, "sendMessage").Message
+//	srv.Press(admin, msg, telegramtest.ButtonData(t, msg, "Alice"))
+package telegramtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Token is the bot token the server expects.
+const Token = "123456:test-token"
+
+// BotUser is the bot itself, as getMe returns it.
+var BotUser = tgbotapi.User{ID: 1, IsBot: true, FirstName: "Duty Bot", UserName: "duty_test_bot"}
+
+// Timeout is how long Next waits for the bot to make a call.
+var Timeout = 5 * time.Second
+
+// Call is an API call the bot made.
+type Call struct {
+	Method string
+	Params map[string]string
+	// Message is the message as the call left it, for sendMessage,
+	// editMessageText and editMessageReplyMarkup
+	Message *tgbotapi.Message
+}
+
+type messageKey struct {
+	chatID    int64
+	messageID int
+}
+
+// Server is a fake Telegram Bot API. Its zero value isn't usable, create it
+// with NewServer.
+type Server struct {
+	server *httptest.Server
+
+	mu           sync.Mutex
+	updates      []tgbotapi.Update
+	updatesAdded chan struct{} // closed and replaced when an update is added
+	messages     map[messageKey]*tgbotapi.Message
+	nextID       int
+	calls        chan Call
+	closed       chan struct{}
+}
+
+// NewServer starts a fake Bot API server, which is closed when the test ends.
+func NewServer(t testing.TB) *Server {
+	s := &Server{
+		updatesAdded: make(chan struct{}),
+		messages:     make(map[messageKey]*tgbotapi.Message),
+		calls:        make(chan Call, 1000),
+		closed:       make(chan struct{}),
+	}
+	s.server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	t.Cleanup(func() {
+		// Wake up the bot's long poll, Close waits for it
+		close(s.closed)
+		s.server.Close()
+	})
+	return s
+}
+
+// Endpoint returns the API endpoint to give tgbotapi.NewBotAPIWithClient.
+func (s *Server) Endpoint() string {
+	return s.server.URL + "/bot%s/%s"
+}
+
+// API returns a Bot API client of the server.
+func (s *Server) API(t testing.TB) *tgbotapi.BotAPI {
+	t.Helper()
+	api, err := tgbotapi.NewBotAPIWithClient(Token, s.Endpoint(), s.server.Client())
+	if err != nil {
+		t.Fatalf("Could not connect to the fake Bot API: %v", err)
+	}
+	return api
+}
+
+// SendText sends a message from a user to a chat, a private chat for a
+// positive chatID and a group otherwise, and returns it. A text starting
+// with a slash is a command.
+func (s *Server) SendText(from *tgbotapi.User, chatID int64, text string) *tgbotapi.Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	m := s.newMessage(chatID)
+	m.From = from
+	m.Text = text
+	if strings.HasPrefix(text, "/") {
+		command, _, _ := strings.Cut(text, " ")
+		command, _, _ = strings.Cut(command, "\n")
+		m.Entities = []tgbotapi.MessageEntity{{Type: "bot_command", Offset: 0, Length: len(command)}}
+	}
+	s.addUpdate(tgbotapi.Update{Message: m})
+	return m
+}
+
+// Press presses the button with the callback data under a message the bot
+// sent, as the user.
+func (s *Server) Press(from *tgbotapi.User, msg *tgbotapi.Message, data string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.nextID++
+	s.addUpdate(tgbotapi.Update{CallbackQuery: &tgbotapi.CallbackQuery{
+		ID:      strconv.Itoa(s.nextID),
+		From:    from,
+		Message: msg,
+		Data:    data,
+	}})
+}
+
+// AddUpdate queues any other update for the bot. Its ID is set by the server.
+func (s *Server) AddUpdate(update tgbotapi.Update) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.addUpdate(update)
+}
+
+func (s *Server) addUpdate(update tgbotapi.Update) {
+	update.UpdateID = len(s.updates) + 1
+	s.updates = append(s.updates, update)
+	close(s.updatesAdded)
+	s.updatesAdded = make(chan struct{})
+}
+
+// Next returns the next call the bot made, other than getMe and getUpdates,
+// waiting for it up to Timeout.
+func (s *Server) Next(t testing.TB) Call {
+	t.Helper()
+	select {
+	case call := <-s.calls:
+		return call
+	case <-time.After(Timeout):
+		t.Fatalf("The bot made no call within %v", Timeout)
+		return Call{}
+	}
+}
+
+// Expect returns the next call the bot made, failing the test unless it is
+// of the method.
+func (s *Server) Expect(t testing.TB, method string) Call {
+	t.Helper()
+	call := s.Next(t)
+	if call.Method != method {
+		t.Fatalf("The bot called %s %v, want %s", call.Method, call.Params, method)
+	}
+	return call
+}
+
+// Message returns a message of a chat as it is now, after its edits, or nil
+// if there is none or it was deleted.
+func (s *Server) Message(chatID int64, messageID int) *tgbotapi.Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if m, ok := s.messages[messageKey{chatID, messageID}]; ok {
+		return copyMessage(m)
+	}
+	return nil
+}
+
+// ButtonData returns the callback data of the button with the text under
+// msg, failing the test if there is none.
+func ButtonData(t testing.TB, msg *tgbotapi.Message, text string) string {
+	t.Helper()
+	if msg.ReplyMarkup != nil {
+		for _, row := range msg.ReplyMarkup.InlineKeyboard {
+			for _, button := range row {
+				if button.Text == text && button.CallbackData != nil {
+					return *button.CallbackData
+				}
+			}
+		}
+	}
+	t.Fatalf("Message %q has no button %q", msg.Text, text)
+	return ""
+}
+
+// newMessage returns a new message in the chat. The caller holds s.mu.
+func (s *Server) newMessage(chatID int64) *tgbotapi.Message {
+	s.nextID++
+	chat := &tgbotapi.Chat{ID: chatID, Type: "private"}
+	if chatID < 0 {
+		chat.Type, chat.Title = "supergroup", "Test group"
+	}
+	return &tgbotapi.Message{MessageID: s.nextID, Chat: chat, Date: int(time.Now().Unix())}
+}
+
+// apiError is the error response of a failed call.
+type apiError struct {
+	code        int
+	description string
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	// The path is /bot<token>/<method>
+	if path.Dir(r.URL.Path) != "/bot"+Token {
+		writeResponse(w, nil, &apiError{http.StatusUnauthorized, "Unauthorized"})
+		return
+	}
+	if err := r.ParseMultipartForm(32 << 20); err != nil && err != http.ErrNotMultipart {
+		writeResponse(w, nil, &apiError{http.StatusBadRequest, "Bad Request: " + err.Error()})
+		return
+	}
+	params := make(map[string]string, len(r.Form))
+	for key := range r.Form {
+		params[key] = r.Form.Get(key)
+	}
+
+	method := path.Base(r.URL.Path)
+	switch method {
+	case "getMe":
+		writeResponse(w, BotUser, nil)
+		return
+	case "getUpdates":
+		writeResponse(w, s.waitForUpdates(r, params), nil)
+		return
+	}
+
+	result, apiErr := s.call(method, params)
+	writeResponse(w, result, apiErr)
+}
+
+// waitForUpdates returns the updates from the offset on, waiting for one as
+// long as the poll's timeout.
+func (s *Server) waitForUpdates(r *http.Request, params map[string]string) []tgbotapi.Update {
+	offset, _ := strconv.Atoi(params["offset"])
+	timeout, _ := strconv.Atoi(params["timeout"])
+	deadline := time.After(time.Duration(timeout) * time.Second)
+	for {
+		s.mu.Lock()
+		var updates []tgbotapi.Update
+		for _, u := range s.updates {
+			if u.UpdateID >= offset {
+				updates = append(updates, u)
+			}
+		}
+		added := s.updatesAdded
+		s.mu.Unlock()
+		if len(updates) > 0 {
+			return updates
+		}
+
+		select {
+		case <-added:
+		case <-deadline:
+			return []tgbotapi.Update{}
+		case <-r.Context().Done():
+			return []tgbotapi.Update{}
+		case <-s.closed:
+			return []tgbotapi.Update{}
+		}
+	}
+}
+
+// call makes the call of a method other than getMe and getUpdates, records
+// it and returns its result.
+func (s *Server) call(method string, params map[string]string) (any, *apiError) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	call := Call{Method: method, Params: params}
+	var result any = true
+	chatID, _ := strconv.ParseInt(params["chat_id"], 10, 64)
+	messageID, _ := strconv.Atoi(params["message_id"])
+	key := messageKey{chatID, messageID}
+
+	switch method {
+	case "sendMessage":
+		m := s.newMessage(chatID)
+		m.From = &BotUser
+		m.Text = params["text"]
+		m.ReplyMarkup = inlineKeyboard(params["reply_markup"])
+		s.messages[messageKey{chatID, m.MessageID}] = m
+		call.Message, result = copyMessage(m), m
+	case "editMessageText", "editMessageReplyMarkup":
+		m, ok := s.messages[key]
+		if !ok {
+			return nil, &apiError{http.StatusBadRequest, "Bad Request: message to edit not found"}
+		}
+		if method == "editMessageText" {
+			m.Text = params["text"]
+		}
+		// Like Telegram, an edit without a keyboard takes it off
+		m.ReplyMarkup = inlineKeyboard(params["reply_markup"])
+		call.Message, result = copyMessage(m), m
+	case "deleteMessage":
+		if _, ok := s.messages[key]; !ok {
+			return nil, &apiError{http.StatusBadRequest, "Bad Request: message to delete not found"}
+		}
+		delete(s.messages, key)
+	case "getChatMember":
+		userID, _ := strconv.ParseInt(params["user_id"], 10, 64)
+		result = tgbotapi.ChatMember{User: &tgbotapi.User{ID: userID}, Status: "member"}
+	}
+
+	select {
+	case s.calls <- call:
+	default:
+		panic(fmt.Sprintf("telegramtest: more than %d calls nobody looked at", cap(s.calls)))
+	}
+	return result, nil
+}
+
+// inlineKeyboard returns the inline keyboard of a reply_markup parameter, or
+// nil if it has none.
+func inlineKeyboard(param string) *tgbotapi.InlineKeyboardMarkup {
+	var markup tgbotapi.InlineKeyboardMarkup
+	if param == "" || json.Unmarshal([]byte(param), &markup) != nil || len(markup.InlineKeyboard) == 0 {
+		return nil
+	}
+	return &markup
+}
+
+func copyMessage(m *tgbotapi.Message) *tgbotapi.Message {
+	copied := *m
+	return &copied
+}
+
+func writeResponse(w http.ResponseWriter, result any, apiErr *apiError) {
+	w.Header().Set("Content-Type", "application/json")
+	if apiErr != nil {
+		w.WriteHeader(apiErr.code)
+		json.NewEncoder(w).Encode(map[string]any{"ok": false, "error_code": apiErr.code, "description": apiErr.description})
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]any{"ok": true, "result": result})
+}
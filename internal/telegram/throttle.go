@@ -0,0 +1,175 @@
+package telegram
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"expvar"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Telegram's limits on messages to a chat, see
+// https://core.telegram.org/bots/faq#my-bot-is-hitting-limits-how-do-i-avoid-this
+const (
+	privateChatInterval = time.Second     // About one message a second
+	groupChatInterval   = 3 * time.Second // 20 messages a minute
+)
+
+// maxRetries is how often a call is tried again after a 429 or 5xx response.
+const maxRetries = 3
+
+// maxRetryAfter is the longest a 429 response is waited out for. The call
+// fails right away if Telegram asks to wait longer.
+const maxRetryAfter = time.Minute
+
+// apiRetries counts the Telegram API calls tried again.
+var apiRetries = expvar.NewInt("telegram_api_retries")
+
+// throttledClient is the HTTP client the Bot API calls go through, so the
+// bot's replies and the notifier's messages share it. It makes the calls of
+// a chat one at a time, in order, and spaces out the messages sent to it as
+// Telegram asks. A 429 response is waited out as long as its retry_after
+// says and a 5xx one is tried again after a backoff, each up to maxRetries
+// times.
+type throttledClient struct {
+	client tgbotapi.HTTPClient
+	now    func() time.Time
+	sleep  func(ctx context.Context, d time.Duration) error
+
+	mu    sync.Mutex
+	chats map[string]*chatThrottle
+}
+
+// chatThrottle lines up the calls of a chat.
+type chatThrottle struct {
+	mu       sync.Mutex
+	lastSent time.Time
+}
+
+func newThrottledClient(client tgbotapi.HTTPClient) *throttledClient {
+	return &throttledClient{client: client, now: time.Now, sleep: sleep, chats: make(map[string]*chatThrottle)}
+}
+
+// sleep waits for d, or until ctx is done.
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Do makes the API call of req, waiting for the chat's earlier calls and
+// trying again as long as Telegram asks.
+func (c *throttledClient) Do(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		if body, err = io.ReadAll(req.Body); err != nil {
+			return nil, err
+		}
+		req.Body.Close()
+	}
+	// The path is /bot<token>/<method>, the token must not be logged
+	method := path.Base(req.URL.Path)
+
+	if chatID := formChatID(req, body); chatID != "" {
+		t := c.chat(chatID)
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		if strings.HasPrefix(method, "send") {
+			interval := privateChatInterval
+			if strings.HasPrefix(chatID, "-") {
+				interval = groupChatInterval
+			}
+			if wait := t.lastSent.Add(interval).Sub(c.now()); wait > 0 {
+				if err := c.sleep(req.Context(), wait); err != nil {
+					return nil, err
+				}
+			}
+			defer func() { t.lastSent = c.now() }()
+		}
+	}
+
+	for attempt := 0; ; attempt++ {
+		retry := req.Clone(req.Context())
+		retry.Body = io.NopCloser(bytes.NewReader(body))
+		resp, err := c.client.Do(retry)
+		if err != nil || attempt == maxRetries {
+			return resp, err
+		}
+		wait, err := retryWait(resp, attempt)
+		if err != nil || wait == 0 {
+			return resp, err
+		}
+		resp.Body.Close()
+		apiRetries.Add(1)
+		log.Printf("[TELEGRAM] %s failed with status %d, trying again in %v", method, resp.StatusCode, wait)
+		if err := c.sleep(req.Context(), wait); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// chat returns the throttle of the chat with the ID.
+func (c *throttledClient) chat(chatID string) *chatThrottle {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	t, ok := c.chats[chatID]
+	if !ok {
+		t = &chatThrottle{}
+		c.chats[chatID] = t
+	}
+	return t
+}
+
+// formChatID returns the chat_id of a form-encoded call, or "" for calls
+// without one, like getUpdates, and file uploads.
+func formChatID(req *http.Request, body []byte) string {
+	if req.Header.Get("Content-Type") != "application/x-www-form-urlencoded" {
+		return ""
+	}
+	values, err := url.ParseQuery(string(body))
+	if err != nil {
+		return ""
+	}
+	return values.Get("chat_id")
+}
+
+// retryWait returns how long to wait before trying a call again after resp,
+// or 0 if it shouldn't be tried again. The body of resp is kept readable.
+func retryWait(resp *http.Response, attempt int) (time.Duration, error) {
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests:
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		resp.Body = io.NopCloser(bytes.NewReader(body))
+		if err != nil {
+			return 0, err
+		}
+		var apiResp tgbotapi.APIResponse
+		wait := time.Second
+		if json.Unmarshal(body, &apiResp) == nil && apiResp.Parameters != nil && apiResp.Parameters.RetryAfter > 0 {
+			wait = time.Duration(apiResp.Parameters.RetryAfter) * time.Second
+		}
+		if wait > maxRetryAfter {
+			return 0, nil
+		}
+		return wait, nil
+	case resp.StatusCode >= 500:
+		return time.Second << attempt, nil
+	}
+	return 0, nil
+}
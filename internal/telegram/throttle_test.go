@@ -0,0 +1,121 @@
+package telegram
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// fakeAPI answers Bot API calls with the queued responses, then with ok.
+type fakeAPI struct {
+	mu        sync.Mutex
+	responses []string // "<status> <body>"
+	calls     []string // chat_id of each call
+}
+
+func (f *fakeAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	r.ParseForm()
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = append(f.calls, r.PostForm.Get("chat_id"))
+	w.Header().Set("Content-Type", "application/json")
+	if len(f.responses) == 0 {
+		w.Write([]byte(`{"ok":true,"result":{"message_id":1,"chat":{"id":1}}}`))
+		return
+	}
+	status, _ := strconv.Atoi(f.responses[0][:3])
+	body := f.responses[0][4:]
+	f.responses = f.responses[1:]
+	w.WriteHeader(status)
+	w.Write([]byte(body))
+}
+
+// newTestAPI returns a Bot API client of a fake server, and the waits the
+// client slept.
+func newTestAPI(t *testing.T, fake *fakeAPI) (*tgbotapi.BotAPI, *[]time.Duration) {
+	t.Helper()
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	now := time.Date(2025, 11, 5, 12, 0, 0, 0, time.UTC)
+	var waits []time.Duration
+	client := newThrottledClient(server.Client())
+	client.now = func() time.Time { return now }
+	client.sleep = func(_ context.Context, d time.Duration) error {
+		waits = append(waits, d)
+		now = now.Add(d)
+		return nil
+	}
+	api := &tgbotapi.BotAPI{Token: "token", Client: client}
+	api.SetAPIEndpoint(server.URL + "/bot%s/%s")
+	return api, &waits
+}
+
+func TestThrottledClient_Retries(t *testing.T) {
+	fake := &fakeAPI{responses: []string{
+		`429 {"ok":false,"error_code":429,"description":"Too Many Requests: retry after 5","parameters":{"retry_after":5}}`,
+		`502 Bad Gateway`,
+	}}
+	api, waits := newTestAPI(t, fake)
+
+	if _, err := api.Send(tgbotapi.NewMessage(42, "Hello")); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+	if len(fake.calls) != 3 {
+		t.Errorf("Calls = %v, want the message sent three times", fake.calls)
+	}
+	if len(*waits) != 2 || (*waits)[0] != 5*time.Second || (*waits)[1] != 2*time.Second {
+		t.Errorf("Waits = %v, want the 5s Telegram asked for and a 2s backoff", *waits)
+	}
+}
+
+func TestThrottledClient_GivesUp(t *testing.T) {
+	fake := &fakeAPI{responses: []string{
+		`429 {"ok":false,"error_code":429,"description":"Too Many Requests: retry after 3600","parameters":{"retry_after":3600}}`,
+	}}
+	api, waits := newTestAPI(t, fake)
+	_, err := api.Send(tgbotapi.NewMessage(42, "Hello"))
+	if apiErr, ok := err.(*tgbotapi.Error); !ok || apiErr.Code != 429 || apiErr.RetryAfter != 3600 {
+		t.Errorf("Send = %v, want the 429 for a wait that long", err)
+	}
+	if len(*waits) != 0 {
+		t.Errorf("Waits = %v, want none", *waits)
+	}
+
+	fake.responses = []string{"500 {}", "500 {}", "500 {}", "500 {}"}
+	fake.calls = nil
+	*waits = nil
+	if _, err := api.Send(tgbotapi.NewMessage(43, "Hello")); err == nil {
+		t.Error("Expected an error once the retries are used up")
+	}
+	if len(fake.calls) != maxRetries+1 || len(*waits) != maxRetries {
+		t.Errorf("Calls = %d, waits = %v, want %d tries", len(fake.calls), *waits, maxRetries+1)
+	}
+}
+
+func TestThrottledClient_SpacesOutMessages(t *testing.T) {
+	fake := &fakeAPI{}
+	api, waits := newTestAPI(t, fake)
+
+	for _, chatID := range []int64{42, -100, 42, -100} {
+		if _, err := api.Send(tgbotapi.NewMessage(chatID, "Hello")); err != nil {
+			t.Fatalf("Send failed: %v", err)
+		}
+	}
+	// Other calls, like edits, aren't held back
+	if _, err := api.Request(tgbotapi.NewEditMessageText(42, 1, "Edited")); err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	// The second message to each chat waits for its interval, less the
+	// time the messages to the other chat waited
+	want := []time.Duration{privateChatInterval, groupChatInterval - privateChatInterval}
+	if len(*waits) != len(want) || (*waits)[0] != want[0] || (*waits)[1] != want[1] {
+		t.Errorf("Waits = %v, want %v", *waits, want)
+	}
+}
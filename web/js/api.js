@@ -41,11 +41,13 @@ async function postData(url = '', data = {}) {
  * Fetches the schedule for a given month.
  * @param {number} year - The year.
  * @param {number} month - The month.
+ * @param {string|number} [userId] - Highlight this user's duties.
  * @returns {Promise<any>} The schedule data.
  */
-export async function getSchedule(year, month) {
+export async function getSchedule(year, month, userId) {
     try {
-        const response = await fetch(`/api/v1/schedule/${year}/${month}`, {
+        const query = userId ? `?user_id=${encodeURIComponent(userId)}` : '';
+        const response = await fetch(`/api/v1/schedule/${year}/${month}${query}`, {
             headers: getAuthHeaders()
         });
         if (!response.ok) {
@@ -77,6 +79,93 @@ export async function getPrognosis(year, month) {
     }
 }
 
+/**
+ * Fetches the current week for the kid-friendly view: only the
+ * authenticated user's own days are marked.
+ * @returns {Promise<any>} The week, or null if not signed in via Telegram.
+ */
+export async function getJuniorWeek() {
+    try {
+        const response = await fetch('/api/v1/schedule/junior', {
+            headers: getAuthHeaders()
+        });
+        if (!response.ok) {
+            throw new Error(`HTTP error! status: ${response.status}`);
+        }
+        return await response.json();
+    } catch (error) {
+        console.error("Failed to fetch junior week:", error);
+        return null;
+    }
+}
+
+/**
+ * Fetches a user's past and upcoming duties for the profile view.
+ * @param {string|number} userId - The user's ID, or 'me' for the signed-in user.
+ * @param {string} [from] - First day, YYYY-MM-DD; defaults to 90 days ago.
+ * @param {string} [to] - Last day, YYYY-MM-DD; defaults to 60 days ahead.
+ * @returns {Promise<{user: object, from: string, to: string, duties: Array<object>}|null>}
+ *   The duties, or null if not signed in.
+ */
+export async function getUserDuties(userId, from, to) {
+    try {
+        const params = new URLSearchParams();
+        if (from) params.set('from', from);
+        if (to) params.set('to', to);
+        const query = params.toString() ? `?${params}` : '';
+        const response = await fetch(`/api/v1/users/${encodeURIComponent(userId)}/duties${query}`, {
+            headers: getAuthHeaders()
+        });
+        if (!response.ok) {
+            throw new Error(`HTTP error! status: ${response.status}`);
+        }
+        return await response.json();
+    } catch (error) {
+        console.error("Failed to fetch user duties:", error);
+        return null;
+    }
+}
+
+/**
+ * Fetches who is signed in outside Telegram, with a session cookie from a
+ * /login link or the Telegram Login Widget.
+ * @returns {Promise<{signed_in: boolean, user_id?: number, first_name?: string, login_bot?: string}|null>}
+ *   The session, with the widget's bot username when nobody is signed in.
+ */
+export async function getSession() {
+    try {
+        const response = await fetch('/api/v1/session', {
+            headers: getAuthHeaders()
+        });
+        if (!response.ok) {
+            throw new Error(`HTTP error! status: ${response.status}`);
+        }
+        return await response.json();
+    } catch (error) {
+        console.error("Failed to fetch session:", error);
+        return null;
+    }
+}
+
+/**
+ * Fetches how the calendar is drawn, as set with /display in the bot.
+ * @returns {Promise<object|null>} week_numbers and adjacent_days, or null on error.
+ */
+export async function getDisplay() {
+    try {
+        const response = await fetch('/api/v1/display', {
+            headers: getAuthHeaders()
+        });
+        if (!response.ok) {
+            throw new Error(`HTTP error! status: ${response.status}`);
+        }
+        return await response.json();
+    } catch (error) {
+        console.error("Failed to fetch display:", error);
+        return null;
+    }
+}
+
 /**
  * Fetches all users.
  * @returns {Promise<any>} A list of users.
@@ -122,4 +211,24 @@ export async function withdrawFromDuty(dutyId) {
  */
 export async function assignDuty(dutyId, userId) {
     return postData(`/api/v1/duties/${dutyId}/assign`, { user_id: userId });
-}
\ No newline at end of file
+}
+/**
+ * Allows an admin to save several duty changes at once, e.g. a month edited
+ * in the calendar. Either all of them are applied or none.
+ * @param {Array<{op: string, date: string, user_id?: number, mode?: string}>} operations -
+ *   create, modify or delete operations, applied in order.
+ * @returns {Promise<{applied: boolean, results: Array<{index: number, status: string, error?: string, user_id?: number}>}>}
+ *   The outcome of every operation, also when the batch failed.
+ */
+export async function applyDutyBatch(operations) {
+    const response = await fetch('/api/v1/duties/batch', {
+        method: 'POST',
+        headers: getAuthHeaders(),
+        body: JSON.stringify({ operations }),
+    });
+    const body = await response.json();
+    if (!response.ok && !body.results) {
+        throw new Error(`HTTP error! status: ${response.status}, body: ${JSON.stringify(body)}`);
+    }
+    return body;
+}
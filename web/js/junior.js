@@ -0,0 +1,59 @@
+import { getJuniorWeek } from './api.js';
+
+// Entry point of the kid-friendly view: the current week as big cards, with
+// only the viewer's own days marked and no other names or stats.
+
+/**
+ * Renders one day card.
+ * @param {object} day - A day of the /schedule/junior response.
+ * @returns {HTMLElement} The card.
+ */
+function renderDay(day) {
+    const card = document.createElement('div');
+    card.className = 'p-4 rounded-2xl shadow text-xl flex justify-between items-center';
+    if (day.mine) {
+        card.className += day.done ? ' bg-green-200' : ' bg-orange-200 font-bold';
+    } else {
+        card.className += ' bg-white';
+    }
+    if (day.today) {
+        card.className += ' ring-4 ring-blue-400';
+    }
+
+    const name = document.createElement('span');
+    name.textContent = day.today ? `${day.weekday} (today)` : day.weekday;
+    card.appendChild(name);
+
+    let icon = '';
+    if (day.mine) {
+        icon = day.done ? '⭐ Done!' : '🧹 Your turn';
+    } else if (day.no_duty) {
+        icon = '🎉';
+    }
+    if (day.bins && day.bins.length > 0) {
+        icon += ' 🗑️';
+    }
+    const badge = document.createElement('span');
+    badge.textContent = icon;
+    card.appendChild(badge);
+    return card;
+}
+
+async function initializeJuniorView() {
+    if (window.Telegram && window.Telegram.WebApp) {
+        window.Telegram.WebApp.ready();
+    }
+
+    const container = document.getElementById('junior-week');
+    const week = await getJuniorWeek();
+    if (!week) {
+        container.innerHTML = '<p class="text-center text-gray-500">Open this page from the bot to see your week.</p>';
+        return;
+    }
+
+    document.getElementById('greeting').textContent = `Hi ${week.name}! 👋`;
+    container.innerHTML = '';
+    week.days.forEach(day => container.appendChild(renderDay(day)));
+}
+
+document.addEventListener('DOMContentLoaded', initializeJuniorView);
@@ -1,5 +1,6 @@
 import { initializeCalendar } from './ui/calendar.js';
 import { setState } from './store.js';
+import { getSession } from './api.js';
 
 // Main entry point for the frontend application.
 console.log("Roster Bot frontend script loaded.");
@@ -7,8 +8,9 @@ console.log("Roster Bot frontend script loaded.");
 function initializeApp() {
     console.log("DOM fully loaded and parsed.");
 
-    // Initialize the Telegram Web App SDK
-    if (window.Telegram && window.Telegram.WebApp) {
+    // Initialize the Telegram Web App SDK. The SDK also loads in a regular
+    // browser, where it has no initData.
+    if (window.Telegram?.WebApp?.initData) {
         window.Telegram.WebApp.ready();
         console.log("Telegram Web App SDK is ready.");
 
@@ -18,14 +20,45 @@ function initializeApp() {
             setState({ currentUser: user });
         }
     } else {
-        console.warn("Telegram Web App SDK not found. Running in standalone mode.");
-        // For local development, you can set a mock user
-        setState({ currentUser: { id: 123, first_name: 'Dev', last_name: 'User', username: 'devuser' } });
+        console.warn("Not opened from Telegram. Running in standalone mode.");
+        initializeBrowserLogin();
     }
 
     // Initialize the calendar
     initializeCalendar();
 }
 
+/**
+ * Outside Telegram, shows who is signed in with a session cookie, or the
+ * Telegram Login Widget to sign in with. The widget redirects to
+ * /login/telegram, which sets the cookie and comes back here.
+ */
+async function initializeBrowserLogin() {
+    const panel = document.getElementById('login-panel');
+    const session = await getSession();
+    if (!panel || !session) {
+        return;
+    }
+    if (session.signed_in) {
+        setState({ currentUser: { id: session.user_id, first_name: session.first_name } });
+        panel.textContent = `Signed in as ${session.first_name}`;
+        panel.classList.remove('hidden');
+        return;
+    }
+    if (!session.login_bot) {
+        return;
+    }
+    const widget = document.createElement('script');
+    widget.async = true;
+    widget.src = 'https://telegram.org/js/telegram-widget.js?22';
+    widget.dataset.telegramLogin = session.login_bot;
+    widget.dataset.size = 'medium';
+    widget.dataset.authUrl = '/login/telegram';
+    widget.dataset.requestAccess = 'read';
+    panel.textContent = 'Sign in to see names and volunteer: ';
+    panel.appendChild(widget);
+    panel.classList.remove('hidden');
+}
+
 // This is where the application will be initialized.
 document.addEventListener('DOMContentLoaded', initializeApp);
\ No newline at end of file
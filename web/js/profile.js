@@ -0,0 +1,70 @@
+import { getUserDuties } from './api.js';
+
+// Entry point of the profile view: one user's past and upcoming duties. The
+// user is picked with ?user_id=, and defaults to the signed-in user.
+
+// Marks of the duty statuses, matching the calendar
+const statusEmoji = { provisional: '🗓', announced: '⏳', acknowledged: '👍', completed: '✅', missed: '❌' };
+const typeLabels = { voluntary: 'volunteered', admin: 'assigned', round_robin: 'rotation' };
+
+/**
+ * Renders one duty as a list item.
+ * @param {object} duty - A duty of the /users/:id/duties response.
+ * @returns {HTMLElement} The list item.
+ */
+function renderDuty(duty) {
+    const item = document.createElement('li');
+    const date = new Date(`${duty.date}T00:00:00Z`).toLocaleDateString(undefined, {
+        weekday: 'short', day: 'numeric', month: 'short', year: 'numeric', timeZone: 'UTC',
+    });
+    const type = typeLabels[duty.assignment_type] || duty.assignment_type;
+    item.textContent = `${statusEmoji[duty.status] || ''} ${date} (${type}${duty.retroactive ? ', recorded later' : ''})`;
+    return item;
+}
+
+/**
+ * Fills a list with duties, or a placeholder if there are none.
+ * @param {string} id - The list's element ID.
+ * @param {Array<object>} duties - The duties to show.
+ * @param {string} empty - The placeholder text.
+ */
+function fillList(id, duties, empty) {
+    const list = document.getElementById(id);
+    list.innerHTML = '';
+    if (duties.length === 0) {
+        const item = document.createElement('li');
+        item.className = 'text-gray-500';
+        item.textContent = empty;
+        list.appendChild(item);
+        return;
+    }
+    duties.forEach(duty => list.appendChild(renderDuty(duty)));
+}
+
+async function initializeProfileView() {
+    if (window.Telegram?.WebApp?.initData) {
+        window.Telegram.WebApp.ready();
+    }
+
+    const userId = new URLSearchParams(window.location.search).get('user_id') || 'me';
+    const profile = await getUserDuties(userId);
+    if (!profile) {
+        document.getElementById('profile-summary').textContent = 'Open this page from the bot, or sign in on the calendar, to see duties.';
+        fillList('profile-upcoming', [], '');
+        fillList('profile-history', [], '');
+        return;
+    }
+
+    const today = new Date().toISOString().split('T')[0];
+    const upcoming = profile.duties.filter(d => d.date >= today);
+    const history = profile.duties.filter(d => d.date < today).reverse();
+    const done = history.filter(d => d.completed).length;
+
+    document.getElementById('profile-name').textContent = `${profile.user.emoji || ''} ${profile.user.name}`.trim();
+    document.getElementById('profile-summary').textContent =
+        `${done} of ${history.length} past duties done since ${profile.from}.`;
+    fillList('profile-upcoming', upcoming, 'Nothing planned yet.');
+    fillList('profile-history', history, 'No duties in this period.');
+}
+
+document.addEventListener('DOMContentLoaded', initializeProfileView);
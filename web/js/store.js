@@ -7,6 +7,7 @@ const state = {
     users: [],
     currentYear: new Date().getFullYear(),
     currentMonth: new Date().getMonth() + 1,
+    highlightUserId: null, // Set from ?user_id= to show one user's duties
 };
 
 // Functions to update and access the state will go here.
@@ -1,23 +1,28 @@
 import VanillaCalendar from '/vendor/vanilla-calendar/vanilla-calendar.min.js';
-import { getSchedule, getPrognosis, getUsers, volunteerForDuty, withdrawFromDuty } from '../api.js';
+import { getSchedule, getPrognosis, getUsers, getDisplay, volunteerForDuty, withdrawFromDuty } from '../api.js';
 import { getState, setState } from '../store.js';
 import { createDutyCard, createModal, showModal, createLoadingSpinner, createErrorMessage, hideModal } from './components.js';
 
 const calendarContainer = document.getElementById('calendar-container');
 let calendar;
 
+// Marks of the duty statuses, matching the bot's /week overview
+const statusEmoji = { provisional: '🗓', announced: '⏳', acknowledged: '👍', completed: '✅', missed: '❌' };
+const statusLabels = { provisional: 'planned, may change', announced: 'announced', acknowledged: 'acknowledged', completed: 'done', missed: 'missed' };
+
 /**
  * Fetches and displays the schedule for the current month.
  */
 async function loadAndDisplaySchedule() {
-    const { currentYear, currentMonth } = getState();
+    const { currentYear, currentMonth, highlightUserId } = getState();
     calendarContainer.innerHTML = createLoadingSpinner();
 
     try {
-        const [scheduleData, prognosisData, usersData] = await Promise.all([
-            getSchedule(currentYear, currentMonth),
+        const [scheduleData, prognosisData, usersData, display] = await Promise.all([
+            getSchedule(currentYear, currentMonth, highlightUserId),
             getPrognosis(currentYear, currentMonth),
-            getUsers()
+            getUsers(),
+            getDisplay()
         ]);
 
         // Display queue summary
@@ -25,7 +30,7 @@ async function loadAndDisplaySchedule() {
 
         if (scheduleData) {
             setState({ schedule: { [`${currentYear}-${currentMonth}`]: scheduleData } });
-            renderCalendar(scheduleData, prognosisData);
+            renderCalendar(scheduleData, prognosisData, display || {});
         } else {
             calendarContainer.innerHTML = createErrorMessage('Could not load schedule.');
         }
@@ -39,8 +44,9 @@ async function loadAndDisplaySchedule() {
  * Renders the calendar with the given schedule data.
  * @param {object} scheduleData - The schedule data for the current month.
  * @param {object} prognosisData - The prognosis data for unassigned days.
+ * @param {object} display - Whether to show week numbers and adjacent days, as set with /display.
  */
-function renderCalendar(scheduleData = {}, prognosisData = {}) {
+function renderCalendar(scheduleData = {}, prognosisData = {}, display = {}) {
     const { currentYear, currentMonth, currentUser } = getState();
     const dutiesByDate = {};
 
@@ -52,28 +58,50 @@ function renderCalendar(scheduleData = {}, prognosisData = {}) {
                 dutiesByDate[date] = [];
             }
             // Add user name and assignment type style
-            let displayName = duty.user_name || 'Unassigned';
+            const user = (scheduleData.users || {})[duty.user_id] || {};
+            let displayName = user.name || 'Unassigned';
+            if (user.emoji) {
+                displayName = `${user.emoji} ${displayName}`;
+            }
 
             // Add queue counts to display name if present
             const queueParts = [];
-            if (duty.volunteer_queue_days > 0) {
-                queueParts.push(`V:${duty.volunteer_queue_days}`);
+            if (user.volunteer_queue_days > 0) {
+                queueParts.push(`V:${user.volunteer_queue_days}`);
             }
-            if (duty.admin_queue_days > 0) {
-                queueParts.push(`A:${duty.admin_queue_days}`);
+            if (user.admin_queue_days > 0) {
+                queueParts.push(`A:${user.admin_queue_days}`);
             }
             if (queueParts.length > 0) {
                 displayName += ` (${queueParts.join(' ')})`;
             }
 
             duty.displayName = displayName;
+            duty.statusEmoji = statusEmoji[duty.status] || '';
             duty.typeClass = duty.assignment_type === 'voluntary' ? 'text-green-600' :
                             duty.assignment_type === 'admin' ? 'text-blue-600' : 'text-gray-600';
             duty.isPrognosis = false;
+            // Single-user view: dim everyone else's duties
+            duty.dimmed = Boolean(scheduleData.highlight_user_id) && !duty.highlighted;
             dutiesByDate[date].push(duty);
         });
     }
 
+    // Add days deliberately left without duty
+    const skipLabels = { holiday: 'Holiday', eating_out: 'Eating out', away: 'Away' };
+    if (scheduleData.skip_days) {
+        scheduleData.skip_days.forEach(skip => {
+            const date = skip.date.split('T')[0];
+            dutiesByDate[date] = [{
+                displayName: 'No duty',
+                typeClass: 'text-red-600',
+                assignment_type: skipLabels[skip.reason] || skip.reason,
+                isSkip: true,
+                date
+            }];
+        });
+    }
+
     // Add prognosis for unassigned days
     if (prognosisData.prognosis) {
         prognosisData.prognosis.forEach(prog => {
@@ -101,7 +129,13 @@ function renderCalendar(scheduleData = {}, prognosisData = {}) {
             lang: 'en',
             iso8601: true,
             selection: { day: 'single' },
-            visibility: { theme: 'light', weekend: true, today: true },
+            visibility: {
+                theme: 'light',
+                weekend: true,
+                today: true,
+                weekNumbers: Boolean(display.week_numbers),
+                daysOutside: Boolean(display.adjacent_days),
+            },
             selected: { dates: dates.map(d => d.date) },
         },
         actions: {
@@ -112,7 +146,7 @@ function renderCalendar(scheduleData = {}, prognosisData = {}) {
                     const content = duties.map(duty => `
                         <div class="p-3 mb-2 border rounded ${duty.typeClass}">
                             <div class="font-bold">${duty.displayName}</div>
-                            <div class="text-sm text-gray-600">${duty.assignment_type}</div>
+                            <div class="text-sm text-gray-600">${duty.assignment_type}${duty.retroactive ? ' (recorded afterwards)' : ''}${duty.status ? ` · ${duty.statusEmoji} ${statusLabels[duty.status] || duty.status}` : ''}</div>
                         </div>
                     `).join('');
                     const modalId = 'duty-details-modal';
@@ -170,16 +204,19 @@ function renderCalendar(scheduleData = {}, prognosisData = {}) {
                 loadAndDisplaySchedule();
             },
             getDays(day, date, HTMLElement, HTMLButtonElement, self) {
-                const dateStr = `${self.selectedYear}-${String(self.selectedMonth + 1).padStart(2, '0')}-${String(day).padStart(2, '0')}`;
+                // The date, not the selected month, as days of adjacent months are shown too
+                const dateStr = date;
                 if (dutiesByDate[dateStr]) {
                     const duties = dutiesByDate[dateStr];
                     const namesHTML = duties.map(duty => {
-                        const bgColor = duty.isPrognosis ? 'bg-gray-200' :
+                        const bgColor = duty.isSkip ? 'bg-red-100' :
+                                       duty.isPrognosis ? 'bg-gray-200' :
                                        duty.assignment_type === 'voluntary' ? 'bg-green-100' :
                                        duty.assignment_type === 'admin' ? 'bg-blue-100' : 'bg-gray-100';
                         const textColor = duty.isPrognosis ? 'text-gray-500' : 'text-gray-800';
-                        const shortName = duty.displayName.substring(0, 3);
-                        return `<span class="${bgColor} ${textColor} px-1 rounded text-[10px]">${shortName}</span>`;
+                        const shortName = (duty.statusEmoji || '') + duty.displayName.substring(0, 3);
+                        const emphasis = duty.dimmed ? 'opacity-30' : duty.highlighted ? 'ring-1 ring-yellow-500 font-bold' : '';
+                        return `<span class="${bgColor} ${textColor} ${emphasis} px-1 rounded text-[10px]">${shortName}</span>`;
                     }).join(' ');
                     HTMLButtonElement.innerHTML = `<span>${day}</span><div style="font-size:10px;margin-top:2px;">${namesHTML}</div>`;
                 }
@@ -230,20 +267,22 @@ function displayQueueSummary(users) {
         if (user.AdminQueueDays > 0) {
             parts.push(`<span class="text-blue-600 font-semibold">A:${user.AdminQueueDays}</span>`);
         }
-        return `<div class="mb-1">👤 <strong>${user.FirstName}</strong>: ${parts.join(', ')}</div>`;
+        return `<div class="mb-1">${user.Emoji || '👤'} <strong>${user.FirstName}</strong>: ${parts.join(', ')}</div>`;
     }).join('');
 
     queueList.innerHTML = queueHTML;
 }
 
 /**
- * Initializes the calendar view.
+ * Initializes the calendar view. Opening the page with ?user_id= highlights
+ * that user's duties and dims the others.
  */
 export function initializeCalendar() {
     const today = new Date();
     setState({
         currentYear: today.getFullYear(),
         currentMonth: today.getMonth() + 1,
+        highlightUserId: new URLSearchParams(window.location.search).get('user_id'),
     });
 
     if (!document.getElementById('calendar')) {
@@ -2,6 +2,8 @@
 module.exports = {
   content: [
     "./index.html",
+    "./junior.html",
+    "./profile.html",
     "./js/**/*.js",
   ],
   theme: {